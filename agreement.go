@@ -0,0 +1,116 @@
+package duplicatecheck
+
+import "fmt"
+
+// AgreementVerdict is how a set of engines judged one pair at a threshold
+type AgreementVerdict int
+
+const (
+	// AgreementAllAbove means every engine scored the pair at or above the threshold
+	AgreementAllAbove AgreementVerdict = iota
+	// AgreementAllBelow means every engine scored it below the threshold
+	AgreementAllBelow
+	// AgreementSplit means the engines disagree
+	AgreementSplit
+)
+
+// String returns the verdict's name: "all-above", "all-below" or "split"
+func (v AgreementVerdict) String() string {
+	switch v {
+	case AgreementAllAbove:
+		return "all-above"
+	case AgreementAllBelow:
+		return "all-below"
+	default:
+		return "split"
+	}
+}
+
+// MarshalText encodes the verdict as its name
+func (v AgreementVerdict) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// EngineAgreement is how several engines scored one pair (see CompareAcrossEngines)
+type EngineAgreement struct {
+	// Engines are the engine names, in the order the engines were given
+	Engines []string `json:"engines"`
+	// Results holds each engine's comparison, keyed by name, with
+	// ThresholdUsed and MeetsThreshold set against Threshold
+	Results   map[string]ComparisonResult `json:"results"`
+	Threshold float64                     `json:"threshold"`
+	// Spread is the highest CombinedSimilarity minus the lowest
+	Spread  float64          `json:"spread"`
+	Verdict AgreementVerdict `json:"verdict"`
+	// Outlier names the one engine on the other side of the threshold from
+	// all the rest, when the verdict is a split with a lone dissenter
+	Outlier string `json:"outlier,omitempty"`
+}
+
+// CompareAcrossEngines compares a and b with every engine and reports whether
+// they agree at threshold (nil engines = one of each AvailableEngines)
+// The hybrid engine compares exactly, without FastCompare estimates, and
+// needs no index. Engines are named by GetName; a name given more than once
+// is suffixed " #2", " #3" and so on.
+func CompareAcrossEngines(a, b Product, engines []DuplicateCheckEngine, threshold float64) EngineAgreement {
+	if engines == nil {
+		for _, newEngine := range AvailableEngines() {
+			engines = append(engines, newEngine())
+		}
+	}
+	agreement := EngineAgreement{
+		Engines:   make([]string, 0, len(engines)),
+		Results:   make(map[string]ComparisonResult, len(engines)),
+		Threshold: threshold,
+	}
+	var above, below []string
+	var min, max float64
+	for i, engine := range engines {
+		name := engine.GetName()
+		for n := 2; ; n++ {
+			if _, taken := agreement.Results[name]; !taken {
+				break
+			}
+			name = fmt.Sprintf("%s #%d", engine.GetName(), n)
+		}
+
+		var result ComparisonResult
+		if hybrid, ok := engine.(*HybridEngine); ok {
+			result = hybrid.compareExact(&a, &b)
+		} else {
+			result = engine.Compare(a, b)
+		}
+		result.stampThreshold(threshold)
+		agreement.Engines = append(agreement.Engines, name)
+		agreement.Results[name] = result
+
+		similarity := result.CombinedSimilarity
+		if i == 0 || similarity < min {
+			min = similarity
+		}
+		if i == 0 || similarity > max {
+			max = similarity
+		}
+		if result.MeetsThreshold {
+			above = append(above, name)
+		} else {
+			below = append(below, name)
+		}
+	}
+
+	agreement.Spread = max - min
+	switch {
+	case len(below) == 0:
+		agreement.Verdict = AgreementAllAbove
+	case len(above) == 0:
+		agreement.Verdict = AgreementAllBelow
+	default:
+		agreement.Verdict = AgreementSplit
+		if len(above) == 1 && len(below) > 1 {
+			agreement.Outlier = above[0]
+		} else if len(below) == 1 && len(above) > 1 {
+			agreement.Outlier = below[0]
+		}
+	}
+	return agreement
+}
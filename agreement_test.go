@@ -0,0 +1,81 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareAcrossEngines(t *testing.T) {
+	// Reordered words: edit distance sees a rewrite, token cosine the same name
+	reordered := [2]Product{
+		{ID: "1", Name: "Sony Wireless Headphones Black Edition"},
+		{ID: "2", Name: "Black Edition Wireless Headphones Sony"},
+	}
+	storage := [2]Product{
+		{ID: "3", Name: "Apple iPhone 15 Pro 128GB"},
+		{ID: "4", Name: "Apple iPhone 15 Pro 256GB"},
+	}
+	tests := []struct {
+		name        string
+		pair        [2]Product
+		threshold   float64
+		wantVerdict AgreementVerdict
+		wantOutlier string
+	}{
+		{"reordered split", reordered, 0.85, AgreementSplit, "TF-IDF Token Cosine"},
+		{"reordered all above", reordered, 0.3, AgreementAllAbove, ""},
+		{"reordered all below", reordered, 0.9, AgreementAllBelow, ""},
+		{"storage all above", storage, 0.8, AgreementAllAbove, ""},
+		{"storage split", storage, 0.85, AgreementSplit, "TF-IDF Token Cosine"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agreement := CompareAcrossEngines(tt.pair[0], tt.pair[1], nil, tt.threshold)
+			if len(agreement.Engines) != len(AvailableEngines()) || len(agreement.Results) != len(agreement.Engines) {
+				t.Fatalf("engines %q with %d results", agreement.Engines, len(agreement.Results))
+			}
+			if agreement.Verdict != tt.wantVerdict || agreement.Outlier != tt.wantOutlier {
+				t.Errorf("verdict %s, outlier %q; want %s, %q", agreement.Verdict, agreement.Outlier, tt.wantVerdict, tt.wantOutlier)
+			}
+			low, high := 1.0, 0.0
+			for _, name := range agreement.Engines {
+				r := agreement.Results[name]
+				if r.ThresholdUsed != tt.threshold || r.MeetsThreshold != meetsThreshold(r.CombinedSimilarity, tt.threshold) {
+					t.Errorf("%s: threshold %v, meets %v at %v", name, r.ThresholdUsed, r.MeetsThreshold, r.CombinedSimilarity)
+				}
+				low, high = math.Min(low, r.CombinedSimilarity), math.Max(high, r.CombinedSimilarity)
+			}
+			if agreement.Spread != high-low {
+				t.Errorf("spread %v, want %v", agreement.Spread, high-low)
+			}
+		})
+	}
+
+	agreement := CompareAcrossEngines(reordered[0], reordered[1], nil, 0.85)
+	if agreement.Spread < 0.4 {
+		t.Errorf("reordered words spread %v, want the engines far apart", agreement.Spread)
+	}
+	data, err := json.Marshal(agreement)
+	if err != nil || !strings.Contains(string(data), `"verdict":"split"`) {
+		t.Errorf("JSON %s, %v", data, err)
+	}
+}
+
+func TestCompareAcrossEnginesNames(t *testing.T) {
+	a, b := Product{ID: "1", Name: "Desk Lamp"}, Product{ID: "2", Name: "Desk Lamp LED"}
+	engines := []DuplicateCheckEngine{NewLevenshteinEngine(), NewTFIDFEngine(), NewLevenshteinEngine()}
+	agreement := CompareAcrossEngines(a, b, engines, 0.5)
+	want := []string{"Levenshtein Distance", "TF-IDF Token Cosine", "Levenshtein Distance #2"}
+	if !reflect.DeepEqual(agreement.Engines, want) {
+		t.Errorf("engines %q, want %q", agreement.Engines, want)
+	}
+	if !reflect.DeepEqual(agreement.Results[want[0]], agreement.Results[want[2]]) {
+		t.Error("the same engine scored differently")
+	}
+	if single := CompareAcrossEngines(a, b, engines[:1], 0.5); single.Spread != 0 || single.Outlier != "" {
+		t.Errorf("one engine: spread %v, outlier %q", single.Spread, single.Outlier)
+	}
+}
@@ -0,0 +1,220 @@
+package duplicatecheck
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// maxAmbiguityTokens is how many shared name tokens an AmbiguityReport lists
+const maxAmbiguityTokens = 5
+
+// AmbiguityReport describes a product matching many others, a "duplicate
+// magnet" such as "USB Cable 1m" that floods scans with low-value pairs
+type AmbiguityReport struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name"`
+	Partners         int           `json:"partners"`          // Products matching it at the threshold
+	MedianSimilarity float64       `json:"median_similarity"` // Median CombinedSimilarity of those matches
+	SharedTokens     []SharedToken `json:"shared_tokens"`     // Its name's words its partners' names share most, usually a generic name
+}
+
+// SharedToken is a word of an ambiguous product's name and how many of its
+// partners' names contain it
+type SharedToken struct {
+	Token    string `json:"token"`
+	Partners int    `json:"partners"`
+}
+
+// AmbiguityPolicy is how scans treat the products of AmbiguityReports (see
+// WithAmbiguousProducts)
+type AmbiguityPolicy struct {
+	// Exclude leaves every pair with one of the products out of scans, as a
+	// pair constraint excluding them would
+	Exclude bool
+	// Penalty is subtracted from the combined similarity of fuzzy matches
+	// with one of the products, flagging them AmbiguousProduct (0 = flag only)
+	Penalty float64
+}
+
+// ambiguousProducts is a set of flagged products and the policy for them
+type ambiguousProducts struct {
+	ids    map[string]bool
+	policy AmbiguityPolicy
+}
+
+// WithAmbiguousProducts makes scans exclude or penalize the pairs of the
+// products in reports, as IdentifyAmbiguousProducts returns them; no reports
+// turns it off (the default). Returns the engine for chaining.
+// Excluded pairs are counted as constraint_skipped_pairs, like the pairs a
+// pair constraint excludes, and both apply. Penalized pairs lose
+// policy.Penalty of their combined similarity, so only much stronger matches
+// than the threshold still make it; Compare penalizes them too.
+func (e *LevenshteinEngine) WithAmbiguousProducts(reports []AmbiguityReport, policy AmbiguityPolicy) *LevenshteinEngine {
+	if len(reports) == 0 {
+		e.ambiguous = nil
+		return e
+	}
+	ids := make(map[string]bool, len(reports))
+	for _, r := range reports {
+		ids[r.ID] = true
+	}
+	e.ambiguous = &ambiguousProducts{ids: ids, policy: policy}
+	return e
+}
+
+// WithAmbiguousProducts makes scans exclude or penalize the pairs of the
+// products in reports (see LevenshteinEngine.WithAmbiguousProducts); the
+// index is unchanged. Returns the engine for chaining.
+func (e *HybridEngine) WithAmbiguousProducts(reports []AmbiguityReport, policy AmbiguityPolicy) *HybridEngine {
+	e.levenshteinEngine.WithAmbiguousProducts(reports, policy)
+	return e
+}
+
+// pair reports whether either product of a pair is ambiguous and penalized
+func (a *ambiguousProducts) pair(p, q *Product) bool {
+	return a != nil && !a.policy.Exclude && (a.ids[p.ID] || a.ids[q.ID])
+}
+
+// excludes reports whether a pair is left out of scans for an ambiguous product
+func (a *ambiguousProducts) excludes(p, q *Product) bool {
+	return a != nil && a.policy.Exclude && (a.ids[p.ID] || a.ids[q.ID])
+}
+
+// IdentifyAmbiguousProducts returns the products with more than minPartners
+// duplicates at threshold, most partners first (ties by ID)
+// It runs a full scan under the engine's settings; HybridEngine finds them
+// from its index without one. nil when the input repeats IDs under
+// DuplicateIDReject.
+func (e *LevenshteinEngine) IdentifyAmbiguousProducts(products []Product, threshold float64, minPartners int) []AmbiguityReport {
+	defer e.guard("IdentifyAmbiguousProducts", nil)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil
+	}
+	ptrs := productPtrs(resolved)
+	partners := make(map[string][]ComparisonResult)
+	for _, r := range e.findPairs(context.Background(), ptrs, threshold) {
+		partners[r.ProductA.ID] = append(partners[r.ProductA.ID], r)
+		partners[r.ProductB.ID] = append(partners[r.ProductB.ID], r)
+	}
+	reports := []AmbiguityReport{}
+	for _, p := range ptrs {
+		if matches := partners[p.ID]; len(matches) > minPartners {
+			reports = append(reports, e.ambiguityReport(p, matches))
+		}
+	}
+	sortAmbiguityReports(reports)
+	return reports
+}
+
+// IdentifyAmbiguousProducts returns the products with more than minPartners
+// duplicates at threshold among the indexed ones, most partners first (see
+// LevenshteinEngine.IdentifyAmbiguousProducts)
+// A product's LSH candidates bound its partners, so only products with more
+// than minPartners candidates are verified: a cheap pass over the index
+// rather than a scan. The index is built from products when there is none.
+func (e *HybridEngine) IdentifyAmbiguousProducts(products []Product, threshold float64, minPartners int) []AmbiguityReport {
+	defer e.levenshteinEngine.guard("IdentifyAmbiguousProducts", nil)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil
+	}
+	idx := e.currentIndex()
+	if idx == nil {
+		if err := e.BuildIndex(resolved); err != nil {
+			return nil
+		}
+		idx = e.currentIndex()
+	}
+	reports := []AmbiguityReport{}
+	for i := range resolved {
+		p := &resolved[i]
+		candidates := 0
+		for _, c := range e.findCandidates(idx, p, threshold) {
+			if c.id != p.ID {
+				candidates++
+			}
+		}
+		if candidates <= minPartners {
+			continue
+		}
+		matches, _ := e.findDuplicatesForOne(idx, p, threshold) // A truncated query still counts its partners
+		partners := matches[:0]
+		for _, m := range matches {
+			if m.ProductB.ID != p.ID {
+				partners = append(partners, m)
+			}
+		}
+		if len(partners) > minPartners {
+			reports = append(reports, e.levenshteinEngine.ambiguityReport(p, partners))
+		}
+	}
+	sortAmbiguityReports(reports)
+	return reports
+}
+
+// ambiguityReport describes product from its matches
+func (e *LevenshteinEngine) ambiguityReport(product *Product, matches []ComparisonResult) AmbiguityReport {
+	prep := e.preparer()
+	name, _ := product.preparedStrings(prep)
+	own := make(map[string]bool)
+	for _, token := range strings.Fields(name) {
+		own[token] = true
+	}
+
+	similarities := make([]float64, len(matches))
+	shared := make(map[string]int)
+	for i := range matches {
+		similarities[i] = matches[i].CombinedSimilarity
+		partner := &matches[i].ProductB
+		if partner.ID == product.ID {
+			partner = &matches[i].ProductA
+		}
+		partnerName, _ := partner.preparedStrings(prep)
+		seen := make(map[string]bool)
+		for _, token := range strings.Fields(partnerName) {
+			if own[token] && !seen[token] {
+				seen[token] = true
+				shared[token]++
+			}
+		}
+	}
+
+	tokens := make([]SharedToken, 0, len(shared))
+	for token, count := range shared {
+		tokens = append(tokens, SharedToken{Token: token, Partners: count})
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].Partners != tokens[j].Partners {
+			return tokens[i].Partners > tokens[j].Partners
+		}
+		return tokens[i].Token < tokens[j].Token
+	})
+	if len(tokens) > maxAmbiguityTokens {
+		tokens = tokens[:maxAmbiguityTokens]
+	}
+
+	sort.Float64s(similarities)
+	median := similarities[len(similarities)/2]
+	if len(similarities)%2 == 0 {
+		median = (similarities[len(similarities)/2-1] + median) / 2
+	}
+	return AmbiguityReport{
+		ID:               product.ID,
+		Name:             product.Name,
+		Partners:         len(matches),
+		MedianSimilarity: median,
+		SharedTokens:     tokens,
+	}
+}
+
+// sortAmbiguityReports orders reports by partners, most first, then by ID
+func sortAmbiguityReports(reports []AmbiguityReport) {
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Partners != reports[j].Partners {
+			return reports[i].Partners > reports[j].Partners
+		}
+		return reports[i].ID < reports[j].ID
+	})
+}
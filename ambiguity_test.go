@@ -0,0 +1,170 @@
+package duplicatecheck
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// ambiguityCatalog plants five generic "USB Cable" products, all matching
+// each other at 0.8, among distinctive ones of which only A1 and A2 match
+func ambiguityCatalog() []Product {
+	return []Product{
+		{ID: "U1", Name: "USB Cable 1m", Description: "USB charging cable"},
+		{ID: "A1", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Noise cancelling over-ear headphones"},
+		{ID: "U2", Name: "USB Cable 1m", Description: "USB charging cable, black"},
+		{ID: "B1", Name: "Le Creuset Dutch Oven 5.5 qt", Description: "Enameled cast iron cookware"},
+		{ID: "U3", Name: "USB Cable 1m", Description: "USB charging cable, white"},
+		{ID: "A2", Name: "Sony WH1000XM5 Wireless Headphones", Description: "Noise cancelling over-ear headphones"},
+		{ID: "C1", Name: "Patagonia Nano Puff Jacket", Description: "Insulated windproof jacket"},
+		{ID: "U4", Name: "USB Cable 1m", Description: "USB data cable"},
+		{ID: "D1", Name: "Breville Barista Express Espresso Machine", Description: "Espresso maker with grinder"},
+		{ID: "U5", Name: "USB Cable 2m", Description: "USB charging cable"},
+		{ID: "E1", Name: "Kindle Paperwhite 16GB", Description: "Waterproof e-reader"},
+	}
+}
+
+func TestIdentifyAmbiguousProducts(t *testing.T) {
+	products := ambiguityCatalog()
+	engines := []struct {
+		name     string
+		identify func([]Product, float64, int) []AmbiguityReport
+	}{
+		{"levenshtein", NewLevenshteinEngine().IdentifyAmbiguousProducts},
+		{"hybrid", NewHybridEngine().IdentifyAmbiguousProducts},
+	}
+	tests := []struct {
+		name        string
+		minPartners int
+		wantIDs     []string
+	}{
+		{"generic names flagged", 2, []string{"U1", "U2", "U3", "U4", "U5"}},
+		{"bar at the cluster size", 4, []string{}},
+		{"every match at zero", 0, []string{"U1", "U2", "U3", "U4", "U5", "A1", "A2"}},
+	}
+	for _, engine := range engines {
+		for _, tt := range tests {
+			t.Run(engine.name+"/"+tt.name, func(t *testing.T) {
+				reports := engine.identify(products, 0.8, tt.minPartners)
+				ids := []string{}
+				for _, r := range reports {
+					ids = append(ids, r.ID)
+					want := 4
+					if r.ID == "A1" || r.ID == "A2" {
+						want = 1
+					}
+					if r.Partners != want {
+						t.Errorf("%s has %d partners, want %d", r.ID, r.Partners, want)
+					}
+					if r.MedianSimilarity < 0.8 || r.MedianSimilarity > 1 {
+						t.Errorf("%s median similarity %v out of range", r.ID, r.MedianSimilarity)
+					}
+				}
+				if !reflect.DeepEqual(ids, tt.wantIDs) {
+					t.Errorf("flagged %v, want %v", ids, tt.wantIDs)
+				}
+			})
+		}
+	}
+}
+
+func TestAmbiguityReportDetails(t *testing.T) {
+	reports := NewHybridEngine().IdentifyAmbiguousProducts(ambiguityCatalog(), 0.8, 2)
+	byID := make(map[string]AmbiguityReport)
+	for _, r := range reports {
+		byID[r.ID] = r
+	}
+
+	wantTokens := []SharedToken{{"cable", 4}, {"usb", 4}, {"1m", 3}}
+	if got := byID["U1"].SharedTokens; !reflect.DeepEqual(got, wantTokens) {
+		t.Errorf("U1 shared tokens %v, want %v", got, wantTokens)
+	}
+	wantTokens = []SharedToken{{"cable", 4}, {"usb", 4}}
+	if got := byID["U5"].SharedTokens; !reflect.DeepEqual(got, wantTokens) {
+		t.Errorf("U5 shared tokens %v, want %v", got, wantTokens)
+	}
+
+	// U4's four matches score 0.8833, 0.832, 0.832 and 0.825
+	if got, want := byID["U4"].MedianSimilarity, 0.832; math.Abs(got-want) > 1e-9 {
+		t.Errorf("U4 median similarity %v, want %v", got, want)
+	}
+	if byID["U1"].Name != "USB Cable 1m" {
+		t.Errorf("U1 name %q", byID["U1"].Name)
+	}
+}
+
+func TestIdentifyAmbiguousProductsRejectedIDs(t *testing.T) {
+	products := []Product{{ID: "X", Name: "USB Cable"}, {ID: "X", Name: "USB Cable 2m"}}
+	engine := NewLevenshteinEngine() // DuplicateIDReject by default
+	if reports := engine.IdentifyAmbiguousProducts(products, 0.8, 0); reports != nil {
+		t.Errorf("got %v for repeated IDs, want nil", reports)
+	}
+}
+
+func TestWithAmbiguousProducts(t *testing.T) {
+	products := ambiguityCatalog()
+	reports := NewHybridEngine().IdentifyAmbiguousProducts(products, 0.8, 2)
+
+	tests := []struct {
+		name      string
+		policy    AmbiguityPolicy
+		wantPairs int
+	}{
+		{"flag only", AmbiguityPolicy{}, 11},
+		{"penalty", AmbiguityPolicy{Penalty: 0.1}, 5}, // A1-A2, and U1-U5, U2-U3, U1-U2 and U1-U3 over 0.9
+		{"exclude", AmbiguityPolicy{Exclude: true}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engines := map[string]interface {
+				FindDuplicates([]Product, float64) []ComparisonResult
+			}{
+				"levenshtein": NewLevenshteinEngine().WithAmbiguousProducts(reports, tt.policy),
+				"hybrid":      NewHybridEngine().WithAmbiguousProducts(reports, tt.policy),
+			}
+			for name, engine := range engines {
+				results := engine.FindDuplicates(products, 0.8)
+				if len(results) != tt.wantPairs {
+					t.Errorf("%s: %d pairs, want %d", name, len(results), tt.wantPairs)
+				}
+				for _, r := range results {
+					headphones := r.ProductA.ID[0] == 'A'
+					if r.AmbiguousProduct == headphones {
+						t.Errorf("%s: %s-%s AmbiguousProduct = %v", name, r.ProductA.ID, r.ProductB.ID, r.AmbiguousProduct)
+					}
+				}
+			}
+		})
+	}
+
+	plain := NewLevenshteinEngine().Compare(products[0], products[9])
+	penalized := NewLevenshteinEngine().WithAmbiguousProducts(reports, AmbiguityPolicy{Penalty: 0.1}).Compare(products[0], products[9])
+	if math.Abs(plain.CombinedSimilarity-0.1-penalized.CombinedSimilarity) > 1e-9 || !penalized.AmbiguousProduct {
+		t.Errorf("penalized Compare = %v (ambiguous %v), want %v less 0.1", penalized.CombinedSimilarity, penalized.AmbiguousProduct, plain.CombinedSimilarity)
+	}
+
+	off := NewLevenshteinEngine().WithAmbiguousProducts(reports, AmbiguityPolicy{Exclude: true}).WithAmbiguousProducts(nil, AmbiguityPolicy{})
+	if got := len(off.FindDuplicates(products, 0.8)); got != 11 {
+		t.Errorf("turned off: %d pairs, want 11", got)
+	}
+}
+
+func TestWithAmbiguousProductsStats(t *testing.T) {
+	products := ambiguityCatalog()
+	reports := NewLevenshteinEngine().IdentifyAmbiguousProducts(products, 0.8, 2)
+	tests := []struct {
+		name   string
+		policy AmbiguityPolicy
+		want   bool
+	}{
+		{"exclude", AmbiguityPolicy{Exclude: true}, true},
+		{"penalty", AmbiguityPolicy{Penalty: 0.1}, false},
+	}
+	for _, tt := range tests {
+		engine := NewLevenshteinEngine().WithAmbiguousProducts(reports, tt.policy)
+		engine.FindDuplicates(products, 0.8)
+		if got := engine.GetScanStats()["constraint_skipped_pairs"].(uint64) > 0; got != tt.want {
+			t.Errorf("%s: constraint_skipped_pairs counted %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
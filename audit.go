@@ -0,0 +1,448 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// auditQueueSize is the number of decisions a scan queues for its audit sink
+// before comparisons wait for the sink to catch up
+const auditQueueSize = 4096
+
+// AuditPolicy selects which comparisons of a scan an audit log records
+type AuditPolicy int
+
+const (
+	// AuditAboveThreshold records the pairs meeting the scan's threshold:
+	// every automated duplicate decision (default)
+	AuditAboveThreshold AuditPolicy = iota
+	// AuditAllCompared records every pair the scan compared, matching or not,
+	// for full forensics. On a large catalog that is most of its pairs: a
+	// 100k-product Levenshtein scan compares billions, and even a hybrid scan
+	// records every verified candidate, so size the sink for it.
+	AuditAllCompared
+)
+
+// String returns the policy's name: "above-threshold" or "all-compared"
+func (p AuditPolicy) String() string {
+	switch p {
+	case AuditAboveThreshold:
+		return "above-threshold"
+	case AuditAllCompared:
+		return "all-compared"
+	default:
+		return fmt.Sprintf("AuditPolicy(%d)", int(p))
+	}
+}
+
+// AuditEntry records one scan decision with everything needed to justify it
+// later without re-running the scan: who was compared, the fingerprints of the
+// text that was scored, every score, why, and under which configuration
+// Store the products' text alongside the log; Verify checks a stored pair
+// against its entry.
+type AuditEntry struct {
+	Time              time.Time `json:"time"`               // When the decision was recorded, UTC
+	RunID             string    `json:"run_id"`             // Identifies the scan; every entry of one scan shares it
+	ConfigFingerprint string    `json:"config_fingerprint"` // EngineConfig.Fingerprint of the engine at the start of the scan
+
+	ProductA string `json:"product_a"`
+	ProductB string `json:"product_b"`
+	// Product.Fingerprint of each product: its name and description,
+	// lowercased and trimmed
+	ContentFingerprintA uint64 `json:"content_fingerprint_a"`
+	ContentFingerprintB uint64 `json:"content_fingerprint_b"`
+	// Fingerprint of each product's text as the engine's TextPreparation
+	// prepared it for comparison; equal to the content fingerprint under the
+	// default preparation
+	PreparedFingerprintA uint64 `json:"prepared_fingerprint_a"`
+	PreparedFingerprintB uint64 `json:"prepared_fingerprint_b"`
+
+	NameDistance               int     `json:"name_distance"`
+	NameSimilarity             float64 `json:"name_similarity"`
+	DescriptionDistance        int     `json:"description_distance"`
+	DescriptionSimilarity      float64 `json:"description_similarity"`
+	CombinedSimilarity         float64 `json:"combined_similarity"`
+	NameInDescriptionAB        float64 `json:"name_in_description_ab,omitempty"`
+	NameInDescriptionBA        float64 `json:"name_in_description_ba,omitempty"`
+	DeobfuscatedNameSimilarity float64 `json:"deobfuscated_name_similarity,omitempty"`
+	EstimatedSimilarity        float64 `json:"estimated_similarity,omitempty"`
+	BundleSimilarity           float64 `json:"bundle_similarity,omitempty"`
+	DuplicateProbability       float64 `json:"duplicate_probability,omitempty"`
+	Stage                      string  `json:"stage,omitempty"`
+	SimilarityMode             string  `json:"similarity_mode"`
+
+	MatchType      string       `json:"match_type"`
+	ReasonCodes    []ReasonCode `json:"reason_codes,omitempty"`
+	Threshold      float64      `json:"threshold"`
+	MeetsThreshold bool         `json:"meets_threshold"`
+	// Normalized weights the pair was scored with
+	NameWeight        float64 `json:"name_weight"`
+	DescriptionWeight float64 `json:"description_weight"`
+}
+
+// Weights returns the weights the pair was scored with
+func (a AuditEntry) Weights() ComparisonWeights {
+	return ComparisonWeights{NameWeight: a.NameWeight, DescriptionWeight: a.DescriptionWeight}
+}
+
+// Verify checks that a and b, read back from storage, are the products the
+// entry was recorded for, and that engine still scores them as recorded
+// Returns an error naming the first check that fails: the IDs, the content or
+// prepared fingerprints (the text changed), the configuration fingerprint
+// (engine isn't configured as the one that scanned), or the recomputed
+// CombinedSimilarity, which must be bit-for-bit the recorded one.
+func (a AuditEntry) Verify(engine DuplicateCheckEngine, productA, productB Product) error {
+	if productA.ID != a.ProductA || productB.ID != a.ProductB {
+		return fmt.Errorf("duplicatecheck: audit entry is for %s and %s, not %s and %s", a.ProductA, a.ProductB, productA.ID, productB.ID)
+	}
+	if productA.Fingerprint() != a.ContentFingerprintA || productB.Fingerprint() != a.ContentFingerprintB {
+		return fmt.Errorf("duplicatecheck: text of %s or %s differs from the text scored", a.ProductA, a.ProductB)
+	}
+	levenshtein := auditedEngine(engine)
+	if levenshtein == nil {
+		return fmt.Errorf("duplicatecheck: can't verify audit entries with %s", engine.GetName())
+	}
+	if fingerprint := engine.(interface{ ConfigFingerprint() string }).ConfigFingerprint(); fingerprint != a.ConfigFingerprint {
+		return fmt.Errorf("duplicatecheck: engine config %s differs from the scan's %s", fingerprint, a.ConfigFingerprint)
+	}
+	prep := levenshtein.preparer()
+	if preparedFingerprint(&productA, prep) != a.PreparedFingerprintA || preparedFingerprint(&productB, prep) != a.PreparedFingerprintB {
+		return fmt.Errorf("duplicatecheck: prepared text of %s or %s differs from the text scored", a.ProductA, a.ProductB)
+	}
+	result := levenshtein.compareWithWeights(&productA, &productB, a.Weights(), memoPair{}, a.Threshold)
+	if result.CombinedSimilarity != a.CombinedSimilarity {
+		return fmt.Errorf("duplicatecheck: %s and %s score %v, recorded %v", a.ProductA, a.ProductB, result.CombinedSimilarity, a.CombinedSimilarity)
+	}
+	return nil
+}
+
+// auditedEngine returns the engine scoring the pairs of an engine WithAudit
+// can be set on, or nil
+func auditedEngine(engine DuplicateCheckEngine) *LevenshteinEngine {
+	switch e := engine.(type) {
+	case *LevenshteinEngine:
+		return e
+	case *HybridEngine:
+		return e.levenshteinEngine
+	default:
+		return nil
+	}
+}
+
+// preparedFingerprint hashes a product's text as prep prepares it
+func preparedFingerprint(p *Product, prep *textPreparer) uint64 {
+	name, desc := p.preparedStrings(prep)
+	return contentFingerprint(name, desc)
+}
+
+// AuditSink receives the entries of an audit log
+// Record is called from one goroutine per scan, so a sink shared by engines
+// or concurrent scans must be safe for concurrent use. A sink buffering
+// entries should implement Flush() error, which is called before the scan
+// returns.
+type AuditSink interface {
+	Record(entry AuditEntry) error
+}
+
+// auditFlusher is implemented by sinks that buffer entries
+type auditFlusher interface {
+	Flush() error
+}
+
+// auditLog is the audit configuration of an engine
+type auditLog struct {
+	sink        AuditSink
+	policy      AuditPolicy
+	fingerprint func() string // ConfigFingerprint of the engine WithAudit was called on
+}
+
+// WithAudit records the decisions of every FindDuplicates scan to sink, as
+// policy selects; a nil sink turns it off (the default). Returns the engine
+// for chaining.
+// Each scan gets a run ID and records from its own goroutine, so the sink's
+// speed doesn't hold up comparisons until its queue of 4096 entries fills;
+// then comparisons wait for room rather than drop an entry. Every entry is
+// recorded, and a buffering sink flushed, before the scan returns. A sink
+// error stops that scan's recording (the scan itself completes), and is
+// logged when a logger is set. Scans of every FindDuplicates variant
+// (Checked, Ctx, Chan, WithSummary, KeepOnlyBestPerProduct) are audited.
+func (e *LevenshteinEngine) WithAudit(sink AuditSink, policy AuditPolicy) *LevenshteinEngine {
+	if sink == nil {
+		e.audit = nil
+		return e
+	}
+	e.audit = &auditLog{sink: sink, policy: policy, fingerprint: e.ConfigFingerprint}
+	return e
+}
+
+// WithAudit records the decisions of every FindDuplicates scan to sink, LSH
+// candidates or full scan alike, with the hybrid engine's config fingerprint
+// (see LevenshteinEngine.WithAudit). Returns the engine for chaining.
+func (e *HybridEngine) WithAudit(sink AuditSink, policy AuditPolicy) *HybridEngine {
+	e.levenshteinEngine.WithAudit(sink, policy)
+	if audit := e.levenshteinEngine.audit; audit != nil {
+		audit.fingerprint = e.ConfigFingerprint
+	}
+	return e
+}
+
+// auditRun records one scan's decisions from its own goroutine
+type auditRun struct {
+	log    *auditLog
+	id     string
+	config string
+	prep   *textPreparer
+	queue  chan ComparisonResult
+	done   chan struct{}
+	logger *slog.Logger
+}
+
+// startAudit starts recording a scan, or returns nil when auditing is off
+func (e *LevenshteinEngine) startAudit() *auditRun {
+	if e.audit == nil {
+		return nil
+	}
+	run := &auditRun{
+		log:    e.audit,
+		id:     newAuditRunID(),
+		config: e.audit.fingerprint(),
+		prep:   e.preparer(),
+		queue:  make(chan ComparisonResult, auditQueueSize),
+		done:   make(chan struct{}),
+		logger: e.logger,
+	}
+	go run.drain()
+	return run
+}
+
+// newAuditRunID returns a random run ID
+func newAuditRunID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read never fails on supported platforms
+	return hex.EncodeToString(b[:])
+}
+
+// record queues a compared pair if the policy selects it, waiting while the
+// queue is full
+func (r *auditRun) record(result *ComparisonResult) {
+	if r == nil || (r.log.policy == AuditAboveThreshold && !result.MeetsThreshold) {
+		return
+	}
+	r.queue <- *result
+}
+
+// finish waits for every queued decision to be recorded and the sink flushed
+func (r *auditRun) finish() {
+	if r == nil {
+		return
+	}
+	close(r.queue)
+	<-r.done
+}
+
+// drain records queued decisions until the scan finishes
+func (r *auditRun) drain() {
+	defer close(r.done)
+	var err error
+	for result := range r.queue {
+		if err == nil {
+			err = r.recordEntry(r.entry(&result))
+		}
+	}
+	if flusher, ok := r.log.sink.(auditFlusher); ok && err == nil {
+		err = flusher.Flush()
+	}
+	if err != nil && r.logger != nil {
+		r.logger.LogAttrs(context.Background(), slog.LevelError, "audit log stopped",
+			slog.String("run_id", r.id),
+			slog.String("reason", err.Error()))
+	}
+}
+
+// recordEntry passes entry to the sink, turning its panic into an error so
+// the queue keeps draining and the scan can't block on it
+func (r *auditRun) recordEntry(entry AuditEntry) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			failure := newPanicError(recovered)
+			failure.Op = "AuditSink.Record"
+			panicsRecovered.Add(1)
+			err = failure
+		}
+	}()
+	return r.log.sink.Record(entry)
+}
+
+// entry builds the audit entry of a compared pair
+func (r *auditRun) entry(result *ComparisonResult) AuditEntry {
+	return AuditEntry{
+		Time:              time.Now().UTC(),
+		RunID:             r.id,
+		ConfigFingerprint: r.config,
+
+		ProductA:             result.ProductA.ID,
+		ProductB:             result.ProductB.ID,
+		ContentFingerprintA:  result.ProductA.Fingerprint(),
+		ContentFingerprintB:  result.ProductB.Fingerprint(),
+		PreparedFingerprintA: preparedFingerprint(&result.ProductA, r.prep),
+		PreparedFingerprintB: preparedFingerprint(&result.ProductB, r.prep),
+
+		NameDistance:               result.NameDistance,
+		NameSimilarity:             result.NameSimilarity,
+		DescriptionDistance:        result.DescriptionDistance,
+		DescriptionSimilarity:      result.DescriptionSimilarity,
+		CombinedSimilarity:         result.CombinedSimilarity,
+		NameInDescriptionAB:        result.NameInDescriptionAB,
+		NameInDescriptionBA:        result.NameInDescriptionBA,
+		DeobfuscatedNameSimilarity: result.DeobfuscatedNameSimilarity,
+		EstimatedSimilarity:        result.EstimatedSimilarity,
+		BundleSimilarity:           result.BundleSimilarity,
+		DuplicateProbability:       result.DuplicateProbability,
+		Stage:                      result.Stage,
+		SimilarityMode:             result.SimilarityMode.String(),
+
+		MatchType:         result.MatchType.String(),
+		ReasonCodes:       result.ReasonCodes,
+		Threshold:         result.ThresholdUsed,
+		MeetsThreshold:    result.MeetsThreshold,
+		NameWeight:        result.WeightsUsed.NameWeight,
+		DescriptionWeight: result.WeightsUsed.DescriptionWeight,
+	}
+}
+
+// JSONLAuditSink appends audit entries to a file as JSON Lines, rotating it
+// when it would grow past a size
+// Entries are only ever appended: a full file is renamed to path.1, path.2
+// and so on, the highest number being the most recent, and a new file is
+// started at path. Rotated files are never written again. Safe for
+// concurrent use.
+type JSONLAuditSink struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	file     *os.File
+	w        *bufio.Writer
+	size     int64
+	next     int // Number of the next rotated file
+}
+
+// NewJSONLAuditSink opens path for appending, creating it if needed, and
+// rotates it once it would grow past maxBytes (0 = never rotate)
+// A file larger than maxBytes is still started, with one entry, so a single
+// entry is never split or lost.
+func NewJSONLAuditSink(path string, maxBytes int64) (*JSONLAuditSink, error) {
+	s := &JSONLAuditSink{path: path, maxBytes: maxBytes, next: 1}
+	rotated, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range rotated {
+		if n, err := strconv.Atoi(strings.TrimPrefix(name, path+".")); err == nil && n >= s.next {
+			s.next = n + 1
+		}
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open opens the current file for appending
+func (s *JSONLAuditSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file, s.w, s.size = file, bufio.NewWriter(file), info.Size()
+	return nil
+}
+
+// Record appends one entry, rotating the file first if it would grow past
+// the size limit
+func (s *JSONLAuditSink) Record(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	if s.maxBytes > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.w.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+// rotate closes the current file, renames it to the next rotated name and
+// starts a new one
+func (s *JSONLAuditSink) rotate() error {
+	if err := s.closeFile(); err != nil {
+		return err
+	}
+	if err := os.Rename(s.path, s.path+"."+strconv.Itoa(s.next)); err != nil {
+		return err
+	}
+	s.next++
+	return s.open()
+}
+
+// Flush writes buffered entries to the file and syncs it to disk
+func (s *JSONLAuditSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close flushes and closes the file; later entries are rejected
+func (s *JSONLAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	return s.closeFile()
+}
+
+// closeFile flushes, syncs and closes the current file
+func (s *JSONLAuditSink) closeFile() error {
+	file := s.file
+	s.file = nil
+	err := s.w.Flush()
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,290 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryAuditSink keeps recorded entries, holding them back until flushed;
+// with a delay, the first Record stalls so the scan fills the audit queue
+type memoryAuditSink struct {
+	mu       sync.Mutex
+	pending  []AuditEntry
+	entries  []AuditEntry
+	delay    time.Duration
+	failures int // Record fails after this many entries (0 = never)
+}
+
+func (s *memoryAuditSink) Record(entry AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.delay > 0 && len(s.pending)+len(s.entries) == 0 {
+		time.Sleep(s.delay)
+	}
+	if s.failures > 0 && len(s.pending)+len(s.entries) >= s.failures {
+		return errors.New("sink full")
+	}
+	s.pending = append(s.pending, entry)
+	return nil
+}
+
+func (s *memoryAuditSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, s.pending...)
+	s.pending = nil
+	return nil
+}
+
+// recorded returns the flushed entries
+func (s *memoryAuditSink) recorded() []AuditEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AuditEntry(nil), s.entries...)
+}
+
+// auditedScanner is an engine WithAudit can be set on
+type auditedScanner interface {
+	DuplicateCheckEngine
+	ConfigFingerprint() string
+}
+
+func TestAuditPolicies(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 80)
+	const threshold = 0.6
+
+	tests := []struct {
+		name   string
+		policy AuditPolicy
+		engine func(sink AuditSink, policy AuditPolicy) auditedScanner
+	}{
+		{"levenshtein above threshold", AuditAboveThreshold, func(sink AuditSink, policy AuditPolicy) auditedScanner {
+			return NewLevenshteinEngine().WithAudit(sink, policy)
+		}},
+		{"levenshtein all compared", AuditAllCompared, func(sink AuditSink, policy AuditPolicy) auditedScanner {
+			return NewLevenshteinEngine().WithAudit(sink, policy)
+		}},
+		{"hybrid above threshold", AuditAboveThreshold, func(sink AuditSink, policy AuditPolicy) auditedScanner {
+			engine := NewHybridEngine().WithExactModeCutoff(0).WithAudit(sink, policy)
+			if err := engine.BuildIndex(catalog); err != nil {
+				t.Fatalf("BuildIndex failed: %v", err)
+			}
+			return engine
+		}},
+		{"hybrid all compared", AuditAllCompared, func(sink AuditSink, policy AuditPolicy) auditedScanner {
+			engine := NewHybridEngine().WithExactModeCutoff(0).WithAudit(sink, policy)
+			if err := engine.BuildIndex(catalog); err != nil {
+				t.Fatalf("BuildIndex failed: %v", err)
+			}
+			return engine
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &memoryAuditSink{}
+			engine := tt.engine(sink, tt.policy)
+			results := engine.FindDuplicates(catalog, threshold)
+			entries := sink.recorded()
+			if len(results) == 0 {
+				t.Fatal("scan found no duplicates to audit")
+			}
+
+			matched := make(map[[2]string]AuditEntry)
+			for _, entry := range entries {
+				if entry.RunID == "" || entry.RunID != entries[0].RunID {
+					t.Fatalf("entry run ID %q, first entry's %q", entry.RunID, entries[0].RunID)
+				}
+				if entry.ConfigFingerprint != engine.ConfigFingerprint() || entry.Threshold != threshold || entry.Time.IsZero() {
+					t.Fatalf("unexpected entry %+v", entry)
+				}
+				if entry.MeetsThreshold {
+					matched[[2]string{entry.ProductA, entry.ProductB}] = entry
+				} else if tt.policy == AuditAboveThreshold {
+					t.Errorf("%s recorded %s-%s below threshold", tt.policy, entry.ProductA, entry.ProductB)
+				}
+			}
+			if len(matched) != len(results) {
+				t.Fatalf("%d entries meet the threshold, scan returned %d duplicates", len(matched), len(results))
+			}
+			for _, result := range results {
+				entry, ok := matched[[2]string{result.ProductA.ID, result.ProductB.ID}]
+				if !ok || entry.CombinedSimilarity != result.CombinedSimilarity || len(entry.ReasonCodes) != len(result.ReasonCodes) ||
+					entry.MatchType != result.MatchType.String() || entry.ContentFingerprintA != result.ProductA.Fingerprint() {
+					t.Fatalf("result %s-%s recorded as %+v", result.ProductA.ID, result.ProductB.ID, entry)
+				}
+			}
+			if tt.policy == AuditAllCompared && len(entries) <= len(results) {
+				t.Errorf("all-compared recorded %d entries for %d duplicates", len(entries), len(results))
+			}
+
+			// Each scan is a new run
+			engine.FindDuplicates(catalog[:40], threshold)
+			if later := sink.recorded(); len(later) > len(entries) && later[len(entries)].RunID == entries[0].RunID {
+				t.Error("second scan reused the run ID")
+			}
+		})
+	}
+}
+
+func TestAuditFlushedBeforeScanReturns(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 120) // 7140 pairs, more than the queue holds
+	sink := &memoryAuditSink{delay: 50 * time.Millisecond}
+	engine := NewLevenshteinEngine().WithAudit(sink, AuditAllCompared)
+	results, summary, err := engine.FindDuplicatesWithSummary(catalog, 0.6)
+	if err != nil {
+		t.Fatalf("FindDuplicatesWithSummary failed: %v", err)
+	}
+	if got := len(sink.recorded()); uint64(got) != summary.Comparisons || got <= auditQueueSize {
+		t.Errorf("%d entries recorded when the scan returned, %d comparisons", got, summary.Comparisons)
+	}
+
+	// A failing sink stops the recording, not the scan
+	failing := &memoryAuditSink{failures: 10}
+	again := NewLevenshteinEngine().WithAudit(failing, AuditAllCompared).FindDuplicates(catalog, 0.6)
+	if len(again) != len(results) {
+		t.Errorf("scan with a failing sink found %d duplicates, want %d", len(again), len(results))
+	}
+	if got := len(failing.recorded()); got != 0 {
+		t.Errorf("failing sink flushed %d entries", got)
+	}
+}
+
+// readAuditFile decodes the entries of one JSON Lines audit file
+func readAuditFile(t *testing.T, path string) []AuditEntry {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestJSONLAuditSinkRotation(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 40)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	const maxBytes = 4096
+
+	scan := func() []AuditEntry {
+		sink, err := NewJSONLAuditSink(path, maxBytes)
+		if err != nil {
+			t.Fatalf("NewJSONLAuditSink failed: %v", err)
+		}
+		defer sink.Close()
+		memory := &memoryAuditSink{}
+		engine := NewLevenshteinEngine().WithAudit(sink, AuditAllCompared)
+		engine.FindDuplicates(catalog, 0.6)
+		engine.WithAudit(memory, AuditAllCompared).FindDuplicates(catalog, 0.6)
+		return memory.recorded()
+	}
+	first := scan()
+	second := scan() // Reopening continues the numbering and appends
+
+	var got []AuditEntry
+	for n := 1; ; n++ {
+		rotated := path + "." + strconv.Itoa(n)
+		info, err := os.Stat(rotated)
+		if os.IsNotExist(err) {
+			if n < 3 {
+				t.Fatalf("only %d rotated files", n-1)
+			}
+			break
+		}
+		if info.Size() > maxBytes {
+			t.Errorf("%s holds %d bytes, more than %d", rotated, info.Size(), maxBytes)
+		}
+		got = append(got, readAuditFile(t, rotated)...)
+	}
+	got = append(got, readAuditFile(t, path)...)
+
+	want := append(first, second...)
+	if len(got) != len(want) {
+		t.Fatalf("files hold %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ProductA != want[i].ProductA || got[i].ProductB != want[i].ProductB || got[i].CombinedSimilarity != want[i].CombinedSimilarity {
+			t.Fatalf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if got[0].RunID == got[len(got)-1].RunID {
+		t.Error("both scans share a run ID")
+	}
+}
+
+func TestAuditEntryVerify(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 80)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewJSONLAuditSink(path, 0)
+	if err != nil {
+		t.Fatalf("NewJSONLAuditSink failed: %v", err)
+	}
+	engine := NewHybridEngine().WithExactModeCutoff(0).
+		WithTextPreparation(TextPreparation{CollapseWhitespace: true}).
+		WithAudit(sink, AuditAboveThreshold)
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	if len(engine.FindDuplicates(catalog, 0.6)) == 0 {
+		t.Fatal("scan found no duplicates to audit")
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// A later process: the log, the stored product text and the stored config
+	entries := readAuditFile(t, path)
+	stored := make(map[string]Product, len(catalog))
+	for _, p := range catalog {
+		stored[p.ID] = Product{ID: p.ID, Name: p.Name, Description: p.Description}
+	}
+	restored, err := NewEngineFromConfig(engine.Config())
+	if err != nil {
+		t.Fatalf("NewEngineFromConfig failed: %v", err)
+	}
+	for _, entry := range entries {
+		if err := entry.Verify(restored, stored[entry.ProductA], stored[entry.ProductB]); err != nil {
+			t.Fatalf("Verify failed: %v", err)
+		}
+	}
+
+	entry := entries[0]
+	edited := stored[entry.ProductB]
+	edited.Name += " v2"
+	forged := entry
+	forged.CombinedSimilarity = 1
+	tests := []struct {
+		name   string
+		entry  AuditEntry
+		engine DuplicateCheckEngine
+		a, b   Product
+	}{
+		{"edited text", entry, restored, stored[entry.ProductA], edited},
+		{"swapped products", entry, restored, stored[entry.ProductB], stored[entry.ProductA]},
+		{"other config", entry, NewHybridEngine(), stored[entry.ProductA], stored[entry.ProductB]},
+		{"forged score", forged, restored, stored[entry.ProductA], stored[entry.ProductB]},
+		{"unsupported engine", entry, NewTFIDFEngine(), stored[entry.ProductA], stored[entry.ProductB]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.entry.Verify(tt.engine, tt.a, tt.b); err == nil {
+				t.Error("Verify accepted the entry")
+			}
+		})
+	}
+}
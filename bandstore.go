@@ -0,0 +1,117 @@
+package duplicatecheck
+
+// BandStore holds the LSH buckets of an index: for each band, the products
+// whose signatures hash to each bucket
+// Products are dense numbers assigned in indexing order; the index keeps the
+// number -> product ID table. Get and ForEach may run concurrently with each
+// other, and Append with Append calls for other bands (BuildIndex fills bands
+// on parallel workers); the index never appends while it is being queried.
+// Slices a store returns belong to it and must not be modified.
+//
+// A store may also implement Flush() error, called once a build, load or
+// compaction has appended everything, and Err() error, reporting the first
+// failure of an Append or Get (which have no error result); a store reporting
+// an error fails the build.
+type BandStore interface {
+	// Append adds product id to the bucket hash of band
+	Append(band int, hash uint64, id uint32)
+	// Get returns the products in the bucket hash of band, in append order
+	Get(band int, hash uint64) []uint32
+	// ForEach calls fn for every bucket of band, in no particular order,
+	// until fn returns false
+	ForEach(band int, fn func(hash uint64, ids []uint32) bool)
+}
+
+// BandStoreFactory creates the empty band store of a new index with numBands bands
+// (see HybridConfig.BandStore)
+type BandStoreFactory func(numBands int) BandStore
+
+// MemoryBandStore is the default BandStore: a map per band from bucket hash to products
+type MemoryBandStore struct {
+	bands []map[uint64][]uint32
+}
+
+// NewMemoryBandStore returns an empty in-memory store with numBands bands
+// It is a BandStoreFactory.
+func NewMemoryBandStore(numBands int) BandStore {
+	s := &MemoryBandStore{bands: make([]map[uint64][]uint32, numBands)}
+	for i := range s.bands {
+		s.bands[i] = make(map[uint64][]uint32)
+	}
+	return s
+}
+
+// Append adds product id to the bucket hash of band
+func (s *MemoryBandStore) Append(band int, hash uint64, id uint32) {
+	s.bands[band][hash] = append(s.bands[band][hash], id)
+}
+
+// Get returns the products in the bucket hash of band
+func (s *MemoryBandStore) Get(band int, hash uint64) []uint32 {
+	return s.bands[band][hash]
+}
+
+// ForEach calls fn for every bucket of band until fn returns false
+func (s *MemoryBandStore) ForEach(band int, fn func(hash uint64, ids []uint32) bool) {
+	for hash, ids := range s.bands[band] {
+		if !fn(hash, ids) {
+			return
+		}
+	}
+}
+
+// clone returns a copy that later appends to s don't affect
+// Buckets are shared but capped at their length, so appending to either
+// copy never writes where the other reads.
+func (s *MemoryBandStore) clone() BandStore {
+	c := &MemoryBandStore{bands: make([]map[uint64][]uint32, len(s.bands))}
+	for i, band := range s.bands {
+		c.bands[i] = make(map[uint64][]uint32, len(band))
+		for hash, ids := range band {
+			c.bands[i][hash] = ids[:len(ids):len(ids)]
+		}
+	}
+	return c
+}
+
+// newBandStore returns an empty band store for a new index
+func (e *HybridEngine) newBandStore() BandStore {
+	if e.bandStore != nil {
+		return e.bandStore(e.numBands)
+	}
+	return NewMemoryBandStore(e.numBands)
+}
+
+// finishBandStore flushes a store that buffers appends and returns the first
+// error it recorded
+func finishBandStore(store BandStore) error {
+	if flusher, ok := store.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return err
+		}
+	}
+	return bandStoreErr(store)
+}
+
+// bandStoreErr returns the first error a store recorded, for stores that record one
+func bandStoreErr(store BandStore) error {
+	if s, ok := store.(interface{ Err() error }); ok {
+		return s.Err()
+	}
+	return nil
+}
+
+// copyBandStore copies every bucket of src into dst, mapping each product
+// through renumber and dropping those it maps to false
+func copyBandStore(dst, src BandStore, numBands int, renumber func(uint32) (uint32, bool)) {
+	for band := 0; band < numBands; band++ {
+		src.ForEach(band, func(hash uint64, ids []uint32) bool {
+			for _, id := range ids {
+				if n, ok := renumber(id); ok {
+					dst.Append(band, hash, n)
+				}
+			}
+			return true
+		})
+	}
+}
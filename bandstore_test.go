@@ -0,0 +1,260 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// diskEngine returns a hybrid engine keeping its buckets in scratch files under t.TempDir()
+func diskEngine(t testing.TB) *HybridEngine {
+	config := DefaultHybridConfig()
+	config.BandStore = DiskBandStores(t.TempDir())
+	return NewHybridEngineWithConfig(config)
+}
+
+func TestDiskBandStoreEngine(t *testing.T) {
+	const threshold = 0.8
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 300)
+	// One salt for both, so their buckets have the same keys
+	memory, disk := NewHybridEngine().WithBucketSalt(1), diskEngine(t).WithBucketSalt(1)
+
+	// Every index operation must leave both engines with the same buckets
+	same := func(step string) {
+		t.Helper()
+		if _, ok := disk.currentIndex().bands.(*DiskBandStore); !ok {
+			t.Fatalf("%s: disk engine index uses %T", step, disk.currentIndex().bands)
+		}
+		if err := bandStoreErr(disk.currentIndex().bands); err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		if got, want := snapshotBuckets(disk.currentIndex(), 0), snapshotBuckets(memory.currentIndex(), 0); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: %d disk buckets differ from %d memory buckets", step, len(got), len(want))
+		}
+		queries := catalog[:40]
+		if got, want := candidateIDs(disk, queries, threshold), candidateIDs(memory, queries, threshold); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: FindDuplicatesForOne results differ", step)
+		}
+	}
+
+	for _, engine := range []*HybridEngine{memory, disk} {
+		if err := engine.BuildIndex(catalog[:250]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	same("BuildIndex")
+
+	for _, engine := range []*HybridEngine{memory, disk} {
+		for _, p := range catalog[250:] {
+			if err := engine.AddProduct(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, p := range catalog[:100] {
+			if err := engine.RemoveProduct(p.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	same("AddProduct and RemoveProduct")
+
+	for _, engine := range []*HybridEngine{memory, disk} {
+		if _, err := engine.Compact(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	same("Compact")
+
+	var saved bytes.Buffer
+	if err := memory.SaveIndex(&saved); err != nil {
+		t.Fatal(err)
+	}
+	if err := disk.LoadIndex(bytes.NewReader(saved.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	same("LoadIndex")
+
+	for _, engine := range []*HybridEngine{memory, disk} {
+		if _, err := engine.BuildIndexProgressive(context.Background(), catalog, ProgressiveOptions{BatchSize: 100}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	same("BuildIndexProgressive")
+}
+
+func TestDiskBandStoreErrors(t *testing.T) {
+	config := DefaultHybridConfig()
+	config.BandStore = DiskBandStores(filepath.Join(t.TempDir(), "missing"))
+	engine := NewHybridEngineWithConfig(config)
+	if err := engine.BuildIndex(GenerateTestCatalog(goldenCatalogSeed, 20)); err == nil {
+		t.Error("BuildIndex succeeded without a directory for its band store")
+	}
+	if engine.currentIndex() != nil {
+		t.Error("failed BuildIndex published an index")
+	}
+}
+
+// writeBandStoreFile writes a store with two flushed segments and returns its path and contents
+func writeBandStoreFile(t *testing.T) (string, map[[2]uint64][]uint32) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bands.dcb")
+	store, err := NewDiskBandStore(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[[2]uint64][]uint32)
+	add := func(band int, hash uint64, id uint32) {
+		store.Append(band, hash, id)
+		key := [2]uint64{uint64(band), hash}
+		want[key] = append(want[key], id)
+	}
+	add(0, 7, 1)
+	add(0, 7, 2)
+	add(2, 9, 3)
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	add(0, 7, 4) // Rewrites the bucket in the second segment
+	add(1, 5, 4)
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path, want
+}
+
+func TestOpenDiskBandStore(t *testing.T) {
+	path, want := writeBandStoreFile(t)
+	store, err := OpenDiskBandStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	got := make(map[[2]uint64][]uint32)
+	for band := 0; band < 3; band++ {
+		store.ForEach(band, func(hash uint64, ids []uint32) bool {
+			got[[2]uint64{uint64(band), hash}] = ids
+			return true
+		})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened store = %v, want %v", got, want)
+	}
+	if ids := store.Get(0, 7); !reflect.DeepEqual(ids, []uint32{1, 2, 4}) {
+		t.Errorf("Get(0, 7) = %v", ids)
+	}
+}
+
+func TestOpenDiskBandStoreRejectsPartialWrites(t *testing.T) {
+	path, _ := writeBandStoreFile(t)
+	complete, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flipped := append([]byte(nil), complete...)
+	flipped[len(flipped)-12] ^= 0xff // An ID of the last segment
+
+	tests := []struct {
+		name     string
+		contents []byte
+	}{
+		{"header cut", complete[:5]},
+		{"segment start cut", complete[:len(complete)-45]},
+		{"records cut", complete[:len(complete)-10]},
+		{"trailer missing", complete[:len(complete)-8]},
+		{"end marker cut", complete[:len(complete)-2]},
+		{"torn record", flipped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partial := filepath.Join(t.TempDir(), "partial.dcb")
+			if err := os.WriteFile(partial, tt.contents, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if store, err := OpenDiskBandStore(partial); !errors.Is(err, ErrCorruptBandStore) {
+				if store != nil {
+					store.Close()
+				}
+				t.Errorf("OpenDiskBandStore = %v, want ErrCorruptBandStore", err)
+			}
+		})
+	}
+}
+
+// BenchmarkBandStore compares the band stores on a synthetic 1M-product index
+// (100k with -short): band hashes are drawn directly, one product in ten
+// sharing every band with an earlier one, so no text is hashed. Reports the
+// heap held by the index and the latency of a query's band lookups.
+func BenchmarkBandStore(b *testing.B) {
+	products := 1_000_000
+	if testing.Short() {
+		products = 100_000
+	}
+	const bands = 20
+
+	stores := []struct {
+		name string
+		new  func(b *testing.B) BandStore
+	}{
+		{"Memory", func(*testing.B) BandStore { return NewMemoryBandStore(bands) }},
+		{"Disk", func(b *testing.B) BandStore { return DiskBandStores(b.TempDir())(bands) }},
+	}
+	for _, s := range stores {
+		b.Run(s.name, func(b *testing.B) {
+			runtime.GC()
+			var before runtime.MemStats
+			runtime.ReadMemStats(&before)
+
+			rng := rand.New(rand.NewSource(1))
+			hashes := make([]uint64, products)
+			store := s.new(b)
+			started := time.Now()
+			for n := 0; n < products; n++ {
+				hashes[n] = rng.Uint64()
+				if n > 0 && rng.Intn(10) == 0 {
+					hashes[n] = hashes[rng.Intn(n)]
+				}
+				for band := 0; band < bands; band++ {
+					store.Append(band, hashes[n]+uint64(band), uint32(n))
+				}
+			}
+			if err := finishBandStore(store); err != nil {
+				b.Fatal(err)
+			}
+			build := time.Since(started)
+
+			runtime.GC()
+			var after runtime.MemStats
+			runtime.ReadMemStats(&after)
+			heap := int64(after.HeapAlloc) - int64(before.HeapAlloc) - int64(len(hashes)*8)
+
+			b.ResetTimer()
+			members := 0
+			for i := 0; i < b.N; i++ {
+				hash := hashes[rng.Intn(products)]
+				for band := 0; band < bands; band++ {
+					members += len(store.Get(band, hash+uint64(band)))
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(heap)/(1<<20), "heap-MB")
+			b.ReportMetric(build.Seconds(), "build-s")
+			if members == 0 {
+				b.Fatal("queries found no members")
+			}
+			runtime.KeepAlive(store)
+			if closer, ok := store.(interface{ Close() error }); ok {
+				closer.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package duplicatecheck
+
+import (
+	"context"
+	"math/bits"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// bestEffortMaxOrderedPairs caps how many pairs LevenshteinEngine.FindDuplicatesBestEffort
+// orders up front (8 bytes each, ~32MB). Larger scans run in plain pair order.
+const bestEffortMaxOrderedPairs = 4 << 20
+
+// FindDuplicatesBestEffort is FindDuplicates under a deadline: when ctx is done
+// it stops and returns what was verified so far, with complete set to false.
+//
+// Pairs are examined most likely first, ranked by a SimHash estimate of name
+// and description weighted like the engine, so a near-exact duplicate is found
+// early even if the scan is cut short. Scans above ~2,900 products skip the
+// ranking and run in index order. Partial results are deduplicated and sorted
+// like complete ones (most similar first). complete is true when the results
+// are exactly what FindDuplicates would return, including when the input is
+// rejected by the DuplicateIDPolicy (nil results).
+func (e *LevenshteinEngine) FindDuplicatesBestEffort(ctx context.Context, products []Product, threshold float64) ([]ComparisonResult, bool) {
+	defer e.guard("FindDuplicatesBestEffort", nil)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil, true
+	}
+	return e.findDuplicatesBestEffort(ctx, productPtrs(resolved), threshold)
+}
+
+// findDuplicatesBestEffort runs the ranked, interruptible scan without validating IDs
+func (e *LevenshteinEngine) findDuplicatesBestEffort(ctx context.Context, products []*Product, threshold float64) ([]ComparisonResult, bool) {
+	n := len(products)
+	if n < 2 {
+		return nil, true
+	}
+	if err := ctx.Err(); err != nil {
+		if e.logger != nil {
+			logScanInterrupted(e.logger, "levenshtein", err, 0)
+		}
+		return nil, false
+	}
+	warmCaches(products, e.preparer())
+
+	var pairs []bestEffortPair
+	if total := n * (n - 1) / 2; total <= bestEffortMaxOrderedPairs {
+		var ok bool
+		if pairs, ok = e.rankPairs(ctx, products); !ok {
+			if e.logger != nil {
+				logScanInterrupted(e.logger, "levenshtein", ctx.Err(), 0)
+			}
+			return nil, false
+		}
+	}
+	pairAt := func(k int) (int, int) {
+		if pairs != nil {
+			return int(pairs[k].i), int(pairs[k].j)
+		}
+		return unrankPair(k, n)
+	}
+	total := n * (n - 1) / 2
+
+	// Workers claim pairs in rank order, so the most likely pairs are verified
+	// first whatever the worker count
+	numWorkers := e.workerCount(n)
+	var (
+		next        int64 = -1
+		interrupted int32
+		mu          sync.Mutex
+		duplicates  []ComparisonResult
+		group       workerGroup
+	)
+	for w := 0; w < numWorkers; w++ {
+		group.Go(func() {
+			var found []ComparisonResult
+			for {
+				k := int(atomic.AddInt64(&next, 1))
+				if k >= total || group.stopped() {
+					break
+				}
+				if ctx.Err() != nil {
+					atomic.StoreInt32(&interrupted, 1)
+					break
+				}
+				i, j := pairAt(k)
+				if !e.pairAllowed(products[i], products[j]) {
+					continue
+				}
+				result := e.compareAt(products[i], products[j], threshold)
+				result.stampThreshold(threshold)
+				if result.MeetsThreshold {
+					found = append(found, result)
+				}
+			}
+			mu.Lock()
+			duplicates = append(duplicates, found...)
+			mu.Unlock()
+		})
+	}
+	group.Wait()
+
+	complete := atomic.LoadInt32(&interrupted) == 0
+	if !complete && e.logger != nil {
+		logScanInterrupted(e.logger, "levenshtein", ctx.Err(), len(duplicates))
+	}
+	return finishBestEffort(duplicates), complete
+}
+
+// bestEffortPair is a pair of product indices (i < j)
+type bestEffortPair struct {
+	i, j uint32
+}
+
+// rankPairs orders every pair by a weighted SimHash estimate, highest first
+// Estimates are quantized to 1/64 steps and counting-sorted, so ranking is
+// O(n²) with no comparisons. Returns false if ctx is done before ranking ends.
+func (e *LevenshteinEngine) rankPairs(ctx context.Context, products []*Product) ([]bestEffortPair, bool) {
+	simHash := newMixedSimHashFilter(3)
+	names := make([]SimHashFingerprint, len(products))
+	descs := make([]SimHashFingerprint, len(products))
+	prep := e.preparer()
+	for i, p := range products {
+		names[i] = simHash.fieldFingerprint(p, prep, ngramName)
+		descs[i] = simHash.fieldFingerprint(p, prep, ngramDescription)
+	}
+	if ctx.Err() != nil {
+		return nil, false
+	}
+
+	weights := e.weights.Normalized()
+	rank := func(i, j int) int {
+		// 64 - weighted Hamming distance: 64 for identical fingerprints
+		distance := weights.NameWeight*float64(bits.OnesCount64(uint64(names[i]^names[j]))) +
+			weights.DescriptionWeight*float64(bits.OnesCount64(uint64(descs[i]^descs[j])))
+		return 64 - int(distance+0.5)
+	}
+
+	var counts [65]int
+	for i := range products {
+		for j := i + 1; j < len(products); j++ {
+			counts[rank(i, j)]++
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, false
+	}
+
+	// Highest rank first
+	var offsets [65]int
+	for r, offset := 64, 0; r >= 0; r-- {
+		offsets[r] = offset
+		offset += counts[r]
+	}
+	pairs := make([]bestEffortPair, len(products)*(len(products)-1)/2)
+	for i := range products {
+		for j := i + 1; j < len(products); j++ {
+			r := rank(i, j)
+			pairs[offsets[r]] = bestEffortPair{i: uint32(i), j: uint32(j)}
+			offsets[r]++
+		}
+	}
+	return pairs, true
+}
+
+// unrankPair maps k in [0, n(n-1)/2) to the k-th pair (i < j) in row order
+func unrankPair(k, n int) (int, int) {
+	i := 0
+	for row := n - 1; k >= row; row-- {
+		k -= row
+		i++
+	}
+	return i, i + 1 + k
+}
+
+// FindDuplicatesBestEffort is FindDuplicates under a deadline: when ctx is done
+// it stops and returns what was verified so far, with complete set to false.
+//
+// LSH candidate pairs from every product are pooled and verified in order of
+// shared band collisions, strongest first, so likely duplicates are confirmed
+// before weak candidates. Without a built index this falls back to the
+// Levenshtein engine's best-effort scan. Partial results are deduplicated and
+// sorted like complete ones (most similar first); complete is true when the
+// results are exactly what FindDuplicates would return.
+func (e *HybridEngine) FindDuplicatesBestEffort(ctx context.Context, products []Product, threshold float64) ([]ComparisonResult, bool) {
+	defer e.levenshteinEngine.guard("FindDuplicatesBestEffort", nil)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil, true
+	}
+	ptrs := productPtrs(resolved)
+	idx := e.currentIndex()
+	if idx == nil {
+		return e.levenshteinEngine.findDuplicatesBestEffort(ctx, ptrs, threshold)
+	}
+
+	// Pool every candidate pair, each pair once
+	type pooledCandidate struct {
+		query      int
+		id         string
+		collisions int
+	}
+	var pool []pooledCandidate
+	queries := make([]hybridQuery, len(ptrs))
+	seen := make(map[string]bool)
+	for q, product := range ptrs {
+		if err := ctx.Err(); err != nil {
+			if e.logger != nil {
+				logScanInterrupted(e.logger, "hybrid", err, 0)
+			}
+			return nil, false
+		}
+		queries[q] = e.newQuery(product)
+		for _, candidate := range e.findCandidates(idx, product, threshold) {
+			if candidate.id == product.ID {
+				continue
+			}
+			pairKey := makePairKey(product.ID, candidate.id)
+			if seen[pairKey] {
+				continue
+			}
+			seen[pairKey] = true
+			pool = append(pool, pooledCandidate{query: q, id: candidate.id, collisions: candidate.collisions})
+		}
+	}
+	sort.SliceStable(pool, func(a, b int) bool {
+		return pool[a].collisions > pool[b].collisions
+	})
+
+	var duplicates []ComparisonResult
+	for _, candidate := range pool {
+		if err := ctx.Err(); err != nil {
+			if e.logger != nil {
+				logScanInterrupted(e.logger, "hybrid", err, len(duplicates))
+			}
+			return finishBestEffort(duplicates), false
+		}
+		result, ok := e.verifyCandidate(idx, ptrs[candidate.query], queries[candidate.query], candidate.id, threshold)
+		if !ok {
+			continue
+		}
+		result.stampThreshold(threshold)
+		if result.MeetsThreshold {
+			duplicates = append(duplicates, result)
+		}
+	}
+	return finishBestEffort(duplicates), true
+}
+
+// finishBestEffort drops repeated pairs and sorts results most similar first
+// Ties are broken by pair key so partial results are stable across runs.
+func finishBestEffort(results []ComparisonResult) []ComparisonResult {
+	seen := make(map[string]bool, len(results))
+	unique := results[:0]
+	for _, result := range results {
+		pairKey := makePairKey(result.ProductA.ID, result.ProductB.ID)
+		if seen[pairKey] {
+			continue
+		}
+		seen[pairKey] = true
+		unique = append(unique, result)
+	}
+	sort.Slice(unique, func(a, b int) bool {
+		if unique[a].CombinedSimilarity != unique[b].CombinedSimilarity {
+			return unique[a].CombinedSimilarity > unique[b].CombinedSimilarity
+		}
+		return makePairKey(unique[a].ProductA.ID, unique[a].ProductB.ID) < makePairKey(unique[b].ProductA.ID, unique[b].ProductB.ID)
+	})
+	return unique
+}
@@ -0,0 +1,144 @@
+package duplicatecheck
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countdownContext reports context.DeadlineExceeded once Err has been called budget times
+// Engines check Err once per unit of work, so this is a deterministic deadline
+type countdownContext struct {
+	context.Context
+	budget int64
+}
+
+func (c *countdownContext) Err() error {
+	if atomic.AddInt64(&c.budget, -1) < 0 {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// bestEffortCatalog returns products with a planted near-exact copy of the
+// first one at the end, where an unranked scan would reach it last
+// Neighbours share half their description, giving LSH weak candidates to rank below it.
+func bestEffortCatalog(n int) []Product {
+	rng := rand.New(rand.NewSource(3))
+	products := make([]Product, n)
+	shared := ""
+	for i := range products {
+		if i%2 == 1 {
+			shared = randomWordText(rng, 20)
+		}
+		products[i] = Product{
+			ID:          fmt.Sprintf("P%04d", i),
+			Name:        randomWordText(rng, 4),
+			Description: shared + " " + randomWordText(rng, 20),
+		}
+	}
+	products[n-1].Name = products[0].Name
+	products[n-1].Description = products[0].Description + "!"
+	return products
+}
+
+func TestFindDuplicatesBestEffort(t *testing.T) {
+	products := bestEffortCatalog(150)
+	const threshold = 0.85
+	planted := makePairKey(products[0].ID, products[len(products)-1].ID)
+
+	hybrid := NewHybridEngine()
+	if err := hybrid.BuildIndex(products); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	engines := []struct {
+		name   string
+		run    func(ctx context.Context) ([]ComparisonResult, bool)
+		full   func() []ComparisonResult
+		budget int64 // Err calls before the deadline: a handful of comparisons
+	}{
+		{
+			name: "Levenshtein",
+			run: func(ctx context.Context) ([]ComparisonResult, bool) {
+				return NewLevenshteinEngine().FindDuplicatesBestEffort(ctx, products, threshold)
+			},
+			full:   func() []ComparisonResult { return NewLevenshteinEngine().FindDuplicates(products, threshold) },
+			budget: 2 + 20, // Two ranking checks, then 20 pairs
+		},
+		{
+			name: "Hybrid",
+			run: func(ctx context.Context) ([]ComparisonResult, bool) {
+				return hybrid.FindDuplicatesBestEffort(ctx, products, threshold)
+			},
+			full:   func() []ComparisonResult { return hybrid.FindDuplicates(products, threshold) },
+			budget: int64(len(products)) + 1, // One check per query while pooling, then 1 candidate
+		},
+	}
+
+	for _, engine := range engines {
+		t.Run(engine.name, func(t *testing.T) {
+			t.Run("Tight deadline finds the planted pair first", func(t *testing.T) {
+				ctx := &countdownContext{Context: context.Background(), budget: engine.budget}
+				results, complete := engine.run(ctx)
+				if complete {
+					t.Fatal("Expected an incomplete scan")
+				}
+				if len(results) == 0 {
+					t.Fatal("Expected the planted duplicate in the partial results")
+				}
+				if key := makePairKey(results[0].ProductA.ID, results[0].ProductB.ID); key != planted {
+					t.Errorf("First result is %s, want the planted pair %s", key, planted)
+				}
+			})
+
+			t.Run("Expired deadline", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+				defer cancel()
+				if results, complete := engine.run(ctx); complete || len(results) != 0 {
+					t.Errorf("Expected nothing from an expired deadline, got %d results (complete=%v)", len(results), complete)
+				}
+			})
+
+			t.Run("Generous deadline is complete", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				defer cancel()
+				results, complete := engine.run(ctx)
+				if !complete {
+					t.Fatal("Expected a complete scan")
+				}
+				want := CanonicalizeResults(engine.full())
+				got := CanonicalizeResults(results)
+				if len(got) != len(want) {
+					t.Fatalf("Got %d results, FindDuplicates found %d", len(got), len(want))
+				}
+				for i := range got {
+					if got[i] != want[i] {
+						t.Errorf("Result %d: got %+v, want %+v", i, got[i], want[i])
+					}
+				}
+				for i := 1; i < len(results); i++ {
+					if results[i].CombinedSimilarity > results[i-1].CombinedSimilarity {
+						t.Fatal("Results should be sorted most similar first")
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestUnrankPair(t *testing.T) {
+	n := 7
+	k := 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if gi, gj := unrankPair(k, n); gi != i || gj != j {
+				t.Errorf("unrankPair(%d, %d) = (%d, %d), want (%d, %d)", k, n, gi, gj, i, j)
+			}
+			k++
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package duplicatecheck
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// BestMatchOptions controls FindBestMatchesWithOptions
+type BestMatchOptions struct {
+	// PerProduct is the number of matches kept per product (default 1)
+	PerProduct int
+	// Symmetric lists a pair kept by both of its products only under the
+	// lexicographically smaller product ID. By default such a pair appears
+	// under both IDs.
+	Symmetric bool
+}
+
+// FindBestMatches returns, for each product with a match at or above
+// threshold, its perProduct best matches, most similar first
+// A pair may be listed under both of its products' IDs, and a product never
+// matches itself; see FindBestMatchesWithOptions to list each pair once.
+func (e *LevenshteinEngine) FindBestMatches(products []Product, threshold float64, perProduct int) map[string][]ComparisonResult {
+	defer e.guard("FindBestMatches", nil)
+	return e.FindBestMatchesWithOptions(products, threshold, BestMatchOptions{PerProduct: perProduct})
+}
+
+// FindBestMatchesWithOptions is FindBestMatches with symmetry control
+// The scan is the one FindDuplicates runs, but each product keeps only a
+// heap of its best opts.PerProduct matches instead of every pair. Results are
+// keyed by product ID; a result's ProductA or ProductB is the keyed product.
+// Returns nil if the input is rejected by the DuplicateIDPolicy.
+func (e *LevenshteinEngine) FindBestMatchesWithOptions(products []Product, threshold float64, opts BestMatchOptions) map[string][]ComparisonResult {
+	defer e.guard("FindBestMatchesWithOptions", nil)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil
+	}
+	ptrs := productPtrs(resolved)
+	quality := e.newQualityCheck()
+	if quality != nil {
+		ptrs = quality.products(ptrs)
+	}
+
+	parallel := len(ptrs) > 50
+	if parallel {
+		warmCaches(ptrs, e.preparer())
+	}
+	memo := e.newScanMemo(ptrs)
+	window := e.newLengthWindow(ptrs, threshold)
+
+	best := newBestMatches(opts.PerProduct)
+	streamPairsWithin(len(ptrs), e.scanWorkers(len(ptrs), parallel), window, nil, func(i, j int, scratch *comparisonScratch) (ComparisonResult, bool) {
+		if !e.pairAllowed(ptrs[i], ptrs[j]) {
+			return ComparisonResult{}, false
+		}
+		result, ok := e.comparePair(ptrs, i, j, memo, scratch, threshold)
+		if !ok {
+			return ComparisonResult{}, false
+		}
+		result.stampThreshold(threshold)
+		return result, result.MeetsThreshold
+	}, func(result ComparisonResult) bool {
+		if quality != nil && !quality.flag(&result) && quality.mode == QualityExclude {
+			return true
+		}
+		best.add(result.ProductA.ID, result)
+		best.add(result.ProductB.ID, result)
+		return true
+	})
+	e.recordScan(window, len(ptrs))
+	return best.finish(opts.Symmetric)
+}
+
+// FindBestMatches returns, for each product with a match at or above
+// threshold, its perProduct best matches among the indexed products, most
+// similar first (see LevenshteinEngine.FindBestMatches)
+func (e *HybridEngine) FindBestMatches(products []Product, threshold float64, perProduct int) map[string][]ComparisonResult {
+	defer e.levenshteinEngine.guard("FindBestMatches", nil)
+	return e.FindBestMatchesWithOptions(products, threshold, BestMatchOptions{PerProduct: perProduct})
+}
+
+// FindBestMatchesWithOptions is FindBestMatches with symmetry control
+// Each product is queried against the index as in FindDuplicatesForOne and
+// keeps its best opts.PerProduct verified matches, so only the products
+// passed in are keys. Without a built index this falls back to the
+// Levenshtein scan.
+func (e *HybridEngine) FindBestMatchesWithOptions(products []Product, threshold float64, opts BestMatchOptions) map[string][]ComparisonResult {
+	defer e.levenshteinEngine.guard("FindBestMatchesWithOptions", nil)
+	idx := e.currentIndex()
+	if idx == nil {
+		return e.levenshteinEngine.FindBestMatchesWithOptions(products, threshold, opts)
+	}
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil
+	}
+	ptrs := productPtrs(resolved)
+	quality := e.levenshteinEngine.newQualityCheck()
+	if quality != nil {
+		ptrs = quality.products(ptrs)
+	}
+
+	best := newBestMatches(opts.PerProduct)
+	for _, product := range ptrs {
+		candidates := e.findCandidates(idx, product, threshold)
+		query := e.newQuery(product)
+		for _, candidate := range candidates {
+			if candidate.id == product.ID {
+				continue
+			}
+			result, ok := e.verifyCandidate(idx, product, query, candidate.id, threshold)
+			if !ok {
+				continue
+			}
+			result.stampThreshold(threshold)
+			if !result.MeetsThreshold {
+				continue
+			}
+			if quality != nil && !quality.flag(&result) && quality.mode == QualityExclude {
+				continue
+			}
+			best.add(product.ID, result)
+		}
+	}
+	return best.finish(opts.Symmetric)
+}
+
+// bestMatches keeps the best k results per product ID
+type bestMatches struct {
+	k     int
+	heaps map[string]*matchHeap
+}
+
+// newBestMatches keeps k results per product (at least 1)
+func newBestMatches(k int) *bestMatches {
+	if k < 1 {
+		k = 1
+	}
+	return &bestMatches{k: k, heaps: make(map[string]*matchHeap)}
+}
+
+// add offers result to id's heap, evicting its weakest match when full
+func (b *bestMatches) add(id string, result ComparisonResult) {
+	h := b.heaps[id]
+	if h == nil {
+		h = &matchHeap{key: id}
+		b.heaps[id] = h
+	}
+	if len(h.results) < b.k {
+		heap.Push(h, result)
+		return
+	}
+	if h.better(&result, &h.results[0]) {
+		h.results[0] = result
+		heap.Fix(h, 0)
+	}
+}
+
+// finish returns each product's matches, best first
+// With symmetric set, a pair kept under both IDs stays under the smaller one only.
+func (b *bestMatches) finish(symmetric bool) map[string][]ComparisonResult {
+	matches := make(map[string][]ComparisonResult, len(b.heaps))
+	for id, h := range b.heaps {
+		sort.Slice(h.results, func(i, j int) bool { return h.better(&h.results[i], &h.results[j]) })
+		matches[id] = h.results
+	}
+	if !symmetric {
+		return matches
+	}
+
+	for id, results := range matches {
+		kept := results[:0]
+		for _, result := range results {
+			other := matchPartner(&result, id)
+			if other < id && listsPartner(matches[other], id) {
+				continue // Listed under the smaller ID
+			}
+			kept = append(kept, result)
+		}
+		matches[id] = kept
+	}
+	for id, results := range matches {
+		if len(results) == 0 {
+			delete(matches, id)
+		}
+	}
+	return matches
+}
+
+// listsPartner reports whether results holds a match with product id
+// Matches are never removed from the smaller ID's list, so this is stable
+// while finish filters the larger IDs' lists.
+func listsPartner(results []ComparisonResult, id string) bool {
+	for i := range results {
+		if results[i].ProductA.ID == id || results[i].ProductB.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPartner returns the ID of the product result pairs with key
+func matchPartner(result *ComparisonResult, key string) string {
+	if result.ProductA.ID == key {
+		return result.ProductB.ID
+	}
+	return result.ProductA.ID
+}
+
+// matchHeap is a min-heap of one product's matches, weakest on top
+type matchHeap struct {
+	key     string
+	results []ComparisonResult
+}
+
+// better orders matches by similarity, then by partner ID for determinism
+func (h *matchHeap) better(a, b *ComparisonResult) bool {
+	if a.CombinedSimilarity != b.CombinedSimilarity {
+		return a.CombinedSimilarity > b.CombinedSimilarity
+	}
+	return matchPartner(a, h.key) < matchPartner(b, h.key)
+}
+
+func (h *matchHeap) Len() int           { return len(h.results) }
+func (h *matchHeap) Less(i, j int) bool { return h.better(&h.results[j], &h.results[i]) }
+func (h *matchHeap) Swap(i, j int)      { h.results[i], h.results[j] = h.results[j], h.results[i] }
+func (h *matchHeap) Push(x interface{}) { h.results = append(h.results, x.(ComparisonResult)) }
+func (h *matchHeap) Pop() interface{} {
+	last := h.results[len(h.results)-1]
+	h.results = h.results[:len(h.results)-1]
+	return last
+}
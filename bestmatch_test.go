@@ -0,0 +1,152 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// hubCatalog has one product with five graded near-duplicates and an unrelated pair
+func hubCatalog() []Product {
+	const desc = "Ergonomic wireless mouse with silent clicks and a USB receiver"
+	return []Product{
+		{ID: "HUB", Name: "Acme Wireless Mouse M100 Black", Description: desc},
+		{ID: "V1", Name: "Acme Wireless Mouse M100 Black.", Description: desc},
+		{ID: "V2", Name: "Acme Wireless Mouse M100 Blck", Description: desc},
+		{ID: "V3", Name: "Acme Wireless Mouse M100 Grey", Description: desc},
+		{ID: "V4", Name: "Acme Wireless Mouse M10 White", Description: desc},
+		{ID: "V5", Name: "Acme Wireless Mice M-100 Red", Description: desc},
+		{ID: "X1", Name: "Stainless Steel Water Bottle 750ml", Description: "Insulated bottle keeps drinks cold for 24 hours"},
+		{ID: "X2", Name: "Glass Teapot with Infuser 1L", Description: "Heat resistant borosilicate teapot for loose leaf tea"},
+	}
+}
+
+func TestFindBestMatches(t *testing.T) {
+	const threshold = 0.75
+	catalog := hubCatalog()
+
+	// The expected order comes from the full scan
+	var hubMatches []ComparisonResult
+	for _, r := range NewLevenshteinEngine().FindDuplicates(catalog, threshold) {
+		if r.ProductA.ID == "HUB" || r.ProductB.ID == "HUB" {
+			hubMatches = append(hubMatches, r)
+		}
+	}
+	if len(hubMatches) != 5 {
+		t.Fatalf("HUB has %d matches above %.2f, want 5", len(hubMatches), threshold)
+	}
+	hub := &matchHeap{key: "HUB"}
+	sort.Slice(hubMatches, func(i, j int) bool { return hub.better(&hubMatches[i], &hubMatches[j]) })
+	wantTop := []string{matchPartner(&hubMatches[0], "HUB"), matchPartner(&hubMatches[1], "HUB")}
+
+	hybrid := NewHybridEngine()
+	if err := hybrid.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	engines := []struct {
+		name string
+		find func(products []Product, threshold float64, opts BestMatchOptions) map[string][]ComparisonResult
+	}{
+		{"levenshtein", NewLevenshteinEngine().FindBestMatchesWithOptions},
+		{"hybrid", hybrid.FindBestMatchesWithOptions},
+		{"hybrid without index", NewHybridEngine().FindBestMatchesWithOptions},
+	}
+	for _, engine := range engines {
+		t.Run(engine.name, func(t *testing.T) {
+			matches := engine.find(catalog, threshold, BestMatchOptions{PerProduct: 2})
+			hub := matches["HUB"]
+			if len(hub) != 2 {
+				t.Fatalf("HUB kept %d matches, want 2", len(hub))
+			}
+			got := []string{matchPartner(&hub[0], "HUB"), matchPartner(&hub[1], "HUB")}
+			if !reflect.DeepEqual(got, wantTop) {
+				t.Errorf("HUB best matches = %v, want %v", got, wantTop)
+			}
+			if hub[0].CombinedSimilarity < hub[1].CombinedSimilarity {
+				t.Error("matches are not sorted best first")
+			}
+			for id, results := range matches {
+				if len(results) > 2 {
+					t.Errorf("%s kept %d matches", id, len(results))
+				}
+				for _, r := range results {
+					if r.ProductA.ID == r.ProductB.ID {
+						t.Errorf("%s matched itself", id)
+					}
+					if r.ProductA.ID != id && r.ProductB.ID != id {
+						t.Errorf("%s lists the pair %s/%s", id, r.ProductA.ID, r.ProductB.ID)
+					}
+					if r.CombinedSimilarity < threshold {
+						t.Errorf("%s kept a match at %.3f", id, r.CombinedSimilarity)
+					}
+				}
+			}
+			for _, id := range []string{"X1", "X2"} {
+				if _, ok := matches[id]; ok {
+					t.Errorf("%s has matches", id)
+				}
+			}
+
+			// Every variant's best match is listed under its own key too
+			if len(matches["V1"]) == 0 {
+				t.Error("V1 has no matches")
+			}
+
+			t.Run("symmetric", func(t *testing.T) {
+				symmetric := engine.find(catalog, threshold, BestMatchOptions{PerProduct: 2, Symmetric: true})
+				seen := make(map[string]string)
+				for id, results := range symmetric {
+					if len(results) == 0 {
+						t.Errorf("%s has an empty list", id)
+					}
+					for _, r := range results {
+						key := PairKey(r.ProductA.ID, r.ProductB.ID)
+						if other, dup := seen[key]; dup {
+							t.Errorf("pair %s listed under %s and %s", key, other, id)
+						}
+						seen[key] = id
+					}
+				}
+				for key, id := range seen {
+					if !listsPair(matches[id], key) {
+						t.Errorf("pair %s under %s is not in the default result", key, id)
+					}
+				}
+				for id, results := range matches {
+					for _, r := range results {
+						other := matchPartner(&r, id)
+						if _, ok := seen[PairKey(id, other)]; !ok {
+							t.Errorf("pair %s/%s was dropped", id, other)
+						}
+					}
+				}
+			})
+		})
+	}
+
+	t.Run("per product defaults to one", func(t *testing.T) {
+		matches := NewLevenshteinEngine().FindBestMatches(catalog, threshold, 0)
+		if len(matches["HUB"]) != 1 || matchPartner(&matches["HUB"][0], "HUB") != wantTop[0] {
+			t.Errorf("HUB = %v, want only %s", resultPairs(matches["HUB"]), wantTop[0])
+		}
+	})
+
+	t.Run("parallel scan", func(t *testing.T) {
+		large := append(lengthDiverseCatalog(120), catalog...)
+		matches := NewLevenshteinEngine().FindBestMatches(large, threshold, 2)
+		got := []string{matchPartner(&matches["HUB"][0], "HUB"), matchPartner(&matches["HUB"][1], "HUB")}
+		if !reflect.DeepEqual(got, wantTop) {
+			t.Errorf("HUB best matches = %v, want %v", got, wantTop)
+		}
+	})
+}
+
+// listsPair reports whether results holds the pair with the given PairKey
+func listsPair(results []ComparisonResult, key string) bool {
+	for _, r := range results {
+		if PairKey(r.ProductA.ID, r.ProductB.ID) == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,325 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultBoilerplateRatio is the default LearnOptions.MinDocumentRatio
+const DefaultBoilerplateRatio = 0.2
+
+// DefaultBoilerplateMinDocuments is the default LearnOptions.MinDocuments
+const DefaultBoilerplateMinDocuments = 5
+
+// LearnOptions configures BoilerplateLearner.Fit
+type LearnOptions struct {
+	// MinDocumentRatio is the fraction of descriptions a chunk must appear in
+	// to be boilerplate (0 = DefaultBoilerplateRatio)
+	MinDocumentRatio float64
+	// MinDocuments is the fewest descriptions a chunk must appear in whatever
+	// the ratio, so text a handful of duplicates share is never learned
+	// (0 = DefaultBoilerplateMinDocuments)
+	MinDocuments int
+	// PhraseWords also learns frequent phrases of this many words inside the
+	// remaining chunks, such as a slogan embedded in a sentence (0 = chunks only)
+	PhraseWords int
+}
+
+// BoilerplateLearner learns the text a catalog's descriptions share and that
+// carries no duplicate signal ("Free shipping on all orders", a legal footer)
+// The zero value is ready to use.
+type BoilerplateLearner struct{}
+
+// Fit builds a BoilerplateModel from the descriptions of products
+// Descriptions are split into chunks at line breaks and sentence ends, and a
+// chunk is boilerplate when it appears in at least MinDocumentRatio of the
+// descriptions and at least MinDocuments of them. Chunks are matched
+// case-insensitively on their words, ignoring punctuation and spacing. With
+// PhraseWords, runs of that many words appearing as often are learned too.
+// Fitting is corpus-dependent: refit when the catalog changes, and use the
+// model through TextPreparation.Boilerplate (see WithBoilerplate).
+func (BoilerplateLearner) Fit(products []Product, opts LearnOptions) *BoilerplateModel {
+	ratio := opts.MinDocumentRatio
+	if ratio <= 0 {
+		ratio = DefaultBoilerplateRatio
+	}
+	minDocuments := opts.MinDocuments
+	if minDocuments <= 0 {
+		minDocuments = DefaultBoilerplateMinDocuments
+	}
+	model := &BoilerplateModel{chunks: make(map[string]bool), phrases: make(map[string]bool), documents: len(products)}
+	if opts.PhraseWords > 0 {
+		model.phraseWords = opts.PhraseWords
+	}
+	frequent := func(documents int) bool {
+		return documents >= minDocuments && float64(documents) >= ratio*float64(len(products))
+	}
+
+	// Document frequency of each chunk, counted once per description
+	chunks := make([][]boilerplateChunk, len(products))
+	frequencies := make(map[string]int)
+	for i := range products {
+		chunks[i] = splitBoilerplateChunks(products[i].Description)
+		seen := make(map[string]bool, len(chunks[i]))
+		for _, chunk := range chunks[i] {
+			if !seen[chunk.key] {
+				seen[chunk.key] = true
+				frequencies[chunk.key]++
+			}
+		}
+	}
+	for key, documents := range frequencies {
+		if frequent(documents) {
+			model.chunks[key] = true
+		}
+	}
+	if model.phraseWords == 0 {
+		return model
+	}
+
+	// Phrases inside the chunks that are kept, never spanning two chunks
+	frequencies = make(map[string]int)
+	for i := range products {
+		seen := make(map[string]bool)
+		for _, chunk := range chunks[i] {
+			if model.chunks[chunk.key] {
+				continue
+			}
+			words := strings.Fields(chunk.key)
+			for start := 0; start+model.phraseWords <= len(words); start++ {
+				phrase := strings.Join(words[start:start+model.phraseWords], " ")
+				if !seen[phrase] {
+					seen[phrase] = true
+					frequencies[phrase]++
+				}
+			}
+		}
+	}
+	for phrase, documents := range frequencies {
+		if frequent(documents) {
+			model.phrases[phrase] = true
+		}
+	}
+	return model
+}
+
+// BoilerplateModel removes learned boilerplate from descriptions (see
+// BoilerplateLearner)
+// A model is not changed after Fit, so engines share it rather than copy it.
+// It encodes to and from JSON, to be stored with the catalog it was fitted on.
+type BoilerplateModel struct {
+	chunks      map[string]bool // Keys of boilerplate chunks
+	phrases     map[string]bool // Boilerplate phrases, phraseWords words each
+	phraseWords int
+	documents   int // Descriptions the model was fitted on
+}
+
+// boilerplateModelJSON is the encoded form of a BoilerplateModel
+type boilerplateModelJSON struct {
+	Chunks      []string `json:"chunks"`
+	Phrases     []string `json:"phrases,omitempty"`
+	PhraseWords int      `json:"phrase_words,omitempty"`
+	Documents   int      `json:"documents"`
+}
+
+// Chunks returns the boilerplate chunks, lowercased words separated by spaces, sorted
+func (m *BoilerplateModel) Chunks() []string {
+	return sortedKeys(m.chunks)
+}
+
+// Phrases returns the boilerplate phrases, sorted
+func (m *BoilerplateModel) Phrases() []string {
+	return sortedKeys(m.phrases)
+}
+
+// sortedKeys returns the keys of set in increasing order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Fingerprint identifies the model's boilerplate; equal models give equal fingerprints
+func (m *BoilerplateModel) Fingerprint() uint64 {
+	fields := []string{"boilerplate/v1", strconv.Itoa(m.phraseWords)}
+	fields = append(fields, m.Chunks()...)
+	fields = append(fields, "")
+	fields = append(fields, m.Phrases()...)
+	return contentFingerprint(fields...)
+}
+
+// MarshalJSON encodes the model with its chunks and phrases sorted
+func (m *BoilerplateModel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(boilerplateModelJSON{
+		Chunks:      m.Chunks(),
+		Phrases:     m.Phrases(),
+		PhraseWords: m.phraseWords,
+		Documents:   m.documents,
+	})
+}
+
+// UnmarshalJSON replaces the model with an encoded one
+func (m *BoilerplateModel) UnmarshalJSON(data []byte) error {
+	var encoded boilerplateModelJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	*m = BoilerplateModel{
+		chunks:      make(map[string]bool, len(encoded.Chunks)),
+		phrases:     make(map[string]bool, len(encoded.Phrases)),
+		phraseWords: encoded.PhraseWords,
+		documents:   encoded.Documents,
+	}
+	for _, chunk := range encoded.Chunks {
+		m.chunks[chunk] = true
+	}
+	for _, phrase := range encoded.Phrases {
+		m.phrases[phrase] = true
+	}
+	return nil
+}
+
+// Strip removes the boilerplate chunks and phrases from text
+// Other chunks are kept as written, with the spacing that preceded them; a
+// chunk losing a phrase has its remaining words joined by single spaces.
+func (m *BoilerplateModel) Strip(text string) string {
+	if m == nil || (len(m.chunks) == 0 && len(m.phrases) == 0) {
+		return text
+	}
+	chunks := splitBoilerplateChunks(text)
+	var b strings.Builder
+	last, stripped := 0, false // End of the previous chunk, kept or not
+	for _, chunk := range chunks {
+		separator := text[last:chunk.start]
+		last = chunk.end
+		kept := text[chunk.start:chunk.end]
+		if m.chunks[chunk.key] {
+			stripped = true
+			continue
+		}
+		if phraseless, changed := m.stripPhrases(kept); changed {
+			stripped = true
+			if kept = phraseless; kept == "" {
+				continue
+			}
+		}
+		if b.Len() > 0 {
+			b.WriteString(separator)
+		}
+		b.WriteString(kept)
+	}
+	if !stripped {
+		return text
+	}
+	return b.String()
+}
+
+// stripPhrases removes the boilerplate phrases from one chunk, reporting
+// whether any was found
+func (m *BoilerplateModel) stripPhrases(chunk string) (string, bool) {
+	if m.phraseWords == 0 || len(m.phrases) == 0 {
+		return chunk, false
+	}
+	tokens := synonymTokens(chunk)
+	covered := make([]bool, len(tokens))
+	found := false
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = strings.ToLower(token.text)
+	}
+	for start := 0; start+m.phraseWords <= len(words); start++ {
+		if m.phrases[strings.Join(words[start:start+m.phraseWords], " ")] {
+			found = true
+			for i := start; i < start+m.phraseWords; i++ {
+				covered[i] = true
+			}
+		}
+	}
+	if !found {
+		return chunk, false
+	}
+
+	// Keep the text of each uncovered token along with the punctuation
+	// attached to it, up to the next token
+	var kept []string
+	for i, token := range tokens {
+		if covered[i] {
+			continue
+		}
+		end := len(chunk)
+		if i+1 < len(tokens) {
+			end = tokens[i+1].start
+		}
+		kept = append(kept, strings.TrimSpace(chunk[token.start:end]))
+	}
+	return strings.Join(kept, " "), true
+}
+
+// boilerplateChunk is one line or sentence of a description
+type boilerplateChunk struct {
+	start, end int    // Byte offsets in the description, surrounding spaces excluded
+	key        string // Lowercased words separated by spaces
+}
+
+// splitBoilerplateChunks splits text at line breaks and at sentence ends
+// ('.', '!' or '?' followed by a space), skipping chunks without words
+func splitBoilerplateChunks(text string) []boilerplateChunk {
+	var chunks []boilerplateChunk
+	add := func(start, end int) {
+		for start < end && unicode.IsSpace(rune(text[start])) {
+			start++
+		}
+		for end > start && unicode.IsSpace(rune(text[end-1])) {
+			end--
+		}
+		tokens := synonymTokens(text[start:end])
+		if len(tokens) == 0 {
+			return
+		}
+		words := make([]string, len(tokens))
+		for i, token := range tokens {
+			words[i] = strings.ToLower(token.text)
+		}
+		chunks = append(chunks, boilerplateChunk{start: start, end: end, key: strings.Join(words, " ")})
+	}
+
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\n':
+			add(start, i)
+			start = i + 1
+		case '.', '!', '?':
+			if i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t' || text[i+1] == '\r' || text[i+1] == '\n' {
+				add(start, i+1)
+				start = i + 1
+			}
+		}
+	}
+	add(start, len(text))
+	return chunks
+}
+
+// WithBoilerplate makes the engine strip model's boilerplate from descriptions
+// before comparing them, by setting TextPreparation.Boilerplate (nil turns it
+// off). Returns the engine for chaining.
+func (e *LevenshteinEngine) WithBoilerplate(model *BoilerplateModel) *LevenshteinEngine {
+	options := e.GetTextPreparation()
+	options.Boilerplate = model
+	return e.WithTextPreparation(options)
+}
+
+// WithBoilerplate makes the engine strip boilerplate before indexing and
+// verification (see LevenshteinEngine.WithBoilerplate). Any built index is
+// discarded. Returns the engine for chaining.
+func (e *HybridEngine) WithBoilerplate(model *BoilerplateModel) *HybridEngine {
+	options := e.GetTextPreparation()
+	options.Boilerplate = model
+	return e.WithTextPreparation(options)
+}
@@ -0,0 +1,214 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// boilerplateFooter is the legal footer injected into footerCatalog
+const boilerplateFooter = "Free shipping on all orders over $50. " +
+	"30-day money-back guarantee, no questions asked. " +
+	"Sold by Acme Retail LLC, 100 Market Street, Springfield."
+
+// footerCatalog returns 100 unrelated products and 20 duplicates of the
+// first 20, with the footer appended to 40% of the descriptions
+// A duplicate is another merchant's listing: only one of a pair carries the footer.
+func footerCatalog() []Product {
+	words := strings.Fields("wireless compact steel cotton leather portable heavy duty " +
+		"ergonomic waterproof rechargeable classic modern vintage premium lightweight " +
+		"adjustable foldable digital analog ceramic bamboo titanium carbon organic " +
+		"insulated magnetic solar manual automatic smart silent travel outdoor kitchen")
+	rng := rand.New(rand.NewSource(3))
+	sentence := func(n int) string {
+		picked := make([]string, n)
+		for i := range picked {
+			picked[i] = words[rng.Intn(len(words))]
+		}
+		return strings.Join(picked, " ")
+	}
+
+	var products []Product
+	for i := 0; i < 100; i++ {
+		products = append(products, Product{
+			ID:          fmt.Sprintf("p%03d", i),
+			Name:        fmt.Sprintf("Item %03d %s", i, sentence(2)),
+			Description: sentence(8) + ". " + sentence(6) + ".",
+		})
+	}
+	for i := 0; i < 20; i++ {
+		dup := products[i]
+		dup.ID += "-dup"
+		dup.Description = strings.Replace(dup.Description, ".", "!", 1)
+		products = append(products, dup)
+	}
+	// 48 of 120 descriptions: products 20-59 and the duplicates of 0-7
+	for i := range products {
+		if (i >= 20 && i < 60) || (i >= 100 && i < 108) {
+			products[i].Description += "\n" + boilerplateFooter
+		}
+	}
+	return products
+}
+
+func TestBoilerplateLearnerFit(t *testing.T) {
+	products := footerCatalog()
+
+	tests := []struct {
+		name   string
+		opts   LearnOptions
+		chunks int
+	}{
+		{"defaults", LearnOptions{}, 3},
+		{"ratio above the footer", LearnOptions{MinDocumentRatio: 0.5}, 0},
+		{"too few documents", LearnOptions{MinDocuments: 49}, 0},
+		{"phrases", LearnOptions{PhraseWords: 3}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := BoilerplateLearner{}.Fit(products, tt.opts)
+			if got := model.Chunks(); len(got) != tt.chunks {
+				t.Errorf("chunks %q, want %d", got, tt.chunks)
+			}
+			// Product descriptions share no phrase outside the footer
+			if got := model.Phrases(); len(got) != 0 {
+				t.Errorf("phrases %q", got)
+			}
+		})
+	}
+
+	model := BoilerplateLearner{}.Fit(products, LearnOptions{})
+	want := []string{
+		"30 day money back guarantee no questions asked",
+		"free shipping on all orders over 50",
+		"sold by acme retail llc 100 market street springfield",
+	}
+	if got := model.Chunks(); !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks %q, want %q", got, want)
+	}
+}
+
+func TestBoilerplateStrip(t *testing.T) {
+	model := BoilerplateLearner{}.Fit([]Product{
+		{Description: "Red mug. Free shipping! Ships in 2 days."},
+		{Description: "Blue bowl.\nFREE SHIPPING\nShips in 2 days."},
+		{Description: "Green plate, free shipping. Dishwasher safe."},
+		{Description: "Gray jug with free shipping included."},
+	}, LearnOptions{MinDocuments: 2, MinDocumentRatio: 0.5, PhraseWords: 2})
+
+	tests := []struct {
+		text, want string
+	}{
+		{"Red mug. Free shipping! Ships in 2 days.", "Red mug."},
+		{"Yellow cup.\n\nFree  shipping.\nHandmade.", "Yellow cup.\nHandmade."},
+		{"Free shipping. Teapot.", "Teapot."},
+		{"Teapot with free shipping, and a lid.", "Teapot with and a lid."},
+		{"Nothing to strip here.", "Nothing to strip here."},
+		{"Free shipping.", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := model.Strip(tt.text); got != tt.want {
+			t.Errorf("Strip(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+	if got := (*BoilerplateModel)(nil).Strip("Free shipping."); got != "Free shipping." {
+		t.Errorf("nil model stripped to %q", got)
+	}
+}
+
+func TestBoilerplateScores(t *testing.T) {
+	products := footerCatalog()
+	model := BoilerplateLearner{}.Fit(products, LearnOptions{})
+	plain, stripped := NewLevenshteinEngine(), NewLevenshteinEngine().WithBoilerplate(model)
+	plain.DisableRabinKarpFilter() // Score every pair, however far apart
+	stripped.DisableRabinKarpFilter()
+	descriptions := func(engine *LevenshteinEngine, a, b Product) float64 {
+		return engine.Compare(a, b).DescriptionSimilarity
+	}
+
+	// Unrelated products sharing only the footer
+	for i := 20; i < 40; i++ {
+		a, b := products[i], products[i+20]
+		before, after := descriptions(plain, a, b), descriptions(stripped, a, b)
+		if after > before-0.3 {
+			t.Errorf("%s/%s: %.3f before stripping, %.3f after", a.ID, b.ID, before, after)
+		}
+	}
+	// True duplicates, with and without a footer on one side
+	for i := 0; i < 20; i++ {
+		a, b := products[i], products[100+i]
+		before, after := descriptions(plain, a, b), descriptions(stripped, a, b)
+		if after < before-1e-9 {
+			t.Errorf("%s/%s: %.3f before stripping, %.3f after", a.ID, b.ID, before, after)
+		}
+		if i < 8 && after < 0.9 {
+			t.Errorf("%s/%s: footer on one side, %.3f before stripping, %.3f after", a.ID, b.ID, before, after)
+		}
+	}
+
+	// Hybrid verification and shingling strip it too
+	hybrid := NewHybridEngine().WithBoilerplate(model)
+	if got, want := hybrid.Compare(products[20], products[40]), stripped.Compare(products[20], products[40]); got.CombinedSimilarity != want.CombinedSimilarity {
+		t.Errorf("hybrid scored %v, Levenshtein %v", got.CombinedSimilarity, want.CombinedSimilarity)
+	}
+	if text := hybrid.indexText(&products[20]); strings.Contains(text, "acme") {
+		t.Errorf("index text kept the footer: %q", text)
+	}
+}
+
+func TestBoilerplateModelConfig(t *testing.T) {
+	products := footerCatalog()
+	model := BoilerplateLearner{}.Fit(products, LearnOptions{PhraseWords: 3})
+
+	// JSON round trip
+	data, err := json.Marshal(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded BoilerplateModel
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Fingerprint() != model.Fingerprint() || !reflect.DeepEqual(decoded.Chunks(), model.Chunks()) {
+		t.Errorf("round trip changed the model: %s", data)
+	}
+	other := BoilerplateLearner{}.Fit(products, LearnOptions{MinDocumentRatio: 0.5})
+	if other.Fingerprint() == model.Fingerprint() {
+		t.Error("different models share a fingerprint")
+	}
+
+	// The engine config records the model and its fingerprint
+	engine := NewLevenshteinEngine().WithBoilerplate(model)
+	cfg := engine.Config()
+	if cfg.BoilerplateFingerprint == "" || cfg.TextPreparation.Boilerplate == nil {
+		t.Fatalf("config %+v has no boilerplate", cfg)
+	}
+	if NewLevenshteinEngine().ConfigFingerprint() == engine.ConfigFingerprint() {
+		t.Error("boilerplate did not change the config fingerprint")
+	}
+	data, err = json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored EngineConfig
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, err := NewEngineFromConfig(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rebuilt.Compare(products[20], products[40]), engine.Compare(products[20], products[40]); got.CombinedSimilarity != want.CombinedSimilarity {
+		t.Errorf("rebuilt engine scored %v, original %v", got.CombinedSimilarity, want.CombinedSimilarity)
+	}
+
+	// A config whose model was swapped is rejected
+	restored.TextPreparation.Boilerplate = other
+	if _, err := NewEngineFromConfig(restored); err == nil {
+		t.Error("mismatched boilerplate model accepted")
+	}
+}
@@ -0,0 +1,91 @@
+package duplicatecheck
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// bucketHash returns the key band hash is stored under in idx.bands
+// Entries, oplog records and queries keep the unsalted band hashes; only the
+// bucket store sees salted keys, so two indexes over the same catalog share no
+// bucket key while every pair collides in exactly the same bands.
+//
+// The salt is deliberately kept out of shingle hashing and MinHash: salted
+// signatures would change which pairs collide, and so each index's results.
+// For the same reason no query is prepared against a salt: queries carry
+// unsalted band hashes, salted here by the index they look up. The salt is
+// part of IndexFingerprint, and the places a salted index meets another's
+// data check it: LoadIndex against a configured BucketSalt, ApplyOplog
+// against the salt the log was recorded on.
+func (idx *LSHIndex) bucketHash(bandHash uint64) uint64 {
+	if idx.salt == 0 {
+		return bandHash // Index files from before salting
+	}
+	return mix64(bandHash ^ idx.salt)
+}
+
+// newBucketSalt returns the bucket salt of a new index: the configured
+// HybridConfig.BucketSalt, or a random non-zero one (derived from the LSH
+// seed in deterministic mode)
+func (e *HybridEngine) newBucketSalt() uint64 {
+	if e.bucketSalt != 0 {
+		return e.bucketSalt
+	}
+	if e.levenshteinEngine.deterministic {
+		return e.deterministicBucketSalt()
+	}
+	var b [8]byte
+	for {
+		_, _ = rand.Read(b[:]) // crypto/rand.Read never fails on supported platforms
+		if salt := binary.LittleEndian.Uint64(b[:]); salt != 0 {
+			return salt
+		}
+	}
+}
+
+// WithBucketSalt sets the bucket hash salt of the indexes built from now on
+// (see HybridConfig.BucketSalt; 0 draws a random salt per index). The current
+// index keeps its salt, and LoadIndex then only accepts files with this salt.
+// Returns the engine for chaining.
+func (e *HybridEngine) WithBucketSalt(salt uint64) *HybridEngine {
+	e.bucketSalt = salt
+	return e
+}
+
+// GetBucketSalt returns the bucket hash salt of the current index (0 if no
+// index is built, or it was loaded from a file written before salting)
+func (e *HybridEngine) GetBucketSalt() uint64 {
+	idx := e.currentIndex()
+	if idx == nil {
+		return 0
+	}
+	return idx.salt
+}
+
+// formatBucketSalt is SnapshotConfig.BucketSalt: the salt in hexadecimal,
+// empty when unsalted
+func formatBucketSalt(salt uint64) string {
+	if salt == 0 {
+		return ""
+	}
+	return strconv.FormatUint(salt, 16)
+}
+
+// parseBucketSalt reads the salt of a saved index's config, checking it
+// against the salt the engine is configured with, if any
+func (e *HybridEngine) parseBucketSalt(config SnapshotConfig) (uint64, error) {
+	var salt uint64
+	if config.BucketSalt != "" {
+		var err error
+		if salt, err = strconv.ParseUint(config.BucketSalt, 16, 64); err != nil {
+			return 0, fmt.Errorf("%w: bad bucket salt %q", ErrInvalidIndexFile, config.BucketSalt)
+		}
+	}
+	if e.bucketSalt != 0 && salt != e.bucketSalt {
+		return 0, fmt.Errorf("%w: index has bucket salt %x, engine is configured with %x",
+			ErrIncompatibleIndex, salt, e.bucketSalt)
+	}
+	return salt, nil
+}
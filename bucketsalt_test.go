@@ -0,0 +1,157 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// bucketMembers lists the members of every bucket of idx per band, sorted, so
+// indexes can be compared whatever their bucket salt
+func bucketMembers(idx *LSHIndex) [][]string {
+	members := make([][]string, idx.numBands)
+	for band := 0; band < idx.numBands; band++ {
+		idx.bands.ForEach(band, func(_ uint64, numbers []uint32) bool {
+			ids := make([]string, 0, len(numbers))
+			for _, n := range numbers {
+				if !idx.removed[n] {
+					ids = append(ids, idx.refs[n])
+				}
+			}
+			sort.Strings(ids)
+			members[band] = append(members[band], strings.Join(ids, ","))
+			return true
+		})
+		sort.Strings(members[band])
+	}
+	return members
+}
+
+// bucketKeys returns the set of bucket keys of idx, across bands
+func bucketKeys(idx *LSHIndex) map[uint64]bool {
+	keys := make(map[uint64]bool)
+	for band := 0; band < idx.numBands; band++ {
+		idx.bands.ForEach(band, func(hash uint64, _ []uint32) bool {
+			keys[hash] = true
+			return true
+		})
+	}
+	return keys
+}
+
+func TestBucketSalt(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 150)
+	build := func(engine *HybridEngine) *HybridEngine {
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatal(err)
+		}
+		return engine
+	}
+	a, b := build(NewHybridEngine()), build(NewHybridEngine())
+
+	if a.GetBucketSalt() == 0 || a.GetBucketSalt() == b.GetBucketSalt() {
+		t.Fatalf("random salts %x and %x", a.GetBucketSalt(), b.GetBucketSalt())
+	}
+	keysA := bucketKeys(a.currentIndex())
+	for key := range bucketKeys(b.currentIndex()) {
+		if keysA[key] {
+			t.Fatalf("bucket key %x in both indexes", key)
+		}
+	}
+	if !reflect.DeepEqual(bucketMembers(a.currentIndex()), bucketMembers(b.currentIndex())) {
+		t.Error("salts changed which products share buckets")
+	}
+	if a.IndexConfigFingerprint() != b.IndexConfigFingerprint() || a.IndexFingerprint() == b.IndexFingerprint() {
+		t.Error("the salt belongs in IndexFingerprint only")
+	}
+	// Queries carry no salt: each index salts them on lookup
+	query := catalog[0]
+	query.ID = "query"
+	if !reflect.DeepEqual(a.queryBandHashes(a.currentIndex(), &query), b.queryBandHashes(b.currentIndex(), &query)) {
+		t.Error("query band hashes depend on the salt")
+	}
+	if got, want := candidateIDs(b, catalog[:50], 0.75), candidateIDs(a, catalog[:50], 0.75); !reflect.DeepEqual(got, want) {
+		t.Error("FindDuplicatesForOne results depend on the salt")
+	}
+	if got, want := comparableResults(b.FindDuplicates(catalog, 0.75)), comparableResults(a.FindDuplicates(catalog, 0.75)); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindDuplicates: %d results with one salt, %d with another", len(got), len(want))
+	}
+
+	// A configured salt is used by every build
+	config := DefaultHybridConfig()
+	config.BucketSalt = a.GetBucketSalt()
+	pinned := build(NewHybridEngineWithConfig(config))
+	if pinned.IndexFingerprint() != a.IndexFingerprint() || !reflect.DeepEqual(pinned.currentIndex().bands, a.currentIndex().bands) {
+		t.Error("the same salt built a different index")
+	}
+	if got := pinned.Config().Hybrid.HybridConfig.BucketSalt; got != config.BucketSalt {
+		t.Errorf("Config() salt %x, want %x", got, config.BucketSalt)
+	}
+}
+
+func TestBucketSaltPersistence(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 100)
+	built := NewHybridEngine()
+	if err := built.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	var file bytes.Buffer
+	if err := built.SaveIndex(&file); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewHybridEngine()
+	if err := loaded.LoadIndex(bytes.NewReader(file.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.GetBucketSalt() != built.GetBucketSalt() || loaded.IndexFingerprint() != built.IndexFingerprint() {
+		t.Errorf("loaded salt %x, saved %x", loaded.GetBucketSalt(), built.GetBucketSalt())
+	}
+	sameIndexResults(t, built, loaded, catalog)
+	// Products added after loading land in the buckets of the saved salt
+	extra := catalog[0]
+	extra.ID = "extra"
+	if err := loaded.AddProduct(extra); err != nil {
+		t.Fatal(err)
+	}
+	if ids := matchedIDs(loaded.FindDuplicatesForOne(catalog[0], 0.9)); !ids["extra"] {
+		t.Errorf("product added to the loaded index not found: %v", ids)
+	}
+
+	// An engine configured with another salt refuses the file
+	err := NewHybridEngine().WithBucketSalt(built.GetBucketSalt() + 1).LoadIndex(bytes.NewReader(file.Bytes()))
+	if !errors.Is(err, ErrIncompatibleIndex) || !strings.Contains(err.Error(), "bucket salt") {
+		t.Errorf("LoadIndex with another configured salt: %v, want ErrIncompatibleIndex", err)
+	}
+}
+
+func TestBucketSaltOplog(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 200)
+	primary := NewHybridEngine()
+	if err := primary.BuildIndex(catalog[:150]); err != nil {
+		t.Fatal(err)
+	}
+	var log bytes.Buffer
+	primary.WithOplog(&log)
+	for _, p := range catalog[150:] {
+		if err := primary.AddProduct(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The same products indexed under another salt are not the log's base
+	other := NewHybridEngine()
+	if err := other.BuildIndex(catalog[:150]); err != nil {
+		t.Fatal(err)
+	}
+	err := other.ApplyOplog(bytes.NewReader(log.Bytes()))
+	if !errors.Is(err, ErrOplogBaseMismatch) || !strings.Contains(err.Error(), "bucket salt") {
+		t.Errorf("ApplyOplog onto another salt: %v, want ErrOplogBaseMismatch naming the salt", err)
+	}
+	if other.IndexedCount() != 150 {
+		t.Errorf("rejected log changed the index: %d products", other.IndexedCount())
+	}
+}
@@ -0,0 +1,119 @@
+package duplicatecheck
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// BundleSplitter decomposes a prepared (normalized) product name into the
+// names of the items it bundles, such as "iphone 14 + airpods pro + case"
+// into "iphone 14", "airpods pro" and "case"
+// A name with fewer than two components is not a bundle.
+type BundleSplitter func(name string) []string
+
+// bundleSeparatorPattern matches the separators DefaultBundleSplitter splits on
+var bundleSeparatorPattern = regexp.MustCompile(`(?i)\s*(?:[+&,]|\bwith\b)\s*`)
+
+// DefaultBundleSplitter splits a name on "+", "&", "," and the word "with",
+// dropping empty components
+func DefaultBundleSplitter(name string) []string {
+	var components []string
+	for _, part := range bundleSeparatorPattern.Split(name, -1) {
+		if part = strings.TrimSpace(part); part != "" {
+			components = append(components, part)
+		}
+	}
+	return components
+}
+
+// ComponentMatch is one item of a bundle comparison's alignment: component
+// IndexA of A's name matched to component IndexB of B's name
+type ComponentMatch struct {
+	A          string  // The component of A's prepared name
+	B          string  // The component of B's prepared name
+	IndexA     int     // Position of A among the components of A's name
+	IndexB     int     // Position of B among the components of B's name
+	Similarity float64 // Name similarity of the two components
+}
+
+// WithBundleSplitter compares bundle listings as sets of items; nil turns it
+// off (the default)
+// When both prepared names split into two or more components, the components
+// are aligned greedily, most similar pair first, and the bundle similarity is
+// the sum of the matched similarities divided by the larger component count,
+// so an item only one side bundles counts as 0. The name scores the higher of
+// its flat and bundle similarity, which FindDuplicates thresholds on; the
+// result records BundleSimilarity and ComponentMatches alongside the flat
+// NameSimilarity. Names that don't split on both sides are compared as
+// before. Length and character-set pruning are off while a splitter is set,
+// since reordered items defeat their bounds. Returns the engine for chaining.
+func (e *LevenshteinEngine) WithBundleSplitter(splitter BundleSplitter) *LevenshteinEngine {
+	e.bundleSplitter = splitter
+	return e
+}
+
+// WithBundleSplitter compares bundle listings as sets of items (see
+// LevenshteinEngine.WithBundleSplitter). Candidates are still found by the
+// flat name's MinHash, which reordering items barely changes. Returns the
+// engine for chaining.
+func (e *HybridEngine) WithBundleSplitter(splitter BundleSplitter) *HybridEngine {
+	e.levenshteinEngine.WithBundleSplitter(splitter)
+	return e
+}
+
+// scoreBundle adds the bundle similarity of two prepared names to their flat
+// score, when both split into several components
+// A flat score the Rabin-Karp filter rejected gets the rejection's maximal
+// distance, so the bundle score still counts.
+func (e *LevenshteinEngine) scoreBundle(score nameScore, nameA, nameB string, scratch *comparisonScratch) nameScore {
+	if e.bundleSplitter == nil {
+		return score
+	}
+	componentsA, componentsB := e.bundleSplitter(nameA), e.bundleSplitter(nameB)
+	if len(componentsA) < 2 || len(componentsB) < 2 {
+		return score
+	}
+	if score.rejected {
+		score = nameScore{distance: utf8.RuneCountInString(nameA) + utf8.RuneCountInString(nameB), skuMixed: score.skuMixed}
+	}
+	score.bundle, score.components = e.alignComponents(componentsA, componentsB, scratch)
+	return score
+}
+
+// alignComponents matches the components of two bundles greedily, most
+// similar pair first, and returns the bundle similarity and the matches in
+// A's component order
+func (e *LevenshteinEngine) alignComponents(componentsA, componentsB []string, scratch *comparisonScratch) (float64, []ComponentMatch) {
+	candidates := make([]ComponentMatch, 0, len(componentsA)*len(componentsB))
+	for i, a := range componentsA {
+		for j, b := range componentsB {
+			distance := e.scratchDistance(a, b, scratch)
+			candidates = append(candidates, ComponentMatch{A: a, B: b, IndexA: i, IndexB: j, Similarity: e.nameSimilarity(a, b, distance)})
+		}
+	}
+	// Stable, so ties go to the earliest components
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Similarity > candidates[j].Similarity
+	})
+
+	usedA, usedB := make([]bool, len(componentsA)), make([]bool, len(componentsB))
+	var matches []ComponentMatch
+	var total float64
+	for _, c := range candidates {
+		if usedA[c.IndexA] || usedB[c.IndexB] {
+			continue
+		}
+		usedA[c.IndexA], usedB[c.IndexB] = true, true
+		matches = append(matches, c)
+		total += c.Similarity
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].IndexA < matches[j].IndexA })
+
+	count := len(componentsA)
+	if len(componentsB) > count {
+		count = len(componentsB)
+	}
+	return total / float64(count), matches
+}
@@ -0,0 +1,130 @@
+package duplicatecheck
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDefaultBundleSplitter(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"iphone 14 + airpods pro + case", []string{"iphone 14", "airpods pro", "case"}},
+		{"Camera & Tripod, Bag with Strap", []string{"Camera", "Tripod", "Bag", "Strap"}},
+		{"Wireless Headphones", []string{"Wireless Headphones"}},
+		{"Notebook + ", []string{"Notebook"}},
+		{"Without Box", []string{"Without Box"}},
+	}
+	for _, tt := range tests {
+		if got := DefaultBundleSplitter(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DefaultBundleSplitter(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBundleSimilarity(t *testing.T) {
+	engine := NewLevenshteinEngine().WithBundleSplitter(DefaultBundleSplitter)
+	tests := []struct {
+		name        string
+		a, b        string
+		wantBundle  float64
+		wantMatches int
+	}{
+		{"same items reordered", "iPhone 14 + AirPods Pro + Case", "Case + iPhone 14 + AirPods Pro", 1, 3},
+		{"same items rephrased", "iPhone 14, AirPods Pro & Case", "AirPods Pro with Case + iPhone 14", 1, 3},
+		{"two of three items", "iPhone 14 + AirPods Pro", "AirPods Pro + Case + iPhone 14", 2.0 / 3, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := engine.Compare(Product{ID: "a", Name: tt.a}, Product{ID: "b", Name: tt.b})
+			if math.Abs(result.BundleSimilarity-tt.wantBundle) > 1e-9 {
+				t.Errorf("BundleSimilarity = %v, want %v", result.BundleSimilarity, tt.wantBundle)
+			}
+			if len(result.ComponentMatches) != tt.wantMatches {
+				t.Errorf("%d component matches, want %d: %+v", len(result.ComponentMatches), tt.wantMatches, result.ComponentMatches)
+			}
+			for _, m := range result.ComponentMatches {
+				if m.A != m.B || m.Similarity != 1 {
+					t.Errorf("match %+v, want equal components", m)
+				}
+			}
+			if result.NameSimilarity >= result.BundleSimilarity {
+				t.Errorf("flat NameSimilarity %v not below the bundle's %v", result.NameSimilarity, result.BundleSimilarity)
+			}
+			if result.CombinedSimilarity != result.BundleSimilarity {
+				t.Errorf("CombinedSimilarity = %v, want the bundle's %v", result.CombinedSimilarity, result.BundleSimilarity)
+			}
+		})
+	}
+}
+
+func TestBundleSimilarityReorderedThreeItems(t *testing.T) {
+	engine := NewLevenshteinEngine().WithBundleSplitter(DefaultBundleSplitter)
+	a := Product{ID: "a", Name: "Nintendo Switch OLED + Mario Kart 8 + Carrying Case"}
+	orders := []string{
+		"Mario Kart 8 + Carrying Case + Nintendo Switch OLED",
+		"Carrying Case, Nintendo Switch OLED & Mario Kart 8",
+		"Nintendo Switch OLED with Carrying Case + Mario Kart 8",
+		"Carry Case + Mario Kart 8 + Nintendo Switch OLED",
+	}
+	for _, name := range orders {
+		if got := engine.Compare(a, Product{ID: "b", Name: name}).BundleSimilarity; got < 0.9 {
+			t.Errorf("%q: BundleSimilarity = %v, want >= 0.9", name, got)
+		}
+	}
+}
+
+func TestBundleSplitterLeavesOtherNames(t *testing.T) {
+	pairs := [][2]Product{
+		{{ID: "1", Name: "Sony WH-1000XM5 Headphones", Description: "Noise cancelling"}, {ID: "2", Name: "Sony WH1000XM5 Headphone", Description: "Noise cancelling headphones"}},
+		{{ID: "1", Name: "iPhone 14 + AirPods Pro"}, {ID: "2", Name: "iPhone 14"}},
+		{{ID: "1", Name: "Camera Tripod"}, {ID: "2", Name: "Tripod for Camera"}},
+	}
+	plain := NewLevenshteinEngine()
+	bundled := NewLevenshteinEngine().WithBundleSplitter(DefaultBundleSplitter)
+	for _, pair := range pairs {
+		want, got := plain.Compare(pair[0], pair[1]), bundled.Compare(pair[0], pair[1])
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q-%q = %+v, want %+v", pair[0].Name, pair[1].Name, got, want)
+		}
+	}
+}
+
+func TestBundleFindDuplicates(t *testing.T) {
+	products := []Product{
+		{ID: "1", Name: "iPhone 14 + AirPods Pro + MagSafe Case"},
+		{ID: "2", Name: "MagSafe Case + AirPods Pro + iPhone 14"},
+		{ID: "3", Name: "Le Creuset Dutch Oven"},
+		{ID: "4", Name: "Kindle Paperwhite"},
+	}
+	tests := []struct {
+		name     string
+		splitter BundleSplitter
+		want     []string
+	}{
+		{"off", nil, []string{}},
+		{"default splitter", DefaultBundleSplitter, []string{PairKey("1", "2")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engines := map[string]DuplicateCheckEngine{
+				"levenshtein": NewLevenshteinEngine().WithBundleSplitter(tt.splitter),
+				"hybrid":      NewHybridEngine().WithBundleSplitter(tt.splitter),
+			}
+			for name, engine := range engines {
+				if got := resultKeys(engine.FindDuplicates(products, 0.9)); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("%s: FindDuplicates = %v, want %v", name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBundleSplitterConfig(t *testing.T) {
+	engine := NewLevenshteinEngine().WithBundleSplitter(DefaultBundleSplitter)
+	if got := engine.Config().Callbacks; !reflect.DeepEqual(got, []string{CallbackBundleSplitter}) {
+		t.Errorf("Callbacks = %v, want [%s]", got, CallbackBundleSplitter)
+	}
+}
@@ -0,0 +1,195 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// MinCalibrationPairs is the fewest labeled pairs FitCalibration accepts
+const MinCalibrationPairs = 20
+
+// ErrTooFewLabels is returned by FitCalibration when the labeled pairs can't
+// support a fit: fewer than MinCalibrationPairs, or only one label
+var ErrTooFewLabels = errors.New("duplicatecheck: too few labeled pairs to calibrate")
+
+// LabeledPair is a pair of products with its ground-truth label
+type LabeledPair struct {
+	A, B      Product
+	Duplicate bool
+}
+
+// CalibrationPoint is one block of a Calibration: the labeled pairs whose
+// scores were pooled together, at their mean similarity
+type CalibrationPoint struct {
+	Similarity  float64 `json:"similarity"`  // Mean CombinedSimilarity of the block
+	Probability float64 `json:"probability"` // Fraction of the block labeled duplicate
+	Pairs       int     `json:"pairs"`       // Labeled pairs in the block
+}
+
+// Calibration maps CombinedSimilarity to the probability that a pair is a
+// true duplicate, fitted on labeled pairs by FitCalibration
+// Points are in increasing order of both similarity and probability.
+type Calibration struct {
+	Points []CalibrationPoint `json:"points"`
+}
+
+// FitCalibration scores every labeled pair with engine.Compare and fits a
+// monotone mapping from CombinedSimilarity to the fraction of pairs that are
+// duplicates, by isotonic regression
+// Pairs are sorted by score and adjacent blocks whose duplicate rates
+// decrease are pooled until the rates never do (pool adjacent violators), so
+// the fit is the closest non-decreasing step function to the labels. Returns
+// ErrTooFewLabels for fewer than MinCalibrationPairs pairs or a single label.
+func FitCalibration(engine DuplicateCheckEngine, labeled []LabeledPair) (*Calibration, error) {
+	scores := make([]float64, len(labeled))
+	labels := make([]bool, len(labeled))
+	for i, pair := range labeled {
+		scores[i] = engine.Compare(pair.A, pair.B).CombinedSimilarity
+		labels[i] = pair.Duplicate
+	}
+	return fitCalibration(scores, labels)
+}
+
+// fitCalibration runs the isotonic regression of labels on scores
+func fitCalibration(scores []float64, labels []bool) (*Calibration, error) {
+	duplicates := 0
+	for _, label := range labels {
+		if label {
+			duplicates++
+		}
+	}
+	if len(scores) < MinCalibrationPairs || duplicates == 0 || duplicates == len(labels) {
+		return nil, fmt.Errorf("%w: %d pairs, %d duplicates", ErrTooFewLabels, len(scores), duplicates)
+	}
+
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return scores[order[a]] < scores[order[b]] })
+
+	// Equal scores share a block, so the fit is a function of the score
+	type block struct {
+		similarity, duplicates float64 // Sums over the block
+		pairs                  int
+	}
+	var blocks []block
+	// pool merges the last block into its predecessors while they have the
+	// higher duplicate rate
+	pool := func() {
+		for n := len(blocks); n > 1; n-- {
+			prev, last := blocks[n-2], blocks[n-1]
+			if prev.duplicates*float64(last.pairs) <= last.duplicates*float64(prev.pairs) {
+				return
+			}
+			blocks[n-2] = block{prev.similarity + last.similarity, prev.duplicates + last.duplicates, prev.pairs + last.pairs}
+			blocks = blocks[:n-1]
+		}
+	}
+	for k, i := range order {
+		score := clampUnit(scores[i])
+		if k == 0 || score != clampUnit(scores[order[k-1]]) {
+			pool()
+			blocks = append(blocks, block{})
+		}
+		b := &blocks[len(blocks)-1]
+		b.similarity += score
+		b.pairs++
+		if labels[i] {
+			b.duplicates++
+		}
+	}
+	pool()
+
+	c := &Calibration{Points: make([]CalibrationPoint, len(blocks))}
+	for i, b := range blocks {
+		c.Points[i] = CalibrationPoint{
+			Similarity:  b.similarity / float64(b.pairs),
+			Probability: b.duplicates / float64(b.pairs),
+			Pairs:       b.pairs,
+		}
+	}
+	return c, nil
+}
+
+// Probability returns the duplicate probability of a CombinedSimilarity
+// Between two points it interpolates linearly, which keeps the mapping
+// monotone; below the first point and above the last it returns their
+// probability. A Calibration without points returns similarity unchanged.
+func (c *Calibration) Probability(similarity float64) float64 {
+	points := c.Points
+	if len(points) == 0 {
+		return clampUnit(similarity)
+	}
+	if math.IsNaN(similarity) || similarity <= points[0].Similarity {
+		return points[0].Probability
+	}
+	last := points[len(points)-1]
+	if similarity >= last.Similarity {
+		return last.Probability
+	}
+	// First point above similarity; the one before is at or below it
+	i := sort.Search(len(points), func(i int) bool { return points[i].Similarity > similarity })
+	lo, hi := points[i-1], points[i]
+	t := (similarity - lo.Similarity) / (hi.Similarity - lo.Similarity)
+	return lo.Probability + t*(hi.Probability-lo.Probability)
+}
+
+// Save writes the calibration to w as JSON
+func (c *Calibration) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(c)
+}
+
+// LoadCalibration reads a calibration written by Calibration.Save
+// Returns an error for points out of order or outside [0.0-1.0], which a
+// fitted calibration never has.
+func LoadCalibration(r io.Reader) (*Calibration, error) {
+	var c Calibration
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("duplicatecheck: reading calibration: %w", err)
+	}
+	for i, p := range c.Points {
+		if clampUnit(p.Similarity) != p.Similarity || clampUnit(p.Probability) != p.Probability ||
+			(i > 0 && (p.Similarity <= c.Points[i-1].Similarity || p.Probability < c.Points[i-1].Probability)) {
+			return nil, fmt.Errorf("duplicatecheck: calibration point %d (%v, %v) is out of order or range", i, p.Similarity, p.Probability)
+		}
+	}
+	return &c, nil
+}
+
+// WithCalibration sets a calibration that fills ComparisonResult.DuplicateProbability
+// of every comparison (nil, the default, leaves it 0)
+// Name-only comparisons (FindDuplicatesByName) aren't calibrated. Returns the
+// engine for chaining.
+func (e *LevenshteinEngine) WithCalibration(c *Calibration) *LevenshteinEngine {
+	e.calibration = c
+	return e
+}
+
+// WithCalibration sets a calibration for verified pairs (see
+// LevenshteinEngine.WithCalibration); fit it on this engine, whose privacy
+// mode estimates score differently from full comparisons
+func (e *HybridEngine) WithCalibration(c *Calibration) *HybridEngine {
+	e.levenshteinEngine.WithCalibration(c)
+	return e
+}
+
+// finishResult fills the fields derived from a result's scores and products:
+// the deprecated fields in compatibility mode, HomoglyphsNormalized,
+// ReasonCodes, and DuplicateProbability with a calibration; with a redactor,
+// the products are replaced by redacted copies
+func (e *LevenshteinEngine) finishResult(r ComparisonResult) ComparisonResult {
+	r = e.withLegacyFields(r)
+	if r.MatchType != MatchIdentifier {
+		r.HomoglyphsNormalized = e.homoglyphsNormalized(&r.ProductA, &r.ProductB)
+	}
+	r.assignReasons()
+	if e.calibration != nil {
+		r.DuplicateProbability = e.calibration.Probability(r.CombinedSimilarity)
+	}
+	return e.redactor.RedactResult(r)
+}
@@ -0,0 +1,213 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+// checkMonotone fails unless c's points increase in similarity and don't
+// decrease in probability
+func checkMonotone(t *testing.T, c *Calibration) {
+	t.Helper()
+	for i := 1; i < len(c.Points); i++ {
+		if prev, p := c.Points[i-1], c.Points[i]; p.Similarity <= prev.Similarity || p.Probability < prev.Probability {
+			t.Fatalf("Points %d and %d out of order: %+v, %+v", i-1, i, prev, p)
+		}
+	}
+}
+
+func TestFitCalibrationKnownCurve(t *testing.T) {
+	curve := func(s float64) float64 { return 1 / (1 + math.Exp(-12*(s-0.6))) }
+	rng := rand.New(rand.NewSource(3))
+	scores := make([]float64, 20000)
+	labels := make([]bool, len(scores))
+	for i := range scores {
+		scores[i] = math.Round(rng.Float64()*1000) / 1000 // Ties, as real scores have
+		labels[i] = rng.Float64() < curve(scores[i])
+	}
+
+	c, err := fitCalibration(scores, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMonotone(t, c)
+	for center := 0.05; center < 1; center += 0.1 {
+		if got, want := c.Probability(center), curve(center); math.Abs(got-want) > 0.05 {
+			t.Errorf("Probability(%.2f) = %.3f, want %.3f", center, got, want)
+		}
+	}
+	previous := 0.0
+	for s := 0.0; s <= 1; s += 0.001 {
+		p := c.Probability(s)
+		if p < previous {
+			t.Fatalf("Probability(%v) = %v decreases from %v", s, p, previous)
+		}
+		previous = p
+	}
+}
+
+func TestFitCalibrationEdgeCases(t *testing.T) {
+	scores := []float64{0.2, 0.5, 0.5, 0.3, 0.9}
+	labels := []bool{false, true, false, false, true}
+	for len(scores) < MinCalibrationPairs {
+		scores, labels = append(scores, scores...), append(labels, labels...)
+	}
+
+	t.Run("Too few labels", func(t *testing.T) {
+		if _, err := fitCalibration(scores[:MinCalibrationPairs-1], labels[:MinCalibrationPairs-1]); !errors.Is(err, ErrTooFewLabels) {
+			t.Errorf("%d pairs: %v, want ErrTooFewLabels", MinCalibrationPairs-1, err)
+		}
+		allDuplicates := make([]bool, len(labels))
+		for i := range allDuplicates {
+			allDuplicates[i] = true
+		}
+		if _, err := fitCalibration(scores, allDuplicates); !errors.Is(err, ErrTooFewLabels) {
+			t.Errorf("One label: %v, want ErrTooFewLabels", err)
+		}
+		if _, err := FitCalibration(NewLevenshteinEngine(), nil); !errors.Is(err, ErrTooFewLabels) {
+			t.Errorf("No pairs: %v, want ErrTooFewLabels", err)
+		}
+	})
+
+	t.Run("Pooling and clamping", func(t *testing.T) {
+		c, err := fitCalibration(scores, labels)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkMonotone(t, c)
+		// 0.2 and 0.3 never match, half of 0.5 does and 0.9 always does;
+		// equal rates aren't violations, so nothing is pooled
+		want := []CalibrationPoint{
+			{Similarity: 0.2, Probability: 0, Pairs: len(scores) / 5},
+			{Similarity: 0.3, Probability: 0, Pairs: len(scores) / 5},
+			{Similarity: 0.5, Probability: 0.5, Pairs: len(scores) * 2 / 5},
+			{Similarity: 0.9, Probability: 1, Pairs: len(scores) / 5},
+		}
+		if !reflect.DeepEqual(c.Points, want) {
+			t.Fatalf("Points = %+v, want %+v", c.Points, want)
+		}
+		for _, tt := range []struct{ similarity, want float64 }{
+			{0, 0}, {0.25, 0}, {0.4, 0.25}, {0.7, 0.75}, {0.95, 1}, {math.NaN(), 0},
+		} {
+			if got := c.Probability(tt.similarity); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Probability(%v) = %v, want %v", tt.similarity, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("Decreasing rates pool", func(t *testing.T) {
+		// A higher score with a lower rate is merged into the block below
+		scores := []float64{0.1, 0.1, 0.6, 0.6, 0.6, 0.6, 0.7, 0.7, 0.7, 0.7}
+		labels := []bool{false, false, true, true, true, false, true, false, false, false}
+		for len(scores) < MinCalibrationPairs {
+			scores, labels = append(scores, scores...), append(labels, labels...)
+		}
+		c, err := fitCalibration(scores, labels)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkMonotone(t, c)
+		if len(c.Points) != 2 || c.Points[1].Probability != 0.5 || math.Abs(c.Points[1].Similarity-0.65) > 1e-9 {
+			t.Errorf("Points = %+v, want 0.6 and 0.7 pooled at probability 0.5", c.Points)
+		}
+	})
+
+	t.Run("No points", func(t *testing.T) {
+		if got := (&Calibration{}).Probability(0.8); got != 0.8 {
+			t.Errorf("Probability = %v, want the similarity unchanged", got)
+		}
+	})
+}
+
+func TestCalibrationSaveLoad(t *testing.T) {
+	c := &Calibration{Points: []CalibrationPoint{
+		{Similarity: 0.3, Probability: 0.05, Pairs: 40},
+		{Similarity: 0.72, Probability: 0.4, Pairs: 12},
+		{Similarity: 0.95, Probability: 0.98, Pairs: 30},
+	}}
+	var file bytes.Buffer
+	if err := c.Save(&file); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCalibration(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, c) {
+		t.Errorf("Loaded %+v, saved %+v", loaded, c)
+	}
+
+	for _, bad := range []string{
+		"",
+		"not json",
+		`{"points":[{"similarity":0.5,"probability":0.5},{"similarity":0.4,"probability":0.6}]}`,
+		`{"points":[{"similarity":0.5,"probability":0.5},{"similarity":0.6,"probability":0.4}]}`,
+		`{"points":[{"similarity":1.5,"probability":0.5}]}`,
+	} {
+		if _, err := LoadCalibration(strings.NewReader(bad)); err == nil {
+			t.Errorf("LoadCalibration(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestWithCalibration(t *testing.T) {
+	cfg := synth.Default()
+	cfg.Size, cfg.DuplicateRate = 400, 0.3
+	var labeled []LabeledPair
+	for _, p := range synth.GeneratePairs(cfg) {
+		labeled = append(labeled, LabeledPair{
+			A:         Product{ID: p.A.ID, Name: p.A.Name, Description: p.A.Description},
+			B:         Product{ID: p.B.ID, Name: p.B.Name, Description: p.B.Description},
+			Duplicate: p.Duplicate,
+		})
+	}
+	c, err := FitCalibration(NewLevenshteinEngine(), labeled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMonotone(t, c)
+	if first, last := c.Points[0], c.Points[len(c.Points)-1]; first.Probability > 0.1 || last.Probability < 0.9 {
+		t.Errorf("Fitted probabilities run from %v to %v", first.Probability, last.Probability)
+	}
+
+	products := make([]Product, 0, 2*len(labeled))
+	for _, pair := range labeled[:60] {
+		products = append(products, pair.A, pair.B)
+	}
+	products, _ = ResolveDuplicateIDs(products, DuplicateIDKeepFirst)
+	hybrid := NewHybridEngine().WithCalibration(c)
+	if err := hybrid.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	engines := []struct {
+		name   string
+		engine DuplicateCheckEngine
+	}{
+		{"Levenshtein", NewLevenshteinEngine().WithCalibration(c)},
+		{"Hybrid", hybrid},
+	}
+	for _, tt := range engines {
+		results := tt.engine.FindDuplicates(products, 0.5)
+		results = append(results, tt.engine.Compare(labeled[0].A, labeled[0].B))
+		if len(results) < 2 {
+			t.Fatalf("%s: expected results to check", tt.name)
+		}
+		for _, r := range results {
+			if want := c.Probability(r.CombinedSimilarity); r.DuplicateProbability != want {
+				t.Errorf("%s: pair %s/%s scored %v: probability %v, want %v", tt.name,
+					r.ProductA.ID, r.ProductB.ID, r.CombinedSimilarity, r.DuplicateProbability, want)
+			}
+		}
+	}
+
+	if r := NewLevenshteinEngine().Compare(labeled[0].A, labeled[0].B); r.DuplicateProbability != 0 {
+		t.Errorf("Uncalibrated engine set DuplicateProbability %v", r.DuplicateProbability)
+	}
+}
@@ -0,0 +1,83 @@
+package duplicatecheck
+
+import (
+	"math"
+	"sort"
+)
+
+// CanonicalDecimals is the number of decimals CanonicalizeResults rounds similarities to
+// Six decimals absorb floating-point noise from summation order while still
+// catching any real scoring change.
+const CanonicalDecimals = 6
+
+// PairKey returns an order-independent key for a pair of product IDs ("a|b" with a < b)
+func PairKey(idA, idB string) string {
+	return makePairKey(idA, idB)
+}
+
+// CanonicalizeResults converts results to a stable form for comparing runs
+// Each ResultRef has ProductAID < ProductBID, similarities are rounded to
+// CanonicalDecimals, and the slice is sorted by ProductAID, then ProductBID.
+// Two runs that found the same pairs with the same scores canonicalize to equal
+// slices regardless of scan order or parallelism, so the output can be stored
+// as a golden file.
+func CanonicalizeResults(results []ComparisonResult) []ResultRef {
+	refs := make([]ResultRef, len(results))
+	for i := range results {
+		ref := results[i].Ref()
+		if ref.ProductBID < ref.ProductAID {
+			ref.ProductAID, ref.ProductBID = ref.ProductBID, ref.ProductAID
+		}
+		ref.NameSimilarity = roundSimilarity(ref.NameSimilarity)
+		ref.DescriptionSimilarity = roundSimilarity(ref.DescriptionSimilarity)
+		ref.CombinedSimilarity = roundSimilarity(ref.CombinedSimilarity)
+		refs[i] = ref
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].ProductAID != refs[j].ProductAID {
+			return refs[i].ProductAID < refs[j].ProductAID
+		}
+		return refs[i].ProductBID < refs[j].ProductBID
+	})
+	return refs
+}
+
+// roundSimilarity rounds a similarity to CanonicalDecimals
+func roundSimilarity(similarity float64) float64 {
+	return roundTo(similarity, CanonicalDecimals)
+}
+
+// roundTo rounds x to decimals places
+func roundTo(x float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	return math.Round(x*scale) / scale
+}
+
+// Rounded returns the result with its similarities rounded to decimals places
+// (a negative decimals leaves them raw), for reports that show fixed precision
+// MeetsThreshold keeps the decision made on the raw CombinedSimilarity, so
+// rounding never moves a pair across the threshold.
+func (r ComparisonResult) Rounded(decimals int) ComparisonResult {
+	if decimals < 0 {
+		return r
+	}
+	for _, similarity := range []*float64{
+		&r.NameSimilarity,
+		&r.DescriptionSimilarity,
+		&r.CombinedSimilarity,
+		&r.NameInDescriptionAB,
+		&r.NameInDescriptionBA,
+		&r.DeobfuscatedNameSimilarity,
+		&r.Similarity,
+	} {
+		*similarity = roundTo(*similarity, decimals)
+	}
+	return r
+}
+
+// RoundResults rounds the similarities of results in place (see Rounded)
+func RoundResults(results []ComparisonResult, decimals int) {
+	for i := range results {
+		results[i] = results[i].Rounded(decimals)
+	}
+}
@@ -0,0 +1,131 @@
+package duplicatecheck
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// charsetBits returns the set of letters a-z in s, either case, one bit each
+func charsetBits(s string) uint32 {
+	var set uint32
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20 // ASCII lowercase; other bytes never land in a-z
+		if c >= 'a' && c <= 'z' {
+			set |= 1 << (c - 'a')
+		}
+	}
+	return set
+}
+
+// charsetShape caches the text shape of a product for charset pruning: rune
+// lengths of the prepared fields and the letters of the prepared name
+func (c *productCache) charsetShape() {
+	c.nameLen = utf8.RuneCountInString(c.normalizedName)
+	c.descLen = utf8.RuneCountInString(c.normalizedDesc)
+	c.nameCharBits = charsetBits(c.normalizedName)
+}
+
+// EnableCharsetPruning turns on charset pruning in scans (default)
+// Before running the distance, a pair's combined similarity is bounded from
+// the prepared name lengths and the letters each name contains: every letter
+// one name has and the other lacks costs at least one edit. Pairs whose bound,
+// with the description at its own length bound, can't reach the scan's
+// threshold are skipped. Compare never prunes, having no threshold, and
+// results are identical either way.
+func (e *LevenshteinEngine) EnableCharsetPruning() {
+	e.noCharsetPruning = false
+}
+
+// DisableCharsetPruning makes scans run the distance for every compared pair
+func (e *LevenshteinEngine) DisableCharsetPruning() {
+	e.noCharsetPruning = true
+}
+
+// IsCharsetPruningEnabled returns whether scans prune pairs by name charset
+func (e *LevenshteinEngine) IsCharsetPruningEnabled() bool {
+	return !e.noCharsetPruning
+}
+
+// charsetPruning reports whether pairs can be pruned safely at threshold
+// Grapheme mode measures other units than the cached rune lengths, and
+// de-obfuscation, cross-field and swapped-field scores can lift a pair above its name bound,
+// as can CrossLanguageNameOnly by moving the description's weight onto the name
+// and a PrefixBias by weighing name edits unevenly, and SKU-like names equal
+// up to separators score above it too, as do low-information names by moving
+// the name's weight onto the description and bundles by aligning their items.
+func (e *LevenshteinEngine) charsetPruning(threshold float64) bool {
+	return !e.noCharsetPruning && threshold > 0 && !e.options.GraphemeMode && e.deobfuscation == nil && e.skuNames == nil && e.lowInfo == nil && e.bundleSplitter == nil && e.options.PrefixBias <= 0 &&
+		(e.crossField == nil || e.crossField.Weight <= 0) && e.swappedFields == nil && e.crossLanguage != CrossLanguageNameOnly
+}
+
+// charsetRejects reports (and counts) whether a pair can't reach threshold
+// under weights, judged from the cached text shape alone
+func (e *LevenshteinEngine) charsetRejects(a, b *Product, weights ComparisonWeights, threshold float64) bool {
+	if !e.charsetPruning(threshold) {
+		return false
+	}
+	prep := e.preparer()
+	cacheA, cacheB := a.loadCacheFor(prep), b.loadCacheFor(prep)
+	bound := e.charsetBound(cacheA, cacheB, weights.Normalized())
+	if e.descriptionLoader != nil && (cacheA.normalizedDesc == "" || cacheB.normalizedDesc == "") {
+		// Descriptions loaded for names within the margin of threshold can lift the pair
+		if loadable := e.charsetNameBound(cacheA, cacheB) + e.lazyDescriptionOptions().Margin; loadable > bound {
+			bound = loadable
+		}
+	}
+	if meetsThreshold(bound, threshold-lengthWindowSlack) {
+		return false
+	}
+	atomic.AddUint64(&e.charsetPruned, 1)
+	return true
+}
+
+// charsetBound returns an upper bound on the combined similarity of two products
+//
+// A Levenshtein distance is at least the length difference of its strings, and
+// at least the number of letters one string contains that the other lacks,
+// since an edit fixes at most one character of each side. Every SimilarityMode
+// decreases with distance, so scoring that lower bound on the distance bounds
+// the name similarity. Descriptions are bounded by their length difference, or
+// taken as a perfect 1.0 when segmented. Both bounds are combined as the
+// comparison combines the scores.
+func (e *LevenshteinEngine) charsetBound(a, b *productCache, weights ComparisonWeights) float64 {
+	nameBound := e.charsetNameBound(a, b)
+
+	descBound := 1.0
+	if e.segmenter == nil {
+		distance := a.descLen - b.descLen
+		if distance < 0 {
+			distance = -distance
+		}
+		descBound = e.similarityBound(a.descLen, b.descLen, distance)
+	}
+	return combinePreparedFields(a.normalizedName, b.normalizedName, a.normalizedDesc, b.normalizedDesc, nameBound, descBound, weights)
+}
+
+// charsetNameBound returns an upper bound on the name similarity of two products
+func (e *LevenshteinEngine) charsetNameBound(a, b *productCache) float64 {
+	distance := a.nameLen - b.nameLen
+	if distance < 0 {
+		distance = -distance
+	}
+	if only := bits.OnesCount32(a.nameCharBits &^ b.nameCharBits); only > distance {
+		distance = only
+	}
+	if only := bits.OnesCount32(b.nameCharBits &^ a.nameCharBits); only > distance {
+		distance = only
+	}
+	return e.similarityBound(a.nameLen, b.nameLen, distance)
+}
+
+// similarityBound is computeSimilarity for texts of the given lengths at distance
+func (e *LevenshteinEngine) similarityBound(lenA, lenB, distance int) float64 {
+	if lenA == 0 && lenB == 0 {
+		return 1.0
+	}
+	if lenB > lenA {
+		lenA = lenB
+	}
+	return e.normalizeSimilarity(distance, lenA)
+}
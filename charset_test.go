@@ -0,0 +1,200 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+func TestCharsetBits(t *testing.T) {
+	tests := []struct {
+		text string
+		want uint32
+	}{
+		{"", 0},
+		{"abc", 0b111},
+		{"ABC cab", 0b111},
+		{"z 42 -", 1 << 25},
+		{"été", 1 << ('t' - 'a')},
+	}
+	for _, tt := range tests {
+		if got := charsetBits(tt.text); got != tt.want {
+			t.Errorf("charsetBits(%q) = %b, want %b", tt.text, got, tt.want)
+		}
+	}
+}
+
+// TestCharsetBoundIsUpperBound checks the bound against the real similarity of
+// random pairs, many of them near each other, in every similarity mode
+func TestCharsetBoundIsUpperBound(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	words := []string{"apple", "iphone", "pro", "max", "galaxy", "s23", "xyz", "kiwi", "über", "café", "zz"}
+	text := func(n int) string {
+		s := ""
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				s += " "
+			}
+			s += words[rng.Intn(len(words))]
+		}
+		return s
+	}
+	modes := []SimilarityMode{SimilarityLinear, SimilarityLengthAdjusted, SimilarityLogistic}
+	for _, mode := range modes {
+		engine := NewLevenshteinEngineWithOptions(LevenshteinOptions{SimilarityMode: mode})
+		engine.DisableRabinKarpFilter()
+		for i := 0; i < 2000; i++ {
+			a := Product{ID: "A", Name: text(rng.Intn(4)), Description: text(rng.Intn(3))}
+			b := Product{ID: "B", Name: text(rng.Intn(4)), Description: text(rng.Intn(3))}
+			if rng.Intn(3) == 0 {
+				b.Name = a.Name + text(1)
+			}
+			weights := ComparisonWeights{NameWeight: rng.Float64(), DescriptionWeight: rng.Float64()}.Normalized()
+			bound := engine.charsetBound(a.loadCache(), b.loadCache(), weights)
+			if got := engine.CompareWithWeights(a, b, weights).CombinedSimilarity; got > bound+1e-9 {
+				t.Fatalf("mode %v: %q / %q scored %v above bound %v", mode, a.Name, b.Name, got, bound)
+			}
+		}
+	}
+}
+
+func TestCharsetPruning(t *testing.T) {
+	cfg := synth.Default()
+	cfg.Size = 60
+	generated, _ := generatedCatalog(cfg)
+	catalogs := map[string][]Product{"generated": generated, "length diverse": lengthDiverseCatalog(60)}
+
+	engines := []struct {
+		name string
+		new  func() *LevenshteinEngine
+	}{
+		{"default", NewLevenshteinEngine},
+		{"name only", func() *LevenshteinEngine {
+			return NewLevenshteinEngineWithWeights(ComparisonWeights{NameWeight: 1, DescriptionWeight: 0})
+		}},
+		{"logistic", func() *LevenshteinEngine {
+			return NewLevenshteinEngineWithOptions(LevenshteinOptions{SimilarityMode: SimilarityLogistic})
+		}},
+		{"weight resolver", func() *LevenshteinEngine {
+			return NewLevenshteinEngine().WithWeightResolver(func(a, b Product) ComparisonWeights {
+				if len(a.Name) < 20 {
+					return ComparisonWeights{NameWeight: 0.9, DescriptionWeight: 0.1}
+				}
+				return ComparisonWeights{}
+			})
+		}},
+		{"segmented", func() *LevenshteinEngine {
+			return NewLevenshteinEngine().WithDescriptionSegmenter(DefaultSegmenter, AlignBestMatch)
+		}},
+	}
+	for catalog, products := range catalogs {
+		for _, tt := range engines {
+			for _, threshold := range []float64{0.6, 0.85} {
+				t.Run(fmt.Sprintf("%s/%s/%.1f", catalog, tt.name, threshold), func(t *testing.T) {
+					pruned, full := tt.new(), tt.new()
+					full.DisableCharsetPruning()
+
+					got := pruned.FindDuplicates(products, threshold)
+					want := full.FindDuplicates(products, threshold)
+					if !reflect.DeepEqual(comparableResults(got), comparableResults(want)) {
+						t.Errorf("pruned scan found %d results, unpruned %d", len(got), len(want))
+					}
+					if full.GetScanStats()["charset_pruned_pairs"].(uint64) != 0 {
+						t.Error("disabled charset pruning still pruned")
+					}
+				})
+			}
+		}
+	}
+
+	t.Run("hybrid", func(t *testing.T) {
+		pruned, full := NewHybridEngine(), NewHybridEngine()
+		full.levenshteinEngine.DisableCharsetPruning()
+		for _, engine := range []*HybridEngine{pruned, full} {
+			if err := engine.BuildIndex(generated); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, want := pruned.FindDuplicates(generated, 0.8), full.FindDuplicates(generated, 0.8)
+		if !reflect.DeepEqual(comparableResults(got), comparableResults(want)) {
+			t.Errorf("pruned scan found %d results, unpruned %d", len(got), len(want))
+		}
+	})
+
+	t.Run("compare never prunes", func(t *testing.T) {
+		engine := NewLevenshteinEngine()
+		result := engine.Compare(Product{ID: "A", Name: "abc"}, Product{ID: "B", Name: "xyz"})
+		if result.NameDistance != 3 || engine.GetScanStats()["charset_pruned_pairs"].(uint64) != 0 {
+			t.Errorf("Compare pruned: %+v", result)
+		}
+	})
+
+	t.Run("prunes dissimilar names", func(t *testing.T) {
+		engine := NewLevenshteinEngine()
+		engine.DisableLengthPruning()
+		products := []Product{{ID: "A", Name: "wxyz kit"}, {ID: "B", Name: "bolt nut"}, {ID: "C", Name: "bolt nuts"}}
+		engine.FindDuplicates(products, 0.85)
+		if pruned := engine.GetScanStats()["charset_pruned_pairs"].(uint64); pruned != 2 {
+			t.Errorf("pruned %d pairs, want 2", pruned)
+		}
+	})
+
+	t.Run("unsafe configurations disable pruning", func(t *testing.T) {
+		crossField := CrossFieldConfig{Weight: 0.5}
+		engines := map[string]*LevenshteinEngine{
+			"grapheme":     NewLevenshteinEngineWithOptions(LevenshteinOptions{GraphemeMode: true}),
+			"deobfuscated": NewLevenshteinEngine().WithDeobfuscation(&DeobfuscationConfig{AggressiveDeobfuscation: true}),
+			"cross field":  NewLevenshteinEngine().WithCrossFieldMatching(&crossField),
+		}
+		for name, engine := range engines {
+			if engine.charsetPruning(0.9) {
+				t.Errorf("%s: charset pruning enabled", name)
+			}
+		}
+		if NewLevenshteinEngine().charsetPruning(0) {
+			t.Error("charset pruning enabled without a threshold")
+		}
+	})
+
+	t.Run("toggle", func(t *testing.T) {
+		engine := NewLevenshteinEngine()
+		if !engine.IsCharsetPruningEnabled() {
+			t.Error("charset pruning should be enabled by default")
+		}
+		engine.DisableCharsetPruning()
+		if engine.IsCharsetPruningEnabled() || engine.charsetPruning(0.9) {
+			t.Error("charset pruning should be disabled")
+		}
+		engine.EnableCharsetPruning()
+		if !engine.IsCharsetPruningEnabled() {
+			t.Error("charset pruning should be enabled again")
+		}
+	})
+}
+
+// BenchmarkCharsetPruning scans a generated 5000-product catalog and reports
+// the name distances run and the pairs charset pruning skipped per scan
+func BenchmarkCharsetPruning(b *testing.B) {
+	cfg := synth.Default()
+	cfg.Size = 5000
+	products, _ := generatedCatalog(cfg)
+	for _, pruning := range []bool{false, true} {
+		b.Run(fmt.Sprintf("pruning=%v", pruning), func(b *testing.B) {
+			engine := NewLevenshteinEngine()
+			if !pruning {
+				engine.DisableCharsetPruning()
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				engine.FindDuplicates(products, 0.85)
+			}
+			b.StopTimer()
+			stats := engine.GetScanStats()
+			b.ReportMetric(float64(stats["name_comparisons"].(uint64))/float64(b.N), "distances/scan")
+			b.ReportMetric(float64(stats["charset_pruned_pairs"].(uint64))/float64(b.N), "pruned/scan")
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package duplicatecheck
+
+import "sync/atomic"
+
+// DefaultCliqueMinSize is the default HybridConfig.CliqueMinSize
+const DefaultCliqueMinSize = 20
+
+// cliqueTracker records the cliques one FindDuplicates run has formed
+// A clique is a query product (its representative) and the candidates sharing
+// at least bands LSH bands with it; only representative-member pairs are
+// verified. Members share at least 2×bands − numBands bands with each other,
+// so with bands near numBands they are near-identical to one another too.
+// Used from the generating goroutine only.
+type cliqueTracker struct {
+	bands   int               // Band collisions making a candidate a member
+	minSize int               // Members plus representative needed to form a clique
+	rep     map[string]string // Member ID -> representative ID (representatives map to themselves)
+	skipped uint64            // Member-member pairs not verified
+}
+
+// newCliqueTracker returns a tracker for one run, or nil when clique detection is off
+// Exact mode returns candidates without band collisions, so it never forms cliques.
+func (e *HybridEngine) newCliqueTracker(idx *LSHIndex) *cliqueTracker {
+	if e.cliqueBands <= 0 || e.exhaustive(idx) {
+		return nil
+	}
+	return &cliqueTracker{bands: e.cliqueBands, minSize: e.cliqueMinSize, rep: make(map[string]string)}
+}
+
+// form makes productID the representative of a clique of its candidates
+// sharing enough bands with it, unless they are too few
+// Candidates already in a clique, or already paired with productID, are left out.
+func (c *cliqueTracker) form(productID string, candidates []lshCandidate, checked map[string]bool) {
+	if c == nil || c.rep[productID] != "" {
+		return
+	}
+	var members []string
+	for _, candidate := range candidates {
+		if candidate.collisions < c.bands || candidate.id == productID || c.rep[candidate.id] != "" ||
+			checked[makePairKey(productID, candidate.id)] {
+			continue
+		}
+		members = append(members, candidate.id)
+	}
+	if len(members)+1 < c.minSize {
+		return
+	}
+	c.rep[productID] = productID
+	for _, id := range members {
+		c.rep[id] = productID
+	}
+}
+
+// spans reports whether b is a member of the clique a represents, so the
+// pair is one of the clique's verified spanning pairs
+func (c *cliqueTracker) spans(a, b string) bool {
+	return c != nil && c.rep[a] == a && c.rep[b] == a && a != b
+}
+
+// inferred reports whether a and b are members of one clique and neither is
+// its representative, counting the pair as skipped
+func (c *cliqueTracker) inferred(a, b string) bool {
+	if c == nil {
+		return false
+	}
+	rep := c.rep[a]
+	if rep == "" || rep == a || rep != c.rep[b] || rep == b {
+		return false
+	}
+	c.skipped++
+	return true
+}
+
+// evict drops a member whose pair with the representative didn't verify, so
+// its pairs with the other members are verified as usual
+func (c *cliqueTracker) evict(id string) {
+	if c != nil && c.rep[id] != id {
+		delete(c.rep, id)
+	}
+}
+
+// finish adds the run's skipped pairs to the engine's count
+func (c *cliqueTracker) finish(e *HybridEngine) {
+	if c != nil {
+		atomic.AddUint64(&e.cliqueSkipped, c.skipped)
+	}
+}
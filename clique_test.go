@@ -0,0 +1,110 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"testing"
+)
+
+// plantClique returns size boilerplate products with identical text, IDs BP0000...
+func plantClique(size int) []Product {
+	products := make([]Product, size)
+	for i := range products {
+		products[i] = Product{
+			ID:          fmt.Sprintf("BP%04d", i),
+			Name:        "Universal replacement part for assorted models",
+			Description: "Contact the seller for compatibility details before ordering this item.",
+		}
+	}
+	return products
+}
+
+func TestCliqueDetection(t *testing.T) {
+	const threshold = 0.9
+	const cliqueSize = 200
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 150)
+	products := append(plantClique(cliqueSize), catalog...)
+
+	run := func(cliqueBands int, products []Product) ([]ComparisonResult, uint64) {
+		t.Helper()
+		config := DefaultHybridConfig()
+		config.CliqueBands = cliqueBands
+		engine := NewHybridEngineWithConfig(config)
+		if err := engine.BuildIndex(products); err != nil {
+			t.Fatal(err)
+		}
+		results := engine.FindDuplicates(products, threshold)
+		return results, engine.GetIndexStats()["verified_pairs"].(uint64)
+	}
+
+	_, baseline := run(0, catalog)
+	_, without := run(0, products)
+	results, with := run(18, products)
+
+	t.Logf("verifications: %d without clique detection, %d with (catalog alone %d)", without, with, baseline)
+	if min := uint64(cliqueSize * (cliqueSize - 1) / 2); without < min {
+		t.Errorf("without clique detection: %d verifications, want at least %d", without, min)
+	}
+	if with > baseline+cliqueSize {
+		t.Errorf("with clique detection: %d verifications, want at most %d (catalog %d + clique %d)",
+			with, baseline+cliqueSize, baseline, cliqueSize)
+	}
+
+	members, inferred := make(map[string]bool), 0
+	for _, r := range results {
+		if r.ClusterInferred {
+			inferred++
+			members[r.ProductA.ID], members[r.ProductB.ID] = true, true
+		}
+	}
+	if inferred != cliqueSize-1 {
+		t.Errorf("%d results marked ClusterInferred, want %d", inferred, cliqueSize-1)
+	}
+	for _, p := range products[:cliqueSize] {
+		if !members[p.ID] {
+			t.Fatalf("clique member %s missing from the results", p.ID)
+		}
+	}
+}
+
+func TestCliqueTracker(t *testing.T) {
+	c := &cliqueTracker{bands: 15, minSize: 3, rep: make(map[string]string)}
+	candidates := []lshCandidate{{"a", 20}, {"b", 16}, {"c", 15}, {"weak", 14}, {"q", 20}}
+
+	c.form("q", candidates[:1], map[string]bool{})
+	if len(c.rep) != 0 {
+		t.Fatalf("formed a clique of 2 with minSize 3: %v", c.rep)
+	}
+	c.form("q", candidates, map[string]bool{makePairKey("q", "c"): true})
+	if len(c.rep) != 3 || c.rep["a"] != "q" || c.rep["b"] != "q" {
+		t.Fatalf("clique = %v, want q representing a and b", c.rep)
+	}
+
+	tests := []struct {
+		a, b            string
+		spans, inferred bool
+	}{
+		{"q", "a", true, false},
+		{"a", "q", false, false},
+		{"a", "b", false, true},
+		{"a", "weak", false, false},
+		{"c", "b", false, false},
+	}
+	for _, tt := range tests {
+		if got := c.spans(tt.a, tt.b); got != tt.spans {
+			t.Errorf("spans(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.spans)
+		}
+		if got := c.inferred(tt.a, tt.b); got != tt.inferred {
+			t.Errorf("inferred(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.inferred)
+		}
+	}
+
+	// A member failing its representative is paired with the others again
+	c.evict("b")
+	if c.inferred("a", "b") {
+		t.Error("evicted member still inferred")
+	}
+	c.evict("q")
+	if !c.spans("q", "a") {
+		t.Error("evict removed the representative")
+	}
+}
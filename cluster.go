@@ -0,0 +1,132 @@
+package duplicatecheck
+
+import "sort"
+
+// DuplicateGroup is a cluster of products linked by any chain of duplicate pairs
+type DuplicateGroup struct {
+	Products []Product // Members, sorted by ID
+	Pairs    int       // Duplicate pairs within the group
+	// MinSimilarity and MaxSimilarity bound the CombinedSimilarity of the
+	// group's pairs; a low MinSimilarity marks a loose cluster
+	MinSimilarity float64
+	MaxSimilarity float64
+	// Similarities holds the CombinedSimilarity of each pair by PairKey when
+	// ClusterOptions.RetainSimilarities is set (nil otherwise)
+	Similarities map[string]float64
+	// Stratum is why SampleClustersForReview picked the group (nil for groups
+	// it didn't return)
+	Stratum *ReviewStratum
+}
+
+// ClusterOptions controls ClusterDuplicates
+type ClusterOptions struct {
+	// RetainSimilarities keeps every pair's similarity in its group, which
+	// ReviewRepresentatives needs; off, groups only keep the bounds
+	RetainSimilarities bool
+}
+
+// ClusterDuplicates groups scan results into connected components: products
+// linked by any chain of pairs end up in one group
+// Groups are ordered by size, largest first, then by their first product ID.
+// Results below their threshold are grouped like the others; filter them
+// first if needed.
+func ClusterDuplicates(results []ComparisonResult, opts ClusterOptions) []DuplicateGroup {
+	parent := make(map[string]string)
+	var find func(id string) string
+	find = func(id string) string {
+		p, ok := parent[id]
+		if !ok || p == id {
+			parent[id] = id
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	for i := range results {
+		a, b := find(results[i].ProductA.ID), find(results[i].ProductB.ID)
+		if a != b {
+			if b < a {
+				a, b = b, a
+			}
+			parent[b] = a
+		}
+	}
+
+	index := make(map[string]int)
+	var groups []DuplicateGroup
+	var members []map[string]bool // Member IDs of each group
+	for i := range results {
+		r := &results[i]
+		root := find(r.ProductA.ID)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, DuplicateGroup{MinSimilarity: r.CombinedSimilarity, MaxSimilarity: r.CombinedSimilarity})
+			members = append(members, make(map[string]bool))
+			if opts.RetainSimilarities {
+				groups[g].Similarities = make(map[string]float64)
+			}
+		}
+		group := &groups[g]
+		for _, p := range []*Product{&r.ProductA, &r.ProductB} {
+			if !members[g][p.ID] {
+				members[g][p.ID] = true
+				group.Products = append(group.Products, *p)
+			}
+		}
+		group.Pairs++
+		if r.CombinedSimilarity < group.MinSimilarity {
+			group.MinSimilarity = r.CombinedSimilarity
+		}
+		if r.CombinedSimilarity > group.MaxSimilarity {
+			group.MaxSimilarity = r.CombinedSimilarity
+		}
+		if group.Similarities != nil {
+			group.Similarities[makePairKey(r.ProductA.ID, r.ProductB.ID)] = r.CombinedSimilarity
+		}
+	}
+
+	for g := range groups {
+		products := groups[g].Products
+		sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].Products) != len(groups[j].Products) {
+			return len(groups[i].Products) > len(groups[j].Products)
+		}
+		return groups[i].Products[0].ID < groups[j].Products[0].ID
+	})
+	return groups
+}
+
+// ReviewRepresentatives returns the most central member of a group, with the
+// highest average similarity to the other members, and the most marginal one,
+// with the lowest
+// Pairs the scan didn't report count as similarity 0. Ties go to the lower
+// ID. ok is false without retained similarities (see
+// ClusterOptions.RetainSimilarities) or with fewer than two members.
+func ReviewRepresentatives(group DuplicateGroup) (central, marginal Product, ok bool) {
+	if group.Similarities == nil || len(group.Products) < 2 {
+		return Product{}, Product{}, false
+	}
+	best, worst := -1.0, 2.0
+	for i := range group.Products {
+		var sum float64
+		for j := range group.Products {
+			if i != j {
+				sum += group.Similarities[makePairKey(group.Products[i].ID, group.Products[j].ID)]
+			}
+		}
+		// Products are sorted by ID, so strict comparisons keep the lower ID on ties
+		average := sum / float64(len(group.Products)-1)
+		if average > best {
+			best, central = average, group.Products[i]
+		}
+		if average < worst {
+			worst, marginal = average, group.Products[i]
+		}
+	}
+	return central, marginal, true
+}
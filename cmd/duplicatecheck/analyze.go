@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+// handleAnalyze reports on a catalog's health; --ambiguous lists the
+// products matching more than --min-partners others, as JSON lines
+func handleAnalyze(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	catalog := flags.String("catalog", "", "JSON array or JSONL catalog file (required)")
+	engineName := flags.String("engine", "hybrid", "engine to analyze with: levenshtein or hybrid")
+	threshold := flags.Float64("threshold", 0.8, "similarity threshold partners are counted at")
+	minPartners := flags.Int("min-partners", 10, "report products with more partners than this")
+	ambiguous := flags.Bool("ambiguous", false, "list ambiguous products, the duplicate magnets with many partners")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *catalog == "" {
+		fmt.Fprintln(stderr, "--catalog is required")
+		return 2
+	}
+	if !*ambiguous {
+		fmt.Fprintln(stderr, "nothing to analyze (want --ambiguous)")
+		return 2
+	}
+	if *engineName != "levenshtein" && *engineName != "hybrid" {
+		fmt.Fprintf(stderr, "unknown --engine %q (want levenshtein or hybrid)\n", *engineName)
+		return 2
+	}
+	if *threshold < 0 || *threshold > 1 {
+		fmt.Fprintln(stderr, "--threshold must be in [0, 1]")
+		return 2
+	}
+	if *minPartners < 0 {
+		fmt.Fprintln(stderr, "--min-partners must not be negative")
+		return 2
+	}
+
+	products, err := loadCatalog(*catalog, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "analyze: %v\n", err)
+		return 1
+	}
+	var reports []duplicatecheck.AmbiguityReport
+	if *engineName == "hybrid" {
+		reports = duplicatecheck.NewHybridEngine().IdentifyAmbiguousProducts(products, *threshold, *minPartners)
+	} else {
+		reports = duplicatecheck.NewLevenshteinEngine().IdentifyAmbiguousProducts(products, *threshold, *minPartners)
+	}
+
+	out := bufio.NewWriter(stdout)
+	encoder := json.NewEncoder(out)
+	for i := range reports {
+		if err := encoder.Encode(reports[i]); err != nil {
+			fmt.Fprintf(stderr, "analyze: %v\n", err)
+			return 1
+		}
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(stderr, "analyze: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "%d products, %d ambiguous\n", len(products), len(reports))
+	return 0
+}
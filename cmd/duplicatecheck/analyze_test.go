@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+func TestAnalyzeAmbiguous(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.jsonl")
+	text := productLine(t, "U1", "USB Cable 1m", "USB charging cable") +
+		productLine(t, "U2", "USB Cable 1m", "USB charging cable, black") +
+		productLine(t, "U3", "USB Cable 1m", "USB charging cable, white") +
+		productLine(t, "U4", "USB Cable 1m", "USB data cable") +
+		productLine(t, "A1", "Sony WH-1000XM5 Wireless Headphones", "Noise cancelling over-ear headphones") +
+		productLine(t, "A2", "Sony WH1000XM5 Wireless Headphones", "Noise cancelling over-ear headphones") +
+		productLine(t, "B1", "Kindle Paperwhite 16GB", "Waterproof e-reader")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, engine := range []string{"levenshtein", "hybrid"} {
+		t.Run(engine, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := []string{"analyze", "--catalog", path, "--ambiguous", "--engine", engine, "--min-partners", "1"}
+			if code := run(args, &stdout, &stderr); code != 0 {
+				t.Fatalf("exit %d: %s", code, stderr.String())
+			}
+			var ids []string
+			for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+				var report duplicatecheck.AmbiguityReport
+				if err := json.Unmarshal([]byte(line), &report); err != nil {
+					t.Fatalf("decode %q: %v", line, err)
+				}
+				if report.Partners != 3 {
+					t.Errorf("%s has %d partners, want 3", report.ID, report.Partners)
+				}
+				ids = append(ids, report.ID)
+			}
+			if got := strings.Join(ids, ","); got != "U1,U2,U3,U4" {
+				t.Errorf("flagged %s, want U1,U2,U3,U4", got)
+			}
+			if !strings.Contains(stderr.String(), "7 products, 4 ambiguous") {
+				t.Errorf("stderr %q lacks the totals", stderr.String())
+			}
+		})
+	}
+
+	for _, args := range [][]string{
+		{"analyze", "--ambiguous"},
+		{"analyze", "--catalog", path},
+		{"analyze", "--catalog", path, "--ambiguous", "--engine", "nope"},
+		{"analyze", "--catalog", path, "--ambiguous", "--threshold", "2"},
+		{"analyze", "--catalog", path, "--ambiguous", "--min-partners", "-1"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != 2 {
+			t.Errorf("%v: exit %d, want 2", args, code)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+// compareOptions configures a compare run
+type compareOptions struct {
+	engine              string  // "levenshtein" or "hybrid"
+	explain             bool    // Print the token-level explanation
+	explainDescriptions bool    // Include description tokens in the explanation
+	showPrepared        bool    // Print the prepared strings the engine compared
+	allEngines          bool    // Compare with every available engine and report agreement
+	threshold           float64 // Threshold the engines' agreement is judged at
+}
+
+// handleCompare scores two products given as JSON objects and prints the similarities
+func handleCompare(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var opts compareOptions
+	flags.StringVar(&opts.engine, "engine", "levenshtein", "engine to compare with: levenshtein or hybrid")
+	flags.BoolVar(&opts.explain, "explain", false, "list matched, near-matched, and unique name tokens")
+	flags.BoolVar(&opts.explainDescriptions, "explain-descriptions", false, "like --explain, also reading description tokens")
+	flags.BoolVar(&opts.showPrepared, "show-prepared", false, "print the prepared names and descriptions the engine compared")
+	flags.BoolVar(&opts.allEngines, "all-engines", false, "compare with every available engine and report whether they agree")
+	flags.Float64Var(&opts.threshold, "threshold", duplicatecheck.DefaultThreshold, "threshold the engines' agreement is judged at, with --all-engines")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		fmt.Fprintln(stderr, "compare takes two products as JSON objects, e.g. '{\"id\":\"1\",\"name\":\"...\"}'")
+		return 2
+	}
+	if opts.engine != "levenshtein" && opts.engine != "hybrid" {
+		fmt.Fprintf(stderr, "unknown --engine %q (want levenshtein or hybrid)\n", opts.engine)
+		return 2
+	}
+	if opts.threshold < 0 || opts.threshold > 1 {
+		fmt.Fprintln(stderr, "--threshold must be in [0, 1]")
+		return 2
+	}
+	if opts.allEngines && opts.showPrepared {
+		fmt.Fprintln(stderr, "--show-prepared can't be combined with --all-engines")
+		return 2
+	}
+
+	var products [2]duplicatecheck.Product
+	for i := range products {
+		if err := json.Unmarshal([]byte(flags.Arg(i)), &products[i]); err != nil {
+			fmt.Fprintf(stderr, "compare: product %d: %v\n", i+1, err)
+			return 2
+		}
+	}
+
+	var engine duplicatecheck.DuplicateCheckEngine = duplicatecheck.NewLevenshteinEngine()
+	if opts.engine == "hybrid" {
+		engine = duplicatecheck.NewHybridEngine()
+	}
+	if opts.allEngines {
+		printAgreement(stdout, duplicatecheck.CompareAcrossEngines(products[0], products[1], nil, opts.threshold))
+	} else if opts.showPrepared {
+		detailed := engine.(duplicatecheck.DetailedComparer).CompareDetailed(products[0], products[1])
+		printScores(stdout, detailed.ComparisonResult)
+		printPrepared(stdout, detailed)
+	} else {
+		printScores(stdout, engine.Compare(products[0], products[1]))
+	}
+
+	if opts.explain || opts.explainDescriptions {
+		explanation := duplicatecheck.ExplainTokensWithOptions(products[0], products[1],
+			duplicatecheck.TokenExplainOptions{IncludeDescriptions: opts.explainDescriptions})
+		fmt.Fprintln(stdout, explanation)
+	}
+	return 0
+}
+
+// printScores prints the similarities of a comparison and its reason codes
+func printScores(w io.Writer, result duplicatecheck.ComparisonResult) {
+	fmt.Fprintf(w, "combined %.3f  name %.3f  description %.3f\n",
+		result.CombinedSimilarity, result.NameSimilarity, result.DescriptionSimilarity)
+	for _, code := range result.ReasonCodes {
+		fmt.Fprintf(w, "  reason %s: %s\n", code, code.Description())
+	}
+}
+
+// printAgreement prints each engine's similarities against the threshold,
+// then whether the engines agree
+func printAgreement(w io.Writer, agreement duplicatecheck.EngineAgreement) {
+	width := 0
+	for _, name := range agreement.Engines {
+		width = max(width, utf8.RuneCountInString(name))
+	}
+	for _, name := range agreement.Engines {
+		result := agreement.Results[name]
+		side := "below"
+		if result.MeetsThreshold {
+			side = "above"
+		}
+		fmt.Fprintf(w, "%-*s  combined %.3f  name %.3f  description %.3f  %s\n", width, name,
+			result.CombinedSimilarity, result.NameSimilarity, result.DescriptionSimilarity, side)
+	}
+	fmt.Fprintf(w, "threshold %.3f  spread %.3f  verdict %s", agreement.Threshold, agreement.Spread, agreement.Verdict)
+	if agreement.Outlier != "" {
+		fmt.Fprintf(w, "  outlier %s", agreement.Outlier)
+	}
+	fmt.Fprintln(w)
+}
+
+// printPrepared prints the preparation steps and the prepared strings of both products
+func printPrepared(w io.Writer, d duplicatecheck.DetailedComparisonResult) {
+	fmt.Fprintf(w, "prepared (%s):\n", strings.Join(d.PreparationSteps, ", "))
+	fields := []struct {
+		label    string
+		prepared string
+		length   duplicatecheck.TextLength
+	}{
+		{"name A", d.NamePreparedA, d.NameLengthA},
+		{"name B", d.NamePreparedB, d.NameLengthB},
+		{"desc A", d.DescPreparedA, d.DescLengthA},
+		{"desc B", d.DescPreparedB, d.DescLengthB},
+	}
+	for _, f := range fields {
+		fmt.Fprintf(w, "  %s %q (%d -> %d runes)\n", f.label, f.prepared, f.length.Raw, f.length.Prepared)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	a := `{"id":"1","name":"Samsung Galxy S23 Ultra","description":"Phantom Black"}`
+	b := `{"id":"2","name":"Samsung Galaxy S23","description":"Black"}`
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{"scores only", []string{"compare", a, b}, []string{"combined 0.", "name 0."}, []string{"matched"}},
+		{"explain", []string{"compare", "--explain", a, b},
+			[]string{"near     (1): galxy~galaxy", "only A   (1): ultra"}, []string{"phantom"}},
+		{"explain descriptions", []string{"compare", "--explain-descriptions", a, b},
+			[]string{"matched  (3): samsung s23 black", "only A   (2): ultra phantom"}, nil},
+		{"reasons", []string{"compare", a, a},
+			[]string{"reason exact_match: Names and descriptions are byte-identical", "reason high_name_similarity:"}, nil},
+		{"hybrid engine", []string{"compare", "--engine", "hybrid", a, b}, []string{"combined 0."}, nil},
+		{"show prepared", []string{"compare", "--show-prepared", a, b},
+			[]string{"prepared (lowercase, trim):", `name A "samsung galxy s23 ultra" (23 -> 23 runes)`, `desc B "black" (5 -> 5 runes)`}, nil},
+		{"all engines", []string{"compare", "--all-engines", "--threshold", "0.6",
+			`{"id":"1","name":"Sony Wireless Headphones Black Edition"}`, `{"id":"2","name":"Black Edition Wireless Headphones Sony"}`},
+			[]string{"Levenshtein Distance ", "TF-IDF Token Cosine  ", "0.874", "above", "below", "verdict split  outlier TF-IDF Token Cosine"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != 0 {
+				t.Fatalf("exit code %d: %s", code, stderr.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, stdout.String())
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(stdout.String(), notWant) {
+					t.Errorf("output has %q:\n%s", notWant, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCompareRejectsBadInput(t *testing.T) {
+	product := `{"id":"1","name":"Lamp"}`
+	for _, args := range [][]string{
+		{"compare", product},
+		{"compare", product, "not json"},
+		{"compare", "--engine", "bogus", product, product},
+		{"compare", "--all-engines", "--threshold", "1.5", product, product},
+		{"compare", "--all-engines", "--show-prepared", product, product},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != 2 {
+			t.Errorf("run(%q) = %d, want 2", args, code)
+		}
+	}
+}
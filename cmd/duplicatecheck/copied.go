@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+// handleCopiedDescriptions lists products sharing a description under
+// different names, as JSON lines
+func handleCopiedDescriptions(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("copied-descriptions", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var opts duplicatecheck.CopiedDescriptionOptions
+	catalog := flags.String("catalog", "", "JSON array or JSONL catalog file (required)")
+	flags.Float64Var(&opts.DescriptionThreshold, "threshold", 0.9, "lowest description similarity reported")
+	flags.Float64Var(&opts.MaxNameSimilarity, "max-name-similarity", 0.5, "highest name similarity reported; closer names are ordinary duplicates")
+	flags.IntVar(&opts.MinDescriptionLength, "min-length", duplicatecheck.DefaultMinCopiedDescriptionLength, "leave out descriptions shorter than this many characters (negative = no minimum)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *catalog == "" {
+		fmt.Fprintln(stderr, "--catalog is required")
+		return 2
+	}
+	if opts.DescriptionThreshold < 0 || opts.DescriptionThreshold > 1 {
+		fmt.Fprintln(stderr, "--threshold must be in [0, 1]")
+		return 2
+	}
+	if opts.MaxNameSimilarity < 0 || opts.MaxNameSimilarity > 1 {
+		fmt.Fprintln(stderr, "--max-name-similarity must be in [0, 1]")
+		return 2
+	}
+
+	products, err := loadCatalog(*catalog, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "copied-descriptions: %v\n", err)
+		return 1
+	}
+	results, err := duplicatecheck.NewHybridEngine().FindCopiedDescriptionsWithOptions(products, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "copied-descriptions: %v\n", err)
+		return 1
+	}
+
+	out := bufio.NewWriter(stdout)
+	encoder := json.NewEncoder(out)
+	for i := range results {
+		if err := encoder.Encode(results[i].Ref()); err != nil {
+			fmt.Fprintf(stderr, "copied-descriptions: %v\n", err)
+			return 1
+		}
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(stderr, "copied-descriptions: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "%d products, %d copied descriptions\n", len(products), len(results))
+	return 0
+}
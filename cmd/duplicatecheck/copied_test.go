@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+func TestCopiedDescriptions(t *testing.T) {
+	description := "Hand-stitched canvas tote with reinforced leather handles, an inner zip pocket and a water-resistant lining."
+	path := filepath.Join(t.TempDir(), "catalog.jsonl")
+	text := productLine(t, "C1", "Canvas Tote Bag", description) +
+		productLine(t, "C2", "Stainless Thermos Flask", description) +
+		productLine(t, "S1", "Ceramic Vase", "Black.") +
+		productLine(t, "S2", "Wool Scarf", "Black.") +
+		productLine(t, "D1", "Sony WH-1000XM5 Headphones", description) +
+		productLine(t, "D2", "Sony WH-1000XM5 Headphones", description)
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	copied, short := duplicatecheck.PairKey("C1", "C2"), duplicatecheck.PairKey("S1", "S2")
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantShort bool
+	}{
+		{"defaults", nil, false},
+		{"no minimum length", []string{"--min-length", "-1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"copied-descriptions", "--catalog", path}, tt.args...)
+			if code := run(args, &stdout, &stderr); code != 0 {
+				t.Fatalf("exit %d: %s", code, stderr.String())
+			}
+			pairs := decodeMatches(t, stdout.String())
+			if !pairs[copied] {
+				t.Errorf("copied pair missing from %v", pairs)
+			}
+			if pairs[short] != tt.wantShort {
+				t.Errorf("short pair reported %v, want %v", pairs[short], tt.wantShort)
+			}
+			if pairs[duplicatecheck.PairKey("D1", "D2")] {
+				t.Error("ordinary duplicate reported")
+			}
+		})
+	}
+
+	for _, args := range [][]string{
+		{"copied-descriptions"},
+		{"copied-descriptions", "--catalog", path, "--threshold", "2"},
+		{"copied-descriptions", "--catalog", path, "--max-name-similarity", "-1"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != 2 {
+			t.Errorf("%v: exit %d, want 2", args, code)
+		}
+	}
+}
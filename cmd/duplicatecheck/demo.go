@@ -0,0 +1,193 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/solrac97gr/duplicatecheck"
+	"github.com/solrac97gr/duplicatecheck/report"
+)
+
+// indexer is implemented by engines that need an index before scanning
+type indexer interface {
+	BuildIndex(products []duplicatecheck.Product) error
+}
+
+// candidateEstimator is implemented by engines that prune candidates (e.g. LSH)
+type candidateEstimator interface {
+	EstimateCandidateReduction(product duplicatecheck.Product) int
+}
+
+// demoPair is an example pair shown in the side-by-side table
+type demoPair struct {
+	label string
+	a, b  duplicatecheck.Product
+}
+
+// demoPairs returns the example pairs run through every engine
+func demoPairs() []demoPair {
+	return []demoPair{
+		{
+			label: "Typo in model number",
+			a:     duplicatecheck.Product{ID: "1", Name: "Apple iPhone 14 Pro", Description: "128GB Space Black, unlocked"},
+			b:     duplicatecheck.Product{ID: "2", Name: "Apple iPhone 14 Pro", Description: "128GB Space Black unlocked"},
+		},
+		{
+			label: "Same product, different seller copy",
+			a:     duplicatecheck.Product{ID: "3", Name: "Sony WH-1000XM5 Headphones", Description: "Wireless noise cancelling over-ear headphones"},
+			b:     duplicatecheck.Product{ID: "4", Name: "Sony WH1000XM5 Headphones", Description: "Noise cancelling wireless over-ear headphones"},
+		},
+		{
+			label: "Related but different models",
+			a:     duplicatecheck.Product{ID: "5", Name: "Samsung Galaxy S21", Description: "256GB Phantom Black"},
+			b:     duplicatecheck.Product{ID: "6", Name: "Samsung Galaxy S22", Description: "256GB Phantom Black"},
+		},
+		{
+			label: "Unrelated products",
+			a:     duplicatecheck.Product{ID: "7", Name: "Organic Cotton T-Shirt", Description: "Soft crew neck tee"},
+			b:     duplicatecheck.Product{ID: "8", Name: "Stainless Steel Water Bottle", Description: "Insulated 750ml"},
+		},
+	}
+}
+
+// handleDemo runs the example pairs and a generated scan through every available engine
+func handleDemo(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("demo", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	pairsOnly := flags.Bool("pairs-only", false, "only compare the example pairs, skip the catalog scan")
+	scanSize := flags.Int("scan-size", 500, "number of generated products for the FindDuplicates scan")
+	threshold := flags.Float64("threshold", duplicatecheck.DefaultThreshold, "similarity threshold for pair verdicts and the catalog scan")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *scanSize < 2 {
+		fmt.Fprintln(stderr, "--scan-size must be at least 2")
+		return 2
+	}
+
+	var engines []duplicatecheck.DuplicateCheckEngine
+	for _, newEngine := range duplicatecheck.AvailableEngines() {
+		engine := newEngine()
+		if te, ok := engine.(duplicatecheck.DefaultThresholdEngine); ok {
+			if err := te.SetDefaultThreshold(*threshold); err != nil {
+				fmt.Fprintf(stderr, "--threshold: %v\n", err)
+				return 2
+			}
+		}
+		engines = append(engines, engine)
+	}
+
+	printPairTable(stdout, engines, demoPairs())
+
+	if *pairsOnly {
+		return 0
+	}
+
+	fmt.Fprintln(stdout)
+	catalog := generateDemoCatalog(*scanSize)
+	if err := printScanTable(stdout, engines, catalog, *threshold); err != nil {
+		fmt.Fprintf(stderr, "scan failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printPairTable prints per-pair similarity from each engine
+func printPairTable(w io.Writer, engines []duplicatecheck.DuplicateCheckEngine, pairs []demoPair) {
+	fmt.Fprintln(w, "Example pairs")
+	fmt.Fprintln(w, strings.Repeat("=", 72))
+	for _, pair := range pairs {
+		fmt.Fprintf(w, "%s\n  A: %s\n  B: %s\n", pair.label, pair.a.Name, pair.b.Name)
+		for _, engine := range engines {
+			result := engine.Compare(pair.a, pair.b)
+			verdict := ""
+			if result.MeetsThreshold {
+				verdict = "duplicate"
+			}
+			fmt.Fprintf(w, "  %-36s %s %6.2f%%  %s\n",
+				engine.GetName(), renderBar(result.CombinedSimilarity, 20), result.CombinedSimilarity*100, verdict)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// printScanTable runs FindDuplicates on the catalog with each engine and prints timing
+func printScanTable(w io.Writer, engines []duplicatecheck.DuplicateCheckEngine, catalog []duplicatecheck.Product, threshold float64) error {
+	fmt.Fprintf(w, "FindDuplicates on %d generated products (threshold %.2f)\n", len(catalog), threshold)
+	fmt.Fprintln(w, strings.Repeat("=", 72))
+	fmt.Fprintf(w, "%-36s %12s %14s %10s\n", "Engine", "Scan time", "Comparisons", "Found")
+
+	allPairs := len(catalog) * (len(catalog) - 1) / 2
+	for _, engine := range engines {
+		start := time.Now()
+		if idx, ok := engine.(indexer); ok {
+			if err := idx.BuildIndex(catalog); err != nil {
+				return fmt.Errorf("%s: %w", engine.GetName(), err)
+			}
+		}
+		var duplicates []duplicatecheck.ComparisonResult
+		if te, ok := engine.(duplicatecheck.DefaultThresholdEngine); ok {
+			duplicates = te.FindDuplicatesDefault(catalog)
+		} else {
+			duplicates = engine.FindDuplicates(catalog, threshold)
+		}
+		elapsed := time.Since(start)
+
+		comparisons := allPairs
+		if est, ok := engine.(candidateEstimator); ok {
+			candidates := 0
+			for _, product := range catalog {
+				candidates += est.EstimateCandidateReduction(product) - 1 // minus self
+			}
+			comparisons = candidates / 2
+		}
+
+		fmt.Fprintf(w, "%-36s %12s %14d %10d\n",
+			engine.GetName(), elapsed.Round(time.Microsecond), comparisons, len(duplicates))
+	}
+	return nil
+}
+
+// renderBar draws a fixed-width bar for a value in [0,1]
+func renderBar(value float64, width int) string {
+	return report.NewBar(value, width).String()
+}
+
+// generateDemoCatalog builds a deterministic catalog where every 10th product
+// is a near-duplicate of the previous one
+func generateDemoCatalog(size int) []duplicatecheck.Product {
+	rng := rand.New(rand.NewSource(1))
+	brands := []string{"Apple", "Samsung", "Sony", "Lenovo", "Dell", "Bose", "Nike", "Adidas", "Canon", "Philips"}
+	items := []string{"Laptop", "Phone", "Headphones", "Monitor", "Running Shoes", "Camera", "Speaker", "Tablet", "Watch", "Blender"}
+	features := []string{"lightweight", "wireless", "waterproof", "fast charging", "premium build", "long battery life",
+		"compact design", "high resolution", "noise cancelling", "ergonomic"}
+
+	catalog := make([]duplicatecheck.Product, 0, size)
+	for i := 0; i < size; i++ {
+		if i%10 == 9 {
+			prev := catalog[i-1]
+			catalog = append(catalog, duplicatecheck.Product{
+				ID:          fmt.Sprintf("P%05d", i),
+				Name:        prev.Name + "!",
+				Description: prev.Description + " Ships fast.",
+			})
+			continue
+		}
+
+		desc := make([]string, 0, 4)
+		for j := 0; j < 4; j++ {
+			desc = append(desc, features[rng.Intn(len(features))])
+		}
+		catalog = append(catalog, duplicatecheck.Product{
+			ID: fmt.Sprintf("P%05d", i),
+			Name: fmt.Sprintf("%s %s %d", brands[rng.Intn(len(brands))],
+				items[rng.Intn(len(items))], 100+rng.Intn(900)),
+			Description: "Features: " + strings.Join(desc, ", ") + ".",
+		})
+	}
+	return catalog
+}
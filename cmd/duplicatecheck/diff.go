@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+// diffOptions configures a diff run
+type diffOptions struct {
+	previous  string  // Previous results (or catalog with --catalogs)
+	current   string  // Current results (or catalog with --catalogs)
+	catalogs  bool    // Inputs are catalogs to scan rather than results files
+	engine    string  // "levenshtein" or "hybrid" (catalogs only)
+	threshold float64 // Similarity threshold (catalogs only)
+	minDelta  float64 // Similarity change reported as "changed"
+}
+
+// diffLine is one pair in diff's JSON-lines output
+type diffLine struct {
+	Change     string   `json:"change"` // "added", "removed", or "changed"
+	ProductAID string   `json:"product_a"`
+	ProductBID string   `json:"product_b"`
+	Previous   *float64 `json:"previous_similarity,omitempty"`
+	Current    *float64 `json:"current_similarity,omitempty"`
+}
+
+// handleDiff compares two duplicate scans and writes the changed pairs as JSON lines
+func handleDiff(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var opts diffOptions
+	flags.StringVar(&opts.previous, "previous", "", "previous results, JSONL or .csv (required)")
+	flags.StringVar(&opts.current, "current", "", "current results, JSONL or .csv (required)")
+	flags.BoolVar(&opts.catalogs, "catalogs", false, "treat --previous and --current as catalogs and scan both")
+	flags.StringVar(&opts.engine, "engine", "levenshtein", "engine to scan catalogs with: levenshtein or hybrid")
+	flags.Float64Var(&opts.threshold, "threshold", duplicatecheck.DefaultThreshold, "similarity threshold when scanning catalogs")
+	flags.Float64Var(&opts.minDelta, "min-delta", 0.01, "similarity change a pair must exceed to be reported as changed")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if opts.previous == "" || opts.current == "" {
+		fmt.Fprintln(stderr, "--previous and --current are required")
+		return 2
+	}
+	if opts.engine != "levenshtein" && opts.engine != "hybrid" {
+		fmt.Fprintf(stderr, "unknown --engine %q (want levenshtein or hybrid)\n", opts.engine)
+		return 2
+	}
+	if opts.threshold < 0 || opts.threshold > 1 {
+		fmt.Fprintln(stderr, "--threshold must be in [0, 1]")
+		return 2
+	}
+	if opts.minDelta < 0 {
+		fmt.Fprintln(stderr, "--min-delta must not be negative")
+		return 2
+	}
+
+	diff, err := diffInputs(opts, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "diff: %v\n", err)
+		return 1
+	}
+	if err := writeDiff(stdout, diff); err != nil {
+		fmt.Fprintf(stderr, "diff: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "%d added, %d removed, %d changed", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	if len(diff.RemovedProducts) > 0 {
+		fmt.Fprintf(stderr, " (%d products removed)", len(diff.RemovedProducts))
+	}
+	fmt.Fprintln(stderr)
+	return 0
+}
+
+// diffInputs loads both inputs and diffs them
+func diffInputs(opts diffOptions, stderr io.Writer) (duplicatecheck.ResultsDiff, error) {
+	diffOpts := duplicatecheck.DiffOptions{MinDelta: opts.minDelta}
+	if opts.catalogs {
+		previous, err := loadCatalog(opts.previous, stderr)
+		if err != nil {
+			return duplicatecheck.ResultsDiff{}, err
+		}
+		current, err := loadCatalog(opts.current, stderr)
+		if err != nil {
+			return duplicatecheck.ResultsDiff{}, err
+		}
+		var engine duplicatecheck.DuplicateCheckEngine = duplicatecheck.NewLevenshteinEngine()
+		if opts.engine == "hybrid" {
+			engine = duplicatecheck.NewHybridEngine()
+		}
+		return duplicatecheck.DiffCatalogs(engine, previous, current, opts.threshold, diffOpts)
+	}
+
+	previous, err := loadResults(opts.previous)
+	if err != nil {
+		return duplicatecheck.ResultsDiff{}, err
+	}
+	current, err := loadResults(opts.current)
+	if err != nil {
+		return duplicatecheck.ResultsDiff{}, err
+	}
+	return duplicatecheck.DiffResultRefs(previous, current, diffOpts), nil
+}
+
+// loadResults reads a results file, as CSV if it ends in .csv and JSONL otherwise
+func loadResults(path string) ([]duplicatecheck.ResultRef, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	format := duplicatecheck.ResultFileJSONL
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		format = duplicatecheck.ResultFileCSV
+	}
+	refs, err := duplicatecheck.ReadResultRefs(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return refs, nil
+}
+
+// writeDiff writes added, removed, then changed pairs as JSON lines
+func writeDiff(w io.Writer, diff duplicatecheck.ResultsDiff) error {
+	out := bufio.NewWriter(w)
+	encoder := json.NewEncoder(out)
+	for i := range diff.Added {
+		ref := &diff.Added[i]
+		if err := encoder.Encode(diffLine{Change: "added", ProductAID: ref.ProductAID, ProductBID: ref.ProductBID, Current: &ref.CombinedSimilarity}); err != nil {
+			return err
+		}
+	}
+	for i := range diff.Removed {
+		ref := &diff.Removed[i]
+		if err := encoder.Encode(diffLine{Change: "removed", ProductAID: ref.ProductAID, ProductBID: ref.ProductBID, Previous: &ref.CombinedSimilarity}); err != nil {
+			return err
+		}
+	}
+	for i := range diff.Changed {
+		c := &diff.Changed[i]
+		line := diffLine{Change: "changed", ProductAID: c.Current.ProductAID, ProductBID: c.Current.ProductBID, Previous: &c.Previous.CombinedSimilarity, Current: &c.Current.CombinedSimilarity}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// decodeDiff parses diff's JSON-lines output into change by pair
+func decodeDiff(t *testing.T, out string) map[string]string {
+	t.Helper()
+	changes := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		var d diffLine
+		if err := json.Unmarshal([]byte(line), &d); err != nil {
+			t.Fatalf("malformed diff line %q: %v", line, err)
+		}
+		changes[d.ProductAID+"|"+d.ProductBID] = d.Change
+	}
+	return changes
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	previous := write("old.jsonl", `{"product_a":"A","product_b":"B","combined_similarity":0.9}
+{"product_a":"C","product_b":"D","combined_similarity":0.86}
+{"product_a":"E","product_b":"F","combined_similarity":0.95}
+`)
+	current := write("new.csv", "product_a,product_b,combined_similarity\nB,A,0.905\nD,C,0.97\nG,H,0.99\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{"default min delta", []string{"diff", "--previous", previous, "--current", current},
+			map[string]string{"C|D": "changed", "E|F": "removed", "G|H": "added"}},
+		{"zero min delta", []string{"diff", "--previous", previous, "--current", current, "--min-delta", "0"},
+			map[string]string{"A|B": "changed", "C|D": "changed", "E|F": "removed", "G|H": "added"}},
+		{"same run", []string{"diff", "--previous", previous, "--current", previous}, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != 0 {
+				t.Fatalf("exit code %d: %s", code, stderr.String())
+			}
+			if got := decodeDiff(t, stdout.String()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("catalogs", func(t *testing.T) {
+		old := findCatalog(t)
+		edited := write("edited.jsonl", productLine(t, "P1", "Sony WH-1000XM5 Headphones", "Wireless noise cancelling over-ear headphones")+
+			productLine(t, "P3", "Organic Cotton T-Shirt", "Soft crew neck tee in heather grey")+
+			productLine(t, "P4", "Organic Cotton T-Shirt", "Soft crew neck tee in heather grey"))
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"diff", "--catalogs", "--previous", old, "--current", edited}, &stdout, &stderr); code != 0 {
+			t.Fatalf("exit code %d: %s", code, stderr.String())
+		}
+		want := map[string]string{"P1|P2": "removed", "J1|J2": "removed", "P3|P4": "added"}
+		if got := decodeDiff(t, stdout.String()); !reflect.DeepEqual(got, want) {
+			t.Errorf("diff = %v, want %v", got, want)
+		}
+		if !strings.Contains(stderr.String(), "3 products removed") {
+			t.Errorf("summary = %q", stderr.String())
+		}
+	})
+
+	t.Run("bad arguments", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"diff", "--previous", previous},
+			{"diff", "--previous", previous, "--current", current, "--min-delta", "-1"},
+			{"diff", "--previous", previous, "--current", current, "--engine", "bogus"},
+		} {
+			if code := run(args, &bytes.Buffer{}, &bytes.Buffer{}); code != 2 {
+				t.Errorf("%v: exit code %d, want 2", args, code)
+			}
+		}
+		if code := run([]string{"diff", "--previous", previous, "--current", filepath.Join(dir, "missing.jsonl")}, &bytes.Buffer{}, &bytes.Buffer{}); code != 1 {
+			t.Errorf("missing file: exit code %d, want 1", code)
+		}
+	})
+}
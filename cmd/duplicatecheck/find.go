@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/solrac97gr/duplicatecheck"
+	"github.com/solrac97gr/duplicatecheck/report"
+)
+
+// findOptions configures a find run
+type findOptions struct {
+	catalog    string    // JSON array or JSONL catalog file
+	engine     string    // "levenshtein" or "hybrid"
+	threshold  float64   // Similarity threshold for matches
+	minQuality float64   // Products scoring below this are left out (0 = keep all)
+	report     string    // HTML or Markdown review report written here (empty = none)
+	statsOnly  bool      // Print estimated DuplicateStats instead of matches
+	tiers      []float64 // Severity tiers, highest first; replaces threshold when set
+	sample     int       // Products sampled for an estimated duplicate rate (0 = scan all)
+	summary    bool      // Print a ResultsSummary of the matches as JSON on stderr
+
+	confirmOver time.Duration // Scans estimated to take longer need yes (0 = never)
+	yes         bool          // Run scans estimated to take longer than confirmOver
+}
+
+// handleFind scans a catalog for duplicates and writes the matches as JSON lines
+func handleFind(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("find", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var opts findOptions
+	flags.StringVar(&opts.catalog, "catalog", "", "JSON array or JSONL catalog file (required)")
+	flags.StringVar(&opts.engine, "engine", "levenshtein", "engine to scan with: levenshtein or hybrid")
+	flags.Float64Var(&opts.threshold, "threshold", duplicatecheck.DefaultThreshold, "similarity threshold for matches")
+	flags.Float64Var(&opts.minQuality, "min-quality", 0, "leave out products whose quality score is below this (0 = off)")
+	flags.StringVar(&opts.report, "report", "", "also write a review report to this .html or .md file")
+	flags.BoolVar(&opts.statsOnly, "stats-only", false, "print estimated duplicate counts as JSON instead of matches (hybrid index, no verification)")
+	flags.Func("tiers", "comma-separated severity tiers, highest first (e.g. 0.97,0.88,0.80); scans once at the lowest and counts matches per tier", func(value string) (err error) {
+		opts.tiers, err = parseTiers(value)
+		return err
+	})
+	flags.IntVar(&opts.sample, "sample", 0, "print a duplicate rate estimated from this many sampled products as JSON instead of matches (hybrid engine)")
+	flags.BoolVar(&opts.summary, "summary", false, "print aggregate statistics of the matches (bands, match types, reasons, brands) as JSON on stderr")
+	flags.DurationVar(&opts.confirmOver, "confirm-over", 10*time.Minute, "refuse scans estimated to take longer than this unless --yes is given (0 = never)")
+	flags.BoolVar(&opts.yes, "yes", false, "run the scan even when it is estimated to take longer than --confirm-over")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if opts.catalog == "" {
+		fmt.Fprintln(stderr, "--catalog is required")
+		return 2
+	}
+	if opts.engine != "levenshtein" && opts.engine != "hybrid" {
+		fmt.Fprintf(stderr, "unknown --engine %q (want levenshtein or hybrid)\n", opts.engine)
+		return 2
+	}
+	if opts.threshold < 0 || opts.threshold > 1 {
+		fmt.Fprintln(stderr, "--threshold must be in [0, 1]")
+		return 2
+	}
+	if opts.minQuality < 0 || opts.minQuality > 1 {
+		fmt.Fprintln(stderr, "--min-quality must be in [0, 1]")
+		return 2
+	}
+	if opts.report != "" && reportFormat(opts.report) == "" {
+		fmt.Fprintf(stderr, "--report %q must end in .html, .htm, .md or .markdown\n", opts.report)
+		return 2
+	}
+	if opts.statsOnly && opts.report != "" {
+		fmt.Fprintln(stderr, "--stats-only lists no matches to --report")
+		return 2
+	}
+	if opts.statsOnly && len(opts.tiers) > 0 {
+		fmt.Fprintln(stderr, "--stats-only counts no tiers")
+		return 2
+	}
+	if opts.sample < 0 {
+		fmt.Fprintln(stderr, "--sample must not be negative")
+		return 2
+	}
+	if opts.summary && opts.statsOnly {
+		fmt.Fprintln(stderr, "--stats-only lists no matches to --summary")
+		return 2
+	}
+	if opts.sample > 0 && (opts.statsOnly || opts.report != "" || len(opts.tiers) > 0 || opts.summary) {
+		fmt.Fprintln(stderr, "--sample lists no matches; it cannot be combined with --stats-only, --report, --tiers or --summary")
+		return 2
+	}
+	if opts.sample > 0 && opts.engine != "hybrid" {
+		fmt.Fprintln(stderr, "--sample needs --engine hybrid")
+		return 2
+	}
+
+	products, err := loadCatalog(opts.catalog, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "find: %v\n", err)
+		return 1
+	}
+	if opts.statsOnly {
+		return printDuplicateStats(products, opts, stdout, stderr)
+	}
+	if opts.sample > 0 {
+		return printSample(products, opts, stdout, stderr)
+	}
+	if !confirmScan(products, opts, stderr) {
+		return 1
+	}
+	engine, err := newFindEngine(products, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "find: %v\n", err)
+		return 1
+	}
+	var results []duplicatecheck.ComparisonResult
+	var tiered duplicatecheck.TieredResults
+	var summary *duplicatecheck.ScanSummary
+	if len(opts.tiers) > 0 {
+		tiered, err = engine.FindDuplicatesTiered(products, opts.tiers)
+		results = tiered.All()
+	} else {
+		var s duplicatecheck.ScanSummary
+		results, s, err = engine.FindDuplicatesWithSummary(products, opts.threshold)
+		summary = &s
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "find: %v\n", err)
+		return 1
+	}
+
+	out := bufio.NewWriter(stdout)
+	encoder := json.NewEncoder(out)
+	for i := range results {
+		if err := encoder.Encode(results[i].Ref()); err != nil {
+			fmt.Fprintf(stderr, "find: %v\n", err)
+			return 1
+		}
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(stderr, "find: %v\n", err)
+		return 1
+	}
+	if opts.report != "" {
+		if err := writeReport(opts.report, results, engine.ConfigFingerprint()); err != nil {
+			fmt.Fprintf(stderr, "find: %v\n", err)
+			return 1
+		}
+	}
+	for _, tier := range tiered.Tiers {
+		fmt.Fprintf(stderr, "tier %v: %d matches\n", tier.Threshold, len(tier.Results))
+	}
+	fmt.Fprintf(stderr, "%d products, %d matches\n", len(products), len(results))
+	if summary != nil {
+		printScanSummary(stderr, *summary)
+	}
+	if opts.summary {
+		if err := printResultsSummary(stderr, results, opts.tiers); err != nil {
+			fmt.Fprintf(stderr, "find: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// printResultsSummary writes SummarizeResults of results as indented JSON,
+// banded by tiers (nil = the default bands) and grouped by brand, taken as the
+// first word of a product's name
+func printResultsSummary(w io.Writer, results []duplicatecheck.ComparisonResult, tiers []float64) error {
+	summary, err := duplicatecheck.SummarizeResults(results, duplicatecheck.SummaryOptions{
+		Tiers:   tiers,
+		GroupBy: nameBrand,
+	})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summary)
+}
+
+// nameBrand returns the first word of a product's name, lowercased, as its brand
+func nameBrand(p duplicatecheck.Product) string {
+	if fields := strings.Fields(p.Name); len(fields) > 0 {
+		return strings.ToLower(fields[0])
+	}
+	return ""
+}
+
+// printScanSummary writes what a scan did, one "scan:" line per topic
+func printScanSummary(w io.Writer, s duplicatecheck.ScanSummary) {
+	fmt.Fprintf(w, "scan: %s engine, %d products, %d candidate pairs, %d compared, %d name distances, %d duplicates\n",
+		s.Engine, s.Products, s.CandidatePairs, s.Comparisons, s.NameDistances, s.Duplicates)
+	fmt.Fprintf(w, "scan: skipped %d by length, %d by charset, %d by constraint, %d by simhash; %d rabin-karp rejections, %d descriptions skipped, %d clique pairs inferred\n",
+		s.LengthPruned, s.CharsetPruned, s.ConstraintSkipped, s.SimHashSkipped, s.RabinKarpRejected, s.DescriptionsSkipped, s.CliqueInferred)
+	if s.Engine == "hybrid" {
+		fmt.Fprintf(w, "scan: %d lookups probed %d bands, %d candidates\n", s.CandidateLookups, s.BandsProbed, s.CandidatesFound)
+	}
+	fmt.Fprintf(w, "scan: %d worker(s), %v\n", s.Workers, s.WallTime.Round(time.Millisecond))
+}
+
+// confirmScan prints the estimated cost of the scan opts configures, and
+// reports whether to run it: always, unless it is estimated to take longer
+// than --confirm-over without --yes
+func confirmScan(products []duplicatecheck.Product, opts findOptions, stderr io.Writer) bool {
+	threshold, kind := opts.threshold, duplicatecheck.EngineLevenshtein
+	if len(opts.tiers) > 0 {
+		threshold = opts.tiers[len(opts.tiers)-1]
+	}
+	if opts.engine == "hybrid" {
+		kind = duplicatecheck.EngineHybrid
+	}
+	estimate := duplicatecheck.EstimateScanCost(products, threshold, kind)
+	fmt.Fprintf(stderr, "estimate: %.0f pairs on %d worker(s), about %v, about %.0f matches (%.1f MB)\n",
+		estimate.Pairs, estimate.Workers, estimate.Duration.Round(time.Millisecond), estimate.Matches,
+		float64(estimate.ResultBytes)/1e6)
+	if estimate.Recommendation != "" {
+		fmt.Fprintf(stderr, "estimate: %s\n", estimate.Recommendation)
+	}
+	if opts.confirmOver > 0 && estimate.Duration > opts.confirmOver && !opts.yes {
+		fmt.Fprintf(stderr, "find: the scan is estimated to take %v, over --confirm-over %v; pass --yes to run it\n",
+			estimate.Duration.Round(time.Millisecond), opts.confirmOver)
+		return false
+	}
+	return true
+}
+
+// parseTiers parses --tiers: comma-separated thresholds in (0, 1], highest first
+func parseTiers(value string) ([]float64, error) {
+	var tiers []float64
+	for _, field := range strings.Split(value, ",") {
+		tier, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("tier %q is not a number", field)
+		}
+		if tier <= 0 || tier > 1 {
+			return nil, fmt.Errorf("tier %v must be in (0, 1]", tier)
+		}
+		if len(tiers) > 0 && tier >= tiers[len(tiers)-1] {
+			return nil, fmt.Errorf("tiers must decrease, but %v follows %v", tier, tiers[len(tiers)-1])
+		}
+		tiers = append(tiers, tier)
+	}
+	return tiers, nil
+}
+
+// printDuplicateStats writes EstimateDuplicateStats for products as indented JSON
+func printDuplicateStats(products []duplicatecheck.Product, opts findOptions, stdout, stderr io.Writer) int {
+	engine := duplicatecheck.NewHybridEngine().WithQualityFilter(qualityFilter(opts), duplicatecheck.QualityExclude)
+	stats, err := engine.EstimateDuplicateStatsWithOptions(products, opts.threshold, duplicatecheck.DuplicateStatsOptions{})
+	if err != nil {
+		fmt.Fprintf(stderr, "find: %v\n", err)
+		return 1
+	}
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(stats); err != nil {
+		fmt.Fprintf(stderr, "find: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printSample writes SampleDuplicateRate for products as indented JSON
+func printSample(products []duplicatecheck.Product, opts findOptions, stdout, stderr io.Writer) int {
+	engine := duplicatecheck.NewHybridEngine().WithQualityFilter(qualityFilter(opts), duplicatecheck.QualityExclude)
+	sample, err := engine.SampleDuplicateRate(products, opts.threshold, duplicatecheck.SampleOptions{Size: opts.sample})
+	if err != nil {
+		fmt.Fprintf(stderr, "find: %v\n", err)
+		return 1
+	}
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(sample); err != nil {
+		fmt.Fprintf(stderr, "find: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// reportFormat returns "html" or "markdown" for a report path by extension,
+// or "" if the extension is neither
+func reportFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return "html"
+	case ".md", ".markdown":
+		return "markdown"
+	}
+	return ""
+}
+
+// writeReport writes a review report of results to path, in the format its
+// extension names, headed by the fingerprint of the engine configuration
+func writeReport(path string, results []duplicatecheck.ComparisonResult, fingerprint string) error {
+	options := report.DefaultReportOptions()
+	options.ConfigFingerprint = fingerprint
+	r := report.GenerateReport(results, options)
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if reportFormat(path) == "html" {
+		err = r.WriteHTML(file)
+	} else {
+		err = r.WriteMarkdown(file)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// findEngine is what a find run needs of either engine
+type findEngine interface {
+	FindDuplicatesWithSummary(products []duplicatecheck.Product, threshold float64) ([]duplicatecheck.ComparisonResult, duplicatecheck.ScanSummary, error)
+	FindDuplicatesTiered(products []duplicatecheck.Product, tiers []float64) (duplicatecheck.TieredResults, error)
+	ConfigFingerprint() string
+}
+
+// newFindEngine returns the engine opts configures, its index built on products
+func newFindEngine(products []duplicatecheck.Product, opts findOptions) (findEngine, error) {
+	filter := qualityFilter(opts)
+	if opts.engine == "hybrid" {
+		engine := duplicatecheck.NewHybridEngine().WithQualityFilter(filter, duplicatecheck.QualityExclude)
+		if err := engine.BuildIndex(products); err != nil {
+			return nil, err
+		}
+		return engine, nil
+	}
+	return duplicatecheck.NewLevenshteinEngine().WithQualityFilter(filter, duplicatecheck.QualityExclude), nil
+}
+
+// qualityFilter returns the filter for --min-quality, or nil when it is off
+func qualityFilter(opts findOptions) *duplicatecheck.QualityFilter {
+	if opts.minQuality <= 0 {
+		return nil
+	}
+	filter := duplicatecheck.DefaultQualityFilter()
+	filter.MinScore = opts.minQuality
+	return filter
+}
+
+// loadCatalog reads a catalog file holding either a JSON array of products or
+// one product per line (JSONL)
+// Malformed JSONL lines are reported on stderr and skipped.
+func loadCatalog(path string, stderr io.Writer) ([]duplicatecheck.Product, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var products []duplicatecheck.Product
+		if err := json.Unmarshal(trimmed, &products); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return products, nil
+	}
+
+	var products []duplicatecheck.Product
+	for number, text := range bytes.Split(data, []byte("\n")) {
+		if product, ok := parseCatalogLine(catalogLine{number: number + 1, text: text}, stderr); ok {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
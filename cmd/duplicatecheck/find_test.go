@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+// findCatalog is a JSONL catalog with one real duplicate pair and one junk pair
+func findCatalog(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "catalog.jsonl")
+	text := productLine(t, "P1", "Sony WH-1000XM5 Headphones", "Wireless noise cancelling over-ear headphones") +
+		productLine(t, "P2", "Sony WH-1000XM5 Headphones", "Wireless noise cancelling over-ear headphones.") +
+		productLine(t, "P3", "Organic Cotton T-Shirt", "Soft crew neck tee in heather grey") +
+		"\n" +
+		productLine(t, "J1", "test", "test") +
+		productLine(t, "J2", "test", "test")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// decodeMatches parses find's JSON-lines output into pair keys
+func decodeMatches(t *testing.T, out string) map[string]bool {
+	t.Helper()
+	pairs := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		var ref duplicatecheck.ResultRef
+		if err := json.Unmarshal([]byte(line), &ref); err != nil {
+			t.Fatalf("malformed match line %q: %v", line, err)
+		}
+		pairs[duplicatecheck.PairKey(ref.ProductAID, ref.ProductBID)] = true
+	}
+	return pairs
+}
+
+func TestFind(t *testing.T) {
+	catalog := findCatalog(t)
+	real, junk := duplicatecheck.PairKey("P1", "P2"), duplicatecheck.PairKey("J1", "J2")
+	tests := []struct {
+		name     string
+		args     []string
+		wantJunk bool
+	}{
+		{"levenshtein", []string{"find", "--catalog", catalog}, true},
+		{"hybrid", []string{"find", "--catalog", catalog, "--engine", "hybrid"}, true},
+		{"min quality", []string{"find", "--catalog", catalog, "--min-quality", "0.75"}, false},
+		{"hybrid min quality", []string{"find", "--catalog", catalog, "--engine", "hybrid", "--min-quality", "0.75"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != 0 {
+				t.Fatalf("find exited with %d: %s", code, stderr.String())
+			}
+			pairs := decodeMatches(t, stdout.String())
+			if !pairs[real] {
+				t.Errorf("missing real pair P1 ↔ P2: %v", pairs)
+			}
+			if pairs[junk] != tt.wantJunk {
+				t.Errorf("junk pair reported = %v, want %v", pairs[junk], tt.wantJunk)
+			}
+			if !strings.Contains(stderr.String(), "candidate pairs") || !strings.Contains(stderr.String(), "worker(s)") {
+				t.Errorf("no scan summary on stderr: %s", stderr.String())
+			}
+		})
+	}
+}
+
+func TestFindReport(t *testing.T) {
+	catalog := findCatalog(t)
+	tests := []struct {
+		file string
+		want string // Only in this format
+	}{
+		{"report.html", "<style>"},
+		{"report.md", "## Summary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			var stdout, stderr bytes.Buffer
+			if code := run([]string{"find", "--catalog", catalog, "--report", path}, &stdout, &stderr); code != 0 {
+				t.Fatalf("find exited with %d: %s", code, stderr.String())
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range []string{tt.want, "Sony WH-1000XM5 Headphones", "P1", "P2", "Engine configuration"} {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("Report lacks %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestFindStatsOnly(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"find", "--catalog", findCatalog(t), "--stats-only"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("find exited with %d: %s", code, stderr.String())
+	}
+	var stats duplicatecheck.DuplicateStats
+	if err := json.Unmarshal(stdout.Bytes(), &stats); err != nil {
+		t.Fatalf("stats output %q: %v", stdout.String(), err)
+	}
+	if stats.Products != 5 || stats.Pairs == 0 || stats.ProductsWithDuplicates < 2 || stats.Exact || stats.Note == "" {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestFindSample(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"find", "--catalog", findCatalog(t), "--engine", "hybrid", "--sample", "3"}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("find exited with %d: %s", code, stderr.String())
+	}
+	var sample duplicatecheck.SampleReport
+	if err := json.Unmarshal(stdout.Bytes(), &sample); err != nil {
+		t.Fatalf("sample output %q: %v", stdout.String(), err)
+	}
+	if sample.Products != 5 || sample.Sampled != 3 || len(sample.Samples) != 3 || sample.Confidence != duplicatecheck.DefaultSampleConfidence {
+		t.Errorf("sample = %+v", sample)
+	}
+	if sample.DuplicateRateLow > sample.DuplicateRate || sample.DuplicateRate > sample.DuplicateRateHigh {
+		t.Errorf("rate %v outside [%v, %v]", sample.DuplicateRate, sample.DuplicateRateLow, sample.DuplicateRateHigh)
+	}
+}
+
+func TestFindTiers(t *testing.T) {
+	for _, engine := range []string{"levenshtein", "hybrid"} {
+		t.Run(engine, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := []string{"find", "--catalog", findCatalog(t), "--engine", engine, "--tiers", "0.97, 0.88,0.80"}
+			if code := run(args, &stdout, &stderr); code != 0 {
+				t.Fatalf("find exited with %d: %s", code, stderr.String())
+			}
+			pairs := decodeMatches(t, stdout.String())
+			if !pairs[duplicatecheck.PairKey("P1", "P2")] {
+				t.Errorf("missing real pair P1 ↔ P2: %v", pairs)
+			}
+			for _, want := range []string{"tier 0.97: 2 matches", "tier 0.88: 0 matches", "tier 0.8: 0 matches", "2 matches"} {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("stderr %q lacks %q", stderr.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestFindSummary(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"find", "--catalog", findCatalog(t), "--summary", "--tiers", "0.97,0.80"}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("find exited with %d: %s", code, stderr.String())
+	}
+	text := stderr.String()
+	start := strings.Index(text, "{\n")
+	if start < 0 {
+		t.Fatalf("no summary on stderr: %s", text)
+	}
+	var summary duplicatecheck.ResultsSummary
+	if err := json.Unmarshal([]byte(text[start:]), &summary); err != nil {
+		t.Fatalf("malformed summary: %v\n%s", err, text[start:])
+	}
+	if summary.Pairs != 2 || summary.Products != 4 || len(summary.Bands) != 2 || summary.Bands[0].Pairs != 2 {
+		t.Errorf("summary %+v, want 2 pairs of 4 products in the 0.97 band", summary)
+	}
+	if len(summary.Groups) != 2 || summary.Groups[0].Group != "sony" || summary.Groups[1].Group != "test" {
+		t.Errorf("groups %+v, want sony and test", summary.Groups)
+	}
+}
+
+func TestFindConfirmOver(t *testing.T) {
+	catalog := findCatalog(t)
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"under the limit", []string{"--confirm-over", "1h"}, 0},
+		{"over the limit", []string{"--confirm-over", "1ns"}, 1},
+		{"over the limit with --yes", []string{"--confirm-over", "1ns", "--yes"}, 0},
+		{"no limit", []string{"--confirm-over", "0"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"find", "--catalog", catalog}, tt.args...)
+			if code := run(args, &stdout, &stderr); code != tt.code {
+				t.Fatalf("find exited with %d, want %d: %s", code, tt.code, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), "estimate: ") {
+				t.Errorf("stderr %q lacks the estimate", stderr.String())
+			}
+			if refused := strings.Contains(stderr.String(), "pass --yes"); refused != (tt.code == 1) || (stdout.Len() == 0) != refused {
+				t.Errorf("refused = %v with output %q", refused, stdout.String())
+			}
+		})
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	dir := t.TempDir()
+	array := filepath.Join(dir, "catalog.json")
+	if err := os.WriteFile(array, []byte(`[{"id": "A"}, {"id": "B"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lines := filepath.Join(dir, "catalog.jsonl")
+	if err := os.WriteFile(lines, []byte("{\"id\": \"A\"}\n{oops}\n\n{\"id\": \"B\"}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{array, lines} {
+		var stderr bytes.Buffer
+		products, err := loadCatalog(path, &stderr)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if len(products) != 2 || products[0].ID != "A" || products[1].ID != "B" {
+			t.Errorf("%s: got %v, want products A and B", path, products)
+		}
+	}
+}
+
+func TestFindRejectsBadFlags(t *testing.T) {
+	tests := [][]string{
+		{"find"},
+		{"find", "--catalog", "x.json", "--engine", "bogus"},
+		{"find", "--catalog", "x.json", "--threshold", "2"},
+		{"find", "--catalog", "x.json", "--min-quality", "-1"},
+		{"find", "--catalog", "x.json", "--report", "out.pdf"},
+		{"find", "--catalog", "x.json", "--stats-only", "--report", "out.md"},
+		{"find", "--catalog", "x.json", "--tiers", "0.8,0.9"},
+		{"find", "--catalog", "x.json", "--tiers", "0.9,0"},
+		{"find", "--catalog", "x.json", "--tiers", "high"},
+		{"find", "--catalog", "x.json", "--stats-only", "--tiers", "0.9"},
+		{"find", "--catalog", "x.json", "--sample", "-1"},
+		{"find", "--catalog", "x.json", "--sample", "10"},
+		{"find", "--catalog", "x.json", "--engine", "hybrid", "--sample", "10", "--stats-only"},
+		{"find", "--catalog", "x.json", "--engine", "hybrid", "--sample", "10", "--report", "out.md"},
+		{"find", "--catalog", "x.json", "--engine", "hybrid", "--sample", "10", "--tiers", "0.9"},
+		{"find", "--catalog", "x.json", "--stats-only", "--summary"},
+	}
+	for _, args := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != 2 {
+			t.Errorf("%v: exit code %d, want 2", args, code)
+		}
+	}
+}
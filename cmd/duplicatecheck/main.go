@@ -0,0 +1,77 @@
+// Command duplicatecheck is a small command-line front end for the duplicatecheck library.
+//
+// Usage:
+//
+//	duplicatecheck demo [--pairs-only] [--scan-size N]
+//	duplicatecheck compare [--engine E] [--explain] [--explain-descriptions] [--show-prepared] [--all-engines [--threshold T]] PRODUCT_A PRODUCT_B
+//	duplicatecheck find --catalog FILE [--engine E] [--threshold T] [--min-quality Q] [--report FILE] [--stats-only] [--summary]
+//	duplicatecheck diff --previous FILE --current FILE [--catalogs] [--engine E] [--threshold T] [--min-delta D]
+//	duplicatecheck watch --catalog FILE [--threshold T] [--poll D] [--output FILE]
+//	duplicatecheck copied-descriptions --catalog FILE [--threshold T] [--max-name-similarity S] [--min-length N]
+//	duplicatecheck analyze --catalog FILE --ambiguous [--engine E] [--threshold T] [--min-partners N]
+//	duplicatecheck version
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Version is set at build time with: go build -ldflags "-X main.Version=1.0.0"
+var Version = "dev"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run dispatches a subcommand and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return 2
+	}
+
+	switch args[0] {
+	case "demo":
+		return handleDemo(args[1:], stdout, stderr)
+	case "compare":
+		return handleCompare(args[1:], stdout, stderr)
+	case "find":
+		return handleFind(args[1:], stdout, stderr)
+	case "diff":
+		return handleDiff(args[1:], stdout, stderr)
+	case "watch":
+		return handleWatch(args[1:], stdout, stderr)
+	case "copied-descriptions":
+		return handleCopiedDescriptions(args[1:], stdout, stderr)
+	case "analyze":
+		return handleAnalyze(args[1:], stdout, stderr)
+	case "version":
+		fmt.Fprintf(stdout, "duplicatecheck %s\n", Version)
+		return 0
+	case "help", "-h", "--help":
+		printUsage(stdout)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+}
+
+// printUsage prints the list of subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: duplicatecheck <command> [flags]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Commands:")
+	fmt.Fprintln(w, "  demo      Compare every available engine side by side")
+	fmt.Fprintln(w, "  compare   Score two products given as JSON objects, optionally explaining the match")
+	fmt.Fprintln(w, "  find      Scan a JSON or JSONL catalog for duplicates")
+	fmt.Fprintln(w, "  diff      Report duplicate pairs added, removed, or changed between two scans")
+	fmt.Fprintln(w, "  watch     Check products appended to a JSONL catalog as they arrive")
+	fmt.Fprintln(w, "  copied-descriptions")
+	fmt.Fprintln(w, "            List products sharing a description under different names")
+	fmt.Fprintln(w, "  analyze   Report catalog health, such as --ambiguous products matching many others")
+	fmt.Fprintln(w, "  version   Print the version")
+}
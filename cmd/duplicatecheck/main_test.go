@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+func TestDemoRunsAllEngines(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"demo", "--scan-size", "80"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("demo exited with %d: %s", code, stderr.String())
+	}
+
+	out := stdout.String()
+	for _, newEngine := range duplicatecheck.AvailableEngines() {
+		name := newEngine().GetName()
+		// Each engine appears once per example pair and once in the scan table
+		if got, want := strings.Count(out, name), len(demoPairs())+1; got != want {
+			t.Errorf("Engine %q appears %d times, want %d", name, got, want)
+		}
+	}
+	if !strings.Contains(out, "FindDuplicates on 80 generated products") {
+		t.Error("Expected the scan table")
+	}
+}
+
+func TestDemoPairsOnly(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"demo", "--pairs-only"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("demo exited with %d: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "FindDuplicates on") {
+		t.Error("--pairs-only should skip the scan table")
+	}
+}
+
+func TestRunRejectsBadInput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("No command: exit code %d, want 2", code)
+	}
+	if code := run([]string{"bogus"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Unknown command: exit code %d, want 2", code)
+	}
+	if code := run([]string{"demo", "--scan-size", "1"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Bad scan size: exit code %d, want 2", code)
+	}
+	if code := run([]string{"demo", "--threshold", "1.5"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Bad threshold: exit code %d, want 2", code)
+	}
+}
+
+func TestRenderBar(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{0, "░░░░"},
+		{0.5, "██░░"},
+		{1, "████"},
+		{1.5, "████"},
+		{-1, "░░░░"},
+	}
+	for _, tt := range tests {
+		if got := renderBar(tt.value, 4); got != tt.want {
+			t.Errorf("renderBar(%v, 4) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+// watchOptions configures a watch run
+type watchOptions struct {
+	catalog   string        // JSONL catalog file, one product per line
+	output    string        // File matches are appended to ("" = stdout)
+	threshold float64       // Similarity threshold for matches
+	poll      time.Duration // How often the catalog is checked for new lines
+}
+
+// handleWatch indexes a JSONL catalog and checks every product appended to it
+func handleWatch(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var opts watchOptions
+	flags.StringVar(&opts.catalog, "catalog", "", "JSONL catalog file to watch (required)")
+	flags.StringVar(&opts.output, "output", "", "append matches to this file instead of stdout")
+	flags.Float64Var(&opts.threshold, "threshold", duplicatecheck.DefaultThreshold, "similarity threshold for matches")
+	flags.DurationVar(&opts.poll, "poll", 5*time.Second, "how often to check the catalog for appended lines")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if opts.catalog == "" {
+		fmt.Fprintln(stderr, "--catalog is required")
+		return 2
+	}
+	if opts.threshold < 0 || opts.threshold > 1 {
+		fmt.Fprintln(stderr, "--threshold must be in [0, 1]")
+		return 2
+	}
+	if opts.poll <= 0 {
+		fmt.Fprintln(stderr, "--poll must be positive")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := watchCatalog(ctx, opts, stdout, stderr); err != nil {
+		fmt.Fprintf(stderr, "watch: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// watchCatalog indexes the catalog, then polls it for appended lines until ctx is done
+// Each new product is checked against the index, its matches are written as
+// JSON lines (duplicatecheck.ResultRef), and it is then added to the index.
+// Matches are flushed after every poll and on shutdown.
+func watchCatalog(ctx context.Context, opts watchOptions, stdout, stderr io.Writer) (err error) {
+	out := stdout
+	if opts.output != "" {
+		file, err := os.OpenFile(opts.output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		out = file
+	}
+	matches := bufio.NewWriter(out)
+	defer func() {
+		if flushErr := matches.Flush(); err == nil {
+			err = flushErr
+		}
+	}()
+
+	tail := &catalogTail{path: opts.catalog}
+	lines, err := tail.readNew()
+	if err != nil {
+		return err
+	}
+	var initial []duplicatecheck.Product
+	for _, line := range lines {
+		if product, ok := parseCatalogLine(line, stderr); ok {
+			initial = append(initial, product)
+		}
+	}
+
+	engine := duplicatecheck.NewHybridEngine()
+	if err := engine.BuildIndex(initial); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "watching %s: %d products indexed\n", opts.catalog, engine.IndexedCount())
+
+	ticker := time.NewTicker(opts.poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		lines, err := tail.readNew()
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			product, ok := parseCatalogLine(line, stderr)
+			if !ok {
+				continue
+			}
+			if err := checkAndIndex(engine, product, opts.threshold, matches); err != nil {
+				fmt.Fprintf(stderr, "line %d: %v\n", line.number, err)
+			}
+		}
+		if err := matches.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// checkAndIndex writes product's matches against the index, then indexes it
+func checkAndIndex(engine *duplicatecheck.HybridEngine, product duplicatecheck.Product, threshold float64, w io.Writer) error {
+	if engine.ContainsProduct(product.ID) {
+		return fmt.Errorf("product ID %q already indexed, skipping", product.ID)
+	}
+	encoder := json.NewEncoder(w)
+	for _, match := range engine.FindDuplicatesForOne(product, threshold) {
+		if err := encoder.Encode(match.Ref()); err != nil {
+			return err
+		}
+	}
+	return engine.AddProduct(product)
+}
+
+// catalogLine is one complete line read from the catalog
+type catalogLine struct {
+	number int // 1-based line number in the catalog
+	text   []byte
+}
+
+// parseCatalogLine decodes a product, reporting malformed lines on stderr
+// Blank lines are skipped silently.
+func parseCatalogLine(line catalogLine, stderr io.Writer) (duplicatecheck.Product, bool) {
+	var product duplicatecheck.Product
+	if len(bytes.TrimSpace(line.text)) == 0 {
+		return product, false
+	}
+	if err := json.Unmarshal(line.text, &product); err != nil {
+		fmt.Fprintf(stderr, "line %d: skipping malformed product: %v\n", line.number, err)
+		return product, false
+	}
+	if product.ID == "" {
+		fmt.Fprintf(stderr, "line %d: skipping product without an ID\n", line.number)
+		return product, false
+	}
+	return product, true
+}
+
+// catalogTail reads the complete lines appended to a file since the last read
+// A trailing line without a newline is left for the next read, so a writer
+// caught mid-line is never parsed half-written.
+type catalogTail struct {
+	path   string
+	offset int64 // Bytes consumed, always at a line boundary
+	lines  int   // Lines consumed
+}
+
+// errCatalogShrank is returned when the catalog is truncated or replaced by a shorter file
+var errCatalogShrank = errors.New("catalog file shrank; restart watch to re-index it")
+
+// readNew returns the complete lines appended since the last call
+func (t *catalogTail) readNew() ([]catalogLine, error) {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < t.offset {
+		return nil, errCatalogShrank
+	}
+	if info.Size() == t.offset {
+		return nil, nil
+	}
+
+	data := make([]byte, info.Size()-t.offset)
+	if _, err := file.ReadAt(data, t.offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return nil, nil // Only a partial line so far
+	}
+	data = data[:end+1]
+	t.offset += int64(len(data))
+
+	var lines []catalogLine
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		t.lines++
+		lines = append(lines, catalogLine{number: t.lines, text: data[:i]})
+		data = data[i+1:]
+	}
+	return lines, nil
+}
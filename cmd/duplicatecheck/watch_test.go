@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+// appendLines appends raw text to the catalog file
+func appendLines(t *testing.T, path, text string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// productLine encodes a product as one catalog line
+func productLine(t *testing.T, id, name, desc string) string {
+	t.Helper()
+	line, err := json.Marshal(duplicatecheck.Product{ID: id, Name: name, Description: desc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(line) + "\n"
+}
+
+// readMatches returns the match records written so far
+func readMatches(t *testing.T, path string) []duplicatecheck.ResultRef {
+	t.Helper()
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var refs []duplicatecheck.ResultRef
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var ref duplicatecheck.ResultRef
+		if err := json.Unmarshal(scanner.Bytes(), &ref); err != nil {
+			t.Fatalf("malformed match line %q: %v", scanner.Text(), err)
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+func TestWatchDetectsAppendedDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	catalog := filepath.Join(dir, "catalog.jsonl")
+	output := filepath.Join(dir, "matches.jsonl")
+	initial := productLine(t, "P1", "Sony WH-1000XM5 Headphones", "Wireless noise cancelling over-ear headphones, 30h battery") +
+		productLine(t, "P2", "Organic Cotton T-Shirt", "Soft crew neck tee in heather grey")
+	if err := os.WriteFile(catalog, []byte(initial), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var stdout, stderr bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- watchCatalog(ctx, watchOptions{catalog: catalog, output: output, threshold: 0.9, poll: 5 * time.Millisecond}, &stdout, &stderr)
+	}()
+
+	// A new product, then (in two writes, to split the line) a near-copy of it
+	kettle := "Stainless steel electric kettle, 1.7 litre, auto shut-off and boil-dry protection"
+	appendLines(t, catalog, productLine(t, "P3", "Bosch Electric Kettle 1.7L", kettle))
+	appendLines(t, catalog, "{not json}\n")
+	copyLine := productLine(t, "P4", "Bosch Electric Kettle 1.7 L", kettle)
+	appendLines(t, catalog, copyLine[:20])
+	time.Sleep(30 * time.Millisecond)
+	appendLines(t, catalog, copyLine[20:])
+	// A near-copy of a product from the initial file
+	appendLines(t, catalog, productLine(t, "P5", "Sony WH-1000XM5 Headphones", "Wireless noise cancelling over-ear headphones, 30h battery."))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(readMatches(t, output)) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watchCatalog: %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, ref := range readMatches(t, output) {
+		got[duplicatecheck.PairKey(ref.ProductAID, ref.ProductBID)] = true
+	}
+	for _, want := range [][2]string{{"P4", "P3"}, {"P5", "P1"}} {
+		if !got[duplicatecheck.PairKey(want[0], want[1])] {
+			t.Errorf("missing match %s ↔ %s (got %v)", want[0], want[1], got)
+		}
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d matches, want 2: %v", len(got), got)
+	}
+	if !strings.Contains(stderr.String(), "line 4: skipping malformed product") {
+		t.Errorf("malformed line not reported: %q", stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("matches written to stdout with --output set: %q", stdout.String())
+	}
+}
+
+func TestCatalogTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.jsonl")
+	if err := os.WriteFile(path, []byte("a\nb\npart"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tail := &catalogTail{path: path}
+
+	reads := []struct {
+		appendText string
+		want       []string
+	}{
+		{"", []string{"a", "b"}},
+		{"", nil},
+		{"ial\n", []string{"partial"}},
+		{"c\nd", []string{"c"}},
+	}
+	for i, read := range reads {
+		appendLines(t, path, read.appendText)
+		lines, err := tail.readNew()
+		if err != nil {
+			t.Fatalf("read %d: %v", i, err)
+		}
+		var got []string
+		for _, line := range lines {
+			got = append(got, string(line.text))
+		}
+		if strings.Join(got, ",") != strings.Join(read.want, ",") {
+			t.Errorf("read %d = %q, want %q", i, got, read.want)
+		}
+	}
+	if tail.lines != 4 {
+		t.Errorf("consumed %d lines, want 4", tail.lines)
+	}
+
+	if err := os.WriteFile(path, []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tail.readNew(); err != errCatalogShrank {
+		t.Errorf("truncated catalog: err = %v, want errCatalogShrank", err)
+	}
+}
+
+func TestWatchRejectsBadFlags(t *testing.T) {
+	tests := [][]string{
+		{"watch"},
+		{"watch", "--catalog", "x.jsonl", "--threshold", "2"},
+		{"watch", "--catalog", "x.jsonl", "--poll", "0s"},
+	}
+	for _, args := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != 2 {
+			t.Errorf("%v: exit code %d, want 2", args, code)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrProductNotIndexed is returned by RemoveProduct for an ID the index doesn't hold
+var ErrProductNotIndexed = errors.New("duplicatecheck: product not indexed")
+
+// AutoCompactPolicy makes RemoveProduct compact the index once removals pile up
+// (see HybridConfig.AutoCompact); the zero value never compacts automatically
+type AutoCompactPolicy struct {
+	// MaxRemoved compacts once more than this many products were removed
+	// since the last compaction (0 = no limit)
+	MaxRemoved int
+	// MaxRemovedRatio compacts once the products removed since the last
+	// compaction exceed this fraction of the indexed products (0 = no limit)
+	MaxRemovedRatio float64
+}
+
+// due reports whether an index with removed tombstones and live products needs compacting
+func (p AutoCompactPolicy) due(removed, live int) bool {
+	if removed == 0 {
+		return false
+	}
+	return (p.MaxRemoved > 0 && removed > p.MaxRemoved) ||
+		(p.MaxRemovedRatio > 0 && float64(removed) > p.MaxRemovedRatio*float64(live))
+}
+
+// CompactionReport describes one Compact run
+type CompactionReport struct {
+	ProductsPurged int           // Removed products whose IDs were dropped from the buckets
+	EntriesRemoved int           // Bucket entries dropped
+	BucketsRemoved int           // Buckets left empty and dropped from the band maps
+	BytesReclaimed int64         // Estimated memory freed in the band maps and the ID list
+	Duration       time.Duration // Time spent building the compacted index
+}
+
+// Rough memory costs behind CompactionReport.BytesReclaimed: a band map slot
+// (hash, slice header and map overhead), a bucket member and a string header
+// per entry of the ID tables
+const (
+	bucketSlotBytes   = 48
+	bucketMemberBytes = 4
+	stringHeaderBytes = 16
+)
+
+// RemoveProduct drops one product from the index without rebuilding it
+// Returns ErrIndexNotBuilt if BuildIndex has not run, and ErrProductNotIndexed
+// if the ID isn't indexed. The product stops being a candidate at once, but
+// its ID stays in the LSH buckets until Compact rewrites them, which
+// HybridConfig.AutoCompact can trigger. Like AddProduct, it changes the index
+// in place, so it must not run concurrently with queries or other updates.
+func (e *HybridEngine) RemoveProduct(id string) (err error) {
+	defer e.levenshteinEngine.guard("RemoveProduct", &err)
+	idx := e.currentIndex()
+	if idx == nil {
+		return ErrIndexNotBuilt
+	}
+	if !e.ContainsProduct(id) {
+		return ErrProductNotIndexed
+	}
+	if err := e.logUpdate(idx, oplogRecord{Op: oplogRemove, ID: id}, nil); err != nil {
+		return err
+	}
+	idx.remove(id)
+
+	if e.autoCompact.due(len(idx.removed), idx.size()) {
+		_, err := e.Compact()
+		return err
+	}
+	return nil
+}
+
+// remove drops an indexed product, leaving its number in the buckets as removed
+func (idx *LSHIndex) remove(id string) {
+	delete(idx.products, id)
+	delete(idx.fingerprints, id)
+	delete(idx.contentHashes, id)
+	delete(idx.queryBands, id)
+	if idx.prefixes != nil {
+		idx.prefixes.remove(id)
+	}
+	for i, indexed := range idx.ids {
+		if indexed == id {
+			copy(idx.ids[i:], idx.ids[i+1:])
+			idx.ids = idx.ids[:len(idx.ids)-1]
+			break
+		}
+	}
+	if idx.removed == nil {
+		idx.removed = make(map[uint32]bool)
+	}
+	idx.removed[idx.numbers[id]] = true
+}
+
+// Compact rewrites the index without the buckets' references to removed
+// products: live products are renumbered densely, emptied buckets are dropped,
+// and the buckets are copied into a new band store (see HybridConfig.BandStore)
+// The compacted copy is built aside from the current index and swapped in, so
+// queries running meanwhile keep using the index they started with; a
+// BuildIndex that replaces the index meanwhile wins, and the report is empty.
+// No product is re-hashed. Returns ErrIndexNotBuilt if BuildIndex has not run,
+// or the new band store's error.
+func (e *HybridEngine) Compact() (_ CompactionReport, err error) {
+	defer e.levenshteinEngine.guard("Compact", &err)
+	return e.compact(true)
+}
+
+// compact is Compact, recording the compaction in the oplog when logged is set
+func (e *HybridEngine) compact(logged bool) (CompactionReport, error) {
+	started := time.Now()
+	idx := e.currentIndex()
+	if idx == nil {
+		return CompactionReport{}, ErrIndexNotBuilt
+	}
+
+	compacted, report, err := idx.compacted()
+	if err != nil {
+		return CompactionReport{}, err
+	}
+	if logged {
+		if err := e.logUpdate(idx, oplogRecord{Op: oplogCompact}, compacted); err != nil {
+			return CompactionReport{}, err
+		}
+	}
+	e.indexMu.Lock()
+	swapped := e.lshIndex == idx
+	if swapped {
+		e.lshIndex = compacted
+	}
+	e.indexMu.Unlock()
+	if !swapped {
+		return CompactionReport{Duration: time.Since(started)}, nil
+	}
+	report.Duration = time.Since(started)
+	return report, nil
+}
+
+// compacted returns a copy of idx without removed products, and what dropping them saved
+func (idx *LSHIndex) compacted() (*LSHIndex, CompactionReport, error) {
+	report := CompactionReport{ProductsPurged: len(idx.removed)}
+	c := *idx
+	c.removed = nil
+
+	// Live products keep their order and take the numbers 0, 1, ...
+	renumbered := make([]uint32, len(idx.refs))
+	c.refs = make([]string, 0, len(idx.refs)-len(idx.removed))
+	c.numbers = make(map[string]uint32, len(idx.refs)-len(idx.removed))
+	for n, id := range idx.refs {
+		if idx.removed[uint32(n)] {
+			continue
+		}
+		renumbered[n] = uint32(len(c.refs))
+		c.numbers[id] = renumbered[n]
+		c.refs = append(c.refs, id)
+	}
+	c.bands = idx.newBands()
+	copyBandStore(c.bands, idx.bands, idx.numBands, func(n uint32) (uint32, bool) {
+		return renumbered[n], !idx.removed[n]
+	})
+	if err := finishBandStore(c.bands); err != nil {
+		return nil, CompactionReport{}, err
+	}
+
+	c.products = make(map[string]*Product, len(idx.products))
+	for id, p := range idx.products {
+		c.products[id] = p
+	}
+	if idx.fingerprints != nil {
+		c.fingerprints = make(map[string]SimHashFingerprint, len(idx.fingerprints))
+		for id, f := range idx.fingerprints {
+			c.fingerprints[id] = f
+		}
+	}
+	if idx.contentHashes != nil {
+		c.contentHashes = make(map[string]uint64, len(idx.contentHashes))
+		for id, h := range idx.contentHashes {
+			c.contentHashes[id] = h
+		}
+	}
+	if idx.queryBands != nil {
+		c.queryBands = make(map[string]indexedBands, len(idx.queryBands))
+		for id, b := range idx.queryBands {
+			c.queryBands[id] = b
+		}
+	}
+	c.ids = append(make([]string, 0, len(idx.ids)), idx.ids...)
+	c.prefixes = idx.prefixes.clone()
+
+	buckets, entries, bytes := idx.bucketUsage()
+	liveBuckets, liveEntries, liveBytes := c.bucketUsage()
+	report.BucketsRemoved = buckets - liveBuckets
+	report.EntriesRemoved = entries - liveEntries
+	report.BytesReclaimed = bytes - liveBytes
+	return &c, report, nil
+}
+
+// bucketSize returns the number of live products in an LSH bucket
+func (idx *LSHIndex) bucketSize(bucket []uint32) int {
+	if len(idx.removed) == 0 {
+		return len(bucket)
+	}
+	size := 0
+	for _, n := range bucket {
+		if !idx.removed[n] {
+			size++
+		}
+	}
+	return size
+}
+
+// bucketUsage counts the buckets and bucket entries of idx and estimates the
+// memory they and the ID tables hold
+func (idx *LSHIndex) bucketUsage() (buckets, entries int, bytes int64) {
+	for band := 0; band < idx.numBands; band++ {
+		idx.bands.ForEach(band, func(_ uint64, ids []uint32) bool {
+			buckets++
+			entries += len(ids)
+			return true
+		})
+	}
+	bytes = int64(buckets)*bucketSlotBytes + int64(entries)*bucketMemberBytes +
+		int64(cap(idx.ids)+cap(idx.refs))*stringHeaderBytes
+	return buckets, entries, bytes
+}
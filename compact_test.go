@@ -0,0 +1,257 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// candidateIDs returns the IDs FindDuplicatesForOne matches for each query, by query ID
+func candidateIDs(engine *HybridEngine, queries []Product, threshold float64) map[string][]string {
+	matches := make(map[string][]string, len(queries))
+	for _, q := range queries {
+		var ids []string
+		for _, r := range engine.FindDuplicatesForOne(q, threshold) {
+			ids = append(ids, r.ProductB.ID)
+		}
+		matches[q.ID] = ids
+	}
+	return matches
+}
+
+func TestCompact(t *testing.T) {
+	const threshold = 0.8
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 1000)
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(catalog[:500]); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range catalog[500:] {
+		if err := engine.AddProduct(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Remove 8 of every 10 products
+	removed := make(map[string]bool)
+	var live []Product
+	for i, p := range catalog {
+		if i%10 < 8 {
+			if err := engine.RemoveProduct(p.ID); err != nil {
+				t.Fatalf("RemoveProduct(%s): %v", p.ID, err)
+			}
+			removed[p.ID] = true
+		} else {
+			live = append(live, p)
+		}
+	}
+	if engine.IndexedCount() != len(live) || engine.ContainsProduct(catalog[0].ID) {
+		t.Fatalf("IndexedCount = %d after removals, want %d", engine.IndexedCount(), len(live))
+	}
+
+	queries := append(append([]Product(nil), live[:100]...), catalog[:50]...)
+	before := candidateIDs(engine, queries, threshold)
+	beforeScan := comparableResults(engine.FindDuplicates(live[:150], threshold))
+	for query, ids := range before {
+		for _, id := range ids {
+			if removed[id] {
+				t.Fatalf("query %s matched removed product %s", query, id)
+			}
+		}
+	}
+	statsBefore := engine.GetIndexStats()
+	if statsBefore["removed_products"] != len(removed) {
+		t.Errorf("removed_products = %v, want %d", statsBefore["removed_products"], len(removed))
+	}
+
+	report, err := engine.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ProductsPurged != len(removed) || report.EntriesRemoved == 0 || report.BucketsRemoved == 0 || report.BytesReclaimed <= 0 {
+		t.Errorf("report = %+v", report)
+	}
+
+	statsAfter := engine.GetIndexStats()
+	if statsAfter["removed_products"] != 0 {
+		t.Errorf("removed_products = %v after Compact", statsAfter["removed_products"])
+	}
+	if bucketsBefore, bucketsAfter := statsBefore["total_buckets"].(int), statsAfter["total_buckets"].(int); bucketsBefore-bucketsAfter != report.BucketsRemoved {
+		t.Errorf("total_buckets %d -> %d, report says %d removed", bucketsBefore, bucketsAfter, report.BucketsRemoved)
+	}
+
+	if after := candidateIDs(engine, queries, threshold); !reflect.DeepEqual(after, before) {
+		t.Error("FindDuplicatesForOne results changed after Compact")
+	}
+	if afterScan := comparableResults(engine.FindDuplicates(live[:150], threshold)); !reflect.DeepEqual(afterScan, beforeScan) {
+		t.Errorf("FindDuplicates: %d results after Compact, %d before", len(afterScan), len(beforeScan))
+	}
+
+	// The compacted buckets are those of an index built from the live products alone
+	fresh := NewHybridEngine().WithBucketSalt(engine.GetBucketSalt())
+	if err := fresh.BuildIndex(live); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(engine.currentIndex().bands, fresh.currentIndex().bands) {
+		t.Error("compacted buckets differ from a fresh build of the live products")
+	}
+	if idx := engine.currentIndex(); len(idx.refs) != len(live) || len(idx.numbers) != len(live) {
+		t.Errorf("compacted index numbers %d products (%d IDs), want %d", len(idx.refs), len(idx.numbers), len(live))
+	}
+}
+
+func TestRemoveProduct(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 300)
+
+	t.Run("errors", func(t *testing.T) {
+		engine := NewHybridEngine()
+		if err := engine.RemoveProduct("x"); !errors.Is(err, ErrIndexNotBuilt) {
+			t.Errorf("RemoveProduct without index: %v", err)
+		}
+		if _, err := engine.Compact(); !errors.Is(err, ErrIndexNotBuilt) {
+			t.Errorf("Compact without index: %v", err)
+		}
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatal(err)
+		}
+		if err := engine.RemoveProduct("missing"); !errors.Is(err, ErrProductNotIndexed) {
+			t.Errorf("RemoveProduct(missing): %v", err)
+		}
+		if err := engine.RemoveProduct(catalog[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := engine.RemoveProduct(catalog[0].ID); !errors.Is(err, ErrProductNotIndexed) {
+			t.Errorf("second RemoveProduct: %v", err)
+		}
+	})
+
+	t.Run("re-add removed product", func(t *testing.T) {
+		engine := NewHybridEngine()
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatal(err)
+		}
+		target := catalog[7]
+		if err := engine.RemoveProduct(target.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := engine.AddProduct(target); err != nil {
+			t.Fatal(err)
+		}
+		if !engine.ContainsProduct(target.ID) || engine.GetIndexStats()["removed_products"] != 0 {
+			t.Fatal("re-added product not indexed, or removal still pending")
+		}
+		idx := engine.currentIndex()
+		for band := 0; band < idx.numBands; band++ {
+			idx.bands.ForEach(band, func(_ uint64, bucket []uint32) bool {
+				seen := 0
+				for _, n := range bucket {
+					if idx.refs[n] == target.ID {
+						seen++
+					}
+				}
+				if seen > 1 {
+					t.Fatalf("bucket references %s %d times", target.ID, seen)
+				}
+				return true
+			})
+		}
+	})
+
+	t.Run("auto compact", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			policy  AutoCompactPolicy
+			removes int
+			pending int
+		}{
+			{"off", AutoCompactPolicy{}, 30, 30},
+			{"count", AutoCompactPolicy{MaxRemoved: 10}, 30, 8},
+			{"ratio", AutoCompactPolicy{MaxRemovedRatio: 0.05}, 30, 1},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				config := DefaultHybridConfig()
+				config.AutoCompact = tt.policy
+				engine := NewHybridEngineWithConfig(config)
+				if err := engine.BuildIndex(catalog); err != nil {
+					t.Fatal(err)
+				}
+				for _, p := range catalog[:tt.removes] {
+					if err := engine.RemoveProduct(p.ID); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if pending := engine.GetIndexStats()["removed_products"]; pending != tt.pending {
+					t.Errorf("removed_products = %v, want %d", pending, tt.pending)
+				}
+			})
+		}
+	})
+
+	t.Run("snapshots leave removed products out", func(t *testing.T) {
+		engine := NewHybridEngine()
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range catalog[:100] {
+			if err := engine.RemoveProduct(p.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var saved bytes.Buffer
+		if err := engine.SaveIndex(&saved); err != nil {
+			t.Fatal(err)
+		}
+		loaded := NewHybridEngine()
+		if err := loaded.LoadIndex(&saved); err != nil {
+			t.Fatal(err)
+		}
+		compacted, _, err := engine.currentIndex().compacted()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(loaded.currentIndex().bands, compacted.bands) {
+			t.Error("loaded index buckets differ from the compacted ones")
+		}
+	})
+}
+
+func TestCompactConcurrentQueries(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 300)
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range catalog[:150] {
+		if err := engine.RemoveProduct(p.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	queries := catalog[125:175]
+	want := candidateIDs(engine, queries, 0.8)
+
+	var wg sync.WaitGroup
+	failures := make(chan string, 4)
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 3; i++ {
+				if got := candidateIDs(engine, queries, 0.8); !reflect.DeepEqual(got, want) {
+					failures <- "results changed during Compact"
+					return
+				}
+			}
+		}()
+	}
+	if _, err := engine.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	close(failures)
+	for failure := range failures {
+		t.Error(failure)
+	}
+}
@@ -0,0 +1,125 @@
+package duplicatecheck
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// cellRate is how fast this machine runs the two distance kernels, measured
+// once per process by calibrateCellRate
+type cellRate struct {
+	dpCellsPerNano    float64 // DP matrix cells per nanosecond
+	myersWordsPerNano float64 // Myers 64-bit block updates per nanosecond
+}
+
+// Rates used when the calibration can't time anything (a coarse clock)
+const (
+	fallbackDPCellsPerNano    = 0.5
+	fallbackMyersWordsPerNano = 0.5
+)
+
+var (
+	calibrateOnce  sync.Once
+	calibratedRate cellRate
+)
+
+// calibrateCellRate measures the distance kernels on a fixed input, once
+// It runs a few short DP and Myers distances (well under a millisecond) and
+// keeps the fastest of each, so a busy moment at startup costs some budget
+// precision rather than shrinking every budget.
+func calibrateCellRate() cellRate {
+	calibrateOnce.Do(func() {
+		rng := rand.New(rand.NewSource(1))
+		text := calibrationText(rng, 2048)
+		short := calibrationText(rng, myersMinPatternLength)
+		long := calibrationText(rng, 512)
+		engine := &LevenshteinEngine{}
+
+		calibratedRate = cellRate{
+			dpCellsPerNano: fastestRate(float64(len(short)*len(text)), func() {
+				engine.distanceWithin(short, text, -1, nil)
+			}, fallbackDPCellsPerNano),
+			myersWordsPerNano: fastestRate(float64((len(long)+63)/64*len(text)), func() {
+				engine.distanceWithin(long, text, -1, nil)
+			}, fallbackMyersWordsPerNano),
+		}
+	})
+	return calibratedRate
+}
+
+// fastestRate returns units per nanosecond of the fastest of a few runs of f
+func fastestRate(units float64, f func(), fallback float64) float64 {
+	var best time.Duration
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		f()
+		if elapsed := time.Since(start); best == 0 || (elapsed > 0 && elapsed < best) {
+			best = elapsed
+		}
+	}
+	if best <= 0 {
+		return fallback
+	}
+	return units / float64(best.Nanoseconds())
+}
+
+// calibrationText returns n random lowercase ASCII letters
+func calibrationText(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('a' + rng.Intn(26))
+	}
+	return string(b)
+}
+
+// cellBudget is the DP work a description comparison may still do, counted
+// in DP cells; Myers block updates are converted at the calibrated ratio
+// A nil budget is unlimited. A budget is used by one comparison at a time.
+type cellBudget struct {
+	remaining  float64 // Cells left
+	myersScale float64 // Cells one Myers block update costs
+	exceeded   bool    // A distance ran out of budget
+}
+
+// newDescriptionBudget returns the budget of one description comparison, or
+// nil without LevenshteinOptions.MaxComparisonDuration
+func (e *LevenshteinEngine) newDescriptionBudget() *cellBudget {
+	if e.options.MaxComparisonDuration <= 0 || e.deterministic {
+		return nil
+	}
+	rate := calibrateCellRate()
+	return &cellBudget{
+		remaining:  float64(e.options.MaxComparisonDuration.Nanoseconds()) * rate.dpCellsPerNano,
+		myersScale: rate.dpCellsPerNano / rate.myersWordsPerNano,
+	}
+}
+
+// spend charges cells DP cells, reporting false once the budget is exhausted
+func (b *cellBudget) spend(cells int) bool {
+	if b == nil {
+		return true
+	}
+	b.remaining -= float64(cells)
+	if b.remaining < 0 {
+		b.exceeded = true
+	}
+	return !b.exceeded
+}
+
+// spendMyers charges words Myers block updates
+func (b *cellBudget) spendMyers(words int) bool {
+	if b == nil {
+		return true
+	}
+	b.remaining -= float64(words) * b.myersScale
+	if b.remaining < 0 {
+		b.exceeded = true
+	}
+	return !b.exceeded
+}
+
+// timedOut reports whether a distance ran out of budget
+func (b *cellBudget) timedOut() bool {
+	return b != nil && b.exceeded
+}
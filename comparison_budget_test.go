@@ -0,0 +1,123 @@
+package duplicatecheck
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestDistanceWithinBudget(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	short, medium, long := calibrationText(rng, 20), calibrationText(rng, 200), calibrationText(rng, 300)
+	engine := NewLevenshteinEngine()
+
+	tests := []struct {
+		name   string
+		s, t   string
+		cells  float64 // Budget in DP cells
+		wantOK bool
+	}{
+		{"DP within budget", short, long, 20 * 300, true},
+		{"DP over budget", short, long, 20*300 - 1, false},
+		{"Myers within budget", medium, long, 4 * 300, true},
+		{"Myers over budget", medium, long, 4*300 - 1, false},
+		{"Empty string costs nothing", "", long, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// One Myers block update costs one cell, so the DP covers
+			// len(s)*len(t) cells and Myers blocks*len(t)
+			budget := &cellBudget{remaining: tt.cells, myersScale: 1}
+			got, ok := engine.distanceWithin(tt.s, tt.t, -1, budget)
+			if ok != tt.wantOK || budget.timedOut() == tt.wantOK {
+				t.Fatalf("distanceWithin() ok = %v, timedOut() = %v, want ok %v", ok, budget.timedOut(), tt.wantOK)
+			}
+			if want := engine.computeDistance(tt.s, tt.t); ok && got != want {
+				t.Errorf("distanceWithin() = %d, want %d", got, want)
+			}
+		})
+	}
+
+	if _, ok := engine.distanceWithin(medium, long, -1, nil); !ok {
+		t.Error("A nil budget should be unlimited")
+	}
+}
+
+func TestMaxComparisonDuration(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	longA := Product{ID: "1", Name: "Steel Water Bottle", Description: calibrationText(rng, 3500)}
+	longB := Product{ID: "2", Name: "Steel Water Bottle", Description: calibrationText(rng, 3500)}
+	normalA := Product{ID: "3", Name: "Ceramic Mug", Description: "Dishwasher safe ceramic mug, 350ml, matte black glaze"}
+	normalB := Product{ID: "4", Name: "Ceramic Mug", Description: "Dishwasher-safe ceramic mug 350 ml with a matte black glaze"}
+
+	opts := DefaultLevenshteinOptions()
+	opts.MaxComparisonDuration = time.Microsecond
+	bounded := NewLevenshteinEngineWithOptions(opts)
+	unbounded := NewLevenshteinEngine()
+
+	t.Run("Long descriptions time out", func(t *testing.T) {
+		result := bounded.Compare(longA, longB)
+		if !result.DescriptionTimedOut || result.DescriptionSimilarity != 0 {
+			t.Errorf("DescriptionTimedOut = %v, DescriptionSimilarity = %v, want true and 0",
+				result.DescriptionTimedOut, result.DescriptionSimilarity)
+		}
+		if result.NameSimilarity != 1 {
+			t.Errorf("Names should never be budgeted, NameSimilarity = %v", result.NameSimilarity)
+		}
+		if stats := bounded.GetScanStats(); stats["description_timeouts"].(uint64) == 0 {
+			t.Error("Timeout should be counted in GetScanStats")
+		}
+	})
+
+	t.Run("Runtime is bounded", func(t *testing.T) {
+		fastest := func(engine *LevenshteinEngine) time.Duration {
+			var best time.Duration
+			for i := 0; i < 5; i++ {
+				start := time.Now()
+				engine.Compare(longA, longB)
+				if elapsed := time.Since(start); best == 0 || elapsed < best {
+					best = elapsed
+				}
+			}
+			return best
+		}
+		if b, u := fastest(bounded), fastest(unbounded); b >= u {
+			t.Errorf("Bounded comparison took %v, unbounded %v", b, u)
+		}
+	})
+
+	t.Run("Normal descriptions are unaffected", func(t *testing.T) {
+		opts := DefaultLevenshteinOptions()
+		opts.MaxComparisonDuration = 10 * time.Millisecond
+		engine := NewLevenshteinEngineWithOptions(opts)
+		got, want := engine.Compare(normalA, normalB), unbounded.Compare(normalA, normalB)
+		if got.DescriptionTimedOut || got.CombinedSimilarity != want.CombinedSimilarity {
+			t.Errorf("Compare() = %v (timed out %v), want %v", got.CombinedSimilarity, got.DescriptionTimedOut, want.CombinedSimilarity)
+		}
+	})
+
+	t.Run("Flag propagates through scans", func(t *testing.T) {
+		results := bounded.FindDuplicates([]Product{longA, longB, normalA, normalB}, 0)
+		timedOut := 0
+		for _, r := range results {
+			if r.DescriptionTimedOut {
+				timedOut++
+			}
+		}
+		if timedOut == 0 {
+			t.Error("FindDuplicates results should carry DescriptionTimedOut")
+		}
+	})
+
+	t.Run("Segmented descriptions share the budget", func(t *testing.T) {
+		halves := func(desc string) []Segment {
+			return []Segment{{Text: desc[:len(desc)/2]}, {Text: desc[len(desc)/2:]}}
+		}
+		engine := NewLevenshteinEngineWithOptions(opts).WithDescriptionSegmenter(halves, AlignByOrder)
+		result := engine.Compare(longA, longB)
+		if !result.DescriptionTimedOut || result.DescriptionSimilarity != 0 {
+			t.Errorf("DescriptionTimedOut = %v, DescriptionSimilarity = %v, want true and 0",
+				result.DescriptionTimedOut, result.DescriptionSimilarity)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package duplicatecheck
+
+// LegacyResult holds the deprecated ComparisonResult fields with their
+// original meaning, for callers migrating off them
+type LegacyResult struct {
+	Distance   int     // Name edit distance (NameDistance), not a description or combined distance
+	Similarity float64 // Weighted combined similarity (CombinedSimilarity), not a name-only score
+}
+
+// LegacyView returns the deprecated Distance and Similarity values, derived
+// from NameDistance and CombinedSimilarity whether or not the engine filled
+// the legacy fields
+func (r ComparisonResult) LegacyView() LegacyResult {
+	return LegacyResult{Distance: r.NameDistance, Similarity: r.CombinedSimilarity}
+}
+
+// EnableCompatibilityMode fills the deprecated ComparisonResult.Distance and
+// Similarity fields with NameDistance and CombinedSimilarity (the default)
+func (e *LevenshteinEngine) EnableCompatibilityMode() {
+	e.noLegacyFields = false
+}
+
+// DisableCompatibilityMode leaves ComparisonResult.Distance and Similarity
+// zero, so code still reading them shows up in testing; thresholds always
+// apply to CombinedSimilarity either way
+func (e *LevenshteinEngine) DisableCompatibilityMode() {
+	e.noLegacyFields = true
+}
+
+// IsCompatibilityModeEnabled returns whether the deprecated fields are filled
+func (e *LevenshteinEngine) IsCompatibilityModeEnabled() bool {
+	return !e.noLegacyFields
+}
+
+// EnableCompatibilityMode fills the deprecated result fields (the default)
+func (e *HybridEngine) EnableCompatibilityMode() {
+	e.levenshteinEngine.EnableCompatibilityMode()
+}
+
+// DisableCompatibilityMode leaves the deprecated result fields zero
+// (see LevenshteinEngine.DisableCompatibilityMode)
+func (e *HybridEngine) DisableCompatibilityMode() {
+	e.levenshteinEngine.DisableCompatibilityMode()
+}
+
+// IsCompatibilityModeEnabled returns whether the deprecated fields are filled
+func (e *HybridEngine) IsCompatibilityModeEnabled() bool {
+	return e.levenshteinEngine.IsCompatibilityModeEnabled()
+}
+
+// EnableCompatibilityMode fills the deprecated result fields (the default)
+func (e *TFIDFEngine) EnableCompatibilityMode() {
+	e.levenshteinEngine.EnableCompatibilityMode()
+}
+
+// DisableCompatibilityMode leaves the deprecated result fields zero
+// (see LevenshteinEngine.DisableCompatibilityMode)
+func (e *TFIDFEngine) DisableCompatibilityMode() {
+	e.levenshteinEngine.DisableCompatibilityMode()
+}
+
+// IsCompatibilityModeEnabled returns whether the deprecated fields are filled
+func (e *TFIDFEngine) IsCompatibilityModeEnabled() bool {
+	return e.levenshteinEngine.IsCompatibilityModeEnabled()
+}
+
+// withLegacyFields fills the deprecated fields of r in compatibility mode
+func (e *LevenshteinEngine) withLegacyFields(r ComparisonResult) ComparisonResult {
+	if !e.noLegacyFields {
+		legacy := r.LegacyView()
+		r.Distance, r.Similarity = legacy.Distance, legacy.Similarity
+	}
+	return r
+}
@@ -0,0 +1,132 @@
+package duplicatecheck
+
+import (
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+func TestCompatibilityMode(t *testing.T) {
+	pairs := [][2]Product{
+		{{ID: "1", Name: "Apple iPhone 13 Pro", Description: "128GB graphite"}, {ID: "2", Name: "Apple iPhone 13 Pro Max", Description: "128GB graphite"}},
+		{{ID: "3", Name: "Steel Kettle", Description: "1.7L"}, {ID: "4", Name: "steel  kettle", Description: "1.7L"}},
+		{{ID: "5", Name: "Garden Hose 50ft", Description: ""}, {ID: "6", Name: "Wireless Mouse", Description: ""}},
+	}
+	engines := map[string]interface {
+		DuplicateCheckEngine
+		DisableCompatibilityMode()
+		IsCompatibilityModeEnabled() bool
+	}{
+		"levenshtein": NewLevenshteinEngine(),
+		"rabin-karp":  func() *LevenshteinEngine { e := NewLevenshteinEngine(); e.EnableRabinKarpFilter(); return e }(),
+		"hybrid":      NewHybridEngine(),
+		"tfidf":       NewTFIDFEngine(),
+	}
+	for name, engine := range engines {
+		t.Run(name, func(t *testing.T) {
+			if !engine.IsCompatibilityModeEnabled() {
+				t.Fatal("Compatibility mode should be on by default")
+			}
+			var before []ComparisonResult
+			for _, p := range pairs {
+				r := engine.Compare(p[0], p[1])
+				if r.Distance != r.NameDistance || r.Similarity != r.CombinedSimilarity {
+					t.Errorf("%s|%s: Distance %d, Similarity %v, want %d and %v", p[0].ID, p[1].ID, r.Distance, r.Similarity, r.NameDistance, r.CombinedSimilarity)
+				}
+				before = append(before, r)
+			}
+
+			engine.DisableCompatibilityMode()
+			for i, p := range pairs {
+				r := engine.Compare(p[0], p[1])
+				if r.Distance != 0 || r.Similarity != 0 {
+					t.Errorf("%s|%s: Distance %d, Similarity %v, want 0", p[0].ID, p[1].ID, r.Distance, r.Similarity)
+				}
+				if r.CombinedSimilarity != before[i].CombinedSimilarity || r.NameDistance != before[i].NameDistance {
+					t.Errorf("%s|%s: precise fields changed with compatibility mode", p[0].ID, p[1].ID)
+				}
+				if got, want := r.LegacyView(), (LegacyResult{before[i].Distance, before[i].Similarity}); got != want {
+					t.Errorf("LegacyView() = %+v, want %+v", got, want)
+				}
+			}
+
+			// FindDuplicatesByName follows the mode too
+			byName, ok := engine.(interface {
+				FindDuplicatesByName([]Product, float64) []ComparisonResult
+			})
+			if !ok {
+				return
+			}
+			names := []Product{pairs[0][0], pairs[0][1], pairs[1][0], pairs[1][1]}
+			quiet := byName.FindDuplicatesByName(names, 0.5)
+			if len(quiet) == 0 {
+				t.Fatal("FindDuplicatesByName found no pairs")
+			}
+			for _, r := range quiet {
+				if r.Distance != 0 || r.Similarity != 0 {
+					t.Errorf("FindDuplicatesByName %s|%s: Distance %d, Similarity %v, want 0", r.ProductA.ID, r.ProductB.ID, r.Distance, r.Similarity)
+				}
+			}
+			engine.(interface{ EnableCompatibilityMode() }).EnableCompatibilityMode()
+			for _, r := range byName.FindDuplicatesByName(names, 0.5) {
+				if r.Distance != r.NameDistance || r.Similarity != r.CombinedSimilarity {
+					t.Errorf("FindDuplicatesByName %s|%s: Distance %d, Similarity %v, want %d and %v", r.ProductA.ID, r.ProductB.ID, r.Distance, r.Similarity, r.NameDistance, r.CombinedSimilarity)
+				}
+			}
+		})
+	}
+}
+
+// TestThresholdSemantics locks every scan path to thresholding
+// CombinedSimilarity inclusively (within ThresholdEpsilon), independent of the
+// legacy fields
+func TestThresholdSemantics(t *testing.T) {
+	cfg := synth.Default()
+	cfg.Size, cfg.DuplicateRate = 120, 0.3
+	products, _ := generatedCatalog(cfg)
+
+	reference := NewLevenshteinEngine().FindDuplicates(products, 0.6)
+	if len(reference) < 2 {
+		t.Fatalf("Catalog produced %d pairs", len(reference))
+	}
+	boundary := reference[len(reference)/2]
+	threshold := boundary.CombinedSimilarity
+	key := PairKey(boundary.ProductA.ID, boundary.ProductB.ID)
+
+	scans := map[string]func(*LevenshteinEngine, *HybridEngine, float64) []ComparisonResult{
+		"sequential": func(l *LevenshteinEngine, _ *HybridEngine, th float64) []ComparisonResult {
+			return l.FindDuplicates(products, th)
+		},
+		"parallel": func(l *LevenshteinEngine, _ *HybridEngine, th float64) []ComparisonResult {
+			return l.FindDuplicatesParallel(products, th)
+		},
+		"hybrid": func(_ *LevenshteinEngine, h *HybridEngine, th float64) []ComparisonResult {
+			return h.FindDuplicates(products, th)
+		},
+	}
+	for name, scan := range scans {
+		for _, compat := range []bool{true, false} {
+			lev, hybrid := NewLevenshteinEngine(), NewHybridEngine()
+			if !compat {
+				lev.DisableCompatibilityMode()
+				hybrid.DisableCompatibilityMode()
+			}
+
+			found := false
+			for _, r := range scan(lev, hybrid, threshold) {
+				if !meetsThreshold(r.CombinedSimilarity, threshold) || !r.MeetsThreshold {
+					t.Errorf("%s (compat %v): %s|%s scored %v below %v", name, compat, r.ProductA.ID, r.ProductB.ID, r.CombinedSimilarity, threshold)
+				}
+				found = found || PairKey(r.ProductA.ID, r.ProductB.ID) == key
+			}
+			if !found {
+				t.Errorf("%s (compat %v): pair at exactly the threshold was excluded", name, compat)
+			}
+			for _, r := range scan(lev, hybrid, threshold+10*ThresholdEpsilon) {
+				if PairKey(r.ProductA.ID, r.ProductB.ID) == key {
+					t.Errorf("%s (compat %v): pair beyond epsilon below the threshold was included", name, compat)
+				}
+			}
+		}
+	}
+}
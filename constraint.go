@@ -0,0 +1,103 @@
+package duplicatecheck
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// PairConstraint reports whether two products may be duplicates at all, e.g.
+// only listings of the same merchant
+// Scans consult it before comparing a pair, so an excluded pair costs one call
+// instead of a Levenshtein comparison. Engines call it from parallel workers,
+// so it must be safe for concurrent use, and it should be symmetric.
+type PairConstraint func(a, b Product) bool
+
+// GroupKey returns the group a product belongs to, e.g. its merchant
+type GroupKey func(p Product) string
+
+// SameGroupOnly returns a constraint allowing only pairs within one group,
+// so listings of the same product by different merchants are never flagged
+func SameGroupOnly(key GroupKey) PairConstraint {
+	return func(a, b Product) bool {
+		return key(a) == key(b)
+	}
+}
+
+// DifferentGroupOnly returns a constraint allowing only pairs across groups,
+// e.g. to find the same product sold by different merchants
+func DifferentGroupOnly(key GroupKey) PairConstraint {
+	return func(a, b Product) bool {
+		return key(a) != key(b)
+	}
+}
+
+// IDPrefixKey returns a GroupKey reading the group from the product ID, up to
+// the first separator: IDPrefixKey(":") puts "acme:123" in group "acme"
+// IDs without the separator form their own group each.
+func IDPrefixKey(separator string) GroupKey {
+	return func(p Product) string {
+		if i := strings.Index(p.ID, separator); i >= 0 {
+			return p.ID[:i]
+		}
+		return p.ID
+	}
+}
+
+// WithPairConstraint restricts FindDuplicates and the other scans
+// (FindDuplicatesParallel, FindBestMatches, rule and resumable scans,
+// VerifyPairs) to pairs the constraint allows; pass nil to remove it
+// Compare and CompareWithWeights compare whatever they are given. Excluded
+// pairs are counted in GetScanStats as constraint_skipped_pairs. Returns the
+// engine for chaining.
+func (e *LevenshteinEngine) WithPairConstraint(constraint PairConstraint) *LevenshteinEngine {
+	e.pairConstraint = constraint
+	return e
+}
+
+// WithPairConstraint restricts candidate verification to pairs the constraint
+// allows (see LevenshteinEngine.WithPairConstraint); pass nil to remove it
+// Candidates are still drawn from the whole index, then excluded before any
+// SimHash screen or Levenshtein comparison. In privacy mode the index holds
+// no text, so candidates passed to the constraint only carry their ID.
+// Returns the engine for chaining.
+func (e *HybridEngine) WithPairConstraint(constraint PairConstraint) *HybridEngine {
+	e.levenshteinEngine.WithPairConstraint(constraint)
+	return e
+}
+
+// pairAllowed reports whether the pair constraint and the suppression store
+// let a scan compare a and b, counting the pairs they exclude
+func (e *LevenshteinEngine) pairAllowed(a, b *Product) bool {
+	if e.pairExcluded(a, b) {
+		atomic.AddUint64(&e.constraintSkipped, 1)
+		return false
+	}
+	return !e.suppressed(a, b)
+}
+
+// pairExcluded reports whether the pair constraint, or the exclusion of
+// ambiguous products (see WithAmbiguousProducts), leaves a pair out of scans
+func (e *LevenshteinEngine) pairExcluded(a, b *Product) bool {
+	if e.ambiguous.excludes(a, b) {
+		return true
+	}
+	return e.pairConstraint != nil && !e.pairConstraint(*a, *b)
+}
+
+// candidateAllowed is pairAllowed for a query product and an indexed candidate
+func (e *HybridEngine) candidateAllowed(idx *LSHIndex, product *Product, candidateID string) bool {
+	if e.levenshteinEngine.pairConstraint == nil && e.levenshteinEngine.ambiguous == nil && e.levenshteinEngine.suppressions == nil {
+		return true
+	}
+	candidate := idx.products[candidateID]
+	if candidate == nil {
+		// Privacy mode: only the ID is known
+		candidate = &Product{ID: candidateID}
+	}
+	return e.levenshteinEngine.pairAllowed(product, candidate)
+}
+
+// constraintSkips returns the running count of pairs excluded by the pair constraint
+func (e *LevenshteinEngine) constraintSkips() uint64 {
+	return atomic.LoadUint64(&e.constraintSkipped)
+}
@@ -0,0 +1,120 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+// merchantCatalog returns a synthetic catalog spread over three merchants by
+// ID prefix, plus a fourth merchant relisting the first products verbatim, so
+// it holds both within-merchant and cross-merchant duplicates
+func merchantCatalog() []Product {
+	cfg := synth.Default()
+	cfg.Size, cfg.DuplicateRate = 150, 0.3
+	generated, _ := generatedCatalog(cfg)
+
+	products := make([]Product, 0, len(generated)+10)
+	for i, p := range generated {
+		products = append(products, Product{ID: fmt.Sprintf("m%d:%s", i%3, p.ID), Name: p.Name, Description: p.Description})
+	}
+	for _, p := range generated[:10] {
+		products = append(products, Product{ID: "m9:" + p.ID, Name: p.Name, Description: p.Description})
+	}
+	return products
+}
+
+// splitByMerchant returns the pair keys of results within one merchant, and
+// the number of cross-merchant results
+func splitByMerchant(results []ComparisonResult) (map[string]bool, int) {
+	merchant := IDPrefixKey(":")
+	within, cross := make(map[string]bool), 0
+	for _, r := range results {
+		if merchant(r.ProductA) == merchant(r.ProductB) {
+			within[PairKey(r.ProductA.ID, r.ProductB.ID)] = true
+		} else {
+			cross++
+		}
+	}
+	return within, cross
+}
+
+func TestPairConstraint(t *testing.T) {
+	products := merchantCatalog()
+	const threshold = 0.8
+
+	hybridScan := func(constraint PairConstraint) ([]ComparisonResult, map[string]interface{}) {
+		engine := NewHybridEngine().WithPairConstraint(constraint)
+		if err := engine.BuildIndex(products); err != nil {
+			t.Fatal(err)
+		}
+		return engine.FindDuplicates(products, threshold), engine.GetIndexStats()
+	}
+	levenshteinScan := func(parallel bool) func(PairConstraint) ([]ComparisonResult, map[string]interface{}) {
+		return func(constraint PairConstraint) ([]ComparisonResult, map[string]interface{}) {
+			engine := NewLevenshteinEngine().WithPairConstraint(constraint)
+			if parallel {
+				return engine.FindDuplicatesParallel(products, threshold), engine.GetScanStats()
+			}
+			return engine.FindDuplicates(products, threshold), engine.GetScanStats()
+		}
+	}
+	scans := []struct {
+		name string
+		scan func(PairConstraint) ([]ComparisonResult, map[string]interface{})
+	}{
+		{"Levenshtein", levenshteinScan(false)},
+		{"Levenshtein parallel", levenshteinScan(true)},
+		{"Hybrid", hybridScan},
+	}
+	for _, tt := range scans {
+		t.Run(tt.name, func(t *testing.T) {
+			unconstrained, _ := tt.scan(nil)
+			wantWithin, cross := splitByMerchant(unconstrained)
+			if len(wantWithin) == 0 || cross == 0 {
+				t.Fatalf("Catalog produced %d within-merchant and %d cross-merchant pairs", len(wantWithin), cross)
+			}
+
+			results, stats := tt.scan(SameGroupOnly(IDPrefixKey(":")))
+			within, cross := splitByMerchant(results)
+			if cross != 0 {
+				t.Errorf("SameGroupOnly returned %d cross-merchant pairs", cross)
+			}
+			if len(within) != len(wantWithin) {
+				t.Errorf("SameGroupOnly found %d within-merchant pairs, want %d", len(within), len(wantWithin))
+			}
+			for key := range wantWithin {
+				if !within[key] {
+					t.Errorf("Within-merchant pair %s missing", key)
+				}
+			}
+			if skipped := stats["constraint_skipped_pairs"].(uint64); skipped == 0 {
+				t.Error("constraint_skipped_pairs should count excluded pairs")
+			}
+
+			results, _ = tt.scan(DifferentGroupOnly(IDPrefixKey(":")))
+			if within, cross := splitByMerchant(results); len(within) != 0 || cross == 0 {
+				t.Errorf("DifferentGroupOnly returned %d within-merchant and %d cross-merchant pairs", len(within), cross)
+			}
+		})
+	}
+}
+
+func TestIDPrefixKey(t *testing.T) {
+	key := IDPrefixKey(":")
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"acme:123", "acme"},
+		{"acme:123:b", "acme"},
+		{"solo", "solo"},
+		{":x", ""},
+	}
+	for _, tt := range tests {
+		if got := key(Product{ID: tt.id}); got != tt.want {
+			t.Errorf("IDPrefixKey(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
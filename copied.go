@@ -0,0 +1,171 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// DefaultMinCopiedDescriptionLength is the default
+// CopiedDescriptionOptions.MinDescriptionLength, in runes
+const DefaultMinCopiedDescriptionLength = 40
+
+// CopiedDescriptionOptions configures FindCopiedDescriptionsWithOptions
+type CopiedDescriptionOptions struct {
+	// DescriptionThreshold is the lowest DescriptionSimilarity reported
+	DescriptionThreshold float64
+	// MaxNameSimilarity is the highest NameSimilarity reported; pairs with
+	// closer names are ordinary duplicates
+	MaxNameSimilarity float64
+	// MinDescriptionLength leaves out products whose prepared description is
+	// shorter than this many runes, so short stock descriptions ("Black.")
+	// don't flood the report (0 = DefaultMinCopiedDescriptionLength, negative
+	// = no minimum)
+	MinDescriptionLength int
+}
+
+// FindCopiedDescriptions finds products sharing a description under different
+// names: pairs whose DescriptionSimilarity is at least descThreshold while
+// their NameSimilarity is at most maxNameSimilarity
+// See FindCopiedDescriptionsWithOptions.
+func (e *HybridEngine) FindCopiedDescriptions(products []Product, descThreshold, maxNameSimilarity float64) []ComparisonResult {
+	defer e.levenshteinEngine.guard("FindCopiedDescriptions", nil)
+	results, _ := e.FindCopiedDescriptionsWithOptions(products, CopiedDescriptionOptions{
+		DescriptionThreshold: descThreshold,
+		MaxNameSimilarity:    maxNameSimilarity,
+	})
+	return results
+}
+
+// FindCopiedDescriptionsWithOptions reports the copy-paste listings among
+// products: a seller's description copied verbatim, or nearly, under another
+// product's name
+// The default 70/30 weighting dilutes such pairs below any useful threshold,
+// so descriptions are matched on their own: each is MinHashed with the
+// engine's LSH settings, candidates come from shared bands, and only their
+// names and descriptions are compared. The engine's index is not used or
+// changed. Results have MatchType MatchCopiedDescription, CombinedSimilarity
+// equal to DescriptionSimilarity, and are ordered by it, highest first. Returns
+// an error for thresholds outside [0, 1] and, under DuplicateIDReject, for
+// repeated IDs.
+func (e *HybridEngine) FindCopiedDescriptionsWithOptions(products []Product, opts CopiedDescriptionOptions) (_ []ComparisonResult, err error) {
+	defer e.levenshteinEngine.guard("FindCopiedDescriptionsWithOptions", &err)
+	if err := validateThreshold(opts.DescriptionThreshold); err != nil {
+		return nil, err
+	}
+	if err := validateThreshold(opts.MaxNameSimilarity); err != nil {
+		return nil, fmt.Errorf("max name similarity: %w", err)
+	}
+	products, _, err = e.resolveProducts(products)
+	if err != nil {
+		return nil, err
+	}
+	minLength := opts.MinDescriptionLength
+	if minLength == 0 {
+		minLength = DefaultMinCopiedDescriptionLength
+	}
+
+	// Stage 1: band the MinHash signatures of every long enough description
+	l := e.levenshteinEngine
+	prep := l.preparer()
+	type bucketKey struct {
+		band int
+		hash uint64
+	}
+	buckets := make(map[bucketKey][]int) // Products in each band bucket, in input order
+	rows := e.numHashFunctions / e.numBands
+	for i := range products {
+		_, desc := products[i].preparedStrings(prep)
+		if utf8.RuneCountInString(desc) < minLength {
+			continue
+		}
+		for _, signature := range e.computeSignatures(desc) {
+			for band := 0; band < e.numBands; band++ {
+				key := bucketKey{band, hashBand(signature, band*rows, (band+1)*rows)}
+				buckets[key] = append(buckets[key], i)
+			}
+		}
+	}
+
+	// Stage 2: compare each candidate pair once, names first
+	type pair struct{ a, b int }
+	seen := make(map[pair]bool)
+	var results []ComparisonResult
+	for _, bucket := range buckets {
+		for x := 0; x < len(bucket); x++ {
+			for y := x + 1; y < len(bucket); y++ {
+				p := pair{bucket[x], bucket[y]}
+				if p.a == p.b || seen[p] {
+					continue
+				}
+				seen[p] = true
+				if result, ok := e.compareCopied(&products[p.a], &products[p.b], opts); ok {
+					results = append(results, result)
+				}
+			}
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].DescriptionSimilarity != results[j].DescriptionSimilarity {
+			return results[i].DescriptionSimilarity > results[j].DescriptionSimilarity
+		}
+		if results[i].ProductA.ID != results[j].ProductA.ID {
+			return results[i].ProductA.ID < results[j].ProductA.ID
+		}
+		return results[i].ProductB.ID < results[j].ProductB.ID
+	})
+	return results, nil
+}
+
+// compareCopied scores one candidate pair of FindCopiedDescriptions,
+// reporting whether it is a copied description
+// Names are scored first: a pair whose names are close enough is an ordinary
+// duplicate and its descriptions aren't compared.
+func (e *HybridEngine) compareCopied(a, b *Product, opts CopiedDescriptionOptions) (ComparisonResult, bool) {
+	l := e.levenshteinEngine
+	if a.ID == b.ID || !l.pairAllowed(a, b) {
+		return ComparisonResult{}, false
+	}
+	nameA, descA := a.preparedStrings(l.preparer())
+	nameB, descB := b.preparedStrings(l.preparer())
+
+	names := l.scoreNamePair(nameA, nameB, nil)
+	if names.rejected {
+		// Names too far apart for the Rabin-Karp filter to score
+		names = nameScore{distance: utf8.RuneCountInString(nameA) + utf8.RuneCountInString(nameB), skuMixed: names.skuMixed}
+	}
+	if names.similarity > opts.MaxNameSimilarity {
+		return ComparisonResult{}, false
+	}
+	desc := l.compareDescriptions(descA, descB, nil)
+	if !meetsThreshold(desc.similarity, opts.DescriptionThreshold) {
+		return ComparisonResult{}, false
+	}
+	atomic.AddUint64(&e.verifiedPairs, 1)
+
+	weights := ComparisonWeights{NameWeight: 0, DescriptionWeight: 1}
+	result := l.withLegacyFields(ComparisonResult{
+		ProductA:              *a,
+		ProductB:              *b,
+		NameDistance:          names.distance,
+		NameSimilarity:        names.similarity,
+		DescriptionDistance:   desc.distance,
+		DescriptionSimilarity: desc.similarity,
+		CombinedSimilarity:    desc.similarity,
+		WeightsUsed:           weights,
+		SimilarityMode:        l.options.SimilarityMode,
+		ThresholdUsed:         opts.DescriptionThreshold,
+		MeetsThreshold:        true,
+		MatchType:             MatchCopiedDescription,
+		SegmentSimilarities:   desc.segments,
+		DescriptionTimedOut:   desc.timedOut,
+		CandidateSource:       CandidateSourceLSH,
+		SKUNameComparison:     names.sku,
+		SKUNameMixed:          names.skuMixed,
+		HomoglyphsNormalized:  l.homoglyphsNormalized(a, b),
+	})
+	result.assignReasons()
+	return l.redactor.RedactResult(result), true
+}
@@ -0,0 +1,111 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// copiedCatalog returns 2000 products with random descriptions and
+// copy-paste listings planted among them: 10 pairs sharing a long description
+// under unrelated names, 10 sharing a short one, and 10 ordinary duplicates
+// GenerateTestCatalog isn't used: its descriptions come from a few templates,
+// so most of its products are copy-paste listings.
+func copiedCatalog() []Product {
+	words := strings.Fields("wireless compact steel cotton leather portable heavy duty " +
+		"ergonomic waterproof rechargeable classic modern vintage premium lightweight " +
+		"adjustable foldable digital analog ceramic bamboo titanium carbon organic " +
+		"insulated magnetic solar manual automatic smart silent travel outdoor kitchen")
+	rng := rand.New(rand.NewSource(7))
+	sentence := func(n int) string {
+		picked := make([]string, n)
+		for i := range picked {
+			picked[i] = words[rng.Intn(len(words))]
+		}
+		return strings.Join(picked, " ")
+	}
+	products := make([]Product, 2000)
+	for i := range products {
+		products[i] = Product{
+			ID:          fmt.Sprintf("p%04d", i),
+			Name:        fmt.Sprintf("Item %04d %s", i, sentence(2)),
+			Description: sentence(10) + ". " + sentence(8) + ".",
+		}
+	}
+	for i := 0; i < 10; i++ {
+		description := sentence(12) + ". " + sentence(10) + "."
+		duplicate := sentence(12) + ". " + sentence(10) + "."
+		products = append(products,
+			Product{ID: fmt.Sprintf("copy-%d-a", i), Name: fmt.Sprintf("Canvas Tote %d", i), Description: description},
+			Product{ID: fmt.Sprintf("copy-%d-b", i), Name: fmt.Sprintf("Stainless Thermos Flask %d", i), Description: description},
+			Product{ID: fmt.Sprintf("short-%d-a", i), Name: fmt.Sprintf("Ceramic Vase %d", i), Description: "Black."},
+			Product{ID: fmt.Sprintf("short-%d-b", i), Name: fmt.Sprintf("Wool Scarf %d", i), Description: "Black."},
+			Product{ID: fmt.Sprintf("dup-%d-a", i), Name: fmt.Sprintf("Bamboo Cutting Board %d", i), Description: duplicate},
+			Product{ID: fmt.Sprintf("dup-%d-b", i), Name: fmt.Sprintf("Bamboo Cuting Board %d", i), Description: duplicate},
+		)
+	}
+	return products
+}
+
+func TestFindCopiedDescriptions(t *testing.T) {
+	products := copiedCatalog()
+	engine := NewHybridEngine()
+
+	pairs := func(results []ComparisonResult) map[string]bool {
+		found := make(map[string]bool)
+		for _, r := range results {
+			found[r.ProductA.ID+"/"+r.ProductB.ID] = true
+			if r.MatchType != MatchCopiedDescription || r.CombinedSimilarity != r.DescriptionSimilarity {
+				t.Errorf("%s/%s: match type %v, combined %v", r.ProductA.ID, r.ProductB.ID, r.MatchType, r.CombinedSimilarity)
+			}
+			if r.DescriptionSimilarity < 0.9 || r.NameSimilarity > 0.5 {
+				t.Errorf("%s/%s: description %.3f, name %.3f", r.ProductA.ID, r.ProductB.ID, r.DescriptionSimilarity, r.NameSimilarity)
+			}
+		}
+		return found
+	}
+
+	var err error
+	results := engine.FindCopiedDescriptions(products, 0.9, 0.5)
+	if len(results) != 10 {
+		t.Errorf("%d pairs reported, want the 10 planted copies", len(results))
+	}
+	found := pairs(results)
+	for i := 0; i < 10; i++ {
+		if !found[fmt.Sprintf("copy-%d-a/copy-%d-b", i, i)] {
+			t.Errorf("copied pair %d not found", i)
+		}
+		if found[fmt.Sprintf("short-%d-a/short-%d-b", i, i)] {
+			t.Errorf("short description pair %d reported", i)
+		}
+		if found[fmt.Sprintf("dup-%d-a/dup-%d-b", i, i)] {
+			t.Errorf("ordinary duplicate %d reported", i)
+		}
+	}
+
+	// Without a minimum length, short descriptions are copies too
+	results, err = engine.FindCopiedDescriptionsWithOptions(products, CopiedDescriptionOptions{
+		DescriptionThreshold: 0.9,
+		MaxNameSimilarity:    0.5,
+		MinDescriptionLength: -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found = pairs(results)
+	for i := 0; i < 10; i++ {
+		if !found[fmt.Sprintf("short-%d-a/short-%d-b", i, i)] {
+			t.Errorf("short description pair %d not found without a minimum length", i)
+		}
+	}
+
+	for _, opts := range []CopiedDescriptionOptions{
+		{DescriptionThreshold: 1.5, MaxNameSimilarity: 0.5},
+		{DescriptionThreshold: 0.9, MaxNameSimilarity: -0.1},
+	} {
+		if _, err := engine.FindCopiedDescriptionsWithOptions(products, opts); err == nil {
+			t.Errorf("options %+v accepted", opts)
+		}
+	}
+}
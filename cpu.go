@@ -0,0 +1,26 @@
+package duplicatecheck
+
+import "runtime"
+
+// CPUFeatures reports the vector extensions of the CPU the process runs on
+type CPUFeatures struct {
+	Arch  string // runtime.GOARCH
+	SSE41 bool   // amd64: SSE4.1
+	AVX2  bool   // amd64: AVX2, with the OS saving the YMM registers
+	NEON  bool   // arm64: Advanced SIMD, which every arm64 CPU has
+}
+
+// cpuFeatures is detected once at startup
+var cpuFeatures = detectCPU()
+
+// DetectCPUFeatures returns the vector extensions of this CPU
+// Detection runs at startup with CPUID on amd64 and needs neither CGO nor the
+// simd build tag.
+func DetectCPUFeatures() CPUFeatures {
+	return cpuFeatures
+}
+
+// baseCPUFeatures returns the features of an architecture without extensions
+func baseCPUFeatures() CPUFeatures {
+	return CPUFeatures{Arch: runtime.GOARCH}
+}
@@ -0,0 +1,31 @@
+package duplicatecheck
+
+// CPUID feature bits
+const (
+	cpuidSSE41   = 1 << 19 // Leaf 1, ECX
+	cpuidOSXSAVE = 1 << 27 // Leaf 1, ECX
+	cpuidAVX     = 1 << 28 // Leaf 1, ECX
+	cpuidAVX2    = 1 << 5  // Leaf 7, EBX
+	xcr0SSEAVX   = 1<<1 | 1<<2
+)
+
+// detectCPU reads the vector extensions from CPUID
+// AVX2 also needs the OS to save the YMM registers, which XCR0 reports.
+func detectCPU() CPUFeatures {
+	features := baseCPUFeatures()
+	maxLeaf, _, _, _ := cpuid(0, 0)
+	if maxLeaf < 1 {
+		return features
+	}
+	_, _, ecx1, _ := cpuid(1, 0)
+	features.SSE41 = ecx1&cpuidSSE41 != 0
+	if maxLeaf < 7 || ecx1&cpuidOSXSAVE == 0 || ecx1&cpuidAVX == 0 {
+		return features
+	}
+	if xcr0, _ := xgetbv(); xcr0&xcr0SSEAVX != xcr0SSEAVX {
+		return features
+	}
+	_, ebx7, _, _ := cpuid(7, 0)
+	features.AVX2 = ebx7&cpuidAVX2 != 0
+	return features
+}
@@ -0,0 +1,40 @@
+package duplicatecheck
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDetectCPUFeaturesAMD64(t *testing.T) {
+	features := DetectCPUFeatures()
+	if features.Arch != "amd64" || features.NEON {
+		t.Errorf("Features %+v on amd64", features)
+	}
+	if features.AVX2 && !features.SSE41 {
+		t.Errorf("AVX2 without SSE4.1: %+v", features)
+	}
+
+	// Linux lists the same CPUID bits, so the two must agree
+	cpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		t.Skip("No /proc/cpuinfo to compare with")
+	}
+	var flags map[string]bool
+	for _, line := range strings.Split(string(cpuinfo), "\n") {
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(name) == "flags" {
+			flags = make(map[string]bool)
+			for _, flag := range strings.Fields(value) {
+				flags[flag] = true
+			}
+			break
+		}
+	}
+	if flags == nil {
+		t.Skip("No CPU flags in /proc/cpuinfo")
+	}
+	if features.SSE41 != flags["sse4_1"] || features.AVX2 != flags["avx2"] {
+		t.Errorf("Detected SSE4.1 %v, AVX2 %v; /proc/cpuinfo lists sse4_1 %v, avx2 %v",
+			features.SSE41, features.AVX2, flags["sse4_1"], flags["avx2"])
+	}
+}
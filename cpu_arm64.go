@@ -0,0 +1,8 @@
+package duplicatecheck
+
+// detectCPU reports NEON, which the arm64 architecture requires
+func detectCPU() CPUFeatures {
+	features := baseCPUFeatures()
+	features.NEON = true
+	return features
+}
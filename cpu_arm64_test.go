@@ -0,0 +1,9 @@
+package duplicatecheck
+
+import "testing"
+
+func TestDetectCPUFeaturesARM64(t *testing.T) {
+	if features := DetectCPUFeatures(); features != (CPUFeatures{Arch: "arm64", NEON: true}) {
+		t.Errorf("Features %+v on arm64", features)
+	}
+}
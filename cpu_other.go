@@ -0,0 +1,9 @@
+//go:build !amd64 && !arm64
+
+package duplicatecheck
+
+// detectCPU reports no vector extensions: the SIMD path supports only amd64
+// and arm64
+func detectCPU() CPUFeatures {
+	return baseCPUFeatures()
+}
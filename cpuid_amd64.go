@@ -0,0 +1,9 @@
+//go:build !simd || !cgo
+
+package duplicatecheck
+
+// cpuid executes CPUID with the given leaf and subleaf (cpuid_amd64.s)
+func cpuid(leaf, subleaf uint32) (eax, ebx, ecx, edx uint32)
+
+// xgetbv reads extended control register 0 (cpuid_amd64.s)
+func xgetbv() (eax, edx uint32)
@@ -0,0 +1,32 @@
+//go:build simd && cgo
+
+package duplicatecheck
+
+/*
+#include <stdint.h>
+#include <cpuid.h>
+
+static void cpuid_count(uint32_t leaf, uint32_t subleaf, uint32_t* regs) {
+	__cpuid_count(leaf, subleaf, regs[0], regs[1], regs[2], regs[3]);
+}
+
+static void xgetbv0(uint32_t* regs) {
+	__asm__ volatile("xgetbv" : "=a"(regs[0]), "=d"(regs[1]) : "c"(0));
+}
+*/
+import "C"
+
+// cpuid executes CPUID with the given leaf and subleaf
+// A package using CGO can't hold Go assembly, so simd builds ask C.
+func cpuid(leaf, subleaf uint32) (eax, ebx, ecx, edx uint32) {
+	var regs [4]C.uint32_t
+	C.cpuid_count(C.uint32_t(leaf), C.uint32_t(subleaf), &regs[0])
+	return uint32(regs[0]), uint32(regs[1]), uint32(regs[2]), uint32(regs[3])
+}
+
+// xgetbv reads extended control register 0
+func xgetbv() (eax, edx uint32) {
+	var regs [2]C.uint32_t
+	C.xgetbv0(&regs[0])
+	return uint32(regs[0]), uint32(regs[1])
+}
@@ -0,0 +1,87 @@
+package duplicatecheck
+
+import "unicode/utf8"
+
+// DefaultCrossFieldMinNameLength is the default CrossFieldConfig.MinNameLength
+const DefaultCrossFieldMinNameLength = 12
+
+// CrossFieldConfig configures matching each product's name against the other
+// product's description, for sellers who paste an existing listing's title
+// into the description of a vaguely named one
+type CrossFieldConfig struct {
+	// MinNameLength is the shortest prepared name, in runes, that is searched
+	// for (default DefaultCrossFieldMinNameLength): a short name like "mug"
+	// occurs in most descriptions
+	MinNameLength int
+	// Weight folds the best cross-field score into CombinedSimilarity as
+	// max(combined, (1-Weight)·combined + Weight·cross), so it can only raise
+	// a score; 0 records the scores without changing CombinedSimilarity
+	Weight float64
+}
+
+// DefaultCrossFieldConfig records cross-field scores without folding them
+// into the combined score; match on them with NameInDescriptionAtLeast
+func DefaultCrossFieldConfig() CrossFieldConfig {
+	return CrossFieldConfig{MinNameLength: DefaultCrossFieldMinNameLength}
+}
+
+// WithCrossFieldMatching makes comparisons also score each name against the
+// other product's description, in ComparisonResult.NameInDescriptionAB and
+// NameInDescriptionBA; nil turns it off (the default)
+// The score is 1 - d/len(name), where d is the smallest edit distance between
+// the name and any substring of the description (a semi-global alignment,
+// linear in the description length, so no windowing is needed). While enabled,
+// the Rabin-Karp name pre-filter is skipped, since these pairs have dissimilar
+// names, and with a Weight above 0 FindDuplicates doesn't prune by name length.
+// Returns the engine for chaining.
+func (e *LevenshteinEngine) WithCrossFieldMatching(config *CrossFieldConfig) *LevenshteinEngine {
+	if config != nil {
+		c := *config
+		if c.MinNameLength <= 0 {
+			c.MinNameLength = DefaultCrossFieldMinNameLength
+		}
+		c.Weight = clampUnit(c.Weight)
+		config = &c
+	}
+	e.crossField = config
+	return e
+}
+
+// WithCrossFieldMatching sets cross-field matching for verification and
+// Compare (see LevenshteinEngine.WithCrossFieldMatching)
+// Only LSH candidates are verified, so pairs sharing little text beyond the
+// pasted title may never be compared.
+func (e *HybridEngine) WithCrossFieldMatching(config *CrossFieldConfig) *HybridEngine {
+	e.levenshteinEngine.WithCrossFieldMatching(config)
+	return e
+}
+
+// crossFieldScores returns the scores of nameA in descB and nameB in descA
+func (e *LevenshteinEngine) crossFieldScores(nameA, nameB, descA, descB string) (float64, float64) {
+	return e.nameInDescription(nameA, descB), e.nameInDescription(nameB, descA)
+}
+
+// nameInDescription scores the best occurrence of name within desc
+// Names shorter than MinNameLength score 0.
+func (e *LevenshteinEngine) nameInDescription(name, desc string) float64 {
+	if desc == "" || utf8.RuneCountInString(name) < e.crossField.MinNameLength {
+		return 0
+	}
+	runes := []rune(name)
+	pattern := newMyersPattern(runes)
+	defer pattern.release()
+	distance := pattern.searchDistance([]rune(desc))
+	return clampUnit(1 - float64(distance)/float64(len(runes)))
+}
+
+// foldCrossField raises combined with the best cross-field score by Weight
+func (e *LevenshteinEngine) foldCrossField(combined, ab, ba float64) float64 {
+	cross := ab
+	if ba > cross {
+		cross = ba
+	}
+	if folded := (1-e.crossField.Weight)*combined + e.crossField.Weight*cross; folded > combined {
+		return folded
+	}
+	return combined
+}
@@ -0,0 +1,127 @@
+package duplicatecheck
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// bruteSearchDistance is the smallest distance between pattern and any
+// substring of text, by trying them all
+func bruteSearchDistance(pattern, text string) int {
+	runes := []rune(text)
+	best := len([]rune(pattern))
+	for i := 0; i <= len(runes); i++ {
+		for j := i; j <= len(runes); j++ {
+			if d := levenshteinMyers(pattern, string(runes[i:j])); d < best {
+				best = d
+			}
+		}
+	}
+	return best
+}
+
+func TestMyersSearchDistance(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	alphabet := []rune("abcdé")
+	random := func(n int) string {
+		runes := make([]rune, n)
+		for i := range runes {
+			runes[i] = alphabet[rng.Intn(len(alphabet))]
+		}
+		return string(runes)
+	}
+
+	for _, size := range [][2]int{{1, 10}, {5, 30}, {20, 8}, {63, 90}, {64, 100}, {70, 120}, {130, 150}} {
+		for trial := 0; trial < 3; trial++ {
+			pattern, text := random(size[0]), random(size[1])
+			if trial == 0 {
+				// Plant the pattern so the best alignment is exact
+				cut := rng.Intn(len([]rune(text)) + 1)
+				text = string([]rune(text)[:cut]) + pattern + string([]rune(text)[cut:])
+			}
+			p := newMyersPattern([]rune(pattern))
+			got := p.searchDistance([]rune(text))
+			p.release()
+			if want := bruteSearchDistance(pattern, text); got != want {
+				t.Errorf("searchDistance(%d runes in %d) = %d, want %d", size[0], len([]rune(text)), got, want)
+			}
+		}
+	}
+}
+
+func TestCrossFieldMatching(t *testing.T) {
+	listing := Product{
+		ID:          "1",
+		Name:        "Apple iPhone 14 Pro Max 256GB Silver",
+		Description: "6.7-inch Super Retina XDR display, A16 Bionic chip, 48MP main camera",
+	}
+	copycat := Product{
+		ID:          "2",
+		Name:        "Smartphone great deal",
+		Description: "Same as Apple iPhone 14 Pro Max 256GB Silver but unlocked, ships in 24 hours",
+	}
+	unrelated := Product{
+		ID:          "3",
+		Name:        "Stainless Steel Water Bottle",
+		Description: "Keeps drinks cold for 24 hours, 750ml",
+	}
+	catalog := []Product{listing, copycat, unrelated}
+	config := DefaultCrossFieldConfig()
+
+	t.Run("Planted title is found", func(t *testing.T) {
+		engine := NewLevenshteinEngine().WithCrossFieldMatching(&config)
+		result := engine.Compare(listing, copycat)
+		if result.NameInDescriptionAB < 0.9 {
+			t.Errorf("NameInDescriptionAB = %v, want at least 0.9", result.NameInDescriptionAB)
+		}
+		if result.NameInDescriptionBA > 0.5 {
+			t.Errorf("NameInDescriptionBA = %v, want a low score", result.NameInDescriptionBA)
+		}
+		if result.CombinedSimilarity >= DefaultThreshold {
+			t.Errorf("CombinedSimilarity = %v, should stay below the threshold without a Weight", result.CombinedSimilarity)
+		}
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		result := NewLevenshteinEngine().Compare(listing, copycat)
+		if result.NameInDescriptionAB != 0 || result.NameInDescriptionBA != 0 {
+			t.Errorf("Cross-field scores = %v, %v without WithCrossFieldMatching", result.NameInDescriptionAB, result.NameInDescriptionBA)
+		}
+	})
+
+	t.Run("Short names are not searched", func(t *testing.T) {
+		engine := NewLevenshteinEngine().WithCrossFieldMatching(&config)
+		mug := Product{ID: "4", Name: "Mug", Description: "Ceramic"}
+		result := engine.Compare(mug, Product{ID: "5", Name: "Cup", Description: "A mug for coffee"})
+		if result.NameInDescriptionAB != 0 {
+			t.Errorf("NameInDescriptionAB = %v for a 3-rune name, want 0", result.NameInDescriptionAB)
+		}
+	})
+
+	t.Run("Weight folds into the combined score", func(t *testing.T) {
+		weighted := config
+		weighted.Weight = 0.9
+		engine := NewLevenshteinEngine().WithCrossFieldMatching(&weighted)
+		if got := engine.Compare(listing, copycat).CombinedSimilarity; got < DefaultThreshold {
+			t.Errorf("CombinedSimilarity = %v, want at least %v", got, DefaultThreshold)
+		}
+		baseline := NewLevenshteinEngine().Compare(listing, unrelated).CombinedSimilarity
+		if got := engine.Compare(listing, unrelated).CombinedSimilarity; got < baseline {
+			t.Errorf("Folding lowered CombinedSimilarity from %v to %v", baseline, got)
+		}
+
+		results := engine.FindDuplicates(catalog, DefaultThreshold)
+		if pairs := resultPairs(results); len(pairs) != 1 || pairs[0] != "1|2" {
+			t.Errorf("FindDuplicates() = %v, want only the planted pair", pairs)
+		}
+	})
+
+	t.Run("Rule matching", func(t *testing.T) {
+		engine := NewLevenshteinEngine().WithCrossFieldMatching(&config)
+		rule := Or(CombinedAtLeast(DefaultThreshold), NameInDescriptionAtLeast(0.9))
+		results := engine.FindDuplicatesByRule(catalog, rule)
+		if len(results) != 1 || results[0].CombinedSimilarity >= DefaultThreshold {
+			t.Errorf("FindDuplicatesByRule() = %d results, want the planted pair below the combined threshold", len(results))
+		}
+	})
+}
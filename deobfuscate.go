@@ -0,0 +1,222 @@
+package duplicatecheck
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDeobfuscationReadings caps the readings of one token tried against the
+// watchlist when its characters have several alternatives
+const maxDeobfuscationReadings = 64
+
+// DeobfuscationConfig configures matching names whose letters were swapped
+// for look-alike digits and symbols ("N1ke A!r Max") to evade brand checks
+type DeobfuscationConfig struct {
+	// Substitutions maps each look-alike character to the letters it may
+	// stand for, most likely first (nil = DefaultObfuscationSubstitutions)
+	Substitutions map[rune]string
+	// Watchlist lists the names worth de-obfuscating, such as brands and
+	// product lines: a name is de-obfuscated only when the result contains
+	// every word of an entry
+	Watchlist []string
+	// AggressiveDeobfuscation de-obfuscates every name, watchlisted or not
+	AggressiveDeobfuscation bool
+}
+
+// DefaultObfuscationSubstitutions returns the default look-alike table:
+// 0→o, 1→i/l, 3→e, 4→a, 5→s, 7→t, 8→b, @→a, $→s, !→i, |→l, €→e
+// The result is a fresh map, so callers can change it freely.
+func DefaultObfuscationSubstitutions() map[rune]string {
+	return map[rune]string{
+		'0': "o", '1': "il", '3': "e", '4': "a", '5': "s", '7': "t", '8': "b",
+		'@': "a", '$': "s", '!': "i", '|': "l", '€': "e",
+	}
+}
+
+// deobfuscator is a DeobfuscationConfig prepared for matching
+type deobfuscator struct {
+	substitutions map[rune][]rune
+	watchlist     [][]string // Lowercased words of each entry
+	aggressive    bool
+	config        DeobfuscationConfig // The config it was prepared from, defaults filled in
+}
+
+// WithDeobfuscation makes name comparisons also score de-obfuscated names;
+// nil turns it off (the default)
+// Within each name, words holding at least one letter have their look-alike
+// characters replaced; words without letters, such as the "14" of "iPhone 14",
+// are model numbers and stay as they are. When the result matches a watchlist
+// entry (or always, with AggressiveDeobfuscation) the de-obfuscated names are
+// compared too, and if they score higher that score is used for
+// CombinedSimilarity and the result is flagged ObfuscationSuspected.
+// NameSimilarity and NameDistance stay the raw scores. Substitutions replace
+// one character with one, so name-length pruning is unaffected. Name-only
+// comparisons (FindDuplicatesByName) don't de-obfuscate. Returns the engine
+// for chaining.
+func (e *LevenshteinEngine) WithDeobfuscation(config *DeobfuscationConfig) *LevenshteinEngine {
+	if config == nil {
+		e.deobfuscation = nil
+		return e
+	}
+	substitutions := config.Substitutions
+	if substitutions == nil {
+		substitutions = DefaultObfuscationSubstitutions()
+	}
+	d := &deobfuscator{substitutions: make(map[rune][]rune, len(substitutions)), aggressive: config.AggressiveDeobfuscation}
+	d.config = DeobfuscationConfig{
+		Substitutions:           make(map[rune]string, len(substitutions)),
+		Watchlist:               append([]string(nil), config.Watchlist...),
+		AggressiveDeobfuscation: config.AggressiveDeobfuscation,
+	}
+	for from, to := range substitutions {
+		d.config.Substitutions[from] = to
+		if letters := []rune(strings.ToLower(to)); len(letters) > 0 {
+			d.substitutions[unicode.ToLower(from)] = letters
+		}
+	}
+	for _, entry := range config.Watchlist {
+		if words := strings.Fields(strings.ToLower(entry)); len(words) > 0 {
+			d.watchlist = append(d.watchlist, words)
+		}
+	}
+	e.deobfuscation = d
+	return e
+}
+
+// WithDeobfuscation sets name de-obfuscation for verification and Compare (see
+// LevenshteinEngine.WithDeobfuscation)
+// Only LSH candidates are verified, so a heavily obfuscated short name may not
+// share enough shingles with the original to be compared.
+func (e *HybridEngine) WithDeobfuscation(config *DeobfuscationConfig) *HybridEngine {
+	e.levenshteinEngine.WithDeobfuscation(config)
+	return e
+}
+
+// apply returns the de-obfuscated form of a prepared name, and whether it
+// should be compared: it differs from name and matches the watchlist (or the
+// deobfuscator is aggressive)
+func (d *deobfuscator) apply(name string) (string, bool) {
+	if !strings.ContainsFunc(name, func(r rune) bool { return d.substitutions[r] != nil }) {
+		return name, false
+	}
+	runes := []rune(name)
+	var words []string
+	for start := 0; start < len(runes); {
+		if unicode.IsSpace(runes[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(runes) && !unicode.IsSpace(runes[end]) {
+			end++
+		}
+		// Substitutions replace one character with one, in place
+		copy(runes[start:end], d.word(runes[start:end]))
+		words = append(words, string(runes[start:end]))
+		start = end
+	}
+	clean := string(runes)
+	if clean == name || (!d.aggressive && !d.watched(words)) {
+		return name, false
+	}
+	return clean, true
+}
+
+// word de-obfuscates one word holding at least one letter
+// With several alternatives for a character, the first reading that is a
+// watchlist word wins; otherwise every character takes its first alternative.
+func (d *deobfuscator) word(runes []rune) []rune {
+	var positions []int
+	hasLetter := false
+	for i, r := range runes {
+		if d.substitutions[r] != nil {
+			positions = append(positions, i)
+		} else if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	if !hasLetter || len(positions) == 0 {
+		return runes
+	}
+
+	reading := func(choice int) []rune {
+		out := append([]rune(nil), runes...)
+		for _, pos := range positions {
+			alternatives := d.substitutions[runes[pos]]
+			out[pos] = alternatives[choice%len(alternatives)]
+			choice /= len(alternatives)
+		}
+		return out
+	}
+	readings := 1
+	for _, pos := range positions {
+		if readings *= len(d.substitutions[runes[pos]]); readings > maxDeobfuscationReadings {
+			readings = maxDeobfuscationReadings
+			break
+		}
+	}
+	for choice := 1; choice < readings && len(d.watchlist) > 0; choice++ {
+		if candidate := reading(choice); d.watchedWord(string(candidate)) {
+			return candidate
+		}
+	}
+	return reading(0)
+}
+
+// watched reports whether words contain every word of a watchlist entry
+func (d *deobfuscator) watched(words []string) bool {
+	for _, entry := range d.watchlist {
+		found := 0
+		for _, want := range entry {
+			for _, word := range words {
+				if word == want {
+					found++
+					break
+				}
+			}
+		}
+		if found == len(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchedWord reports whether word is a word of any watchlist entry
+func (d *deobfuscator) watchedWord(word string) bool {
+	for _, entry := range d.watchlist {
+		for _, want := range entry {
+			if word == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scoreTextNames is scoreNames, also scoring the de-obfuscated names when
+// either qualifies (see WithDeobfuscation)
+// The result keeps the raw score; a higher de-obfuscated score is recorded
+// alongside it. A raw pair rejected by the Rabin-Karp filter whose
+// de-obfuscated form isn't gets the rejection's maximal distance.
+func (e *LevenshteinEngine) scoreTextNames(nameA, nameB string, scratch *comparisonScratch) nameScore {
+	raw := e.scoreNames(nameA, nameB, scratch)
+	if e.deobfuscation == nil {
+		return raw
+	}
+	cleanA, okA := e.deobfuscation.apply(nameA)
+	cleanB, okB := e.deobfuscation.apply(nameB)
+	if !okA && !okB {
+		return raw
+	}
+	clean := e.scoreNames(cleanA, cleanB, scratch)
+	if clean.rejected || (!raw.rejected && clean.similarity <= raw.similarity) {
+		return raw
+	}
+	if raw.rejected {
+		raw = nameScore{distance: utf8.RuneCountInString(nameA) + utf8.RuneCountInString(nameB)}
+	}
+	raw.deobfuscated, raw.obfuscated = clean.similarity, true
+	return raw
+}
@@ -0,0 +1,112 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithDeobfuscation(t *testing.T) {
+	nike := &DeobfuscationConfig{Watchlist: []string{"Nike", "Air Jordan"}}
+	flight := &DeobfuscationConfig{Watchlist: []string{"Flight"}}
+	aggressive := &DeobfuscationConfig{Watchlist: nike.Watchlist, AggressiveDeobfuscation: true}
+	tests := []struct {
+		name           string
+		config         *DeobfuscationConfig
+		a, b           string
+		wantSuspected  bool
+		wantMinCleaned float64
+	}{
+		{"Leet brand", nike, "N1ke A!r Max 90", "Nike Air Max 90", true, 0.95},
+		{"Symbols", nike, "A!r J0rd@n 1 Retro", "Air Jordan 1 Retro", true, 0.95},
+		{"Second alternative", flight, "Nike F1ight", "Nike Flight", true, 0.95},
+		{"Not watchlisted", nike, "Ad1da5 Samba", "Adidas Samba", false, 0},
+		{"Aggressive", aggressive, "Ad1da5 Samba", "Adidas Samba", true, 0.95},
+		{"Off", nil, "N1ke A!r Max 90", "Nike Air Max 90", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain := NewLevenshteinEngine().Compare(Product{ID: "a", Name: tt.a}, Product{ID: "b", Name: tt.b})
+			r := NewLevenshteinEngine().WithDeobfuscation(tt.config).Compare(Product{ID: "a", Name: tt.a}, Product{ID: "b", Name: tt.b})
+			if r.ObfuscationSuspected != tt.wantSuspected {
+				t.Fatalf("ObfuscationSuspected = %v, want %v", r.ObfuscationSuspected, tt.wantSuspected)
+			}
+			if r.NameSimilarity != plain.NameSimilarity || r.NameDistance != plain.NameDistance {
+				t.Errorf("Raw name scores changed: %v/%d, want %v/%d", r.NameSimilarity, r.NameDistance, plain.NameSimilarity, plain.NameDistance)
+			}
+			if !tt.wantSuspected {
+				if !reflect.DeepEqual(r, plain) {
+					t.Errorf("Result %+v, want %+v", r, plain)
+				}
+				return
+			}
+			if r.DeobfuscatedNameSimilarity < tt.wantMinCleaned || r.CombinedSimilarity < tt.wantMinCleaned {
+				t.Errorf("De-obfuscated similarity %v, combined %v, want at least %v", r.DeobfuscatedNameSimilarity, r.CombinedSimilarity, tt.wantMinCleaned)
+			}
+		})
+	}
+}
+
+func TestDeobfuscationKeepsModelNumbers(t *testing.T) {
+	pairs := [][2]string{
+		{"iPhone 14", "iPhone 15"},
+		{"Nike Air Max 90", "Nike Air Max 95"},
+		{"Galaxy S23 Ultra", "Galaxy S24 Ultra"},
+	}
+	configs := []*DeobfuscationConfig{
+		{Watchlist: []string{"Nike", "iPhone", "Galaxy"}},
+		{AggressiveDeobfuscation: true},
+	}
+	for _, pair := range pairs {
+		a, b := Product{ID: "a", Name: pair[0]}, Product{ID: "b", Name: pair[1]}
+		want := NewLevenshteinEngine().Compare(a, b)
+		for _, config := range configs {
+			if got := NewLevenshteinEngine().WithDeobfuscation(config).Compare(a, b); !reflect.DeepEqual(got, want) {
+				t.Errorf("%q vs %q (aggressive %v): %+v, want %+v", pair[0], pair[1], config.AggressiveDeobfuscation, got, want)
+			}
+		}
+	}
+}
+
+func TestDeobfuscationFindDuplicates(t *testing.T) {
+	products := []Product{
+		{ID: "1", Name: "Nike Air Max 90", Description: "Classic running shoe with visible air cushioning"},
+		{ID: "2", Name: "N1ke A!r Max 90", Description: "Classic running shoe with visible air cushioning"},
+		{ID: "3", Name: "Nike Air Max 95", Description: "Running shoe with a layered upper and air units"},
+	}
+	config := &DeobfuscationConfig{Watchlist: []string{"Nike"}}
+	engines := map[string]DuplicateCheckEngine{
+		"Levenshtein": NewLevenshteinEngine().WithDeobfuscation(config),
+		"Hybrid":      NewHybridEngine().WithDeobfuscation(config),
+	}
+	for name, engine := range engines {
+		found := false
+		for _, r := range engine.FindDuplicates(products, 0.95) {
+			if r.ProductA.ID == "1" && r.ProductB.ID == "2" || r.ProductA.ID == "2" && r.ProductB.ID == "1" {
+				found = r.ObfuscationSuspected
+			}
+		}
+		if !found {
+			t.Errorf("%s: obfuscated listing not flagged as a duplicate", name)
+		}
+	}
+}
+
+func TestDefaultObfuscationSubstitutions(t *testing.T) {
+	subs := DefaultObfuscationSubstitutions()
+	subs['0'] = "x"
+	if DefaultObfuscationSubstitutions()['0'] != "o" {
+		t.Error("DefaultObfuscationSubstitutions shares its map")
+	}
+	custom := NewLevenshteinEngine().WithDeobfuscation(&DeobfuscationConfig{
+		Substitutions: map[rune]string{'#': "h"},
+		Watchlist:     []string{"Hoka"},
+	})
+	r := custom.Compare(Product{ID: "a", Name: "#oka Clifton"}, Product{ID: "b", Name: "Hoka Clifton"})
+	if !r.ObfuscationSuspected || r.DeobfuscatedNameSimilarity != 1 {
+		t.Errorf("Custom substitution: suspected %v, similarity %v", r.ObfuscationSuspected, r.DeobfuscatedNameSimilarity)
+	}
+	r = custom.Compare(Product{ID: "a", Name: "N1ke Air"}, Product{ID: "b", Name: "Nike Air"})
+	if r.ObfuscationSuspected {
+		t.Error("Custom substitutions should replace the defaults")
+	}
+}
@@ -0,0 +1,123 @@
+package duplicatecheck
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// Text preparation steps, as listed by TextPreparation.Steps
+const (
+	PrepStepStripHTML          = "strip-html"
+	PrepStepHomoglyphs         = "homoglyphs"
+	PrepStepLowercase          = "lowercase"
+	PrepStepFoldAccents        = "fold-accents"
+	PrepStepSynonyms           = "synonyms"
+	PrepStepCollapseWhitespace = "collapse-whitespace"
+	PrepStepTrim               = "trim"
+	PrepStepTruncate           = "truncate-description" // Followed by ":<runes>"
+)
+
+// TextLength is a field's length in runes before and after preparation
+type TextLength struct {
+	Raw      int
+	Prepared int
+}
+
+// DetailedComparisonResult is a ComparisonResult with the text the engine
+// actually compared, for debugging scores
+// The prepared strings are the ones cached on the products, not copies.
+type DetailedComparisonResult struct {
+	ComparisonResult
+	NamePreparedA    string
+	NamePreparedB    string
+	DescPreparedA    string
+	DescPreparedB    string
+	PreparationSteps []string // Steps applied to every field, in order (see TextPreparation.Steps)
+	NameLengthA      TextLength
+	NameLengthB      TextLength
+	DescLengthA      TextLength
+	DescLengthB      TextLength
+}
+
+// DetailedComparer is implemented by engines that can report the prepared
+// text behind a comparison
+// It is a separate interface so DuplicateCheckEngine stays small.
+type DetailedComparer interface {
+	// CompareDetailed compares two products like Compare and reports the prepared text
+	CompareDetailed(a, b Product) DetailedComparisonResult
+}
+
+// Ensure both engines implement DetailedComparer
+var (
+	_ DetailedComparer = (*LevenshteinEngine)(nil)
+	_ DetailedComparer = (*HybridEngine)(nil)
+)
+
+// Steps lists the preparation steps p applies, in the order they run
+// Truncation applies to descriptions only and is listed as
+// "truncate-description:<runes>".
+func (p TextPreparation) Steps() []string {
+	var steps []string
+	if p.StripHTML {
+		steps = append(steps, PrepStepStripHTML)
+	}
+	if p.Homoglyphs != nil {
+		steps = append(steps, PrepStepHomoglyphs)
+	}
+	steps = append(steps, PrepStepLowercase)
+	if p.FoldAccents {
+		steps = append(steps, PrepStepFoldAccents)
+	}
+	if p.Synonyms != nil {
+		steps = append(steps, PrepStepSynonyms)
+	}
+	if p.CollapseWhitespace {
+		steps = append(steps, PrepStepCollapseWhitespace)
+	} else {
+		steps = append(steps, PrepStepTrim)
+	}
+	if p.MaxDescriptionLength > 0 {
+		steps = append(steps, PrepStepTruncate+":"+strconv.Itoa(p.MaxDescriptionLength))
+	}
+	return steps
+}
+
+// CompareDetailed compares two products like Compare and reports the prepared
+// strings the distances were computed on
+// Compare stays the cheap path; use this only to inspect a score.
+func (e *LevenshteinEngine) CompareDetailed(a, b Product) DetailedComparisonResult {
+	defer e.guard("CompareDetailed", nil)
+	return e.detailed(&a, &b, e.ComparePtr(&a, &b))
+}
+
+// CompareDetailed compares two products like CompareExact and reports the
+// prepared strings the distances were computed on
+func (e *HybridEngine) CompareDetailed(a, b Product) DetailedComparisonResult {
+	defer e.levenshteinEngine.guard("CompareDetailed", nil)
+	return e.levenshteinEngine.detailed(&a, &b, e.compareExact(&a, &b))
+}
+
+// detailed wraps result with the prepared text of a and b, read from the
+// caches the comparison filled
+func (e *LevenshteinEngine) detailed(a, b *Product, result ComparisonResult) DetailedComparisonResult {
+	prep := e.preparer()
+	nameA, descA := a.preparedStrings(prep)
+	nameB, descB := b.preparedStrings(prep)
+	return DetailedComparisonResult{
+		ComparisonResult: result,
+		NamePreparedA:    nameA,
+		NamePreparedB:    nameB,
+		DescPreparedA:    descA,
+		DescPreparedB:    descB,
+		PreparationSteps: prep.options.Steps(),
+		NameLengthA:      preparedLength(a.Name, nameA),
+		NameLengthB:      preparedLength(b.Name, nameB),
+		DescLengthA:      preparedLength(a.Description, descA),
+		DescLengthB:      preparedLength(b.Description, descB),
+	}
+}
+
+// preparedLength returns the rune lengths of a raw field and its prepared form
+func preparedLength(raw, prepared string) TextLength {
+	return TextLength{Raw: utf8.RuneCountInString(raw), Prepared: utf8.RuneCountInString(prepared)}
+}
@@ -0,0 +1,80 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestCompareDetailed(t *testing.T) {
+	synonyms := NewSynonymDictionary()
+	synonyms.Add("stainless steel", "ss", "inox")
+
+	a := Product{ID: "a", Name: "  <b>Café</b> Mug   INOX ", Description: "<p>Ceramic&nbsp;mug,\n\n  350ml, dishwasher safe</p>"}
+	b := Product{ID: "b", Name: "Café Mug Inox", Description: "Ceramic mug 350 ml"}
+
+	tests := []struct {
+		name      string
+		prep      TextPreparation
+		wantSteps []string
+		wantNameA string
+		wantDescA string
+	}{
+		{"default", DefaultTextPreparation(), []string{"lowercase", "trim"},
+			"<b>café</b> mug   inox", "<p>ceramic&nbsp;mug,\n\n  350ml, dishwasher safe</p>"},
+		{"html and accents", TextPreparation{StripHTML: true, FoldAccents: true},
+			[]string{"strip-html", "lowercase", "fold-accents", "trim"},
+			"cafe  mug   inox", "ceramic mug,\n\n  350ml, dishwasher safe"},
+		{"every step", TextPreparation{StripHTML: true, FoldAccents: true, CollapseWhitespace: true, MaxDescriptionLength: 12, Synonyms: synonyms},
+			[]string{"strip-html", "lowercase", "fold-accents", "synonyms", "collapse-whitespace", "truncate-description:12"},
+			"cafe mug stainless steel", "ceramic mug,"},
+	}
+	for _, tt := range tests {
+		for _, engine := range []interface {
+			DetailedComparer
+			preparer() *textPreparer
+		}{
+			NewLevenshteinEngine().WithTextPreparation(tt.prep),
+			NewHybridEngine().WithTextPreparation(tt.prep),
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				got := engine.CompareDetailed(a, b)
+				if !reflect.DeepEqual(got.PreparationSteps, tt.wantSteps) {
+					t.Errorf("PreparationSteps = %q, want %q", got.PreparationSteps, tt.wantSteps)
+				}
+				if got.NamePreparedA != tt.wantNameA || got.DescPreparedA != tt.wantDescA {
+					t.Errorf("prepared A = %q / %q, want %q / %q", got.NamePreparedA, got.DescPreparedA, tt.wantNameA, tt.wantDescA)
+				}
+
+				// The reported strings are the ones the distances were computed on
+				verifier := NewLevenshteinEngine()
+				if d := verifier.computeDistance(got.NamePreparedA, got.NamePreparedB); d != got.NameDistance {
+					t.Errorf("distance of reported names = %d, result has %d", d, got.NameDistance)
+				}
+				if d := verifier.computeDistance(got.DescPreparedA, got.DescPreparedB); d != got.DescriptionDistance {
+					t.Errorf("distance of reported descriptions = %d, result has %d", d, got.DescriptionDistance)
+				}
+				if plain := engine.(DuplicateCheckEngine).Compare(a, b); plain.CombinedSimilarity != got.CombinedSimilarity {
+					t.Errorf("CombinedSimilarity = %v, Compare gives %v", got.CombinedSimilarity, plain.CombinedSimilarity)
+				}
+
+				if got.NameLengthA.Raw != 25 || got.NameLengthA.Prepared != len([]rune(tt.wantNameA)) {
+					t.Errorf("NameLengthA = %+v", got.NameLengthA)
+				}
+				if got.DescLengthB != (TextLength{Raw: 18, Prepared: len([]rune(got.DescPreparedB))}) {
+					t.Errorf("DescLengthB = %+v", got.DescLengthB)
+				}
+			})
+		}
+	}
+
+	t.Run("shares cached strings", func(t *testing.T) {
+		engine := NewLevenshteinEngine().WithTextPreparation(TextPreparation{StripHTML: true})
+		a, b := a, b
+		nameA, descA := a.preparedStrings(engine.preparer())
+		got := engine.CompareDetailed(a, b)
+		if unsafe.StringData(got.NamePreparedA) != unsafe.StringData(nameA) || unsafe.StringData(got.DescPreparedA) != unsafe.StringData(descA) {
+			t.Error("CompareDetailed copied the prepared strings instead of returning the cached ones")
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package duplicatecheck
+
+// deterministicSaltKey derives the bucket salt of deterministic mode from the LSH seed
+const deterministicSaltKey = 0x6465746572736c74 // "deterslt"
+
+// EnableDeterministicMode makes scans reproducible byte for byte, for
+// re-runs that must match an original exactly, at the cost of speed
+// Every scan, index build and verification runs on one goroutine whatever
+// the catalog size or SetMaxWorkers, so results come out in the same order on
+// every run. Wall-clock and random inputs are pinned: MaxComparisonDuration
+// is ignored, since its budget depends on a CPU rate measured at runtime, and
+// the recall monitor samples by query count instead of at random. Everything
+// else already is: candidates are ranked by band collisions, then ID, and a
+// pair's similarities combine in a fixed order on one goroutine. The mode is
+// recorded in Config, so it changes ConfigFingerprint, and it is stamped into
+// ScanSummary and the header of FindDuplicatesToFileSorted's output.
+func (e *LevenshteinEngine) EnableDeterministicMode() {
+	e.deterministic = true
+}
+
+// DisableDeterministicMode restores parallel scans (the default)
+func (e *LevenshteinEngine) DisableDeterministicMode() {
+	e.deterministic = false
+}
+
+// IsDeterministicModeEnabled returns whether scans are deterministic
+func (e *LevenshteinEngine) IsDeterministicModeEnabled() bool {
+	return e.deterministic
+}
+
+// EnableDeterministicMode makes scans reproducible byte for byte (see
+// LevenshteinEngine.EnableDeterministicMode)
+// The index is built on one goroutine too, and a new index without a
+// configured HybridConfig.BucketSalt gets one derived from the LSH seed
+// instead of a random one, so SaveIndex writes the same file on every run.
+func (e *HybridEngine) EnableDeterministicMode() {
+	e.levenshteinEngine.EnableDeterministicMode()
+}
+
+// DisableDeterministicMode restores parallel work and random bucket salts (the default)
+func (e *HybridEngine) DisableDeterministicMode() {
+	e.levenshteinEngine.DisableDeterministicMode()
+}
+
+// IsDeterministicModeEnabled returns whether scans are deterministic
+func (e *HybridEngine) IsDeterministicModeEnabled() bool {
+	return e.levenshteinEngine.IsDeterministicModeEnabled()
+}
+
+// EnableDeterministicMode makes scans reproducible byte for byte (see
+// LevenshteinEngine.EnableDeterministicMode)
+func (e *TFIDFEngine) EnableDeterministicMode() {
+	e.levenshteinEngine.EnableDeterministicMode()
+}
+
+// DisableDeterministicMode restores parallel scans (the default)
+func (e *TFIDFEngine) DisableDeterministicMode() {
+	e.levenshteinEngine.DisableDeterministicMode()
+}
+
+// IsDeterministicModeEnabled returns whether scans are deterministic
+func (e *TFIDFEngine) IsDeterministicModeEnabled() bool {
+	return e.levenshteinEngine.IsDeterministicModeEnabled()
+}
+
+// deterministicBucketSalt returns the bucket salt of deterministic mode: a
+// non-zero function of the LSH seed
+func (e *HybridEngine) deterministicBucketSalt() uint64 {
+	if salt := mix64(uint64(e.minHash.seed) ^ deterministicSaltKey); salt != 0 {
+		return salt
+	}
+	return deterministicSaltKey
+}
@@ -0,0 +1,157 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fingerprintedEngine is an engine whose configuration has a fingerprint
+type fingerprintedEngine interface {
+	DuplicateCheckEngine
+	ConfigFingerprint() string
+}
+
+// serializedScan writes a scan's results, in the order the scan returned them,
+// with the JSONL result writer
+func serializedScan(t *testing.T, engine fingerprintedEngine, products []Product) []byte {
+	t.Helper()
+	results := engine.FindDuplicates(products, 0.6)
+	refs := make([]ResultRef, len(results))
+	for i := range results {
+		refs[i] = results[i].Ref()
+	}
+	var out bytes.Buffer
+	if err := WriteResultRefsWithFingerprint(&out, refs, ResultFileJSONL, engine.ConfigFingerprint()); err != nil {
+		t.Fatalf("WriteResultRefsWithFingerprint failed: %v", err)
+	}
+	return out.Bytes()
+}
+
+func TestDeterministicMode(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4)) // As in TestParallelScanOrderVaries
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 80)
+	engines := map[string]func() fingerprintedEngine{
+		"levenshtein": func() fingerprintedEngine {
+			engine := NewLevenshteinEngine()
+			engine.SetMaxWorkers(8)
+			engine.EnableDeterministicMode()
+			return engine
+		},
+		"hybrid": func() fingerprintedEngine {
+			engine := NewHybridEngine()
+			engine.SetMaxWorkers(8)
+			engine.EnableDeterministicMode()
+			if err := engine.BuildIndex(catalog); err != nil {
+				t.Fatalf("BuildIndex failed: %v", err)
+			}
+			return engine
+		},
+	}
+	for name, newEngine := range engines {
+		t.Run(name, func(t *testing.T) {
+			first := serializedScan(t, newEngine(), catalog)
+			if bytes.Count(first, []byte("\n")) < 20 {
+				t.Fatalf("only %d lines of output", bytes.Count(first, []byte("\n")))
+			}
+			for run := 2; run <= 3; run++ {
+				if again := serializedScan(t, newEngine(), catalog); !bytes.Equal(again, first) {
+					t.Fatalf("run %d wrote different bytes", run)
+				}
+			}
+		})
+	}
+}
+
+// TestParallelScanOrderVaries shows what deterministic mode fixes: parallel
+// scans find the same pairs in a varying order
+func TestParallelScanOrderVaries(t *testing.T) {
+	// Workers interleave once they run on several threads, even on one CPU
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 150)
+	engine := NewLevenshteinEngine()
+	engine.SetMaxWorkers(8)
+	first := serializedScan(t, engine, catalog)
+	for run := 0; run < 20; run++ {
+		again := serializedScan(t, engine, catalog)
+		if !bytes.Equal(again, first) {
+			if len(again) != len(first) {
+				t.Fatalf("parallel runs wrote %d and %d bytes: the pairs differ, not just their order", len(first), len(again))
+			}
+			return
+		}
+	}
+	t.Error("21 parallel scans returned pairs in the same order")
+}
+
+func TestDeterministicModeRecorded(t *testing.T) {
+	engine := NewLevenshteinEngine()
+	normal := engine.ConfigFingerprint()
+	engine.EnableDeterministicMode()
+	if !engine.Config().DeterministicMode || engine.ConfigFingerprint() == normal {
+		t.Error("deterministic mode missing from Config")
+	}
+	rebuilt, err := NewEngineFromConfig(engine.Config())
+	if err != nil || !rebuilt.(*LevenshteinEngine).IsDeterministicModeEnabled() {
+		t.Errorf("NewEngineFromConfig lost the mode: %v", err)
+	}
+
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 120)
+	_, summary, err := engine.FindDuplicatesWithSummary(catalog, 0.8)
+	if err != nil || !summary.Deterministic || summary.Workers != 1 {
+		t.Errorf("summary deterministic %v on %d workers, %v", summary.Deterministic, summary.Workers, err)
+	}
+
+	// Time budgets depend on the machine: deterministic mode ignores them
+	long := strings.Repeat("soft cotton fabric with reinforced seams ", 200)
+	a, b := Product{ID: "1", Name: "Shirt", Description: long}, Product{ID: "2", Name: "Shirt", Description: long + "and pockets"}
+	timed := NewLevenshteinEngineWithOptions(LevenshteinOptions{MaxComparisonDuration: time.Nanosecond})
+	timed.EnableDeterministicMode()
+	if r := timed.Compare(a, b); r.DescriptionTimedOut || r.DescriptionSimilarity < 0.9 {
+		t.Errorf("description timed out in deterministic mode: %+v", r)
+	}
+
+	dir := t.TempDir()
+	for _, format := range []ResultFileFormat{ResultFileJSONL, ResultFileCSV} {
+		path := filepath.Join(dir, "results")
+		if err := engine.FindDuplicatesToFileSorted(catalog, 0.8, path, SpillOptions{Format: format}); err != nil {
+			t.Fatalf("FindDuplicatesToFileSorted failed: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if !bytes.Contains(data, []byte("deterministic_mode")) {
+			t.Errorf("format %d header doesn't record the mode:\n%.200s", format, data)
+		}
+		file, _ := os.Open(path)
+		refs, fingerprint, err := ReadResultRefsWithFingerprint(file, format)
+		file.Close()
+		if err != nil || fingerprint != engine.ConfigFingerprint() || len(refs) == 0 {
+			t.Errorf("format %d read back %d refs under %q, %v", format, len(refs), fingerprint, err)
+		}
+	}
+}
+
+func TestDeterministicBucketSalt(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 50)
+	salts := make([]uint64, 2)
+	for i := range salts {
+		engine := NewHybridEngine()
+		engine.EnableDeterministicMode()
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatalf("BuildIndex failed: %v", err)
+		}
+		salts[i] = engine.GetBucketSalt()
+	}
+	if salts[0] == 0 || salts[0] != salts[1] {
+		t.Errorf("deterministic salts %x, want one non-zero salt", salts)
+	}
+	other := NewHybridEngine().WithLSHSeed(7)
+	other.EnableDeterministicMode()
+	other.BuildIndex(catalog)
+	if other.GetBucketSalt() == salts[0] {
+		t.Error("another LSH seed derived the same salt")
+	}
+}
@@ -0,0 +1,305 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffOptions controls DiffResults
+type DiffOptions struct {
+	// MinDelta is how far CombinedSimilarity must move for a pair found in both
+	// runs to count as Changed (0 reports any difference beyond rounding noise)
+	MinDelta float64
+}
+
+// ChangedPair is a pair found in both runs whose similarity moved
+type ChangedPair struct {
+	Key      string    `json:"key"`      // PairKey of the two product IDs
+	Previous ResultRef `json:"previous"` // Result in the previous run
+	Current  ResultRef `json:"current"`  // Result in the current run
+	Delta    float64   `json:"delta"`    // Current minus previous CombinedSimilarity
+}
+
+// ResultsDiff is the difference between two duplicate scans
+// Pairs are matched by PairKey, so result order and the A/B order within a
+// pair don't matter. Refs are oriented with ProductAID < ProductBID and each
+// slice is sorted by pair key.
+type ResultsDiff struct {
+	Added   []ResultRef   `json:"added"`   // Pairs only in the current run
+	Removed []ResultRef   `json:"removed"` // Pairs only in the previous run
+	Changed []ChangedPair `json:"changed"` // Pairs in both runs that moved by more than MinDelta
+	// RemovedProducts lists IDs present in the previous catalog but not the
+	// current one (DiffCatalogs only). Their pairs are always in Removed.
+	RemovedProducts []string `json:"removed_products,omitempty"`
+}
+
+// Empty reports whether the diff has no added, removed, or changed pairs
+func (d *ResultsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffResults compares the results of two scans, e.g. yesterday's and today's
+func DiffResults(previous, current []ComparisonResult, opts DiffOptions) ResultsDiff {
+	return DiffResultRefs(resultRefs(previous), resultRefs(current), opts)
+}
+
+// DiffResultRefs is DiffResults for results loaded with ReadResultRefs
+// If a pair appears more than once in a run, its last result is used.
+func DiffResultRefs(previous, current []ResultRef, opts DiffOptions) ResultsDiff {
+	before, after := refsByPair(previous), refsByPair(current)
+	var diff ResultsDiff
+	for key, cur := range after {
+		prev, found := before[key]
+		if !found {
+			diff.Added = append(diff.Added, cur)
+			continue
+		}
+		delta := roundSimilarity(cur.CombinedSimilarity - prev.CombinedSimilarity)
+		if delta != 0 && math.Abs(delta) > opts.MinDelta {
+			diff.Changed = append(diff.Changed, ChangedPair{Key: key, Previous: prev, Current: cur, Delta: delta})
+		}
+	}
+	for key, prev := range before {
+		if _, found := after[key]; !found {
+			diff.Removed = append(diff.Removed, prev)
+		}
+	}
+
+	sortRefs(diff.Added)
+	sortRefs(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Key < diff.Changed[j].Key })
+	return diff
+}
+
+// DiffCatalogs scans both catalog snapshots with engine and diffs the results
+// Pairs involving a product that was removed between the snapshots are
+// reported as Removed, and the product IDs are listed in RemovedProducts.
+// Engines with a BuildIndex method are re-indexed for each snapshot, so a
+// HybridEngine is left indexing current.
+func DiffCatalogs(engine DuplicateCheckEngine, previous, current []Product, threshold float64, opts DiffOptions) (ResultsDiff, error) {
+	if err := validateThreshold(threshold); err != nil {
+		return ResultsDiff{}, err
+	}
+	before, err := scanSnapshot(engine, previous, threshold)
+	if err != nil {
+		return ResultsDiff{}, fmt.Errorf("previous catalog: %w", err)
+	}
+	after, err := scanSnapshot(engine, current, threshold)
+	if err != nil {
+		return ResultsDiff{}, fmt.Errorf("current catalog: %w", err)
+	}
+	diff := DiffResults(before, after, opts)
+
+	kept := make(map[string]bool, len(current))
+	for i := range current {
+		kept[current[i].ID] = true
+	}
+	seen := make(map[string]bool)
+	for i := range previous {
+		id := previous[i].ID
+		if !kept[id] && !seen[id] {
+			seen[id] = true
+			diff.RemovedProducts = append(diff.RemovedProducts, id)
+		}
+	}
+	sort.Strings(diff.RemovedProducts)
+	return diff, nil
+}
+
+// indexingEngine is implemented by engines that query a prebuilt index
+type indexingEngine interface {
+	BuildIndex(products []Product) error
+}
+
+// checkedEngine is implemented by engines that report input problems
+type checkedEngine interface {
+	FindDuplicatesChecked(products []Product, threshold float64) ([]ComparisonResult, error)
+}
+
+// scanSnapshot indexes products if the engine keeps an index, then scans them
+func scanSnapshot(engine DuplicateCheckEngine, products []Product, threshold float64) ([]ComparisonResult, error) {
+	if indexer, ok := engine.(indexingEngine); ok {
+		if err := indexer.BuildIndex(products); err != nil {
+			return nil, err
+		}
+	}
+	if checked, ok := engine.(checkedEngine); ok {
+		return checked.FindDuplicatesChecked(products, threshold)
+	}
+	return engine.FindDuplicates(products, threshold), nil
+}
+
+// WriteResultRefs writes refs in the format FindDuplicatesToFileSorted produces
+func WriteResultRefs(w io.Writer, refs []ResultRef, format ResultFileFormat) error {
+	return WriteResultRefsWithFingerprint(w, refs, format, "")
+}
+
+// WriteResultRefsWithFingerprint is WriteResultRefs under a header holding
+// the ConfigFingerprint of the engine that found refs, as
+// FindDuplicatesToFileSorted writes it ("" writes no header)
+func WriteResultRefsWithFingerprint(w io.Writer, refs []ResultRef, format ResultFileFormat, fingerprint string) error {
+	out, err := newResultWriter(w, format, resultFileHeader{ConfigFingerprint: fingerprint})
+	if err != nil {
+		return err
+	}
+	for i := range refs {
+		if err := out.write(&refs[i]); err != nil {
+			return err
+		}
+	}
+	return out.flush()
+}
+
+// ReadResultRefs reads results written by WriteResultRefs or
+// FindDuplicatesToFileSorted; blank JSONL lines and the config fingerprint
+// header are skipped
+func ReadResultRefs(r io.Reader, format ResultFileFormat) ([]ResultRef, error) {
+	refs, _, err := ReadResultRefsWithFingerprint(r, format)
+	return refs, err
+}
+
+// ReadResultRefsWithFingerprint is ReadResultRefs also returning the config
+// fingerprint in the file's header ("" for files written without one)
+func ReadResultRefsWithFingerprint(r io.Reader, format ResultFileFormat) ([]ResultRef, string, error) {
+	switch format {
+	case ResultFileJSONL:
+		return readResultRefsJSONL(r)
+	case ResultFileCSV:
+		return readResultRefsCSV(r)
+	default:
+		return nil, "", fmt.Errorf("duplicatecheck: unknown result file format %d", format)
+	}
+}
+
+// readResultRefsJSONL decodes one ResultRef per line, after an optional
+// resultFileHeader record
+func readResultRefsJSONL(r io.Reader) ([]ResultRef, string, error) {
+	var refs []ResultRef
+	fingerprint := ""
+	first := true
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Bytes()
+		if len(bytes.TrimSpace(text)) == 0 {
+			continue
+		}
+		if first {
+			first = false
+			var header resultFileHeader
+			if json.Unmarshal(text, &header) == nil && header.ConfigFingerprint != "" {
+				fingerprint = header.ConfigFingerprint
+				continue
+			}
+		}
+		var ref ResultRef
+		if err := json.Unmarshal(text, &ref); err != nil {
+			return nil, "", fmt.Errorf("duplicatecheck: results line %d: %w", line, err)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, fingerprint, scanner.Err()
+}
+
+// readResultRefsCSV decodes rows under the header newResultWriter writes
+// Columns are found by header name, so their order doesn't matter.
+// Comment lines above the header, such as the config fingerprint's, are read first.
+func readResultRefsCSV(r io.Reader) ([]ResultRef, string, error) {
+	in := bufio.NewReader(r)
+	fingerprint, skipped := "", 0
+	for {
+		if prefix, _ := in.Peek(2); string(prefix) != "# " {
+			break
+		}
+		line, err := in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, "", err
+		}
+		if strings.HasPrefix(line, csvFingerprintPrefix) {
+			fingerprint = strings.TrimSpace(strings.TrimPrefix(line, csvFingerprintPrefix))
+		}
+		skipped++
+	}
+	reader := csv.NewReader(in)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fingerprint, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range []string{"product_a", "product_b", "combined_similarity"} {
+		if _, ok := columns[name]; !ok {
+			return nil, "", fmt.Errorf("duplicatecheck: results CSV has no %q column", name)
+		}
+	}
+
+	var refs []ResultRef
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return refs, fingerprint, nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		line, _ := reader.FieldPos(0)
+		line += skipped
+		ref := ResultRef{ProductAID: row[columns["product_a"]], ProductBID: row[columns["product_b"]]}
+		for name, field := range map[string]*float64{
+			"combined_similarity":    &ref.CombinedSimilarity,
+			"name_similarity":        &ref.NameSimilarity,
+			"description_similarity": &ref.DescriptionSimilarity,
+		} {
+			column, ok := columns[name]
+			if !ok {
+				continue
+			}
+			if *field, err = strconv.ParseFloat(row[column], 64); err != nil {
+				return nil, "", fmt.Errorf("duplicatecheck: results CSV line %d: %s: %w", line, name, err)
+			}
+		}
+		refs = append(refs, ref)
+	}
+}
+
+// resultRefs converts results to refs
+func resultRefs(results []ComparisonResult) []ResultRef {
+	refs := make([]ResultRef, len(results))
+	for i := range results {
+		refs[i] = results[i].Ref()
+	}
+	return refs
+}
+
+// refsByPair indexes refs by PairKey, orienting each with ProductAID < ProductBID
+func refsByPair(refs []ResultRef) map[string]ResultRef {
+	byPair := make(map[string]ResultRef, len(refs))
+	for _, ref := range refs {
+		if ref.ProductBID < ref.ProductAID {
+			ref.ProductAID, ref.ProductBID = ref.ProductBID, ref.ProductAID
+		}
+		byPair[makePairKey(ref.ProductAID, ref.ProductBID)] = ref
+	}
+	return byPair
+}
+
+// sortRefs orders oriented refs by pair key
+func sortRefs(refs []ResultRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		return makePairKey(refs[i].ProductAID, refs[i].ProductBID) < makePairKey(refs[j].ProductAID, refs[j].ProductBID)
+	})
+}
@@ -0,0 +1,192 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// diffResult builds a result between two IDs with the given combined similarity
+func diffResult(idA, idB string, similarity float64) ComparisonResult {
+	return ComparisonResult{
+		ProductA:           Product{ID: idA},
+		ProductB:           Product{ID: idB},
+		NameSimilarity:     similarity,
+		CombinedSimilarity: similarity,
+	}
+}
+
+// refKeys returns the pair keys of refs, in order
+func refKeys(refs []ResultRef) []string {
+	keys := make([]string, len(refs))
+	for i, ref := range refs {
+		keys[i] = PairKey(ref.ProductAID, ref.ProductBID)
+	}
+	return keys
+}
+
+func TestDiffResults(t *testing.T) {
+	previous := []ComparisonResult{
+		diffResult("A", "B", 0.90), // Unchanged
+		diffResult("C", "D", 0.88), // Moves a little
+		diffResult("E", "F", 0.86), // Moves a lot
+		diffResult("G", "H", 0.95), // Disappears
+	}
+	current := []ComparisonResult{
+		diffResult("J", "I", 0.91), // New, reversed IDs
+		diffResult("F", "E", 0.97), // Reversed IDs and order
+		diffResult("D", "C", 0.89),
+		diffResult("B", "A", 0.90),
+	}
+
+	tests := []struct {
+		name        string
+		minDelta    float64
+		wantChanged []string
+	}{
+		{"any change", 0, []string{"C|D", "E|F"}},
+		{"material changes only", 0.05, []string{"E|F"}},
+		{"threshold above every move", 0.2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffResults(previous, current, DiffOptions{MinDelta: tt.minDelta})
+			if got := refKeys(diff.Added); !reflect.DeepEqual(got, []string{"I|J"}) {
+				t.Errorf("Added = %v, want [I|J]", got)
+			}
+			if diff.Added[0].ProductAID != "I" {
+				t.Errorf("Added ref not oriented: %+v", diff.Added[0])
+			}
+			if got := refKeys(diff.Removed); !reflect.DeepEqual(got, []string{"G|H"}) {
+				t.Errorf("Removed = %v, want [G|H]", got)
+			}
+			var changed []string
+			for _, c := range diff.Changed {
+				changed = append(changed, c.Key)
+			}
+			if !reflect.DeepEqual(changed, tt.wantChanged) {
+				t.Errorf("Changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+
+	t.Run("delta", func(t *testing.T) {
+		diff := DiffResults(previous, current, DiffOptions{MinDelta: 0.05})
+		if c := diff.Changed[0]; c.Delta != 0.11 || c.Previous.CombinedSimilarity != 0.86 || c.Current.CombinedSimilarity != 0.97 {
+			t.Errorf("Changed[0] = %+v", c)
+		}
+	})
+
+	t.Run("input order does not matter", func(t *testing.T) {
+		want := DiffResults(previous, current, DiffOptions{})
+		reversed := make([]ComparisonResult, len(current))
+		for i := range current {
+			reversed[len(current)-1-i] = current[i]
+		}
+		if got := DiffResults(previous, reversed, DiffOptions{}); !reflect.DeepEqual(got, want) {
+			t.Errorf("reordered input gave %+v, want %+v", got, want)
+		}
+		if diff := DiffResults(current, reversed, DiffOptions{}); !diff.Empty() {
+			t.Errorf("diff of a run with itself = %+v", diff)
+		}
+	})
+}
+
+func TestResultRefsRoundTrip(t *testing.T) {
+	refs := CanonicalizeResults([]ComparisonResult{
+		diffResult("P1", "P2", 0.912345),
+		diffResult("P3", "P4", 1),
+	})
+	refs[0].DescriptionSimilarity = 0.5
+	for _, format := range []ResultFileFormat{ResultFileJSONL, ResultFileCSV} {
+		var buf bytes.Buffer
+		if err := WriteResultRefs(&buf, refs, format); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadResultRefs(&buf, format)
+		if err != nil {
+			t.Fatalf("format %d: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, refs) {
+			t.Errorf("format %d: read %+v, want %+v", format, got, refs)
+		}
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			input  string
+			format ResultFileFormat
+		}{
+			{"bad JSON line", "{\"product_a\":\"A\"}\nnot json\n", ResultFileJSONL},
+			{"missing CSV column", "product_a,name_similarity\nA,0.5\n", ResultFileCSV},
+			{"bad CSV number", "product_a,product_b,combined_similarity\nA,B,high\n", ResultFileCSV},
+		}
+		for _, c := range cases {
+			if _, err := ReadResultRefs(strings.NewReader(c.input), c.format); err == nil {
+				t.Errorf("%s: expected an error", c.name)
+			}
+		}
+	})
+}
+
+func TestDiffCatalogs(t *testing.T) {
+	previous := loadSampleCatalog(t)
+	before := NewLevenshteinEngine().FindDuplicates(previous, 0.85)
+	if len(before) < 2 {
+		t.Fatalf("sample catalog has %d pairs at 0.85", len(before))
+	}
+
+	// Drop one endpoint of the first pair and add an exact copy of another product
+	removed := before[0].ProductA.ID
+	var current []Product
+	for _, p := range previous {
+		if p.ID != removed {
+			current = append(current, p)
+		}
+	}
+	copied := current[len(current)-1]
+	copied.ID = "COPY"
+	current = append(current, copied)
+
+	for _, engine := range []DuplicateCheckEngine{NewLevenshteinEngine(), NewHybridEngine()} {
+		t.Run(engine.GetName(), func(t *testing.T) {
+			diff, err := DiffCatalogs(engine, previous, current, 0.85, DiffOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(diff.RemovedProducts, []string{removed}) {
+				t.Errorf("RemovedProducts = %v, want [%s]", diff.RemovedProducts, removed)
+			}
+			for _, r := range before {
+				if r.ProductA.ID != removed && r.ProductB.ID != removed {
+					continue
+				}
+				if !listsRef(diff.Removed, PairKey(r.ProductA.ID, r.ProductB.ID)) {
+					t.Errorf("pair %s/%s of a removed product is not in Removed", r.ProductA.ID, r.ProductB.ID)
+				}
+			}
+			if !listsRef(diff.Added, PairKey(copied.ID, current[len(current)-2].ID)) {
+				t.Errorf("copy is not in Added: %v", refKeys(diff.Added))
+			}
+			if len(diff.Changed) != 0 {
+				t.Errorf("unchanged products produced changes: %+v", diff.Changed)
+			}
+		})
+	}
+
+	if _, err := DiffCatalogs(NewLevenshteinEngine(), previous, current, 1.5, DiffOptions{}); err == nil {
+		t.Error("expected an error for an invalid threshold")
+	}
+}
+
+// listsRef reports whether refs holds the pair with the given PairKey
+func listsRef(refs []ResultRef, key string) bool {
+	for _, ref := range refs {
+		if PairKey(ref.ProductAID, ref.ProductBID) == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,336 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+// ErrCorruptBandStore is returned by OpenDiskBandStore for a file that isn't a
+// complete band store, such as one whose last flush was cut short by a crash
+var ErrCorruptBandStore = errors.New("duplicatecheck: corrupt or partially written band store")
+
+// Band store file layout, all integers little-endian:
+//
+//	header:  "DCBANDS1" numBands:u32
+//	segment: "SEG1" buckets:u32 {band:u32 hash:u64 count:u32 ids:count×u32}… crc:u32 "END1"
+//
+// Each Flush appends one segment holding every bucket changed since the last
+// one, in full; a later segment's bucket replaces an earlier one. The CRC
+// covers the bucket records, so a segment cut short or torn by a crash fails
+// to verify and the file is rejected.
+const (
+	diskBandMagic    = "DCBANDS1"
+	diskSegmentMagic = "SEG1"
+	diskSegmentEnd   = "END1"
+)
+
+// DiskBandStore is a BandStore keeping bucket members in an append-only file
+// Only a directory of bucket hash -> file offset stays in memory, with the
+// members appended since the last Flush; Get reads the rest from the file.
+// Rewritten buckets leave their old copy behind as garbage until the index is
+// compacted into a new store.
+type DiskBandStore struct {
+	file    *os.File
+	size    int64                   // File length: where the next segment goes
+	dir     []map[uint64]diskExtent // Flushed buckets, per band
+	pending []map[uint64][]uint32   // Members appended since the last Flush, per band
+	errMu   sync.Mutex
+	err     error // First failure, reported by Err
+}
+
+// diskExtent locates a flushed bucket's members in the file
+type diskExtent struct {
+	offset int64
+	count  uint32
+}
+
+// NewDiskBandStore creates (or truncates) a band store file at path
+func NewDiskBandStore(path string, numBands int) (*DiskBandStore, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	s := newDiskBandStore(file, numBands)
+	header := make([]byte, len(diskBandMagic)+4)
+	copy(header, diskBandMagic)
+	binary.LittleEndian.PutUint32(header[len(diskBandMagic):], uint32(numBands))
+	if _, err := file.Write(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	s.size = int64(len(header))
+	return s, nil
+}
+
+// newDiskBandStore returns an empty store over file
+func newDiskBandStore(file *os.File, numBands int) *DiskBandStore {
+	s := &DiskBandStore{
+		file:    file,
+		dir:     make([]map[uint64]diskExtent, numBands),
+		pending: make([]map[uint64][]uint32, numBands),
+	}
+	for i := 0; i < numBands; i++ {
+		s.dir[i] = make(map[uint64]diskExtent)
+		s.pending[i] = make(map[uint64][]uint32)
+	}
+	return s
+}
+
+// OpenDiskBandStore reopens a band store file written by NewDiskBandStore
+// Returns an error wrapping ErrCorruptBandStore if the file is truncated, a
+// segment fails its checksum, or the last flush didn't complete.
+func OpenDiskBandStore(path string) (*DiskBandStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	s, err := readDiskBandStore(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// readDiskBandStore rebuilds the directory of a band store file
+func readDiskBandStore(file *os.File) (*DiskBandStore, error) {
+	in := bufio.NewReader(file)
+	header := make([]byte, len(diskBandMagic)+4)
+	if _, err := io.ReadFull(in, header); err != nil || string(header[:len(diskBandMagic)]) != diskBandMagic {
+		return nil, fmt.Errorf("%w: bad header", ErrCorruptBandStore)
+	}
+	numBands := int(binary.LittleEndian.Uint32(header[len(diskBandMagic):]))
+	s := newDiskBandStore(file, numBands)
+	s.size = int64(len(header))
+
+	for {
+		start := make([]byte, 8)
+		n, err := io.ReadFull(in, start)
+		if n == 0 && err == io.EOF {
+			return s, nil
+		}
+		if err != nil || string(start[:4]) != diskSegmentMagic {
+			return nil, fmt.Errorf("%w: segment at offset %d is incomplete", ErrCorruptBandStore, s.size)
+		}
+		segment, err := s.readSegment(in, binary.LittleEndian.Uint32(start[4:]), s.size+8)
+		if err != nil {
+			return nil, fmt.Errorf("%w: segment at offset %d: %v", ErrCorruptBandStore, s.size, err)
+		}
+		s.size += 8 + segment
+	}
+}
+
+// readSegment reads the bucket records of one segment, whose records start
+// at offset in the file, and its trailer; the directory is updated only once
+// the checksum matches. Returns the length of the records and trailer.
+func (s *DiskBandStore) readSegment(in *bufio.Reader, buckets uint32, offset int64) (int64, error) {
+	crc := crc32.NewIEEE()
+	updates := make([]map[uint64]diskExtent, len(s.dir))
+	for i := range updates {
+		updates[i] = make(map[uint64]diskExtent)
+	}
+	length := int64(0)
+	record := make([]byte, 16)
+	for b := uint32(0); b < buckets; b++ {
+		if _, err := io.ReadFull(in, record); err != nil {
+			return 0, err
+		}
+		crc.Write(record)
+		band := binary.LittleEndian.Uint32(record)
+		hash := binary.LittleEndian.Uint64(record[4:])
+		count := binary.LittleEndian.Uint32(record[12:])
+		if int(band) >= len(s.dir) {
+			return 0, fmt.Errorf("band %d out of range", band)
+		}
+		updates[band][hash] = diskExtent{offset: offset + length + 16, count: count}
+		if _, err := io.CopyN(crc, in, int64(count)*4); err != nil {
+			return 0, err
+		}
+		length += 16 + int64(count)*4
+	}
+	trailer := make([]byte, 8)
+	if _, err := io.ReadFull(in, trailer); err != nil {
+		return 0, err
+	}
+	if binary.LittleEndian.Uint32(trailer) != crc.Sum32() || string(trailer[4:]) != diskSegmentEnd {
+		return 0, errors.New("checksum mismatch")
+	}
+	for band, extents := range updates {
+		for hash, extent := range extents {
+			s.dir[band][hash] = extent
+		}
+	}
+	return length + 8, nil
+}
+
+// DiskBandStores returns a BandStoreFactory creating each index's store in a
+// new scratch file in dir
+// The file is unlinked as soon as it is created, so it disappears once the
+// store is garbage collected (on systems that can't unlink open files it
+// stays in dir). A store whose file can't be created reports the error
+// through Err, which fails BuildIndex.
+func DiskBandStores(dir string) BandStoreFactory {
+	return func(numBands int) BandStore {
+		file, err := os.CreateTemp(dir, "bands-*.dcb")
+		if err != nil {
+			return &DiskBandStore{err: err}
+		}
+		path := file.Name()
+		file.Close()
+		s, err := NewDiskBandStore(path, numBands)
+		if err != nil {
+			return &DiskBandStore{err: err}
+		}
+		os.Remove(path)
+		return s
+	}
+}
+
+// Append adds product id to the bucket hash of band, in memory until Flush
+func (s *DiskBandStore) Append(band int, hash uint64, id uint32) {
+	if s.file == nil {
+		return
+	}
+	s.pending[band][hash] = append(s.pending[band][hash], id)
+}
+
+// Get returns the products in the bucket hash of band, reading flushed
+// members from the file
+func (s *DiskBandStore) Get(band int, hash uint64) []uint32 {
+	if s.file == nil {
+		return nil
+	}
+	extent, flushed := s.dir[band][hash]
+	pending := s.pending[band][hash]
+	if !flushed {
+		return pending
+	}
+	ids, err := s.read(extent, len(pending))
+	if err != nil {
+		s.fail(err)
+	}
+	return append(ids, pending...)
+}
+
+// read returns the members of a flushed bucket, with room for extra more
+func (s *DiskBandStore) read(extent diskExtent, extra int) ([]uint32, error) {
+	buf := make([]byte, int(extent.count)*4)
+	if _, err := s.file.ReadAt(buf, extent.offset); err != nil {
+		return nil, err
+	}
+	ids := make([]uint32, extent.count, int(extent.count)+extra)
+	for i := range ids {
+		ids[i] = binary.LittleEndian.Uint32(buf[i*4:])
+	}
+	return ids, nil
+}
+
+// ForEach calls fn for every bucket of band until fn returns false
+func (s *DiskBandStore) ForEach(band int, fn func(hash uint64, ids []uint32) bool) {
+	if s.file == nil {
+		return
+	}
+	for hash := range s.dir[band] {
+		if !fn(hash, s.Get(band, hash)) {
+			return
+		}
+	}
+	for hash, ids := range s.pending[band] {
+		if _, flushed := s.dir[band][hash]; !flushed && !fn(hash, ids) {
+			return
+		}
+	}
+}
+
+// Flush writes every bucket appended to since the last Flush as one segment
+// and syncs the file; the directory points at the new copies only once the
+// segment is on disk
+func (s *DiskBandStore) Flush() error {
+	if err := s.Err(); err != nil {
+		return err
+	}
+	var records []byte
+	buckets := uint32(0)
+	updates := make([]map[uint64]diskExtent, len(s.dir))
+	for band, pending := range s.pending {
+		updates[band] = make(map[uint64]diskExtent, len(pending))
+		for hash, appended := range pending {
+			ids := appended
+			if extent, flushed := s.dir[band][hash]; flushed {
+				old, err := s.read(extent, len(appended))
+				if err != nil {
+					s.fail(err)
+					return err
+				}
+				ids = append(old, appended...)
+			}
+			record := make([]byte, 16+len(ids)*4)
+			binary.LittleEndian.PutUint32(record, uint32(band))
+			binary.LittleEndian.PutUint64(record[4:], hash)
+			binary.LittleEndian.PutUint32(record[12:], uint32(len(ids)))
+			for i, id := range ids {
+				binary.LittleEndian.PutUint32(record[16+i*4:], id)
+			}
+			updates[band][hash] = diskExtent{offset: s.size + 8 + int64(len(records)) + 16, count: uint32(len(ids))}
+			records = append(records, record...)
+			buckets++
+		}
+	}
+	if buckets == 0 {
+		return nil
+	}
+
+	segment := make([]byte, 0, 8+len(records)+8)
+	segment = append(segment, diskSegmentMagic...)
+	segment = binary.LittleEndian.AppendUint32(segment, buckets)
+	segment = append(segment, records...)
+	segment = binary.LittleEndian.AppendUint32(segment, crc32.ChecksumIEEE(records))
+	segment = append(segment, diskSegmentEnd...)
+	if _, err := s.file.WriteAt(segment, s.size); err != nil {
+		s.fail(err)
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		s.fail(err)
+		return err
+	}
+
+	s.size += int64(len(segment))
+	for band, extents := range updates {
+		for hash, extent := range extents {
+			s.dir[band][hash] = extent
+		}
+		s.pending[band] = make(map[uint64][]uint32)
+	}
+	return nil
+}
+
+// Err returns the first error an Append, Get or Flush ran into
+func (s *DiskBandStore) Err() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.err
+}
+
+// fail records err unless an earlier error was recorded
+func (s *DiskBandStore) fail(err error) {
+	s.errMu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.errMu.Unlock()
+}
+
+// Close closes the store's file; members appended since the last Flush are lost
+func (s *DiskBandStore) Close() error {
+	if s.file == nil {
+		return s.Err()
+	}
+	return s.file.Close()
+}
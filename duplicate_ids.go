@@ -0,0 +1,310 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DuplicateIDPolicy controls what happens when the same Product ID appears
+// more than once in an input slice passed to BuildIndex or FindDuplicates
+type DuplicateIDPolicy int
+
+const (
+	// DuplicateIDReject returns a *DuplicateIDError listing the repeated IDs (default)
+	// Silent corruption is the worst outcome, so rejecting is the safe default
+	DuplicateIDReject DuplicateIDPolicy = iota
+	// DuplicateIDKeepFirst keeps the first occurrence of each ID and drops the rest
+	DuplicateIDKeepFirst
+	// DuplicateIDKeepLast keeps the last occurrence of each ID and drops the rest
+	DuplicateIDKeepLast
+	// DuplicateIDSuffix keeps every occurrence, renaming repeats to "<id>#<n>"
+	// with n counting from 2 and skipping any "<id>#<n>" already taken by
+	// another product. The original ID is preserved in Product.SourceID for
+	// reporting
+	DuplicateIDSuffix
+)
+
+// String returns a human-readable name for the policy
+func (p DuplicateIDPolicy) String() string {
+	switch p {
+	case DuplicateIDReject:
+		return "reject"
+	case DuplicateIDKeepFirst:
+		return "keep-first"
+	case DuplicateIDKeepLast:
+		return "keep-last"
+	case DuplicateIDSuffix:
+		return "suffix"
+	default:
+		return fmt.Sprintf("DuplicateIDPolicy(%d)", int(p))
+	}
+}
+
+// DuplicateIDError is returned when the input contains repeated Product IDs
+// and the active policy is DuplicateIDReject
+type DuplicateIDError struct {
+	IDs []string // Offending IDs, sorted, each listed once
+}
+
+// Error implements the error interface
+func (e *DuplicateIDError) Error() string {
+	return fmt.Sprintf("duplicatecheck: %d product ID(s) appear more than once: %s",
+		len(e.IDs), strings.Join(e.IDs, ", "))
+}
+
+// ResolveDuplicateIDs applies a DuplicateIDPolicy to a product slice
+// Returns the input slice unchanged when all IDs are unique (no copy is made)
+// Otherwise returns a new slice with the policy applied, or an error for DuplicateIDReject
+func ResolveDuplicateIDs(products []Product, policy DuplicateIDPolicy) ([]Product, error) {
+	counts := make(map[string]int, len(products))
+	var repeated []string
+	for i := range products {
+		id := products[i].ID
+		counts[id]++
+		if counts[id] == 2 {
+			repeated = append(repeated, id)
+		}
+	}
+
+	if len(repeated) == 0 {
+		return products, nil
+	}
+
+	switch policy {
+	case DuplicateIDKeepFirst:
+		seen := make(map[string]bool, len(counts))
+		resolved := make([]Product, 0, len(counts))
+		for i := range products {
+			if seen[products[i].ID] {
+				continue
+			}
+			seen[products[i].ID] = true
+			resolved = append(resolved, copyProductFields(&products[i]))
+		}
+		return resolved, nil
+
+	case DuplicateIDKeepLast:
+		// Walk backwards so the last occurrence wins, then restore input order
+		seen := make(map[string]bool, len(counts))
+		keep := make([]int, 0, len(counts))
+		for i := len(products) - 1; i >= 0; i-- {
+			if seen[products[i].ID] {
+				continue
+			}
+			seen[products[i].ID] = true
+			keep = append(keep, i)
+		}
+		resolved := make([]Product, 0, len(keep))
+		for k := len(keep) - 1; k >= 0; k-- {
+			resolved = append(resolved, copyProductFields(&products[keep[k]]))
+		}
+		return resolved, nil
+
+	case DuplicateIDSuffix:
+		// A suffixed ID must not clash with an input ID ("a#2" in a, a, a#2)
+		// or an earlier suffixed one, so taken values of n are skipped
+		seen := make(map[string]bool, len(counts))
+		next := make(map[string]int, len(repeated))
+		taken := make(map[string]bool, len(products))
+		for id := range counts {
+			taken[id] = true
+		}
+		resolved := make([]Product, 0, len(products))
+		for i := range products {
+			p := copyProductFields(&products[i])
+			id := products[i].ID
+			if counts[id] > 1 {
+				if p.SourceID == "" {
+					p.SourceID = id
+				}
+				if seen[id] {
+					n := next[id]
+					if n == 0 {
+						n = 2
+					}
+					for taken[fmt.Sprintf("%s#%d", id, n)] {
+						n++
+					}
+					p.ID = fmt.Sprintf("%s#%d", id, n)
+					taken[p.ID] = true
+					next[id] = n + 1
+				}
+				seen[id] = true
+			}
+			resolved = append(resolved, p)
+		}
+		return resolved, nil
+
+	default:
+		sort.Strings(repeated)
+		return nil, &DuplicateIDError{IDs: repeated}
+	}
+}
+
+// collapseInputCopies drops the entries repeating an earlier entry's ID and
+// content fingerprint (see Product.Fingerprint), the same listing included
+// twice by mistake, and returns how many it dropped
+// Returns the input slice unchanged when nothing repeats; otherwise the
+// result points to the kept entries of the input.
+func collapseInputCopies(products []*Product) ([]*Product, int) {
+	// IDs are usually unique: fingerprint nothing unless one repeats
+	ids := make(map[string]bool, len(products))
+	repeated := false
+	for _, p := range products {
+		if ids[p.ID] {
+			repeated = true
+			break
+		}
+		ids[p.ID] = true
+	}
+	if !repeated {
+		return products, 0
+	}
+
+	type entry struct {
+		id          string
+		fingerprint uint64
+	}
+	seen := make(map[entry]bool, len(products))
+	kept := make([]*Product, 0, len(products))
+	for _, p := range products {
+		key := entry{p.ID, p.Fingerprint()}
+		if !seen[key] {
+			seen[key] = true
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(products) {
+		return products, 0
+	}
+	return kept, len(products) - len(kept)
+}
+
+// resolveInput collapses copies of the same entry, unless input
+// deduplication is off, then applies policy to the repeated IDs left
+// Returns the input slice unchanged when all IDs are unique, and the number
+// of entries collapsed.
+func resolveInput(products []Product, policy DuplicateIDPolicy, keepCopies bool) ([]Product, int, error) {
+	collapsed := 0
+	if !keepCopies {
+		var kept []*Product
+		if kept, collapsed = collapseInputCopies(productPtrs(products)); collapsed > 0 {
+			values := make([]Product, len(kept))
+			for i, p := range kept {
+				values[i] = copyProductFields(p)
+			}
+			products = values
+		}
+	}
+	resolved, err := ResolveDuplicateIDs(products, policy)
+	return resolved, collapsed, err
+}
+
+// resolveInputPtrs is resolveInput over product pointers (see
+// resolveDuplicateIDPtrs)
+func resolveInputPtrs(products []*Product, policy DuplicateIDPolicy, keepCopies bool) ([]*Product, error) {
+	if !keepCopies {
+		products, _ = collapseInputCopies(products)
+	}
+	return resolveDuplicateIDPtrs(products, policy)
+}
+
+// EnableInputDeduplication makes scans collapse entries of the input that
+// repeat an earlier entry's ID and content (default)
+// The same listing included twice by an upstream bug would otherwise pair
+// with itself at similarity 1.0, or fail the scan under DuplicateIDReject.
+// Copies are detected by ID and Product.Fingerprint, so entries sharing an ID
+// with different content still go through the DuplicateIDPolicy. Scan
+// summaries count the entries collapsed as InputDuplicatesCollapsed.
+func (e *LevenshteinEngine) EnableInputDeduplication() {
+	e.keepInputCopies = false
+}
+
+// DisableInputDeduplication hands every entry of the input to the
+// DuplicateIDPolicy, copies included, as before input deduplication
+func (e *LevenshteinEngine) DisableInputDeduplication() {
+	e.keepInputCopies = true
+}
+
+// IsInputDeduplicationEnabled returns whether scans collapse copies of the
+// same input entry
+func (e *LevenshteinEngine) IsInputDeduplicationEnabled() bool {
+	return !e.keepInputCopies
+}
+
+// EnableInputDeduplication makes BuildIndex and scans collapse entries of the
+// input that repeat an earlier entry's ID and content (default, see
+// LevenshteinEngine.EnableInputDeduplication)
+func (e *HybridEngine) EnableInputDeduplication() {
+	e.levenshteinEngine.EnableInputDeduplication()
+}
+
+// DisableInputDeduplication hands every entry of the input to the
+// DuplicateIDPolicy, copies included
+func (e *HybridEngine) DisableInputDeduplication() {
+	e.levenshteinEngine.DisableInputDeduplication()
+}
+
+// IsInputDeduplicationEnabled returns whether BuildIndex and scans collapse
+// copies of the same input entry
+func (e *HybridEngine) IsInputDeduplicationEnabled() bool {
+	return e.levenshteinEngine.IsInputDeduplicationEnabled()
+}
+
+// resolveProducts prepares a scan's input: copies collapsed, then the
+// engine's DuplicateIDPolicy applied; returns the number of copies collapsed
+func (e *LevenshteinEngine) resolveProducts(products []Product) ([]Product, int, error) {
+	return resolveInput(products, e.idPolicy, e.keepInputCopies)
+}
+
+// resolveProducts prepares a scan's or build's input (see
+// LevenshteinEngine.resolveProducts)
+func (e *HybridEngine) resolveProducts(products []Product) ([]Product, int, error) {
+	return resolveInput(products, e.idPolicy, e.levenshteinEngine.keepInputCopies)
+}
+
+// resolveDuplicateIDPtrs applies a DuplicateIDPolicy to a slice of product pointers
+// Returns the input slice unchanged when all IDs are unique; otherwise the
+// result points to fresh copies, as with ResolveDuplicateIDs
+func resolveDuplicateIDPtrs(products []*Product, policy DuplicateIDPolicy) ([]*Product, error) {
+	seen := make(map[string]bool, len(products))
+	for _, p := range products {
+		if !seen[p.ID] {
+			seen[p.ID] = true
+			continue
+		}
+
+		// First repeat found: resolve on copies
+		values := make([]Product, len(products))
+		for k := range products {
+			values[k] = copyProductFields(products[k])
+		}
+		resolved, err := ResolveDuplicateIDs(values, policy)
+		if err != nil {
+			return nil, err
+		}
+		return productPtrs(resolved), nil
+	}
+	return products, nil
+}
+
+// productPtrs returns a pointer to each element of products
+func productPtrs(products []Product) []*Product {
+	ptrs := make([]*Product, len(products))
+	for i := range products {
+		ptrs[i] = &products[i]
+	}
+	return ptrs
+}
+
+// copyProductFields copies the exported fields of a product without its caches
+// The copy gets fresh (empty) normalization and n-gram caches
+func copyProductFields(p *Product) Product {
+	return Product{
+		ID:          p.ID,
+		SourceID:    p.SourceID,
+		Name:        p.Name,
+		Description: p.Description,
+	}
+}
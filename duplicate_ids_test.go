@@ -0,0 +1,290 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func duplicateIDProducts() []Product {
+	return []Product{
+		{ID: "A", Name: "Apple iPhone 14 Pro", Description: "Old listing text for the phone"},
+		{ID: "B", Name: "Samsung Galaxy S22", Description: "Android flagship"},
+		{ID: "A", Name: "Sony WH-1000XM5 Headphones", Description: "Noise cancelling over-ear"},
+		{ID: "C", Name: "Apple iPhone 14 Pro", Description: "Old listing text for the phone"},
+	}
+}
+
+func TestResolveDuplicateIDs(t *testing.T) {
+	t.Run("Unique IDs are returned unchanged", func(t *testing.T) {
+		products := []Product{{ID: "1", Name: "a"}, {ID: "2", Name: "b"}}
+		resolved, err := ResolveDuplicateIDs(products, DuplicateIDReject)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(resolved) != 2 || &resolved[0] != &products[0] {
+			t.Error("Expected the original slice to be returned")
+		}
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		_, err := ResolveDuplicateIDs(duplicateIDProducts(), DuplicateIDReject)
+		var dupErr *DuplicateIDError
+		if !errors.As(err, &dupErr) {
+			t.Fatalf("Expected *DuplicateIDError, got %v", err)
+		}
+		if len(dupErr.IDs) != 1 || dupErr.IDs[0] != "A" {
+			t.Errorf("Expected offending IDs [A], got %v", dupErr.IDs)
+		}
+		if !strings.Contains(err.Error(), "A") {
+			t.Errorf("Error message should list the offending ID: %s", err)
+		}
+	})
+
+	t.Run("Keep first", func(t *testing.T) {
+		resolved, err := ResolveDuplicateIDs(duplicateIDProducts(), DuplicateIDKeepFirst)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(resolved) != 3 {
+			t.Fatalf("Expected 3 products, got %d", len(resolved))
+		}
+		if resolved[0].ID != "A" || resolved[0].Name != "Apple iPhone 14 Pro" {
+			t.Errorf("Expected first occurrence of A to be kept, got %+v", resolved[0].Name)
+		}
+	})
+
+	t.Run("Keep last", func(t *testing.T) {
+		resolved, err := ResolveDuplicateIDs(duplicateIDProducts(), DuplicateIDKeepLast)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := []string{"B", "A", "C"}
+		if len(resolved) != len(want) {
+			t.Fatalf("Expected %d products, got %d", len(want), len(resolved))
+		}
+		for i, id := range want {
+			if resolved[i].ID != id {
+				t.Errorf("Position %d: expected ID %s, got %s", i, id, resolved[i].ID)
+			}
+		}
+		if resolved[1].Name != "Sony WH-1000XM5 Headphones" {
+			t.Errorf("Expected last occurrence of A to be kept, got %s", resolved[1].Name)
+		}
+	})
+
+	t.Run("Suffix", func(t *testing.T) {
+		resolved, err := ResolveDuplicateIDs(duplicateIDProducts(), DuplicateIDSuffix)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(resolved) != 4 {
+			t.Fatalf("Expected 4 products, got %d", len(resolved))
+		}
+		if resolved[0].ID != "A" || resolved[0].SourceID != "A" {
+			t.Errorf("First occurrence should keep its ID, got ID=%s SourceID=%s", resolved[0].ID, resolved[0].SourceID)
+		}
+		if resolved[2].ID != "A#2" || resolved[2].SourceID != "A" {
+			t.Errorf("Repeat should be suffixed, got ID=%s SourceID=%s", resolved[2].ID, resolved[2].SourceID)
+		}
+		if resolved[1].SourceID != "" {
+			t.Errorf("Unique IDs should not get a SourceID, got %s", resolved[1].SourceID)
+		}
+	})
+
+	t.Run("Suffix skips taken IDs", func(t *testing.T) {
+		products := []Product{
+			{ID: "a", Name: "First"},
+			{ID: "a", Name: "Second"},
+			{ID: "a#2", Name: "Third"},
+			{ID: "a", Name: "Fourth"},
+		}
+		resolved, err := ResolveDuplicateIDs(products, DuplicateIDSuffix)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := []string{"a", "a#3", "a#2", "a#4"}
+		seen := make(map[string]bool)
+		for i, p := range resolved {
+			if p.ID != want[i] {
+				t.Errorf("Product %d (%s): ID %s, want %s", i, p.Name, p.ID, want[i])
+			}
+			if seen[p.ID] {
+				t.Errorf("ID %s assigned twice", p.ID)
+			}
+			seen[p.ID] = true
+		}
+		if resolved[2].SourceID != "" || resolved[1].SourceID != "a" {
+			t.Errorf("SourceIDs %q, %q", resolved[1].SourceID, resolved[2].SourceID)
+		}
+	})
+}
+
+func TestLevenshteinDuplicateIDPolicy(t *testing.T) {
+	engine := NewLevenshteinEngine()
+
+	if engine.GetDuplicateIDPolicy() != DuplicateIDReject {
+		t.Fatalf("Default policy should be reject, got %s", engine.GetDuplicateIDPolicy())
+	}
+
+	results, err := engine.FindDuplicatesChecked(duplicateIDProducts(), 0.9)
+	if err == nil || results != nil {
+		t.Fatalf("Expected error and no results, got %v / %d results", err, len(results))
+	}
+	if engine.FindDuplicates(duplicateIDProducts(), 0.9) != nil {
+		t.Error("FindDuplicates should return nil when IDs are rejected")
+	}
+
+	engine.SetDuplicateIDPolicy(DuplicateIDSuffix)
+	results, err = engine.FindDuplicatesChecked(duplicateIDProducts(), 0.9)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, r := range results {
+		if r.ProductA.ID == r.ProductB.ID {
+			t.Errorf("Pair shares an ID after suffixing: %s", r.ProductA.ID)
+		}
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected the A/C pair only, got %d results", len(results))
+	}
+}
+
+func TestHybridDuplicateIDPolicy(t *testing.T) {
+	t.Run("Reject leaves index untouched", func(t *testing.T) {
+		engine := NewHybridEngine()
+		if err := engine.BuildIndex(duplicateIDProducts()); err == nil {
+			t.Fatal("Expected BuildIndex to reject repeated IDs")
+		}
+		if engine.GetIndexStats()["indexed"] != false {
+			t.Error("Index should not be built after rejection")
+		}
+		if _, err := engine.FindDuplicatesChecked(duplicateIDProducts(), 0.9); err == nil {
+			t.Error("Expected FindDuplicatesChecked to reject repeated IDs")
+		}
+	})
+
+	t.Run("Keep last leaves no stale bucket references", func(t *testing.T) {
+		engine := NewHybridEngine()
+		engine.SetDuplicateIDPolicy(DuplicateIDKeepLast)
+		if err := engine.BuildIndex(duplicateIDProducts()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		kept := engine.lshIndex.products["A"]
+		if kept.Name != "Sony WH-1000XM5 Headphones" {
+			t.Fatalf("Expected last occurrence of A in the index, got %s", kept.Name)
+		}
+
+		// Each band must reference every indexed ID exactly once
+		for bandIdx := 0; bandIdx < engine.numBands; bandIdx++ {
+			total := 0
+			engine.lshIndex.bands.ForEach(bandIdx, func(_ uint64, bucket []uint32) bool {
+				total += len(bucket)
+				return true
+			})
+			if total != len(engine.lshIndex.products) {
+				t.Errorf("Band %d holds %d entries for %d products", bandIdx, total, len(engine.lshIndex.products))
+			}
+		}
+
+		// And the bucket holding "A" must be the one derived from the kept text
+		text := strings.ToLower(kept.Name + " " + kept.Description)
+		signature := computeMinHashSignature(generateShingles(text, engine.shingleSize), engine.minHash)
+		rows := engine.lshIndex.rowsPerBand
+		for bandIdx := 0; bandIdx < engine.numBands; bandIdx++ {
+			bucket := engine.lshIndex.bands.Get(bandIdx, engine.lshIndex.bucketHash(hashBand(signature, bandIdx*rows, (bandIdx+1)*rows)))
+			found := false
+			for _, n := range bucket {
+				if engine.lshIndex.refs[n] == "A" {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Band %d: A is not in the bucket for its kept text", bandIdx)
+			}
+		}
+	})
+
+	t.Run("Suffix indexes every occurrence", func(t *testing.T) {
+		engine := NewHybridEngine()
+		engine.SetDuplicateIDPolicy(DuplicateIDSuffix)
+		if err := engine.BuildIndex(duplicateIDProducts()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if engine.GetIndexStats()["total_products"] != 4 {
+			t.Errorf("Expected 4 indexed products, got %v", engine.GetIndexStats()["total_products"])
+		}
+		if engine.lshIndex.products["A#2"].SourceID != "A" {
+			t.Error("Suffixed product should preserve its original ID in SourceID")
+		}
+	})
+}
+
+func TestInputDeduplication(t *testing.T) {
+	lamp := Product{ID: "L", Name: "Brass Desk Lamp", Description: "Adjustable arm"}
+	products := []Product{
+		lamp,
+		{ID: "K", Name: "Steel Kettle", Description: "1.7L"},
+		lamp,
+		{ID: "L", Name: "  brass desk lamp", Description: "Adjustable arm "}, // Same content after normalization
+	}
+
+	t.Run("copies collapsed", func(t *testing.T) {
+		engines := map[string]interface {
+			FindDuplicatesWithSummary([]Product, float64) ([]ComparisonResult, ScanSummary, error)
+		}{
+			"levenshtein": NewLevenshteinEngine(),
+			"hybrid":      NewHybridEngine(),
+		}
+		for name, engine := range engines {
+			results, summary, err := engine.FindDuplicatesWithSummary(products, 0.5)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if len(results) != 0 {
+				t.Errorf("%s: %d pairs among the copies, want 0", name, len(results))
+			}
+			if summary.InputDuplicatesCollapsed != 2 || summary.Products != 2 {
+				t.Errorf("%s: collapsed %d of %d products, want 2 of 2", name, summary.InputDuplicatesCollapsed, summary.Products)
+			}
+		}
+
+		hybrid := NewHybridEngine()
+		if err := hybrid.BuildIndex(products); err != nil {
+			t.Fatal(err)
+		}
+		if got := hybrid.IndexedCount(); got != 2 {
+			t.Errorf("indexed %d products, want 2", got)
+		}
+	})
+
+	t.Run("same ID, other content", func(t *testing.T) {
+		edited := append(products[:2:2], Product{ID: "L", Name: "Brass Desk Lamp XL", Description: "Adjustable arm"})
+		if _, err := NewLevenshteinEngine().FindDuplicatesChecked(edited, 0.5); !errors.As(err, new(*DuplicateIDError)) {
+			t.Errorf("got %v, want a *DuplicateIDError", err)
+		}
+	})
+
+	t.Run("off", func(t *testing.T) {
+		engine := NewLevenshteinEngine()
+		engine.DisableInputDeduplication()
+		if engine.IsInputDeduplicationEnabled() {
+			t.Fatal("input deduplication still enabled")
+		}
+		if _, err := engine.FindDuplicatesChecked(products, 0.5); !errors.As(err, new(*DuplicateIDError)) {
+			t.Errorf("got %v, want a *DuplicateIDError", err)
+		}
+		engine.SetDuplicateIDPolicy(DuplicateIDSuffix)
+		results, summary, err := engine.FindDuplicatesWithSummary(products, 0.5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 3 || summary.InputDuplicatesCollapsed != 0 {
+			t.Errorf("%d self-pairs, %d collapsed; want 3 and 0", len(results), summary.InputDuplicatesCollapsed)
+		}
+		if restored, err := NewEngineFromConfig(engine.Config()); err != nil || restored.(*LevenshteinEngine).IsInputDeduplicationEnabled() {
+			t.Errorf("config round trip: %v, deduplication enabled", err)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package duplicatecheck
+
+import "fmt"
+
+// DuplicateStatsOptions controls EstimateDuplicateStatsWithOptions
+type DuplicateStatsOptions struct {
+	// Exact verifies every LSH candidate as FindDuplicates does, keeping only
+	// counters, so the numbers match a FindDuplicates run without holding its
+	// results. By default candidates are only scored by SimHash.
+	Exact bool
+	// Margin is how far below the threshold a SimHash estimate may fall and
+	// still count (0 uses HybridConfig.SimHashMargin). Ignored when Exact.
+	Margin float64
+}
+
+// DuplicateStats summarizes the duplicates in a catalog without listing them
+type DuplicateStats struct {
+	Products               int    `json:"products"`                 // Products queried against the index
+	ProductsWithDuplicates int    `json:"products_with_duplicates"` // Products in at least one counted pair
+	Pairs                  int    `json:"pairs"`                    // Distinct counted pairs
+	Candidates             int    `json:"candidates"`               // Distinct LSH candidate pairs examined
+	Exact                  bool   `json:"exact"`                    // Counts are verified (see DuplicateStatsOptions.Exact)
+	Note                   string `json:"note"`                     // How the counts were obtained and how far to trust them
+}
+
+// EstimateDuplicateStats counts probable duplicates for a dashboard without
+// running Levenshtein or materializing pairs
+// Each product's LSH candidates count as duplicates when their SimHash
+// estimated similarity is at least threshold - SimHashMargin. The index is
+// built from products unless one is already built, in which case products are
+// queried against it as in FindDuplicates. Returns zero DuplicateStats if the
+// input is rejected by the DuplicateIDPolicy or the index can't be built; use
+// EstimateDuplicateStatsWithOptions to receive the error.
+func (e *HybridEngine) EstimateDuplicateStats(products []Product, threshold float64) DuplicateStats {
+	defer e.levenshteinEngine.guard("EstimateDuplicateStats", nil)
+	stats, _ := e.EstimateDuplicateStatsWithOptions(products, threshold, DuplicateStatsOptions{})
+	return stats
+}
+
+// EstimateDuplicateStatsWithOptions is EstimateDuplicateStats with exact counting
+// and margin control
+// Candidates and pairs are deduplicated as in FindDuplicates, and the pair
+// constraint and quality filter apply. Memory beyond the index grows with the
+// number of candidate pairs (one integer each), not with ComparisonResults.
+func (e *HybridEngine) EstimateDuplicateStatsWithOptions(products []Product, threshold float64, opts DuplicateStatsOptions) (_ DuplicateStats, err error) {
+	defer e.levenshteinEngine.guard("EstimateDuplicateStatsWithOptions", &err)
+	if err := validateThreshold(threshold); err != nil {
+		return DuplicateStats{}, err
+	}
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return DuplicateStats{}, err
+	}
+	idx := e.currentIndex()
+	if idx == nil {
+		if err := e.BuildIndex(resolved); err != nil {
+			return DuplicateStats{}, err
+		}
+		idx = e.currentIndex()
+	}
+	margin := opts.Margin
+	if margin <= 0 {
+		margin = e.simHashMargin
+	}
+
+	ptrs := productPtrs(resolved)
+	quality := e.levenshteinEngine.newQualityCheck()
+	if quality != nil {
+		ptrs = quality.products(ptrs)
+	}
+	excludeLow := quality != nil && quality.mode == QualityExclude
+
+	// Positions give every product a small integer, so pairs are tracked as
+	// one uint64 instead of a key string
+	positions := make(map[string]int, len(idx.ids)+len(ptrs))
+	position := func(id string) uint64 {
+		p, ok := positions[id]
+		if !ok {
+			p = len(positions)
+			positions[id] = p
+		}
+		return uint64(p)
+	}
+	for _, id := range idx.ids {
+		position(id)
+	}
+	fingerprints := make(map[string]SimHashFingerprint)
+	fingerprint := func(id string) (SimHashFingerprint, bool) {
+		if f, ok := idx.fingerprints[id]; ok {
+			return f, true
+		}
+		if f, ok := fingerprints[id]; ok {
+			return f, true
+		}
+		candidate, ok := idx.products[id]
+		if !ok {
+			return 0, false
+		}
+		f := e.simHash.Compute64(e.indexText(candidate))
+		fingerprints[id] = f
+		return f, true
+	}
+
+	// counts decides whether one candidate pair counts
+	counts := func(product *Product, query hybridQuery, candidateID string) bool {
+		if opts.Exact {
+			result, ok := e.verifyCandidate(idx, product, query, candidateID, threshold)
+			if !ok {
+				return false
+			}
+			result.stampThreshold(threshold)
+			return result.MeetsThreshold && (!excludeLow || quality.flag(&result))
+		}
+		if !e.candidateAllowed(idx, product, candidateID) {
+			return false
+		}
+		if excludeLow {
+			if candidate, ok := idx.products[candidateID]; ok && quality.isLow(candidate) {
+				return false
+			}
+		}
+		f, ok := fingerprint(candidateID)
+		return ok && Similarity(query.fingerprint, f) >= threshold-margin
+	}
+
+	stats := DuplicateStats{Products: len(ptrs), Exact: opts.Exact}
+	checked := make(map[uint64]bool)
+	flagged := make(map[uint64]bool)
+	for _, product := range ptrs {
+		query := e.newQuery(product)
+		if !opts.Exact && !e.simHashScreen && e.privacy == nil {
+			if indexed := idx.products[product.ID]; indexed != nil &&
+				indexed.Name == product.Name && indexed.Description == product.Description {
+				// Scanning the indexed catalog: reuse the product's fingerprint
+				query.fingerprint, _ = fingerprint(product.ID)
+			} else {
+				query.fingerprint = e.simHash.Compute64(query.text)
+			}
+		}
+		self := position(product.ID)
+		for _, candidate := range e.findCandidates(idx, product, threshold) {
+			if candidate.id == product.ID {
+				continue
+			}
+			other := position(candidate.id)
+			key := self<<32 | other
+			if other < self {
+				key = other<<32 | self
+			}
+			if checked[key] {
+				continue
+			}
+			checked[key] = true
+			stats.Candidates++
+			if counts(product, query, candidate.id) {
+				stats.Pairs++
+				flagged[self], flagged[other] = true, true
+			}
+		}
+	}
+	stats.ProductsWithDuplicates = len(flagged)
+
+	if opts.Exact {
+		stats.Note = "exact: every LSH candidate verified as in FindDuplicates"
+	} else {
+		stats.Note = fmt.Sprintf("estimated: LSH candidates with SimHash similarity >= %.2f (threshold %.2f - margin %.2f), not verified; "+
+			"expect more pairs than FindDuplicates near the threshold and fewer on short texts", threshold-margin, threshold, margin)
+	}
+	return stats, nil
+}
@@ -0,0 +1,53 @@
+//go:build !race
+
+package duplicatecheck
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// allocatedBytes returns the bytes f allocates
+func allocatedBytes(f func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+// TestExactDuplicateStatsMemory compares allocations of two paths, which the
+// race detector skews; the file isn't built with -race
+func TestExactDuplicateStatsMemory(t *testing.T) {
+	// Variants of one product: nearly every pair is a duplicate
+	var products []Product
+	for i := 0; i < 300; i++ {
+		products = append(products, Product{
+			ID:          fmt.Sprintf("v%03d", i),
+			Name:        fmt.Sprintf("Acme Pro Blender 1200W Model %d", i),
+			Description: "Stainless steel blender with six speeds, pulse mode and a 1.5 litre glass jar",
+		})
+	}
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+
+	var stats DuplicateStats
+	var results []ComparisonResult
+	counted := allocatedBytes(func() {
+		stats, _ = engine.EstimateDuplicateStatsWithOptions(products, 0.85, DuplicateStatsOptions{Exact: true})
+	})
+	found := allocatedBytes(func() {
+		results = engine.FindDuplicates(products, 0.85)
+	})
+	if stats.Pairs != len(results) || stats.Pairs < 10000 {
+		t.Fatalf("Exact stats counted %d pairs, FindDuplicates found %d", stats.Pairs, len(results))
+	}
+	// Verification itself still allocates; the results and pair keys don't
+	if 3*counted > 2*found {
+		t.Errorf("Exact stats allocated %d bytes, FindDuplicates %d", counted, found)
+	}
+}
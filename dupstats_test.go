@@ -0,0 +1,82 @@
+package duplicatecheck
+
+import (
+	"math"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+// estimateTolerance is the relative error allowed between estimated and
+// verified counts on the generated catalog at moderate thresholds; near 1 the
+// SimHash margin dominates and estimates run high (see README)
+const estimateTolerance = 0.4
+
+// distinctProducts returns the number of products appearing in results
+func distinctProducts(results []ComparisonResult) int {
+	ids := make(map[string]bool)
+	for _, r := range results {
+		ids[r.ProductA.ID], ids[r.ProductB.ID] = true, true
+	}
+	return len(ids)
+}
+
+func TestEstimateDuplicateStats(t *testing.T) {
+	cfg := synth.Default()
+	cfg.Size, cfg.DuplicateRate = 500, 0.1
+	products, _ := generatedCatalog(cfg)
+
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	for _, threshold := range []float64{0.75, 0.85} {
+		results := engine.FindDuplicates(products, threshold)
+		exact, err := engine.EstimateDuplicateStatsWithOptions(products, threshold, DuplicateStatsOptions{Exact: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !exact.Exact || exact.Pairs != len(results) || exact.ProductsWithDuplicates != distinctProducts(results) {
+			t.Errorf("threshold %v: exact stats %d pairs, %d products; FindDuplicates %d pairs, %d products",
+				threshold, exact.Pairs, exact.ProductsWithDuplicates, len(results), distinctProducts(results))
+		}
+
+		estimated := engine.EstimateDuplicateStats(products, threshold)
+		if estimated.Exact || estimated.Note == "" || estimated.Products != len(products) {
+			t.Errorf("threshold %v: estimated stats = %+v", threshold, estimated)
+		}
+		for _, c := range []struct {
+			name            string
+			estimate, exact int
+		}{
+			{"pairs", estimated.Pairs, exact.Pairs},
+			{"products with duplicates", estimated.ProductsWithDuplicates, exact.ProductsWithDuplicates},
+		} {
+			if c.exact == 0 || math.Abs(float64(c.estimate-c.exact))/float64(c.exact) > estimateTolerance {
+				t.Errorf("threshold %v: estimated %s %d, verified %d", threshold, c.name, c.estimate, c.exact)
+			}
+		}
+	}
+
+	t.Run("Index is built or reused", func(t *testing.T) {
+		fresh := NewHybridEngine()
+		if stats := fresh.EstimateDuplicateStats(products, 0.85); stats.Pairs == 0 || fresh.IndexedCount() != len(products) {
+			t.Errorf("Estimate without an index: %d pairs, %d indexed", stats.Pairs, fresh.IndexedCount())
+		}
+		idx := engine.lshIndex
+		engine.EstimateDuplicateStats(products[:100], 0.85)
+		if engine.lshIndex != idx {
+			t.Error("A built index should be reused")
+		}
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		if _, err := NewHybridEngine().EstimateDuplicateStatsWithOptions(products, 1.5, DuplicateStatsOptions{}); err == nil {
+			t.Error("Expected an error for a threshold above 1")
+		}
+		repeated := []Product{products[0], {ID: products[0].ID, Name: products[0].Name + " v2"}}
+		if _, err := NewHybridEngine().EstimateDuplicateStatsWithOptions(repeated, 0.85, DuplicateStatsOptions{}); err == nil {
+			t.Error("Expected a *DuplicateIDError for repeated IDs")
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package duplicatecheck
+
+import "sort"
+
+// VersionDelta reports how much an edit changed a product (see CompareVersions)
+type VersionDelta struct {
+	NameSimilarity        float64 // Similarity of the two versions' names (1.0: unchanged)
+	DescriptionSimilarity float64 // Similarity of the two versions' descriptions (1.0: unchanged)
+	NameChanged           bool    // The prepared names differ
+	DescriptionChanged    bool    // The prepared descriptions differ
+}
+
+// EditField names the field an edit changed
+type EditField int
+
+const (
+	// EditFieldNone means neither field's change made the match
+	EditFieldNone EditField = iota
+	// EditFieldName means the name change made the match
+	EditFieldName
+	// EditFieldDescription means the description change made the match
+	EditFieldDescription
+)
+
+// String returns the field's name
+func (f EditField) String() string {
+	switch f {
+	case EditFieldName:
+		return "name"
+	case EditFieldDescription:
+		return "description"
+	default:
+		return "none"
+	}
+}
+
+// EditMatch is a match an edit created, attributed to the field responsible
+type EditMatch struct {
+	Result ComparisonResult // The after-version's match
+	Cause  EditField        // The field whose change contributed most
+	// CombinedSimilarity of the match with the name kept at its before-version
+	// value, and with the description kept at its before-version value
+	WithoutNameChange        float64
+	WithoutDescriptionChange float64
+}
+
+// EditImpact reports how an edit changed a product's duplicates in the index
+// (see CheckEditImpact)
+type EditImpact struct {
+	Delta           VersionDelta
+	NewMatches      []EditMatch        // Matches of the after-version the before-version didn't have
+	ResolvedMatches []ComparisonResult // Matches of the before-version the after-version doesn't have
+	KeptMatches     []ComparisonResult // Matches of both versions, as the after-version scores them
+	Truncated       bool               // A version's query exceeded HybridConfig.MaxCandidates
+}
+
+// CompareVersions reports how much an edit changed a product, field by field
+// The versions are compared under the engine's similarity settings; IDs are
+// ignored.
+func (e *LevenshteinEngine) CompareVersions(before, after Product) VersionDelta {
+	defer e.guard("CompareVersions", nil)
+	result := e.CompareWithWeights(before, after, ComparisonWeights{NameWeight: 0.5, DescriptionWeight: 0.5})
+	nameBefore, descBefore := before.preparedStrings(e.preparer())
+	nameAfter, descAfter := after.preparedStrings(e.preparer())
+	return VersionDelta{
+		NameSimilarity:        result.NameSimilarity,
+		DescriptionSimilarity: result.DescriptionSimilarity,
+		NameChanged:           nameBefore != nameAfter,
+		DescriptionChanged:    descBefore != descAfter,
+	}
+}
+
+// CompareVersions reports how much an edit changed a product, field by field
+// (see LevenshteinEngine.CompareVersions)
+func (e *HybridEngine) CompareVersions(before, after Product) VersionDelta {
+	defer e.levenshteinEngine.guard("CompareVersions", nil)
+	return e.levenshteinEngine.CompareVersions(before, after)
+}
+
+// CheckEditImpact reports whether editing an indexed product from before to
+// after moves it into, or out of, duplicate territory at threshold
+// It is CheckEditImpactChecked without the error: an empty EditImpact
+// without an index, or in privacy mode.
+func (e *HybridEngine) CheckEditImpact(before, after Product, threshold float64) EditImpact {
+	defer e.levenshteinEngine.guard("CheckEditImpact", nil)
+	impact, _ := e.CheckEditImpactChecked(before, after, threshold)
+	return impact
+}
+
+// CheckEditImpactChecked queries the index with both versions of an edited
+// product, as FindDuplicatesForOne does, and diffs their matches
+// Meant for edits not yet applied to the index: the after-version's query
+// under an indexed ID counts as a stale product query (see GetIndexStats).
+// Matches with the product itself, by either version's ID, are left out.
+// Each new match is re-scored with the name, then the description, held at
+// its before-version value; the field whose change lost the most similarity
+// is its Cause. Returns ErrIndexNotBuilt without an index, ErrNoProductText in
+// privacy mode, which can't re-score, and ErrQueryTruncated with the impact
+// when a query exceeded HybridConfig.MaxCandidates.
+func (e *HybridEngine) CheckEditImpactChecked(before, after Product, threshold float64) (_ EditImpact, err error) {
+	defer e.levenshteinEngine.guard("CheckEditImpactChecked", &err)
+	idx := e.currentIndex()
+	if idx == nil {
+		return EditImpact{}, ErrIndexNotBuilt
+	}
+	if e.privacy != nil {
+		return EditImpact{}, ErrNoProductText
+	}
+
+	impact := EditImpact{Delta: e.CompareVersions(before, after)}
+	self := map[string]bool{before.ID: true, after.ID: true}
+	matchesBefore, errBefore := e.findDuplicatesForOne(idx, &before, threshold)
+	matchesAfter, errAfter := e.findDuplicatesForOne(idx, &after, threshold)
+	impact.Truncated = errBefore != nil || errAfter != nil
+
+	matchedBefore := make(map[string]bool, len(matchesBefore))
+	for _, result := range matchesBefore {
+		if !self[result.ProductB.ID] {
+			matchedBefore[result.ProductB.ID] = true
+		}
+	}
+	matchedAfter := make(map[string]bool, len(matchesAfter))
+	for _, result := range matchesAfter {
+		id := result.ProductB.ID
+		if self[id] {
+			continue
+		}
+		matchedAfter[id] = true
+		if matchedBefore[id] {
+			impact.KeptMatches = append(impact.KeptMatches, result)
+			continue
+		}
+		impact.NewMatches = append(impact.NewMatches, e.attributeEdit(before, after, result))
+	}
+	for _, result := range matchesBefore {
+		if id := result.ProductB.ID; !self[id] && !matchedAfter[id] {
+			impact.ResolvedMatches = append(impact.ResolvedMatches, result)
+		}
+	}
+
+	sort.Slice(impact.NewMatches, func(i, j int) bool {
+		return impact.NewMatches[i].Result.ProductB.ID < impact.NewMatches[j].Result.ProductB.ID
+	})
+	sortResultsByPartner(impact.ResolvedMatches)
+	sortResultsByPartner(impact.KeptMatches)
+	if impact.Truncated {
+		return impact, ErrQueryTruncated
+	}
+	return impact, nil
+}
+
+// attributeEdit re-scores a new match of the after-version with each field
+// held at its before-version value, and blames the field whose change lost
+// the most similarity
+func (e *HybridEngine) attributeEdit(before, after Product, result ComparisonResult) EditMatch {
+	partner := result.ProductB
+	oldName, oldDescription := after, after
+	oldName.Name = before.Name
+	oldDescription.Description = before.Description
+
+	match := EditMatch{
+		Result:                   result,
+		WithoutNameChange:        e.levenshteinEngine.Compare(oldName, partner).CombinedSimilarity,
+		WithoutDescriptionChange: e.levenshteinEngine.Compare(oldDescription, partner).CombinedSimilarity,
+	}
+	nameGain := result.CombinedSimilarity - match.WithoutNameChange
+	descriptionGain := result.CombinedSimilarity - match.WithoutDescriptionChange
+	switch {
+	case descriptionGain > nameGain && descriptionGain > 0:
+		match.Cause = EditFieldDescription
+	case nameGain > 0:
+		match.Cause = EditFieldName
+	}
+	return match
+}
+
+// sortResultsByPartner orders one product's results by the partner's ID
+func sortResultsByPartner(results []ComparisonResult) {
+	sort.Slice(results, func(i, j int) bool { return results[i].ProductB.ID < results[j].ProductB.ID })
+}
@@ -0,0 +1,131 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func editCatalog() []Product {
+	return []Product{
+		{ID: "iphone", Name: "Apple iPhone 14 Pro Max 256GB", Description: "Smartphone with A16 Bionic chip"},
+		{ID: "iphone-dup", Name: "Apple iPhone 14 Pro Max 256 GB", Description: "Smartphone with A16 Bionic chip"},
+		{ID: "galaxy", Name: "Samsung Galaxy S23 Ultra", Description: "Android flagship phone"},
+		{ID: "sony", Name: "Sony WH-1000XM5 Headphnoes", Description: "Noise cancelling wireless headphones"},
+		{ID: "bose", Name: "Bose QuietComfort Ultra Earbuds", Description: "Noise cancelling wireless earbuds"},
+	}
+}
+
+func TestCheckEditImpact(t *testing.T) {
+	catalog := editCatalog()
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	rename := func(p Product, name string) Product {
+		p.Name = name
+		return p
+	}
+
+	tests := []struct {
+		name         string
+		before       Product
+		after        Product
+		wantNew      map[string]EditField
+		wantResolved []string
+		wantKept     []string
+	}{
+		{
+			name:    "copied name",
+			before:  catalog[2],
+			after:   rename(catalog[2], catalog[0].Name),
+			wantNew: map[string]EditField{"iphone": EditFieldName, "iphone-dup": EditFieldName},
+		},
+		{
+			name:    "typo fix",
+			before:  catalog[3],
+			after:   rename(catalog[3], "Sony WH-1000XM5 Headphones"),
+			wantNew: map[string]EditField{},
+		},
+		{
+			name:         "renamed away",
+			before:       catalog[1],
+			after:        rename(catalog[1], "Google Pixel 8 Pro 128GB"),
+			wantNew:      map[string]EditField{},
+			wantResolved: []string{"iphone"},
+		},
+		{
+			name:     "unchanged",
+			before:   catalog[1],
+			after:    catalog[1],
+			wantNew:  map[string]EditField{},
+			wantKept: []string{"iphone"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impact, err := engine.CheckEditImpactChecked(tt.before, tt.after, 0.7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(impact.NewMatches) != len(tt.wantNew) {
+				t.Fatalf("new matches %+v, want %v", impact.NewMatches, tt.wantNew)
+			}
+			for _, match := range impact.NewMatches {
+				id := match.Result.ProductB.ID
+				cause, ok := tt.wantNew[id]
+				if !ok || match.Cause != cause {
+					t.Errorf("new match %s caused by %s, want %v", id, match.Cause, tt.wantNew)
+				}
+				if match.WithoutNameChange >= match.Result.CombinedSimilarity {
+					t.Errorf("new match %s scores %.3f without the name change, %.3f with it", id, match.WithoutNameChange, match.Result.CombinedSimilarity)
+				}
+			}
+			if got := partnerIDs(impact.ResolvedMatches); fmt.Sprint(got) != fmt.Sprint(tt.wantResolved) {
+				t.Errorf("resolved matches %v, want %v", got, tt.wantResolved)
+			}
+			if got := partnerIDs(impact.KeptMatches); fmt.Sprint(got) != fmt.Sprint(tt.wantKept) {
+				t.Errorf("kept matches %v, want %v", got, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	before := Product{ID: "sony", Name: "Sony WH-1000XM5 Headphnoes", Description: "Noise cancelling"}
+	after := before
+	after.Name = "Sony WH-1000XM5 Headphones"
+
+	delta := NewHybridEngine().CompareVersions(before, after)
+	if !delta.NameChanged || delta.DescriptionChanged {
+		t.Errorf("changed name %v, description %v; want only the name", delta.NameChanged, delta.DescriptionChanged)
+	}
+	if delta.NameSimilarity < 0.9 || delta.NameSimilarity == 1 || delta.DescriptionSimilarity != 1 {
+		t.Errorf("name similarity %.3f, description similarity %.3f", delta.NameSimilarity, delta.DescriptionSimilarity)
+	}
+}
+
+func TestCheckEditImpactErrors(t *testing.T) {
+	product := editCatalog()[0]
+	if _, err := NewHybridEngine().CheckEditImpactChecked(product, product, 0.7); !errors.Is(err, ErrIndexNotBuilt) {
+		t.Errorf("without an index: got %v, want ErrIndexNotBuilt", err)
+	}
+
+	private := NewHybridEngine()
+	private.EnablePrivacyMode(PrivacyOptions{Salt: []byte("salt")})
+	if err := private.BuildIndex(editCatalog()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := private.CheckEditImpactChecked(product, product, 0.7); !errors.Is(err, ErrNoProductText) {
+		t.Errorf("in privacy mode: got %v, want ErrNoProductText", err)
+	}
+}
+
+// partnerIDs returns the ProductB IDs of results, in order
+func partnerIDs(results []ComparisonResult) []string {
+	ids := []string{}
+	for _, result := range results {
+		ids = append(ids, result.ProductB.ID)
+	}
+	return ids
+}
@@ -1,120 +1,232 @@
 package duplicatecheck
 
 import (
-	"strings"
+	"errors"
+	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 )
 
 // Product represents an item in your ecommerce system
+// Product holds no locks, so it is safe to copy; the lazily built caches live
+// behind a shared pointer (see productCache).
+//
+// Don't change a Product's Name or Description once an engine has read it:
+// edit a copy instead (or call UpdateProduct for an indexed product). The
+// cache notices the change and is rebuilt, so scores are never stale, but an
+// in-place edit usually means an index or a result still holds the old text.
+// SetMutationCheck reports such edits.
 type Product struct {
 	ID          string
+	SourceID    string // Original ID when ID was rewritten by DuplicateIDSuffix (empty otherwise)
 	Name        string
-	Description string // Product description up to 3000 characters
-	// Cached normalized versions (lazy initialization)
+	Description string       // Product description up to 3000 characters
+	cache       atomic.Value // *productCache, built on first use (see loadCache)
+}
+
+// productCache holds values derived from a product's Name and Description
+// Copies of a Product share the cache; it records the fields and the text
+// preparation it was built from, so a copy whose fields were changed, or a
+// product read by an engine preparing text differently, rebuilds it instead
+// of reusing it.
+type productCache struct {
+	name, desc     string      // Source fields the cache was built from
+	owner          *Product    // Product that built the cache; copies share it (see SetMutationCheck)
+	mutated        atomic.Bool // The owner's fields were found changed (counted once)
+	prep           uint64      // Fingerprint of the TextPreparation that built it
 	normalizedName string
 	normalizedDesc string
-	normalized     uint32 // atomic flag: 0 = not normalized, 1 = normalized
-	// N-gram caching for repeated comparisons
-	ngramsCache map[int][][2]string // ngramsCache[n] = n-grams for this n value
-	ngramsMutex sync.RWMutex         // Protects ngramsCache and normalized strings
+	homoglyphs     bool // TextPreparation.Homoglyphs changed Name or Description
+	// Text shape for charset pruning (see charsetShape)
+	nameLen, descLen int    // Rune lengths of normalizedName and normalizedDesc
+	nameCharBits     uint32 // Letters a-z in normalizedName
+	// Content fingerprint (lazy initialization, see Fingerprint)
+	fingerprint   uint64
+	fingerprinted uint32 // atomic flag: 0 = not computed, 1 = computed
+	// Description language and name spec tokens (lazy, see
+	// descriptionLanguage and specTokens)
+	language atomic.Pointer[detectedLanguage]
+	specs    atomic.Pointer[SpecTokens]
+	// N-gram caching for repeated comparisons (see fieldNgrams)
+	ngramsCache map[ngramKey]*cachedNgrams
+	ngramsClock atomic.Uint64 // Request counter ordering description n-gram sizes for eviction
+	ngramsMutex sync.RWMutex  // Protects ngramsCache
+	// MinHash signatures cached by HybridEngine.Warmup (see cachedSignatures)
+	signatures   [][]uint32
+	signatureKey uint64       // IndexConfigFingerprint the signatures were computed under
+	signatureMu  sync.RWMutex // Protects signatures and signatureKey
 }
 
-// getNormalizedStrings returns cached normalized (lowercase, trimmed) versions of Name and Description
-// This avoids repeated string operations in batch comparisons
-// Uses double-checked locking pattern with atomic flag for thread-safe lazy initialization
-func (p *Product) getNormalizedStrings() (name, desc string) {
-	// Fast path: if already normalized, return immediately (atomic read, no lock needed)
-	if atomic.LoadUint32(&p.normalized) == 1 {
-		return p.normalizedName, p.normalizedDesc
+// cachedValue returns the product's cache if it matches the current fields and
+// the default text preparation, or nil
+func (p *Product) cachedValue() *productCache {
+	return p.cachedValueFor(defaultPreparer)
+}
+
+// cachedValueFor returns the product's cache if it matches the current fields and prep, or nil
+// Comparing strings that share backing memory is a pointer check, so this is cheap
+func (p *Product) cachedValueFor(prep *textPreparer) *productCache {
+	c, _ := p.cache.Load().(*productCache)
+	if c == nil {
+		return nil
+	}
+	if c.name != p.Name || c.desc != p.Description {
+		if c.owner == p {
+			checkMutation(p, c) // Changed in place, not an edited copy
+		}
+		return nil
 	}
+	if c.prep != prep.fingerprint {
+		return nil
+	}
+	return c
+}
 
-	// Slow path: need to normalize - acquire lock
-	p.ngramsMutex.Lock()
-	defer p.ngramsMutex.Unlock()
+// loadCache returns the product's cache under the default text preparation
+func (p *Product) loadCache() *productCache {
+	return p.loadCacheFor(defaultPreparer)
+}
 
-	// Double-check: another goroutine might have done the work while waiting for lock
-	if p.normalized == 0 {
-		p.normalizedName = strings.ToLower(strings.TrimSpace(p.Name))
-		p.normalizedDesc = strings.ToLower(strings.TrimSpace(p.Description))
-		// Atomic store to ensure visibility across goroutines
-		atomic.StoreUint32(&p.normalized, 1)
+// loadCacheFor returns the product's cache under prep, building it on first use
+// Concurrent first callers may both build one; the first to publish wins
+func (p *Product) loadCacheFor(prep *textPreparer) *productCache {
+	if c := p.cachedValueFor(prep); c != nil {
+		return c
 	}
-	return p.normalizedName, p.normalizedDesc
-}
 
-// GetNgrams returns cached n-grams for the product name
-// Generates and caches n-grams on first call, returns cached version on subsequent calls
-// n parameter specifies the n-gram size (e.g., 2 for bigrams, 3 for trigrams)
-// Thread-safe with double-checked locking pattern
-func (p *Product) GetNgrams(n int) [][2]string {
-	if n < 1 {
-		return [][2]string{}
+	name, desc, homoglyphs := prep.options.prepare(p.Name, p.Description)
+	name, desc = prep.interner.Intern(name), prep.interner.Intern(desc)
+	c := &productCache{
+		name:           p.Name,
+		desc:           p.Description,
+		owner:          p,
+		prep:           prep.fingerprint,
+		normalizedName: name,
+		normalizedDesc: desc,
+		homoglyphs:     homoglyphs,
+	}
+	c.charsetShape()
+	if p.cache.CompareAndSwap(p.cache.Load(), c) {
+		return c
 	}
+	// Lost the race: use the published cache if it matches, else our own
+	if published := p.cachedValueFor(prep); published != nil {
+		return published
+	}
+	return c
+}
 
-	// Check if already cached (fast path - read-heavy, most calls hit this)
-	p.ngramsMutex.RLock()
-	if p.ngramsCache != nil {
-		if cached, exists := p.ngramsCache[n]; exists {
-			p.ngramsMutex.RUnlock()
-			return cached
-		}
+// warmCaches builds the cache of every product under prep
+// Parallel scans call this first so workers (and the product copies stored in
+// results) only ever read published caches
+func warmCaches(products []*Product, prep *textPreparer) {
+	for _, p := range products {
+		p.loadCacheFor(prep)
 	}
-	p.ngramsMutex.RUnlock()
+}
+
+// getNormalizedStrings returns cached normalized (lowercase, trimmed) versions of Name and Description
+// This avoids repeated string operations in batch comparisons
+func (p *Product) getNormalizedStrings() (name, desc string) {
+	return p.preparedStrings(defaultPreparer)
+}
 
-	// Slow path: need to generate and cache
-	// Generate n-grams (outside lock to minimize contention)
-	name, _ := p.getNormalizedStrings()
-	ngrams := generateNgrams(name, n)
+// preparedStrings returns Name and Description as prepared by prep, cached
+func (p *Product) preparedStrings(prep *textPreparer) (name, desc string) {
+	c := p.loadCacheFor(prep)
+	return c.normalizedName, c.normalizedDesc
+}
 
-	// Store result with proper locking
-	p.ngramsMutex.Lock()
-	defer p.ngramsMutex.Unlock()
+// ComparisonResult contains the similarity metrics between two products
+type ComparisonResult struct {
+	ProductA                   Product
+	ProductB                   Product
+	NameDistance               int               // Raw distance score for names
+	NameSimilarity             float64           // Normalized similarity for names [0.0-1.0]
+	DescriptionDistance        int               // Raw distance score for descriptions
+	DescriptionSimilarity      float64           // Normalized similarity for descriptions [0.0-1.0]
+	CombinedSimilarity         float64           // Weighted combined similarity score [0.0-1.0]
+	Stage                      string            // How the score was obtained: "" for full comparison, or StageEstimated/StageExternal/StageEstimatedReject
+	WeightsUsed                ComparisonWeights // Normalized weights applied to this pair (default, resolver, or explicit)
+	SimilarityMode             SimilarityMode    // How distances were normalized into similarities
+	ThresholdUsed              float64           // Threshold the pair was judged against (explicit, or the engine default)
+	MeetsThreshold             bool              // CombinedSimilarity >= ThresholdUsed - ThresholdEpsilon
+	MatchType                  MatchType         // Exact, normalized-exact (case/whitespace only), or fuzzy
+	DifferenceKinds            []string          // For MatchNormalizedExact: what normalization removed (DifferenceCase, DifferenceWhitespace)
+	SegmentSimilarities        []SegmentScore    // Per-section description scores when a DescriptionSegmenter is set
+	LowQualityInput            bool              // A product failed the engine's QualityFilter (QualityFlag mode)
+	DescriptionTimedOut        bool              // The description comparison exceeded MaxComparisonDuration and scored 0
+	NameInDescriptionAB        float64           // A's name found in B's description [0.0-1.0], with WithCrossFieldMatching
+	NameInDescriptionBA        float64           // B's name found in A's description [0.0-1.0], with WithCrossFieldMatching
+	CandidateSource            string            // How HybridEngine found the pair: CandidateSourceLSH or CandidateSourceExhaustive ("" for other engines)
+	DuplicateProbability       float64           // Probability the pair is a true duplicate, with WithCalibration (0 otherwise)
+	ObfuscationSuspected       bool              // Names matched better with look-alike characters replaced, with WithDeobfuscation
+	DeobfuscatedNameSimilarity float64           // Similarity of the de-obfuscated names when ObfuscationSuspected (0 otherwise)
+	DescriptionLoadFailed      bool              // A description the pair needed failed to load; scored without loaded descriptions (see WithLazyDescriptionLoader)
+	SameSourceIDs              bool              // The IDs name the same source, with WithIDComparator and FlagSameSource
+	CrossLanguage              bool              // The descriptions are in different languages, with a CrossLanguagePolicy other than CrossLanguagePenalize
+	ClusterInferred            bool              // HybridEngine verified this pair as a clique's representative-member pair; pairs between the other members were inferred, not computed (see HybridConfig.CliqueBands)
+	SKUNameComparison          bool              // Both names are SKU-like and were compared by exact SKU key, with WithSKUNames
+	SKUNameMixed               bool              // One name is SKU-like and the other isn't; compared as usual, with WithSKUNames
+	LowInfoNames               bool              // Both names carry no product information; compared by description alone, with WithLowInfoNames
+	AmbiguousProduct           bool              // A product is one WithAmbiguousProducts was given; CombinedSimilarity carries its penalty
+	EstimatedSimilarity        float64           // MinHash-estimated Jaccard similarity HybridEngine.Compare judged the pair by, with HybridConfig.FastCompare (0 otherwise)
+	BundleSimilarity           float64           // Similarity of the names' item-by-item alignment when both are bundles, with WithBundleSplitter (0 otherwise)
+	ComponentMatches           []ComponentMatch  // The alignment BundleSimilarity was scored by
+	HomoglyphsNormalized       bool              // Look-alike characters were replaced in either product's text, with WithHomoglyphs
 
-	// Ensure cache is initialized
-	if p.ngramsCache == nil {
-		p.ngramsCache = make(map[int][][2]string)
-	}
+	// With WithSwappedFields, a pair whose fields looked swapped and whose
+	// crossed comparison met the threshold: CombinedSimilarity is the crossed
+	// score, NameSimilarity and DescriptionSimilarity stay uncrossed
+	FieldsSwappedSuspected       bool
+	SwappedNameSimilarity        float64 // The swapped product's description against the other's name (0 otherwise)
+	SwappedDescriptionSimilarity float64 // The swapped product's name against the other's description (0 otherwise)
 
-	// Double-check: another goroutine might have already cached this n-gram size
-	if cached, exists := p.ngramsCache[n]; exists {
-		return cached
-	}
+	// ReasonCodes says why the pair was flagged or scored as it was, in
+	// ReasonRegistry order; a pair meeting its threshold has at least one.
+	// It consolidates MatchType and the flags above, which stay for now and
+	// always agree with it; automation should branch on HasReason.
+	ReasonCodes []ReasonCode
 
-	// Cache the result
-	p.ngramsCache[n] = ngrams
-	return ngrams
+	// Deprecated: Distance is NameDistance, not a combined distance; use
+	// NameDistance, or LegacyView while migrating. Zero when the engine's
+	// compatibility mode is disabled (see EnableCompatibilityMode).
+	Distance int
+	// Deprecated: Similarity is CombinedSimilarity, not a name-only score; use
+	// CombinedSimilarity, or LegacyView while migrating. Zero when the engine's
+	// compatibility mode is disabled.
+	Similarity float64
 }
 
-// generateNgrams generates n-grams of size n from a string
-// Returns pairs of (ngram_string, position) for efficient comparison
-func generateNgrams(s string, n int) [][2]string {
-	if n < 1 || len(s) < n {
-		return [][2]string{}
-	}
+// DefaultThreshold is the similarity threshold engines start with
+// 0.85 (85% similar) is a good starting point for product catalogs
+const DefaultThreshold = 0.85
 
-	ngrams := make([][2]string, 0, len(s)-n+1)
-	runes := []rune(s)
+// ThresholdEpsilon is the tolerance of every threshold comparison: a
+// similarity meets threshold when similarity >= threshold - ThresholdEpsilon
+// It absorbs floating-point noise, so a pair scoring 0.8499999999999999 on one
+// platform and 0.85 on another meets a 0.85 threshold on both.
+const ThresholdEpsilon = 1e-9
 
-	for i := 0; i <= len(runes)-n; i++ {
-		ngram := string(runes[i : i+n])
-		ngrams = append(ngrams, [2]string{ngram, string(rune(i))}) // Store ngram and position
-	}
+// meetsThreshold reports whether similarity reaches threshold, within ThresholdEpsilon
+func meetsThreshold(similarity, threshold float64) bool {
+	return similarity >= threshold-ThresholdEpsilon
+}
 
-	return ngrams
+// stampThreshold records the threshold a result was judged against
+func (r *ComparisonResult) stampThreshold(threshold float64) {
+	r.ThresholdUsed = threshold
+	r.MeetsThreshold = meetsThreshold(r.CombinedSimilarity, threshold)
+	r.assignReasons()
 }
 
-// ComparisonResult contains the similarity metrics between two products
-type ComparisonResult struct {
-	ProductA              Product
-	ProductB              Product
-	NameDistance          int     // Raw distance score for names
-	NameSimilarity        float64 // Normalized similarity for names [0.0-1.0]
-	DescriptionDistance   int     // Raw distance score for descriptions
-	DescriptionSimilarity float64 // Normalized similarity for descriptions [0.0-1.0]
-	CombinedSimilarity    float64 // Weighted combined similarity score [0.0-1.0]
-	Distance              int     // Legacy: kept for backward compatibility
-	Similarity            float64 // Legacy: kept for backward compatibility (same as CombinedSimilarity)
+// validateThreshold checks that a threshold is a number in [0.0-1.0]
+func validateThreshold(threshold float64) error {
+	if math.IsNaN(threshold) || threshold < 0 || threshold > 1 {
+		return fmt.Errorf("duplicatecheck: threshold must be in [0, 1], got %v", threshold)
+	}
+	return nil
 }
 
 // ComparisonWeights defines how much weight to give to name vs description
@@ -123,6 +235,103 @@ type ComparisonWeights struct {
 	DescriptionWeight float64 // Weight for description similarity (0.0-1.0)
 }
 
+// Normalized returns a cleaned copy of the weights that sums to 1.0
+// Rules:
+//   - Negative, NaN, and infinite weights are treated as 0
+//   - If both weights are 0 after cleaning, DefaultWeights() is returned
+//   - Otherwise each weight is divided by the total
+//
+// All comparisons use the normalized form internally, so config values like
+// 0.7000000000000001 can't push scores outside [0.0-1.0]
+func (w ComparisonWeights) Normalized() ComparisonWeights {
+	name := cleanWeight(w.NameWeight)
+	desc := cleanWeight(w.DescriptionWeight)
+
+	total := name + desc
+	if total == 0 || math.IsInf(total, 0) {
+		return DefaultWeights()
+	}
+
+	nameWeight := name / total
+	return ComparisonWeights{
+		NameWeight:        nameWeight,
+		DescriptionWeight: 1.0 - nameWeight,
+	}
+}
+
+// Validate reports weights that Normalized would have to repair
+// Returns an error for negative, NaN, or infinite weights, or when both weights are 0
+func (w ComparisonWeights) Validate() error {
+	for _, field := range []struct {
+		name  string
+		value float64
+	}{
+		{"NameWeight", w.NameWeight},
+		{"DescriptionWeight", w.DescriptionWeight},
+	} {
+		if math.IsNaN(field.value) || math.IsInf(field.value, 0) || field.value < 0 {
+			return fmt.Errorf("duplicatecheck: %s must be a finite non-negative number, got %v", field.name, field.value)
+		}
+	}
+	if w.NameWeight == 0 && w.DescriptionWeight == 0 {
+		return errors.New("duplicatecheck: NameWeight and DescriptionWeight can't both be 0")
+	}
+	return nil
+}
+
+// cleanWeight maps invalid weights (negative, NaN, infinite) to 0
+func cleanWeight(w float64) float64 {
+	if math.IsNaN(w) || math.IsInf(w, 0) || w < 0 {
+		return 0
+	}
+	return w
+}
+
+// combineSimilarities blends name and description similarity with normalized weights
+// Combined as a WeightedScore, description first, so the result is
+// descSim + (nameSim - descSim) * NameWeight and identical fields always
+// combine to exactly 1.0.
+func combineSimilarities(nameSim, descSim float64, weights ComparisonWeights) float64 {
+	var score WeightedScore
+	score.Add("description", descSim, weights.DescriptionWeight, true)
+	score.Add("name", nameSim, weights.NameWeight, true)
+	combined, _, _ := score.combine()
+	return combined
+}
+
+// combinePreparedFields combines field similarities of two prepared products
+// A field empty on both sides is missing, and the WeightedScore gives its
+// weight to the other field. A pair where each product lacks a different
+// field has nothing to compare and scores 0.
+func combinePreparedFields(nameA, nameB, descA, descB string, nameSim, descSim float64, weights ComparisonWeights) float64 {
+	nameMissing := nameA == "" && nameB == ""
+	descMissing := descA == "" && descB == ""
+	if nameMissing && descMissing {
+		// Two empty products: as equal as their descriptions
+		return descSim
+	}
+	if !nameMissing && !descMissing && (nameA == "" || nameB == "") && (descA == "" || descB == "") {
+		// One product has no data at all
+		return 0.0
+	}
+	var score WeightedScore
+	score.Add("description", descSim, weights.DescriptionWeight, !descMissing)
+	score.Add("name", nameSim, weights.NameWeight, !nameMissing)
+	combined, _, _ := score.combine()
+	return combined
+}
+
+// clampUnit clamps a score into [0.0-1.0], mapping NaN to 0
+func clampUnit(x float64) float64 {
+	if x < 0 || math.IsNaN(x) {
+		return 0
+	}
+	if x > 1 {
+		return 1
+	}
+	return x
+}
+
 // DefaultWeights returns sensible default weights
 // Name is weighted more heavily since it's typically more distinctive
 func DefaultWeights() ComparisonWeights {
@@ -132,21 +341,123 @@ func DefaultWeights() ComparisonWeights {
 	}
 }
 
+// PresetTechProducts weights names heavily: brand and model number identify electronics
+func PresetTechProducts() ComparisonWeights {
+	return ComparisonWeights{NameWeight: 0.8, DescriptionWeight: 0.2}
+}
+
+// PresetFashionProducts weights names almost exclusively: descriptions of apparel are boilerplate
+func PresetFashionProducts() ComparisonWeights {
+	return ComparisonWeights{NameWeight: 0.9, DescriptionWeight: 0.1}
+}
+
+// PresetContentProducts favors descriptions: book and media titles vary, synopses don't
+func PresetContentProducts() ComparisonWeights {
+	return ComparisonWeights{NameWeight: 0.4, DescriptionWeight: 0.6}
+}
+
+// PresetBalanced weights name and description equally
+func PresetBalanced() ComparisonWeights {
+	return ComparisonWeights{NameWeight: 0.5, DescriptionWeight: 0.5}
+}
+
+// WeightResolver picks the weights for one pair, e.g. by category or name pattern
+// Returning the zero ComparisonWeights{} falls back to the engine's weights.
+// Engines call it from parallel workers, so it must be safe for concurrent use.
+type WeightResolver func(a, b Product) ComparisonWeights
+
 // DuplicateCheckEngine is the interface that all similarity algorithms must implement.
 // This allows us to swap different algorithms (Levenshtein, Jaro-Winkler, Cosine, etc.)
 // and compare their performance and accuracy for detecting duplicate products.
+//
+// The built-in engines share these semantics, which spec_test.go checks for
+// every FindDuplicates variant; optimizations must keep them:
+//   - Compare is symmetric: Compare(b, a) gives the same distances and
+//     similarities as Compare(a, b), with ProductA and ProductB as passed
+//   - weights are normalized before they are applied (see
+//     ComparisonWeights.Normalized), so (2, 1) scores as (2/3, 1/3) and (0, 0)
+//     as DefaultWeights(); CombinedSimilarity is the field similarities
+//     weighted by WeightsUsed, and weights never change the field similarities
+//   - a field empty on both sides gives its weight to the other field: no
+//     names scores the descriptions, no descriptions scores the names; two
+//     empty products score 1.0, and a name-only product against a
+//     description-only one scores 0. A field empty on one side only scores 0
+//     and keeps its weight.
+//   - thresholds are inclusive within ThresholdEpsilon: a pair matches when
+//     CombinedSimilarity >= threshold - ThresholdEpsilon, and MatchRule
+//     minimums compare the same way
+//   - the deprecated Similarity and Distance fields are CombinedSimilarity and
+//     NameDistance, or zero without compatibility mode; thresholds never read them
 type DuplicateCheckEngine interface {
 	// GetName returns the human-readable name of the algorithm
 	GetName() string
 
 	// Compare computes the similarity between two products based on their names and descriptions
-	// Returns a ComparisonResult with distance and similarity metrics
+	// Returns a ComparisonResult with distance and similarity metrics. Symmetric
+	// in a and b.
 	Compare(a, b Product) ComparisonResult
 
 	// CompareWithWeights allows custom weighting of name vs description similarity
+	// The weights are normalized first and reported in WeightsUsed.
 	CompareWithWeights(a, b Product, weights ComparisonWeights) ComparisonResult
 
 	// FindDuplicates searches for potential duplicates in a product list
-	// Returns pairs of products that exceed the similarity threshold [0.0-1.0]
+	// Returns each pair of products whose CombinedSimilarity is at least the
+	// threshold [0.0-1.0], less ThresholdEpsilon, once, scored as Compare
+	// scores it and stamped with ThresholdUsed and MeetsThreshold. Engines
+	// generating candidates (HybridEngine's LSH) may miss pairs, but never
+	// return one below the threshold.
+	// No matches, including fewer than two products, is an empty non-nil
+	// slice; nil means the input was rejected (FindDuplicatesChecked, where an
+	// engine has it, returns the error). Check len(results) == 0 for "no matches".
 	FindDuplicates(products []Product, threshold float64) []ComparisonResult
 }
+
+// DefaultThresholdEngine is implemented by engines that own a default threshold,
+// so call sites don't each carry their own threshold constant
+type DefaultThresholdEngine interface {
+	// SetDefaultThreshold changes the default; returns an error outside [0.0-1.0]
+	SetDefaultThreshold(threshold float64) error
+
+	// GetDefaultThreshold returns the default (DefaultThreshold unless changed)
+	GetDefaultThreshold() float64
+
+	// IsDuplicate compares two products and checks the result against the default
+	IsDuplicate(a, b Product) (bool, ComparisonResult)
+
+	// FindDuplicatesDefault is FindDuplicates with the default threshold
+	FindDuplicatesDefault(products []Product) []ComparisonResult
+}
+
+var (
+	_ DefaultThresholdEngine = (*LevenshteinEngine)(nil)
+	_ DefaultThresholdEngine = (*HybridEngine)(nil)
+)
+
+// PointerEngine is implemented by engines that compare products by pointer
+// The value-based DuplicateCheckEngine methods wrap these. Products passed by
+// pointer keep their normalization caches between comparisons, which matters
+// in the O(n²) scans over long descriptions.
+type PointerEngine interface {
+	// ComparePtr is Compare without copying either product
+	ComparePtr(a, b *Product) ComparisonResult
+
+	// FindDuplicatesPtr is FindDuplicates over product pointers
+	FindDuplicatesPtr(products []*Product, threshold float64) []ComparisonResult
+}
+
+var (
+	_ PointerEngine = (*LevenshteinEngine)(nil)
+	_ PointerEngine = (*HybridEngine)(nil)
+)
+
+// AvailableEngines returns constructors for every engine shipped with the package
+// Tools that compare engines (like the CLI demo) iterate this list instead of
+// hardcoding engine types. New engines should be registered here.
+func AvailableEngines() []func() DuplicateCheckEngine {
+	return []func() DuplicateCheckEngine{
+		func() DuplicateCheckEngine { return NewLevenshteinEngine() },
+		func() DuplicateCheckEngine { return NewHybridEngine() },
+		func() DuplicateCheckEngine { return NewTFIDFEngine() },
+	}
+}
@@ -0,0 +1,540 @@
+package duplicatecheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+// loadSampleCatalog loads the small hand-written catalog in testdata/sample_catalog.json
+// P001/P002 differ only in case, P003/P004 in color name, P005/P006 in model number
+func loadSampleCatalog(t testing.TB) []Product {
+	t.Helper()
+	data, err := os.ReadFile("testdata/sample_catalog.json")
+	if err != nil {
+		t.Fatalf("Failed to read sample catalog: %v", err)
+	}
+	var products []Product
+	if err := json.Unmarshal(data, &products); err != nil {
+		t.Fatalf("Failed to decode sample catalog: %v", err)
+	}
+	return products
+}
+
+// sampleProduct returns the sample catalog product with id
+func sampleProduct(t testing.TB, catalog []Product, id string) Product {
+	t.Helper()
+	for _, p := range catalog {
+		if p.ID == id {
+			return p
+		}
+	}
+	t.Fatalf("Sample catalog has no product %s", id)
+	return Product{}
+}
+
+func TestComparisonWeightsNormalized(t *testing.T) {
+	tests := []struct {
+		name     string
+		weights  ComparisonWeights
+		wantName float64
+	}{
+		{"Already normalized", ComparisonWeights{0.7, 0.3}, 0.7},
+		{"Unnormalized", ComparisonWeights{2, 2}, 0.5},
+		{"Config drift", ComparisonWeights{0.7000000000000001, 0.3}, 0.7},
+		{"Both zero falls back to defaults", ComparisonWeights{0, 0}, DefaultWeights().NameWeight},
+		{"Negative treated as zero", ComparisonWeights{-1, 0.5}, 0},
+		{"NaN treated as zero", ComparisonWeights{math.NaN(), 1}, 0},
+		{"Infinity treated as zero", ComparisonWeights{math.Inf(1), 1}, 0},
+		{"Name only", ComparisonWeights{1, 0}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.weights.Normalized()
+			if math.Abs(got.NameWeight-tt.wantName) > 1e-12 {
+				t.Errorf("NameWeight = %v, want %v", got.NameWeight, tt.wantName)
+			}
+			if got.NameWeight+got.DescriptionWeight != 1.0 {
+				t.Errorf("Normalized weights sum to %v, want exactly 1.0", got.NameWeight+got.DescriptionWeight)
+			}
+		})
+	}
+}
+
+func TestComparisonWeightsValidate(t *testing.T) {
+	valid := []ComparisonWeights{{0.7, 0.3}, {1, 0}, {0, 5}}
+	for _, w := range valid {
+		if err := w.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", w, err)
+		}
+	}
+
+	invalid := []ComparisonWeights{{0, 0}, {-0.1, 1}, {math.NaN(), 1}, {1, math.Inf(1)}}
+	for _, w := range invalid {
+		if err := w.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", w)
+		}
+	}
+}
+
+// TestSimilarityBoundsProperty checks that every similarity output stays in [0,1]
+// for randomized weights, including pathological ones
+func TestSimilarityBoundsProperty(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	engine := NewLevenshteinEngine()
+
+	pathological := []float64{0, 1e-300, 1e300, -1, math.NaN(), math.Inf(1), math.Inf(-1), 0.7000000000000001}
+	randomWeight := func() float64 {
+		if rng.Intn(3) == 0 {
+			return pathological[rng.Intn(len(pathological))]
+		}
+		return rng.Float64() * 10
+	}
+
+	texts := []string{"", "iPhone 14 Pro", "iphone 14 pro max", "Samsung Galaxy S22",
+		"Lightweight running shoe with breathable mesh upper", "kitten", "sitting"}
+	randomProduct := func(id string) Product {
+		return Product{ID: id, Name: texts[rng.Intn(len(texts))], Description: texts[rng.Intn(len(texts))]}
+	}
+
+	inUnit := func(x float64) bool { return x >= 0 && x <= 1 }
+
+	for i := 0; i < 2000; i++ {
+		weights := ComparisonWeights{NameWeight: randomWeight(), DescriptionWeight: randomWeight()}
+		a, b := randomProduct("a"), randomProduct("b")
+
+		result := engine.CompareWithWeights(a, b, weights)
+		if !inUnit(result.NameSimilarity) || !inUnit(result.DescriptionSimilarity) ||
+			!inUnit(result.CombinedSimilarity) || !inUnit(result.Similarity) {
+			t.Fatalf("Out of range with weights %+v: name=%v desc=%v combined=%v",
+				weights, result.NameSimilarity, result.DescriptionSimilarity, result.CombinedSimilarity)
+		}
+
+		same := Product{ID: "c", Name: a.Name, Description: a.Description}
+		identical := engine.CompareWithWeights(a, same, weights)
+		if (a.Name != "" || a.Description != "") && identical.CombinedSimilarity != 1.0 {
+			t.Fatalf("Identical products scored %v with weights %+v", identical.CombinedSimilarity, weights)
+		}
+	}
+}
+
+func TestAvailableEngines(t *testing.T) {
+	constructors := AvailableEngines()
+	if len(constructors) < 2 {
+		t.Fatalf("Expected at least the Levenshtein and Hybrid engines, got %d", len(constructors))
+	}
+
+	names := make(map[string]bool)
+	for _, newEngine := range constructors {
+		engine := newEngine()
+		if engine == nil {
+			t.Fatal("Constructor returned nil engine")
+		}
+		name := engine.GetName()
+		if names[name] {
+			t.Errorf("Engine %q registered twice", name)
+		}
+		names[name] = true
+
+		// Each constructor must return a fresh instance
+		if newEngine() == engine {
+			t.Errorf("Engine %q constructor returned a shared instance", name)
+		}
+	}
+
+	for _, want := range []string{"Levenshtein Distance", "Hybrid (MinHash+LSH → Levenshtein)"} {
+		if !names[want] {
+			t.Errorf("Expected %q to be registered", want)
+		}
+	}
+}
+
+func TestDefaultThreshold(t *testing.T) {
+	engines := []interface {
+		DuplicateCheckEngine
+		DefaultThresholdEngine
+	}{NewLevenshteinEngine(), NewHybridEngine()}
+
+	near := []Product{
+		{ID: "1", Name: "Apple iPhone 14 Pro", Description: "128GB Space Black"},
+		{ID: "2", Name: "Apple iPhone 14 Pro", Description: "128GB Space Black!"},
+		{ID: "3", Name: "Samsung Galaxy S21", Description: "256GB Phantom Black"},
+		{ID: "4", Name: "Samsung Galaxy S22", Description: "256GB Phantom Black"},
+	}
+
+	for _, engine := range engines {
+		t.Run(engine.GetName(), func(t *testing.T) {
+			if engine.GetDefaultThreshold() != DefaultThreshold {
+				t.Fatalf("Default threshold = %v, want %v", engine.GetDefaultThreshold(), DefaultThreshold)
+			}
+
+			for _, bad := range []float64{-0.1, 1.1, math.NaN(), math.Inf(1)} {
+				if err := engine.SetDefaultThreshold(bad); err == nil {
+					t.Errorf("SetDefaultThreshold(%v) should fail", bad)
+				}
+			}
+			if engine.GetDefaultThreshold() != DefaultThreshold {
+				t.Error("Rejected thresholds must not change the default")
+			}
+
+			if err := engine.SetDefaultThreshold(0.99); err != nil {
+				t.Fatalf("SetDefaultThreshold(0.99) failed: %v", err)
+			}
+
+			dup, result := engine.IsDuplicate(near[2], near[3])
+			if dup || result.MeetsThreshold || result.ThresholdUsed != 0.99 {
+				t.Errorf("S21/S22 at 0.99: dup=%v meets=%v used=%v", dup, result.MeetsThreshold, result.ThresholdUsed)
+			}
+			if err := engine.SetDefaultThreshold(0.9); err != nil {
+				t.Fatalf("SetDefaultThreshold(0.9) failed: %v", err)
+			}
+			if dup, _ := engine.IsDuplicate(near[2], near[3]); !dup {
+				t.Error("S21/S22 should be a duplicate at 0.9")
+			}
+
+			// The default threshold is used and stamped
+			for _, r := range engine.FindDuplicatesDefault(near) {
+				if r.ThresholdUsed != 0.9 || !r.MeetsThreshold {
+					t.Errorf("Default scan result stamped with %v (meets=%v)", r.ThresholdUsed, r.MeetsThreshold)
+				}
+			}
+
+			// Explicit thresholds are unaffected by the default
+			explicit := engine.FindDuplicates(near, 0.98)
+			if len(explicit) != 1 {
+				t.Errorf("Explicit 0.98 scan found %d pairs, want 1", len(explicit))
+			}
+			for _, r := range explicit {
+				if r.ThresholdUsed != 0.98 || !r.MeetsThreshold {
+					t.Errorf("Explicit scan result stamped with %v (meets=%v)", r.ThresholdUsed, r.MeetsThreshold)
+				}
+			}
+		})
+	}
+}
+
+func TestWeightPresets(t *testing.T) {
+	presets := map[string]ComparisonWeights{
+		"Tech":     PresetTechProducts(),
+		"Fashion":  PresetFashionProducts(),
+		"Content":  PresetContentProducts(),
+		"Balanced": PresetBalanced(),
+	}
+	for name, w := range presets {
+		if err := w.Validate(); err != nil {
+			t.Errorf("%s preset is invalid: %v", name, err)
+		}
+		if w.NameWeight+w.DescriptionWeight != 1.0 {
+			t.Errorf("%s preset sums to %v", name, w.NameWeight+w.DescriptionWeight)
+		}
+	}
+	if PresetTechProducts().NameWeight != 0.8 || PresetContentProducts().NameWeight != 0.4 {
+		t.Error("Tech and content presets should be 0.8/0.2 and 0.4/0.6")
+	}
+}
+
+func TestWeightResolver(t *testing.T) {
+	iphoneWeights := ComparisonWeights{NameWeight: 0.9, DescriptionWeight: 0.1}
+	resolver := func(a, b Product) ComparisonWeights {
+		if strings.Contains(a.Name, "iPhone") && strings.Contains(b.Name, "iPhone") {
+			return iphoneWeights
+		}
+		return ComparisonWeights{} // engine default
+	}
+
+	// Both pairs share identical names and differ only in description
+	products := []Product{
+		{ID: "1", Name: "Apple iPhone 14", Description: "Space black, 128GB, unlocked"},
+		{ID: "2", Name: "Apple iPhone 14", Description: "Midnight purple, 256GB, carrier locked"},
+		{ID: "3", Name: "Samsung Galaxy S22", Description: "Space black, 128GB, unlocked"},
+		{ID: "4", Name: "Samsung Galaxy S22", Description: "Midnight purple, 256GB, carrier locked"},
+	}
+	// Pad with unrelated products so the parallel scan path runs the resolver concurrently
+	for i := 0; i < 60; i++ {
+		products = append(products, Product{ID: fmt.Sprintf("pad%d", i), Name: fmt.Sprintf("Filler item %d", i)})
+	}
+
+	engines := []DuplicateCheckEngine{
+		NewLevenshteinEngine().WithWeightResolver(resolver),
+		NewHybridEngine().WithWeightResolver(resolver),
+	}
+
+	for _, engine := range engines {
+		t.Run(engine.GetName(), func(t *testing.T) {
+			scores := make(map[string]ComparisonResult)
+			for _, r := range engine.FindDuplicates(products, 0.0) {
+				scores[makePairKey(r.ProductA.ID, r.ProductB.ID)] = r
+			}
+
+			iphone, galaxy := scores[makePairKey("1", "2")], scores[makePairKey("3", "4")]
+			// WeightsUsed holds the normalized form
+			if iphone.WeightsUsed != iphoneWeights.Normalized() {
+				t.Errorf("iPhone pair used %+v, want %+v", iphone.WeightsUsed, iphoneWeights)
+			}
+			if galaxy.WeightsUsed != DefaultWeights().Normalized() {
+				t.Errorf("Galaxy pair used %+v, want defaults", galaxy.WeightsUsed)
+			}
+			if iphone.CombinedSimilarity <= galaxy.CombinedSimilarity {
+				t.Errorf("Resolver weights should raise the iPhone score: %.3f vs %.3f",
+					iphone.CombinedSimilarity, galaxy.CombinedSimilarity)
+			}
+
+			// Explicit weights bypass the resolver
+			explicit := engine.CompareWithWeights(products[0], products[1], PresetContentProducts())
+			if explicit.WeightsUsed != PresetContentProducts().Normalized() {
+				t.Errorf("CompareWithWeights used %+v", explicit.WeightsUsed)
+			}
+		})
+	}
+}
+
+func TestProductCache(t *testing.T) {
+	p := Product{ID: "1", Name: "  Apple iPhone  ", Description: "Smartphone"}
+	if p.cache.Load() != nil {
+		t.Fatal("Cache should be built lazily")
+	}
+
+	NewLevenshteinEngine().ComparePtr(&p, &Product{ID: "2", Name: "Apple iPhone"})
+	cache := p.cachedValue()
+	if cache == nil || cache.normalizedName != "apple iphone" {
+		t.Fatal("ComparePtr should cache normalization on the product")
+	}
+
+	t.Run("Copies share the cache", func(t *testing.T) {
+		q := p
+		if q.loadCache() != cache {
+			t.Error("A copy should reuse the original's cache")
+		}
+	})
+
+	t.Run("Changed fields invalidate the cache", func(t *testing.T) {
+		q := p
+		q.Name = "Samsung Galaxy"
+		if name, _ := q.getNormalizedStrings(); name != "samsung galaxy" {
+			t.Errorf("Expected the new name to be normalized, got %q", name)
+		}
+		if name, _ := p.getNormalizedStrings(); name != "apple iphone" {
+			t.Errorf("Original should keep its cache, got %q", name)
+		}
+		if p.Fingerprint() == q.Fingerprint() {
+			t.Error("Fingerprints should follow the current fields")
+		}
+	})
+}
+
+// TestSmallInputs locks down the DuplicateCheckEngine convention for inputs
+// that can't form a pair: an empty non-nil slice, never nil, and no error
+func TestSmallInputs(t *testing.T) {
+	one := []Product{{ID: "1", Name: "Sony WH-1000XM5 Headphones", Description: "Noise cancelling"}}
+	hybridIndexing := func(products []Product) func() DuplicateCheckEngine {
+		return func() DuplicateCheckEngine {
+			engine := NewHybridEngine()
+			if err := engine.BuildIndex(products); err != nil {
+				t.Fatal(err)
+			}
+			return engine
+		}
+	}
+	engines := []struct {
+		name string
+		new  func() DuplicateCheckEngine
+	}{
+		{"Levenshtein", func() DuplicateCheckEngine { return NewLevenshteinEngine() }},
+		{"Hybrid without index", func() DuplicateCheckEngine { return NewHybridEngine() }},
+		{"Hybrid with empty index", hybridIndexing([]Product{})},
+		{"Hybrid indexing the product alone", hybridIndexing(one)},
+	}
+	inputs := []struct {
+		name     string
+		products []Product
+	}{
+		{"nil", nil},
+		{"empty", []Product{}},
+		{"one product", one},
+	}
+	for _, e := range engines {
+		for _, input := range inputs {
+			t.Run(e.name+"/"+input.name, func(t *testing.T) {
+				engine := e.new()
+				if results := engine.FindDuplicates(input.products, 0); results == nil || len(results) != 0 {
+					t.Errorf("FindDuplicates = %#v, want an empty non-nil slice", results)
+				}
+				results, err := engine.(checkedEngine).FindDuplicatesChecked(input.products, 0)
+				if err != nil || results == nil || len(results) != 0 {
+					t.Errorf("FindDuplicatesChecked = %#v, %v", results, err)
+				}
+				if results := engine.(PointerEngine).FindDuplicatesPtr(productPtrs(input.products), 0); results == nil || len(results) != 0 {
+					t.Errorf("FindDuplicatesPtr = %#v", results)
+				}
+			})
+		}
+	}
+
+	t.Run("Levenshtein parallel", func(t *testing.T) {
+		for _, input := range inputs {
+			if results := NewLevenshteinEngine().FindDuplicatesParallel(input.products, 0); results == nil || len(results) != 0 {
+				t.Errorf("%s: FindDuplicatesParallel = %#v", input.name, results)
+			}
+		}
+	})
+
+	t.Run("Hybrid empty index", func(t *testing.T) {
+		engine := hybridIndexing([]Product{})().(*HybridEngine)
+		stats := engine.GetIndexStats()
+		if stats["indexed"] != true || stats["total_products"] != 0 || stats["avg_bucket_size"] != 0.0 || stats["total_buckets"] != 0 {
+			t.Errorf("GetIndexStats = %v", stats)
+		}
+		results, err := engine.FindDuplicatesForOneChecked(one[0], 0)
+		if err != nil || results == nil || len(results) != 0 {
+			t.Errorf("FindDuplicatesForOneChecked = %#v, %v", results, err)
+		}
+	})
+
+	t.Run("Hybrid no matches", func(t *testing.T) {
+		engine := hybridIndexing(GenerateTestCatalog(goldenCatalogSeed, 50))().(*HybridEngine)
+		if results := engine.FindDuplicatesForOne(Product{ID: "q", Name: "zzzz qqqq"}, 0.9); results == nil || len(results) != 0 {
+			t.Errorf("FindDuplicatesForOne = %#v, want an empty non-nil slice", results)
+		}
+	})
+
+	t.Run("Hybrid one product against a larger index", func(t *testing.T) {
+		catalog := []Product{one[0], {ID: "2", Name: "Sony WH-1000XM5 Headphone", Description: "Noise cancelling"}}
+		engine := hybridIndexing(catalog)().(*HybridEngine)
+		if results := engine.FindDuplicates(one, 0.8); len(results) != 1 || results[0].ProductB.ID != "2" {
+			t.Errorf("FindDuplicates = %v, want the indexed duplicate", results)
+		}
+	})
+
+	t.Run("canPair", func(t *testing.T) {
+		a, b := &Product{ID: "a"}, &Product{ID: "b"}
+		tests := []struct {
+			indexed  []string
+			products []*Product
+			want     bool
+		}{
+			{nil, []*Product{a, b}, false},
+			{[]string{"a", "b"}, nil, false},
+			{[]string{"a"}, []*Product{a}, false},
+			{[]string{"b"}, []*Product{a}, true},
+			{[]string{"a", "b"}, []*Product{a}, true},
+			{[]string{"a"}, []*Product{a, b}, true},
+		}
+		for _, tt := range tests {
+			idx := &LSHIndex{products: make(map[string]*Product), ids: tt.indexed}
+			for _, id := range tt.indexed {
+				idx.products[id] = &Product{ID: id}
+			}
+			if got := idx.canPair(tt.products); got != tt.want {
+				t.Errorf("Index %v, %d products: canPair = %v, want %v", tt.indexed, len(tt.products), got, tt.want)
+			}
+		}
+	})
+}
+
+// TestThresholdBoundary scores a pair exactly on the threshold, and a hair
+// below it as another platform's rounding might, and checks that every path
+// of both engines judges it the same way
+func TestThresholdBoundary(t *testing.T) {
+	// Names 13/14 similar, identical descriptions: 1 + (13/14 - 1) * 0.7 = 0.95
+	a := Product{ID: "a", Name: "Oak Desk Lamp", Description: "warm white light"}
+	b := Product{ID: "b", Name: "Oak Desk Lamps", Description: "warm white light"}
+	products := []Product{a, b, {ID: "c", Name: "Brass Floor Fan", Description: "three speeds"}}
+	similarity := NewLevenshteinEngine().Compare(a, b).CombinedSimilarity
+	if math.Abs(similarity-0.95) > 1e-12 {
+		t.Fatalf("boundary pair scores %v, want 0.95", similarity)
+	}
+
+	paths := map[string]func(threshold float64) bool{
+		"IsDuplicate": func(threshold float64) bool {
+			engine := NewLevenshteinEngine()
+			engine.SetDefaultThreshold(threshold)
+			dup, _ := engine.IsDuplicate(a, b)
+			return dup
+		},
+		"FindDuplicates": func(threshold float64) bool {
+			return len(NewLevenshteinEngine().FindDuplicates(products, threshold)) == 1
+		},
+		"FindDuplicatesParallel": func(threshold float64) bool {
+			return len(NewLevenshteinEngine().FindDuplicatesParallel(products, threshold)) == 1
+		},
+		"FindDuplicatesTiered": func(threshold float64) bool {
+			tiered, err := NewLevenshteinEngine().FindDuplicatesTiered(products, []float64{threshold})
+			return err == nil && len(tiered.All()) == 1
+		},
+		"VerifyPairs": func(threshold float64) bool {
+			results, err := NewLevenshteinEngine().VerifyPairs(context.Background(), []ProductPair{{a, b}}, threshold, VerifyOptions{})
+			return err == nil && len(results) == 1
+		},
+		"hybrid IsDuplicate": func(threshold float64) bool {
+			engine := NewHybridEngine()
+			engine.SetDefaultThreshold(threshold)
+			dup, _ := engine.IsDuplicate(a, b)
+			return dup
+		},
+		"hybrid FindDuplicates": func(threshold float64) bool {
+			return len(NewHybridEngine().FindDuplicates(products, threshold)) == 1
+		},
+		"hybrid VerifyPairs": func(threshold float64) bool {
+			results, err := NewHybridEngine().VerifyPairs(context.Background(), []ProductPair{{a, b}}, threshold, VerifyOptions{})
+			return err == nil && len(results) == 1
+		},
+	}
+	tests := []struct {
+		name      string
+		threshold float64
+		match     bool
+	}{
+		{"exactly on the threshold", 0.95, true},
+		{"one ulp above the score", math.Nextafter(similarity, 1), true},
+		{"within epsilon above the score", similarity + ThresholdEpsilon/2, true},
+		{"beyond epsilon above the score", similarity + 10*ThresholdEpsilon, false},
+	}
+	for _, tt := range tests {
+		for name, matches := range paths {
+			if got := matches(tt.threshold); got != tt.match {
+				t.Errorf("%s: %s at %v matched %v, want %v", tt.name, name, tt.threshold, got, tt.match)
+			}
+		}
+	}
+}
+
+// TestCombinedSimilarityDeterministic checks that sequential, parallel and
+// hybrid verification paths score every pair bit-identically to Compare
+func TestCombinedSimilarityDeterministic(t *testing.T) {
+	const threshold = 0.7
+	products := GenerateTestCatalog(goldenCatalogSeed, 100)
+	engine := NewLevenshteinEngine()
+	hybrid := NewHybridEngine()
+
+	paths := map[string][]ComparisonResult{
+		"FindDuplicates":         engine.FindDuplicates(products, threshold),
+		"FindDuplicatesParallel": engine.FindDuplicatesParallel(products, threshold),
+		"hybrid FindDuplicates":  hybrid.FindDuplicates(products, threshold),
+	}
+	var pairs []ProductPair
+	for _, r := range paths["FindDuplicates"] {
+		pairs = append(pairs, ProductPair{r.ProductA, r.ProductB})
+	}
+	var err error
+	if paths["hybrid VerifyPairs"], err = hybrid.VerifyPairs(context.Background(), pairs, threshold, VerifyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(paths["FindDuplicates"]) == 0 {
+		t.Fatal("no pairs found")
+	}
+
+	for name, results := range paths {
+		for _, r := range results {
+			want := engine.Compare(r.ProductA, r.ProductB).CombinedSimilarity
+			if math.Float64bits(r.CombinedSimilarity) != math.Float64bits(want) {
+				t.Errorf("%s: %s/%s scored %.17g, Compare %.17g", name, r.ProductA.ID, r.ProductB.ID, r.CombinedSimilarity, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// EngineConfigVersion is the EngineConfig layout Config writes
+const EngineConfigVersion = 1
+
+// Callback names listed in EngineConfig.Callbacks
+const (
+	CallbackWeightResolver       = "weight_resolver"
+	CallbackPairConstraint       = "pair_constraint"
+	CallbackDescriptionSegmenter = "description_segmenter"
+	CallbackDescriptionLoader    = "description_loader"
+	CallbackIDComparator         = "id_comparator"
+	CallbackPrivacyVerifier      = "privacy_verifier"
+	CallbackBundleSplitter       = "bundle_splitter"
+)
+
+// EngineConfig is a serializable snapshot of every engine setting that
+// affects results, so a past run can be reproduced (see NewEngineFromConfig)
+// Settings that are functions, such as a WeightResolver, can't be serialized:
+// Callbacks names the ones the engine had, to be set again on the rebuilt
+// engine. Settings that only change how fast a scan runs (workers, memos,
+// pruning, checkpoints) and loggers are not recorded; deterministic mode,
+// which pins the order of results, is.
+type EngineConfig struct {
+	Version int    `json:"version"`
+	Engine  string `json:"engine"` // EngineKind name: "levenshtein" or "hybrid"
+
+	Threshold         float64            `json:"threshold"` // Default threshold (see SetDefaultThreshold)
+	Weights           ComparisonWeights  `json:"weights"`
+	Options           LevenshteinOptions `json:"options"` // Zero tuning parameters filled with their defaults
+	TextPreparation   TextPreparation    `json:"text_preparation"`
+	RabinKarp         bool               `json:"rabin_karp"`
+	DuplicateIDPolicy DuplicateIDPolicy  `json:"duplicate_id_policy"`
+	CompatibilityMode bool               `json:"compatibility_mode"`
+	KeepInputCopies   bool               `json:"keep_input_copies,omitempty"`  // See DisableInputDeduplication
+	DeterministicMode bool               `json:"deterministic_mode,omitempty"` // See EnableDeterministicMode
+
+	SegmentAlignment        SegmentAlignment       `json:"segment_alignment"`
+	Quality                 *QualityFilter         `json:"quality,omitempty"`
+	QualityMode             QualityMode            `json:"quality_mode"`
+	Calibration             *Calibration           `json:"calibration,omitempty"`
+	CrossField              *CrossFieldConfig      `json:"cross_field,omitempty"`
+	SwappedFields           *SwappedFieldsConfig   `json:"swapped_fields,omitempty"`
+	Deobfuscation           *DeobfuscationConfig   `json:"deobfuscation,omitempty"`
+	Redaction               *Redactor              `json:"redaction,omitempty"`
+	IDComparison            IDComparisonOptions    `json:"id_comparison"`
+	LazyDescription         LazyDescriptionOptions `json:"lazy_description"`
+	CrossLanguage           CrossLanguagePolicy    `json:"cross_language"`
+	CrossLanguageConfidence float64                `json:"cross_language_confidence"`
+	Variants                *VariantOptions        `json:"variants,omitempty"`
+	// BoilerplateFingerprint identifies TextPreparation.Boilerplate, whose model
+	// is corpus-dependent; NewEngineFromConfig checks the model still matches it
+	BoilerplateFingerprint string `json:"boilerplate_fingerprint,omitempty"`
+
+	SKUNames      *SKUNameConfig     `json:"sku_names,omitempty"`
+	LowInfoNames  *LowInfoNameConfig `json:"low_info_names,omitempty"`
+	OutputShaping *OutputShaping     `json:"output_shaping,omitempty"` // nil when FindDuplicates returns every pair
+
+	// Hybrid holds a hybrid engine's index settings (nil for other engines)
+	Hybrid *HybridIndexConfig `json:"hybrid,omitempty"`
+
+	// Callbacks names the function settings the engine had (see the Callback constants)
+	Callbacks []string `json:"callbacks,omitempty"`
+}
+
+// HybridIndexConfig is the hybrid engine part of an EngineConfig
+// HybridConfig.BandStore is a function and is not recorded.
+type HybridIndexConfig struct {
+	HybridConfig
+	LSHSeed     int64 `json:"lsh_seed"`     // See WithLSHSeed
+	PrivacyMode bool  `json:"privacy_mode"` // See EnablePrivacyMode; the salt is not recorded
+}
+
+// Config returns a snapshot of the engine's settings (see EngineConfig)
+// The snapshot shares nothing with the engine.
+func (e *LevenshteinEngine) Config() EngineConfig {
+	prep := e.GetTextPreparation()
+	prep.Synonyms = prep.Synonyms.Clone()
+	prep.Homoglyphs = prep.Homoglyphs.Clone()
+	confidence := e.crossLanguageConfidence
+	if confidence <= 0 {
+		confidence = DefaultCrossLanguageConfidence
+	}
+	cfg := EngineConfig{
+		Version:           EngineConfigVersion,
+		Engine:            EngineLevenshtein.String(),
+		Threshold:         e.threshold,
+		Weights:           e.weights,
+		Options:           e.options.withDefaults(),
+		TextPreparation:   prep,
+		RabinKarp:         e.IsRabinKarpEnabled(),
+		DuplicateIDPolicy: e.idPolicy,
+		CompatibilityMode: e.IsCompatibilityModeEnabled(),
+		KeepInputCopies:   e.keepInputCopies,
+		DeterministicMode: e.deterministic,
+
+		SegmentAlignment:        e.segmentAlign,
+		QualityMode:             e.qualityMode,
+		IDComparison:            e.idOptions,
+		LazyDescription:         e.GetLazyDescriptionOptions(),
+		CrossLanguage:           e.crossLanguage,
+		CrossLanguageConfidence: confidence,
+	}
+	if prep.Boilerplate != nil {
+		cfg.BoilerplateFingerprint = strconv.FormatUint(prep.Boilerplate.Fingerprint(), 16)
+	}
+	if e.quality != nil {
+		quality := *e.quality
+		quality.Placeholders = append([]string(nil), quality.Placeholders...)
+		cfg.Quality = &quality
+	}
+	if e.calibration != nil {
+		cfg.Calibration = &Calibration{Points: append([]CalibrationPoint(nil), e.calibration.Points...)}
+	}
+	if e.crossField != nil {
+		crossField := *e.crossField
+		cfg.CrossField = &crossField
+	}
+	if e.swappedFields != nil {
+		swappedFields := *e.swappedFields
+		cfg.SwappedFields = &swappedFields
+	}
+	if e.deobfuscation != nil {
+		deobfuscation := e.deobfuscation.config
+		deobfuscation.Substitutions = make(map[rune]string, len(e.deobfuscation.config.Substitutions))
+		for from, to := range e.deobfuscation.config.Substitutions {
+			deobfuscation.Substitutions[from] = to
+		}
+		deobfuscation.Watchlist = append([]string(nil), deobfuscation.Watchlist...)
+		cfg.Deobfuscation = &deobfuscation
+	}
+	if e.redactor != nil {
+		redactor := *e.redactor
+		redactor.Patterns = append(redactor.Patterns[:0:0], redactor.Patterns...)
+		cfg.Redaction = &redactor
+	}
+	if e.variants != nil {
+		variants := *e.variants
+		variants.Axes = append([]SpecDimension(nil), variants.Axes...)
+		cfg.Variants = &variants
+	}
+	if e.skuNames != nil {
+		cfg.SKUNames = &SKUNameConfig{Patterns: append([]*regexp.Regexp(nil), e.skuNames.config.Patterns...)}
+	}
+	if e.lowInfo != nil {
+		lowInfo := e.lowInfo.config
+		lowInfo.StopWords = append([]string(nil), lowInfo.StopWords...)
+		cfg.LowInfoNames = &lowInfo
+	}
+	if e.shaping != (OutputShaping{}) {
+		shaping := e.shaping
+		cfg.OutputShaping = &shaping
+	}
+
+	for _, callback := range []struct {
+		name string
+		set  bool
+	}{
+		{CallbackWeightResolver, e.weightResolver != nil},
+		{CallbackPairConstraint, e.pairConstraint != nil},
+		{CallbackDescriptionSegmenter, e.segmenter != nil},
+		{CallbackDescriptionLoader, e.descriptionLoader != nil},
+		{CallbackIDComparator, e.idComparator != nil},
+		{CallbackBundleSplitter, e.bundleSplitter != nil},
+	} {
+		if callback.set {
+			cfg.Callbacks = append(cfg.Callbacks, callback.name)
+		}
+	}
+	return cfg
+}
+
+// Config returns a snapshot of the engine's settings, index settings
+// included (see EngineConfig)
+// The index itself is not part of the snapshot.
+func (e *HybridEngine) Config() EngineConfig {
+	cfg := e.levenshteinEngine.Config()
+	cfg.Engine = EngineHybrid.String()
+	cfg.Threshold = e.threshold
+	cfg.DuplicateIDPolicy = e.idPolicy
+	cfg.Hybrid = &HybridIndexConfig{
+		HybridConfig: HybridConfig{
+			NumHashFunctions:       e.numHashFunctions,
+			NumBands:               e.numBands,
+			ShingleSize:            e.shingleSize,
+			ChunkedSignatures:      e.chunkSize > 0,
+			ChunkSize:              e.chunkSize,
+			ChunkOverlap:           e.chunkOverlap,
+			SimHashScreen:          e.simHashScreen,
+			SimHashMargin:          e.simHashMargin,
+			RetainCandidates:       e.retainCandidates,
+			RetainMatches:          e.retainMatches,
+			CandidateWarnThreshold: e.candidateWarn,
+			MaxCandidates:          e.maxCandidates,
+			MaxBucketFanout:        e.maxBucketFanout,
+			SplitCompoundTokens:    e.splitCompounds,
+			AdaptiveBands:          e.adaptiveBands,
+			AdaptiveBandEpsilon:    e.bandEpsilon,
+			MaxShinglesPerProduct:  e.maxShingles,
+			MaxIndexTextLength:     e.maxIndexTextLength,
+			BuildWorkers:           e.buildWorkers,
+			ExactModeCutoff:        e.exactCutoff,
+			AutoCompact:            e.autoCompact,
+			CliqueBands:            e.cliqueBands,
+			CliqueMinSize:          e.cliqueMinSize,
+			FastCompare:            e.fastCompare,
+			FastCompareFloor:       e.fastCompareFloor,
+			SignatureScheme:        e.minHash.scheme,
+			BucketSalt:             e.bucketSalt,
+			EnablePrefixIndex:      e.prefixIndex,
+			PrefixLSHTokens:        e.prefixLSHTokens,
+		},
+		LSHSeed:     e.minHash.seed,
+		PrivacyMode: e.privacy != nil,
+	}
+	if e.privacy != nil && e.privacy.verifier != nil {
+		cfg.Callbacks = append(cfg.Callbacks, CallbackPrivacyVerifier)
+	}
+	return cfg
+}
+
+// Fingerprint identifies the configuration: a hash of its JSON encoding, in
+// hexadecimal, equal for equal configurations and stable across runs
+// Empty if the configuration can't be encoded (a NaN weight).
+func (c EngineConfig) Fingerprint() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return strconv.FormatUint(contentFingerprint("engine-config", string(data)), 16)
+}
+
+// ConfigFingerprint returns the fingerprint of the engine's Config, which
+// FindDuplicatesToFileSorted writes into its output
+func (e *LevenshteinEngine) ConfigFingerprint() string {
+	return e.Config().Fingerprint()
+}
+
+// ConfigFingerprint returns the fingerprint of the engine's Config
+func (e *HybridEngine) ConfigFingerprint() string {
+	return e.Config().Fingerprint()
+}
+
+// NewEngineFromConfig builds an engine equivalent to the one cfg was taken
+// from: a *LevenshteinEngine or a *HybridEngine, with every recorded setting
+// Callbacks are not restored; set them again before comparing. A hybrid
+// engine has no index yet, and in privacy mode it draws a new salt.
+func NewEngineFromConfig(cfg EngineConfig) (DuplicateCheckEngine, error) {
+	if cfg.Version < 1 || cfg.Version > EngineConfigVersion {
+		return nil, fmt.Errorf("duplicatecheck: engine config version %d is not supported (want 1 to %d)", cfg.Version, EngineConfigVersion)
+	}
+	if err := validateThreshold(cfg.Threshold); err != nil {
+		return nil, err
+	}
+	if model := cfg.TextPreparation.Boilerplate; cfg.BoilerplateFingerprint != "" &&
+		(model == nil || strconv.FormatUint(model.Fingerprint(), 16) != cfg.BoilerplateFingerprint) {
+		return nil, errors.New("duplicatecheck: boilerplate model does not match the config's boilerplate fingerprint")
+	}
+	switch cfg.Engine {
+	case EngineLevenshtein.String():
+		engine := NewLevenshteinEngine()
+		engine.applyConfig(cfg)
+		engine.threshold = cfg.Threshold
+		engine.idPolicy = cfg.DuplicateIDPolicy
+		return engine, nil
+	case EngineHybrid.String():
+		if cfg.Hybrid == nil {
+			return nil, errors.New("duplicatecheck: hybrid engine config has no index settings")
+		}
+		engine := NewHybridEngineWithConfig(cfg.Hybrid.HybridConfig).WithLSHSeed(cfg.Hybrid.LSHSeed)
+		engine.levenshteinEngine.applyConfig(cfg)
+		engine.threshold = cfg.Threshold
+		engine.idPolicy = cfg.DuplicateIDPolicy
+		if cfg.Hybrid.PrivacyMode {
+			engine.EnablePrivacyMode(PrivacyOptions{})
+		}
+		return engine, nil
+	default:
+		return nil, fmt.Errorf("duplicatecheck: unknown engine %q in config", cfg.Engine)
+	}
+}
+
+// applyConfig sets the comparison settings of cfg on a new engine
+func (e *LevenshteinEngine) applyConfig(cfg EngineConfig) {
+	e.weights = cfg.Weights
+	e.SetOptions(cfg.Options)
+	e.WithTextPreparation(cfg.TextPreparation)
+	e.keepInputCopies = cfg.KeepInputCopies
+	e.deterministic = cfg.DeterministicMode
+	if !cfg.RabinKarp {
+		e.DisableRabinKarpFilter()
+	}
+	if !cfg.CompatibilityMode {
+		e.DisableCompatibilityMode()
+	}
+	e.segmentAlign = cfg.SegmentAlignment
+	e.WithQualityFilter(cfg.Quality, cfg.QualityMode)
+	e.WithCalibration(cfg.Calibration)
+	e.WithCrossFieldMatching(cfg.CrossField)
+	e.WithSwappedFields(cfg.SwappedFields)
+	e.WithDeobfuscation(cfg.Deobfuscation)
+	e.WithResultRedaction(cfg.Redaction)
+	e.idOptions = cfg.IDComparison
+	e.SetLazyDescriptionOptions(cfg.LazyDescription)
+	e.WithCrossLanguagePolicy(cfg.CrossLanguage, cfg.CrossLanguageConfidence)
+	if cfg.Variants != nil {
+		e.WithVariantDetection(*cfg.Variants)
+	}
+	e.WithSKUNames(cfg.SKUNames)
+	e.WithLowInfoNames(cfg.LowInfoNames)
+	if cfg.OutputShaping != nil {
+		e.WithOutputShaping(*cfg.OutputShaping)
+	}
+}
+
+// withDefaults returns the options with zero tuning parameters replaced by
+// the defaults they fall back to, so equivalent options compare equal
+func (o LevenshteinOptions) withDefaults() LevenshteinOptions {
+	if o.LengthFloor <= 0 {
+		o.LengthFloor = DefaultLengthFloor
+	}
+	if o.LogisticMidpoint <= 0 || o.LogisticMidpoint >= 1 {
+		o.LogisticMidpoint = DefaultLogisticMidpoint
+	}
+	if o.LogisticSteepness <= 0 {
+		o.LogisticSteepness = DefaultLogisticSteepness
+	}
+	if o.PrefixBias < 0 {
+		o.PrefixBias = 0
+	}
+	if o.PrefixFraction <= 0 || o.PrefixFraction > 1 {
+		o.PrefixFraction = DefaultPrefixFraction
+	}
+	return o
+}
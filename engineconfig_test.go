@@ -0,0 +1,254 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// customizedEngine returns a Levenshtein engine with most settings changed
+func customizedEngine() *LevenshteinEngine {
+	opts := DefaultLevenshteinOptions()
+	opts.SimilarityMode = SimilarityLengthAdjusted
+	opts.LengthFloor = 16
+	opts.PrefixBias = 1.5
+	engine := NewLevenshteinEngineWithWeights(ComparisonWeights{NameWeight: 0.6, DescriptionWeight: 0.4}).
+		WithTextPreparation(TextPreparation{FoldAccents: true, CollapseWhitespace: true, StripHTML: true, Synonyms: DefaultSynonyms(), Homoglyphs: DefaultHomoglyphs()}).
+		WithQualityFilter(DefaultQualityFilter(), QualityFlag).
+		WithCalibration(&Calibration{Points: []CalibrationPoint{{Similarity: 0.5, Probability: 0.1, Pairs: 10}, {Similarity: 0.9, Probability: 0.8, Pairs: 10}}}).
+		WithCrossFieldMatching(&CrossFieldConfig{Weight: 0.3}).
+		WithDeobfuscation(&DeobfuscationConfig{Watchlist: []string{"Nike Air"}}).
+		WithResultRedaction(DefaultPIIRedactor()).
+		WithCrossLanguagePolicy(CrossLanguageFlag, 0.8).
+		WithVariantDetection(VariantOptions{Axes: []SpecDimension{SpecColor}, Output: VariantsSeparate})
+	engine.SetOptions(opts)
+	engine.DisableRabinKarpFilter()
+	engine.DisableCompatibilityMode()
+	engine.SetDuplicateIDPolicy(DuplicateIDKeepFirst)
+	if err := engine.SetDefaultThreshold(0.72); err != nil {
+		panic(err)
+	}
+	return engine
+}
+
+// roundTrip rebuilds an engine from cfg through its JSON encoding
+func roundTrip(t *testing.T, cfg EngineConfig) DuplicateCheckEngine {
+	t.Helper()
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded EngineConfig
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	engine, err := NewEngineFromConfig(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return engine
+}
+
+// resultSummaries lists what a reproduced run must repeat of each result
+func resultSummaries(results []ComparisonResult) []string {
+	summaries := make([]string, len(results))
+	for i, r := range results {
+		summaries[i] = strings.Join([]string{r.ProductA.ID, r.ProductB.ID, r.ProductA.Description, r.MatchType.String()}, "|")
+		summaries[i] += "|" + formatFloat(r.CombinedSimilarity) + "|" + formatFloat(r.DuplicateProbability)
+	}
+	return summaries
+}
+
+// formatFloat formats f exactly
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// readResultFile reads a results file and its config fingerprint
+func readResultFile(t *testing.T, path string, format ResultFileFormat) ([]ResultRef, string) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	refs, fingerprint, err := ReadResultRefsWithFingerprint(f, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return refs, fingerprint
+}
+
+func TestEngineConfigRoundTrip(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, 20)
+
+	hybrid := NewHybridEngineWithConfig(HybridConfig{NumHashFunctions: 120, NumBands: 30, ShingleSize: 2, SplitCompoundTokens: true, SimHashScreen: true}).
+		WithLSHSeed(7).
+		WithSynonyms(DefaultSynonyms()).
+		WithCrossFieldMatching(&CrossFieldConfig{Weight: 0.2})
+	if err := hybrid.SetDefaultThreshold(0.7); err != nil {
+		t.Fatal(err)
+	}
+
+	engines := []struct {
+		name   string
+		engine interface {
+			DuplicateCheckEngine
+			Config() EngineConfig
+			ConfigFingerprint() string
+		}
+	}{
+		{"levenshtein", customizedEngine()},
+		{"hybrid", hybrid},
+	}
+	for _, tt := range engines {
+		t.Run(tt.name, func(t *testing.T) {
+			rebuilt := roundTrip(t, tt.engine.Config())
+			if got, want := rebuilt.(interface{ Config() EngineConfig }).Config(), tt.engine.Config(); !reflect.DeepEqual(got, want) {
+				t.Errorf("rebuilt config %+v, want %+v", got, want)
+			}
+			if got := rebuilt.(interface{ ConfigFingerprint() string }).ConfigFingerprint(); got != tt.engine.ConfigFingerprint() {
+				t.Errorf("rebuilt fingerprint %s, want %s", got, tt.engine.ConfigFingerprint())
+			}
+
+			scan := func(engine DuplicateCheckEngine) []string {
+				if indexer, ok := engine.(indexingEngine); ok {
+					if err := indexer.BuildIndex(products); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return resultSummaries(engine.FindDuplicates(products, 0.6))
+			}
+			want := scan(tt.engine)
+			if len(want) == 0 {
+				t.Fatal("the golden catalog should have duplicates")
+			}
+			if got := scan(rebuilt); !reflect.DeepEqual(got, want) {
+				t.Errorf("rebuilt engine found %d results, the original %d", len(got), len(want))
+			}
+		})
+	}
+
+	// Callbacks are named, not restored
+	cfg := NewLevenshteinEngine().WithPairConstraint(func(a, b Product) bool { return true }).Config()
+	if !reflect.DeepEqual(cfg.Callbacks, []string{CallbackPairConstraint}) {
+		t.Errorf("callbacks %v", cfg.Callbacks)
+	}
+	if rebuilt := roundTrip(t, cfg).(*LevenshteinEngine); rebuilt.pairConstraint != nil {
+		t.Error("the pair constraint was restored")
+	}
+
+	for _, bad := range []EngineConfig{
+		{Version: EngineConfigVersion + 1, Engine: "levenshtein", Threshold: 0.8},
+		{Version: EngineConfigVersion, Engine: "tfidf", Threshold: 0.8},
+		{Version: EngineConfigVersion, Engine: "hybrid", Threshold: 0.8},
+		{Version: EngineConfigVersion, Engine: "levenshtein", Threshold: 1.5},
+	} {
+		if _, err := NewEngineFromConfig(bad); err == nil {
+			t.Errorf("NewEngineFromConfig(%+v) succeeded", bad)
+		}
+	}
+}
+
+func TestConfigFingerprint(t *testing.T) {
+	base := NewLevenshteinEngine().ConfigFingerprint()
+	if base == "" || base != NewLevenshteinEngine().ConfigFingerprint() {
+		t.Fatalf("fingerprints of default engines differ: %q", base)
+	}
+	// Zero tuning parameters fall back to the defaults, so spelling them out changes nothing
+	if got := NewLevenshteinEngineWithOptions(DefaultLevenshteinOptions()).ConfigFingerprint(); got != base {
+		t.Errorf("default options: fingerprint %s, want %s", got, base)
+	}
+	if NewHybridEngine().ConfigFingerprint() == base {
+		t.Error("hybrid and Levenshtein engines share a fingerprint")
+	}
+
+	changes := map[string]func(e *LevenshteinEngine){
+		"threshold":      func(e *LevenshteinEngine) { _ = e.SetDefaultThreshold(0.9) },
+		"weights":        func(e *LevenshteinEngine) { e.weights = ComparisonWeights{NameWeight: 0.5, DescriptionWeight: 0.5} },
+		"options":        func(e *LevenshteinEngine) { e.SetOptions(LevenshteinOptions{GraphemeMode: true}) },
+		"timeout":        func(e *LevenshteinEngine) { e.options.MaxComparisonDuration = time.Millisecond },
+		"preparation":    func(e *LevenshteinEngine) { e.WithTextPreparation(TextPreparation{FoldAccents: true}) },
+		"synonyms":       func(e *LevenshteinEngine) { e.WithSynonyms(DefaultSynonyms()) },
+		"homoglyphs":     func(e *LevenshteinEngine) { e.WithHomoglyphs(DefaultHomoglyphs()) },
+		"output shaping": func(e *LevenshteinEngine) { e.WithOutputShaping(OutputShaping{KeepOnlyBestPerProduct: true}) },
+		"rabin-karp":     func(e *LevenshteinEngine) { e.DisableRabinKarpFilter() },
+		"id policy":      func(e *LevenshteinEngine) { e.SetDuplicateIDPolicy(DuplicateIDKeepFirst) },
+		"compatibility":  func(e *LevenshteinEngine) { e.DisableCompatibilityMode() },
+		"quality":        func(e *LevenshteinEngine) { e.WithQualityFilter(DefaultQualityFilter(), QualityExclude) },
+		"calibration":    func(e *LevenshteinEngine) { e.WithCalibration(&Calibration{}) },
+		"cross-field":    func(e *LevenshteinEngine) { e.WithCrossFieldMatching(&CrossFieldConfig{}) },
+		"deobfuscation":  func(e *LevenshteinEngine) { e.WithDeobfuscation(&DeobfuscationConfig{}) },
+		"redaction":      func(e *LevenshteinEngine) { e.WithResultRedaction(DefaultPIIRedactor()) },
+		"cross-language": func(e *LevenshteinEngine) {
+			e.WithCrossLanguagePolicy(CrossLanguageNameOnly, 0)
+		},
+		"variants":  func(e *LevenshteinEngine) { e.WithVariantDetection(VariantOptions{}) },
+		"segmenter": func(e *LevenshteinEngine) { e.WithDescriptionSegmenter(nil, AlignByLabel) },
+		"resolver": func(e *LevenshteinEngine) {
+			e.WithWeightResolver(func(a, b Product) ComparisonWeights { return DefaultWeights() })
+		},
+		"lazy loads": func(e *LevenshteinEngine) { e.SetLazyDescriptionOptions(LazyDescriptionOptions{Margin: 0.3}) },
+	}
+	seen := map[string]string{base: "default"}
+	for name, change := range changes {
+		engine := NewLevenshteinEngine()
+		change(engine)
+		fingerprint := engine.ConfigFingerprint()
+		if other, ok := seen[fingerprint]; ok {
+			t.Errorf("%s: same fingerprint as %s", name, other)
+		}
+		seen[fingerprint] = name
+	}
+
+	hybridChanges := map[string]func() *HybridEngine{
+		"seed":      func() *HybridEngine { return NewHybridEngine().WithLSHSeed(1) },
+		"bands":     func() *HybridEngine { return NewHybridEngineWithConfig(HybridConfig{NumBands: 25}) },
+		"privacy":   func() *HybridEngine { e := NewHybridEngine(); e.EnablePrivacyMode(PrivacyOptions{}); return e },
+		"cutoff":    func() *HybridEngine { return NewHybridEngine().WithExactModeCutoff(0) },
+		"threshold": func() *HybridEngine { e := NewHybridEngine(); _ = e.SetDefaultThreshold(0.5); return e },
+	}
+	hybridBase := NewHybridEngine().ConfigFingerprint()
+	for name, build := range hybridChanges {
+		if build().ConfigFingerprint() == hybridBase {
+			t.Errorf("hybrid %s: fingerprint unchanged", name)
+		}
+	}
+}
+
+func TestConfigFingerprintInOutputs(t *testing.T) {
+	engine := customizedEngine()
+	products := GenerateTestCatalog(goldenCatalogSeed, 20)
+	found := len(engine.FindDuplicates(products, 0.6))
+	for _, format := range []ResultFileFormat{ResultFileJSONL, ResultFileCSV} {
+		path := filepath.Join(t.TempDir(), "results")
+		if err := engine.FindDuplicatesToFileSorted(products, 0.6, path, SpillOptions{Format: format}); err != nil {
+			t.Fatal(err)
+		}
+		refs, fingerprint := readResultFile(t, path, format)
+		if fingerprint != engine.ConfigFingerprint() {
+			t.Errorf("format %d: fingerprint %q, want %q", format, fingerprint, engine.ConfigFingerprint())
+		}
+		if len(refs) != found || found == 0 {
+			t.Errorf("format %d: %d results, want %d", format, len(refs), found)
+		}
+
+		// Written by hand, with and without a fingerprint
+		for _, want := range []string{"", "abc123"} {
+			var buf bytes.Buffer
+			if err := WriteResultRefsWithFingerprint(&buf, refs, format, want); err != nil {
+				t.Fatal(err)
+			}
+			got, fingerprint, err := ReadResultRefsWithFingerprint(&buf, format)
+			if err != nil || fingerprint != want || !reflect.DeepEqual(got, refs) {
+				t.Errorf("format %d, fingerprint %q: read %d refs, fingerprint %q, error %v", format, want, len(got), fingerprint, err)
+			}
+		}
+	}
+}
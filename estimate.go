@@ -0,0 +1,317 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+// EngineKind names an engine whose scan EstimateScanCost predicts
+type EngineKind int
+
+const (
+	// EngineLevenshtein compares every pair (LevenshteinEngine.FindDuplicates)
+	EngineLevenshtein EngineKind = iota
+	// EngineHybrid builds an index and verifies its LSH candidates
+	// (HybridEngine.BuildIndex, then FindDuplicates)
+	EngineHybrid
+)
+
+// String returns the engine's name, as the CLI's --engine flag spells it
+func (k EngineKind) String() string {
+	switch k {
+	case EngineLevenshtein:
+		return "levenshtein"
+	case EngineHybrid:
+		return "hybrid"
+	default:
+		return "unknown"
+	}
+}
+
+// Sample sizes of EstimateScanCost
+const (
+	estimateSampleProducts = 200  // Products the calibration pairs are drawn from
+	estimatePairAttempts   = 3000 // Random pairs drawn for the length window fraction
+	estimateTimedPairs     = 1000 // Most pairs timed for the comparison rate
+	estimateIndexProducts  = 1000 // Products in the throwaway mini-index
+	estimateVerifiedPairs  = 300  // Most mini-index candidates verified
+	estimateSeed           = 1    // Seed of every sample, so estimates repeat
+)
+
+// ScanEstimate predicts the cost of a duplicate scan (see EstimateScanCost)
+type ScanEstimate struct {
+	Engine               EngineKind    // The engine estimated
+	Products             int           // Catalog size
+	Pairs                float64       // Pairs the engine compares: n(n-1)/2 less length-pruned pairs, or the LSH candidates
+	ComparisonsPerSecond float64       // Measured on sampled pairs by one worker
+	Workers              int           // Goroutines comparing pairs (Hybrid: building the index; it verifies on one)
+	Duration             time.Duration // Estimated wall time, index build included
+	MatchRate            float64       // Fraction of compared pairs expected to match
+	Matches              float64       // Expected matches at the threshold
+	ResultBytes          int64         // Estimated peak memory of the result slice
+	Recommended          EngineKind    // The engine estimated faster
+	Recommendation       string        // Why the other engine is recommended ("" when Engine is)
+}
+
+// EstimateScanCost predicts how long FindDuplicates of engine takes on
+// products at threshold, and how many results it returns, without scanning
+// It times a new engine of each kind on samples of the catalog itself, so
+// string lengths are representative: Levenshtein comparisons of random pairs
+// (which also give the share of pairs the length window prunes), and a
+// throwaway Hybrid index of up to 1000 sampled products, whose candidate pairs,
+// scaled by the pairs of the full catalog, estimate its LSH candidates and,
+// verified, the match rate. Wall time counts the workers and CPUs the scan
+// would use (see EffectiveWorkerCount). Both engines are estimated, and
+// Recommended names the faster. A sample takes well under a second; treat the
+// result as an order of magnitude.
+func EstimateScanCost(products []Product, threshold float64, engine EngineKind) ScanEstimate {
+	threshold = clampUnit(threshold)
+	rng := rand.New(rand.NewSource(estimateSeed))
+	levenshtein := estimateLevenshteinScan(products, threshold, rng)
+	hybrid := estimateHybridScan(products, threshold, rng)
+	if hybrid.Matches > 0 {
+		// Random pairs rarely match: the mini-index's verified candidates
+		// estimate the matches far better
+		levenshtein.Matches = hybrid.Matches
+	}
+
+	estimates := map[EngineKind]*ScanEstimate{EngineLevenshtein: &levenshtein, EngineHybrid: &hybrid}
+	estimate, other := estimates[engine], estimates[EngineHybrid]
+	if estimate == nil {
+		estimate = &levenshtein
+	}
+	if estimate.Engine == EngineHybrid {
+		other = &levenshtein
+	}
+	if estimate.Pairs > 0 {
+		estimate.MatchRate = math.Min(estimate.Matches/estimate.Pairs, 1)
+	}
+	estimate.ResultBytes = int64(estimate.Matches * float64(reflect.TypeOf(ComparisonResult{}).Size()))
+	estimate.Recommended = estimate.Engine
+	// The other engine must win clearly: both are estimates
+	if other.Duration < estimate.Duration/2 {
+		estimate.Recommended = other.Engine
+		estimate.Recommendation = fmt.Sprintf("%v is estimated faster (%v instead of %v)",
+			other.Engine, other.Duration.Round(time.Millisecond), estimate.Duration.Round(time.Millisecond))
+	}
+	return *estimate
+}
+
+// estimateLevenshteinScan times Levenshtein comparisons of random pairs of
+// sampled products
+// The scan memo computes a name or description once per pair of distinct
+// values, so the time of the pairs' names and descriptions is only counted
+// for the share of pairs the memo can't answer.
+func estimateLevenshteinScan(products []Product, threshold float64, rng *rand.Rand) ScanEstimate {
+	n := len(products)
+	estimate := ScanEstimate{Engine: EngineLevenshtein, Products: n, Workers: 1}
+	if n < 2 {
+		return estimate
+	}
+	engine := NewLevenshteinEngine()
+	if n > 50 {
+		estimate.Workers = engine.workerCount(n)
+	}
+
+	// The scan prepares every product, and the memo groups them by field
+	prep := engine.preparer()
+	started := time.Now()
+	names, descriptions := make(map[string]int), make(map[string]int)
+	for i := range products {
+		name, desc := products[i].preparedStrings(prep)
+		names[name]++
+		descriptions[desc]++
+	}
+	prepare := time.Since(started)
+	total := float64(n) * float64(n-1) / 2
+	freshNames, freshDescriptions := memoMisses(names, total), memoMisses(descriptions, total)
+
+	// Pairs outside the length window are never compared
+	sample := sampleProducts(products, estimateSampleProducts, rng)
+	warmCaches(sample, prep)
+	window := engine.newLengthWindow(sample, threshold)
+	var timed []pairIndex
+	admitted := 0
+	for k := 0; k < estimatePairAttempts; k++ {
+		i, j := rng.Intn(len(sample)), rng.Intn(len(sample)-1)
+		if j >= i {
+			j++
+		}
+		if window != nil && !window.admits(i, j) {
+			continue
+		}
+		admitted++
+		if len(timed) < estimateTimedPairs {
+			timed = append(timed, pairIndex{i, j})
+		}
+	}
+	estimate.Pairs = total * float64(admitted) / estimatePairAttempts
+	if len(timed) == 0 {
+		estimate.Duration = prepare
+		return estimate
+	}
+
+	// Whole comparisons, then their names and descriptions alone
+	matches := 0
+	scratch := &comparisonScratch{}
+	started = time.Now()
+	for _, pair := range timed {
+		result, ok := engine.comparePair(sample, pair.i, pair.j, nil, scratch, threshold)
+		if ok && meetsThreshold(result.CombinedSimilarity, threshold) {
+			matches++
+		}
+	}
+	whole := time.Since(started)
+	var nameTime, descriptionTime time.Duration
+	for _, pair := range timed {
+		a, b := sample[pair.i], sample[pair.j]
+		weights := engine.resolveWeights(a, b)
+		if engine.charsetRejects(a, b, weights, threshold) {
+			continue
+		}
+		nameA, descA := a.preparedStrings(prep)
+		nameB, descB := b.preparedStrings(prep)
+		started = time.Now()
+		score := engine.scoreNamePair(nameA, nameB, scratch)
+		nameTime += time.Since(started)
+		if !score.rejected && !engine.skipsDescription(score.similarity, weights.Normalized(), threshold) {
+			started = time.Now()
+			engine.compareDescriptions(descA, descB, scratch)
+			descriptionTime += time.Since(started)
+		}
+	}
+	memoized := time.Duration((1-freshNames)*float64(nameTime) + (1-freshDescriptions)*float64(descriptionTime))
+	perPairs := whole - memoized
+	if perPairs < whole/20 {
+		perPairs = whole / 20
+	}
+
+	estimate.ComparisonsPerSecond = float64(len(timed)) / math.Max(perPairs.Seconds(), 1e-9)
+	estimate.Matches = estimate.Pairs * float64(matches) / float64(len(timed))
+	estimate.Duration = prepare + scanDuration(estimate.Pairs, estimate.ComparisonsPerSecond, estimate.Workers)
+	return estimate
+}
+
+// memoMisses returns the share of a scan's pairs whose field the scan memo
+// has to compute, for the number of products holding each field value
+// The memo stores one score per ordered pair of values, and one per value
+// shared by several products for the pairs within it.
+func memoMisses(counts map[string]int, pairs float64) float64 {
+	distinct := float64(len(counts))
+	computed := distinct * (distinct - 1)
+	for _, count := range counts {
+		if count > 1 {
+			computed++
+		}
+	}
+	return math.Min(computed/pairs, 1)
+}
+
+// estimateHybridScan builds a throwaway index of sampled products and times
+// its candidate lookups and verification
+func estimateHybridScan(products []Product, threshold float64, rng *rand.Rand) ScanEstimate {
+	n := len(products)
+	estimate := ScanEstimate{Engine: EngineHybrid, Products: n, Workers: 1}
+	if n < 2 {
+		return estimate
+	}
+	full := NewHybridEngine()
+	estimate.Workers = full.buildWorkerCount(n)
+
+	// LSH even for a small sample, to count its candidates
+	engine := NewHybridEngine().WithExactModeCutoff(0)
+	sample := sampleProducts(products, estimateIndexProducts, rng)
+	indexed := make([]Product, len(sample))
+	for i, p := range sample {
+		indexed[i] = Product{ID: p.ID, SourceID: p.SourceID, Name: p.Name, Description: p.Description}
+	}
+	started := time.Now()
+	if err := engine.BuildIndex(indexed); err != nil {
+		return estimate
+	}
+	build := time.Since(started)
+	idx := engine.currentIndex()
+
+	// Each sampled pair's candidates, each pair once
+	type candidatePair struct {
+		product *Product
+		id      string
+	}
+	var pairs []candidatePair
+	seen := make(map[string]bool)
+	started = time.Now()
+	for _, product := range sample {
+		for _, candidate := range engine.findCandidates(idx, product, threshold) {
+			key := makePairKey(product.ID, candidate.id)
+			if candidate.id != product.ID && !seen[key] {
+				seen[key] = true
+				pairs = append(pairs, candidatePair{product, candidate.id})
+			}
+		}
+	}
+	lookups := time.Since(started)
+
+	scale := float64(n) / float64(len(sample))
+	pairScale := float64(n) * float64(n-1) / (float64(len(sample)) * float64(len(sample)-1))
+	estimate.Pairs = float64(len(pairs)) * pairScale
+	overhead := time.Duration(float64(build+lookups) * scale)
+	if n < full.exactCutoff {
+		// Small catalogs are verified exhaustively
+		estimate.Pairs = float64(n) * float64(n-1) / 2
+	}
+	if len(pairs) == 0 {
+		estimate.Duration = overhead
+		return estimate
+	}
+
+	rng.Shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
+	if len(pairs) > estimateVerifiedPairs {
+		pairs = pairs[:estimateVerifiedPairs]
+	}
+	matches := 0
+	started = time.Now()
+	for _, pair := range pairs {
+		result, ok := engine.verifyCandidate(idx, pair.product, engine.newQuery(pair.product), pair.id, threshold)
+		if ok && meetsThreshold(result.CombinedSimilarity, threshold) {
+			matches++
+		}
+	}
+	elapsed := time.Since(started)
+	candidateRate := float64(matches) / float64(len(pairs))
+	estimate.ComparisonsPerSecond = float64(len(pairs)) / math.Max(elapsed.Seconds(), 1e-9)
+	estimate.Matches = float64(len(seen)) * pairScale * candidateRate
+	// Candidates are verified sequentially
+	estimate.Duration = overhead + scanDuration(estimate.Pairs, estimate.ComparisonsPerSecond, 1)
+	return estimate
+}
+
+// pairIndex is a pair of indices into a product slice
+type pairIndex struct {
+	i, j int
+}
+
+// sampleProducts returns up to size products of products, drawn without
+// replacement, copied without their caches so preparing them is timed too
+func sampleProducts(products []Product, size int, rng *rand.Rand) []*Product {
+	if size > len(products) {
+		size = len(products)
+	}
+	sample := make([]*Product, size)
+	for k, i := range rng.Perm(len(products))[:size] {
+		p := &products[i]
+		sample[k] = &Product{ID: p.ID, SourceID: p.SourceID, Name: p.Name, Description: p.Description}
+	}
+	return sample
+}
+
+// scanDuration returns the wall time of comparing pairs at perSecond per
+// worker, on as many workers as the CPUs allow
+func scanDuration(pairs, perSecond float64, workers int) time.Duration {
+	if cpus := hostCPUs.availableCPUs(detectedQuota()); workers > cpus {
+		workers = cpus
+	}
+	return time.Duration(pairs / perSecond / float64(workers) * float64(time.Second))
+}
@@ -0,0 +1,81 @@
+package duplicatecheck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+func TestEstimateScanCost(t *testing.T) {
+	const threshold = 0.85
+	scans := map[EngineKind]func([]Product){
+		EngineLevenshtein: func(products []Product) {
+			NewLevenshteinEngine().FindDuplicates(products, threshold)
+		},
+		EngineHybrid: func(products []Product) {
+			engine := NewHybridEngine()
+			if err := engine.BuildIndex(products); err != nil {
+				t.Fatal(err)
+			}
+			engine.FindDuplicates(products, threshold)
+		},
+	}
+	for _, size := range []int{150, 600} {
+		cfg := synth.Default()
+		cfg.Size = size
+		products, _ := generatedCatalog(cfg)
+		for kind, scan := range scans {
+			estimate := EstimateScanCost(products, threshold, kind)
+			started := time.Now()
+			scan(products)
+			actual := time.Since(started)
+
+			// Loose: the estimate is meant as an order of magnitude
+			if ratio := float64(estimate.Duration) / float64(actual); ratio < 1.0/3 || ratio > 3 {
+				t.Errorf("%d products, %v: estimated %v, took %v", size, kind, estimate.Duration, actual)
+			}
+			if estimate.Engine != kind || estimate.Products != size || estimate.Pairs <= 0 || estimate.Matches <= 0 ||
+				estimate.ResultBytes <= 0 || estimate.Workers < 1 || estimate.ComparisonsPerSecond <= 0 {
+				t.Errorf("%d products, %v: incomplete estimate %+v", size, kind, estimate)
+			}
+			t.Logf("%d products, %v: estimated %v, took %v", size, kind, estimate.Duration, actual)
+		}
+	}
+}
+
+func TestEstimateScanCostRecommendation(t *testing.T) {
+	tests := []struct {
+		size        int
+		engine      EngineKind
+		recommended EngineKind
+	}{
+		// A small catalog doesn't repay building an index
+		{60, EngineHybrid, EngineLevenshtein},
+		{60, EngineLevenshtein, EngineLevenshtein},
+		// A large one compares far fewer pairs through it
+		{3000, EngineLevenshtein, EngineHybrid},
+		{3000, EngineHybrid, EngineHybrid},
+	}
+	for _, tt := range tests {
+		cfg := synth.Default()
+		cfg.Size = tt.size
+		products, _ := generatedCatalog(cfg)
+		estimate := EstimateScanCost(products, 0.85, tt.engine)
+		if estimate.Recommended != tt.recommended || (estimate.Recommendation == "") != (tt.recommended == tt.engine) {
+			t.Errorf("%d products, %v: recommended %v (%q), want %v", tt.size, tt.engine, estimate.Recommended, estimate.Recommendation, tt.recommended)
+		}
+	}
+}
+
+func TestEstimateScanCostSmallInputs(t *testing.T) {
+	for _, products := range [][]Product{nil, {{ID: "a", Name: "Lamp"}}} {
+		estimate := EstimateScanCost(products, 0.85, EngineHybrid)
+		if estimate.Pairs != 0 || estimate.Duration != 0 || estimate.Matches != 0 {
+			t.Errorf("%d products: estimate %+v", len(products), estimate)
+		}
+	}
+	if EngineLevenshtein.String() != "levenshtein" || EngineHybrid.String() != "hybrid" {
+		t.Errorf("engine names %v, %v", EngineLevenshtein, EngineHybrid)
+	}
+}
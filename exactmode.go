@@ -0,0 +1,49 @@
+package duplicatecheck
+
+// DefaultExactModeCutoff is the default HybridConfig.ExactModeCutoff: below
+// 100 indexed products the Levenshtein engine is the documented choice
+const DefaultExactModeCutoff = 100
+
+// Candidate sources reported in ComparisonResult.CandidateSource by HybridEngine
+const (
+	// CandidateSourceLSH marks a pair whose products shared an LSH bucket
+	CandidateSourceLSH = "lsh"
+	// CandidateSourceExhaustive marks a pair found by comparing the query with
+	// every indexed product, below HybridConfig.ExactModeCutoff
+	CandidateSourceExhaustive = "exhaustive"
+)
+
+// WithExactModeCutoff sets the index size below which queries skip LSH and
+// verify every indexed product (see HybridConfig.ExactModeCutoff); 0 always
+// uses LSH. Returns the engine for chaining.
+func (e *HybridEngine) WithExactModeCutoff(cutoff int) *HybridEngine {
+	if cutoff < 0 {
+		cutoff = 0
+	}
+	e.exactCutoff = cutoff
+	return e
+}
+
+// exhaustive reports whether queries against idx verify every indexed product
+// The mode follows the index size, so AddProduct crossing the cutoff switches
+// later queries to LSH.
+func (e *HybridEngine) exhaustive(idx *LSHIndex) bool {
+	return idx.size() < e.exactCutoff
+}
+
+// candidateSource returns the CandidateSource of pairs found in idx
+func (e *HybridEngine) candidateSource(idx *LSHIndex) string {
+	if e.exhaustive(idx) {
+		return CandidateSourceExhaustive
+	}
+	return CandidateSourceLSH
+}
+
+// allCandidates returns every indexed product as a candidate, in indexing order
+func allCandidates(idx *LSHIndex) []lshCandidate {
+	candidates := make([]lshCandidate, len(idx.ids))
+	for i, id := range idx.ids {
+		candidates[i] = lshCandidate{id: id}
+	}
+	return candidates
+}
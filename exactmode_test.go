@@ -0,0 +1,127 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"testing"
+)
+
+// resultSources returns the distinct CandidateSource values of results
+func resultSources(results []ComparisonResult) map[string]bool {
+	sources := make(map[string]bool)
+	for _, r := range results {
+		sources[r.CandidateSource] = true
+	}
+	return sources
+}
+
+func TestExactModeSmallCatalog(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 60)
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	lshOnly := NewHybridEngine().WithExactModeCutoff(0)
+	if err := lshOnly.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	levenshtein := NewLevenshteinEngine()
+
+	for _, threshold := range []float64{0.6, 0.75, 0.85} {
+		want := CanonicalizeResults(levenshtein.FindDuplicates(catalog, threshold))
+		results := engine.FindDuplicates(catalog, threshold)
+		if got := CanonicalizeResults(results); !reflect.DeepEqual(got, want) {
+			t.Errorf("threshold %v: hybrid found %d pairs, Levenshtein %d", threshold, len(got), len(want))
+		}
+		if sources := resultSources(results); len(results) > 0 && !reflect.DeepEqual(sources, map[string]bool{CandidateSourceExhaustive: true}) {
+			t.Errorf("threshold %v: candidate sources %v", threshold, sources)
+		}
+		t.Logf("threshold %v: exact mode %d pairs, LSH only %d", threshold, len(want), len(lshOnly.FindDuplicates(catalog, threshold)))
+
+		for _, product := range catalog[:10] {
+			var matches []ComparisonResult
+			for _, other := range catalog {
+				if result := levenshtein.Compare(product, other); other.ID != product.ID && result.CombinedSimilarity >= threshold {
+					matches = append(matches, result)
+				}
+			}
+			want := CanonicalizeResults(matches)
+			if got := CanonicalizeResults(withoutSelf(engine.FindDuplicatesForOne(product, threshold), product.ID)); !reflect.DeepEqual(got, want) {
+				t.Errorf("threshold %v, query %s: hybrid found %v, Levenshtein %v", threshold, product.ID, got, want)
+			}
+		}
+	}
+
+	stats := engine.GetIndexStats()
+	if stats["exact_mode"] != true || stats["exact_mode_cutoff"] != DefaultExactModeCutoff {
+		t.Errorf("exact_mode = %v, exact_mode_cutoff = %v", stats["exact_mode"], stats["exact_mode_cutoff"])
+	}
+	if lshOnly.GetIndexStats()["exact_mode"] != false {
+		t.Error("WithExactModeCutoff(0) should always use LSH")
+	}
+}
+
+// withoutSelf drops results pairing a product with itself
+func withoutSelf(results []ComparisonResult, id string) []ComparisonResult {
+	var kept []ComparisonResult
+	for _, r := range results {
+		if r.ProductA.ID != r.ProductB.ID || r.ProductA.ID != id {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+func TestExactModeCutoffTransition(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 150)
+	const cutoff = 100
+
+	t.Run("Large catalog uses LSH", func(t *testing.T) {
+		engine := NewHybridEngine()
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatal(err)
+		}
+		results := engine.FindDuplicates(catalog, 0.75)
+		if len(results) == 0 || !reflect.DeepEqual(resultSources(results), map[string]bool{CandidateSourceLSH: true}) {
+			t.Errorf("%d results from %v", len(results), resultSources(results))
+		}
+		if engine.GetIndexStats()["exact_mode"] != false {
+			t.Error("exact_mode should be off at 150 products")
+		}
+	})
+
+	t.Run("AddProduct crosses the cutoff", func(t *testing.T) {
+		engine := NewHybridEngine()
+		if err := engine.BuildIndex(catalog[:cutoff-1]); err != nil {
+			t.Fatal(err)
+		}
+		query := catalog[0]
+		query.ID = "query"
+		query.Description += " Ships in two days."
+
+		before := engine.FindDuplicatesForOne(query, 0.75)
+		if engine.GetIndexStats()["exact_mode"] != true || !resultSources(before)[CandidateSourceExhaustive] {
+			t.Fatalf("Below the cutoff: exact_mode = %v, sources %v", engine.GetIndexStats()["exact_mode"], resultSources(before))
+		}
+		if candidates := engine.EstimateCandidateReduction(query); candidates != cutoff-1 {
+			t.Errorf("Exact mode verifies %d candidates, want %d", candidates, cutoff-1)
+		}
+
+		if err := engine.AddProduct(catalog[cutoff-1]); err != nil {
+			t.Fatal(err)
+		}
+		after := engine.FindDuplicatesForOne(query, 0.75)
+		if engine.GetIndexStats()["exact_mode"] != false || !resultSources(after)[CandidateSourceLSH] {
+			t.Fatalf("At the cutoff: exact_mode = %v, sources %v", engine.GetIndexStats()["exact_mode"], resultSources(after))
+		}
+		// The near-copy is found either way
+		for _, results := range [][]ComparisonResult{before, after} {
+			found := false
+			for _, r := range results {
+				found = found || r.ProductB.ID == catalog[0].ID
+			}
+			if !found {
+				t.Errorf("Near-copy of %s missing from %d results", catalog[0].ID, len(results))
+			}
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultNearMatchDistance is the default TokenExplainOptions.MaxTokenDistance
+const DefaultNearMatchDistance = 2
+
+// DefaultMaxDescriptionTokens is the default TokenExplainOptions.MaxDescriptionTokens
+const DefaultMaxDescriptionTokens = 100
+
+// TokenExplainOptions controls ExplainTokensWithOptions
+type TokenExplainOptions struct {
+	// MaxTokenDistance is the largest edit distance between two unmatched
+	// tokens that makes them a near match (default DefaultNearMatchDistance).
+	// A near match also needs at most half the shorter token edited, so "s21"
+	// and "s22" pair up but "a" and "b" don't.
+	MaxTokenDistance int
+	// IncludeDescriptions adds description tokens after the name tokens
+	IncludeDescriptions bool
+	// MaxDescriptionTokens caps the description tokens read per product
+	// (default DefaultMaxDescriptionTokens)
+	MaxDescriptionTokens int
+}
+
+// TokenPair is a near match between a token of A and a token of B
+type TokenPair struct {
+	A        string `json:"a"`
+	B        string `json:"b"`
+	Distance int    `json:"distance"` // Edit distance between the tokens
+}
+
+// TokenExplanation summarizes which words two products share
+// Tokens are runs of letters and digits of the normalized text, so they are
+// lowercase; repeated tokens are listed once per occurrence.
+type TokenExplanation struct {
+	MatchedTokens []string    `json:"matched_tokens"` // Tokens found on both sides
+	NearMatches   []TokenPair `json:"near_matches"`   // Unmatched tokens paired with a similar one
+	UniqueToA     []string    `json:"unique_to_a"`    // Tokens of A with no counterpart in B
+	UniqueToB     []string    `json:"unique_to_b"`    // Tokens of B with no counterpart in A
+}
+
+// ExplainTokens explains a comparison of a and b word by word, over their
+// normalized names
+func ExplainTokens(a, b Product) TokenExplanation {
+	return ExplainTokensWithOptions(a, b, TokenExplainOptions{})
+}
+
+// ExplainTokensWithOptions is ExplainTokens with near-match and description control
+// Tokens are aligned greedily: identical tokens first, in A's order, then the
+// remaining pairs closest first (ties by position). This is not an optimal
+// assignment, but it is fast and reads naturally.
+func ExplainTokensWithOptions(a, b Product, opts TokenExplainOptions) TokenExplanation {
+	if opts.MaxTokenDistance <= 0 {
+		opts.MaxTokenDistance = DefaultNearMatchDistance
+	}
+	if opts.MaxDescriptionTokens <= 0 {
+		opts.MaxDescriptionTokens = DefaultMaxDescriptionTokens
+	}
+	tokensA, tokensB := explainTokens(&a, opts), explainTokens(&b, opts)
+
+	var explanation TokenExplanation
+	available := make(map[string][]int) // Unmatched positions of each token in B
+	for j, token := range tokensB {
+		available[token] = append(available[token], j)
+	}
+	matchedA := make([]bool, len(tokensA))
+	matchedB := make([]bool, len(tokensB))
+	for i, token := range tokensA {
+		if positions := available[token]; len(positions) > 0 {
+			available[token] = positions[1:]
+			matchedA[i], matchedB[positions[0]] = true, true
+			explanation.MatchedTokens = append(explanation.MatchedTokens, token)
+		}
+	}
+
+	type nearCandidate struct{ i, j, distance int }
+	var candidates []nearCandidate
+	for i, tokenA := range tokensA {
+		if matchedA[i] {
+			continue
+		}
+		for j, tokenB := range tokensB {
+			if matchedB[j] {
+				continue
+			}
+			if distance, ok := nearTokenDistance(tokenA, tokenB, opts.MaxTokenDistance); ok {
+				candidates = append(candidates, nearCandidate{i, j, distance})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(x, y int) bool { return candidates[x].distance < candidates[y].distance })
+	for _, c := range candidates {
+		if matchedA[c.i] || matchedB[c.j] {
+			continue
+		}
+		matchedA[c.i], matchedB[c.j] = true, true
+		explanation.NearMatches = append(explanation.NearMatches, TokenPair{A: tokensA[c.i], B: tokensB[c.j], Distance: c.distance})
+	}
+
+	for i, token := range tokensA {
+		if !matchedA[i] {
+			explanation.UniqueToA = append(explanation.UniqueToA, token)
+		}
+	}
+	for j, token := range tokensB {
+		if !matchedB[j] {
+			explanation.UniqueToB = append(explanation.UniqueToB, token)
+		}
+	}
+	return explanation
+}
+
+// String renders the explanation compactly, one line per list
+func (t TokenExplanation) String() string {
+	near := make([]string, len(t.NearMatches))
+	for i, pair := range t.NearMatches {
+		near[i] = fmt.Sprintf("%s~%s", pair.A, pair.B)
+	}
+	var sb strings.Builder
+	writeTokenLine(&sb, "matched", t.MatchedTokens)
+	writeTokenLine(&sb, "near", near)
+	writeTokenLine(&sb, "only A", t.UniqueToA)
+	writeTokenLine(&sb, "only B", t.UniqueToB)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// writeTokenLine writes "label (n): tokens", or "-" for no tokens
+func writeTokenLine(sb *strings.Builder, label string, tokens []string) {
+	list := "-"
+	if len(tokens) > 0 {
+		list = strings.Join(tokens, " ")
+	}
+	fmt.Fprintf(sb, "%-8s (%d): %s\n", label, len(tokens), list)
+}
+
+// explainTokens returns the tokens of p's normalized name, then of its
+// description if opts includes it
+func explainTokens(p *Product, opts TokenExplainOptions) []string {
+	name, desc := p.getNormalizedStrings()
+	var tokens []string
+	for _, token := range synonymTokens(name) {
+		tokens = append(tokens, token.text)
+	}
+	if opts.IncludeDescriptions {
+		for i, token := range synonymTokens(desc) {
+			if i == opts.MaxDescriptionTokens {
+				break
+			}
+			tokens = append(tokens, token.text)
+		}
+	}
+	return tokens
+}
+
+// nearTokenDistance returns the edit distance between a and b and whether it
+// makes them a near match: at most maxDistance, and at most half the shorter
+// token
+func nearTokenDistance(a, b string, maxDistance int) (int, bool) {
+	lenA, lenB := len([]rune(a)), len([]rune(b))
+	shorter, diff := lenA, lenB-lenA
+	if lenB < lenA {
+		shorter, diff = lenB, lenA-lenB
+	}
+	if diff > maxDistance || 2*diff > shorter {
+		return 0, false
+	}
+	distance := levenshteinMyers(a, b)
+	return distance, distance <= maxDistance && 2*distance <= shorter
+}
@@ -0,0 +1,106 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExplainTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Product
+		opts TokenExplainOptions
+		want TokenExplanation
+	}{
+		{
+			name: "Typo is a near match",
+			a:    Product{ID: "1", Name: "Samsung Galxy S21"},
+			b:    Product{ID: "2", Name: "Samsung Galaxy S21"},
+			want: TokenExplanation{
+				MatchedTokens: []string{"samsung", "s21"},
+				NearMatches:   []TokenPair{{A: "galxy", B: "galaxy", Distance: 1}},
+			},
+		},
+		{
+			name: "Extra words are unique",
+			a:    Product{ID: "1", Name: "Samsung Galaxy S23 Ultra 512GB", Description: "Phantom Black, 12GB RAM"},
+			b:    Product{ID: "2", Name: "Samsung Galaxy S23", Description: "Black, 8GB RAM"},
+			opts: TokenExplainOptions{IncludeDescriptions: true},
+			want: TokenExplanation{
+				MatchedTokens: []string{"samsung", "galaxy", "s23", "black", "ram"},
+				UniqueToA:     []string{"ultra", "512gb", "phantom", "12gb"},
+				UniqueToB:     []string{"8gb"},
+			},
+		},
+		{
+			name: "Names only by default",
+			a:    Product{ID: "1", Name: "Samsung Galaxy S23 Ultra", Description: "Phantom Black"},
+			b:    Product{ID: "2", Name: "Samsung Galaxy S23", Description: "Cream"},
+			want: TokenExplanation{
+				MatchedTokens: []string{"samsung", "galaxy", "s23"},
+				UniqueToA:     []string{"ultra"},
+			},
+		},
+		{
+			name: "Short tokens need half their runes intact",
+			a:    Product{ID: "1", Name: "Cable A 2m"},
+			b:    Product{ID: "2", Name: "Cable B 3m"},
+			want: TokenExplanation{
+				MatchedTokens: []string{"cable"},
+				NearMatches:   []TokenPair{{A: "2m", B: "3m", Distance: 1}},
+				UniqueToA:     []string{"a"},
+				UniqueToB:     []string{"b"},
+			},
+		},
+		{
+			name: "Repeated tokens match once each",
+			a:    Product{ID: "1", Name: "Pack Pack Pack"},
+			b:    Product{ID: "2", Name: "Pack Pack"},
+			want: TokenExplanation{
+				MatchedTokens: []string{"pack", "pack"},
+				UniqueToA:     []string{"pack"},
+			},
+		},
+		{
+			name: "Closest near match wins",
+			a:    Product{ID: "1", Name: "Headphones Sonny"},
+			b:    Product{ID: "2", Name: "Headphons Sony"},
+			opts: TokenExplainOptions{MaxTokenDistance: 3},
+			want: TokenExplanation{
+				NearMatches: []TokenPair{
+					{A: "headphones", B: "headphons", Distance: 1},
+					{A: "sonny", B: "sony", Distance: 1},
+				},
+			},
+		},
+		{
+			name: "Description cap",
+			a:    Product{ID: "1", Name: "Lamp", Description: "warm white dimmable"},
+			b:    Product{ID: "2", Name: "Lamp", Description: "warm"},
+			opts: TokenExplainOptions{IncludeDescriptions: true, MaxDescriptionTokens: 1},
+			want: TokenExplanation{MatchedTokens: []string{"lamp", "warm"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExplainTokensWithOptions(tt.a, tt.b, tt.opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExplainTokensWithOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenExplanationString(t *testing.T) {
+	got := ExplainTokens(Product{Name: "Samsung Galxy S23 Ultra"}, Product{Name: "Samsung Galaxy S23"}).String()
+	want := strings.Join([]string{
+		"matched  (2): samsung s23",
+		"near     (1): galxy~galaxy",
+		"only A   (1): ultra",
+		"only B   (0): -",
+	}, "\n")
+	if got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,304 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// ErrIncompatibleIndex is returned when an exported index was built with
+// settings that assign products to different buckets than this engine
+var ErrIncompatibleIndex = errors.New("duplicatecheck: index was built with an incompatible configuration")
+
+// ExportFormat selects the layout written by ExportIndexSnapshot
+type ExportFormat int
+
+const (
+	// ExportJSON writes a single JSON document (IndexSnapshot)
+	ExportJSON ExportFormat = iota
+	// ExportJSONL writes one JSON record per line: a "config" record, then a
+	// "bucket" record per bucket, then a "signature" record per product.
+	// Suited to large indexes that don't fit comfortably in one document.
+	ExportJSONL
+)
+
+// ExportOptions controls ExportIndexSnapshot
+type ExportOptions struct {
+	// Format selects JSON (default) or JSONL output
+	Format ExportFormat
+	// IncludeSignatures adds the MinHash signatures of every product.
+	// Signatures are recomputed from the indexed text, so they are not
+	// available in privacy mode. Large: NumHashFunctions values per chunk.
+	IncludeSignatures bool
+	// MaxBucketSample caps the product IDs listed per bucket (0 = no cap).
+	// BucketSnapshot.Size always holds the full bucket size.
+	MaxBucketSample int
+}
+
+// SnapshotConfig describes the engine settings an index was built with
+type SnapshotConfig struct {
+	Engine            string  `json:"engine"`
+	NumHashFunctions  int     `json:"num_hash_functions"`
+	LSHSeed           int64   `json:"lsh_seed"` // Seed of the MinHash hash family (see WithLSHSeed)
+	NumBands          int     `json:"num_bands"`
+	RowsPerBand       int     `json:"rows_per_band"`
+	ShingleSize       int     `json:"shingle_size"`
+	ChunkedSignatures bool    `json:"chunked_signatures"`
+	ChunkSize         int     `json:"chunk_size,omitempty"`
+	ChunkOverlap      int     `json:"chunk_overlap,omitempty"`
+	SimHashScreen     bool    `json:"simhash_screen"`
+	SimHashMargin     float64 `json:"simhash_margin"`
+	PrivacyMode       bool    `json:"privacy_mode"`
+	TotalProducts     int     `json:"total_products"`
+	// Indexing limits (see HybridConfig), omitted when unlimited
+	MaxShinglesPerProduct int `json:"max_shingles_per_product,omitempty"`
+	MaxIndexTextLength    int `json:"max_index_text_length,omitempty"`
+	// SignatureScheme names how signatures were computed (see
+	// HybridConfig.SignatureScheme), omitted for the default
+	SignatureScheme string `json:"signature_scheme,omitempty"`
+	// ShingleTokenization names how shingle words were split
+	ShingleTokenization string `json:"shingle_tokenization"`
+	// TextPreparation is the TextPreparation fingerprint, in hexadecimal
+	TextPreparation string `json:"text_preparation"`
+	// BucketSalt is the index's bucket hash salt in hexadecimal (see
+	// HybridConfig.BucketSalt), omitted for unsalted indexes
+	BucketSalt string `json:"bucket_salt,omitempty"`
+	// Fingerprint identifies every setting that decides bucket assignments, in
+	// hexadecimal (see IndexConfigFingerprint)
+	Fingerprint string `json:"fingerprint"`
+}
+
+// BucketSnapshot lists the members of one LSH bucket
+// Hash is the bucket's key in hexadecimal (uint64 doesn't survive JSON number
+// parsing in many tools): the band hash mixed with SnapshotConfig.BucketSalt
+type BucketSnapshot struct {
+	Band      int      `json:"band"`
+	Hash      string   `json:"hash"`
+	Size      int      `json:"size"`
+	Members   []string `json:"members"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// SignatureSnapshot holds the MinHash signatures of one product
+// (one per chunk in chunked mode)
+type SignatureSnapshot struct {
+	ProductID  string     `json:"product_id"`
+	Signatures [][]uint32 `json:"signatures"`
+}
+
+// IndexSnapshot is the document written by ExportIndexSnapshot in ExportJSON format
+//
+//	{
+//	  "config":     {...},                                   // SnapshotConfig
+//	  "buckets":    [{"band": 0, "hash": "9f1c...", "size": 2, "members": ["a", "b"]}, ...],
+//	  "signatures": [{"product_id": "a", "signatures": [[...]]}, ...]   // optional
+//	}
+//
+// Buckets are ordered by band, then hash; signatures by product ID.
+type IndexSnapshot struct {
+	Config     SnapshotConfig      `json:"config"`
+	Buckets    []BucketSnapshot    `json:"buckets"`
+	Signatures []SignatureSnapshot `json:"signatures,omitempty"`
+}
+
+// snapshotRecord wraps each line of ExportJSONL output
+type snapshotRecord struct {
+	Type      string             `json:"type"` // "config", "bucket" or "signature"
+	Config    *SnapshotConfig    `json:"config,omitempty"`
+	Bucket    *BucketSnapshot    `json:"bucket,omitempty"`
+	Signature *SignatureSnapshot `json:"signature,omitempty"`
+}
+
+// ExportIndexSnapshot writes the LSH bucket membership, engine config and,
+// optionally, per-product MinHash signatures to w for offline analysis.
+// It only reads the index, the one published when it's called.
+func (e *HybridEngine) ExportIndexSnapshot(w io.Writer, opts ExportOptions) (err error) {
+	defer e.levenshteinEngine.guard("ExportIndexSnapshot", &err)
+	idx := e.currentIndex()
+	if idx == nil {
+		return ErrIndexNotBuilt
+	}
+	if opts.IncludeSignatures && e.privacy != nil {
+		return errors.New("duplicatecheck: signatures are not available in privacy mode")
+	}
+
+	config := e.snapshotConfig(idx)
+	buckets := snapshotBuckets(idx, opts.MaxBucketSample)
+	var signatures []SignatureSnapshot
+	if opts.IncludeSignatures {
+		signatures = e.snapshotSignatures(idx)
+	}
+
+	switch opts.Format {
+	case ExportJSON:
+		return json.NewEncoder(w).Encode(IndexSnapshot{
+			Config:     config,
+			Buckets:    buckets,
+			Signatures: signatures,
+		})
+	case ExportJSONL:
+		enc := json.NewEncoder(w)
+		if err := enc.Encode(snapshotRecord{Type: "config", Config: &config}); err != nil {
+			return err
+		}
+		for i := range buckets {
+			if err := enc.Encode(snapshotRecord{Type: "bucket", Bucket: &buckets[i]}); err != nil {
+				return err
+			}
+		}
+		for i := range signatures {
+			if err := enc.Encode(snapshotRecord{Type: "signature", Signature: &signatures[i]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("duplicatecheck: unknown export format %d", opts.Format)
+	}
+}
+
+// snapshotConfig returns the engine settings of idx
+func (e *HybridEngine) snapshotConfig(idx *LSHIndex) SnapshotConfig {
+	return SnapshotConfig{
+		Engine:            e.GetName(),
+		NumHashFunctions:  e.numHashFunctions,
+		LSHSeed:           e.minHash.seed,
+		NumBands:          e.numBands,
+		RowsPerBand:       idx.rowsPerBand,
+		ShingleSize:       e.shingleSize,
+		ChunkedSignatures: e.chunkSize > 0,
+		ChunkSize:         e.chunkSize,
+		ChunkOverlap:      e.chunkOverlap,
+		SimHashScreen:     e.simHashScreen,
+		SimHashMargin:     e.simHashMargin,
+		PrivacyMode:       e.privacy != nil,
+		TotalProducts:     idx.size(),
+
+		MaxShinglesPerProduct: e.maxShingles,
+		MaxIndexTextLength:    e.maxIndexTextLength,
+
+		SignatureScheme:     e.signatureSchemeName(),
+		ShingleTokenization: e.shingleTokenization(),
+		TextPreparation:     strconv.FormatUint(e.preparer().fingerprint, 16),
+		BucketSalt:          formatBucketSalt(idx.salt),
+		Fingerprint:         strconv.FormatUint(e.IndexConfigFingerprint(), 16),
+	}
+}
+
+// IndexConfigFingerprint identifies the settings that decide which buckets a
+// product lands in: MinHash family, signature scheme and banding, shingle
+// size and word splitting, chunking, indexing limits, text preparation,
+// SKU-like names, low-information names, and the fields the weights index
+// Indexes built under different fingerprints place the same product in
+// different buckets and can't be queried or merged together.
+func (e *HybridEngine) IndexConfigFingerprint() uint64 {
+	parts := []string{"index-config/v1",
+		strconv.Itoa(e.numHashFunctions),
+		strconv.FormatInt(e.minHash.seed, 10),
+		strconv.Itoa(e.numBands),
+		strconv.Itoa(e.shingleSize),
+		e.shingleTokenization(),
+		strconv.Itoa(e.chunkSize),
+		strconv.Itoa(e.chunkOverlap),
+		strconv.FormatUint(e.preparer().fingerprint, 16),
+	}
+	// Only non-default schemes and set limits are included, so other indexes
+	// keep their fingerprint
+	if e.minHash.scheme != SignatureKIndependent {
+		parts = append(parts, "scheme", e.minHash.scheme.String())
+	}
+	if e.maxShingles > 0 || e.maxIndexTextLength > 0 {
+		parts = append(parts, "limits",
+			strconv.Itoa(e.maxShingles), strconv.Itoa(e.maxIndexTextLength))
+	}
+	parts = append(parts, e.skuFingerprintParts()...)
+	parts = append(parts, e.lowInfoFingerprintParts()...)
+	parts = append(parts, e.indexedFieldsFingerprintParts()...)
+	return contentFingerprint(parts...)
+}
+
+// signatureSchemeName is SnapshotConfig.SignatureScheme: the scheme's name,
+// empty for the default
+func (e *HybridEngine) signatureSchemeName() string {
+	if e.minHash.scheme == SignatureKIndependent {
+		return ""
+	}
+	return e.minHash.scheme.String()
+}
+
+// CheckSnapshotConfig reports whether an index exported with config is
+// compatible with this engine, returning an error wrapping
+// ErrIncompatibleIndex if its buckets were assigned differently. Snapshots
+// from before the fingerprint was recorded are always incompatible.
+func (e *HybridEngine) CheckSnapshotConfig(config SnapshotConfig) error {
+	want := strconv.FormatUint(e.IndexConfigFingerprint(), 16)
+	if config.Fingerprint != want {
+		return fmt.Errorf("%w (snapshot %q, engine %q)", ErrIncompatibleIndex, config.Fingerprint, want)
+	}
+	return nil
+}
+
+// snapshotBuckets lists every bucket of idx ordered by band, then hash, by
+// product ID and without removed products
+func snapshotBuckets(idx *LSHIndex, maxSample int) []BucketSnapshot {
+	var buckets []BucketSnapshot
+	for bandIdx := 0; bandIdx < idx.numBands; bandIdx++ {
+		band := make(map[uint64][]string)
+		idx.bands.ForEach(bandIdx, func(hash uint64, numbers []uint32) bool {
+			var ids []string
+			for _, n := range numbers {
+				if !idx.removed[n] {
+					ids = append(ids, idx.refs[n])
+				}
+			}
+			if len(ids) > 0 {
+				band[hash] = ids
+			}
+			return true
+		})
+		hashes := make([]uint64, 0, len(band))
+		for hash := range band {
+			hashes = append(hashes, hash)
+		}
+		sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+
+		for _, hash := range hashes {
+			ids := band[hash]
+			members := ids
+			truncated := false
+			if maxSample > 0 && len(members) > maxSample {
+				members = members[:maxSample]
+				truncated = true
+			}
+			buckets = append(buckets, BucketSnapshot{
+				Band:      bandIdx,
+				Hash:      fmt.Sprintf("%016x", hash),
+				Size:      len(ids),
+				Members:   append([]string(nil), members...),
+				Truncated: truncated,
+			})
+		}
+	}
+	return buckets
+}
+
+// snapshotSignatures recomputes the MinHash signatures of every product of idx
+func (e *HybridEngine) snapshotSignatures(idx *LSHIndex) []SignatureSnapshot {
+	ids := make([]string, 0, len(idx.products))
+	for id := range idx.products {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	signatures := make([]SignatureSnapshot, 0, len(ids))
+	for _, id := range ids {
+		signatures = append(signatures, SignatureSnapshot{
+			ProductID:  id,
+			Signatures: e.productSignatures(idx.products[id], e.indexText(idx.products[id])),
+		})
+	}
+	return signatures
+}
@@ -0,0 +1,214 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func exportTestProducts() []Product {
+	return []Product{
+		{ID: "1", Name: "iPhone 14 Pro", Description: "Latest Apple smartphone with A16 chip"},
+		{ID: "2", Name: "iPhone 14 Pro", Description: "Latest Apple smartphone with A16 chip!"},
+		{ID: "3", Name: "Samsung Galaxy S22", Description: "Android flagship phone"},
+		{ID: "4", Name: "Sony WH-1000XM5", Description: "Noise cancelling headphones"},
+		{ID: "5", Name: "Dell XPS 13", Description: "Compact laptop with InfinityEdge display"},
+	}
+}
+
+func TestExportIndexSnapshot(t *testing.T) {
+	products := exportTestProducts()
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := engine.ExportIndexSnapshot(&buf, ExportOptions{IncludeSignatures: true}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	var snapshot IndexSnapshot
+	if err := json.Unmarshal(buf.Bytes(), &snapshot); err != nil {
+		t.Fatalf("Export is not valid JSON: %v", err)
+	}
+
+	t.Run("Config matches engine", func(t *testing.T) {
+		c := snapshot.Config
+		if c.NumHashFunctions != engine.numHashFunctions || c.NumBands != engine.numBands ||
+			c.ShingleSize != engine.shingleSize || c.RowsPerBand != engine.lshIndex.rowsPerBand {
+			t.Errorf("Config mismatch: %+v", c)
+		}
+		if c.Engine != engine.GetName() || c.TotalProducts != len(products) || c.PrivacyMode || c.ChunkedSignatures {
+			t.Errorf("Config mismatch: %+v", c)
+		}
+	})
+
+	t.Run("Every product appears in exactly numBands buckets", func(t *testing.T) {
+		counts := make(map[string]int)
+		perBand := make(map[string]map[int]bool)
+		for _, bucket := range snapshot.Buckets {
+			if bucket.Size != len(bucket.Members) {
+				t.Errorf("Bucket %s: size %d but %d members", bucket.Hash, bucket.Size, len(bucket.Members))
+			}
+			for _, id := range bucket.Members {
+				counts[id]++
+				if perBand[id] == nil {
+					perBand[id] = make(map[int]bool)
+				}
+				perBand[id][bucket.Band] = true
+			}
+		}
+		for _, p := range products {
+			if counts[p.ID] != engine.numBands || len(perBand[p.ID]) != engine.numBands {
+				t.Errorf("Product %s appears in %d buckets across %d bands, want %d",
+					p.ID, counts[p.ID], len(perBand[p.ID]), engine.numBands)
+			}
+		}
+	})
+
+	t.Run("Signatures", func(t *testing.T) {
+		if len(snapshot.Signatures) != len(products) {
+			t.Fatalf("Expected %d signatures, got %d", len(products), len(snapshot.Signatures))
+		}
+		for _, s := range snapshot.Signatures {
+			if len(s.Signatures) != 1 || len(s.Signatures[0]) != engine.numHashFunctions {
+				t.Errorf("Product %s: unexpected signature shape", s.ProductID)
+			}
+		}
+	})
+}
+
+func TestExportIndexSnapshotOptions(t *testing.T) {
+	products := make([]Product, 0, 12)
+	for i := 0; i < 12; i++ {
+		// Identical text puts every product in the same bucket of every band
+		products = append(products, Product{ID: string(rune('a' + i)), Name: "Same Name", Description: "same text"})
+	}
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	t.Run("MaxBucketSample caps members", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := engine.ExportIndexSnapshot(&buf, ExportOptions{MaxBucketSample: 5}); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		var snapshot IndexSnapshot
+		if err := json.Unmarshal(buf.Bytes(), &snapshot); err != nil {
+			t.Fatalf("Export is not valid JSON: %v", err)
+		}
+		if len(snapshot.Buckets) != engine.numBands {
+			t.Fatalf("Expected one bucket per band, got %d", len(snapshot.Buckets))
+		}
+		for _, bucket := range snapshot.Buckets {
+			if len(bucket.Members) != 5 || bucket.Size != 12 || !bucket.Truncated {
+				t.Errorf("Expected 5 of 12 members and truncated, got %d of %d (truncated=%v)",
+					len(bucket.Members), bucket.Size, bucket.Truncated)
+			}
+		}
+		if snapshot.Signatures != nil {
+			t.Error("Signatures should be omitted unless requested")
+		}
+	})
+
+	t.Run("JSONL", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := engine.ExportIndexSnapshot(&buf, ExportOptions{Format: ExportJSONL, IncludeSignatures: true}); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		types := make(map[string]int)
+		scanner := bufio.NewScanner(&buf)
+		for scanner.Scan() {
+			var record snapshotRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("Line is not valid JSON: %v", err)
+			}
+			types[record.Type]++
+		}
+		if types["config"] != 1 || types["bucket"] != engine.numBands || types["signature"] != len(products) {
+			t.Errorf("Unexpected record counts: %v", types)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := NewHybridEngine().ExportIndexSnapshot(&buf, ExportOptions{}); !errors.Is(err, ErrIndexNotBuilt) {
+			t.Errorf("Expected ErrIndexNotBuilt, got %v", err)
+		}
+
+		private := NewHybridEngine()
+		private.EnablePrivacyMode(PrivacyOptions{})
+		if err := private.BuildIndex(products); err != nil {
+			t.Fatalf("BuildIndex failed: %v", err)
+		}
+		if err := private.ExportIndexSnapshot(&buf, ExportOptions{IncludeSignatures: true}); err == nil {
+			t.Error("Expected an error exporting signatures in privacy mode")
+		}
+		if err := private.ExportIndexSnapshot(&buf, ExportOptions{}); err != nil {
+			t.Errorf("Bucket export should work in privacy mode: %v", err)
+		}
+	})
+}
+
+func TestExportIndexSnapshotDuringRebuild(t *testing.T) {
+	small := exportTestProducts()[:3]
+	large := exportTestProducts()
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(small); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	// Rebuild with alternating catalogs while exporting
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			catalog := small
+			if i%2 == 0 {
+				catalog = large
+			}
+			if err := engine.BuildIndex(catalog); err != nil {
+				t.Errorf("BuildIndex failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		var buf bytes.Buffer
+		if err := engine.ExportIndexSnapshot(&buf, ExportOptions{IncludeSignatures: true}); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		var snapshot IndexSnapshot
+		if err := json.Unmarshal(buf.Bytes(), &snapshot); err != nil {
+			t.Fatalf("Export is not valid JSON: %v", err)
+		}
+		// Config, buckets and signatures all describe the same index
+		if n := snapshot.Config.TotalProducts; n != len(snapshot.Signatures) || (n != len(small) && n != len(large)) {
+			t.Fatalf("Snapshot of %d products holds %d signatures", n, len(snapshot.Signatures))
+		}
+		members := make(map[string]bool)
+		for _, bucket := range snapshot.Buckets {
+			for _, id := range bucket.Members {
+				members[id] = true
+			}
+		}
+		if len(members) != snapshot.Config.TotalProducts {
+			t.Fatalf("Snapshot of %d products has %d in its buckets", snapshot.Config.TotalProducts, len(members))
+		}
+	}
+	close(done)
+	wg.Wait()
+}
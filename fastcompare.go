@@ -0,0 +1,93 @@
+package duplicatecheck
+
+// DefaultFastCompareFloor is the default HybridConfig.FastCompareFloor
+const DefaultFastCompareFloor = 0.2
+
+// CompareExact compares two products with the full Levenshtein comparison,
+// whatever HybridConfig.FastCompare says
+func (e *HybridEngine) CompareExact(a, b Product) ComparisonResult {
+	defer e.levenshteinEngine.guard("CompareExact", nil)
+	return e.compareExact(&a, &b)
+}
+
+// compareExact is CompareExact without copying the products
+func (e *HybridEngine) compareExact(a, b *Product) ComparisonResult {
+	result := e.levenshteinEngine.ComparePtr(a, b)
+	result.stampThreshold(e.threshold)
+	return result
+}
+
+// compareFast is ComparePtr with HybridConfig.FastCompare: pairs whose
+// MinHash estimate is below the floor are rejected without Levenshtein
+func (e *HybridEngine) compareFast(a, b *Product) ComparisonResult {
+	estimate, ok := e.estimatePair(a, b)
+	if !ok {
+		return e.compareExact(a, b)
+	}
+	if estimate >= e.fastCompareFloor {
+		result := e.compareExact(a, b)
+		result.EstimatedSimilarity = estimate
+		return result
+	}
+	result := e.levenshteinEngine.finishResult(ComparisonResult{
+		ProductA:            *a,
+		ProductB:            *b,
+		CombinedSimilarity:  estimate,
+		EstimatedSimilarity: estimate,
+		Stage:               StageEstimatedReject,
+	})
+	result.stampThreshold(e.threshold)
+	return result
+}
+
+// estimatePair returns the MinHash-estimated Jaccard similarity of two
+// products' index texts, when both are indexed or both have signatures
+// cached by Warmup; false otherwise
+func (e *HybridEngine) estimatePair(a, b *Product) (float64, bool) {
+	signaturesA, signaturesB := e.cachedQuerySignatures(a), e.cachedQuerySignatures(b)
+	if signaturesA == nil || signaturesB == nil {
+		idx := e.currentIndex()
+		if idx == nil || idx.products[a.ID] == nil || idx.products[b.ID] == nil {
+			return 0, false
+		}
+		if signaturesA == nil {
+			signaturesA = e.productSignatures(a, e.indexText(a))
+		}
+		if signaturesB == nil {
+			signaturesB = e.productSignatures(b, e.indexText(b))
+		}
+	}
+	return estimateSignatureSimilarity(signaturesA, signaturesB), true
+}
+
+// cachedQuerySignatures returns a product's signatures as Warmup cached them
+// under the index configuration, or nil
+func (e *HybridEngine) cachedQuerySignatures(product *Product) [][]uint32 {
+	if c := product.cachedValueFor(e.preparer()); c != nil {
+		return c.cachedSignatures(e.IndexConfigFingerprint)
+	}
+	return nil
+}
+
+// estimateSignatureSimilarity returns the fraction of agreeing rows of the
+// most similar pair of signatures, one from each side (chunks, in chunked mode)
+func estimateSignatureSimilarity(a, b [][]uint32) float64 {
+	best := 0.0
+	for _, sa := range a {
+		for _, sb := range b {
+			if len(sa) == 0 || len(sa) != len(sb) {
+				continue
+			}
+			agree := 0
+			for i := range sa {
+				if sa[i] == sb[i] {
+					agree++
+				}
+			}
+			if estimate := float64(agree) / float64(len(sa)); estimate > best {
+				best = estimate
+			}
+		}
+	}
+	return best
+}
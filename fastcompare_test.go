@@ -0,0 +1,155 @@
+package duplicatecheck
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// fastCompareCatalog holds a near-duplicate pair and two unrelated products
+func fastCompareCatalog() []Product {
+	return []Product{
+		{ID: "1", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Industry leading noise cancelling with two processors and eight microphones"},
+		{ID: "2", Name: "Sony WH-1000XM5 Wireless Headphone", Description: "Industry leading noise cancelling with two processors and eight microphones"},
+		{ID: "3", Name: "Le Creuset Enameled Cast Iron Dutch Oven", Description: "Heavy cookware that keeps heat evenly for slow braises and stews"},
+		{ID: "4", Name: "Kindle Paperwhite Signature Edition", Description: "Waterproof e-reader with a flush-front design and adjustable warm light"},
+	}
+}
+
+func TestHybridFastCompare(t *testing.T) {
+	products := fastCompareCatalog()
+	config := DefaultHybridConfig()
+	config.FastCompare = true
+	engine := NewHybridEngineWithConfig(config)
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	exact := NewLevenshteinEngine()
+
+	tests := []struct {
+		name       string
+		a, b       int
+		wantReject bool
+	}{
+		{"near duplicates compared in full", 0, 1, false},
+		{"unrelated rejected by estimate", 0, 2, true},
+		{"unrelated rejected by estimate again", 2, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := products[tt.a], products[tt.b]
+			result := engine.Compare(a, b)
+			if result.EstimatedSimilarity <= 0 && !tt.wantReject {
+				t.Errorf("no estimate recorded for an indexed pair")
+			}
+			if tt.wantReject {
+				if result.Stage != StageEstimatedReject {
+					t.Fatalf("Stage = %q, want %q (estimate %v)", result.Stage, StageEstimatedReject, result.EstimatedSimilarity)
+				}
+				if result.EstimatedSimilarity >= DefaultFastCompareFloor || result.CombinedSimilarity != result.EstimatedSimilarity {
+					t.Errorf("rejected at estimate %v, similarity %v", result.EstimatedSimilarity, result.CombinedSimilarity)
+				}
+				if result.MeetsThreshold || result.NameDistance != 0 {
+					t.Errorf("rejected pair meets threshold %v, name distance %d", result.MeetsThreshold, result.NameDistance)
+				}
+				precise := engine.CompareExact(a, b)
+				if precise.Stage != "" || precise.EstimatedSimilarity != 0 {
+					t.Errorf("CompareExact stage %q, estimate %v", precise.Stage, precise.EstimatedSimilarity)
+				}
+				return
+			}
+			want := exact.Compare(a, b)
+			if result.Stage != "" || result.CombinedSimilarity != want.CombinedSimilarity || result.NameDistance != want.NameDistance {
+				t.Errorf("got stage %q similarity %v, want Levenshtein's %v", result.Stage, result.CombinedSimilarity, want.CombinedSimilarity)
+			}
+		})
+	}
+}
+
+func TestHybridFastCompareFloor(t *testing.T) {
+	products := fastCompareCatalog()
+	config := DefaultHybridConfig()
+	config.FastCompare = true
+	engine := NewHybridEngineWithConfig(config)
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	estimate := engine.Compare(products[0], products[1]).EstimatedSimilarity
+	if estimate <= 0 || estimate >= 0.99 {
+		t.Fatalf("near-duplicate estimate %v, want one strictly inside (0, 0.99)", estimate)
+	}
+
+	tests := []struct {
+		name       string
+		floor      float64
+		wantReject bool
+	}{
+		{"floor at the estimate", estimate, false},
+		{"floor above the estimate", estimate + 0.01, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.FastCompareFloor = tt.floor
+			engine := NewHybridEngineWithConfig(config)
+			if err := engine.BuildIndex(products); err != nil {
+				t.Fatal(err)
+			}
+			if got := engine.Compare(products[0], products[1]).Stage == StageEstimatedReject; got != tt.wantReject {
+				t.Errorf("rejected = %v, want %v", got, tt.wantReject)
+			}
+			if got := engine.Config().Hybrid.FastCompareFloor; got != tt.floor {
+				t.Errorf("Config FastCompareFloor = %v, want %v", got, tt.floor)
+			}
+		})
+	}
+}
+
+func TestHybridFastCompareFallback(t *testing.T) {
+	products := fastCompareCatalog()
+	stranger := Product{ID: "9", Name: "Patagonia Nano Puff Jacket", Description: "Insulated windproof jacket"}
+
+	fast := DefaultHybridConfig()
+	fast.FastCompare = true
+	engines := map[string]*HybridEngine{
+		"fast, no index":  NewHybridEngineWithConfig(fast),
+		"default":         NewHybridEngine(),
+		"fast, unindexed": NewHybridEngineWithConfig(fast),
+	}
+	if err := engines["default"].BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	if err := engines["fast, unindexed"].BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	exact := NewLevenshteinEngine()
+	for name, engine := range engines {
+		for _, b := range []Product{products[2], stranger} {
+			a := products[0]
+			if name == "fast, unindexed" && b.ID != stranger.ID {
+				continue // Both indexed
+			}
+			got, want := engine.Compare(a, b), exact.Compare(a, b)
+			want.stampThreshold(engine.GetDefaultThreshold())
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %s-%s = %+v, want %+v", name, a.ID, b.ID, got, want)
+			}
+		}
+	}
+}
+
+func TestHybridFastCompareWarmed(t *testing.T) {
+	products := fastCompareCatalog()
+	config := DefaultHybridConfig()
+	config.FastCompare = true
+	engine := NewHybridEngineWithConfig(config)
+	if err := engine.BuildIndex(products[:1]); err != nil {
+		t.Fatal(err)
+	}
+	queries := products[2:]
+	if _, err := engine.Warmup(context.Background(), queries, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := engine.Compare(queries[0], queries[1]).Stage; got != StageEstimatedReject {
+		t.Errorf("warmed unindexed pair: Stage = %q, want %q", got, StageEstimatedReject)
+	}
+}
@@ -0,0 +1,175 @@
+package duplicatecheck
+
+// FieldComparison holds the distance and similarity of a single product field
+type FieldComparison struct {
+	Distance   int     // Raw edit distance
+	Similarity float64 // Normalized similarity [0.0-1.0]
+}
+
+// FieldComparer is implemented by engines that can compare a single field
+// without touching (or even normalizing) the other one.
+// It is a separate interface so DuplicateCheckEngine stays small.
+type FieldComparer interface {
+	// CompareNames compares product names only
+	CompareNames(a, b Product) FieldComparison
+
+	// CompareDescriptions compares product descriptions only
+	CompareDescriptions(a, b Product) FieldComparison
+
+	// FindDuplicatesByName returns pairs whose name similarity meets the threshold
+	FindDuplicatesByName(products []Product, threshold float64) []ComparisonResult
+}
+
+// Ensure both engines implement FieldComparer
+var (
+	_ FieldComparer = (*LevenshteinEngine)(nil)
+	_ FieldComparer = (*HybridEngine)(nil)
+)
+
+// normalizedNameOnly returns the prepared name without preparing the description
+// Uses the cached value when the product has already been prepared by prep
+func (p *Product) normalizedNameOnly(prep *textPreparer) string {
+	if c := p.cachedValueFor(prep); c != nil {
+		return c.normalizedName
+	}
+	return prep.options.prepareText(p.Name)
+}
+
+// normalizedDescOnly returns the prepared description without preparing the name
+// Uses the cached value when the product has already been prepared by prep
+func (p *Product) normalizedDescOnly(prep *textPreparer) string {
+	if c := p.cachedValueFor(prep); c != nil {
+		return c.normalizedDesc
+	}
+	return prep.options.prepareDescription(p.Description)
+}
+
+// CompareNames computes Levenshtein distance and similarity between product names
+// The descriptions are never read, so this is much cheaper than
+// CompareWithWeights with ComparisonWeights{1, 0} on long-description products
+func (e *LevenshteinEngine) CompareNames(a, b Product) FieldComparison {
+	defer e.guard("CompareNames", nil)
+	nameA, nameB := a.normalizedNameOnly(e.preparer()), b.normalizedNameOnly(e.preparer())
+	distance := e.computeDistance(nameA, nameB)
+	return FieldComparison{
+		Distance:   distance,
+		Similarity: e.nameSimilarity(nameA, nameB, distance),
+	}
+}
+
+// CompareDescriptions computes Levenshtein distance and similarity between descriptions
+// The names are never read
+func (e *LevenshteinEngine) CompareDescriptions(a, b Product) FieldComparison {
+	defer e.guard("CompareDescriptions", nil)
+	return e.compareField(a.normalizedDescOnly(e.preparer()), b.normalizedDescOnly(e.preparer()))
+}
+
+// compareField computes distance and similarity for two normalized strings
+func (e *LevenshteinEngine) compareField(s, t string) FieldComparison {
+	distance := e.computeDistance(s, t)
+	return FieldComparison{
+		Distance:   distance,
+		Similarity: e.computeSimilarity(s, t, distance),
+	}
+}
+
+// FindDuplicatesByName finds all pairs whose name similarity meets the threshold
+// Descriptions are ignored. Returned results carry the name metrics in both the
+// Name* and Combined/legacy fields; Description* fields are zero.
+//
+// Because names are short, pairs whose length difference alone rules out the
+// threshold are skipped without running Levenshtein, and the DP stops early
+// once the distance can no longer reach the threshold.
+func (e *LevenshteinEngine) FindDuplicatesByName(products []Product, threshold float64) []ComparisonResult {
+	defer e.guard("FindDuplicatesByName", nil)
+	names := make([]string, len(products))
+	lengths := make([]int, len(products))
+	homoglyphs := make([]bool, len(products)) // The homoglyph table changed the name
+	table := e.preparer().options.Homoglyphs
+	for i := range products {
+		names[i] = products[i].normalizedNameOnly(e.preparer())
+		lengths[i] = e.textLength(names[i])
+		homoglyphs[i] = table != nil && table.Apply(products[i].Name) != products[i].Name
+	}
+
+	return scanPairs(len(products), e.scanWorkers(len(products), len(products) > 50), func(i, j int) (ComparisonResult, bool) {
+		distance, similarity, ok := e.nameWithin(names[i], names[j], lengths[i], lengths[j], threshold)
+		if !ok {
+			return ComparisonResult{}, false
+		}
+
+		result := ComparisonResult{
+			ProductA:           products[i],
+			ProductB:           products[j],
+			NameDistance:       distance,
+			NameSimilarity:     similarity,
+			CombinedSimilarity: similarity,
+			SimilarityMode:     e.options.SimilarityMode,
+			ThresholdUsed:      threshold,
+			MeetsThreshold:     true,
+
+			HomoglyphsNormalized: homoglyphs[i] || homoglyphs[j],
+		}
+		result.assignReasons()
+		return e.redactor.RedactResult(e.withLegacyFields(result)), true
+	})
+}
+
+// nameWithin scores two prepared names of lengths lenA and lenB (see
+// textLength), reporting whether their similarity reaches threshold
+// Pairs whose length difference alone rules out the threshold are rejected
+// without running Levenshtein, and the DP stops early once the distance can
+// no longer reach it; the distance and similarity of a rejected pair are 0.
+// FindDuplicatesByName and StringComparer.SimilarityWithin share it.
+func (e *LevenshteinEngine) nameWithin(nameA, nameB string, lenA, lenB int, threshold float64) (int, float64, bool) {
+	maxLen := lenA
+	if lenB > maxLen {
+		maxLen = lenB
+	}
+
+	// similarity >= threshold - ThresholdEpsilon  <=>
+	// distance <= (1 - threshold + ThresholdEpsilon) * maxLen
+	// (the last epsilon keeps 0.2*40 = 7.9999... from flooring to 7)
+	maxDistance := int((1-threshold+ThresholdEpsilon)*float64(maxLen) + 1e-9)
+	if !e.similarityBoundedByLinear() {
+		// The mode can score above linear similarity: no distance bound is safe
+		maxDistance = maxLen
+	}
+	lenDiff := lenA - lenB
+	if lenDiff < 0 {
+		lenDiff = -lenDiff
+	}
+	if maxLen > 0 && lenDiff > maxDistance {
+		return 0, 0, false
+	}
+
+	distance := e.computeDistanceWithThreshold(nameA, nameB, maxDistance)
+	if distance > maxDistance {
+		// Early exit: distance is only a lower bound, and already too large
+		return 0, 0, false
+	}
+	similarity := e.nameSimilarity(nameA, nameB, distance)
+	if !meetsThreshold(similarity, threshold) {
+		return 0, 0, false
+	}
+	return distance, similarity, true
+}
+
+// CompareNames compares product names only (delegates to Levenshtein)
+func (e *HybridEngine) CompareNames(a, b Product) FieldComparison {
+	defer e.levenshteinEngine.guard("CompareNames", nil)
+	return e.levenshteinEngine.CompareNames(a, b)
+}
+
+// CompareDescriptions compares product descriptions only (delegates to Levenshtein)
+func (e *HybridEngine) CompareDescriptions(a, b Product) FieldComparison {
+	defer e.levenshteinEngine.guard("CompareDescriptions", nil)
+	return e.levenshteinEngine.CompareDescriptions(a, b)
+}
+
+// FindDuplicatesByName finds pairs by name similarity only (delegates to Levenshtein)
+// LSH signatures cover name and description together, so the index isn't used
+func (e *HybridEngine) FindDuplicatesByName(products []Product, threshold float64) []ComparisonResult {
+	defer e.levenshteinEngine.guard("FindDuplicatesByName", nil)
+	return e.levenshteinEngine.FindDuplicatesByName(products, threshold)
+}
@@ -0,0 +1,135 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompareNamesAndDescriptions(t *testing.T) {
+	engine := NewLevenshteinEngine()
+	a := Product{ID: "1", Name: "Apple iPhone 14", Description: "128GB Blue"}
+	b := Product{ID: "2", Name: "apple iphone 13 ", Description: "256GB Blue"}
+
+	names := engine.CompareNames(a, b)
+	if names.Distance != 1 {
+		t.Errorf("Name distance = %d, want 1", names.Distance)
+	}
+	full := engine.Compare(a, b)
+	if names.Similarity != full.NameSimilarity {
+		t.Errorf("CompareNames similarity %.4f differs from Compare %.4f", names.Similarity, full.NameSimilarity)
+	}
+
+	descs := engine.CompareDescriptions(a, b)
+	if descs.Distance != 3 {
+		t.Errorf("Description distance = %d, want 3", descs.Distance)
+	}
+	if descs.Similarity != full.DescriptionSimilarity {
+		t.Errorf("CompareDescriptions similarity %.4f differs from Compare %.4f", descs.Similarity, full.DescriptionSimilarity)
+	}
+
+	t.Run("Other field is not normalized", func(t *testing.T) {
+		p := Product{Name: "Name", Description: "Description"}
+		q := Product{Name: "Name", Description: "Description"}
+		engine.CompareNames(p, q)
+		if p.cache.Load() != nil || q.cache.Load() != nil {
+			t.Error("CompareNames should not populate the normalization cache")
+		}
+	})
+
+	t.Run("Hybrid delegates", func(t *testing.T) {
+		hybrid := NewHybridEngine()
+		if hybrid.CompareNames(a, b) != names {
+			t.Error("Hybrid CompareNames should match Levenshtein")
+		}
+		if hybrid.CompareDescriptions(a, b) != descs {
+			t.Error("Hybrid CompareDescriptions should match Levenshtein")
+		}
+	})
+}
+
+func TestFindDuplicatesByName(t *testing.T) {
+	engine := NewLevenshteinEngine()
+
+	products := []Product{
+		{ID: "1", Name: "Apple iPhone 14 Pro", Description: "Completely different text A"},
+		{ID: "2", Name: "Apple iPhone 14 Pro", Description: "Nothing alike at all, B"},
+		{ID: "3", Name: "Apple iPhone 14 Pro Max", Description: "x"},
+		{ID: "4", Name: "Samsung Galaxy S22", Description: "Completely different text A"},
+	}
+
+	results := engine.FindDuplicatesByName(products, 0.9)
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 pair, got %d", len(results))
+	}
+	r := results[0]
+	if r.ProductA.ID != "1" || r.ProductB.ID != "2" || r.NameSimilarity != 1.0 || r.CombinedSimilarity != 1.0 {
+		t.Errorf("Unexpected result: %s/%s name=%.2f combined=%.2f",
+			r.ProductA.ID, r.ProductB.ID, r.NameSimilarity, r.CombinedSimilarity)
+	}
+
+	t.Run("Matches brute force on a larger catalog", func(t *testing.T) {
+		catalog := generateUserArticles(120)
+		for _, threshold := range []float64{0.6, 0.8, 0.95} {
+			want := 0
+			for i := range catalog {
+				for j := i + 1; j < len(catalog); j++ {
+					if engine.CompareNames(catalog[i], catalog[j]).Similarity >= threshold {
+						want++
+					}
+				}
+			}
+			if got := len(engine.FindDuplicatesByName(catalog, threshold)); got != want {
+				t.Errorf("Threshold %.2f: got %d pairs, want %d", threshold, got, want)
+			}
+		}
+	})
+}
+
+func TestDistanceThresholdEarlyExit(t *testing.T) {
+	engine := NewLevenshteinEngine()
+	pairs := [][2]string{
+		{"kitten", "sitting"},
+		{"apple iphone 14 pro", "samsung galaxy s22 ultra"},
+		{"abcdefghij", "jihgfedcba"},
+		{"same", "same"},
+	}
+	for _, pair := range pairs {
+		exact := engine.computeDistance(pair[0], pair[1])
+		for maxDistance := 0; maxDistance <= exact+2; maxDistance++ {
+			got := engine.computeDistanceWithThreshold(pair[0], pair[1], maxDistance)
+			if exact <= maxDistance && got != exact {
+				t.Errorf("%q/%q max=%d: got %d, want exact %d", pair[0], pair[1], maxDistance, got, exact)
+			}
+			if exact > maxDistance && got <= maxDistance {
+				t.Errorf("%q/%q max=%d: got %d, should exceed the bound", pair[0], pair[1], maxDistance, got)
+			}
+		}
+	}
+}
+
+// BenchmarkCompareNamesVsWeights compares CompareNames with the weight-1.0
+// workaround on products with long descriptions
+func BenchmarkCompareNamesVsWeights(b *testing.B) {
+	desc := strings.Repeat("Premium build quality with long battery life and fast charging. ", 40)
+	makePair := func(i int) (Product, Product) {
+		return Product{ID: "a", Name: fmt.Sprintf("Apple iPhone %d Pro", i%20), Description: desc},
+			Product{ID: "b", Name: fmt.Sprintf("Apple iPhone %d Pro", (i+1)%20), Description: desc + "!"}
+	}
+	engine := NewLevenshteinEngine()
+
+	b.Run("CompareWithWeights{1,0}", func(b *testing.B) {
+		weights := ComparisonWeights{NameWeight: 1, DescriptionWeight: 0}
+		for i := 0; i < b.N; i++ {
+			x, y := makePair(i)
+			engine.CompareWithWeights(x, y, weights)
+		}
+	})
+
+	b.Run("CompareNames", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x, y := makePair(i)
+			engine.CompareNames(x, y)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package duplicatecheck
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sync/atomic"
+)
+
+// Fingerprint returns a 64-bit hash of the product's comparable content
+// Computed over the normalized (lowercase, trimmed) name and description, so
+// products that differ only in case or surrounding whitespace share a fingerprint.
+// The ID is not part of the fingerprint.
+//
+// The hash is FNV-1a 64 over a length-prefixed encoding of each field, which is
+// fixed and safe to persist. It only changes if the normalization rules change,
+// which will be called out in the changelog.
+//
+// Cached on first call alongside the normalized strings (see productCache).
+func (p *Product) Fingerprint() uint64 {
+	c := p.loadCache()
+
+	// Fast path: already computed
+	if atomic.LoadUint32(&c.fingerprinted) == 1 {
+		return atomic.LoadUint64(&c.fingerprint)
+	}
+
+	// Slow path: computation is deterministic, so concurrent callers may race
+	// to compute it but always store the same value
+	fp := contentFingerprint(c.normalizedName, c.normalizedDesc)
+	atomic.StoreUint64(&c.fingerprint, fp)
+	atomic.StoreUint32(&c.fingerprinted, 1)
+	return fp
+}
+
+// ProductsEqualContent reports whether two products have the same comparable content
+// IDs are ignored; name and description are compared after normalization
+func ProductsEqualContent(a, b Product) bool {
+	if a.Fingerprint() != b.Fingerprint() {
+		return false
+	}
+	// Equal fingerprints: confirm to rule out hash collisions
+	nameA, descA := a.getNormalizedStrings()
+	nameB, descB := b.getNormalizedStrings()
+	return nameA == nameB && descA == descB
+}
+
+// contentFingerprint hashes normalized fields with FNV-1a 64
+// Each field is prefixed with its byte length so field boundaries can't shift
+func contentFingerprint(fields ...string) uint64 {
+	h := fnv.New64a()
+	var length [8]byte
+	for _, field := range fields {
+		binary.LittleEndian.PutUint64(length[:], uint64(len(field)))
+		h.Write(length[:])
+		h.Write([]byte(field))
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,66 @@
+package duplicatecheck
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestProductFingerprint(t *testing.T) {
+	t.Run("Invariant under case and surrounding whitespace", func(t *testing.T) {
+		a := Product{ID: "1", Name: "Apple iPhone 14", Description: "128GB Blue"}
+		b := Product{ID: "2", Name: "  APPLE IPHONE 14 ", Description: "128gb blue\n"}
+		if a.Fingerprint() != b.Fingerprint() {
+			t.Error("Fingerprints should match for case/whitespace-only differences")
+		}
+		if !ProductsEqualContent(a, b) {
+			t.Error("ProductsEqualContent should be true")
+		}
+	})
+
+	t.Run("Differs when content differs", func(t *testing.T) {
+		a := Product{ID: "1", Name: "Apple iPhone 14", Description: "128GB Blue"}
+		b := Product{ID: "1", Name: "Apple iPhone 14", Description: "256GB Blue"}
+		if a.Fingerprint() == b.Fingerprint() {
+			t.Error("Fingerprints should differ when the description differs")
+		}
+		if ProductsEqualContent(a, b) {
+			t.Error("ProductsEqualContent should be false")
+		}
+	})
+
+	t.Run("Field boundaries matter", func(t *testing.T) {
+		a := Product{Name: "ab", Description: "c"}
+		b := Product{Name: "a", Description: "bc"}
+		if a.Fingerprint() == b.Fingerprint() {
+			t.Error("Moving text between fields should change the fingerprint")
+		}
+	})
+
+	t.Run("Stable value", func(t *testing.T) {
+		// Fingerprints may be persisted, so the hash must not change silently
+		p := Product{Name: "Apple iPhone 14", Description: "128GB Blue"}
+		if got, want := p.Fingerprint(), contentFingerprint("apple iphone 14", "128gb blue"); got != want {
+			t.Errorf("Fingerprint() = %x, want %x", got, want)
+		}
+		if got := contentFingerprint(); got != 0xcbf29ce484222325 {
+			t.Errorf("Empty fingerprint should be the FNV-1a offset basis, got %x", got)
+		}
+	})
+
+	t.Run("Concurrent computation", func(t *testing.T) {
+		p := &Product{ID: "c", Name: "Samsung Galaxy S22", Description: "Android flagship"}
+		want := contentFingerprint("samsung galaxy s22", "android flagship")
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if got := p.Fingerprint(); got != want {
+					t.Errorf("Fingerprint() = %x, want %x", got, want)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,238 @@
+package duplicatecheck
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// GateAction is what a Gatekeeper recommends doing with a new product
+type GateAction int
+
+const (
+	// GateInsert means no existing product is similar enough to block the insert
+	GateInsert GateAction = iota
+	// GateReview means the product resembles an existing one and needs a human decision
+	GateReview
+	// GateReject means the product duplicates an existing one
+	GateReject
+)
+
+// String returns the action name
+func (a GateAction) String() string {
+	switch a {
+	case GateInsert:
+		return "insert"
+	case GateReview:
+		return "review"
+	case GateReject:
+		return "reject"
+	default:
+		return fmt.Sprintf("GateAction(%d)", int(a))
+	}
+}
+
+// GatePolicy maps the best match similarity to a GateAction with threshold bands
+//
+//	similarity >= RejectThreshold                    → GateReject
+//	ReviewThreshold <= similarity < RejectThreshold  → GateReview
+//	similarity < ReviewThreshold                     → GateInsert
+type GatePolicy struct {
+	RejectThreshold float64 // Default 0.95
+	ReviewThreshold float64 // Default 0.85
+
+	// NameRecheck re-scores Review-band matches on names alone (ComparisonWeights{1, 0})
+	// to settle borderline cases: a name similarity at or above RejectThreshold
+	// rejects, and the product is only inserted once every such match falls
+	// below ReviewThreshold - NameRecheckMargin. The margin is a hysteresis band
+	// that keeps products hovering around ReviewThreshold from flapping between
+	// review and insert.
+	NameRecheck       bool
+	NameRecheckMargin float64 // Default 0.05
+}
+
+// DefaultGatePolicy returns the default bands: reject at 0.95, review from 0.85
+func DefaultGatePolicy() GatePolicy {
+	return GatePolicy{
+		RejectThreshold:   0.95,
+		ReviewThreshold:   0.85,
+		NameRecheck:       false,
+		NameRecheckMargin: 0.05,
+	}
+}
+
+// Validate reports whether the policy's thresholds are usable
+// Thresholds must be in [0.0-1.0] with ReviewThreshold <= RejectThreshold, and
+// NameRecheckMargin must be in [0.0-1.0)
+func (p GatePolicy) Validate() error {
+	if err := validateThreshold(p.RejectThreshold); err != nil {
+		return fmt.Errorf("duplicatecheck: invalid RejectThreshold: %w", err)
+	}
+	if err := validateThreshold(p.ReviewThreshold); err != nil {
+		return fmt.Errorf("duplicatecheck: invalid ReviewThreshold: %w", err)
+	}
+	if p.ReviewThreshold > p.RejectThreshold {
+		return fmt.Errorf("duplicatecheck: ReviewThreshold %v is above RejectThreshold %v", p.ReviewThreshold, p.RejectThreshold)
+	}
+	if math.IsNaN(p.NameRecheckMargin) || p.NameRecheckMargin < 0 || p.NameRecheckMargin >= 1 {
+		return fmt.Errorf("duplicatecheck: NameRecheckMargin must be in [0, 1), got %v", p.NameRecheckMargin)
+	}
+	return nil
+}
+
+// action returns the band a similarity falls in, comparing within ThresholdEpsilon
+func (p GatePolicy) action(similarity float64) GateAction {
+	switch {
+	case meetsThreshold(similarity, p.RejectThreshold):
+		return GateReject
+	case meetsThreshold(similarity, p.ReviewThreshold):
+		return GateReview
+	default:
+		return GateInsert
+	}
+}
+
+// GateDecision is the outcome of Gatekeeper.Evaluate
+type GateDecision struct {
+	Action GateAction
+	// Matches holds every existing product at or above ReviewThreshold, most
+	// similar first, stamped with ReviewThreshold
+	Matches []ComparisonResult
+	// Policy is the policy the decision was made under
+	Policy GatePolicy
+	// Rechecked is true when NameRecheck changed the outcome of the Review band
+	Rechecked bool
+	// Compared is the number of existing products the new one was compared with
+	Compared int
+	// Partial is true when evaluation stopped early (see Evaluate)
+	Partial bool
+}
+
+// Gatekeeper packages the "check before insert" flow: compare a new product
+// with an existing catalog and decide to insert, reject, or send it to review.
+// Safe for concurrent use; Admit calls are serialized so two similar products
+// admitted at the same time can't both be inserted.
+type Gatekeeper struct {
+	engine   DuplicateCheckEngine
+	policy   GatePolicy
+	notifier *NotificationQueue // Receives review and reject decisions (see WithNotifier)
+	mu       sync.RWMutex
+	catalog  []Product
+}
+
+// NewGatekeeper creates a gatekeeper over an engine and the existing catalog
+// The catalog is copied. Returns an error if the policy is invalid.
+func NewGatekeeper(engine DuplicateCheckEngine, catalog []Product, policy GatePolicy) (*Gatekeeper, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	g := &Gatekeeper{
+		engine:  engine,
+		policy:  policy,
+		catalog: append([]Product(nil), catalog...),
+	}
+	// Concurrent Evaluate calls then only read the catalog's caches
+	warmCaches(productPtrs(g.catalog), preparerOf(engine))
+	return g, nil
+}
+
+// GetPolicy returns the gatekeeper's policy
+func (g *Gatekeeper) GetPolicy() GatePolicy {
+	return g.policy
+}
+
+// Len returns the number of products in the catalog
+func (g *Gatekeeper) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.catalog)
+}
+
+// Evaluate decides what to do with product without changing the catalog
+//
+// If ctx is cancelled mid-evaluation, Evaluate returns ctx.Err() together with
+// a decision over the products compared so far, marked Partial. A partial
+// GateReject is final (more matches can't lower it); a partial GateInsert or
+// GateReview is not and should not be acted on.
+func (g *Gatekeeper) Evaluate(ctx context.Context, product Product) (GateDecision, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.evaluate(ctx, &product)
+}
+
+// Admit evaluates product and appends it to the catalog when the decision is
+// GateInsert. Partial decisions never insert.
+func (g *Gatekeeper) Admit(ctx context.Context, product Product) (GateDecision, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	decision, err := g.evaluate(ctx, &product)
+	if err == nil && decision.Action == GateInsert {
+		g.catalog = append(g.catalog, copyProductFields(&product))
+		g.catalog[len(g.catalog)-1].loadCacheFor(preparerOf(g.engine))
+	}
+	return decision, err
+}
+
+// evaluate compares product with the catalog; the caller holds g.mu
+func (g *Gatekeeper) evaluate(ctx context.Context, product *Product) (GateDecision, error) {
+	decision := GateDecision{Policy: g.policy}
+	pointer, hasPointer := g.engine.(PointerEngine)
+
+	var err error
+	for i := range g.catalog {
+		if err = ctx.Err(); err != nil {
+			decision.Partial = true
+			break
+		}
+
+		var result ComparisonResult
+		if hasPointer {
+			result = pointer.ComparePtr(&g.catalog[i], product)
+		} else {
+			result = g.engine.Compare(g.catalog[i], *product)
+		}
+		decision.Compared++
+
+		result.stampThreshold(g.policy.ReviewThreshold)
+		if result.MeetsThreshold {
+			decision.Matches = append(decision.Matches, result)
+		}
+	}
+	SortByRelevance(decision.Matches)
+
+	if len(decision.Matches) > 0 {
+		decision.Action = g.policy.action(decision.Matches[0].CombinedSimilarity)
+	}
+	if decision.Action == GateReview && g.policy.NameRecheck && !decision.Partial {
+		g.recheckNames(&decision, product)
+	}
+	if !decision.Partial || decision.Action == GateReject {
+		g.notify(&decision)
+	}
+	return decision, err
+}
+
+// recheckNames settles a Review decision by re-scoring its matches on names alone
+func (g *Gatekeeper) recheckNames(decision *GateDecision, product *Product) {
+	nameOnly := ComparisonWeights{NameWeight: 1, DescriptionWeight: 0}
+	insertBelow := g.policy.ReviewThreshold - g.policy.NameRecheckMargin
+
+	best := 0.0
+	for _, match := range decision.Matches {
+		similarity := g.engine.CompareWithWeights(match.ProductA, *product, nameOnly).CombinedSimilarity
+		if similarity > best {
+			best = similarity
+		}
+	}
+
+	switch {
+	case best >= g.policy.RejectThreshold:
+		decision.Action = GateReject
+		decision.Rechecked = true
+	case best < insertBelow:
+		decision.Action = GateInsert
+		decision.Rechecked = true
+	}
+}
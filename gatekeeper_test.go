@@ -0,0 +1,228 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func gatekeeperCatalog() []Product {
+	return []Product{
+		{ID: "1", Name: "Apple iPhone 14 Pro 128GB", Description: "Smartphone with A16 Bionic chip and ProMotion display"},
+		{ID: "2", Name: "Sony WH-1000XM5 Headphones", Description: "Wireless noise cancelling over-ear headphones"},
+		{ID: "3", Name: "Dell XPS 13 Laptop", Description: "Compact laptop with InfinityEdge display"},
+	}
+}
+
+func TestGatekeeperBands(t *testing.T) {
+	gate, err := NewGatekeeper(NewLevenshteinEngine(), gatekeeperCatalog(), DefaultGatePolicy())
+	if err != nil {
+		t.Fatalf("NewGatekeeper failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		product Product
+		want    GateAction
+	}{
+		{
+			name:    "Exact copy is rejected",
+			product: Product{ID: "new", Name: "Apple iPhone 14 Pro 128GB", Description: "Smartphone with A16 Bionic chip and ProMotion display"},
+			want:    GateReject,
+		},
+		{
+			name:    "Close variant goes to review",
+			product: Product{ID: "new", Name: "Apple iPhone 14 Pro 256GB", Description: "Smartphone with A16 Bionic chip and Super Retina display"},
+			want:    GateReview,
+		},
+		{
+			name:    "Unrelated product is inserted",
+			product: Product{ID: "new", Name: "Nike Air Max 90", Description: "Running shoes with visible air cushioning"},
+			want:    GateInsert,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := gate.Evaluate(context.Background(), tt.product)
+			if err != nil {
+				t.Fatalf("Evaluate failed: %v", err)
+			}
+			if decision.Action != tt.want {
+				best := 0.0
+				if len(decision.Matches) > 0 {
+					best = decision.Matches[0].CombinedSimilarity
+				}
+				t.Errorf("Action = %s (best %.4f), want %s", decision.Action, best, tt.want)
+			}
+			if decision.Compared != 3 || decision.Partial {
+				t.Errorf("Expected a complete evaluation over 3 products, got %d (partial=%v)", decision.Compared, decision.Partial)
+			}
+			for i, match := range decision.Matches {
+				if !match.MeetsThreshold || match.ThresholdUsed != decision.Policy.ReviewThreshold {
+					t.Errorf("Match %d should be stamped with ReviewThreshold", i)
+				}
+				if i > 0 && match.CombinedSimilarity > decision.Matches[i-1].CombinedSimilarity {
+					t.Error("Matches should be sorted most similar first")
+				}
+			}
+		})
+	}
+
+	if gate.Len() != 3 {
+		t.Error("Evaluate should not change the catalog")
+	}
+}
+
+func TestGatekeeperAdmit(t *testing.T) {
+	gate, err := NewGatekeeper(NewHybridEngine(), gatekeeperCatalog(), DefaultGatePolicy())
+	if err != nil {
+		t.Fatalf("NewGatekeeper failed: %v", err)
+	}
+
+	shoes := Product{ID: "4", Name: "Nike Air Max 90", Description: "Running shoes with visible air cushioning"}
+	if decision, err := gate.Admit(context.Background(), shoes); err != nil || decision.Action != GateInsert {
+		t.Fatalf("Expected insert, got %s (%v)", decision.Action, err)
+	}
+	if gate.Len() != 4 {
+		t.Fatalf("Admit should insert, catalog has %d products", gate.Len())
+	}
+
+	shoes.ID = "5"
+	decision, err := gate.Admit(context.Background(), shoes)
+	if err != nil || decision.Action != GateReject || decision.Matches[0].ProductA.ID != "4" {
+		t.Errorf("Second copy should be rejected against the admitted one, got %s (%v)", decision.Action, err)
+	}
+	if gate.Len() != 4 {
+		t.Error("Rejected products must not be inserted")
+	}
+}
+
+func TestGatekeeperNameRecheck(t *testing.T) {
+	catalog := []Product{{ID: "1", Name: "Samsung Galaxy S23 Ultra", Description: "Phantom black, 256GB storage, 12GB RAM"}}
+	// Equal weights make the description able to lift a pair into the review band
+	engine := NewLevenshteinEngineWithWeights(ComparisonWeights{NameWeight: 0.5, DescriptionWeight: 0.5})
+	policy := DefaultGatePolicy()
+	policy.NameRecheck = true
+	gate, err := NewGatekeeper(engine, catalog, policy)
+	if err != nil {
+		t.Fatalf("NewGatekeeper failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		product   Product
+		want      GateAction
+		rechecked bool
+	}{
+		{
+			name:      "Same name rejects",
+			product:   Product{ID: "2", Name: "Samsung Galaxy S23 Ultra", Description: "Phantom black, 512GB storage, 8GB RAM!!"},
+			want:      GateReject,
+			rechecked: true,
+		},
+		{
+			name:      "Different name inserts",
+			product:   Product{ID: "2", Name: "Samsung Galaxy S22 Plus", Description: "Phantom black, 256GB storage, 12GB RAM"},
+			want:      GateInsert,
+			rechecked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Without the recheck both land in the review band
+			plain, _ := NewGatekeeper(engine, catalog, DefaultGatePolicy())
+			if decision, _ := plain.Evaluate(context.Background(), tt.product); decision.Action != GateReview {
+				t.Fatalf("Test product should be in the review band, got %s", decision.Action)
+			}
+
+			decision, err := gate.Evaluate(context.Background(), tt.product)
+			if err != nil {
+				t.Fatalf("Evaluate failed: %v", err)
+			}
+			if decision.Action != tt.want || decision.Rechecked != tt.rechecked {
+				t.Errorf("Got %s (rechecked=%v), want %s (rechecked=%v)", decision.Action, decision.Rechecked, tt.want, tt.rechecked)
+			}
+		})
+	}
+}
+
+func TestGatePolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(p *GatePolicy)
+		wantErr bool
+	}{
+		{"Default", func(p *GatePolicy) {}, false},
+		{"Single band", func(p *GatePolicy) { p.ReviewThreshold = p.RejectThreshold }, false},
+		{"Review above reject", func(p *GatePolicy) { p.ReviewThreshold = 0.97 }, true},
+		{"Reject above 1", func(p *GatePolicy) { p.RejectThreshold = 1.2 }, true},
+		{"Negative review", func(p *GatePolicy) { p.ReviewThreshold = -0.1 }, true},
+		{"NaN", func(p *GatePolicy) { p.RejectThreshold = math.NaN() }, true},
+		{"Margin of 1", func(p *GatePolicy) { p.NameRecheckMargin = 1 }, true},
+		{"Negative margin", func(p *GatePolicy) { p.NameRecheckMargin = -0.01 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := DefaultGatePolicy()
+			tt.modify(&policy)
+			if err := policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, err := NewGatekeeper(NewLevenshteinEngine(), nil, policy); (err != nil) != tt.wantErr {
+				t.Errorf("NewGatekeeper error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// cancellingEngine cancels a context after a number of comparisons
+type cancellingEngine struct {
+	*LevenshteinEngine
+	cancel context.CancelFunc
+	after  int
+	calls  int
+}
+
+func (e *cancellingEngine) ComparePtr(a, b *Product) ComparisonResult {
+	e.calls++
+	if e.calls == e.after {
+		e.cancel()
+	}
+	return e.LevenshteinEngine.ComparePtr(a, b)
+}
+
+func TestGatekeeperCancellation(t *testing.T) {
+	catalog := append(gatekeeperCatalog(), Product{ID: "4", Name: "Nike Air Max 90", Description: "Running shoes"})
+	copyOfFirst := Product{ID: "new", Name: catalog[0].Name, Description: catalog[0].Description}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	engine := &cancellingEngine{LevenshteinEngine: NewLevenshteinEngine(), cancel: cancel, after: 2}
+	gate, err := NewGatekeeper(engine, catalog, DefaultGatePolicy())
+	if err != nil {
+		t.Fatalf("NewGatekeeper failed: %v", err)
+	}
+
+	decision, err := gate.Admit(ctx, copyOfFirst)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if !decision.Partial || decision.Compared != 2 {
+		t.Errorf("Expected a partial decision over 2 products, got %d (partial=%v)", decision.Compared, decision.Partial)
+	}
+	// The exact copy was compared before cancellation, so the partial reject is usable
+	if decision.Action != GateReject {
+		t.Errorf("Partial decision should already reject, got %s", decision.Action)
+	}
+	if gate.Len() != len(catalog) {
+		t.Error("A cancelled Admit must not insert")
+	}
+
+	unrelated := Product{ID: "new", Name: "Standing Desk", Description: "Electric height adjustable desk"}
+	if decision, err := gate.Admit(ctx, unrelated); err == nil || decision.Action != GateInsert || gate.Len() != len(catalog) {
+		t.Error("An already cancelled context must not insert")
+	}
+}
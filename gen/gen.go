@@ -0,0 +1,194 @@
+// Package gen generates reproducible synthetic product catalogs for
+// evaluating and benchmarking duplicate detection
+//
+// It is the generator behind the library's own tests and benchmarks, so
+// numbers measured on a GenConfig are comparable with the project's claims
+// and with other users' measurements on the same config:
+//
+//	cfg := gen.DefaultGenConfig()
+//	cfg.Size, cfg.DuplicateRate = 10000, 0.05
+//	products, truth := gen.GenerateCatalogWithTruth(cfg)
+//
+// Products belong to a few categories (electronics, kitchen appliances,
+// outdoor gear) and carry specs such as storage, color, screen size, power
+// and volume. Duplicates are copies of another product with Mutations applied.
+// The mutators are exported for fuzzing other pipelines.
+package gen
+
+import (
+	"math/rand"
+
+	"github.com/solrac97gr/duplicatecheck"
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+// Mutation is one way of turning a product into a near-duplicate of itself
+type Mutation = synth.Mutation
+
+// Mutations applied to duplicates
+const (
+	MutateCase        = synth.MutateCase        // Change the case of the name
+	MutateReorder     = synth.MutateReorder     // Move one name token elsewhere in the name
+	MutateTypo        = synth.MutateTypo        // Insert, delete, substitute or transpose one letter of the name
+	MutateSpecSwap    = synth.MutateSpecSwap    // Replace one spec in name and description (no-op without one)
+	MutateBoilerplate = synth.MutateBoilerplate // Append a seller boilerplate sentence to the description
+)
+
+// AllMutations returns every Mutation
+func AllMutations() []Mutation {
+	return append([]Mutation(nil), synth.AllMutations...)
+}
+
+// GenConfig controls catalog and pair generation
+// Zero fields take the DefaultGenConfig value, except Seed and Size.
+type GenConfig struct {
+	Seed int64 // Same seed and config, same output
+	Size int   // Products in a catalog, pairs in GenerateLabeledPairs
+
+	// Name length in words, drawn uniformly (default 4-8). Brand, type, model
+	// and specs come first, then filler words; longer names are cut.
+	MinNameWords, MaxNameWords int
+	// Description length in words, drawn uniformly (default 20-60)
+	MinDescriptionWords, MaxDescriptionWords int
+
+	// DuplicateRate is the fraction of catalog products that are mutated
+	// copies of another product, or of labeled pairs that are duplicates
+	// (default 0.1; counts are rounded to the nearest integer)
+	DuplicateRate float64
+	// Mutations are drawn from, uniformly, for each duplicate (default all)
+	Mutations []Mutation
+	// MutationsPerDuplicate is the number of mutations applied (default 1)
+	MutationsPerDuplicate int
+	// IDPrefix starts every product ID (default "P": "P0001", "P0002", ...)
+	IDPrefix string
+}
+
+// DefaultGenConfig returns 1,000 products, 10% of them duplicates with one
+// mutation of any kind, seed 1
+func DefaultGenConfig() GenConfig {
+	return fromSynth(synth.Default())
+}
+
+// GenerateCatalog returns cfg.Size products; DuplicateRate of them are
+// mutated copies of another product, at random positions
+func GenerateCatalog(cfg GenConfig) []duplicatecheck.Product {
+	products, _ := GenerateCatalogWithTruth(cfg)
+	return products
+}
+
+// GenerateCatalogWithTruth is GenerateCatalog, also returning the duplicate
+// pairs: every pair of products copied from the same original, keyed by
+// duplicatecheck.PairKey like CanonicalizeResults output
+func GenerateCatalogWithTruth(cfg GenConfig) ([]duplicatecheck.Product, map[string]bool) {
+	catalog := synth.Generate(cfg.toSynth())
+	products := make([]duplicatecheck.Product, len(catalog.Records))
+	for i, r := range catalog.Records {
+		products[i] = product(r)
+	}
+	truth := make(map[string]bool)
+	for _, pair := range catalog.DuplicatePairs() {
+		truth[duplicatecheck.PairKey(products[pair[0]].ID, products[pair[1]].ID)] = true
+	}
+	return products, truth
+}
+
+// LabeledPair is a pair of products with its ground-truth label
+type LabeledPair struct {
+	A, B      duplicatecheck.Product
+	Duplicate bool
+	Mutations []Mutation // Mutations that turned A into B (duplicates only)
+}
+
+// GenerateLabeledPairs returns cfg.Size pairs, DuplicateRate of them labeled
+// duplicates (a product and a mutated copy) and the rest hard negatives: two
+// different products of the same category
+// Score each pair with Compare and compare the label to pick a threshold.
+func GenerateLabeledPairs(cfg GenConfig) []LabeledPair {
+	generated := synth.GeneratePairs(cfg.toSynth())
+	pairs := make([]LabeledPair, len(generated))
+	for i, p := range generated {
+		pairs[i] = LabeledPair{A: product(p.A), B: product(p.B), Duplicate: p.Duplicate, Mutations: p.Mutations}
+	}
+	return pairs
+}
+
+// Mutate returns a copy of p with n mutations drawn from allowed (every
+// mutation when empty), and the mutations applied
+func Mutate(rng *rand.Rand, p duplicatecheck.Product, allowed []Mutation, n int) (duplicatecheck.Product, []Mutation) {
+	if len(allowed) == 0 {
+		allowed = synth.AllMutations
+	}
+	r, applied := synth.Mutate(rng, record(p), allowed, n)
+	return product(r), applied
+}
+
+// ChangeCase returns s lowercased, uppercased, or with every word's first
+// letter changed, whichever differs from s
+func ChangeCase(rng *rand.Rand, s string) string {
+	return synth.ChangeCase(rng, s)
+}
+
+// ReorderTokens moves one space-separated token of s to another position
+func ReorderTokens(rng *rand.Rand, s string) string {
+	return synth.ReorderTokens(rng, s)
+}
+
+// InjectTypos applies n random letter edits to s: substitutions, insertions,
+// deletions, or transpositions of adjacent letters
+// Digits and punctuation are never edited, so model numbers and specs survive.
+func InjectTypos(rng *rand.Rand, s string, n int) string {
+	return synth.InjectTypos(rng, s, n)
+}
+
+// SwapSpecs replaces one spec token of s (storage like "256GB", a color,
+// screen size, power or volume) with another value of the same kind
+// Returns s unchanged when it has no spec token.
+func SwapSpecs(rng *rand.Rand, s string) string {
+	return synth.SwapSpecs(rng, s)
+}
+
+// AppendBoilerplate appends a seller boilerplate sentence to s
+// ("Free shipping on orders over $50.")
+func AppendBoilerplate(rng *rand.Rand, s string) string {
+	return synth.AppendBoilerplate(rng, s)
+}
+
+// toSynth converts the public config
+func (c GenConfig) toSynth() synth.Config {
+	return synth.Config{
+		Seed:                  c.Seed,
+		Size:                  c.Size,
+		MinNameWords:          c.MinNameWords,
+		MaxNameWords:          c.MaxNameWords,
+		MinDescriptionWords:   c.MinDescriptionWords,
+		MaxDescriptionWords:   c.MaxDescriptionWords,
+		DuplicateRate:         c.DuplicateRate,
+		Mutations:             c.Mutations,
+		MutationsPerDuplicate: c.MutationsPerDuplicate,
+		IDPrefix:              c.IDPrefix,
+	}
+}
+
+// fromSynth converts an internal config
+func fromSynth(c synth.Config) GenConfig {
+	return GenConfig{
+		Seed:                  c.Seed,
+		Size:                  c.Size,
+		MinNameWords:          c.MinNameWords,
+		MaxNameWords:          c.MaxNameWords,
+		MinDescriptionWords:   c.MinDescriptionWords,
+		MaxDescriptionWords:   c.MaxDescriptionWords,
+		DuplicateRate:         c.DuplicateRate,
+		Mutations:             append([]Mutation(nil), c.Mutations...),
+		MutationsPerDuplicate: c.MutationsPerDuplicate,
+		IDPrefix:              c.IDPrefix,
+	}
+}
+
+func product(r synth.Record) duplicatecheck.Product {
+	return duplicatecheck.Product{ID: r.ID, Name: r.Name, Description: r.Description}
+}
+
+func record(p duplicatecheck.Product) synth.Record {
+	return synth.Record{ID: p.ID, Name: p.Name, Description: p.Description}
+}
@@ -0,0 +1,87 @@
+package gen
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+func TestGenerateCatalogWithTruth(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		rate       float64
+		wantFamily int // Products with at least one duplicate pair
+	}{
+		{"Default rate", 500, 0.1, 50},
+		{"No duplicates", 100, 0, 0},
+		{"Rounded count", 30, 0.05, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultGenConfig()
+			cfg.Size, cfg.DuplicateRate = tt.size, tt.rate
+			products, truth := GenerateCatalogWithTruth(cfg)
+			if len(products) != tt.size {
+				t.Fatalf("Generated %d products, want %d", len(products), tt.size)
+			}
+			// Every duplicate adds at least one true pair
+			if len(truth) < tt.wantFamily || tt.wantFamily == 0 && len(truth) != 0 {
+				t.Errorf("%d true pairs for %d duplicates", len(truth), tt.wantFamily)
+			}
+			ids := make(map[string]bool)
+			for _, p := range products {
+				ids[p.ID] = true
+			}
+			for key := range truth {
+				if key != duplicatecheck.PairKey(key[:5], key[6:]) || !ids[key[:5]] || !ids[key[6:]] {
+					t.Errorf("Truth key %q does not name two products", key)
+				}
+			}
+		})
+	}
+}
+
+func TestDeterminism(t *testing.T) {
+	cfg := DefaultGenConfig()
+	cfg.Size = 200
+
+	if !reflect.DeepEqual(GenerateCatalog(cfg), GenerateCatalog(cfg)) {
+		t.Error("GenerateCatalog() should be deterministic per GenConfig")
+	}
+	if !reflect.DeepEqual(GenerateLabeledPairs(cfg), GenerateLabeledPairs(cfg)) {
+		t.Error("GenerateLabeledPairs() should be deterministic per GenConfig")
+	}
+	other := cfg
+	other.Seed = 2
+	if reflect.DeepEqual(GenerateCatalog(cfg), GenerateCatalog(other)) {
+		t.Error("Different seeds should generate different catalogs")
+	}
+}
+
+func TestGenerateLabeledPairs(t *testing.T) {
+	cfg := DefaultGenConfig()
+	cfg.Size, cfg.DuplicateRate = 400, 0.2
+	cfg.Mutations = []Mutation{MutateTypo, MutateCase}
+	cfg.MutationsPerDuplicate = 2
+
+	duplicates := 0
+	for _, p := range GenerateLabeledPairs(cfg) {
+		if !p.Duplicate {
+			continue
+		}
+		duplicates++
+		for _, m := range p.Mutations {
+			if m != MutateTypo && m != MutateCase {
+				t.Errorf("Pair %s has disallowed mutation %s", p.A.ID, m)
+			}
+		}
+		if len(p.Mutations) != 2 {
+			t.Errorf("Pair %s has %d mutations, want 2", p.A.ID, len(p.Mutations))
+		}
+	}
+	if duplicates != 80 {
+		t.Errorf("%d duplicate pairs, want 80", duplicates)
+	}
+}
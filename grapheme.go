@@ -0,0 +1,232 @@
+package duplicatecheck
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// graphemeClass is the subset of UAX #29 Grapheme_Cluster_Break properties
+// needed to segment product text
+type graphemeClass uint8
+
+const (
+	gcOther graphemeClass = iota
+	gcCR
+	gcLF
+	gcControl
+	gcExtend
+	gcZWJ
+	gcSpacingMark
+	gcRegionalIndicator
+	gcPictographic
+	gcHangulL
+	gcHangulV
+	gcHangulT
+	gcHangulLV
+	gcHangulLVT
+)
+
+// graphemeUnitBase is the first value used for multi-rune clusters when
+// clusters are interned as runes; it lies above unicode.MaxRune so it can
+// never collide with a single-rune cluster
+const graphemeUnitBase = unicode.MaxRune + 1
+
+// classifyGraphemeRune returns the grapheme break class of r
+//
+// This is a minimal approximation of the Unicode property tables: it covers
+// combining marks, emoji modifiers and ZWJ sequences, variation selectors,
+// tag sequences, regional indicators and Hangul jamo. Prepend characters are
+// treated as Other.
+func classifyGraphemeRune(r rune) graphemeClass {
+	switch {
+	case r == '\r':
+		return gcCR
+	case r == '\n':
+		return gcLF
+	case r == 0x200D:
+		return gcZWJ
+	case r == 0x200C,
+		r >= 0xFE00 && r <= 0xFE0F,   // Variation selectors
+		r >= 0xE0100 && r <= 0xE01EF, // Variation selectors supplement
+		r >= 0xE0020 && r <= 0xE007F, // Tags (subdivision flags)
+		r >= 0x1F3FB && r <= 0x1F3FF: // Emoji skin tone modifiers
+		return gcExtend
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		return gcRegionalIndicator
+	case isExtendedPictographic(r):
+		return gcPictographic
+	case r >= 0x1100 && r <= 0x115F, r >= 0xA960 && r <= 0xA97C:
+		return gcHangulL
+	case r >= 0x1160 && r <= 0x11A7, r >= 0xD7B0 && r <= 0xD7C6:
+		return gcHangulV
+	case r >= 0x11A8 && r <= 0x11FF, r >= 0xD7CB && r <= 0xD7FB:
+		return gcHangulT
+	case r >= 0xAC00 && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return gcHangulLV
+		}
+		return gcHangulLVT
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return gcExtend
+	case unicode.Is(unicode.Mc, r):
+		return gcSpacingMark
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp):
+		return gcControl
+	}
+	return gcOther
+}
+
+// isExtendedPictographic approximates the Extended_Pictographic property
+// with the blocks that hold emoji
+func isExtendedPictographic(r rune) bool {
+	switch {
+	case r == 0x00A9, r == 0x00AE, r == 0x203C, r == 0x2049, r == 0x2122, r == 0x2139,
+		r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	case r >= 0x2194 && r <= 0x21AA,
+		r >= 0x2300 && r <= 0x23FF,
+		r >= 0x25A0 && r <= 0x27BF,
+		r >= 0x2B00 && r <= 0x2BFF,
+		r >= 0x1F000 && r <= 0x1FAFF,
+		r >= 0x1FC00 && r <= 0x1FFFD:
+		return true
+	}
+	return false
+}
+
+// isHangulJoin implements rules GB6-GB8 (Hangul syllable sequences)
+func isHangulJoin(prev, curr graphemeClass) bool {
+	switch prev {
+	case gcHangulL:
+		return curr == gcHangulL || curr == gcHangulV || curr == gcHangulLV || curr == gcHangulLVT
+	case gcHangulLV, gcHangulV:
+		return curr == gcHangulV || curr == gcHangulT
+	case gcHangulLVT, gcHangulT:
+		return curr == gcHangulT
+	}
+	return false
+}
+
+// graphemeClusters splits s into extended grapheme clusters following the
+// core rules of UAX #29 (GB3-GB13)
+//
+// Examples of single clusters:
+//
+//	"é" written as "e" + U+0301          (combining mark)
+//	"👍🏽"                                 (emoji + skin tone modifier)
+//	"👨‍👩‍👧‍👦"                                 (ZWJ sequence)
+//	"🇪🇸"                                 (regional indicator pair)
+func graphemeClusters(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	clusters := make([]string, 0, len(s))
+	start := 0
+	prevClass := gcOther
+	emojiSeq := false // inside ExtPict Extend* (ZWJ)? - rule GB11
+	riCount := 0      // regional indicators since the last non-RI - rules GB12/13
+
+	for i, r := range s {
+		class := classifyGraphemeRune(r)
+
+		if i > 0 && graphemeBreak(prevClass, class, emojiSeq, riCount) {
+			clusters = append(clusters, s[start:i])
+			start = i
+		}
+
+		switch class {
+		case gcPictographic:
+			emojiSeq = true
+		case gcExtend, gcZWJ:
+			// Extend and ZWJ keep an emoji sequence open
+		default:
+			emojiSeq = false
+		}
+		if class == gcRegionalIndicator {
+			riCount++
+		} else {
+			riCount = 0
+		}
+		prevClass = class
+	}
+
+	return append(clusters, s[start:])
+}
+
+// graphemeBreak reports whether there is a cluster boundary between a rune
+// of class prev and one of class curr
+func graphemeBreak(prev, curr graphemeClass, emojiSeq bool, riCount int) bool {
+	switch {
+	case prev == gcCR && curr == gcLF: // GB3
+		return false
+	case prev == gcCR, prev == gcLF, prev == gcControl: // GB4
+		return true
+	case curr == gcCR, curr == gcLF, curr == gcControl: // GB5
+		return true
+	case isHangulJoin(prev, curr): // GB6-GB8
+		return false
+	case curr == gcExtend, curr == gcZWJ, curr == gcSpacingMark: // GB9, GB9a
+		return false
+	case prev == gcZWJ && curr == gcPictographic && emojiSeq: // GB11
+		return false
+	case prev == gcRegionalIndicator && curr == gcRegionalIndicator: // GB12, GB13
+		return riCount%2 == 0
+	}
+	return true // GB999
+}
+
+// isSingleUnitASCII reports whether every byte of s is its own grapheme
+// cluster, which holds for ASCII text without CR LF pairs
+func isSingleUnitASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf || s[i] == '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// graphemeUnits converts s and t into comparable unit slices, one element per
+// grapheme cluster. Single-rune clusters keep their rune value; multi-rune
+// clusters are interned above unicode.MaxRune so equal clusters map to equal
+// units across both strings and the rune-based DP can run unchanged.
+func graphemeUnits(s, t string) ([]rune, []rune) {
+	// ASCII fast path: clusters and runes coincide
+	if isSingleUnitASCII(s) && isSingleUnitASCII(t) {
+		return []rune(s), []rune(t)
+	}
+
+	var interned map[string]rune
+	toUnits := func(text string) []rune {
+		clusters := graphemeClusters(text)
+		units := make([]rune, len(clusters))
+		for i, cluster := range clusters {
+			r, size := utf8.DecodeRuneInString(cluster)
+			if size == len(cluster) {
+				units[i] = r
+				continue
+			}
+			if interned == nil {
+				interned = make(map[string]rune)
+			}
+			id, ok := interned[cluster]
+			if !ok {
+				id = graphemeUnitBase + rune(len(interned))
+				interned[cluster] = id
+			}
+			units[i] = id
+		}
+		return units
+	}
+
+	return toUnits(s), toUnits(t)
+}
+
+// graphemeCount returns the number of grapheme clusters in s
+func graphemeCount(s string) int {
+	if isSingleUnitASCII(s) {
+		return len(s)
+	}
+	return len(graphemeClusters(s))
+}
@@ -0,0 +1,119 @@
+package duplicatecheck
+
+import (
+	"testing"
+)
+
+func TestGraphemeClusters(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{"Empty", "", 0},
+		{"ASCII", "iphone", 6},
+		{"CR LF is one cluster", "a\r\nb", 3},
+		{"Combining accent", "café", 4},
+		{"Skin tone modifier", "👍🏽", 1},
+		{"Every skin tone is one unit", "👍🏻👍🏼👍🏽👍🏾👍🏿", 5},
+		{"ZWJ family", "👨‍👩‍👧‍👦", 1},
+		{"ZWJ with skin tones", "👩🏽‍💻", 1},
+		{"Variation selector", "❤️", 1},
+		{"Keycap", "1️⃣", 1},
+		{"Flag pair", "🇪🇸", 1},
+		{"Consecutive flags", "🇪🇸🇫🇷🇩🇪", 3},
+		{"Odd regional indicator", "🇪🇸🇫", 2},
+		{"Subdivision flag", "🏴\U000E0067\U000E0062\U000E0065\U000E006E\U000E0067\U000E007F", 1},
+		{"Hangul jamo", "각", 1},
+		{"Mixed", "🔥 Deal 👨‍👩‍👧‍👦", 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := graphemeClusters(tt.input)
+			if len(got) != tt.want {
+				t.Errorf("graphemeClusters(%q) = %q (%d clusters), want %d", tt.input, got, len(got), tt.want)
+			}
+			if graphemeCount(tt.input) != tt.want {
+				t.Errorf("graphemeCount(%q) = %d, want %d", tt.input, graphemeCount(tt.input), tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphemeMode(t *testing.T) {
+	runeEngine := NewLevenshteinEngine()
+	graphemeEngine := NewLevenshteinEngineWithOptions(LevenshteinOptions{GraphemeMode: true})
+
+	// Rabin-Karp works on bytes and would reject the emoji-heavy names up front
+	runeEngine.DisableRabinKarpFilter()
+	graphemeEngine.DisableRabinKarpFilter()
+
+	if runeEngine.GetOptions().GraphemeMode {
+		t.Fatal("Grapheme mode should be off by default")
+	}
+
+	t.Run("ZWJ sequence counts as one edit", func(t *testing.T) {
+		a := Product{ID: "1", Name: "🔥 Deal 👨‍👩‍👧‍👦 Family Pack"}
+		b := Product{ID: "2", Name: "🔥 Deal Family Pack"}
+
+		graphemeSim := graphemeEngine.Compare(a, b).NameSimilarity
+		runeSim := runeEngine.Compare(a, b).NameSimilarity
+
+		if graphemeSim < 0.9 {
+			t.Errorf("Grapheme mode similarity = %.3f, want >= 0.9", graphemeSim)
+		}
+		if runeSim > graphemeSim-0.15 {
+			t.Errorf("Rune mode similarity %.3f should be much lower than grapheme mode %.3f", runeSim, graphemeSim)
+		}
+	})
+
+	t.Run("Skin tone change is a single substitution", func(t *testing.T) {
+		result := graphemeEngine.Compare(
+			Product{ID: "1", Name: "👍🏽 Thumbs Up Mug"},
+			Product{ID: "2", Name: "👍🏿 Thumbs Up Mug"},
+		)
+		if result.NameDistance != 1 {
+			t.Errorf("Expected distance 1, got %d", result.NameDistance)
+		}
+	})
+
+	t.Run("Decomposed accent is one unit", func(t *testing.T) {
+		distance := graphemeEngine.computeDistance("café", "cafe")
+		if distance != 1 {
+			t.Errorf("Expected distance 1, got %d", distance)
+		}
+	})
+
+	t.Run("ASCII results match rune mode", func(t *testing.T) {
+		pairs := [][2]string{{"kitten", "sitting"}, {"iphone 14 pro", "iphone 14 pro max"}, {"", "abc"}}
+		for _, pair := range pairs {
+			want := runeEngine.computeDistance(pair[0], pair[1])
+			got := graphemeEngine.computeDistance(pair[0], pair[1])
+			if got != want {
+				t.Errorf("%q vs %q: grapheme distance %d, rune distance %d", pair[0], pair[1], got, want)
+			}
+		}
+	})
+}
+
+func BenchmarkGraphemeModeASCII(b *testing.B) {
+	s := "lightweight running shoe with breathable mesh upper"
+	u := "lightweight running shoes with breathable mesh uppers"
+
+	b.Run("Runes", func(b *testing.B) {
+		engine := NewLevenshteinEngine()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			engine.computeSimilarity(s, u, engine.computeDistance(s, u))
+		}
+	})
+
+	b.Run("Graphemes", func(b *testing.B) {
+		engine := NewLevenshteinEngineWithOptions(LevenshteinOptions{GraphemeMode: true})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			engine.computeSimilarity(s, u, engine.computeDistance(s, u))
+		}
+	})
+}
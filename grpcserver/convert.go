@@ -0,0 +1,190 @@
+// Package grpcserver adapts the duplicatecheck library to the v1
+// DuplicateCheckService schema: conversions between its messages and the
+// library types, and a Server implementing the service on a HybridEngine
+//
+// The package has no transport of its own. Register a Server with a
+// grpc.Server through the generated v1.RegisterDuplicateCheckServiceServer.
+// It's a module of its own, like the bindings, so the library module doesn't
+// depend on gRPC.
+package grpcserver
+
+import (
+	"github.com/solrac97gr/duplicatecheck"
+	v1 "github.com/solrac97gr/duplicatecheck/proto/duplicatecheck/v1"
+)
+
+// ProductToProto converts a product to its message
+func ProductToProto(p duplicatecheck.Product) *v1.Product {
+	return &v1.Product{Id: p.ID, SourceId: p.SourceID, Name: p.Name, Description: p.Description}
+}
+
+// ProductFromProto converts a product message; nil is the zero product
+func ProductFromProto(p *v1.Product) duplicatecheck.Product {
+	if p == nil {
+		return duplicatecheck.Product{}
+	}
+	return duplicatecheck.Product{ID: p.Id, SourceID: p.SourceId, Name: p.Name, Description: p.Description}
+}
+
+// WeightsToProto converts comparison weights to their message
+func WeightsToProto(w duplicatecheck.ComparisonWeights) *v1.ComparisonWeights {
+	return &v1.ComparisonWeights{NameWeight: w.NameWeight, DescriptionWeight: w.DescriptionWeight}
+}
+
+// WeightsFromProto converts a weights message; nil is the zero weights
+func WeightsFromProto(w *v1.ComparisonWeights) duplicatecheck.ComparisonWeights {
+	if w == nil {
+		return duplicatecheck.ComparisonWeights{}
+	}
+	return duplicatecheck.ComparisonWeights{NameWeight: w.NameWeight, DescriptionWeight: w.DescriptionWeight}
+}
+
+// ResultToProto converts a comparison result to its message
+// Every field but the deprecated Distance and Similarity is carried.
+func ResultToProto(r duplicatecheck.ComparisonResult) *v1.ComparisonResult {
+	var segments []*v1.SegmentScore
+	for _, s := range r.SegmentSimilarities {
+		segments = append(segments, &v1.SegmentScore{
+			Label:      s.Label,
+			IndexA:     int64(s.IndexA),
+			IndexB:     int64(s.IndexB),
+			Weight:     s.Weight,
+			Distance:   int64(s.Distance),
+			Similarity: s.Similarity,
+		})
+	}
+	var components []*v1.ComponentMatch
+	for _, m := range r.ComponentMatches {
+		components = append(components, &v1.ComponentMatch{
+			A:          m.A,
+			B:          m.B,
+			IndexA:     int64(m.IndexA),
+			IndexB:     int64(m.IndexB),
+			Similarity: m.Similarity,
+		})
+	}
+	var reasons []string
+	for _, code := range r.ReasonCodes {
+		reasons = append(reasons, string(code))
+	}
+	return &v1.ComparisonResult{
+		ProductA:                     ProductToProto(r.ProductA),
+		ProductB:                     ProductToProto(r.ProductB),
+		NameDistance:                 int64(r.NameDistance),
+		NameSimilarity:               r.NameSimilarity,
+		DescriptionDistance:          int64(r.DescriptionDistance),
+		DescriptionSimilarity:        r.DescriptionSimilarity,
+		CombinedSimilarity:           r.CombinedSimilarity,
+		Stage:                        r.Stage,
+		WeightsUsed:                  WeightsToProto(r.WeightsUsed),
+		SimilarityMode:               v1.SimilarityMode(r.SimilarityMode),
+		ThresholdUsed:                r.ThresholdUsed,
+		MeetsThreshold:               r.MeetsThreshold,
+		MatchType:                    v1.MatchType(r.MatchType),
+		DifferenceKinds:              append([]string(nil), r.DifferenceKinds...),
+		SegmentSimilarities:          segments,
+		LowQualityInput:              r.LowQualityInput,
+		DescriptionTimedOut:          r.DescriptionTimedOut,
+		NameInDescriptionAb:          r.NameInDescriptionAB,
+		NameInDescriptionBa:          r.NameInDescriptionBA,
+		CandidateSource:              r.CandidateSource,
+		DuplicateProbability:         r.DuplicateProbability,
+		ObfuscationSuspected:         r.ObfuscationSuspected,
+		DeobfuscatedNameSimilarity:   r.DeobfuscatedNameSimilarity,
+		DescriptionLoadFailed:        r.DescriptionLoadFailed,
+		SameSourceIds:                r.SameSourceIDs,
+		CrossLanguage:                r.CrossLanguage,
+		ClusterInferred:              r.ClusterInferred,
+		SkuNameComparison:            r.SKUNameComparison,
+		SkuNameMixed:                 r.SKUNameMixed,
+		LowInfoNames:                 r.LowInfoNames,
+		AmbiguousProduct:             r.AmbiguousProduct,
+		EstimatedSimilarity:          r.EstimatedSimilarity,
+		BundleSimilarity:             r.BundleSimilarity,
+		ComponentMatches:             components,
+		ReasonCodes:                  reasons,
+		HomoglyphsNormalized:         r.HomoglyphsNormalized,
+		FieldsSwappedSuspected:       r.FieldsSwappedSuspected,
+		SwappedNameSimilarity:        r.SwappedNameSimilarity,
+		SwappedDescriptionSimilarity: r.SwappedDescriptionSimilarity,
+	}
+}
+
+// ResultFromProto converts a comparison result message; nil is the zero result
+func ResultFromProto(r *v1.ComparisonResult) duplicatecheck.ComparisonResult {
+	if r == nil {
+		return duplicatecheck.ComparisonResult{}
+	}
+	var segments []duplicatecheck.SegmentScore
+	for _, s := range r.SegmentSimilarities {
+		if s == nil {
+			continue
+		}
+		segments = append(segments, duplicatecheck.SegmentScore{
+			Label:      s.Label,
+			IndexA:     int(s.IndexA),
+			IndexB:     int(s.IndexB),
+			Weight:     s.Weight,
+			Distance:   int(s.Distance),
+			Similarity: s.Similarity,
+		})
+	}
+	var components []duplicatecheck.ComponentMatch
+	for _, m := range r.ComponentMatches {
+		if m == nil {
+			continue
+		}
+		components = append(components, duplicatecheck.ComponentMatch{
+			A:          m.A,
+			B:          m.B,
+			IndexA:     int(m.IndexA),
+			IndexB:     int(m.IndexB),
+			Similarity: m.Similarity,
+		})
+	}
+	var reasons []duplicatecheck.ReasonCode
+	for _, code := range r.ReasonCodes {
+		reasons = append(reasons, duplicatecheck.ReasonCode(code))
+	}
+	return duplicatecheck.ComparisonResult{
+		ProductA:                     ProductFromProto(r.ProductA),
+		ProductB:                     ProductFromProto(r.ProductB),
+		NameDistance:                 int(r.NameDistance),
+		NameSimilarity:               r.NameSimilarity,
+		DescriptionDistance:          int(r.DescriptionDistance),
+		DescriptionSimilarity:        r.DescriptionSimilarity,
+		CombinedSimilarity:           r.CombinedSimilarity,
+		Stage:                        r.Stage,
+		WeightsUsed:                  WeightsFromProto(r.WeightsUsed),
+		SimilarityMode:               duplicatecheck.SimilarityMode(r.SimilarityMode),
+		ThresholdUsed:                r.ThresholdUsed,
+		MeetsThreshold:               r.MeetsThreshold,
+		MatchType:                    duplicatecheck.MatchType(r.MatchType),
+		DifferenceKinds:              append([]string(nil), r.DifferenceKinds...),
+		SegmentSimilarities:          segments,
+		LowQualityInput:              r.LowQualityInput,
+		DescriptionTimedOut:          r.DescriptionTimedOut,
+		NameInDescriptionAB:          r.NameInDescriptionAb,
+		NameInDescriptionBA:          r.NameInDescriptionBa,
+		CandidateSource:              r.CandidateSource,
+		DuplicateProbability:         r.DuplicateProbability,
+		ObfuscationSuspected:         r.ObfuscationSuspected,
+		DeobfuscatedNameSimilarity:   r.DeobfuscatedNameSimilarity,
+		DescriptionLoadFailed:        r.DescriptionLoadFailed,
+		SameSourceIDs:                r.SameSourceIds,
+		CrossLanguage:                r.CrossLanguage,
+		ClusterInferred:              r.ClusterInferred,
+		SKUNameComparison:            r.SkuNameComparison,
+		SKUNameMixed:                 r.SkuNameMixed,
+		LowInfoNames:                 r.LowInfoNames,
+		AmbiguousProduct:             r.AmbiguousProduct,
+		EstimatedSimilarity:          r.EstimatedSimilarity,
+		BundleSimilarity:             r.BundleSimilarity,
+		ComponentMatches:             components,
+		ReasonCodes:                  reasons,
+		HomoglyphsNormalized:         r.HomoglyphsNormalized,
+		FieldsSwappedSuspected:       r.FieldsSwappedSuspected,
+		SwappedNameSimilarity:        r.SwappedNameSimilarity,
+		SwappedDescriptionSimilarity: r.SwappedDescriptionSimilarity,
+	}
+}
@@ -0,0 +1,141 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/solrac97gr/duplicatecheck"
+	v1 "github.com/solrac97gr/duplicatecheck/proto/duplicatecheck/v1"
+)
+
+// streamBuffer is how many FindDuplicates results wait on a slow stream
+// before the scan blocks
+const streamBuffer = 64
+
+// Server implements DuplicateCheckService on a configured HybridEngine
+// FindDuplicatesForOne queries the engine's index, which the caller builds
+// and maintains. Requests stop when their context is done; errors are the
+// library's, for a gRPC interceptor to map to status codes.
+type Server struct {
+	v1.UnimplementedDuplicateCheckServiceServer
+	engine *duplicatecheck.HybridEngine
+}
+
+var _ v1.DuplicateCheckServiceServer = (*Server)(nil)
+
+// NewServer returns a Server comparing with engine
+func NewServer(engine *duplicatecheck.HybridEngine) *Server {
+	return &Server{engine: engine}
+}
+
+// Compare scores one pair, with the request's weights when it has them
+func (s *Server) Compare(ctx context.Context, req *v1.CompareRequest) (*v1.CompareResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	a, b := ProductFromProto(req.A), ProductFromProto(req.B)
+	var result duplicatecheck.ComparisonResult
+	if req.Weights != nil {
+		result = s.engine.CompareWithWeights(a, b, WeightsFromProto(req.Weights))
+	} else {
+		result = s.engine.Compare(a, b)
+	}
+	return &v1.CompareResponse{Result: ResultToProto(result)}, nil
+}
+
+// FindDuplicatesForOne streams the indexed products matching the request's
+// product, every response flagged Truncated when the query was cut to
+// HybridConfig.MaxCandidates
+func (s *Server) FindDuplicatesForOne(req *v1.FindDuplicatesForOneRequest, stream v1.DuplicateCheckService_FindDuplicatesForOneServer) error {
+	ctx := stream.Context()
+	threshold, err := s.threshold(req.Threshold)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	results, err := s.engine.FindDuplicatesForOneChecked(ProductFromProto(req.Product), threshold)
+	truncated := errors.Is(err, duplicatecheck.ErrQueryTruncated)
+	if err != nil && !truncated {
+		return err
+	}
+	for i := range results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := stream.Send(&v1.FindDuplicatesForOneResponse{Result: ResultToProto(results[i]), Truncated: truncated}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindDuplicates streams the duplicate pairs of the request's products as the
+// scan finds them (see HybridEngine.FindDuplicatesChan), so large result sets
+// aren't held in memory; a failed send stops the scan
+// Like the engine's FindDuplicates, the products are queried against the
+// index when there is one, and paired with each other otherwise.
+func (s *Server) FindDuplicates(req *v1.FindDuplicatesRequest, stream v1.DuplicateCheckService_FindDuplicatesServer) error {
+	threshold, err := s.threshold(req.Threshold)
+	if err != nil {
+		return err
+	}
+	products := make([]duplicatecheck.Product, len(req.Products))
+	for i, p := range req.Products {
+		products[i] = ProductFromProto(p)
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	results, errs := s.engine.FindDuplicatesChan(ctx, products, threshold, streamBuffer)
+	for result := range results {
+		if err := stream.Send(&v1.FindDuplicatesResponse{Result: ResultToProto(result)}); err != nil {
+			cancel()
+			for range results {
+			}
+			return err
+		}
+	}
+	return <-errs
+}
+
+// GetIndexStats reports HybridEngine.GetIndexStats, numbers and durations
+// (in seconds) apart from flags
+func (s *Server) GetIndexStats(ctx context.Context, _ *v1.GetIndexStatsRequest) (*v1.GetIndexStatsResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	resp := &v1.GetIndexStatsResponse{Numbers: make(map[string]float64), Flags: make(map[string]bool)}
+	for key, value := range s.engine.GetIndexStats() {
+		switch v := value.(type) {
+		case bool:
+			resp.Flags[key] = v
+		case time.Duration:
+			resp.Numbers[key] = v.Seconds()
+		case int:
+			resp.Numbers[key] = float64(v)
+		case int64:
+			resp.Numbers[key] = float64(v)
+		case uint64:
+			resp.Numbers[key] = float64(v)
+		case float64:
+			resp.Numbers[key] = v
+		}
+	}
+	return resp, nil
+}
+
+// threshold returns a request's threshold, the engine's default when unset
+func (s *Server) threshold(t *float64) (float64, error) {
+	if t == nil {
+		return s.engine.GetDefaultThreshold(), nil
+	}
+	if math.IsNaN(*t) || *t < 0 || *t > 1 {
+		return 0, fmt.Errorf("grpcserver: threshold must be in [0, 1], got %v", *t)
+	}
+	return *t, nil
+}
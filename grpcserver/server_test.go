@@ -0,0 +1,272 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck"
+	v1 "github.com/solrac97gr/duplicatecheck/proto/duplicatecheck/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// recordingStream collects a stream's responses, failing the send after
+// failAfter of them (0 = never)
+type recordingStream[T any] struct {
+	grpc.ServerStream // Unused by the server, nil
+	ctx               context.Context
+	sent              []*T
+	failAfter         int
+}
+
+var errSendFailed = errors.New("send failed")
+
+func (s *recordingStream[T]) Send(resp *T) error {
+	if s.failAfter > 0 && len(s.sent) == s.failAfter {
+		return errSendFailed
+	}
+	s.sent = append(s.sent, resp)
+	return nil
+}
+
+func (s *recordingStream[T]) Context() context.Context {
+	return s.ctx
+}
+
+// serverCatalog holds one duplicate pair among unrelated products
+func serverCatalog() []duplicatecheck.Product {
+	return []duplicatecheck.Product{
+		{ID: "1", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Noise cancelling"},
+		{ID: "2", Name: "Sony WH1000XM5 Wireless Headphones", Description: "Noise cancelling"},
+		{ID: "3", Name: "Le Creuset Dutch Oven", Description: "Cast iron cookware"},
+		{ID: "4", Name: "Kindle Paperwhite", Description: "Waterproof e-reader"},
+	}
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	engine := duplicatecheck.NewHybridEngine()
+	if err := engine.BuildIndex(serverCatalog()); err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(engine)
+}
+
+func TestServerCompare(t *testing.T) {
+	server := newTestServer(t)
+	catalog := serverCatalog()
+	a, b := ProductToProto(catalog[0]), ProductToProto(catalog[1])
+	weights := duplicatecheck.ComparisonWeights{NameWeight: 1}
+
+	tests := []struct {
+		name    string
+		weights *v1.ComparisonWeights
+		want    duplicatecheck.ComparisonResult
+	}{
+		{"engine weights", nil, duplicatecheck.NewHybridEngine().Compare(catalog[0], catalog[1])},
+		{"request weights", WeightsToProto(weights), duplicatecheck.NewHybridEngine().CompareWithWeights(catalog[0], catalog[1], weights)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := server.Compare(context.Background(), &v1.CompareRequest{A: a, B: b, Weights: tt.weights})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ResultFromProto(resp.Result); got.CombinedSimilarity != tt.want.CombinedSimilarity {
+				t.Errorf("similarity %v, want %v", got.CombinedSimilarity, tt.want.CombinedSimilarity)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := server.Compare(ctx, &v1.CompareRequest{A: a, B: b}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled Compare: %v", err)
+	}
+}
+
+func TestServerFindDuplicatesForOne(t *testing.T) {
+	server := newTestServer(t)
+	query := ProductToProto(duplicatecheck.Product{ID: "q", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Noise cancelling"})
+	low := 0.0
+	invalid := 2.0
+
+	tests := []struct {
+		name      string
+		threshold *float64
+		wantIDs   int
+		wantErr   bool
+	}{
+		{"default threshold", nil, 2, false},
+		{"explicit threshold", &low, 2, false}, // LSH candidates only
+		{"invalid threshold", &invalid, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &recordingStream[v1.FindDuplicatesForOneResponse]{ctx: context.Background()}
+			err := server.FindDuplicatesForOne(&v1.FindDuplicatesForOneRequest{Product: query, Threshold: tt.threshold}, stream)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(stream.sent) < tt.wantIDs {
+				t.Errorf("%d results, want at least %d", len(stream.sent), tt.wantIDs)
+			}
+			for _, resp := range stream.sent {
+				if resp.Result.ProductA.Id != "q" || resp.Truncated {
+					t.Errorf("unexpected response %+v", resp)
+				}
+			}
+		})
+	}
+
+	unindexed := NewServer(duplicatecheck.NewHybridEngine())
+	stream := &recordingStream[v1.FindDuplicatesForOneResponse]{ctx: context.Background()}
+	if err := unindexed.FindDuplicatesForOne(&v1.FindDuplicatesForOneRequest{Product: query}, stream); !errors.Is(err, duplicatecheck.ErrIndexNotBuilt) {
+		t.Errorf("no index: %v, want ErrIndexNotBuilt", err)
+	}
+}
+
+func TestServerFindDuplicates(t *testing.T) {
+	// Without an index the engine scans every pair of the request's products
+	server := NewServer(duplicatecheck.NewHybridEngine())
+	names := []string{
+		"Canvas Tote Bag", "Stainless Thermos Flask", "Ceramic Vase", "Wool Scarf", "Garden Hose Reel",
+		"Espresso Grinder", "Yoga Mat", "Desk Lamp", "Hiking Backpack", "Cast Iron Skillet",
+		"Bluetooth Speaker", "Running Shoes", "Leather Wallet", "Electric Kettle", "Board Game Set",
+	}
+	var products []*v1.Product
+	for i, name := range names {
+		for copy := 0; copy < 2; copy++ {
+			products = append(products, ProductToProto(duplicatecheck.Product{ID: fmt.Sprintf("%d-%d", i, copy), Name: name, Description: name}))
+		}
+	}
+	threshold := 0.9
+
+	stream := &recordingStream[v1.FindDuplicatesResponse]{ctx: context.Background()}
+	if err := server.FindDuplicates(&v1.FindDuplicatesRequest{Products: products, Threshold: &threshold}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != len(names) {
+		t.Errorf("%d results streamed, want %d", len(stream.sent), len(names))
+	}
+	for _, resp := range stream.sent {
+		if a, b := resp.Result.ProductA.Id, resp.Result.ProductB.Id; a[:len(a)-2] != b[:len(b)-2] {
+			t.Errorf("unexpected pair %s-%s", a, b)
+		}
+	}
+
+	failing := &recordingStream[v1.FindDuplicatesResponse]{ctx: context.Background(), failAfter: 3}
+	if err := server.FindDuplicates(&v1.FindDuplicatesRequest{Products: products, Threshold: &threshold}, failing); !errors.Is(err, errSendFailed) {
+		t.Errorf("failed send: %v, want errSendFailed", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelled := &recordingStream[v1.FindDuplicatesResponse]{ctx: ctx}
+	if err := server.FindDuplicates(&v1.FindDuplicatesRequest{Products: products, Threshold: &threshold}, cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: %v, want context.Canceled", err)
+	}
+
+	repeated := []*v1.Product{products[0], {Id: products[0].Id, Name: products[0].Name + " v2"}}
+	var idErr *duplicatecheck.DuplicateIDError
+	if err := server.FindDuplicates(&v1.FindDuplicatesRequest{Products: repeated}, stream); !errors.As(err, &idErr) {
+		t.Errorf("repeated IDs: %v, want *DuplicateIDError", err)
+	}
+}
+
+func TestServerGetIndexStats(t *testing.T) {
+	server := newTestServer(t)
+	resp, err := server.GetIndexStats(context.Background(), &v1.GetIndexStatsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key := range server.engine.GetIndexStats() {
+		_, number := resp.Numbers[key]
+		_, flag := resp.Flags[key]
+		if !number && !flag {
+			t.Errorf("stat %s missing from the response", key)
+		}
+	}
+	if resp.Numbers["total_products"] != 4 || !resp.Flags["indexed"] {
+		t.Errorf("total_products %v, indexed %v", resp.Numbers["total_products"], resp.Flags["indexed"])
+	}
+}
+
+// TestServerOverGRPC serves a Server through the generated registration and
+// calls it with the generated client
+func TestServerOverGRPC(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	v1.RegisterDuplicateCheckServiceServer(grpcServer, newTestServer(t))
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := v1.NewDuplicateCheckServiceClient(conn)
+	ctx := context.Background()
+	catalog := serverCatalog()
+
+	resp, err := client.Compare(ctx, &v1.CompareRequest{A: ProductToProto(catalog[0]), B: ProductToProto(catalog[1])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := duplicatecheck.NewHybridEngine().Compare(catalog[0], catalog[1]); resp.Result.CombinedSimilarity != want.CombinedSimilarity {
+		t.Errorf("Compare similarity %v, want %v", resp.Result.CombinedSimilarity, want.CombinedSimilarity)
+	}
+
+	products := make([]*v1.Product, len(catalog))
+	for i, p := range catalog {
+		products[i] = ProductToProto(p)
+	}
+	stream, err := client.FindDuplicates(ctx, &v1.FindDuplicatesRequest{Products: products})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pairs []string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		pairs = append(pairs, resp.Result.ProductA.Id+"-"+resp.Result.ProductB.Id)
+	}
+	if len(pairs) != 1 || (pairs[0] != "1-2" && pairs[0] != "2-1") {
+		t.Errorf("FindDuplicates streamed %v, want the 1-2 pair", pairs)
+	}
+
+	invalid := 2.0
+	stream, err = client.FindDuplicates(ctx, &v1.FindDuplicatesRequest{Products: products, Threshold: &invalid})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unknown {
+		t.Errorf("invalid threshold: %v, want an Unknown status", err)
+	}
+
+	stats, err := client.GetIndexStats(ctx, &v1.GetIndexStatsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Numbers["total_products"] != 4 || !stats.Flags["indexed"] {
+		t.Errorf("total_products %v, indexed %v", stats.Numbers["total_products"], stats.Flags["indexed"])
+	}
+}
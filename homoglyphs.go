@@ -0,0 +1,246 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HomoglyphTable maps characters that look identical to Latin letters and
+// digits ("о" Cyrillic o, "Α" Greek Alpha, "Ｓ" fullwidth S) to them, so
+// "Sаmsung" with a Cyrillic а reads as "Samsung"
+// Mapping is alphabet-aware: a Cyrillic or Greek look-alike is only replaced
+// in a word that, once replaced, is entirely Latin, and only when the word or
+// the rest of the text already has Latin letters. Genuinely Cyrillic or Greek
+// words, which have letters with no Latin twin, and wholly Cyrillic or Greek
+// text are left as they are. Fullwidth forms are always replaced.
+//
+// Engines use a table through TextPreparation.Homoglyphs (see WithHomoglyphs).
+type HomoglyphTable struct {
+	latin map[rune]rune // Look-alike -> Latin letter or digit
+}
+
+// NewHomoglyphTable returns an empty table
+func NewHomoglyphTable() *HomoglyphTable {
+	return &HomoglyphTable{latin: make(map[rune]rune)}
+}
+
+// defaultHomoglyphs lists the built-in look-alikes of each Latin letter: a
+// trimmed subset of Unicode's confusables.txt keeping only the Cyrillic and
+// Greek letters indistinguishable from Latin in common fonts
+var defaultHomoglyphs = map[rune]string{
+	'A': "АΑ", 'B': "ВΒ", 'C': "С", 'E': "ЕΕ", 'H': "НΗ", 'I': "ІΙ", 'J': "Ј",
+	'K': "КΚ", 'M': "МΜ", 'N': "Ν", 'O': "ОΟ", 'P': "РΡ", 'S': "Ѕ", 'T': "ТΤ",
+	'X': "ХΧ", 'Y': "ҮΥ", 'Z': "Ζ",
+	'a': "а", 'c': "с", 'd': "ԁ", 'e': "е", 'h': "һ", 'i': "і", 'j': "ј",
+	'o': "оο", 'p': "р", 'q': "ԛ", 's': "ѕ", 'w': "ԝ", 'x': "х", 'y': "у",
+}
+
+// DefaultHomoglyphs returns a new table with the built-in look-alikes: the
+// Cyrillic and Greek letters indistinguishable from Latin ones, and the
+// fullwidth forms of Latin letters and digits
+// The result is a fresh copy, so callers can Add to it freely.
+func DefaultHomoglyphs() *HomoglyphTable {
+	t := NewHomoglyphTable()
+	for latin, lookalikes := range defaultHomoglyphs {
+		for _, r := range lookalikes {
+			t.Add(r, latin)
+		}
+	}
+	for r := 'Ａ'; r <= 'Ｚ'; r++ {
+		t.Add(r, 'A'+(r-'Ａ'))
+		t.Add(r+('ａ'-'Ａ'), 'a'+(r-'Ａ'))
+	}
+	for r := '０'; r <= '９'; r++ {
+		t.Add(r, '0'+(r-'０'))
+	}
+	return t
+}
+
+// Add maps lookalike to latin
+// A later Add of the same look-alike replaces its mapping.
+func (t *HomoglyphTable) Add(lookalike, latin rune) {
+	if t.latin == nil {
+		t.latin = make(map[rune]rune)
+	}
+	t.latin[lookalike] = latin
+}
+
+// Len returns the number of look-alikes in the table
+func (t *HomoglyphTable) Len() int {
+	return len(t.latin)
+}
+
+// Clone returns an independent copy of the table
+func (t *HomoglyphTable) Clone() *HomoglyphTable {
+	if t == nil {
+		return nil
+	}
+	clone := &HomoglyphTable{latin: make(map[rune]rune, len(t.latin))}
+	for lookalike, latin := range t.latin {
+		clone.latin[lookalike] = latin
+	}
+	return clone
+}
+
+// Fingerprint identifies the table's mappings; equal mappings give equal fingerprints
+func (t *HomoglyphTable) Fingerprint() uint64 {
+	entries := t.entries()
+	fields := make([]string, 0, 2*len(entries)+1)
+	fields = append(fields, "homoglyphs/v1")
+	lookalikes := make([]string, 0, len(entries))
+	for lookalike := range entries {
+		lookalikes = append(lookalikes, lookalike)
+	}
+	sort.Strings(lookalikes)
+	for _, lookalike := range lookalikes {
+		fields = append(fields, lookalike, entries[lookalike])
+	}
+	return contentFingerprint(fields...)
+}
+
+// MarshalJSON encodes the table as a JSON object mapping each look-alike to
+// its Latin character: {"а": "a", "Ο": "O"}
+func (t *HomoglyphTable) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.entries())
+}
+
+// UnmarshalJSON replaces the table's mappings with those of a MarshalJSON
+// document; keys and values must be single characters
+func (t *HomoglyphTable) UnmarshalJSON(data []byte) error {
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	*t = HomoglyphTable{latin: make(map[rune]rune, len(entries))}
+	for lookalike, latin := range entries {
+		from, n := utf8.DecodeRuneInString(lookalike)
+		to, m := utf8.DecodeRuneInString(latin)
+		if n == 0 || n != len(lookalike) || m == 0 || m != len(latin) {
+			return fmt.Errorf("duplicatecheck: homoglyph %q -> %q: want single characters", lookalike, latin)
+		}
+		t.Add(from, to)
+	}
+	return nil
+}
+
+// entries returns the mappings as strings
+func (t *HomoglyphTable) entries() map[string]string {
+	entries := make(map[string]string, len(t.latin))
+	for lookalike, latin := range t.latin {
+		entries[string(lookalike)] = string(latin)
+	}
+	return entries
+}
+
+// Apply replaces the look-alikes in text, alphabet-aware (see HomoglyphTable)
+// Words are runs of letters and digits; everything between them is kept.
+func (t *HomoglyphTable) Apply(text string) string {
+	if t == nil || len(t.latin) == 0 || !strings.ContainsFunc(text, t.mapped) {
+		return text
+	}
+	tokens := synonymTokens(text)
+	latinText := false // Some word is Latin without any replacement
+	for _, token := range tokens {
+		if t.latinWord(token.text) {
+			latinText = true
+			break
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	last := 0 // End of the text copied so far
+	for _, token := range tokens {
+		word, ok := t.replace(token.text, latinText)
+		if !ok {
+			continue
+		}
+		b.WriteString(text[last:token.start])
+		b.WriteString(word)
+		last = token.end
+	}
+	if last == 0 {
+		return text // Nothing replaced
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// mapped reports whether r has a mapping
+func (t *HomoglyphTable) mapped(r rune) bool {
+	_, ok := t.latin[r]
+	return ok
+}
+
+// latinWord reports whether word has a Latin letter and nothing the table
+// would replace
+func (t *HomoglyphTable) latinWord(word string) bool {
+	return strings.ContainsFunc(word, isLatinLetter) && !strings.ContainsFunc(word, t.mapped)
+}
+
+// replace returns word with its look-alikes replaced, and whether it changed
+// Fullwidth and other non-letter-script look-alikes are always replaced;
+// foreign-script ones only when every letter of the result is Latin, and the
+// word or its text (latinText) has Latin letters of its own.
+func (t *HomoglyphTable) replace(word string, latinText bool) (string, bool) {
+	var foreign, latin, alien bool
+	for _, r := range word {
+		if _, ok := t.latin[r]; ok {
+			foreign = foreign || !unicode.Is(unicode.Latin, r) && !unicode.Is(unicode.Common, r)
+		} else if isLatinLetter(r) {
+			latin = true
+		} else if unicode.IsLetter(r) {
+			alien = true // A letter with no Latin twin: the word is genuinely foreign
+		}
+	}
+	if foreign && (alien || !latin && !latinText) {
+		return word, false
+	}
+	replaced := strings.Map(func(r rune) rune {
+		if to, ok := t.latin[r]; ok {
+			return to
+		}
+		return r
+	}, word)
+	return replaced, replaced != word
+}
+
+// isLatinLetter reports whether r is a letter of the Latin script
+func isLatinLetter(r rune) bool {
+	return unicode.Is(unicode.Latin, r)
+}
+
+// homoglyphsNormalized reports whether the engine's homoglyph table changed
+// either product's text
+func (e *LevenshteinEngine) homoglyphsNormalized(a, b *Product) bool {
+	prep := e.preparer()
+	if prep.options.Homoglyphs == nil {
+		return false
+	}
+	return a.loadCacheFor(prep).homoglyphs || b.loadCacheFor(prep).homoglyphs
+}
+
+// WithHomoglyphs makes the engine replace look-alike characters with their
+// Latin twins before comparing text, by setting TextPreparation.Homoglyphs to
+// a copy of table (nil turns it off)
+// Results of pairs where the table changed either product's text are flagged
+// HomoglyphsNormalized. Returns the engine for chaining.
+func (e *LevenshteinEngine) WithHomoglyphs(table *HomoglyphTable) *LevenshteinEngine {
+	options := e.GetTextPreparation()
+	options.Homoglyphs = table
+	return e.WithTextPreparation(options)
+}
+
+// WithHomoglyphs makes the engine replace look-alike characters before
+// indexing and verification (see LevenshteinEngine.WithHomoglyphs), so a
+// listing spelled with look-alikes shares the original's shingles. Any built
+// index is discarded. Returns the engine for chaining.
+func (e *HybridEngine) WithHomoglyphs(table *HomoglyphTable) *HybridEngine {
+	options := e.GetTextPreparation()
+	options.Homoglyphs = table
+	return e.WithTextPreparation(options)
+}
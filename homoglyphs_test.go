@@ -0,0 +1,135 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHomoglyphTableApply(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"cyrillic letter in a latin word", "Sаmsung Galaxy S23", "Samsung Galaxy S23"},
+		{"greek capitals in a latin word", "iPhone ΡRΟ Max", "iPhone PRO Max"},
+		{"look-alike word in latin text", "Nike АІR Max", "Nike AIR Max"},
+		{"fullwidth letters and digits", "ＳＡＭＳＵＮＧ Galaxy Ｓ２３", "SAMSUNG Galaxy S23"},
+		{"genuine cyrillic name", "Самсунг Галакси S23", "Самсунг Галакси S23"},
+		{"genuine cyrillic word in latin text", "Samsung Галакси", "Samsung Галакси"},
+		{"look-alike word in cyrillic text", "Кофе раса", "Кофе раса"},
+		{"genuine greek name", "Κινητό τηλέφωνο", "Κινητό τηλέφωνο"},
+		{"punctuation kept", "Sаmsung—Galaxy (S23)", "Samsung—Galaxy (S23)"},
+	}
+	table := DefaultHomoglyphs()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := table.Apply(tt.text); got != tt.want {
+				t.Errorf("Apply(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHomoglyphTableExtend(t *testing.T) {
+	table := DefaultHomoglyphs()
+	before := table.Fingerprint()
+	if got := table.Apply("ᏚAMSUNG"); got != "ᏚAMSUNG" {
+		t.Fatalf("Cherokee S replaced without a mapping: %q", got)
+	}
+	table.Add('Ꮪ', 'S')
+	if got := table.Apply("ᏚAMSUNG"); got != "SAMSUNG" {
+		t.Errorf("Apply with an added mapping = %q, want SAMSUNG", got)
+	}
+	if table.Fingerprint() == before {
+		t.Error("Adding a mapping left the fingerprint unchanged")
+	}
+	if DefaultHomoglyphs().Apply("ᏚAMSUNG") != "ᏚAMSUNG" {
+		t.Error("Add changed the built-in table")
+	}
+
+	data, err := json.Marshal(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded HomoglyphTable
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Len() != table.Len() || decoded.Fingerprint() != table.Fingerprint() {
+		t.Errorf("JSON round trip gave %d mappings, want %d", decoded.Len(), table.Len())
+	}
+	if err := json.Unmarshal([]byte(`{"ab": "a"}`), &decoded); err == nil {
+		t.Error("A multi-character look-alike was accepted")
+	}
+}
+
+func TestHomoglyphComparison(t *testing.T) {
+	genuine := Product{ID: "1", Name: "Samsung Galaxy S23", Description: "Phantom Black, 128GB"}
+	spoofed := Product{ID: "2", Name: "Sаmsung Galaxy S23", Description: "Phantom Black, 128GB"}
+	engine := NewLevenshteinEngine().WithHomoglyphs(DefaultHomoglyphs())
+
+	result := engine.Compare(genuine, spoofed)
+	if result.CombinedSimilarity != 1.0 || !result.HomoglyphsNormalized || !result.HasReason(ReasonHomoglyphs) {
+		t.Errorf("spoofed listing: similarity %.3f, flagged %v, codes %v; want 1.0 and flagged",
+			result.CombinedSimilarity, result.HomoglyphsNormalized, result.ReasonCodes)
+	}
+	if plain := NewLevenshteinEngine().Compare(genuine, spoofed); plain.CombinedSimilarity == 1.0 || plain.HomoglyphsNormalized {
+		t.Errorf("without the table: similarity %.3f, flagged %v", plain.CombinedSimilarity, plain.HomoglyphsNormalized)
+	}
+	if other := engine.Compare(genuine, genuine); other.HomoglyphsNormalized {
+		t.Error("a pair without look-alikes was flagged")
+	}
+
+	// Genuinely Cyrillic names keep their letters: they score as without the table
+	a := Product{ID: "3", Name: "Самсунг Галакси S23 черный", Description: "Смартфон, 128 ГБ"}
+	b := Product{ID: "4", Name: "Самсунг Галакси S23 Ultra", Description: "Смартфон, 256 ГБ"}
+	want := NewLevenshteinEngine().Compare(a, b)
+	got := engine.Compare(a, b)
+	if got.CombinedSimilarity != want.CombinedSimilarity || got.HomoglyphsNormalized {
+		t.Errorf("cyrillic names: similarity %.3f, flagged %v; want %.3f unflagged",
+			got.CombinedSimilarity, got.HomoglyphsNormalized, want.CombinedSimilarity)
+	}
+
+	if steps := engine.GetTextPreparation().Steps(); steps[0] != PrepStepHomoglyphs {
+		t.Errorf("steps %v, want homoglyphs first", steps)
+	}
+	names := engine.FindDuplicatesByName([]Product{genuine, spoofed}, 0.9)
+	if len(names) != 1 || !names[0].HomoglyphsNormalized {
+		t.Errorf("FindDuplicatesByName: %+v, want one flagged pair", names)
+	}
+}
+
+func TestHomoglyphsHybridCandidate(t *testing.T) {
+	catalog := append(GenerateTestCatalog(7, 200),
+		Product{ID: "genuine", Name: "Samsung Galaxy S23 Ultra", Description: "Phantom Black smartphone"})
+	// Every a, e, o and p swapped: few shingles survive without normalization
+	spoofed := Product{ID: "spoofed", Name: "Sаmsung Gаlаxy S23 Ultrа", Description: "Рhаntоm Blасk smаrtрhоnе"}
+
+	engine := NewHybridEngine().WithHomoglyphs(DefaultHomoglyphs())
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, result := range engine.FindDuplicatesForOne(spoofed, 0.9) {
+		if result.ProductB.ID == "genuine" {
+			found = true
+			if !result.HomoglyphsNormalized {
+				t.Error("the spoofed listing's match is not flagged")
+			}
+		}
+	}
+	if !found {
+		t.Error("the spoofed listing was not matched to the genuine one")
+	}
+
+	plain := NewHybridEngine()
+	if err := plain.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range plain.FindDuplicatesForOne(spoofed, 0.9) {
+		if result.ProductB.ID == "genuine" {
+			t.Error("without the table the spoofed listing matched anyway; the test proves nothing")
+		}
+	}
+}
@@ -1,40 +1,411 @@
 package duplicatecheck
 
 import (
+	"context"
+	"errors"
 	"hash/fnv"
+	"log/slog"
 	"math"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ErrIndexNotBuilt is returned by HybridEngine operations that need a built index
+var ErrIndexNotBuilt = errors.New("duplicatecheck: index not built, call BuildIndex first")
+
+// ErrQueryTruncated is returned with partial results when a query had more
+// LSH candidates than HybridConfig.MaxCandidates
+var ErrQueryTruncated = errors.New("duplicatecheck: query candidates truncated by MaxCandidates")
+
 // HybridEngine implements a multi-stage hybrid architecture for efficient duplicate detection
 // Stage 1: Fast filtering using MinHash + LSH to reduce millions to hundreds
 // Stage 2: Medium refinement using n-grams and blocking
 // Stage 3: Precise verification using Levenshtein on final candidates
 type HybridEngine struct {
-	levenshteinEngine *LevenshteinEngine
-	lshIndex          *LSHIndex
-	numHashFunctions  int
-	numBands          int
-	shingleSize       int
+	levenshteinEngine  *LevenshteinEngine
+	lshIndex           *LSHIndex
+	numHashFunctions   int
+	minHash            *minHashFamily // MinHash hash family (see WithLSHSeed)
+	numBands           int
+	shingleSize        int
+	chunkSize          int               // Chunked signature mode: runes per chunk (0 = disabled)
+	chunkOverlap       int               // Chunked signature mode: runes shared by consecutive chunks
+	simHash            *SimHashFilter    // Fingerprints for privacy mode and the SimHash screen
+	simHashScreen      bool              // Reject candidates by SimHash estimate before Levenshtein
+	simHashMargin      float64           // Safety margin below threshold for the SimHash screen
+	simHashSkipped     uint64            // Candidates rejected by the SimHash screen (atomic)
+	idPolicy           DuplicateIDPolicy // How repeated Product IDs in the input are handled
+	privacy            *privacyState     // Non-nil when privacy mode is enabled
+	threshold          float64           // Default threshold for IsDuplicate and FindDuplicatesDefault
+	retainCandidates   bool              // Keep FindDuplicates candidate pairs for ReVerify
+	retained           *retainedCandidates
+	retainMatches      bool                 // Keep FindDuplicates matches for the pair-delta methods
+	matches            *pairPartners        // Matches retained from the last FindDuplicates run, kept current by the pair-delta methods
+	indexMu            sync.RWMutex         // Guards swapping lshIndex and retained (see currentIndex)
+	logger             *slog.Logger         // Optional event logger (see WithLogger)
+	candidateWarn      int                  // Per-query candidate count logged as a warning
+	maxCandidates      int                  // Per-query candidate cap (0 = unlimited)
+	maxBucketFanout    int                  // Buckets larger than this are skipped (0 = unlimited)
+	splitCompounds     bool                 // Split shingle words on internal hyphens and slashes
+	truncatedQueries   uint64               // Queries cut to maxCandidates (atomic)
+	skippedBuckets     uint64               // Buckets skipped for exceeding maxBucketFanout (atomic)
+	adaptiveBands      bool                 // Probe only the bands a query's threshold needs
+	bandEpsilon        float64              // Miss probability adaptiveBands allows
+	bandQueries        uint64               // Candidate lookups run (atomic)
+	probedBands        uint64               // Bands probed across all lookups (atomic)
+	maxShingles        int                  // Shingles per signature, sampled beyond this (0 = unlimited)
+	maxIndexTextLength int                  // Runes of index text kept (0 = unlimited)
+	indexingReport     func(IndexingReport) // Called for products hitting an indexing limit
+	buildWorkers       int                  // BuildIndex hashing goroutines (0 = getOptimalWorkerCount)
+	exactCutoff        int                  // Indexes smaller than this skip LSH (0 = never)
+	autoCompact        AutoCompactPolicy    // When RemoveProduct compacts the index
+	bandStore          BandStoreFactory     // Creates each index's bucket storage (nil = in memory)
+	bucketSalt         uint64               // Bucket hash salt of new indexes (0 = random per index)
+	cliqueBands        int                  // Band collisions making a candidate a clique member (0 = off)
+	cliqueMinSize      int                  // Products needed to form a clique
+	cliqueSkipped      uint64               // Clique member pairs left unverified (atomic)
+	verifiedPairs      uint64               // Candidates compared by Levenshtein (atomic)
+	bandReuses         uint64               // Queries reusing the band hashes their product was indexed under (atomic)
+	staleQueries       uint64               // Queries of indexed IDs whose content changed since indexing (atomic)
+
+	fastCompare      bool    // Compare rejects pairs by MinHash estimate (see HybridConfig.FastCompare)
+	fastCompareFloor float64 // Estimate below which FastCompare rejects a pair
+
+	scanCandidates uint64 // LSH candidates scans looked up, before pair deduplication (atomic)
+	scanPairs      uint64 // Distinct candidate pairs scans handed to verification (atomic)
+
+	recallEvery   int                // Queries per recall sample (0 = monitor off, see WithRecallMonitor)
+	recallHook    func(RecallSample) // Receives each recall sample
+	recallQueries uint64             // Queries counted by the recall monitor (atomic)
+
+	prefixIndex     bool // Index prepared names for PrefixCandidates (see HybridConfig.EnablePrefixIndex)
+	prefixLSHTokens int  // Words typed from which PrefixCandidates merges LSH candidates
+
+	oplog  *oplogWriter // Records index updates (see WithOplog)
+	replay *oplogReplay // Operation log records applied so far (see ApplyOplog)
 }
 
 // LSHIndex implements Locality Sensitive Hashing for fast similarity search
 type LSHIndex struct {
-	bands       []map[uint64][]string // Each band maps hash -> product IDs
+	bands       BandStore // Each band maps hash -> product numbers (see refs)
 	numBands    int
 	rowsPerBand int
-	products    map[string]Product // Product ID -> Product
+	products    map[string]*Product // Product ID -> Product (empty in privacy mode)
+	refs        []string            // Product number -> ID, in indexing order (removed products included)
+	numbers     map[string]uint32   // Product ID -> number (removed products included)
+	newBands    func() BandStore    // Creates an empty store like bands (see Compact)
+	// Privacy mode and SimHash screen only: per-product fingerprints
+	fingerprints  map[string]SimHashFingerprint // Product ID -> SimHash of normalized text
+	contentHashes map[string]uint64             // Product ID -> salted hash of normalized text
+	totalChunks   int                           // Number of MinHash signatures indexed (chunks across all products)
+	sampled       int                           // Products whose shingles were sampled
+	truncated     int                           // Products whose index text was truncated
+	buildWorkers  int                           // Goroutines that hashed products in BuildIndex
+	buildDuration time.Duration                 // BuildIndex wall time (0 until it finishes)
+	ids           []string                      // Product IDs in indexing order
+	catalogSize   int                           // Products BuildIndexProgressive is indexing (0 = all indexed)
+	removed       map[uint32]bool               // Products removed since the last Compact, still in the buckets
+	salt          uint64                        // Mixed into every bucket hash in bands (0 = unsalted, see bucketHash)
+	queryBands    map[string]indexedBands       // Product ID -> band hashes it was indexed under, reused by its queries (nil in privacy mode)
+	prefixes      *prefixIndex                  // Prepared names for PrefixCandidates (nil unless HybridConfig.EnablePrefixIndex)
+}
+
+// buildThroughput returns the products BuildIndex indexed per second
+func (idx *LSHIndex) buildThroughput() float64 {
+	if idx.buildDuration <= 0 {
+		return 0
+	}
+	return float64(len(idx.ids)) / idx.buildDuration.Seconds()
+}
+
+// size returns the number of indexed products
+func (idx *LSHIndex) size() int {
+	if len(idx.products) == 0 {
+		return len(idx.fingerprints)
+	}
+	return len(idx.products)
+}
+
+// canPair reports whether any of products can pair with an indexed product:
+// both sides hold products, and not just one product indexed alone
+func (idx *LSHIndex) canPair(products []*Product) bool {
+	size := idx.size()
+	switch {
+	case len(products) == 0 || size == 0:
+		return false
+	case len(products) == 1 && size == 1:
+		return idx.ids[0] != products[0].ID
+	}
+	return true
+}
+
+// HybridConfig holds tuning parameters for the HybridEngine
+type HybridConfig struct {
+	// NumHashFunctions is the MinHash signature length
+	NumHashFunctions int
+	// NumBands is the number of LSH bands (NumHashFunctions / NumBands rows each)
+	NumBands int
+	// ShingleSize is the number of words per shingle
+	ShingleSize int
+	// ChunkedSignatures splits long text into overlapping chunks and indexes a
+	// MinHash signature per chunk, so a product is a candidate if any chunk collides.
+	// Improves recall when one paragraph of a long description was edited or appended,
+	// at the cost of a larger index. Off by default.
+	ChunkedSignatures bool
+	// ChunkSize is the chunk length in characters (runes) when ChunkedSignatures is on
+	ChunkSize int
+	// ChunkOverlap is the number of characters shared by consecutive chunks
+	ChunkOverlap int
+	// SimHashScreen caches a SimHash fingerprint per product at BuildIndex time and
+	// rejects candidates whose estimated similarity is below threshold - SimHashMargin
+	// before running Levenshtein. Speeds up queries on long descriptions. Off by default.
+	SimHashScreen bool
+	// SimHashMargin is the safety margin for SimHashScreen (same default as SimHashFilter.QuickReject)
+	SimHashMargin float64
+	// RetainCandidates keeps every candidate pair examined by the last indexed
+	// FindDuplicates run so ReVerify can re-score them later. Costs memory
+	// proportional to the number of candidate pairs. Off by default.
+	RetainCandidates bool
+	// RetainMatches keeps the duplicate pairs found by the last indexed
+	// FindDuplicates run, per product, so UpdatePairsForProduct and
+	// RemovePairsForProduct can tell which of a product's pairs went stale.
+	// Costs memory proportional to the number of pairs. Off by default.
+	RetainMatches bool
+	// CandidateWarnThreshold is the number of LSH candidates for one query above
+	// which a warning is logged (see WithLogger). Default DefaultCandidateWarnThreshold.
+	CandidateWarnThreshold int
+	// MaxCandidates caps the candidates verified per query. When a query has
+	// more, the ones sharing the most bands are kept (ties by ID) and the query
+	// is reported as truncated (see FindDuplicatesForOneChecked). 0 = unlimited (default).
+	MaxCandidates int
+	// MaxBucketFanout skips any LSH bucket holding more products than this
+	// during a query; buckets that large are nearly always shared boilerplate.
+	// 0 = unlimited (default).
+	MaxBucketFanout int
+	// SplitCompoundTokens splits shingle words on internal hyphens and slashes,
+	// so "wh-1000xm5" and "wh 1000xm5" shingle alike. Off by default.
+	SplitCompoundTokens bool
+	// AdaptiveBands probes only as many LSH bands as a query's threshold needs
+	// to keep the chance of missing a pair at that similarity below
+	// AdaptiveBandEpsilon. High-threshold queries then collect fewer, better
+	// candidates; low thresholds still probe every band. The bound assumes the
+	// threshold is the Jaccard similarity of the shingle sets, so pairs with
+	// scattered edits can be missed more often. Off by default.
+	AdaptiveBands bool
+	// AdaptiveBandEpsilon is the miss probability AdaptiveBands allows
+	// (default DefaultAdaptiveBandEpsilon)
+	AdaptiveBandEpsilon float64
+	// MaxShinglesPerProduct caps the shingles hashed into each MinHash
+	// signature. Longer texts keep a deterministic sample spread across the
+	// whole text (the shingles with the smallest hash), and queries sample the
+	// same way, so indexing time stops growing with description length while
+	// near-duplicates still share most of their sample. Applies per chunk with
+	// ChunkedSignatures. 0 = unlimited (default).
+	MaxShinglesPerProduct int
+	// MaxIndexTextLength is a hard ceiling, in runes, on the prepared name and
+	// description text shingled and fingerprinted for the index; the rest is
+	// ignored by LSH (verification still compares the full text).
+	// 0 = unlimited (default).
+	MaxIndexTextLength int
+	// BuildWorkers is the number of goroutines hashing products in BuildIndex
+	// (0 = chosen from the catalog size and CPU count, 1 = sequential). The
+	// index is identical for any value.
+	BuildWorkers int
+	// ExactModeCutoff is the index size below which queries skip LSH and
+	// verify every indexed product, matching the Levenshtein engine: at that
+	// scale LSH only costs recall. Queries switch to LSH once AddProduct
+	// grows the index to the cutoff. 0 always uses LSH.
+	// Default DefaultExactModeCutoff.
+	ExactModeCutoff int
+	// AutoCompact makes RemoveProduct run Compact once enough products were
+	// removed since the last compaction. Off by default.
+	AutoCompact AutoCompactPolicy
+	// BandStore creates the storage of each index's LSH buckets, such as
+	// DiskBandStores for indexes too large for memory. nil keeps them in
+	// memory (NewMemoryBandStore). Not part of EngineConfig.
+	BandStore BandStoreFactory `json:"-"`
+	// CliqueBands turns on clique detection in FindDuplicates: when a query's
+	// candidates include at least CliqueMinSize - 1 products sharing this many
+	// bands with it, typically boilerplate listings, the query becomes the
+	// clique's representative and only its pairs with the members are
+	// verified, instead of every pair among them. Those results are marked
+	// ClusterInferred. 0 = off (default); values near NumBands are safest.
+	CliqueBands int
+	// CliqueMinSize is the smallest clique CliqueBands forms, representative
+	// included (default DefaultCliqueMinSize)
+	CliqueMinSize int
+	// FastCompare makes Compare estimate the Jaccard similarity of two
+	// products from their MinHash signatures when both are indexed or both
+	// were warmed (see Warmup), and reject pairs estimated below
+	// FastCompareFloor without running Levenshtein (Stage
+	// StageEstimatedReject). Other pairs are compared in full as usual. Off by
+	// default, since a rejected pair's CombinedSimilarity is the estimate;
+	// CompareExact always compares in full.
+	FastCompare bool
+	// FastCompareFloor is the estimate below which FastCompare rejects a pair
+	// (default DefaultFastCompareFloor)
+	FastCompareFloor float64
+	// SignatureScheme selects how MinHash signatures are computed. The
+	// default SignatureKIndependent hashes every shingle NumHashFunctions
+	// times; SignatureOnePermutation hashes it once, for several times faster
+	// BuildIndex on long texts with comparable accuracy. Part of the index
+	// config fingerprint: indexes of different schemes don't mix.
+	SignatureScheme SignatureScheme
+	// BucketSalt is mixed into every bucket hash of the indexes the engine
+	// builds, so identical text in two indexes lands in different buckets.
+	// 0 (default) draws a random salt for each BuildIndex; set it to rebuild
+	// an index with identical bucket hashes. Results don't depend on the salt:
+	// MinHash signatures and query band hashes are unsalted.
+	BucketSalt uint64 `json:",omitempty"`
+	// EnablePrefixIndex keeps a sorted index of the prepared names of indexed
+	// products for PrefixCandidates, the lookup behind autocomplete-style
+	// duplicate search. It costs a string reference and an entry per product.
+	// Off by default, which leaves the index as it was.
+	EnablePrefixIndex bool `json:",omitempty"`
+	// PrefixLSHTokens is the number of words typed from which PrefixCandidates
+	// also looks the text up in the LSH index (default DefaultPrefixLSHTokens)
+	PrefixLSHTokens int `json:",omitempty"`
+}
+
+// DefaultAdaptiveBandEpsilon is the default HybridConfig.AdaptiveBandEpsilon
+const DefaultAdaptiveBandEpsilon = 0.01
+
+// DefaultHybridConfig returns the default hybrid engine configuration
+func DefaultHybridConfig() HybridConfig {
+	return HybridConfig{
+		NumHashFunctions:  100, // Number of MinHash functions
+		NumBands:          20,  // Number of LSH bands
+		ShingleSize:       3,   // 3-gram shingles
+		ChunkedSignatures: false,
+		ChunkSize:         500, // ~1/6 of a maximum-length description
+		ChunkOverlap:      100,
+		SimHashScreen:     false,
+		SimHashMargin:     simHashSafetyMargin,
+
+		AdaptiveBandEpsilon: DefaultAdaptiveBandEpsilon,
+
+		CandidateWarnThreshold: DefaultCandidateWarnThreshold,
+
+		ExactModeCutoff: DefaultExactModeCutoff,
+
+		FastCompareFloor: DefaultFastCompareFloor,
+
+		PrefixLSHTokens: DefaultPrefixLSHTokens,
+	}
 }
 
 // NewHybridEngine creates a hybrid duplicate detection engine
 func NewHybridEngine() *HybridEngine {
-	return &HybridEngine{
-		levenshteinEngine: NewLevenshteinEngine(),
-		numHashFunctions:  100, // Number of MinHash functions
-		numBands:          20,  // Number of LSH bands
-		shingleSize:       3,   // 3-gram shingles
+	return NewHybridEngineWithConfig(DefaultHybridConfig())
+}
+
+// NewHybridEngineWithConfig creates a hybrid engine with custom tuning parameters
+// Invalid values fall back to the defaults
+func NewHybridEngineWithConfig(config HybridConfig) *HybridEngine {
+	defaults := DefaultHybridConfig()
+	if config.NumHashFunctions < 1 {
+		config.NumHashFunctions = defaults.NumHashFunctions
+	}
+	if config.NumBands < 1 || config.NumBands > config.NumHashFunctions {
+		config.NumBands = defaults.NumBands
+		if config.NumBands > config.NumHashFunctions {
+			config.NumBands = config.NumHashFunctions
+		}
+	}
+	if config.ShingleSize < 1 {
+		config.ShingleSize = defaults.ShingleSize
+	}
+	if config.SignatureScheme != SignatureOnePermutation {
+		config.SignatureScheme = SignatureKIndependent
 	}
+
+	engine := &HybridEngine{
+		levenshteinEngine:  NewLevenshteinEngine(),
+		numHashFunctions:   config.NumHashFunctions,
+		minHash:            newMinHashFamily(config.NumHashFunctions, DefaultLSHSeed, config.SignatureScheme),
+		numBands:           config.NumBands,
+		shingleSize:        config.ShingleSize,
+		simHash:            newMixedSimHashFilter(3),
+		simHashScreen:      config.SimHashScreen,
+		simHashMargin:      config.SimHashMargin,
+		threshold:          DefaultThreshold,
+		retainCandidates:   config.RetainCandidates,
+		retainMatches:      config.RetainMatches,
+		candidateWarn:      config.CandidateWarnThreshold,
+		maxCandidates:      config.MaxCandidates,
+		maxBucketFanout:    config.MaxBucketFanout,
+		splitCompounds:     config.SplitCompoundTokens,
+		adaptiveBands:      config.AdaptiveBands,
+		bandEpsilon:        config.AdaptiveBandEpsilon,
+		maxShingles:        config.MaxShinglesPerProduct,
+		maxIndexTextLength: config.MaxIndexTextLength,
+		buildWorkers:       config.BuildWorkers,
+		exactCutoff:        config.ExactModeCutoff,
+		autoCompact:        config.AutoCompact,
+		bandStore:          config.BandStore,
+		cliqueBands:        config.CliqueBands,
+		cliqueMinSize:      config.CliqueMinSize,
+		fastCompare:        config.FastCompare,
+		fastCompareFloor:   config.FastCompareFloor,
+		bucketSalt:         config.BucketSalt,
+		prefixIndex:        config.EnablePrefixIndex,
+		prefixLSHTokens:    config.PrefixLSHTokens,
+	}
+	if engine.simHashMargin <= 0 {
+		engine.simHashMargin = defaults.SimHashMargin
+	}
+	if engine.candidateWarn < 1 {
+		engine.candidateWarn = defaults.CandidateWarnThreshold
+	}
+	if engine.maxCandidates < 0 {
+		engine.maxCandidates = 0
+	}
+	if engine.maxBucketFanout < 0 {
+		engine.maxBucketFanout = 0
+	}
+	if engine.bandEpsilon <= 0 || engine.bandEpsilon >= 1 {
+		engine.bandEpsilon = defaults.AdaptiveBandEpsilon
+	}
+	if engine.maxShingles < 0 {
+		engine.maxShingles = 0
+	}
+	if engine.maxIndexTextLength < 0 {
+		engine.maxIndexTextLength = 0
+	}
+	if engine.prefixLSHTokens < 1 {
+		engine.prefixLSHTokens = defaults.PrefixLSHTokens
+	}
+	if engine.buildWorkers < 0 {
+		engine.buildWorkers = 0
+	}
+	if engine.exactCutoff < 0 {
+		engine.exactCutoff = 0
+	}
+	if engine.cliqueBands < 0 {
+		engine.cliqueBands = 0
+	}
+	if engine.cliqueMinSize < 2 {
+		engine.cliqueMinSize = DefaultCliqueMinSize
+	}
+	if engine.fastCompareFloor <= 0 || engine.fastCompareFloor > 1 {
+		engine.fastCompareFloor = defaults.FastCompareFloor
+	}
+
+	if config.ChunkedSignatures {
+		if config.ChunkSize < 1 {
+			config.ChunkSize = defaults.ChunkSize
+		}
+		if config.ChunkOverlap < 0 || config.ChunkOverlap >= config.ChunkSize {
+			config.ChunkOverlap = config.ChunkSize / 5
+		}
+		engine.chunkSize = config.ChunkSize
+		engine.chunkOverlap = config.ChunkOverlap
+	}
+
+	return engine
 }
 
 // GetName returns the name of this algorithm
@@ -42,188 +413,778 @@ func (e *HybridEngine) GetName() string {
 	return "Hybrid (MinHash+LSH → Levenshtein)"
 }
 
+// SetDuplicateIDPolicy controls how BuildIndex and FindDuplicates handle repeated Product IDs
+// The default is DuplicateIDReject
+func (e *HybridEngine) SetDuplicateIDPolicy(policy DuplicateIDPolicy) {
+	e.idPolicy = policy
+}
+
+// GetDuplicateIDPolicy returns the active duplicate ID policy
+func (e *HybridEngine) GetDuplicateIDPolicy() DuplicateIDPolicy {
+	return e.idPolicy
+}
+
 // BuildIndex creates the LSH index for a collection of products
 // This is done once during initialization or when products change
-func (e *HybridEngine) BuildIndex(products []Product) {
-	rowsPerBand := e.numHashFunctions / e.numBands
+// Returns a *DuplicateIDError (leaving any previous index untouched) when the
+// input repeats IDs under the DuplicateIDReject policy
+func (e *HybridEngine) BuildIndex(products []Product) (err error) {
+	defer e.levenshteinEngine.guard("BuildIndex", &err)
+	return e.BuildIndexCtx(context.Background(), products)
+}
 
-	e.lshIndex = &LSHIndex{
-		bands:       make([]map[uint64][]string, e.numBands),
-		numBands:    e.numBands,
-		rowsPerBand: rowsPerBand,
-		products:    make(map[string]Product),
+// BuildIndexCtx is BuildIndex with cancellation: when ctx is done, the build
+// stops and returns ctx.Err(), leaving any previous index untouched
+// Products are hashed on HybridConfig.BuildWorkers goroutines and merged in
+// input order, so the index is the same for any worker count.
+func (e *HybridEngine) BuildIndexCtx(ctx context.Context, products []Product) (err error) {
+	defer e.levenshteinEngine.guard("BuildIndexCtx", &err)
+	products, _, err = e.resolveProducts(products)
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+	workers := e.buildWorkerCount(len(products))
+	if e.logger != nil {
+		e.logger.LogAttrs(ctx, slog.LevelInfo, "index build started",
+			slog.String("engine", "hybrid"),
+			slog.Int("products", len(products)),
+			slog.Int("workers", workers))
+	}
+	e.logIndexedFields(ctx)
+
+	idx := e.newLSHIndex(0)
+	idx.buildWorkers = workers
+
+	// Index private copies so verification can share their caches by pointer
+	// without aliasing the caller's slice
+	indexed := make([]Product, len(products))
+	copy(indexed, products)
+	e.levenshteinEngine.interner.internProducts(indexed)
+	if err := e.indexProducts(ctx, idx, indexed, workers); err != nil {
+		if e.logger != nil {
+			e.logger.LogAttrs(context.Background(), slog.LevelWarn, "index build cancelled",
+				slog.String("engine", "hybrid"),
+				slog.Int("products", len(indexed)),
+				slog.Int("indexed", len(idx.ids)),
+				slog.Duration("duration", time.Since(started)))
+		}
+		return err
+	}
+	idx.buildDuration = time.Since(started)
+
+	// Publish the finished index; readers holding the old one keep a consistent view
+	e.indexMu.Lock()
+	e.lshIndex = idx
+	e.retained = nil
+	e.matches = nil
+	e.indexMu.Unlock()
+
+	if e.logger != nil {
+		e.logger.LogAttrs(context.Background(), slog.LevelInfo, "index build finished",
+			slog.String("engine", "hybrid"),
+			slog.Int("products", len(indexed)),
+			slog.Int("signatures", idx.totalChunks),
+			slog.Int("sampled", idx.sampled),
+			slog.Int("truncated", idx.truncated),
+			slog.Int("workers", workers),
+			slog.Float64("products_per_sec", idx.buildThroughput()),
+			slog.Duration("duration", idx.buildDuration))
 	}
+	return nil
+}
 
-	// Initialize band maps
-	for i := 0; i < e.numBands; i++ {
-		e.lshIndex.bands[i] = make(map[uint64][]string)
+// newLSHIndex returns an empty index for a catalog of catalogSize products
+// (0 when the index is built in one go)
+func (e *HybridEngine) newLSHIndex(catalogSize int) *LSHIndex {
+	idx := &LSHIndex{
+		bands:       e.newBandStore(),
+		numBands:    e.numBands,
+		rowsPerBand: e.numHashFunctions / e.numBands,
+		products:    make(map[string]*Product),
+		numbers:     make(map[string]uint32),
+		newBands:    e.newBandStore,
+		catalogSize: catalogSize,
+		salt:        e.newBucketSalt(),
 	}
+	if e.privacy != nil || e.simHashScreen {
+		idx.fingerprints = make(map[string]SimHashFingerprint)
+	}
+	if e.privacy != nil {
+		idx.contentHashes = make(map[string]uint64)
+	} else {
+		idx.queryBands = make(map[string]indexedBands)
+		if e.prefixIndex {
+			idx.prefixes = newPrefixIndex()
+		}
+	}
+	return idx
+}
 
-	// Index each product
-	for _, product := range products {
-		e.indexProduct(product)
+// computeSignatures returns the MinHash signatures indexed for a text
+// In chunked mode, text longer than one chunk yields a signature per chunk
+func (e *HybridEngine) computeSignatures(text string) [][]uint32 {
+	signatures, _, _ := e.indexSignatures(text)
+	return signatures
+}
+
+// indexSignatures is computeSignatures, also returning the shingles generated
+// before sampling and whether any signature was sampled
+func (e *HybridEngine) indexSignatures(text string) ([][]uint32, int, bool) {
+	chunks := []string{text}
+	if e.chunkSize > 0 {
+		chunks = splitChunks(text, e.chunkSize, e.chunkOverlap)
+	}
+	signatures := make([][]uint32, 0, len(chunks))
+	total, anySampled := 0, false
+	for _, chunk := range chunks {
+		shingles, generated, sampled := e.limitedShingles(chunk)
+		signatures = append(signatures, computeMinHashSignature(shingles, e.minHash))
+		total += generated
+		anySampled = anySampled || sampled
 	}
+	return signatures, total, anySampled
 }
 
-// indexProduct adds a product to the LSH index
-func (e *HybridEngine) indexProduct(product Product) {
-	// Store product
-	e.lshIndex.products[product.ID] = product
+// splitChunks splits text into chunks of size runes, consecutive chunks sharing overlap runes
+// Text that fits in one chunk is returned as is
+func splitChunks(text string, size, overlap int) []string {
+	runes := []rune(text)
+	if len(runes) <= size {
+		return []string{text}
+	}
 
-	// Generate combined text for hashing
-	text := strings.ToLower(product.Name + " " + product.Description)
+	step := size - overlap
+	chunks := make([]string, 0, len(runes)/step+1)
+	for start := 0; start < len(runes); start += step {
+		end := start + size
+		if end >= len(runes) {
+			chunks = append(chunks, string(runes[start:]))
+			break
+		}
+		chunks = append(chunks, string(runes[start:end]))
+	}
+	return chunks
+}
 
-	// Generate shingles (n-grams)
-	shingles := generateShingles(text, e.shingleSize)
+// SetDefaultThreshold changes the threshold used by IsDuplicate, FindDuplicatesDefault
+// and stamped on Compare results; returns an error outside [0.0-1.0]
+func (e *HybridEngine) SetDefaultThreshold(threshold float64) error {
+	if err := validateThreshold(threshold); err != nil {
+		return err
+	}
+	e.threshold = threshold
+	return nil
+}
 
-	// Compute MinHash signature
-	signature := computeMinHashSignature(shingles, e.numHashFunctions)
+// GetDefaultThreshold returns the engine's default threshold
+func (e *HybridEngine) GetDefaultThreshold() float64 {
+	return e.threshold
+}
 
-	// Add to LSH bands
-	for bandIdx := 0; bandIdx < e.numBands; bandIdx++ {
-		// Hash this band's rows together
-		bandHash := hashBand(signature, bandIdx*e.lshIndex.rowsPerBand,
-			(bandIdx+1)*e.lshIndex.rowsPerBand)
+// IsDuplicate compares two products against the default threshold
+func (e *HybridEngine) IsDuplicate(a, b Product) (bool, ComparisonResult) {
+	defer e.levenshteinEngine.guard("IsDuplicate", nil)
+	result := e.Compare(a, b)
+	return result.MeetsThreshold, result
+}
 
-		// Add product ID to this band bucket
-		e.lshIndex.bands[bandIdx][bandHash] = append(
-			e.lshIndex.bands[bandIdx][bandHash],
-			product.ID,
-		)
-	}
+// FindDuplicatesDefault is FindDuplicates with the default threshold
+func (e *HybridEngine) FindDuplicatesDefault(products []Product) []ComparisonResult {
+	defer e.levenshteinEngine.guard("FindDuplicatesDefault", nil)
+	return e.FindDuplicates(products, e.threshold)
+}
+
+// WithWeightResolver sets a resolver that picks weights per pair during
+// verification and Compare; pass nil to remove it. Returns the engine for chaining.
+func (e *HybridEngine) WithWeightResolver(resolver WeightResolver) *HybridEngine {
+	e.levenshteinEngine.WithWeightResolver(resolver)
+	return e
 }
 
 // Compare implements single product comparison (for interface compatibility)
 func (e *HybridEngine) Compare(a, b Product) ComparisonResult {
-	return e.levenshteinEngine.Compare(a, b)
+	defer e.levenshteinEngine.guard("Compare", nil)
+	return e.ComparePtr(&a, &b)
+}
+
+// ComparePtr is Compare without copying the products
+// With HybridConfig.FastCompare, pairs estimated far apart skip Levenshtein.
+func (e *HybridEngine) ComparePtr(a, b *Product) ComparisonResult {
+	defer e.levenshteinEngine.guard("ComparePtr", nil)
+	if e.fastCompare {
+		return e.compareFast(a, b)
+	}
+	return e.compareExact(a, b)
 }
 
 // CompareWithWeights implements weighted comparison (for interface compatibility)
 func (e *HybridEngine) CompareWithWeights(a, b Product, weights ComparisonWeights) ComparisonResult {
-	return e.levenshteinEngine.CompareWithWeights(a, b, weights)
+	defer e.levenshteinEngine.guard("CompareWithWeights", nil)
+	result := e.levenshteinEngine.CompareWithWeights(a, b, weights)
+	result.stampThreshold(e.threshold)
+	return result
 }
 
 // FindDuplicates uses the hybrid multi-stage approach
 // Stage 1: LSH filtering (reduces to ~1-5% of corpus)
 // Stage 2: Levenshtein verification on candidates
+//
+// With the default DuplicateIDReject policy, FindDuplicates returns nil when the
+// input contains repeated IDs; use FindDuplicatesChecked to receive the error.
 func (e *HybridEngine) FindDuplicates(products []Product, threshold float64) []ComparisonResult {
-	if e.lshIndex == nil {
-		// Fallback to regular Levenshtein if index not built
-		return e.levenshteinEngine.FindDuplicates(products, threshold)
+	defer e.levenshteinEngine.guard("FindDuplicates", nil)
+	duplicates, _ := e.FindDuplicatesChecked(products, threshold)
+	return duplicates
+}
+
+// FindDuplicatesChecked is like FindDuplicates but reports input problems
+// Returns a *DuplicateIDError when the input repeats IDs under DuplicateIDReject
+func (e *HybridEngine) FindDuplicatesChecked(products []Product, threshold float64) (_ []ComparisonResult, err error) {
+	defer e.levenshteinEngine.guard("FindDuplicatesChecked", &err)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil, err
 	}
+	return e.findDuplicatesUnchecked(productPtrs(resolved), threshold), nil
+}
+
+// FindDuplicatesPtr is FindDuplicates over product pointers
+// Candidates are verified against the indexed products by pointer, so neither
+// side of a pair is copied until it is reported
+func (e *HybridEngine) FindDuplicatesPtr(products []*Product, threshold float64) []ComparisonResult {
+	defer e.levenshteinEngine.guard("FindDuplicatesPtr", nil)
+	resolved, err := resolveInputPtrs(products, e.idPolicy, e.levenshteinEngine.keepInputCopies)
+	if err != nil {
+		return nil
+	}
+	return e.findDuplicatesUnchecked(resolved, threshold)
+}
 
+// findDuplicatesUnchecked runs the hybrid scan without validating IDs
+func (e *HybridEngine) findDuplicatesUnchecked(products []*Product, threshold float64) []ComparisonResult {
 	var duplicates []ComparisonResult
+	if e.levenshteinEngine.shaping.KeepOnlyBestPerProduct {
+		duplicates = e.levenshteinEngine.variantOutput(e.findBestPairs(products, threshold))
+	} else {
+		duplicates = e.levenshteinEngine.variantOutput(e.findPairs(products, threshold))
+	}
+	e.levenshteinEngine.writeVerificationSample(products, threshold)
+	return duplicates
+}
+
+// findPairs is findDuplicatesUnchecked returning variants whatever
+// VariantOptions.Output says
+func (e *HybridEngine) findPairs(products []*Product, threshold float64) []ComparisonResult {
+	duplicates := []ComparisonResult{}
+	e.streamScan(context.Background(), products, threshold, nil, func(result ComparisonResult) bool {
+		duplicates = append(duplicates, result)
+		return true
+	})
+	return duplicates
+}
+
+// streamScan is findPairs passing each match to yield as it is found, on the
+// calling goroutine; the scan stops early when yield returns false or ctx is
+// done, which the full-scan fallback also passes to the description loader
+// With best, pairs no product can keep are dropped before reaching yield (nil
+// = keep every pair).
+func (e *HybridEngine) streamScan(ctx context.Context, products []*Product, threshold float64, best *bestPerProduct, yield func(ComparisonResult) bool) {
+	idx := e.currentIndex()
+	if idx == nil {
+		// Fallback to regular Levenshtein if index not built
+		if e.logger != nil {
+			e.logger.LogAttrs(context.Background(), slog.LevelWarn, "index not built, falling back to full scan",
+				slog.String("engine", "hybrid"),
+				slog.Int("products", len(products)))
+		}
+		e.levenshteinEngine.streamScan(ctx, ctx.Done(), products, threshold, best, yield)
+		return
+	}
+
+	var started time.Time
+	var skippedBefore, constrainedBefore uint64
+	if e.logger != nil {
+		path := "indexed"
+		if e.exhaustive(idx) {
+			path = "exhaustive"
+		}
+		logScanStarted(e.logger, "hybrid", path, len(products), threshold)
+		started, skippedBefore = time.Now(), atomic.LoadUint64(&e.simHashSkipped)
+		constrainedBefore = e.levenshteinEngine.constraintSkips()
+	}
+
+	quality := e.levenshteinEngine.newQualityCheck()
+	if quality != nil {
+		products = quality.products(products)
+	}
+
+	done := ctx.Done()
 	checked := make(map[string]bool) // Track checked pairs to avoid duplicates
+	cliques := e.newCliqueTracker(idx)
 
-	// For each product, find candidates using LSH
-	for _, product := range products {
-		candidates := e.findCandidates(product)
+	audit := e.levenshteinEngine.startAudit()
+	defer audit.finish()
 
-		// Stage 3: Precise verification with Levenshtein
-		for _, candidateID := range candidates {
-			// Skip self-comparison
-			if candidateID == product.ID {
-				continue
-			}
+	var retained *retainedCandidates
+	if e.retainCandidates && e.privacy == nil {
+		retained = &retainedCandidates{index: idx}
+	}
+	var partners *pairPartners
+	if e.retainMatches {
+		partners = newPairPartners()
+	}
 
-			// Skip if already checked this pair
-			pairKey := makePairKey(product.ID, candidateID)
-			if checked[pairKey] {
-				continue
+	// Stage 1: each product's LSH candidates, each pair once
+	generate := func(visit func(hybridPair) bool) {
+		if !idx.canPair(products) {
+			return
+		}
+		for _, product := range products {
+			if done != nil {
+				select {
+				case <-done:
+					return
+				default:
+				}
 			}
-			checked[pairKey] = true
-
-			// Get candidate product
-			candidate, exists := e.lshIndex.products[candidateID]
-			if !exists {
-				continue
+			candidates := e.findCandidates(idx, product, threshold)
+			atomic.AddUint64(&e.scanCandidates, uint64(len(candidates)))
+			cliques.form(product.ID, candidates, checked)
+			query := e.newQuery(product)
+			queryIdx := -1
+			if retained != nil {
+				retained.queries = append(retained.queries, *product)
+				queryIdx = len(retained.queries) - 1
 			}
 
-			// Precise comparison with Levenshtein
-			result := e.levenshteinEngine.Compare(product, candidate)
+			for _, candidate := range candidates {
+				candidateID := candidate.id
+
+				// Skip self-comparison
+				if candidateID == product.ID {
+					continue
+				}
+
+				// Skip if already checked this pair
+				pairKey := makePairKey(product.ID, candidateID)
+				if checked[pairKey] {
+					continue
+				}
+				checked[pairKey] = true
+				if cliques.inferred(product.ID, candidateID) {
+					continue
+				}
+				if retained != nil {
+					retained.add(queryIdx, candidateID)
+				}
+				pair := hybridPair{product: product, query: query, candidateID: candidateID,
+					clique: cliques.spans(product.ID, candidateID)}
+				atomic.AddUint64(&e.scanPairs, 1)
+				if !visit(pair) {
+					return
+				}
+			}
+		}
+	}
 
-			if result.CombinedSimilarity >= threshold {
-				duplicates = append(duplicates, result)
+	// Stage 2: precise verification with Levenshtein, in candidate order
+	matches := 0
+	runStages(1, generate, func(pair hybridPair) (ComparisonResult, bool) {
+		result, ok := e.verifyCandidate(idx, pair.product, pair.query, pair.candidateID, threshold)
+		if ok {
+			result.stampThreshold(threshold)
+		}
+		if pair.clique {
+			// Members failing their representative are verified pair by pair
+			if !ok || !result.MeetsThreshold {
+				cliques.evict(pair.candidateID)
 			}
+			result.ClusterInferred = true
+			result.assignReasons()
+		}
+		if ok {
+			audit.record(&result)
+		}
+		return result, ok && result.MeetsThreshold
+	}, func(result ComparisonResult) bool {
+		// Indexed candidates are checked here; queries were filtered above
+		if quality != nil && !quality.flag(&result) && quality.mode == QualityExclude {
+			return true
 		}
+		if !best.offer(result.ProductA.ID, result.ProductB.ID, result.CombinedSimilarity) {
+			return true
+		}
+		matches++
+		if partners != nil {
+			partners.set(result.ProductA.ID, result.ProductB.ID, result.CombinedSimilarity)
+		}
+		return yield(result)
+	})
+	cliques.finish(e)
+
+	if retained != nil {
+		// Concurrent ReVerify calls then only read the scanned products' caches
+		warmCaches(productPtrs(retained.queries), e.preparer())
+		e.indexMu.Lock()
+		e.retained = retained
+		e.indexMu.Unlock()
+	}
+	if partners != nil {
+		e.indexMu.Lock()
+		e.matches = partners
+		e.indexMu.Unlock()
 	}
 
-	return duplicates
+	if e.logger != nil {
+		if e.simHashScreen {
+			logPreFilterSummary(e.logger, "hybrid", "simhash", atomic.LoadUint64(&e.simHashSkipped)-skippedBefore)
+		}
+		if e.levenshteinEngine.pairConstraint != nil {
+			logPreFilterSummary(e.logger, "hybrid", "pair-constraint", e.levenshteinEngine.constraintSkips()-constrainedBefore)
+		}
+		if cliques != nil {
+			logPreFilterSummary(e.logger, "hybrid", "clique", cliques.skipped)
+		}
+		logScanFinished(e.logger, "hybrid", matches, started)
+	}
 }
 
 // FindDuplicatesForOne finds duplicates for a single product against the indexed corpus
 // This is the key method for the "1 article vs 500 articles" scenario
+// Candidates are verified strongest first (most shared LSH bands), so results
+// are ordered roughly by likelihood of being a duplicate. An indexed product
+// with the query's ID is skipped (see QueryOptions).
 func (e *HybridEngine) FindDuplicatesForOne(product Product, threshold float64) []ComparisonResult {
-	if e.lshIndex == nil {
-		return nil
+	defer e.levenshteinEngine.guard("FindDuplicatesForOne", nil)
+	duplicates, _ := e.FindDuplicatesForOneChecked(product, threshold)
+	return duplicates
+}
+
+// FindDuplicatesForOneChecked is like FindDuplicatesForOne but reports problems
+// Returns ErrIndexNotBuilt without an index, and ErrQueryTruncated together with
+// the results when the query exceeded HybridConfig.MaxCandidates.
+func (e *HybridEngine) FindDuplicatesForOneChecked(product Product, threshold float64) (_ []ComparisonResult, err error) {
+	defer e.levenshteinEngine.guard("FindDuplicatesForOneChecked", &err)
+	idx := e.currentIndex()
+	if idx == nil {
+		return nil, ErrIndexNotBuilt
+	}
+
+	defer e.monitorRecall(idx, threshold)
+	return e.findDuplicatesForOne(idx, &product, threshold)
+}
+
+// FindDuplicatesForOneWithOptions is FindDuplicatesForOneChecked with control
+// over which indexed products the query skips, also returning what it skipped
+// Skipped candidates are never verified.
+func (e *HybridEngine) FindDuplicatesForOneWithOptions(product Product, threshold float64, opts QueryOptions) (_ []ComparisonResult, _ QuerySummary, err error) {
+	defer e.levenshteinEngine.guard("FindDuplicatesForOneWithOptions", &err)
+	idx := e.currentIndex()
+	if idx == nil {
+		return nil, QuerySummary{}, ErrIndexNotBuilt
 	}
 
+	defer e.monitorRecall(idx, threshold)
+	return e.queryIndex(idx, &product, threshold, opts)
+}
+
+// findDuplicatesForOne runs FindDuplicatesForOneChecked against idx
+func (e *HybridEngine) findDuplicatesForOne(idx *LSHIndex, product *Product, threshold float64) ([]ComparisonResult, error) {
+	duplicates, _, err := e.queryIndex(idx, product, threshold, QueryOptions{})
+	return duplicates, err
+}
+
+// queryIndex runs FindDuplicatesForOneWithOptions against idx
+func (e *HybridEngine) queryIndex(idx *LSHIndex, product *Product, threshold float64, opts QueryOptions) ([]ComparisonResult, QuerySummary, error) {
 	// Stage 1: Fast LSH filtering
-	candidates := e.findCandidates(product)
+	candidates, truncated := e.findCandidatesCapped(idx, product, threshold)
+	query := e.newQuery(product)
+	exclusion := newQueryExclusion(product, opts)
 
-	var duplicates []ComparisonResult
+	var summary QuerySummary
+	duplicates := []ComparisonResult{}
 
 	// Stage 2: Precise verification with Levenshtein (only on candidates)
-	for _, candidateID := range candidates {
-		candidate, exists := e.lshIndex.products[candidateID]
-		if !exists {
+	for _, candidate := range candidates {
+		if exclusion.skips(candidate.id, &summary) {
+			continue
+		}
+		result, ok := e.verifyCandidate(idx, product, query, candidate.id, threshold)
+		if !ok {
 			continue
 		}
 
-		result := e.levenshteinEngine.Compare(product, candidate)
-
-		if result.CombinedSimilarity >= threshold {
+		result.stampThreshold(threshold)
+		if result.MeetsThreshold {
 			duplicates = append(duplicates, result)
 		}
 	}
 
-	return duplicates
+	summary.Duplicates = len(duplicates)
+	if truncated {
+		return duplicates, summary, ErrQueryTruncated
+	}
+	return duplicates, summary, nil
+}
+
+// HasDuplicate reports whether the indexed corpus holds any duplicate of product
+// It verifies candidates strongest first and stops at the first match, which is
+// much cheaper than FindDuplicatesForOne for "is there ANY duplicate?" gating.
+// Returns the matching result, or false and an empty result if none is found.
+// An indexed product with the query's ID is skipped (see QueryOptions).
+func (e *HybridEngine) HasDuplicate(product Product, threshold float64) (bool, ComparisonResult) {
+	defer e.levenshteinEngine.guard("HasDuplicate", nil)
+	return e.HasDuplicateWithOptions(product, threshold, QueryOptions{})
+}
+
+// HasDuplicateWithOptions is HasDuplicate with control over which indexed
+// products the query skips, before they are verified
+func (e *HybridEngine) HasDuplicateWithOptions(product Product, threshold float64, opts QueryOptions) (bool, ComparisonResult) {
+	defer e.levenshteinEngine.guard("HasDuplicateWithOptions", nil)
+	idx := e.currentIndex()
+	if idx == nil {
+		return false, ComparisonResult{}
+	}
+
+	candidates := e.findCandidates(idx, &product, threshold)
+	query := e.newQuery(&product)
+	exclusion := newQueryExclusion(&product, opts)
+
+	var summary QuerySummary
+	for _, candidate := range candidates {
+		if exclusion.skips(candidate.id, &summary) {
+			continue
+		}
+		result, ok := e.verifyCandidate(idx, &product, query, candidate.id, threshold)
+		if !ok {
+			continue
+		}
+
+		result.stampThreshold(threshold)
+		if result.MeetsThreshold {
+			return true, result
+		}
+	}
+
+	return false, ComparisonResult{}
+}
+
+// hybridPair is a query product and one of its LSH candidates
+type hybridPair struct {
+	product     *Product
+	query       hybridQuery
+	candidateID string
+	clique      bool // product represents a clique candidateID belongs to
+}
+
+// hybridQuery holds per-query values reused across every candidate
+type hybridQuery struct {
+	text        string             // indexText of the query product
+	fingerprint SimHashFingerprint // SimHash of text, only computed when a SimHash stage needs it
+}
+
+// newQuery prepares the per-query values for a product
+func (e *HybridEngine) newQuery(product *Product) hybridQuery {
+	query := hybridQuery{text: e.indexText(product)}
+	if e.privacy != nil || e.simHashScreen {
+		query.fingerprint = e.simHash.Compute64(query.text)
+	}
+	return query
+}
+
+// verifyCandidate runs the final verification stage for one candidate
+// Returns false if the candidate can't be verified or was screened out
+func (e *HybridEngine) verifyCandidate(idx *LSHIndex, product *Product, query hybridQuery, candidateID string, threshold float64) (ComparisonResult, bool) {
+	if !e.candidateAllowed(idx, product, candidateID) {
+		return ComparisonResult{}, false
+	}
+	if e.privacy != nil {
+		result, ok := e.estimateCandidate(idx, product, query, candidateID)
+		result.CandidateSource = e.candidateSource(idx)
+		return result, ok
+	}
+
+	// Optional SimHash screen: cheap O(1) estimate before O(m×n) Levenshtein
+	// (pairs the IDs identify as one entity match whatever their text, and
+	// SKU-like names match by key, whatever their separators)
+	sameEntity := e.levenshteinEngine.idRelation(product.ID, candidateID) == IDSameEntity
+	if e.simHashScreen && !sameEntity {
+		if fingerprint, exists := idx.fingerprints[candidateID]; exists &&
+			!QuickRejectFingerprints(query.fingerprint, fingerprint, threshold, e.simHashMargin) &&
+			!e.skuPair(product, idx.products[candidateID]) {
+			atomic.AddUint64(&e.simHashSkipped, 1)
+			return ComparisonResult{}, false
+		}
+	}
+
+	candidate, exists := idx.products[candidateID]
+	if !exists {
+		return ComparisonResult{}, false
+	}
+	weights := e.levenshteinEngine.resolveWeights(product, candidate)
+	if !sameEntity && e.levenshteinEngine.charsetRejects(product, candidate, weights, threshold) {
+		return ComparisonResult{}, false
+	}
+	atomic.AddUint64(&e.verifiedPairs, 1)
+	result := e.levenshteinEngine.compareWithWeights(product, candidate, weights, memoPair{}, threshold)
+	result.CandidateSource = e.candidateSource(idx)
+	return result, true
+}
+
+// lshCandidate is a product that shares at least one LSH bucket with a query
+type lshCandidate struct {
+	id         string
+	collisions int // Number of band buckets shared with the query (across chunks in chunked mode)
 }
 
 // findCandidates uses LSH to find similar products quickly
-// Returns product IDs that are likely similar
-func (e *HybridEngine) findCandidates(product Product) []string {
-	// Generate combined text
-	text := strings.ToLower(product.Name + " " + product.Description)
+// Returns candidates ranked by band collisions, strongest first (ties by ID),
+// so callers that stop early verify the most promising candidates first
+// threshold selects the bands probed with AdaptiveBands (0 probes them all).
+// Below the exact mode cutoff every indexed product is returned unranked.
+func (e *HybridEngine) findCandidates(idx *LSHIndex, product *Product, threshold float64) []lshCandidate {
+	candidates, _ := e.findCandidatesCapped(idx, product, threshold)
+	return candidates
+}
 
-	// Generate shingles
-	shingles := generateShingles(text, e.shingleSize)
+// findCandidatesCapped is findCandidates, also reporting whether the ranked
+// list was cut to maxCandidates
+func (e *HybridEngine) findCandidatesCapped(idx *LSHIndex, product *Product, threshold float64) ([]lshCandidate, bool) {
+	if e.exhaustive(idx) {
+		return allCandidates(idx), false
+	}
 
-	// Compute MinHash signature
-	signature := computeMinHashSignature(shingles, e.numHashFunctions)
+	// Band hashes of the MinHash signatures (one per chunk in chunked mode),
+	// as the product was indexed under when it is unchanged
+	hashes := e.queryBandHashes(idx, product)
+
+	// Count band collisions per candidate across the probed bands of every
+	// signature, skipping removed products until Compact drops them
+	bands := e.probeBands(threshold)
+	atomic.AddUint64(&e.bandQueries, 1)
+	atomic.AddUint64(&e.probedBands, uint64(bands))
+	collisions, skipped := e.bandCollisions(idx, hashes, bands)
+
+	// Convert to a ranked slice
+	candidates := make([]lshCandidate, 0, len(collisions))
+	for number, count := range collisions {
+		candidates = append(candidates, lshCandidate{id: idx.refs[number], collisions: count})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].collisions != candidates[j].collisions {
+			return candidates[i].collisions > candidates[j].collisions
+		}
+		return candidates[i].id < candidates[j].id
+	})
 
-	// Find candidates by checking all bands
-	candidateSet := make(map[string]bool)
+	if skipped > 0 {
+		atomic.AddUint64(&e.skippedBuckets, uint64(skipped))
+		if e.logger != nil {
+			e.logger.LogAttrs(context.Background(), slog.LevelWarn, "oversized buckets skipped",
+				slog.String("engine", "hybrid"),
+				slog.String("product_id", product.ID),
+				slog.Int("buckets", skipped),
+				slog.Int("max_bucket_fanout", e.maxBucketFanout))
+		}
+	}
+	if e.logger != nil && len(candidates) > e.candidateWarn {
+		e.logger.LogAttrs(context.Background(), slog.LevelWarn, "too many candidates",
+			slog.String("engine", "hybrid"),
+			slog.String("product_id", product.ID),
+			slog.Int("candidates", len(candidates)),
+			slog.Int("warn_threshold", e.candidateWarn))
+	}
 
-	for bandIdx := 0; bandIdx < e.numBands; bandIdx++ {
-		// Hash this band
-		bandHash := hashBand(signature, bandIdx*e.lshIndex.rowsPerBand,
-			(bandIdx+1)*e.lshIndex.rowsPerBand)
+	// Overflow: keep the strongest candidates (the list is already ranked)
+	if e.maxCandidates > 0 && len(candidates) > e.maxCandidates {
+		atomic.AddUint64(&e.truncatedQueries, 1)
+		if e.logger != nil {
+			e.logger.LogAttrs(context.Background(), slog.LevelWarn, "candidates truncated",
+				slog.String("engine", "hybrid"),
+				slog.String("product_id", product.ID),
+				slog.Int("candidates", len(candidates)),
+				slog.Int("max_candidates", e.maxCandidates))
+		}
+		return candidates[:e.maxCandidates], true
+	}
+	return candidates, false
+}
 
-		// Get all products in this bucket
-		if bucket, exists := e.lshIndex.bands[bandIdx][bandHash]; exists {
-			for _, productID := range bucket {
-				candidateSet[productID] = true
+// bandCollisions counts the first bands buckets each indexed product shares
+// with the band hashes of a query's signatures (numBands per signature), and
+// how many oversized buckets were skipped
+func (e *HybridEngine) bandCollisions(idx *LSHIndex, hashes []uint64, bands int) (map[uint32]int, int) {
+	collisions := make(map[uint32]int)
+	skipped := 0
+
+	for start := 0; start+idx.numBands <= len(hashes); start += idx.numBands {
+		for bandIdx := 0; bandIdx < bands; bandIdx++ {
+			bandHash := hashes[start+bandIdx]
+
+			// Get all products in this bucket
+			if bucket := idx.bands.Get(bandIdx, idx.bucketHash(bandHash)); len(bucket) > 0 {
+				if e.maxBucketFanout > 0 && idx.bucketSize(bucket) > e.maxBucketFanout {
+					skipped++
+					continue
+				}
+				for _, number := range bucket {
+					if !idx.removed[number] {
+						collisions[number]++
+					}
+				}
 			}
 		}
 	}
+	return collisions, skipped
+}
 
-	// Convert set to slice
-	candidates := make([]string, 0, len(candidateSet))
-	for id := range candidateSet {
-		candidates = append(candidates, id)
+// probeBands returns how many bands a query at threshold probes
+// Treating threshold as the Jaccard similarity of a pair's shingle sets, the
+// pair collides in one band of r rows with probability p = threshold^r and is
+// missed by k bands with probability (1-p)^k. With AdaptiveBands the smallest
+// k keeping that at or below bandEpsilon is probed; otherwise, or when no k
+// up to numBands does, every band is.
+func (e *HybridEngine) probeBands(threshold float64) int {
+	if !e.adaptiveBands || threshold <= 0 {
+		return e.numBands
+	}
+	if threshold >= 1 {
+		return 1
+	}
+	p := math.Pow(threshold, float64(e.numHashFunctions/e.numBands))
+	if p >= 1 {
+		return 1
+	}
+	bands := int(math.Ceil(math.Log(e.bandEpsilon) / math.Log1p(-p)))
+	if bands < 1 {
+		return 1
 	}
+	if bands > e.numBands {
+		return e.numBands
+	}
+	return bands
+}
 
-	return candidates
+// indexText returns the combined prepared text used for shingling and fingerprints
+// It is built from the same prepared strings verification compares, cut to
+// MaxIndexTextLength.
+func (e *HybridEngine) indexText(product *Product) string {
+	text, _ := e.limitedIndexText(product)
+	return text
 }
 
-// generateShingles creates n-gram shingles from text
+// generateShingles creates word n-gram shingles from text
+// Words are split as by shingleTokens without splitting compounds.
 func generateShingles(text string, n int) []string {
-	// Clean text: lowercase and split into tokens
-	tokens := strings.Fields(text)
+	return shinglesOf(shingleTokens(text, false), n)
+}
 
+// shinglesOf joins every run of n consecutive tokens into a shingle
+// Fewer than n tokens make a single shingle of all of them.
+func shinglesOf(tokens []string, n int) []string {
 	if len(tokens) < n {
-		return []string{text}
+		return []string{strings.Join(tokens, " ")}
 	}
 
 	shingles := make([]string, 0, len(tokens)-n+1)
@@ -237,37 +1198,6 @@ func generateShingles(text string, n int) []string {
 	return shingles
 }
 
-// computeMinHashSignature computes MinHash signature for a set of shingles
-func computeMinHashSignature(shingles []string, numHashes int) []uint32 {
-	signature := make([]uint32, numHashes)
-
-	// Initialize with max values
-	for i := range signature {
-		signature[i] = math.MaxUint32
-	}
-
-	// For each shingle
-	for _, shingle := range shingles {
-		// Hash with different seeds
-		for i := 0; i < numHashes; i++ {
-			hash := hashWithSeed(shingle, uint32(i))
-			if hash < signature[i] {
-				signature[i] = hash
-			}
-		}
-	}
-
-	return signature
-}
-
-// hashWithSeed hashes a string with a given seed
-func hashWithSeed(s string, seed uint32) uint32 {
-	h := fnv.New32a()
-	h.Write([]byte(s))
-	h.Write([]byte{byte(seed), byte(seed >> 8), byte(seed >> 16), byte(seed >> 24)})
-	return h.Sum32()
-}
-
 // hashBand hashes a portion of the signature to create a band hash
 func hashBand(signature []uint32, start, end int) uint64 {
 	h := fnv.New64a()
@@ -293,15 +1223,18 @@ func makePairKey(id1, id2 string) string {
 
 // GetIndexStats returns statistics about the LSH index
 func (e *HybridEngine) GetIndexStats() map[string]interface{} {
-	if e.lshIndex == nil {
+	idx := e.currentIndex()
+	if idx == nil {
 		return map[string]interface{}{"indexed": false}
 	}
 
 	stats := map[string]interface{}{
-		"indexed":        true,
-		"total_products": len(e.lshIndex.products),
-		"num_bands":      e.numBands,
-		"rows_per_band":  e.lshIndex.rowsPerBand,
+		"indexed":            true,
+		"total_products":     idx.size(),
+		"privacy_mode":       e.privacy != nil,
+		"plaintext_retained": len(idx.products) > 0,
+		"num_bands":          e.numBands,
+		"rows_per_band":      idx.rowsPerBand,
 	}
 
 	// Calculate average bucket size
@@ -309,32 +1242,88 @@ func (e *HybridEngine) GetIndexStats() map[string]interface{} {
 	totalProducts := 0
 	maxBucketSize := 0
 
-	for _, band := range e.lshIndex.bands {
-		totalBuckets += len(band)
-		for _, bucket := range band {
-			size := len(bucket)
+	for band := 0; band < idx.numBands; band++ {
+		idx.bands.ForEach(band, func(_ uint64, bucket []uint32) bool {
+			totalBuckets++
+			size := len(bucket) // Removed products included, until Compact
 			totalProducts += size
 			if size > maxBucketSize {
 				maxBucketSize = size
 			}
-		}
+			return true
+		})
 	}
 
+	avgBucketSize := 0.0
 	if totalBuckets > 0 {
-		stats["avg_bucket_size"] = float64(totalProducts) / float64(totalBuckets)
+		avgBucketSize = float64(totalProducts) / float64(totalBuckets)
 	}
+	stats["avg_bucket_size"] = avgBucketSize
 	stats["max_bucket_size"] = maxBucketSize
 	stats["total_buckets"] = totalBuckets
 
+	stats["simhash_screen"] = e.simHashScreen
+	stats["simhash_skipped"] = atomic.LoadUint64(&e.simHashSkipped)
+	stats["constraint_skipped_pairs"] = e.levenshteinEngine.constraintSkips()
+	stats["verified_pairs"] = atomic.LoadUint64(&e.verifiedPairs)
+	stats["band_hash_reuses"] = atomic.LoadUint64(&e.bandReuses)
+	stats["stale_product_queries"] = atomic.LoadUint64(&e.staleQueries)
+	stats["scan_candidates"] = atomic.LoadUint64(&e.scanCandidates)
+	stats["scan_candidate_pairs"] = atomic.LoadUint64(&e.scanPairs)
+
+	stats["clique_bands"] = e.cliqueBands
+	stats["clique_inferred_pairs"] = atomic.LoadUint64(&e.cliqueSkipped)
+
+	stats["max_candidates"] = e.maxCandidates
+	stats["max_bucket_fanout"] = e.maxBucketFanout
+	stats["truncated_queries"] = atomic.LoadUint64(&e.truncatedQueries)
+	stats["skipped_buckets"] = atomic.LoadUint64(&e.skippedBuckets)
+
+	stats["adaptive_bands"] = e.adaptiveBands
+	stats["adaptive_band_epsilon"] = e.bandEpsilon
+	queries := atomic.LoadUint64(&e.bandQueries)
+	stats["probed_bands"] = atomic.LoadUint64(&e.probedBands)
+	if queries > 0 {
+		stats["avg_probed_bands"] = float64(atomic.LoadUint64(&e.probedBands)) / float64(queries)
+	}
+
+	stats["max_shingles_per_product"] = e.maxShingles
+	stats["max_index_text_length"] = e.maxIndexTextLength
+	stats["sampled_products"] = idx.sampled
+	stats["truncated_products"] = idx.truncated
+
+	stats["index_coverage"] = idx.coverage()
+
+	stats["exact_mode"] = e.exhaustive(idx)
+	stats["exact_mode_cutoff"] = e.exactCutoff
+
+	stats["removed_products"] = len(idx.removed)
+
+	stats["build_workers"] = idx.buildWorkers
+	stats["build_duration"] = idx.buildDuration
+	stats["build_products_per_sec"] = idx.buildThroughput()
+
+	stats["prefix_index"] = idx.prefixes != nil
+	if idx.prefixes != nil {
+		stats["prefix_entries"] = idx.prefixes.size()
+	}
+
+	stats["chunked_mode"] = e.chunkSize > 0
+	stats["total_chunks"] = idx.totalChunks
+	if size := idx.size(); size > 0 {
+		stats["avg_chunks_per_product"] = float64(idx.totalChunks) / float64(size)
+	}
+
 	return stats
 }
 
 // EstimateCandidateReduction estimates how many candidates LSH will find
 func (e *HybridEngine) EstimateCandidateReduction(product Product) int {
-	if e.lshIndex == nil {
+	idx := e.currentIndex()
+	if idx == nil {
 		return 0
 	}
-	candidates := e.findCandidates(product)
+	candidates := e.findCandidates(idx, &product, 0)
 	return len(candidates)
 }
 
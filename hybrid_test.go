@@ -1,7 +1,13 @@
 package duplicatecheck
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -395,9 +401,10 @@ func TestMinHashSignature(t *testing.T) {
 	shingles2 := generateShingles(text2, 3)
 	shingles3 := generateShingles(text3, 3)
 
-	sig1 := computeMinHashSignature(shingles1, 100)
-	sig2 := computeMinHashSignature(shingles2, 100)
-	sig3 := computeMinHashSignature(shingles3, 100)
+	family := newMinHashFamily(100, DefaultLSHSeed, SignatureKIndependent)
+	sig1 := computeMinHashSignature(shingles1, family)
+	sig2 := computeMinHashSignature(shingles2, family)
+	sig3 := computeMinHashSignature(shingles3, family)
 
 	t.Logf("Shingles1: %d, Shingles3: %d", len(shingles1), len(shingles3))
 
@@ -433,3 +440,618 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// generateLongDescriptionPairs creates products with long pseudo-random descriptions
+// and, for each, a variant with an extra paragraph appended
+func generateLongDescriptionPairs(count, descLen, extraLen int) (originals, variants []Product) {
+	rng := rand.New(rand.NewSource(7))
+	vocabulary := strings.Fields("battery display camera sensor premium aluminum frame wireless charging " +
+		"stainless steel waterproof lightweight ergonomic design adjustable strap warranty included " +
+		"portable compact durable fabric cotton leather organic natural blend fast shipping " +
+		"performance storage memory processor quad core graphics audio speaker bluetooth")
+
+	words := func(n int) string {
+		var b strings.Builder
+		for b.Len() < n {
+			b.WriteString(vocabulary[rng.Intn(len(vocabulary))])
+			b.WriteByte(' ')
+		}
+		return strings.TrimSpace(b.String())
+	}
+
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("Item %d %s", i, words(20))
+		desc := words(descLen)
+		originals = append(originals, Product{ID: fmt.Sprintf("P%03d", i), Name: name, Description: desc})
+		variants = append(variants, Product{
+			ID:          fmt.Sprintf("V%03d", i),
+			Name:        name,
+			Description: desc + " " + words(extraLen),
+		})
+	}
+	return originals, variants
+}
+
+// TestHybridChunkedSignatureRecall checks that chunked MinHash recovers pairs whose
+// description gained a long appended paragraph
+func TestHybridChunkedSignatureRecall(t *testing.T) {
+	originals, variants := generateLongDescriptionPairs(150, 600, 400)
+	threshold := 0.85
+
+	wholeText := NewHybridEngine()
+	config := DefaultHybridConfig()
+	config.ChunkedSignatures = true
+	config.ChunkSize = 200
+	config.ChunkOverlap = 40
+	chunked := NewHybridEngineWithConfig(config)
+
+	for _, engine := range []*HybridEngine{wholeText, chunked} {
+		if err := engine.BuildIndex(originals); err != nil {
+			t.Fatalf("BuildIndex failed: %v", err)
+		}
+	}
+
+	found := func(engine *HybridEngine, variant Product, wantID string) bool {
+		for _, r := range engine.FindDuplicatesForOne(variant, threshold) {
+			if r.ProductB.ID == wantID {
+				return true
+			}
+		}
+		return false
+	}
+
+	exact := NewLevenshteinEngine()
+	expected, wholeFound, chunkedFound := 0, 0, 0
+	for i, variant := range variants {
+		if exact.Compare(variant, originals[i]).CombinedSimilarity < threshold {
+			continue
+		}
+		expected++
+		if found(wholeText, variant, originals[i].ID) {
+			wholeFound++
+		}
+		if found(chunked, variant, originals[i].ID) {
+			chunkedFound++
+		} else {
+			t.Errorf("Chunked mode missed pair %s/%s", variant.ID, originals[i].ID)
+		}
+	}
+
+	t.Logf("Pairs above %.2f: %d, whole-text found %d, chunked found %d", threshold, expected, wholeFound, chunkedFound)
+	if expected == 0 {
+		t.Fatal("Test corpus produced no pairs above threshold")
+	}
+	if chunkedFound < wholeFound {
+		t.Errorf("Chunked mode found fewer pairs (%d) than whole-text mode (%d)", chunkedFound, wholeFound)
+	}
+
+	stats := chunked.GetIndexStats()
+	if stats["chunked_mode"] != true {
+		t.Error("Stats should report chunked mode")
+	}
+	if stats["total_chunks"].(int) <= len(originals) {
+		t.Errorf("Expected more chunks than products, got %v", stats["total_chunks"])
+	}
+	if wholeText.GetIndexStats()["total_chunks"] != len(originals) {
+		t.Errorf("Whole-text mode should index one signature per product, got %v", wholeText.GetIndexStats()["total_chunks"])
+	}
+}
+
+func TestSplitChunks(t *testing.T) {
+	if chunks := splitChunks("short text", 50, 10); len(chunks) != 1 || chunks[0] != "short text" {
+		t.Errorf("Short text should be a single chunk, got %q", chunks)
+	}
+
+	text := strings.Repeat("abcdefghij", 10) // 100 runes
+	chunks := splitChunks(text, 40, 10)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d: %q", len(chunks), chunks)
+	}
+	for i := 1; i < len(chunks); i++ {
+		prev := chunks[i-1]
+		if !strings.HasPrefix(chunks[i], prev[len(prev)-10:]) {
+			t.Errorf("Chunk %d should start with the last 10 runes of chunk %d", i, i-1)
+		}
+	}
+	if last := chunks[len(chunks)-1]; !strings.HasSuffix(text, last) {
+		t.Errorf("Last chunk should end the text, got %q", last)
+	}
+}
+
+// TestHybridSimHashScreenRecall checks that the SimHash screen with the default
+// margin never drops a match found by the unscreened hybrid pipeline
+func TestHybridSimHashScreenRecall(t *testing.T) {
+	catalog := generateUserArticles(150)
+	originals, variants := generateLongDescriptionPairs(60, 400, 100)
+	catalog = append(catalog, originals...)
+
+	plain := NewHybridEngine()
+	config := DefaultHybridConfig()
+	config.SimHashScreen = true
+	screened := NewHybridEngineWithConfig(config)
+
+	for _, engine := range []*HybridEngine{plain, screened} {
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatalf("BuildIndex failed: %v", err)
+		}
+	}
+
+	queries := append([]Product{}, variants...)
+	for i := 0; i < len(catalog); i += 4 {
+		queries = append(queries, Product{
+			ID:          "Q",
+			Name:        catalog[i].Name + " (2nd edition)",
+			Description: catalog[i].Description,
+		})
+	}
+
+	matches := 0
+	for _, threshold := range []float64{0.75, 0.85, 0.95} {
+		for _, query := range queries {
+			got := make(map[string]bool)
+			for _, r := range screened.FindDuplicatesForOne(query, threshold) {
+				got[r.ProductB.ID] = true
+			}
+			for _, r := range plain.FindDuplicatesForOne(query, threshold) {
+				matches++
+				if !got[r.ProductB.ID] {
+					t.Errorf("Threshold %.2f: SimHash screen lost match %s for %s (similarity %.3f)",
+						threshold, r.ProductB.ID, query.Name, r.CombinedSimilarity)
+				}
+			}
+		}
+	}
+
+	stats := screened.GetIndexStats()
+	t.Logf("Verified %d matches; SimHash screen skipped %v candidates", matches, stats["simhash_skipped"])
+	if stats["simhash_screen"] != true {
+		t.Error("Stats should report the SimHash screen as enabled")
+	}
+	if plain.GetIndexStats()["simhash_skipped"] != uint64(0) {
+		t.Error("Unscreened engine should not skip candidates")
+	}
+}
+
+// BenchmarkHybridSimHashScreen measures query latency on long descriptions
+// with and without the SimHash screen. Products open with one of a few shared
+// boilerplate paragraphs, so chunked LSH returns many candidates that only
+// share the boilerplate - the case where Levenshtein verification dominates.
+//
+// Run with: go test -bench=BenchmarkHybridSimHashScreen -benchtime=50x
+func BenchmarkHybridSimHashScreen(b *testing.B) {
+	rng := rand.New(rand.NewSource(11))
+	randomWord := func() string {
+		letters := make([]byte, 3+rng.Intn(6))
+		for i := range letters {
+			letters[i] = byte('a' + rng.Intn(26))
+		}
+		return string(letters)
+	}
+	paragraph := func(length int) string {
+		var sb strings.Builder
+		for sb.Len() < length {
+			sb.WriteString(randomWord())
+			sb.WriteByte(' ')
+		}
+		return strings.TrimSpace(sb.String())
+	}
+
+	boilerplates := make([]string, 40)
+	for i := range boilerplates {
+		boilerplates[i] = paragraph(500)
+	}
+
+	catalog := make([]Product, 2000)
+	for i := range catalog {
+		catalog[i] = Product{
+			ID:          fmt.Sprintf("B%04d", i),
+			Name:        fmt.Sprintf("Item %d", i),
+			Description: boilerplates[i%len(boilerplates)] + " " + paragraph(2000),
+		}
+	}
+
+	queries := make([]Product, 20)
+	for i := range queries {
+		source := catalog[i*7]
+		queries[i] = Product{ID: "Q", Name: source.Name, Description: source.Description + " Updated."}
+	}
+
+	for _, screen := range []bool{false, true} {
+		config := DefaultHybridConfig()
+		config.ChunkedSignatures = true
+		config.SimHashScreen = screen
+		engine := NewHybridEngineWithConfig(config)
+		if err := engine.BuildIndex(catalog); err != nil {
+			b.Fatalf("BuildIndex failed: %v", err)
+		}
+
+		b.Run(fmt.Sprintf("screen=%v", screen), func(b *testing.B) {
+			latencies := make([]time.Duration, 0, b.N)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				start := time.Now()
+				engine.FindDuplicatesForOne(queries[i%len(queries)], 0.85)
+				latencies = append(latencies, time.Since(start))
+			}
+			b.StopTimer()
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			b.ReportMetric(float64(latencies[len(latencies)/2].Microseconds()), "p50-µs")
+		})
+	}
+}
+
+// generateRankedCandidateCatalog builds groups where each query has one strong
+// candidate (a near-copy) and weak candidates that share part of its description
+// but differ too much to be duplicates
+func generateRankedCandidateCatalog(groups, weakPerGroup int) (catalog, queries []Product) {
+	rng := rand.New(rand.NewSource(7))
+	vocab := make([]string, 2000)
+	for i := range vocab {
+		vocab[i] = fmt.Sprintf("w%04d", i)
+	}
+	randomWords := func(n int) []string {
+		words := make([]string, n)
+		for i := range words {
+			words[i] = vocab[rng.Intn(len(vocab))]
+		}
+		return words
+	}
+
+	for g := 0; g < groups; g++ {
+		base := randomWords(60)
+		name := fmt.Sprintf("Gadget %d Model %s", g, strings.Join(randomWords(2), " "))
+
+		queries = append(queries, Product{ID: fmt.Sprintf("Q%d", g), Name: name, Description: strings.Join(base, " ")})
+		catalog = append(catalog, Product{
+			ID:          fmt.Sprintf("G%d-strong", g),
+			Name:        name,
+			Description: strings.Join(base, " ") + " refurbished",
+		})
+
+		for k := 0; k < weakPerGroup; k++ {
+			// Keep most of the description, rewrite the tail
+			desc := append(append([]string(nil), base[:45]...), randomWords(15)...)
+			catalog = append(catalog, Product{
+				ID:          fmt.Sprintf("G%d-weak%02d", g, k),
+				Name:        fmt.Sprintf("Gadget %d Model %s", g, strings.Join(randomWords(2), " ")), // similar enough to verify in full
+				Description: strings.Join(desc, " "),
+			})
+		}
+	}
+	return catalog, queries
+}
+
+func TestHybridCandidateRanking(t *testing.T) {
+	catalog, queries := generateRankedCandidateCatalog(10, 30)
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	for _, query := range queries {
+		candidates := engine.findCandidates(engine.currentIndex(), &query, 0)
+		for i := 1; i < len(candidates); i++ {
+			if candidates[i].collisions > candidates[i-1].collisions {
+				t.Fatalf("%s: candidates not ranked by collisions", query.ID)
+			}
+		}
+		if len(candidates) == 0 || !strings.HasSuffix(candidates[0].id, "-strong") {
+			t.Errorf("%s: expected the strong candidate first, got %v", query.ID, candidates[:min(3, len(candidates))])
+		}
+
+		// Ranking must not change the full result set
+		want := make(map[string]bool)
+		for _, c := range candidates {
+			if result := engine.Compare(query, *engine.lshIndex.products[c.id]); result.CombinedSimilarity >= 0.85 {
+				want[c.id] = true
+			}
+		}
+		got := engine.FindDuplicatesForOne(query, 0.85)
+		if len(got) != len(want) {
+			t.Errorf("%s: got %d results, want %d", query.ID, len(got), len(want))
+		}
+		for _, r := range got {
+			if !want[r.ProductB.ID] {
+				t.Errorf("%s: unexpected result %s", query.ID, r.ProductB.ID)
+			}
+		}
+
+		found, match := engine.HasDuplicate(query, 0.85)
+		if found != (len(want) > 0) {
+			t.Errorf("%s: HasDuplicate = %v, want %v", query.ID, found, len(want) > 0)
+		}
+		if found && (!want[match.ProductB.ID] || !match.MeetsThreshold) {
+			t.Errorf("%s: HasDuplicate returned non-matching %s", query.ID, match.ProductB.ID)
+		}
+	}
+
+	t.Run("No duplicate", func(t *testing.T) {
+		found, _ := engine.HasDuplicate(Product{ID: "X", Name: "Unrelated", Description: "nothing in common"}, 0.85)
+		if found {
+			t.Error("Expected no duplicate")
+		}
+		if found, _ := NewHybridEngine().HasDuplicate(queries[0], 0.85); found {
+			t.Error("Expected false without an index")
+		}
+	})
+}
+
+func BenchmarkHybridHasDuplicate(b *testing.B) {
+	catalog, queries := generateRankedCandidateCatalog(20, 30)
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(catalog); err != nil {
+		b.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	b.Run("FindDuplicatesForOne", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			engine.FindDuplicatesForOne(queries[i%len(queries)], 0.85)
+		}
+	})
+
+	b.Run("HasDuplicate", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			engine.HasDuplicate(queries[i%len(queries)], 0.85)
+		}
+	})
+}
+
+// plantHotBucket indexes junk products and adds them all to the query's band-0
+// bucket, as if they shared one boilerplate band with it
+func plantHotBucket(t *testing.T, engine *HybridEngine, catalog []Product, query Product, junk int) {
+	t.Helper()
+	rng := rand.New(rand.NewSource(11))
+	var junkIDs []string
+	for i := 0; i < junk; i++ {
+		id := fmt.Sprintf("JUNK%04d", i)
+		junkIDs = append(junkIDs, id)
+		catalog = append(catalog, Product{ID: id, Name: randomWordText(rng, 3), Description: randomWordText(rng, 30)})
+	}
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	signature := engine.computeSignatures(engine.indexText(&query))[0]
+	band := hashBand(signature, 0, engine.lshIndex.rowsPerBand)
+	for _, id := range junkIDs {
+		engine.lshIndex.bands.Append(0, engine.lshIndex.bucketHash(band), engine.lshIndex.numbers[id])
+	}
+}
+
+func TestHybridCandidateCap(t *testing.T) {
+	catalog, queries := generateRankedCandidateCatalog(1, 30)
+	query := queries[0]
+	const junk = 500
+
+	uncapped := NewHybridEngine()
+	plantHotBucket(t, uncapped, catalog, query, junk)
+	ranked := uncapped.findCandidates(uncapped.currentIndex(), &query, 0)
+
+	tests := []struct {
+		name           string
+		config         func(*HybridConfig)
+		wantErr        error
+		maxComparisons int
+		minComparisons int
+		wantTruncated  uint64
+		wantSkipped    bool
+		wantLogMessage string
+	}{
+		{"unlimited", func(*HybridConfig) {}, nil, len(ranked), junk, 0, false, ""},
+		{"candidate cap", func(c *HybridConfig) { c.MaxCandidates = 20 }, ErrQueryTruncated, 20, 20, 1, false, "candidates truncated"},
+		{"bucket fanout", func(c *HybridConfig) { c.MaxBucketFanout = 100 }, nil, 100, 1, 0, true, "oversized buckets skipped"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultHybridConfig()
+			tt.config(&config)
+			handler := &captureHandler{}
+			var comparisons int64
+			engine := NewHybridEngineWithConfig(config).WithLogger(slog.New(handler)).
+				WithWeightResolver(func(a, b Product) ComparisonWeights {
+					atomic.AddInt64(&comparisons, 1)
+					return ComparisonWeights{}
+				})
+			plantHotBucket(t, engine, catalog, query, junk)
+
+			results, err := engine.FindDuplicatesForOneChecked(query, 0.85)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if n := int(atomic.LoadInt64(&comparisons)); n < tt.minComparisons || n > tt.maxComparisons {
+				t.Errorf("verified %d candidates, want %d-%d", n, tt.minComparisons, tt.maxComparisons)
+			}
+			if len(results) == 0 || results[0].ProductB.ID != "G0-strong" {
+				t.Errorf("strongest candidate missing from results")
+			}
+
+			stats := engine.GetIndexStats()
+			if got := stats["truncated_queries"].(uint64); got != tt.wantTruncated {
+				t.Errorf("truncated_queries = %d, want %d", got, tt.wantTruncated)
+			}
+			if got := stats["skipped_buckets"].(uint64) > 0; got != tt.wantSkipped {
+				t.Errorf("skipped_buckets = %d, want skipped=%v", stats["skipped_buckets"], tt.wantSkipped)
+			}
+			if tt.wantLogMessage != "" {
+				if _, ok := handler.find(tt.wantLogMessage); !ok {
+					t.Errorf("missing %q log event", tt.wantLogMessage)
+				}
+			}
+		})
+	}
+
+	t.Run("strongest candidates survive", func(t *testing.T) {
+		config := DefaultHybridConfig()
+		config.MaxCandidates = 20
+		engine := NewHybridEngineWithConfig(config)
+		plantHotBucket(t, engine, catalog, query, junk)
+
+		capped := engine.findCandidates(engine.currentIndex(), &query, 0)
+		if len(capped) != 20 {
+			t.Fatalf("got %d candidates, want 20", len(capped))
+		}
+		for i := range capped {
+			if capped[i] != ranked[i] {
+				t.Errorf("candidate %d = %v, want %v", i, capped[i], ranked[i])
+			}
+		}
+		// Every candidate sharing more than the planted band outranks the junk
+		kept := make(map[string]bool)
+		for _, candidate := range capped {
+			kept[candidate.id] = true
+		}
+		for _, candidate := range ranked {
+			if candidate.collisions > 1 && !kept[candidate.id] {
+				t.Errorf("candidate %s with %d collisions dropped", candidate.id, candidate.collisions)
+			}
+		}
+	})
+
+	t.Run("no index", func(t *testing.T) {
+		if _, err := NewHybridEngine().FindDuplicatesForOneChecked(query, 0.85); !errors.Is(err, ErrIndexNotBuilt) {
+			t.Errorf("err = %v, want ErrIndexNotBuilt", err)
+		}
+	})
+}
+
+// bandFamilyCatalog returns families of products sharing a description of
+// words random words: even variants replace one word (near duplicates), odd
+// ones replace related words (related products)
+func bandFamilyCatalog(families, perFamily, words, related int) []Product {
+	rng := rand.New(rand.NewSource(23))
+	catalog := make([]Product, 0, families*perFamily)
+	for f := 0; f < families; f++ {
+		base := strings.Fields(randomWordText(rng, words))
+		for v := 0; v < perFamily; v++ {
+			variant := append([]string(nil), base...)
+			edits := 1
+			if v%2 == 1 {
+				edits = related
+			}
+			for i := 0; i < edits && v > 0; i++ {
+				variant[rng.Intn(words)] = randomWordText(rng, 1)
+			}
+			catalog = append(catalog, Product{
+				ID:          fmt.Sprintf("F%04d-%d", f, v),
+				Name:        fmt.Sprintf("Family %d", f),
+				Description: strings.Join(variant, " "),
+			})
+		}
+	}
+	return catalog
+}
+
+func TestHybridProbeBands(t *testing.T) {
+	tests := []struct {
+		name      string
+		adaptive  bool
+		epsilon   float64
+		threshold float64
+		want      int
+	}{
+		{"Disabled", false, 0, 0.95, 20},
+		{"No threshold", true, 0, 0, 20},
+		{"Threshold 0.95", true, 0, 0.95, 4}, // 0.95^5 = 0.77: (1-0.77)^4 < 0.01
+		{"Threshold 0.85", true, 0, 0.85, 8}, // 0.85^5 = 0.44
+		{"Low threshold", true, 0, 0.7, 20},  // Would need 26 bands
+		{"Stricter epsilon", true, 0.001, 0.95, 5},
+		{"Exact match", true, 0, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultHybridConfig()
+			config.AdaptiveBands = tt.adaptive
+			if tt.epsilon > 0 {
+				config.AdaptiveBandEpsilon = tt.epsilon
+			}
+			engine := NewHybridEngineWithConfig(config)
+			if err := engine.BuildIndex(bandFamilyCatalog(2, 2, 20, 10)); err != nil {
+				t.Fatalf("BuildIndex failed: %v", err)
+			}
+			if got := engine.probeBands(tt.threshold); got != tt.want {
+				t.Errorf("probeBands(%v) = %d, want %d", tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHybridAdaptiveBandsRecall(t *testing.T) {
+	const threshold = 0.95
+	// Related products replace half the words, scoring below the threshold
+	catalog := bandFamilyCatalog(60, 6, 200, 100)
+
+	baseline := NewHybridEngine()
+	config := DefaultHybridConfig()
+	config.AdaptiveBands = true
+	adaptive := NewHybridEngineWithConfig(config)
+	for _, engine := range []*HybridEngine{baseline, adaptive} {
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatalf("BuildIndex failed: %v", err)
+		}
+	}
+
+	want := resultPairs(baseline.FindDuplicates(catalog, threshold))
+	got := make(map[string]bool)
+	for _, pair := range resultPairs(adaptive.FindDuplicates(catalog, threshold)) {
+		got[pair] = true
+	}
+	lost := 0
+	for _, pair := range want {
+		if !got[pair] {
+			lost++
+		}
+	}
+	if len(want) == 0 {
+		t.Fatal("baseline found no pairs")
+	}
+	if rate := float64(lost) / float64(len(want)); rate > config.AdaptiveBandEpsilon {
+		t.Errorf("adaptive bands lost %d of %d pairs (%.3f), want at most %.2f", lost, len(want), rate, config.AdaptiveBandEpsilon)
+	}
+
+	probed := adaptive.GetIndexStats()["avg_probed_bands"].(float64)
+	if probed >= float64(adaptive.numBands) {
+		t.Errorf("avg_probed_bands = %v, want fewer than %d", probed, adaptive.numBands)
+	}
+	if all := baseline.GetIndexStats()["avg_probed_bands"].(float64); all != float64(baseline.numBands) {
+		t.Errorf("baseline avg_probed_bands = %v, want %d", all, baseline.numBands)
+	}
+
+	// Fewer bands collect fewer of the related products as candidates
+	query := catalog[0]
+	if a, b := len(adaptive.findCandidates(adaptive.currentIndex(), &query, threshold)), len(baseline.findCandidates(baseline.currentIndex(), &query, threshold)); a > b {
+		t.Errorf("adaptive query found %d candidates, all bands %d", a, b)
+	}
+	t.Logf("lost %d of %d pairs probing %.1f bands", lost, len(want), probed)
+}
+
+func BenchmarkHybridAdaptiveBands(b *testing.B) {
+	const threshold = 0.95
+	catalog := bandFamilyCatalog(1000, 10, 60, 6)
+	queries := make([]Product, 100)
+	for i := range queries {
+		source := catalog[i*97]
+		queries[i] = Product{ID: "Q", Name: source.Name, Description: source.Description + " updated"}
+	}
+
+	for _, adaptive := range []bool{false, true} {
+		config := DefaultHybridConfig()
+		config.AdaptiveBands = adaptive
+		engine := NewHybridEngineWithConfig(config)
+		if err := engine.BuildIndex(catalog); err != nil {
+			b.Fatalf("BuildIndex failed: %v", err)
+		}
+		name := "AllBands"
+		if adaptive {
+			name = "Adaptive"
+		}
+		b.Run(name, func(b *testing.B) {
+			candidates := 0
+			for i := 0; i < b.N; i++ {
+				query := queries[i%len(queries)]
+				candidates += len(engine.findCandidates(engine.currentIndex(), &query, threshold))
+				engine.FindDuplicatesForOne(query, threshold)
+			}
+			b.ReportMetric(float64(candidates)/float64(b.N), "candidates/op")
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package duplicatecheck
+
+import "strings"
+
+// IDRelation is what two product IDs say about the products behind them
+type IDRelation int
+
+const (
+	// IDUnrelated means the IDs say nothing; the pair is scored as usual
+	IDUnrelated IDRelation = iota
+	// IDSameSource means both products come from the same source, where near
+	// duplicates are usually variants (sizes, colors) rather than duplicates
+	IDSameSource
+	// IDSameEntity means the IDs carry the same identity key, so the products
+	// are the same item whatever their text says
+	IDSameEntity
+)
+
+// String returns the relation name
+func (r IDRelation) String() string {
+	switch r {
+	case IDUnrelated:
+		return "unrelated"
+	case IDSameSource:
+		return "same-source"
+	case IDSameEntity:
+		return "same-entity"
+	default:
+		return "unknown"
+	}
+}
+
+// IDComparator relates two product IDs (see WithIDComparator)
+// Engines call it from parallel workers, so it must be safe for concurrent
+// use, and it should be symmetric. IDs it can't interpret should be IDUnrelated.
+type IDComparator func(idA, idB string) IDRelation
+
+// IDComparisonOptions controls what WithIDComparator does with IDSameSource pairs
+type IDComparisonOptions struct {
+	// SameSourcePenalty is subtracted from the combined similarity of fuzzy
+	// same-source pairs (0 = none). Exact and normalized-exact pairs keep their
+	// score: a listing repeated verbatim within a source is a duplicate.
+	SameSourcePenalty float64
+	// FlagSameSource sets ComparisonResult.SameSourceIDs on same-source pairs
+	FlagSameSource bool
+}
+
+// PrefixedIDComparator returns an IDComparator for IDs shaped "source<sep>key",
+// such as "amazon:B0ABC123" with separator ":"
+// IDs split at the first separator. Equal keys are IDSameEntity
+// ("amazon:B0ABC123" and "ebay:B0ABC123"), equal sources with different keys
+// IDSameSource. IDs without the separator, or with an empty source or key,
+// are IDUnrelated to everything.
+func PrefixedIDComparator(separator string) IDComparator {
+	return func(idA, idB string) IDRelation {
+		sourceA, keyA, okA := splitPrefixedID(idA, separator)
+		sourceB, keyB, okB := splitPrefixedID(idB, separator)
+		switch {
+		case !okA || !okB:
+			return IDUnrelated
+		case keyA == keyB:
+			return IDSameEntity
+		case sourceA == sourceB:
+			return IDSameSource
+		default:
+			return IDUnrelated
+		}
+	}
+}
+
+// splitPrefixedID splits id into its source and key, reporting whether both are non-empty
+func splitPrefixedID(id, separator string) (source, key string, ok bool) {
+	if separator == "" {
+		return "", "", false
+	}
+	source, key, found := strings.Cut(id, separator)
+	return source, key, found && source != "" && key != ""
+}
+
+// WithIDComparator makes comparisons consult the product IDs first; pass nil
+// to remove it
+// IDSameEntity pairs score a combined similarity of 1.0 with MatchIdentifier
+// without comparing any text (their field similarities stay zero).
+// IDSameSource pairs are scored as usual, then penalized or flagged as opts
+// says. Since IDSameEntity pairs match whatever their names, scans stop pruning
+// pairs by name length. Returns the engine for chaining.
+func (e *LevenshteinEngine) WithIDComparator(comparator IDComparator, opts IDComparisonOptions) *LevenshteinEngine {
+	e.idComparator = comparator
+	e.idOptions = opts
+	return e
+}
+
+// WithIDComparator makes verification consult the product IDs first (see
+// LevenshteinEngine.WithIDComparator); pass nil to remove it
+// Only LSH candidates are verified, so IDSameEntity pairs whose text shares no
+// bucket are not found by FindDuplicates above the exact mode cutoff.
+// Returns the engine for chaining.
+func (e *HybridEngine) WithIDComparator(comparator IDComparator, opts IDComparisonOptions) *HybridEngine {
+	e.levenshteinEngine.WithIDComparator(comparator, opts)
+	return e
+}
+
+// idRelation returns the relation of two IDs under the engine's comparator
+func (e *LevenshteinEngine) idRelation(idA, idB string) IDRelation {
+	if e.idComparator == nil {
+		return IDUnrelated
+	}
+	return e.idComparator(idA, idB)
+}
+
+// identifierResult scores a pair whose IDs identify the same entity
+func (e *LevenshteinEngine) identifierResult(a, b *Product, weights ComparisonWeights) ComparisonResult {
+	return e.finishResult(ComparisonResult{
+		ProductA:           *a,
+		ProductB:           *b,
+		CombinedSimilarity: 1.0,
+		WeightsUsed:        weights,
+		SimilarityMode:     e.options.SimilarityMode,
+		ThresholdUsed:      e.threshold,
+		MeetsThreshold:     e.threshold <= 1.0,
+		MatchType:          MatchIdentifier,
+	})
+}
+
+// sameSourceSimilarity applies the same-source penalty to a fuzzy combined similarity
+func (e *LevenshteinEngine) sameSourceSimilarity(similarity float64) float64 {
+	if e.idOptions.SameSourcePenalty <= 0 {
+		return similarity
+	}
+	return clampUnit(similarity - e.idOptions.SameSourcePenalty)
+}
@@ -0,0 +1,111 @@
+package duplicatecheck
+
+import "testing"
+
+func TestPrefixedIDComparator(t *testing.T) {
+	compare := PrefixedIDComparator(":")
+	tests := []struct {
+		a, b string
+		want IDRelation
+	}{
+		{"amazon:B0ABC123", "ebay:B0ABC123", IDSameEntity},
+		{"amazon:B0ABC123", "amazon:B0ABC123", IDSameEntity},
+		{"amazon:B0ABC123", "amazon:B0XYZ999", IDSameSource},
+		{"amazon:B0ABC123", "ebay:B0XYZ999", IDUnrelated},
+		{"amazon:a:b", "ebay:a:b", IDSameEntity}, // Split at the first separator only
+		{"B0ABC123", "ebay:B0ABC123", IDUnrelated},
+		{":B0ABC123", "ebay:B0ABC123", IDUnrelated},
+		{"amazon:", "amazon:", IDUnrelated},
+		{"", "", IDUnrelated},
+		{"amazon|B0ABC123", "ebay|B0ABC123", IDUnrelated},
+	}
+	for _, tt := range tests {
+		if got := compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("compare(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := compare(tt.b, tt.a); got != tt.want {
+			t.Errorf("compare(%q, %q) = %v, want %v (not symmetric)", tt.b, tt.a, got, tt.want)
+		}
+	}
+	if got := PrefixedIDComparator("|")("amazon|B0ABC123", "ebay|B0ABC123"); got != IDSameEntity {
+		t.Errorf("custom separator: %v, want same-entity", got)
+	}
+	if got := PrefixedIDComparator("")("a:b", "c:b"); got != IDUnrelated {
+		t.Errorf("empty separator: %v, want unrelated", got)
+	}
+}
+
+func TestWithIDComparator(t *testing.T) {
+	const threshold = 0.8
+	products := []Product{
+		// Same item from two sources, listed with unrelated text
+		{ID: "amazon:B0ABC123", Name: "Acme Cordless Drill 18V", Description: "Two batteries included"},
+		{ID: "ebay:B0ABC123", Name: "Power tool bundle", Description: "Barely used, ships fast"},
+		// Variants within one source
+		{ID: "shop:RED-M", Name: "Cotton T-Shirt Red Medium", Description: "Soft cotton tee"},
+		{ID: "shop:RED-L", Name: "Cotton T-Shirt Red Large", Description: "Soft cotton tee"},
+		// Near duplicates across sources, and with malformed IDs
+		{ID: "north:LAMP-1", Name: "Brass Desk Lamp", Description: "Adjustable arm"},
+		{ID: "south:LAMP-9", Name: "Brass Desk Lamp.", Description: "Adjustable arm"},
+		{ID: "MUG", Name: "Stoneware Coffee Mug", Description: "Dishwasher safe"},
+		{ID: ":MUG", Name: "Stoneware Coffee Mug 12oz", Description: "Dishwasher safe"},
+	}
+	plainLevenshtein, plainHybrid := NewLevenshteinEngine(), NewHybridEngine()
+
+	tests := []struct {
+		name string
+		opts IDComparisonOptions
+		// Expected effect on the same-source pair
+		sameSourceFound   bool
+		sameSourceFlagged bool
+	}{
+		{"no options", IDComparisonOptions{}, true, false},
+		{"flag", IDComparisonOptions{FlagSameSource: true}, true, true},
+		{"penalty", IDComparisonOptions{SameSourcePenalty: 0.2}, false, false},
+	}
+	for _, tt := range tests {
+		engines := []struct {
+			name          string
+			engine, plain DuplicateCheckEngine
+		}{
+			{"levenshtein", NewLevenshteinEngine().WithIDComparator(PrefixedIDComparator(":"), tt.opts), plainLevenshtein},
+			{"hybrid", NewHybridEngine().WithIDComparator(PrefixedIDComparator(":"), tt.opts), plainHybrid},
+		}
+		for _, e := range engines {
+			t.Run(tt.name+"/"+e.name, func(t *testing.T) {
+				found := make(map[string]ComparisonResult)
+				for _, r := range e.engine.FindDuplicates(products, threshold) {
+					found[makePairKey(r.ProductA.ID, r.ProductB.ID)] = r
+				}
+
+				entity, ok := found[makePairKey("amazon:B0ABC123", "ebay:B0ABC123")]
+				if !ok || entity.MatchType != MatchIdentifier || entity.CombinedSimilarity != 1.0 {
+					t.Errorf("same-entity pair = %+v (found %v), want an identifier match at 1.0", entity, ok)
+				}
+				if entity.NameDistance != 0 || entity.NameSimilarity != 0 {
+					t.Errorf("same-entity pair compared names: distance %d, similarity %v", entity.NameDistance, entity.NameSimilarity)
+				}
+
+				variant, ok := found[makePairKey("shop:RED-M", "shop:RED-L")]
+				if ok != tt.sameSourceFound {
+					t.Errorf("same-source pair found = %v, want %v", ok, tt.sameSourceFound)
+				}
+				if ok && variant.SameSourceIDs != tt.sameSourceFlagged {
+					t.Errorf("same-source pair SameSourceIDs = %v, want %v", variant.SameSourceIDs, tt.sameSourceFlagged)
+				}
+				if got, plain := e.engine.Compare(products[2], products[3]), e.plain.Compare(products[2], products[3]); got.CombinedSimilarity != clampUnit(plain.CombinedSimilarity-tt.opts.SameSourcePenalty) {
+					t.Errorf("same-source Compare = %v, plain %v with penalty %v", got.CombinedSimilarity, plain.CombinedSimilarity, tt.opts.SameSourcePenalty)
+				}
+
+				// Unrelated and malformed IDs score exactly as without a comparator
+				for _, pair := range [][2]int{{4, 5}, {6, 7}} {
+					a, b := products[pair[0]], products[pair[1]]
+					got, plain := e.engine.Compare(a, b), e.plain.Compare(a, b)
+					if got.CombinedSimilarity != plain.CombinedSimilarity || got.MatchType != plain.MatchType || got.SameSourceIDs {
+						t.Errorf("%s/%s scored %v (%v), %v without a comparator", a.ID, b.ID, got.CombinedSimilarity, got.MatchType, plain.CombinedSimilarity)
+					}
+				}
+			})
+		}
+	}
+}
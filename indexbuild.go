@@ -0,0 +1,268 @@
+package duplicatecheck
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// buildBatchSize is the number of products whose index entries are computed
+// before they are merged into the band maps, bounding the memory a parallel
+// BuildIndex holds beyond the index itself
+const buildBatchSize = 4096
+
+// buildClaimSize is the number of products a build worker claims at a time
+const buildClaimSize = 32
+
+// indexEntry is everything BuildIndex derives from one product before adding
+// it to the index; computing it touches no shared state
+type indexEntry struct {
+	fingerprint SimHashFingerprint // SimHash of the index text (privacy mode, SimHash screen)
+	contentHash uint64             // Salted content hash (privacy mode)
+	bandHashes  []uint64           // Band hashes, numBands per signature
+	signatures  int                // MinHash signatures (chunks in chunked mode)
+	report      IndexingReport     // Indexing limits hit by the product
+}
+
+// newIndexEntry computes the index entry of product
+// Safe to call concurrently for different products.
+func (e *HybridEngine) newIndexEntry(product *Product) indexEntry {
+	// Generate combined text for hashing
+	text, length := e.limitedIndexText(product)
+
+	var entry indexEntry
+	if e.privacy != nil || e.simHashScreen {
+		entry.fingerprint = e.simHash.Compute64(text)
+	}
+	if e.privacy != nil {
+		entry.contentHash = e.privacy.contentHash(text)
+	} else {
+		// Build the cache now so concurrent queries only read it
+		product.loadCacheFor(e.preparer())
+	}
+
+	// Compute MinHash signatures (one, or one per chunk in chunked mode), or
+	// the signature of a SKU-like name's key (see WithSKUNames)
+	var signatures [][]uint32
+	var shingles int
+	var sampled bool
+	if key, ok := e.skuKey(product); ok {
+		signatures = [][]uint32{e.skuSignature(key)}
+	} else {
+		signatures, shingles, sampled = e.indexSignatures(text)
+	}
+	entry.signatures = len(signatures)
+	entry.bandHashes = e.bandHashes(signatures)
+
+	entry.report = IndexingReport{
+		ProductID:  product.ID,
+		TextLength: length,
+		Truncated:  e.maxIndexTextLength > 0 && length > e.maxIndexTextLength,
+		Shingles:   shingles,
+		Sampled:    sampled,
+	}
+	return entry
+}
+
+// bandHashes returns the band hashes of signatures, numBands per signature
+func (e *HybridEngine) bandHashes(signatures [][]uint32) []uint64 {
+	rowsPerBand := e.numHashFunctions / e.numBands
+	hashes := make([]uint64, 0, len(signatures)*e.numBands)
+	for _, signature := range signatures {
+		for bandIdx := 0; bandIdx < e.numBands; bandIdx++ {
+			// Hash this band's rows together
+			hashes = append(hashes, hashBand(signature, bandIdx*rowsPerBand, (bandIdx+1)*rowsPerBand))
+		}
+	}
+	return hashes
+}
+
+// addIndexEntry adds a product and its entry to an LSH index under construction
+func (e *HybridEngine) addIndexEntry(idx *LSHIndex, product *Product, entry indexEntry) {
+	number := e.addIndexedProduct(idx, product, entry)
+	for bandIdx := 0; bandIdx < e.numBands; bandIdx++ {
+		e.addToBand(idx, bandIdx, number, entry)
+	}
+}
+
+// addIndexedProduct records a product and its entry everywhere but the bands,
+// returning the product's number
+func (e *HybridEngine) addIndexedProduct(idx *LSHIndex, product *Product, entry indexEntry) uint32 {
+	// Store product, or only its fingerprints in privacy mode
+	if idx.fingerprints != nil {
+		idx.fingerprints[product.ID] = entry.fingerprint
+	}
+	if e.privacy != nil {
+		idx.contentHashes[product.ID] = entry.contentHash
+	} else {
+		idx.products[product.ID] = product
+	}
+	idx.ids = append(idx.ids, product.ID)
+	number := uint32(len(idx.refs))
+	idx.refs = append(idx.refs, product.ID)
+	idx.numbers[product.ID] = number
+	idx.totalChunks += entry.signatures
+	if idx.queryBands != nil {
+		idx.queryBands[product.ID] = indexedBands{content: bandContent(product), hashes: entry.bandHashes}
+	}
+	if idx.prefixes != nil {
+		name, _ := product.preparedStrings(e.preparer())
+		idx.prefixes.add(name, product.ID)
+	}
+
+	if entry.report.Sampled {
+		idx.sampled++
+	}
+	if entry.report.Truncated {
+		idx.truncated++
+	}
+	if (entry.report.Sampled || entry.report.Truncated) && e.indexingReport != nil {
+		e.indexingReport(entry.report)
+	}
+	return number
+}
+
+// addToBand adds a product to the buckets of one band
+// Bands are independent, so different bands may be filled concurrently.
+func (e *HybridEngine) addToBand(idx *LSHIndex, bandIdx int, number uint32, entry indexEntry) {
+	var added map[uint64]bool
+	if entry.signatures > 1 {
+		added = make(map[uint64]bool, entry.signatures)
+	}
+
+	for s := 0; s < entry.signatures; s++ {
+		bandHash := entry.bandHashes[s*e.numBands+bandIdx]
+
+		// Chunks sharing a bucket reference the product once
+		if added != nil {
+			if added[bandHash] {
+				continue
+			}
+			added[bandHash] = true
+		}
+
+		// Add the product to this band bucket
+		idx.bands.Append(bandIdx, idx.bucketHash(bandHash), number)
+	}
+}
+
+// indexProducts adds products to idx in order, computing their entries on
+// workers goroutines a batch at a time
+// Each batch is then merged in input order: products on the calling goroutine,
+// and each band's buckets by one worker, so the index (including the order of
+// IDs within each bucket) is identical for any worker count and no locks are
+// taken. Returns ctx.Err() if ctx is cancelled, leaving idx partly built.
+func (e *HybridEngine) indexProducts(ctx context.Context, idx *LSHIndex, products []Product, workers int) error {
+	entries := make([]indexEntry, buildBatchSize)
+	for start := 0; start < len(products); start += buildBatchSize {
+		end := start + buildBatchSize
+		if end > len(products) {
+			end = len(products)
+		}
+		batch := products[start:end]
+		if err := e.computeIndexEntries(ctx, batch, entries[:len(batch)], workers); err != nil {
+			return err
+		}
+		first := uint32(len(idx.refs))
+		for i := range batch {
+			e.addIndexedProduct(idx, &batch[i], entries[i])
+		}
+		e.mergeBands(idx, first, entries[:len(batch)], workers)
+		for i := range batch {
+			entries[i] = indexEntry{}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return finishBandStore(idx.bands)
+}
+
+// computeIndexEntries fills entries[i] with the entry of products[i]
+func (e *HybridEngine) computeIndexEntries(ctx context.Context, products []Product, entries []indexEntry, workers int) error {
+	if workers > (len(products)+buildClaimSize-1)/buildClaimSize {
+		workers = (len(products) + buildClaimSize - 1) / buildClaimSize
+	}
+	if workers <= 1 {
+		for i := range products {
+			if i%buildClaimSize == 0 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			entries[i] = e.newIndexEntry(&products[i])
+		}
+		return nil
+	}
+
+	var next int64
+	var group workerGroup
+	for w := 0; w < workers; w++ {
+		group.Go(func() {
+			for ctx.Err() == nil && !group.stopped() {
+				// Claim a run of products; descriptions vary in length, so
+				// small claims keep workers evenly loaded
+				start := int(atomic.AddInt64(&next, buildClaimSize)) - buildClaimSize
+				if start >= len(products) {
+					return
+				}
+				end := start + buildClaimSize
+				if end > len(products) {
+					end = len(products)
+				}
+				for i := start; i < end; i++ {
+					entries[i] = e.newIndexEntry(&products[i])
+				}
+			}
+		})
+	}
+	group.Wait()
+	return ctx.Err()
+}
+
+// mergeBands adds a batch of products, numbered from first, to every band, a
+// band per worker at a time
+func (e *HybridEngine) mergeBands(idx *LSHIndex, first uint32, entries []indexEntry, workers int) {
+	fill := func(bandIdx int) {
+		for i := range entries {
+			e.addToBand(idx, bandIdx, first+uint32(i), entries[i])
+		}
+	}
+	if workers > e.numBands {
+		workers = e.numBands
+	}
+	if workers <= 1 || len(entries) < buildClaimSize {
+		for bandIdx := 0; bandIdx < e.numBands; bandIdx++ {
+			fill(bandIdx)
+		}
+		return
+	}
+
+	var next int64
+	var group workerGroup
+	for w := 0; w < workers; w++ {
+		group.Go(func() {
+			for !group.stopped() {
+				bandIdx := int(atomic.AddInt64(&next, 1)) - 1
+				if bandIdx >= e.numBands {
+					return
+				}
+				fill(bandIdx)
+			}
+		})
+	}
+	group.Wait()
+}
+
+// buildWorkerCount returns the number of workers indexing n products
+// An explicit BuildWorkers is capped by SetMaxWorkers; deterministic mode
+// builds on one.
+func (e *HybridEngine) buildWorkerCount(n int) int {
+	if e.levenshteinEngine.deterministic {
+		return 1
+	}
+	if e.buildWorkers > 0 {
+		if max := e.levenshteinEngine.maxWorkers; max > 0 && max < e.buildWorkers {
+			return max
+		}
+		return e.buildWorkers
+	}
+	return e.levenshteinEngine.workerCount(n)
+}
@@ -0,0 +1,132 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+// indexContents returns the parts of an index a build decides
+func indexContents(idx *LSHIndex) []interface{} {
+	return []interface{}{idx.bands, idx.ids, idx.fingerprints, idx.contentHashes, idx.totalChunks, idx.sampled}
+}
+
+func TestParallelBuildIndexDeterminism(t *testing.T) {
+	cfg := synth.Default()
+	cfg.Size = 300
+	small, _ := generatedCatalog(cfg)
+	// Spans two merge batches; short descriptions keep the builds quick
+	cfg.Size = buildBatchSize + 100
+	cfg.MinDescriptionWords, cfg.MaxDescriptionWords = 4, 12
+	products, _ := generatedCatalog(cfg)
+
+	chunked := DefaultHybridConfig()
+	chunked.ChunkedSignatures, chunked.ChunkSize, chunked.ChunkOverlap = true, 120, 20
+	screened := DefaultHybridConfig()
+	screened.SimHashScreen, screened.MaxShinglesPerProduct = true, 16
+
+	tests := []struct {
+		name     string
+		config   HybridConfig
+		products []Product
+		private  bool
+	}{
+		{"Default", DefaultHybridConfig(), products, false},
+		{"Chunked", chunked, small, false},
+		{"SimHash screen and sampling", screened, small, false},
+		{"Privacy mode", DefaultHybridConfig(), small, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// One engine throughout, since privacy mode salts per engine, and
+			// a fixed bucket salt for every build
+			engine := NewHybridEngineWithConfig(tt.config).WithBucketSalt(1)
+			if tt.private {
+				engine.EnablePrivacyMode(PrivacyOptions{})
+			}
+			build := func(workers int) *LSHIndex {
+				engine.buildWorkers = workers
+				if err := engine.BuildIndex(tt.products); err != nil {
+					t.Fatal(err)
+				}
+				return engine.lshIndex
+			}
+			want := indexContents(build(1))
+			for _, workers := range []int{2, 7} {
+				if got := indexContents(build(workers)); !reflect.DeepEqual(got, want) {
+					t.Errorf("Index built with %d workers differs from the sequential build", workers)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildIndexCtx(t *testing.T) {
+	cfg := synth.Default()
+	cfg.Size = 300
+	products, _ := generatedCatalog(cfg)
+
+	t.Run("Cancelled build keeps the previous index", func(t *testing.T) {
+		engine := NewHybridEngine()
+		if err := engine.BuildIndex(products[:10]); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := engine.BuildIndexCtx(ctx, products); !errors.Is(err, context.Canceled) {
+			t.Fatalf("BuildIndexCtx() = %v, want context.Canceled", err)
+		}
+		if n := engine.IndexedCount(); n != 10 {
+			t.Errorf("IndexedCount() = %d, want the previous 10", n)
+		}
+	})
+
+	t.Run("Throughput is reported", func(t *testing.T) {
+		handler := &captureHandler{}
+		config := DefaultHybridConfig()
+		config.BuildWorkers = 3
+		engine := NewHybridEngineWithConfig(config).WithLogger(slog.New(handler))
+		if err := engine.BuildIndexCtx(context.Background(), products); err != nil {
+			t.Fatal(err)
+		}
+		stats := engine.GetIndexStats()
+		if stats["build_workers"] != 3 || stats["build_products_per_sec"].(float64) <= 0 {
+			t.Errorf("build_workers = %v, build_products_per_sec = %v", stats["build_workers"], stats["build_products_per_sec"])
+		}
+		record, ok := handler.find("index build finished")
+		if !ok || record.attrs["workers"].Int64() != 3 || record.attrs["products_per_sec"].Float64() <= 0 {
+			t.Errorf("index build finished event = %+v", record)
+		}
+	})
+}
+
+func BenchmarkHybridBuildIndexParallel(b *testing.B) {
+	cfg := synth.Default()
+	cfg.Size = 50000
+	products, _ := generatedCatalog(cfg)
+
+	for _, workers := range []int{1, 2, 4, 8, 16} {
+		if workers > 1 && workers > 2*runtime.NumCPU() {
+			continue
+		}
+		b.Run(fmt.Sprintf("%d_workers", workers), func(b *testing.B) {
+			config := DefaultHybridConfig()
+			config.BuildWorkers = workers
+			engine := NewHybridEngineWithConfig(config)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := engine.BuildIndex(products); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(products)*b.N)/b.Elapsed().Seconds(), "products/sec")
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package duplicatecheck
+
+// currentIndex returns the published index (nil if none)
+// BuildIndex publishes a new index by swapping the pointer, so callers that
+// keep the returned index see one consistent snapshot even if the index is
+// rebuilt meanwhile
+func (e *HybridEngine) currentIndex() *LSHIndex {
+	e.indexMu.RLock()
+	defer e.indexMu.RUnlock()
+	return e.lshIndex
+}
+
+// resetIndex discards the index and any retained candidates and matches
+func (e *HybridEngine) resetIndex() {
+	e.indexMu.Lock()
+	e.lshIndex = nil
+	e.retained = nil
+	e.matches = nil
+	e.indexMu.Unlock()
+}
+
+// ForEachIndexed calls fn for every indexed product, in indexing order, until fn returns false
+// Iterates a consistent snapshot: a concurrent BuildIndex doesn't affect a
+// running iteration. In privacy mode the index holds no text, so products only
+// carry their ID.
+func (e *HybridEngine) ForEachIndexed(fn func(Product) bool) {
+	idx := e.currentIndex()
+	if idx == nil {
+		return
+	}
+	for _, id := range idx.ids {
+		product := Product{ID: id}
+		if p, exists := idx.products[id]; exists {
+			product = *p
+		}
+		if !fn(product) {
+			return
+		}
+	}
+}
+
+// IndexedProducts returns a copy of every indexed product, in indexing order
+// Prefer ForEachIndexed for large indexes; see it for privacy mode
+func (e *HybridEngine) IndexedProducts() []Product {
+	products := make([]Product, 0, e.IndexedCount())
+	e.ForEachIndexed(func(p Product) bool {
+		products = append(products, p)
+		return true
+	})
+	return products
+}
+
+// IndexedCount returns the number of indexed products (0 if no index is built)
+func (e *HybridEngine) IndexedCount() int {
+	idx := e.currentIndex()
+	if idx == nil {
+		return 0
+	}
+	return len(idx.ids)
+}
+
+// ContainsProduct reports whether a product ID is in the index
+func (e *HybridEngine) ContainsProduct(id string) bool {
+	idx := e.currentIndex()
+	if idx == nil {
+		return false
+	}
+	if _, exists := idx.products[id]; exists {
+		return true
+	}
+	_, exists := idx.contentHashes[id]
+	return exists
+}
+
+// AddProduct indexes one more product without rebuilding the index
+// Returns ErrIndexNotBuilt if BuildIndex has not run, and a *DuplicateIDError
+// if the ID is already indexed. The index is extended in place, so AddProduct
+// must not run concurrently with queries or other AddProduct calls. Adding a
+// product removed since the last compaction runs Compact first.
+func (e *HybridEngine) AddProduct(product Product) (err error) {
+	defer e.levenshteinEngine.guard("AddProduct", &err)
+	idx := e.currentIndex()
+	if idx == nil {
+		return ErrIndexNotBuilt
+	}
+	if e.ContainsProduct(product.ID) {
+		return &DuplicateIDError{IDs: []string{product.ID}}
+	}
+	if number, exists := idx.numbers[product.ID]; exists && idx.removed[number] {
+		// The buckets still reference the removed product under this ID
+		if _, err := e.Compact(); err != nil {
+			return err
+		}
+		idx = e.currentIndex()
+	}
+
+	// Index a private copy, as BuildIndex does
+	indexed := e.levenshteinEngine.interner.product(&product)
+	entry := e.newIndexEntry(&indexed)
+	if err := e.logUpdate(idx, e.entryRecord(oplogAdd, &indexed, entry), nil); err != nil {
+		return err
+	}
+	e.addIndexEntry(idx, &indexed, entry)
+	return bandStoreErr(idx.bands)
+}
+
+// UpdateProduct replaces an indexed product with a new version under the same
+// ID, without rebuilding the index
+// Returns ErrIndexNotBuilt if BuildIndex has not run, and ErrProductNotIndexed
+// if the ID isn't indexed. A version with the same content (see
+// Product.Fingerprint) changes nothing. Otherwise the old version is removed
+// as by RemoveProduct and the new one indexed after it; like RemoveProduct it
+// can trigger HybridConfig.AutoCompact, and it must not run concurrently with
+// queries or other updates.
+func (e *HybridEngine) UpdateProduct(product Product) (err error) {
+	defer e.levenshteinEngine.guard("UpdateProduct", &err)
+	idx := e.currentIndex()
+	if idx == nil {
+		return ErrIndexNotBuilt
+	}
+	if !e.ContainsProduct(product.ID) {
+		return ErrProductNotIndexed
+	}
+
+	indexed := e.levenshteinEngine.interner.product(&product)
+	entry := e.newIndexEntry(&indexed)
+	content := entry.contentHash
+	if e.privacy == nil {
+		content = indexed.Fingerprint()
+	}
+	if content == idx.contentFingerprint(product.ID) {
+		return nil
+	}
+	if err := e.logUpdate(idx, e.entryRecord(oplogUpdate, &indexed, entry), nil); err != nil {
+		return err
+	}
+	idx.remove(product.ID)
+	e.addIndexEntry(idx, &indexed, entry)
+	if err := bandStoreErr(idx.bands); err != nil {
+		return err
+	}
+
+	if e.autoCompact.due(len(idx.removed), idx.size()) {
+		_, err := e.Compact()
+		return err
+	}
+	return nil
+}
+
+// retainedCandidates are the candidate pairs examined by one indexed FindDuplicates run
+type retainedCandidates struct {
+	index   *LSHIndex // Index the candidates were drawn from
+	queries []Product // Scanned products (copies sharing their caches)
+	pairs   []retainedPair
+}
+
+// retainedPair is one candidate pair: a scanned product and an indexed one
+type retainedPair struct {
+	query     int // Index into retainedCandidates.queries
+	candidate *Product
+}
+
+// add records a candidate pair
+func (r *retainedCandidates) add(query int, candidateID string) {
+	if candidate, exists := r.index.products[candidateID]; exists {
+		r.pairs = append(r.pairs, retainedPair{query: query, candidate: candidate})
+	}
+}
+
+// ReVerify re-runs the verification stage with new weights over the candidate
+// pairs retained from the last FindDuplicates run (see HybridConfig.RetainCandidates)
+// Every retained candidate is re-scored, including those that missed the
+// original threshold, so looser settings can surface pairs the first run
+// dropped. LSH is not re-run. Returns nil when nothing was retained; retained
+// pairs are dropped by BuildIndex and are never kept in privacy mode.
+func (e *HybridEngine) ReVerify(threshold float64, weights ComparisonWeights) []ComparisonResult {
+	defer e.levenshteinEngine.guard("ReVerify", nil)
+	e.indexMu.RLock()
+	retained := e.retained
+	e.indexMu.RUnlock()
+	if retained == nil {
+		return nil
+	}
+
+	var results []ComparisonResult
+	for _, pair := range retained.pairs {
+		if !e.levenshteinEngine.pairAllowed(&retained.queries[pair.query], pair.candidate) {
+			continue
+		}
+		result := e.levenshteinEngine.CompareWithWeightsPtr(&retained.queries[pair.query], pair.candidate, weights)
+		result.stampThreshold(threshold)
+		if result.MeetsThreshold {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// HasRetainedCandidates reports whether ReVerify has candidate pairs to work on
+func (e *HybridEngine) HasRetainedCandidates() bool {
+	e.indexMu.RLock()
+	defer e.indexMu.RUnlock()
+	return e.retained != nil
+}
@@ -0,0 +1,265 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// generateIndexedCatalog builds groups of products that share a name, with
+// descriptions that drift further from the first member as the group grows
+func generateIndexedCatalog(groups, perGroup int) []Product {
+	rng := rand.New(rand.NewSource(5))
+	var products []Product
+	for g := 0; g < groups; g++ {
+		name := randomWordText(rng, 4)
+		desc := randomWordText(rng, 12)
+		for m := 0; m < perGroup; m++ {
+			products = append(products, Product{
+				ID:          fmt.Sprintf("g%02d-%d", g, m),
+				Name:        name,
+				Description: desc + " " + randomWordText(rng, m*6),
+			})
+		}
+	}
+	return products
+}
+
+func TestIndexedProducts(t *testing.T) {
+	products := generateIndexedCatalog(10, 3)
+	engine := NewHybridEngine()
+
+	if engine.IndexedCount() != 0 || engine.ContainsProduct(products[0].ID) || len(engine.IndexedProducts()) != 0 {
+		t.Error("An engine without an index should report no products")
+	}
+
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	t.Run("Iteration is complete and ordered", func(t *testing.T) {
+		indexed := engine.IndexedProducts()
+		if len(indexed) != len(products) || engine.IndexedCount() != len(products) {
+			t.Fatalf("Expected %d products, got %d (count %d)", len(products), len(indexed), engine.IndexedCount())
+		}
+		for i := range products {
+			if indexed[i].ID != products[i].ID || indexed[i].Name != products[i].Name || indexed[i].Description != products[i].Description {
+				t.Errorf("Product %d: got %s, want %s", i, indexed[i].ID, products[i].ID)
+			}
+			if !engine.ContainsProduct(products[i].ID) {
+				t.Errorf("ContainsProduct(%s) = false", products[i].ID)
+			}
+		}
+		if engine.ContainsProduct("missing") {
+			t.Error("ContainsProduct should be false for unknown IDs")
+		}
+	})
+
+	t.Run("Early stop", func(t *testing.T) {
+		visited := 0
+		engine.ForEachIndexed(func(Product) bool {
+			visited++
+			return visited < 5
+		})
+		if visited != 5 {
+			t.Errorf("Expected iteration to stop after 5 products, visited %d", visited)
+		}
+	})
+
+	t.Run("Privacy mode yields IDs", func(t *testing.T) {
+		private := NewHybridEngine()
+		private.EnablePrivacyMode(PrivacyOptions{})
+		if err := private.BuildIndex(products); err != nil {
+			t.Fatalf("BuildIndex failed: %v", err)
+		}
+		for i, p := range private.IndexedProducts() {
+			if p.ID != products[i].ID || p.Name != "" || p.Description != "" {
+				t.Fatalf("Expected ID-only product %s, got %+v", products[i].ID, p)
+			}
+		}
+		if !private.ContainsProduct(products[0].ID) || private.IndexedCount() != len(products) {
+			t.Error("Privacy mode should still report membership and count")
+		}
+	})
+}
+
+func TestIndexedSnapshotConsistency(t *testing.T) {
+	small := generateIndexedCatalog(5, 2)
+	large := generateIndexedCatalog(15, 2)
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(small); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	// Rebuild with alternating catalogs while iterating
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			catalog := small
+			if i%2 == 0 {
+				catalog = large
+			}
+			if err := engine.BuildIndex(catalog); err != nil {
+				t.Errorf("BuildIndex failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		var ids []string
+		engine.ForEachIndexed(func(p Product) bool {
+			ids = append(ids, p.ID)
+			return true
+		})
+		if len(ids) != len(small) && len(ids) != len(large) {
+			t.Fatalf("Iteration saw %d products, a mix of two indexes", len(ids))
+		}
+		for k, id := range ids {
+			if id != large[k].ID {
+				t.Fatalf("Iteration saw %s at %d, want %s", id, k, large[k].ID)
+			}
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestReVerify(t *testing.T) {
+	products := generateIndexedCatalog(12, 4)
+	const threshold = 0.85
+
+	config := DefaultHybridConfig()
+	config.RetainCandidates = true
+	engine := NewHybridEngineWithConfig(config)
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	if engine.ReVerify(threshold, DefaultWeights()) != nil || engine.HasRetainedCandidates() {
+		t.Fatal("Nothing should be retained before FindDuplicates")
+	}
+
+	pairs := func(results []ComparisonResult) map[string]bool {
+		set := make(map[string]bool, len(results))
+		for _, r := range results {
+			set[makePairKey(r.ProductA.ID, r.ProductB.ID)] = true
+		}
+		return set
+	}
+	original := pairs(engine.FindDuplicates(products, threshold))
+	if len(original) == 0 {
+		t.Fatal("Test catalog should produce duplicates")
+	}
+
+	t.Run("Same settings reproduce the run", func(t *testing.T) {
+		again := pairs(engine.ReVerify(threshold, DefaultWeights()))
+		if len(again) != len(original) {
+			t.Fatalf("ReVerify found %d pairs, FindDuplicates %d", len(again), len(original))
+		}
+		for key := range original {
+			if !again[key] {
+				t.Errorf("Missing pair %s", key)
+			}
+		}
+	})
+
+	t.Run("Stricter threshold is a subset", func(t *testing.T) {
+		strict := pairs(engine.ReVerify(0.97, DefaultWeights()))
+		if len(strict) >= len(original) {
+			t.Errorf("Expected fewer pairs at 0.97, got %d of %d", len(strict), len(original))
+		}
+		for key := range strict {
+			if !original[key] {
+				t.Errorf("Pair %s is not in the original results", key)
+			}
+		}
+	})
+
+	t.Run("Name-only weights are a superset", func(t *testing.T) {
+		// Group members share names, so ignoring descriptions can only raise their scores
+		nameOnly := pairs(engine.ReVerify(threshold, ComparisonWeights{NameWeight: 1}))
+		for key := range original {
+			if !nameOnly[key] {
+				t.Errorf("Pair %s dropped by name-only weights", key)
+			}
+		}
+		if len(nameOnly) <= len(original) {
+			t.Errorf("Expected name-only weights to recover pairs the run dropped, got %d of %d", len(nameOnly), len(original))
+		}
+	})
+
+	t.Run("BuildIndex drops retained pairs", func(t *testing.T) {
+		if err := engine.BuildIndex(products); err != nil {
+			t.Fatalf("BuildIndex failed: %v", err)
+		}
+		if engine.ReVerify(threshold, DefaultWeights()) != nil {
+			t.Error("Retained pairs belong to the previous index")
+		}
+	})
+
+	t.Run("Off by default", func(t *testing.T) {
+		plain := NewHybridEngine()
+		if err := plain.BuildIndex(products); err != nil {
+			t.Fatalf("BuildIndex failed: %v", err)
+		}
+		plain.FindDuplicates(products, threshold)
+		if plain.HasRetainedCandidates() {
+			t.Error("Candidates should only be retained with RetainCandidates")
+		}
+	})
+}
+
+func TestAddProduct(t *testing.T) {
+	products := generateIndexedCatalog(10, 2)
+	engine := NewHybridEngine()
+	if err := engine.AddProduct(products[0]); err != ErrIndexNotBuilt {
+		t.Errorf("Expected ErrIndexNotBuilt without an index, got %v", err)
+	}
+
+	// Index every group's first member, then add the rest one by one
+	var firsts, rest []Product
+	for i, p := range products {
+		if i%2 == 0 {
+			firsts = append(firsts, p)
+		} else {
+			rest = append(rest, p)
+		}
+	}
+	if err := engine.BuildIndex(firsts); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	for _, p := range rest {
+		if err := engine.AddProduct(p); err != nil {
+			t.Fatalf("AddProduct(%s) failed: %v", p.ID, err)
+		}
+	}
+
+	rebuilt := NewHybridEngine()
+	if err := rebuilt.BuildIndex(append(append([]Product(nil), firsts...), rest...)); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	if engine.IndexedCount() != rebuilt.IndexedCount() {
+		t.Errorf("Expected %d indexed products, got %d", rebuilt.IndexedCount(), engine.IndexedCount())
+	}
+	for _, p := range products {
+		got, want := engine.FindDuplicatesForOne(p, 0.5), rebuilt.FindDuplicatesForOne(p, 0.5)
+		if len(got) != len(want) {
+			t.Errorf("%s: incremental index found %d duplicates, rebuilt index %d", p.ID, len(got), len(want))
+		}
+	}
+
+	var dupErr *DuplicateIDError
+	if err := engine.AddProduct(products[0]); !errors.As(err, &dupErr) {
+		t.Errorf("Expected a DuplicateIDError for an indexed ID, got %v", err)
+	}
+}
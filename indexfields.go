@@ -0,0 +1,112 @@
+package duplicatecheck
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ZeroWeightEpsilon is the normalized weight below which the hybrid engine
+// treats a field as unweighted and leaves it out of its index
+const ZeroWeightEpsilon = 1e-6
+
+// extremeWeight is the normalized weight below which a field that is still
+// indexed draws a warning (see logIndexedFields)
+const extremeWeight = 0.1
+
+// IndexedFields names the product fields the hybrid engine shingles and signs
+// for LSH candidate generation, which follow the engine's weights:
+//
+//	DescriptionWeight < ZeroWeightEpsilon  → IndexNameOnly
+//	NameWeight < ZeroWeightEpsilon         → IndexDescriptionOnly
+//	otherwise                              → IndexBothFields
+//
+// Weights are normalized first (see ComparisonWeights.Normalized). Without
+// this, a name-only engine would still bucket products by their descriptions,
+// and twins whose descriptions differ would never become candidates. Names
+// alone are shingled into character bigrams rather than word shingles, which
+// are too coarse for a few words; descriptions alone keep word shingles.
+// Mixed weights index both fields as one text, however lopsided; index
+// builds log a warning when either weight is below 0.1.
+type IndexedFields int
+
+const (
+	// IndexBothFields shingles the name and description together (default)
+	IndexBothFields IndexedFields = iota
+	// IndexNameOnly shingles the name alone
+	IndexNameOnly
+	// IndexDescriptionOnly shingles the description alone
+	IndexDescriptionOnly
+)
+
+// String returns "name+description", "name" or "description"
+func (f IndexedFields) String() string {
+	switch f {
+	case IndexNameOnly:
+		return "name"
+	case IndexDescriptionOnly:
+		return "description"
+	default:
+		return "name+description"
+	}
+}
+
+// indexedFieldsFor returns the fields indexed under weights
+func indexedFieldsFor(weights ComparisonWeights) IndexedFields {
+	w := weights.Normalized()
+	switch {
+	case w.DescriptionWeight < ZeroWeightEpsilon:
+		return IndexNameOnly
+	case w.NameWeight < ZeroWeightEpsilon:
+		return IndexDescriptionOnly
+	default:
+		return IndexBothFields
+	}
+}
+
+// WithWeights sets the weights the engine scores pairs with and, through
+// them, the fields its index is built from (see IndexedFields)
+// Takes effect on the index from the next index build; an index built under
+// other indexed fields stops matching IndexConfigFingerprint. A WeightResolver
+// or per-call weights don't change the indexed fields. Returns the engine for
+// chaining.
+func (e *HybridEngine) WithWeights(weights ComparisonWeights) *HybridEngine {
+	e.levenshteinEngine.weights = weights
+	return e
+}
+
+// GetWeights returns the engine's weights
+func (e *HybridEngine) GetWeights() ComparisonWeights {
+	return e.levenshteinEngine.weights
+}
+
+// IndexedFields returns the fields the engine's weights make it index
+func (e *HybridEngine) IndexedFields() IndexedFields {
+	return indexedFieldsFor(e.levenshteinEngine.weights)
+}
+
+// indexedFieldsFingerprintParts returns the IndexConfigFingerprint parts of
+// the indexed fields (none when both are indexed)
+func (e *HybridEngine) indexedFieldsFingerprintParts() []string {
+	if fields := e.IndexedFields(); fields != IndexBothFields {
+		return []string{"fields", fields.String()}
+	}
+	return nil
+}
+
+// logIndexedFields warns when one field carries almost no weight yet is
+// still indexed with the other, so its text can keep pairs from becoming
+// candidates while barely counting in their score
+func (e *HybridEngine) logIndexedFields(ctx context.Context) {
+	if e.logger == nil || e.IndexedFields() != IndexBothFields {
+		return
+	}
+	w := e.levenshteinEngine.weights.Normalized()
+	if w.NameWeight >= extremeWeight && w.DescriptionWeight >= extremeWeight {
+		return
+	}
+	e.logger.LogAttrs(ctx, slog.LevelWarn, "index shingles a field the weights nearly ignore",
+		slog.String("engine", "hybrid"),
+		slog.Float64("name_weight", w.NameWeight),
+		slog.Float64("description_weight", w.DescriptionWeight),
+		slog.String("indexed_fields", IndexBothFields.String()))
+}
@@ -0,0 +1,141 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// noisyDescriptionCatalog returns a generated catalog whose descriptions are
+// all replaced by random words, so only names tell duplicates apart
+func noisyDescriptionCatalog(n int) []Product {
+	rng := rand.New(rand.NewSource(11))
+	words := strings.Fields("lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore et dolore magna aliqua enim minim veniam quis nostrud exercitation ullamco laboris nisi aliquip ex ea commodo consequat")
+	catalog := GenerateTestCatalog(goldenCatalogSeed, n)
+	for i := range catalog {
+		noise := make([]string, 20+rng.Intn(40))
+		for j := range noise {
+			noise[j] = words[rng.Intn(len(words))]
+		}
+		catalog[i].Description = strings.Join(noise, " ")
+	}
+	return catalog
+}
+
+func TestIndexedFields(t *testing.T) {
+	tests := []struct {
+		weights ComparisonWeights
+		want    IndexedFields
+	}{
+		{DefaultWeights(), IndexBothFields},
+		{ComparisonWeights{NameWeight: 1, DescriptionWeight: 0}, IndexNameOnly},
+		{ComparisonWeights{NameWeight: 5, DescriptionWeight: 1e-9}, IndexNameOnly},
+		{ComparisonWeights{NameWeight: 0, DescriptionWeight: 0.3}, IndexDescriptionOnly},
+		{ComparisonWeights{NameWeight: 0.99, DescriptionWeight: 0.01}, IndexBothFields},
+		{ComparisonWeights{NameWeight: 0, DescriptionWeight: 0}, IndexBothFields}, // Normalized to the defaults
+	}
+	for _, tt := range tests {
+		engine := NewHybridEngine().WithWeights(tt.weights)
+		if got := engine.IndexedFields(); got != tt.want {
+			t.Errorf("%+v: IndexedFields() = %v, want %v", tt.weights, got, tt.want)
+		}
+	}
+
+	// Only indexes of one field change fingerprint
+	base := NewHybridEngine().IndexConfigFingerprint()
+	if got := NewHybridEngine().WithWeights(ComparisonWeights{NameWeight: 0.9, DescriptionWeight: 0.1}).IndexConfigFingerprint(); got != base {
+		t.Error("mixed weights changed the index config fingerprint")
+	}
+	nameOnly := NewHybridEngine().WithWeights(ComparisonWeights{NameWeight: 1})
+	descOnly := NewHybridEngine().WithWeights(ComparisonWeights{DescriptionWeight: 1})
+	if a, b := nameOnly.IndexConfigFingerprint(), descOnly.IndexConfigFingerprint(); a == base || b == base || a == b {
+		t.Error("indexed fields aren't part of the index config fingerprint")
+	}
+
+	// Restored from its config
+	restored, err := NewEngineFromConfig(nameOnly.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.(*HybridEngine).IndexedFields(); got != IndexNameOnly {
+		t.Errorf("restored engine indexes %v", got)
+	}
+}
+
+func TestIndexedFieldsRecall(t *testing.T) {
+	catalog := noisyDescriptionCatalog(400)
+	const threshold = 0.85
+
+	// recall returns the share of the Levenshtein engine's pairs the hybrid
+	// engine finds through LSH under weights
+	recall := func(t *testing.T, weights ComparisonWeights) float64 {
+		t.Helper()
+		truth := NewLevenshteinEngineWithWeights(weights).FindDuplicates(catalog, threshold)
+		if len(truth) < 20 {
+			t.Fatalf("%d ground-truth pairs", len(truth))
+		}
+		engine := NewHybridEngine().WithWeights(weights).WithExactModeCutoff(0)
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatal(err)
+		}
+		found := make(map[string]bool)
+		for _, r := range engine.FindDuplicates(catalog, threshold) {
+			found[makePairKey(r.ProductA.ID, r.ProductB.ID)] = true
+		}
+		hits := 0
+		for _, r := range truth {
+			if found[makePairKey(r.ProductA.ID, r.ProductB.ID)] {
+				hits++
+			}
+		}
+		return float64(hits) / float64(len(truth))
+	}
+
+	t.Run("Name only", func(t *testing.T) {
+		// Shingling the noise descriptions too finds none of these pairs
+		if got := recall(t, ComparisonWeights{NameWeight: 1}); got < 0.99 {
+			t.Errorf("recall %.3f of the Levenshtein engine's pairs", got)
+		}
+	})
+
+	t.Run("Description only", func(t *testing.T) {
+		// Noise descriptions match nothing, so give every other product's
+		// neighbour, under another name, a near copy of its description
+		saved := append([]Product(nil), catalog...)
+		defer copy(catalog, saved)
+		for i := 0; i+1 < len(catalog); i += 2 {
+			catalog[i+1].Description = catalog[i].Description + " extra"
+		}
+		if got := recall(t, ComparisonWeights{DescriptionWeight: 1}); got < 0.99 {
+			t.Errorf("recall %.3f of the Levenshtein engine's pairs", got)
+		}
+	})
+}
+
+func TestIndexedFieldsWarning(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights ComparisonWeights
+		warned  bool
+	}{
+		{"Default", DefaultWeights(), false},
+		{"Name only", ComparisonWeights{NameWeight: 1}, false},
+		{"Nearly name only", ComparisonWeights{NameWeight: 0.95, DescriptionWeight: 0.05}, true},
+		{"Nearly description only", ComparisonWeights{NameWeight: 0.02, DescriptionWeight: 0.98}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			engine := NewHybridEngine().WithWeights(tt.weights).
+				WithLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+			if err := engine.BuildIndex(loadSampleCatalog(t)); err != nil {
+				t.Fatal(err)
+			}
+			if warned := strings.Contains(buf.String(), "index shingles a field the weights nearly ignore"); warned != tt.warned {
+				t.Errorf("warned %v, want %v:\n%s", warned, tt.warned, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package duplicatecheck
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// IndexingReport describes a product whose index text hit a HybridConfig
+// indexing limit (MaxIndexTextLength or MaxShinglesPerProduct)
+type IndexingReport struct {
+	ProductID  string
+	TextLength int  // Runes of index text (prepared name and description) before truncation
+	Truncated  bool // Index text was cut to MaxIndexTextLength runes
+	Shingles   int  // Shingles before sampling (summed across chunks in chunked mode)
+	Sampled    bool // Shingles were sampled down to MaxShinglesPerProduct
+}
+
+// WithIndexingReport calls report during BuildIndex for every product that was
+// truncated or sampled, so data teams can find and fix the source feed
+// report runs on the goroutine calling BuildIndex; nil disables it.
+// Returns the engine for chaining.
+func (e *HybridEngine) WithIndexingReport(report func(IndexingReport)) *HybridEngine {
+	e.indexingReport = report
+	return e
+}
+
+// limitedIndexText returns the index text of product, its IndexedFields, cut to
+// maxIndexTextLength runes, with its length before the cut
+func (e *HybridEngine) limitedIndexText(product *Product) (string, int) {
+	name, desc := product.preparedStrings(e.preparer())
+	var text string
+	switch e.IndexedFields() {
+	case IndexNameOnly:
+		text = name
+	case IndexDescriptionOnly:
+		text = desc
+	default:
+		text = name + " " + desc
+		if e.lowInfoIndexed(name) {
+			text = desc // The name would only add noise (see WithLowInfoNames)
+		}
+	}
+	length := utf8.RuneCountInString(text)
+	if e.maxIndexTextLength == 0 || length <= e.maxIndexTextLength {
+		return text, length
+	}
+	runes := 0
+	for i := range text {
+		if runes == e.maxIndexTextLength {
+			return text[:i], length
+		}
+		runes++
+	}
+	return text, length
+}
+
+// limitedShingles returns the shingles of text, sampled down to
+// maxShingles, with the number generated before sampling and whether the
+// sample dropped any
+func (e *HybridEngine) limitedShingles(text string) ([]string, int, bool) {
+	var shingles []string
+	if e.IndexedFields() == IndexNameOnly {
+		shingles = charShingles(text, nameShingleSize)
+	} else {
+		shingles = shinglesOf(shingleTokens(text, e.splitCompounds), e.shingleSize)
+	}
+	sample, sampled := sampleShingles(shingles, e.maxShingles)
+	return sample, len(shingles), sampled
+}
+
+// sampleShingles keeps the limit distinct shingles with the smallest mixed
+// hash (a bottom-k sample), in text order, or returns shingles unchanged if
+// there are no more than limit (0 = unlimited)
+// The sample is deterministic and spread across the whole text, and two texts
+// sharing most shingles keep most of the same ones; taking every k-th shingle
+// instead would shift the whole sample when a duplicate inserts one sentence.
+func sampleShingles(shingles []string, limit int) ([]string, bool) {
+	if limit == 0 || len(shingles) <= limit {
+		return shingles, false
+	}
+	// Mixed so the sample doesn't correlate with the MinHash hashes
+	ranks := make([]uint64, len(shingles))
+	for i, shingle := range shingles {
+		ranks[i] = mix64(shingleHash(shingle))
+	}
+	distinct := append(uint64Slice(nil), ranks...)
+	sort.Sort(distinct)
+	n := 0
+	for i, rank := range distinct {
+		if i == 0 || rank != distinct[n-1] {
+			distinct[n] = rank
+			n++
+		}
+	}
+	if n <= limit {
+		return shingles, false
+	}
+
+	cutoff := distinct[limit-1]
+	sample := make([]string, 0, limit)
+	kept := make(map[uint64]bool, limit)
+	for i, rank := range ranks {
+		if rank <= cutoff && !kept[rank] {
+			kept[rank] = true
+			sample = append(sample, shingles[i])
+		}
+	}
+	return sample, true
+}
+
+// uint64Slice sorts ascending without sort.Slice's reflection
+type uint64Slice []uint64
+
+func (s uint64Slice) Len() int           { return len(s) }
+func (s uint64Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s uint64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
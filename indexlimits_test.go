@@ -0,0 +1,169 @@
+package duplicatecheck
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSampleShingles(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	shingles := generateShingles(randomWordText(rng, 2000), 3)
+
+	tests := []struct {
+		name        string
+		limit       int
+		wantLen     int
+		wantSampled bool
+	}{
+		{"Unlimited", 0, len(shingles), false},
+		{"Under the cap", len(shingles), len(shingles), false},
+		{"Sampled", 256, 256, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, sampled := sampleShingles(shingles, tt.limit)
+			if len(got) != tt.wantLen || sampled != tt.wantSampled {
+				t.Fatalf("sampleShingles() = %d shingles, sampled %v, want %d and %v", len(got), sampled, tt.wantLen, tt.wantSampled)
+			}
+			again, _ := sampleShingles(shingles, tt.limit)
+			if strings.Join(got, "|") != strings.Join(again, "|") {
+				t.Error("sampleShingles() should be deterministic")
+			}
+		})
+	}
+
+	t.Run("Sample survives an inserted sentence", func(t *testing.T) {
+		words := strings.Fields(randomWordText(rng, 2000))
+		edited := append(append(append([]string(nil), words[:1000]...), "an inserted sentence right in the middle"), words[1000:]...)
+		a, _ := sampleShingles(generateShingles(strings.Join(words, " "), 3), 256)
+		b, _ := sampleShingles(generateShingles(strings.Join(edited, " "), 3), 256)
+		kept := make(map[string]bool, len(a))
+		for _, shingle := range a {
+			kept[shingle] = true
+		}
+		shared := 0
+		for _, shingle := range b {
+			if kept[shingle] {
+				shared++
+			}
+		}
+		if shared < 240 {
+			t.Errorf("Samples share %d of 256 shingles", shared)
+		}
+	})
+}
+
+func TestLimitedIndexText(t *testing.T) {
+	config := DefaultHybridConfig()
+	config.MaxIndexTextLength = 8
+	engine := NewHybridEngineWithConfig(config)
+
+	product := Product{ID: "1", Name: "Café", Description: "crème brûlée torch"}
+	text, length := engine.limitedIndexText(&product)
+	if utf8.RuneCountInString(text) != 8 || !utf8.ValidString(text) {
+		t.Errorf("limitedIndexText() = %q, want 8 valid runes", text)
+	}
+	if full := NewHybridEngine().indexText(&product); length != utf8.RuneCountInString(full) {
+		t.Errorf("length = %d, want %d", length, utf8.RuneCountInString(full))
+	}
+}
+
+func TestIndexingLimits(t *testing.T) {
+	rng := rand.New(rand.NewSource(9))
+	var products []Product
+	for i := 0; i < 6; i++ {
+		products = append(products, Product{
+			ID:          string(rune('A' + i)),
+			Name:        "Scraped Listing " + randomWordText(rng, 3),
+			Description: randomWordText(rng, 3500), // ~21,000 characters
+		})
+	}
+	for i := 0; i < 50; i++ {
+		products = append(products, Product{ID: "short-" + randomWordText(rng, 1), Name: randomWordText(rng, 4), Description: randomWordText(rng, 20)})
+	}
+
+	config := DefaultHybridConfig()
+	config.MaxShinglesPerProduct = 256
+	config.ExactModeCutoff = 0 // Candidates must come from LSH
+	var reports []IndexingReport
+	limited := NewHybridEngineWithConfig(config).WithIndexingReport(func(r IndexingReport) {
+		reports = append(reports, r)
+	})
+	if err := limited.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Stats and report", func(t *testing.T) {
+		stats := limited.GetIndexStats()
+		if stats["sampled_products"] != 6 || stats["truncated_products"] != 0 {
+			t.Errorf("sampled_products = %v, truncated_products = %v, want 6 and 0", stats["sampled_products"], stats["truncated_products"])
+		}
+		if len(reports) != 6 {
+			t.Fatalf("%d reports, want 6", len(reports))
+		}
+		for i, r := range reports {
+			if r.ProductID != products[i].ID || !r.Sampled || r.Truncated || r.Shingles < 3000 {
+				t.Errorf("Report %+v", r)
+			}
+		}
+	})
+
+	t.Run("Duplicate of a sampled product is a candidate", func(t *testing.T) {
+		words := strings.Fields(products[2].Description)
+		words[100], words[2000] = "edited", "words"
+		duplicate := Product{
+			ID:          "dup",
+			Name:        products[2].Name,
+			Description: strings.Join(words[:1500], " ") + " Free shipping on all orders. " + strings.Join(words[1500:], " "),
+		}
+		found := false
+		for _, c := range limited.findCandidates(limited.currentIndex(), &duplicate, 0) {
+			found = found || c.id == products[2].ID
+		}
+		if !found {
+			t.Error("Sampled product not retrieved for its duplicate")
+		}
+	})
+
+	t.Run("Truncation", func(t *testing.T) {
+		config := DefaultHybridConfig()
+		config.MaxIndexTextLength = 2000
+		config.ExactModeCutoff = 0 // Candidates must come from LSH
+		engine := NewHybridEngineWithConfig(config)
+		if err := engine.BuildIndex(products); err != nil {
+			t.Fatal(err)
+		}
+		if stats := engine.GetIndexStats(); stats["truncated_products"] != 6 {
+			t.Errorf("truncated_products = %v, want 6", stats["truncated_products"])
+		}
+		// Text past the ceiling doesn't reach the index
+		tail := Product{ID: "tail", Name: "Unrelated", Description: products[0].Description[10000:]}
+		for _, c := range engine.findCandidates(engine.currentIndex(), &tail, 0) {
+			if c.id == products[0].ID {
+				t.Error("Truncated text was indexed")
+			}
+		}
+	})
+
+	t.Run("BuildIndex time is bounded", func(t *testing.T) {
+		fastest := func(engine *HybridEngine) time.Duration {
+			var best time.Duration
+			for i := 0; i < 5; i++ {
+				start := time.Now()
+				if err := engine.BuildIndex(products); err != nil {
+					t.Fatal(err)
+				}
+				if elapsed := time.Since(start); best == 0 || elapsed < best {
+					best = elapsed
+				}
+			}
+			return best
+		}
+		if l, u := fastest(limited), fastest(NewHybridEngine()); l >= u {
+			t.Errorf("Limited BuildIndex took %v, unlimited %v", l, u)
+		}
+	})
+}
@@ -0,0 +1,375 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// pipelineBatchSize is the number of products per batch when
+// PipelineOptions.BatchSize is 0
+const pipelineBatchSize = 256
+
+// PipelineOptions configures BuildIndexPipelined
+type PipelineOptions struct {
+	// Workers is the number of signature workers; 0 uses HybridConfig.BuildWorkers
+	// or the engine's worker count (1 in deterministic mode)
+	Workers int
+	// BatchSize is the number of products handed between stages at a time
+	// (0 = 256)
+	BatchSize int
+	// QueueDepth bounds each queue between stages, in batches; 0 allows two
+	// batches per worker. A full queue blocks the stage feeding it.
+	QueueDepth int
+	// Report is called once the build ends, successfully or not, on the
+	// calling goroutine
+	Report func(IngestReport)
+}
+
+// IngestReport times the stages of a BuildIndexPipelined run
+// Stage times are busy time: ParseTime is spent in ProductSource.Next,
+// HashTime computing signatures (summed across workers) and InsertTime
+// adding to the index. When the stages overlap, ParseTime +
+// HashTime/Workers + InsertTime exceeds WallTime; the stage with the largest
+// share is the bottleneck.
+type IngestReport struct {
+	Read       int           // Products read from the source
+	Indexed    int           // Products indexed
+	Collapsed  int           // Copies of an earlier entry dropped (see EnableInputDeduplication)
+	Workers    int           // Signature workers
+	ParseTime  time.Duration // Reading and parsing products
+	HashTime   time.Duration // Computing index entries, summed across workers
+	InsertTime time.Duration // Adding entries to the index
+	WallTime   time.Duration // The whole build
+	// PeakParseQueue is the most batches waiting for a signature worker
+	PeakParseQueue int
+	// PeakHashQueue is the most hashed batches waiting for the index writer,
+	// including those held back to keep input order
+	PeakHashQueue int
+}
+
+// Overlap returns how much the stages ran concurrently: the stages' combined
+// time (hashing divided among the workers) over the wall time, minus 1
+// 0 means the stages ran one after the other, as a load followed by BuildIndex.
+func (r IngestReport) Overlap() float64 {
+	if r.WallTime <= 0 {
+		return 0
+	}
+	hash := r.HashTime
+	if r.Workers > 1 {
+		hash /= time.Duration(r.Workers)
+	}
+	overlap := float64(r.ParseTime+hash+r.InsertTime)/float64(r.WallTime) - 1
+	if overlap < 0 {
+		return 0
+	}
+	return overlap
+}
+
+// ingestBatch is a run of consecutive products moving through the pipeline
+type ingestBatch struct {
+	seq      int // Position among the batches, from 0
+	products []Product
+	entries  []indexEntry
+}
+
+// BuildIndexPipelined is BuildIndexCtx reading the catalog from src while it
+// is indexed, so parsing, hashing and insertion overlap instead of running one
+// after the other
+// A reader goroutine parses batches of products into a bounded queue, a pool
+// of signature workers computes their index entries, and the calling
+// goroutine adds them to the index in input order, so the index is the one
+// BuildIndex builds from the same products. Every queue is bounded by
+// PipelineOptions.QueueDepth, so memory beyond the index stays bounded
+// however fast the source is.
+//
+// The index is published only when the build succeeds: a source error,
+// repeated IDs under DuplicateIDReject (a *DuplicateIDError listing all of
+// them, once the whole source is read) or ctx being done stop every stage and
+// leave any previous index untouched. Copies of an entry are collapsed and
+// DuplicateIDKeepFirst applied as products arrive; DuplicateIDKeepLast and
+// DuplicateIDSuffix need the whole catalog before the first product is
+// indexed, so under them the source is read in full and BuildIndexCtx builds
+// the index.
+func (e *HybridEngine) BuildIndexPipelined(ctx context.Context, src ProductSource, opts PipelineOptions) (err error) {
+	defer e.levenshteinEngine.guard("BuildIndexPipelined", &err)
+	started := time.Now()
+	var report IngestReport
+	defer func() {
+		if opts.Report != nil {
+			report.WallTime = time.Since(started)
+			opts.Report(report)
+		}
+	}()
+
+	if e.idPolicy == DuplicateIDKeepLast || e.idPolicy == DuplicateIDSuffix {
+		return e.buildIndexTwoPhase(ctx, src, &report)
+	}
+
+	// The catalog size is unknown: size the workers as for a batch of a
+	// parallel BuildIndex
+	workers := opts.Workers
+	if workers <= 0 || e.levenshteinEngine.deterministic {
+		workers = e.buildWorkerCount(buildBatchSize)
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = pipelineBatchSize
+	}
+	depth := opts.QueueDepth
+	if depth <= 0 {
+		depth = 2 * workers
+	}
+	report.Workers = workers
+	if e.logger != nil {
+		e.logger.LogAttrs(ctx, slog.LevelInfo, "index build started",
+			slog.String("engine", "hybrid"),
+			slog.String("source", "pipelined"),
+			slog.Int("workers", workers),
+			slog.Int("batch_size", batchSize))
+	}
+	e.logIndexedFields(ctx)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Batches between the reader taking a slot and the writer indexing them,
+	// bounding the queues and the batches held back for input order together
+	slots := make(chan struct{}, 2*depth+workers)
+	parsed := make(chan *ingestBatch, depth)
+	hashed := make(chan *ingestBatch, depth)
+
+	var readErr error
+	var repeated []string
+	var parseTime, hashTime time.Duration
+	var peakParsed, peakHashed int64
+	group := &workerGroup{halt: cancel} // A panicking stage cancels the others
+	group.Go(func() {
+		defer close(parsed)
+		repeated, readErr = e.readBatches(ctx, src, batchSize, slots, parsed, &report, &parseTime, &peakParsed)
+		if readErr != nil {
+			cancel()
+		}
+	})
+
+	var hashNanos int64
+	for w := 0; w < workers; w++ {
+		group.Go(func() {
+			for batch := range parsed {
+				hashStarted := time.Now()
+				batch.entries = make([]indexEntry, len(batch.products))
+				for i := range batch.products {
+					if i%buildClaimSize == 0 && ctx.Err() != nil {
+						break
+					}
+					batch.entries[i] = e.newIndexEntry(&batch.products[i])
+				}
+				atomic.AddInt64(&hashNanos, int64(time.Since(hashStarted)))
+				select {
+				case hashed <- batch:
+					recordPeak(&peakHashed, len(hashed))
+				case <-ctx.Done():
+				}
+			}
+		})
+	}
+	go func() {
+		group.wg.Wait()
+		close(hashed)
+	}()
+
+	// Index batches in input order, holding back those that finish early
+	idx := e.newLSHIndex(0)
+	idx.buildWorkers = workers
+	pending := make(map[int]*ingestBatch)
+	next := 0
+	for batch := range hashed {
+		if ctx.Err() != nil {
+			continue // Drain so the workers can exit
+		}
+		pending[batch.seq] = batch
+		recordPeak(&peakHashed, len(hashed)+len(pending))
+		for batch := pending[next]; batch != nil; batch = pending[next] {
+			delete(pending, next)
+			next++
+			insertStarted := time.Now()
+			first := uint32(len(idx.refs))
+			for i := range batch.products {
+				e.addIndexedProduct(idx, &batch.products[i], batch.entries[i])
+			}
+			e.mergeBands(idx, first, batch.entries, 1)
+			report.InsertTime += time.Since(insertStarted)
+			<-slots
+		}
+	}
+	hashTime = time.Duration(atomic.LoadInt64(&hashNanos))
+	group.Wait() // Raises a panic of the reader or a hasher
+
+	// The reader is done once hashed is closed
+	report.Indexed = len(idx.ids)
+	report.ParseTime = parseTime
+	report.HashTime = hashTime
+	report.PeakParseQueue = int(atomic.LoadInt64(&peakParsed))
+	report.PeakHashQueue = int(atomic.LoadInt64(&peakHashed))
+
+	err = readErr
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == nil && len(repeated) > 0 {
+		sort.Strings(repeated)
+		err = &DuplicateIDError{IDs: repeated}
+	}
+	if err == nil {
+		err = finishBandStore(idx.bands)
+	}
+	if err != nil {
+		if e.logger != nil {
+			e.logger.LogAttrs(context.Background(), slog.LevelWarn, "index build cancelled",
+				slog.String("engine", "hybrid"),
+				slog.Int("products", report.Read),
+				slog.Int("indexed", len(idx.ids)),
+				slog.Duration("duration", time.Since(started)),
+				slog.String("error", err.Error()))
+		}
+		return err
+	}
+	idx.buildDuration = time.Since(started)
+
+	e.indexMu.Lock()
+	e.lshIndex = idx
+	e.retained = nil
+	e.matches = nil
+	e.indexMu.Unlock()
+
+	if e.logger != nil {
+		e.logger.LogAttrs(context.Background(), slog.LevelInfo, "index build finished",
+			slog.String("engine", "hybrid"),
+			slog.Int("products", len(idx.ids)),
+			slog.Int("signatures", idx.totalChunks),
+			slog.Int("sampled", idx.sampled),
+			slog.Int("truncated", idx.truncated),
+			slog.Int("workers", workers),
+			slog.Float64("products_per_sec", idx.buildThroughput()),
+			slog.Duration("parse_time", report.ParseTime),
+			slog.Duration("hash_time", report.HashTime),
+			slog.Duration("insert_time", report.InsertTime),
+			slog.Duration("duration", idx.buildDuration))
+	}
+	return nil
+}
+
+// readBatches reads src into batches of private copies, collapsing copies and
+// applying DuplicateIDKeepFirst or DuplicateIDReject as it goes, and sends them
+// to parsed, each after taking a slot
+// Returns the IDs repeated under DuplicateIDReject (whose repeats are dropped) and the source's error, if any. Stops early when ctx is done.
+func (e *HybridEngine) readBatches(ctx context.Context, src ProductSource, batchSize int, slots chan struct{}, parsed chan<- *ingestBatch, report *IngestReport, parseTime *time.Duration, peak *int64) ([]string, error) {
+	keepCopies := e.levenshteinEngine.keepInputCopies
+	fingerprints := make(map[string]uint64) // First entry's content by ID
+	reported := make(map[string]bool)
+	var repeated []string
+
+	seq := 0
+	batch := &ingestBatch{products: make([]Product, 0, batchSize)}
+	send := func() bool {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return false
+		}
+		select {
+		case parsed <- batch:
+			recordPeak(peak, len(parsed))
+		case <-ctx.Done():
+			return false
+		}
+		seq++
+		batch = &ingestBatch{seq: seq, products: make([]Product, 0, batchSize)}
+		return true
+	}
+
+	for ctx.Err() == nil {
+		readStarted := time.Now()
+		p, err := src.Next()
+		*parseTime += time.Since(readStarted)
+		if errors.Is(err, io.EOF) {
+			if len(batch.products) > 0 {
+				send()
+			}
+			return repeated, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("duplicatecheck: reading product %d: %w", report.Read+1, err)
+		}
+		report.Read++
+
+		var fingerprint uint64
+		if !keepCopies {
+			fingerprint = p.Fingerprint()
+		}
+		if first, seen := fingerprints[p.ID]; seen {
+			if !keepCopies && first == fingerprint {
+				report.Collapsed++
+				continue
+			}
+			if e.idPolicy == DuplicateIDReject && !reported[p.ID] {
+				reported[p.ID] = true
+				repeated = append(repeated, p.ID)
+			}
+			continue
+		}
+		fingerprints[p.ID] = fingerprint
+
+		batch.products = append(batch.products, e.levenshteinEngine.interner.product(&p))
+		if len(batch.products) == batchSize && !send() {
+			break
+		}
+	}
+	return nil, nil
+}
+
+// buildIndexTwoPhase reads src in full, then builds the index with BuildIndexCtx
+func (e *HybridEngine) buildIndexTwoPhase(ctx context.Context, src ProductSource, report *IngestReport) error {
+	var products []Product
+	readStarted := time.Now()
+	for ctx.Err() == nil {
+		p, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			report.ParseTime = time.Since(readStarted)
+			return fmt.Errorf("duplicatecheck: reading product %d: %w", len(products)+1, err)
+		}
+		products = append(products, p)
+	}
+	report.ParseTime = time.Since(readStarted)
+	report.Read = len(products)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	report.Workers = e.buildWorkerCount(len(products))
+	buildStarted := time.Now()
+	err := e.BuildIndexCtx(ctx, products)
+	report.InsertTime = time.Since(buildStarted)
+	if err == nil {
+		report.Indexed = e.IndexedCount()
+	}
+	return err
+}
+
+// recordPeak raises *peak to depth if it is larger
+func recordPeak(peak *int64, depth int) {
+	for {
+		current := atomic.LoadInt64(peak)
+		if int64(depth) <= current || atomic.CompareAndSwapInt64(peak, current, int64(depth)) {
+			return
+		}
+	}
+}
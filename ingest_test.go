@@ -0,0 +1,311 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// indexFileBytes returns the bytes SaveIndex writes for engine
+func indexFileBytes(t *testing.T, engine *HybridEngine) []byte {
+	t.Helper()
+	var file bytes.Buffer
+	if err := engine.SaveIndex(&file); err != nil {
+		t.Fatalf("SaveIndex failed: %v", err)
+	}
+	return file.Bytes()
+}
+
+func TestBuildIndexPipelinedMatchesBuildIndex(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4)) // Let batches finish out of order
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 200)
+	catalog = append(catalog, catalog[3], catalog[10]) // Copies, collapsed by both
+
+	const salt = 0x5eed
+	want := NewHybridEngine().WithBucketSalt(salt)
+	if err := want.BuildIndex(catalog); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts PipelineOptions
+	}{
+		{"defaults", PipelineOptions{}},
+		{"one worker", PipelineOptions{Workers: 1}},
+		{"small batches", PipelineOptions{Workers: 4, BatchSize: 7, QueueDepth: 1}},
+		{"batch larger than catalog", PipelineOptions{Workers: 3, BatchSize: 5000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewHybridEngine().WithBucketSalt(salt)
+			var report IngestReport
+			tt.opts.Report = func(r IngestReport) { report = r }
+			if err := engine.BuildIndexPipelined(context.Background(), NewSliceProductSource(catalog), tt.opts); err != nil {
+				t.Fatalf("BuildIndexPipelined failed: %v", err)
+			}
+			if got, wantIdx := engine.currentIndex(), want.currentIndex(); !reflect.DeepEqual(got.ids, wantIdx.ids) ||
+				!reflect.DeepEqual(bucketMembers(got), bucketMembers(wantIdx)) {
+				t.Fatal("index differs from BuildIndex's")
+			}
+			if !bytes.Equal(indexFileBytes(t, engine), indexFileBytes(t, want)) {
+				t.Error("saved index differs from BuildIndex's")
+			}
+			if report.Read != len(catalog) || report.Indexed != len(catalog)-2 || report.Collapsed != 2 {
+				t.Errorf("report read %d, indexed %d, collapsed %d", report.Read, report.Indexed, report.Collapsed)
+			}
+			if report.WallTime <= 0 || report.HashTime <= 0 || report.InsertTime <= 0 {
+				t.Errorf("report missing stage timings: %+v", report)
+			}
+		})
+	}
+}
+
+func TestBuildIndexPipelinedDuplicateIDs(t *testing.T) {
+	catalog := []Product{
+		{ID: "a", Name: "Red Shirt"},
+		{ID: "b", Name: "Blue Shirt"},
+		{ID: "a", Name: "Green Shirt"},
+		{ID: "c", Name: "Black Shirt"},
+		{ID: "b", Name: "Blue Shirt Large"},
+	}
+	tests := []struct {
+		policy  DuplicateIDPolicy
+		wantErr []string
+	}{
+		{DuplicateIDReject, []string{"a", "b"}},
+		{DuplicateIDKeepFirst, nil},
+		{DuplicateIDKeepLast, nil},
+		{DuplicateIDSuffix, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			want := NewHybridEngine()
+			want.SetDuplicateIDPolicy(tt.policy)
+			wantErr := want.BuildIndex(catalog)
+
+			engine := NewHybridEngine()
+			engine.SetDuplicateIDPolicy(tt.policy)
+			err := engine.BuildIndexPipelined(context.Background(), NewSliceProductSource(catalog), PipelineOptions{BatchSize: 2})
+			if tt.wantErr != nil {
+				var dupErr *DuplicateIDError
+				if !errors.As(err, &dupErr) || !reflect.DeepEqual(dupErr.IDs, tt.wantErr) || wantErr == nil {
+					t.Fatalf("err = %v, want IDs %v", err, tt.wantErr)
+				}
+				if engine.currentIndex() != nil {
+					t.Error("rejected build published an index")
+				}
+				return
+			}
+			if err != nil || wantErr != nil {
+				t.Fatalf("err = %v, BuildIndex err = %v", err, wantErr)
+			}
+			if got := engine.IndexedProducts(); !reflect.DeepEqual(got, want.IndexedProducts()) {
+				t.Errorf("indexed %v, want %v", got, want.IndexedProducts())
+			}
+		})
+	}
+}
+
+// failingSource yields products, then an error
+type failingSource struct {
+	products []Product
+	err      error
+}
+
+func (s *failingSource) Next() (Product, error) {
+	if len(s.products) == 0 {
+		return Product{}, s.err
+	}
+	p := s.products[0]
+	s.products = s.products[1:]
+	return p, nil
+}
+
+// cancellingSource yields products, cancelling its build after 500
+type cancellingSource struct {
+	cancel context.CancelFunc
+	read   int
+}
+
+func (s *cancellingSource) Next() (Product, error) {
+	if s.read++; s.read == 500 {
+		s.cancel()
+	}
+	return Product{ID: fmt.Sprintf("p%d", s.read), Name: fmt.Sprintf("Product %d", s.read)}, nil
+}
+
+func TestBuildIndexPipelinedStops(t *testing.T) {
+	previous := GenerateTestCatalog(goldenCatalogSeed, 20)
+	broken := errors.New("disk on fire")
+
+	tests := []struct {
+		name    string
+		src     func(cancel context.CancelFunc) ProductSource
+		wantErr error
+	}{
+		{"source error", func(context.CancelFunc) ProductSource {
+			return &failingSource{products: GenerateTestCatalog(goldenCatalogSeed, 300), err: broken}
+		}, broken},
+		{"cancelled", func(cancel context.CancelFunc) ProductSource { return &cancellingSource{cancel: cancel} }, context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewHybridEngine()
+			if err := engine.BuildIndex(previous); err != nil {
+				t.Fatalf("BuildIndex failed: %v", err)
+			}
+			before := engine.currentIndex()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var reported bool
+			opts := PipelineOptions{Workers: 2, BatchSize: 16, QueueDepth: 1, Report: func(IngestReport) { reported = true }}
+			if err := engine.BuildIndexPipelined(ctx, tt.src(cancel), opts); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if engine.currentIndex() != before {
+				t.Error("failed build replaced the previous index")
+			}
+			if !reported {
+				t.Error("Report not called")
+			}
+		})
+	}
+}
+
+func TestProductSources(t *testing.T) {
+	want := []Product{
+		{ID: "1", Name: "Red Shirt", Description: "Cotton, size M"},
+		{ID: "2", Name: "Blue \"Denim\" Jeans"},
+		{ID: "3", SourceID: "x", Name: "Hat", Description: "Wool\nwinter hat"},
+	}
+	tests := []struct {
+		name  string
+		input string
+		csv   bool
+	}{
+		{"jsonl", `{"id":"1","name":"Red Shirt","description":"Cotton, size M"}
+{"ID":"2","Name":"Blue \"Denim\" Jeans"}
+
+{"id":"3","sourceid":"x","name":"Hat","description":"Wool\nwinter hat"}
+`, false},
+		{"json array", ` [{"id":"1","name":"Red Shirt","description":"Cotton, size M"},
+ {"id":"2","name":"Blue \"Denim\" Jeans"}, {"id":"3","sourceid":"x","name":"Hat","description":"Wool\nwinter hat"}]`, false},
+		{"csv", "\ufeffName,ID,extra,Description,source_id\n" +
+			"Red Shirt,1,,\"Cotton, size M\",\n" +
+			"\"Blue \"\"Denim\"\" Jeans\",2,,,\n" +
+			"Hat,3,,\"Wool\nwinter hat\",x\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var src ProductSource = NewJSONLProductSource(strings.NewReader(tt.input))
+			if tt.csv {
+				var err error
+				if src, err = NewCSVProductSource(strings.NewReader(tt.input)); err != nil {
+					t.Fatalf("NewCSVProductSource failed: %v", err)
+				}
+			}
+			var got []Product
+			for {
+				p, err := src.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Next failed: %v", err)
+				}
+				got = append(got, p)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("read %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	if _, err := NewCSVProductSource(strings.NewReader("id,title\n1,Hat\n")); err == nil {
+		t.Error("CSV without a name column accepted")
+	}
+	malformed := NewJSONLProductSource(strings.NewReader("{\"id\":\"1\"}\n{oops}\n"))
+	if _, err := malformed.Next(); err != nil {
+		t.Fatalf("first product: %v", err)
+	}
+	if _, err := malformed.Next(); err == nil || err == io.EOF {
+		t.Errorf("malformed product: err = %v", err)
+	}
+}
+
+// writeCatalogFile writes n generated products as JSON Lines to a temp file
+func writeCatalogFile(b *testing.B, n int) string {
+	b.Helper()
+	path := filepath.Join(b.TempDir(), "catalog.jsonl")
+	file, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	enc := json.NewEncoder(file)
+	for _, p := range GenerateTestCatalog(goldenCatalogSeed, n) {
+		if err := enc.Encode(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
+
+// BenchmarkCatalogIngest compares loading a 100k-product file and then
+// building the index with building it from the file as it is read
+func BenchmarkCatalogIngest(b *testing.B) {
+	path := writeCatalogFile(b, 100000)
+	open := func() (*os.File, ProductSource) {
+		file, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return file, NewJSONLProductSource(file)
+	}
+
+	b.Run("load then build", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			file, src := open()
+			var products []Product
+			for {
+				p, err := src.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+				products = append(products, p)
+			}
+			file.Close()
+			if err := NewHybridEngine().BuildIndex(products); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("pipelined", func(b *testing.B) {
+		var overlap float64
+		for i := 0; i < b.N; i++ {
+			file, src := open()
+			opts := PipelineOptions{Report: func(r IngestReport) { overlap += r.Overlap() }}
+			if err := NewHybridEngine().BuildIndexPipelined(context.Background(), src, opts); err != nil {
+				b.Fatal(err)
+			}
+			file.Close()
+		}
+		b.ReportMetric(overlap/float64(b.N), "overlap")
+	})
+}
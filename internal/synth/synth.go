@@ -0,0 +1,456 @@
+// Package synth generates the synthetic product catalogs behind the public
+// gen package and the library's own tests and benchmarks
+// It works on plain records so that the root package's tests can use it
+// without an import cycle; gen converts records to duplicatecheck.Product.
+package synth
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"unicode"
+)
+
+// Record is one generated product
+type Record struct {
+	ID          string
+	Name        string
+	Description string
+}
+
+// Mutation is one way of turning a product into a near-duplicate of itself
+type Mutation int
+
+const (
+	MutateCase        Mutation = iota // Change the case of the name
+	MutateReorder                     // Move one name token elsewhere in the name
+	MutateTypo                        // Insert, delete, substitute or transpose one letter of the name
+	MutateSpecSwap                    // Replace one spec (storage, color, size...) in name and description
+	MutateBoilerplate                 // Append a boilerplate sentence to the description
+)
+
+// AllMutations lists every Mutation
+var AllMutations = []Mutation{MutateCase, MutateReorder, MutateTypo, MutateSpecSwap, MutateBoilerplate}
+
+// String returns the mutation name
+func (m Mutation) String() string {
+	switch m {
+	case MutateCase:
+		return "case"
+	case MutateReorder:
+		return "reorder"
+	case MutateTypo:
+		return "typo"
+	case MutateSpecSwap:
+		return "spec-swap"
+	case MutateBoilerplate:
+		return "boilerplate"
+	default:
+		return fmt.Sprintf("Mutation(%d)", int(m))
+	}
+}
+
+// Config controls generation; see the gen package for field documentation
+type Config struct {
+	Seed                  int64
+	Size                  int
+	MinNameWords          int
+	MaxNameWords          int
+	MinDescriptionWords   int
+	MaxDescriptionWords   int
+	DuplicateRate         float64
+	Mutations             []Mutation
+	MutationsPerDuplicate int
+	IDPrefix              string
+}
+
+// Default returns the default configuration: 1,000 products, 10% duplicates,
+// every mutation, one per duplicate
+func Default() Config {
+	return Config{
+		Seed:                  1,
+		Size:                  1000,
+		MinNameWords:          4,
+		MaxNameWords:          8,
+		MinDescriptionWords:   20,
+		MaxDescriptionWords:   60,
+		DuplicateRate:         0.1,
+		Mutations:             AllMutations,
+		MutationsPerDuplicate: 1,
+		IDPrefix:              "P",
+	}
+}
+
+// normalize fills zero and inconsistent fields from Default
+func (c Config) normalize() Config {
+	d := Default()
+	if c.MinNameWords <= 0 {
+		c.MinNameWords = d.MinNameWords
+	}
+	if c.MaxNameWords < c.MinNameWords {
+		c.MaxNameWords = c.MinNameWords
+	}
+	if c.MaxDescriptionWords <= 0 {
+		c.MinDescriptionWords, c.MaxDescriptionWords = d.MinDescriptionWords, d.MaxDescriptionWords
+	}
+	if c.MinDescriptionWords < 0 {
+		c.MinDescriptionWords = 0
+	}
+	if c.MaxDescriptionWords < c.MinDescriptionWords {
+		c.MaxDescriptionWords = c.MinDescriptionWords
+	}
+	if c.DuplicateRate < 0 || math.IsNaN(c.DuplicateRate) {
+		c.DuplicateRate = 0
+	}
+	if c.DuplicateRate > 1 {
+		c.DuplicateRate = 1
+	}
+	if len(c.Mutations) == 0 {
+		c.Mutations = d.Mutations
+	}
+	if c.MutationsPerDuplicate <= 0 {
+		c.MutationsPerDuplicate = d.MutationsPerDuplicate
+	}
+	if c.IDPrefix == "" {
+		c.IDPrefix = d.IDPrefix
+	}
+	return c
+}
+
+// DuplicateCount returns how many of size items are duplicates at rate
+func DuplicateCount(size int, rate float64) int {
+	return int(math.Round(float64(size) * rate))
+}
+
+// Catalog is a generated catalog with its ground truth
+type Catalog struct {
+	Records []Record
+	Family  []int // Family[i] is shared by a product and all its duplicates
+}
+
+// Generate returns a catalog of cfg.Size records, DuplicateCount of which are
+// mutated copies of another record; the same Config always yields the same catalog
+func Generate(cfg Config) Catalog {
+	cfg = cfg.normalize()
+	if cfg.Size <= 0 {
+		return Catalog{}
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	duplicates := DuplicateCount(cfg.Size, cfg.DuplicateRate)
+	if duplicates >= cfg.Size {
+		duplicates = cfg.Size - 1 // Duplicates need at least one original
+	}
+	originals := cfg.Size - duplicates
+
+	records := make([]Record, 0, cfg.Size)
+	family := make([]int, 0, cfg.Size)
+	for i := 0; i < originals; i++ {
+		records = append(records, original(rng, cfg))
+		family = append(family, i)
+	}
+	for i := 0; i < duplicates; i++ {
+		source := rng.Intn(originals)
+		dup, _ := Mutate(rng, records[source], cfg.Mutations, cfg.MutationsPerDuplicate)
+		records = append(records, dup)
+		family = append(family, source)
+	}
+
+	rng.Shuffle(len(records), func(i, j int) {
+		records[i], records[j] = records[j], records[i]
+		family[i], family[j] = family[j], family[i]
+	})
+	width := len(fmt.Sprint(cfg.Size))
+	if width < 4 {
+		width = 4
+	}
+	for i := range records {
+		records[i].ID = fmt.Sprintf("%s%0*d", cfg.IDPrefix, width, i+1)
+	}
+	return Catalog{Records: records, Family: family}
+}
+
+// DuplicatePairs returns every pair of positions in the same family
+func (c Catalog) DuplicatePairs() [][2]int {
+	members := make(map[int][]int)
+	for i, f := range c.Family {
+		members[f] = append(members[f], i)
+	}
+	var pairs [][2]int
+	for i, f := range c.Family {
+		for _, j := range members[f] {
+			if j > i {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+	}
+	return pairs
+}
+
+// LabeledPair is a pair of records with its ground-truth label
+type LabeledPair struct {
+	A, B      Record
+	Duplicate bool
+	Mutations []Mutation // Mutations that turned A into B (duplicates only)
+}
+
+// GeneratePairs returns cfg.Size pairs, DuplicateCount of them duplicates (a
+// record and a mutated copy) and the rest two different products of the same
+// category, in shuffled order
+func GeneratePairs(cfg Config) []LabeledPair {
+	cfg = cfg.normalize()
+	if cfg.Size <= 0 {
+		return nil
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	duplicates := DuplicateCount(cfg.Size, cfg.DuplicateRate)
+
+	pairs := make([]LabeledPair, 0, cfg.Size)
+	for i := 0; i < cfg.Size; i++ {
+		category := categories[rng.Intn(len(categories))]
+		a := originalIn(rng, cfg, category)
+		if i < duplicates {
+			b, applied := Mutate(rng, a, cfg.Mutations, cfg.MutationsPerDuplicate)
+			pairs = append(pairs, LabeledPair{A: a, B: b, Duplicate: true, Mutations: applied})
+			continue
+		}
+		b := originalIn(rng, cfg, category)
+		for b.Name == a.Name {
+			b = originalIn(rng, cfg, category)
+		}
+		pairs = append(pairs, LabeledPair{A: a, B: b})
+	}
+
+	rng.Shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
+	width := len(fmt.Sprint(cfg.Size))
+	for i := range pairs {
+		pairs[i].A.ID = fmt.Sprintf("%s%0*d-a", cfg.IDPrefix, width, i+1)
+		pairs[i].B.ID = fmt.Sprintf("%s%0*d-b", cfg.IDPrefix, width, i+1)
+	}
+	return pairs
+}
+
+// Mutate returns a copy of r with n mutations drawn from allowed, and the
+// mutations applied
+func Mutate(rng *rand.Rand, r Record, allowed []Mutation, n int) (Record, []Mutation) {
+	applied := make([]Mutation, n)
+	for i := range applied {
+		m := allowed[rng.Intn(len(allowed))]
+		r = m.apply(rng, r)
+		applied[i] = m
+	}
+	return r, applied
+}
+
+// apply applies the mutation to r
+func (m Mutation) apply(rng *rand.Rand, r Record) Record {
+	switch m {
+	case MutateCase:
+		r.Name = ChangeCase(rng, r.Name)
+	case MutateReorder:
+		r.Name = ReorderTokens(rng, r.Name)
+	case MutateTypo:
+		r.Name = InjectTypos(rng, r.Name, 1)
+	case MutateSpecSwap:
+		if from, to, ok := specSwap(rng, r.Name); ok {
+			r.Name = replaceToken(r.Name, from, to)
+			r.Description = replaceToken(r.Description, from, to)
+		}
+	case MutateBoilerplate:
+		r.Description = AppendBoilerplate(rng, r.Description)
+	}
+	return r
+}
+
+// ChangeCase returns s lowercased, uppercased, or with every word capitalized
+// or lowercased at its first letter, whichever differs from s
+func ChangeCase(rng *rand.Rand, s string) string {
+	variants := []func(string) string{
+		strings.ToLower,
+		strings.ToUpper,
+		func(s string) string { return mapWordStarts(s, unicode.ToUpper) },
+		func(s string) string { return mapWordStarts(s, unicode.ToLower) },
+	}
+	start := rng.Intn(len(variants))
+	for i := range variants {
+		if changed := variants[(start+i)%len(variants)](s); changed != s {
+			return changed
+		}
+	}
+	return s
+}
+
+// mapWordStarts applies f to the first rune of every word
+func mapWordStarts(s string, f func(rune) rune) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if i == 0 || runes[i-1] == ' ' {
+			runes[i] = f(r)
+		}
+	}
+	return string(runes)
+}
+
+// ReorderTokens moves one space-separated token of s to another position
+func ReorderTokens(rng *rand.Rand, s string) string {
+	tokens := strings.Fields(s)
+	if len(tokens) < 2 {
+		return s
+	}
+	from := rng.Intn(len(tokens))
+	to := rng.Intn(len(tokens) - 1)
+	if to >= from {
+		to++
+	}
+	token := tokens[from]
+	tokens = append(tokens[:from], tokens[from+1:]...)
+	tokens = append(tokens[:to], append([]string{token}, tokens[to:]...)...)
+	return strings.Join(tokens, " ")
+}
+
+// InjectTypos applies n random letter edits to s: a substitution, insertion,
+// deletion, or transposition of adjacent letters
+// Each edit changes the edit distance to s by at most two (one for all but
+// transpositions); only letters are edited, so numbers and specs survive.
+func InjectTypos(rng *rand.Rand, s string, n int) string {
+	runes := []rune(s)
+	for k := 0; k < n; k++ {
+		var letters []int
+		for i, r := range runes {
+			if unicode.IsLetter(r) {
+				letters = append(letters, i)
+			}
+		}
+		if len(letters) == 0 {
+			break
+		}
+		i := letters[rng.Intn(len(letters))]
+		letter := rune('a' + rng.Intn(26))
+		switch rng.Intn(4) {
+		case 0: // Substitute
+			for letter == unicode.ToLower(runes[i]) {
+				letter = rune('a' + rng.Intn(26))
+			}
+			runes[i] = letter
+		case 1: // Insert
+			runes = append(runes[:i], append([]rune{letter}, runes[i:]...)...)
+		case 2: // Delete
+			if len(letters) > 1 {
+				runes = append(runes[:i], runes[i+1:]...)
+			} else {
+				runes[i] = letter
+			}
+		default: // Transpose with the next letter
+			if i+1 < len(runes) && unicode.IsLetter(runes[i+1]) && runes[i] != runes[i+1] {
+				runes[i], runes[i+1] = runes[i+1], runes[i]
+			} else {
+				runes = append(runes[:i], append([]rune{letter}, runes[i:]...)...)
+			}
+		}
+	}
+	return string(runes)
+}
+
+// SwapSpecs replaces one spec token of s (a storage size, color, screen size,
+// power or volume) with another value of the same kind
+// Returns s unchanged when it has no spec token.
+func SwapSpecs(rng *rand.Rand, s string) string {
+	if from, to, ok := specSwap(rng, s); ok {
+		return replaceToken(s, from, to)
+	}
+	return s
+}
+
+// specSwap picks a spec token of s and a different value of its kind
+func specSwap(rng *rand.Rand, s string) (from, to string, ok bool) {
+	type found struct {
+		token string
+		kind  []string
+	}
+	var specs []found
+	for _, token := range strings.Fields(s) {
+		if kind, exists := specKindOf[strings.ToLower(strings.Trim(token, ".,"))]; exists {
+			specs = append(specs, found{strings.Trim(token, ".,"), kind})
+		}
+	}
+	if len(specs) == 0 {
+		return "", "", false
+	}
+	spec := specs[rng.Intn(len(specs))]
+	for {
+		to = spec.kind[rng.Intn(len(spec.kind))]
+		if !strings.EqualFold(to, spec.token) {
+			return spec.token, to, true
+		}
+	}
+}
+
+// replaceToken replaces every whole-token occurrence of from in s with to
+func replaceToken(s, from, to string) string {
+	tokens := strings.Split(s, " ")
+	for i, token := range tokens {
+		trimmed := strings.TrimRight(token, ".,")
+		if trimmed == from {
+			tokens[i] = to + token[len(trimmed):]
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// AppendBoilerplate appends a seller boilerplate sentence to s
+func AppendBoilerplate(rng *rand.Rand, s string) string {
+	sentence := boilerplate[rng.Intn(len(boilerplate))]
+	if s == "" {
+		return sentence
+	}
+	return s + " " + sentence
+}
+
+// original returns a new product of a random category
+func original(rng *rand.Rand, cfg Config) Record {
+	return originalIn(rng, cfg, categories[rng.Intn(len(categories))])
+}
+
+// originalIn returns a new product of category c
+func originalIn(rng *rand.Rand, cfg Config, c category) Record {
+	brand := c.brands[rng.Intn(len(c.brands))]
+	kind := c.types[rng.Intn(len(c.types))]
+	model := fmt.Sprintf("%c%d", 'A'+rng.Intn(26), 10+rng.Intn(990))
+	specs := make([]string, len(c.specs))
+	for i, values := range c.specs {
+		specs[i] = values[rng.Intn(len(values))]
+	}
+
+	// Name: brand, type, model and specs, padded or cut to the drawn length
+	words := append([]string{brand, kind, model}, specs...)
+	target := cfg.MinNameWords + rng.Intn(cfg.MaxNameWords-cfg.MinNameWords+1)
+	for len(strings.Fields(strings.Join(words, " "))) < target {
+		words = append(words, nameFillers[rng.Intn(len(nameFillers))])
+	}
+	nameTokens := strings.Fields(strings.Join(words, " "))
+	if len(nameTokens) > target {
+		nameTokens = nameTokens[:target]
+	}
+
+	// Description: spec sentences, then filler sentences up to the drawn length
+	target = cfg.MinDescriptionWords + rng.Intn(cfg.MaxDescriptionWords-cfg.MinDescriptionWords+1)
+	var desc []string
+	if target > 0 {
+		desc = strings.Fields(fmt.Sprintf("The %s %s by %s.", strings.ToLower(kind), model, brand))
+		for _, spec := range specs {
+			desc = append(desc, strings.Fields(fmt.Sprintf("Features %s.", spec))...)
+		}
+		for len(desc) < target {
+			sentence := 6 + rng.Intn(8)
+			for w := 0; w < sentence; w++ {
+				desc = append(desc, descriptionWords[rng.Intn(len(descriptionWords))])
+			}
+			desc[len(desc)-1] += "."
+		}
+		if len(desc) > target {
+			desc = desc[:target]
+		}
+	}
+
+	return Record{Name: strings.Join(nameTokens, " "), Description: strings.Join(desc, " ")}
+}
@@ -0,0 +1,196 @@
+package synth
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const sample = "Samsung Smartphone K420 256GB Black 13-inch Pro"
+
+func TestMutators(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	t.Run("ChangeCase folds equal", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			got := ChangeCase(rng, sample)
+			if got == sample || !strings.EqualFold(got, sample) {
+				t.Fatalf("ChangeCase() = %q", got)
+			}
+		}
+	})
+
+	t.Run("ReorderTokens keeps the tokens", func(t *testing.T) {
+		want := sortedFields(sample)
+		for i := 0; i < 50; i++ {
+			got := ReorderTokens(rng, sample)
+			if got == sample || !reflect.DeepEqual(sortedFields(got), want) {
+				t.Fatalf("ReorderTokens() = %q", got)
+			}
+		}
+	})
+
+	t.Run("InjectTypos edits letters only", func(t *testing.T) {
+		for n := 1; n <= 3; n++ {
+			for i := 0; i < 50; i++ {
+				got := InjectTypos(rng, sample, n)
+				if d := distance(got, sample); d == 0 || d > 2*n {
+					t.Fatalf("InjectTypos(%d) = %q, distance %d", n, got, d)
+				}
+				if digits(got) != digits(sample) {
+					t.Fatalf("InjectTypos(%d) = %q edited a digit", n, got)
+				}
+			}
+		}
+	})
+
+	t.Run("SwapSpecs replaces a spec with its kind", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			got := SwapSpecs(rng, sample)
+			from, to := diffToken(sample, got)
+			if from == "" || !reflect.DeepEqual(specKindOf[strings.ToLower(from)], specKindOf[strings.ToLower(to)]) {
+				t.Fatalf("SwapSpecs() = %q", got)
+			}
+		}
+		if got := SwapSpecs(rng, "Plain Mug"); got != "Plain Mug" {
+			t.Errorf("SwapSpecs() without specs = %q", got)
+		}
+	})
+
+	t.Run("SpecSwap keeps name and description consistent", func(t *testing.T) {
+		r := Record{Name: sample, Description: "Comes in 256GB, with a Black finish."}
+		for i := 0; i < 50; i++ {
+			got := MutateSpecSwap.apply(rng, r)
+			from, to := diffToken(r.Name, got.Name)
+			if !strings.Contains(r.Description, from) {
+				continue
+			}
+			if strings.Contains(got.Description, from+",") || !strings.Contains(got.Description, to) {
+				t.Fatalf("Description %q not swapped from %s to %s", got.Description, from, to)
+			}
+		}
+	})
+
+	t.Run("AppendBoilerplate keeps the text", func(t *testing.T) {
+		got := AppendBoilerplate(rng, "Great kettle.")
+		if !strings.HasPrefix(got, "Great kettle. ") || len(got) == len("Great kettle. ") {
+			t.Errorf("AppendBoilerplate() = %q", got)
+		}
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	cfg := Default()
+	cfg.Size = 300
+
+	catalog := Generate(cfg)
+	if len(catalog.Records) != 300 || len(catalog.Family) != 300 {
+		t.Fatalf("Generate() returned %d records", len(catalog.Records))
+	}
+	families := make(map[int]bool)
+	ids := make(map[string]bool)
+	for i, r := range catalog.Records {
+		families[catalog.Family[i]] = true
+		ids[r.ID] = true
+		if words := len(strings.Fields(r.Description)); words < cfg.MinDescriptionWords {
+			t.Errorf("Record %s has %d description words", r.ID, words)
+		}
+	}
+	if want := 300 - DuplicateCount(300, cfg.DuplicateRate); len(families) != want {
+		t.Errorf("%d families, want %d", len(families), want)
+	}
+	if len(ids) != 300 || !ids["P0001"] || !ids["P0300"] {
+		t.Errorf("IDs should be unique and numbered from P0001")
+	}
+	if !reflect.DeepEqual(Generate(cfg), catalog) {
+		t.Error("Generate() should be deterministic per Config")
+	}
+	cfg.Seed++
+	if reflect.DeepEqual(Generate(cfg).Records, catalog.Records) {
+		t.Error("Different seeds should generate different catalogs")
+	}
+}
+
+func TestGeneratePairs(t *testing.T) {
+	cfg := Default()
+	cfg.Size, cfg.DuplicateRate = 200, 0.25
+
+	pairs := GeneratePairs(cfg)
+	duplicates := 0
+	for _, p := range pairs {
+		if p.Duplicate {
+			duplicates++
+			if len(p.Mutations) != 1 {
+				t.Errorf("Pair %s has %d mutations", p.A.ID, len(p.Mutations))
+			}
+		} else if p.A.Name == p.B.Name {
+			t.Errorf("Negative pair %s has identical names", p.A.ID)
+		}
+	}
+	if len(pairs) != 200 || duplicates != 50 {
+		t.Errorf("GeneratePairs() = %d pairs, %d duplicates, want 200 and 50", len(pairs), duplicates)
+	}
+	if !reflect.DeepEqual(GeneratePairs(cfg), pairs) {
+		t.Error("GeneratePairs() should be deterministic per Config")
+	}
+}
+
+func sortedFields(s string) []string {
+	fields := strings.Fields(s)
+	sort.Strings(fields)
+	return fields
+}
+
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// diffToken returns the first token that differs between a and b
+func diffToken(a, b string) (string, string) {
+	ta, tb := strings.Fields(a), strings.Fields(b)
+	for i := range ta {
+		if i < len(tb) && ta[i] != tb[i] {
+			return ta[i], tb[i]
+		}
+	}
+	return "", ""
+}
+
+// distance is the plain Levenshtein distance between a and b
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = smallest(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+func smallest(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
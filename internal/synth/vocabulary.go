@@ -0,0 +1,76 @@
+package synth
+
+import "strings"
+
+// Spec kinds: every value of a kind can replace another in MutateSpecSwap
+var (
+	storageSpecs = []string{"64GB", "128GB", "256GB", "512GB", "1TB"}
+	colorSpecs   = []string{"Black", "White", "Silver", "Blue", "Red", "Green", "Graphite"}
+	screenSpecs  = []string{"11-inch", "13-inch", "14-inch", "15.6-inch", "27-inch"}
+	powerSpecs   = []string{"800W", "1200W", "1500W", "2000W"}
+	volumeSpecs  = []string{"500ml", "750ml", "1L", "1.5L", "2L", "32L"}
+)
+
+// specKindOf maps every lowercased spec value to its kind
+var specKindOf = func() map[string][]string {
+	kinds := make(map[string][]string)
+	for _, kind := range [][]string{storageSpecs, colorSpecs, screenSpecs, powerSpecs, volumeSpecs} {
+		for _, value := range kind {
+			kinds[strings.ToLower(value)] = kind
+		}
+	}
+	return kinds
+}()
+
+// category is a family of products sharing brands, types and spec kinds
+type category struct {
+	brands []string
+	types  []string
+	specs  [][]string // Spec kinds, one value of each per product
+}
+
+var categories = []category{
+	{
+		brands: []string{"Samsung", "Apple", "Sony", "Lenovo", "Xiaomi", "Asus"},
+		types:  []string{"Smartphone", "Laptop", "Tablet", "Headphones", "Smartwatch", "Monitor"},
+		specs:  [][]string{storageSpecs, colorSpecs, screenSpecs},
+	},
+	{
+		brands: []string{"Philips", "Bosch", "Tefal", "Dyson", "Breville"},
+		types:  []string{"Blender", "Kettle", "Vacuum Cleaner", "Coffee Maker", "Air Fryer"},
+		specs:  [][]string{powerSpecs, colorSpecs, volumeSpecs},
+	},
+	{
+		brands: []string{"Hydro Flask", "Coleman", "Osprey", "Yeti", "Stanley"},
+		types:  []string{"Water Bottle", "Backpack", "Tent", "Cooler", "Thermos"},
+		specs:  [][]string{volumeSpecs, colorSpecs},
+	},
+}
+
+// nameFillers pad names to their drawn length
+var nameFillers = []string{
+	"Pro", "Max", "Plus", "Lite", "Ultra", "Edition", "Wireless", "Portable",
+	"Compact", "Premium", "Series", "Classic", "Sport", "Mini", "Gen 2",
+}
+
+// descriptionWords fill descriptions to their drawn length
+var descriptionWords = []string{
+	"durable", "lightweight", "design", "with", "and", "for", "everyday", "use",
+	"premium", "materials", "battery", "life", "fast", "charging", "easy", "to",
+	"clean", "quiet", "operation", "ergonomic", "handle", "stainless", "steel",
+	"travel", "home", "office", "reliable", "performance", "advanced", "technology",
+	"warranty", "included", "compatible", "accessories", "energy", "efficient",
+	"sleek", "finish", "the", "perfect", "choice", "outdoor", "adventures",
+	"high", "quality", "sound", "display", "capacity", "insulated", "keeps",
+	"drinks", "cold", "hot", "hours", "water", "resistant", "built", "last",
+}
+
+// boilerplate are seller sentences appended by MutateBoilerplate
+var boilerplate = []string{
+	"Free shipping on orders over $50.",
+	"Ships within 24 hours from our warehouse.",
+	"100% satisfaction guaranteed or your money back.",
+	"Contact us with any questions before purchasing.",
+	"Brand new in original packaging.",
+	"Check out our store for more great deals!",
+}
@@ -0,0 +1,171 @@
+package duplicatecheck
+
+import (
+	"hash/maphash"
+	"strings"
+	"sync"
+)
+
+// internShards is the number of independently locked shards of an Interner
+const internShards = 64
+
+// Interner deduplicates strings: Intern returns one shared copy of every
+// distinct content, so a catalog where thousands of products repeat the same
+// boilerplate description holds it in memory once
+// Strings are sharded by a hash of their content, each shard behind its own
+// lock, so an Interner is safe for concurrent use and one can be shared by
+// every engine and index of a process (see WithInterner). Interned strings
+// are kept until the Interner itself is unreachable.
+type Interner struct {
+	seed   maphash.Seed
+	shards [internShards]internShard
+}
+
+// internShard holds the strings of one hash shard
+type internShard struct {
+	mu      sync.Mutex
+	strings map[string]string
+	refs    uint64 // Intern calls answered by this shard
+	bytes   uint64 // Bytes of the distinct strings
+	saved   uint64 // Bytes of the calls answered with an existing string
+}
+
+// InternerStats describes what an Interner holds
+type InternerStats struct {
+	Strings    int    `json:"strings"`     // Distinct strings held
+	References uint64 `json:"references"`  // Non-empty strings interned, repeats included
+	Bytes      uint64 `json:"bytes"`       // Bytes of the distinct strings
+	BytesSaved uint64 `json:"bytes_saved"` // Estimate: bytes of the repeats, which would otherwise each hold a copy
+}
+
+// NewInterner returns an empty Interner
+func NewInterner() *Interner {
+	in := &Interner{seed: maphash.MakeSeed()}
+	for i := range in.shards {
+		in.shards[i].strings = make(map[string]string)
+	}
+	return in
+}
+
+// Intern returns the interned string equal to s, interning a private copy of
+// s the first time its content is seen
+// The copy keeps s from pinning the larger buffer it may be a slice of, such as
+// a CSV line. A nil Interner returns s.
+func (in *Interner) Intern(s string) string {
+	if in == nil || s == "" {
+		return s
+	}
+	shard := &in.shards[maphash.String(in.seed, s)%internShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.refs++
+	if interned, ok := shard.strings[s]; ok {
+		shard.saved += uint64(len(s))
+		return interned
+	}
+	interned := strings.Clone(s)
+	shard.strings[interned] = interned
+	shard.bytes += uint64(len(interned))
+	return interned
+}
+
+// Stats returns the Interner's counts, a consistent snapshot of each shard
+func (in *Interner) Stats() InternerStats {
+	var stats InternerStats
+	if in == nil {
+		return stats
+	}
+	for i := range in.shards {
+		shard := &in.shards[i]
+		shard.mu.Lock()
+		stats.Strings += len(shard.strings)
+		stats.References += shard.refs
+		stats.Bytes += shard.bytes
+		stats.BytesSaved += shard.saved
+		shard.mu.Unlock()
+	}
+	return stats
+}
+
+// product returns a copy of p's fields with Name and Description interned
+// The copy has no cache, so its prepared text is built from (and interned
+// with) the shared strings rather than pinning p's. A nil Interner copies the
+// fields as they are, as copyProductFields does.
+func (in *Interner) product(p *Product) Product {
+	return Product{
+		ID:          p.ID,
+		SourceID:    p.SourceID,
+		Name:        in.Intern(p.Name),
+		Description: in.Intern(p.Description),
+	}
+}
+
+// internProducts replaces the fields of private product copies with interned
+// strings; a nil Interner leaves them, and their caches, as they are
+func (in *Interner) internProducts(products []Product) {
+	if in == nil {
+		return
+	}
+	for i := range products {
+		products[i] = in.product(&products[i])
+	}
+}
+
+// WithInterner makes the engine intern the text it prepares, so products with
+// the same name or description share one copy of its prepared form; nil turns
+// it off (the default)
+// Pass the same Interner to several engines to share strings between them;
+// Stats measures the benefit. Products whose prepared text was cached before
+// keep it. Interning changes memory use, never results. Returns the engine
+// for chaining.
+func (e *LevenshteinEngine) WithInterner(in *Interner) *LevenshteinEngine {
+	e.interner = in
+	prep := *e.preparer()
+	prep.interner = in
+	e.prep = &prep
+	return e
+}
+
+// Interner returns the engine's Interner, or nil
+func (e *LevenshteinEngine) Interner() *Interner {
+	return e.interner
+}
+
+// WithInterner makes the engine intern the text it prepares and the names and
+// descriptions of the products it indexes (see LevenshteinEngine.WithInterner)
+// BuildIndex, BuildIndexPipelined, BuildIndexProgressive, AddProduct,
+// UpdateProduct and LoadIndex store interned copies, so the index holds each
+// distinct text once however many products repeat it. Takes effect from the
+// next index build or update. Returns the engine for chaining.
+func (e *HybridEngine) WithInterner(in *Interner) *HybridEngine {
+	e.levenshteinEngine.WithInterner(in)
+	return e
+}
+
+// Interner returns the engine's Interner, or nil
+func (e *HybridEngine) Interner() *Interner {
+	return e.levenshteinEngine.Interner()
+}
+
+// internedProductSource interns the fields of another source's products
+type internedProductSource struct {
+	src ProductSource
+	in  *Interner
+}
+
+// NewInterningProductSource returns a ProductSource yielding src's products
+// with Name and Description interned by in, for loading a catalog with much
+// repeated text into memory
+// Engines with WithInterner already intern what BuildIndexPipelined reads.
+func NewInterningProductSource(src ProductSource, in *Interner) ProductSource {
+	return &internedProductSource{src: src, in: in}
+}
+
+// Next implements ProductSource
+func (s *internedProductSource) Next() (Product, error) {
+	p, err := s.src.Next()
+	if err != nil {
+		return p, err
+	}
+	return s.in.product(&p), nil
+}
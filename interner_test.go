@@ -0,0 +1,208 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+// repeatedTextCatalog returns a generated catalog as JSON Lines, where 30% of
+// the products repeat an earlier product's description, so decoding it gives
+// every repeat a copy of its own
+func repeatedTextCatalog(t testing.TB, n int) []byte {
+	t.Helper()
+	rng := rand.New(rand.NewSource(7))
+	catalog := GenerateTestCatalog(goldenCatalogSeed, n)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range catalog {
+		if i > 0 && rng.Intn(10) < 3 {
+			catalog[i].Description = catalog[rng.Intn(i)].Description
+		}
+		if err := enc.Encode(catalog[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// readCatalog decodes a JSON Lines catalog
+func readCatalog(t testing.TB, src ProductSource) []Product {
+	t.Helper()
+	var products []Product
+	for {
+		p, err := src.Next()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatal(err)
+			}
+			return products
+		}
+		products = append(products, p)
+	}
+}
+
+func TestInterner(t *testing.T) {
+	in := NewInterner()
+	a := in.Intern(strings.Repeat("boilerplate ", 10))
+	b := in.Intern(strings.Repeat("boilerplate ", 10))
+	if a != b || unsafe.StringData(a) != unsafe.StringData(b) {
+		t.Error("equal strings don't share their bytes")
+	}
+	in.Intern("other")
+	if got := in.Intern(""); got != "" {
+		t.Errorf("Intern(\"\") = %q", got)
+	}
+	want := InternerStats{Strings: 2, References: 3, Bytes: 125, BytesSaved: 120}
+	if got := in.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	// A nil Interner interns nothing
+	var none *Interner
+	if got := none.Intern("text"); got != "text" || none.Stats() != (InternerStats{}) {
+		t.Errorf("nil Interner: %q, %+v", got, none.Stats())
+	}
+
+	// Concurrent use
+	shared := NewInterner()
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				shared.Intern(strings.Repeat("x", i%50+1))
+			}
+		}()
+	}
+	wg.Wait()
+	if got := shared.Stats(); got.Strings != 50 || got.References != 8000 {
+		t.Errorf("concurrent Stats() = %+v", got)
+	}
+}
+
+func TestInternedResultsIdentical(t *testing.T) {
+	data := repeatedTextCatalog(t, 150)
+	plain := readCatalog(t, NewJSONLProductSource(bytes.NewReader(data)))
+	in := NewInterner()
+	interned := readCatalog(t, NewInterningProductSource(NewJSONLProductSource(bytes.NewReader(data)), in))
+	if !reflect.DeepEqual(productFields(plain), productFields(interned)) {
+		t.Fatal("interning source changed the products")
+	}
+	if stats := in.Stats(); stats.BytesSaved == 0 || stats.References <= uint64(stats.Strings) {
+		t.Errorf("no repeats interned: %+v", stats)
+	}
+
+	t.Run("Levenshtein", func(t *testing.T) {
+		want := CanonicalizeResults(NewLevenshteinEngine().FindDuplicates(plain, 0.8))
+		got := CanonicalizeResults(NewLevenshteinEngine().WithInterner(in).FindDuplicates(interned, 0.8))
+		if len(want) == 0 || !reflect.DeepEqual(got, want) {
+			t.Errorf("interned: %d results, plain %d", len(got), len(want))
+		}
+	})
+
+	t.Run("Hybrid", func(t *testing.T) {
+		reference := NewHybridEngine()
+		if err := reference.BuildIndex(plain); err != nil {
+			t.Fatal(err)
+		}
+		want := CanonicalizeResults(reference.FindDuplicates(plain, 0.8))
+
+		shared := NewInterner()
+		engine := NewHybridEngine().WithInterner(shared)
+		if engine.Interner() != shared {
+			t.Fatal("Interner() doesn't return the engine's Interner")
+		}
+		err := engine.BuildIndexPipelined(context.Background(), NewJSONLProductSource(bytes.NewReader(data)), PipelineOptions{Workers: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := CanonicalizeResults(engine.FindDuplicates(plain, 0.8)); len(want) == 0 || !reflect.DeepEqual(got, want) {
+			t.Errorf("interned index: %d results, plain %d", len(got), len(want))
+		}
+
+		// Indexed repeats share their description
+		idx := engine.currentIndex()
+		byText := make(map[string]*byte)
+		for _, p := range idx.products {
+			if data, seen := byText[p.Description]; seen && data != unsafe.StringData(p.Description) {
+				t.Fatalf("%s holds its own copy of a repeated description", p.ID)
+			}
+			byText[p.Description] = unsafe.StringData(p.Description)
+		}
+		if shared.Stats().BytesSaved == 0 {
+			t.Error("index build interned no repeats")
+		}
+	})
+}
+
+// productFields returns the fields of products, without their caches
+func productFields(products []Product) [][4]string {
+	fields := make([][4]string, len(products))
+	for i, p := range products {
+		fields[i] = [4]string{p.ID, p.SourceID, p.Name, p.Description}
+	}
+	return fields
+}
+
+// indexHeap returns the heap a hybrid index of the JSON Lines catalog data
+// holds, with interning or without
+func indexHeap(t testing.TB, data []byte, intern bool) int64 {
+	t.Helper()
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	engine := NewHybridEngine()
+	if intern {
+		engine.WithInterner(NewInterner())
+	}
+	if err := engine.BuildIndexPipelined(context.Background(), NewJSONLProductSource(bytes.NewReader(data)), PipelineOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	runtime.GC()
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(engine)
+	return int64(after.HeapAlloc) - int64(before.HeapAlloc)
+}
+
+func TestInternerReducesIndexHeap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds two 1000-product indexes")
+	}
+	data := repeatedTextCatalog(t, 1000)
+	plain, interned := indexHeap(t, data, false), indexHeap(t, data, true)
+	if interned >= plain {
+		t.Errorf("interned index holds %d bytes, plain %d", interned, plain)
+	}
+	t.Logf("heap: plain %d KiB, interned %d KiB", plain>>10, interned>>10)
+}
+
+func BenchmarkInternerMemory(b *testing.B) {
+	data := repeatedTextCatalog(b, 20000)
+	for _, intern := range []bool{false, true} {
+		name := "plain"
+		if intern {
+			name = "interned"
+		}
+		b.Run(name, func(b *testing.B) {
+			var heap int64
+			for i := 0; i < b.N; i++ {
+				heap = indexHeap(b, data, intern)
+			}
+			b.ReportMetric(float64(heap)/(1<<20), "heap-MB")
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// DefaultLabelingBatchSize is the default LabelingOptions.BatchSize
+const DefaultLabelingBatchSize = 100
+
+// LabelingReason says why SelectPairsForLabeling picked a pair
+type LabelingReason int
+
+const (
+	// BoundaryRegion pairs score near the decision threshold under engineA
+	BoundaryRegion LabelingReason = iota
+	// EngineDisagreement pairs score further apart than the disagreement
+	// margin under the two engines
+	EngineDisagreement
+	// StratumFill pairs fill the quota of a similarity stratum the other
+	// picks left under-sampled
+	StratumFill
+)
+
+// String returns the reason's name, as written by WriteLabelingCandidates
+func (r LabelingReason) String() string {
+	switch r {
+	case BoundaryRegion:
+		return "boundary"
+	case EngineDisagreement:
+		return "disagreement"
+	case StratumFill:
+		return "stratum"
+	default:
+		return "unknown"
+	}
+}
+
+// LabelingCandidate is a pair SelectPairsForLabeling proposes for labeling,
+// oriented so A.ID < B.ID
+type LabelingCandidate struct {
+	A, B   Product
+	ScoreA float64 // CombinedSimilarity under engineA
+	ScoreB float64 // CombinedSimilarity under engineB
+	Reason LabelingReason
+}
+
+// Label returns the pair with its ground-truth label, for FitCalibration
+func (c LabelingCandidate) Label(duplicate bool) LabeledPair {
+	return LabeledPair{A: c.A, B: c.B, Duplicate: duplicate}
+}
+
+// LabelingOptions controls SelectPairsForLabeling
+type LabelingOptions struct {
+	// BatchSize is the number of pairs to select (default DefaultLabelingBatchSize)
+	BatchSize int
+	// BoundaryLow and BoundaryHigh bound the engineA scores of boundary
+	// pairs (default 0.78–0.90, used when BoundaryHigh <= BoundaryLow)
+	BoundaryLow, BoundaryHigh float64
+	// DisagreementMargin is the score difference above which the engines
+	// disagree (default 0.1)
+	DisagreementMargin float64
+	// Floor is the lowest engineA score considered (default 0.5, at most
+	// BoundaryLow): engineA's FindDuplicates runs at it, so pairs below it are
+	// never seen
+	Floor float64
+	// Strata is the number of equal-width engineA score ranges between Floor
+	// and 1 (default 5), each of which gets StratumQuota pairs where it has them
+	Strata int
+	// StratumQuota is the fewest pairs selected per stratum (default 5;
+	// negative for no quota)
+	StratumQuota int
+	// ShortlistSize caps the pairs engineB scores in search of disagreements
+	// (default 5 × BatchSize)
+	ShortlistSize int
+	// Seed drives every random choice; equal seeds and inputs select equal pairs
+	Seed int64
+}
+
+// DefaultLabelingOptions returns the default labeling options
+func DefaultLabelingOptions() LabelingOptions {
+	return LabelingOptions{
+		BatchSize:          DefaultLabelingBatchSize,
+		BoundaryLow:        0.78,
+		BoundaryHigh:       0.90,
+		DisagreementMargin: 0.1,
+		Floor:              0.5,
+		Strata:             5,
+		StratumQuota:       5,
+		ShortlistSize:      5 * DefaultLabelingBatchSize,
+	}
+}
+
+// withDefaults fills the zero and invalid fields of opts from the defaults
+func (opts LabelingOptions) withDefaults() LabelingOptions {
+	defaults := DefaultLabelingOptions()
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaults.BatchSize
+	}
+	if opts.BoundaryHigh <= opts.BoundaryLow {
+		opts.BoundaryLow, opts.BoundaryHigh = defaults.BoundaryLow, defaults.BoundaryHigh
+	}
+	if opts.DisagreementMargin <= 0 {
+		opts.DisagreementMargin = defaults.DisagreementMargin
+	}
+	if opts.Floor <= 0 || opts.Floor >= 1 {
+		opts.Floor = defaults.Floor
+	}
+	opts.Floor = math.Min(opts.Floor, opts.BoundaryLow)
+	if opts.Strata <= 0 {
+		opts.Strata = defaults.Strata
+	}
+	if opts.StratumQuota < 0 {
+		opts.StratumQuota = 0
+	} else if opts.StratumQuota == 0 {
+		opts.StratumQuota = defaults.StratumQuota
+	}
+	if opts.ShortlistSize <= 0 {
+		opts.ShortlistSize = 5 * opts.BatchSize
+	}
+	return opts
+}
+
+// SelectPairsForLabeling picks the pairs most worth labeling next: pairs near
+// the decision boundary, pairs two engines score differently, and pairs from
+// similarity ranges the others leave under-sampled
+// engineA scans products broadly (a HybridEngine scales to large catalogs);
+// engineB only scores a shortlist of engineA's pairs, the boundary band's
+// closest to its middle first, then a seeded sample, plus the selected pairs.
+// Selection fills the batch with disagreements (largest first) and boundary
+// pairs (closest to the band's middle first) while leaving room for every
+// stratum's quota, then fills the quotas from a seeded sample. When the
+// quotas exceed BatchSize, informative pairs are only taken into strata short
+// of their quota, and the rest are filled from the lowest stratum up.
+// Label the result with LabelingCandidate.Label to feed FitCalibration.
+func SelectPairsForLabeling(products []Product, engineA, engineB DuplicateCheckEngine, opts LabelingOptions) []LabelingCandidate {
+	opts = opts.withDefaults()
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	// engineA's pairs, oriented and in a deterministic order
+	results := engineA.FindDuplicates(products, opts.Floor)
+	pool := make([]LabelingCandidate, len(results))
+	for i, r := range results {
+		a, b := r.ProductA, r.ProductB
+		if b.ID < a.ID {
+			a, b = b, a
+		}
+		pool[i] = LabelingCandidate{A: a, B: b, ScoreA: r.CombinedSimilarity}
+	}
+	sort.Slice(pool, func(i, j int) bool {
+		return makePairKey(pool[i].A.ID, pool[i].B.ID) < makePairKey(pool[j].A.ID, pool[j].B.ID)
+	})
+	rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+
+	// Boundary pairs, closest to the band's middle first
+	middle := (opts.BoundaryLow + opts.BoundaryHigh) / 2
+	var boundary []int
+	for i := range pool {
+		if pool[i].ScoreA >= opts.BoundaryLow && pool[i].ScoreA <= opts.BoundaryHigh {
+			boundary = append(boundary, i)
+		}
+	}
+	sort.SliceStable(boundary, func(i, j int) bool {
+		return math.Abs(pool[boundary[i]].ScoreA-middle) < math.Abs(pool[boundary[j]].ScoreA-middle)
+	})
+
+	// engineB scores the shortlist: boundary pairs, then the shuffled rest
+	scored := make([]bool, len(pool))
+	scoreB := func(i int) {
+		if !scored[i] {
+			pool[i].ScoreB = engineB.Compare(pool[i].A, pool[i].B).CombinedSimilarity
+			scored[i] = true
+		}
+	}
+	shortlist := append([]int(nil), boundary...)
+	for i := range pool {
+		if pool[i].ScoreA < opts.BoundaryLow || pool[i].ScoreA > opts.BoundaryHigh {
+			shortlist = append(shortlist, i)
+		}
+	}
+	if len(shortlist) > opts.ShortlistSize {
+		shortlist = shortlist[:opts.ShortlistSize]
+	}
+	var disagreements []int
+	for _, i := range shortlist {
+		scoreB(i)
+		if math.Abs(pool[i].ScoreA-pool[i].ScoreB) > opts.DisagreementMargin {
+			disagreements = append(disagreements, i)
+		}
+	}
+	sort.SliceStable(disagreements, func(i, j int) bool {
+		return math.Abs(pool[disagreements[i]].ScoreA-pool[disagreements[i]].ScoreB) >
+			math.Abs(pool[disagreements[j]].ScoreA-pool[disagreements[j]].ScoreB)
+	})
+
+	// Stratum quotas, capped by the pairs each stratum has
+	stratum := func(score float64) int {
+		s := int((score - opts.Floor) / (1 - opts.Floor) * float64(opts.Strata))
+		if s >= opts.Strata {
+			s = opts.Strata - 1
+		}
+		return s
+	}
+	quota := make([]int, opts.Strata)
+	for i := range pool {
+		if s := stratum(pool[i].ScoreA); quota[s] < opts.StratumQuota {
+			quota[s]++
+		}
+	}
+	reserved := 0
+	for _, q := range quota {
+		reserved += q
+	}
+
+	var selected []LabelingCandidate
+	taken := make([]bool, len(pool))
+	counts := make([]int, opts.Strata)
+	take := func(i int, reason LabelingReason) {
+		scoreB(i)
+		pool[i].Reason = reason
+		selected = append(selected, pool[i])
+		taken[i] = true
+		s := stratum(pool[i].ScoreA)
+		if counts[s] < quota[s] {
+			reserved--
+		}
+		counts[s]++
+	}
+
+	// Informative pairs, as long as the quotas still fit
+	for _, group := range []struct {
+		picks  []int
+		reason LabelingReason
+	}{{disagreements, EngineDisagreement}, {boundary, BoundaryRegion}} {
+		for _, i := range group.picks {
+			s := stratum(pool[i].ScoreA)
+			fills := counts[s] < quota[s]
+			if taken[i] || len(selected) >= opts.BatchSize || (!fills && len(selected)+reserved >= opts.BatchSize) {
+				continue
+			}
+			take(i, group.reason)
+		}
+	}
+
+	// Strata the informative pairs left under-sampled, from the seeded order
+	for s := 0; s < opts.Strata; s++ {
+		for i := 0; i < len(pool) && counts[s] < quota[s] && len(selected) < opts.BatchSize; i++ {
+			if !taken[i] && stratum(pool[i].ScoreA) == s {
+				take(i, StratumFill)
+			}
+		}
+	}
+	return selected
+}
+
+// labelingRecord is the serialized form of a LabelingCandidate
+type labelingRecord struct {
+	ProductAID string  `json:"product_a"`
+	ProductBID string  `json:"product_b"`
+	NameA      string  `json:"name_a"`
+	NameB      string  `json:"name_b"`
+	ScoreA     float64 `json:"score_a"`
+	ScoreB     float64 `json:"score_b"`
+	Reason     string  `json:"reason"`
+}
+
+// WriteLabelingCandidates writes candidates for a labeling tool, as JSONL
+// records or CSV rows under a header (the formats of WriteResultRefs)
+// Each record holds both product IDs and names, both scores and the reason.
+func WriteLabelingCandidates(w io.Writer, candidates []LabelingCandidate, format ResultFileFormat) error {
+	buf := bufio.NewWriter(w)
+	switch format {
+	case ResultFileJSONL:
+		enc := json.NewEncoder(buf)
+		for _, c := range candidates {
+			if err := enc.Encode(labelingRecord{c.A.ID, c.B.ID, c.A.Name, c.B.Name, c.ScoreA, c.ScoreB, c.Reason.String()}); err != nil {
+				return err
+			}
+		}
+	case ResultFileCSV:
+		cw := csv.NewWriter(buf)
+		if err := cw.Write([]string{"product_a", "product_b", "name_a", "name_b", "score_a", "score_b", "reason"}); err != nil {
+			return err
+		}
+		for _, c := range candidates {
+			if err := cw.Write([]string{
+				c.A.ID,
+				c.B.ID,
+				c.A.Name,
+				c.B.Name,
+				strconv.FormatFloat(c.ScoreA, 'f', -1, 64),
+				strconv.FormatFloat(c.ScoreB, 'f', -1, 64),
+				c.Reason.String(),
+			}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("duplicatecheck: unknown result file format %d", format)
+	}
+	return buf.Flush()
+}
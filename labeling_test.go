@@ -0,0 +1,152 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectPairsForLabeling(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 100)
+	engineA := NewHybridEngine()
+	engineB := NewLevenshteinEngineWithWeights(ComparisonWeights{NameWeight: 0.3, DescriptionWeight: 0.7})
+	opts := LabelingOptions{BatchSize: 60, DisagreementMargin: 0.2, StratumQuota: 4, Seed: 42}
+	defaults := opts.withDefaults()
+
+	// Pairs available per stratum, from the pairs engineA sees
+	available := make([]int, defaults.Strata)
+	stratumOf := func(score float64) int {
+		s := int((score - defaults.Floor) / (1 - defaults.Floor) * float64(defaults.Strata))
+		return int(math.Min(float64(s), float64(defaults.Strata-1)))
+	}
+	for _, r := range engineA.FindDuplicates(catalog, defaults.Floor) {
+		available[stratumOf(r.CombinedSimilarity)]++
+	}
+
+	selected := SelectPairsForLabeling(catalog, engineA, engineB, opts)
+	if len(selected) != opts.BatchSize {
+		t.Fatalf("selected %d pairs, want %d", len(selected), opts.BatchSize)
+	}
+
+	counts := make([]int, defaults.Strata)
+	reasons := make(map[LabelingReason]int)
+	seen := make(map[string]bool)
+	for _, c := range selected {
+		key := makePairKey(c.A.ID, c.B.ID)
+		if seen[key] || c.A.ID >= c.B.ID {
+			t.Fatalf("pair %s repeated or not oriented", key)
+		}
+		seen[key] = true
+		counts[stratumOf(c.ScoreA)]++
+		reasons[c.Reason]++
+
+		if want := engineB.Compare(c.A, c.B).CombinedSimilarity; c.ScoreB != want {
+			t.Errorf("%s: ScoreB %v, engineB scores %v", key, c.ScoreB, want)
+		}
+		switch c.Reason {
+		case EngineDisagreement:
+			if math.Abs(c.ScoreA-c.ScoreB) <= defaults.DisagreementMargin {
+				t.Errorf("%s: disagreement pair scores %v and %v, within the margin", key, c.ScoreA, c.ScoreB)
+			}
+		case BoundaryRegion:
+			if c.ScoreA < defaults.BoundaryLow || c.ScoreA > defaults.BoundaryHigh {
+				t.Errorf("%s: boundary pair scores %v, outside the band", key, c.ScoreA)
+			}
+		}
+	}
+	for s, count := range counts {
+		if want := int(math.Min(float64(opts.StratumQuota), float64(available[s]))); count < want {
+			t.Errorf("stratum %d: %d pairs, quota %d (%d available)", s, count, want, available[s])
+		}
+	}
+	if reasons[EngineDisagreement] == 0 || reasons[StratumFill] == 0 {
+		t.Errorf("reasons = %v, want disagreement and stratum pairs", reasons)
+	}
+	t.Logf("reasons: %v, per stratum %v of %v available", reasons, counts, available)
+
+	// Deterministic for a seed
+	if again := SelectPairsForLabeling(catalog, engineA, engineB, opts); !reflect.DeepEqual(again, selected) {
+		t.Error("equal seeds selected different pairs")
+	}
+
+	// Labels feed FitCalibration
+	labeled := make([]LabeledPair, len(selected))
+	for i, c := range selected {
+		labeled[i] = c.Label(c.ScoreB >= 0.8)
+	}
+	if _, err := FitCalibration(engineA, labeled); err != nil {
+		t.Errorf("FitCalibration on the labeled batch: %v", err)
+	}
+}
+
+func TestSelectPairsForLabelingAgreeingEngines(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 120)
+	tests := []struct {
+		name string
+		opts LabelingOptions
+		// Whether any stratum may exceed its quota: only once the quotas
+		// leave room in the batch for more boundary pairs
+		overQuota bool
+	}{
+		{"quotas over the batch", LabelingOptions{BatchSize: 6, Strata: 4, StratumQuota: 3}, false},
+		{"boundary after quotas", LabelingOptions{BatchSize: 30, Strata: 4, StratumQuota: 2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected := SelectPairsForLabeling(catalog, NewLevenshteinEngine(), NewLevenshteinEngine(), tt.opts)
+			if len(selected) != tt.opts.BatchSize {
+				t.Fatalf("selected %d pairs, want %d", len(selected), tt.opts.BatchSize)
+			}
+			perStratum := make(map[int]int)
+			for _, c := range selected {
+				// Identical engines never disagree
+				if c.Reason == EngineDisagreement || c.ScoreA != c.ScoreB {
+					t.Errorf("%s/%s: reason %v with scores %v and %v", c.A.ID, c.B.ID, c.Reason, c.ScoreA, c.ScoreB)
+				}
+				perStratum[int(math.Min((c.ScoreA-0.5)/0.125, 3))]++
+			}
+			over := false
+			for _, count := range perStratum {
+				over = over || count > tt.opts.StratumQuota
+			}
+			if over != tt.overQuota {
+				t.Errorf("pairs per stratum %v, quota %d", perStratum, tt.opts.StratumQuota)
+			}
+		})
+	}
+}
+
+func TestWriteLabelingCandidates(t *testing.T) {
+	candidates := []LabelingCandidate{
+		{A: Product{ID: "a", Name: "Desk, oak"}, B: Product{ID: "b", Name: "Oak desk"}, ScoreA: 0.82, ScoreB: 0.6, Reason: EngineDisagreement},
+		{A: Product{ID: "c", Name: "Lamp"}, B: Product{ID: "d", Name: "Lamp."}, ScoreA: 0.95, ScoreB: 0.95, Reason: StratumFill},
+	}
+
+	var jsonl bytes.Buffer
+	if err := WriteLabelingCandidates(&jsonl, candidates, ResultFileJSONL); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"product_a":"a","product_b":"b","name_a":"Desk, oak","name_b":"Oak desk","score_a":0.82,"score_b":0.6,"reason":"disagreement"}`
+	if first := strings.SplitN(jsonl.String(), "\n", 2)[0]; first != want {
+		t.Errorf("JSONL record = %s, want %s", first, want)
+	}
+
+	var out bytes.Buffer
+	if err := WriteLabelingCandidates(&out, candidates, ResultFileCSV); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][6] != "reason" || rows[1][2] != "Desk, oak" || rows[2][6] != "stratum" {
+		t.Errorf("CSV rows = %q", rows)
+	}
+
+	if err := WriteLabelingCandidates(&out, candidates, ResultFileFormat(9)); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
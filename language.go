@@ -0,0 +1,263 @@
+package duplicatecheck
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// languageSamples are the texts the built-in language profiles are trained
+// on: product-listing vocabulary plus each language's common function words
+// Keys are ISO 639-1 codes, the values DetectLanguage returns.
+var languageSamples = map[string]string{
+	"en": `The wireless headphones with noise cancelling deliver clear sound and a long battery life.
+		This stainless steel water bottle keeps your drinks cold for the whole day and is easy to clean.
+		Made from soft cotton, the shirt is comfortable and fits well; machine washable.
+		Includes a charging cable, a user manual and a travel case. Available in black, white and blue.
+		The lightweight laptop has a fast processor, a bright display and plenty of storage for your files.
+		Ideal for the kitchen: this nonstick frying pan heats evenly and is safe for the dishwasher.
+		With adjustable height and a sturdy frame, the desk lamp is perfect for reading and working at home.
+		Our running shoes offer cushioning and support, with a breathable upper and durable rubber sole.
+		Please note that the color may vary slightly. It is the best choice for everyday use and gifts.
+		This product comes with a warranty of two years, which covers any defects in the materials.`,
+	"es": `Los auriculares inalámbricos con cancelación de ruido ofrecen un sonido claro y una batería de larga duración.
+		Esta botella de agua de acero inoxidable mantiene tus bebidas frías durante todo el día y es fácil de limpiar.
+		Fabricada en algodón suave, la camisa es cómoda y se ajusta bien; se puede lavar a máquina.
+		Incluye un cable de carga, un manual de usuario y un estuche de viaje. Disponible en negro, blanco y azul.
+		El portátil ligero tiene un procesador rápido, una pantalla brillante y mucho almacenamiento para tus archivos.
+		Ideal para la cocina: esta sartén antiadherente se calienta de manera uniforme y es apta para el lavavajillas.
+		Con altura ajustable y una estructura resistente, la lámpara de escritorio es perfecta para leer y trabajar en casa.
+		Nuestras zapatillas para correr ofrecen amortiguación y soporte, con una parte superior transpirable y suela de goma.
+		Tenga en cuenta que el color puede variar ligeramente. Es la mejor opción para el uso diario y para regalos.
+		Este producto tiene una garantía de dos años, que cubre cualquier defecto de los materiales.`,
+	"fr": `Les écouteurs sans fil avec réduction de bruit offrent un son clair et une batterie longue durée.
+		Cette bouteille d'eau en acier inoxydable garde vos boissons fraîches toute la journée et se nettoie facilement.
+		Fabriquée en coton doux, la chemise est confortable et bien ajustée ; lavable en machine.
+		Comprend un câble de charge, un manuel d'utilisation et un étui de voyage. Disponible en noir, blanc et bleu.
+		L'ordinateur portable léger possède un processeur rapide, un écran lumineux et beaucoup de stockage pour vos fichiers.
+		Idéale pour la cuisine : cette poêle antiadhésive chauffe de manière uniforme et passe au lave-vaisselle.
+		Avec une hauteur réglable et une structure solide, la lampe de bureau est parfaite pour lire et travailler à la maison.
+		Nos chaussures de course offrent amorti et maintien, avec une tige respirante et une semelle en caoutchouc.
+		Veuillez noter que la couleur peut légèrement varier. C'est le meilleur choix pour un usage quotidien et les cadeaux.
+		Ce produit est livré avec une garantie de deux ans, qui couvre tous les défauts des matériaux.`,
+	"de": `Die kabellosen Kopfhörer mit Geräuschunterdrückung liefern klaren Klang und eine lange Akkulaufzeit.
+		Diese Trinkflasche aus Edelstahl hält Ihre Getränke den ganzen Tag kalt und ist leicht zu reinigen.
+		Aus weicher Baumwolle gefertigt, ist das Hemd bequem und sitzt gut; waschbar in der Maschine.
+		Enthält ein Ladekabel, eine Bedienungsanleitung und eine Reisetasche. Erhältlich in Schwarz, Weiß und Blau.
+		Der leichte Laptop hat einen schnellen Prozessor, einen hellen Bildschirm und viel Speicher für Ihre Dateien.
+		Ideal für die Küche: Diese antihaftbeschichtete Pfanne erhitzt sich gleichmäßig und ist spülmaschinenfest.
+		Mit verstellbarer Höhe und einem stabilen Rahmen ist die Schreibtischlampe perfekt zum Lesen und Arbeiten zu Hause.
+		Unsere Laufschuhe bieten Dämpfung und Halt, mit einem atmungsaktiven Obermaterial und einer Sohle aus Gummi.
+		Bitte beachten Sie, dass die Farbe leicht abweichen kann. Die beste Wahl für den täglichen Gebrauch und als Geschenk.
+		Dieses Produkt wird mit einer Garantie von zwei Jahren geliefert, die alle Materialfehler abdeckt.`,
+	"it": `Le cuffie senza fili con cancellazione del rumore offrono un suono chiaro e una batteria di lunga durata.
+		Questa borraccia in acciaio inossidabile mantiene le tue bevande fredde per tutto il giorno ed è facile da pulire.
+		Realizzata in morbido cotone, la camicia è comoda e veste bene; lavabile in lavatrice.
+		Include un cavo di ricarica, un manuale utente e una custodia da viaggio. Disponibile in nero, bianco e blu.
+		Il portatile leggero ha un processore veloce, uno schermo luminoso e molto spazio per i tuoi file.
+		Ideale per la cucina: questa padella antiaderente si scalda in modo uniforme ed è lavabile in lavastoviglie.
+		Con altezza regolabile e una struttura robusta, la lampada da scrivania è perfetta per leggere e lavorare a casa.
+		Le nostre scarpe da corsa offrono ammortizzazione e sostegno, con una tomaia traspirante e una suola in gomma.
+		Si prega di notare che il colore potrebbe variare leggermente. È la scelta migliore per l'uso quotidiano e per i regali.
+		Questo prodotto viene fornito con una garanzia di due anni, che copre tutti i difetti dei materiali.`,
+	"pt": `Os fones de ouvido sem fio com cancelamento de ruído oferecem um som claro e uma bateria de longa duração.
+		Esta garrafa de água de aço inoxidável mantém as suas bebidas geladas durante todo o dia e é fácil de limpar.
+		Feita de algodão macio, a camisa é confortável e veste bem; pode ser lavada na máquina.
+		Inclui um cabo de carregamento, um manual do usuário e um estojo de viagem. Disponível em preto, branco e azul.
+		O notebook leve tem um processador rápido, uma tela brilhante e muito armazenamento para os seus arquivos.
+		Ideal para a cozinha: esta frigideira antiaderente aquece de maneira uniforme e pode ir à máquina de lavar louça.
+		Com altura ajustável e uma estrutura resistente, a luminária de mesa é perfeita para ler e trabalhar em casa.
+		Os nossos tênis de corrida oferecem amortecimento e apoio, com um cabedal respirável e sola de borracha.
+		Observe que a cor pode variar ligeiramente. É a melhor escolha para o uso diário e para presentes.
+		Este produto vem com uma garantia de dois anos, que cobre quaisquer defeitos dos materiais.`,
+}
+
+// languageProfile holds one language's trigram log-probabilities
+type languageProfile struct {
+	lang     string
+	logProbs map[string]float64
+	unseen   float64 // Log-probability of a trigram the samples lack
+}
+
+// languageProfiles are the built-in profiles, trained on first use
+var languageProfiles = sync.OnceValue(func() []languageProfile {
+	langs := make([]string, 0, len(languageSamples))
+	for lang := range languageSamples {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs) // Deterministic tie-breaking
+
+	profiles := make([]languageProfile, 0, len(langs))
+	for _, lang := range langs {
+		counts := make(map[string]int)
+		total := 0
+		forEachTrigram(languageSamples[lang], func(trigram string) {
+			counts[trigram]++
+			total++
+		})
+		// Add-one smoothing over the seen trigrams plus one slot for the rest
+		denominator := float64(total + len(counts) + 1)
+		profile := languageProfile{
+			lang:     lang,
+			logProbs: make(map[string]float64, len(counts)),
+			unseen:   math.Log(1 / denominator),
+		}
+		for trigram, count := range counts {
+			profile.logProbs[trigram] = math.Log(float64(count+1) / denominator)
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles
+})
+
+// forEachTrigram calls fn with the letter trigrams of text: lowercased words,
+// each padded with a space on both sides so word starts and ends count
+// Anything but a letter separates words.
+func forEachTrigram(text string, fn func(trigram string)) {
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			fn(string(runes[i : i+3]))
+		}
+	}
+}
+
+// DetectLanguage returns the language of text as an ISO 639-1 code (en, es,
+// fr, de, it or pt) and the confidence of that guess in [0, 1]
+// Each built-in profile scores the text's letter trigrams; confidence is the
+// best profile's share of the likelihood, so a text that could be several
+// languages, like a lone brand name, gets a low one. Text without letters
+// returns "" and 0. Short texts work: a product description's sentence or
+// two is usually enough.
+func DetectLanguage(text string) (lang string, confidence float64) {
+	profiles := languageProfiles()
+	scores := make([]float64, len(profiles))
+	trigrams := 0
+	forEachTrigram(text, func(trigram string) {
+		trigrams++
+		for i, profile := range profiles {
+			if logProb, ok := profile.logProbs[trigram]; ok {
+				scores[i] += logProb
+			} else {
+				scores[i] += profile.unseen
+			}
+		}
+	})
+	if trigrams == 0 {
+		return "", 0
+	}
+
+	best := 0
+	for i := range scores {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	// Softmax over log-likelihoods, shifted by the best for stability
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(score - scores[best])
+	}
+	return profiles[best].lang, 1 / sum
+}
+
+// detectedLanguage is a product description's DetectLanguage result
+type detectedLanguage struct {
+	lang       string
+	confidence float64
+}
+
+// descriptionLanguage returns the language of the product's description,
+// detected once and cached alongside the normalized strings
+// Detection reads the source description: text preparation may strip the
+// accents and stop words it relies on.
+func (c *productCache) descriptionLanguage() detectedLanguage {
+	if detected := c.language.Load(); detected != nil {
+		return *detected
+	}
+	// Deterministic, so concurrent callers may race but store the same value
+	lang, confidence := DetectLanguage(c.desc)
+	detected := &detectedLanguage{lang: lang, confidence: confidence}
+	c.language.Store(detected)
+	return *detected
+}
+
+// CrossLanguagePolicy selects how a pair whose descriptions are in different
+// languages is compared (see WithCrossLanguagePolicy)
+type CrossLanguagePolicy int
+
+const (
+	// CrossLanguagePenalize compares the descriptions as usual, so a
+	// translation scores low (default; languages aren't detected)
+	CrossLanguagePenalize CrossLanguagePolicy = iota
+	// CrossLanguageNameOnly scores the pair on its names alone: the weights
+	// are renormalized onto the name and the descriptions aren't compared
+	CrossLanguageNameOnly
+	// CrossLanguageFlag compares as usual and sets ComparisonResult.CrossLanguage
+	CrossLanguageFlag
+)
+
+// String returns the policy name
+func (p CrossLanguagePolicy) String() string {
+	switch p {
+	case CrossLanguagePenalize:
+		return "penalize"
+	case CrossLanguageNameOnly:
+		return "name-only"
+	case CrossLanguageFlag:
+		return "flag"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultCrossLanguageConfidence is the detection confidence both
+// descriptions need before their languages are trusted to differ
+const DefaultCrossLanguageConfidence = 0.9
+
+// WithCrossLanguagePolicy sets how pairs whose descriptions are in different
+// languages are compared (see CrossLanguagePolicy)
+// Descriptions differ in language when DetectLanguage gives them different
+// languages, each with at least minConfidence (<= 0 = DefaultCrossLanguageConfidence);
+// a pair with an empty or uncertain description is compared as usual.
+// Either policy but CrossLanguagePenalize also sets ComparisonResult.CrossLanguage.
+func (e *LevenshteinEngine) WithCrossLanguagePolicy(policy CrossLanguagePolicy, minConfidence float64) *LevenshteinEngine {
+	if minConfidence <= 0 {
+		minConfidence = DefaultCrossLanguageConfidence
+	}
+	e.crossLanguage = policy
+	e.crossLanguageConfidence = minConfidence
+	return e
+}
+
+// WithCrossLanguagePolicy sets how pairs whose descriptions are in different
+// languages are compared (see LevenshteinEngine.WithCrossLanguagePolicy)
+// Candidates still come from the index, which shingles descriptions too, so
+// a translated pair is only found if its names alone make it a candidate.
+func (e *HybridEngine) WithCrossLanguagePolicy(policy CrossLanguagePolicy, minConfidence float64) *HybridEngine {
+	e.levenshteinEngine.WithCrossLanguagePolicy(policy, minConfidence)
+	return e
+}
+
+// GetCrossLanguagePolicy returns the engine's cross-language policy
+func (e *LevenshteinEngine) GetCrossLanguagePolicy() CrossLanguagePolicy {
+	return e.crossLanguage
+}
+
+// crossLanguagePair reports whether the prepared products' descriptions are
+// confidently in different languages; always false under CrossLanguagePenalize
+func (e *LevenshteinEngine) crossLanguagePair(a, b *Product) bool {
+	if e.crossLanguage == CrossLanguagePenalize {
+		return false
+	}
+	prep := e.preparer()
+	cacheA, cacheB := a.loadCacheFor(prep), b.loadCacheFor(prep)
+	if cacheA.desc == "" || cacheB.desc == "" {
+		return false
+	}
+	langA, langB := cacheA.descriptionLanguage(), cacheB.descriptionLanguage()
+	return langA.lang != langB.lang &&
+		langA.confidence >= e.crossLanguageConfidence && langB.confidence >= e.crossLanguageConfidence
+}
@@ -0,0 +1,96 @@
+package duplicatecheck
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		lang string
+	}{
+		{"Wireless bluetooth speaker with deep bass and 12 hour battery", "en"},
+		{"Altavoz bluetooth inalámbrico con graves profundos y batería de 12 horas", "es"},
+		{"Enceinte bluetooth sans fil avec basses profondes et autonomie de 12 heures", "fr"},
+		{"Kabelloser Bluetooth-Lautsprecher mit tiefem Bass und 12 Stunden Akku", "de"},
+		{"Altoparlante bluetooth senza fili con bassi profondi e batteria da 12 ore", "it"},
+		{"Caixa de som bluetooth sem fio com graves profundos e bateria de 12 horas", "pt"},
+		{"Ergonomic office chair with lumbar support", "en"},
+		{"Silla de oficina ergonómica con soporte lumbar", "es"},
+		{"Cotton t-shirt, slim fit, machine washable", "en"},
+		{"Camiseta de algodón, corte ajustado, lavable a máquina", "es"},
+		{"Stainless steel kitchen knife set", "en"},
+		{"Juego de cuchillos de cocina de acero inoxidable", "es"},
+	}
+	for _, tt := range tests {
+		lang, confidence := DetectLanguage(tt.text)
+		if lang != tt.lang || confidence < DefaultCrossLanguageConfidence {
+			t.Errorf("DetectLanguage(%q) = %q, %.3f; want %q with confidence >= %v", tt.text, lang, confidence, tt.lang, DefaultCrossLanguageConfidence)
+		}
+	}
+
+	// Too little text to tell
+	if _, confidence := DetectLanguage("Sony"); confidence >= DefaultCrossLanguageConfidence {
+		t.Errorf("a lone brand name detected with confidence %.3f", confidence)
+	}
+	for _, text := range []string{"", "12345 - 6.5%"} {
+		if lang, confidence := DetectLanguage(text); lang != "" || confidence != 0 {
+			t.Errorf("DetectLanguage(%q) = %q, %v; want no language", text, lang, confidence)
+		}
+	}
+}
+
+func TestCrossLanguagePolicy(t *testing.T) {
+	english := Product{ID: "en-1", Name: "Acme X200 Bluetooth Speaker",
+		Description: "Wireless bluetooth speaker with deep bass, a waterproof case and a 12 hour battery"}
+	spanish := Product{ID: "es-1", Name: "Acme X200 Bluetooth Speaker.",
+		Description: "Altavoz bluetooth inalámbrico con graves profundos, carcasa resistente al agua y batería de 12 horas"}
+	// Same language, different product: never flagged
+	other := Product{ID: "en-2", Name: "Acme X200 Bluetooth Speakers",
+		Description: "Portable speaker with a fabric cover, a carry strap and a built-in microphone for calls"}
+
+	tests := []struct {
+		policy        CrossLanguagePolicy
+		minCombined   float64 // Bounds on the translated pair's score
+		maxCombined   float64
+		crossLanguage bool
+	}{
+		{CrossLanguagePenalize, 0, 0.8, false},
+		{CrossLanguageNameOnly, 0.95, 1, true},
+		{CrossLanguageFlag, 0, 0.8, true},
+	}
+	plain := NewLevenshteinEngine().Compare(english, spanish)
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			engine := NewLevenshteinEngine().WithCrossLanguagePolicy(tt.policy, 0)
+			result := engine.Compare(english, spanish)
+			if result.CombinedSimilarity < tt.minCombined || result.CombinedSimilarity > tt.maxCombined {
+				t.Errorf("combined similarity %.3f, want in [%v, %v]", result.CombinedSimilarity, tt.minCombined, tt.maxCombined)
+			}
+			if result.CrossLanguage != tt.crossLanguage {
+				t.Errorf("CrossLanguage = %v, want %v", result.CrossLanguage, tt.crossLanguage)
+			}
+			if tt.policy != CrossLanguageNameOnly && result.CombinedSimilarity != plain.CombinedSimilarity {
+				t.Errorf("combined similarity %v, want the default engine's %v", result.CombinedSimilarity, plain.CombinedSimilarity)
+			}
+			if same := engine.Compare(english, other); same.CrossLanguage {
+				t.Errorf("same-language pair flagged as cross-language")
+			}
+
+			// Scans agree with Compare, pruning included
+			found := false
+			for _, r := range engine.FindDuplicates([]Product{english, spanish, other}, 0.9) {
+				if makePairKey(r.ProductA.ID, r.ProductB.ID) == makePairKey(english.ID, spanish.ID) {
+					found = r.CrossLanguage == tt.crossLanguage
+				}
+			}
+			if found != (tt.policy == CrossLanguageNameOnly) {
+				t.Errorf("FindDuplicates found the translated pair: %v", found)
+			}
+		})
+	}
+
+	// A cutoff above any detection confidence never treats languages as different
+	strict := NewLevenshteinEngine().WithCrossLanguagePolicy(CrossLanguageNameOnly, 1.1)
+	if result := strict.Compare(english, spanish); result.CrossLanguage || result.CombinedSimilarity != plain.CombinedSimilarity {
+		t.Errorf("cutoff 1.1: CrossLanguage %v, similarity %v", result.CrossLanguage, result.CombinedSimilarity)
+	}
+}
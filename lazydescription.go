@@ -0,0 +1,187 @@
+package duplicatecheck
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// DescriptionLoader fetches the description of the product with the given ID
+// (see WithLazyDescriptionLoader)
+type DescriptionLoader func(ctx context.Context, id string) (string, error)
+
+const (
+	// DefaultLazyDescriptionMargin is how close to the threshold a pair's name
+	// similarity must be for its missing descriptions to be loaded
+	DefaultLazyDescriptionMargin = 0.1
+	// DefaultMaxConcurrentLoads bounds the loader calls in flight during a scan
+	DefaultMaxConcurrentLoads = 4
+)
+
+// LazyDescriptionOptions tunes when and how WithLazyDescriptionLoader loads descriptions
+type LazyDescriptionOptions struct {
+	// Margin is how close to the threshold, on either side, a pair's name
+	// similarity must be for the pair to be worth loading descriptions for
+	Margin float64
+	// MaxConcurrentLoads bounds the loader calls in flight during a scan
+	MaxConcurrentLoads int
+}
+
+// DefaultLazyDescriptionOptions returns the default lazy description options
+// Zero or negative values also fall back to these defaults
+func DefaultLazyDescriptionOptions() LazyDescriptionOptions {
+	return LazyDescriptionOptions{
+		Margin:             DefaultLazyDescriptionMargin,
+		MaxConcurrentLoads: DefaultMaxConcurrentLoads,
+	}
+}
+
+// WithLazyDescriptionLoader makes FindDuplicates fetch missing descriptions
+// for the pairs that need them; pass nil to remove it. Returns the engine for chaining.
+// Scans first score each pair on what its products carry. When a product of
+// the pair has no description and the name similarity is within the margin of
+// the threshold (see SetLazyDescriptionOptions), the missing descriptions are
+// loaded and the pair is scored again with them, so its result carries them.
+// Pairs whose names clearly match or clearly don't are never loaded for, nor
+// are pairs whose descriptions the comparison would skip anyway. Each product
+// is loaded at most once per scan, with at most MaxConcurrentLoads loads in
+// flight. A loader error leaves the pair scored without loaded descriptions,
+// flagged DescriptionLoadFailed. FindDuplicatesCtx passes its context to the
+// loader; Compare and the other scans never call it.
+func (e *LevenshteinEngine) WithLazyDescriptionLoader(loader DescriptionLoader) *LevenshteinEngine {
+	e.descriptionLoader = loader
+	return e
+}
+
+// SetLazyDescriptionOptions replaces the engine's lazy description options
+func (e *LevenshteinEngine) SetLazyDescriptionOptions(opts LazyDescriptionOptions) {
+	e.lazyDescription = opts
+}
+
+// GetLazyDescriptionOptions returns the engine's lazy description options, defaults filled in
+func (e *LevenshteinEngine) GetLazyDescriptionOptions() LazyDescriptionOptions {
+	return e.lazyDescriptionOptions()
+}
+
+// lazyDescriptionOptions returns the lazy description options with zero values defaulted
+func (e *LevenshteinEngine) lazyDescriptionOptions() LazyDescriptionOptions {
+	opts := e.lazyDescription
+	if opts.Margin <= 0 {
+		opts.Margin = DefaultLazyDescriptionMargin
+	}
+	if opts.MaxConcurrentLoads <= 0 {
+		opts.MaxConcurrentLoads = DefaultMaxConcurrentLoads
+	}
+	return opts
+}
+
+// descriptionLoads memoizes the descriptions loaded during one scan
+type descriptionLoads struct {
+	ctx    context.Context
+	engine *LevenshteinEngine
+	slots  chan struct{} // Bounds the loader calls in flight
+	mu     sync.Mutex
+	loads  map[string]*descriptionLoad
+}
+
+// descriptionLoad is the description of one product, loaded once per scan
+type descriptionLoad struct {
+	done        chan struct{} // Closed once description and err are set
+	description string
+	err         error
+}
+
+// newDescriptionLoads returns the description loads of a scan, or nil
+// without a loader
+func (e *LevenshteinEngine) newDescriptionLoads(ctx context.Context) *descriptionLoads {
+	if e.descriptionLoader == nil {
+		return nil
+	}
+	return &descriptionLoads{
+		ctx:    ctx,
+		engine: e,
+		slots:  make(chan struct{}, e.lazyDescriptionOptions().MaxConcurrentLoads),
+		loads:  make(map[string]*descriptionLoad),
+	}
+}
+
+// get returns the description of product id, calling the loader on first use
+// Concurrent callers for the same product wait for the first one's load.
+func (l *descriptionLoads) get(id string) (string, error) {
+	l.mu.Lock()
+	load, exists := l.loads[id]
+	if !exists {
+		load = &descriptionLoad{done: make(chan struct{})}
+		l.loads[id] = load
+	}
+	l.mu.Unlock()
+	if exists {
+		<-load.done
+		return load.description, load.err
+	}
+
+	defer close(load.done)
+	select {
+	case l.slots <- struct{}{}:
+	case <-l.ctx.Done():
+		load.err = l.ctx.Err()
+		return "", load.err
+	}
+	defer func() { <-l.slots }()
+	if load.err = l.ctx.Err(); load.err != nil {
+		return "", load.err
+	}
+
+	atomic.AddUint64(&l.engine.loaderCalls, 1)
+	load.description, load.err = l.engine.descriptionLoader(l.ctx, id)
+	if load.err != nil {
+		atomic.AddUint64(&l.engine.loaderFailures, 1)
+	}
+	return load.description, load.err
+}
+
+// fill returns p with its description loaded when it has none, and whether it
+// needed one
+func (l *descriptionLoads) fill(p *Product) (*Product, bool, error) {
+	if _, desc := p.preparedStrings(l.engine.preparer()); desc != "" {
+		return p, false, nil
+	}
+	description, err := l.get(p.ID)
+	if err != nil {
+		return p, true, err
+	}
+	loaded := *p
+	loaded.Description = description
+	return &loaded, true, nil
+}
+
+// compareScanPair is comparePair, rescoring borderline pairs with the
+// descriptions loads fetches (see WithLazyDescriptionLoader)
+func (e *LevenshteinEngine) compareScanPair(products []*Product, i, j int, memo *scanMemo, scratch *comparisonScratch, loads *descriptionLoads, threshold float64) (ComparisonResult, bool) {
+	result, ok := e.comparePair(products, i, j, memo, scratch, threshold)
+	if !ok || loads == nil {
+		return result, ok
+	}
+
+	nameSimilarity := result.NameSimilarity
+	if result.ObfuscationSuspected {
+		nameSimilarity = result.DeobfuscatedNameSimilarity
+	}
+	margin := e.lazyDescriptionOptions().Margin
+	if nameSimilarity < threshold-margin || nameSimilarity >= threshold+margin ||
+		e.skipsDescription(nameSimilarity, result.WeightsUsed, threshold) {
+		return result, true
+	}
+
+	a, neededA, errA := loads.fill(products[i])
+	b, neededB, errB := loads.fill(products[j])
+	if !neededA && !neededB {
+		return result, true
+	}
+	if errA != nil || errB != nil {
+		result.DescriptionLoadFailed = true
+		return result, true
+	}
+	// The scan memo grouped products by their descriptions before loading
+	return e.compareWithWeights(a, b, e.resolveWeights(a, b), memoPair{}, threshold), true
+}
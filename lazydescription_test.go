@@ -0,0 +1,335 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+// countingLoader is a DescriptionLoader over a fixed map that records its calls
+type countingLoader struct {
+	descriptions map[string]string
+	failing      map[string]bool
+	delay        time.Duration
+
+	mu       sync.Mutex
+	calls    map[string]int
+	inFlight int32
+	peak     int32
+}
+
+func newCountingLoader(descriptions map[string]string) *countingLoader {
+	return &countingLoader{descriptions: descriptions, calls: make(map[string]int)}
+}
+
+func (l *countingLoader) load(ctx context.Context, id string) (string, error) {
+	current := atomic.AddInt32(&l.inFlight, 1)
+	defer atomic.AddInt32(&l.inFlight, -1)
+	for {
+		peak := atomic.LoadInt32(&l.peak)
+		if current <= peak || atomic.CompareAndSwapInt32(&l.peak, peak, current) {
+			break
+		}
+	}
+
+	l.mu.Lock()
+	l.calls[id]++
+	l.mu.Unlock()
+	if l.delay > 0 {
+		time.Sleep(l.delay)
+	}
+	if l.failing[id] {
+		return "", fmt.Errorf("enrichment service: %s unavailable", id)
+	}
+	return l.descriptions[id], nil
+}
+
+// loadedIDs returns the IDs loaded, sorted
+func (l *countingLoader) loadedIDs() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ids := make([]string, 0, len(l.calls))
+	for id := range l.calls {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// nameOnly returns products without their descriptions, and the descriptions by ID
+func nameOnly(products []Product) ([]Product, map[string]string) {
+	sparse := make([]Product, len(products))
+	descriptions := make(map[string]string, len(products))
+	for i, p := range products {
+		sparse[i] = Product{ID: p.ID, Name: p.Name}
+		descriptions[p.ID] = p.Description
+	}
+	return sparse, descriptions
+}
+
+func TestLazyDescriptionLoader(t *testing.T) {
+	const threshold = 0.85
+	// Names 1/2 are near the threshold, 3/4 clearly match, 5 and 6 match nothing
+	full := []Product{
+		{ID: "1", Name: "Nike Air Max 90", Description: "Running shoe with visible air cushioning, white and red"},
+		{ID: "2", Name: "Nike Air Max 95", Description: "Running shoe with visible air cushioning, white and red"},
+		{ID: "3", Name: "Samsung Galaxy S24 Ultra 256GB", Description: "Flagship phone"},
+		{ID: "4", Name: "Samsung Galaxy S24 Ultra 256 GB", Description: "Flagship phone"},
+		{ID: "5", Name: "Ceramic Coffee Mug", Description: "Holds 350ml"},
+		{ID: "6", Name: "Garden Hose 50ft", Description: "Expandable hose"},
+	}
+	products, descriptions := nameOnly(full)
+
+	t.Run("loads only borderline pairs", func(t *testing.T) {
+		// The catalog must have the intended shape for the test to mean anything
+		margin := DefaultLazyDescriptionMargin
+		var borderline []string
+		for i := range products {
+			for j := i + 1; j < len(products); j++ {
+				similarity := NewLevenshteinEngine().Compare(products[i], products[j]).NameSimilarity
+				if similarity >= threshold-margin && similarity < threshold+margin {
+					borderline = append(borderline, PairKey(products[i].ID, products[j].ID))
+				}
+			}
+		}
+		if want := []string{PairKey("1", "2")}; !reflect.DeepEqual(borderline, want) {
+			t.Fatalf("borderline pairs = %v, want %v", borderline, want)
+		}
+
+		loader := newCountingLoader(descriptions)
+		engine := NewLevenshteinEngine().WithLazyDescriptionLoader(loader.load)
+		results := engine.FindDuplicates(products, threshold)
+
+		if got := loader.loadedIDs(); !reflect.DeepEqual(got, []string{"1", "2"}) {
+			t.Errorf("loaded %v, want [1 2]", got)
+		}
+		if len(results) != 2 {
+			t.Fatalf("got %d results, want 2: %v", len(results), comparableResults(results))
+		}
+		for _, r := range results {
+			loaded := r.ProductA.ID == "1" || r.ProductA.ID == "2"
+			if loaded != (r.ProductA.Description != "" && r.ProductB.Description != "") {
+				t.Errorf("pair %s-%s: descriptions %q, %q", r.ProductA.ID, r.ProductB.ID, r.ProductA.Description, r.ProductB.Description)
+			}
+			if r.DescriptionLoadFailed {
+				t.Errorf("pair %s-%s flagged DescriptionLoadFailed", r.ProductA.ID, r.ProductB.ID)
+			}
+		}
+		for _, p := range products {
+			if p.Description != "" {
+				t.Errorf("input product %s was modified", p.ID)
+			}
+		}
+		stats := engine.GetScanStats()
+		if stats["description_loads"] != uint64(2) || stats["description_load_failures"] != uint64(0) {
+			t.Errorf("stats = %v, want 2 loads and no failures", stats)
+		}
+	})
+
+	t.Run("clear pairs never load", func(t *testing.T) {
+		clear := []Product{products[2], products[3], products[4], products[5]}
+		loader := newCountingLoader(descriptions)
+		engine := NewLevenshteinEngine().WithLazyDescriptionLoader(loader.load)
+
+		got := engine.FindDuplicates(clear, threshold)
+		want := NewLevenshteinEngine().FindDuplicates(clear, threshold)
+		if ids := loader.loadedIDs(); len(ids) != 0 {
+			t.Errorf("loaded %v for clear pairs", ids)
+		}
+		if !reflect.DeepEqual(comparableResults(got), comparableResults(want)) {
+			t.Errorf("results %v, want %v", comparableResults(got), comparableResults(want))
+		}
+	})
+
+	t.Run("rescores with loaded descriptions", func(t *testing.T) {
+		// Same names as 1 and 2, but descriptions that tell them apart
+		different := map[string]string{
+			"1": "Running shoe with visible air cushioning, white and red",
+			"2": "Vintage leather boot, hand stitched, brown",
+		}
+		pair := []Product{products[0], products[1]}
+		withLoader := NewLevenshteinEngine().WithLazyDescriptionLoader(newCountingLoader(different).load)
+
+		if got := NewLevenshteinEngine().FindDuplicates(pair, threshold); len(got) != 1 {
+			t.Fatalf("names alone: got %d results, want 1", len(got))
+		}
+		if got := withLoader.FindDuplicates(pair, threshold); len(got) != 0 {
+			t.Errorf("with descriptions: got %v, want no match", comparableResults(got))
+		}
+
+		// Lowered threshold, margin widened to keep the names borderline: the
+		// result is Compare on the loaded products
+		withLoader.SetLazyDescriptionOptions(LazyDescriptionOptions{Margin: 0.25})
+		got := withLoader.FindDuplicates(pair, 0.7)
+		loadedA, loadedB := Product{ID: "1", Name: pair[0].Name, Description: different["1"]}, Product{ID: "2", Name: pair[1].Name, Description: different["2"]}
+		want := NewLevenshteinEngine().Compare(loadedA, loadedB)
+		want.stampThreshold(0.7)
+		if len(got) != 1 || !reflect.DeepEqual(comparableResults(got), comparableResults([]ComparisonResult{want})) {
+			t.Errorf("got %v, want %v", comparableResults(got), comparableResults([]ComparisonResult{want}))
+		}
+	})
+
+	t.Run("products with descriptions are not loaded", func(t *testing.T) {
+		loader := newCountingLoader(descriptions)
+		engine := NewLevenshteinEngine().WithLazyDescriptionLoader(loader.load)
+		engine.FindDuplicates([]Product{full[0], products[1]}, threshold)
+		if got := loader.loadedIDs(); !reflect.DeepEqual(got, []string{"2"}) {
+			t.Errorf("loaded %v, want [2]", got)
+		}
+	})
+
+	t.Run("load errors degrade to names", func(t *testing.T) {
+		loader := newCountingLoader(descriptions)
+		loader.failing = map[string]bool{"2": true}
+		engine := NewLevenshteinEngine().WithLazyDescriptionLoader(loader.load)
+
+		results := engine.FindDuplicates(products, threshold)
+		want := NewLevenshteinEngine().FindDuplicates(products, threshold)
+		for i := range want {
+			if want[i].ProductA.ID == "1" {
+				want[i].DescriptionLoadFailed = true
+				want[i].assignReasons()
+			}
+		}
+		if !reflect.DeepEqual(comparableResults(results), comparableResults(want)) {
+			t.Errorf("results %v, want %v", comparableResults(results), comparableResults(want))
+		}
+		if failures := engine.GetScanStats()["description_load_failures"]; failures != uint64(1) {
+			t.Errorf("description_load_failures = %v, want 1", failures)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		loader := newCountingLoader(descriptions)
+		engine := NewLevenshteinEngine().WithLazyDescriptionLoader(loader.load)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		results, err := engine.FindDuplicatesCtx(ctx, products, threshold)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+		if ids := loader.loadedIDs(); len(ids) != 0 {
+			t.Errorf("loaded %v after cancellation", ids)
+		}
+		flagged := 0
+		for _, r := range results {
+			if r.DescriptionLoadFailed {
+				flagged++
+			}
+		}
+		if flagged != 1 {
+			t.Errorf("%d results flagged DescriptionLoadFailed, want 1", flagged)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		engine := NewLevenshteinEngine()
+		if got := engine.GetLazyDescriptionOptions(); got != DefaultLazyDescriptionOptions() {
+			t.Errorf("default options = %+v", got)
+		}
+		engine.SetLazyDescriptionOptions(LazyDescriptionOptions{Margin: 0.2})
+		if got := engine.GetLazyDescriptionOptions(); got.Margin != 0.2 || got.MaxConcurrentLoads != DefaultMaxConcurrentLoads {
+			t.Errorf("options = %+v", got)
+		}
+
+		// A wider margin makes the clearly matching names borderline too
+		loader := newCountingLoader(descriptions)
+		engine.WithLazyDescriptionLoader(loader.load).FindDuplicates(products, threshold)
+		if got := loader.loadedIDs(); !reflect.DeepEqual(got, []string{"1", "2", "3", "4"}) {
+			t.Errorf("loaded %v, want [1 2 3 4]", got)
+		}
+	})
+}
+
+func TestLazyDescriptionMemoization(t *testing.T) {
+	// Every pair of names is borderline: they differ in one or two digits
+	var full []Product
+	for i := 10; i < 70; i++ {
+		full = append(full, Product{
+			ID:          fmt.Sprintf("shoe-%d", i),
+			Name:        fmt.Sprintf("Nike Air Max %d", i),
+			Description: "Running shoe with visible air cushioning",
+		})
+	}
+	products, descriptions := nameOnly(full)
+
+	for _, parallel := range []bool{false, true} {
+		t.Run(fmt.Sprintf("parallel=%v", parallel), func(t *testing.T) {
+			loader := newCountingLoader(descriptions)
+			loader.delay = time.Millisecond
+			engine := NewLevenshteinEngine().WithLazyDescriptionLoader(loader.load)
+			engine.SetLazyDescriptionOptions(LazyDescriptionOptions{MaxConcurrentLoads: 2})
+
+			var results []ComparisonResult
+			if parallel {
+				results = engine.findDuplicatesParallel(context.Background(), productPtrs(products), 0.85)
+			} else {
+				results = engine.findDuplicatesSequential(context.Background(), productPtrs(products), 0.85)
+			}
+
+			if len(results) == 0 {
+				t.Fatal("no results")
+			}
+			for _, r := range results {
+				if r.ProductA.Description == "" || r.ProductB.Description == "" {
+					t.Fatalf("pair %s-%s scored without descriptions", r.ProductA.ID, r.ProductB.ID)
+				}
+			}
+			loader.mu.Lock()
+			defer loader.mu.Unlock()
+			if len(loader.calls) != len(products) {
+				t.Errorf("loaded %d products, want %d", len(loader.calls), len(products))
+			}
+			for id, calls := range loader.calls {
+				if calls != 1 {
+					t.Errorf("product %s loaded %d times", id, calls)
+				}
+			}
+			if peak := atomic.LoadInt32(&loader.peak); peak > 2 {
+				t.Errorf("%d loads in flight, want at most 2", peak)
+			}
+		})
+	}
+
+	t.Run("once per scan", func(t *testing.T) {
+		loader := newCountingLoader(descriptions)
+		engine := NewLevenshteinEngine().WithLazyDescriptionLoader(loader.load)
+		engine.FindDuplicates(products[:5], 0.85)
+		engine.FindDuplicates(products[:5], 0.85)
+		if calls := loader.calls[products[0].ID]; calls != 2 {
+			t.Errorf("product loaded %d times over two scans, want 2", calls)
+		}
+	})
+}
+
+func TestLazyDescriptionCharsetPruning(t *testing.T) {
+	// Pruning must account for the descriptions loading can add
+	cfg := synth.Default()
+	cfg.Size, cfg.DuplicateRate, cfg.Seed = 120, 0.2, 5
+	full, _ := generatedCatalog(cfg)
+	products, descriptions := nameOnly(full)
+
+	pruned := NewLevenshteinEngine().WithLazyDescriptionLoader(newCountingLoader(descriptions).load)
+	unpruned := NewLevenshteinEngine().WithLazyDescriptionLoader(newCountingLoader(descriptions).load)
+	unpruned.DisableCharsetPruning()
+
+	for _, threshold := range []float64{0.7, 0.85} {
+		got := comparableResults(pruned.FindDuplicates(products, threshold))
+		want := comparableResults(unpruned.FindDuplicates(products, threshold))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("threshold %v: %d results with charset pruning, %d without", threshold, len(got), len(want))
+		}
+	}
+	if pruned.GetScanStats()["charset_pruned_pairs"] == uint64(0) {
+		t.Error("charset pruning pruned nothing")
+	}
+}
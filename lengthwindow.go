@@ -0,0 +1,209 @@
+package duplicatecheck
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// lengthWindowSlack keeps the length bound conservative against float rounding
+const lengthWindowSlack = 1e-9
+
+// lengthWindow restricts a scan to pairs whose name lengths can still reach the threshold
+//
+// A Levenshtein distance is at least the length difference of its strings, so
+// linear name similarity is at most short/long. With the description scoring a
+// perfect 1.0, the best a pair can reach is
+//
+//	combined ≤ NameWeight·short/long + DescriptionWeight
+//
+// and any pair with short/long below (threshold - DescriptionWeight)/NameWeight
+// cannot match. Pairs with an empty name or description on either side only
+// score lower, so the bound holds for them too. Products are sorted by name
+// length once; each product is then compared only with the products in its
+// admissible length range.
+type lengthWindow struct {
+	lengths []int   // Prepared name length of each product
+	order   []int   // Product indices sorted by (length, index)
+	ratio   float64 // Minimum short/long name length ratio of a possible match
+	visited uint64  // Pairs enumerated so far
+}
+
+// EnableLengthPruning turns on name-length pruning in FindDuplicates (default)
+// Pairs whose name lengths differ too much to reach the threshold, even with
+// identical descriptions, are never compared. Results are identical either way.
+func (e *LevenshteinEngine) EnableLengthPruning() {
+	e.noLengthPruning = false
+}
+
+// DisableLengthPruning makes FindDuplicates compare every pair
+func (e *LevenshteinEngine) DisableLengthPruning() {
+	e.noLengthPruning = true
+}
+
+// IsLengthPruningEnabled returns whether FindDuplicates prunes pairs by name length
+func (e *LevenshteinEngine) IsLengthPruningEnabled() bool {
+	return !e.noLengthPruning
+}
+
+// GetScanStats returns cumulative FindDuplicates pair counts
+// pairs_compared counts pairs handed to the comparison; length_pruned_pairs
+// counts pairs skipped by length pruning without being compared.
+// description_timeouts counts description comparisons, by Compare as well as
+// scans, cut short by MaxComparisonDuration. constraint_skipped_pairs counts
+// pairs any scan left uncompared because the pair constraint excluded them
+// (see WithPairConstraint); FindDuplicates counts them in pairs_compared too.
+// name_comparisons counts name distances computed, by Compare as well as scans;
+// the name memo keeps it near the number of distinct name pairs in a scan.
+// charset_pruned_pairs counts pairs any scan, Hybrid verification included,
+// skipped by charset pruning (see EnableCharsetPruning); scans count them in
+// pairs_compared too. description_loads and description_load_failures count
+// the calls scans made to the lazy description loader, and those that failed
+// (see WithLazyDescriptionLoader). description_skipped_pairs counts pairs whose
+// description comparison was skipped because their names ruled out the
+// threshold, and rabin_karp_rejected_pairs pairs the Rabin-Karp filter
+// rejected, by Compare as well as scans. suppressed_pairs counts pairs scans
+// skipped as suppressed, and expired_suppressions and voided_suppressions
+// pairs compared because their suppression had expired or a product had
+// changed (see WithSuppressions).
+func (e *LevenshteinEngine) GetScanStats() map[string]interface{} {
+	return map[string]interface{}{
+		"pairs_compared":            atomic.LoadUint64(&e.pairsCompared),
+		"name_comparisons":          atomic.LoadUint64(&e.nameComparisons),
+		"length_pruned_pairs":       atomic.LoadUint64(&e.lengthPruned),
+		"length_pruning":            e.IsLengthPruningEnabled(),
+		"charset_pruned_pairs":      atomic.LoadUint64(&e.charsetPruned),
+		"charset_pruning":           e.IsCharsetPruningEnabled(),
+		"description_timeouts":      atomic.LoadUint64(&e.descriptionTimeout),
+		"constraint_skipped_pairs":  e.constraintSkips(),
+		"description_loads":         atomic.LoadUint64(&e.loaderCalls),
+		"description_load_failures": atomic.LoadUint64(&e.loaderFailures),
+		"description_skipped_pairs": atomic.LoadUint64(&e.descriptionSkipped),
+		"rabin_karp_rejected_pairs": atomic.LoadUint64(&e.rabinKarpRejected),
+		"suppressed_pairs":          atomic.LoadUint64(&e.suppressionsApplied),
+		"expired_suppressions":      atomic.LoadUint64(&e.suppressionsExpired),
+		"voided_suppressions":       atomic.LoadUint64(&e.suppressionsVoided),
+		"swapped_fields_compared":   atomic.LoadUint64(&e.swappedFieldsCompared),
+	}
+}
+
+// newLengthWindow returns the length window for a scan at threshold, or nil
+// when no pair can be pruned safely
+// Pruning needs one weight pair for the whole scan (no WeightResolver), a
+// SimilarityMode that never scores above linear similarity, no cross-field
+// score folded into the combined score, no crossed comparison of swapped
+// fields, which pairs a name with a description, no SKU-like name matching,
+// which scores names equal up to separators 1.0, no low-information name
+// detection, which ignores some pairs' names, and no bundle splitting, which
+// scores reordered items alike.
+func (e *LevenshteinEngine) newLengthWindow(products []*Product, threshold float64) *lengthWindow {
+	if e.weightResolver != nil {
+		return nil
+	}
+	return e.newLengthWindowAt(products, lengthWindowRatio(e.weights, threshold))
+}
+
+// lengthWindowRatio returns the minimum short/long name length ratio of a
+// pair that can reach threshold under weights (0 or less: no bound)
+func lengthWindowRatio(weights ComparisonWeights, threshold float64) float64 {
+	weights = weights.Normalized()
+	if weights.NameWeight <= 0 {
+		return 0
+	}
+	return (threshold-ThresholdEpsilon-weights.DescriptionWeight)/weights.NameWeight - lengthWindowSlack
+}
+
+// newLengthWindowAt is newLengthWindow for a length ratio (see
+// lengthWindowRatio) the caller chose
+func (e *LevenshteinEngine) newLengthWindowAt(products []*Product, ratio float64) *lengthWindow {
+	if e.noLengthPruning || !e.similarityBoundedByLinear() || len(products) < 3 || ratio <= 0 ||
+		(e.crossField != nil && e.crossField.Weight > 0) || e.swappedFields != nil || e.idComparator != nil || e.skuNames != nil || e.lowInfo != nil || e.bundleSplitter != nil {
+		return nil
+	}
+
+	prep := e.preparer()
+	w := &lengthWindow{
+		lengths: make([]int, len(products)),
+		order:   make([]int, len(products)),
+		ratio:   ratio,
+	}
+	for i, p := range products {
+		name, _ := p.preparedStrings(prep)
+		w.lengths[i] = e.textLength(name)
+		w.order[i] = i
+	}
+	sort.Slice(w.order, func(a, b int) bool {
+		la, lb := w.lengths[w.order[a]], w.lengths[w.order[b]]
+		return la < lb || (la == lb && w.order[a] < w.order[b])
+	})
+	return w
+}
+
+// partners appends to dst, in ascending order, every j > i whose name length
+// is admissible for product i
+func (w *lengthWindow) partners(i int, dst []int) []int {
+	length := float64(w.lengths[i])
+	low, high := length*w.ratio, length/w.ratio
+
+	start := sort.Search(len(w.order), func(k int) bool {
+		return float64(w.lengths[w.order[k]]) >= low
+	})
+	for _, j := range w.order[start:] {
+		if float64(w.lengths[j]) > high {
+			break
+		}
+		if j > i {
+			dst = append(dst, j)
+		}
+	}
+	sort.Ints(dst)
+	return dst
+}
+
+// admits reports whether products i and j have admissible name lengths
+func (w *lengthWindow) admits(i, j int) bool {
+	short, long := float64(w.lengths[i]), float64(w.lengths[j])
+	if short > long {
+		short, long = long, short
+	}
+	return short >= long*w.ratio
+}
+
+// eachPair calls visit for every pair (i < j) of n items in row order, or for
+// the admissible pairs only when w is not nil; visit returning false stops it
+func (w *lengthWindow) eachPair(n int, visit func(i, j int) bool) {
+	if w == nil {
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				if !visit(i, j) {
+					return
+				}
+			}
+		}
+		return
+	}
+
+	var partners []int
+	for i := 0; i < n; i++ {
+		partners = w.partners(i, partners[:0])
+		for _, j := range partners {
+			w.visited++
+			if !visit(i, j) {
+				return
+			}
+		}
+	}
+}
+
+// recordScan adds a finished scan of n products to the engine's pair counters
+func (e *LevenshteinEngine) recordScan(w *lengthWindow, n int) {
+	total := uint64(n) * uint64(n-1) / 2
+	if n < 2 {
+		total = 0
+	}
+	if w == nil {
+		atomic.AddUint64(&e.pairsCompared, total)
+		return
+	}
+	atomic.AddUint64(&e.pairsCompared, w.visited)
+	atomic.AddUint64(&e.lengthPruned, total-w.visited)
+}
@@ -0,0 +1,149 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// lengthDiverseCatalog builds n products with names of 1 to 12 words; a third
+// are variants of an earlier product with a word added or dropped, so many
+// pairs sit near the length bound
+func lengthDiverseCatalog(n int) []Product {
+	rng := rand.New(rand.NewSource(17))
+	products := make([]Product, n)
+	for i := range products {
+		name := randomWordText(rng, 1+rng.Intn(12))
+		desc := randomWordText(rng, 8)
+		if i > 0 && rng.Float64() < 0.35 {
+			base := products[rng.Intn(i)]
+			name, desc = base.Name+" "+randomWordText(rng, 1), base.Description
+			if rng.Intn(2) == 0 && len(base.Name) > 8 {
+				name = base.Name[:len(base.Name)-4]
+			}
+		}
+		if rng.Intn(10) == 0 {
+			desc = ""
+		}
+		products[i] = Product{ID: fmt.Sprintf("L%05d", i), Name: name, Description: desc}
+	}
+	return products
+}
+
+// resultPairs lists result pairs in order
+func resultPairs(results []ComparisonResult) []string {
+	pairs := make([]string, len(results))
+	for i, r := range results {
+		pairs[i] = r.ProductA.ID + "|" + r.ProductB.ID
+	}
+	return pairs
+}
+
+func TestLengthPruning(t *testing.T) {
+	engines := []struct {
+		name string
+		new  func() *LevenshteinEngine
+	}{
+		{"default weights", NewLevenshteinEngine},
+		{"name only", func() *LevenshteinEngine {
+			return NewLevenshteinEngineWithWeights(ComparisonWeights{NameWeight: 1, DescriptionWeight: 0})
+		}},
+		{"description heavy", func() *LevenshteinEngine {
+			return NewLevenshteinEngineWithWeights(ComparisonWeights{NameWeight: 0.3, DescriptionWeight: 0.7})
+		}},
+		{"length adjusted", func() *LevenshteinEngine {
+			return NewLevenshteinEngineWithOptions(LevenshteinOptions{SimilarityMode: SimilarityLengthAdjusted})
+		}},
+		{"grapheme mode", func() *LevenshteinEngine {
+			return NewLevenshteinEngineWithOptions(LevenshteinOptions{GraphemeMode: true})
+		}},
+	}
+	for _, size := range []int{40, 80} {
+		products := lengthDiverseCatalog(size)
+		for _, tt := range engines {
+			for _, threshold := range []float64{0.5, 0.85, 1} {
+				t.Run(fmt.Sprintf("%s/%d products/%.2f", tt.name, size, threshold), func(t *testing.T) {
+					pruned, full := tt.new(), tt.new()
+					full.DisableLengthPruning()
+
+					got := pruned.FindDuplicates(products, threshold)
+					want := full.FindDuplicates(products, threshold)
+					if len(want) == 0 && threshold <= 0.85 {
+						t.Fatal("expected results to compare")
+					}
+					if size <= 50 {
+						// Sequential scans keep row order
+						if !reflect.DeepEqual(resultPairs(got), resultPairs(want)) {
+							t.Fatalf("pruned scan order differs:\n got %v\nwant %v", resultPairs(got), resultPairs(want))
+						}
+					}
+					if !reflect.DeepEqual(comparableResults(got), comparableResults(want)) {
+						t.Errorf("pruned scan found %d results, unpruned %d", len(got), len(want))
+					}
+
+					stats := pruned.GetScanStats()
+					compared, skipped := stats["pairs_compared"].(uint64), stats["length_pruned_pairs"].(uint64)
+					if total := uint64(size * (size - 1) / 2); compared+skipped != total {
+						t.Errorf("compared %d + pruned %d != %d pairs", compared, skipped, total)
+					}
+					if skipped == 0 && tt.name != "description heavy" && threshold >= 0.85 {
+						t.Error("expected pruned pairs at a high threshold")
+					}
+				})
+			}
+		}
+	}
+
+	t.Run("weight resolver disables pruning", func(t *testing.T) {
+		engine := NewLevenshteinEngine().WithWeightResolver(func(a, b Product) ComparisonWeights {
+			return ComparisonWeights{NameWeight: 0.1, DescriptionWeight: 0.9}
+		})
+		if engine.newLengthWindow(productPtrs(lengthDiverseCatalog(10)), 0.9) != nil {
+			t.Error("length window built with a weight resolver")
+		}
+	})
+
+	t.Run("logistic mode disables pruning", func(t *testing.T) {
+		engine := NewLevenshteinEngineWithOptions(LevenshteinOptions{SimilarityMode: SimilarityLogistic})
+		if engine.newLengthWindow(productPtrs(lengthDiverseCatalog(10)), 0.9) != nil {
+			t.Error("length window built in logistic mode")
+		}
+	})
+
+	t.Run("toggle", func(t *testing.T) {
+		engine := NewLevenshteinEngine()
+		if !engine.IsLengthPruningEnabled() {
+			t.Error("length pruning should be enabled by default")
+		}
+		engine.DisableLengthPruning()
+		if engine.IsLengthPruningEnabled() || engine.newLengthWindow(productPtrs(lengthDiverseCatalog(10)), 0.9) != nil {
+			t.Error("disabled length pruning still built a window")
+		}
+		engine.EnableLengthPruning()
+		if !engine.IsLengthPruningEnabled() {
+			t.Error("length pruning should be enabled again")
+		}
+	})
+}
+
+// BenchmarkLengthPruning scans 5000 products with names of 1 to 12 words and
+// reports the pairs compared per scan
+func BenchmarkLengthPruning(b *testing.B) {
+	products := lengthDiverseCatalog(5000)
+	for _, pruning := range []bool{false, true} {
+		b.Run(fmt.Sprintf("pruning=%v", pruning), func(b *testing.B) {
+			engine := NewLevenshteinEngine()
+			if !pruning {
+				engine.DisableLengthPruning()
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				engine.FindDuplicates(products, 0.85)
+			}
+			b.StopTimer()
+			stats := engine.GetScanStats()
+			b.ReportMetric(float64(stats["pairs_compared"].(uint64))/float64(b.N), "pairs/scan")
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package duplicatecheck
 
 import (
-	"runtime"
-	"sync"
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
 // min3 returns the minimum of three integers using optimized logic
@@ -25,35 +28,64 @@ func min3(a, b, c int) int {
 	return min
 }
 
-// intSlicePool reuses integer slices for Levenshtein DP matrices
-// This reduces allocations and GC pressure in batch operations
-var intSlicePool = sync.Pool{
-	New: func() interface{} {
-		// Pre-allocate with common size (most product names/descriptions are < 1024 chars)
-		s := make([]int, 1024)
-		return &s
-	},
+// getIntSlice retrieves a DP row of length minSize from the pool
+func getIntSlice(minSize int) []int {
+	if pools := slicePools.Load(); pools.rows != nil {
+		return pools.rows.get(minSize)
+	}
+	return make([]int, minSize)
 }
 
-// getIntSlice retrieves a slice from the pool with at least the required capacity
-func getIntSlice(minSize int) []int {
-	slice := *intSlicePool.Get().(*[]int)
-	if cap(slice) < minSize {
-		// Need larger slice, allocate new one
-		slice = make([]int, minSize)
+// putIntSlice returns a DP row to the pool, reporting whether it was pooled
+func putIntSlice(slice []int) bool {
+	if pools := slicePools.Load(); pools.rows != nil {
+		return pools.rows.put(slice)
+	}
+	return false
+}
+
+// getRunes decodes s into a pooled buffer
+func getRunes(s string) []rune {
+	var buf []rune
+	if pools := slicePools.Load(); pools.runes != nil {
+		// len(s) bytes always hold at least as many runes
+		buf = pools.runes.get(len(s))[:0]
 	} else {
-		// Reuse pooled slice, resize to needed length
-		slice = slice[:minSize]
+		buf = make([]rune, 0, len(s))
+	}
+	for _, r := range s {
+		buf = append(buf, r)
+	}
+	return buf
+}
+
+// putRunes returns a decoded buffer to the pool, reporting whether it was pooled
+func putRunes(buf []rune) bool {
+	if pools := slicePools.Load(); pools.runes != nil {
+		return pools.runes.put(buf)
+	}
+	return false
+}
+
+// getUint64Slice retrieves a zeroed slice of length size from the pool
+func getUint64Slice(size int) []uint64 {
+	pools := slicePools.Load()
+	if pools.vectors == nil {
+		return make([]uint64, size)
+	}
+	slice := pools.vectors.get(size)
+	for i := range slice {
+		slice[i] = 0
 	}
 	return slice
 }
 
-// putIntSlice returns a slice to the pool for reuse
-func putIntSlice(slice []int) {
-	// Only pool reasonably-sized slices to avoid memory bloat
-	if cap(slice) <= 4096 {
-		intSlicePool.Put(&slice)
+// putUint64Slice returns a slice to the pool, reporting whether it was pooled
+func putUint64Slice(slice []uint64) bool {
+	if pools := slicePools.Load(); pools.vectors != nil {
+		return pools.vectors.put(slice)
 	}
+	return false
 }
 
 // adaptiveThreshold dynamically adjusts similarity threshold based on string characteristics
@@ -114,14 +146,20 @@ func adaptiveThreshold(baseThreshold float64, lenA, lenB int) float64 {
 	return baseThreshold
 }
 
-// getOptimalWorkerCount calculates the ideal number of workers based on dataset size and CPU cores
+// getOptimalWorkerCount calculates the ideal number of workers based on dataset
+// size and the CPUs the process may use (see EffectiveWorkerCount)
+func getOptimalWorkerCount(numProducts int) int {
+	quota, limited := detectedQuota()
+	return workersFor(numProducts, hostCPUs.availableCPUs(quota, limited))
+}
+
+// workersFor sizes the workers for numProducts products on cpus CPUs
 // This adaptive approach provides:
 // - Minimal overhead for small datasets (2 workers)
 // - Full CPU utilization for medium datasets (all cores)
 // - Slight oversubscription for large datasets (up to 2x cores) to hide I/O latency
 // Expected speedup: 15-20% from better resource utilization
-func getOptimalWorkerCount(numProducts int) int {
-	cpus := runtime.NumCPU()
+func workersFor(numProducts, cpus int) int {
 
 	// Small datasets: minimize parallelization overhead
 	// 2 workers is usually optimal to avoid channel overhead
@@ -170,8 +208,125 @@ func getOptimalWorkerCount(numProducts int) int {
 // 2. Substring sampling for very long descriptions (optional)
 // 3. Two-row DP approach keeps memory usage at O(min(m,n))
 type LevenshteinEngine struct {
-	weights      ComparisonWeights  // Weights for combining name and description scores
-	rabinKarpFilter *RabinKarpFilter // Optional pre-filter for fast rejection
+	weights            ComparisonWeights    // Weights for combining name and description scores
+	rabinKarpFilter    *RabinKarpFilter     // Optional pre-filter for fast rejection
+	idPolicy           DuplicateIDPolicy    // How repeated Product IDs in the input are handled
+	keepInputCopies    bool                 // Don't collapse copies of the same input entry (see DisableInputDeduplication)
+	options            LevenshteinOptions   // Comparison behavior toggles
+	threshold          float64              // Default threshold for IsDuplicate and FindDuplicatesDefault
+	weightResolver     WeightResolver       // Optional per-pair weights, consulted by Compare
+	logger             *slog.Logger         // Optional event logger (see WithLogger)
+	rabinKarpRejected  uint64               // Rabin-Karp rejections (atomic)
+	segmenter          DescriptionSegmenter // Optional description sections (see WithDescriptionSegmenter)
+	segmentAlign       SegmentAlignment     // How segments of two descriptions are paired
+	prep               *textPreparer        // Text preparation (nil = DefaultTextPreparation)
+	noDescriptionMemo  bool                 // Disables the per-scan description memo (see EnableDescriptionMemo)
+	noNameMemo         bool                 // Disables the per-scan name memo (see EnableNameMemo)
+	quality            *QualityFilter       // Optional junk-product filter (see WithQualityFilter)
+	qualityMode        QualityMode          // What FindDuplicates does with low-quality products
+	noLengthPruning    bool                 // Disables name-length pruning (see EnableLengthPruning)
+	pairsCompared      uint64               // FindDuplicates pairs compared (atomic, see GetScanStats)
+	nameComparisons    uint64               // Name distances computed (atomic, see GetScanStats)
+	lengthPruned       uint64               // FindDuplicates pairs skipped by length pruning (atomic)
+	noCharsetPruning   bool                 // Disables charset pruning (see EnableCharsetPruning)
+	charsetPruned      uint64               // Scan pairs skipped by charset pruning (atomic)
+	checkpointInterval int                  // Pairs between resumable scan checkpoints (0 = DefaultCheckpointInterval)
+	descriptionTimeout uint64               // Description comparisons cut short by MaxComparisonDuration (atomic)
+	crossField         *CrossFieldConfig    // Optional name-in-description matching (see WithCrossFieldMatching)
+	swappedFields      *SwappedFieldsConfig // Optional crossed comparison of swapped-looking pairs (see WithSwappedFields)
+	noLegacyFields     bool                 // Leaves the deprecated result fields zero (see EnableCompatibilityMode)
+	pairConstraint     PairConstraint       // Optional filter on which pairs scans compare (see WithPairConstraint)
+	constraintSkipped  uint64               // Pairs excluded by pairConstraint (atomic, see GetScanStats)
+	calibration        *Calibration         // Optional similarity-to-probability mapping (see WithCalibration)
+	deobfuscation      *deobfuscator        // Optional look-alike character matching (see WithDeobfuscation)
+	redactor           *Redactor            // Optional masking of returned products (see WithResultRedaction)
+	descriptionLoader  DescriptionLoader    // Optional loader of missing descriptions (see WithLazyDescriptionLoader)
+	loaderCalls        uint64               // Description loader calls made by scans (atomic, see GetScanStats)
+	loaderFailures     uint64               // Description loader calls that failed (atomic)
+	noDescriptionSkip  bool                 // Disables the lazy description skip (see EnableDescriptionSkip)
+	idComparator       IDComparator         // Optional ID relation check (see WithIDComparator)
+	idOptions          IDComparisonOptions  // What idComparator's same-source pairs get
+	maxWorkers         int                  // Parallel scan goroutine limit (0 = detected, see SetMaxWorkers)
+	deterministic      bool                 // One goroutine, no wall-clock or random inputs (see EnableDeterministicMode)
+	safeMode           bool                 // Public entry points recover panics (see EnableSafeMode)
+	interner           *Interner            // Shares prepared and indexed text (see WithInterner)
+	// When descriptionLoader loads (see SetLazyDescriptionOptions)
+	lazyDescription LazyDescriptionOptions
+
+	crossLanguage           CrossLanguagePolicy // How translated descriptions are compared (see WithCrossLanguagePolicy)
+	crossLanguageConfidence float64             // Detection confidence both descriptions need
+	variants                *VariantOptions     // Optional variant classification (see WithVariantDetection)
+
+	skuNames *skuNameMatcher // Optional exact matching of SKU-like names (see WithSKUNames)
+	lowInfo  *lowInfoNames   // Optional description-only comparison of junk names (see WithLowInfoNames)
+
+	bundleSplitter BundleSplitter // Optional set-of-items comparison of bundle names (see WithBundleSplitter)
+
+	scanLimits ScanLimits    // Limits checked before all-pairs scans (see WithScanLimits)
+	shaping    OutputShaping // How FindDuplicates trims its output (see WithOutputShaping)
+
+	suppressions        *SuppressionStore // Optional reviewed pairs scans skip (see WithSuppressions)
+	suppressionsApplied uint64            // Pairs scans skipped as suppressed (atomic)
+	suppressionsExpired uint64            // Pairs scans compared because their suppression expired (atomic)
+	suppressionsVoided  uint64            // Pairs scans compared because a product changed since suppressed (atomic)
+
+	descriptionSkipped    uint64            // Description comparisons skipped by skipsDescription (atomic, see GetScanStats)
+	swappedFieldsCompared uint64            // Crossed comparisons of swapped-looking pairs (atomic, see GetScanStats)
+	scanSummaryHook       func(ScanSummary) // Receives the summary of streaming and file scans (see WithScanSummary)
+
+	verificationSample *verificationSampling // Optional QA sample of every scan's pairs (see WithVerificationSampling)
+	audit              *auditLog             // Optional record of every scan's decisions (see WithAudit)
+	notifier           *resultNotifier       // Optional notification of streamed matches (see WithNotifier)
+	ambiguous          *ambiguousProducts    // Optional exclusion or penalty of duplicate magnets (see WithAmbiguousProducts)
+}
+
+// LevenshteinOptions configures how the Levenshtein engine measures distance
+type LevenshteinOptions struct {
+	// GraphemeMode runs the distance over extended grapheme clusters (UAX #29)
+	// instead of runes, so an emoji ZWJ sequence, a flag, or a letter with a
+	// combining accent counts as one edit. Similarity is normalized by the
+	// cluster count. Off by default; ASCII input takes a fast path either way.
+	GraphemeMode bool
+
+	// SimilarityMode selects how distances become similarities for both name
+	// and description (see SimilarityLinear, SimilarityLengthAdjusted, SimilarityLogistic)
+	SimilarityMode SimilarityMode
+	// LengthFloor is the length below which SimilarityLengthAdjusted penalizes edits
+	LengthFloor int
+	// LogisticMidpoint is the linear similarity SimilarityLogistic maps to ~0.5
+	LogisticMidpoint float64
+	// LogisticSteepness controls how sharply SimilarityLogistic separates scores around the midpoint
+	LogisticSteepness float64
+
+	// MaxComparisonDuration bounds the time one description comparison may
+	// take (0 = unbounded). The distance loops count their work against a
+	// budget derived from a cells-per-nanosecond rate measured once, when the
+	// first engine with this option is created; a comparison that runs out
+	// scores its description 0 and sets ComparisonResult.DescriptionTimedOut.
+	// Names are short and never budgeted.
+	MaxComparisonDuration time.Duration
+
+	// PrefixBias makes name edits within the first PrefixFraction of the
+	// longer name cost 1+PrefixBias instead of 1 (0 = off), for catalogs whose
+	// names lead with brand and product line: "Sony" vs "Bose" then weighs more
+	// than "Black" vs "Midnight" (see prefixWeightedSimilarity). Descriptions
+	// are unaffected.
+	PrefixBias float64
+	// PrefixFraction is the share of the longer name PrefixBias weighs (0 =
+	// DefaultPrefixFraction)
+	PrefixFraction float64
+}
+
+// DefaultLevenshteinOptions returns the default options (rune-based distance, linear similarity)
+// Zero tuning parameters also fall back to these defaults
+func DefaultLevenshteinOptions() LevenshteinOptions {
+	return LevenshteinOptions{
+		GraphemeMode:      false,
+		SimilarityMode:    SimilarityLinear,
+		LengthFloor:       DefaultLengthFloor,
+		LogisticMidpoint:  DefaultLogisticMidpoint,
+		LogisticSteepness: DefaultLogisticSteepness,
+	}
 }
 
 // NewLevenshteinEngine creates a new instance of the Levenshtein algorithm engine
@@ -180,6 +335,7 @@ func NewLevenshteinEngine() *LevenshteinEngine {
 	return &LevenshteinEngine{
 		weights:         DefaultWeights(),
 		rabinKarpFilter: NewRabinKarpFilter(5), // Enable Rabin-Karp pre-filtering
+		threshold:       DefaultThreshold,
 	}
 }
 
@@ -189,7 +345,29 @@ func NewLevenshteinEngineWithWeights(weights ComparisonWeights) *LevenshteinEngi
 	return &LevenshteinEngine{
 		weights:         weights,
 		rabinKarpFilter: NewRabinKarpFilter(5), // Enable Rabin-Karp pre-filtering
+		threshold:       DefaultThreshold,
+	}
+}
+
+// NewLevenshteinEngineWithOptions creates an engine with default weights,
+// Rabin-Karp pre-filtering, and the given options
+func NewLevenshteinEngineWithOptions(opts LevenshteinOptions) *LevenshteinEngine {
+	engine := NewLevenshteinEngine()
+	engine.SetOptions(opts)
+	return engine
+}
+
+// SetOptions replaces the engine's comparison options
+func (e *LevenshteinEngine) SetOptions(opts LevenshteinOptions) {
+	if opts.MaxComparisonDuration > 0 {
+		calibrateCellRate()
 	}
+	e.options = opts
+}
+
+// GetOptions returns the engine's comparison options
+func (e *LevenshteinEngine) GetOptions() LevenshteinOptions {
+	return e.options
 }
 
 // GetName returns the name of this algorithm
@@ -218,103 +396,333 @@ func (e *LevenshteinEngine) IsRabinKarpEnabled() bool {
 	return e.rabinKarpFilter != nil && e.rabinKarpFilter.IsEnabled()
 }
 
+// SetDuplicateIDPolicy controls how FindDuplicates handles repeated Product IDs
+// The default is DuplicateIDReject
+func (e *LevenshteinEngine) SetDuplicateIDPolicy(policy DuplicateIDPolicy) {
+	e.idPolicy = policy
+}
+
+// GetDuplicateIDPolicy returns the active duplicate ID policy
+func (e *LevenshteinEngine) GetDuplicateIDPolicy() DuplicateIDPolicy {
+	return e.idPolicy
+}
+
+// SetDefaultThreshold changes the threshold used by IsDuplicate, FindDuplicatesDefault
+// and stamped on Compare results; returns an error outside [0.0-1.0]
+func (e *LevenshteinEngine) SetDefaultThreshold(threshold float64) error {
+	if err := validateThreshold(threshold); err != nil {
+		return err
+	}
+	e.threshold = threshold
+	return nil
+}
+
+// GetDefaultThreshold returns the engine's default threshold
+func (e *LevenshteinEngine) GetDefaultThreshold() float64 {
+	return e.threshold
+}
+
+// IsDuplicate compares two products against the default threshold
+func (e *LevenshteinEngine) IsDuplicate(a, b Product) (bool, ComparisonResult) {
+	defer e.guard("IsDuplicate", nil)
+	result := e.Compare(a, b)
+	return result.MeetsThreshold, result
+}
+
+// FindDuplicatesDefault is FindDuplicates with the default threshold
+func (e *LevenshteinEngine) FindDuplicatesDefault(products []Product) []ComparisonResult {
+	defer e.guard("FindDuplicatesDefault", nil)
+	return e.FindDuplicates(products, e.threshold)
+}
+
+// WithWeightResolver sets a resolver that picks weights per pair in Compare and
+// FindDuplicates; pass nil to remove it. Returns the engine for chaining.
+// CompareWithWeights always uses the weights it is given.
+func (e *LevenshteinEngine) WithWeightResolver(resolver WeightResolver) *LevenshteinEngine {
+	e.weightResolver = resolver
+	return e
+}
+
+// resolveWeights returns the weights for a pair: the resolver's, or the engine's
+func (e *LevenshteinEngine) resolveWeights(a, b *Product) ComparisonWeights {
+	if e.weightResolver != nil {
+		if weights := e.weightResolver(*a, *b); weights != (ComparisonWeights{}) {
+			return weights
+		}
+	}
+	return e.weights
+}
+
 // Compare computes the Levenshtein distance and similarity between two products
-// Uses default weights (70% name, 30% description)
+// Uses the engine weights (default 70% name, 30% description), or the weight
+// resolver's choice for this pair when one is set
 // Uses Rabin-Karp pre-filtering to quickly reject obviously dissimilar pairs
 func (e *LevenshteinEngine) Compare(a, b Product) ComparisonResult {
-	return e.CompareWithWeights(a, b, e.weights)
+	defer e.guard("Compare", nil)
+	return e.ComparePtr(&a, &b)
+}
+
+// ComparePtr is Compare without copying the products
+// Normalization is cached on the products themselves, so repeated comparisons
+// of the same products skip it
+func (e *LevenshteinEngine) ComparePtr(a, b *Product) ComparisonResult {
+	defer e.guard("ComparePtr", nil)
+	return e.CompareWithWeightsPtr(a, b, e.resolveWeights(a, b))
+}
+
+// compareAt is ComparePtr for a scan at threshold, which may skip the
+// descriptions of pairs that can't reach it (see skipsDescription)
+func (e *LevenshteinEngine) compareAt(a, b *Product, threshold float64) ComparisonResult {
+	return e.compareWithWeights(a, b, e.resolveWeights(a, b), memoPair{}, threshold)
 }
 
 // CompareWithWeights computes similarity with custom weights for name vs description
 func (e *LevenshteinEngine) CompareWithWeights(a, b Product, weights ComparisonWeights) ComparisonResult {
-	// Use cached normalized strings to avoid repeated ToLower/TrimSpace operations
-	nameA, descA := a.getNormalizedStrings()
-	nameB, descB := b.getNormalizedStrings()
+	defer e.guard("CompareWithWeights", nil)
+	return e.CompareWithWeightsPtr(&a, &b, weights)
+}
 
-	// Fast rejection using Rabin-Karp pre-filter
-	// Only use for very high thresholds where we can confidently reject
-	// Use threshold 0.85 - only reject if Rabin-Karp says definitely not similar
-	// This avoids false negatives (missing true matches)
-	if e.rabinKarpFilter != nil && e.rabinKarpFilter.IsEnabled() && len(nameA) > 20 && len(nameB) > 20 {
-		// Quick name rejection: only for longer strings where rolling hash is reliable
-		if !e.rabinKarpFilter.QuickReject(nameA, nameB, 0.85) {
-			// Names are very different (high confidence), return low similarity
-			return ComparisonResult{
-				ProductA:              a,
-				ProductB:              b,
-				NameDistance:          len([]rune(nameA)) + len([]rune(nameB)), // Max distance
-				NameSimilarity:        0.0,
-				DescriptionDistance:   0,
-				DescriptionSimilarity: 0.0,
-				CombinedSimilarity:    0.0,
-				Distance:              0,
-				Similarity:            0.0,
-			}
-		}
+// CompareWithWeightsPtr is CompareWithWeights without copying the products
+func (e *LevenshteinEngine) CompareWithWeightsPtr(a, b *Product, weights ComparisonWeights) ComparisonResult {
+	defer e.guard("CompareWithWeightsPtr", nil)
+	return e.compareWithWeights(a, b, weights, memoPair{}, 0)
+}
+
+// compareWithWeights is CompareWithWeightsPtr, reusing description scores
+// through pair.memo when a scan provides one
+// threshold is the caller's threshold, below which a pair may skip its
+// description comparison (see skipsDescription); 0 never skips.
+func (e *LevenshteinEngine) compareWithWeights(a, b *Product, weights ComparisonWeights, pair memoPair, threshold float64) ComparisonResult {
+	// Normalize weights upfront (see ComparisonWeights.Normalized)
+	normalized := weights.Normalized()
+
+	// IDs naming the same entity settle the pair without reading its text
+	relation := e.idRelation(a.ID, b.ID)
+	if relation == IDSameEntity {
+		return e.identifierResult(a, b, normalized)
 	}
+	sameSource := relation == IDSameSource && e.idOptions.FlagSameSource
 
-	// Compute name similarity
-	nameDistance := e.computeDistance(nameA, nameB)
-	nameSimilarity := e.computeSimilarity(nameA, nameB, nameDistance)
+	// Use cached normalized strings to avoid repeated ToLower/TrimSpace operations
+	nameA, descA := a.preparedStrings(e.preparer())
+	nameB, descB := b.preparedStrings(e.preparer())
+
+	// Equal after normalization: similarity is 1.0 without running the DP
+	if nameA == nameB && descA == descB {
+		result := e.normalizedExactResult(a, b, normalized)
+		result.SameSourceIDs = sameSource
+		_, result.SKUNameComparison = e.skuNames.key(nameA)
+		result.assignReasons()
+		return result
+	}
 
-	// Lazy description comparison: only compute if name similarity suggests possible match
-	// Calculate normalized weights upfront for threshold check
-	totalWeight := weights.NameWeight + weights.DescriptionWeight
-	if totalWeight == 0 {
-		totalWeight = 1.0
+	// Descriptions in different languages: flagged, and under
+	// CrossLanguageNameOnly not compared at all
+	crossLanguage := e.crossLanguagePair(a, b)
+	nameOnly := crossLanguage && e.crossLanguage == CrossLanguageNameOnly
+	if nameOnly {
+		normalized = ComparisonWeights{NameWeight: 1.0, DescriptionWeight: 0.0}
+	}
+	// Low-information names on both sides: the descriptions decide
+	lowInfo := !nameOnly && e.lowInfoPair(nameA, nameB, pair)
+	if lowInfo {
+		normalized = ComparisonWeights{NameWeight: 0.0, DescriptionWeight: 1.0}
 	}
-	normalizedNameWeight := weights.NameWeight / totalWeight
-	normalizedDescWeight := weights.DescriptionWeight / totalWeight
 
-	// Early exit: if even perfect description match can't reach reasonable threshold (60%)
-	// AND description weight is low (< 40%), skip expensive description comparison
-	maxPossibleSimilarity := nameSimilarity*normalizedNameWeight + 1.0*normalizedDescWeight
+	// Name score, shared by every pair of the same two names in a scan
+	names := pair.name(func() nameScore {
+		return e.scoreNamePair(nameA, nameB, pair.scratch)
+	})
+	if names.rejected && lowInfo {
+		// The names don't count: score the descriptions anyway
+		names = nameScore{distance: len([]rune(nameA)) + len([]rune(nameB))}
+	}
+	if names.rejected {
+		atomic.AddUint64(&e.rabinKarpRejected, 1)
+		// Names are very different (high confidence), return low similarity
+		result := ComparisonResult{
+			ProductA:              *a,
+			ProductB:              *b,
+			NameDistance:          len([]rune(nameA)) + len([]rune(nameB)), // Max distance
+			NameSimilarity:        0.0,
+			DescriptionDistance:   0,
+			DescriptionSimilarity: 0.0,
+			CombinedSimilarity:    0.0,
+			WeightsUsed:           normalized,
+			SimilarityMode:        e.options.SimilarityMode,
+			ThresholdUsed:         e.threshold,
+			MeetsThreshold:        meetsThreshold(0, e.threshold),
+			SameSourceIDs:         sameSource,
+			CrossLanguage:         crossLanguage,
+			SKUNameMixed:          names.skuMixed,
+		}
+		// Unless a product's fields were swapped (see WithSwappedFields)
+		e.swappedFieldsScore(nameA, nameB, descA, descB, 0, normalized, threshold, pair.scratch).apply(&result)
+		return e.finishResult(result)
+	}
+	nameDistance, nameSimilarity := names.distance, names.similarity
+	// Obfuscated names are scored as their de-obfuscated forms
+	effectiveNameSimilarity := nameSimilarity
+	if names.obfuscated {
+		effectiveNameSimilarity = names.deobfuscated
+	}
+	// Bundles score the better of the flat and the item-by-item comparison
+	if names.bundle > effectiveNameSimilarity {
+		effectiveNameSimilarity = names.bundle
+	}
 
 	var descDistance int
 	var descSimilarity float64
-
-	// Skip expensive description comparison only if:
-	// 1. Description weight is relatively low (< 0.4)
-	// 2. Even perfect description match won't help much
-	// 3. Both descriptions exist
-	if maxPossibleSimilarity < 0.60 && normalizedDescWeight < 0.4 && descA != "" && descB != "" {
+	var segmentScores []SegmentScore
+	var descTimedOut bool
+	var recorded []ReasonCode // Codes only the comparison knows (see assignReasons)
+
+	// Lazy description comparison: skip it when the name similarity rules out
+	// a match (see skipsDescription) and both descriptions exist
+	if nameOnly {
+		descDistance = len([]rune(descA)) + len([]rune(descB))
+	} else if e.skipsDescription(effectiveNameSimilarity, normalized, threshold) && descA != "" && descB != "" {
 		descDistance = len([]rune(descA)) + len([]rune(descB)) // Max possible distance
 		descSimilarity = 0.0
+		atomic.AddUint64(&e.descriptionSkipped, 1)
+		recorded = append(recorded, ReasonDescriptionSkipped)
 	} else {
-		// Compute description similarity (needed for accurate result)
-		descDistance = e.computeDistance(descA, descB)
-		descSimilarity = e.computeSimilarity(descA, descB, descDistance)
+		// Same description texts seen earlier in this scan: reuse their score
+		score := pair.description(func() descriptionScore {
+			return e.compareDescriptions(descA, descB, pair.scratch)
+		})
+		descDistance, descSimilarity, segmentScores, descTimedOut = score.distance, score.similarity, score.segments, score.timedOut
 	}
 
 	// Compute weighted combined similarity
-	// If either field is empty, use only the non-empty field
-	var combinedSimilarity float64
-	if nameA == "" && nameB == "" {
-		// Both names empty, use only description
-		combinedSimilarity = descSimilarity
-	} else if descA == "" && descB == "" {
-		// Both descriptions empty, use only name
-		combinedSimilarity = nameSimilarity
-	} else if (nameA == "" || nameB == "") && (descA == "" || descB == "") {
-		// One product has no data at all
-		combinedSimilarity = 0.0
-	} else {
-		// Both have data, use weighted combination (weights already normalized above)
-		combinedSimilarity = (nameSimilarity * normalizedNameWeight) +
-			(descSimilarity * normalizedDescWeight)
+	combinedSimilarity := combinePreparedFields(nameA, nameB, descA, descB, effectiveNameSimilarity, descSimilarity, normalized)
+
+	var nameInDescAB, nameInDescBA float64
+	if e.crossField != nil {
+		nameInDescAB, nameInDescBA = e.crossFieldScores(nameA, nameB, descA, descB)
+		combinedSimilarity = e.foldCrossField(combinedSimilarity, nameInDescAB, nameInDescBA)
+	}
+	// A product whose fields were swapped is scored crossed (see WithSwappedFields)
+	swapped := e.swappedFieldsScore(nameA, nameB, descA, descB, combinedSimilarity, normalized, threshold, pair.scratch)
+	if swapped.suspected {
+		combinedSimilarity = swapped.combined
+	}
+	if relation == IDSameSource {
+		combinedSimilarity = e.sameSourceSimilarity(combinedSimilarity)
+	}
+	ambiguous := e.ambiguous.pair(a, b)
+	if ambiguous {
+		combinedSimilarity = clampUnit(combinedSimilarity - e.ambiguous.policy.Penalty)
+	}
+	matchType, vetoed := e.variantMatch(a, b, nameSimilarity)
+	if vetoed {
+		combinedSimilarity = 0
+		recorded = append(recorded, ReasonVariantVetoed)
 	}
 
-	return ComparisonResult{
-		ProductA:              a,
-		ProductB:              b,
+	return e.finishResult(ComparisonResult{
+		ProductA:              *a,
+		ProductB:              *b,
 		NameDistance:          nameDistance,
 		NameSimilarity:        nameSimilarity,
 		DescriptionDistance:   descDistance,
 		DescriptionSimilarity: descSimilarity,
 		CombinedSimilarity:    combinedSimilarity,
-		Distance:              nameDistance,       // Legacy field
-		Similarity:            combinedSimilarity, // Legacy field
+		WeightsUsed:           normalized,
+		SimilarityMode:        e.options.SimilarityMode,
+		ThresholdUsed:         e.threshold,
+		MeetsThreshold:        meetsThreshold(combinedSimilarity, e.threshold),
+		MatchType:             matchType,
+		SegmentSimilarities:   segmentScores,
+		DescriptionTimedOut:   descTimedOut,
+		NameInDescriptionAB:   nameInDescAB,
+		NameInDescriptionBA:   nameInDescBA,
+
+		ObfuscationSuspected:       names.obfuscated,
+		DeobfuscatedNameSimilarity: names.deobfuscated,
+		SameSourceIDs:              sameSource,
+		CrossLanguage:              crossLanguage,
+		SKUNameComparison:          names.sku,
+		AmbiguousProduct:           ambiguous,
+		SKUNameMixed:               names.skuMixed,
+		LowInfoNames:               lowInfo,
+		BundleSimilarity:           names.bundle,
+		ComponentMatches:           names.components,
+		ReasonCodes:                recorded,
+
+		FieldsSwappedSuspected:       swapped.suspected,
+		SwappedNameSimilarity:        swapped.nameSimilarity,
+		SwappedDescriptionSimilarity: swapped.descSimilarity,
+	})
+}
+
+// skipsDescription reports whether a pair with this name similarity skips its
+// description comparison under normalized weights at the caller's threshold
+// Skipped only if the description weight is relatively low (< 0.4) and even a
+// perfect description match can't reach the threshold, so skipping never
+// changes which pairs match. Without a threshold (0, as in Compare), with
+// cross-field matching, which can lift a pair above that bound, or with
+// DisableDescriptionSkip, nothing is skipped.
+func (e *LevenshteinEngine) skipsDescription(nameSimilarity float64, normalized ComparisonWeights, threshold float64) bool {
+	if e.noDescriptionSkip || e.crossField != nil {
+		return false
+	}
+	maxPossibleSimilarity := combineSimilarities(nameSimilarity, 1.0, normalized)
+	return !meetsThreshold(maxPossibleSimilarity, threshold) && normalized.DescriptionWeight < 0.4
+}
+
+// EnableDescriptionSkip lets scans skip the description comparison of pairs
+// whose names rule out the threshold (default); results are identical either way
+func (e *LevenshteinEngine) EnableDescriptionSkip() {
+	e.noDescriptionSkip = false
+}
+
+// DisableDescriptionSkip makes scans compare every pair's descriptions
+func (e *LevenshteinEngine) DisableDescriptionSkip() {
+	e.noDescriptionSkip = true
+}
+
+// scoreNames scores two prepared names, in scratch (nil: the pools)
+// Long names the Rabin-Karp filter rules out are rejected without a distance.
+func (e *LevenshteinEngine) scoreNames(nameA, nameB string, scratch *comparisonScratch) nameScore {
+	// Fast rejection using Rabin-Karp pre-filter
+	// Only use for very high thresholds where we can confidently reject
+	// Use threshold 0.85 - only reject if Rabin-Karp says definitely not similar
+	// This avoids false negatives (missing true matches)
+	// Skipped with cross-field matching, which targets pairs with dissimilar names
+	if e.crossField == nil && e.rabinKarpFilter != nil && e.rabinKarpFilter.IsEnabled() && len(nameA) > 20 && len(nameB) > 20 {
+		// Quick name rejection: only for longer strings where rolling hash is reliable
+		if !e.rabinKarpFilter.QuickReject(nameA, nameB, 0.85) {
+			return nameScore{rejected: true}
+		}
+	}
+
+	atomic.AddUint64(&e.nameComparisons, 1)
+	distance := e.scratchDistance(nameA, nameB, scratch)
+	return nameScore{distance: distance, similarity: e.nameSimilarity(nameA, nameB, distance)}
+}
+
+// compareDescriptions scores two prepared descriptions, by segment when a
+// segmenter is set (see WithDescriptionSegmenter)
+// Flat comparisons work in scratch (nil: the pools). Under
+// MaxComparisonDuration a comparison that runs out of budget scores 0.
+func (e *LevenshteinEngine) compareDescriptions(descA, descB string, scratch *comparisonScratch) descriptionScore {
+	budget := e.newDescriptionBudget()
+	var score descriptionScore
+	if e.segmenter != nil {
+		distance, similarity, segments := e.compareSegmented(descA, descB, budget)
+		score = descriptionScore{distance: distance, similarity: similarity, segments: segments}
+	} else {
+		distance, _ := e.scratchDistanceWithin(descA, descB, -1, budget, scratch)
+		score = descriptionScore{distance: distance, similarity: e.computeSimilarity(descA, descB, distance)}
 	}
+
+	if budget.timedOut() {
+		atomic.AddUint64(&e.descriptionTimeout, 1)
+		// Like a skipped comparison: the maximum distance and no similarity
+		return descriptionScore{distance: e.textLength(descA) + e.textLength(descB), timedOut: true}
+	}
+	return score
 }
 
 // computeDistance calculates the Levenshtein distance between two strings.
@@ -365,6 +773,7 @@ func (e *LevenshteinEngine) CompareWithWeights(a, b Product, weights ComparisonW
 // This reduces space from O(m*n) to O(min(m,n))
 // computeDistance calculates Levenshtein distance between two strings
 // Inline hint: this is a thin wrapper - should be inlined for performance
+//
 //go:inline
 func (e *LevenshteinEngine) computeDistance(s, t string) int {
 	return e.computeDistanceWithThreshold(s, t, -1)
@@ -372,11 +781,43 @@ func (e *LevenshteinEngine) computeDistance(s, t string) int {
 
 // computeDistanceWithThreshold calculates Levenshtein distance with early termination
 // If maxDistance >= 0, returns early if distance exceeds this threshold
+// (the returned value is then a lower bound greater than maxDistance, not the exact distance)
 func (e *LevenshteinEngine) computeDistanceWithThreshold(s, t string, maxDistance int) int {
+	distance, _ := e.distanceWithin(s, t, maxDistance, nil)
+	return distance
+}
+
+// distanceWithin is computeDistanceWithThreshold charging its work to budget
+// Returns false, with a meaningless distance, once budget is exhausted.
+func (e *LevenshteinEngine) distanceWithin(s, t string, maxDistance int, budget *cellBudget) (int, bool) {
+	return e.scratchDistanceWithin(s, t, maxDistance, budget, nil)
+}
+
+// scratchDistance is computeDistance working in a scan worker's scratch
+// buffers (the pools when scratch is nil)
+func (e *LevenshteinEngine) scratchDistance(s, t string, scratch *comparisonScratch) int {
+	distance, _ := e.scratchDistanceWithin(s, t, -1, nil, scratch)
+	return distance
+}
+
+// scratchDistanceWithin is distanceWithin working in a scan worker's scratch
+// buffers (the pools when scratch is nil)
+func (e *LevenshteinEngine) scratchDistanceWithin(s, t string, maxDistance int, budget *cellBudget, scratch *comparisonScratch) (int, bool) {
 	// Convert strings to rune slices for proper Unicode handling
 	// (a rune is a Unicode code point, handles emojis, accents, etc.)
-	rs := []rune(s)
-	rt := []rune(t)
+	// In grapheme mode each element is a whole grapheme cluster instead
+	var rs, rt []rune
+	if e.options.GraphemeMode {
+		rs, rt = graphemeUnits(s, t)
+	} else if scratch != nil {
+		rs, rt = scratch.decode(s, t)
+	} else {
+		rs, rt = getRunes(s), getRunes(t)
+		defer func(rs, rt []rune) {
+			putRunes(rs)
+			putRunes(rt)
+		}(rs, rt)
+	}
 
 	// Optimization: make rs the shorter string to minimize space usage
 	if len(rs) > len(rt) {
@@ -388,25 +829,35 @@ func (e *LevenshteinEngine) computeDistanceWithThreshold(s, t string, maxDistanc
 
 	// Edge cases: if one string is empty, the distance is the length of the other
 	if n == 0 {
-		return m
+		return m, true
 	}
 	if m == 0 {
-		return n
+		return n, true
 	}
 
 	// Early termination: if length difference alone exceeds threshold, return early
 	lenDiff := m - n
 	if maxDistance >= 0 && lenDiff > maxDistance {
-		return lenDiff
+		return lenDiff, true
+	}
+
+	// Long strings: Myers' bit-parallel algorithm gives the same distance in a
+	// fraction of the time (see myers.go)
+	if n > myersMinPatternLength {
+		return myersDistanceWithin(rs, rt, maxDistance, budget, scratch)
 	}
 
-	// Get slices from pool to reduce allocations
-	prev := getIntSlice(n + 1)
-	curr := getIntSlice(n + 1)
-	defer func() {
-		putIntSlice(prev)
-		putIntSlice(curr)
-	}()
+	// Get slices from the scratch or the pool to reduce allocations
+	var prev, curr []int
+	if scratch != nil {
+		prev, curr = scratch.rows(n + 1)
+	} else {
+		prev, curr = getIntSlice(n+1), getIntSlice(n+1)
+		defer func() {
+			putIntSlice(prev)
+			putIntSlice(curr)
+		}()
+	}
 
 	// Initialize first row: distance from empty string to prefixes of rs
 	// [0, 1, 2, 3, ..., n]
@@ -436,12 +887,29 @@ func (e *LevenshteinEngine) computeDistanceWithThreshold(s, t string, maxDistanc
 			curr[i] = min3(insertion, deletion, substitution)
 		}
 
+		// Early termination: row minimums never decrease, so once every cell
+		// exceeds maxDistance the final distance will too
+		if maxDistance >= 0 {
+			rowMin := curr[0]
+			for i := 1; i <= n; i++ {
+				if curr[i] < rowMin {
+					rowMin = curr[i]
+				}
+			}
+			if rowMin > maxDistance {
+				return rowMin, true
+			}
+		}
+		if !budget.spend(n) {
+			return 0, false
+		}
+
 		// Swap rows: current becomes previous for next iteration
 		prev, curr = curr, prev
 	}
 
 	// The final answer is in the last cell of prev
-	return prev[n]
+	return prev[n], true
 }
 
 // computeSimilarity converts the Levenshtein distance into a normalized
@@ -454,22 +922,23 @@ func (e *LevenshteinEngine) computeDistanceWithThreshold(s, t string, maxDistanc
 //	"APPLE" vs "APPLE"  → distance=0, max=5 → similarity = 1 - 0/5 = 1.00 (100% similar)
 //	"APPLE" vs "APPL"   → distance=1, max=5 → similarity = 1 - 1/5 = 0.80 (80% similar)
 //	"APPLE" vs "ORANGE" → distance=5, max=6 → similarity = 1 - 5/6 = 0.17 (17% similar)
+//
 // computeSimilarity converts Levenshtein distance to similarity score [0.0-1.0]
 // Inline hint: called frequently in inner loops - inlining reduces overhead
+//
 //go:inline
 func (e *LevenshteinEngine) computeSimilarity(s, t string, distance int) float64 {
-	rs := []rune(s)
-	rt := []rune(t)
+	lenS, lenT := e.textLength(s), e.textLength(t)
 
 	// Special case: both strings are empty
-	if len(rs) == 0 && len(rt) == 0 {
+	if lenS == 0 && lenT == 0 {
 		return 1.0
 	}
 
 	// Find the maximum length between the two strings
-	maxLen := len(rs)
-	if len(rt) > maxLen {
-		maxLen = len(rt)
+	maxLen := lenS
+	if lenT > maxLen {
+		maxLen = lenT
 	}
 
 	// Avoid division by zero (shouldn't happen, but be safe)
@@ -477,8 +946,17 @@ func (e *LevenshteinEngine) computeSimilarity(s, t string, distance int) float64
 		return 0.0
 	}
 
-	// Normalize distance to a 0-1 scale
-	return 1.0 - float64(distance)/float64(maxLen)
+	// Normalize distance to a 0-1 scale (linear unless another SimilarityMode is set)
+	return e.normalizeSimilarity(distance, maxLen)
+}
+
+// textLength returns the length of s in the units the distance is measured in:
+// runes by default, grapheme clusters in grapheme mode
+func (e *LevenshteinEngine) textLength(s string) int {
+	if e.options.GraphemeMode {
+		return graphemeCount(s)
+	}
+	return utf8.RuneCountInString(s)
 }
 
 // FindDuplicates scans a list of products and finds all pairs that are
@@ -496,32 +974,162 @@ func (e *LevenshteinEngine) computeSimilarity(s, t string, distance int) float64
 //   - This performs O(n²) comparisons where n is the number of products
 //   - For 1000 products, this is ~500,000 comparisons
 //   - Automatically uses parallel processing for large datasets (>50 products)
+//
+// Repeated Product IDs are handled according to the engine's DuplicateIDPolicy.
+// With the default DuplicateIDReject policy, FindDuplicates returns nil when the
+// input contains repeated IDs; use FindDuplicatesChecked to receive the error.
+// A scan exceeding the engine's ScanLimits is only logged as a warning.
 func (e *LevenshteinEngine) FindDuplicates(products []Product, threshold float64) []ComparisonResult {
-	// Use parallel version for larger datasets
-	if len(products) > 50 {
-		return e.FindDuplicatesParallel(products, threshold)
+	defer e.guard("FindDuplicates", nil)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil
 	}
+	e.warnScanLimits(resolved, threshold)
+	return e.findDuplicatesUnchecked(context.Background(), productPtrs(resolved), threshold)
+}
 
-	// Use simple sequential version for small datasets
-	return e.findDuplicatesSequential(products, threshold)
+// FindDuplicatesChecked is like FindDuplicates but reports input problems
+// Returns a *DuplicateIDError when the input repeats IDs under DuplicateIDReject,
+// and a *ScanLimitError when the scan would exceed the engine's ScanLimits.
+func (e *LevenshteinEngine) FindDuplicatesChecked(products []Product, threshold float64) (_ []ComparisonResult, err error) {
+	defer e.guard("FindDuplicatesChecked", &err)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkScanLimits(resolved, threshold); err != nil {
+		return nil, err
+	}
+	return e.findDuplicatesUnchecked(context.Background(), productPtrs(resolved), threshold), nil
 }
 
-// findDuplicatesSequential is the original sequential implementation
-func (e *LevenshteinEngine) findDuplicatesSequential(products []Product, threshold float64) []ComparisonResult {
-	duplicates := make([]ComparisonResult, 0, len(products)/10) // Pre-allocate with estimate
+// FindDuplicatesCtx is FindDuplicatesChecked passing ctx to the lazy
+// description loader (see WithLazyDescriptionLoader)
+// Once ctx is cancelled no further descriptions are loaded: the scan finishes
+// with the pairs still needing one flagged DescriptionLoadFailed, and returns
+// its results with ctx.Err().
+func (e *LevenshteinEngine) FindDuplicatesCtx(ctx context.Context, products []Product, threshold float64) (_ []ComparisonResult, err error) {
+	defer e.guard("FindDuplicatesCtx", &err)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkScanLimits(resolved, threshold); err != nil {
+		return nil, err
+	}
+	duplicates := e.findDuplicatesUnchecked(ctx, productPtrs(resolved), threshold)
+	return duplicates, ctx.Err()
+}
 
-	// Compare each product with every other product (once)
-	for i := 0; i < len(products); i++ {
-		for j := i + 1; j < len(products); j++ {
-			result := e.Compare(products[i], products[j])
+// FindDuplicatesPtr is FindDuplicates over product pointers
+// Products are never copied during the scan, and each product's normalization
+// is cached on the product itself for the next call
+func (e *LevenshteinEngine) FindDuplicatesPtr(products []*Product, threshold float64) []ComparisonResult {
+	defer e.guard("FindDuplicatesPtr", nil)
+	resolved, err := resolveInputPtrs(products, e.idPolicy, e.keepInputCopies)
+	if err != nil {
+		return nil
+	}
+	if e.logger != nil && e.scanLimits != (ScanLimits{}) {
+		values := make([]Product, len(resolved))
+		for i, p := range resolved {
+			values[i] = copyProductFields(p)
+		}
+		e.warnScanLimits(values, threshold)
+	}
+	return e.findDuplicatesUnchecked(context.Background(), resolved, threshold)
+}
 
-			// If similarity meets or exceeds threshold, it's a potential duplicate
-			if result.Similarity >= threshold {
-				duplicates = append(duplicates, result)
+// findDuplicatesUnchecked picks the sequential or parallel scan without validating IDs
+// ctx is passed to the lazy description loader.
+func (e *LevenshteinEngine) findDuplicatesUnchecked(ctx context.Context, products []*Product, threshold float64) []ComparisonResult {
+	var duplicates []ComparisonResult
+	if e.shaping.KeepOnlyBestPerProduct {
+		duplicates = e.variantOutput(e.findBestPairs(ctx, products, threshold))
+	} else {
+		duplicates = e.variantOutput(e.findPairs(ctx, products, threshold))
+	}
+	e.writeVerificationSample(products, threshold)
+	return duplicates
+}
+
+// findPairs is findDuplicatesUnchecked returning variants whatever
+// VariantOptions.Output says
+func (e *LevenshteinEngine) findPairs(ctx context.Context, products []*Product, threshold float64) []ComparisonResult {
+	duplicates := []ComparisonResult{}
+	e.streamScan(ctx, nil, products, threshold, nil, func(result ComparisonResult) bool {
+		duplicates = append(duplicates, result)
+		return true
+	})
+	return duplicates
+}
+
+// streamScan is findPairs passing each match to yield as it is found, on the
+// calling goroutine; the scan stops early when yield returns false or done
+// is closed (nil = never), and with best, pairs no product can keep are
+// dropped before reaching yield (nil = keep every pair)
+// Low-quality products are filtered and flagged here, like every scan does.
+func (e *LevenshteinEngine) streamScan(ctx context.Context, done <-chan struct{}, products []*Product, threshold float64, best *bestPerProduct, yield func(ComparisonResult) bool) {
+	if quality := e.newQualityCheck(); quality != nil {
+		products = quality.products(products)
+		matched := yield
+		yield = func(result ComparisonResult) bool {
+			if !quality.flag(&result) && quality.mode == QualityExclude {
+				return true
 			}
+			return matched(result)
 		}
 	}
+	if len(products) < 2 {
+		return
+	}
+	parallel := len(products) > 50
+	if e.logger != nil {
+		e.streamLogged(ctx, done, products, threshold, parallel, best, yield)
+		return
+	}
+	e.streamDuplicates(ctx, done, products, threshold, parallel, best, yield)
+}
+
+// streamLogged is streamDuplicates with scan events
+func (e *LevenshteinEngine) streamLogged(ctx context.Context, done <-chan struct{}, products []*Product, threshold float64, parallel bool, best *bestPerProduct, yield func(ComparisonResult) bool) {
+	path := "sequential"
+	if parallel {
+		path = "parallel"
+	}
+	logScanStarted(e.logger, "levenshtein", path, len(products), threshold)
+	started, rejectedBefore, prunedBefore := time.Now(), e.rabinKarpRejections(), atomic.LoadUint64(&e.lengthPruned)
+	constrainedBefore, charsetBefore := e.constraintSkips(), atomic.LoadUint64(&e.charsetPruned)
+
+	matches := 0
+	e.streamDuplicates(ctx, done, products, threshold, parallel, best, func(result ComparisonResult) bool {
+		matches++
+		return yield(result)
+	})
+
+	if e.IsRabinKarpEnabled() {
+		logPreFilterSummary(e.logger, "levenshtein", "rabin-karp", e.rabinKarpRejections()-rejectedBefore)
+	}
+	if e.IsLengthPruningEnabled() {
+		logPreFilterSummary(e.logger, "levenshtein", "length-window", atomic.LoadUint64(&e.lengthPruned)-prunedBefore)
+	}
+	if e.IsCharsetPruningEnabled() {
+		logPreFilterSummary(e.logger, "levenshtein", "charset", atomic.LoadUint64(&e.charsetPruned)-charsetBefore)
+	}
+	if e.pairConstraint != nil {
+		logPreFilterSummary(e.logger, "levenshtein", "pair-constraint", e.constraintSkips()-constrainedBefore)
+	}
+	logScanFinished(e.logger, "levenshtein", matches, started)
+}
 
+// findDuplicatesSequential is the original sequential implementation
+func (e *LevenshteinEngine) findDuplicatesSequential(ctx context.Context, products []*Product, threshold float64) []ComparisonResult {
+	duplicates := make([]ComparisonResult, 0, len(products)/10) // Pre-allocate with estimate
+	e.streamDuplicates(ctx, nil, products, threshold, false, nil, func(result ComparisonResult) bool {
+		duplicates = append(duplicates, result)
+		return true
+	})
 	return duplicates
 }
 
@@ -529,66 +1137,122 @@ func (e *LevenshteinEngine) findDuplicatesSequential(products []Product, thresho
 // across multiple CPU cores for better performance on large datasets.
 // Uses adaptive worker pool sizing based on dataset size and CPU count.
 func (e *LevenshteinEngine) FindDuplicatesParallel(products []Product, threshold float64) []ComparisonResult {
-	numProducts := len(products)
-	if numProducts < 2 {
-		return nil
-	}
+	defer e.guard("FindDuplicatesParallel", nil)
+	return e.findDuplicatesParallel(context.Background(), productPtrs(products), threshold)
+}
 
-	// Use adaptive worker pool sizing based on dataset characteristics
-	numWorkers := getOptimalWorkerCount(numProducts)
-	if numWorkers > numProducts {
-		numWorkers = numProducts
-	}
+// findDuplicatesParallel is FindDuplicatesParallel over product pointers
+// Workers receive pair indices, so no product is copied per comparison
+func (e *LevenshteinEngine) findDuplicatesParallel(ctx context.Context, products []*Product, threshold float64) []ComparisonResult {
+	duplicates := make([]ComparisonResult, 0, len(products)/10)
+	e.streamDuplicates(ctx, nil, products, threshold, true, nil, func(result ComparisonResult) bool {
+		duplicates = append(duplicates, result)
+		return true
+	})
+	return duplicates
+}
 
-	// Channel for work distribution
-	type workItem struct {
-		i, j int
+// streamDuplicates compares each product with every other product (once),
+// skipping pairs whose name lengths rule out a match, and passes the pairs
+// meeting threshold, and kept by best (see streamScan), to yield; see
+// streamPairsWithin for done
+// The parallel scan builds every cache up front so workers only read them.
+func (e *LevenshteinEngine) streamDuplicates(ctx context.Context, done <-chan struct{}, products []*Product, threshold float64, parallel bool, best *bestPerProduct, yield func(ComparisonResult) bool) {
+	if len(products) < 2 {
+		return
 	}
-	workChan := make(chan workItem, numWorkers*2)
-	resultChan := make(chan ComparisonResult, numWorkers*2)
-
-	// Start worker goroutines
-	var wg sync.WaitGroup
-	for w := 0; w < numWorkers; w++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for work := range workChan {
-				result := e.Compare(products[work.i], products[work.j])
-				if result.Similarity >= threshold {
-					resultChan <- result
-				}
-			}
-		}()
+	if parallel {
+		warmCaches(products, e.preparer())
 	}
-
-	// Send work items
-	go func() {
-		for i := 0; i < numProducts; i++ {
-			for j := i + 1; j < numProducts; j++ {
-				workChan <- workItem{i, j}
-			}
+	memo := e.newScanMemo(products)
+	loads := e.newDescriptionLoads(ctx)
+	window := e.newLengthWindow(products, threshold)
+	audit := e.startAudit()
+	defer audit.finish()
+
+	evaluate := func(i, j int, scratch *comparisonScratch) (ComparisonResult, bool) {
+		if !e.pairAllowed(products[i], products[j]) {
+			return ComparisonResult{}, false
 		}
-		close(workChan)
-	}()
-
-	// Collect results in separate goroutine
-	// Use pointer to slice so appends are visible outside the goroutine
-	duplicates := make([]ComparisonResult, 0, numProducts/10)
-	done := make(chan struct{})
-	go func() {
-		for result := range resultChan {
-			duplicates = append(duplicates, result)
+		result, ok := e.compareScanPair(products, i, j, memo, scratch, loads, threshold)
+		if !ok {
+			return ComparisonResult{}, false
 		}
-		close(done)
-	}()
 
-	// Wait for all workers to finish
-	wg.Wait()
-	close(resultChan)
+		// If similarity meets or exceeds threshold, it's a potential duplicate
+		result.stampThreshold(threshold)
+		audit.record(&result)
+		return result, result.MeetsThreshold && best.offer(result.ProductA.ID, result.ProductB.ID, result.CombinedSimilarity)
+	}
+	if fail := scanFailure(ctx); fail != nil && parallel {
+		// A worker's panic cancels the scan at once (see FindDuplicatesChan)
+		evaluate = recoverPairs(evaluate, fail)
+	}
+	streamPairsWithin(len(products), e.scanWorkers(len(products), parallel), window, done, evaluate, yield)
+	e.recordScan(window, len(products))
+}
 
-	// Wait for result collection to finish
-	<-done
+// scanPairs evaluates every pair (i < j) of n items and collects the results
+// for which evaluate returns true.
+// With more than one worker, pairs are distributed over a worker pool (size
+// it with scanWorkers); result order is then nondeterministic.
+func scanPairs(n int, workers int, evaluate func(i, j int) (ComparisonResult, bool)) []ComparisonResult {
+	return scanPairsWithin(n, workers, nil, func(i, j int, _ *comparisonScratch) (ComparisonResult, bool) {
+		return evaluate(i, j)
+	})
+}
 
+// scanPairsWithin is scanPairs over the pairs admitted by window (nil = every
+// pair), passing evaluate the scratch buffers of the worker running it
+func scanPairsWithin(n int, workers int, window *lengthWindow, evaluate func(i, j int, scratch *comparisonScratch) (ComparisonResult, bool)) []ComparisonResult {
+	duplicates := make([]ComparisonResult, 0, n/10) // Pre-allocate with estimate
+	streamPairsWithin(n, workers, window, nil, evaluate, func(result ComparisonResult) bool {
+		duplicates = append(duplicates, result)
+		return true
+	})
 	return duplicates
 }
+
+// streamPairs is scanPairs without collection: every kept result is passed to
+// yield as soon as it is found. yield runs on a single goroutine, so it needs
+// no locking; returning false stops the scan early.
+func streamPairs(n int, workers int, evaluate func(i, j int) (ComparisonResult, bool), yield func(ComparisonResult) bool) {
+	streamPairsWithin(n, workers, nil, nil, func(i, j int, _ *comparisonScratch) (ComparisonResult, bool) {
+		return evaluate(i, j)
+	}, yield)
+}
+
+// streamPairsWithin is streamPairs over the pairs admitted by window (nil =
+// every pair), passing evaluate the scratch buffers of the worker running it
+// Pairs run through runWorkerStages on at most n workers; each worker, or the
+// one sequential loop, owns one comparisonScratch for the whole scan. Closing
+// done (nil = never) stops generating pairs, so a cancelled scan ends without
+// a match to refuse.
+func streamPairsWithin(n int, workers int, window *lengthWindow, done <-chan struct{}, evaluate func(i, j int, scratch *comparisonScratch) (ComparisonResult, bool), yield func(ComparisonResult) bool) {
+	if workers > n {
+		workers = n
+	}
+	generate := func(visit func(pairIndexes) bool) {
+		window.eachPair(n, func(i, j int) bool {
+			if done != nil {
+				select {
+				case <-done:
+					return false
+				default:
+				}
+			}
+			return visit(pairIndexes{i, j})
+		})
+	}
+	runWorkerStages(workers, generate, func() func(pairIndexes) (ComparisonResult, bool) {
+		scratch := &comparisonScratch{}
+		return func(pair pairIndexes) (ComparisonResult, bool) {
+			return evaluate(pair.i, pair.j, scratch)
+		}
+	}, yield)
+}
+
+// pairIndexes is a pair of a scan, as indexes into its products
+type pairIndexes struct {
+	i, j int
+}
@@ -1,7 +1,11 @@
 package duplicatecheck
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
 )
 
 func TestLevenshteinDistance(t *testing.T) {
@@ -338,6 +342,84 @@ func TestLevenshteinGetName(t *testing.T) {
 }
 
 // Benchmark the distance computation for different string lengths
+func TestFindDuplicatesPtr(t *testing.T) {
+	pairSet := func(results []ComparisonResult) map[string]float64 {
+		set := make(map[string]float64, len(results))
+		for _, r := range results {
+			set[makePairKey(r.ProductA.ID, r.ProductB.ID)] = r.CombinedSimilarity
+		}
+		return set
+	}
+
+	engines := []struct {
+		name   string
+		engine interface {
+			DuplicateCheckEngine
+			PointerEngine
+		}
+	}{
+		{"Levenshtein", NewLevenshteinEngine()},
+		{"Hybrid", NewHybridEngine()},
+	}
+
+	for _, tt := range engines {
+		for _, size := range []int{20, 120} { // Sequential and parallel scans
+			t.Run(fmt.Sprintf("%s/%d products", tt.name, size), func(t *testing.T) {
+				products := make([]Product, size)
+				for i := range products {
+					// Consecutive products share their text, so every engine finds them
+					products[i] = Product{
+						ID:          fmt.Sprintf("p%03d", i),
+						Name:        fmt.Sprintf("Wireless Headphones Model %d", i/2),
+						Description: fmt.Sprintf("Noise cancelling over-ear headphones, series %d", i/2),
+					}
+				}
+				if hybrid, ok := tt.engine.(*HybridEngine); ok {
+					if err := hybrid.BuildIndex(products); err != nil {
+						t.Fatalf("BuildIndex failed: %v", err)
+					}
+				}
+
+				want := pairSet(tt.engine.FindDuplicates(products, 0.7))
+				got := pairSet(tt.engine.FindDuplicatesPtr(productPtrs(products), 0.7))
+				if len(want) == 0 || len(got) != len(want) {
+					t.Fatalf("FindDuplicatesPtr found %d pairs, FindDuplicates %d", len(got), len(want))
+				}
+				for key, similarity := range want {
+					if got[key] != similarity {
+						t.Errorf("Pair %s: %.4f vs %.4f", key, got[key], similarity)
+					}
+				}
+
+				a, b := &products[0], &products[1]
+				if !reflect.DeepEqual(tt.engine.ComparePtr(a, b), tt.engine.Compare(*a, *b)) {
+					t.Error("ComparePtr should match Compare")
+				}
+			})
+		}
+	}
+
+	t.Run("Duplicate IDs", func(t *testing.T) {
+		products := []*Product{
+			{ID: "1", Name: "Apple iPhone 14"},
+			{ID: "1", Name: "Apple iPhone 14s"},
+		}
+		engine := NewLevenshteinEngine()
+		if results := engine.FindDuplicatesPtr(products, 0.8); results != nil {
+			t.Errorf("Expected nil under DuplicateIDReject, got %d results", len(results))
+		}
+
+		engine.SetDuplicateIDPolicy(DuplicateIDSuffix)
+		results := engine.FindDuplicatesPtr(products, 0.8)
+		if len(results) != 1 || results[0].ProductB.ID != "1#2" {
+			t.Fatalf("Expected one suffixed pair, got %+v", results)
+		}
+		if products[1].ID != "1" {
+			t.Error("FindDuplicatesPtr should not rewrite the caller's products")
+		}
+	})
+}
+
 func BenchmarkLevenshteinDistance(b *testing.B) {
 	engine := NewLevenshteinEngine()
 
@@ -377,15 +459,11 @@ func BenchmarkLevenshteinDistance(b *testing.B) {
 func BenchmarkLevenshteinFindDuplicates(b *testing.B) {
 	engine := NewLevenshteinEngine()
 
-	// Generate test products
+	// Generate test products (the gen package's catalog)
 	generateProducts := func(n int) []Product {
-		products := make([]Product, n)
-		for i := 0; i < n; i++ {
-			products[i] = Product{
-				ID:   string(rune(i)),
-				Name: "Sample Product Name Number " + string(rune(i+'0')),
-			}
-		}
+		cfg := synth.Default()
+		cfg.Size = n
+		products, _ := generatedCatalog(cfg)
 		return products
 	}
 
@@ -469,3 +547,76 @@ func BenchmarkLevenshteinLongDescriptions(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkFindDuplicatesPtr measures the 1000-product long-description scan
+// "Copy per pair" reproduces the value-based scan this replaced: every
+// comparison received fresh copies of both products and normalized them again
+func BenchmarkFindDuplicatesPtr(b *testing.B) {
+	engine := NewLevenshteinEngine()
+	products := generateUserArticles(1000)
+	ptrs := productPtrs(products)
+
+	b.Run("Copy per pair", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scanPairs(len(products), engine.scanWorkers(len(products), true), func(i, j int) (ComparisonResult, bool) {
+				result := engine.Compare(copyProductFields(&products[i]), copyProductFields(&products[j]))
+				result.stampThreshold(0.85)
+				return result, result.MeetsThreshold
+			})
+		}
+	})
+
+	b.Run("FindDuplicates", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			engine.FindDuplicates(products, 0.85)
+		}
+	})
+
+	b.Run("FindDuplicatesPtr", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			engine.FindDuplicatesPtr(ptrs, 0.85)
+		}
+	})
+}
+
+func TestDescriptionSkipThreshold(t *testing.T) {
+	// Dissimilar names, identical descriptions: with a 0.35 description weight
+	// a perfect description match stays under the old fixed 0.60 bound, yet
+	// reaches a threshold of 0.4
+	a := Product{ID: "a", Name: "Red cotton shirt", Description: "Machine washable, made in Portugal, sizes S to XL"}
+	b := Product{ID: "b", Name: "Blue denim jacket", Description: a.Description}
+	weights := ComparisonWeights{NameWeight: 0.65, DescriptionWeight: 0.35}
+
+	engine := NewLevenshteinEngineWithWeights(weights)
+	result := engine.Compare(a, b)
+	if result.DescriptionSimilarity != 1 {
+		t.Errorf("Compare skipped the descriptions: DescriptionSimilarity = %v", result.DescriptionSimilarity)
+	}
+	if result.CombinedSimilarity < 0.4 || result.CombinedSimilarity >= 0.6 {
+		t.Fatalf("CombinedSimilarity = %v, want in [0.4, 0.6) for this case", result.CombinedSimilarity)
+	}
+	if found := engine.FindDuplicates([]Product{a, b}, 0.4); len(found) != 1 {
+		t.Errorf("FindDuplicates at 0.4 found %d pairs, want 1", len(found))
+	}
+}
+
+func TestDescriptionSkipPreservesResults(t *testing.T) {
+	cfg := synth.Default()
+	cfg.Seed = 11
+	cfg.Size = 60
+	catalog, _ := generatedCatalog(cfg)
+
+	for nameWeight := 0.1; nameWeight < 1; nameWeight += 0.2 {
+		weights := ComparisonWeights{NameWeight: nameWeight, DescriptionWeight: 1 - nameWeight}
+		skipping, comparing := NewLevenshteinEngineWithWeights(weights), NewLevenshteinEngineWithWeights(weights)
+		comparing.DisableDescriptionSkip()
+		for threshold := 0.3; threshold < 1; threshold += 0.2 {
+			got := comparableResults(skipping.FindDuplicates(catalog, threshold))
+			want := comparableResults(comparing.FindDuplicates(catalog, threshold))
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("weights %.2f/%.2f, threshold %.2f: %d pairs with the skip, %d without",
+					weights.NameWeight, weights.DescriptionWeight, threshold, len(got), len(want))
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package duplicatecheck
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCandidateWarnThreshold is the per-query LSH candidate count above
+// which a hybrid engine with a logger emits a warning
+const DefaultCandidateWarnThreshold = 1000
+
+// WithLogger sets the logger for engine events; nil (the default) disables logging
+// Scans log their path and a pre-filter summary at Debug, interrupted scans at
+// Warn. With logging disabled no event is built. Returns the engine for chaining.
+func (e *LevenshteinEngine) WithLogger(logger *slog.Logger) *LevenshteinEngine {
+	e.logger = logger
+	return e
+}
+
+// WithLogger sets the logger for engine events; nil (the default) disables logging
+// Index builds log at Info; fallbacks to a full scan and queries with more than
+// HybridConfig.CandidateWarnThreshold candidates at Warn; scan paths and
+// pre-filter summaries at Debug. The internal Levenshtein engine logs to the
+// same logger. Returns the engine for chaining.
+func (e *HybridEngine) WithLogger(logger *slog.Logger) *HybridEngine {
+	e.logger = logger
+	e.levenshteinEngine.logger = logger
+	return e
+}
+
+// logScanStarted records which scan path a FindDuplicates call took
+func logScanStarted(logger *slog.Logger, engine, path string, products int, threshold float64) {
+	logger.LogAttrs(context.Background(), slog.LevelDebug, "scan started",
+		slog.String("engine", engine),
+		slog.String("path", path),
+		slog.Int("products", products),
+		slog.Float64("threshold", threshold))
+}
+
+// logScanFinished records the outcome of a FindDuplicates call
+func logScanFinished(logger *slog.Logger, engine string, duplicates int, started time.Time) {
+	logger.LogAttrs(context.Background(), slog.LevelDebug, "scan finished",
+		slog.String("engine", engine),
+		slog.Int("duplicates", duplicates),
+		slog.Duration("duration", time.Since(started)))
+}
+
+// logPreFilterSummary records how many comparisons a pre-filter rejected during a scan
+func logPreFilterSummary(logger *slog.Logger, engine, filter string, rejected uint64) {
+	logger.LogAttrs(context.Background(), slog.LevelDebug, "prefilter summary",
+		slog.String("engine", engine),
+		slog.String("filter", filter),
+		slog.Uint64("rejected", rejected))
+}
+
+// logScanInterrupted records a scan stopped by its context
+func logScanInterrupted(logger *slog.Logger, engine string, err error, verified int) {
+	logger.LogAttrs(context.Background(), slog.LevelWarn, "scan interrupted",
+		slog.String("engine", engine),
+		slog.String("reason", err.Error()),
+		slog.Int("duplicates_so_far", verified))
+}
+
+// rabinKarpRejections returns the running count of Rabin-Karp rejections
+func (e *LevenshteinEngine) rabinKarpRejections() uint64 {
+	return atomic.LoadUint64(&e.rabinKarpRejected)
+}
@@ -0,0 +1,183 @@
+package duplicatecheck
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// capturedRecord is one log event seen by captureHandler
+type capturedRecord struct {
+	level   slog.Level
+	message string
+	attrs   map[string]slog.Value
+}
+
+// captureHandler records every event at Debug and above
+type captureHandler struct {
+	mu      sync.Mutex
+	records []capturedRecord
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	record := capturedRecord{level: r.Level, message: r.Message, attrs: make(map[string]slog.Value)}
+	r.Attrs(func(a slog.Attr) bool {
+		record.attrs[a.Key] = a.Value
+		return true
+	})
+	h.mu.Lock()
+	h.records = append(h.records, record)
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler      { return h }
+
+// find returns the first event with the given message
+func (h *captureHandler) find(message string) (capturedRecord, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, record := range h.records {
+		if record.message == message {
+			return record, true
+		}
+	}
+	return capturedRecord{}, false
+}
+
+func TestLogging(t *testing.T) {
+	catalog := bestEffortCatalog(60)
+
+	t.Run("indexed hybrid scan", func(t *testing.T) {
+		config := DefaultHybridConfig()
+		config.CandidateWarnThreshold = 1
+		config.ExactModeCutoff = 0 // Scan through LSH
+		handler := &captureHandler{}
+		engine := NewHybridEngineWithConfig(config).WithLogger(slog.New(handler))
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatalf("BuildIndex: %v", err)
+		}
+		engine.FindDuplicates(catalog, 0.85)
+
+		tests := []struct {
+			message string
+			level   slog.Level
+			attr    string
+			want    string
+		}{
+			{"index build started", slog.LevelInfo, "engine", "hybrid"},
+			{"index build finished", slog.LevelInfo, "engine", "hybrid"},
+			{"scan started", slog.LevelDebug, "path", "indexed"},
+			{"scan finished", slog.LevelDebug, "engine", "hybrid"},
+			{"too many candidates", slog.LevelWarn, "engine", "hybrid"},
+		}
+		for _, tt := range tests {
+			record, ok := handler.find(tt.message)
+			if !ok {
+				t.Errorf("missing %q event", tt.message)
+				continue
+			}
+			if record.level != tt.level {
+				t.Errorf("%q logged at %v, want %v", tt.message, record.level, tt.level)
+			}
+			if got := record.attrs[tt.attr].String(); got != tt.want {
+				t.Errorf("%q %s = %q, want %q", tt.message, tt.attr, got, tt.want)
+			}
+		}
+		if record, ok := handler.find("index build finished"); ok && record.attrs["products"].Int64() != int64(len(catalog)) {
+			t.Errorf("index build finished products = %v, want %d", record.attrs["products"], len(catalog))
+		}
+	})
+
+	t.Run("default candidate threshold is quiet", func(t *testing.T) {
+		handler := &captureHandler{}
+		engine := NewHybridEngine().WithLogger(slog.New(handler))
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatalf("BuildIndex: %v", err)
+		}
+		engine.FindDuplicates(catalog, 0.85)
+		if _, ok := handler.find("too many candidates"); ok {
+			t.Errorf("unexpected candidate warning below DefaultCandidateWarnThreshold")
+		}
+	})
+
+	t.Run("unindexed fallback", func(t *testing.T) {
+		handler := &captureHandler{}
+		engine := NewHybridEngine().WithLogger(slog.New(handler))
+		engine.FindDuplicates(catalog, 0.85)
+
+		record, ok := handler.find("index not built, falling back to full scan")
+		if !ok {
+			t.Fatalf("missing fallback warning")
+		}
+		if record.level != slog.LevelWarn {
+			t.Errorf("fallback logged at %v, want WARN", record.level)
+		}
+		if started, ok := handler.find("scan started"); !ok || started.attrs["path"].String() != "parallel" {
+			t.Errorf("scan started = %+v, want levenshtein parallel path", started)
+		}
+	})
+
+	t.Run("pre-filter summary", func(t *testing.T) {
+		handler := &captureHandler{}
+		engine := NewLevenshteinEngine().WithLogger(slog.New(handler))
+		engine.EnableRabinKarpFilter()
+		engine.FindDuplicates(catalog[:10], 0.85)
+
+		record, ok := handler.find("prefilter summary")
+		if !ok {
+			t.Fatalf("missing prefilter summary")
+		}
+		if got := record.attrs["filter"].String(); got != "rabin-karp" {
+			t.Errorf("filter = %q, want rabin-karp", got)
+		}
+		if started, ok := handler.find("scan started"); !ok || started.attrs["path"].String() != "sequential" {
+			t.Errorf("scan started = %+v, want sequential path", started)
+		}
+	})
+
+	t.Run("interrupted best-effort scan", func(t *testing.T) {
+		for _, name := range []string{"levenshtein", "hybrid"} {
+			handler := &captureHandler{}
+			logger := slog.New(handler)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			if name == "levenshtein" {
+				NewLevenshteinEngine().WithLogger(logger).FindDuplicatesBestEffort(ctx, catalog, 0.85)
+			} else {
+				engine := NewHybridEngine().WithLogger(logger)
+				if err := engine.BuildIndex(catalog); err != nil {
+					t.Fatalf("BuildIndex: %v", err)
+				}
+				engine.FindDuplicatesBestEffort(ctx, catalog, 0.85)
+			}
+
+			record, ok := handler.find("scan interrupted")
+			if !ok {
+				t.Errorf("%s: missing scan interrupted event", name)
+				continue
+			}
+			if got := record.attrs["reason"].String(); got != context.Canceled.Error() {
+				t.Errorf("%s: reason = %q, want %q", name, got, context.Canceled.Error())
+			}
+			if got := record.attrs["engine"].String(); got != name {
+				t.Errorf("%s: engine = %q", name, got)
+			}
+		}
+	})
+
+	t.Run("no logger", func(t *testing.T) {
+		engine := NewHybridEngine()
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatalf("BuildIndex: %v", err)
+		}
+		if got := engine.FindDuplicates(catalog, 0.85); len(got) == 0 {
+			t.Errorf("FindDuplicates without a logger found nothing")
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package duplicatecheck
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DefaultLowInfoMinAlphanumeric is the default LowInfoNameConfig.MinAlphanumeric
+	DefaultLowInfoMinAlphanumeric = 3
+	// DefaultLowInfoMaxNameShare is the default LowInfoNameConfig.MaxNameShare
+	DefaultLowInfoMaxNameShare = 0.05
+	// DefaultLowInfoMinNameRepeats is the default LowInfoNameConfig.MinNameRepeats
+	DefaultLowInfoMinNameRepeats = 20
+)
+
+// defaultLowInfoStopWords are words classified-ads titles are made of when
+// they say nothing about the product
+var defaultLowInfoStopWords = []string{
+	"a", "amazing", "an", "and", "bargain", "best", "buy", "cheap", "check", "deal", "deals",
+	"for", "free", "good", "great", "hot", "in", "it", "look", "must", "nice", "now", "obo",
+	"of", "offer", "on", "only", "or", "price", "sale", "see", "sell", "selling", "stuff",
+	"the", "this", "to", "today", "urgent", "with", "wow",
+}
+
+// LowInfoNameConfig configures detecting names that carry no product
+// information, such as "Great deal!!" or "Must see", so pairs of them are
+// compared by description (see WithLowInfoNames)
+// The defaults are conservative: a name with any word outside StopWords, or
+// one a handful of products share, is never flagged.
+type LowInfoNameConfig struct {
+	// MinAlphanumeric flags names with fewer letters and digits than this
+	// (0 = DefaultLowInfoMinAlphanumeric; negative = off)
+	MinAlphanumeric int
+	// StopWords flags names made only of these words, single letters,
+	// punctuation and symbols such as emoji (nil = DefaultLowInfoStopWords();
+	// empty = off). Matched against the prepared, lowercased name.
+	StopWords []string
+	// MaxNameShare flags, in scans, a name shared by more than this fraction
+	// of the products (0 = DefaultLowInfoMaxNameShare; negative = off)
+	MaxNameShare float64
+	// MinNameRepeats is how many products must share a name for MaxNameShare
+	// to flag it (0 = DefaultLowInfoMinNameRepeats)
+	MinNameRepeats int
+}
+
+// DefaultLowInfoStopWords returns the default LowInfoNameConfig.StopWords,
+// English classified-ads filler ("great", "deal", "must", "see")
+// The result is a fresh slice, so callers can extend it freely.
+func DefaultLowInfoStopWords() []string {
+	return append([]string(nil), defaultLowInfoStopWords...)
+}
+
+// lowInfoNames is a LowInfoNameConfig prepared for matching
+type lowInfoNames struct {
+	config    LowInfoNameConfig // Defaults filled in
+	stopWords map[string]bool
+}
+
+// WithLowInfoNames makes comparisons weigh only the descriptions of pairs
+// whose names both carry no product information; nil turns it off (the
+// default). Returns the engine for chaining.
+// A name is low-information when it is very short, made only of stop words,
+// punctuation and emoji, or (in scans) shared by a large fraction of the
+// products; see LowInfoNameConfig. Such a pair is compared with
+// NameWeight 0 and DescriptionWeight 1, recorded in WeightsUsed, and flagged
+// LowInfoNames. A pair where only one name is low-information keeps its
+// weights. Under CrossLanguageNameOnly, pairs whose descriptions are in
+// different languages stay name-only.
+func (e *LevenshteinEngine) WithLowInfoNames(config *LowInfoNameConfig) *LevenshteinEngine {
+	if config == nil {
+		e.lowInfo = nil
+		return e
+	}
+	c := *config
+	if c.MinAlphanumeric == 0 {
+		c.MinAlphanumeric = DefaultLowInfoMinAlphanumeric
+	}
+	if c.StopWords == nil {
+		c.StopWords = DefaultLowInfoStopWords()
+	}
+	if c.MaxNameShare == 0 {
+		c.MaxNameShare = DefaultLowInfoMaxNameShare
+	}
+	if c.MinNameRepeats <= 0 {
+		c.MinNameRepeats = DefaultLowInfoMinNameRepeats
+	}
+	c.StopWords = append([]string(nil), c.StopWords...)
+	stopWords := make(map[string]bool, len(c.StopWords))
+	for _, word := range c.StopWords {
+		stopWords[strings.ToLower(word)] = true
+	}
+	e.lowInfo = &lowInfoNames{config: c, stopWords: stopWords}
+	return e
+}
+
+// WithLowInfoNames compares pairs of low-information names by description
+// (see LevenshteinEngine.WithLowInfoNames), and indexes products with a
+// low-information name by their description alone
+// Only the name's own text decides what is indexed: the catalog share rule
+// applies to LevenshteinEngine scans. Any built index is discarded. Returns
+// the engine for chaining.
+func (e *HybridEngine) WithLowInfoNames(config *LowInfoNameConfig) *HybridEngine {
+	e.levenshteinEngine.WithLowInfoNames(config)
+	e.resetIndex()
+	return e
+}
+
+// text reports whether a prepared name is low-information by its text alone:
+// too few letters and digits, or no word outside the stop words
+func (l *lowInfoNames) text(name string) bool {
+	if l == nil {
+		return false
+	}
+	alphanumeric := 0
+	content := false
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := len([]rune(word))
+		alphanumeric += runes
+		if runes > 1 && !l.stopWords[word] {
+			content = true
+		}
+	}
+	if l.config.MinAlphanumeric > 0 && alphanumeric < l.config.MinAlphanumeric {
+		return true
+	}
+	return len(l.stopWords) > 0 && !content
+}
+
+// scan flags the low-information names of a scan's products, by index
+// A name is flagged by its text, or when at least MinNameRepeats and more
+// than MaxNameShare of the products share it.
+func (l *lowInfoNames) scan(products []*Product, prep *textPreparer) []bool {
+	names := make([]string, len(products))
+	counts := make(map[string]int)
+	for i, p := range products {
+		names[i], _ = p.preparedStrings(prep)
+		counts[names[i]]++
+	}
+	common := float64(len(products)) * l.config.MaxNameShare
+	flags := make([]bool, len(products))
+	for i, name := range names {
+		count := counts[name]
+		flags[i] = l.text(name) ||
+			(l.config.MaxNameShare > 0 && count >= l.config.MinNameRepeats && float64(count) > common)
+	}
+	return flags
+}
+
+// lowInfoPair reports whether both names of a pair are low-information,
+// using the scan's flags when pair comes from one
+func (e *LevenshteinEngine) lowInfoPair(nameA, nameB string, pair memoPair) bool {
+	if e.lowInfo == nil {
+		return false
+	}
+	if pair.memo != nil && pair.memo.lowInfo != nil {
+		return pair.memo.lowInfo[pair.i] && pair.memo.lowInfo[pair.j]
+	}
+	return e.lowInfo.text(nameA) && e.lowInfo.text(nameB)
+}
+
+// fingerprint identifies the text rules, for IndexConfigFingerprint
+func (l *lowInfoNames) fingerprint() uint64 {
+	fields := append([]string{"low-info-names/v1", strconv.Itoa(l.config.MinAlphanumeric)}, l.config.StopWords...)
+	return contentFingerprint(fields...)
+}
+
+// lowInfoIndexed reports whether the hybrid engine indexes a product with
+// this prepared name by its description alone (see WithLowInfoNames)
+func (e *HybridEngine) lowInfoIndexed(name string) bool {
+	return e.levenshteinEngine.lowInfo.text(name)
+}
+
+// lowInfoFingerprintParts returns the IndexConfigFingerprint parts of the
+// low-information name settings (none when off)
+func (e *HybridEngine) lowInfoFingerprintParts() []string {
+	if e.levenshteinEngine.lowInfo == nil {
+		return nil
+	}
+	return []string{"low-info-names", strconv.FormatUint(e.levenshteinEngine.lowInfo.fingerprint(), 16)}
+}
@@ -0,0 +1,155 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// classifiedsCatalog returns 150 classified ads with throwaway names and
+// random descriptions, 15 of them reposts: the same description, lightly
+// edited, under another throwaway name
+func classifiedsCatalog() (products []Product, reposts map[string]string) {
+	junk := []string{"Great deal!!", "Must see", "WOW 🔥🔥", "Cheap!!!", "Best price", "Look!!",
+		"Hot deal", "Nice stuff", "!!!", "Sale", "Buy now", "Free", "Great offer today", "OBO"}
+	words := strings.Fields("sofa bike stroller guitar desk lamp drill mower kayak tent " +
+		"oak leather barely used moving sale pickup only downtown works perfectly " +
+		"scratches box included original receipt smoke free home kids pets cash")
+	rng := rand.New(rand.NewSource(11))
+	sentence := func(n int) string {
+		picked := make([]string, n)
+		for i := range picked {
+			picked[i] = words[rng.Intn(len(words))]
+		}
+		return strings.Join(picked, " ")
+	}
+
+	reposts = make(map[string]string)
+	for i := 0; i < 135; i++ {
+		products = append(products, Product{
+			ID:          fmt.Sprintf("ad-%03d", i),
+			Name:        junk[rng.Intn(len(junk))],
+			Description: sentence(14) + ". " + sentence(12) + ".",
+		})
+		if i%9 == 0 {
+			original := products[len(products)-1]
+			name := junk[rng.Intn(len(junk))]
+			for name == original.Name {
+				name = junk[rng.Intn(len(junk))]
+			}
+			repost := Product{ID: original.ID + "-repost", Name: name, Description: original.Description + " Call now"}
+			products = append(products, repost)
+			reposts[original.ID] = repost.ID
+		}
+	}
+	return products, reposts
+}
+
+// repostRecall returns the fraction of reposts found among results
+func repostRecall(results []ComparisonResult, reposts map[string]string) float64 {
+	found := 0
+	for _, r := range results {
+		if reposts[r.ProductA.ID] == r.ProductB.ID || reposts[r.ProductB.ID] == r.ProductA.ID {
+			found++
+		}
+	}
+	return float64(found) / float64(len(reposts))
+}
+
+func TestLowInfoNamesText(t *testing.T) {
+	engine := NewLevenshteinEngine().WithLowInfoNames(&LowInfoNameConfig{})
+	tests := []struct {
+		name string
+		low  bool
+	}{
+		{"great deal!!", true},
+		{"must see", true},
+		{"wow 🔥🔥", true},
+		{"!!!", true},
+		{"ab", true},
+		{"l@@k!! cheap", true},
+		{"great deal on iphone 14", false},
+		{"sofa", false},
+		{"apple iphone 14 pro max 256gb silver", false},
+		{"nike air max 90", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := engine.lowInfo.text(tt.name); got != tt.low {
+				t.Errorf("text(%q) = %v, want %v", tt.name, got, tt.low)
+			}
+		})
+	}
+
+	// Overrides: no stop words, a higher length floor
+	custom := NewLevenshteinEngine().WithLowInfoNames(&LowInfoNameConfig{StopWords: []string{}, MinAlphanumeric: 5})
+	if custom.lowInfo.text("must see") || !custom.lowInfo.text("sofa") {
+		t.Error("custom config ignored")
+	}
+}
+
+func TestLowInfoNamesRecall(t *testing.T) {
+	products, reposts := classifiedsCatalog()
+
+	plain := NewLevenshteinEngine()
+	if recall := repostRecall(plain.FindDuplicates(products, 0.85), reposts); recall > 0.1 {
+		t.Errorf("recall without WithLowInfoNames: %.2f, want near 0", recall)
+	}
+
+	engine := NewLevenshteinEngine().WithLowInfoNames(&LowInfoNameConfig{})
+	results := engine.FindDuplicates(products, 0.85)
+	if recall := repostRecall(results, reposts); recall < 0.95 {
+		t.Errorf("recall with WithLowInfoNames: %.2f, want near 1", recall)
+	}
+	for _, r := range results {
+		if !r.LowInfoNames || r.WeightsUsed != (ComparisonWeights{NameWeight: 0, DescriptionWeight: 1}) {
+			t.Errorf("%s/%s: LowInfoNames %v, weights %+v", r.ProductA.ID, r.ProductB.ID, r.LowInfoNames, r.WeightsUsed)
+		}
+	}
+
+	hybrid := NewHybridEngine().WithLowInfoNames(&LowInfoNameConfig{})
+	if err := hybrid.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	if recall := repostRecall(hybrid.FindDuplicates(products, 0.85), reposts); recall < 0.9 {
+		t.Errorf("hybrid recall with WithLowInfoNames: %.2f, want near 1", recall)
+	}
+}
+
+func TestLowInfoNamesUnaffected(t *testing.T) {
+	catalogs := map[string][]Product{
+		"sample":    loadSampleCatalog(t),
+		"generated": GenerateTestCatalog(goldenCatalogSeed, 200),
+	}
+	for name, products := range catalogs {
+		t.Run(name, func(t *testing.T) {
+			engine := NewLevenshteinEngine().WithLowInfoNames(&LowInfoNameConfig{})
+			for i, low := range engine.lowInfo.scan(productPtrs(products), engine.preparer()) {
+				if low {
+					t.Errorf("%q flagged low-information", products[i].Name)
+				}
+			}
+			want := comparableResults(NewLevenshteinEngine().FindDuplicates(products, 0.8))
+			if got := comparableResults(engine.FindDuplicates(products, 0.8)); len(got) != len(want) {
+				t.Errorf("%d duplicates, %d without WithLowInfoNames", len(got), len(want))
+			}
+		})
+	}
+
+	// Repeated names: a name listed by most of a scan is flagged there only
+	repeated := make([]Product, 40)
+	for i := range repeated {
+		repeated[i] = Product{ID: fmt.Sprint(i), Name: "Gift Card", Description: fmt.Sprintf("Value %d", i)}
+	}
+	engine := NewLevenshteinEngine().WithLowInfoNames(&LowInfoNameConfig{})
+	if flags := engine.lowInfo.scan(productPtrs(repeated), engine.preparer()); !flags[0] {
+		t.Error("a name 40 of 40 products share isn't flagged")
+	}
+	if engine.Compare(repeated[0], repeated[1]).LowInfoNames {
+		t.Error("Compare flagged a single pair by catalog share")
+	}
+	if NewHybridEngine().IndexConfigFingerprint() == NewHybridEngine().WithLowInfoNames(&LowInfoNameConfig{}).IndexConfigFingerprint() {
+		t.Error("WithLowInfoNames left the index fingerprint unchanged")
+	}
+}
@@ -0,0 +1,295 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ProductProvider returns the current catalog for a ManagedHybridEngine to index
+type ProductProvider func(ctx context.Context) ([]Product, error)
+
+// ErrManagedEngineClosed is returned by RefreshNow after Close
+var ErrManagedEngineClosed = errors.New("duplicatecheck: managed engine closed")
+
+// RefreshPolicy decides when a ManagedHybridEngine rebuilds its index
+// Every Interval the provider is asked for the catalog; the index is rebuilt
+// if its product count moved by at least MinProductsDelta, or if it is older
+// than MaxStaleness. Edits that keep the count the same are therefore picked
+// up once the index goes stale.
+type RefreshPolicy struct {
+	// Interval between provider polls (default 5 minutes)
+	Interval time.Duration
+	// MinProductsDelta is the change in product count that triggers a rebuild
+	// (0 rebuilds on every poll)
+	MinProductsDelta int
+	// MaxStaleness rebuilds an index this old whatever the delta (0 = never forced)
+	MaxStaleness time.Duration
+}
+
+// DefaultRefreshPolicy polls every 5 minutes, rebuilding when a product was
+// added or removed, and at least hourly
+func DefaultRefreshPolicy() RefreshPolicy {
+	return RefreshPolicy{
+		Interval:         5 * time.Minute,
+		MinProductsDelta: 1,
+		MaxStaleness:     time.Hour,
+	}
+}
+
+// ManagedHybridConfig configures a ManagedHybridEngine
+type ManagedHybridConfig struct {
+	Policy RefreshPolicy
+	// NewEngine builds the engine each refresh indexes into (default
+	// NewHybridEngine), so weights, text preparation and filters carry over
+	NewEngine func() *HybridEngine
+	// Logger receives refresh events: rebuilds at Info, provider and build
+	// failures at Warn (nil disables logging)
+	Logger *slog.Logger
+}
+
+// ManagedStats counts a ManagedHybridEngine's refreshes, for metrics
+type ManagedStats struct {
+	Refreshes     uint64    // Indexes built and swapped in (including the initial one)
+	Skipped       uint64    // Polls the policy found no rebuild necessary for
+	Failures      uint64    // Polls whose provider or BuildIndex failed
+	LastError     error     // Error of the latest failure (nil if none yet)
+	LastRefreshed time.Time // When the serving index was swapped in
+	Products      int       // Products in the serving index
+}
+
+// ManagedHybridEngine serves queries from a HybridEngine whose index it keeps
+// fresh in the background
+// Each refresh builds a new engine off to the side and swaps it in atomically;
+// every query runs against one engine from start to finish, so queries in
+// flight during a swap see either the old index or the new one, never a mix.
+// When the provider or the build fails, the previous index keeps serving and
+// the failure is logged and counted in Stats.
+type ManagedHybridEngine struct {
+	provider  ProductProvider
+	policy    RefreshPolicy
+	newEngine func() *HybridEngine
+	logger    *slog.Logger
+	now       func() time.Time // Replaced in tests
+
+	engine    atomic.Pointer[HybridEngine]
+	refreshMu sync.Mutex // Serializes refreshes
+	statsMu   sync.Mutex // Guards stats
+	stats     ManagedStats
+
+	ctx       context.Context // Cancelled by Close, aborting provider calls
+	cancel    context.CancelFunc
+	done      chan struct{} // Closed when the refresh goroutine exits
+	closeOnce sync.Once
+}
+
+// NewManagedHybridEngine indexes the provider's catalog and starts refreshing
+// it under config.Policy
+// The initial build runs before returning; its error is returned and no
+// goroutine is started. Call Close to stop refreshing.
+func NewManagedHybridEngine(ctx context.Context, provider ProductProvider, config ManagedHybridConfig) (*ManagedHybridEngine, error) {
+	m := newManagedHybridEngine(provider, config, time.Now)
+	if err := m.refresh(ctx, true); err != nil {
+		m.cancel()
+		return nil, err
+	}
+	ticker := time.NewTicker(m.policy.Interval)
+	go m.run(ticker.C, ticker.Stop)
+	return m, nil
+}
+
+// newManagedHybridEngine sets up a managed engine without building or starting it
+func newManagedHybridEngine(provider ProductProvider, config ManagedHybridConfig, now func() time.Time) *ManagedHybridEngine {
+	if config.Policy.Interval <= 0 {
+		config.Policy.Interval = DefaultRefreshPolicy().Interval
+	}
+	if config.NewEngine == nil {
+		config.NewEngine = NewHybridEngine
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ManagedHybridEngine{
+		provider:  provider,
+		policy:    config.Policy,
+		newEngine: config.NewEngine,
+		logger:    config.Logger,
+		now:       now,
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+}
+
+// run refreshes on every tick until Close
+func (m *ManagedHybridEngine) run(tick <-chan time.Time, stop func()) {
+	defer close(m.done)
+	defer stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-tick:
+			_ = m.refresh(m.ctx, false) // Failures are logged and counted
+		}
+	}
+}
+
+// RefreshNow polls the provider and rebuilds the index, whatever the policy
+// On error the previous index keeps serving.
+func (m *ManagedHybridEngine) RefreshNow(ctx context.Context) error {
+	if m.ctx.Err() != nil {
+		return ErrManagedEngineClosed
+	}
+	return m.refresh(ctx, true)
+}
+
+// Close stops the refresh goroutine and waits for it to exit
+// Queries keep working against the last index. Close is idempotent.
+func (m *ManagedHybridEngine) Close() error {
+	m.closeOnce.Do(func() {
+		m.cancel()
+		<-m.done
+	})
+	return nil
+}
+
+// refresh polls the provider and, if forced or due, swaps in a new index
+func (m *ManagedHybridEngine) refresh(ctx context.Context, force bool) error {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
+	products, err := m.provider(ctx)
+	if err != nil {
+		m.recordFailure("provider failed", err)
+		return err
+	}
+	if !force && !m.due(len(products)) {
+		m.statsMu.Lock()
+		m.stats.Skipped++
+		m.statsMu.Unlock()
+		return nil
+	}
+
+	started := m.now()
+	engine := m.newEngine()
+	// Cancelled by Close, so it never waits out a long build
+	if err := engine.BuildIndexCtx(ctx, products); err != nil {
+		m.recordFailure("index build failed", err)
+		return err
+	}
+	m.engine.Store(engine)
+
+	m.statsMu.Lock()
+	m.stats.Refreshes++
+	m.stats.LastRefreshed = m.now()
+	m.stats.Products = engine.IndexedCount()
+	m.statsMu.Unlock()
+	if m.logger != nil {
+		m.logger.LogAttrs(context.Background(), slog.LevelInfo, "index refreshed",
+			slog.String("engine", "managed-hybrid"),
+			slog.Int("products", engine.IndexedCount()),
+			slog.Duration("duration", m.now().Sub(started)))
+	}
+	return nil
+}
+
+// due reports whether the policy calls for a rebuild given the provider's
+// current product count
+func (m *ManagedHybridEngine) due(products int) bool {
+	m.statsMu.Lock()
+	indexed, refreshed := m.stats.Products, m.stats.LastRefreshed
+	m.statsMu.Unlock()
+
+	if m.policy.MaxStaleness > 0 && m.now().Sub(refreshed) >= m.policy.MaxStaleness {
+		return true
+	}
+	delta := products - indexed
+	if delta < 0 {
+		delta = -delta
+	}
+	return delta >= m.policy.MinProductsDelta
+}
+
+// recordFailure counts and logs a failed refresh; the serving index is kept
+func (m *ManagedHybridEngine) recordFailure(msg string, err error) {
+	m.statsMu.Lock()
+	m.stats.Failures++
+	m.stats.LastError = err
+	m.statsMu.Unlock()
+	if m.logger != nil {
+		m.logger.LogAttrs(context.Background(), slog.LevelWarn, msg,
+			slog.String("engine", "managed-hybrid"),
+			slog.String("error", err.Error()))
+	}
+}
+
+// Stats returns the refresh counters
+func (m *ManagedHybridEngine) Stats() ManagedStats {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+	return m.stats
+}
+
+// LastRefreshed returns when the serving index was swapped in
+func (m *ManagedHybridEngine) LastRefreshed() time.Time {
+	return m.Stats().LastRefreshed
+}
+
+// Engine returns the engine currently serving queries
+// It is never modified after being swapped in; use it to run several queries
+// against the same index, or for methods ManagedHybridEngine doesn't wrap.
+func (m *ManagedHybridEngine) Engine() *HybridEngine {
+	return m.engine.Load()
+}
+
+// GetName returns the name of this algorithm
+func (m *ManagedHybridEngine) GetName() string {
+	return m.Engine().GetName()
+}
+
+// Compare computes the similarity between two products
+func (m *ManagedHybridEngine) Compare(a, b Product) ComparisonResult {
+	return m.Engine().Compare(a, b)
+}
+
+// CompareWithWeights computes similarity with custom weights
+func (m *ManagedHybridEngine) CompareWithWeights(a, b Product, weights ComparisonWeights) ComparisonResult {
+	return m.Engine().CompareWithWeights(a, b, weights)
+}
+
+// FindDuplicates finds duplicates of products in the serving index
+func (m *ManagedHybridEngine) FindDuplicates(products []Product, threshold float64) []ComparisonResult {
+	return m.Engine().FindDuplicates(products, threshold)
+}
+
+// FindDuplicatesChecked is FindDuplicates reporting input problems
+func (m *ManagedHybridEngine) FindDuplicatesChecked(products []Product, threshold float64) ([]ComparisonResult, error) {
+	return m.Engine().FindDuplicatesChecked(products, threshold)
+}
+
+// FindDuplicatesForOne finds indexed duplicates of one product
+func (m *ManagedHybridEngine) FindDuplicatesForOne(product Product, threshold float64) []ComparisonResult {
+	return m.Engine().FindDuplicatesForOne(product, threshold)
+}
+
+// FindDuplicatesForOneChecked is FindDuplicatesForOne reporting truncated queries
+func (m *ManagedHybridEngine) FindDuplicatesForOneChecked(product Product, threshold float64) ([]ComparisonResult, error) {
+	return m.Engine().FindDuplicatesForOneChecked(product, threshold)
+}
+
+// HasDuplicate reports whether the serving index holds any duplicate of product
+func (m *ManagedHybridEngine) HasDuplicate(product Product, threshold float64) (bool, ComparisonResult) {
+	return m.Engine().HasDuplicate(product, threshold)
+}
+
+// IndexedCount returns the number of products in the serving index
+func (m *ManagedHybridEngine) IndexedCount() int {
+	return m.Engine().IndexedCount()
+}
+
+// GetIndexStats returns the serving index's statistics
+func (m *ManagedHybridEngine) GetIndexStats() map[string]interface{} {
+	return m.Engine().GetIndexStats()
+}
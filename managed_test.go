@@ -0,0 +1,315 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable clock for refresh policies
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// catalogProvider serves one of several catalog versions, or an error
+type catalogProvider struct {
+	mu       sync.Mutex
+	versions [][]Product
+	current  int
+	err      error
+	calls    int
+}
+
+func (p *catalogProvider) fetch(ctx context.Context) ([]Product, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.versions[p.current], nil
+}
+
+func (p *catalogProvider) set(version int, err error) {
+	p.mu.Lock()
+	p.current, p.err = version, err
+	p.mu.Unlock()
+}
+
+func (p *catalogProvider) callCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+// managedProbe is the query of the managed engine tests
+var managedProbe = Product{ID: "probe", Name: "Acme Steel Water Bottle 750ml", Description: "Insulated, keeps drinks cold for 24 hours"}
+
+// managedCatalog returns catalog version v: size distinct products plus v+1
+// copies of managedProbe, every ID prefixed with the version
+func managedCatalog(v, size int) []Product {
+	products := generateIndexedCatalog(size, 1)
+	for i := range products {
+		products[i].ID = fmt.Sprintf("v%d-%s", v, products[i].ID)
+	}
+	for i := 0; i <= v; i++ {
+		dup := managedProbe
+		dup.ID = fmt.Sprintf("v%d-probe%d", v, i)
+		products = append(products, dup)
+	}
+	return products
+}
+
+// startManaged builds the first index and runs the refresh loop on a manual tick
+func startManaged(t *testing.T, provider *catalogProvider, config ManagedHybridConfig, clock *fakeClock) (*ManagedHybridEngine, chan time.Time) {
+	t.Helper()
+	m := newManagedHybridEngine(provider.fetch, config, clock.Now)
+	if err := m.refresh(context.Background(), true); err != nil {
+		t.Fatalf("initial refresh failed: %v", err)
+	}
+	tick := make(chan time.Time)
+	go m.run(tick, func() {})
+	t.Cleanup(func() { m.Close() })
+	return m, tick
+}
+
+// tickAndWait delivers one tick and waits for its poll to be counted
+func tickAndWait(t *testing.T, m *ManagedHybridEngine, tick chan time.Time) {
+	t.Helper()
+	polls := func() uint64 {
+		stats := m.Stats()
+		return stats.Refreshes + stats.Skipped + stats.Failures
+	}
+	before := polls()
+	tick <- time.Time{}
+	deadline := time.Now().Add(5 * time.Second)
+	for polls() == before {
+		if time.Now().After(deadline) {
+			t.Fatal("tick was not handled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestManagedRefreshPolicy(t *testing.T) {
+	provider := &catalogProvider{versions: [][]Product{
+		managedCatalog(0, 20),
+		managedCatalog(1, 22),
+		managedCatalog(2, 26),
+	}}
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	policy := RefreshPolicy{Interval: time.Minute, MinProductsDelta: 5, MaxStaleness: 10 * time.Minute}
+	m, tick := startManaged(t, provider, ManagedHybridConfig{Policy: policy}, clock)
+
+	steps := []struct {
+		name          string
+		version       int
+		advance       time.Duration
+		wantRefreshes uint64
+		wantSkipped   uint64
+		wantIndexed   int
+	}{
+		{"Small delta is skipped", 1, time.Minute, 1, 1, 21},
+		{"Large delta rebuilds", 2, time.Minute, 2, 1, 29},
+		{"Fresh index is kept", 2, 9 * time.Minute, 2, 2, 29},
+		{"Stale index rebuilds", 2, time.Minute, 3, 2, 29},
+	}
+	refreshes, lastRefreshed := m.Stats().Refreshes, m.LastRefreshed()
+	for _, step := range steps {
+		provider.set(step.version, nil)
+		clock.Advance(step.advance)
+		tickAndWait(t, m, tick)
+
+		stats := m.Stats()
+		if stats.Refreshes != step.wantRefreshes || stats.Skipped != step.wantSkipped {
+			t.Errorf("%s: refreshes %d, skipped %d, want %d and %d",
+				step.name, stats.Refreshes, stats.Skipped, step.wantRefreshes, step.wantSkipped)
+		}
+		if got := m.IndexedCount(); got != step.wantIndexed {
+			t.Errorf("%s: IndexedCount() = %d, want %d", step.name, got, step.wantIndexed)
+		}
+		wantRefreshed := lastRefreshed
+		if stats.Refreshes > refreshes {
+			wantRefreshed = clock.Now()
+		}
+		if !m.LastRefreshed().Equal(wantRefreshed) {
+			t.Errorf("%s: LastRefreshed() = %v, want %v", step.name, m.LastRefreshed(), wantRefreshed)
+		}
+		refreshes, lastRefreshed = stats.Refreshes, m.LastRefreshed()
+	}
+}
+
+func TestManagedProviderFailure(t *testing.T) {
+	provider := &catalogProvider{versions: [][]Product{managedCatalog(0, 10), managedCatalog(1, 30)}}
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	handler := &captureHandler{}
+	config := ManagedHybridConfig{Policy: RefreshPolicy{Interval: time.Minute}, Logger: slog.New(handler)}
+	m, tick := startManaged(t, provider, config, clock)
+	refreshed := m.LastRefreshed()
+
+	errCatalog := errors.New("catalog unavailable")
+	provider.set(1, errCatalog)
+	clock.Advance(time.Minute)
+	tickAndWait(t, m, tick)
+
+	stats := m.Stats()
+	if stats.Failures != 1 || !errors.Is(stats.LastError, errCatalog) {
+		t.Errorf("Stats() = %+v, want one failure with %v", stats, errCatalog)
+	}
+	if !m.LastRefreshed().Equal(refreshed) || m.IndexedCount() != 11 {
+		t.Errorf("Failed refresh replaced the index: %d products, refreshed %v", m.IndexedCount(), m.LastRefreshed())
+	}
+	if got := m.FindDuplicatesForOne(managedProbe, 0.9); len(got) != 1 {
+		t.Errorf("Previous index should keep serving, got %d matches", len(got))
+	}
+	record, ok := handler.find("provider failed")
+	if !ok || record.level != slog.LevelWarn || record.attrs["error"].String() != errCatalog.Error() {
+		t.Errorf("Failure should be logged at Warn, got %+v", record)
+	}
+	if err := m.RefreshNow(context.Background()); !errors.Is(err, errCatalog) {
+		t.Errorf("RefreshNow() = %v, want %v", err, errCatalog)
+	}
+
+	provider.set(1, nil)
+	if err := m.RefreshNow(context.Background()); err != nil {
+		t.Fatalf("RefreshNow() failed: %v", err)
+	}
+	if m.IndexedCount() != 32 {
+		t.Errorf("IndexedCount() = %d after recovery, want 32", m.IndexedCount())
+	}
+}
+
+func TestManagedSwapUnderQueries(t *testing.T) {
+	const versions = 4
+	provider := &catalogProvider{}
+	for v := 0; v < versions; v++ {
+		provider.versions = append(provider.versions, managedCatalog(v, 10+5*v))
+	}
+	clock := &fakeClock{now: time.Now()}
+	m, _ := startManaged(t, provider, ManagedHybridConfig{}, clock)
+
+	done := make(chan struct{})
+	var refreshFailed atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			provider.set(i%versions, nil)
+			if err := m.RefreshNow(context.Background()); err != nil {
+				t.Errorf("RefreshNow() failed: %v", err)
+				refreshFailed.Store(true)
+				return
+			}
+		}
+	}()
+
+	// Queries run until the index has been swapped a few times, however
+	// slowly the refreshes go
+	var queries sync.WaitGroup
+	for q := 0; q < 4; q++ {
+		queries.Add(1)
+		go func() {
+			defer queries.Done()
+			for i := 0; i < 100 || (m.Stats().Refreshes < 3 && !refreshFailed.Load()); i++ {
+				results, err := m.FindDuplicatesForOneChecked(managedProbe, 0.9)
+				if err != nil {
+					t.Errorf("Query during swap failed: %v", err)
+					return
+				}
+				// Every match comes from one version, which has exactly
+				// version+1 copies of the probe
+				if len(results) == 0 || len(results) > versions {
+					t.Errorf("Query saw %d matches", len(results))
+					return
+				}
+				prefix := fmt.Sprintf("v%d-", len(results)-1)
+				for _, r := range results {
+					id := r.ProductB.ID
+					if id == managedProbe.ID {
+						id = r.ProductA.ID
+					}
+					if !strings.HasPrefix(id, prefix) {
+						t.Errorf("Query mixed versions: %s among %d matches", id, len(results))
+						return
+					}
+				}
+			}
+		}()
+	}
+	queries.Wait()
+	close(done)
+	wg.Wait()
+
+	if m.Stats().Refreshes < 3 {
+		t.Errorf("Expected refreshes during the queries, got %d", m.Stats().Refreshes)
+	}
+}
+
+func TestManagedClose(t *testing.T) {
+	provider := &catalogProvider{versions: [][]Product{managedCatalog(0, 5)}}
+	config := ManagedHybridConfig{Policy: RefreshPolicy{Interval: time.Millisecond}}
+	m, err := NewManagedHybridEngine(context.Background(), provider.fetch, config)
+	if err != nil {
+		t.Fatalf("NewManagedHybridEngine() failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for provider.callCount() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("Refresh loop never polled the provider")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	m.Close()
+	m.Close()
+
+	select {
+	case <-m.done:
+	default:
+		t.Fatal("Close returned before the refresh goroutine exited")
+	}
+	calls := provider.callCount()
+	time.Sleep(10 * time.Millisecond)
+	if provider.callCount() != calls {
+		t.Error("Provider polled after Close")
+	}
+	if err := m.RefreshNow(context.Background()); !errors.Is(err, ErrManagedEngineClosed) {
+		t.Errorf("RefreshNow() after Close = %v, want %v", err, ErrManagedEngineClosed)
+	}
+	if got := m.FindDuplicatesForOne(managedProbe, 0.9); len(got) != 1 {
+		t.Errorf("Queries should keep working after Close, got %d matches", len(got))
+	}
+}
+
+func TestNewManagedHybridEngineError(t *testing.T) {
+	errCatalog := errors.New("catalog unavailable")
+	provider := &catalogProvider{err: errCatalog}
+	if _, err := NewManagedHybridEngine(context.Background(), provider.fetch, ManagedHybridConfig{}); !errors.Is(err, errCatalog) {
+		t.Errorf("NewManagedHybridEngine() = %v, want %v", err, errCatalog)
+	}
+}
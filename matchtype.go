@@ -0,0 +1,105 @@
+package duplicatecheck
+
+import "strings"
+
+// MatchType classifies how a pair's similarity was established
+type MatchType int
+
+const (
+	// MatchFuzzy means the pair was scored by edit distance (default)
+	MatchFuzzy MatchType = iota
+	// MatchNormalizedExact means names and descriptions are equal after
+	// normalization but not byte for byte; DifferenceKinds says what differed
+	MatchNormalizedExact
+	// MatchExact means names and descriptions are byte-identical
+	MatchExact
+	// MatchIdentifier means the IDs identify the same entity (see
+	// WithIDComparator); no text was compared
+	MatchIdentifier
+	// MatchVariant means the names are of one product family but differ in
+	// variant axes such as storage or color (see WithVariantDetection)
+	MatchVariant
+	// MatchCopiedDescription means the descriptions match while the names
+	// don't, a copy-paste listing (see FindCopiedDescriptions);
+	// CombinedSimilarity is the description similarity
+	MatchCopiedDescription
+)
+
+// String returns the match type name
+func (m MatchType) String() string {
+	switch m {
+	case MatchFuzzy:
+		return "fuzzy"
+	case MatchNormalizedExact:
+		return "normalized-exact"
+	case MatchExact:
+		return "exact"
+	case MatchIdentifier:
+		return "identifier"
+	case MatchVariant:
+		return "variant"
+	case MatchCopiedDescription:
+		return "copied-description"
+	default:
+		return "unknown"
+	}
+}
+
+// Difference kinds recorded in ComparisonResult.DifferenceKinds
+const (
+	DifferenceCase       = "case"       // Letter case
+	DifferenceWhitespace = "whitespace" // Leading or trailing whitespace
+	// DifferencePreparation marks differences removed by a non-default
+	// TextPreparation (accents, markup, inner whitespace, truncated text)
+	DifferencePreparation = "preparation"
+)
+
+// normalizedExactResult scores a pair whose normalized fields are equal
+// Both similarities are 1.0 by definition, so no distance is computed.
+func (e *LevenshteinEngine) normalizedExactResult(a, b *Product, weights ComparisonWeights) ComparisonResult {
+	result := e.finishResult(ComparisonResult{
+		ProductA:              *a,
+		ProductB:              *b,
+		NameSimilarity:        1.0,
+		DescriptionSimilarity: 1.0,
+		CombinedSimilarity:    1.0,
+		WeightsUsed:           weights,
+		SimilarityMode:        e.options.SimilarityMode,
+		ThresholdUsed:         e.threshold,
+		MeetsThreshold:        e.threshold <= 1.0,
+		MatchType:             MatchExact,
+	})
+
+	var caseDiffers, spaceDiffers, prepDiffers bool
+	for _, field := range [][2]string{{a.Name, b.Name}, {a.Description, b.Description}} {
+		x, y := field[0], field[1]
+		if x == y {
+			continue
+		}
+		if strings.ToLower(strings.TrimSpace(x)) != strings.ToLower(strings.TrimSpace(y)) {
+			prepDiffers = true
+			continue
+		}
+		// Whichever single transformation doesn't make the raw fields equal was needed too
+		if strings.TrimSpace(x) != strings.TrimSpace(y) {
+			caseDiffers = true
+		}
+		if strings.ToLower(x) != strings.ToLower(y) {
+			spaceDiffers = true
+		}
+	}
+
+	if caseDiffers || spaceDiffers || prepDiffers {
+		result.MatchType = MatchNormalizedExact
+		if caseDiffers {
+			result.DifferenceKinds = append(result.DifferenceKinds, DifferenceCase)
+		}
+		if spaceDiffers {
+			result.DifferenceKinds = append(result.DifferenceKinds, DifferenceWhitespace)
+		}
+		if prepDiffers {
+			result.DifferenceKinds = append(result.DifferenceKinds, DifferencePreparation)
+		}
+	}
+	return result
+}
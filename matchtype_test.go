@@ -0,0 +1,84 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchType(t *testing.T) {
+	catalog := loadSampleCatalog(t)
+	p001 := sampleProduct(t, catalog, "P001")
+	engine := NewLevenshteinEngine()
+
+	padded := p001
+	padded.Name = "  " + p001.Name + "\n"
+
+	tests := []struct {
+		name  string
+		a, b  Product
+		want  MatchType
+		kinds []string
+	}{
+		{"Case only (P001/P002)", p001, sampleProduct(t, catalog, "P002"), MatchNormalizedExact, []string{DifferenceCase}},
+		{"Whitespace only", p001, padded, MatchNormalizedExact, []string{DifferenceWhitespace}},
+		{"Case and whitespace", sampleProduct(t, catalog, "P002"), padded, MatchNormalizedExact, []string{DifferenceCase, DifferenceWhitespace}},
+		{"Byte-identical", p001, Product{ID: "copy", Name: p001.Name, Description: p001.Description}, MatchExact, nil},
+		{"Fuzzy (P005/P006)", sampleProduct(t, catalog, "P005"), sampleProduct(t, catalog, "P006"), MatchFuzzy, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := engine.Compare(tt.a, tt.b)
+			if result.MatchType != tt.want || !reflect.DeepEqual(result.DifferenceKinds, tt.kinds) {
+				t.Errorf("Got %s %v, want %s %v", result.MatchType, result.DifferenceKinds, tt.want, tt.kinds)
+			}
+			if tt.want != MatchFuzzy && (result.CombinedSimilarity != 1.0 || result.NameDistance != 0 || result.DescriptionDistance != 0) {
+				t.Errorf("Normalized-equal pairs should score 1.0 with zero distance, got %+v", result)
+			}
+		})
+	}
+
+	t.Run("FindDuplicates surfaces the pair", func(t *testing.T) {
+		for _, engine := range []DuplicateCheckEngine{NewLevenshteinEngine(), NewHybridEngine()} {
+			if hybrid, ok := engine.(*HybridEngine); ok {
+				if err := hybrid.BuildIndex(catalog); err != nil {
+					t.Fatalf("BuildIndex failed: %v", err)
+				}
+			}
+			found := false
+			for _, result := range engine.FindDuplicates(catalog, 0.5) {
+				if makePairKey(result.ProductA.ID, result.ProductB.ID) == "P001|P002" {
+					found = result.MatchType == MatchNormalizedExact
+				}
+			}
+			if !found {
+				t.Errorf("%s: expected P001/P002 as a normalized-exact match", engine.GetName())
+			}
+		}
+	})
+}
+
+// BenchmarkNormalizedExact compares case-only copies (no DP) with a one-character
+// edit (full DP) on 3000-char descriptions
+func BenchmarkNormalizedExact(b *testing.B) {
+	description := strings.Repeat("Premium aluminium body with all-day battery. ", 66)
+	original := Product{ID: "A", Name: "UltraBook Pro 14 Laptop", Description: description}
+	engine := NewLevenshteinEngine()
+
+	b.Run("Case only", func(b *testing.B) {
+		upper := Product{ID: "B", Name: strings.ToUpper(original.Name), Description: strings.ToUpper(description)}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = engine.ComparePtr(&original, &upper)
+		}
+	})
+
+	b.Run("One edit", func(b *testing.B) {
+		edited := Product{ID: "B", Name: original.Name, Description: description + "!"}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = engine.ComparePtr(&original, &edited)
+		}
+	})
+}
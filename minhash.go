@@ -0,0 +1,253 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"math/rand"
+)
+
+// DefaultLSHSeed seeds the MinHash hash family of new hybrid engines
+// A fixed default keeps BuildIndex output identical across runs and processes;
+// use WithLSHSeed to draw a different family
+const DefaultLSHSeed int64 = 0x5eed
+
+// mersenne61 is the prime 2^61 - 1 used by the universal hash family
+const mersenne61 = 1<<61 - 1
+
+// SignatureScheme selects how MinHash signatures are computed (see
+// HybridConfig.SignatureScheme)
+type SignatureScheme int
+
+const (
+	// SignatureKIndependent applies every hash function of the family to every
+	// shingle and keeps each function's minimum: NumHashFunctions hashes per
+	// shingle (default)
+	SignatureKIndependent SignatureScheme = iota
+	// SignatureOnePermutation hashes each shingle once and keeps the minimum of
+	// each of NumHashFunctions bins the hash range is split into, filling
+	// empty bins from non-empty ones (optimal densification, Shrivastava
+	// 2017). About as accurate, at a fraction of the hashing cost.
+	SignatureOnePermutation
+)
+
+// String returns the scheme's name
+func (s SignatureScheme) String() string {
+	switch s {
+	case SignatureKIndependent:
+		return "k-independent"
+	case SignatureOnePermutation:
+		return "one-permutation"
+	default:
+		return fmt.Sprintf("SignatureScheme(%d)", int(s))
+	}
+}
+
+// minHashFamily is a universal hash family h_i(x) = (a_i*x + b_i) mod p over
+// p = 2^61 - 1, applied to a single 64-bit base hash of each shingle.
+// The parameters are drawn once from the seed, so the same seed always yields
+// the same signatures, and each h_i is independent of the others. The
+// one-permutation scheme uses h_0 only.
+type minHashFamily struct {
+	seed   int64
+	scheme SignatureScheme
+	a      []uint64 // Multipliers in [1, p)
+	b      []uint64 // Offsets in [0, p)
+}
+
+// newMinHashFamily draws numHashes hash functions from seed
+func newMinHashFamily(numHashes int, seed int64, scheme SignatureScheme) *minHashFamily {
+	rng := rand.New(rand.NewSource(seed))
+	family := &minHashFamily{
+		seed:   seed,
+		scheme: scheme,
+		a:      make([]uint64, numHashes),
+		b:      make([]uint64, numHashes),
+	}
+	for i := 0; i < numHashes; i++ {
+		family.a[i] = 1 + uint64(rng.Int63n(mersenne61-1))
+		family.b[i] = uint64(rng.Int63n(mersenne61))
+	}
+	return family
+}
+
+// size returns the number of hash functions (the signature length)
+func (f *minHashFamily) size() int {
+	return len(f.a)
+}
+
+// shingleHash is the 64-bit base hash every function of the family is applied to
+func shingleHash(shingle string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(shingle))
+	return h.Sum64()
+}
+
+// mulAddMod61 returns (a*x + b) mod 2^61-1 for a, b < 2^61-1
+func mulAddMod61(a, x, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, x)
+	// a*x = hi*2^64 + lo = (hi<<3 | lo>>61)*2^61 + (lo & p), and 2^61 ≡ 1 (mod p)
+	r := (hi<<3 | lo>>61) + (lo & mersenne61)
+	r = (r & mersenne61) + (r >> 61)
+	r += b
+	r = (r & mersenne61) + (r >> 61)
+	if r >= mersenne61 {
+		r -= mersenne61
+	}
+	return r
+}
+
+// computeMinHashSignature computes the MinHash signature of a set of shingles
+// under the family's scheme
+// Each shingle is hashed once; the family then derives one value per function.
+// Signature values are the low 32 bits of each minimum.
+func computeMinHashSignature(shingles []string, family *minHashFamily) []uint32 {
+	minima := make([]uint64, family.size())
+	for i := range minima {
+		minima[i] = math.MaxUint64
+	}
+
+	if family.scheme == SignatureOnePermutation {
+		onePermutationMinima(shingles, family, minima)
+	} else {
+		for _, shingle := range shingles {
+			x := shingleHash(shingle) % mersenne61
+			for i := range minima {
+				if hash := mulAddMod61(family.a[i], x, family.b[i]); hash < minima[i] {
+					minima[i] = hash
+				}
+			}
+		}
+	}
+
+	signature := make([]uint32, len(minima))
+	for i, m := range minima {
+		signature[i] = uint32(m)
+	}
+	return signature
+}
+
+// onePermutationMinima fills minima with the one-permutation signature of
+// shingles: h_0 of each shingle picks a bin by its top bits, and each bin keeps
+// its smallest hash
+// Empty bins, common for short texts, then copy the minimum of a non-empty bin
+// found by probing a seeded sequence per bin, so two texts fill the same empty
+// bin from the same source and the agreement of a position still estimates
+// their Jaccard similarity. Minima stay at math.MaxUint64 when there are no
+// shingles.
+func onePermutationMinima(shingles []string, family *minHashFamily, minima []uint64) {
+	bins := uint64(len(minima))
+	filled := 0
+	for _, shingle := range shingles {
+		hash := mulAddMod61(family.a[0], shingleHash(shingle)%mersenne61, family.b[0])
+		bin, _ := bits.Mul64(hash<<3, bins) // hash < 2^61, so hash<<3 spans the uint64 range
+		if minima[bin] == math.MaxUint64 {
+			filled++
+		}
+		if hash < minima[bin] {
+			minima[bin] = hash
+		}
+	}
+	if filled == 0 || filled == len(minima) {
+		return
+	}
+
+	empty := make([]bool, len(minima))
+	for i, m := range minima {
+		empty[i] = m == math.MaxUint64
+	}
+	for i := range minima {
+		if !empty[i] {
+			continue
+		}
+		probe := mix64(uint64(family.seed) ^ uint64(i)*0x9e3779b97f4a7c15)
+		for {
+			probe = mix64(probe + 0x9e3779b97f4a7c15)
+			if source, _ := bits.Mul64(probe, bins); !empty[source] {
+				minima[i] = minima[source]
+				break
+			}
+		}
+	}
+}
+
+// WithLSHSeed replaces the MinHash hash family with one drawn from seed
+// Engines with the same seed and config, including a fixed
+// HybridConfig.BucketSalt, build identical indexes. Any built
+// index was hashed with the old family and is discarded; call BuildIndex again.
+// Returns the engine for chaining.
+func (e *HybridEngine) WithLSHSeed(seed int64) *HybridEngine {
+	e.minHash = newMinHashFamily(e.numHashFunctions, seed, e.minHash.scheme)
+	e.resetIndex()
+	return e
+}
+
+// GetSignatureScheme returns how the engine computes MinHash signatures (see
+// HybridConfig.SignatureScheme)
+func (e *HybridEngine) GetSignatureScheme() SignatureScheme {
+	return e.minHash.scheme
+}
+
+// GetLSHSeed returns the seed of the MinHash hash family (DefaultLSHSeed unless changed)
+func (e *HybridEngine) GetLSHSeed() int64 {
+	return e.minHash.seed
+}
+
+// CollisionRate measures how often MinHash signatures agree between dissimilar products
+// Every pair in sample that shares no shingle (true Jaccard similarity 0) is
+// compared position by position; the result is the mean fraction of matching
+// positions. A sound hash family keeps it near 0. Pairs sharing shingles are
+// skipped; returns 0 if no pair qualifies. O(n²) in the sample size.
+func (e *HybridEngine) CollisionRate(sample []Product) float64 {
+	defer e.levenshteinEngine.guard("CollisionRate", nil)
+	type sampled struct {
+		shingles  map[string]bool
+		signature []uint32
+	}
+	items := make([]sampled, len(sample))
+	for i := range sample {
+		shingles := e.shingles(e.indexText(&sample[i]))
+		set := make(map[string]bool, len(shingles))
+		for _, shingle := range shingles {
+			set[shingle] = true
+		}
+		items[i] = sampled{shingles: set, signature: computeMinHashSignature(shingles, e.minHash)}
+	}
+
+	var total float64
+	pairs := 0
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if sharesShingle(items[i].shingles, items[j].shingles) {
+				continue
+			}
+			matches := 0
+			for k, value := range items[i].signature {
+				if items[j].signature[k] == value {
+					matches++
+				}
+			}
+			total += float64(matches) / float64(len(items[i].signature))
+			pairs++
+		}
+	}
+
+	if pairs == 0 {
+		return 0
+	}
+	return total / float64(pairs)
+}
+
+// sharesShingle reports whether two shingle sets intersect
+func sharesShingle(a, b map[string]bool) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	for shingle := range a {
+		if b[shingle] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,270 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestMulAddMod61(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	p := big.NewInt(mersenne61)
+	edge := []uint64{0, 1, mersenne61 - 1, mersenne61 - 2}
+
+	for i := 0; i < 1000; i++ {
+		a, x, b := uint64(rng.Int63n(mersenne61)), uint64(rng.Int63n(mersenne61)), uint64(rng.Int63n(mersenne61))
+		if i < len(edge)*len(edge) {
+			a, x, b = edge[i%len(edge)], edge[i/len(edge)], edge[i%len(edge)]
+		}
+
+		want := new(big.Int).Mul(new(big.Int).SetUint64(a), new(big.Int).SetUint64(x))
+		want.Add(want, new(big.Int).SetUint64(b)).Mod(want, p)
+		if got := mulAddMod61(a, x, b); got != want.Uint64() {
+			t.Fatalf("mulAddMod61(%d, %d, %d) = %d, want %d", a, x, b, got, want.Uint64())
+		}
+	}
+}
+
+// randomWordText returns a text of n random lowercase words
+func randomWordText(rng *rand.Rand, n int) string {
+	words := make([]string, n)
+	for i := range words {
+		word := make([]byte, 3+rng.Intn(5))
+		for j := range word {
+			word[j] = byte('a' + rng.Intn(26))
+		}
+		words[i] = string(word)
+	}
+	return strings.Join(words, " ")
+}
+
+func TestMinHashSignatureAgreement(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	engine := NewHybridEngine()
+
+	t.Run("Unrelated strings", func(t *testing.T) {
+		sample := make([]Product, 400)
+		for i := range sample {
+			sample[i] = Product{ID: fmt.Sprint(i), Name: randomWordText(rng, 8)}
+		}
+		// True Jaccard is 0, so agreement should be chance-level 32-bit collisions
+		if rate := engine.CollisionRate(sample); rate > 0.001 {
+			t.Errorf("CollisionRate = %.5f, want ~0", rate)
+		}
+	})
+
+	t.Run("Agreement estimates Jaccard", func(t *testing.T) {
+		// 50 shared and 50 private shingles per side: Jaccard = 50/150
+		const jaccard = 1.0 / 3.0
+		var sumErr float64
+		const trials = 200
+		for trial := 0; trial < trials; trial++ {
+			shared := strings.Fields(randomWordText(rng, 50))
+			a := append(strings.Fields(randomWordText(rng, 50)), shared...)
+			b := append(strings.Fields(randomWordText(rng, 50)), shared...)
+
+			sigA, sigB := computeMinHashSignature(a, engine.minHash), computeMinHashSignature(b, engine.minHash)
+			matches := 0
+			for i := range sigA {
+				if sigA[i] == sigB[i] {
+					matches++
+				}
+			}
+			sumErr += float64(matches)/float64(len(sigA)) - jaccard
+		}
+		// Unbiased estimator: the mean error shrinks with the number of trials
+		if bias := sumErr / trials; math.Abs(bias) > 0.01 {
+			t.Errorf("Mean agreement deviates from Jaccard by %.4f", bias)
+		}
+	})
+}
+
+func TestWithLSHSeed(t *testing.T) {
+	products := exportTestProducts()
+	snapshot := func(engine *HybridEngine) string {
+		t.Helper()
+		if err := engine.BuildIndex(products); err != nil {
+			t.Fatalf("BuildIndex failed: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := engine.ExportIndexSnapshot(&buf, ExportOptions{IncludeSignatures: true}); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	if seed := NewHybridEngine().GetLSHSeed(); seed != DefaultLSHSeed {
+		t.Errorf("Default seed = %d, want %d", seed, DefaultLSHSeed)
+	}
+
+	t.Run("Same seed builds identical indexes", func(t *testing.T) {
+		a := snapshot(NewHybridEngine().WithLSHSeed(42).WithBucketSalt(1))
+		b := snapshot(NewHybridEngine().WithLSHSeed(42).WithBucketSalt(1))
+		if a != b {
+			t.Error("Indexes built with the same seed should be identical")
+		}
+		if !strings.Contains(a, `"lsh_seed":42`) {
+			t.Error("Snapshot config should record the seed")
+		}
+	})
+
+	t.Run("Different seeds draw different families", func(t *testing.T) {
+		if snapshot(NewHybridEngine().WithLSHSeed(1)) == snapshot(NewHybridEngine().WithLSHSeed(2)) {
+			t.Error("Different seeds should produce different signatures")
+		}
+	})
+
+	t.Run("Reseeding discards the index", func(t *testing.T) {
+		engine := NewHybridEngine()
+		if err := engine.BuildIndex(products); err != nil {
+			t.Fatalf("BuildIndex failed: %v", err)
+		}
+		engine.WithLSHSeed(7)
+		if engine.lshIndex != nil {
+			t.Error("WithLSHSeed should discard the index built with the old family")
+		}
+		if results := engine.FindDuplicatesForOne(products[0], 0.9); results != nil {
+			t.Errorf("Expected no results without an index, got %d", len(results))
+		}
+	})
+}
+
+func TestOnePermutationAccuracy(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	kIndependent := newMinHashFamily(100, DefaultLSHSeed, SignatureKIndependent)
+	onePermutation := newMinHashFamily(100, DefaultLSHSeed, SignatureOnePermutation)
+	agreement := func(a, b []string, family *minHashFamily) float64 {
+		sigA, sigB := computeMinHashSignature(a, family), computeMinHashSignature(b, family)
+		matches := 0
+		for i := range sigA {
+			if sigA[i] == sigB[i] {
+				matches++
+			}
+		}
+		return float64(matches) / float64(len(sigA))
+	}
+
+	tests := []struct {
+		name            string
+		shared, private int
+	}{
+		{"long texts", 200, 100},
+		{"medium texts", 50, 50},
+		{"short texts", 8, 4}, // 16 shingles for 100 bins: mostly densified
+		{"very short texts", 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jaccard := float64(tt.shared) / float64(tt.shared+2*tt.private)
+			const trials = 300
+			var errK, errOne, biasOne float64
+			for trial := 0; trial < trials; trial++ {
+				shared := strings.Fields(randomWordText(rng, tt.shared))
+				a := append(strings.Fields(randomWordText(rng, tt.private)), shared...)
+				b := append(strings.Fields(randomWordText(rng, tt.private)), shared...)
+				k, one := agreement(a, b, kIndependent), agreement(a, b, onePermutation)
+				errK += math.Abs(k - jaccard)
+				errOne += math.Abs(one - jaccard)
+				biasOne += one - jaccard
+			}
+			errK, errOne, biasOne = errK/trials, errOne/trials, biasOne/trials
+			if errOne > errK+0.03 {
+				t.Errorf("one-permutation mean error %.4f, k-independent %.4f", errOne, errK)
+			}
+			if math.Abs(biasOne) > 0.02 {
+				t.Errorf("one-permutation estimates deviate from Jaccard %.3f by %.4f", jaccard, biasOne)
+			}
+		})
+	}
+
+	t.Run("densified", func(t *testing.T) {
+		signature := computeMinHashSignature([]string{"abc", "bcd", "cde"}, onePermutation)
+		for i, value := range signature {
+			if value == math.MaxUint32 {
+				t.Fatalf("bin %d left empty", i)
+			}
+		}
+		for i, value := range computeMinHashSignature(nil, onePermutation) {
+			if value != math.MaxUint32 {
+				t.Fatalf("bin %d of an empty set = %d", i, value)
+			}
+		}
+	})
+}
+
+func TestSignatureSchemeIndexes(t *testing.T) {
+	products := exportTestProducts()
+	config := DefaultHybridConfig()
+	config.SignatureScheme = SignatureOnePermutation
+	config.ExactModeCutoff = 0
+	onePermutation := NewHybridEngineWithConfig(config)
+	if err := onePermutation.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := onePermutation.WithLSHSeed(3).GetSignatureScheme(); got != SignatureOnePermutation {
+		t.Errorf("scheme after WithLSHSeed = %s", got)
+	}
+	if NewHybridEngine().GetSignatureScheme() != SignatureKIndependent {
+		t.Error("default scheme should be k-independent")
+	}
+	if onePermutation.IndexConfigFingerprint() == NewHybridEngine().WithLSHSeed(3).IndexConfigFingerprint() {
+		t.Error("schemes share an index config fingerprint")
+	}
+
+	onePermutation = NewHybridEngineWithConfig(config)
+	if err := onePermutation.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	if results := onePermutation.FindDuplicatesForOne(products[1], 0.7); len(results) == 0 {
+		t.Error("one-permutation index found no duplicates")
+	}
+	var buf bytes.Buffer
+	if err := onePermutation.SaveIndex(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"signature_scheme":"one-permutation"`) {
+		t.Error("index file doesn't record the signature scheme")
+	}
+	if err := NewHybridEngine().LoadIndex(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrIncompatibleIndex) {
+		t.Errorf("loading a one-permutation index into a k-independent engine: %v", err)
+	}
+	if err := NewHybridEngineWithConfig(config).LoadIndex(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Errorf("loading into a one-permutation engine: %v", err)
+	}
+}
+
+func BenchmarkSignatureScheme(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	products := make([]Product, 10000)
+	for i := range products {
+		products[i] = Product{ID: fmt.Sprint(i), Name: randomWordText(rng, 6), Description: randomWordText(rng, 250)}
+	}
+
+	shingles := generateShingles(products[0].Description, 3)
+	for _, scheme := range []SignatureScheme{SignatureKIndependent, SignatureOnePermutation} {
+		b.Run("signature/"+scheme.String(), func(b *testing.B) {
+			family := newMinHashFamily(100, DefaultLSHSeed, scheme)
+			for i := 0; i < b.N; i++ {
+				computeMinHashSignature(shingles, family)
+			}
+		})
+		b.Run("BuildIndex/"+scheme.String(), func(b *testing.B) {
+			config := DefaultHybridConfig()
+			config.SignatureScheme = scheme
+			engine := NewHybridEngineWithConfig(config)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := engine.BuildIndex(products); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(products)*b.N)/b.Elapsed().Seconds(), "products/sec")
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package duplicatecheck
+
+import "sort"
+
+// weightProfile is one named weighting of a FindDuplicatesMultiWeights scan
+type weightProfile struct {
+	name      string
+	weights   ComparisonWeights
+	threshold float64
+}
+
+// pairScores holds one pair's name and description scores while
+// FindDuplicatesMultiWeights scores it under each weight profile
+type pairScores struct {
+	name           nameScore
+	description    descriptionScore
+	hasName        bool
+	hasDescription bool
+}
+
+// profileMatch is a pair meeting one weight profile's threshold
+type profileMatch struct {
+	profile int // Index into the scan's profiles
+	result  ComparisonResult
+}
+
+// FindDuplicatesMultiWeights runs one scan and scores every pair under each
+// weight profile, returning each profile's duplicates under its name
+// Name and description similarities don't depend on the weights, so each pair
+// computes them once and only the combination is repeated per profile: a scan
+// for two profiles costs about one FindDuplicates, not two. A profile's
+// threshold comes from thresholds, or is the engine's default threshold (see
+// SetDefaultThreshold) when missing. Each profile's results are those
+// FindDuplicates returns with the engine weights set to that profile's, with
+// that profile's CombinedSimilarity and WeightsUsed: pruning uses the most
+// permissive profile, and a description one profile skips is still compared
+// for the others. The profiles replace the engine weights and any
+// WeightResolver, and the lazy description loader isn't called. Every profile
+// has an entry, empty when nothing matched; nil when the input repeats IDs
+// under DuplicateIDReject.
+func (e *LevenshteinEngine) FindDuplicatesMultiWeights(products []Product, profiles map[string]ComparisonWeights, thresholds map[string]float64) map[string][]ComparisonResult {
+	defer e.guard("FindDuplicatesMultiWeights", nil)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil
+	}
+	plan := e.weightProfiles(profiles, thresholds)
+	found := make([][]ComparisonResult, len(plan))
+	for k := range found {
+		found[k] = []ComparisonResult{}
+	}
+
+	ptrs := productPtrs(resolved)
+	quality := e.newQualityCheck()
+	if quality != nil {
+		ptrs = quality.products(ptrs)
+	}
+	e.scanProfiles(ptrs, plan, func(matches []profileMatch) bool {
+		for _, m := range matches {
+			if quality != nil && !quality.flag(&m.result) && quality.mode == QualityExclude {
+				continue
+			}
+			found[m.profile] = append(found[m.profile], m.result)
+		}
+		return true
+	})
+
+	duplicates := make(map[string][]ComparisonResult, len(plan))
+	for k, profile := range plan {
+		duplicates[profile.name] = e.variantOutput(found[k])
+	}
+	return duplicates
+}
+
+// weightProfiles returns the profiles of a FindDuplicatesMultiWeights scan,
+// sorted by name
+func (e *LevenshteinEngine) weightProfiles(profiles map[string]ComparisonWeights, thresholds map[string]float64) []weightProfile {
+	plan := make([]weightProfile, 0, len(profiles))
+	for name, weights := range profiles {
+		threshold, ok := thresholds[name]
+		if !ok {
+			threshold = e.threshold
+		}
+		plan = append(plan, weightProfile{name: name, weights: weights, threshold: threshold})
+	}
+	sort.Slice(plan, func(a, b int) bool { return plan[a].name < plan[b].name })
+	return plan
+}
+
+// scanProfiles compares every pair of products once under all profiles,
+// passing each pair's matches to yield
+// The length window admits the pairs of the most permissive profile; the
+// pruning within a profile (charset, description skip) is that profile's own,
+// so each profile matches exactly what a scan under its weights would.
+func (e *LevenshteinEngine) scanProfiles(products []*Product, plan []weightProfile, yield func([]profileMatch) bool) {
+	if len(products) < 2 || len(plan) == 0 {
+		return
+	}
+	parallel := len(products) > 50
+	if parallel {
+		warmCaches(products, e.preparer())
+	}
+	memo := e.newScanMemo(products)
+	ratio := lengthWindowRatio(plan[0].weights, plan[0].threshold)
+	for _, profile := range plan[1:] {
+		if r := lengthWindowRatio(profile.weights, profile.threshold); r < ratio {
+			ratio = r
+		}
+	}
+	window := e.newLengthWindowAt(products, ratio)
+
+	generate := func(visit func(pairIndexes) bool) {
+		window.eachPair(len(products), func(i, j int) bool {
+			return visit(pairIndexes{i, j})
+		})
+	}
+	runWorkerStages(e.scanWorkers(len(products), parallel), generate, func() func(pairIndexes) ([]profileMatch, bool) {
+		scratch, scores := &comparisonScratch{}, &pairScores{}
+		return func(pair pairIndexes) ([]profileMatch, bool) {
+			a, b := products[pair.i], products[pair.j]
+			if !e.pairAllowed(a, b) {
+				return nil, false
+			}
+			*scores = pairScores{}
+			var matches []profileMatch
+			for k, profile := range plan {
+				if e.charsetRejects(a, b, profile.weights, profile.threshold) && e.idRelation(a.ID, b.ID) != IDSameEntity {
+					continue
+				}
+				result := e.compareWithWeights(a, b, profile.weights, memoPair{memo: memo, i: pair.i, j: pair.j, scratch: scratch, scores: scores}, profile.threshold)
+				result.stampThreshold(profile.threshold)
+				if result.MeetsThreshold {
+					matches = append(matches, profileMatch{profile: k, result: result})
+				}
+			}
+			return matches, len(matches) > 0
+		}
+	}, yield)
+	e.recordScan(window, len(products))
+}
+
+// FindDuplicatesMultiWeights scores every pair under each weight profile in
+// one exhaustive scan (delegates to Levenshtein)
+// Like FindDuplicatesByName, it doesn't use the index.
+func (e *HybridEngine) FindDuplicatesMultiWeights(products []Product, profiles map[string]ComparisonWeights, thresholds map[string]float64) map[string][]ComparisonResult {
+	defer e.levenshteinEngine.guard("FindDuplicatesMultiWeights", nil)
+	return e.levenshteinEngine.FindDuplicatesMultiWeights(products, profiles, thresholds)
+}
@@ -0,0 +1,110 @@
+package duplicatecheck
+
+import (
+	"math"
+	"testing"
+)
+
+// multiWeightProfiles weigh names, descriptions, and the engine default; the
+// default profile takes the engine threshold
+var multiWeightProfiles = map[string]ComparisonWeights{
+	"merch":   {NameWeight: 0.9, DescriptionWeight: 0.1},
+	"content": {NameWeight: 0.2, DescriptionWeight: 0.8},
+	"default": DefaultWeights(),
+}
+
+var multiWeightThresholds = map[string]float64{"merch": 0.8, "content": 0.7}
+
+// resultsByPair indexes results by their products' IDs
+func resultsByPair(results []ComparisonResult) map[[2]string]ComparisonResult {
+	byPair := make(map[[2]string]ComparisonResult, len(results))
+	for _, r := range results {
+		byPair[[2]string{r.ProductA.ID, r.ProductB.ID}] = r
+	}
+	return byPair
+}
+
+func TestFindDuplicatesMultiWeights(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 120)
+	multi := NewLevenshteinEngine().FindDuplicatesMultiWeights(catalog, multiWeightProfiles, multiWeightThresholds)
+	if len(multi) != len(multiWeightProfiles) {
+		t.Fatalf("%d profiles returned, want %d", len(multi), len(multiWeightProfiles))
+	}
+
+	for name, weights := range multiWeightProfiles {
+		t.Run(name, func(t *testing.T) {
+			engine := NewLevenshteinEngineWithWeights(weights)
+			threshold, ok := multiWeightThresholds[name]
+			if !ok {
+				threshold = engine.GetDefaultThreshold()
+			}
+			want := resultsByPair(engine.FindDuplicates(catalog, threshold))
+			got := resultsByPair(multi[name])
+			if len(want) == 0 || len(got) != len(want) {
+				t.Fatalf("%d pairs, independent scan %d", len(got), len(want))
+			}
+			for pair, w := range want {
+				g, ok := got[pair]
+				if !ok {
+					t.Errorf("pair %v missing", pair)
+					continue
+				}
+				if math.Abs(g.CombinedSimilarity-w.CombinedSimilarity) > 1e-12 || g.WeightsUsed != w.WeightsUsed || g.ThresholdUsed != threshold {
+					t.Errorf("pair %v: %v under %+v at %v, independent scan %v under %+v",
+						pair, g.CombinedSimilarity, g.WeightsUsed, g.ThresholdUsed, w.CombinedSimilarity, w.WeightsUsed)
+				}
+			}
+		})
+	}
+}
+
+func TestFindDuplicatesMultiWeightsWork(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 120)
+	profiles := map[string]ComparisonWeights{"merch": multiWeightProfiles["merch"], "content": multiWeightProfiles["content"]}
+
+	separate := NewLevenshteinEngine()
+	for name, weights := range profiles {
+		separate.weights = weights
+		separate.FindDuplicates(catalog, multiWeightThresholds[name])
+	}
+	shared := NewLevenshteinEngine()
+	shared.FindDuplicatesMultiWeights(catalog, profiles, multiWeightThresholds)
+
+	separateDistances := separate.GetScanStats()["name_comparisons"].(uint64)
+	sharedDistances := shared.GetScanStats()["name_comparisons"].(uint64)
+	if float64(sharedDistances) > 0.6*float64(separateDistances) {
+		t.Errorf("one scan computed %d name distances, two scans %d", sharedDistances, separateDistances)
+	}
+
+	if got := shared.FindDuplicatesMultiWeights(catalog, nil, nil); len(got) != 0 {
+		t.Errorf("no profiles: %v", got)
+	}
+	repeated := append(catalog[:2:2], Product{ID: catalog[0].ID, Name: catalog[0].Name + " v2"})
+	if got := shared.FindDuplicatesMultiWeights(repeated, profiles, nil); got != nil {
+		t.Errorf("repeated IDs: %v", got)
+	}
+}
+
+// BenchmarkFindDuplicatesMultiWeights compares two FindDuplicates runs with
+// one two-profile scan, reporting the name distances each computes
+func BenchmarkFindDuplicatesMultiWeights(b *testing.B) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 2000)
+	profiles := map[string]ComparisonWeights{"merch": multiWeightProfiles["merch"], "content": multiWeightProfiles["content"]}
+	b.Run("separate", func(b *testing.B) {
+		engine := NewLevenshteinEngine()
+		for i := 0; i < b.N; i++ {
+			for name, weights := range profiles {
+				engine.weights = weights
+				engine.FindDuplicates(catalog, multiWeightThresholds[name])
+			}
+		}
+		b.ReportMetric(float64(engine.GetScanStats()["name_comparisons"].(uint64))/float64(b.N), "distances/op")
+	})
+	b.Run("shared", func(b *testing.B) {
+		engine := NewLevenshteinEngine()
+		for i := 0; i < b.N; i++ {
+			engine.FindDuplicatesMultiWeights(catalog, profiles, multiWeightThresholds)
+		}
+		b.ReportMetric(float64(engine.GetScanStats()["name_comparisons"].(uint64))/float64(b.N), "distances/op")
+	})
+}
@@ -0,0 +1,90 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// MutationCheck selects what happens when a product's Name or Description
+// was changed in place after an engine cached its text (see SetMutationCheck)
+type MutationCheck int32
+
+const (
+	// MutationCheckOff rebuilds the cache silently (the default)
+	MutationCheckOff MutationCheck = iota
+	// MutationCheckDetect rebuilds the cache, counts the mutation (see
+	// MutationsDetected) and logs a warning to slog.Default
+	MutationCheckDetect
+	// MutationCheckStrict panics with an error wrapping ErrProductMutated,
+	// for tests
+	MutationCheckStrict
+)
+
+// String returns the mode's name: "off", "detect" or "strict"
+func (m MutationCheck) String() string {
+	switch m {
+	case MutationCheckDetect:
+		return "detect"
+	case MutationCheckStrict:
+		return "strict"
+	default:
+		return "off"
+	}
+}
+
+// ErrProductMutated is wrapped by the panic of MutationCheckStrict
+var ErrProductMutated = errors.New("duplicatecheck: product text changed after caching")
+
+var (
+	mutationCheck     atomic.Int32  // MutationCheck in effect
+	mutationsDetected atomic.Uint64 // Mutations seen under Detect or Strict
+)
+
+// SetMutationCheck sets how every engine reacts to products changed in place
+// after their text was cached, returning the previous mode
+// The check is package-wide since the cache belongs to the Product, not to
+// an engine. It only runs when a cache no longer matches its product's
+// fields, so it costs nothing while products are left alone, in any mode. A
+// copy whose fields were edited is not a mutation: only the Product value the
+// cache was built for is checked.
+func SetMutationCheck(mode MutationCheck) MutationCheck {
+	return MutationCheck(mutationCheck.Swap(int32(mode)))
+}
+
+// MutationsDetected returns how many in-place product mutations
+// MutationCheckDetect and MutationCheckStrict have seen, each counted once
+// however often the stale cache is read
+func MutationsDetected() uint64 {
+	return mutationsDetected.Load()
+}
+
+// checkMutation reacts to p's fields no longer matching the cache c it built
+func checkMutation(p *Product, c *productCache) {
+	mode := MutationCheck(mutationCheck.Load())
+	if mode == MutationCheckOff {
+		return
+	}
+	if c.mutated.CompareAndSwap(false, true) {
+		mutationsDetected.Add(1)
+	} else if mode == MutationCheckDetect {
+		return // Logged on its first read
+	}
+	nameChanged, descChanged := c.name != p.Name, c.desc != p.Description
+	if mode == MutationCheckStrict {
+		fields := "name"
+		switch {
+		case nameChanged && descChanged:
+			fields = "name and description"
+		case descChanged:
+			fields = "description"
+		}
+		panic(fmt.Errorf("%w: product %q %s was edited in place; edit a copy instead", ErrProductMutated, p.ID, fields))
+	}
+	slog.Default().LogAttrs(context.Background(), slog.LevelWarn, "product changed after caching",
+		slog.String("product_id", p.ID),
+		slog.Bool("name_changed", nameChanged),
+		slog.Bool("description_changed", descChanged))
+}
@@ -0,0 +1,123 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// mutationPair returns two products and an engine that has cached both
+func mutationPair() (*LevenshteinEngine, *Product, *Product) {
+	a := &Product{ID: "A", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Noise cancelling, black"}
+	b := &Product{ID: "B", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Noise cancelling, black"}
+	engine := NewLevenshteinEngine()
+	engine.ComparePtr(a, b)
+	return engine, a, b
+}
+
+func TestMutationCheck(t *testing.T) {
+	defer SetMutationCheck(SetMutationCheck(MutationCheckOff))
+
+	t.Run("off", func(t *testing.T) {
+		engine, a, b := mutationPair()
+		before := MutationsDetected()
+		a.Name = "Apple iPhone 15 Pro 256GB"
+		if r := engine.ComparePtr(a, b); r.NameSimilarity > 0.5 {
+			t.Errorf("name similarity %v after the edit: the stale cache was used", r.NameSimilarity)
+		}
+		if MutationsDetected() != before {
+			t.Error("mutation counted with the check off")
+		}
+	})
+
+	t.Run("detect", func(t *testing.T) {
+		SetMutationCheck(MutationCheckDetect)
+		var logs bytes.Buffer
+		defer slog.SetDefault(slog.Default())
+		slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+		engine, a, b := mutationPair()
+		before := MutationsDetected()
+		a.Description = "Over-ear, silver"
+		for i := 0; i < 3; i++ {
+			if r := engine.ComparePtr(a, b); r.DescriptionSimilarity == 1 {
+				t.Fatal("the stale cache was used")
+			}
+		}
+		if got := MutationsDetected() - before; got != 1 {
+			t.Errorf("%d mutations counted, want 1", got)
+		}
+		if out := logs.String(); strings.Count(out, "product changed after caching") != 1 ||
+			!strings.Contains(out, "product_id=A") || !strings.Contains(out, "description_changed=true") {
+			t.Errorf("log:\n%s", out)
+		}
+
+		// Edited copies share the cache but aren't mutations
+		c := *b
+		c.Name = "Bose QC45"
+		engine.ComparePtr(&c, a)
+		if got := MutationsDetected() - before; got != 1 {
+			t.Errorf("%d mutations counted after editing a copy, want 1", got)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		SetMutationCheck(MutationCheckStrict)
+		engine, a, b := mutationPair()
+
+		c := *a
+		c.Name = "Sony WH-1000XM4 Wireless Headphones"
+		engine.ComparePtr(&c, b) // An edited copy doesn't panic
+
+		a.Name = "Apple iPhone 15 Pro 256GB"
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ErrProductMutated) || !strings.Contains(err.Error(), `"A" name`) {
+				t.Errorf("panic %v, want ErrProductMutated naming A's name", err)
+			}
+		}()
+		engine.ComparePtr(a, b)
+		t.Error("no panic for an in-place edit")
+	})
+}
+
+func TestMutationCheckScan(t *testing.T) {
+	defer SetMutationCheck(SetMutationCheck(MutationCheckDetect))
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+
+	products := GenerateTestCatalog(goldenCatalogSeed, 60)
+	engine := NewLevenshteinEngine()
+	engine.FindDuplicates(products, 0.8)
+	before := MutationsDetected()
+	products[3].Name = products[4].Name
+	products[3].Description = products[4].Description
+	found := false
+	for _, r := range engine.FindDuplicates(products, 0.8) {
+		found = found || PairKey(r.ProductA.ID, r.ProductB.ID) == PairKey(products[3].ID, products[4].ID)
+	}
+	if !found {
+		t.Error("the edited product doesn't match its new twin")
+	}
+	if got := MutationsDetected() - before; got != 1 {
+		t.Errorf("%d mutations counted, want 1", got)
+	}
+}
+
+// BenchmarkMutationCheck compares cached products under each mode: the check
+// only runs on a stale cache, so the modes cost the same
+func BenchmarkMutationCheck(b *testing.B) {
+	defer SetMutationCheck(SetMutationCheck(MutationCheckOff))
+	for _, mode := range []MutationCheck{MutationCheckOff, MutationCheckDetect, MutationCheckStrict} {
+		b.Run(mode.String(), func(b *testing.B) {
+			SetMutationCheck(mode)
+			engine, x, y := mutationPair()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				engine.ComparePtr(x, y)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package duplicatecheck
+
+// Myers' bit-parallel Levenshtein distance (Myers 1999, in Hyyrö's formulation
+// for global edit distance). Each column of the DP matrix is encoded as two
+// bit vectors of vertical deltas (+1 in Pv, -1 in Mv), so one text character
+// advances 64 cells with a handful of word operations. Patterns longer than 64
+// runes are split into 64-bit blocks processed top to bottom, carrying the
+// horizontal delta from one block into the next.
+//
+// Results are exactly those of the DP; it is pure Go and needs no build tags.
+
+// myersMinPatternLength is the shorter-string length (in runes or grapheme
+// units) from which computeDistanceWithThreshold switches from the DP to
+// Myers. Below it the DP's setup cost is lower.
+const myersMinPatternLength = 32
+
+// levenshteinMyers returns the Levenshtein distance between s and t over runes
+func levenshteinMyers(s, t string) int {
+	return myersDistance([]rune(s), []rune(t), -1)
+}
+
+// myersDistance returns the Levenshtein distance between a and b
+// If maxDistance >= 0, it may return early with a value greater than
+// maxDistance once the distance is known to exceed it.
+func myersDistance(a, b []rune, maxDistance int) int {
+	distance, _ := myersDistanceWithin(a, b, maxDistance, nil, nil)
+	return distance
+}
+
+// myersDistanceWithin is myersDistance charging one block update per text
+// rune and block to budget; returns false once budget is exhausted
+// Its vectors come from scratch, or from the pools when scratch is nil.
+func myersDistanceWithin(a, b []rune, maxDistance int, budget *cellBudget, scratch *comparisonScratch) (int, bool) {
+	// The shorter string is the pattern (bit vectors), the longer the text
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(a) == 0 {
+		return len(b), true
+	}
+
+	var pattern *myersPattern
+	if scratch != nil {
+		pattern = scratch.myersPattern(a)
+	} else {
+		pattern = newMyersPattern(a)
+		defer pattern.release()
+	}
+	if pattern.blocks == 1 {
+		return pattern.distanceSingle(b, maxDistance, budget)
+	}
+	return pattern.distanceBlocked(b, maxDistance, budget, scratch)
+}
+
+// myersPattern holds the match vectors (Peq) of a pattern: for every rune in
+// the pattern, the bit positions where it occurs, one word per block
+type myersPattern struct {
+	length int
+	blocks int
+	ascii  [128]int32     // Peq row of each ASCII rune (0: not in pattern)
+	other  map[rune]int32 // Peq row of each non-ASCII rune
+	eq     []uint64       // Peq rows of blocks words each; row 0 is all zeros
+}
+
+// newMyersPattern builds the match vectors of pattern
+func newMyersPattern(pattern []rune) *myersPattern {
+	p := &myersPattern{}
+	p.eq = getUint64Slice(p.index(pattern))
+	p.fill(pattern)
+	return p
+}
+
+// index assigns the Peq rows of pattern's runes, returning the number of
+// words the match vectors need
+// The ASCII rows must be zero and other empty or nil.
+func (p *myersPattern) index(pattern []rune) int {
+	p.length = len(pattern)
+	p.blocks = (len(pattern) + 63) / 64
+	rows := int32(1)
+	for _, r := range pattern {
+		if p.lookup(r) == 0 {
+			if r >= 0 && r < 128 {
+				p.ascii[r] = rows
+			} else {
+				if p.other == nil {
+					p.other = make(map[rune]int32)
+				}
+				p.other[r] = rows
+			}
+			rows++
+		}
+	}
+	return int(rows) * p.blocks
+}
+
+// fill sets the bits of pattern's runes in the zeroed match vectors p.eq
+func (p *myersPattern) fill(pattern []rune) {
+	for i, r := range pattern {
+		p.eq[int(p.lookup(r))*p.blocks+i/64] |= 1 << uint(i%64)
+	}
+}
+
+// release returns the match vectors to the pool; p can't be used afterwards
+func (p *myersPattern) release() {
+	putUint64Slice(p.eq)
+	p.eq = nil
+}
+
+// lookup returns the Peq row of r (0 if r is not in the pattern)
+func (p *myersPattern) lookup(r rune) int32 {
+	if r >= 0 && r < 128 {
+		return p.ascii[r]
+	}
+	return p.other[r]
+}
+
+// distanceSingle runs the single-word algorithm (pattern of at most 64 runes)
+func (p *myersPattern) distanceSingle(text []rune, maxDistance int, budget *cellBudget) (int, bool) {
+	pv := ^uint64(0)
+	mv := uint64(0)
+	high := uint64(1) << uint(p.length-1)
+	score := p.length
+
+	for j, r := range text {
+		eq := p.eq[p.lookup(r)]
+		xv := eq | mv
+		xh := (((eq & pv) + pv) ^ pv) | eq
+		ph := mv | ^(xh | pv)
+		mh := pv & xh
+
+		if ph&high != 0 {
+			score++
+		} else if mh&high != 0 {
+			score--
+		}
+
+		// Row 0 of a global alignment grows by one per text character
+		ph = ph<<1 | 1
+		mh <<= 1
+		pv = mh | ^(xv | ph)
+		mv = ph & xv
+
+		// Each remaining text character can lower the score by at most one
+		if maxDistance >= 0 && score-(len(text)-j-1) > maxDistance {
+			return score - (len(text) - j - 1), true
+		}
+		if !budget.spendMyers(p.blocks) {
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+// distanceBlocked runs the multi-word algorithm for patterns over 64 runes
+// Its vertical delta vectors come from scratch, or the pools when scratch is nil.
+func (p *myersPattern) distanceBlocked(text []rune, maxDistance int, budget *cellBudget, scratch *comparisonScratch) (int, bool) {
+	var pv, mv []uint64
+	if scratch != nil {
+		pv, mv = scratch.vectors(p.blocks)
+	} else {
+		pv, mv = getUint64Slice(p.blocks), getUint64Slice(p.blocks)
+		defer func() {
+			putUint64Slice(pv)
+			putUint64Slice(mv)
+		}()
+	}
+	for i := range pv {
+		pv[i] = ^uint64(0)
+	}
+	last := p.blocks - 1
+	lastHigh := uint64(1) << uint((p.length-1)%64)
+	score := p.length
+
+	for j, r := range text {
+		row := p.eq[int(p.lookup(r))*p.blocks:]
+		carry := 1 // Horizontal delta entering the top block (row 0)
+
+		for k := 0; k <= last; k++ {
+			eq := row[k]
+			xv := eq | mv[k]
+			if carry < 0 {
+				eq |= 1
+			}
+			xh := (((eq & pv[k]) + pv[k]) ^ pv[k]) | eq
+			ph := mv[k] | ^(xh | pv[k])
+			mh := pv[k] & xh
+
+			high := uint64(1) << 63
+			if k == last {
+				high = lastHigh
+			}
+			out := 0
+			if ph&high != 0 {
+				out = 1
+			} else if mh&high != 0 {
+				out = -1
+			}
+
+			ph <<= 1
+			mh <<= 1
+			if carry < 0 {
+				mh |= 1
+			} else if carry > 0 {
+				ph |= 1
+			}
+			pv[k] = mh | ^(xv | ph)
+			mv[k] = ph & xv
+			carry = out
+		}
+		score += carry
+
+		if maxDistance >= 0 && score-(len(text)-j-1) > maxDistance {
+			return score - (len(text) - j - 1), true
+		}
+		if !budget.spendMyers(p.blocks) {
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+// searchDistance returns the smallest edit distance between the pattern and
+// any substring of text (Sellers' semi-global alignment): row 0 of the DP is
+// all zeros, so a match may start anywhere in text at no cost
+func (p *myersPattern) searchDistance(text []rune) int {
+	pv := getUint64Slice(p.blocks)
+	mv := getUint64Slice(p.blocks)
+	defer func() {
+		putUint64Slice(pv)
+		putUint64Slice(mv)
+	}()
+	for i := range pv {
+		pv[i] = ^uint64(0)
+	}
+	last := p.blocks - 1
+	lastHigh := uint64(1) << uint((p.length-1)%64)
+	score := p.length
+	best := score
+
+	for _, r := range text {
+		row := p.eq[int(p.lookup(r))*p.blocks:]
+		carry := 0 // Row 0 stays zero: no horizontal delta enters the top block
+
+		for k := 0; k <= last; k++ {
+			eq := row[k]
+			xv := eq | mv[k]
+			if carry < 0 {
+				eq |= 1
+			}
+			xh := (((eq & pv[k]) + pv[k]) ^ pv[k]) | eq
+			ph := mv[k] | ^(xh | pv[k])
+			mh := pv[k] & xh
+
+			high := uint64(1) << 63
+			if k == last {
+				high = lastHigh
+			}
+			out := 0
+			if ph&high != 0 {
+				out = 1
+			} else if mh&high != 0 {
+				out = -1
+			}
+
+			ph <<= 1
+			mh <<= 1
+			if carry < 0 {
+				mh |= 1
+			} else if carry > 0 {
+				ph |= 1
+			}
+			pv[k] = mh | ^(xv | ph)
+			mv[k] = ph & xv
+			carry = out
+		}
+		score += carry
+		if score < best {
+			best = score
+		}
+	}
+	return best
+}
@@ -0,0 +1,117 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// referenceDistance is the textbook full-matrix Levenshtein DP over runes
+func referenceDistance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// randomRunes returns n runes from alphabet
+func randomRunes(rng *rand.Rand, alphabet []rune, n int) []rune {
+	out := make([]rune, n)
+	for i := range out {
+		out[i] = alphabet[rng.Intn(len(alphabet))]
+	}
+	return out
+}
+
+func TestMyersMatchesDP(t *testing.T) {
+	rng := rand.New(rand.NewSource(64))
+	alphabets := [][]rune{
+		[]rune("ab"),                          // Many ties and long matching runs
+		[]rune("abcdefghijklmnopqrstuvwxyz "), // Text-like
+		[]rune("aé日😀"),                        // Non-ASCII
+	}
+	// Lengths around the single-word and block boundaries
+	lengths := []int{1, 2, 31, 33, 63, 64, 65, 127, 128, 129, 200, 300}
+
+	for iter := 0; iter < 1000; iter++ {
+		alphabet := alphabets[iter%len(alphabets)]
+		a := randomRunes(rng, alphabet, lengths[rng.Intn(len(lengths))])
+		var b []rune
+		if rng.Intn(2) == 0 {
+			b = randomRunes(rng, alphabet, lengths[rng.Intn(len(lengths))])
+		} else {
+			// Edited copy: the realistic near-duplicate case
+			b = []rune(mutateText(rng, string(a), rng.Intn(10)))
+		}
+
+		want := referenceDistance(a, b)
+		if got := levenshteinMyers(string(a), string(b)); got != want {
+			t.Fatalf("levenshteinMyers(%q, %q) = %d, want %d", string(a), string(b), got, want)
+		}
+		for _, maxDistance := range []int{0, want - 1, want, want + 5} {
+			if maxDistance < 0 {
+				continue
+			}
+			got := myersDistance(a, b, maxDistance)
+			if want <= maxDistance && got != want {
+				t.Fatalf("max=%d: got %d, want exact %d", maxDistance, got, want)
+			}
+			if want > maxDistance && got <= maxDistance {
+				t.Fatalf("max=%d: got %d, should exceed the bound (exact %d)", maxDistance, got, want)
+			}
+		}
+	}
+}
+
+func TestMyersEdgeCases(t *testing.T) {
+	tests := []struct {
+		s, t string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"same", "same", 0},
+		{"flaw", "lawn", 2},
+	}
+	for _, tt := range tests {
+		if got := levenshteinMyers(tt.s, tt.t); got != tt.want {
+			t.Errorf("levenshteinMyers(%q, %q) = %d, want %d", tt.s, tt.t, got, tt.want)
+		}
+	}
+}
+
+// BenchmarkMyersVsDP compares the bit-parallel distance with the scalar DP
+func BenchmarkMyersVsDP(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{64, 750, 2000} {
+		s := string(randomRunes(rng, []rune("abcdefghijklmnopqrstuvwxyz "), n))
+		t := mutateText(rng, s, n/20)
+
+		b.Run(fmt.Sprintf("DP %d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = levenshteinDistanceScalar(s, t)
+			}
+		})
+		b.Run(fmt.Sprintf("Myers %d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = levenshteinMyers(s, t)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package duplicatecheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// nearestNeighborChunk is the number of products NearestNeighborReport
+// queries before handing their entries on, bounding what the streaming
+// writer holds
+const nearestNeighborChunk = 1024
+
+// defaultWidenSample is the number of indexed products a product without LSH
+// candidates is compared with when NearestNeighborOptions.WidenSample is 0
+const defaultWidenSample = 256
+
+// NeighborSource is how a product's nearest neighbor was found
+type NeighborSource int
+
+const (
+	// NeighborFromCandidates means the neighbor is the best of the product's
+	// LSH candidates (every indexed product below the exact mode cutoff)
+	NeighborFromCandidates NeighborSource = iota
+	// NeighborWidened means no candidate could be verified, and the neighbor
+	// is the best of a sample of the index
+	NeighborWidened
+	// NeighborNotFound means no other product could be compared, even after
+	// widening: the entry has no NearestID and its Similarity means nothing
+	NeighborNotFound
+)
+
+// String returns the source's name: "candidates", "widened" or "not-found"
+func (s NeighborSource) String() string {
+	switch s {
+	case NeighborFromCandidates:
+		return "candidates"
+	case NeighborWidened:
+		return "widened"
+	default:
+		return "not-found"
+	}
+}
+
+// MarshalText encodes the source as its name
+func (s NeighborSource) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// NNEntry is one product's nearest neighbor among the indexed products
+// Similarity is a uniqueness score read backwards: near 1 for a probable
+// duplicate, near 0 for a product unlike anything else (often junk or
+// miscategorized).
+type NNEntry struct {
+	ProductID          string         `json:"product_id"`
+	NearestID          string         `json:"nearest_id,omitempty"` // Empty when Source is NeighborNotFound
+	Similarity         float64        `json:"similarity"`           // CombinedSimilarity of the pair
+	CandidatesExamined int            `json:"candidates_examined"`  // Products compared, widening included
+	Source             NeighborSource `json:"source"`
+}
+
+// Found reports whether the entry has a nearest neighbor
+func (n NNEntry) Found() bool {
+	return n.Source != NeighborNotFound
+}
+
+// NearestNeighborOptions controls NearestNeighborReportCtx and WriteNearestNeighborReport
+type NearestNeighborOptions struct {
+	// WidenSample is the number of indexed products, spread evenly over the
+	// index, a product is compared with when none of its LSH candidates can be
+	// verified (0 = 256, negative = never widen)
+	WidenSample int
+}
+
+// nnHistogramBuckets is the number of buckets of a NearestNeighborHistogram
+const nnHistogramBuckets = 10
+
+// NearestNeighborHistogram counts nearest-neighbor similarities in buckets of width 0.1
+type NearestNeighborHistogram struct {
+	// Counts[i] is the number of products whose nearest neighbor has a
+	// similarity in [i/10, (i+1)/10); the last bucket includes 1.0
+	Counts [nnHistogramBuckets]int `json:"counts"`
+	// NotFound is the number of products without a nearest neighbor
+	NotFound int `json:"not_found"`
+}
+
+// add counts one entry
+func (h *NearestNeighborHistogram) add(entry *NNEntry) {
+	if !entry.Found() {
+		h.NotFound++
+		return
+	}
+	bucket := int(entry.Similarity * nnHistogramBuckets)
+	if bucket >= nnHistogramBuckets {
+		bucket = nnHistogramBuckets - 1
+	}
+	if bucket < 0 {
+		bucket = 0
+	}
+	h.Counts[bucket]++
+}
+
+// String renders the histogram as one line per bucket, with bars scaled to
+// the largest bucket
+func (h NearestNeighborHistogram) String() string {
+	const width = 40
+	largest := h.NotFound
+	for _, count := range h.Counts {
+		largest = max(largest, count)
+	}
+	bar := func(count int) string {
+		if largest == 0 {
+			return ""
+		}
+		return strings.Repeat("#", (count*width+largest-1)/largest)
+	}
+
+	var b strings.Builder
+	for i := nnHistogramBuckets - 1; i >= 0; i-- {
+		closing := ")"
+		if i == nnHistogramBuckets-1 {
+			closing = "]"
+		}
+		fmt.Fprintf(&b, "[%.1f, %.1f%s %7d %s\n", float64(i)/nnHistogramBuckets, float64(i+1)/nnHistogramBuckets, closing, h.Counts[i], bar(h.Counts[i]))
+	}
+	fmt.Fprintf(&b, "not found  %7d %s\n", h.NotFound, bar(h.NotFound))
+	return b.String()
+}
+
+// NearestNeighborReport returns each product's most similar other indexed
+// product, whatever the similarity, in input order
+// Products are queried against the index as in FindDuplicatesForOne, which is
+// built from products unless one is already built, and a product never
+// matches its own ID. Returns nil if the input is rejected by the
+// DuplicateIDPolicy or the index can't be built; use NearestNeighborReportCtx
+// to receive the error and the histogram.
+func (e *HybridEngine) NearestNeighborReport(products []Product) []NNEntry {
+	defer e.levenshteinEngine.guard("NearestNeighborReport", nil)
+	entries, _, _ := e.NearestNeighborReportCtx(context.Background(), products, NearestNeighborOptions{})
+	return entries
+}
+
+// NearestNeighborReportCtx is NearestNeighborReport with cancellation and
+// options, also returning the histogram of the entries' similarities
+// Products are queried in parallel. A product whose LSH candidates all fail
+// verification, or which has none (its buckets hold nobody else), is compared
+// with a sample of the index instead (NeighborWidened); if that finds no one
+// either, its entry says so (NeighborNotFound) rather than reporting 0. When
+// ctx is done the report stops and returns ctx.Err() with no entries.
+func (e *HybridEngine) NearestNeighborReportCtx(ctx context.Context, products []Product, opts NearestNeighborOptions) (_ []NNEntry, _ NearestNeighborHistogram, err error) {
+	defer e.levenshteinEngine.guard("NearestNeighborReportCtx", &err)
+	entries := make([]NNEntry, 0, len(products))
+	histogram, err := e.nearestNeighbors(ctx, products, opts, func(chunk []NNEntry) error {
+		entries = append(entries, chunk...)
+		return nil
+	})
+	if err != nil {
+		return nil, NearestNeighborHistogram{}, err
+	}
+	return entries, histogram, nil
+}
+
+// WriteNearestNeighborReport streams NearestNeighborReportCtx to w as JSON
+// Lines, one NNEntry per line in input order, followed by one line holding
+// the histogram as {"histogram": {...}}
+// Only a chunk of entries is held at a time, so large catalogs can be
+// reported without keeping every entry in memory. On error, the lines
+// already written stay written.
+func (e *HybridEngine) WriteNearestNeighborReport(ctx context.Context, w io.Writer, products []Product, opts NearestNeighborOptions) (_ NearestNeighborHistogram, err error) {
+	defer e.levenshteinEngine.guard("WriteNearestNeighborReport", &err)
+	enc := json.NewEncoder(w)
+	histogram, err := e.nearestNeighbors(ctx, products, opts, func(chunk []NNEntry) error {
+		for i := range chunk {
+			if err := enc.Encode(&chunk[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return histogram, err
+	}
+	return histogram, enc.Encode(struct {
+		Histogram NearestNeighborHistogram `json:"histogram"`
+	}{histogram})
+}
+
+// nearestNeighbors finds the nearest neighbor of every product, handing the
+// entries to yield a chunk at a time, in input order
+func (e *HybridEngine) nearestNeighbors(ctx context.Context, products []Product, opts NearestNeighborOptions, yield func([]NNEntry) error) (NearestNeighborHistogram, error) {
+	var histogram NearestNeighborHistogram
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return histogram, err
+	}
+	idx := e.currentIndex()
+	if idx == nil {
+		if err := e.BuildIndexCtx(ctx, resolved); err != nil {
+			return histogram, err
+		}
+		idx = e.currentIndex()
+	}
+	widen := opts.WidenSample
+	if widen == 0 {
+		widen = defaultWidenSample
+	}
+
+	chunk := make([]NNEntry, 0, nearestNeighborChunk)
+	for start := 0; start < len(resolved); start += nearestNeighborChunk {
+		end := min(start+nearestNeighborChunk, len(resolved))
+		chunk = chunk[:end-start]
+		e.forEachParallel(ctx, end-start, func(i int) {
+			chunk[i] = e.nearestNeighbor(idx, &resolved[start+i], widen)
+		})
+		if err := ctx.Err(); err != nil {
+			return histogram, err
+		}
+		for i := range chunk {
+			histogram.add(&chunk[i])
+		}
+		if err := yield(chunk); err != nil {
+			return histogram, err
+		}
+	}
+	return histogram, nil
+}
+
+// forEachParallel calls fn for 0..n-1 on the engine's workers, stopping early
+// when ctx is done
+func (e *HybridEngine) forEachParallel(ctx context.Context, n int, fn func(i int)) {
+	workers := e.levenshteinEngine.workerCount(n)
+	if workers <= 1 {
+		for i := 0; i < n && ctx.Err() == nil; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var next int64
+	var group workerGroup
+	for w := 0; w < workers; w++ {
+		group.Go(func() {
+			for ctx.Err() == nil && !group.stopped() {
+				i := int(atomic.AddInt64(&next, 1)) - 1
+				if i >= n {
+					return
+				}
+				fn(i)
+			}
+		})
+	}
+	group.Wait()
+}
+
+// nearestNeighbor finds the most similar indexed product to product, widening
+// to up to widen evenly spread indexed products when no LSH candidate verifies
+func (e *HybridEngine) nearestNeighbor(idx *LSHIndex, product *Product, widen int) NNEntry {
+	entry := NNEntry{ProductID: product.ID, Source: NeighborNotFound}
+	query := e.newQuery(product)
+	consider := func(id string, source NeighborSource) {
+		if id == product.ID {
+			return
+		}
+		entry.CandidatesExamined++
+		result, ok := e.verifyCandidate(idx, product, query, id, 0)
+		if !ok {
+			return
+		}
+		similarity := result.CombinedSimilarity
+		if !entry.Found() || similarity > entry.Similarity || (similarity == entry.Similarity && id < entry.NearestID) {
+			entry.NearestID, entry.Similarity, entry.Source = id, similarity, source
+		}
+	}
+
+	candidates := e.findCandidates(idx, product, 0)
+	for _, candidate := range candidates {
+		consider(candidate.id, NeighborFromCandidates)
+	}
+	if entry.Found() || widen < 0 {
+		return entry
+	}
+
+	// Candidates that failed verification would fail again
+	tried := make(map[string]bool, len(candidates))
+	for _, candidate := range candidates {
+		tried[candidate.id] = true
+	}
+	ids := idx.ids
+	step := max(1, (len(ids)+widen-1)/widen)
+	for i := 0; i < len(ids); i += step {
+		if !tried[ids[i]] {
+			consider(ids[i], NeighborWidened)
+		}
+	}
+	return entry
+}
@@ -0,0 +1,159 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// plantedCatalog returns a generated catalog with an exact copy of one
+// product under another ID and a junk row unlike anything else
+func plantedCatalog() []Product {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 120)
+	duplicate := catalog[5]
+	duplicate.ID = "planted-duplicate"
+	return append(catalog, duplicate, Product{ID: "planted-junk", Name: "zzqx vvkk wpfj", Description: "qqqq 0000 xxxx jjjj"})
+}
+
+func TestNearestNeighborReport(t *testing.T) {
+	catalog := plantedCatalog()
+	engine := NewHybridEngine()
+	entries, histogram, err := engine.NearestNeighborReportCtx(context.Background(), catalog, NearestNeighborOptions{})
+	if err != nil {
+		t.Fatalf("NearestNeighborReportCtx failed: %v", err)
+	}
+	if len(entries) != len(catalog) {
+		t.Fatalf("%d entries for %d products", len(entries), len(catalog))
+	}
+	byID := make(map[string]NNEntry, len(entries))
+	for i, entry := range entries {
+		if entry.ProductID != catalog[i].ID {
+			t.Fatalf("entry %d is for %s, want %s", i, entry.ProductID, catalog[i].ID)
+		}
+		if entry.NearestID == entry.ProductID {
+			t.Errorf("%s is its own nearest neighbor", entry.ProductID)
+		}
+		byID[entry.ProductID] = entry
+	}
+
+	tests := []struct {
+		id          string
+		wantNearest string
+		wantSource  NeighborSource
+		check       func(NNEntry) bool
+	}{
+		{"planted-duplicate", catalog[5].ID, NeighborFromCandidates, func(e NNEntry) bool { return e.Similarity == 1 }},
+		{catalog[5].ID, "planted-duplicate", NeighborFromCandidates, func(e NNEntry) bool { return e.Similarity == 1 }},
+		{"planted-junk", "", NeighborWidened, func(e NNEntry) bool {
+			return e.NearestID != "" && e.Similarity < 0.5 && e.CandidatesExamined > 0 && e.CandidatesExamined <= defaultWidenSample
+		}},
+	}
+	for _, tt := range tests {
+		entry := byID[tt.id]
+		if tt.wantNearest != "" && entry.NearestID != tt.wantNearest {
+			t.Errorf("%s: nearest %s, want %s", tt.id, entry.NearestID, tt.wantNearest)
+		}
+		if entry.Source != tt.wantSource || !tt.check(entry) {
+			t.Errorf("%s: unexpected entry %+v", tt.id, entry)
+		}
+	}
+
+	total := histogram.NotFound
+	for _, count := range histogram.Counts {
+		total += count
+	}
+	if total != len(catalog) || histogram.Counts[nnHistogramBuckets-1] < 2 {
+		t.Errorf("histogram %+v doesn't count every product", histogram)
+	}
+	if rendered := histogram.String(); !strings.Contains(rendered, "[0.9, 1.0]") || !strings.Contains(rendered, "not found") {
+		t.Errorf("histogram rendered as:\n%s", rendered)
+	}
+}
+
+func TestNearestNeighborNotFound(t *testing.T) {
+	catalog := plantedCatalog()
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	entries, histogram, err := engine.NearestNeighborReportCtx(context.Background(), catalog[len(catalog)-1:], NearestNeighborOptions{WidenSample: -1})
+	if err != nil {
+		t.Fatalf("NearestNeighborReportCtx failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Found() || entries[0].NearestID != "" || entries[0].Source != NeighborNotFound {
+		t.Errorf("junk row without widening: %+v", entries)
+	}
+	if histogram.NotFound != 1 {
+		t.Errorf("histogram %+v, want the junk row as not found", histogram)
+	}
+
+	// A lone indexed product has no one to compare with, widened or not
+	lone := NewHybridEngine()
+	if entries := lone.NearestNeighborReport(catalog[:1]); len(entries) != 1 || entries[0].Found() {
+		t.Errorf("lone product: %+v", entries)
+	}
+}
+
+func TestNearestNeighborReportCancelled(t *testing.T) {
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(plantedCatalog()); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	entries, _, err := engine.NearestNeighborReportCtx(ctx, plantedCatalog(), NearestNeighborOptions{})
+	if !errors.Is(err, context.Canceled) || entries != nil {
+		t.Errorf("got %d entries, err %v; want context.Canceled", len(entries), err)
+	}
+}
+
+func TestWriteNearestNeighborReport(t *testing.T) {
+	catalog := plantedCatalog()
+	engine := NewHybridEngine()
+	want, wantHistogram, err := engine.NearestNeighborReportCtx(context.Background(), catalog, NearestNeighborOptions{})
+	if err != nil {
+		t.Fatalf("NearestNeighborReportCtx failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	histogram, err := engine.WriteNearestNeighborReport(context.Background(), &out, catalog, NearestNeighborOptions{})
+	if err != nil {
+		t.Fatalf("WriteNearestNeighborReport failed: %v", err)
+	}
+	if histogram != wantHistogram {
+		t.Errorf("histogram %+v, want %+v", histogram, wantHistogram)
+	}
+
+	scanner := bufio.NewScanner(&out)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != len(want)+1 {
+		t.Fatalf("%d lines, want %d entries and the histogram", len(lines), len(want))
+	}
+	for i, entry := range want {
+		var got struct {
+			ProductID  string  `json:"product_id"`
+			NearestID  string  `json:"nearest_id"`
+			Source     string  `json:"source"`
+			Similarity float64 `json:"similarity"`
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &got); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		if got.ProductID != entry.ProductID || got.NearestID != entry.NearestID || got.Source != entry.Source.String() || got.Similarity != entry.Similarity {
+			t.Fatalf("line %d = %s, want %+v", i+1, lines[i], entry)
+		}
+	}
+	var last struct {
+		Histogram *NearestNeighborHistogram `json:"histogram"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil || last.Histogram == nil || *last.Histogram != histogram {
+		t.Errorf("last line %s, err %v", lines[len(lines)-1], err)
+	}
+}
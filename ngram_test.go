@@ -1,6 +1,10 @@
 package duplicatecheck
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -155,3 +159,147 @@ func BenchmarkNgramGeneration(b *testing.B) {
 		_ = generateNgrams(longText, 3)
 	}
 }
+
+// cachedNgramKeys returns the field and size keys a product's cache holds
+func cachedNgramKeys(p *Product) map[ngramKey]bool {
+	c := p.loadCache()
+	c.ngramsMutex.RLock()
+	defer c.ngramsMutex.RUnlock()
+	keys := make(map[ngramKey]bool, len(c.ngramsCache))
+	for key := range c.ngramsCache {
+		keys[key] = true
+	}
+	return keys
+}
+
+func TestFieldNgramCaching(t *testing.T) {
+	product := Product{ID: "fields", Name: "Apple iPhone", Description: "Smartphone with A15 chip"}
+
+	name := product.GetNameNgrams(3)
+	desc := product.GetDescriptionNgrams(3)
+	if len(name) != 10 || len(desc) != 22 || name[0][0] != "app" || desc[0][0] != "sma" {
+		t.Fatalf("name %d n-grams from %q, description %d from %q", len(name), name[0][0], len(desc), desc[0][0])
+	}
+
+	// Second requests hit the cache: the same slices, per field
+	if again := product.GetDescriptionNgrams(3); &again[0] != &desc[0] {
+		t.Error("description n-grams regenerated")
+	}
+	if alias := product.GetNgrams(3); &alias[0] != &name[0] {
+		t.Error("GetNgrams doesn't share GetNameNgrams' cache")
+	}
+	want := map[ngramKey]bool{{ngramName, 3}: true, {ngramDescription, 3}: true}
+	if got := cachedNgramKeys(&product); !reflect.DeepEqual(got, want) {
+		t.Errorf("cached keys %v, want %v", got, want)
+	}
+
+	// Edited text gets n-grams of its own
+	product.Description = "Tablet"
+	if got := product.GetDescriptionNgrams(3); len(got) != 4 || got[0][0] != "tab" {
+		t.Errorf("edited description n-grams %v", got)
+	}
+	if got := (&Product{}).GetDescriptionNgrams(0); len(got) != 0 {
+		t.Errorf("GetDescriptionNgrams(0) = %v", got)
+	}
+}
+
+func TestDescriptionNgramEviction(t *testing.T) {
+	if previous := SetDescriptionNgramCacheLimit(2); previous != DefaultDescriptionNgramCacheLimit {
+		t.Fatalf("default limit %d", previous)
+	}
+	defer SetDescriptionNgramCacheLimit(0)
+
+	product := Product{ID: "evict", Name: "Wireless Mouse", Description: "Ergonomic wireless mouse with silent clicks"}
+	for _, n := range []int{2, 3, 4, 5, 6} {
+		product.GetNameNgrams(n) // Names aren't capped
+	}
+	product.GetDescriptionNgrams(2)
+	product.GetDescriptionNgrams(3)
+	product.GetDescriptionNgrams(2) // 3 is now the least recently requested
+	product.GetDescriptionNgrams(4)
+
+	keys := cachedNgramKeys(&product)
+	if !keys[ngramKey{ngramDescription, 2}] || !keys[ngramKey{ngramDescription, 4}] || keys[ngramKey{ngramDescription, 3}] {
+		t.Errorf("cached keys %v, want description sizes 2 and 4", keys)
+	}
+	if len(keys) != 7 {
+		t.Errorf("%d cached keys, want 5 name sizes and 2 description sizes", len(keys))
+	}
+	// An evicted size is generated again
+	if got := product.GetDescriptionNgrams(3); len(got) != 41 {
+		t.Errorf("regenerated %d n-grams", len(got))
+	}
+
+	if previous := SetDescriptionNgramCacheLimit(-1); previous != 2 {
+		t.Errorf("previous limit %d, want 2", previous)
+	}
+	if got := descriptionNgramLimit(); got != DefaultDescriptionNgramCacheLimit {
+		t.Errorf("limit %d after reset", got)
+	}
+}
+
+func TestFieldNgramConcurrency(t *testing.T) {
+	defer SetDescriptionNgramCacheLimit(SetDescriptionNgramCacheLimit(2))
+	product := Product{ID: "concurrent", Name: "Concurrent Test Product", Description: strings.Repeat("shared description text ", 20)}
+	want := map[ngramKey]int{}
+	for n := 1; n <= 6; n++ {
+		want[ngramKey{ngramName, n}] = len(generateNgrams("concurrent test product", n))
+		want[ngramKey{ngramDescription, n}] = len(generateNgrams(strings.TrimSpace(product.Description), n))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 8)
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				n := (w+i)%6 + 1
+				key, got := ngramKey{ngramName, n}, 0
+				if i%2 == 0 {
+					key.field, got = ngramDescription, len(product.GetDescriptionNgrams(n))
+				} else {
+					got = len(product.GetNameNgrams(n))
+				}
+				if got != want[key] {
+					errs <- fmt.Sprintf("%v: %d n-grams, want %d", key, got, want[key])
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	held := 0
+	for key := range cachedNgramKeys(&product) {
+		if key.field == ngramDescription {
+			held++
+		}
+	}
+	if held > 2 {
+		t.Errorf("%d description sizes cached over a limit of 2", held)
+	}
+}
+
+func BenchmarkDescriptionNgrams(b *testing.B) {
+	description := strings.Repeat("Durable stainless steel body, dishwasher safe, 2 year warranty. ", 47) // ~3000 chars
+	b.Run("Uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = generateNgrams(description, 3)
+		}
+	})
+	b.Run("Cached", func(b *testing.B) {
+		product := Product{ID: "bench", Name: "Steel Bottle", Description: description}
+		product.GetDescriptionNgrams(3) // First comparison
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = product.GetDescriptionNgrams(3)
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package duplicatecheck
+
+import "sync/atomic"
+
+// DefaultDescriptionNgramCacheLimit is the default number of description
+// n-gram sizes a product keeps cached (see SetDescriptionNgramCacheLimit)
+const DefaultDescriptionNgramCacheLimit = 4
+
+// descriptionNgramCacheLimit is the limit in effect (0 = the default)
+var descriptionNgramCacheLimit atomic.Int32
+
+// SetDescriptionNgramCacheLimit caps how many description n-gram sizes each
+// product keeps cached, returning the previous cap; limit < 1 restores
+// DefaultDescriptionNgramCacheLimit
+// The n-grams of a 3000-character description take far more memory than the
+// description, so once a product holds more sizes than the cap, the size
+// requested least recently is evicted. Name n-grams are not capped. The cap
+// is package-wide since the cache belongs to the Product, not to an engine.
+func SetDescriptionNgramCacheLimit(limit int) int {
+	if limit < 1 {
+		limit = 0
+	}
+	previous := int(descriptionNgramCacheLimit.Swap(int32(limit)))
+	if previous == 0 {
+		return DefaultDescriptionNgramCacheLimit
+	}
+	return previous
+}
+
+// descriptionNgramLimit returns the description n-gram cap in effect
+func descriptionNgramLimit() int {
+	if limit := int(descriptionNgramCacheLimit.Load()); limit > 0 {
+		return limit
+	}
+	return DefaultDescriptionNgramCacheLimit
+}
+
+// ngramField selects the prepared field n-grams are generated from
+type ngramField uint8
+
+const (
+	ngramName ngramField = iota
+	ngramDescription
+)
+
+// ngramKey identifies a cached n-gram list
+type ngramKey struct {
+	field ngramField
+	n     int
+}
+
+// cachedNgrams is a cached n-gram list and when it was last requested
+type cachedNgrams struct {
+	ngrams [][2]string
+	used   atomic.Uint64 // productCache.ngramsClock at the last request
+}
+
+// GetNgrams returns cached n-grams for the product name
+// Alias of GetNameNgrams, kept for compatibility
+func (p *Product) GetNgrams(n int) [][2]string {
+	return p.GetNameNgrams(n)
+}
+
+// GetNameNgrams returns cached n-grams of the product's normalized name
+// Generates and caches n-grams on first call, returns cached version on subsequent calls
+// n parameter specifies the n-gram size (e.g., 2 for bigrams, 3 for trigrams)
+// Thread-safe; the returned slice is shared and must not be modified
+func (p *Product) GetNameNgrams(n int) [][2]string {
+	return p.fieldNgrams(defaultPreparer, ngramName, n)
+}
+
+// GetDescriptionNgrams returns cached n-grams of the product's normalized
+// description, as GetNameNgrams does for the name
+// At most SetDescriptionNgramCacheLimit sizes are kept per product.
+func (p *Product) GetDescriptionNgrams(n int) [][2]string {
+	return p.fieldNgrams(defaultPreparer, ngramDescription, n)
+}
+
+// fieldNgrams returns the cached n-grams of a field as prepared by prep
+func (p *Product) fieldNgrams(prep *textPreparer, field ngramField, n int) [][2]string {
+	if n < 1 {
+		return [][2]string{}
+	}
+	return p.loadCacheFor(prep).ngrams(field, n)
+}
+
+// ngrams returns the n-grams of a prepared field, generating them on first use
+// Thread-safe with double-checked locking pattern
+func (c *productCache) ngrams(field ngramField, n int) [][2]string {
+	key := ngramKey{field: field, n: n}
+
+	// Check if already cached (fast path - read-heavy, most calls hit this)
+	c.ngramsMutex.RLock()
+	if cached, exists := c.ngramsCache[key]; exists {
+		cached.used.Store(c.ngramsClock.Add(1))
+		c.ngramsMutex.RUnlock()
+		return cached.ngrams
+	}
+	c.ngramsMutex.RUnlock()
+
+	// Slow path: generate outside the lock to minimize contention
+	text := c.normalizedName
+	if field == ngramDescription {
+		text = c.normalizedDesc
+	}
+	entry := &cachedNgrams{ngrams: generateNgrams(text, n)}
+
+	c.ngramsMutex.Lock()
+	defer c.ngramsMutex.Unlock()
+
+	if c.ngramsCache == nil {
+		c.ngramsCache = make(map[ngramKey]*cachedNgrams)
+	}
+
+	// Double-check: another goroutine might have already cached this key
+	if cached, exists := c.ngramsCache[key]; exists {
+		cached.used.Store(c.ngramsClock.Add(1))
+		return cached.ngrams
+	}
+
+	entry.used.Store(c.ngramsClock.Add(1))
+	c.ngramsCache[key] = entry
+	if field == ngramDescription {
+		c.evictDescriptionNgrams(descriptionNgramLimit())
+	}
+	return entry.ngrams
+}
+
+// evictDescriptionNgrams drops the least recently requested description
+// n-gram sizes until at most limit remain; the caller holds ngramsMutex
+func (c *productCache) evictDescriptionNgrams(limit int) {
+	for {
+		held := 0
+		var oldest ngramKey
+		var oldestUse uint64
+		for key, entry := range c.ngramsCache {
+			if key.field != ngramDescription {
+				continue
+			}
+			held++
+			if used := entry.used.Load(); held == 1 || used < oldestUse {
+				oldest, oldestUse = key, used
+			}
+		}
+		if held <= limit {
+			return
+		}
+		delete(c.ngramsCache, oldest)
+	}
+}
+
+// generateNgrams generates n-grams of size n from a string
+// Returns pairs of (ngram_string, position) for efficient comparison
+func generateNgrams(s string, n int) [][2]string {
+	if n < 1 || len(s) < n {
+		return [][2]string{}
+	}
+
+	ngrams := make([][2]string, 0, len(s)-n+1)
+	runes := []rune(s)
+
+	for i := 0; i <= len(runes)-n; i++ {
+		ngram := string(runes[i : i+n])
+		ngrams = append(ngrams, [2]string{ngram, string(rune(i))}) // Store ngram and position
+	}
+
+	return ngrams
+}
@@ -0,0 +1,442 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of NotifyOptions
+const (
+	// DefaultNotifyBatchSize is the default NotifyOptions.BatchSize
+	DefaultNotifyBatchSize = 100
+	// DefaultNotifyBuffer is the default NotifyOptions.Buffer
+	DefaultNotifyBuffer = 256
+	// DefaultNotifyConcurrency is the default NotifyOptions.Concurrency
+	DefaultNotifyConcurrency = 4
+)
+
+// ErrNotificationQueueClosed is returned by NotificationQueue.Close when the
+// queue was already closed
+var ErrNotificationQueueClosed = errors.New("duplicatecheck: notification queue closed")
+
+// Notification sources, as reported in Notification.Source
+const (
+	// NotifySourceScan marks matches of a FindDuplicatesChan scan
+	NotifySourceScan = "scan"
+	// NotifySourceGatekeeper marks the matches of a Gatekeeper decision
+	NotifySourceGatekeeper = "gatekeeper"
+)
+
+// Notification reports matches to a ResultNotifier; it is also the JSON
+// payload HTTPWebhookNotifier posts
+type Notification struct {
+	Source            string    `json:"source"`             // NotifySourceScan or NotifySourceGatekeeper
+	RunID             string    `json:"run_id"`             // Identifies the scan or Gatekeeper decision; its notifications share it
+	ConfigFingerprint string    `json:"config_fingerprint"` // EngineConfig.Fingerprint of the engine that scored the matches
+	Time              time.Time `json:"time"`               // When the notification was queued, UTC
+	// Action is the GateAction of a Gatekeeper decision ("review" or "reject")
+	Action  string          `json:"action,omitempty"`
+	Matches []NotifiedMatch `json:"matches"`
+}
+
+// NotifiedMatch is one match of a Notification
+// Products are as the results held them, so redacted under WithResultRedaction,
+// and further redacted by NotifyOptions.Redactor when one is set.
+type NotifiedMatch struct {
+	ProductA              NotifiedProduct `json:"product_a"`
+	ProductB              NotifiedProduct `json:"product_b"`
+	NameSimilarity        float64         `json:"name_similarity"`
+	DescriptionSimilarity float64         `json:"description_similarity"`
+	CombinedSimilarity    float64         `json:"combined_similarity"`
+	DuplicateProbability  float64         `json:"duplicate_probability,omitempty"`
+	Threshold             float64         `json:"threshold"`
+	MatchType             string          `json:"match_type"`
+	ReasonCodes           []ReasonCode    `json:"reason_codes,omitempty"`
+}
+
+// NotifiedProduct is a product of a NotifiedMatch
+type NotifiedProduct struct {
+	ID          string `json:"id"`
+	SourceID    string `json:"source_id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ResultNotifier delivers notifications of matches to a downstream system
+// Notify is called from the delivery goroutines of a NotificationQueue, up to
+// NotifyOptions.Concurrency at a time, and must return once ctx is done.
+type ResultNotifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NotifyMode selects how matches are grouped into notifications
+type NotifyMode int
+
+const (
+	// NotifyPerMatch sends one notification per match (default)
+	NotifyPerMatch NotifyMode = iota
+	// NotifyPerBatch groups a scan's matches into notifications of up to
+	// NotifyOptions.BatchSize, the last one sent when the scan ends; a
+	// Gatekeeper decision is one batch
+	NotifyPerBatch
+)
+
+// String returns the mode's name: "per-match" or "per-batch"
+func (m NotifyMode) String() string {
+	switch m {
+	case NotifyPerMatch:
+		return "per-match"
+	case NotifyPerBatch:
+		return "per-batch"
+	default:
+		return fmt.Sprintf("NotifyMode(%d)", int(m))
+	}
+}
+
+// OverflowPolicy decides what happens to a notification when the queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowDrop drops the notification, counting it in
+	// NotificationStats.Dropped, so a slow endpoint never slows a scan (default)
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock makes the scan wait for room, so no notification is lost
+	// but a slow endpoint slows the scan once the buffer fills
+	OverflowBlock
+)
+
+// String returns the policy's name: "drop" or "block"
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDrop:
+		return "drop"
+	case OverflowBlock:
+		return "block"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+// NotifyOptions configures a NotificationQueue
+type NotifyOptions struct {
+	// MinSimilarity is the lowest CombinedSimilarity notified; 0 notifies
+	// every match a scan returns or a Gatekeeper decision holds
+	MinSimilarity float64
+	// Mode groups matches into notifications (default NotifyPerMatch)
+	Mode NotifyMode
+	// BatchSize is the most matches per notification under NotifyPerBatch
+	// (default DefaultNotifyBatchSize)
+	BatchSize int
+	// Buffer is the number of notifications queued for delivery (default
+	// DefaultNotifyBuffer); Overflow decides what a full queue does
+	Buffer int
+	// Concurrency is the number of deliveries in flight (default
+	// DefaultNotifyConcurrency)
+	Concurrency int
+	// Overflow is what happens to notifications the full queue can't take
+	Overflow OverflowPolicy
+	// Redactor masks the notified products, on top of WithResultRedaction
+	Redactor *Redactor
+	// Logger receives failed deliveries at Warn (nil = none)
+	Logger *slog.Logger
+}
+
+// NotificationStats counts a NotificationQueue's notifications
+type NotificationStats struct {
+	Queued    uint64 `json:"queued"`    // Accepted for delivery
+	Delivered uint64 `json:"delivered"` // Notify returned nil
+	Failed    uint64 `json:"failed"`    // Notify returned an error or panicked
+	Dropped   uint64 `json:"dropped"`   // Refused by a full queue under OverflowDrop, or a closed one
+}
+
+// NotificationQueue delivers notifications to a ResultNotifier from its own
+// goroutines, so scans and Gatekeeper decisions only wait to queue them
+// Share one queue between engines and Gatekeepers with WithNotifier, and Close
+// it when done to deliver what is queued. Safe for concurrent use.
+type NotificationQueue struct {
+	notifier ResultNotifier
+	opts     NotifyOptions
+	queue    chan Notification
+	workers  sync.WaitGroup
+	ctx      context.Context // Cancelled when Close gives up waiting
+	cancel   context.CancelFunc
+
+	mu     sync.RWMutex // Held for reading while queuing, for writing to close queue
+	closed bool
+
+	queued, delivered, failed, dropped atomic.Uint64
+}
+
+// NewNotificationQueue starts the delivery goroutines of a queue for notifier
+func NewNotificationQueue(notifier ResultNotifier, opts NotifyOptions) *NotificationQueue {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultNotifyBatchSize
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultNotifyBuffer
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultNotifyConcurrency
+	}
+	q := &NotificationQueue{
+		notifier: notifier,
+		opts:     opts,
+		queue:    make(chan Notification, opts.Buffer),
+	}
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	for i := 0; i < opts.Concurrency; i++ {
+		q.workers.Add(1)
+		go q.deliver()
+	}
+	return q
+}
+
+// Options returns the queue's options, defaults filled in
+func (q *NotificationQueue) Options() NotifyOptions {
+	return q.opts
+}
+
+// Stats returns the queue's counts so far
+func (q *NotificationQueue) Stats() NotificationStats {
+	return NotificationStats{
+		Queued:    q.queued.Load(),
+		Delivered: q.delivered.Load(),
+		Failed:    q.failed.Load(),
+		Dropped:   q.dropped.Load(),
+	}
+}
+
+// Close stops accepting notifications and waits for the queued ones to be
+// delivered; when ctx is done first, deliveries in flight are cancelled and
+// ctx.Err() returned. Later notifications are dropped.
+func (q *NotificationQueue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrNotificationQueueClosed
+	}
+	q.closed = true
+	close(q.queue)
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// push queues n under the overflow policy
+func (q *NotificationQueue) push(n Notification) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		q.dropped.Add(1)
+		return
+	}
+	if q.opts.Overflow == OverflowBlock {
+		q.queue <- n
+		q.queued.Add(1)
+		return
+	}
+	select {
+	case q.queue <- n:
+		q.queued.Add(1)
+	default:
+		q.dropped.Add(1)
+	}
+}
+
+// deliver passes queued notifications to the notifier until Close
+func (q *NotificationQueue) deliver() {
+	defer q.workers.Done()
+	for n := range q.queue {
+		if err := q.notify(n); err != nil {
+			q.failed.Add(1)
+			if q.opts.Logger != nil {
+				q.opts.Logger.LogAttrs(context.Background(), slog.LevelWarn, "notification failed",
+					slog.String("source", n.Source),
+					slog.String("run_id", n.RunID),
+					slog.Int("matches", len(n.Matches)),
+					slog.String("reason", err.Error()))
+			}
+			continue
+		}
+		q.delivered.Add(1)
+	}
+}
+
+// notify calls the notifier, turning its panic into an error so the other
+// notifications are still delivered
+func (q *NotificationQueue) notify(n Notification) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			failure := newPanicError(recovered)
+			failure.Op = "ResultNotifier.Notify"
+			panicsRecovered.Add(1)
+			err = failure
+		}
+	}()
+	return q.notifier.Notify(q.ctx, n)
+}
+
+// notifyRun groups the matches of one scan or decision into notifications
+type notifyRun struct {
+	queue  *NotificationQueue
+	source string
+	id     string
+	config string
+	action string
+	batch  []NotifiedMatch
+}
+
+// start begins a run of source's notifications, or returns nil for a nil queue
+func (q *NotificationQueue) start(source, config string) *notifyRun {
+	if q == nil {
+		return nil
+	}
+	return &notifyRun{queue: q, source: source, id: newAuditRunID(), config: config}
+}
+
+// add notifies result, or adds it to the batch, if it is similar enough
+func (r *notifyRun) add(result *ComparisonResult) {
+	if r == nil || result.CombinedSimilarity < r.queue.opts.MinSimilarity {
+		return
+	}
+	match := r.queue.match(result)
+	if r.queue.opts.Mode == NotifyPerMatch {
+		r.send([]NotifiedMatch{match})
+		return
+	}
+	r.batch = append(r.batch, match)
+	if len(r.batch) >= r.queue.opts.BatchSize {
+		r.flush()
+	}
+}
+
+// flush sends the batched matches
+func (r *notifyRun) flush() {
+	if r == nil || len(r.batch) == 0 {
+		return
+	}
+	r.send(r.batch)
+	r.batch = nil
+}
+
+// send queues a notification of matches
+func (r *notifyRun) send(matches []NotifiedMatch) {
+	r.queue.push(Notification{
+		Source:            r.source,
+		RunID:             r.id,
+		ConfigFingerprint: r.config,
+		Time:              time.Now().UTC(),
+		Action:            r.action,
+		Matches:           matches,
+	})
+}
+
+// match converts a result to its notified form
+func (q *NotificationQueue) match(result *ComparisonResult) NotifiedMatch {
+	return NotifiedMatch{
+		ProductA:              q.product(&result.ProductA),
+		ProductB:              q.product(&result.ProductB),
+		NameSimilarity:        result.NameSimilarity,
+		DescriptionSimilarity: result.DescriptionSimilarity,
+		CombinedSimilarity:    result.CombinedSimilarity,
+		DuplicateProbability:  result.DuplicateProbability,
+		Threshold:             result.ThresholdUsed,
+		MatchType:             result.MatchType.String(),
+		ReasonCodes:           result.ReasonCodes,
+	}
+}
+
+// product converts a product to its notified form, redacted
+func (q *NotificationQueue) product(p *Product) NotifiedProduct {
+	redacted := q.opts.Redactor.RedactProduct(*p)
+	return NotifiedProduct{ID: redacted.ID, SourceID: redacted.SourceID, Name: redacted.Name, Description: redacted.Description}
+}
+
+// resultNotifier is the notification configuration of an engine
+type resultNotifier struct {
+	queue       *NotificationQueue
+	fingerprint func() string // ConfigFingerprint of the engine WithNotifier was called on
+}
+
+// WithNotifier sends the matches of every FindDuplicatesChan scan to queue,
+// as its options select; nil turns it off (the default). Returns the engine
+// for chaining.
+// A match is queued once it has been sent on the result channel, and a
+// NotifyPerBatch scan queues its last batch when it ends, cancelled or not.
+// Delivery runs on the queue's goroutines: under OverflowDrop a slow endpoint
+// never slows the scan, under OverflowBlock it does once the buffer is full.
+func (e *LevenshteinEngine) WithNotifier(queue *NotificationQueue) *LevenshteinEngine {
+	if queue == nil {
+		e.notifier = nil
+		return e
+	}
+	e.notifier = &resultNotifier{queue: queue, fingerprint: e.ConfigFingerprint}
+	return e
+}
+
+// WithNotifier sends the matches of every FindDuplicatesChan scan to queue,
+// with the hybrid engine's config fingerprint (see
+// LevenshteinEngine.WithNotifier). Returns the engine for chaining.
+func (e *HybridEngine) WithNotifier(queue *NotificationQueue) *HybridEngine {
+	e.levenshteinEngine.WithNotifier(queue)
+	if notifier := e.levenshteinEngine.notifier; notifier != nil {
+		notifier.fingerprint = e.ConfigFingerprint
+	}
+	return e
+}
+
+// startNotify starts notifying a scan's matches, or returns nil when
+// notifications are off
+func (e *LevenshteinEngine) startNotify() *notifyRun {
+	if e.notifier == nil {
+		return nil
+	}
+	return e.notifier.queue.start(NotifySourceScan, e.notifier.fingerprint())
+}
+
+// WithNotifier sends the matches of Evaluate and Admit decisions to queue
+// (nil, the default, sends none); returns the gatekeeper for chaining
+// Decisions to insert send nothing; a review or reject sends its matches,
+// those at or above NotifyOptions.MinSimilarity, with the decision's Action,
+// one notification per match or, under NotifyPerBatch, one per decision.
+// Partial decisions are only notified when they reject. Call it before
+// sharing the gatekeeper between goroutines.
+func (g *Gatekeeper) WithNotifier(queue *NotificationQueue) *Gatekeeper {
+	g.notifier = queue
+	return g
+}
+
+// notify sends a decision's matches to the gatekeeper's queue
+func (g *Gatekeeper) notify(decision *GateDecision) {
+	if g.notifier == nil || decision.Action == GateInsert {
+		return
+	}
+	config := ""
+	if fingerprinted, ok := g.engine.(interface{ ConfigFingerprint() string }); ok {
+		config = fingerprinted.ConfigFingerprint()
+	}
+	run := g.notifier.start(NotifySourceGatekeeper, config)
+	run.action = decision.Action.String()
+	for i := range decision.Matches {
+		run.add(&decision.Matches[i])
+	}
+	run.flush()
+}
@@ -0,0 +1,222 @@
+package duplicatecheck
+
+import (
+	"context"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotifyScan(t *testing.T) {
+	catalog := loadSampleCatalog(t)
+	for _, mode := range []NotifyMode{NotifyPerMatch, NotifyPerBatch} {
+		t.Run(mode.String(), func(t *testing.T) {
+			receiver := &webhookReceiver{}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			queue := NewNotificationQueue(NewHTTPWebhookNotifier(server.URL, nil), NotifyOptions{Mode: mode, BatchSize: 2})
+			engine := NewHybridEngine().WithNotifier(queue)
+			if err := engine.BuildIndex(catalog); err != nil {
+				t.Fatal(err)
+			}
+			results, err := drainChan(engine.FindDuplicatesChan(context.Background(), catalog, 0.85, 0))
+			if err != nil || len(results) < 3 {
+				t.Fatalf("%d results, error %v", len(results), err)
+			}
+			if err := queue.Close(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			notifications := receiver.notifications(t)
+			want := len(results)
+			if mode == NotifyPerBatch {
+				want = (len(results) + 1) / 2
+			}
+			if len(notifications) != want {
+				t.Fatalf("%d notifications of %d results, want %d", len(notifications), len(results), want)
+			}
+			matches := 0
+			for _, n := range notifications {
+				if n.Source != NotifySourceScan || n.RunID != notifications[0].RunID || n.ConfigFingerprint != engine.ConfigFingerprint() {
+					t.Errorf("notification %s of run %s under %s", n.Source, n.RunID, n.ConfigFingerprint)
+				}
+				matches += len(n.Matches)
+			}
+			if matches != len(results) {
+				t.Errorf("%d matches notified, want %d", matches, len(results))
+			}
+			if stats := queue.Stats(); stats != (NotificationStats{Queued: uint64(want), Delivered: uint64(want)}) {
+				t.Errorf("Stats() = %+v", stats)
+			}
+		})
+	}
+}
+
+func TestNotifyMinSimilarityAndRedaction(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	redactor := &Redactor{Patterns: []*regexp.Regexp{regexp.MustCompile(`(?i)iphone`)}}
+	queue := NewNotificationQueue(NewHTTPWebhookNotifier(server.URL, nil), NotifyOptions{MinSimilarity: 0.99, Redactor: redactor})
+	engine := NewLevenshteinEngine().WithNotifier(queue)
+	results, err := drainChan(engine.FindDuplicatesChan(context.Background(), loadSampleCatalog(t), 0.85, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := 0
+	for _, r := range results {
+		if r.CombinedSimilarity >= 0.99 {
+			want++
+		}
+	}
+	notifications := receiver.notifications(t)
+	if want == 0 || want == len(results) || len(notifications) != want {
+		t.Fatalf("%d notifications of %d results, want %d", len(notifications), len(results), want)
+	}
+	for _, n := range notifications {
+		for _, product := range []NotifiedProduct{n.Matches[0].ProductA, n.Matches[0].ProductB} {
+			if strings.Contains(strings.ToLower(product.Name), "iphone") {
+				t.Errorf("notified name %q isn't redacted", product.Name)
+			}
+		}
+	}
+	// The scan's own results aren't redacted
+	for _, r := range results {
+		if strings.Contains(r.ProductA.Name, DefaultRedactionToken) {
+			t.Errorf("result product %q redacted", r.ProductA.Name)
+		}
+	}
+}
+
+func TestNotifySlowEndpoint(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 100)
+	engine := NewLevenshteinEngine()
+	baseline, err := drainChan(engine.FindDuplicatesChan(context.Background(), catalog, 0.8, 0))
+	if err != nil || len(baseline) < 10 {
+		t.Fatalf("%d results, error %v", len(baseline), err)
+	}
+
+	t.Run("Adequate buffer", func(t *testing.T) {
+		// The endpoint answers nothing until the scan is over
+		receiver := &webhookReceiver{release: make(chan struct{})}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		queue := NewNotificationQueue(NewHTTPWebhookNotifier(server.URL, nil), NotifyOptions{Buffer: len(baseline), Concurrency: 2})
+		engine.WithNotifier(queue)
+		defer engine.WithNotifier(nil)
+
+		results, err := drainChan(engine.FindDuplicatesChan(context.Background(), catalog, 0.8, 0))
+		if err != nil || len(results) != len(baseline) {
+			t.Fatalf("%d results, error %v", len(results), err)
+		}
+		if stats := queue.Stats(); stats.Delivered != 0 || stats.Queued != uint64(len(results)) {
+			t.Errorf("scan finished with %+v", stats)
+		}
+
+		close(receiver.release)
+		if err := queue.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if stats := queue.Stats(); stats.Delivered != uint64(len(results)) || stats.Dropped != 0 {
+			t.Errorf("Stats() = %+v after Close", stats)
+		}
+	})
+
+	t.Run("Overflow drops", func(t *testing.T) {
+		receiver := &webhookReceiver{release: make(chan struct{})}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		queue := NewNotificationQueue(NewHTTPWebhookNotifier(server.URL, nil), NotifyOptions{Buffer: 2, Concurrency: 1, Overflow: OverflowDrop})
+		engine.WithNotifier(queue)
+		defer engine.WithNotifier(nil)
+
+		results, err := drainChan(engine.FindDuplicatesChan(context.Background(), catalog, 0.8, 0))
+		if err != nil || len(results) != len(baseline) {
+			t.Fatalf("%d results, error %v", len(results), err)
+		}
+		stats := queue.Stats()
+		if stats.Dropped == 0 || stats.Queued+stats.Dropped != uint64(len(results)) {
+			t.Errorf("Stats() = %+v for %d results", stats, len(results))
+		}
+
+		close(receiver.release)
+		if err := queue.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if got := queue.Stats().Delivered; got != stats.Queued {
+			t.Errorf("%d delivered, %d queued", got, stats.Queued)
+		}
+	})
+
+	t.Run("Close deadline", func(t *testing.T) {
+		receiver := &webhookReceiver{release: make(chan struct{})}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+		defer close(receiver.release)
+
+		queue := NewNotificationQueue(NewHTTPWebhookNotifier(server.URL, nil), NotifyOptions{Buffer: len(baseline), Overflow: OverflowBlock})
+		engine.WithNotifier(queue)
+		defer engine.WithNotifier(nil)
+		if _, err := drainChan(engine.FindDuplicatesChan(context.Background(), catalog, 0.8, 0)); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := queue.Close(ctx); err != context.DeadlineExceeded {
+			t.Errorf("Close() = %v", err)
+		}
+		if stats := queue.Stats(); stats.Failed == 0 || stats.Delivered+stats.Failed != stats.Queued {
+			t.Errorf("Stats() = %+v after Close gave up", stats)
+		}
+		if err := queue.Close(context.Background()); err != ErrNotificationQueueClosed {
+			t.Errorf("second Close() = %v", err)
+		}
+	})
+}
+
+func TestNotifyGatekeeper(t *testing.T) {
+	catalog := loadSampleCatalog(t)
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	queue := NewNotificationQueue(NewHTTPWebhookNotifier(server.URL, nil), NotifyOptions{Mode: NotifyPerBatch})
+	gatekeeper, err := NewGatekeeper(NewLevenshteinEngine(), catalog[1:], DefaultGatePolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	gatekeeper.WithNotifier(queue)
+
+	rejected, err := gatekeeper.Admit(context.Background(), catalog[0])
+	if err != nil || rejected.Action != GateReject {
+		t.Fatalf("Admit(duplicate) = %v, %v", rejected.Action, err)
+	}
+	inserted, err := gatekeeper.Admit(context.Background(), Product{ID: "new", Name: "Cast Iron Skillet 12 inch", Description: "Pre-seasoned"})
+	if err != nil || inserted.Action != GateInsert {
+		t.Fatalf("Admit(new) = %v, %v", inserted.Action, err)
+	}
+	if err := queue.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	notifications := receiver.notifications(t)
+	if len(notifications) != 1 {
+		t.Fatalf("%d notifications, want the rejection's", len(notifications))
+	}
+	n := notifications[0]
+	if n.Source != NotifySourceGatekeeper || n.Action != "reject" || len(n.Matches) != len(rejected.Matches) ||
+		n.Matches[0].ProductB.ID != catalog[0].ID {
+		t.Errorf("notification %+v", n)
+	}
+}
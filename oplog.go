@@ -0,0 +1,338 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// oplogFormat identifies operation logs in their base records
+const oplogFormat = "duplicatecheck-oplog/v1"
+
+// ErrInvalidOplog is returned by ApplyOplog for input that isn't an operation
+// log: malformed records, or records before any base record
+var ErrInvalidOplog = errors.New("duplicatecheck: not a valid operation log")
+
+// ErrOplogBaseMismatch is returned by ApplyOplog when the log was recorded on
+// another index than the one it is applied to
+var ErrOplogBaseMismatch = errors.New("duplicatecheck: operation log recorded on a different index")
+
+// ErrOplogSequence is returned by ApplyOplog when a record is missing: the
+// log skips sequence numbers, or starts after the records applied so far
+var ErrOplogSequence = errors.New("duplicatecheck: operation log records out of sequence")
+
+// Operation log record types
+const (
+	oplogBase    = "base"    // Starts the records applied to one index
+	oplogAdd     = "add"     // AddProduct
+	oplogRemove  = "remove"  // RemoveProduct
+	oplogUpdate  = "update"  // UpdateProduct
+	oplogCompact = "compact" // Compact, including automatic compactions
+)
+
+// oplogRecord is one line of an operation log
+// Added and updated products carry their index entry, so replaying them
+// hashes nothing; in privacy mode they carry no text either.
+type oplogRecord struct {
+	Seq uint64 `json:"seq"` // 1, 2, ... after each base record, which has 0
+	Op  string `json:"op"`
+	ID  string `json:"id,omitempty"`
+
+	// Base records
+	Format string `json:"format,omitempty"` // Always oplogFormat
+	Base   string `json:"base,omitempty"`   // IndexFingerprint of the index before the first record, hexadecimal
+	Config string `json:"config,omitempty"` // IndexConfigFingerprint, hexadecimal
+	Salt   string `json:"salt,omitempty"`   // Bucket salt of the index, hexadecimal (see SnapshotConfig.BucketSalt)
+
+	// Add and update records
+	Product    *Product `json:"product,omitempty"`    // Omitted in privacy mode
+	Content    string   `json:"content,omitempty"`    // Product.Fingerprint, or the salted content hash in privacy mode, hexadecimal
+	SimHash    uint64   `json:"simhash,omitempty"`    // Privacy mode and SimHash screen
+	BandHashes []uint64 `json:"bands,omitempty"`      // numBands per signature
+	Signatures int      `json:"signatures,omitempty"` // MinHash signatures (chunks in chunked mode)
+	Sampled    bool     `json:"sampled,omitempty"`    // Shingles were sampled (see HybridConfig.MaxShinglesPerProduct)
+	Truncated  bool     `json:"truncated,omitempty"`  // Index text was truncated
+}
+
+// oplogWriter appends the records of one engine's index updates to a writer
+type oplogWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	index *LSHIndex // Index the records since the last base record apply to
+	seq   uint64    // Last sequence number written
+}
+
+// oplogReplay tracks what ApplyOplog has applied to an engine's index
+type oplogReplay struct {
+	index *LSHIndex // Index the records were applied to
+	base  string    // Base fingerprint of their log
+	seq   uint64    // Last sequence number applied
+}
+
+// WithOplog appends a record of every index update to w: AddProduct,
+// RemoveProduct, UpdateProduct and Compact, including automatic compactions
+// (nil turns it off). Another engine whose index was loaded from the same
+// SaveIndex snapshot replays the records with ApplyOplog and stays identical,
+// without shipping new snapshots. Records are JSON lines carrying the
+// products' band hashes, so replaying them hashes nothing, and in privacy
+// mode they carry no product text. A new base record starts the log whenever
+// the index was replaced (BuildIndex, LoadIndex) since the last record;
+// replicas then need a new snapshot. Records are written before the update
+// is applied: a failed write fails the update, leaving the index unchanged.
+// Returns the engine for chaining.
+func (e *HybridEngine) WithOplog(w io.Writer) *HybridEngine {
+	if w == nil {
+		e.oplog = nil
+		return e
+	}
+	e.oplog = &oplogWriter{w: w}
+	return e
+}
+
+// IndexFingerprint identifies the contents of the index: its configuration
+// (see IndexConfigFingerprint), bucket salt and each product's ID and
+// content, in indexing order (0 if no index is built)
+// Engines with equal fingerprints return the same results. Compaction doesn't
+// change the fingerprint.
+func (e *HybridEngine) IndexFingerprint() uint64 {
+	idx := e.currentIndex()
+	if idx == nil {
+		return 0
+	}
+	return e.indexFingerprint(idx)
+}
+
+// indexFingerprint is IndexFingerprint for idx
+func (e *HybridEngine) indexFingerprint(idx *LSHIndex) uint64 {
+	fields := make([]string, 0, 4+2*len(idx.ids))
+	fields = append(fields, "index/v1", strconv.FormatUint(e.IndexConfigFingerprint(), 16))
+	if idx.salt != 0 {
+		// Unsalted indexes keep their fingerprint
+		fields = append(fields, "salt", formatBucketSalt(idx.salt))
+	}
+	for _, id := range idx.ids {
+		fields = append(fields, id, strconv.FormatUint(idx.contentFingerprint(id), 16))
+	}
+	return contentFingerprint(fields...)
+}
+
+// contentFingerprint returns the content fingerprint of an indexed product:
+// Product.Fingerprint, or its salted content hash in privacy mode
+func (idx *LSHIndex) contentFingerprint(id string) uint64 {
+	if p, exists := idx.products[id]; exists {
+		return p.Fingerprint()
+	}
+	return idx.contentHashes[id]
+}
+
+// logUpdate appends the record of an update about to be applied to idx,
+// first writing a base record if idx isn't the index the log's records apply to
+// Compactions pass the index they swap in as next, which later records apply to.
+func (e *HybridEngine) logUpdate(idx *LSHIndex, record oplogRecord, next *LSHIndex) error {
+	o := e.oplog
+	if o == nil {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// The writer's state only changes once the record is written, so a failed
+	// write leaves no gap in the sequence numbers
+	out := bufio.NewWriter(o.w)
+	enc := json.NewEncoder(out)
+	seq := o.seq
+	if o.index != idx {
+		seq = 0
+		header := oplogRecord{
+			Op:     oplogBase,
+			Format: oplogFormat,
+			Base:   strconv.FormatUint(e.indexFingerprint(idx), 16),
+			Config: strconv.FormatUint(e.IndexConfigFingerprint(), 16),
+			Salt:   formatBucketSalt(idx.salt),
+		}
+		if err := enc.Encode(header); err != nil {
+			return fmt.Errorf("duplicatecheck: writing oplog: %w", err)
+		}
+	}
+	record.Seq = seq + 1
+	if err := enc.Encode(record); err != nil {
+		return fmt.Errorf("duplicatecheck: writing oplog: %w", err)
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("duplicatecheck: writing oplog: %w", err)
+	}
+	o.index, o.seq = idx, record.Seq
+	if next != nil {
+		o.index = next
+	}
+	return nil
+}
+
+// entryRecord returns the record of adding or updating product with entry
+func (e *HybridEngine) entryRecord(op string, product *Product, entry indexEntry) oplogRecord {
+	record := oplogRecord{
+		Op:         op,
+		ID:         product.ID,
+		SimHash:    uint64(entry.fingerprint),
+		BandHashes: entry.bandHashes,
+		Signatures: entry.signatures,
+		Sampled:    entry.report.Sampled,
+		Truncated:  entry.report.Truncated,
+	}
+	if e.privacy != nil {
+		record.Content = strconv.FormatUint(entry.contentHash, 16)
+	} else {
+		stored := copyProductFields(product)
+		record.Product = &stored
+		record.Content = strconv.FormatUint(product.Fingerprint(), 16)
+	}
+	return record
+}
+
+// ApplyOplog replays an operation log written by another engine's WithOplog
+// onto this engine's index, which must be the one the log was recorded on: a
+// copy loaded from the same SaveIndex snapshot, with records applied up to
+// some point. Records already applied are skipped, so a log can be replayed
+// again from its start, or resumed after a partial replay. Returns
+// ErrIndexNotBuilt without an index, an error wrapping ErrOplogBaseMismatch
+// when the index differs from the log's base (ErrIncompatibleIndex when its
+// configuration does), ErrOplogSequence when a record is missing, and
+// ErrInvalidOplog for malformed input. Records before the error stay
+// applied. Like AddProduct, it must not run concurrently with queries or
+// other updates.
+func (e *HybridEngine) ApplyOplog(r io.Reader) (err error) {
+	defer e.levenshteinEngine.guard("ApplyOplog", &err)
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var replay *oplogReplay
+	for {
+		var record oplogRecord
+		if err := dec.Decode(&record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidOplog, err)
+		}
+
+		idx := e.currentIndex()
+		if idx == nil {
+			return ErrIndexNotBuilt
+		}
+		if record.Op == oplogBase {
+			var err error
+			if replay, err = e.startReplay(idx, record); err != nil {
+				return err
+			}
+			continue
+		}
+		switch {
+		case replay == nil:
+			return fmt.Errorf("%w: record %d before any base record", ErrInvalidOplog, record.Seq)
+		case replay.index != idx:
+			return fmt.Errorf("%w: the index was replaced during the replay", ErrOplogBaseMismatch)
+		case record.Seq <= replay.seq:
+			continue // Applied already
+		case record.Seq > replay.seq+1:
+			return fmt.Errorf("%w: record %d follows record %d", ErrOplogSequence, record.Seq, replay.seq)
+		}
+		if err := e.applyRecord(idx, &record); err != nil {
+			return fmt.Errorf("applying oplog record %d: %w", record.Seq, err)
+		}
+		replay.index, replay.seq = e.currentIndex(), record.Seq
+	}
+}
+
+// startReplay checks a base record against idx and returns the replay state
+// of its records: resumed if they were being applied to idx, new otherwise
+func (e *HybridEngine) startReplay(idx *LSHIndex, record oplogRecord) (*oplogReplay, error) {
+	if record.Format != oplogFormat {
+		return nil, fmt.Errorf("%w: format %q, want %q", ErrInvalidOplog, record.Format, oplogFormat)
+	}
+	if want := strconv.FormatUint(e.IndexConfigFingerprint(), 16); record.Config != want {
+		return nil, fmt.Errorf("%w (oplog %q, engine %q)", ErrIncompatibleIndex, record.Config, want)
+	}
+	if want := formatBucketSalt(idx.salt); record.Salt != want {
+		return nil, fmt.Errorf("%w: oplog recorded on an index with bucket salt %q, this index has %q",
+			ErrOplogBaseMismatch, record.Salt, want)
+	}
+	if e.replay != nil && e.replay.index == idx && e.replay.base == record.Base {
+		return e.replay, nil
+	}
+	if got := strconv.FormatUint(e.indexFingerprint(idx), 16); got != record.Base {
+		return nil, fmt.Errorf("%w (oplog base %s, index %s)", ErrOplogBaseMismatch, record.Base, got)
+	}
+	e.replay = &oplogReplay{index: idx, base: record.Base}
+	return e.replay, nil
+}
+
+// applyRecord applies one add, remove, update or compact record to idx
+// Automatic compactions aren't run: the log records those the primary ran.
+func (e *HybridEngine) applyRecord(idx *LSHIndex, record *oplogRecord) error {
+	switch record.Op {
+	case oplogAdd, oplogUpdate:
+		product, entry, err := e.recordEntry(record)
+		if err != nil {
+			return err
+		}
+		if record.Op == oplogUpdate {
+			if !e.ContainsProduct(record.ID) {
+				return ErrProductNotIndexed
+			}
+			idx.remove(record.ID)
+		} else if e.ContainsProduct(record.ID) {
+			return &DuplicateIDError{IDs: []string{record.ID}}
+		} else if number, exists := idx.numbers[record.ID]; exists && idx.removed[number] {
+			if _, err := e.compact(false); err != nil {
+				return err
+			}
+			idx = e.currentIndex()
+		}
+		e.addIndexEntry(idx, product, entry)
+		return bandStoreErr(idx.bands)
+	case oplogRemove:
+		if !e.ContainsProduct(record.ID) {
+			return ErrProductNotIndexed
+		}
+		idx.remove(record.ID)
+		return nil
+	case oplogCompact:
+		_, err := e.compact(false)
+		return err
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrInvalidOplog, record.Op)
+	}
+}
+
+// recordEntry rebuilds the product and index entry an add or update record carries
+func (e *HybridEngine) recordEntry(record *oplogRecord) (*Product, indexEntry, error) {
+	if len(record.BandHashes) != record.Signatures*e.numBands || record.Signatures == 0 {
+		return nil, indexEntry{}, fmt.Errorf("%w: %d band hashes for %d signatures", ErrInvalidOplog, len(record.BandHashes), record.Signatures)
+	}
+	entry := indexEntry{
+		fingerprint: SimHashFingerprint(record.SimHash),
+		bandHashes:  record.BandHashes,
+		signatures:  record.Signatures,
+		report:      IndexingReport{ProductID: record.ID, Sampled: record.Sampled, Truncated: record.Truncated},
+	}
+	product := &Product{ID: record.ID}
+	if e.privacy != nil {
+		hash, err := strconv.ParseUint(record.Content, 16, 64)
+		if err != nil {
+			return nil, indexEntry{}, fmt.Errorf("%w: record for %q has content hash %q", ErrInvalidOplog, record.ID, record.Content)
+		}
+		entry.contentHash = hash
+	} else {
+		if record.Product == nil || record.Product.ID != record.ID {
+			return nil, indexEntry{}, fmt.Errorf("%w: record for %q lacks the product", ErrInvalidOplog, record.ID)
+		}
+		stored := copyProductFields(record.Product)
+		product = &stored
+		product.loadCacheFor(e.preparer())
+		if content := strconv.FormatUint(product.Fingerprint(), 16); content != record.Content {
+			return nil, indexEntry{}, fmt.Errorf("%w: product %q content fingerprint is %s, record has %s", ErrInvalidOplog, record.ID, content, record.Content)
+		}
+	}
+	return product, entry, nil
+}
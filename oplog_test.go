@@ -0,0 +1,255 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// oplogEngine returns an engine compacting after 15 removals, as primary and replicas share
+func oplogEngine() *HybridEngine {
+	config := DefaultHybridConfig()
+	config.AutoCompact = AutoCompactPolicy{MaxRemoved: 15}
+	return NewHybridEngineWithConfig(config)
+}
+
+// replicaOf returns an engine with the index in snapshot loaded
+func replicaOf(t *testing.T, snapshot []byte, setup func(*HybridEngine)) *HybridEngine {
+	t.Helper()
+	replica := oplogEngine()
+	if setup != nil {
+		setup(replica)
+	}
+	if err := replica.LoadIndex(bytes.NewReader(snapshot)); err != nil {
+		t.Fatal(err)
+	}
+	return replica
+}
+
+// oplogUpdates runs adds, removals, updates and re-adds of catalog products on engine
+func oplogUpdates(t *testing.T, engine *HybridEngine, catalog []Product) {
+	t.Helper()
+	for _, p := range catalog[150:200] {
+		if err := engine.AddProduct(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 200; i += 13 {
+		if err := engine.RemoveProduct(catalog[i].ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i < 200; i += 17 {
+		if i%13 == 0 {
+			continue // Removed
+		}
+		updated := catalog[i]
+		updated.Name += " (2nd edition)"
+		if err := engine.UpdateProduct(updated); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Removed, then back: the first re-add compacts
+	for i := 0; i < 50; i += 13 {
+		if err := engine.AddProduct(catalog[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// sameReplica checks that replica returns what primary returns for a battery of queries
+func sameReplica(t *testing.T, primary, replica *HybridEngine, queries []Product) {
+	t.Helper()
+	if got, want := replica.IndexFingerprint(), primary.IndexFingerprint(); got != want {
+		t.Errorf("replica fingerprint %x, primary %x", got, want)
+	}
+	if !reflect.DeepEqual(replica.IndexedProducts(), primary.IndexedProducts()) {
+		t.Error("replica holds different products")
+	}
+	if got, want := candidateIDs(replica, queries, 0.75), candidateIDs(primary, queries, 0.75); !reflect.DeepEqual(got, want) {
+		t.Errorf("replica matched %v, primary %v", got, want)
+	}
+	got, want := comparableResults(replica.FindDuplicates(queries, 0.75)), comparableResults(primary.FindDuplicates(queries, 0.75))
+	if len(want) == 0 || !reflect.DeepEqual(got, want) {
+		t.Errorf("replica found %d duplicates, primary %d", len(got), len(want))
+	}
+}
+
+func TestOplogReplay(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 200)
+	primary := oplogEngine()
+	if err := primary.BuildIndex(catalog[:150]); err != nil {
+		t.Fatal(err)
+	}
+	var snapshot, log bytes.Buffer
+	if err := primary.SaveIndex(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	primary.WithOplog(&log)
+	oplogUpdates(t, primary, catalog)
+	lines := strings.SplitAfter(log.String(), "\n")
+	for _, op := range []string{`"op":"add"`, `"op":"remove"`, `"op":"update"`, `"op":"compact"`} {
+		if !strings.Contains(log.String(), op) {
+			t.Errorf("oplog has no %s record", op)
+		}
+	}
+
+	queries := append(append([]Product(nil), catalog[:30]...), catalog[170:]...)
+	replica := replicaOf(t, snapshot.Bytes(), nil)
+	if err := replica.ApplyOplog(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	sameReplica(t, primary, replica, queries)
+
+	// Replaying again, or resuming a partial replay, applies each record once
+	if err := replica.ApplyOplog(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatalf("second replay: %v", err)
+	}
+	sameReplica(t, primary, replica, queries)
+	partial := replicaOf(t, snapshot.Bytes(), nil)
+	if err := partial.ApplyOplog(strings.NewReader(strings.Join(lines[:len(lines)/2], ""))); err != nil {
+		t.Fatalf("first half: %v", err)
+	}
+	if partial.IndexFingerprint() == primary.IndexFingerprint() {
+		t.Error("half the log gave the whole log's index")
+	}
+	if err := partial.ApplyOplog(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatalf("whole log after the first half: %v", err)
+	}
+	sameReplica(t, primary, partial, queries)
+
+	// Privacy mode: band hashes and content hashes, no text
+	private := func(e *HybridEngine) { e.EnablePrivacyMode(PrivacyOptions{Salt: []byte("replicas")}) }
+	privatePrimary := oplogEngine()
+	private(privatePrimary)
+	if err := privatePrimary.BuildIndex(catalog[:150]); err != nil {
+		t.Fatal(err)
+	}
+	snapshot.Reset()
+	log.Reset()
+	if err := privatePrimary.SaveIndex(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	privatePrimary.WithOplog(&log)
+	oplogUpdates(t, privatePrimary, catalog)
+	if strings.Contains(log.String(), catalog[175].Name) {
+		t.Error("privacy mode oplog holds product text")
+	}
+	privateReplica := replicaOf(t, snapshot.Bytes(), private)
+	if err := privateReplica.ApplyOplog(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := privateReplica.IndexFingerprint(), privatePrimary.IndexFingerprint(); got != want {
+		t.Errorf("private replica fingerprint %x, primary %x", got, want)
+	}
+}
+
+func TestOplogErrors(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 100)
+	primary := oplogEngine()
+	if err := primary.BuildIndex(catalog[:75]); err != nil {
+		t.Fatal(err)
+	}
+	var snapshot, log bytes.Buffer
+	if err := primary.SaveIndex(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	primary.WithOplog(&log)
+	for _, p := range catalog[75:85] {
+		if err := primary.AddProduct(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.SplitAfter(log.String(), "\n")
+
+	other := oplogEngine()
+	if err := other.BuildIndex(catalog[:74]); err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultHybridConfig()
+	config.NumBands = 10
+	rebanded := NewHybridEngineWithConfig(config)
+	if err := rebanded.BuildIndex(catalog[:75]); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		replica *HybridEngine
+		log     string
+		want    error
+	}{
+		{"mismatched base", other, log.String(), ErrOplogBaseMismatch},
+		{"other configuration", rebanded, log.String(), ErrIncompatibleIndex},
+		{"missing record", replicaOf(t, snapshot.Bytes(), nil), lines[0] + lines[1] + lines[3], ErrOplogSequence},
+		{"out of order", replicaOf(t, snapshot.Bytes(), nil), lines[0] + lines[2] + lines[1], ErrOplogSequence},
+		{"no base record", replicaOf(t, snapshot.Bytes(), nil), lines[1], ErrInvalidOplog},
+		{"malformed", replicaOf(t, snapshot.Bytes(), nil), lines[0] + "{", ErrInvalidOplog},
+		{"no index", NewHybridEngine(), log.String(), ErrIndexNotBuilt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.replica.ApplyOplog(strings.NewReader(tt.log)); !errors.Is(err, tt.want) {
+				t.Errorf("ApplyOplog: %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// A write failure fails the update and leaves the index unchanged
+	primary.WithOplog(failingWriter{})
+	if err := primary.AddProduct(catalog[85]); err == nil || primary.ContainsProduct(catalog[85].ID) {
+		t.Errorf("AddProduct with a failing oplog: %v", err)
+	}
+	if err := primary.RemoveProduct(catalog[0].ID); err == nil || !primary.ContainsProduct(catalog[0].ID) {
+		t.Errorf("RemoveProduct with a failing oplog: %v", err)
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestUpdateProduct(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 50)
+	engine := NewHybridEngine()
+	if err := engine.UpdateProduct(catalog[0]); !errors.Is(err, ErrIndexNotBuilt) {
+		t.Errorf("UpdateProduct without an index: %v", err)
+	}
+	if err := engine.BuildIndex(catalog[:40]); err != nil {
+		t.Fatal(err)
+	}
+	var log bytes.Buffer
+	engine.WithOplog(&log)
+
+	if err := engine.UpdateProduct(catalog[45]); !errors.Is(err, ErrProductNotIndexed) {
+		t.Errorf("UpdateProduct of an unindexed product: %v", err)
+	}
+	// Same content, differently cased: nothing to do
+	same := catalog[3]
+	same.Name = strings.ToUpper(same.Name)
+	if err := engine.UpdateProduct(same); err != nil || log.Len() != 0 {
+		t.Errorf("unchanged update: %v, oplog %q", err, log.String())
+	}
+
+	// The new version is found, the old one isn't
+	updated := catalog[3]
+	updated.Name, updated.Description = catalog[45].Name, catalog[45].Description
+	if err := engine.UpdateProduct(updated); err != nil {
+		t.Fatal(err)
+	}
+	if engine.IndexedCount() != 40 || !strings.Contains(log.String(), `"op":"update"`) {
+		t.Errorf("%d products indexed, oplog %q", engine.IndexedCount(), log.String())
+	}
+	found := false
+	for _, r := range engine.FindDuplicatesForOne(catalog[45], 0.95) {
+		found = found || r.ProductB.ID == catalog[3].ID
+	}
+	if !found {
+		t.Error("updated product not matched by its new content")
+	}
+}
@@ -0,0 +1,171 @@
+package duplicatecheck
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+)
+
+// OutputShaping trims what FindDuplicates returns while the scan runs, so
+// pairs a later filter would discard are never accumulated
+type OutputShaping struct {
+	// KeepOnlyBestPerProduct keeps each product's single most similar
+	// partner: a pair survives when it is the best match of at least one of
+	// its products, so at most one pair per product is returned whatever the
+	// threshold. Ties go to the partner with the smaller ID. This is not a
+	// global top-K: a product's best pair is kept however weak it is compared
+	// with other products' pairs, and a pair that is second-best for both of
+	// its products is dropped however strong it is.
+	KeepOnlyBestPerProduct bool `json:"keep_only_best_per_product,omitempty"`
+}
+
+// WithOutputShaping sets how FindDuplicates and its Checked, Ctx, Ptr and
+// WithSummary forms shape their output (the zero value, the default, returns
+// every pair)
+// With KeepOnlyBestPerProduct, workers track each product's best similarity
+// so far and drop a pair as soon as both of its products have a better one,
+// bounding result memory at O(n) even at low thresholds. Streaming and file
+// scans are not shaped, since they report pairs before knowing each product's
+// best. Returns the engine for chaining.
+func (e *LevenshteinEngine) WithOutputShaping(shaping OutputShaping) *LevenshteinEngine {
+	e.shaping = shaping
+	return e
+}
+
+// GetOutputShaping returns the engine's output shaping
+func (e *LevenshteinEngine) GetOutputShaping() OutputShaping {
+	return e.shaping
+}
+
+// WithOutputShaping sets how FindDuplicates and its Checked, Ptr and
+// WithSummary forms shape their output (see LevenshteinEngine.WithOutputShaping)
+// Returns the engine for chaining.
+func (e *HybridEngine) WithOutputShaping(shaping OutputShaping) *HybridEngine {
+	e.levenshteinEngine.WithOutputShaping(shaping)
+	return e
+}
+
+// GetOutputShaping returns the engine's output shaping
+func (e *HybridEngine) GetOutputShaping() OutputShaping {
+	return e.levenshteinEngine.GetOutputShaping()
+}
+
+// bestPerProduct keeps each product's best pair of a scan
+// Workers call offer, which drops pairs no product can keep; the scan's yield
+// calls add. Pairs reach add out of order in parallel scans, so a product's
+// kept pair is only final once the scan ends and finish reconciles them.
+type bestPerProduct struct {
+	scores map[string]*atomic.Uint64   // Product ID -> best CombinedSimilarity offered so far, as float64 bits
+	best   map[string]ComparisonResult // Product ID -> best pair added so far (yield goroutine only)
+	order  []string                    // Product IDs in scan order
+}
+
+// newBestPerProduct returns a tracker for a scan of products
+func newBestPerProduct(products []*Product) *bestPerProduct {
+	b := &bestPerProduct{
+		scores: make(map[string]*atomic.Uint64, len(products)),
+		best:   make(map[string]ComparisonResult, len(products)),
+		order:  make([]string, len(products)),
+	}
+	for i, p := range products {
+		b.scores[p.ID] = new(atomic.Uint64)
+		b.order[i] = p.ID
+	}
+	return b
+}
+
+// offer raises both products' best scores to similarity and reports whether
+// the pair may still be kept: it is at least as similar as one product's best
+// Safe for concurrent use; a nil tracker keeps every pair. Products the
+// tracker doesn't know, such as indexed products outside the scan, keep it.
+func (b *bestPerProduct) offer(idA, idB string, similarity float64) bool {
+	if b == nil {
+		return true
+	}
+	keptA := b.raise(idA, similarity)
+	keptB := b.raise(idB, similarity)
+	return keptA || keptB
+}
+
+// raise sets id's best score to similarity if that is higher, reporting
+// whether similarity is at least the best
+// Similarities are non-negative, so their float64 bits order like them.
+func (b *bestPerProduct) raise(id string, similarity float64) bool {
+	score := b.scores[id]
+	if score == nil {
+		return true
+	}
+	bits := math.Float64bits(similarity)
+	for {
+		current := score.Load()
+		if bits < current {
+			return false
+		}
+		if bits == current || score.CompareAndSwap(current, bits) {
+			return true
+		}
+	}
+}
+
+// add records result as the best pair of each of its products it beats
+// Returns true, for use as a scan's yield.
+func (b *bestPerProduct) add(result ComparisonResult) bool {
+	b.keep(result.ProductA.ID, result.ProductB.ID, result)
+	b.keep(result.ProductB.ID, result.ProductA.ID, result)
+	return true
+}
+
+// keep makes result id's best pair if it beats the current one: more similar,
+// or as similar with a partner of smaller ID
+func (b *bestPerProduct) keep(id, partner string, result ComparisonResult) {
+	current, ok := b.best[id]
+	if ok {
+		currentPartner := current.ProductA.ID
+		if currentPartner == id {
+			currentPartner = current.ProductB.ID
+		}
+		if result.CombinedSimilarity < current.CombinedSimilarity ||
+			result.CombinedSimilarity == current.CombinedSimilarity && partner >= currentPartner {
+			return
+		}
+	}
+	if !ok && b.scores[id] == nil {
+		b.order = append(b.order, id) // An indexed product outside the scan
+	}
+	b.best[id] = result
+}
+
+// finish returns each kept pair once, in the scan order of the first of its
+// products to keep it
+func (b *bestPerProduct) finish() []ComparisonResult {
+	results := make([]ComparisonResult, 0, len(b.best))
+	seen := make(map[string]bool, len(b.best))
+	for _, id := range b.order {
+		result, ok := b.best[id]
+		if !ok {
+			continue
+		}
+		key := makePairKey(result.ProductA.ID, result.ProductB.ID)
+		if !seen[key] {
+			seen[key] = true
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// findBestPairs is findPairs keeping each product's best pair only (see
+// OutputShaping.KeepOnlyBestPerProduct)
+func (e *LevenshteinEngine) findBestPairs(ctx context.Context, products []*Product, threshold float64) []ComparisonResult {
+	best := newBestPerProduct(products)
+	e.streamScan(ctx, nil, products, threshold, best, best.add)
+	return best.finish()
+}
+
+// findBestPairs is findPairs keeping each product's best pair only (see
+// OutputShaping.KeepOnlyBestPerProduct)
+func (e *HybridEngine) findBestPairs(products []*Product, threshold float64) []ComparisonResult {
+	best := newBestPerProduct(products)
+	e.streamScan(context.Background(), products, threshold, best, best.add)
+	return best.finish()
+}
@@ -0,0 +1,157 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// partnerCatalog returns a hub product and five partners one to five edits away
+// from it, each with an identical twin, and enough filler products to make
+// the Levenshtein scan parallel
+// Partners' edits don't overlap, so partners are further from each other
+// than from the hub: the hub is each partner's best match after its twin.
+func partnerCatalog() []Product {
+	const base = "Stainless Steel Water Bottle 750ml Blue"
+	products := []Product{{ID: "hub", Name: base}}
+	for k, start := 1, 0; k <= 5; k, start = k+1, start+k+1 {
+		name := base[:start] + strings.Repeat("z", k) + base[start+k:]
+		products = append(products,
+			Product{ID: fmt.Sprintf("p%d", k), Name: name},
+			Product{ID: fmt.Sprintf("p%d-twin", k), Name: name})
+	}
+	return append(products, GenerateTestCatalog(11, 60)...)
+}
+
+// bestPairKeys returns the pairs of results that are the best of at least
+// one of their products, ties going to the smaller partner ID
+func bestPairKeys(results []ComparisonResult) []string {
+	best := make(map[string]ComparisonResult)
+	partner := func(r ComparisonResult, id string) string {
+		if r.ProductA.ID == id {
+			return r.ProductB.ID
+		}
+		return r.ProductA.ID
+	}
+	for _, r := range results {
+		for _, id := range []string{r.ProductA.ID, r.ProductB.ID} {
+			current, ok := best[id]
+			if !ok || r.CombinedSimilarity > current.CombinedSimilarity ||
+				r.CombinedSimilarity == current.CombinedSimilarity && partner(r, id) < partner(current, id) {
+				best[id] = r
+			}
+		}
+	}
+	var kept []ComparisonResult
+	for _, r := range best {
+		kept = append(kept, r)
+	}
+	return pairKeys(kept)
+}
+
+// pairKeys returns the distinct PairKeys of results, sorted
+func pairKeys(results []ComparisonResult) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, r := range results {
+		if key := PairKey(r.ProductA.ID, r.ProductB.ID); !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestKeepOnlyBestPerProduct(t *testing.T) {
+	catalog := partnerCatalog()
+	tests := []struct {
+		name      string
+		products  []Product
+		newEngine func(products []Product, shaping OutputShaping) DuplicateCheckEngine
+	}{
+		{"levenshtein parallel", catalog, func(_ []Product, shaping OutputShaping) DuplicateCheckEngine {
+			engine := NewLevenshteinEngine().WithOutputShaping(shaping)
+			engine.SetMaxWorkers(4)
+			return engine
+		}},
+		{"levenshtein sequential", catalog[:11], func(_ []Product, shaping OutputShaping) DuplicateCheckEngine {
+			return NewLevenshteinEngine().WithOutputShaping(shaping)
+		}},
+		{"hybrid", catalog, func(products []Product, shaping OutputShaping) DuplicateCheckEngine {
+			engine := NewHybridEngine().WithOutputShaping(shaping)
+			if err := engine.BuildIndex(products); err != nil {
+				t.Fatal(err)
+			}
+			return engine
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := tt.products
+			all := tt.newEngine(products, OutputShaping{}).FindDuplicates(products, 0.7)
+			shaped := tt.newEngine(products, OutputShaping{KeepOnlyBestPerProduct: true}).FindDuplicates(products, 0.7)
+
+			// Only the hub's best pair survives among its five, since every
+			// partner has a better match in its twin
+			var hubPairs []string
+			for _, r := range shaped {
+				if r.ProductA.ID == "hub" || r.ProductB.ID == "hub" {
+					hubPairs = append(hubPairs, PairKey(r.ProductA.ID, r.ProductB.ID))
+				}
+			}
+			if want := PairKey("hub", "p1"); len(hubPairs) != 1 || hubPairs[0] != want {
+				t.Errorf("hub pairs %v, want only %s", hubPairs, want)
+			}
+
+			// A pair survives when it is the best of either product: hub-p1
+			// is the hub's best but not p1's, p1's twin is
+			got, want := pairKeys(shaped), bestPairKeys(all)
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("kept %d pairs %v,\nwant the %d best-per-product pairs %v", len(got), got, len(want), want)
+			}
+			if len(got) != len(shaped) {
+				t.Errorf("%d results for %d distinct pairs", len(shaped), len(got))
+			}
+			if len(shaped) > len(products) {
+				t.Errorf("%d results for %d products", len(shaped), len(products))
+			}
+		})
+	}
+}
+
+func TestKeepOnlyBestPerProductMemory(t *testing.T) {
+	products := GenerateTestCatalog(3, 120)
+	const threshold = 0.3
+
+	retained := func(scan func() []ComparisonResult) (uint64, int) {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		results := scan()
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		n := len(results)
+		runtime.KeepAlive(results)
+		if after.HeapAlloc < before.HeapAlloc {
+			return 0, n
+		}
+		return after.HeapAlloc - before.HeapAlloc, n
+	}
+	allBytes, all := retained(func() []ComparisonResult {
+		return NewLevenshteinEngine().FindDuplicates(products, threshold)
+	})
+	shapedBytes, shaped := retained(func() []ComparisonResult {
+		return NewLevenshteinEngine().WithOutputShaping(OutputShaping{KeepOnlyBestPerProduct: true}).FindDuplicates(products, threshold)
+	})
+
+	t.Logf("unshaped: %d results, %d bytes retained; shaped: %d results, %d bytes", all, allBytes, shaped, shapedBytes)
+	if shaped > len(products) || shaped*10 > all {
+		t.Errorf("shaped scan kept %d results of %d, want at most one per product", shaped, all)
+	}
+	if shapedBytes*4 > allBytes {
+		t.Errorf("shaped scan retained %d bytes, unshaped %d", shapedBytes, allBytes)
+	}
+}
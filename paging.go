@@ -0,0 +1,242 @@
+package duplicatecheck
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrInvalidCursor is returned by PagedResults.Page for a cursor it didn't
+// issue: malformed, or for another sort order
+var ErrInvalidCursor = errors.New("duplicatecheck: invalid page cursor")
+
+// ErrCursorPastEnd is returned by PagedResults.Page when no result follows
+// the cursor, such as one kept from a larger result set
+var ErrCursorPastEnd = errors.New("duplicatecheck: page cursor is past the last result")
+
+// pageCursorVersion is the version of the encoded cursor format
+const pageCursorVersion = 1
+
+// SortOrder is the order PagedResults serves results in
+type SortOrder int
+
+const (
+	// BySimilarityDesc orders by CombinedSimilarity, highest first
+	BySimilarityDesc SortOrder = iota
+	// ByProductAID orders by ProductA ID, then ProductB ID
+	ByProductAID
+	// ByClusterSize keeps each cluster's pairs together, largest cluster
+	// first, then by similarity; clusters are the groups ClusterDuplicates
+	// forms from the results
+	ByClusterSize
+)
+
+// String returns the sort order's name
+func (o SortOrder) String() string {
+	switch o {
+	case BySimilarityDesc:
+		return "similarity_desc"
+	case ByProductAID:
+		return "product_a_id"
+	case ByClusterSize:
+		return "cluster_size"
+	default:
+		return fmt.Sprintf("SortOrder(%d)", int(o))
+	}
+}
+
+// PagedResults serves scan results one page at a time in a fixed order
+// Results are sorted once, by NewPagedResults; each Page finds its cursor by
+// binary search. Cursors encode the sort key of the last result served, so a
+// cursor keeps pointing at the same place in the order across calls and after
+// a round trip through an API, whatever the page size. Page may be called
+// concurrently; Filter may not be called concurrently with Page.
+type PagedResults struct {
+	order   SortOrder
+	results []ComparisonResult // Sorted
+	keys    []pageKey          // Sort key of each result
+	visible []int              // Indexes into results passing the filter, in order
+}
+
+// pageKey is the position of a result in a sort order
+// Only copies of one result share a key; they keep their input order.
+type pageKey struct {
+	ClusterSize int     `json:"c,omitempty"`
+	Cluster     string  `json:"r,omitempty"` // First product ID of the cluster
+	Similarity  float64 `json:"s"`
+	ProductAID  string  `json:"a"`
+	ProductBID  string  `json:"b"`
+}
+
+// pageCursor is the decoded form of a cursor
+type pageCursor struct {
+	Version  int       `json:"v"`
+	Order    SortOrder `json:"o"`
+	Position int       `json:"p"` // Visible results before the cursor, a hint for the search
+	Key      pageKey   `json:"k"` // Key of the last result served
+	Repeat   int       `json:"n"` // Results with that key served so far
+}
+
+// NewPagedResults sorts a copy of results in order for paging
+func NewPagedResults(results []ComparisonResult, order SortOrder) *PagedResults {
+	p := &PagedResults{order: order, results: append([]ComparisonResult(nil), results...)}
+	p.keys = make([]pageKey, len(p.results))
+	for i := range p.results {
+		r := &p.results[i]
+		p.keys[i] = pageKey{Similarity: r.CombinedSimilarity, ProductAID: r.ProductA.ID, ProductBID: r.ProductB.ID}
+	}
+	if order == ByClusterSize {
+		clusters := make(map[string]*DuplicateGroup) // Product ID -> its cluster
+		groups := ClusterDuplicates(p.results, ClusterOptions{})
+		for g := range groups {
+			for _, product := range groups[g].Products {
+				clusters[product.ID] = &groups[g]
+			}
+		}
+		for i := range p.keys {
+			group := clusters[p.keys[i].ProductAID]
+			p.keys[i].ClusterSize, p.keys[i].Cluster = len(group.Products), group.Products[0].ID
+		}
+	}
+
+	sorted := make([]int, len(p.results))
+	for i := range sorted {
+		sorted[i] = i
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return p.less(&p.keys[sorted[i]], &p.keys[sorted[j]]) })
+	results, keys := make([]ComparisonResult, len(sorted)), make([]pageKey, len(sorted))
+	for to, from := range sorted {
+		results[to], keys[to] = p.results[from], p.keys[from]
+	}
+	p.results, p.keys = results, keys
+	return p.Filter(nil)
+}
+
+// less reports whether key a comes before key b in the sort order
+func (p *PagedResults) less(a, b *pageKey) bool {
+	if p.order == ByClusterSize {
+		if a.ClusterSize != b.ClusterSize {
+			return a.ClusterSize > b.ClusterSize
+		}
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+	}
+	if p.order != ByProductAID && a.Similarity != b.Similarity {
+		return a.Similarity > b.Similarity
+	}
+	if a.ProductAID != b.ProductAID {
+		return a.ProductAID < b.ProductAID
+	}
+	if a.ProductBID != b.ProductBID {
+		return a.ProductBID < b.ProductBID
+	}
+	return a.Similarity > b.Similarity
+}
+
+// Filter serves only the results keep accepts (nil serves all of them)
+// Cursors stay valid across filters: a page resumes after the cursor's
+// result whether or not the new filter accepts it. Returns the results for
+// chaining.
+func (p *PagedResults) Filter(keep func(ComparisonResult) bool) *PagedResults {
+	p.visible = p.visible[:0]
+	for i := range p.results {
+		if keep == nil || keep(p.results[i]) {
+			p.visible = append(p.visible, i)
+		}
+	}
+	return p
+}
+
+// TotalCount returns the number of results served, after the filter
+func (p *PagedResults) TotalCount() int {
+	return len(p.visible)
+}
+
+// Order returns the sort order results are served in
+func (p *PagedResults) Order() SortOrder {
+	return p.order
+}
+
+// Page returns up to pageSize results following cursor ("" for the first
+// page), and the cursor of the next page ("" after the last one)
+// Returns ErrInvalidCursor for a malformed cursor or one issued under another
+// sort order, and ErrCursorPastEnd when no result follows the cursor.
+func (p *PagedResults) Page(cursor string, pageSize int) ([]ComparisonResult, string, error) {
+	if pageSize <= 0 {
+		return nil, "", fmt.Errorf("duplicatecheck: page size %d must be positive", pageSize)
+	}
+	start := 0
+	if cursor != "" {
+		c, err := p.decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if start = p.after(c); start >= len(p.visible) {
+			return nil, "", ErrCursorPastEnd
+		}
+	}
+
+	end := start + pageSize
+	if end > len(p.visible) {
+		end = len(p.visible)
+	}
+	items := make([]ComparisonResult, 0, end-start)
+	for _, i := range p.visible[start:end] {
+		items = append(items, p.results[i])
+	}
+	if end == len(p.visible) {
+		return items, "", nil
+	}
+	c := pageCursor{Version: pageCursorVersion, Order: p.order, Position: end, Key: p.keys[p.visible[end-1]]}
+	for i := end - 1; i >= 0 && p.keys[p.visible[i]] == c.Key; i-- {
+		c.Repeat++
+	}
+	next, err := p.encodeCursor(c)
+	return items, next, err
+}
+
+// after returns the position among visible results of the first one after c
+// The cursor's position is tried first; when the results before it changed
+// (another filter, another container) the key is binary searched, skipping
+// the copies of its result already served.
+func (p *PagedResults) after(c pageCursor) int {
+	if c.Position > 0 && c.Position <= len(p.visible) && p.keys[p.visible[c.Position-1]] == c.Key {
+		return c.Position
+	}
+	start := sort.Search(len(p.visible), func(i int) bool { return !p.less(&p.keys[p.visible[i]], &c.Key) })
+	for served := 0; served < c.Repeat && start < len(p.visible) && p.keys[p.visible[start]] == c.Key; served++ {
+		start++
+	}
+	return start
+}
+
+// encodeCursor returns the opaque form of c: unpadded URL-safe base64 of its JSON
+func (p *PagedResults) encodeCursor(c pageCursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor parses a cursor issued by encodeCursor for this sort order
+func (p *PagedResults) decodeCursor(cursor string) (pageCursor, error) {
+	var c pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if c.Version != pageCursorVersion {
+		return c, fmt.Errorf("%w: version %d", ErrInvalidCursor, c.Version)
+	}
+	if c.Order != p.order {
+		return c, fmt.Errorf("%w: issued for order %s, results are in %s", ErrInvalidCursor, c.Order, p.order)
+	}
+	return c, nil
+}
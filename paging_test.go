@@ -0,0 +1,217 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// pagingResults returns the duplicates of a generated catalog
+func pagingResults(t *testing.T) []ComparisonResult {
+	t.Helper()
+	results := NewLevenshteinEngine().FindDuplicates(GenerateTestCatalog(goldenCatalogSeed, 150), 0.75)
+	if len(results) < 20 {
+		t.Fatalf("only %d results to page through", len(results))
+	}
+	return results
+}
+
+// allPages collects every page of p, failing on errors
+func allPages(t *testing.T, p *PagedResults, pageSize int) []ComparisonResult {
+	t.Helper()
+	var all []ComparisonResult
+	cursor := ""
+	for pages := 0; ; pages++ {
+		items, next, err := p.Page(cursor, pageSize)
+		if err != nil {
+			t.Fatalf("page %d: %v", pages, err)
+		}
+		if len(items) == 0 || (next != "" && len(items) != pageSize) {
+			t.Fatalf("page %d has %d items, next %q", pages, len(items), next)
+		}
+		all = append(all, items...)
+		if next == "" {
+			return all
+		}
+		cursor = next
+	}
+}
+
+func TestPagedResultsOrders(t *testing.T) {
+	results := pagingResults(t)
+
+	tests := []struct {
+		order SortOrder
+		less  func(a, b ComparisonResult) bool
+	}{
+		{BySimilarityDesc, func(a, b ComparisonResult) bool { return a.CombinedSimilarity > b.CombinedSimilarity }},
+		{ByProductAID, func(a, b ComparisonResult) bool { return a.ProductA.ID < b.ProductA.ID }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order.String(), func(t *testing.T) {
+			paged := NewPagedResults(results, tt.order)
+			for _, pageSize := range []int{1, 7, len(results), 1000} {
+				all := allPages(t, paged, pageSize)
+				if len(all) != len(results) || paged.TotalCount() != len(results) {
+					t.Fatalf("page size %d: %d results of %d", pageSize, len(all), len(results))
+				}
+				if !sort.SliceIsSorted(all, func(i, j int) bool { return tt.less(all[i], all[j]) }) {
+					t.Errorf("page size %d: results out of order", pageSize)
+				}
+				// Every result exactly once
+				if !reflect.DeepEqual(CanonicalizeResults(all), CanonicalizeResults(results)) {
+					t.Errorf("page size %d: pages differ from the results", pageSize)
+				}
+			}
+		})
+	}
+
+	t.Run("cluster_size", func(t *testing.T) {
+		all := allPages(t, NewPagedResults(results, ByClusterSize), 5)
+		groups := ClusterDuplicates(results, ClusterOptions{})
+		size := make(map[string]int)
+		for _, group := range groups {
+			for _, product := range group.Products {
+				size[product.ID] = len(group.Products)
+			}
+		}
+		// Cluster sizes never grow, and each cluster's pairs are contiguous
+		seen := make(map[int]bool)
+		for i, r := range all {
+			if i > 0 && size[r.ProductA.ID] > size[all[i-1].ProductA.ID] {
+				t.Fatalf("cluster of %d after one of %d", size[r.ProductA.ID], size[all[i-1].ProductA.ID])
+			}
+			group := -1
+			for g := range groups {
+				for _, product := range groups[g].Products {
+					if product.ID == r.ProductA.ID {
+						group = g
+					}
+				}
+			}
+			if i > 0 && !sameCluster(groups[group], all[i-1]) && seen[group] {
+				t.Fatalf("cluster %d split across the order", group)
+			}
+			seen[group] = true
+		}
+		if len(all) != len(results) {
+			t.Errorf("%d results of %d", len(all), len(results))
+		}
+	})
+}
+
+// sameCluster reports whether r belongs to group
+func sameCluster(group DuplicateGroup, r ComparisonResult) bool {
+	for _, product := range group.Products {
+		if product.ID == r.ProductA.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPagedResultsCursors(t *testing.T) {
+	results := pagingResults(t)
+	paged := NewPagedResults(results, BySimilarityDesc)
+
+	// The same cursor serves the same page on every call, and on a new
+	// container built from the same results in another order
+	first, cursor, err := paged.Page("", 5)
+	if err != nil || cursor == "" {
+		t.Fatalf("first page: %v, cursor %q", err, cursor)
+	}
+	shuffled := append([]ComparisonResult(nil), results...)
+	for i, j := 0, len(shuffled)-1; i < j; i, j = i+1, j-1 {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	a, nextA, errA := paged.Page(cursor, 5)
+	b, nextB, errB := paged.Page(cursor, 5)
+	c, _, errC := NewPagedResults(shuffled, BySimilarityDesc).Page(cursor, 5)
+	if errA != nil || errB != nil || errC != nil {
+		t.Fatal(errA, errB, errC)
+	}
+	if !reflect.DeepEqual(a, b) || nextA != nextB || !reflect.DeepEqual(a, c) {
+		t.Error("cursor served different pages")
+	}
+	if a[0].CombinedSimilarity > first[len(first)-1].CombinedSimilarity {
+		t.Error("second page starts above the first")
+	}
+	// A larger page size from the same cursor starts at the same result
+	if bigger, _, err := paged.Page(cursor, 9); err != nil || !reflect.DeepEqual(bigger[:5], a) {
+		t.Errorf("page size changed where the cursor resumes: %v", err)
+	}
+
+	// Copies of a result are each served once, even split across pages
+	doubled := append(append([]ComparisonResult(nil), results...), results...)
+	if all := allPages(t, NewPagedResults(doubled, BySimilarityDesc), 3); !reflect.DeepEqual(CanonicalizeResults(all), CanonicalizeResults(doubled)) {
+		t.Errorf("%d of %d copied results served", len(all), len(doubled))
+	}
+
+	// Malformed cursors and cursors of another order
+	_, byID, _ := NewPagedResults(results, ByProductAID).Page("", 5)
+	for _, bad := range []string{"not base64!", "e30", strings.Repeat("A", 12), byID} {
+		if _, _, err := paged.Page(bad, 5); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("cursor %q: %v, want ErrInvalidCursor", bad, err)
+		}
+	}
+	if _, _, err := paged.Page("", 0); err == nil {
+		t.Error("page size 0 accepted")
+	}
+
+	// A cursor from a larger result set, past the end of a smaller one
+	var last string
+	for next := cursor; next != ""; {
+		last = next
+		_, next, _ = paged.Page(next, 5)
+	}
+	if _, _, err := NewPagedResults(results[:5], BySimilarityDesc).Page(last, 5); !errors.Is(err, ErrCursorPastEnd) {
+		t.Errorf("cursor past the end: %v, want ErrCursorPastEnd", err)
+	}
+}
+
+func TestPagedResultsFilter(t *testing.T) {
+	results := pagingResults(t)
+	strong := func(r ComparisonResult) bool { return r.CombinedSimilarity >= 0.85 }
+	want := 0
+	for _, r := range results {
+		if strong(r) {
+			want++
+		}
+	}
+	if want == 0 || want == len(results) {
+		t.Fatalf("filter keeps %d of %d results", want, len(results))
+	}
+
+	paged := NewPagedResults(results, ByProductAID).Filter(strong)
+	if paged.TotalCount() != want {
+		t.Errorf("TotalCount %d, want %d", paged.TotalCount(), want)
+	}
+	all := allPages(t, paged, 3)
+	if len(all) != want {
+		t.Errorf("pages hold %d results, want %d", len(all), want)
+	}
+	for _, r := range all {
+		if !strong(r) {
+			t.Errorf("filtered out result served: %v", r.CombinedSimilarity)
+		}
+	}
+
+	// A cursor taken unfiltered resumes at the same place once filtered
+	unfiltered := NewPagedResults(results, ByProductAID)
+	page, cursor, err := unfiltered.Page("", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filtered, _, err := unfiltered.Filter(strong).Page(cursor, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filtered[0].ProductA.ID < page[len(page)-1].ProductA.ID {
+		t.Errorf("filtered page resumed at %s, before %s", filtered[0].ProductA.ID, page[len(page)-1].ProductA.ID)
+	}
+	if unfiltered.Filter(nil).TotalCount() != len(results) {
+		t.Error("nil filter did not restore every result")
+	}
+}
@@ -0,0 +1,189 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrMatchesNotRetained is returned by UpdatePairsForProduct and
+// RemovePairsForProduct when no matches are retained (see HybridConfig.RetainMatches)
+var ErrMatchesNotRetained = errors.New("duplicatecheck: no matches retained")
+
+// PairDelta is the change one product's edit or removal makes to a stored
+// duplicate-pair set: delete the pairs of ProductID with each of Deleted,
+// then write Inserted and Updated
+type PairDelta struct {
+	ProductID string `json:"product_id"`
+	// Removed is set when the product left the index: Deleted holds all its partners
+	Removed bool `json:"removed,omitempty"`
+	// Deleted are the partners whose pairs no longer match, sorted
+	Deleted []string `json:"deleted,omitempty"`
+	// Inserted are the new pairs, with the product as ProductA
+	Inserted []ComparisonResult `json:"inserted,omitempty"`
+	// Updated are the pairs still matching whose CombinedSimilarity changed,
+	// with the product as ProductA
+	Updated []ComparisonResult `json:"updated,omitempty"`
+}
+
+// Empty reports whether the delta changes nothing
+func (d PairDelta) Empty() bool {
+	return len(d.Deleted) == 0 && len(d.Inserted) == 0 && len(d.Updated) == 0
+}
+
+// pairPartners is a duplicate-pair set by product: each pair is held under
+// both of its products
+type pairPartners struct {
+	partners map[string]map[string]float64 // Product ID -> partner ID -> CombinedSimilarity
+}
+
+// newPairPartners returns an empty pair set
+func newPairPartners() *pairPartners {
+	return &pairPartners{partners: make(map[string]map[string]float64)}
+}
+
+// set records the pair of a and b with its similarity
+func (m *pairPartners) set(a, b string, similarity float64) {
+	for _, ids := range [2][2]string{{a, b}, {b, a}} {
+		if m.partners[ids[0]] == nil {
+			m.partners[ids[0]] = make(map[string]float64)
+		}
+		m.partners[ids[0]][ids[1]] = similarity
+	}
+}
+
+// drop forgets the pair of a and b
+func (m *pairPartners) drop(a, b string) {
+	for _, ids := range [2][2]string{{a, b}, {b, a}} {
+		delete(m.partners[ids[0]], ids[1])
+		if len(m.partners[ids[0]]) == 0 {
+			delete(m.partners, ids[0])
+		}
+	}
+}
+
+// apply brings the set up to date with delta
+func (m *pairPartners) apply(delta PairDelta) {
+	for _, partner := range delta.Deleted {
+		m.drop(delta.ProductID, partner)
+	}
+	for _, results := range [][]ComparisonResult{delta.Inserted, delta.Updated} {
+		for _, r := range results {
+			m.set(delta.ProductID, r.ProductB.ID, r.CombinedSimilarity)
+		}
+	}
+}
+
+// retainedMatches returns the matches retained from the last FindDuplicates run
+func (e *HybridEngine) retainedMatches() (*pairPartners, error) {
+	e.indexMu.RLock()
+	defer e.indexMu.RUnlock()
+	if e.lshIndex == nil {
+		return nil, ErrIndexNotBuilt
+	}
+	if e.matches == nil {
+		return nil, ErrMatchesNotRetained
+	}
+	return e.matches, nil
+}
+
+// UpdatePairsForProduct indexes a new version of one product and returns how
+// the duplicate pairs found by the last FindDuplicates run change at threshold
+// (see HybridConfig.RetainMatches)
+// The product is updated as by UpdateProduct, or added as by AddProduct if it
+// isn't indexed; newVersion is indexed under productID whatever its ID. Its new
+// pairs come from FindDuplicatesForOne, and the retained matches are updated
+// with the delta, so each call works from the pairs the previous ones left.
+// Apply every delta to the stored set in order and it stays what a fresh scan
+// at the same threshold would return, output shaping aside. Returns
+// ErrIndexNotBuilt, ErrMatchesNotRetained when no indexed FindDuplicates ran
+// with RetainMatches, the index update's error, or ErrQueryTruncated together
+// with a delta that may delete pairs the query's MaxCandidates cut. Like
+// UpdateProduct it must not run concurrently with queries or other updates.
+func (e *HybridEngine) UpdatePairsForProduct(productID string, newVersion Product, threshold float64) (_ PairDelta, err error) {
+	defer e.levenshteinEngine.guard("UpdatePairsForProduct", &err)
+	matches, err := e.retainedMatches()
+	if err != nil {
+		return PairDelta{}, err
+	}
+	return e.updatePairs(productID, newVersion, threshold, matches.partners[productID], matches)
+}
+
+// UpdatePairsForProductFrom is UpdatePairsForProduct with the product's
+// previous pairs given by the caller, as partner ID -> CombinedSimilarity,
+// for stores the engine's scan didn't fill
+// A pair is reported as updated when its similarity differs from the given
+// one. Retained matches, if any, are updated too.
+func (e *HybridEngine) UpdatePairsForProductFrom(productID string, newVersion Product, threshold float64, previous map[string]float64) (_ PairDelta, err error) {
+	defer e.levenshteinEngine.guard("UpdatePairsForProductFrom", &err)
+	if e.currentIndex() == nil {
+		return PairDelta{}, ErrIndexNotBuilt
+	}
+	matches, _ := e.retainedMatches()
+	return e.updatePairs(productID, newVersion, threshold, previous, matches)
+}
+
+// updatePairs indexes newVersion and diffs its matches against previous,
+// applying the delta to matches (nil = none retained)
+func (e *HybridEngine) updatePairs(productID string, newVersion Product, threshold float64, previous map[string]float64, matches *pairPartners) (PairDelta, error) {
+	newVersion.ID = productID
+	var err error
+	if e.ContainsProduct(productID) {
+		err = e.UpdateProduct(newVersion)
+	} else {
+		err = e.AddProduct(newVersion)
+	}
+	if err != nil {
+		return PairDelta{}, err
+	}
+
+	results, err := e.FindDuplicatesForOneChecked(newVersion, threshold)
+	if err != nil && !errors.Is(err, ErrQueryTruncated) {
+		return PairDelta{}, err
+	}
+	delta := PairDelta{ProductID: productID}
+	current := make(map[string]bool, len(results))
+	for _, r := range results {
+		partner := r.ProductB.ID
+		current[partner] = true
+		if similarity, paired := previous[partner]; !paired {
+			delta.Inserted = append(delta.Inserted, r)
+		} else if similarity != r.CombinedSimilarity {
+			delta.Updated = append(delta.Updated, r)
+		}
+	}
+	for partner := range previous {
+		if !current[partner] {
+			delta.Deleted = append(delta.Deleted, partner)
+		}
+	}
+	sort.Strings(delta.Deleted)
+
+	if matches != nil {
+		matches.apply(delta)
+	}
+	return delta, err
+}
+
+// RemovePairsForProduct removes one product from the index, as by
+// RemoveProduct, and returns the deletion of every pair retained for it (see
+// UpdatePairsForProduct)
+// Stores tracking pairs themselves can call RemoveProduct and delete all the
+// product's pairs.
+func (e *HybridEngine) RemovePairsForProduct(productID string) (_ PairDelta, err error) {
+	defer e.levenshteinEngine.guard("RemovePairsForProduct", &err)
+	matches, err := e.retainedMatches()
+	if err != nil {
+		return PairDelta{}, err
+	}
+	if err := e.RemoveProduct(productID); err != nil {
+		return PairDelta{}, err
+	}
+
+	delta := PairDelta{ProductID: productID, Removed: true}
+	for partner := range matches.partners[productID] {
+		delta.Deleted = append(delta.Deleted, partner)
+	}
+	sort.Strings(delta.Deleted)
+	matches.apply(delta)
+	return delta, nil
+}
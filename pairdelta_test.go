@@ -0,0 +1,225 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// pairStore is a caller's duplicate-pair table: PairKey -> CombinedSimilarity
+type pairStore map[string]float64
+
+// load fills the store from a scan
+func (s pairStore) load(results []ComparisonResult) {
+	for _, r := range results {
+		s[PairKey(r.ProductA.ID, r.ProductB.ID)] = r.CombinedSimilarity
+	}
+}
+
+// apply writes a delta to the store
+func (s pairStore) apply(delta PairDelta) {
+	for _, partner := range delta.Deleted {
+		delete(s, PairKey(delta.ProductID, partner))
+	}
+	s.load(delta.Inserted)
+	s.load(delta.Updated)
+}
+
+// retainingEngine returns an engine retaining matches, with catalog indexed and scanned
+func retainingEngine(t *testing.T, catalog []Product, threshold float64) (*HybridEngine, pairStore) {
+	t.Helper()
+	config := DefaultHybridConfig()
+	config.RetainMatches = true
+	engine := NewHybridEngineWithConfig(config)
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	store := pairStore{}
+	store.load(engine.FindDuplicates(catalog, threshold))
+	return engine, store
+}
+
+func TestUpdatePairsForProduct(t *testing.T) {
+	const threshold = 0.85
+	catalog := []Product{
+		{ID: "A", Name: "Sony WH-1000XM5 Wireless Noise Cancelling Headphones", Description: "Over-ear, black, 30 hours of battery"},
+		{ID: "B", Name: "Sony WH-1000XM5 Wireless Noise Cancelling Headphone", Description: "Over-ear, black, 30 hours of battery"},
+		{ID: "C", Name: "Apple AirPods Pro 2nd Generation with MagSafe Case", Description: "In-ear, white, USB-C charging"},
+		{ID: "D", Name: "Logitech MX Master 3S Wireless Mouse", Description: "Graphite, 8000 DPI sensor"},
+		{ID: "E", Name: "Samsung Galaxy S24 Ultra 512GB", Description: "Titanium gray smartphone"},
+	}
+	engine, store := retainingEngine(t, catalog, threshold)
+	if !reflect.DeepEqual(store, pairStore{PairKey("A", "B"): store[PairKey("A", "B")]}) {
+		t.Fatalf("initial pairs %v, want A-B only", store)
+	}
+
+	// B is rewritten into a copy of C: A-B goes, B-C comes
+	edited := Product{Name: "Apple AirPods Pro 2nd Generation with MagSafe Case.", Description: "In-ear, white, USB-C charging"}
+	delta, err := engine.UpdatePairsForProduct("B", edited, threshold)
+	if err != nil {
+		t.Fatalf("UpdatePairsForProduct failed: %v", err)
+	}
+	if delta.ProductID != "B" || delta.Removed || !reflect.DeepEqual(delta.Deleted, []string{"A"}) ||
+		len(delta.Inserted) != 1 || delta.Inserted[0].ProductA.ID != "B" || delta.Inserted[0].ProductB.ID != "C" || len(delta.Updated) != 0 {
+		t.Fatalf("delta %+v, want A deleted and B-C inserted", delta)
+	}
+	if !engine.ContainsProduct("B") || engine.IndexedProducts()[len(catalog)-1].Name != edited.Name {
+		t.Error("the new version of B wasn't indexed")
+	}
+	store.apply(delta)
+
+	// The same version again changes nothing
+	if again, err := engine.UpdatePairsForProduct("B", edited, threshold); err != nil || !again.Empty() {
+		t.Errorf("repeated update: %+v, %v", again, err)
+	}
+	// A slight edit keeps the pair at a new score
+	edited.Description = "In-ear, white, USB-C charging case"
+	delta, err = engine.UpdatePairsForProduct("B", edited, threshold)
+	if err != nil || len(delta.Deleted) != 0 || len(delta.Inserted) != 0 || len(delta.Updated) != 1 ||
+		delta.Updated[0].CombinedSimilarity == store[PairKey("B", "C")] {
+		t.Fatalf("score change: %+v, %v", delta, err)
+	}
+	store.apply(delta)
+
+	// A product not indexed yet is added
+	delta, err = engine.UpdatePairsForProduct("F", Product{ID: "ignored", Name: catalog[3].Name, Description: catalog[3].Description}, threshold)
+	if err != nil || len(delta.Inserted) != 1 || delta.Inserted[0].ProductB.ID != "D" || !engine.ContainsProduct("F") || engine.ContainsProduct("ignored") {
+		t.Fatalf("new product: %+v, %v", delta, err)
+	}
+	store.apply(delta)
+
+	// Removing C deletes all its pairs
+	delta, err = engine.RemovePairsForProduct("C")
+	if err != nil || !delta.Removed || !reflect.DeepEqual(delta.Deleted, []string{"B"}) || engine.ContainsProduct("C") {
+		t.Fatalf("removal: %+v, %v", delta, err)
+	}
+	store.apply(delta)
+	want := pairStore{PairKey("D", "F"): 1}
+	if !reflect.DeepEqual(store, want) {
+		t.Errorf("pairs %v, want %v", store, want)
+	}
+	if _, err := engine.RemovePairsForProduct("C"); !errors.Is(err, ErrProductNotIndexed) {
+		t.Errorf("removing C twice: %v, want ErrProductNotIndexed", err)
+	}
+}
+
+func TestUpdatePairsForProductFrom(t *testing.T) {
+	const threshold = 0.85
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 40)
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	if _, err := engine.UpdatePairsForProduct(catalog[0].ID, catalog[0], threshold); !errors.Is(err, ErrMatchesNotRetained) {
+		t.Errorf("without RetainMatches: %v, want ErrMatchesNotRetained", err)
+	}
+	if _, err := engine.RemovePairsForProduct(catalog[0].ID); !errors.Is(err, ErrMatchesNotRetained) || !engine.ContainsProduct(catalog[0].ID) {
+		t.Errorf("without RetainMatches: %v, want ErrMatchesNotRetained and nothing removed", err)
+	}
+	if _, err := NewHybridEngine().UpdatePairsForProductFrom("x", Product{}, threshold, nil); !errors.Is(err, ErrIndexNotBuilt) {
+		t.Errorf("without an index: %v, want ErrIndexNotBuilt", err)
+	}
+
+	// The caller's stored pairs of the first product, one of them stale
+	target := catalog[0]
+	previous := map[string]float64{"ARTICLE_9999": 0.9}
+	for _, r := range engine.FindDuplicatesForOne(target, threshold) {
+		previous[r.ProductB.ID] = r.CombinedSimilarity
+	}
+	delta, err := engine.UpdatePairsForProductFrom(target.ID, target, threshold, previous)
+	if err != nil || !reflect.DeepEqual(delta.Deleted, []string{"ARTICLE_9999"}) || len(delta.Inserted) != 0 || len(delta.Updated) != 0 {
+		t.Errorf("delta %+v, %v; want only the stale pair deleted", delta, err)
+	}
+}
+
+// TestPairDeltasConverge applies the deltas of random edits, additions and
+// removals to a stored pair set and checks it ends where a fresh scan does
+func TestPairDeltasConverge(t *testing.T) {
+	const threshold = 0.85
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 150)
+	engine, store := retainingEngine(t, catalog, threshold)
+
+	current := make(map[string]Product, len(catalog))
+	for _, p := range catalog {
+		current[p.ID] = p
+	}
+	ids := func() []string {
+		var list []string
+		engine.ForEachIndexed(func(p Product) bool {
+			list = append(list, p.ID)
+			return true
+		})
+		return list
+	}
+	rng := rand.New(rand.NewSource(3))
+	deleted := 0
+	for step := 0; step < 120; step++ {
+		live := ids()
+		id := live[rng.Intn(len(live))]
+		var delta PairDelta
+		var err error
+		switch op := rng.Intn(10); {
+		case op < 2:
+			delta, err = engine.RemovePairsForProduct(id)
+			delete(current, id)
+		default:
+			// Copy another product, sometimes with an edit, or write an unrelated one
+			source := current[live[rng.Intn(len(live))]]
+			version := Product{Name: source.Name, Description: source.Description}
+			switch rng.Intn(3) {
+			case 0:
+				version.Name += " v2"
+			case 1:
+				version = Product{Name: fmt.Sprintf("Unrelated product %d", step), Description: fmt.Sprintf("Filler text number %d", step*7919)}
+			}
+			if op == 9 {
+				id = fmt.Sprintf("NEW_%03d", step)
+			}
+			delta, err = engine.UpdatePairsForProduct(id, version, threshold)
+			version.ID = id
+			current[id] = version
+		}
+		if err != nil {
+			t.Fatalf("step %d on %s: %v", step, id, err)
+		}
+		deleted += len(delta.Deleted)
+		store.apply(delta)
+	}
+
+	var products []Product
+	for _, id := range ids() {
+		products = append(products, current[id])
+	}
+	fresh := NewHybridEngine()
+	if err := fresh.BuildIndex(products); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	want := pairStore{}
+	want.load(fresh.FindDuplicates(products, threshold))
+	if len(want) < 10 || deleted < 10 {
+		t.Fatalf("only %d pairs in the final catalog, %d deleted on the way", len(want), deleted)
+	}
+	if !reflect.DeepEqual(store, want) {
+		for key := range want {
+			if _, ok := store[key]; !ok {
+				t.Errorf("missing %s", key)
+			}
+		}
+		for key := range store {
+			if _, ok := want[key]; !ok {
+				t.Errorf("stale %s", key)
+			}
+		}
+		t.Fatalf("deltas left %d pairs, a fresh scan finds %d", len(store), len(want))
+	}
+}
+
+func TestRetainMatchesConfig(t *testing.T) {
+	config := DefaultHybridConfig()
+	config.RetainMatches = true
+	if !NewHybridEngineWithConfig(config).Config().Hybrid.RetainMatches {
+		t.Error("RetainMatches missing from Config")
+	}
+}
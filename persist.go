@@ -0,0 +1,322 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+)
+
+// indexFormatVersion is the index file format written by SaveIndex
+//
+//	1: per-product MinHash signatures; buckets were rebuilt from them on load
+//	2: LSH buckets stored directly
+//	3: bucket hashes salted per index (see HybridConfig.BucketSalt)
+const indexFormatVersion = 3
+
+// indexFileFormat identifies index files in their header
+const indexFileFormat = "duplicatecheck-index"
+
+// ErrInvalidIndexFile is returned by LoadIndex for input that isn't a
+// readable index file: a missing or corrupted header, or a damaged body
+var ErrInvalidIndexFile = errors.New("duplicatecheck: not a valid index file")
+
+// ErrIndexTooOld is returned by LoadIndex when upgrading an index file to the
+// current format would need the original products; rebuild it with BuildIndex
+var ErrIndexTooOld = errors.New("duplicatecheck: index file too old to migrate, rebuild the index")
+
+// IndexFormatVersion returns the index file format version SaveIndex writes
+func IndexFormatVersion() int {
+	return indexFormatVersion
+}
+
+// indexMigrations upgrade a saved index by one format version, keyed by the
+// version they read. A migration returns an error wrapping ErrIndexTooOld
+// when the file lacks what it needs.
+var indexMigrations = map[int]func(e *HybridEngine, doc *savedIndex) error{
+	1: migrateIndexV1,
+	2: migrateIndexV2,
+}
+
+// CanMigrate reports whether LoadIndex can upgrade index files written in
+// format version from to version to (from == to needs no migration)
+// A file can still be refused with ErrIndexTooOld if it lacks data its
+// migration needs.
+func CanMigrate(from, to int) bool {
+	if from < 1 || from > to || to > indexFormatVersion {
+		return false
+	}
+	for v := from; v < to; v++ {
+		if indexMigrations[v] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// indexFileHeader is the first JSON value of an index file
+type indexFileHeader struct {
+	Format  string         `json:"format"` // Always indexFileFormat
+	Version int            `json:"version"`
+	Config  SnapshotConfig `json:"config"`
+	// SaltCheck identifies the privacy mode salt, in hexadecimal, so a file is
+	// never queried with content hashes salted differently (privacy mode only)
+	SaltCheck string `json:"salt_check,omitempty"`
+}
+
+// savedIndex is the second JSON value of an index file
+// Fields are read by the format versions noted; migrations rewrite older
+// documents into the current layout.
+type savedIndex struct {
+	IDs           []string                      `json:"ids"`                      // Indexing order
+	Products      []Product                     `json:"products,omitempty"`       // Omitted in privacy mode
+	Fingerprints  map[string]SimHashFingerprint `json:"fingerprints,omitempty"`   // Privacy mode and SimHash screen
+	ContentHashes map[string]uint64             `json:"content_hashes,omitempty"` // Privacy mode
+	TotalChunks   int                           `json:"total_chunks"`
+	Sampled       int                           `json:"sampled"`
+	Truncated     int                           `json:"truncated"`
+	CatalogSize   int                           `json:"catalog_size,omitempty"` // Partial progressive builds
+	Buckets       []BucketSnapshot              `json:"buckets,omitempty"`      // Version 2
+	Signatures    []SignatureSnapshot           `json:"signatures,omitempty"`   // Version 1
+}
+
+// SaveIndex writes the built index to w in the current format (see
+// IndexFormatVersion), so LoadIndex can restore it without re-hashing the
+// catalog
+// The file holds a JSON header (format version and SnapshotConfig) followed by
+// a JSON document with the indexed products (or, in privacy mode, only their
+// fingerprints and salted hashes) and the LSH buckets.
+func (e *HybridEngine) SaveIndex(w io.Writer) (err error) {
+	defer e.levenshteinEngine.guard("SaveIndex", &err)
+	idx := e.currentIndex()
+	if idx == nil {
+		return ErrIndexNotBuilt
+	}
+
+	header := indexFileHeader{
+		Format:  indexFileFormat,
+		Version: indexFormatVersion,
+		Config:  e.snapshotConfig(idx),
+	}
+	header.Config.TotalProducts = idx.size()
+	if e.privacy != nil {
+		header.SaltCheck = e.privacy.saltCheck()
+	}
+	doc := savedIndex{
+		IDs:           idx.ids,
+		Fingerprints:  idx.fingerprints,
+		ContentHashes: idx.contentHashes,
+		TotalChunks:   idx.totalChunks,
+		Sampled:       idx.sampled,
+		Truncated:     idx.truncated,
+		CatalogSize:   idx.catalogSize,
+		Buckets:       snapshotBuckets(idx, 0),
+	}
+	if e.privacy == nil {
+		doc.Products = make([]Product, 0, len(idx.ids))
+		for _, id := range idx.ids {
+			p := idx.products[id]
+			doc.Products = append(doc.Products, Product{ID: p.ID, SourceID: p.SourceID, Name: p.Name, Description: p.Description})
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// LoadIndex replaces the index with one written by SaveIndex
+// Files from older format versions are upgraded on the fly when CanMigrate
+// allows it (version 1 rebuilds its buckets from the stored MinHash
+// signatures, without shingling any text); ErrIndexTooOld is returned only
+// when a migration would need the original products. The file must have been
+// built with the same bucketing settings (an error wrapping
+// ErrIncompatibleIndex otherwise) and, in privacy mode, the same salt.
+// Unreadable input returns an error wrapping ErrInvalidIndexFile. On any
+// error the current index is left untouched.
+func (e *HybridEngine) LoadIndex(r io.Reader) (err error) {
+	defer e.levenshteinEngine.guard("LoadIndex", &err)
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var header indexFileHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("%w: reading header: %v", ErrInvalidIndexFile, err)
+	}
+	if header.Format != indexFileFormat {
+		return fmt.Errorf("%w: header format is %q, want %q", ErrInvalidIndexFile, header.Format, indexFileFormat)
+	}
+	switch {
+	case header.Version > indexFormatVersion:
+		return fmt.Errorf("%w: format version %d is newer than this library reads (%d)",
+			ErrInvalidIndexFile, header.Version, indexFormatVersion)
+	case !CanMigrate(header.Version, indexFormatVersion):
+		return fmt.Errorf("%w: no migration from format version %d", ErrIndexTooOld, header.Version)
+	}
+	if err := e.CheckSnapshotConfig(header.Config); err != nil {
+		return err
+	}
+	if header.Config.PrivacyMode != (e.privacy != nil) {
+		return fmt.Errorf("%w: index privacy mode is %v, engine %v",
+			ErrIncompatibleIndex, header.Config.PrivacyMode, e.privacy != nil)
+	}
+	if e.privacy != nil && header.SaltCheck != e.privacy.saltCheck() {
+		return fmt.Errorf("%w: index was built with a different privacy salt", ErrIncompatibleIndex)
+	}
+	salt, err := e.parseBucketSalt(header.Config)
+	if err != nil {
+		return err
+	}
+
+	var doc savedIndex
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("%w: reading index: %v", ErrInvalidIndexFile, err)
+	}
+	for v := header.Version; v < indexFormatVersion; v++ {
+		if err := indexMigrations[v](e, &doc); err != nil {
+			return err
+		}
+	}
+	idx, err := e.restoreIndex(&doc, salt)
+	if err != nil {
+		return err
+	}
+
+	e.indexMu.Lock()
+	e.lshIndex = idx
+	e.retained = nil
+	e.matches = nil
+	e.indexMu.Unlock()
+
+	if e.logger != nil {
+		e.logger.LogAttrs(context.Background(), slog.LevelInfo, "index loaded",
+			slog.String("engine", "hybrid"),
+			slog.Int("products", len(idx.ids)),
+			slog.Int("format_version", header.Version))
+	}
+	return nil
+}
+
+// restoreIndex builds an LSH index from a saved index in the current format
+// whose buckets are keyed under salt
+func (e *HybridEngine) restoreIndex(doc *savedIndex, salt uint64) (*LSHIndex, error) {
+	idx := &LSHIndex{
+		bands:         e.newBandStore(),
+		numBands:      e.numBands,
+		rowsPerBand:   e.numHashFunctions / e.numBands,
+		products:      make(map[string]*Product, len(doc.Products)),
+		refs:          doc.IDs,
+		numbers:       make(map[string]uint32, len(doc.IDs)),
+		newBands:      e.newBandStore,
+		fingerprints:  doc.Fingerprints,
+		contentHashes: doc.ContentHashes,
+		totalChunks:   doc.TotalChunks,
+		sampled:       doc.Sampled,
+		truncated:     doc.Truncated,
+		ids:           append([]string(nil), doc.IDs...), // RemoveProduct edits ids in place, refs never
+		catalogSize:   doc.CatalogSize,
+		salt:          salt,
+	}
+	for n, id := range doc.IDs {
+		idx.numbers[id] = uint32(n)
+	}
+	for _, bucket := range doc.Buckets {
+		hash, err := strconv.ParseUint(bucket.Hash, 16, 64)
+		if err != nil || bucket.Band < 0 || bucket.Band >= e.numBands {
+			return nil, fmt.Errorf("%w: bad bucket (band %d, hash %q)", ErrInvalidIndexFile, bucket.Band, bucket.Hash)
+		}
+		for _, id := range bucket.Members {
+			n, exists := idx.numbers[id]
+			if !exists {
+				return nil, fmt.Errorf("%w: bucket member %q is not an indexed product", ErrInvalidIndexFile, id)
+			}
+			idx.bands.Append(bucket.Band, hash, n)
+		}
+	}
+	if err := finishBandStore(idx.bands); err != nil {
+		return nil, err
+	}
+
+	e.levenshteinEngine.interner.internProducts(doc.Products)
+	for i := range doc.Products {
+		product := &doc.Products[i]
+		product.loadCacheFor(e.preparer())
+		idx.products[product.ID] = product
+	}
+	if e.privacy != nil {
+		if len(idx.fingerprints) != len(idx.ids) || len(idx.contentHashes) != len(idx.ids) {
+			return nil, fmt.Errorf("%w: privacy index lacks fingerprints", ErrInvalidIndexFile)
+		}
+		return idx, nil
+	}
+	if len(idx.products) != len(idx.ids) {
+		return nil, fmt.Errorf("%w: %d product IDs but %d products", ErrInvalidIndexFile, len(idx.ids), len(idx.products))
+	}
+	if e.prefixIndex && e.privacy == nil {
+		idx.prefixes = newPrefixIndex()
+		for _, id := range idx.ids {
+			name, _ := idx.products[id].preparedStrings(e.preparer())
+			idx.prefixes.add(name, id)
+		}
+	}
+	if e.simHashScreen && len(idx.fingerprints) != len(idx.ids) {
+		// Saved without the SimHash screen: fingerprint the stored text
+		idx.fingerprints = make(map[string]SimHashFingerprint, len(idx.ids))
+		for id, product := range idx.products {
+			text, _ := e.limitedIndexText(product)
+			idx.fingerprints[id] = e.simHash.Compute64(text)
+		}
+	}
+	return idx, nil
+}
+
+// migrateIndexV1 rebuilds the buckets of a version 1 file from its per-product
+// MinHash signatures, which is cheap: no text is shingled
+// Products saved without signatures are re-hashed from their stored text; in
+// privacy mode there is none, so the file is too old.
+func migrateIndexV1(e *HybridEngine, doc *savedIndex) error {
+	signatures := make(map[string][][]uint32, len(doc.Signatures))
+	for _, s := range doc.Signatures {
+		signatures[s.ProductID] = s.Signatures
+	}
+	products := make(map[string]*Product, len(doc.Products))
+	for i := range doc.Products {
+		products[doc.Products[i].ID] = &doc.Products[i]
+	}
+
+	idx := &LSHIndex{bands: NewMemoryBandStore(e.numBands), numBands: e.numBands, refs: doc.IDs}
+	doc.TotalChunks = 0
+	for n, id := range doc.IDs {
+		sigs, ok := signatures[id]
+		if !ok {
+			product, ok := products[id]
+			if !ok {
+				return fmt.Errorf("%w: product %q has neither signatures nor text", ErrIndexTooOld, id)
+			}
+			text, _ := e.limitedIndexText(product)
+			sigs = e.productSignatures(product, text)
+		}
+		entry := indexEntry{signatures: len(sigs), bandHashes: e.bandHashes(sigs)}
+		for bandIdx := 0; bandIdx < e.numBands; bandIdx++ {
+			e.addToBand(idx, bandIdx, uint32(n), entry)
+		}
+		doc.TotalChunks += len(sigs)
+	}
+	doc.Buckets = snapshotBuckets(idx, 0)
+	doc.Signatures = nil
+	return nil
+}
+
+// migrateIndexV2 upgrades a version 2 file, whose bucket hashes are unsalted:
+// its header carries no bucket salt, so the index keeps them as they are
+func migrateIndexV2(e *HybridEngine, doc *savedIndex) error {
+	return nil
+}
@@ -0,0 +1,296 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// indexV1Fixture is a version 1 index file of the first indexV1Products
+// products of the golden catalog, built with the default hybrid config
+// Run `go test -run TestLoadIndexV1 -update` after an intentional change to
+// bucketing (the file then fails CheckSnapshotConfig).
+var indexV1Fixture = filepath.Join("testdata", "index_v1.json")
+
+const indexV1Products = 40
+
+// writeIndexV1 writes the built index of e in format version 1: products and
+// their MinHash signatures, without buckets
+func writeIndexV1(e *HybridEngine, w *bytes.Buffer) error {
+	idx := e.lshIndex
+	header := indexFileHeader{Format: indexFileFormat, Version: 1, Config: e.snapshotConfig(idx)}
+	header.Config.BucketSalt = "" // Version 1 had no bucket salt
+	doc := savedIndex{IDs: idx.ids, Fingerprints: idx.fingerprints, ContentHashes: idx.contentHashes}
+	if e.privacy != nil {
+		header.SaltCheck = e.privacy.saltCheck()
+	}
+	for _, id := range idx.ids {
+		product := idx.products[id]
+		if product == nil {
+			continue // Privacy mode: version 1 stored no signatures
+		}
+		doc.Products = append(doc.Products, *product)
+		text, _ := e.limitedIndexText(product)
+		doc.Signatures = append(doc.Signatures, SignatureSnapshot{ProductID: id, Signatures: e.computeSignatures(text)})
+	}
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	return enc.Encode(doc)
+}
+
+// sameIndexResults checks that loaded finds what built finds, for the whole
+// catalog and per product
+func sameIndexResults(t *testing.T, built, loaded *HybridEngine, products []Product) {
+	t.Helper()
+	want := CanonicalizeResults(built.FindDuplicates(products, 0.75))
+	if len(want) == 0 {
+		t.Fatal("The catalog should produce duplicates")
+	}
+	if got := CanonicalizeResults(loaded.FindDuplicates(products, 0.75)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Loaded index found %v, built index %v", got, want)
+	}
+	for _, p := range products[:10] {
+		want := CanonicalizeResults(built.FindDuplicatesForOne(p, 0.75))
+		if got := CanonicalizeResults(loaded.FindDuplicatesForOne(p, 0.75)); !reflect.DeepEqual(got, want) {
+			t.Errorf("Query %s: loaded index found %v, built index %v", p.ID, got, want)
+		}
+	}
+	if !reflect.DeepEqual(bucketMembers(loaded.lshIndex), bucketMembers(built.lshIndex)) {
+		t.Error("Loaded buckets differ from built buckets")
+	}
+}
+
+func TestSaveLoadIndex(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, 60)
+	tests := []struct {
+		name   string
+		engine func() *HybridEngine
+	}{
+		{"Default", NewHybridEngine},
+		{"Chunked", func() *HybridEngine {
+			config := DefaultHybridConfig()
+			config.ChunkedSignatures, config.ChunkSize, config.ChunkOverlap = true, 60, 20
+			return NewHybridEngineWithConfig(config)
+		}},
+		{"SimHash screen", func() *HybridEngine {
+			config := DefaultHybridConfig()
+			config.SimHashScreen = true
+			return NewHybridEngineWithConfig(config)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			built := tt.engine()
+			if err := built.BuildIndex(products); err != nil {
+				t.Fatal(err)
+			}
+			var file bytes.Buffer
+			if err := built.SaveIndex(&file); err != nil {
+				t.Fatal(err)
+			}
+			var header indexFileHeader
+			if err := json.NewDecoder(bytes.NewReader(file.Bytes())).Decode(&header); err != nil || header.Version != IndexFormatVersion() {
+				t.Errorf("Header version %d (%v), want %d", header.Version, err, IndexFormatVersion())
+			}
+
+			loaded := tt.engine()
+			if err := loaded.LoadIndex(&file); err != nil {
+				t.Fatal(err)
+			}
+			if loaded.IndexedCount() != len(products) {
+				t.Errorf("Loaded %d products, want %d", loaded.IndexedCount(), len(products))
+			}
+			sameIndexResults(t, built, loaded, products)
+		})
+	}
+
+	t.Run("Privacy mode", func(t *testing.T) {
+		salt := []byte("catalog salt")
+		built := NewHybridEngine()
+		built.EnablePrivacyMode(PrivacyOptions{Salt: salt})
+		if err := built.BuildIndex(products); err != nil {
+			t.Fatal(err)
+		}
+		var file bytes.Buffer
+		if err := built.SaveIndex(&file); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(file.String(), products[0].Name) {
+			t.Error("A privacy mode index file should hold no product text")
+		}
+
+		other := NewHybridEngine()
+		other.EnablePrivacyMode(PrivacyOptions{Salt: []byte("other salt")})
+		if err := other.LoadIndex(bytes.NewReader(file.Bytes())); !errors.Is(err, ErrIncompatibleIndex) {
+			t.Errorf("Loading with another salt: %v, want ErrIncompatibleIndex", err)
+		}
+		if err := NewHybridEngine().LoadIndex(bytes.NewReader(file.Bytes())); !errors.Is(err, ErrIncompatibleIndex) {
+			t.Errorf("Loading without privacy mode: %v, want ErrIncompatibleIndex", err)
+		}
+
+		loaded := NewHybridEngine()
+		loaded.EnablePrivacyMode(PrivacyOptions{Salt: salt})
+		if err := loaded.LoadIndex(&file); err != nil {
+			t.Fatal(err)
+		}
+		sameIndexResults(t, built, loaded, products)
+	})
+
+	if err := NewHybridEngine().SaveIndex(&bytes.Buffer{}); !errors.Is(err, ErrIndexNotBuilt) {
+		t.Errorf("SaveIndex without an index: %v, want ErrIndexNotBuilt", err)
+	}
+}
+
+// A loaded index must keep removals out of its number table, as a built one does
+func TestLoadIndexThenRemove(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, 120)
+	built := NewHybridEngine()
+	if err := built.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	var file bytes.Buffer
+	if err := built.SaveIndex(&file); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewHybridEngine()
+	if err := loaded.LoadIndex(&file); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < len(products); i += 10 {
+		for _, engine := range []*HybridEngine{built, loaded} {
+			if err := engine.RemoveProduct(products[i].ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if got, want := loaded.currentIndex().refs, built.currentIndex().refs; !reflect.DeepEqual(got, want) {
+		t.Errorf("Loaded index numbers %v, built %v", got, want)
+	}
+	if got, want := candidateIDs(loaded, products[:40], 0.8), candidateIDs(built, products[:40], 0.8); !reflect.DeepEqual(got, want) {
+		t.Errorf("Loaded index found %v, built %v", got, want)
+	}
+}
+
+func TestLoadIndexV1(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, indexV1Products)
+	built := NewHybridEngine()
+	if err := built.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	if *updateGolden {
+		var file bytes.Buffer
+		if err := writeIndexV1(built, &file); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(indexV1Fixture, file.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := os.Open(indexV1Fixture)
+	if err != nil {
+		t.Fatalf("Failed to open fixture (run with -update to create it): %v", err)
+	}
+	defer file.Close()
+	loaded := NewHybridEngine()
+	if err := loaded.LoadIndex(file); err != nil {
+		t.Fatal(err)
+	}
+	sameIndexResults(t, built, loaded, products)
+	if loaded.lshIndex.totalChunks != built.lshIndex.totalChunks {
+		t.Errorf("Loaded %d signatures, built %d", loaded.lshIndex.totalChunks, built.lshIndex.totalChunks)
+	}
+
+	t.Run("Privacy mode needs the products", func(t *testing.T) {
+		private := NewHybridEngine()
+		private.EnablePrivacyMode(PrivacyOptions{Salt: []byte("salt")})
+		if err := private.BuildIndex(products); err != nil {
+			t.Fatal(err)
+		}
+		var v1 bytes.Buffer
+		if err := writeIndexV1(private, &v1); err != nil {
+			t.Fatal(err)
+		}
+		if err := private.LoadIndex(&v1); !errors.Is(err, ErrIndexTooOld) {
+			t.Errorf("LoadIndex = %v, want ErrIndexTooOld", err)
+		}
+		if private.IndexedCount() != len(products) {
+			t.Error("A failed load should keep the current index")
+		}
+	})
+}
+
+func TestLoadIndexErrors(t *testing.T) {
+	built := NewHybridEngine()
+	if err := built.BuildIndex(GenerateTestCatalog(goldenCatalogSeed, 20)); err != nil {
+		t.Fatal(err)
+	}
+	var file bytes.Buffer
+	if err := built.SaveIndex(&file); err != nil {
+		t.Fatal(err)
+	}
+	valid := file.String()
+	header, body, _ := strings.Cut(valid, "\n")
+	withHeader := func(from, to string) string {
+		return strings.Replace(header, from, to, 1) + "\n" + body
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want error
+	}{
+		{"Empty", "", ErrInvalidIndexFile},
+		{"Not JSON", "\x00\x01 binary junk", ErrInvalidIndexFile},
+		{"Truncated header", header[:len(header)/2], ErrInvalidIndexFile},
+		{"Wrong format", withHeader(`"format":"duplicatecheck-index"`, `"format":"something-else"`), ErrInvalidIndexFile},
+		{"Missing version", withHeader(`"version":3,`, ``), ErrIndexTooOld},
+		{"Newer version", withHeader(`"version":3`, `"version":99`), ErrInvalidIndexFile},
+		{"Truncated body", valid[:len(valid)-len(body)/2], ErrInvalidIndexFile},
+		{"Bad bucket", strings.Replace(valid, `"band":0,"hash":"`, `"band":0,"hash":"zz`, 1), ErrInvalidIndexFile},
+		{"Other config", withHeader(`"fingerprint":"`, `"fingerprint":"0`), ErrIncompatibleIndex},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewHybridEngine().LoadIndex(strings.NewReader(tt.file))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("LoadIndex = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	config := DefaultHybridConfig()
+	config.ShingleSize = 2
+	if err := NewHybridEngineWithConfig(config).LoadIndex(strings.NewReader(valid)); !errors.Is(err, ErrIncompatibleIndex) {
+		t.Errorf("Loading into another shingle size: %v, want ErrIncompatibleIndex", err)
+	}
+}
+
+func TestCanMigrate(t *testing.T) {
+	tests := []struct {
+		from, to int
+		want     bool
+	}{
+		{1, 2, true},
+		{1, 3, true},
+		{2, 3, true},
+		{3, 3, true},
+		{1, 1, true},
+		{0, 2, false},
+		{2, 1, false},
+		{3, 4, false},
+	}
+	for _, tt := range tests {
+		if got := CanMigrate(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanMigrate(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
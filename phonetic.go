@@ -139,3 +139,20 @@ func (pf *PhoneticFilter) Enable() {
 func (pf *PhoneticFilter) IsEnabled() bool {
 	return pf.enabled
 }
+
+// Name returns the filter name ("phonetic")
+func (pf *PhoneticFilter) Name() string {
+	return "phonetic"
+}
+
+// MightMatch reports whether two names may reach threshold (see PreFilter)
+// Unlike MaybeMatch, a Soundex mismatch alone doesn't reject: Soundex keeps the
+// first letter verbatim, so "Phone" and "iPhone" get different codes while
+// being 83% similar. Names with different codes are only rejected when their
+// character counts also bound the similarity below threshold.
+func (pf *PhoneticFilter) MightMatch(nameA, nameB string, threshold float64) bool {
+	if pf.MaybeMatch(nameA, nameB) {
+		return true
+	}
+	return meetsThreshold(levenshteinSimilarityBound(nameA, nameB), threshold)
+}
@@ -0,0 +1,324 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrIncompletePipeline is returned by Pipeline.Run without a generator or a verifier
+var ErrIncompletePipeline = errors.New("duplicatecheck: pipeline needs a generator and a verifier")
+
+// CandidatePair is a pair of products proposed for verification
+type CandidatePair struct {
+	A, B *Product
+}
+
+// CandidateGenerator is the first pipeline stage: it proposes the pairs worth verifying
+type CandidateGenerator interface {
+	// Generate calls yield with candidate pairs until it runs out or yield
+	// returns false. Pairs may repeat, in either order, and may pair a product
+	// with itself; Pipeline drops both.
+	Generate(products []Product, yield func(CandidatePair) bool)
+}
+
+// PairFilter is a cheap check between generation and verification
+// Filters should be recall-safe, like PreFilter: Keep may keep pairs that
+// won't match, but a pair it drops is never verified.
+type PairFilter interface {
+	// Keep returns false only if a and b can't reach threshold
+	Keep(a, b Product, threshold float64) bool
+}
+
+// Verifier is the last pipeline stage: it scores a candidate pair
+type Verifier interface {
+	Verify(a, b Product) ComparisonResult
+}
+
+// Pipeline finds duplicates by passing the pairs a generator proposes through
+// filters, in order, and scoring the survivors with a verifier
+//
+//	pipeline := duplicatecheck.Pipeline{
+//	    Generator: duplicatecheck.BlockingGenerator{Strategy: duplicatecheck.NewBlockingStrategy(3)},
+//	    Filters:   []duplicatecheck.PairFilter{duplicatecheck.PreFilterPairFilter{Filter: duplicatecheck.NewPhoneticFilter()}},
+//	    Verifier:  duplicatecheck.LevenshteinVerifier{Engine: duplicatecheck.NewLevenshteinEngine()},
+//	}
+//	results, err := pipeline.Run(products, 0.85)
+//
+// The engines run their scans on the same stages (all pairs or LSH candidates,
+// their pre-filters, Levenshtein), so a custom pipeline shares their
+// parallelization, threshold and result handling.
+type Pipeline struct {
+	Generator CandidateGenerator
+	Filters   []PairFilter
+	Verifier  Verifier
+	// Workers is the number of goroutines verifying pairs; 0 or 1 verifies
+	// them sequentially, returning results in generation order. Above 1,
+	// filters and the verifier must be safe for concurrent use.
+	Workers int
+	// IDPolicy handles repeated product IDs (default DuplicateIDReject)
+	IDPolicy DuplicateIDPolicy
+}
+
+// Run returns every verified pair at or above threshold, each pair once
+// Returns an error for an incomplete pipeline, an invalid threshold, or input
+// rejected by the IDPolicy.
+func (p *Pipeline) Run(products []Product, threshold float64) ([]ComparisonResult, error) {
+	var results []ComparisonResult
+	err := p.Stream(products, threshold, func(result ComparisonResult) bool {
+		results = append(results, result)
+		return true
+	})
+	return results, err
+}
+
+// Stream is Run without collection: every match is passed to yield as soon as
+// it is found, on the calling goroutine; returning false stops the run early
+func (p *Pipeline) Stream(products []Product, threshold float64, yield func(ComparisonResult) bool) error {
+	if p.Generator == nil || p.Verifier == nil {
+		return ErrIncompletePipeline
+	}
+	if err := validateThreshold(threshold); err != nil {
+		return err
+	}
+	products, err := ResolveDuplicateIDs(products, p.IDPolicy)
+	if err != nil {
+		return err
+	}
+
+	checked := make(map[string]bool) // Pairs already proposed
+	generate := func(visit func(CandidatePair) bool) {
+		p.Generator.Generate(products, func(pair CandidatePair) bool {
+			if pair.A == nil || pair.B == nil || pair.A.ID == pair.B.ID {
+				return true
+			}
+			key := makePairKey(pair.A.ID, pair.B.ID)
+			if checked[key] {
+				return true
+			}
+			checked[key] = true
+			return visit(pair)
+		})
+	}
+	runStages(p.Workers, generate, func(pair CandidatePair) (ComparisonResult, bool) {
+		for _, filter := range p.Filters {
+			if !filter.Keep(*pair.A, *pair.B, threshold) {
+				return ComparisonResult{}, false
+			}
+		}
+		result := p.Verifier.Verify(*pair.A, *pair.B)
+		result.stampThreshold(threshold)
+		return result, result.MeetsThreshold
+	}, yield)
+	return nil
+}
+
+// runStages is the execution engine shared by Pipeline and the engine scans
+// It evaluates every pair generate proposes (filtering and verifying it) and
+// hands the kept results to yield on the calling goroutine; yield returning
+// false stops the run. With more than one worker, pairs are evaluated on a
+// worker pool and result order is nondeterministic; otherwise it follows
+// generation order.
+func runStages[P any](workers int, generate func(visit func(P) bool), evaluate func(P) (ComparisonResult, bool), yield func(ComparisonResult) bool) {
+	runWorkerStages(workers, generate, func() func(P) (ComparisonResult, bool) { return evaluate }, yield)
+}
+
+// runWorkerStages is runStages with per-worker state: newWorker is called once
+// per worker goroutine (once for a sequential run) for the evaluate function
+// that worker runs, so it may own buffers no other goroutine touches
+// Workers may produce any result type R, such as a pair's results under
+// several weight profiles. A panic in a worker or in generate stops the run
+// and is raised again on the calling goroutine (see workerGroup).
+func runWorkerStages[P, R any](workers int, generate func(visit func(P) bool), newWorker func() func(P) (R, bool), yield func(R) bool) {
+	if workers <= 1 {
+		evaluate := newWorker()
+		generate(func(pair P) bool {
+			result, keep := evaluate(pair)
+			return !keep || yield(result)
+		})
+		return
+	}
+
+	workChan := make(chan P, workers*2)
+	resultChan := make(chan R, workers*2)
+	stop := make(chan struct{}) // Closed when yield asks to stop or a worker panics
+	halt := sync.OnceFunc(func() { close(stop) })
+	defer halt() // Frees the workers should yield panic
+
+	// Start worker goroutines
+	group := &workerGroup{halt: halt}
+	for w := 0; w < workers; w++ {
+		group.Go(func() {
+			evaluate := newWorker()
+			for pair := range workChan {
+				if result, keep := evaluate(pair); keep {
+					select {
+					case resultChan <- result:
+					case <-stop:
+					}
+				}
+			}
+		})
+	}
+
+	// Send work items
+	group.Go(func() {
+		defer close(workChan)
+		generate(func(pair P) bool {
+			select {
+			case workChan <- pair:
+				return true
+			case <-stop:
+				return false
+			}
+		})
+	})
+
+	// Close the result channel once all workers have finished
+	go func() {
+		group.wg.Wait()
+		close(resultChan)
+	}()
+
+	// Hand results to yield on this goroutine
+	stopped := false
+	for result := range resultChan {
+		if stopped {
+			continue // Drain so workers can exit
+		}
+		if !yield(result) {
+			stopped = true
+			halt()
+		}
+	}
+	group.Wait() // Raises a worker's panic here
+}
+
+// AllPairsGenerator proposes every pair of products, in row order, as
+// LevenshteinEngine.FindDuplicates compares them
+type AllPairsGenerator struct{}
+
+// Generate implements CandidateGenerator
+func (AllPairsGenerator) Generate(products []Product, yield func(CandidatePair) bool) {
+	for i := range products {
+		for j := i + 1; j < len(products); j++ {
+			if !yield(CandidatePair{A: &products[i], B: &products[j]}) {
+				return
+			}
+		}
+	}
+}
+
+// BlockingGenerator proposes the pairs within each block of Strategy: products
+// whose names share a prefix
+// Blocks are visited in order of their first product, pairs within a block in row order.
+type BlockingGenerator struct {
+	Strategy *BlockingStrategy
+}
+
+// Generate implements CandidateGenerator
+func (g BlockingGenerator) Generate(products []Product, yield func(CandidatePair) bool) {
+	var keys []string
+	blocks := make(map[string][]int)
+	for i := range products {
+		key := g.Strategy.GetBlockKey(products[i])
+		if _, exists := blocks[key]; !exists {
+			keys = append(keys, key)
+		}
+		blocks[key] = append(blocks[key], i)
+	}
+	for _, key := range keys {
+		block := blocks[key]
+		for x, i := range block {
+			for _, j := range block[x+1:] {
+				if !yield(CandidatePair{A: &products[i], B: &products[j]}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// LSHGenerator proposes each product's LSH candidates from Engine's current
+// index, strongest first, as HybridEngine.FindDuplicates verifies them
+// Candidates are indexed products, so they need not be among the products
+// generated for. Build the index first (BuildIndex); without one, or in
+// privacy mode, where the index keeps no products, it proposes nothing.
+type LSHGenerator struct {
+	Engine *HybridEngine
+}
+
+// Generate implements CandidateGenerator
+func (g LSHGenerator) Generate(products []Product, yield func(CandidatePair) bool) {
+	idx := g.Engine.currentIndex()
+	if idx == nil {
+		return
+	}
+	for i := range products {
+		for _, candidate := range g.Engine.findCandidates(idx, &products[i], 0) {
+			indexed, exists := idx.products[candidate.id]
+			if !exists {
+				continue
+			}
+			if !yield(CandidatePair{A: &products[i], B: indexed}) {
+				return
+			}
+		}
+	}
+}
+
+// SimHashPairFilter drops pairs whose SimHash estimate of prepared name and
+// description is below threshold - Margin, as HybridConfig.SimHashScreen does
+// Like that screen it trades a little recall for speed; both fingerprints are
+// computed per pair.
+type SimHashPairFilter struct {
+	Filter *SimHashFilter
+	Margin float64
+}
+
+// NewSimHashPairFilter returns a filter with the hybrid engine's SimHash features and margin
+func NewSimHashPairFilter() *SimHashPairFilter {
+	return &SimHashPairFilter{Filter: newMixedSimHashFilter(3), Margin: simHashSafetyMargin}
+}
+
+// Keep implements PairFilter
+func (f *SimHashPairFilter) Keep(a, b Product, threshold float64) bool {
+	nameA, descA := a.getNormalizedStrings()
+	nameB, descB := b.getNormalizedStrings()
+	return QuickRejectFingerprints(f.Filter.Compute64(nameA+" "+descA), f.Filter.Compute64(nameB+" "+descB), threshold, f.Margin)
+}
+
+// PreFilterPairFilter runs a PreFilter on the prepared names of a pair
+// A disabled filter keeps every pair.
+type PreFilterPairFilter struct {
+	Filter PreFilter
+}
+
+// Keep implements PairFilter
+func (f PreFilterPairFilter) Keep(a, b Product, threshold float64) bool {
+	if !f.Filter.IsEnabled() {
+		return true
+	}
+	nameA, _ := a.getNormalizedStrings()
+	nameB, _ := b.getNormalizedStrings()
+	return f.Filter.MightMatch(nameA, nameB, threshold)
+}
+
+// LevenshteinVerifier scores pairs with Engine.Compare: its weights, weight
+// resolver, similarity mode and result options
+type LevenshteinVerifier struct {
+	Engine *LevenshteinEngine
+}
+
+// Verify implements Verifier
+func (v LevenshteinVerifier) Verify(a, b Product) ComparisonResult {
+	return v.Engine.ComparePtr(&a, &b)
+}
+
+var (
+	_ CandidateGenerator = AllPairsGenerator{}
+	_ CandidateGenerator = BlockingGenerator{}
+	_ CandidateGenerator = LSHGenerator{}
+	_ PairFilter         = (*SimHashPairFilter)(nil)
+	_ PairFilter         = PreFilterPairFilter{}
+	_ Verifier           = LevenshteinVerifier{}
+)
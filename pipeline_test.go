@@ -0,0 +1,198 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+// repeatingGenerator wraps a generator, proposing every pair twice (once
+// reversed) and each product with itself
+type repeatingGenerator struct {
+	inner CandidateGenerator
+}
+
+func (g repeatingGenerator) Generate(products []Product, yield func(CandidatePair) bool) {
+	g.inner.Generate(products, func(pair CandidatePair) bool {
+		return yield(pair) && yield(CandidatePair{A: pair.B, B: pair.A}) && yield(CandidatePair{A: pair.A, B: pair.A})
+	})
+}
+
+// countingVerifier counts the pairs it verifies
+type countingVerifier struct {
+	Verifier
+	mu    *sync.Mutex
+	pairs map[string]int
+}
+
+func (v countingVerifier) Verify(a, b Product) ComparisonResult {
+	v.mu.Lock()
+	v.pairs[PairKey(a.ID, b.ID)]++
+	v.mu.Unlock()
+	return v.Verifier.Verify(a, b)
+}
+
+func TestCustomPipeline(t *testing.T) {
+	products := []Product{
+		{ID: "1", Name: "Samsung Galaxy S23 Ultra", Description: "Smartphone with 200MP camera"},
+		{ID: "2", Name: "Samsung Galaxy S23 Ultr", Description: "Smartphone with 200MP camera"},
+		{ID: "3", Name: "Samsung Galaxy Tab S9", Description: "Tablet with AMOLED display"},
+		{ID: "4", Name: "Sony WH-1000XM5", Description: "Noise cancelling headphones"},
+		{ID: "5", Name: "Sony WH-1000XM4", Description: "Noise cancelling headphones"},
+		{ID: "6", Name: "Apple iPhone 15", Description: "Smartphone with 48MP camera"},
+		{ID: "7", Name: "Sonny WH-1000XM5", Description: "Noise cancelling headphones"},
+		{ID: "8", Name: "Sonos Era 100", Description: "Wireless speaker"},
+	}
+	verifier := countingVerifier{Verifier: LevenshteinVerifier{Engine: NewLevenshteinEngine()}, mu: &sync.Mutex{}, pairs: make(map[string]int)}
+	pipeline := Pipeline{
+		Generator: repeatingGenerator{BlockingGenerator{Strategy: NewBlockingStrategy(3)}},
+		Filters:   []PairFilter{PreFilterPairFilter{Filter: NewPhoneticFilter()}},
+		Verifier:  verifier,
+	}
+
+	results, err := pipeline.Run(products, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, r := range results {
+		if !r.MeetsThreshold || r.ThresholdUsed != 0.9 || r.CombinedSimilarity < 0.9 {
+			t.Errorf("%s ↔ %s: similarity %v, threshold %v, meets %v", r.ProductA.ID, r.ProductB.ID, r.CombinedSimilarity, r.ThresholdUsed, r.MeetsThreshold)
+		}
+		key := PairKey(r.ProductA.ID, r.ProductB.ID)
+		if got[key] {
+			t.Errorf("pair %s returned twice", key)
+		}
+		got[key] = true
+	}
+	for _, key := range []string{PairKey("1", "2"), PairKey("4", "5"), PairKey("4", "7")} {
+		if !got[key] {
+			t.Errorf("missing %s: %v", key, got)
+		}
+	}
+	if got[PairKey("1", "3")] {
+		t.Error("Galaxy phone and tablet matched at 0.9")
+	}
+	for key, n := range verifier.pairs {
+		if n != 1 {
+			t.Errorf("pair %s verified %d times", key, n)
+		}
+	}
+	if verifier.pairs[PairKey("1", "6")] != 0 || verifier.pairs[PairKey("1", "1")] != 0 {
+		t.Errorf("verified a pair outside its block or a self-pair: %v", verifier.pairs)
+	}
+	if verifier.pairs[PairKey("4", "8")] != 0 || verifier.pairs[PairKey("4", "5")] != 1 {
+		t.Errorf("phonetic filter should drop Sony ↔ Sonos only: %v", verifier.pairs)
+	}
+
+	t.Run("workers", func(t *testing.T) {
+		parallel := pipeline
+		parallel.Workers = 4
+		parallelResults, err := parallel.Run(products, 0.9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(CanonicalizeResults(parallelResults), CanonicalizeResults(results)) {
+			t.Error("parallel run found other pairs")
+		}
+	})
+
+	t.Run("stream stops early", func(t *testing.T) {
+		seen := 0
+		err := pipeline.Stream(products, 0.5, func(ComparisonResult) bool {
+			seen++
+			return false
+		})
+		if err != nil || seen != 1 {
+			t.Errorf("Stream saw %d results (%v), want 1", seen, err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := (&Pipeline{Generator: AllPairsGenerator{}}).Run(products, 0.9); !errors.Is(err, ErrIncompletePipeline) {
+			t.Errorf("no verifier: %v", err)
+		}
+		if _, err := pipeline.Run(products, 1.5); err == nil {
+			t.Error("threshold 1.5 accepted")
+		}
+		repeated := append([]Product{{ID: "1", Name: "Again"}}, products...)
+		var idErr *DuplicateIDError
+		if _, err := pipeline.Run(repeated, 0.9); !errors.As(err, &idErr) {
+			t.Errorf("repeated IDs: %v", err)
+		}
+	})
+}
+
+// TestCannedPipelines checks the adapters reproduce the engines' scans
+func TestCannedPipelines(t *testing.T) {
+	cfg := synth.Default()
+	cfg.Size = 60
+	products, _ := generatedCatalog(cfg)
+
+	levenshtein := NewLevenshteinEngine()
+	hybrid := NewHybridEngine()
+	if err := hybrid.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		pipeline Pipeline
+		want     func(threshold float64) []ComparisonResult
+	}{
+		{"levenshtein", Pipeline{Generator: AllPairsGenerator{}, Verifier: LevenshteinVerifier{Engine: levenshtein}, Workers: 4},
+			func(threshold float64) []ComparisonResult { return levenshtein.FindDuplicates(products, threshold) }},
+		{"hybrid", Pipeline{Generator: LSHGenerator{Engine: hybrid}, Verifier: LevenshteinVerifier{Engine: hybrid.levenshteinEngine}},
+			func(threshold float64) []ComparisonResult { return hybrid.FindDuplicates(products, threshold) }},
+	}
+	for _, tt := range tests {
+		for _, threshold := range []float64{0.75, 0.85} {
+			got, err := tt.pipeline.Run(products, threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.want(threshold)
+			if len(want) == 0 {
+				t.Fatalf("%s: no results to compare at %v", tt.name, threshold)
+			}
+			if !reflect.DeepEqual(CanonicalizeResults(got), CanonicalizeResults(want)) {
+				t.Errorf("%s at %v: pipeline found %d pairs, engine %d", tt.name, threshold, len(got), len(want))
+			}
+		}
+	}
+
+	t.Run("simhash filter", func(t *testing.T) {
+		pipeline := Pipeline{
+			Generator: AllPairsGenerator{},
+			Filters:   []PairFilter{NewSimHashPairFilter()},
+			Verifier:  LevenshteinVerifier{Engine: levenshtein},
+		}
+		got, err := pipeline.Run(products, 0.85)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := CanonicalizeResults(levenshtein.FindDuplicates(products, 0.85))
+		// A screen may lose a few pairs, but never invents one
+		all := make(map[string]bool)
+		for _, r := range want {
+			all[PairKey(r.ProductAID, r.ProductBID)] = true
+		}
+		for _, r := range got {
+			if !all[PairKey(r.ProductA.ID, r.ProductB.ID)] {
+				t.Errorf("filtered run found extra pair %s ↔ %s", r.ProductA.ID, r.ProductB.ID)
+			}
+		}
+		if len(got) < len(want)*9/10 {
+			t.Errorf("SimHash filter kept %d of %d pairs", len(got), len(want))
+		}
+	})
+
+	t.Run("no index", func(t *testing.T) {
+		pipeline := Pipeline{Generator: LSHGenerator{Engine: NewHybridEngine()}, Verifier: LevenshteinVerifier{Engine: levenshtein}}
+		if got, err := pipeline.Run(products, 0.85); err != nil || len(got) != 0 {
+			t.Errorf("got %d results (%v) without an index", len(got), err)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package duplicatecheck
+
+// PreFilter is a cheap check run before Levenshtein to skip pairs that can't
+// reach a threshold. Implementations must be recall-safe: MightMatch may let
+// dissimilar pairs through, but must never reject a pair whose Levenshtein
+// similarity is at or above threshold.
+//
+// SimHashFilter, RabinKarpFilter, and PhoneticFilter implement PreFilter.
+type PreFilter interface {
+	// Name identifies the filter in logs and stats
+	Name() string
+	// MightMatch returns false only if a and b can't reach threshold
+	MightMatch(a, b string, threshold float64) bool
+	Enable()
+	Disable()
+	IsEnabled() bool
+}
+
+var (
+	_ PreFilter = (*SimHashFilter)(nil)
+	_ PreFilter = (*RabinKarpFilter)(nil)
+	_ PreFilter = (*PhoneticFilter)(nil)
+)
+
+// levenshteinSimilarityBound returns an upper bound on the linear Levenshtein
+// similarity of a and b, computed in O(n) from their rune counts
+// Every edit fixes at most one surplus rune on each side, so the distance is at
+// least the larger of the two multiset differences.
+func levenshteinSimilarityBound(a, b string) float64 {
+	counts := make(map[rune]int)
+	lenA, lenB := 0, 0
+	for _, r := range a {
+		counts[r]++
+		lenA++
+	}
+	for _, r := range b {
+		counts[r]--
+		lenB++
+	}
+
+	maxLen := lenA
+	if lenB > maxLen {
+		maxLen = lenB
+	}
+	if maxLen == 0 {
+		return 1.0
+	}
+
+	surplusA, surplusB := 0, 0
+	for _, c := range counts {
+		if c > 0 {
+			surplusA += c
+		} else {
+			surplusB -= c
+		}
+	}
+	distance := surplusA
+	if surplusB > distance {
+		distance = surplusB
+	}
+	return 1.0 - float64(distance)/float64(maxLen)
+}
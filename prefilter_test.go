@@ -0,0 +1,111 @@
+package duplicatecheck
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// preFilters returns a fresh instance of every shipped PreFilter
+func preFilters() []PreFilter {
+	return []PreFilter{
+		NewSimHashFilter(3),
+		NewRabinKarpFilter(5),
+		NewPhoneticFilter(),
+	}
+}
+
+// mutateText applies edits random single-character edits to s
+func mutateText(rng *rand.Rand, s string, edits int) string {
+	b := []byte(s)
+	for i := 0; i < edits; i++ {
+		c := byte('a' + rng.Intn(26))
+		switch op := rng.Intn(3); {
+		case op == 0 || len(b) == 0:
+			pos := rng.Intn(len(b) + 1)
+			b = append(b[:pos], append([]byte{c}, b[pos:]...)...)
+		case op == 1:
+			pos := rng.Intn(len(b))
+			b = append(b[:pos], b[pos+1:]...)
+		default:
+			b[rng.Intn(len(b))] = c
+		}
+	}
+	return string(b)
+}
+
+// preFilterCorpus generates pairs of short names and longer phrases with a few edits each
+func preFilterCorpus() [][2]string {
+	rng := rand.New(rand.NewSource(21))
+	var pairs [][2]string
+	for i := 0; i < 400; i++ {
+		base := randomWordText(rng, 1+rng.Intn(8))
+		pairs = append(pairs, [2]string{base, mutateText(rng, base, 1+rng.Intn(4))})
+	}
+	// Brand-style pairs differing in the first letter, which Soundex keeps verbatim
+	pairs = append(pairs,
+		[2]string{"dell", "del"},
+		[2]string{"iphone", "phone"},
+		[2]string{"samsung galaxy", "xamsung galaxy"},
+	)
+	return pairs
+}
+
+func TestPreFilterContract(t *testing.T) {
+	engine := NewLevenshteinEngine()
+	corpus := preFilterCorpus()
+	thresholds := []float64{0.5, 0.7, 0.8, 0.85, 0.9, 0.95}
+
+	for _, f := range preFilters() {
+		t.Run(f.Name(), func(t *testing.T) {
+			t.Run("Enabled by default", func(t *testing.T) {
+				if !f.IsEnabled() {
+					t.Error("Filters should be enabled by default")
+				}
+			})
+
+			t.Run("Identical strings pass", func(t *testing.T) {
+				for _, pair := range corpus {
+					for _, threshold := range thresholds {
+						if !f.MightMatch(pair[0], pair[0], threshold) {
+							t.Fatalf("Rejected identical %q at %.2f", pair[0], threshold)
+						}
+					}
+				}
+			})
+
+			t.Run("Symmetric", func(t *testing.T) {
+				for _, pair := range corpus {
+					for _, threshold := range thresholds {
+						if f.MightMatch(pair[0], pair[1], threshold) != f.MightMatch(pair[1], pair[0], threshold) {
+							t.Fatalf("Asymmetric decision for %q / %q at %.2f", pair[0], pair[1], threshold)
+						}
+					}
+				}
+			})
+
+			t.Run("No false negatives", func(t *testing.T) {
+				for _, pair := range corpus {
+					similarity := engine.computeSimilarity(pair[0], pair[1], engine.computeDistance(pair[0], pair[1]))
+					for _, threshold := range thresholds {
+						if similarity >= threshold && !f.MightMatch(pair[0], pair[1], threshold) {
+							t.Errorf("Rejected %q / %q (similarity %.2f) at %.2f", pair[0], pair[1], similarity, threshold)
+						}
+					}
+				}
+			})
+
+			t.Run("Disabled filters pass", func(t *testing.T) {
+				f.Disable()
+				defer f.Enable()
+				if f.IsEnabled() {
+					t.Fatal("Disable should turn the filter off")
+				}
+				for _, pair := range corpus {
+					if !f.MightMatch(pair[0], pair[1]+" unrelated tail", 1.0) {
+						t.Fatalf("Disabled filter rejected %q / %q", pair[0], pair[1])
+					}
+				}
+			})
+		})
+	}
+}
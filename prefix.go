@@ -0,0 +1,337 @@
+package duplicatecheck
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Defaults of the prefix lookup (see HybridEngine.PrefixCandidates)
+const (
+	// DefaultPrefixLSHTokens is the default HybridConfig.PrefixLSHTokens
+	DefaultPrefixLSHTokens = 3
+	// DefaultPrefixLimit is the number of candidates PrefixCandidates returns
+	// for a limit below 1
+	DefaultPrefixLimit = 10
+)
+
+// Prefix lookup tuning
+const (
+	// prefixMinScore is the Score below which a candidate isn't returned
+	prefixMinScore = 0.5
+	// prefixFuzzRunes is how many trailing runes of the typed text may be
+	// mistyped: names sharing the rest are scored too when exact prefix
+	// matches are too few
+	prefixFuzzRunes = 2
+	// prefixFuzzMinRunes is the typed length from which the fuzzy lookup
+	// runs; shorter anchors would score most of the index
+	prefixFuzzMinRunes = 4
+)
+
+// ScoredCandidate is an indexed product matching text typed so far (see
+// HybridEngine.PrefixCandidates)
+type ScoredCandidate struct {
+	Product Product
+	// Score is how completely the typed text appears in the product's name:
+	// 1 - d/len(typed), where d is the fewest edits turning the typed text
+	// into some run of the name's runes. Typed text the name starts with, or
+	// contains, scores 1.0, so a half-typed name isn't penalized for the
+	// missing rest as a symmetric comparison would.
+	Score float64
+	// Coverage is the share of the name the typed text covers (its length
+	// over the name's, at most 1.0); among equal scores, names the user has
+	// typed more of rank first
+	Coverage float64
+	// FromPrefix is set when the name starts with the typed text, give or
+	// take its last prefixFuzzRunes runes
+	FromPrefix bool
+	// FromLSH is set when the product is an LSH candidate of the typed text,
+	// looked up from HybridConfig.PrefixLSHTokens words on
+	FromLSH bool
+}
+
+// prefixEntry is an indexed product in the prefix index
+type prefixEntry struct {
+	name  string // Prepared name
+	id    string
+	runes int // Rune length of name
+}
+
+// less orders entries by name, then ID
+func (p prefixEntry) less(other prefixEntry) bool {
+	if p.name != other.name {
+		return p.name < other.name
+	}
+	return p.id < other.id
+}
+
+// prefixIndex holds the prepared names of an index's products in sorted
+// order, so the names starting with a prefix are one contiguous range
+// Index builds append unsorted and the first lookup sorts; once sorted,
+// AddProduct and UpdateProduct insert in place. Updates never run
+// concurrently with queries (see AddProduct), so lookups read the sorted
+// entries without holding the lock.
+type prefixIndex struct {
+	mu      sync.Mutex
+	entries []prefixEntry
+	sorted  bool
+	names   map[string]string // Product ID -> prepared name
+}
+
+// newPrefixIndex returns an empty prefix index
+func newPrefixIndex() *prefixIndex {
+	return &prefixIndex{names: make(map[string]string)}
+}
+
+// add indexes the prepared name of a product
+func (p *prefixIndex) add(name, id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry := prefixEntry{name: name, id: id, runes: len([]rune(name))}
+	p.names[id] = name
+	n := len(p.entries)
+	if !p.sorted || n == 0 || p.entries[n-1].less(entry) {
+		p.entries = append(p.entries, entry)
+		return
+	}
+	i := sort.Search(n, func(i int) bool { return entry.less(p.entries[i]) })
+	p.entries = append(p.entries, prefixEntry{})
+	copy(p.entries[i+1:], p.entries[i:])
+	p.entries[i] = entry
+}
+
+// remove drops a product from the index
+func (p *prefixIndex) remove(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	name, ok := p.names[id]
+	if !ok {
+		return
+	}
+	delete(p.names, id)
+	entry := prefixEntry{name: name, id: id}
+	i := 0
+	if p.sorted {
+		i = sort.Search(len(p.entries), func(i int) bool { return !p.entries[i].less(entry) })
+	}
+	for ; i < len(p.entries); i++ {
+		if p.entries[i].id == id && p.entries[i].name == name {
+			p.entries = append(p.entries[:i], p.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// sortedEntries returns the entries sorted, sorting them on first use
+func (p *prefixIndex) sortedEntries() []prefixEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.sorted {
+		sort.Slice(p.entries, func(i, j int) bool { return p.entries[i].less(p.entries[j]) })
+		p.sorted = true
+	}
+	return p.entries
+}
+
+// size returns the number of indexed names
+func (p *prefixIndex) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// clone returns a copy of p that later updates to p don't affect; nil for nil
+func (p *prefixIndex) clone() *prefixIndex {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c := &prefixIndex{
+		entries: append([]prefixEntry(nil), p.entries...),
+		sorted:  p.sorted,
+		names:   make(map[string]string, len(p.names)),
+	}
+	for id, name := range p.names {
+		c.names[id] = name
+	}
+	return c
+}
+
+// prefixRange returns the range of sorted entries whose names start with prefix
+func prefixRange(entries []prefixEntry, prefix string) (lo, hi int) {
+	lo = sort.Search(len(entries), func(i int) bool { return entries[i].name >= prefix })
+	hi = lo + sort.Search(len(entries)-lo, func(i int) bool {
+		return !strings.HasPrefix(entries[lo+i].name, prefix)
+	})
+	return lo, hi
+}
+
+// typedScore returns ScoredCandidate.Score: 1 - d/len(typed), where d is the
+// edit distance from typed to the closest run of name's runes
+// This is the Levenshtein recurrence with a free start and end in name
+// (Sellers' algorithm), in two rows.
+func typedScore(typed []rune, name string, prev, curr []int) float64 {
+	if len(typed) == 0 {
+		return 0
+	}
+	runes := []rune(name)
+	for i := range prev[:len(typed)+1] {
+		prev[i] = i
+	}
+	best := len(typed)
+	for _, r := range runes {
+		curr[0] = 0 // A run may start anywhere in the name
+		for i, t := range typed {
+			cost := 1
+			if t == r {
+				cost = 0
+			}
+			curr[i+1] = min(min(prev[i]+cost, prev[i+1]+1), curr[i]+1)
+		}
+		if d := curr[len(typed)]; d < best {
+			best = d
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(best)/float64(len(typed))
+}
+
+// prefixLookup gathers the candidates of one PrefixCandidates call
+type prefixLookup struct {
+	typed      []rune
+	prev, curr []int // typedScore rows
+	found      map[string]*ScoredCandidate
+}
+
+// score adds or updates the candidate of an indexed product, returning it,
+// or nil if it scores below prefixMinScore
+func (l *prefixLookup) score(id, name string, runes int) *ScoredCandidate {
+	if c, ok := l.found[id]; ok {
+		return c
+	}
+	score := typedScore(l.typed, name, l.prev, l.curr)
+	if score < prefixMinScore {
+		return nil
+	}
+	coverage := 1.0
+	if runes > len(l.typed) {
+		coverage = float64(len(l.typed)) / float64(runes)
+	}
+	c := &ScoredCandidate{Score: score, Coverage: coverage}
+	l.found[id] = c
+	return c
+}
+
+// shortestNames returns up to limit entries of a range, shortest names first
+// (ties by name and ID): among names starting with the typed text, those
+// with the fewest runes left to type
+func shortestNames(entries []prefixEntry, limit int) []prefixEntry {
+	best := make([]prefixEntry, 0, limit)
+	shorter := func(a, b prefixEntry) bool {
+		if a.runes != b.runes {
+			return a.runes < b.runes
+		}
+		return a.less(b)
+	}
+	for _, entry := range entries {
+		if len(best) == limit && !shorter(entry, best[limit-1]) {
+			continue
+		}
+		i := sort.Search(len(best), func(i int) bool { return shorter(entry, best[i]) })
+		if len(best) < limit {
+			best = append(best, prefixEntry{})
+		}
+		copy(best[i+1:], best[i:])
+		best[i] = entry
+	}
+	return best
+}
+
+// PrefixCandidates returns up to limit indexed products whose names match a
+// partially typed name, best first, for autocomplete-style duplicate lookups
+// that run on every keystroke
+// Needs HybridConfig.EnablePrefixIndex; returns nil without it, without an
+// index, or in privacy mode. The typed text is prepared like names are. Names
+// starting with it are found in a sorted index of the prepared names, the
+// shortest first; when those are fewer than limit and at least 4 runes were
+// typed, names sharing all but its last 2 runes are scored too, to forgive a
+// typo in the rune just typed. From HybridConfig.PrefixLSHTokens words on, the
+// text is also looked up in the LSH index like a product with that name, and
+// the candidates merged, each product once. Candidates are ranked by Score,
+// then Coverage, then ID, and those scoring below 0.5 are dropped. limit < 1
+// uses DefaultPrefixLimit.
+func (e *HybridEngine) PrefixCandidates(partialName string, limit int) []ScoredCandidate {
+	defer e.levenshteinEngine.guard("PrefixCandidates", nil)
+	idx := e.currentIndex()
+	if idx == nil || idx.prefixes == nil {
+		return nil
+	}
+	if limit < 1 {
+		limit = DefaultPrefixLimit
+	}
+	typed, _, _ := e.preparer().options.prepare(partialName, "")
+	if typed == "" {
+		return nil
+	}
+	lookup := &prefixLookup{typed: []rune(typed), found: make(map[string]*ScoredCandidate)}
+	lookup.prev, lookup.curr = make([]int, len(lookup.typed)+1), make([]int, len(lookup.typed)+1)
+
+	// Names starting with the typed text, the closest to complete first
+	entries := idx.prefixes.sortedEntries()
+	lo, hi := prefixRange(entries, typed)
+	for _, entry := range shortestNames(entries[lo:hi], limit) {
+		lookup.score(entry.id, entry.name, entry.runes).FromPrefix = true
+	}
+	// Too few: forgive the last runes typed
+	if hi-lo < limit && len(lookup.typed) >= prefixFuzzMinRunes {
+		anchorLo, anchorHi := prefixRange(entries, string(lookup.typed[:len(lookup.typed)-prefixFuzzRunes]))
+		for i := anchorLo; i < anchorHi; i++ {
+			if i >= lo && i < hi {
+				continue
+			}
+			if c := lookup.score(entries[i].id, entries[i].name, entries[i].runes); c != nil {
+				c.FromPrefix = true
+			}
+		}
+	}
+
+	// Long enough to shingle: merge the LSH candidates
+	if len(strings.Fields(typed)) >= e.prefixLSHTokens {
+		query := Product{Name: partialName}
+		for _, candidate := range e.findCandidates(idx, &query, 0) {
+			product, ok := idx.products[candidate.id]
+			if !ok {
+				continue
+			}
+			name, _ := product.preparedStrings(e.preparer())
+			if c := lookup.score(candidate.id, name, len([]rune(name))); c != nil {
+				c.FromLSH = true
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(lookup.found))
+	for id := range lookup.found {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := lookup.found[ids[i]], lookup.found[ids[j]]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Coverage != b.Coverage {
+			return a.Coverage > b.Coverage
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	candidates := make([]ScoredCandidate, len(ids))
+	for i, id := range ids {
+		candidates[i] = *lookup.found[id]
+		candidates[i].Product = *idx.products[id]
+	}
+	return candidates
+}
@@ -0,0 +1,258 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// prefixCatalog returns products whose names share prefixes
+func prefixCatalog() []Product {
+	names := []string{
+		"Apple iPhone 13 Pro Max 256GB Graphite",
+		"Apple iPhone 13 Pro 128GB",
+		"Apple iPhone 13",
+		"Apple Watch Series 8",
+		"Apricot Jam 500g",
+		"Samsung Galaxy S22 Ultra 256GB",
+		"Samsung Galaxy S22",
+	}
+	products := make([]Product, len(names))
+	for i, name := range names {
+		products[i] = Product{ID: fmt.Sprintf("P%d", i+1), Name: name}
+	}
+	return products
+}
+
+// newPrefixEngine returns an engine with the prefix index, indexed on products
+func newPrefixEngine(t testing.TB, products []Product) *HybridEngine {
+	t.Helper()
+	config := DefaultHybridConfig()
+	config.EnablePrefixIndex = true
+	engine := NewHybridEngineWithConfig(config).WithExactModeCutoff(0)
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	return engine
+}
+
+// prefixIDs returns the product IDs of candidates, in order
+func prefixIDs(candidates []ScoredCandidate) []string {
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.Product.ID
+	}
+	return ids
+}
+
+func TestPrefixCandidatesShortPrefix(t *testing.T) {
+	engine := newPrefixEngine(t, prefixCatalog())
+
+	// Names starting with the text, the closest to complete first
+	got := engine.PrefixCandidates("apple iph", 10)
+	if ids := prefixIDs(got); !reflect.DeepEqual(ids, []string{"P3", "P2", "P1"}) {
+		t.Fatalf("PrefixCandidates(apple iph) = %v", ids)
+	}
+	for _, c := range got {
+		if c.Score != 1 || !c.FromPrefix || c.FromLSH {
+			t.Errorf("%s: %+v", c.Product.ID, c)
+		}
+	}
+	if got[0].Coverage <= got[1].Coverage || got[0].Product.Name != "Apple iPhone 13" {
+		t.Errorf("ranking %+v", got)
+	}
+
+	// The limit keeps the shortest names
+	if ids := prefixIDs(engine.PrefixCandidates("ap", 2)); !reflect.DeepEqual(ids, []string{"P3", "P5"}) {
+		t.Errorf("PrefixCandidates(ap, 2) = %v", ids)
+	}
+
+	// A typo in the last rune typed
+	got = engine.PrefixCandidates("samsunh", 10)
+	if ids := prefixIDs(got); !reflect.DeepEqual(ids, []string{"P7", "P6"}) {
+		t.Fatalf("PrefixCandidates(samsunh) = %v", ids)
+	}
+	if got[0].Score >= 1 || got[0].Score < prefixMinScore || !got[0].FromPrefix {
+		t.Errorf("typo candidate %+v", got[0])
+	}
+
+	// Nothing matching, and nothing typed
+	if got := engine.PrefixCandidates("xbox", 10); len(got) != 0 {
+		t.Errorf("PrefixCandidates(xbox) = %v", prefixIDs(got))
+	}
+	if got := engine.PrefixCandidates("  ", 10); got != nil {
+		t.Errorf("PrefixCandidates(blank) = %v", prefixIDs(got))
+	}
+}
+
+func TestPrefixCandidatesLSHCutover(t *testing.T) {
+	engine := newPrefixEngine(t, prefixCatalog())
+
+	// An early typo leaves no prefix match; below the cutover nothing is found
+	if got := engine.PrefixCandidates("Aple iPhone", 10); len(got) != 0 {
+		for _, c := range got {
+			if c.FromLSH {
+				t.Errorf("LSH candidate %s below the cutover", c.Product.ID)
+			}
+		}
+	}
+
+	// From 3 words on, LSH finds it
+	got := engine.PrefixCandidates("Aple iPhone 13 Pro Max 256GB Graphite", 10)
+	if len(got) == 0 || got[0].Product.ID != "P1" {
+		t.Fatalf("PrefixCandidates(full name with typo) = %v", prefixIDs(got))
+	}
+	if !got[0].FromLSH || got[0].FromPrefix {
+		t.Errorf("top candidate %+v", got[0])
+	}
+
+	// A higher cutover keeps LSH out
+	config := DefaultHybridConfig()
+	config.EnablePrefixIndex = true
+	config.PrefixLSHTokens = 10
+	late := NewHybridEngineWithConfig(config).WithExactModeCutoff(0)
+	if err := late.BuildIndex(prefixCatalog()); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range late.PrefixCandidates("Aple iPhone 13 Pro Max 256GB Graphite", 10) {
+		if c.FromLSH {
+			t.Errorf("LSH candidate %s below a cutover of 10 words", c.Product.ID)
+		}
+	}
+}
+
+func TestPrefixCandidatesDedup(t *testing.T) {
+	engine := newPrefixEngine(t, prefixCatalog())
+
+	// Typed in full, the name is both a prefix match and an LSH candidate
+	got := engine.PrefixCandidates("Samsung Galaxy S22 Ultra", 10)
+	seen := make(map[string]bool)
+	for _, c := range got {
+		if seen[c.Product.ID] {
+			t.Errorf("%s returned twice", c.Product.ID)
+		}
+		seen[c.Product.ID] = true
+	}
+	if len(got) == 0 || got[0].Product.ID != "P6" {
+		t.Fatalf("PrefixCandidates(samsung galaxy s22 ultra) = %v", prefixIDs(got))
+	}
+	if !got[0].FromPrefix || !got[0].FromLSH || got[0].Score != 1 {
+		t.Errorf("top candidate %+v", got[0])
+	}
+}
+
+func TestPrefixCandidatesUpdates(t *testing.T) {
+	engine := newPrefixEngine(t, prefixCatalog())
+	engine.PrefixCandidates("a", 10) // Sorts the prefix index
+
+	// "Apple Watch" shares all but the last 2 runes and ranks after full matches
+	if err := engine.AddProduct(Product{ID: "P8", Name: "Apple iPad Air"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := prefixIDs(engine.PrefixCandidates("apple ip", 10)); !reflect.DeepEqual(ids, []string{"P8", "P3", "P2", "P1", "P4"}) {
+		t.Errorf("after AddProduct: %v", ids)
+	}
+	if err := engine.UpdateProduct(Product{ID: "P8", Name: "Banana Bread"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := prefixIDs(engine.PrefixCandidates("ban", 10)); !reflect.DeepEqual(ids, []string{"P8"}) {
+		t.Errorf("after UpdateProduct: %v", ids)
+	}
+	if ids := prefixIDs(engine.PrefixCandidates("apple ip", 10)); !reflect.DeepEqual(ids, []string{"P3", "P2", "P1", "P4"}) {
+		t.Errorf("old name after UpdateProduct: %v", ids)
+	}
+	if err := engine.RemoveProduct("P3"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := prefixIDs(engine.PrefixCandidates("apple ip", 10)); !reflect.DeepEqual(ids, []string{"P2", "P1", "P4"}) {
+		t.Errorf("after RemoveProduct: %v", ids)
+	}
+	if n := engine.GetIndexStats()["prefix_entries"]; n != 7 {
+		t.Errorf("prefix_entries = %v, want 7", n)
+	}
+
+	// Restored by LoadIndex
+	var buf bytes.Buffer
+	if err := engine.SaveIndex(&buf); err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultHybridConfig()
+	config.EnablePrefixIndex = true
+	loaded := NewHybridEngineWithConfig(config)
+	if err := loaded.LoadIndex(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if ids := prefixIDs(loaded.PrefixCandidates("apple ip", 10)); !reflect.DeepEqual(ids, []string{"P2", "P1", "P4"}) {
+		t.Errorf("after LoadIndex: %v", ids)
+	}
+}
+
+func TestPrefixIndexDisabled(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 500)
+	plain := NewHybridEngine()
+	if err := plain.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	if plain.currentIndex().prefixes != nil {
+		t.Fatal("prefix index built without EnablePrefixIndex")
+	}
+	if got := plain.PrefixCandidates(catalog[0].Name, 10); got != nil {
+		t.Errorf("PrefixCandidates without the prefix index = %v", prefixIDs(got))
+	}
+
+	// The option adds the prefix index and leaves the rest of the index alone
+	config := DefaultHybridConfig()
+	config.EnablePrefixIndex = true
+	prefixed := NewHybridEngineWithConfig(config)
+	if err := prefixed.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	if plain.IndexConfigFingerprint() != prefixed.IndexConfigFingerprint() {
+		t.Error("the prefix index changed the index config fingerprint")
+	}
+	plainStats, prefixedStats := plain.GetIndexStats(), prefixed.GetIndexStats()
+	for _, key := range []string{"total_products", "total_buckets", "avg_bucket_size", "max_bucket_size"} {
+		if plainStats[key] != prefixedStats[key] {
+			t.Errorf("%s: %v without the prefix index, %v with it", key, plainStats[key], prefixedStats[key])
+		}
+	}
+	if plainStats["prefix_index"] != false || prefixedStats["prefix_entries"] != len(catalog) {
+		t.Errorf("prefix stats %v, %v", plainStats["prefix_index"], prefixedStats["prefix_entries"])
+	}
+	if !reflect.DeepEqual(plain.FindDuplicates(catalog[:100], 0.8), prefixed.FindDuplicates(catalog[:100], 0.8)) {
+		t.Error("the prefix index changed FindDuplicates")
+	}
+
+}
+
+func BenchmarkPrefixCandidates(b *testing.B) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 100_000)
+	engine := newPrefixEngine(b, catalog)
+	queries := []string{"sa", "apple", "samsung gal", catalog[42].Name}
+	for _, query := range queries {
+		engine.PrefixCandidates(query, 10) // Sorts the prefix index
+		b.Run(fmt.Sprintf("%q", query), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				engine.PrefixCandidates(query, 10)
+			}
+		})
+	}
+}
+
+func BenchmarkPrefixIndexBuild(b *testing.B) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 20_000)
+	for _, enabled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("enabled=%v", enabled), func(b *testing.B) {
+			config := DefaultHybridConfig()
+			config.EnablePrefixIndex = enabled
+			for i := 0; i < b.N; i++ {
+				engine := NewHybridEngineWithConfig(config)
+				if err := engine.BuildIndex(catalog); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
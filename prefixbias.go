@@ -0,0 +1,88 @@
+package duplicatecheck
+
+import "math"
+
+// DefaultPrefixFraction is the share of the longer name LevenshteinOptions.PrefixBias
+// weighs when PrefixFraction is 0: about the brand and product line of a
+// typical "Brand Line Model Color" name
+const DefaultPrefixFraction = 0.3
+
+// nameSimilarity is computeSimilarity for names, which a PrefixBias weighs by
+// position; distance is the plain edit distance of the names
+func (e *LevenshteinEngine) nameSimilarity(nameA, nameB string, distance int) float64 {
+	if e.options.PrefixBias <= 0 {
+		return e.computeSimilarity(nameA, nameB, distance)
+	}
+	return e.prefixWeightedSimilarity(nameA, nameB)
+}
+
+// prefixWeightedSimilarity scores two names by a position-weighted alignment:
+// the Levenshtein DP where deleting, inserting or substituting a unit within
+// the first PrefixFraction of the longer name costs 1+PrefixBias, and 1 past it
+// A substitution costs the larger weight of its two positions. The weighted
+// distance is divided by the weight of every position of the longer name, the
+// most an alignment can cost, and shaped by the SimilarityMode like a plain
+// distance. Units are runes, or grapheme clusters in grapheme mode.
+//
+// Position-weighting the alignment was chosen over scoring the leading tokens
+// apart from the rest: it needs no tokenization, so "WH-1000XM5" vs
+// "WH1000XM5" costs one light edit rather than a mismatched token.
+func (e *LevenshteinEngine) prefixWeightedSimilarity(nameA, nameB string) float64 {
+	var a, b []rune
+	if e.options.GraphemeMode {
+		a, b = graphemeUnits(nameA, nameB)
+	} else {
+		a, b = []rune(nameA), []rune(nameB)
+	}
+	maxLen := len(a)
+	if len(b) > maxLen {
+		maxLen = len(b)
+	}
+	if maxLen == 0 {
+		return 1.0
+	}
+
+	fraction := e.options.PrefixFraction
+	if fraction <= 0 || fraction > 1 {
+		fraction = DefaultPrefixFraction
+	}
+	prefix := int(math.Ceil(fraction * float64(maxLen)))
+	heavy := 1 + e.options.PrefixBias
+	weight := func(pos int) float64 {
+		if pos < prefix {
+			return heavy
+		}
+		return 1
+	}
+
+	// Two rows of the DP over a (rows) and b (columns)
+	prev := make([]float64, len(b)+1)
+	curr := make([]float64, len(b)+1)
+	for j := 1; j <= len(b); j++ {
+		prev[j] = prev[j-1] + weight(j-1)
+	}
+	for i := 1; i <= len(a); i++ {
+		wa := weight(i - 1)
+		curr[0] = prev[0] + wa
+		for j := 1; j <= len(b); j++ {
+			wb := weight(j - 1)
+			best := prev[j] + wa // Delete a[i-1]
+			if insert := curr[j-1] + wb; insert < best {
+				best = insert
+			}
+			substitute := prev[j-1]
+			if a[i-1] != b[j-1] {
+				substitute += math.Max(wa, wb)
+			}
+			if substitute < best {
+				best = substitute
+			}
+			curr[j] = best
+		}
+		prev, curr = curr, prev
+	}
+
+	// Every position of the longer name: prefix units heavy, the rest 1
+	total := float64(maxLen) + e.options.PrefixBias*float64(prefix)
+	return e.shapeSimilarity(prev[len(b)]/total, maxLen)
+}
@@ -0,0 +1,91 @@
+package duplicatecheck
+
+import (
+	"math"
+	"testing"
+)
+
+// prefixBiasEngine returns an engine with the given PrefixBias
+func prefixBiasEngine(bias float64) *LevenshteinEngine {
+	opts := DefaultLevenshteinOptions()
+	opts.PrefixBias = bias
+	return NewLevenshteinEngineWithOptions(opts)
+}
+
+func TestPrefixBias(t *testing.T) {
+	sony := Product{ID: "1", Name: "Sony WH-1000XM5 Black", Description: "Wireless noise cancelling headphones"}
+	bose := Product{ID: "2", Name: "Bose WH-1000XM5 Black", Description: "Wireless noise cancelling headphones"}
+	midnight := Product{ID: "3", Name: "Sony WH-1000XM5 Midnight", Description: "Wireless noise cancelling headphones"}
+
+	tests := []struct {
+		bias          float64
+		brand, colour float64 // Name similarity of sony/bose and sony/midnight
+	}{
+		{0, 1 - 3.0/21, 1 - 8.0/24}, // Plain Levenshtein: the brand pair scores higher
+		{1, 0.7857, 0.75},
+		{2, 0.7429, 0.8},
+		{3, 0.7143, 0.8333},
+	}
+	for _, tt := range tests {
+		engine := prefixBiasEngine(tt.bias)
+		brand, colour := engine.Compare(sony, bose), engine.Compare(sony, midnight)
+		if math.Abs(brand.NameSimilarity-tt.brand) > 1e-4 || math.Abs(colour.NameSimilarity-tt.colour) > 1e-4 {
+			t.Errorf("bias %v: name similarities %.4f (brand differs), %.4f (color differs); want %.4f, %.4f",
+				tt.bias, brand.NameSimilarity, colour.NameSimilarity, tt.brand, tt.colour)
+		}
+		// Distances stay plain edit counts
+		if brand.NameDistance != 3 || colour.NameDistance != 8 {
+			t.Errorf("bias %v: name distances %d, %d", tt.bias, brand.NameDistance, colour.NameDistance)
+		}
+		if tt.bias >= 2 && brand.CombinedSimilarity >= colour.CombinedSimilarity {
+			t.Errorf("bias %v: brand pair %.4f scores at least the color pair %.4f", tt.bias, brand.CombinedSimilarity, colour.CombinedSimilarity)
+		}
+	}
+
+	// Off, scores are today's exactly
+	products := GenerateTestCatalog(goldenCatalogSeed, 60)
+	plain, off := NewLevenshteinEngine(), prefixBiasEngine(0)
+	for i := 1; i < len(products); i++ {
+		if a, b := plain.Compare(products[0], products[i]), off.Compare(products[0], products[i]); a.CombinedSimilarity != b.CombinedSimilarity {
+			t.Fatalf("bias 0 scored %v, default %v", b.CombinedSimilarity, a.CombinedSimilarity)
+		}
+	}
+	if got := prefixBiasEngine(2).Compare(sony, sony); got.NameSimilarity != 1 {
+		t.Errorf("identical names scored %v", got.NameSimilarity)
+	}
+}
+
+// TestPrefixBiasScans checks that scans, whose pruning bounds assume plain
+// similarity, find exactly the pairs Compare scores at threshold under a bias
+func TestPrefixBiasScans(t *testing.T) {
+	const threshold = 0.8
+	products := GenerateTestCatalog(goldenCatalogSeed, 80)
+	engine := prefixBiasEngine(3)
+	want := 0
+	for i := range products {
+		for j := i + 1; j < len(products); j++ {
+			if meetsThreshold(engine.Compare(products[i], products[j]).CombinedSimilarity, threshold) {
+				want++
+			}
+		}
+	}
+	if got := len(engine.FindDuplicates(products, threshold)); got != want {
+		t.Errorf("FindDuplicates found %d pairs, Compare %d", got, want)
+	}
+
+	// Names only: the bias reorders which pair is found
+	headphones := []Product{
+		{ID: "sony", Name: "Sony WH-1000XM5 Black"},
+		{ID: "bose", Name: "Bose WH-1000XM5 Black"},
+		{ID: "midnight", Name: "Sony WH-1000XM5 Midnight"},
+	}
+	for _, tt := range []struct {
+		bias float64
+		pair string
+	}{{0, makePairKey("sony", "bose")}, {3, makePairKey("sony", "midnight")}} {
+		results := prefixBiasEngine(tt.bias).FindDuplicatesByName(headphones, 0.78)
+		if len(results) != 1 || makePairKey(results[0].ProductA.ID, results[0].ProductB.ID) != tt.pair {
+			t.Errorf("bias %v: FindDuplicatesByName = %v, want only %s", tt.bias, sortedPairKeys(results), tt.pair)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package duplicatecheck
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TextPreparation describes how product text is prepared before comparison
+// Every engine prepares text through one, so engines configured alike see the
+// same text. Lowercasing and trimming always apply; the zero value (see
+// DefaultTextPreparation) does nothing else.
+//
+// Prepared strings are cached on the Product together with the preparation's
+// fingerprint. A Product compared by an engine with a different preparation
+// rebuilds its cache instead of reusing strings prepared under other rules.
+type TextPreparation struct {
+	// StripHTML replaces markup tags with spaces and decodes entities (&amp; → &)
+	StripHTML bool
+	// FoldAccents maps accented Latin letters to their base letter (é → e, ß → ss)
+	// and drops combining marks
+	FoldAccents bool
+	// CollapseWhitespace turns every run of whitespace, newlines included, into
+	// one space. Leave it off when using a DescriptionSegmenter that splits on lines.
+	CollapseWhitespace bool
+	// MaxDescriptionLength truncates prepared descriptions to this many runes (0 = no limit)
+	MaxDescriptionLength int
+	// Synonyms rewrites abbreviations and variants to canonical forms after
+	// lowercasing and accent folding (nil = none). Engines keep a copy, so
+	// changing the dictionary later does not affect them.
+	Synonyms *SynonymDictionary
+	// Boilerplate removes the chunks and phrases a BoilerplateModel learned
+	// from the catalog from descriptions, before any other step (nil = none)
+	Boilerplate *BoilerplateModel
+	// Homoglyphs replaces look-alike Cyrillic, Greek and fullwidth characters
+	// with their Latin twins before lowercasing (nil = none). Engines keep a
+	// copy, so changing the table later does not affect them.
+	Homoglyphs *HomoglyphTable
+}
+
+// DefaultTextPreparation returns the default preparation: lowercase and trim only
+func DefaultTextPreparation() TextPreparation {
+	return TextPreparation{}
+}
+
+// Fingerprint identifies the preparation rules; equal options give equal fingerprints
+func (p TextPreparation) Fingerprint() uint64 {
+	flags := []byte("000")
+	for i, on := range []bool{p.StripHTML, p.FoldAccents, p.CollapseWhitespace} {
+		if on {
+			flags[i] = '1'
+		}
+	}
+	fields := []string{"text-preparation/v1", string(flags), strconv.Itoa(p.MaxDescriptionLength)}
+	if p.Synonyms != nil {
+		fields = append(fields, strconv.FormatUint(p.Synonyms.Fingerprint(), 16))
+	}
+	if p.Boilerplate != nil {
+		fields = append(fields, "boilerplate", strconv.FormatUint(p.Boilerplate.Fingerprint(), 16))
+	}
+	if p.Homoglyphs != nil {
+		fields = append(fields, "homoglyphs", strconv.FormatUint(p.Homoglyphs.Fingerprint(), 16))
+	}
+	return contentFingerprint(fields...)
+}
+
+// Prepare returns the prepared name and description, as engines compare them
+func (p TextPreparation) Prepare(name, description string) (string, string) {
+	name, description, _ = p.prepare(name, description)
+	return name, description
+}
+
+// prepare is Prepare, also reporting whether Homoglyphs changed either text
+func (p TextPreparation) prepare(name, description string) (string, string, bool) {
+	name, nameHomoglyphs := p.prepareTextChecked(name)
+	description, descHomoglyphs := p.prepareDescriptionChecked(description)
+	return name, description, nameHomoglyphs || descHomoglyphs
+}
+
+// prepareText applies every step except description truncation
+func (p TextPreparation) prepareText(s string) string {
+	s, _ = p.prepareTextChecked(s)
+	return s
+}
+
+// prepareTextChecked is prepareText, also reporting whether Homoglyphs changed s
+func (p TextPreparation) prepareTextChecked(s string) (string, bool) {
+	if p.StripHTML {
+		s = stripHTML(s)
+	}
+	replaced := p.Homoglyphs.Apply(s)
+	homoglyphs := replaced != s
+	s = strings.ToLower(replaced)
+	if p.FoldAccents {
+		s = foldAccents(s)
+	}
+	s = p.Synonyms.Apply(s)
+	if p.CollapseWhitespace {
+		return strings.Join(strings.Fields(s), " "), homoglyphs
+	}
+	return strings.TrimSpace(s), homoglyphs
+}
+
+// prepareDescription is boilerplate stripping, prepareText and truncation to
+// MaxDescriptionLength
+func (p TextPreparation) prepareDescription(s string) string {
+	s, _ = p.prepareDescriptionChecked(s)
+	return s
+}
+
+// prepareDescriptionChecked is prepareDescription, also reporting whether
+// Homoglyphs changed s
+func (p TextPreparation) prepareDescriptionChecked(s string) (string, bool) {
+	s, homoglyphs := p.prepareTextChecked(p.Boilerplate.Strip(s))
+	if p.MaxDescriptionLength > 0 && utf8.RuneCountInString(s) > p.MaxDescriptionLength {
+		s = strings.TrimSpace(string([]rune(s)[:p.MaxDescriptionLength]))
+	}
+	return s, homoglyphs
+}
+
+// textPreparer is a TextPreparation with its fingerprint computed once
+// Interning doesn't change the prepared text, so it isn't part of the fingerprint.
+type textPreparer struct {
+	options     TextPreparation
+	fingerprint uint64
+	interner    *Interner // Interns prepared text (nil = none, see WithInterner)
+}
+
+// newTextPreparer precomputes the fingerprint of options
+func newTextPreparer(options TextPreparation) *textPreparer {
+	options.Synonyms = options.Synonyms.Clone()
+	options.Homoglyphs = options.Homoglyphs.Clone()
+	return &textPreparer{options: options, fingerprint: options.Fingerprint()}
+}
+
+// defaultPreparer prepares text for engines left at DefaultTextPreparation
+var defaultPreparer = newTextPreparer(DefaultTextPreparation())
+
+// textPreparationOwner is implemented by engines that prepare text, so helpers
+// holding only a DuplicateCheckEngine can warm caches the way it reads them
+type textPreparationOwner interface {
+	preparer() *textPreparer
+}
+
+// preparerOf returns the preparer engine uses, or the default one
+func preparerOf(engine DuplicateCheckEngine) *textPreparer {
+	if owner, ok := engine.(textPreparationOwner); ok {
+		return owner.preparer()
+	}
+	return defaultPreparer
+}
+
+// WithTextPreparation sets how the engine prepares text before comparing it
+// Returns the engine for chaining.
+func (e *LevenshteinEngine) WithTextPreparation(options TextPreparation) *LevenshteinEngine {
+	e.prep = newTextPreparer(options)
+	e.prep.interner = e.interner
+	return e
+}
+
+// GetTextPreparation returns the engine's text preparation
+func (e *LevenshteinEngine) GetTextPreparation() TextPreparation {
+	return e.preparer().options
+}
+
+// preparer returns the engine's preparer (the default one unless set)
+func (e *LevenshteinEngine) preparer() *textPreparer {
+	if e.prep == nil {
+		return defaultPreparer
+	}
+	return e.prep
+}
+
+// WithTextPreparation sets how the engine prepares text for indexing and
+// verification. Any built index is discarded, since it was built from text
+// prepared under the old rules. Returns the engine for chaining.
+func (e *HybridEngine) WithTextPreparation(options TextPreparation) *HybridEngine {
+	e.levenshteinEngine.WithTextPreparation(options)
+	e.resetIndex()
+	return e
+}
+
+// GetTextPreparation returns the engine's text preparation
+func (e *HybridEngine) GetTextPreparation() TextPreparation {
+	return e.levenshteinEngine.GetTextPreparation()
+}
+
+// preparer returns the preparer shared with the verification engine
+func (e *HybridEngine) preparer() *textPreparer {
+	return e.levenshteinEngine.preparer()
+}
+
+// stripHTML replaces tags with spaces and decodes entities
+func stripHTML(s string) string {
+	if !strings.ContainsAny(s, "<&") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+			b.WriteByte(' ')
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return html.UnescapeString(b.String())
+}
+
+// accentFolds maps lowercase accented Latin letters to their base letters
+var accentFolds = func() map[rune]string {
+	groups := map[string]string{
+		"a": "àáâãäåāăąǎ", "c": "çćĉċč", "d": "ďđ", "e": "èéêëēĕėęě",
+		"g": "ĝğġģ", "h": "ĥħ", "i": "ìíîïĩīĭįıǐ", "j": "ĵ", "k": "ķ",
+		"l": "ĺļľŀł", "n": "ñńņňŉ", "o": "òóôõöøōŏőǒ", "r": "ŕŗř",
+		"s": "śŝşš", "t": "ţťŧ", "u": "ùúûüũūŭůűųǔ", "w": "ŵ", "y": "ýÿŷ",
+		"z": "źżž", "ae": "æ", "oe": "œ", "ss": "ß", "th": "þ",
+	}
+	folds := make(map[rune]string)
+	for base, accented := range groups {
+		for _, r := range accented {
+			folds[r] = base
+		}
+	}
+	return folds
+}()
+
+// foldAccents replaces accented letters with base letters and drops combining marks
+func foldAccents(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if folded, ok := accentFolds[r]; ok {
+			b.WriteString(folded)
+		} else if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,109 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTextPreparation(t *testing.T) {
+	tests := []struct {
+		name     string
+		prep     TextPreparation
+		inName   string
+		inDesc   string
+		wantName string
+		wantDesc string
+	}{
+		{"default", DefaultTextPreparation(), "  Café Crème ", "<b>Rich</b>  &amp; smooth", "café crème", "<b>rich</b>  &amp; smooth"},
+		{"fold accents", TextPreparation{FoldAccents: true}, "Café Crème Straße", "Œuvre", "cafe creme strasse", "oeuvre"},
+		{"combining marks", TextPreparation{FoldAccents: true}, "Cafe\u0301", "", "cafe", ""},
+		{"strip html", TextPreparation{StripHTML: true}, "Mug", "<p>Rich&nbsp;&amp; smooth</p>", "mug", "rich & smooth"},
+		{"collapse whitespace", TextPreparation{CollapseWhitespace: true}, "Big   Mug", "line one\n\n  line two", "big mug", "line one line two"},
+		{"truncate description", TextPreparation{MaxDescriptionLength: 8}, "A very long name", "ceramic mug, 350ml", "a very long name", "ceramic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, desc := tt.prep.Prepare(tt.inName, tt.inDesc)
+			if name != tt.wantName || desc != tt.wantDesc {
+				t.Errorf("Prepare(%q, %q) = %q, %q; want %q, %q", tt.inName, tt.inDesc, name, desc, tt.wantName, tt.wantDesc)
+			}
+		})
+	}
+}
+
+func TestTextPreparationFingerprint(t *testing.T) {
+	preps := []TextPreparation{
+		DefaultTextPreparation(),
+		{FoldAccents: true},
+		{StripHTML: true},
+		{CollapseWhitespace: true},
+		{MaxDescriptionLength: 500},
+		{FoldAccents: true, StripHTML: true, CollapseWhitespace: true, MaxDescriptionLength: 500},
+	}
+	seen := make(map[uint64]int)
+	for i, prep := range preps {
+		fp := prep.Fingerprint()
+		if j, dup := seen[fp]; dup {
+			t.Errorf("preparations %d and %d share fingerprint %x", i, j, fp)
+		}
+		seen[fp] = i
+		if again := prep.Fingerprint(); again != fp {
+			t.Errorf("preparation %d: fingerprint not stable (%x, %x)", i, fp, again)
+		}
+	}
+}
+
+func TestTextPreparationSharedProducts(t *testing.T) {
+	a := &Product{ID: "A", Name: "Café Crème Mug", Description: "Porcelaine émaillée, 350 ml"}
+	b := &Product{ID: "B", Name: "Cafe Creme Mug", Description: "Porcelaine emaillee, 350 ml"}
+
+	folding := NewLevenshteinEngine().WithTextPreparation(TextPreparation{FoldAccents: true})
+	plain := NewLevenshteinEngine()
+
+	// Alternate engines on the same Product instances: each must see its own preparation
+	for round := 0; round < 2; round++ {
+		folded := folding.ComparePtr(a, b)
+		if folded.CombinedSimilarity != 1 {
+			t.Errorf("round %d: folding engine similarity = %.3f, want 1", round, folded.CombinedSimilarity)
+		}
+		if folded.MatchType != MatchNormalizedExact || !reflect.DeepEqual(folded.DifferenceKinds, []string{DifferencePreparation}) {
+			t.Errorf("round %d: folding engine match = %v %v, want normalized-exact [preparation]", round, folded.MatchType, folded.DifferenceKinds)
+		}
+
+		unfolded := plain.ComparePtr(a, b)
+		if unfolded.CombinedSimilarity >= 1 || unfolded.MatchType != MatchFuzzy {
+			t.Errorf("round %d: plain engine similarity = %.3f (%v), want a fuzzy score below 1", round, unfolded.CombinedSimilarity, unfolded.MatchType)
+		}
+	}
+
+	t.Run("field comparisons", func(t *testing.T) {
+		if got := folding.CompareNames(*a, *b).Similarity; got != 1 {
+			t.Errorf("folding CompareNames = %.3f, want 1", got)
+		}
+		if got := plain.CompareNames(*a, *b).Similarity; got >= 1 {
+			t.Errorf("plain CompareNames = %.3f, want below 1", got)
+		}
+	})
+
+	t.Run("hybrid engine", func(t *testing.T) {
+		catalog := []Product{*b, {ID: "C", Name: "Espresso Cup", Description: "Stoneware, 90 ml"}}
+		engine := NewHybridEngine()
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatalf("BuildIndex: %v", err)
+		}
+		engine.WithTextPreparation(TextPreparation{FoldAccents: true})
+		if stats := engine.GetIndexStats(); stats["indexed"] != false {
+			t.Errorf("index kept after WithTextPreparation: %v", stats)
+		}
+		if err := engine.BuildIndex(catalog); err != nil {
+			t.Fatalf("BuildIndex: %v", err)
+		}
+		results := engine.FindDuplicatesForOne(*a, 0.99)
+		if len(results) != 1 || results[0].ProductB.ID != "B" {
+			t.Errorf("FindDuplicatesForOne = %d results, want B as exact match after folding", len(results))
+		}
+		if got := engine.GetTextPreparation(); !got.FoldAccents {
+			t.Errorf("GetTextPreparation = %+v", got)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package duplicatecheck
+
+import (
+	"crypto/rand"
+	"hash/fnv"
+	"strconv"
+)
+
+// Verification stages reported in ComparisonResult.Stage
+// An empty Stage means the pair was verified with the full Levenshtein comparison
+const (
+	// StageEstimated marks a similarity estimated from SimHash fingerprints
+	StageEstimated = "estimated"
+	// StageExternal marks a similarity returned by a caller-provided PrivacyVerifier
+	StageExternal = "external"
+	// StageEstimatedReject marks a HybridEngine.Compare result rejected by its
+	// MinHash estimate, with HybridConfig.FastCompare
+	StageEstimatedReject = "estimated-reject"
+)
+
+// PrivacyVerifier computes the similarity of two products by ID
+// It lets callers fetch raw text from a controlled system instead of the index
+// Returned similarities are clamped to [0.0-1.0]
+type PrivacyVerifier func(idA, idB string) (float64, error)
+
+// PrivacyOptions configures HybridEngine privacy mode
+type PrivacyOptions struct {
+	// Salt is mixed into content hashes so they can't be matched against
+	// precomputed hashes of known text. A random salt is generated when empty.
+	Salt []byte
+	// Verifier replaces SimHash estimation in the final stage when set
+	Verifier PrivacyVerifier
+}
+
+// privacyState holds the active privacy configuration of a HybridEngine
+type privacyState struct {
+	salt     []byte
+	verifier PrivacyVerifier
+}
+
+// EnablePrivacyMode makes BuildIndex retain only MinHash band hashes,
+// SimHash fingerprints, and salted content hashes - never product text.
+//
+// Accuracy tradeoff: without the raw text, final verification can't run
+// Levenshtein. Results are either SimHash estimates (Stage = StageEstimated),
+// which are coarse and tend to overestimate similarity of unrelated text,
+// or come from opts.Verifier (Stage = StageExternal). Exact content matches
+// are still detected through the salted hash and score 1.0.
+//
+// Call before BuildIndex; an existing index is discarded.
+func (e *HybridEngine) EnablePrivacyMode(opts PrivacyOptions) {
+	salt := opts.Salt
+	if len(salt) == 0 {
+		salt = make([]byte, 16)
+		_, _ = rand.Read(salt) // crypto/rand.Read never fails on supported platforms
+	}
+	e.privacy = &privacyState{
+		salt:     append([]byte(nil), salt...),
+		verifier: opts.Verifier,
+	}
+	e.resetIndex()
+}
+
+// DisablePrivacyMode returns to the default mode; an existing index is discarded
+func (e *HybridEngine) DisablePrivacyMode() {
+	e.privacy = nil
+	e.resetIndex()
+}
+
+// IsPrivacyModeEnabled returns whether privacy mode is active
+func (e *HybridEngine) IsPrivacyModeEnabled() bool {
+	return e.privacy != nil
+}
+
+// contentHash computes a salted hash of normalized product text
+func (ps *privacyState) contentHash(text string) uint64 {
+	h := fnv.New64a()
+	h.Write(ps.salt)
+	h.Write([]byte(text))
+	return h.Sum64()
+}
+
+// saltCheck identifies the salt without revealing it, in hexadecimal
+func (ps *privacyState) saltCheck() string {
+	return strconv.FormatUint(ps.contentHash("duplicatecheck salt check"), 16)
+}
+
+// estimateCandidate scores a candidate without access to its text
+// Returns false if the candidate is unknown or the verifier failed
+func (e *HybridEngine) estimateCandidate(idx *LSHIndex, product *Product, query hybridQuery, candidateID string) (ComparisonResult, bool) {
+	fingerprint, exists := idx.fingerprints[candidateID]
+	if !exists {
+		return ComparisonResult{}, false
+	}
+
+	var similarity float64
+	stage := StageEstimated
+
+	switch {
+	case e.privacy.contentHash(query.text) == idx.contentHashes[candidateID]:
+		similarity = 1.0
+	case e.privacy.verifier != nil:
+		score, err := e.privacy.verifier(product.ID, candidateID)
+		if err != nil {
+			return ComparisonResult{}, false
+		}
+		similarity = clampUnit(score)
+		stage = StageExternal
+	default:
+		similarity = Similarity(query.fingerprint, fingerprint)
+	}
+
+	return e.levenshteinEngine.finishResult(ComparisonResult{
+		ProductA:           *product,
+		ProductB:           Product{ID: candidateID},
+		CombinedSimilarity: similarity,
+		Stage:              stage,
+	}), true
+}
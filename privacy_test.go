@@ -0,0 +1,192 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// collectIndexStrings walks a value with reflection and returns every string it holds
+func collectIndexStrings(v reflect.Value, out *[]string) {
+	switch v.Kind() {
+	case reflect.String:
+		*out = append(*out, v.String())
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			collectIndexStrings(v.Elem(), out)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			collectIndexStrings(v.Field(i), out)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectIndexStrings(v.Index(i), out)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			collectIndexStrings(iter.Key(), out)
+			collectIndexStrings(iter.Value(), out)
+		}
+	}
+}
+
+func TestPrivacyModeRetainsNoText(t *testing.T) {
+	products := []Product{
+		{ID: "u1", Name: "Vintage bike for sale", Description: "Contact jane.doe@example.com or call 555-0134"},
+		{ID: "u2", Name: "Vintage bike for sale!", Description: "Contact jane.doe@example.com or call 555-0134 today"},
+		{ID: "u3", Name: "Sofa, barely used", Description: "Email mark@example.org, phone 555-0199"},
+	}
+
+	engine := NewHybridEngine()
+	engine.EnablePrivacyMode(PrivacyOptions{Salt: []byte("test-salt")})
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	t.Run("Reflection walk finds no product text", func(t *testing.T) {
+		ids := make(map[string]bool)
+		for _, p := range products {
+			ids[p.ID] = true
+		}
+
+		var stored []string
+		collectIndexStrings(reflect.ValueOf(engine.lshIndex), &stored)
+		for _, s := range stored {
+			if !ids[s] {
+				t.Errorf("Index retains a string that is not a product ID: %q", s)
+			}
+		}
+	})
+
+	t.Run("Stats confirm no plaintext retention", func(t *testing.T) {
+		stats := engine.GetIndexStats()
+		if stats["privacy_mode"] != true {
+			t.Error("Stats should report privacy mode")
+		}
+		if stats["plaintext_retained"] != false {
+			t.Error("Stats should report no plaintext retention")
+		}
+		if stats["total_products"] != len(products) {
+			t.Errorf("Expected %d indexed products, got %v", len(products), stats["total_products"])
+		}
+	})
+
+	t.Run("Results are marked as estimated", func(t *testing.T) {
+		results := engine.FindDuplicatesForOne(products[0], 0.5)
+		if len(results) == 0 {
+			t.Fatal("Expected at least the exact match")
+		}
+		for _, r := range results {
+			if r.Stage != StageEstimated {
+				t.Errorf("Expected stage %q, got %q", StageEstimated, r.Stage)
+			}
+			if r.ProductB.Name != "" || r.ProductB.Description != "" {
+				t.Error("Estimated results must not carry indexed text")
+			}
+			if r.ProductB.ID == "u1" && r.CombinedSimilarity != 1.0 {
+				t.Errorf("Exact content match should score 1.0, got %.3f", r.CombinedSimilarity)
+			}
+		}
+	})
+}
+
+func TestPrivacyModeVerifier(t *testing.T) {
+	products := []Product{
+		{ID: "a", Name: "Understanding Machine Learning", Description: "A guide to ML algorithms"},
+		{ID: "b", Name: "Understanding Machine Learning", Description: "A guide to ML algorithms and more"},
+	}
+
+	calls := 0
+	engine := NewHybridEngine()
+	engine.EnablePrivacyMode(PrivacyOptions{
+		Verifier: func(idA, idB string) (float64, error) {
+			calls++
+			if idB == "b" {
+				return 0.93, nil
+			}
+			return 0, errors.New("not found")
+		},
+	})
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	query := Product{ID: "q", Name: "Understanding Machine Learning", Description: "A guide to ML algorithms!"}
+	results := engine.FindDuplicatesForOne(query, 0.9)
+	if calls == 0 {
+		t.Fatal("Verifier should have been called")
+	}
+	for _, r := range results {
+		if r.Stage != StageExternal {
+			t.Errorf("Expected stage %q, got %q", StageExternal, r.Stage)
+		}
+		if r.ProductB.ID == "a" {
+			t.Error("Candidates whose verification failed should be skipped")
+		}
+	}
+}
+
+func TestPrivacyModeAccuracy(t *testing.T) {
+	corpus := generateUserArticles(120)
+
+	exact := NewHybridEngine()
+	if err := exact.BuildIndex(corpus); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	private := NewHybridEngine()
+	private.EnablePrivacyMode(PrivacyOptions{})
+	if err := private.BuildIndex(corpus); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	// Estimates for near-duplicates should stay close to the full pipeline
+	const tolerance = 0.2
+	compared := 0
+	for i := 0; i < len(corpus); i += 7 {
+		query := Product{
+			ID:          "Q",
+			Name:        corpus[i].Name,
+			Description: corpus[i].Description + " Revised edition.",
+		}
+		want := exact.FindDuplicatesForOne(query, 0.8)
+		got := make(map[string]float64)
+		for _, r := range private.FindDuplicatesForOne(query, 0.0) {
+			got[r.ProductB.ID] = r.CombinedSimilarity
+		}
+		for _, r := range want {
+			estimate, ok := got[r.ProductB.ID]
+			if !ok {
+				t.Errorf("Privacy mode lost candidate %s", r.ProductB.ID)
+				continue
+			}
+			compared++
+			if math.Abs(estimate-r.CombinedSimilarity) > tolerance {
+				t.Errorf("%s: estimate %.3f differs from exact %.3f by more than %.2f",
+					r.ProductB.ID, estimate, r.CombinedSimilarity, tolerance)
+			}
+		}
+	}
+	if compared == 0 {
+		t.Fatal("Expected some near-duplicate pairs to compare")
+	}
+	t.Logf("Compared %d near-duplicate pairs within ±%.2f", compared, tolerance)
+}
+
+func TestPrivacyModeToggle(t *testing.T) {
+	engine := NewHybridEngine()
+	if engine.IsPrivacyModeEnabled() {
+		t.Fatal("Privacy mode should be off by default")
+	}
+	engine.EnablePrivacyMode(PrivacyOptions{})
+	if !engine.IsPrivacyModeEnabled() {
+		t.Fatal("Privacy mode should be on after EnablePrivacyMode")
+	}
+	engine.DisablePrivacyMode()
+	if engine.IsPrivacyModeEnabled() {
+		t.Fatal("Privacy mode should be off after DisablePrivacyMode")
+	}
+}
@@ -0,0 +1,161 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ProductSource yields a catalog one product at a time, for BuildIndexPipelined
+// Next returns io.EOF after the last product; any other error stops the build.
+type ProductSource interface {
+	Next() (Product, error)
+}
+
+// sliceProductSource yields the products of a slice
+type sliceProductSource struct {
+	products []Product
+	next     int
+}
+
+// NewSliceProductSource returns a ProductSource over products, in order
+func NewSliceProductSource(products []Product) ProductSource {
+	return &sliceProductSource{products: products}
+}
+
+// Next implements ProductSource
+func (s *sliceProductSource) Next() (Product, error) {
+	if s.next >= len(s.products) {
+		return Product{}, io.EOF
+	}
+	s.next++
+	return s.products[s.next-1], nil
+}
+
+// jsonProductSource decodes products from a JSON array or a stream of JSON objects
+type jsonProductSource struct {
+	r     *bufio.Reader
+	dec   *json.Decoder
+	array bool
+	read  int
+}
+
+// NewJSONLProductSource returns a ProductSource decoding r as JSON Lines (one
+// product object per line), or as a JSON array of products
+// Objects are decoded by encoding/json into Product, so keys match its
+// field names case-insensitively: id, name, description, sourceid.
+func NewJSONLProductSource(r io.Reader) ProductSource {
+	return &jsonProductSource{r: bufio.NewReader(r)}
+}
+
+// Next implements ProductSource
+func (s *jsonProductSource) Next() (Product, error) {
+	if s.dec == nil {
+		if err := s.start(); err != nil {
+			return Product{}, err
+		}
+	}
+	if s.array && !s.dec.More() {
+		return Product{}, io.EOF
+	}
+
+	var p Product
+	if err := s.dec.Decode(&p); err != nil {
+		if err == io.EOF && !s.array {
+			return Product{}, io.EOF
+		}
+		return Product{}, fmt.Errorf("duplicatecheck: reading JSON product %d: %w", s.read+1, err)
+	}
+	s.read++
+	return p, nil
+}
+
+// start creates the decoder, consuming the opening bracket of a JSON array
+func (s *jsonProductSource) start() error {
+	s.dec = json.NewDecoder(s.r)
+	for {
+		r, _, err := s.r.ReadRune()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("duplicatecheck: reading JSON products: %w", err)
+		}
+		if unicode.IsSpace(r) {
+			continue
+		}
+		if err := s.r.UnreadRune(); err != nil {
+			return err
+		}
+		if s.array = r == '['; s.array {
+			if _, err := s.dec.Token(); err != nil {
+				return fmt.Errorf("duplicatecheck: reading JSON products: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// csvProductSource reads products from CSV rows
+type csvProductSource struct {
+	r       *csv.Reader
+	columns map[string]int // Column name -> index
+}
+
+// NewCSVProductSource returns a ProductSource reading r as CSV with a header
+// row naming its columns
+// The id and name columns are required; description and source_id are
+// optional and other columns are ignored. Names are matched case-insensitively.
+func NewCSVProductSource(r io.Reader) (ProductSource, error) {
+	cr := csv.NewReader(bufio.NewReader(r))
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("duplicatecheck: reading CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, exists := columns[name]; !exists {
+			columns[name] = i
+		}
+	}
+	for _, required := range []string{"id", "name"} {
+		if _, exists := columns[required]; !exists {
+			return nil, fmt.Errorf("duplicatecheck: CSV header has no %q column", required)
+		}
+	}
+	return &csvProductSource{r: cr, columns: columns}, nil
+}
+
+// Next implements ProductSource
+func (s *csvProductSource) Next() (Product, error) {
+	record, err := s.r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return Product{}, io.EOF
+		}
+		return Product{}, fmt.Errorf("duplicatecheck: reading CSV product: %w", err)
+	}
+	field := func(name string) string {
+		if i, exists := s.columns[name]; exists && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+	return Product{
+		ID:          field("id"),
+		SourceID:    field("source_id"),
+		Name:        field("name"),
+		Description: field("description"),
+	}, nil
+}
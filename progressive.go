@@ -0,0 +1,284 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// progressiveBatches is the number of batches BuildIndexProgressive splits a
+// catalog into when ProgressiveOptions.BatchSize is 0
+const progressiveBatches = 20
+
+// minProgressiveBatch is the smallest default progressive batch
+const minProgressiveBatch = 1000
+
+// ErrResumeTokenMismatch is returned by BuildIndexProgressive when a resume
+// token doesn't match the catalog or the index it would continue
+var ErrResumeTokenMismatch = errors.New("duplicatecheck: resume token does not match the catalog or index")
+
+// ProgressiveOptions configures BuildIndexProgressive
+type ProgressiveOptions struct {
+	// BatchSize is the number of products indexed between publications.
+	// 0 splits the catalog into about 20 batches of at least 1000 products.
+	BatchSize int
+	// Progress is called after each batch is published, on the building goroutine
+	Progress func(BuildProgress)
+	// Resume continues the build a previous call returned; the zero value
+	// starts a new one
+	Resume BuildResumeToken
+}
+
+// BuildProgress reports a progressive index build after a published batch
+type BuildProgress struct {
+	Indexed int              // Products indexed and queryable
+	Total   int              // Products in the catalog
+	Elapsed time.Duration    // Time since this call started
+	ETA     time.Duration    // Estimated time left, from this call's rate so far
+	Resume  BuildResumeToken // Token continuing the build from this batch
+}
+
+// BuildResumeToken records how far a progressive build got
+// Tokens are plain values and can be stored as JSON.
+type BuildResumeToken struct {
+	Catalog uint64 `json:"catalog"` // Fingerprint of the product IDs and contents, in order
+	Offset  int    `json:"offset"`  // Products indexed, from the start of the catalog
+	Total   int    `json:"total"`   // Products in the catalog
+}
+
+// Done reports whether the token's build indexed the whole catalog
+func (t BuildResumeToken) Done() bool {
+	return t.Catalog != 0 && t.Offset >= t.Total
+}
+
+// BuildIndexProgressive is BuildIndexCtx for catalogs too large to wait for:
+// it indexes products in batches and publishes the partial index after each
+// one, so queries run against what is indexed so far (see IndexCoverage and
+// FindDuplicatesForOneWithCoverage).
+//
+// The first batch replaces any previous index. When ctx is done the build
+// stops, the last published batch stays queryable, and the returned token and
+// ctx.Err() let a later call continue with ProgressiveOptions.Resume: on the
+// same engine, or on one that loaded the partial index with LoadIndex. Resuming
+// with another catalog, or an index other than the token's, returns
+// ErrResumeTokenMismatch. The returned token is Done once every product is
+// indexed, and the final index is the one BuildIndex builds.
+//
+// Each publication copies the bucket maps of the partial index, so very small
+// batches slow down large builds.
+func (e *HybridEngine) BuildIndexProgressive(ctx context.Context, products []Product, opts ProgressiveOptions) (_ BuildResumeToken, err error) {
+	defer e.levenshteinEngine.guard("BuildIndexProgressive", &err)
+	products, _, err = e.resolveProducts(products)
+	if err != nil {
+		return opts.Resume, err
+	}
+	n := len(products)
+	token := BuildResumeToken{Catalog: catalogFingerprint(productPtrs(products)), Total: n}
+
+	var idx *LSHIndex
+	if opts.Resume == (BuildResumeToken{}) {
+		idx = e.newLSHIndex(n)
+	} else {
+		if opts.Resume.Catalog != token.Catalog || opts.Resume.Total != n || opts.Resume.Offset > n {
+			return opts.Resume, fmt.Errorf("%w: token is for another catalog", ErrResumeTokenMismatch)
+		}
+		current := e.currentIndex()
+		if !current.hasPrefix(products[:opts.Resume.Offset]) {
+			return opts.Resume, fmt.Errorf("%w: index doesn't hold the first %d products", ErrResumeTokenMismatch, opts.Resume.Offset)
+		}
+		// The published index may be in use: extend a copy
+		if idx, err = current.clone(); err != nil {
+			return opts.Resume, err
+		}
+		idx.catalogSize = n
+		token.Offset = opts.Resume.Offset
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = (n + progressiveBatches - 1) / progressiveBatches
+		if batchSize < minProgressiveBatch {
+			batchSize = minProgressiveBatch
+		}
+	}
+	started := time.Now()
+	workers := e.buildWorkerCount(batchSize)
+	idx.buildWorkers = workers
+	if e.logger != nil {
+		e.logger.LogAttrs(ctx, slog.LevelInfo, "index build started",
+			slog.String("engine", "hybrid"),
+			slog.Int("products", n),
+			slog.Int("workers", workers),
+			slog.Int("batch_size", batchSize),
+			slog.Int("resume_offset", token.Offset))
+	}
+	e.logIndexedFields(ctx)
+
+	// Index private copies, as BuildIndexCtx does. A resumed build that is
+	// already complete still publishes once.
+	resumed := token.Offset
+	for first := true; first || token.Offset < n; first = false {
+		end := token.Offset + batchSize
+		if end > n {
+			end = n
+		}
+		batch := make([]Product, end-token.Offset)
+		copy(batch, products[token.Offset:end])
+		e.levenshteinEngine.interner.internProducts(batch)
+		if err := e.indexProducts(ctx, idx, batch, workers); err != nil {
+			if e.logger != nil {
+				e.logger.LogAttrs(context.Background(), slog.LevelWarn, "index build cancelled",
+					slog.String("engine", "hybrid"),
+					slog.Int("products", n),
+					slog.Int("indexed", token.Offset),
+					slog.Duration("duration", time.Since(started)))
+			}
+			return token, err
+		}
+		token.Offset = end
+
+		// Publish a copy while the build goes on; the last batch publishes the
+		// index itself
+		published := idx
+		if end < n {
+			if published, err = idx.clone(); err != nil {
+				return token, err
+			}
+		} else {
+			idx.buildDuration = time.Since(started)
+		}
+		e.indexMu.Lock()
+		e.lshIndex = published
+		e.retained = nil
+		e.matches = nil
+		e.indexMu.Unlock()
+
+		if opts.Progress != nil {
+			elapsed := time.Since(started)
+			progress := BuildProgress{Indexed: end, Total: n, Elapsed: elapsed, Resume: token}
+			if done := end - resumed; done > 0 {
+				progress.ETA = time.Duration(float64(elapsed) / float64(done) * float64(n-end))
+			}
+			opts.Progress(progress)
+		}
+	}
+
+	if e.logger != nil {
+		e.logger.LogAttrs(context.Background(), slog.LevelInfo, "index build finished",
+			slog.String("engine", "hybrid"),
+			slog.Int("products", n),
+			slog.Int("signatures", idx.totalChunks),
+			slog.Int("sampled", idx.sampled),
+			slog.Int("truncated", idx.truncated),
+			slog.Int("workers", workers),
+			slog.Float64("products_per_sec", idx.buildThroughput()),
+			slog.Duration("duration", idx.buildDuration))
+	}
+	return token, nil
+}
+
+// IndexCoverage returns the fraction of the catalog the published index holds:
+// below 1 while BuildIndexProgressive is still indexing (or was stopped), 1
+// for a complete index and 0 without one
+// A query against a partial index can miss duplicates among the products not
+// yet indexed, so a negative result is only as good as its coverage.
+func (e *HybridEngine) IndexCoverage() float64 {
+	idx := e.currentIndex()
+	if idx == nil {
+		return 0
+	}
+	return idx.coverage()
+}
+
+// FindDuplicatesForOneWithCoverage is FindDuplicatesForOne, also returning the
+// IndexCoverage of the index the query ran against
+// Without an index it returns no results and coverage 0.
+func (e *HybridEngine) FindDuplicatesForOneWithCoverage(product Product, threshold float64) ([]ComparisonResult, float64) {
+	defer e.levenshteinEngine.guard("FindDuplicatesForOneWithCoverage", nil)
+	idx := e.currentIndex()
+	if idx == nil {
+		return nil, 0
+	}
+	duplicates, _ := e.findDuplicatesForOne(idx, &product, threshold)
+	return duplicates, idx.coverage()
+}
+
+// coverage returns the fraction of its catalog idx holds
+func (idx *LSHIndex) coverage() float64 {
+	if idx.catalogSize <= 0 || len(idx.ids) >= idx.catalogSize {
+		return 1
+	}
+	return float64(len(idx.ids)) / float64(idx.catalogSize)
+}
+
+// hasPrefix reports whether idx holds exactly products, in order
+// A nil index holds no products.
+func (idx *LSHIndex) hasPrefix(products []Product) bool {
+	if idx == nil {
+		return len(products) == 0
+	}
+	if len(idx.ids) != len(products) {
+		return false
+	}
+	for i, id := range idx.ids {
+		if products[i].ID != id {
+			return false
+		}
+	}
+	return true
+}
+
+// clone returns a copy of idx that later additions to idx don't affect
+// Bucket and ID slices are shared but capped at their length, so appending
+// to either index's copy never writes where the other reads; band stores
+// other than the in-memory one are copied into a new store.
+func (idx *LSHIndex) clone() (*LSHIndex, error) {
+	c := *idx
+	if memory, ok := idx.bands.(*MemoryBandStore); ok {
+		c.bands = memory.clone()
+	} else {
+		c.bands = idx.newBands()
+		copyBandStore(c.bands, idx.bands, idx.numBands, func(n uint32) (uint32, bool) { return n, true })
+		if err := finishBandStore(c.bands); err != nil {
+			return nil, err
+		}
+	}
+	c.refs = idx.refs[:len(idx.refs):len(idx.refs)]
+	c.numbers = make(map[string]uint32, len(idx.numbers))
+	for id, n := range idx.numbers {
+		c.numbers[id] = n
+	}
+	c.products = make(map[string]*Product, len(idx.products))
+	for id, p := range idx.products {
+		c.products[id] = p
+	}
+	if idx.fingerprints != nil {
+		c.fingerprints = make(map[string]SimHashFingerprint, len(idx.fingerprints))
+		for id, f := range idx.fingerprints {
+			c.fingerprints[id] = f
+		}
+	}
+	if idx.contentHashes != nil {
+		c.contentHashes = make(map[string]uint64, len(idx.contentHashes))
+		for id, h := range idx.contentHashes {
+			c.contentHashes[id] = h
+		}
+	}
+	if idx.queryBands != nil {
+		c.queryBands = make(map[string]indexedBands, len(idx.queryBands))
+		for id, b := range idx.queryBands {
+			c.queryBands[id] = b
+		}
+	}
+	c.ids = idx.ids[:len(idx.ids):len(idx.ids)]
+	c.prefixes = idx.prefixes.clone()
+	if idx.removed != nil {
+		c.removed = make(map[uint32]bool, len(idx.removed))
+		for n := range idx.removed {
+			c.removed[n] = true
+		}
+	}
+	return &c, nil
+}
@@ -0,0 +1,214 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// matchedIDs returns the indexed side of each result
+func matchedIDs(results []ComparisonResult) map[string]bool {
+	ids := make(map[string]bool, len(results))
+	for _, r := range results {
+		ids[r.ProductB.ID] = true
+	}
+	return ids
+}
+
+// containsAll reports whether every key of sub is in set
+func containsAll(set, sub map[string]bool) bool {
+	for id := range sub {
+		if !set[id] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuildIndexProgressive(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 600)
+	const batchSize = 100
+	built := NewHybridEngine()
+	if err := built.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	queries := []Product{catalog[0], catalog[150], catalog[320], catalog[599]}
+
+	engine := NewHybridEngine().WithBucketSalt(built.GetBucketSalt())
+	previous := make([]map[string]bool, len(queries))
+	var progress []BuildProgress
+
+	// A concurrent reader sees snapshots that only grow
+	done := make(chan struct{})
+	readerErr := make(chan string, 1)
+	go func() {
+		defer close(readerErr)
+		lastCoverage, last := 0.0, map[string]bool{}
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			results, coverage := engine.FindDuplicatesForOneWithCoverage(queries[1], 0.75)
+			ids := matchedIDs(results)
+			if coverage < lastCoverage || !containsAll(ids, last) {
+				readerErr <- "reader saw the index shrink"
+				return
+			}
+			lastCoverage, last = coverage, ids
+		}
+	}()
+
+	token, err := engine.BuildIndexProgressive(context.Background(), catalog, ProgressiveOptions{
+		BatchSize: batchSize,
+		Progress: func(p BuildProgress) {
+			progress = append(progress, p)
+			if got, want := engine.IndexCoverage(), float64(p.Indexed)/float64(p.Total); got != want {
+				t.Errorf("After %d products: coverage %v, want %v", p.Indexed, got, want)
+			}
+			if engine.IndexedCount() != p.Indexed || p.Resume.Offset != p.Indexed {
+				t.Errorf("After %d products: %d indexed, token offset %d", p.Indexed, engine.IndexedCount(), p.Resume.Offset)
+			}
+			for i, q := range queries {
+				ids := matchedIDs(engine.FindDuplicatesForOne(q, 0.75))
+				if previous[i] != nil && !containsAll(ids, previous[i]) {
+					t.Errorf("After %d products: query %s lost matches (%v, was %v)", p.Indexed, q.ID, ids, previous[i])
+				}
+				previous[i] = ids
+			}
+		},
+	})
+	close(done)
+	if msg, ok := <-readerErr; ok {
+		t.Error(msg)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(progress) != len(catalog)/batchSize {
+		t.Fatalf("Progress called %d times, want %d", len(progress), len(catalog)/batchSize)
+	}
+	if last := progress[len(progress)-1]; last.Indexed != len(catalog) || last.ETA != 0 {
+		t.Errorf("Last progress %+v", last)
+	}
+	if !token.Done() || engine.IndexCoverage() != 1 || engine.GetIndexStats()["index_coverage"] != 1.0 {
+		t.Errorf("Token %+v, coverage %v after a complete build", token, engine.IndexCoverage())
+	}
+	if !reflect.DeepEqual(engine.currentIndex().bands, built.currentIndex().bands) {
+		t.Error("Progressive buckets differ from BuildIndex buckets")
+	}
+	for i, q := range queries {
+		if want := matchedIDs(built.FindDuplicatesForOne(q, 0.75)); !reflect.DeepEqual(previous[i], want) {
+			t.Errorf("Query %s: progressive index found %v, built index %v", q.ID, previous[i], want)
+		}
+	}
+}
+
+func TestBuildIndexProgressiveResume(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 500)
+	const batchSize, stopAfter = 100, 2
+	built := NewHybridEngine()
+	if err := built.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+
+	// stopped returns an engine whose build was cancelled after stopAfter batches
+	stopped := func(t *testing.T) (*HybridEngine, BuildResumeToken) {
+		engine := NewHybridEngine().WithBucketSalt(built.GetBucketSalt())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		batches := 0
+		token, err := engine.BuildIndexProgressive(ctx, catalog, ProgressiveOptions{
+			BatchSize: batchSize,
+			Progress: func(BuildProgress) {
+				if batches++; batches == stopAfter {
+					cancel()
+				}
+			},
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("BuildIndexProgressive = %v, want context.Canceled", err)
+		}
+		if token.Offset != stopAfter*batchSize || token.Done() {
+			t.Fatalf("Token %+v after %d batches", token, stopAfter)
+		}
+		return engine, token
+	}
+	finish := func(t *testing.T, engine *HybridEngine, token BuildResumeToken) {
+		t.Helper()
+		resumed := 0
+		final, err := engine.BuildIndexProgressive(context.Background(), catalog, ProgressiveOptions{
+			BatchSize: batchSize,
+			Resume:    token,
+			Progress:  func(BuildProgress) { resumed++ },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !final.Done() || resumed != (len(catalog)-token.Offset)/batchSize {
+			t.Errorf("Token %+v after %d resumed batches", final, resumed)
+		}
+		if !reflect.DeepEqual(engine.currentIndex().bands, built.currentIndex().bands) ||
+			!reflect.DeepEqual(engine.currentIndex().ids, built.currentIndex().ids) {
+			t.Error("Resumed index differs from BuildIndex")
+		}
+	}
+
+	t.Run("Same engine", func(t *testing.T) {
+		engine, token := stopped(t)
+		if got, want := engine.IndexCoverage(), float64(token.Offset)/float64(len(catalog)); got != want {
+			t.Errorf("Stopped build: coverage %v, want %v", got, want)
+		}
+		query := catalog[0]
+		query.ID = "query" // Matches catalog[0], which the query would skip as itself
+		if results, coverage := engine.FindDuplicatesForOneWithCoverage(query, 0.75); len(results) == 0 || coverage >= 1 {
+			t.Errorf("Partial index: %d results at coverage %v", len(results), coverage)
+		}
+		finish(t, engine, token)
+	})
+
+	t.Run("After restart", func(t *testing.T) {
+		engine, token := stopped(t)
+		var file bytes.Buffer
+		if err := engine.SaveIndex(&file); err != nil {
+			t.Fatal(err)
+		}
+		restarted := NewHybridEngine()
+		if err := restarted.LoadIndex(&file); err != nil {
+			t.Fatal(err)
+		}
+		if restarted.IndexCoverage() != engine.IndexCoverage() {
+			t.Errorf("Loaded coverage %v, saved %v", restarted.IndexCoverage(), engine.IndexCoverage())
+		}
+		finish(t, restarted, token)
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		engine, token := stopped(t)
+		edited := append([]Product(nil), catalog...)
+		edited[len(edited)-1].Name += " (refurbished)"
+		tests := []struct {
+			name     string
+			engine   *HybridEngine
+			products []Product
+		}{
+			{"Edited catalog", engine, edited},
+			{"Shorter catalog", engine, catalog[:400]},
+			{"No index", NewHybridEngine(), catalog},
+			{"Other index", built, catalog},
+		}
+		for _, tt := range tests {
+			_, err := tt.engine.BuildIndexProgressive(context.Background(), tt.products, ProgressiveOptions{Resume: token})
+			if !errors.Is(err, ErrResumeTokenMismatch) {
+				t.Errorf("%s: %v, want ErrResumeTokenMismatch", tt.name, err)
+			}
+		}
+		if engine.IndexedCount() != token.Offset {
+			t.Error("A refused resume should keep the partial index")
+		}
+	})
+}
@@ -0,0 +1,19 @@
+// Package duplicatecheckv1 holds the Go bindings of duplicatecheck.proto, the
+// v1 schema of the comparison service
+//
+// duplicatecheck.pb.go and duplicatecheck_grpc.pb.go are generated by
+// protoc-gen-go and protoc-gen-go-grpc; don't edit them. After changing the
+// schema, regenerate them from the proto directory (see `make proto`):
+//
+//	protoc -I . \
+//		--go_out=. --go_opt=paths=source_relative \
+//		--go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//		duplicatecheck/v1/duplicatecheck.proto
+//
+// The bindings depend on google.golang.org/protobuf and google.golang.org/grpc,
+// so they live in their own module, github.com/solrac97gr/duplicatecheck/proto,
+// and the library module stays free of dependencies. A test checks the
+// generated descriptors against duplicatecheck.proto, so the two can't drift.
+// Register a server with RegisterDuplicateCheckServiceServer; the grpcserver
+// module implements one on a HybridEngine.
+package duplicatecheckv1
@@ -0,0 +1,1740 @@
+// Comparison service of the duplicatecheck library, for callers in other
+// languages. Messages mirror the library types of the same names; see the Go
+// package github.com/solrac97gr/duplicatecheck for the meaning of each field.
+//
+// Fields are only ever added: removed fields are reserved, never reused.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: duplicatecheck/v1/duplicatecheck.proto
+
+package duplicatecheckv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// MatchType numbers match the library's MatchType values
+type MatchType int32
+
+const (
+	MatchType_MATCH_TYPE_FUZZY              MatchType = 0
+	MatchType_MATCH_TYPE_NORMALIZED_EXACT   MatchType = 1
+	MatchType_MATCH_TYPE_EXACT              MatchType = 2
+	MatchType_MATCH_TYPE_IDENTIFIER         MatchType = 3
+	MatchType_MATCH_TYPE_VARIANT            MatchType = 4
+	MatchType_MATCH_TYPE_COPIED_DESCRIPTION MatchType = 5
+)
+
+// Enum value maps for MatchType.
+var (
+	MatchType_name = map[int32]string{
+		0: "MATCH_TYPE_FUZZY",
+		1: "MATCH_TYPE_NORMALIZED_EXACT",
+		2: "MATCH_TYPE_EXACT",
+		3: "MATCH_TYPE_IDENTIFIER",
+		4: "MATCH_TYPE_VARIANT",
+		5: "MATCH_TYPE_COPIED_DESCRIPTION",
+	}
+	MatchType_value = map[string]int32{
+		"MATCH_TYPE_FUZZY":              0,
+		"MATCH_TYPE_NORMALIZED_EXACT":   1,
+		"MATCH_TYPE_EXACT":              2,
+		"MATCH_TYPE_IDENTIFIER":         3,
+		"MATCH_TYPE_VARIANT":            4,
+		"MATCH_TYPE_COPIED_DESCRIPTION": 5,
+	}
+)
+
+func (x MatchType) Enum() *MatchType {
+	p := new(MatchType)
+	*p = x
+	return p
+}
+
+func (x MatchType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MatchType) Descriptor() protoreflect.EnumDescriptor {
+	return file_duplicatecheck_v1_duplicatecheck_proto_enumTypes[0].Descriptor()
+}
+
+func (MatchType) Type() protoreflect.EnumType {
+	return &file_duplicatecheck_v1_duplicatecheck_proto_enumTypes[0]
+}
+
+func (x MatchType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MatchType.Descriptor instead.
+func (MatchType) EnumDescriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{0}
+}
+
+// SimilarityMode numbers match the library's SimilarityMode values
+type SimilarityMode int32
+
+const (
+	SimilarityMode_SIMILARITY_MODE_LINEAR          SimilarityMode = 0
+	SimilarityMode_SIMILARITY_MODE_LENGTH_ADJUSTED SimilarityMode = 1
+	SimilarityMode_SIMILARITY_MODE_LOGISTIC        SimilarityMode = 2
+)
+
+// Enum value maps for SimilarityMode.
+var (
+	SimilarityMode_name = map[int32]string{
+		0: "SIMILARITY_MODE_LINEAR",
+		1: "SIMILARITY_MODE_LENGTH_ADJUSTED",
+		2: "SIMILARITY_MODE_LOGISTIC",
+	}
+	SimilarityMode_value = map[string]int32{
+		"SIMILARITY_MODE_LINEAR":          0,
+		"SIMILARITY_MODE_LENGTH_ADJUSTED": 1,
+		"SIMILARITY_MODE_LOGISTIC":        2,
+	}
+)
+
+func (x SimilarityMode) Enum() *SimilarityMode {
+	p := new(SimilarityMode)
+	*p = x
+	return p
+}
+
+func (x SimilarityMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SimilarityMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_duplicatecheck_v1_duplicatecheck_proto_enumTypes[1].Descriptor()
+}
+
+func (SimilarityMode) Type() protoreflect.EnumType {
+	return &file_duplicatecheck_v1_duplicatecheck_proto_enumTypes[1]
+}
+
+func (x SimilarityMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SimilarityMode.Descriptor instead.
+func (SimilarityMode) EnumDescriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{1}
+}
+
+type Product struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id          string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	SourceId    string `protobuf:"bytes,2,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	Name        string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Description string `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+}
+
+func (x *Product) Reset() {
+	*x = Product{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Product) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Product) ProtoMessage() {}
+
+func (x *Product) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Product.ProtoReflect.Descriptor instead.
+func (*Product) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Product) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Product) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+func (x *Product) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Product) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type ComparisonWeights struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	NameWeight        float64 `protobuf:"fixed64,1,opt,name=name_weight,json=nameWeight,proto3" json:"name_weight,omitempty"`
+	DescriptionWeight float64 `protobuf:"fixed64,2,opt,name=description_weight,json=descriptionWeight,proto3" json:"description_weight,omitempty"`
+}
+
+func (x *ComparisonWeights) Reset() {
+	*x = ComparisonWeights{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ComparisonWeights) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComparisonWeights) ProtoMessage() {}
+
+func (x *ComparisonWeights) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComparisonWeights.ProtoReflect.Descriptor instead.
+func (*ComparisonWeights) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ComparisonWeights) GetNameWeight() float64 {
+	if x != nil {
+		return x.NameWeight
+	}
+	return 0
+}
+
+func (x *ComparisonWeights) GetDescriptionWeight() float64 {
+	if x != nil {
+		return x.DescriptionWeight
+	}
+	return 0
+}
+
+type SegmentScore struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Label      string  `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	IndexA     int64   `protobuf:"varint,2,opt,name=index_a,json=indexA,proto3" json:"index_a,omitempty"`
+	IndexB     int64   `protobuf:"varint,3,opt,name=index_b,json=indexB,proto3" json:"index_b,omitempty"`
+	Weight     float64 `protobuf:"fixed64,4,opt,name=weight,proto3" json:"weight,omitempty"`
+	Distance   int64   `protobuf:"varint,5,opt,name=distance,proto3" json:"distance,omitempty"`
+	Similarity float64 `protobuf:"fixed64,6,opt,name=similarity,proto3" json:"similarity,omitempty"`
+}
+
+func (x *SegmentScore) Reset() {
+	*x = SegmentScore{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SegmentScore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentScore) ProtoMessage() {}
+
+func (x *SegmentScore) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentScore.ProtoReflect.Descriptor instead.
+func (*SegmentScore) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SegmentScore) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *SegmentScore) GetIndexA() int64 {
+	if x != nil {
+		return x.IndexA
+	}
+	return 0
+}
+
+func (x *SegmentScore) GetIndexB() int64 {
+	if x != nil {
+		return x.IndexB
+	}
+	return 0
+}
+
+func (x *SegmentScore) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *SegmentScore) GetDistance() int64 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *SegmentScore) GetSimilarity() float64 {
+	if x != nil {
+		return x.Similarity
+	}
+	return 0
+}
+
+type ComponentMatch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	A          string  `protobuf:"bytes,1,opt,name=a,proto3" json:"a,omitempty"`
+	B          string  `protobuf:"bytes,2,opt,name=b,proto3" json:"b,omitempty"`
+	IndexA     int64   `protobuf:"varint,3,opt,name=index_a,json=indexA,proto3" json:"index_a,omitempty"`
+	IndexB     int64   `protobuf:"varint,4,opt,name=index_b,json=indexB,proto3" json:"index_b,omitempty"`
+	Similarity float64 `protobuf:"fixed64,5,opt,name=similarity,proto3" json:"similarity,omitempty"`
+}
+
+func (x *ComponentMatch) Reset() {
+	*x = ComponentMatch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ComponentMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentMatch) ProtoMessage() {}
+
+func (x *ComponentMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentMatch.ProtoReflect.Descriptor instead.
+func (*ComponentMatch) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ComponentMatch) GetA() string {
+	if x != nil {
+		return x.A
+	}
+	return ""
+}
+
+func (x *ComponentMatch) GetB() string {
+	if x != nil {
+		return x.B
+	}
+	return ""
+}
+
+func (x *ComponentMatch) GetIndexA() int64 {
+	if x != nil {
+		return x.IndexA
+	}
+	return 0
+}
+
+func (x *ComponentMatch) GetIndexB() int64 {
+	if x != nil {
+		return x.IndexB
+	}
+	return 0
+}
+
+func (x *ComponentMatch) GetSimilarity() float64 {
+	if x != nil {
+		return x.Similarity
+	}
+	return 0
+}
+
+// ComparisonResult carries every ComparisonResult field but the deprecated
+// Distance and Similarity
+type ComparisonResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProductA                   *Product           `protobuf:"bytes,1,opt,name=product_a,json=productA,proto3" json:"product_a,omitempty"`
+	ProductB                   *Product           `protobuf:"bytes,2,opt,name=product_b,json=productB,proto3" json:"product_b,omitempty"`
+	NameDistance               int64              `protobuf:"varint,3,opt,name=name_distance,json=nameDistance,proto3" json:"name_distance,omitempty"`
+	NameSimilarity             float64            `protobuf:"fixed64,4,opt,name=name_similarity,json=nameSimilarity,proto3" json:"name_similarity,omitempty"`
+	DescriptionDistance        int64              `protobuf:"varint,5,opt,name=description_distance,json=descriptionDistance,proto3" json:"description_distance,omitempty"`
+	DescriptionSimilarity      float64            `protobuf:"fixed64,6,opt,name=description_similarity,json=descriptionSimilarity,proto3" json:"description_similarity,omitempty"`
+	CombinedSimilarity         float64            `protobuf:"fixed64,7,opt,name=combined_similarity,json=combinedSimilarity,proto3" json:"combined_similarity,omitempty"`
+	Stage                      string             `protobuf:"bytes,8,opt,name=stage,proto3" json:"stage,omitempty"`
+	WeightsUsed                *ComparisonWeights `protobuf:"bytes,9,opt,name=weights_used,json=weightsUsed,proto3" json:"weights_used,omitempty"`
+	SimilarityMode             SimilarityMode     `protobuf:"varint,10,opt,name=similarity_mode,json=similarityMode,proto3,enum=duplicatecheck.v1.SimilarityMode" json:"similarity_mode,omitempty"`
+	ThresholdUsed              float64            `protobuf:"fixed64,11,opt,name=threshold_used,json=thresholdUsed,proto3" json:"threshold_used,omitempty"`
+	MeetsThreshold             bool               `protobuf:"varint,12,opt,name=meets_threshold,json=meetsThreshold,proto3" json:"meets_threshold,omitempty"`
+	MatchType                  MatchType          `protobuf:"varint,13,opt,name=match_type,json=matchType,proto3,enum=duplicatecheck.v1.MatchType" json:"match_type,omitempty"`
+	DifferenceKinds            []string           `protobuf:"bytes,14,rep,name=difference_kinds,json=differenceKinds,proto3" json:"difference_kinds,omitempty"`
+	SegmentSimilarities        []*SegmentScore    `protobuf:"bytes,15,rep,name=segment_similarities,json=segmentSimilarities,proto3" json:"segment_similarities,omitempty"`
+	LowQualityInput            bool               `protobuf:"varint,16,opt,name=low_quality_input,json=lowQualityInput,proto3" json:"low_quality_input,omitempty"`
+	DescriptionTimedOut        bool               `protobuf:"varint,17,opt,name=description_timed_out,json=descriptionTimedOut,proto3" json:"description_timed_out,omitempty"`
+	NameInDescriptionAb        float64            `protobuf:"fixed64,18,opt,name=name_in_description_ab,json=nameInDescriptionAb,proto3" json:"name_in_description_ab,omitempty"`
+	NameInDescriptionBa        float64            `protobuf:"fixed64,19,opt,name=name_in_description_ba,json=nameInDescriptionBa,proto3" json:"name_in_description_ba,omitempty"`
+	CandidateSource            string             `protobuf:"bytes,20,opt,name=candidate_source,json=candidateSource,proto3" json:"candidate_source,omitempty"`
+	DuplicateProbability       float64            `protobuf:"fixed64,21,opt,name=duplicate_probability,json=duplicateProbability,proto3" json:"duplicate_probability,omitempty"`
+	ObfuscationSuspected       bool               `protobuf:"varint,22,opt,name=obfuscation_suspected,json=obfuscationSuspected,proto3" json:"obfuscation_suspected,omitempty"`
+	DeobfuscatedNameSimilarity float64            `protobuf:"fixed64,23,opt,name=deobfuscated_name_similarity,json=deobfuscatedNameSimilarity,proto3" json:"deobfuscated_name_similarity,omitempty"`
+	DescriptionLoadFailed      bool               `protobuf:"varint,24,opt,name=description_load_failed,json=descriptionLoadFailed,proto3" json:"description_load_failed,omitempty"`
+	SameSourceIds              bool               `protobuf:"varint,25,opt,name=same_source_ids,json=sameSourceIds,proto3" json:"same_source_ids,omitempty"`
+	CrossLanguage              bool               `protobuf:"varint,26,opt,name=cross_language,json=crossLanguage,proto3" json:"cross_language,omitempty"`
+	ClusterInferred            bool               `protobuf:"varint,27,opt,name=cluster_inferred,json=clusterInferred,proto3" json:"cluster_inferred,omitempty"`
+	SkuNameComparison          bool               `protobuf:"varint,28,opt,name=sku_name_comparison,json=skuNameComparison,proto3" json:"sku_name_comparison,omitempty"`
+	SkuNameMixed               bool               `protobuf:"varint,29,opt,name=sku_name_mixed,json=skuNameMixed,proto3" json:"sku_name_mixed,omitempty"`
+	LowInfoNames               bool               `protobuf:"varint,30,opt,name=low_info_names,json=lowInfoNames,proto3" json:"low_info_names,omitempty"`
+	AmbiguousProduct           bool               `protobuf:"varint,31,opt,name=ambiguous_product,json=ambiguousProduct,proto3" json:"ambiguous_product,omitempty"`
+	EstimatedSimilarity        float64            `protobuf:"fixed64,32,opt,name=estimated_similarity,json=estimatedSimilarity,proto3" json:"estimated_similarity,omitempty"`
+	BundleSimilarity           float64            `protobuf:"fixed64,33,opt,name=bundle_similarity,json=bundleSimilarity,proto3" json:"bundle_similarity,omitempty"`
+	ComponentMatches           []*ComponentMatch  `protobuf:"bytes,34,rep,name=component_matches,json=componentMatches,proto3" json:"component_matches,omitempty"`
+	// Reason codes, as ReasonCode strings (see ReasonRegistry)
+	ReasonCodes                  []string `protobuf:"bytes,35,rep,name=reason_codes,json=reasonCodes,proto3" json:"reason_codes,omitempty"`
+	HomoglyphsNormalized         bool     `protobuf:"varint,36,opt,name=homoglyphs_normalized,json=homoglyphsNormalized,proto3" json:"homoglyphs_normalized,omitempty"`
+	FieldsSwappedSuspected       bool     `protobuf:"varint,37,opt,name=fields_swapped_suspected,json=fieldsSwappedSuspected,proto3" json:"fields_swapped_suspected,omitempty"`
+	SwappedNameSimilarity        float64  `protobuf:"fixed64,38,opt,name=swapped_name_similarity,json=swappedNameSimilarity,proto3" json:"swapped_name_similarity,omitempty"`
+	SwappedDescriptionSimilarity float64  `protobuf:"fixed64,39,opt,name=swapped_description_similarity,json=swappedDescriptionSimilarity,proto3" json:"swapped_description_similarity,omitempty"`
+}
+
+func (x *ComparisonResult) Reset() {
+	*x = ComparisonResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ComparisonResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComparisonResult) ProtoMessage() {}
+
+func (x *ComparisonResult) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComparisonResult.ProtoReflect.Descriptor instead.
+func (*ComparisonResult) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ComparisonResult) GetProductA() *Product {
+	if x != nil {
+		return x.ProductA
+	}
+	return nil
+}
+
+func (x *ComparisonResult) GetProductB() *Product {
+	if x != nil {
+		return x.ProductB
+	}
+	return nil
+}
+
+func (x *ComparisonResult) GetNameDistance() int64 {
+	if x != nil {
+		return x.NameDistance
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetNameSimilarity() float64 {
+	if x != nil {
+		return x.NameSimilarity
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetDescriptionDistance() int64 {
+	if x != nil {
+		return x.DescriptionDistance
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetDescriptionSimilarity() float64 {
+	if x != nil {
+		return x.DescriptionSimilarity
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetCombinedSimilarity() float64 {
+	if x != nil {
+		return x.CombinedSimilarity
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *ComparisonResult) GetWeightsUsed() *ComparisonWeights {
+	if x != nil {
+		return x.WeightsUsed
+	}
+	return nil
+}
+
+func (x *ComparisonResult) GetSimilarityMode() SimilarityMode {
+	if x != nil {
+		return x.SimilarityMode
+	}
+	return SimilarityMode_SIMILARITY_MODE_LINEAR
+}
+
+func (x *ComparisonResult) GetThresholdUsed() float64 {
+	if x != nil {
+		return x.ThresholdUsed
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetMeetsThreshold() bool {
+	if x != nil {
+		return x.MeetsThreshold
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetMatchType() MatchType {
+	if x != nil {
+		return x.MatchType
+	}
+	return MatchType_MATCH_TYPE_FUZZY
+}
+
+func (x *ComparisonResult) GetDifferenceKinds() []string {
+	if x != nil {
+		return x.DifferenceKinds
+	}
+	return nil
+}
+
+func (x *ComparisonResult) GetSegmentSimilarities() []*SegmentScore {
+	if x != nil {
+		return x.SegmentSimilarities
+	}
+	return nil
+}
+
+func (x *ComparisonResult) GetLowQualityInput() bool {
+	if x != nil {
+		return x.LowQualityInput
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetDescriptionTimedOut() bool {
+	if x != nil {
+		return x.DescriptionTimedOut
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetNameInDescriptionAb() float64 {
+	if x != nil {
+		return x.NameInDescriptionAb
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetNameInDescriptionBa() float64 {
+	if x != nil {
+		return x.NameInDescriptionBa
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetCandidateSource() string {
+	if x != nil {
+		return x.CandidateSource
+	}
+	return ""
+}
+
+func (x *ComparisonResult) GetDuplicateProbability() float64 {
+	if x != nil {
+		return x.DuplicateProbability
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetObfuscationSuspected() bool {
+	if x != nil {
+		return x.ObfuscationSuspected
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetDeobfuscatedNameSimilarity() float64 {
+	if x != nil {
+		return x.DeobfuscatedNameSimilarity
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetDescriptionLoadFailed() bool {
+	if x != nil {
+		return x.DescriptionLoadFailed
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetSameSourceIds() bool {
+	if x != nil {
+		return x.SameSourceIds
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetCrossLanguage() bool {
+	if x != nil {
+		return x.CrossLanguage
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetClusterInferred() bool {
+	if x != nil {
+		return x.ClusterInferred
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetSkuNameComparison() bool {
+	if x != nil {
+		return x.SkuNameComparison
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetSkuNameMixed() bool {
+	if x != nil {
+		return x.SkuNameMixed
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetLowInfoNames() bool {
+	if x != nil {
+		return x.LowInfoNames
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetAmbiguousProduct() bool {
+	if x != nil {
+		return x.AmbiguousProduct
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetEstimatedSimilarity() float64 {
+	if x != nil {
+		return x.EstimatedSimilarity
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetBundleSimilarity() float64 {
+	if x != nil {
+		return x.BundleSimilarity
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetComponentMatches() []*ComponentMatch {
+	if x != nil {
+		return x.ComponentMatches
+	}
+	return nil
+}
+
+func (x *ComparisonResult) GetReasonCodes() []string {
+	if x != nil {
+		return x.ReasonCodes
+	}
+	return nil
+}
+
+func (x *ComparisonResult) GetHomoglyphsNormalized() bool {
+	if x != nil {
+		return x.HomoglyphsNormalized
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetFieldsSwappedSuspected() bool {
+	if x != nil {
+		return x.FieldsSwappedSuspected
+	}
+	return false
+}
+
+func (x *ComparisonResult) GetSwappedNameSimilarity() float64 {
+	if x != nil {
+		return x.SwappedNameSimilarity
+	}
+	return 0
+}
+
+func (x *ComparisonResult) GetSwappedDescriptionSimilarity() float64 {
+	if x != nil {
+		return x.SwappedDescriptionSimilarity
+	}
+	return 0
+}
+
+type CompareRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	A *Product `protobuf:"bytes,1,opt,name=a,proto3" json:"a,omitempty"`
+	B *Product `protobuf:"bytes,2,opt,name=b,proto3" json:"b,omitempty"`
+	// Unset compares with the engine's weights
+	Weights *ComparisonWeights `protobuf:"bytes,3,opt,name=weights,proto3" json:"weights,omitempty"`
+}
+
+func (x *CompareRequest) Reset() {
+	*x = CompareRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompareRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompareRequest) ProtoMessage() {}
+
+func (x *CompareRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompareRequest.ProtoReflect.Descriptor instead.
+func (*CompareRequest) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CompareRequest) GetA() *Product {
+	if x != nil {
+		return x.A
+	}
+	return nil
+}
+
+func (x *CompareRequest) GetB() *Product {
+	if x != nil {
+		return x.B
+	}
+	return nil
+}
+
+func (x *CompareRequest) GetWeights() *ComparisonWeights {
+	if x != nil {
+		return x.Weights
+	}
+	return nil
+}
+
+type CompareResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Result *ComparisonResult `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+}
+
+func (x *CompareResponse) Reset() {
+	*x = CompareResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompareResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompareResponse) ProtoMessage() {}
+
+func (x *CompareResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompareResponse.ProtoReflect.Descriptor instead.
+func (*CompareResponse) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CompareResponse) GetResult() *ComparisonResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+type FindDuplicatesForOneRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Product *Product `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	// Unset uses the engine's default threshold
+	Threshold *float64 `protobuf:"fixed64,2,opt,name=threshold,proto3,oneof" json:"threshold,omitempty"`
+}
+
+func (x *FindDuplicatesForOneRequest) Reset() {
+	*x = FindDuplicatesForOneRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindDuplicatesForOneRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindDuplicatesForOneRequest) ProtoMessage() {}
+
+func (x *FindDuplicatesForOneRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindDuplicatesForOneRequest.ProtoReflect.Descriptor instead.
+func (*FindDuplicatesForOneRequest) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FindDuplicatesForOneRequest) GetProduct() *Product {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+func (x *FindDuplicatesForOneRequest) GetThreshold() float64 {
+	if x != nil && x.Threshold != nil {
+		return *x.Threshold
+	}
+	return 0
+}
+
+type FindDuplicatesForOneResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Result *ComparisonResult `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	// The query had more candidates than the engine verifies; set on every
+	// response of such a query
+	Truncated bool `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"`
+}
+
+func (x *FindDuplicatesForOneResponse) Reset() {
+	*x = FindDuplicatesForOneResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindDuplicatesForOneResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindDuplicatesForOneResponse) ProtoMessage() {}
+
+func (x *FindDuplicatesForOneResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindDuplicatesForOneResponse.ProtoReflect.Descriptor instead.
+func (*FindDuplicatesForOneResponse) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FindDuplicatesForOneResponse) GetResult() *ComparisonResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *FindDuplicatesForOneResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+type FindDuplicatesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Products []*Product `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
+	// Unset uses the engine's default threshold
+	Threshold *float64 `protobuf:"fixed64,2,opt,name=threshold,proto3,oneof" json:"threshold,omitempty"`
+}
+
+func (x *FindDuplicatesRequest) Reset() {
+	*x = FindDuplicatesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindDuplicatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindDuplicatesRequest) ProtoMessage() {}
+
+func (x *FindDuplicatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindDuplicatesRequest.ProtoReflect.Descriptor instead.
+func (*FindDuplicatesRequest) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FindDuplicatesRequest) GetProducts() []*Product {
+	if x != nil {
+		return x.Products
+	}
+	return nil
+}
+
+func (x *FindDuplicatesRequest) GetThreshold() float64 {
+	if x != nil && x.Threshold != nil {
+		return *x.Threshold
+	}
+	return 0
+}
+
+type FindDuplicatesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Result *ComparisonResult `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+}
+
+func (x *FindDuplicatesResponse) Reset() {
+	*x = FindDuplicatesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindDuplicatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindDuplicatesResponse) ProtoMessage() {}
+
+func (x *FindDuplicatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindDuplicatesResponse.ProtoReflect.Descriptor instead.
+func (*FindDuplicatesResponse) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *FindDuplicatesResponse) GetResult() *ComparisonResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+type GetIndexStatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetIndexStatsRequest) Reset() {
+	*x = GetIndexStatsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetIndexStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetIndexStatsRequest) ProtoMessage() {}
+
+func (x *GetIndexStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetIndexStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetIndexStatsRequest) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{11}
+}
+
+// GetIndexStatsResponse splits the library's index statistics by type;
+// durations are in seconds
+type GetIndexStatsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Numbers map[string]float64 `protobuf:"bytes,1,rep,name=numbers,proto3" json:"numbers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3"`
+	Flags   map[string]bool    `protobuf:"bytes,2,rep,name=flags,proto3" json:"flags,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+func (x *GetIndexStatsResponse) Reset() {
+	*x = GetIndexStatsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetIndexStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetIndexStatsResponse) ProtoMessage() {}
+
+func (x *GetIndexStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetIndexStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetIndexStatsResponse) Descriptor() ([]byte, []int) {
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetIndexStatsResponse) GetNumbers() map[string]float64 {
+	if x != nil {
+		return x.Numbers
+	}
+	return nil
+}
+
+func (x *GetIndexStatsResponse) GetFlags() map[string]bool {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
+var File_duplicatecheck_v1_duplicatecheck_proto protoreflect.FileDescriptor
+
+var file_duplicatecheck_v1_duplicatecheck_proto_rawDesc = []byte{
+	0x0a, 0x26, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b,
+	0x2f, 0x76, 0x31, 0x2f, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65,
+	0x63, 0x6b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x11, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63,
+	0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x22, 0x6c, 0x0a, 0x07, 0x50,
+	0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72,
+	0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65,
+	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x63, 0x0a, 0x11, 0x43, 0x6f, 0x6d,
+	0x70, 0x61, 0x72, 0x69, 0x73, 0x6f, 0x6e, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74, 0x73, 0x12, 0x1f,
+	0x0a, 0x0b, 0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x0a, 0x6e, 0x61, 0x6d, 0x65, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12,
+	0x2d, 0x0a, 0x12, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x77,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x11, 0x64, 0x65, 0x73,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0xaa,
+	0x01, 0x0a, 0x0c, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x6c, 0x61, 0x62, 0x65, 0x6c, 0x12, 0x17, 0x0a, 0x07, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x5f, 0x61,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x41, 0x12, 0x17,
+	0x0a, 0x07, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x5f, 0x62, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x06, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x42, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x73,
+	0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x0a, 0x73, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x22, 0x7e, 0x0a, 0x0e, 0x43,
+	0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x12, 0x0c, 0x0a,
+	0x01, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x01, 0x61, 0x12, 0x0c, 0x0a, 0x01, 0x62,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x01, 0x62, 0x12, 0x17, 0x0a, 0x07, 0x69, 0x6e, 0x64,
+	0x65, 0x78, 0x5f, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x69, 0x6e, 0x64, 0x65,
+	0x78, 0x41, 0x12, 0x17, 0x0a, 0x07, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x5f, 0x62, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x06, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x42, 0x12, 0x1e, 0x0a, 0x0a, 0x73,
+	0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x0a, 0x73, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x22, 0xe0, 0x0f, 0x0a, 0x10,
+	0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x69, 0x73, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x12, 0x37, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x5f, 0x61, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63,
+	0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x52,
+	0x08, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x41, 0x12, 0x37, 0x0a, 0x09, 0x70, 0x72, 0x6f,
+	0x64, 0x75, 0x63, 0x74, 0x5f, 0x62, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x64,
+	0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31,
+	0x2e, 0x50, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63,
+	0x74, 0x42, 0x12, 0x23, 0x0a, 0x0d, 0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x64, 0x69, 0x73, 0x74, 0x61,
+	0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x6e, 0x61, 0x6d, 0x65, 0x44,
+	0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x6e, 0x61, 0x6d, 0x65, 0x5f,
+	0x73, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x0e, 0x6e, 0x61, 0x6d, 0x65, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79,
+	0x12, 0x31, 0x0a, 0x14, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x13,
+	0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x44, 0x69, 0x73, 0x74, 0x61,
+	0x6e, 0x63, 0x65, 0x12, 0x35, 0x0a, 0x16, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x5f, 0x73, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x15, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x12, 0x2f, 0x0a, 0x13, 0x63, 0x6f,
+	0x6d, 0x62, 0x69, 0x6e, 0x65, 0x64, 0x5f, 0x73, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74,
+	0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x12, 0x63, 0x6f, 0x6d, 0x62, 0x69, 0x6e, 0x65,
+	0x64, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x73,
+	0x74, 0x61, 0x67, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x67,
+	0x65, 0x12, 0x47, 0x0a, 0x0c, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x73, 0x5f, 0x75, 0x73, 0x65,
+	0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63,
+	0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70,
+	0x61, 0x72, 0x69, 0x73, 0x6f, 0x6e, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74, 0x73, 0x52, 0x0b, 0x77,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x73, 0x55, 0x73, 0x65, 0x64, 0x12, 0x4a, 0x0a, 0x0f, 0x73, 0x69,
+	0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x21, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63,
+	0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69,
+	0x74, 0x79, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x0e, 0x73, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69,
+	0x74, 0x79, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68,
+	0x6f, 0x6c, 0x64, 0x5f, 0x75, 0x73, 0x65, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0d,
+	0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x55, 0x73, 0x65, 0x64, 0x12, 0x27, 0x0a,
+	0x0f, 0x6d, 0x65, 0x65, 0x74, 0x73, 0x5f, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64,
+	0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x6d, 0x65, 0x65, 0x74, 0x73, 0x54, 0x68, 0x72,
+	0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x3b, 0x0a, 0x0a, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x5f,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1c, 0x2e, 0x64, 0x75, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x4d,
+	0x61, 0x74, 0x63, 0x68, 0x54, 0x79, 0x70, 0x65, 0x52, 0x09, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x64, 0x69, 0x66, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63,
+	0x65, 0x5f, 0x6b, 0x69, 0x6e, 0x64, 0x73, 0x18, 0x0e, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x64,
+	0x69, 0x66, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x4b, 0x69, 0x6e, 0x64, 0x73, 0x12, 0x52,
+	0x0a, 0x14, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x73, 0x69, 0x6d, 0x69, 0x6c, 0x61,
+	0x72, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x64,
+	0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x52, 0x13, 0x73,
+	0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x69,
+	0x65, 0x73, 0x12, 0x2a, 0x0a, 0x11, 0x6c, 0x6f, 0x77, 0x5f, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74,
+	0x79, 0x5f, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x18, 0x10, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x6c,
+	0x6f, 0x77, 0x51, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x32,
+	0x0a, 0x15, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x69,
+	0x6d, 0x65, 0x64, 0x5f, 0x6f, 0x75, 0x74, 0x18, 0x11, 0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x64,
+	0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x69, 0x6d, 0x65, 0x64, 0x4f,
+	0x75, 0x74, 0x12, 0x33, 0x0a, 0x16, 0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x69, 0x6e, 0x5f, 0x64, 0x65,
+	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61, 0x62, 0x18, 0x12, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x13, 0x6e, 0x61, 0x6d, 0x65, 0x49, 0x6e, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x62, 0x12, 0x33, 0x0a, 0x16, 0x6e, 0x61, 0x6d, 0x65, 0x5f,
+	0x69, 0x6e, 0x5f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x62,
+	0x61, 0x18, 0x13, 0x20, 0x01, 0x28, 0x01, 0x52, 0x13, 0x6e, 0x61, 0x6d, 0x65, 0x49, 0x6e, 0x44,
+	0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x61, 0x12, 0x29, 0x0a, 0x10,
+	0x63, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x63, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74,
+	0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x33, 0x0a, 0x15, 0x64, 0x75, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x62, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79,
+	0x18, 0x15, 0x20, 0x01, 0x28, 0x01, 0x52, 0x14, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74,
+	0x65, 0x50, 0x72, 0x6f, 0x62, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x33, 0x0a, 0x15,
+	0x6f, 0x62, 0x66, 0x75, 0x73, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x73, 0x75, 0x73, 0x70,
+	0x65, 0x63, 0x74, 0x65, 0x64, 0x18, 0x16, 0x20, 0x01, 0x28, 0x08, 0x52, 0x14, 0x6f, 0x62, 0x66,
+	0x75, 0x73, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74, 0x65,
+	0x64, 0x12, 0x40, 0x0a, 0x1c, 0x64, 0x65, 0x6f, 0x62, 0x66, 0x75, 0x73, 0x63, 0x61, 0x74, 0x65,
+	0x64, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x73, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74,
+	0x79, 0x18, 0x17, 0x20, 0x01, 0x28, 0x01, 0x52, 0x1a, 0x64, 0x65, 0x6f, 0x62, 0x66, 0x75, 0x73,
+	0x63, 0x61, 0x74, 0x65, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72,
+	0x69, 0x74, 0x79, 0x12, 0x36, 0x0a, 0x17, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x5f, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x18, 0x18,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x15, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x4c, 0x6f, 0x61, 0x64, 0x46, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x12, 0x26, 0x0a, 0x0f, 0x73,
+	0x61, 0x6d, 0x65, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x19,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x73, 0x61, 0x6d, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x49, 0x64, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x72, 0x6f, 0x73, 0x73, 0x5f, 0x6c, 0x61, 0x6e,
+	0x67, 0x75, 0x61, 0x67, 0x65, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x63, 0x72, 0x6f,
+	0x73, 0x73, 0x4c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x63, 0x6c,
+	0x75, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x6e, 0x66, 0x65, 0x72, 0x72, 0x65, 0x64, 0x18, 0x1b,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x49, 0x6e, 0x66,
+	0x65, 0x72, 0x72, 0x65, 0x64, 0x12, 0x2e, 0x0a, 0x13, 0x73, 0x6b, 0x75, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x69, 0x73, 0x6f, 0x6e, 0x18, 0x1c, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x11, 0x73, 0x6b, 0x75, 0x4e, 0x61, 0x6d, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x61,
+	0x72, 0x69, 0x73, 0x6f, 0x6e, 0x12, 0x24, 0x0a, 0x0e, 0x73, 0x6b, 0x75, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x5f, 0x6d, 0x69, 0x78, 0x65, 0x64, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x73,
+	0x6b, 0x75, 0x4e, 0x61, 0x6d, 0x65, 0x4d, 0x69, 0x78, 0x65, 0x64, 0x12, 0x24, 0x0a, 0x0e, 0x6c,
+	0x6f, 0x77, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x1e, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x0c, 0x6c, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x4e, 0x61, 0x6d, 0x65,
+	0x73, 0x12, 0x2b, 0x0a, 0x11, 0x61, 0x6d, 0x62, 0x69, 0x67, 0x75, 0x6f, 0x75, 0x73, 0x5f, 0x70,
+	0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x61, 0x6d,
+	0x62, 0x69, 0x67, 0x75, 0x6f, 0x75, 0x73, 0x50, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x12, 0x31,
+	0x0a, 0x14, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x73, 0x69, 0x6d, 0x69,
+	0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x18, 0x20, 0x20, 0x01, 0x28, 0x01, 0x52, 0x13, 0x65, 0x73,
+	0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74,
+	0x79, 0x12, 0x2b, 0x0a, 0x11, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x5f, 0x73, 0x69, 0x6d, 0x69,
+	0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x18, 0x21, 0x20, 0x01, 0x28, 0x01, 0x52, 0x10, 0x62, 0x75,
+	0x6e, 0x64, 0x6c, 0x65, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x12, 0x4e,
+	0x0a, 0x11, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x5f, 0x6d, 0x61, 0x74, 0x63,
+	0x68, 0x65, 0x73, 0x18, 0x22, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x64, 0x75, 0x70, 0x6c,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f,
+	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x10, 0x63, 0x6f,
+	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x12, 0x21,
+	0x0a, 0x0c, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x23,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x43, 0x6f, 0x64, 0x65,
+	0x73, 0x12, 0x33, 0x0a, 0x15, 0x68, 0x6f, 0x6d, 0x6f, 0x67, 0x6c, 0x79, 0x70, 0x68, 0x73, 0x5f,
+	0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x64, 0x18, 0x24, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x14, 0x68, 0x6f, 0x6d, 0x6f, 0x67, 0x6c, 0x79, 0x70, 0x68, 0x73, 0x4e, 0x6f, 0x72, 0x6d,
+	0x61, 0x6c, 0x69, 0x7a, 0x65, 0x64, 0x12, 0x38, 0x0a, 0x18, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73,
+	0x5f, 0x73, 0x77, 0x61, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x73, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74,
+	0x65, 0x64, 0x18, 0x25, 0x20, 0x01, 0x28, 0x08, 0x52, 0x16, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73,
+	0x53, 0x77, 0x61, 0x70, 0x70, 0x65, 0x64, 0x53, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64,
+	0x12, 0x36, 0x0a, 0x17, 0x73, 0x77, 0x61, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
+	0x5f, 0x73, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x18, 0x26, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x15, 0x73, 0x77, 0x61, 0x70, 0x70, 0x65, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x53, 0x69,
+	0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x12, 0x44, 0x0a, 0x1e, 0x73, 0x77, 0x61, 0x70,
+	0x70, 0x65, 0x64, 0x5f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x73, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x18, 0x27, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x1c, 0x73, 0x77, 0x61, 0x70, 0x70, 0x65, 0x64, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x22, 0xa4,
+	0x01, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x28, 0x0a, 0x01, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x64,
+	0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31,
+	0x2e, 0x50, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x52, 0x01, 0x61, 0x12, 0x28, 0x0a, 0x01, 0x62,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x64, 0x75,
+	0x63, 0x74, 0x52, 0x01, 0x62, 0x12, 0x3e, 0x0a, 0x07, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61,
+	0x72, 0x69, 0x73, 0x6f, 0x6e, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74, 0x73, 0x52, 0x07, 0x77, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x73, 0x22, 0x4e, 0x0a, 0x0f, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3b, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d,
+	0x70, 0x61, 0x72, 0x69, 0x73, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x06, 0x72,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x84, 0x01, 0x0a, 0x1b, 0x46, 0x69, 0x6e, 0x64, 0x44, 0x75,
+	0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x46, 0x6f, 0x72, 0x4f, 0x6e, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x34, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x64, 0x75,
+	0x63, 0x74, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x12, 0x21, 0x0a, 0x09, 0x74,
+	0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00,
+	0x52, 0x09, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x88, 0x01, 0x01, 0x42, 0x0c,
+	0x0a, 0x0a, 0x5f, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x22, 0x79, 0x0a, 0x1c,
+	0x46, 0x69, 0x6e, 0x64, 0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x46, 0x6f,
+	0x72, 0x4f, 0x6e, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3b, 0x0a, 0x06,
+	0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x64,
+	0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31,
+	0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x69, 0x73, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x72, 0x75,
+	0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x74, 0x72,
+	0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x22, 0x80, 0x01, 0x0a, 0x15, 0x46, 0x69, 0x6e, 0x64,
+	0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x36, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63,
+	0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x52,
+	0x08, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x73, 0x12, 0x21, 0x0a, 0x09, 0x74, 0x68, 0x72,
+	0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x09,
+	0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x88, 0x01, 0x01, 0x42, 0x0c, 0x0a, 0x0a,
+	0x5f, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x22, 0x55, 0x0a, 0x16, 0x46, 0x69,
+	0x6e, 0x64, 0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3b, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x69,
+	0x73, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x22, 0x16, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x53, 0x74, 0x61,
+	0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xa9, 0x02, 0x0a, 0x15, 0x47, 0x65,
+	0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a, 0x07, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x35, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x64, 0x65,
+	0x78, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x6e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x73, 0x12, 0x49, 0x0a, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x33, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63,
+	0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78,
+	0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x46, 0x6c,
+	0x61, 0x67, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x1a,
+	0x3a, 0x0a, 0x0c, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
+	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65,
+	0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x38, 0x0a, 0x0a, 0x46,
+	0x6c, 0x61, 0x67, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x3a, 0x02, 0x38, 0x01, 0x2a, 0xae, 0x01, 0x0a, 0x09, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x10, 0x4d, 0x41, 0x54, 0x43, 0x48, 0x5f, 0x54, 0x59, 0x50,
+	0x45, 0x5f, 0x46, 0x55, 0x5a, 0x5a, 0x59, 0x10, 0x00, 0x12, 0x1f, 0x0a, 0x1b, 0x4d, 0x41, 0x54,
+	0x43, 0x48, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c, 0x49, 0x5a,
+	0x45, 0x44, 0x5f, 0x45, 0x58, 0x41, 0x43, 0x54, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x4d, 0x41,
+	0x54, 0x43, 0x48, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x45, 0x58, 0x41, 0x43, 0x54, 0x10, 0x02,
+	0x12, 0x19, 0x0a, 0x15, 0x4d, 0x41, 0x54, 0x43, 0x48, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x49,
+	0x44, 0x45, 0x4e, 0x54, 0x49, 0x46, 0x49, 0x45, 0x52, 0x10, 0x03, 0x12, 0x16, 0x0a, 0x12, 0x4d,
+	0x41, 0x54, 0x43, 0x48, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x56, 0x41, 0x52, 0x49, 0x41, 0x4e,
+	0x54, 0x10, 0x04, 0x12, 0x21, 0x0a, 0x1d, 0x4d, 0x41, 0x54, 0x43, 0x48, 0x5f, 0x54, 0x59, 0x50,
+	0x45, 0x5f, 0x43, 0x4f, 0x50, 0x49, 0x45, 0x44, 0x5f, 0x44, 0x45, 0x53, 0x43, 0x52, 0x49, 0x50,
+	0x54, 0x49, 0x4f, 0x4e, 0x10, 0x05, 0x2a, 0x6f, 0x0a, 0x0e, 0x53, 0x69, 0x6d, 0x69, 0x6c, 0x61,
+	0x72, 0x69, 0x74, 0x79, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x1a, 0x0a, 0x16, 0x53, 0x49, 0x4d, 0x49,
+	0x4c, 0x41, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x4c, 0x49, 0x4e, 0x45,
+	0x41, 0x52, 0x10, 0x00, 0x12, 0x23, 0x0a, 0x1f, 0x53, 0x49, 0x4d, 0x49, 0x4c, 0x41, 0x52, 0x49,
+	0x54, 0x59, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x4c, 0x45, 0x4e, 0x47, 0x54, 0x48, 0x5f, 0x41,
+	0x44, 0x4a, 0x55, 0x53, 0x54, 0x45, 0x44, 0x10, 0x01, 0x12, 0x1c, 0x0a, 0x18, 0x53, 0x49, 0x4d,
+	0x49, 0x4c, 0x41, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x4c, 0x4f, 0x47,
+	0x49, 0x53, 0x54, 0x49, 0x43, 0x10, 0x02, 0x32, 0xb1, 0x03, 0x0a, 0x15, 0x44, 0x75, 0x70, 0x6c,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x12, 0x50, 0x0a, 0x07, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x12, 0x21, 0x2e, 0x64,
+	0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31,
+	0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x22, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b,
+	0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x79, 0x0a, 0x14, 0x46, 0x69, 0x6e, 0x64, 0x44, 0x75, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x73, 0x46, 0x6f, 0x72, 0x4f, 0x6e, 0x65, 0x12, 0x2e, 0x2e, 0x64, 0x75,
+	0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e,
+	0x46, 0x69, 0x6e, 0x64, 0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x46, 0x6f,
+	0x72, 0x4f, 0x6e, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2f, 0x2e, 0x64, 0x75,
+	0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e,
+	0x46, 0x69, 0x6e, 0x64, 0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x46, 0x6f,
+	0x72, 0x4f, 0x6e, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x67,
+	0x0a, 0x0e, 0x46, 0x69, 0x6e, 0x64, 0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73,
+	0x12, 0x28, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63,
+	0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x29, 0x2e, 0x64, 0x75, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x46,
+	0x69, 0x6e, 0x64, 0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x62, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x49, 0x6e,
+	0x64, 0x65, 0x78, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x27, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74,
+	0x49, 0x6e, 0x64, 0x65, 0x78, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x28, 0x2e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65,
+	0x63, 0x6b, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x4f, 0x5a, 0x4d, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x73, 0x6f, 0x6c, 0x72, 0x61, 0x63,
+	0x39, 0x37, 0x67, 0x72, 0x2f, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68,
+	0x65, 0x63, 0x6b, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63,
+	0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2f, 0x76, 0x31, 0x3b, 0x64, 0x75, 0x70, 0x6c,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_duplicatecheck_v1_duplicatecheck_proto_rawDescOnce sync.Once
+	file_duplicatecheck_v1_duplicatecheck_proto_rawDescData = file_duplicatecheck_v1_duplicatecheck_proto_rawDesc
+)
+
+func file_duplicatecheck_v1_duplicatecheck_proto_rawDescGZIP() []byte {
+	file_duplicatecheck_v1_duplicatecheck_proto_rawDescOnce.Do(func() {
+		file_duplicatecheck_v1_duplicatecheck_proto_rawDescData = protoimpl.X.CompressGZIP(file_duplicatecheck_v1_duplicatecheck_proto_rawDescData)
+	})
+	return file_duplicatecheck_v1_duplicatecheck_proto_rawDescData
+}
+
+var file_duplicatecheck_v1_duplicatecheck_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_duplicatecheck_v1_duplicatecheck_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_duplicatecheck_v1_duplicatecheck_proto_goTypes = []any{
+	(MatchType)(0),                       // 0: duplicatecheck.v1.MatchType
+	(SimilarityMode)(0),                  // 1: duplicatecheck.v1.SimilarityMode
+	(*Product)(nil),                      // 2: duplicatecheck.v1.Product
+	(*ComparisonWeights)(nil),            // 3: duplicatecheck.v1.ComparisonWeights
+	(*SegmentScore)(nil),                 // 4: duplicatecheck.v1.SegmentScore
+	(*ComponentMatch)(nil),               // 5: duplicatecheck.v1.ComponentMatch
+	(*ComparisonResult)(nil),             // 6: duplicatecheck.v1.ComparisonResult
+	(*CompareRequest)(nil),               // 7: duplicatecheck.v1.CompareRequest
+	(*CompareResponse)(nil),              // 8: duplicatecheck.v1.CompareResponse
+	(*FindDuplicatesForOneRequest)(nil),  // 9: duplicatecheck.v1.FindDuplicatesForOneRequest
+	(*FindDuplicatesForOneResponse)(nil), // 10: duplicatecheck.v1.FindDuplicatesForOneResponse
+	(*FindDuplicatesRequest)(nil),        // 11: duplicatecheck.v1.FindDuplicatesRequest
+	(*FindDuplicatesResponse)(nil),       // 12: duplicatecheck.v1.FindDuplicatesResponse
+	(*GetIndexStatsRequest)(nil),         // 13: duplicatecheck.v1.GetIndexStatsRequest
+	(*GetIndexStatsResponse)(nil),        // 14: duplicatecheck.v1.GetIndexStatsResponse
+	nil,                                  // 15: duplicatecheck.v1.GetIndexStatsResponse.NumbersEntry
+	nil,                                  // 16: duplicatecheck.v1.GetIndexStatsResponse.FlagsEntry
+}
+var file_duplicatecheck_v1_duplicatecheck_proto_depIdxs = []int32{
+	2,  // 0: duplicatecheck.v1.ComparisonResult.product_a:type_name -> duplicatecheck.v1.Product
+	2,  // 1: duplicatecheck.v1.ComparisonResult.product_b:type_name -> duplicatecheck.v1.Product
+	3,  // 2: duplicatecheck.v1.ComparisonResult.weights_used:type_name -> duplicatecheck.v1.ComparisonWeights
+	1,  // 3: duplicatecheck.v1.ComparisonResult.similarity_mode:type_name -> duplicatecheck.v1.SimilarityMode
+	0,  // 4: duplicatecheck.v1.ComparisonResult.match_type:type_name -> duplicatecheck.v1.MatchType
+	4,  // 5: duplicatecheck.v1.ComparisonResult.segment_similarities:type_name -> duplicatecheck.v1.SegmentScore
+	5,  // 6: duplicatecheck.v1.ComparisonResult.component_matches:type_name -> duplicatecheck.v1.ComponentMatch
+	2,  // 7: duplicatecheck.v1.CompareRequest.a:type_name -> duplicatecheck.v1.Product
+	2,  // 8: duplicatecheck.v1.CompareRequest.b:type_name -> duplicatecheck.v1.Product
+	3,  // 9: duplicatecheck.v1.CompareRequest.weights:type_name -> duplicatecheck.v1.ComparisonWeights
+	6,  // 10: duplicatecheck.v1.CompareResponse.result:type_name -> duplicatecheck.v1.ComparisonResult
+	2,  // 11: duplicatecheck.v1.FindDuplicatesForOneRequest.product:type_name -> duplicatecheck.v1.Product
+	6,  // 12: duplicatecheck.v1.FindDuplicatesForOneResponse.result:type_name -> duplicatecheck.v1.ComparisonResult
+	2,  // 13: duplicatecheck.v1.FindDuplicatesRequest.products:type_name -> duplicatecheck.v1.Product
+	6,  // 14: duplicatecheck.v1.FindDuplicatesResponse.result:type_name -> duplicatecheck.v1.ComparisonResult
+	15, // 15: duplicatecheck.v1.GetIndexStatsResponse.numbers:type_name -> duplicatecheck.v1.GetIndexStatsResponse.NumbersEntry
+	16, // 16: duplicatecheck.v1.GetIndexStatsResponse.flags:type_name -> duplicatecheck.v1.GetIndexStatsResponse.FlagsEntry
+	7,  // 17: duplicatecheck.v1.DuplicateCheckService.Compare:input_type -> duplicatecheck.v1.CompareRequest
+	9,  // 18: duplicatecheck.v1.DuplicateCheckService.FindDuplicatesForOne:input_type -> duplicatecheck.v1.FindDuplicatesForOneRequest
+	11, // 19: duplicatecheck.v1.DuplicateCheckService.FindDuplicates:input_type -> duplicatecheck.v1.FindDuplicatesRequest
+	13, // 20: duplicatecheck.v1.DuplicateCheckService.GetIndexStats:input_type -> duplicatecheck.v1.GetIndexStatsRequest
+	8,  // 21: duplicatecheck.v1.DuplicateCheckService.Compare:output_type -> duplicatecheck.v1.CompareResponse
+	10, // 22: duplicatecheck.v1.DuplicateCheckService.FindDuplicatesForOne:output_type -> duplicatecheck.v1.FindDuplicatesForOneResponse
+	12, // 23: duplicatecheck.v1.DuplicateCheckService.FindDuplicates:output_type -> duplicatecheck.v1.FindDuplicatesResponse
+	14, // 24: duplicatecheck.v1.DuplicateCheckService.GetIndexStats:output_type -> duplicatecheck.v1.GetIndexStatsResponse
+	21, // [21:25] is the sub-list for method output_type
+	17, // [17:21] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_duplicatecheck_v1_duplicatecheck_proto_init() }
+func file_duplicatecheck_v1_duplicatecheck_proto_init() {
+	if File_duplicatecheck_v1_duplicatecheck_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Product); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*ComparisonWeights); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*SegmentScore); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*ComponentMatch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*ComparisonResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*CompareRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*CompareResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*FindDuplicatesForOneRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*FindDuplicatesForOneResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*FindDuplicatesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*FindDuplicatesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*GetIndexStatsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*GetIndexStatsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[7].OneofWrappers = []any{}
+	file_duplicatecheck_v1_duplicatecheck_proto_msgTypes[9].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_duplicatecheck_v1_duplicatecheck_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_duplicatecheck_v1_duplicatecheck_proto_goTypes,
+		DependencyIndexes: file_duplicatecheck_v1_duplicatecheck_proto_depIdxs,
+		EnumInfos:         file_duplicatecheck_v1_duplicatecheck_proto_enumTypes,
+		MessageInfos:      file_duplicatecheck_v1_duplicatecheck_proto_msgTypes,
+	}.Build()
+	File_duplicatecheck_v1_duplicatecheck_proto = out.File
+	file_duplicatecheck_v1_duplicatecheck_proto_rawDesc = nil
+	file_duplicatecheck_v1_duplicatecheck_proto_goTypes = nil
+	file_duplicatecheck_v1_duplicatecheck_proto_depIdxs = nil
+}
@@ -0,0 +1,303 @@
+// Comparison service of the duplicatecheck library, for callers in other
+// languages. Messages mirror the library types of the same names; see the Go
+// package github.com/solrac97gr/duplicatecheck for the meaning of each field.
+//
+// Fields are only ever added: removed fields are reserved, never reused.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: duplicatecheck/v1/duplicatecheck.proto
+
+package duplicatecheckv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	DuplicateCheckService_Compare_FullMethodName              = "/duplicatecheck.v1.DuplicateCheckService/Compare"
+	DuplicateCheckService_FindDuplicatesForOne_FullMethodName = "/duplicatecheck.v1.DuplicateCheckService/FindDuplicatesForOne"
+	DuplicateCheckService_FindDuplicates_FullMethodName       = "/duplicatecheck.v1.DuplicateCheckService/FindDuplicates"
+	DuplicateCheckService_GetIndexStats_FullMethodName        = "/duplicatecheck.v1.DuplicateCheckService/GetIndexStats"
+)
+
+// DuplicateCheckServiceClient is the client API for DuplicateCheckService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// DuplicateCheckService compares products against a configured engine and
+// its index
+type DuplicateCheckServiceClient interface {
+	// Compare scores one pair of products
+	Compare(ctx context.Context, in *CompareRequest, opts ...grpc.CallOption) (*CompareResponse, error)
+	// FindDuplicatesForOne streams the indexed products matching one product
+	FindDuplicatesForOne(ctx context.Context, in *FindDuplicatesForOneRequest, opts ...grpc.CallOption) (DuplicateCheckService_FindDuplicatesForOneClient, error)
+	// FindDuplicates streams the duplicate pairs of products as the scan finds
+	// them: against the index when the engine has one, among the products
+	// otherwise
+	FindDuplicates(ctx context.Context, in *FindDuplicatesRequest, opts ...grpc.CallOption) (DuplicateCheckService_FindDuplicatesClient, error)
+	// GetIndexStats reports on the engine's index
+	GetIndexStats(ctx context.Context, in *GetIndexStatsRequest, opts ...grpc.CallOption) (*GetIndexStatsResponse, error)
+}
+
+type duplicateCheckServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDuplicateCheckServiceClient(cc grpc.ClientConnInterface) DuplicateCheckServiceClient {
+	return &duplicateCheckServiceClient{cc}
+}
+
+func (c *duplicateCheckServiceClient) Compare(ctx context.Context, in *CompareRequest, opts ...grpc.CallOption) (*CompareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompareResponse)
+	err := c.cc.Invoke(ctx, DuplicateCheckService_Compare_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *duplicateCheckServiceClient) FindDuplicatesForOne(ctx context.Context, in *FindDuplicatesForOneRequest, opts ...grpc.CallOption) (DuplicateCheckService_FindDuplicatesForOneClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DuplicateCheckService_ServiceDesc.Streams[0], DuplicateCheckService_FindDuplicatesForOne_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &duplicateCheckServiceFindDuplicatesForOneClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DuplicateCheckService_FindDuplicatesForOneClient interface {
+	Recv() (*FindDuplicatesForOneResponse, error)
+	grpc.ClientStream
+}
+
+type duplicateCheckServiceFindDuplicatesForOneClient struct {
+	grpc.ClientStream
+}
+
+func (x *duplicateCheckServiceFindDuplicatesForOneClient) Recv() (*FindDuplicatesForOneResponse, error) {
+	m := new(FindDuplicatesForOneResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *duplicateCheckServiceClient) FindDuplicates(ctx context.Context, in *FindDuplicatesRequest, opts ...grpc.CallOption) (DuplicateCheckService_FindDuplicatesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DuplicateCheckService_ServiceDesc.Streams[1], DuplicateCheckService_FindDuplicates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &duplicateCheckServiceFindDuplicatesClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DuplicateCheckService_FindDuplicatesClient interface {
+	Recv() (*FindDuplicatesResponse, error)
+	grpc.ClientStream
+}
+
+type duplicateCheckServiceFindDuplicatesClient struct {
+	grpc.ClientStream
+}
+
+func (x *duplicateCheckServiceFindDuplicatesClient) Recv() (*FindDuplicatesResponse, error) {
+	m := new(FindDuplicatesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *duplicateCheckServiceClient) GetIndexStats(ctx context.Context, in *GetIndexStatsRequest, opts ...grpc.CallOption) (*GetIndexStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetIndexStatsResponse)
+	err := c.cc.Invoke(ctx, DuplicateCheckService_GetIndexStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DuplicateCheckServiceServer is the server API for DuplicateCheckService service.
+// All implementations must embed UnimplementedDuplicateCheckServiceServer
+// for forward compatibility
+//
+// DuplicateCheckService compares products against a configured engine and
+// its index
+type DuplicateCheckServiceServer interface {
+	// Compare scores one pair of products
+	Compare(context.Context, *CompareRequest) (*CompareResponse, error)
+	// FindDuplicatesForOne streams the indexed products matching one product
+	FindDuplicatesForOne(*FindDuplicatesForOneRequest, DuplicateCheckService_FindDuplicatesForOneServer) error
+	// FindDuplicates streams the duplicate pairs of products as the scan finds
+	// them: against the index when the engine has one, among the products
+	// otherwise
+	FindDuplicates(*FindDuplicatesRequest, DuplicateCheckService_FindDuplicatesServer) error
+	// GetIndexStats reports on the engine's index
+	GetIndexStats(context.Context, *GetIndexStatsRequest) (*GetIndexStatsResponse, error)
+	mustEmbedUnimplementedDuplicateCheckServiceServer()
+}
+
+// UnimplementedDuplicateCheckServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedDuplicateCheckServiceServer struct {
+}
+
+func (UnimplementedDuplicateCheckServiceServer) Compare(context.Context, *CompareRequest) (*CompareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Compare not implemented")
+}
+func (UnimplementedDuplicateCheckServiceServer) FindDuplicatesForOne(*FindDuplicatesForOneRequest, DuplicateCheckService_FindDuplicatesForOneServer) error {
+	return status.Errorf(codes.Unimplemented, "method FindDuplicatesForOne not implemented")
+}
+func (UnimplementedDuplicateCheckServiceServer) FindDuplicates(*FindDuplicatesRequest, DuplicateCheckService_FindDuplicatesServer) error {
+	return status.Errorf(codes.Unimplemented, "method FindDuplicates not implemented")
+}
+func (UnimplementedDuplicateCheckServiceServer) GetIndexStats(context.Context, *GetIndexStatsRequest) (*GetIndexStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIndexStats not implemented")
+}
+func (UnimplementedDuplicateCheckServiceServer) mustEmbedUnimplementedDuplicateCheckServiceServer() {}
+
+// UnsafeDuplicateCheckServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DuplicateCheckServiceServer will
+// result in compilation errors.
+type UnsafeDuplicateCheckServiceServer interface {
+	mustEmbedUnimplementedDuplicateCheckServiceServer()
+}
+
+func RegisterDuplicateCheckServiceServer(s grpc.ServiceRegistrar, srv DuplicateCheckServiceServer) {
+	s.RegisterService(&DuplicateCheckService_ServiceDesc, srv)
+}
+
+func _DuplicateCheckService_Compare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DuplicateCheckServiceServer).Compare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DuplicateCheckService_Compare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DuplicateCheckServiceServer).Compare(ctx, req.(*CompareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DuplicateCheckService_FindDuplicatesForOne_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FindDuplicatesForOneRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DuplicateCheckServiceServer).FindDuplicatesForOne(m, &duplicateCheckServiceFindDuplicatesForOneServer{ServerStream: stream})
+}
+
+type DuplicateCheckService_FindDuplicatesForOneServer interface {
+	Send(*FindDuplicatesForOneResponse) error
+	grpc.ServerStream
+}
+
+type duplicateCheckServiceFindDuplicatesForOneServer struct {
+	grpc.ServerStream
+}
+
+func (x *duplicateCheckServiceFindDuplicatesForOneServer) Send(m *FindDuplicatesForOneResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DuplicateCheckService_FindDuplicates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FindDuplicatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DuplicateCheckServiceServer).FindDuplicates(m, &duplicateCheckServiceFindDuplicatesServer{ServerStream: stream})
+}
+
+type DuplicateCheckService_FindDuplicatesServer interface {
+	Send(*FindDuplicatesResponse) error
+	grpc.ServerStream
+}
+
+type duplicateCheckServiceFindDuplicatesServer struct {
+	grpc.ServerStream
+}
+
+func (x *duplicateCheckServiceFindDuplicatesServer) Send(m *FindDuplicatesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DuplicateCheckService_GetIndexStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIndexStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DuplicateCheckServiceServer).GetIndexStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DuplicateCheckService_GetIndexStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DuplicateCheckServiceServer).GetIndexStats(ctx, req.(*GetIndexStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DuplicateCheckService_ServiceDesc is the grpc.ServiceDesc for DuplicateCheckService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DuplicateCheckService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "duplicatecheck.v1.DuplicateCheckService",
+	HandlerType: (*DuplicateCheckServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Compare",
+			Handler:    _DuplicateCheckService_Compare_Handler,
+		},
+		{
+			MethodName: "GetIndexStats",
+			Handler:    _DuplicateCheckService_GetIndexStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FindDuplicatesForOne",
+			Handler:       _DuplicateCheckService_FindDuplicatesForOne_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "FindDuplicates",
+			Handler:       _DuplicateCheckService_FindDuplicates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "duplicatecheck/v1/duplicatecheck.proto",
+}
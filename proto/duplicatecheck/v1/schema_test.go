@@ -0,0 +1,147 @@
+package duplicatecheckv1
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	blockPattern  = regexp.MustCompile(`(?s)\b(message|enum|service) (\w+) \{(\}|.*?\n\})`)
+	fieldPattern  = regexp.MustCompile(`^(optional |repeated )?(map<\w+, \w+>|\w+) (\w+) = (\d+);`)
+	valuePattern  = regexp.MustCompile(`^(\w+) = (\d+);`)
+	methodPattern = regexp.MustCompile(`^rpc (\w+)\((\w+)\) returns \((stream )?(\w+)\);`)
+)
+
+// schemaLines returns the trimmed lines of a block body
+func schemaLines(body string) []string {
+	lines := strings.Split(body, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return lines
+}
+
+// fieldType returns the schema type of a generated field, as written in
+// duplicatecheck.proto
+func fieldType(field protoreflect.FieldDescriptor) string {
+	switch {
+	case field.IsMap():
+		return "map<" + fieldType(field.MapKey()) + ", " + fieldType(field.MapValue()) + ">"
+	case field.Kind() == protoreflect.MessageKind:
+		return string(field.Message().Name())
+	case field.Kind() == protoreflect.EnumKind:
+		return string(field.Enum().Name())
+	}
+	return field.Kind().String()
+}
+
+// fieldLabel returns the schema label of a generated field
+func fieldLabel(field protoreflect.FieldDescriptor) string {
+	switch {
+	case field.IsMap():
+		return ""
+	case field.IsList():
+		return "repeated "
+	case field.HasOptionalKeyword():
+		return "optional "
+	}
+	return ""
+}
+
+// TestBindingsMatchSchema fails when duplicatecheck.proto changed without the
+// bindings being regenerated
+func TestBindingsMatchSchema(t *testing.T) {
+	schema, err := os.ReadFile("duplicatecheck.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := File_duplicatecheck_v1_duplicatecheck_proto
+	messages, enums, services := 0, 0, 0
+	for _, block := range blockPattern.FindAllStringSubmatch(string(schema), -1) {
+		kind, name, body := block[1], protoreflect.Name(block[2]), block[3]
+		switch kind {
+		case "enum":
+			enums++
+			enum := file.Enums().ByName(name)
+			if enum == nil {
+				t.Errorf("enum %s isn't generated", name)
+				continue
+			}
+			values := 0
+			for _, line := range schemaLines(body) {
+				m := valuePattern.FindStringSubmatch(line)
+				if m == nil {
+					continue
+				}
+				values++
+				value := enum.Values().ByName(protoreflect.Name(m[1]))
+				if value == nil || strconv.Itoa(int(value.Number())) != m[2] {
+					t.Errorf("%s value %s = %s isn't generated", name, m[1], m[2])
+				}
+			}
+			if values != enum.Values().Len() {
+				t.Errorf("%s has %d values, generated %d", name, values, enum.Values().Len())
+			}
+
+		case "message":
+			messages++
+			message := file.Messages().ByName(name)
+			if message == nil {
+				t.Errorf("message %s isn't generated", name)
+				continue
+			}
+			fields := 0
+			for _, line := range schemaLines(body) {
+				m := fieldPattern.FindStringSubmatch(line)
+				if m == nil {
+					continue
+				}
+				fields++
+				field := message.Fields().ByName(protoreflect.Name(m[3]))
+				if field == nil {
+					t.Errorf("%s.%s isn't generated", name, m[3])
+					continue
+				}
+				if got := fieldLabel(field) + fieldType(field) + " = " + strconv.Itoa(int(field.Number())); got != m[1]+m[2]+" = "+m[4] {
+					t.Errorf("%s.%s is generated as %s, schema has %s%s = %s", name, m[3], got, m[1], m[2], m[4])
+				}
+			}
+			if fields != message.Fields().Len() {
+				t.Errorf("%s has %d fields, generated %d", name, fields, message.Fields().Len())
+			}
+
+		case "service":
+			services++
+			service := file.Services().ByName(name)
+			if service == nil {
+				t.Errorf("service %s isn't generated", name)
+				continue
+			}
+			methods := 0
+			for _, line := range schemaLines(body) {
+				m := methodPattern.FindStringSubmatch(line)
+				if m == nil {
+					continue
+				}
+				methods++
+				method := service.Methods().ByName(protoreflect.Name(m[1]))
+				if method == nil || string(method.Input().Name()) != m[2] || string(method.Output().Name()) != m[4] ||
+					method.IsStreamingServer() != (m[3] != "") || method.IsStreamingClient() {
+					t.Errorf("rpc %s isn't generated as %s", m[1], line)
+				}
+			}
+			if methods != service.Methods().Len() {
+				t.Errorf("%s has %d methods, generated %d", name, methods, service.Methods().Len())
+			}
+		}
+	}
+	if messages != file.Messages().Len() || enums != file.Enums().Len() || services != file.Services().Len() {
+		t.Errorf("schema has %d messages, %d enums and %d services; generated %d, %d and %d",
+			messages, enums, services, file.Messages().Len(), file.Enums().Len(), file.Services().Len())
+	}
+}
@@ -0,0 +1,241 @@
+package duplicatecheck
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Quality rules reported in QualityReport.Rules
+const (
+	QualityAlphanumericRatio = "alphanumeric-ratio" // Too few letters and digits
+	QualityTokenCount        = "token-count"        // Too few words
+	QualityRepeatedRun       = "repeated-run"       // One character repeated too many times in a row
+	QualityPlaceholder       = "placeholder"        // Placeholder or injected text ("test", "lorem ipsum", SQL)
+)
+
+// DefaultPlaceholders are the phrases QualityFilter treats as placeholder text
+// Matched as whole words against the normalized name and description.
+var DefaultPlaceholders = []string{
+	"test", "testing", "xxx", "asdf", "qwerty", "lorem ipsum", "placeholder",
+	"dummy", "n/a", "tbd", "todo",
+	"select * from", "drop table", "insert into", "union select", "or 1=1",
+}
+
+// QualityFilter recognizes garbage products (keyboard mashing, placeholders,
+// SQL fragments) that only produce noise in duplicate reports
+// Each rule is disabled by its zero value. A product is low quality when its
+// score falls below MinScore; every triggered rule halves the score.
+type QualityFilter struct {
+	// MinAlphanumericRatio is the minimum share of letters and digits among
+	// non-space characters of name and description
+	MinAlphanumericRatio float64
+	// MinTokens is the minimum number of words in name and description together
+	MinTokens int
+	// MaxRepeatedRun is the longest allowed run of one repeated character
+	MaxRepeatedRun int
+	// Placeholders are phrases that mark placeholder text (see DefaultPlaceholders)
+	Placeholders []string
+	// MinScore is the score below which a product is low quality
+	MinScore float64
+}
+
+// DefaultQualityFilter returns a filter with every rule on; one triggered rule marks a product
+func DefaultQualityFilter() *QualityFilter {
+	return &QualityFilter{
+		MinAlphanumericRatio: 0.6,
+		MinTokens:            2,
+		MaxRepeatedRun:       4,
+		Placeholders:         DefaultPlaceholders,
+		MinScore:             0.75,
+	}
+}
+
+// QualityReport is the assessment of one product
+type QualityReport struct {
+	Score float64  // 1.0 for clean text, halved by each triggered rule
+	Rules []string // Triggered rules (QualityAlphanumericRatio, ...)
+}
+
+// Assess scores a product's text against the filter's rules
+func (f *QualityFilter) Assess(p Product) QualityReport {
+	name, desc := p.getNormalizedStrings()
+	text := strings.TrimSpace(name + " " + desc)
+
+	report := QualityReport{Score: 1}
+	trigger := func(rule string) {
+		report.Rules = append(report.Rules, rule)
+		report.Score /= 2
+	}
+
+	if f.MinAlphanumericRatio > 0 && alphanumericRatio(text) < f.MinAlphanumericRatio {
+		trigger(QualityAlphanumericRatio)
+	}
+	words := strings.Fields(text)
+	if f.MinTokens > 0 && len(words) < f.MinTokens {
+		trigger(QualityTokenCount)
+	}
+	if f.MaxRepeatedRun > 0 && longestRun(text) > f.MaxRepeatedRun {
+		trigger(QualityRepeatedRun)
+	}
+	if containsPhrase(words, f.Placeholders) {
+		trigger(QualityPlaceholder)
+	}
+	return report
+}
+
+// IsLowQuality reports whether a product scores below MinScore
+func (f *QualityFilter) IsLowQuality(p Product) bool {
+	return f.Assess(p).Score < f.MinScore
+}
+
+// alphanumericRatio returns the share of letters and digits among non-space runes
+// Empty text has ratio 0.
+func alphanumericRatio(text string) float64 {
+	var total, alnum int
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		total++
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			alnum++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(alnum) / float64(total)
+}
+
+// longestRun returns the length of the longest run of one repeated non-space rune
+func longestRun(text string) int {
+	longest, run := 0, 0
+	var prev rune
+	for _, r := range text {
+		if r == prev && !unicode.IsSpace(r) {
+			run++
+		} else {
+			run = 1
+		}
+		prev = r
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+// containsPhrase reports whether any phrase occurs as consecutive whole words
+// Words are compared with surrounding punctuation trimmed.
+func containsPhrase(words []string, phrases []string) bool {
+	if len(phrases) == 0 {
+		return false
+	}
+	trimmed := make([]string, len(words))
+	for i, w := range words {
+		trimmed[i] = strings.TrimFunc(w, func(r rune) bool {
+			return unicode.IsPunct(r) && r != '*' && r != '/' && r != '='
+		})
+	}
+	for _, phrase := range phrases {
+		target := strings.Fields(strings.ToLower(phrase))
+		if len(target) == 0 {
+			continue
+		}
+	next:
+		for i := 0; i+len(target) <= len(trimmed); i++ {
+			for k, word := range target {
+				if trimmed[i+k] != word {
+					continue next
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// QualityMode selects what FindDuplicates does with low-quality products
+type QualityMode int
+
+const (
+	// QualityOff ignores product quality (default)
+	QualityOff QualityMode = iota
+	// QualityExclude leaves low-quality products out of FindDuplicates entirely
+	QualityExclude
+	// QualityFlag compares every product but sets LowQualityInput on results
+	// involving a low-quality product
+	QualityFlag
+)
+
+// WithQualityFilter makes FindDuplicates exclude or flag low-quality products
+// (see QualityMode); a nil filter or QualityOff turns it off. Compare is not
+// affected. Returns the engine for chaining.
+func (e *LevenshteinEngine) WithQualityFilter(filter *QualityFilter, mode QualityMode) *LevenshteinEngine {
+	e.quality, e.qualityMode = filter, mode
+	return e
+}
+
+// WithQualityFilter makes FindDuplicates exclude or flag low-quality products,
+// including indexed candidates (see LevenshteinEngine.WithQualityFilter)
+func (e *HybridEngine) WithQualityFilter(filter *QualityFilter, mode QualityMode) *HybridEngine {
+	e.levenshteinEngine.WithQualityFilter(filter, mode)
+	return e
+}
+
+// qualityCheck assesses the products of one scan, each at most once
+type qualityCheck struct {
+	filter *QualityFilter
+	mode   QualityMode
+	low    map[string]bool // Product ID -> low quality
+}
+
+// newQualityCheck returns the scan's quality check, or nil when quality is ignored
+func (e *LevenshteinEngine) newQualityCheck() *qualityCheck {
+	if e.quality == nil || e.qualityMode == QualityOff {
+		return nil
+	}
+	return &qualityCheck{filter: e.quality, mode: e.qualityMode, low: make(map[string]bool)}
+}
+
+// isLow reports (and remembers) whether a product is low quality
+func (q *qualityCheck) isLow(p *Product) bool {
+	low, seen := q.low[p.ID]
+	if !seen {
+		low = q.filter.IsLowQuality(*p)
+		q.low[p.ID] = low
+	}
+	return low
+}
+
+// products assesses every product and drops low-quality ones in QualityExclude mode
+// Assessing up front, from the products themselves, keeps flagging from
+// reading the redacted copies results may hold (see WithResultRedaction).
+func (q *qualityCheck) products(products []*Product) []*Product {
+	kept := make([]*Product, 0, len(products))
+	for _, p := range products {
+		if !q.isLow(p) || q.mode != QualityExclude {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// results drops (QualityExclude) or flags (QualityFlag) results involving a low-quality product
+func (q *qualityCheck) results(results []ComparisonResult) []ComparisonResult {
+	kept := results[:0]
+	for _, result := range results {
+		if !q.flag(&result) && q.mode == QualityExclude {
+			continue
+		}
+		kept = append(kept, result)
+	}
+	return kept
+}
+
+// flag sets LowQualityInput on a result and reports whether its products are all acceptable
+func (q *qualityCheck) flag(result *ComparisonResult) bool {
+	result.LowQualityInput = q.isLow(&result.ProductA) || q.isLow(&result.ProductB)
+	result.assignReasons()
+	return !result.LowQualityInput
+}
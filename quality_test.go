@@ -0,0 +1,173 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// junkProducts are garbage rows of the kind found in real catalog exports
+func junkProducts() []Product {
+	return []Product{
+		{ID: "J01", Name: "test", Description: "test"},
+		{ID: "J02", Name: "test1", Description: "test"},
+		{ID: "J03", Name: "asdfgh", Description: ""},
+		{ID: "J04", Name: "asdfgj", Description: ""},
+		{ID: "J05", Name: "xxx", Description: "xxx"},
+		{ID: "J06", Name: "Lorem ipsum", Description: "Lorem ipsum dolor sit amet"},
+		{ID: "J07", Name: "Lorem ipsum", Description: "Lorem ipsum dolor sit amet"},
+		{ID: "J08", Name: "aaaaaaaa", Description: "aaaaaaaaaa"},
+		{ID: "J09", Name: "'; DROP TABLE products; --", Description: "'; DROP TABLE products; --"},
+		{ID: "J10", Name: "#### ???? !!!!", Description: "$$$ %%% ###"},
+	}
+}
+
+// saltedCatalog interleaves the sample catalog with junk rows
+func saltedCatalog(t *testing.T) (products []Product, junk map[string]bool) {
+	t.Helper()
+	junk = make(map[string]bool)
+	real, garbage := loadSampleCatalog(t), junkProducts()
+	for i := range real {
+		products = append(products, real[i])
+		if i < len(garbage) {
+			junk[garbage[i].ID] = true
+			products = append(products, garbage[i])
+		}
+	}
+	return products, junk
+}
+
+func TestQualityFilterAssess(t *testing.T) {
+	filter := DefaultQualityFilter()
+	tests := []struct {
+		name    string
+		product Product
+		want    []string
+	}{
+		{"real product", Product{Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Industry-leading noise cancelling"}, nil},
+		{"placeholder word", Product{Name: "Test product", Description: "Do not ship"}, []string{QualityPlaceholder}},
+		{"placeholder inside a word", Product{Name: "Contest winner trophy", Description: "Gold plated"}, nil},
+		{"placeholder phrase", Product{Name: "Lorem ipsum", Description: "dolor sit amet"}, []string{QualityPlaceholder}},
+		{"single token", Product{Name: "asdfgh"}, []string{QualityTokenCount}},
+		{"repeated run", Product{Name: "Zzzzzzz pillow", Description: "Soft"}, []string{QualityRepeatedRun}},
+		{"symbols", Product{Name: "##### ????", Description: "!!!! $$$"}, []string{QualityAlphanumericRatio, QualityRepeatedRun}},
+		{"sql injection", Product{Name: "x'; DROP TABLE products; --", Description: ""}, []string{QualityPlaceholder}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := filter.Assess(tt.product)
+			if !reflect.DeepEqual(report.Rules, tt.want) {
+				t.Errorf("Rules = %v, want %v", report.Rules, tt.want)
+			}
+			wantScore := 1.0
+			for range tt.want {
+				wantScore /= 2
+			}
+			if report.Score != wantScore {
+				t.Errorf("Score = %v, want %v", report.Score, wantScore)
+			}
+		})
+	}
+
+	t.Run("disabled rules", func(t *testing.T) {
+		if report := (&QualityFilter{}).Assess(Product{Name: "xxx"}); len(report.Rules) != 0 || report.Score != 1 {
+			t.Errorf("zero filter triggered %v", report.Rules)
+		}
+	})
+
+	t.Run("salted catalog", func(t *testing.T) {
+		products, junk := saltedCatalog(t)
+		for _, p := range products {
+			if got := filter.IsLowQuality(p); got != junk[p.ID] {
+				t.Errorf("%s %q: low quality = %v, want %v (%v)", p.ID, p.Name, got, junk[p.ID], filter.Assess(p).Rules)
+			}
+		}
+	})
+}
+
+func TestQualityModes(t *testing.T) {
+	products, junk := saltedCatalog(t)
+	const threshold = 0.5
+
+	engines := []struct {
+		name string
+		new  func(mode QualityMode) DuplicateCheckEngine
+	}{
+		{"levenshtein", func(mode QualityMode) DuplicateCheckEngine {
+			return NewLevenshteinEngine().WithQualityFilter(DefaultQualityFilter(), mode)
+		}},
+		{"hybrid", func(mode QualityMode) DuplicateCheckEngine {
+			engine := NewHybridEngine().WithQualityFilter(DefaultQualityFilter(), mode)
+			if err := engine.BuildIndex(products); err != nil {
+				t.Fatal(err)
+			}
+			return engine
+		}},
+	}
+	for _, tt := range engines {
+		t.Run(tt.name, func(t *testing.T) {
+			off := comparableResults(tt.new(QualityOff).FindDuplicates(products, threshold))
+			realPairs, junkPairs := 0, 0
+			for _, r := range off {
+				if r.LowQualityInput {
+					t.Errorf("Off mode flagged %s ↔ %s", r.ProductA.ID, r.ProductB.ID)
+				}
+				if junk[r.ProductA.ID] && junk[r.ProductB.ID] {
+					junkPairs++
+				} else if !junk[r.ProductA.ID] && !junk[r.ProductB.ID] {
+					realPairs++
+				}
+			}
+			if junkPairs == 0 || realPairs == 0 {
+				t.Fatalf("salted scan needs junk and real pairs, got %d and %d", junkPairs, realPairs)
+			}
+
+			t.Run("exclude", func(t *testing.T) {
+				got := comparableResults(tt.new(QualityExclude).FindDuplicates(products, threshold))
+				var want []ComparisonResult
+				for _, r := range off {
+					if !junk[r.ProductA.ID] && !junk[r.ProductB.ID] {
+						want = append(want, r)
+					}
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Exclude kept %d results, want the %d real pairs", len(got), len(want))
+				}
+			})
+
+			t.Run("flag", func(t *testing.T) {
+				got := comparableResults(tt.new(QualityFlag).FindDuplicates(products, threshold))
+				if len(got) != len(off) {
+					t.Fatalf("Flag changed the result set: %d results, want %d", len(got), len(off))
+				}
+				for i, r := range got {
+					want := junk[r.ProductA.ID] || junk[r.ProductB.ID]
+					if r.LowQualityInput != want {
+						t.Errorf("%s ↔ %s: LowQualityInput = %v, want %v", r.ProductA.ID, r.ProductB.ID, r.LowQualityInput, want)
+					}
+					r.LowQualityInput = false
+					var codes []ReasonCode
+					for _, code := range r.ReasonCodes {
+						if code != ReasonLowQualityInput {
+							codes = append(codes, code)
+						}
+					}
+					r.ReasonCodes = codes
+					if !reflect.DeepEqual(r, off[i]) {
+						t.Errorf("%s ↔ %s differs from the Off scan beyond the flag", r.ProductA.ID, r.ProductB.ID)
+					}
+				}
+			})
+		})
+	}
+
+	t.Run("parallel scan", func(t *testing.T) {
+		large := append(bestEffortCatalog(120), junkProducts()...)
+		engine := NewLevenshteinEngine().WithQualityFilter(DefaultQualityFilter(), QualityExclude)
+		for _, r := range engine.FindDuplicates(large, threshold) {
+			if strings.HasPrefix(r.ProductA.ID, "J") || strings.HasPrefix(r.ProductB.ID, "J") {
+				t.Errorf("Exclude kept junk pair %s ↔ %s", r.ProductA.ID, r.ProductB.ID)
+			}
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package duplicatecheck
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// indexedBands is the band hashes a product was indexed under, kept so its
+// queries skip shingling, MinHash and band hashing while it is unchanged
+type indexedBands struct {
+	content uint64   // bandContent of the product when indexed
+	hashes  []uint64 // numBands per signature, as indexEntry.bandHashes
+}
+
+// bandContent identifies the fields a product's band hashes are computed from
+func bandContent(product *Product) uint64 {
+	return contentFingerprint("bands", product.Name, product.Description)
+}
+
+// queryBandHashes returns the band hashes of a query's signatures: the ones
+// the product was indexed under when the index holds its ID with the same
+// content, freshly computed otherwise
+// A query under an indexed ID whose content changed is counted and logged,
+// since it usually means the index wasn't updated (see UpdateProduct).
+func (e *HybridEngine) queryBandHashes(idx *LSHIndex, product *Product) []uint64 {
+	if stored, ok := idx.queryBands[product.ID]; ok {
+		if stored.content == bandContent(product) {
+			atomic.AddUint64(&e.bandReuses, 1)
+			return stored.hashes
+		}
+		atomic.AddUint64(&e.staleQueries, 1)
+		if e.logger != nil {
+			e.logger.LogAttrs(context.Background(), slog.LevelWarn, "indexed product changed",
+				slog.String("engine", "hybrid"),
+				slog.String("product_id", product.ID))
+		}
+	}
+	return e.bandHashes(e.querySignatures(product, e.indexText(product)))
+}
@@ -0,0 +1,158 @@
+package duplicatecheck
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+// lshConfig returns the default config with queries always through LSH
+func lshConfig() HybridConfig {
+	config := DefaultHybridConfig()
+	config.ExactModeCutoff = 0
+	return config
+}
+
+func TestQueryBandHashesReuse(t *testing.T) {
+	catalog := GenerateTestCatalog(7, 100)
+	chunked := lshConfig()
+	chunked.ChunkedSignatures = true
+	chunked.ChunkSize = 40
+	chunked.ChunkOverlap = 10
+
+	configs := map[string]HybridConfig{"default": lshConfig(), "chunked": chunked}
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			engine := NewHybridEngineWithConfig(config)
+			if err := engine.BuildIndex(catalog); err != nil {
+				t.Fatal(err)
+			}
+			idx := engine.currentIndex()
+			for i := range catalog {
+				p := &catalog[i]
+				fresh := engine.bandHashes(engine.querySignatures(p, engine.indexText(p)))
+				if got := engine.queryBandHashes(idx, p); !reflect.DeepEqual(got, fresh) {
+					t.Fatalf("%s: stored band hashes differ from fresh ones", p.ID)
+				}
+			}
+			if got := engine.GetIndexStats()["band_hash_reuses"]; got != uint64(len(catalog)) {
+				t.Errorf("band_hash_reuses = %v, want %d", got, len(catalog))
+			}
+
+			// A scan of the indexed corpus finds what it finds computing every
+			// query's hashes
+			fresh := NewHybridEngineWithConfig(config)
+			if err := fresh.BuildIndex(catalog); err != nil {
+				t.Fatal(err)
+			}
+			fresh.currentIndex().queryBands = nil
+			if got, want := resultKeys(engine.FindDuplicates(catalog, 0.8)), resultKeys(fresh.FindDuplicates(catalog, 0.8)); !reflect.DeepEqual(got, want) {
+				t.Errorf("reused hashes found %d pairs, fresh ones %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestQueryBandHashesStaleContent(t *testing.T) {
+	catalog := []Product{
+		{ID: "1", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Industry leading noise cancelling headphones"},
+		{ID: "2", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Industry leading noise cancelling headphones"},
+		{ID: "3", Name: "Kindle Paperwhite Signature Edition", Description: "Waterproof e-reader with adjustable warm light"},
+	}
+	handler := &captureHandler{}
+	engine := NewHybridEngineWithConfig(lshConfig()).WithLogger(slog.New(handler))
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+
+	// ID 3 now holds the headphones: the stored hashes of the e-reader would
+	// find nothing
+	changed := Product{ID: "3", Name: catalog[0].Name, Description: catalog[0].Description}
+	found := make(map[string]bool)
+	for _, r := range engine.FindDuplicatesForOne(changed, 0.8) {
+		found[r.ProductB.ID] = true
+	}
+	if !found["1"] || !found["2"] {
+		t.Errorf("changed product matched %v, want 1 and 2", found)
+	}
+	stats := engine.GetIndexStats()
+	if stats["stale_product_queries"] != uint64(1) || stats["band_hash_reuses"] != uint64(0) {
+		t.Errorf("stale_product_queries %v, band_hash_reuses %v", stats["stale_product_queries"], stats["band_hash_reuses"])
+	}
+	record, ok := handler.find("indexed product changed")
+	if !ok || record.attrs["product_id"].String() != "3" || record.level != slog.LevelWarn {
+		t.Errorf("stale query logged %+v (%v)", record, ok)
+	}
+
+	// The unchanged product reuses its hashes
+	engine.FindDuplicatesForOne(catalog[0], 0.8)
+	if got := engine.GetIndexStats()["band_hash_reuses"]; got != uint64(1) {
+		t.Errorf("band_hash_reuses = %v, want 1", got)
+	}
+}
+
+func TestQueryBandHashesIndexUpdates(t *testing.T) {
+	catalog := GenerateTestCatalog(3, 50)
+	engine := NewHybridEngineWithConfig(lshConfig())
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	removed := catalog[0].ID
+	if err := engine.RemoveProduct(removed); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := engine.currentIndex().queryBands[removed]; ok {
+		t.Error("removed product's band hashes kept")
+	}
+	if _, err := engine.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	idx := engine.currentIndex()
+	if len(idx.queryBands) != len(catalog)-1 {
+		t.Errorf("%d band hash entries after Compact, want %d", len(idx.queryBands), len(catalog)-1)
+	}
+	added := Product{ID: "new", Name: "Brand New Product", Description: "Added after the build"}
+	if err := engine.AddProduct(added); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := engine.currentIndex().queryBands["new"]; !ok {
+		t.Error("added product's band hashes not kept")
+	}
+
+	private := NewHybridEngineWithConfig(lshConfig())
+	private.EnablePrivacyMode(PrivacyOptions{Salt: []byte("salt")})
+	if err := private.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	if private.currentIndex().queryBands != nil {
+		t.Error("privacy mode kept band hashes by product ID")
+	}
+}
+
+// BenchmarkHybridCandidateGeneration queries the products of a 10k-product
+// index, under their indexed IDs (stored band hashes) and under new IDs
+// (signatures computed per query)
+func BenchmarkHybridCandidateGeneration(b *testing.B) {
+	catalog := GenerateTestCatalog(1, 10000)
+	engine := NewHybridEngineWithConfig(lshConfig())
+	if err := engine.BuildIndex(catalog); err != nil {
+		b.Fatal(err)
+	}
+	idx := engine.currentIndex()
+	unindexed := make([]Product, len(catalog))
+	for i, p := range catalog {
+		unindexed[i] = Product{ID: "q-" + p.ID, Name: p.Name, Description: p.Description}
+	}
+
+	for _, bench := range []struct {
+		name    string
+		queries []Product
+	}{{"indexed", catalog}, {"unindexed", unindexed}} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				engine.findCandidates(idx, &bench.queries[i%len(bench.queries)], 0.8)
+			}
+		})
+	}
+}
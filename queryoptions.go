@@ -0,0 +1,92 @@
+package duplicatecheck
+
+// QueryOptions controls which candidates a one-vs-many query compares the
+// query product with (see FindDuplicatesForOneWithOptions, HasDuplicateWithOptions
+// and CompareOneToMany)
+// The zero value, used by FindDuplicatesForOne and HasDuplicate, skips
+// candidates with the query's ID, so checking an updated listing against a
+// catalog still holding its old version doesn't match it with itself.
+type QueryOptions struct {
+	// IncludeSelf compares candidates whose ID equals the query's too
+	IncludeSelf bool
+	// ExcludeIDs are candidates never compared with the query, such as the
+	// other members of its variant group
+	ExcludeIDs []string
+}
+
+// QuerySummary reports what one query did
+type QuerySummary struct {
+	Candidates      int // Candidates the query considered, before the skips below
+	SelfSkipped     int // Candidates skipped for sharing the query's ID
+	ExcludedSkipped int // Candidates skipped as listed in QueryOptions.ExcludeIDs
+	Duplicates      int // Results returned
+}
+
+// queryExclusion decides, before any comparison, which candidates a query skips
+type queryExclusion struct {
+	selfID      string          // The query's ID, skipped unless includeSelf
+	includeSelf bool            // QueryOptions.IncludeSelf
+	excluded    map[string]bool // QueryOptions.ExcludeIDs
+}
+
+// newQueryExclusion returns the exclusion opts configures for a query of product
+func newQueryExclusion(product *Product, opts QueryOptions) queryExclusion {
+	exclusion := queryExclusion{selfID: product.ID, includeSelf: opts.IncludeSelf}
+	if len(opts.ExcludeIDs) > 0 {
+		exclusion.excluded = make(map[string]bool, len(opts.ExcludeIDs))
+		for _, id := range opts.ExcludeIDs {
+			exclusion.excluded[id] = true
+		}
+	}
+	return exclusion
+}
+
+// skips counts candidateID into summary and reports whether the query skips it
+func (q queryExclusion) skips(candidateID string, summary *QuerySummary) bool {
+	summary.Candidates++
+	if candidateID == q.selfID && !q.includeSelf {
+		summary.SelfSkipped++
+		return true
+	}
+	if q.excluded[candidateID] {
+		summary.ExcludedSkipped++
+		return true
+	}
+	return false
+}
+
+// CompareOneToMany compares product with each of candidates, returning the
+// matches at or above threshold in candidate order, and what the query skipped
+// Candidates opts excludes, by default those with product's ID, are skipped
+// before any comparison; the pair constraint and suppressions apply as in
+// FindDuplicates.
+func (e *LevenshteinEngine) CompareOneToMany(product Product, candidates []Product, threshold float64, opts QueryOptions) ([]ComparisonResult, QuerySummary) {
+	defer e.guard("CompareOneToMany", nil)
+	exclusion := newQueryExclusion(&product, opts)
+	var summary QuerySummary
+	matches := []ComparisonResult{}
+	for i := range candidates {
+		candidate := &candidates[i]
+		if exclusion.skips(candidate.ID, &summary) || !e.pairAllowed(&product, candidate) {
+			continue
+		}
+		weights := e.resolveWeights(&product, candidate)
+		if e.charsetRejects(&product, candidate, weights, threshold) && e.idRelation(product.ID, candidate.ID) != IDSameEntity {
+			continue
+		}
+		result := e.compareWithWeights(&product, candidate, weights, memoPair{}, threshold)
+		result.stampThreshold(threshold)
+		if result.MeetsThreshold {
+			matches = append(matches, result)
+		}
+	}
+	summary.Duplicates = len(matches)
+	return matches, summary
+}
+
+// CompareOneToMany compares product with each of candidates without the index
+// (see LevenshteinEngine.CompareOneToMany)
+func (e *HybridEngine) CompareOneToMany(product Product, candidates []Product, threshold float64, opts QueryOptions) ([]ComparisonResult, QuerySummary) {
+	defer e.levenshteinEngine.guard("CompareOneToMany", nil)
+	return e.levenshteinEngine.CompareOneToMany(product, candidates, threshold, opts)
+}
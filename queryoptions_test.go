@@ -0,0 +1,122 @@
+package duplicatecheck
+
+import (
+	"testing"
+)
+
+// queryCatalog returns a catalog holding a listing's old version (ID "L"), a
+// duplicate of it from another seller and another member of its variant
+// group, among filler
+func queryCatalog() []Product {
+	return append([]Product{
+		{ID: "L", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Noise cancelling over-ear headphones, black"},
+		{ID: "dup", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Noise cancelling over-ear headphones, black."},
+		{ID: "variant", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Noise cancelling over-ear headphones - black"},
+	}, GenerateTestCatalog(5, 100)...)
+}
+
+// updatedListing is the listing "L" after an edit, still under its ID
+var updatedListing = Product{ID: "L", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Noise cancelling over-ear headphones, black (2024)"}
+
+// resultIDs returns the ProductB IDs of results as a set
+func resultIDs(results []ComparisonResult) map[string]bool {
+	ids := make(map[string]bool, len(results))
+	for _, r := range results {
+		ids[r.ProductB.ID] = true
+	}
+	return ids
+}
+
+func TestFindDuplicatesForOneSkipsSelf(t *testing.T) {
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(queryCatalog()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		opts         QueryOptions
+		want         []string // Among the results
+		notWant      []string // Not among the results
+		self, others int      // Wanted summary skip counts
+	}{
+		{"default", QueryOptions{}, []string{"dup", "variant"}, []string{"L"}, 1, 0},
+		{"include self", QueryOptions{IncludeSelf: true}, []string{"L", "dup", "variant"}, nil, 0, 0},
+		{"exclude IDs", QueryOptions{ExcludeIDs: []string{"variant", "absent"}}, []string{"dup"}, []string{"L", "variant"}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifiedBefore := engine.GetIndexStats()["verified_pairs"].(uint64)
+			results, summary, err := engine.FindDuplicatesForOneWithOptions(updatedListing, 0.8, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := resultIDs(results)
+			for _, id := range tt.want {
+				if !ids[id] {
+					t.Errorf("%s missing from %v", id, ids)
+				}
+			}
+			for _, id := range tt.notWant {
+				if ids[id] {
+					t.Errorf("%s matched: %v", id, ids)
+				}
+			}
+			if summary.SelfSkipped != tt.self || summary.ExcludedSkipped != tt.others || summary.Duplicates != len(results) {
+				t.Errorf("summary %+v, want %d self and %d excluded skipped", summary, tt.self, tt.others)
+			}
+			verified := int(engine.GetIndexStats()["verified_pairs"].(uint64) - verifiedBefore)
+			if want := summary.Candidates - summary.SelfSkipped - summary.ExcludedSkipped; verified != want {
+				t.Errorf("%d candidates verified, want %d: skipped candidates must not be compared", verified, want)
+			}
+		})
+	}
+
+	if ids := resultIDs(engine.FindDuplicatesForOne(updatedListing, 0.8)); ids["L"] || !ids["dup"] {
+		t.Errorf("FindDuplicatesForOne matched %v, want dup without the listing itself", ids)
+	}
+}
+
+func TestHasDuplicateSkipsSelf(t *testing.T) {
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(queryCatalog()); err != nil {
+		t.Fatal(err)
+	}
+	// The listing's old version is its strongest candidate; HasDuplicate must
+	// look past it
+	found, result := engine.HasDuplicate(updatedListing, 0.8)
+	if !found || result.ProductB.ID == "L" {
+		t.Errorf("HasDuplicate = %v, %s; want a match other than the listing itself", found, result.ProductB.ID)
+	}
+	if found, result := engine.HasDuplicateWithOptions(updatedListing, 0.95, QueryOptions{IncludeSelf: true}); !found || result.ProductB.ID != "L" {
+		t.Errorf("IncludeSelf at 0.95: %v, %s; want the listing's old version", found, result.ProductB.ID)
+	}
+	if found, result := engine.HasDuplicateWithOptions(updatedListing, 0.8, QueryOptions{ExcludeIDs: []string{"dup", "variant"}}); found {
+		t.Errorf("every other match excluded, yet HasDuplicate found %s", result.ProductB.ID)
+	}
+}
+
+func TestCompareOneToMany(t *testing.T) {
+	var compared []string
+	engine := NewLevenshteinEngine().WithPairConstraint(func(a, b Product) bool {
+		compared = append(compared, b.ID)
+		return true
+	})
+	candidates := queryCatalog()[:3]
+
+	results, summary := engine.CompareOneToMany(updatedListing, candidates, 0.8, QueryOptions{ExcludeIDs: []string{"variant"}})
+	if len(results) != 1 || results[0].ProductB.ID != "dup" {
+		t.Errorf("results %v, want dup only", resultIDs(results))
+	}
+	if summary != (QuerySummary{Candidates: 3, SelfSkipped: 1, ExcludedSkipped: 1, Duplicates: 1}) {
+		t.Errorf("summary %+v", summary)
+	}
+	if len(compared) != 1 || compared[0] != "dup" {
+		t.Errorf("compared %v, want dup only", compared)
+	}
+
+	results, _ = engine.CompareOneToMany(updatedListing, candidates, 0.8, QueryOptions{IncludeSelf: true})
+	if ids := resultIDs(results); !ids["L"] || !ids["dup"] || !ids["variant"] {
+		t.Errorf("IncludeSelf matched %v, want all three", ids)
+	}
+}
@@ -162,16 +162,7 @@ func (rkf *RabinKarpFilter) estimateSimilarity(s, t string) float64 {
 		return lengthSimilarity
 	}
 
-	// Count matching hashes
-	matches := 0
-	for _, hS := range hashesS {
-		for _, hT := range hashesT {
-			if hS == hT {
-				matches++
-				break
-			}
-		}
-	}
+	matches := countMatchingHashes(hashesS, hashesT)
 
 	// Estimate based on hash overlap
 	if matches == 0 {
@@ -196,6 +187,30 @@ func (rkf *RabinKarpFilter) estimateSimilarity(s, t string) float64 {
 	return estimated
 }
 
+// countMatchingHashes returns the size of the multiset intersection of two
+// hash lists: each window hash matches at most one equal hash on the other side,
+// so a hash repeated 3 times in a and twice in b counts 2, and the count is the
+// same whichever list comes first
+// Runs in O(len(a)+len(b)): the shorter list is counted into a map and the
+// longer one probed against it.
+func countMatchingHashes(a, b []uint64) int {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	remaining := make(map[uint64]int, len(a))
+	for _, h := range a {
+		remaining[h]++
+	}
+	matches := 0
+	for _, h := range b {
+		if remaining[h] > 0 {
+			remaining[h]--
+			matches++
+		}
+	}
+	return matches
+}
+
 // estimateSimilarityByCharacters estimates similarity by counting character overlap
 // Used for short strings where rolling hash is less effective
 func (rkf *RabinKarpFilter) estimateSimilarityByCharacters(s, t string) float64 {
@@ -231,13 +246,24 @@ func (rkf *RabinKarpFilter) estimateSimilarityByCharacters(s, t string) float64
 	return float64(2*matches) / float64(totalChars)
 }
 
+// Name returns the filter name ("rabin-karp")
+func (rkf *RabinKarpFilter) Name() string {
+	return "rabin-karp"
+}
+
 // QuickReject determines if strings should be rejected before expensive Levenshtein
 // Returns true if strings are likely similar (should continue to Levenshtein)
 // Returns false if strings are definitely dissimilar (can safely skip Levenshtein)
+// Alias of MightMatch
+func (rkf *RabinKarpFilter) QuickReject(s, t string, threshold float64) bool {
+	return rkf.MightMatch(s, t, threshold)
+}
+
+// MightMatch reports whether two strings may reach threshold (see PreFilter)
 //
 // Uses conservative estimation with safety margin to avoid false negatives
 // (we'd rather do extra Levenshtein than miss a true match)
-func (rkf *RabinKarpFilter) QuickReject(s, t string, threshold float64) bool {
+func (rkf *RabinKarpFilter) MightMatch(s, t string, threshold float64) bool {
 	if !rkf.enabled {
 		return true // If disabled, always continue to Levenshtein
 	}
@@ -262,9 +288,15 @@ func (rkf *RabinKarpFilter) QuickReject(s, t string, threshold float64) bool {
 	// Estimate similarity using rolling hash
 	estimated := rkf.estimateSimilarity(s, t)
 
-	// Conservative: use safety margin of 0.2 to avoid false negatives
-	// If estimated < (threshold - 0.2), we can confidently reject
-	return estimated >= (threshold - 0.25)
+	// Conservative: use safety margin of 0.25 to avoid false negatives
+	if estimated >= (threshold - 0.25) {
+		return true
+	}
+
+	// Each edit can break up to windowSize rolling windows, so a few scattered
+	// typos can sink the estimate of a pair that is well above threshold.
+	// Only reject when the character counts confirm it can't get there.
+	return meetsThreshold(levenshteinSimilarityBound(s, t), threshold)
 }
 
 // GetWindowSize returns the current window size for hashing
@@ -0,0 +1,168 @@
+package duplicatecheck
+
+// ReasonCode says why a pair was flagged or scored as it was (see
+// ComparisonResult.ReasonCodes)
+// Codes are stable strings: existing codes are never renamed or reused, so
+// automation can branch on them as new features add codes.
+type ReasonCode string
+
+// Reason codes, in the order ComparisonResult.ReasonCodes lists them
+const (
+	// How the pair matched
+	ReasonExactMatch         ReasonCode = "exact_match"
+	ReasonNormalizedExact    ReasonCode = "normalized_exact"
+	ReasonIdentifierMatch    ReasonCode = "identifier_match"
+	ReasonCopiedDescription  ReasonCode = "copied_description"
+	ReasonVariant            ReasonCode = "variant"
+	ReasonCombinedSimilarity ReasonCode = "combined_similarity"
+
+	// What carried the score
+	ReasonHighNameSimilarity        ReasonCode = "high_name_similarity"
+	ReasonHighDescriptionSimilarity ReasonCode = "high_description_similarity"
+	ReasonNameInDescription         ReasonCode = "name_in_description"
+	ReasonBundleMatch               ReasonCode = "bundle_match"
+	ReasonSKUName                   ReasonCode = "sku_name"
+	ReasonEstimatedSimilarity       ReasonCode = "estimated_similarity"
+	ReasonExternalScore             ReasonCode = "external_score"
+	ReasonClusterInferred           ReasonCode = "cluster_inferred"
+
+	// What qualifies the score
+	ReasonSKUNameMixed           ReasonCode = "sku_name_mixed"
+	ReasonLowInfoNames           ReasonCode = "low_info_names"
+	ReasonObfuscationSuspected   ReasonCode = "obfuscation_suspected"
+	ReasonCrossLanguage          ReasonCode = "cross_language"
+	ReasonSameSource             ReasonCode = "same_source"
+	ReasonAmbiguousProduct       ReasonCode = "ambiguous_product"
+	ReasonLowQualityInput        ReasonCode = "low_quality_input"
+	ReasonDescriptionTimedOut    ReasonCode = "description_timed_out"
+	ReasonDescriptionLoadFailed  ReasonCode = "description_load_failed"
+	ReasonDescriptionSkipped     ReasonCode = "description_skipped"
+	ReasonVariantVetoed          ReasonCode = "variant_vetoed"
+	ReasonHomoglyphs             ReasonCode = "homoglyphs_normalized"
+	ReasonFieldsSwappedSuspected ReasonCode = "fields_swapped_suspected"
+)
+
+// ReasonInfo documents a reason code
+type ReasonInfo struct {
+	Code        ReasonCode `json:"code"`
+	Description string     `json:"description"`
+	// applies reports whether the code applies to a result; nil for codes
+	// only the comparison knows, which it records itself
+	applies func(r *ComparisonResult) bool
+}
+
+// reasonRegistry lists every reason code, in ReasonCodes order
+var reasonRegistry = []ReasonInfo{
+	{ReasonExactMatch, "Names and descriptions are byte-identical",
+		func(r *ComparisonResult) bool { return r.MatchType == MatchExact }},
+	{ReasonNormalizedExact, "Names and descriptions are equal after normalization (see DifferenceKinds)",
+		func(r *ComparisonResult) bool { return r.MatchType == MatchNormalizedExact }},
+	{ReasonIdentifierMatch, "The IDs identify the same entity; no text was compared",
+		func(r *ComparisonResult) bool { return r.MatchType == MatchIdentifier }},
+	{ReasonCopiedDescription, "The description was copied under a different name",
+		func(r *ComparisonResult) bool { return r.MatchType == MatchCopiedDescription }},
+	{ReasonVariant, "The names are variants of one product family",
+		func(r *ComparisonResult) bool { return r.MatchType == MatchVariant }},
+	{ReasonCombinedSimilarity, "The weighted name and description similarity met the threshold",
+		func(r *ComparisonResult) bool {
+			return r.MeetsThreshold && (r.MatchType == MatchFuzzy || r.MatchType == MatchVariant)
+		}},
+	{ReasonHighNameSimilarity, "The names alone are similar enough to meet the threshold",
+		func(r *ComparisonResult) bool {
+			return r.textScored() && r.NameSimilarity > 0 && meetsThreshold(r.NameSimilarity, r.ThresholdUsed)
+		}},
+	{ReasonHighDescriptionSimilarity, "The descriptions alone are similar enough to meet the threshold",
+		func(r *ComparisonResult) bool {
+			return r.textScored() && r.DescriptionSimilarity > 0 && meetsThreshold(r.DescriptionSimilarity, r.ThresholdUsed) &&
+				(r.ProductA.Description != "" || r.ProductB.Description != "")
+		}},
+	{ReasonNameInDescription, "One product's name appears in the other's description",
+		func(r *ComparisonResult) bool {
+			found := max(r.NameInDescriptionAB, r.NameInDescriptionBA)
+			return found > 0 && meetsThreshold(found, r.ThresholdUsed)
+		}},
+	{ReasonBundleMatch, "Both names are bundles and their items align",
+		func(r *ComparisonResult) bool {
+			return r.BundleSimilarity > 0 && r.BundleSimilarity >= r.NameSimilarity
+		}},
+	{ReasonSKUName, "Both names are SKU-like and were compared by SKU key",
+		func(r *ComparisonResult) bool { return r.SKUNameComparison }},
+	{ReasonEstimatedSimilarity, "The score is a fingerprint estimate, not an edit distance",
+		func(r *ComparisonResult) bool { return r.Stage == StageEstimated || r.Stage == StageEstimatedReject }},
+	{ReasonExternalScore, "The score came from the privacy mode verifier",
+		func(r *ComparisonResult) bool { return r.Stage == StageExternal }},
+	{ReasonClusterInferred, "The pair was verified as a clique's representative pair",
+		func(r *ComparisonResult) bool { return r.ClusterInferred }},
+	{ReasonSKUNameMixed, "Only one name is SKU-like",
+		func(r *ComparisonResult) bool { return r.SKUNameMixed }},
+	{ReasonLowInfoNames, "Both names carry no product information; the descriptions decided",
+		func(r *ComparisonResult) bool { return r.LowInfoNames }},
+	{ReasonObfuscationSuspected, "The names match better with look-alike characters replaced",
+		func(r *ComparisonResult) bool { return r.ObfuscationSuspected }},
+	{ReasonCrossLanguage, "The descriptions are in different languages",
+		func(r *ComparisonResult) bool { return r.CrossLanguage }},
+	{ReasonSameSource, "The IDs name the same source",
+		func(r *ComparisonResult) bool { return r.SameSourceIDs }},
+	{ReasonAmbiguousProduct, "A product is ambiguous; the score carries its penalty",
+		func(r *ComparisonResult) bool { return r.AmbiguousProduct }},
+	{ReasonLowQualityInput, "A product failed the quality filter",
+		func(r *ComparisonResult) bool { return r.LowQualityInput }},
+	{ReasonDescriptionTimedOut, "The description comparison timed out and scored 0",
+		func(r *ComparisonResult) bool { return r.DescriptionTimedOut }},
+	{ReasonDescriptionLoadFailed, "A description failed to load; the pair was scored without it",
+		func(r *ComparisonResult) bool { return r.DescriptionLoadFailed }},
+	{ReasonDescriptionSkipped, "The names ruled out a match, so the descriptions weren't compared and scored 0", nil},
+	{ReasonVariantVetoed, "The products' specs conflict outside the variant axes; the pair scored 0", nil},
+	{ReasonHomoglyphs, "Look-alike Cyrillic, Greek or fullwidth characters were replaced with Latin ones",
+		func(r *ComparisonResult) bool { return r.HomoglyphsNormalized }},
+	{ReasonFieldsSwappedSuspected, "One product's name and description look swapped; they matched compared crossed",
+		func(r *ComparisonResult) bool { return r.FieldsSwappedSuspected }},
+}
+
+// ReasonRegistry returns every reason code with its description, in the
+// order ComparisonResult.ReasonCodes lists them
+// Report generators and the CLI render explanations from it.
+func ReasonRegistry() []ReasonInfo {
+	return append([]ReasonInfo(nil), reasonRegistry...)
+}
+
+// Description returns the code's human-readable explanation, empty for an
+// unknown code
+func (c ReasonCode) Description() string {
+	for _, info := range reasonRegistry {
+		if info.Code == c {
+			return info.Description
+		}
+	}
+	return ""
+}
+
+// HasReason reports whether code is among the result's ReasonCodes
+func (r *ComparisonResult) HasReason(code ReasonCode) bool {
+	for _, c := range r.ReasonCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// textScored reports whether the result's similarities come from comparing
+// the products' text
+func (r *ComparisonResult) textScored() bool {
+	return r.MatchType != MatchIdentifier && r.Stage == ""
+}
+
+// assignReasons sets ReasonCodes from the result's other fields, keeping the
+// codes the comparison recorded
+// Every code path calls it once a result's fields are final: stampThreshold
+// and finishResult do for most.
+func (r *ComparisonResult) assignReasons() {
+	var codes []ReasonCode
+	for _, info := range reasonRegistry {
+		if info.applies != nil && info.applies(r) || info.applies == nil && r.HasReason(info.Code) {
+			codes = append(codes, info.Code)
+		}
+	}
+	r.ReasonCodes = codes
+}
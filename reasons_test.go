@@ -0,0 +1,255 @@
+package duplicatecheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// reasonScenarios returns, for each reason code, results of a fixture
+// scenario that should carry it
+func reasonScenarios(t *testing.T) map[ReasonCode]func() []ComparisonResult {
+	one := func(r ComparisonResult) []ComparisonResult { return []ComparisonResult{r} }
+	indexed := func(engine *HybridEngine, products []Product) *HybridEngine {
+		if err := engine.BuildIndex(products); err != nil {
+			t.Fatal(err)
+		}
+		return engine
+	}
+	mouse := Product{ID: "m1", Name: "Logitech MX Master 3S Wireless Mouse", Description: "Ergonomic mouse with quiet clicks"}
+	mouseCopy := Product{ID: "m2", Name: "Logitech MX Master 3S Wireless Mouse.", Description: "Ergonomic mouse with quiet clicks"}
+	copiedText := "Ships within 24 hours from our warehouse, with a 30 day no questions asked return policy"
+	prefixed := PrefixedIDComparator(":")
+	catalog := loadSampleCatalog(t)
+	variants := NewLevenshteinEngine().WithVariantDetection(VariantOptions{Axes: []SpecDimension{SpecStorage, SpecColor}})
+	english := Product{ID: "en-1", Name: "Acme X200 Bluetooth Speaker",
+		Description: "Wireless bluetooth speaker with deep bass, a waterproof case and a 12 hour battery"}
+	spanish := Product{ID: "es-1", Name: "Acme X200 Bluetooth Speaker.",
+		Description: "Altavoz bluetooth inalámbrico con graves profundos, carcasa resistente al agua y batería de 12 horas"}
+	privacyProducts := []Product{
+		{ID: "a", Name: "Understanding Machine Learning", Description: "A guide to ML algorithms"},
+		{ID: "b", Name: "Understanding Machine Learning", Description: "A guide to ML algorithms!"},
+	}
+
+	return map[ReasonCode]func() []ComparisonResult{
+		ReasonExactMatch: func() []ComparisonResult {
+			return one(NewLevenshteinEngine().Compare(mouse, Product{ID: "m3", Name: mouse.Name, Description: mouse.Description}))
+		},
+		ReasonNormalizedExact: func() []ComparisonResult {
+			return one(NewLevenshteinEngine().Compare(mouse, Product{ID: "m3", Name: "logitech mx master 3s wireless mouse", Description: mouse.Description}))
+		},
+		ReasonIdentifierMatch: func() []ComparisonResult {
+			engine := NewLevenshteinEngine().WithIDComparator(prefixed, IDComparisonOptions{})
+			return one(engine.Compare(Product{ID: "amazon:B0ABC123", Name: "Acme Cordless Drill 18V"}, Product{ID: "ebay:B0ABC123", Name: "Power tool bundle"}))
+		},
+		ReasonCopiedDescription: func() []ComparisonResult {
+			return NewHybridEngine().FindCopiedDescriptions([]Product{
+				{ID: "c1", Name: "Stoneware Coffee Mug", Description: copiedText},
+				{ID: "c2", Name: "Brass Desk Lamp", Description: copiedText},
+			}, 0.9, 0.5)
+		},
+		ReasonVariant: func() []ComparisonResult {
+			return one(variants.Compare(sampleProduct(t, catalog, "P003"), sampleProduct(t, catalog, "P004")))
+		},
+		ReasonCombinedSimilarity: func() []ComparisonResult {
+			return NewLevenshteinEngine().FindDuplicates([]Product{mouse, mouseCopy}, 0.8)
+		},
+		ReasonHighNameSimilarity: func() []ComparisonResult {
+			return one(NewLevenshteinEngine().Compare(mouse, mouseCopy))
+		},
+		ReasonHighDescriptionSimilarity: func() []ComparisonResult {
+			return one(NewLevenshteinEngine().Compare(mouse, Product{ID: "m3", Name: "Quiet clicks", Description: mouse.Description}))
+		},
+		ReasonNameInDescription: func() []ComparisonResult {
+			config := DefaultCrossFieldConfig()
+			return one(NewLevenshteinEngine().WithCrossFieldMatching(&config).Compare(
+				Product{ID: "1", Name: "Apple iPhone 14 Pro Max 256GB Silver", Description: "6.7-inch Super Retina XDR display, A16 Bionic chip, 48MP main camera"},
+				Product{ID: "2", Name: "Smartphone great deal", Description: "Same as Apple iPhone 14 Pro Max 256GB Silver but unlocked, ships in 24 hours"}))
+		},
+		ReasonBundleMatch: func() []ComparisonResult {
+			return one(NewLevenshteinEngine().WithBundleSplitter(DefaultBundleSplitter).Compare(
+				Product{ID: "1", Name: "Apple iPhone 14 + Silicone Case"},
+				Product{ID: "2", Name: "Silicone Case & Apple iPhone 14"}))
+		},
+		ReasonSKUName: func() []ComparisonResult {
+			return one(NewLevenshteinEngine().WithSKUNames(&SKUNameConfig{}).Compare(
+				Product{ID: "a", Name: "SKU-00421", Description: "Spare part"}, Product{ID: "b", Name: "SKU-00421", Description: "Spare part."}))
+		},
+		ReasonSKUNameMixed: func() []ComparisonResult {
+			return one(NewLevenshteinEngine().WithSKUNames(&SKUNameConfig{}).Compare(
+				Product{ID: "a", Name: "SKU-00421", Description: "Spare part"}, Product{ID: "b", Name: "Wireless Mouse", Description: "Spare part"}))
+		},
+		ReasonEstimatedSimilarity: func() []ComparisonResult {
+			engine := NewHybridEngine()
+			engine.EnablePrivacyMode(PrivacyOptions{Salt: []byte("salt")})
+			return indexed(engine, privacyProducts).FindDuplicatesForOne(Product{ID: "q", Name: "Understanding Machine Learning", Description: "A guide to ML algorithms."}, 0.5)
+		},
+		ReasonExternalScore: func() []ComparisonResult {
+			engine := NewHybridEngine()
+			engine.EnablePrivacyMode(PrivacyOptions{Verifier: func(idA, idB string) (float64, error) { return 0.93, nil }})
+			return indexed(engine, privacyProducts).FindDuplicatesForOne(Product{ID: "q", Name: "Understanding Machine Learning", Description: "A guide to ML algorithms."}, 0.9)
+		},
+		ReasonClusterInferred: func() []ComparisonResult {
+			config := DefaultHybridConfig()
+			config.CliqueBands = 18
+			products := append(plantClique(DefaultCliqueMinSize+5), GenerateTestCatalog(goldenCatalogSeed, 100)...)
+			return indexed(NewHybridEngineWithConfig(config), products).FindDuplicates(products, 0.9)
+		},
+		ReasonLowInfoNames: func() []ComparisonResult {
+			return one(NewLevenshteinEngine().WithLowInfoNames(&LowInfoNameConfig{}).Compare(
+				Product{ID: "a", Name: "great deal!!", Description: copiedText}, Product{ID: "b", Name: "must see", Description: copiedText}))
+		},
+		ReasonObfuscationSuspected: func() []ComparisonResult {
+			return one(NewLevenshteinEngine().WithDeobfuscation(&DeobfuscationConfig{Watchlist: []string{"Nike"}}).Compare(
+				Product{ID: "a", Name: "N1ke A!r Max 90"}, Product{ID: "b", Name: "Nike Air Max 90"}))
+		},
+		ReasonCrossLanguage: func() []ComparisonResult {
+			return one(NewLevenshteinEngine().WithCrossLanguagePolicy(CrossLanguageFlag, 0).Compare(english, spanish))
+		},
+		ReasonSameSource: func() []ComparisonResult {
+			engine := NewLevenshteinEngine().WithIDComparator(prefixed, IDComparisonOptions{FlagSameSource: true})
+			return one(engine.Compare(Product{ID: "shop:RED-M", Name: "Cotton T-Shirt Red Medium"}, Product{ID: "shop:RED-L", Name: "Cotton T-Shirt Red Large"}))
+		},
+		ReasonAmbiguousProduct: func() []ComparisonResult {
+			products := ambiguityCatalog()
+			reports := NewHybridEngine().IdentifyAmbiguousProducts(products, 0.8, 2)
+			return NewLevenshteinEngine().WithAmbiguousProducts(reports, AmbiguityPolicy{}).FindDuplicates(products, 0.8)
+		},
+		ReasonLowQualityInput: func() []ComparisonResult {
+			products, _ := saltedCatalog(t)
+			return NewLevenshteinEngine().WithQualityFilter(DefaultQualityFilter(), QualityFlag).FindDuplicates(products, 0.5)
+		},
+		ReasonDescriptionTimedOut: func() []ComparisonResult {
+			rng := rand.New(rand.NewSource(4))
+			opts := DefaultLevenshteinOptions()
+			opts.MaxComparisonDuration = time.Microsecond
+			return one(NewLevenshteinEngineWithOptions(opts).Compare(
+				Product{ID: "1", Name: "Steel Water Bottle", Description: calibrationText(rng, 3500)},
+				Product{ID: "2", Name: "Steel Water Bottle", Description: calibrationText(rng, 3500)}))
+		},
+		ReasonDescriptionLoadFailed: func() []ComparisonResult {
+			products := []Product{{ID: "1", Name: "Nike Air Max 90"}, {ID: "2", Name: "Nike Air Max 95"}}
+			loader := func(ctx context.Context, id string) (string, error) { return "", errors.New("unavailable") }
+			return NewLevenshteinEngine().WithLazyDescriptionLoader(loader).FindDuplicates(products, 0.85)
+		},
+		ReasonDescriptionSkipped: func() []ComparisonResult {
+			a := Product{ID: "a", Name: "Stoneware Coffee Mug", Description: "Dishwasher safe"}
+			b := Product{ID: "b", Name: "Garden Hose 50ft", Description: "Expandable hose"}
+			return one(NewLevenshteinEngine().compareAt(&a, &b, 0.9))
+		},
+		ReasonVariantVetoed: func() []ComparisonResult {
+			return one(variants.Compare(sampleProduct(t, catalog, "P005"), sampleProduct(t, catalog, "P006")))
+		},
+		ReasonHomoglyphs: func() []ComparisonResult {
+			return one(NewLevenshteinEngine().WithHomoglyphs(DefaultHomoglyphs()).Compare(mouse,
+				Product{ID: "m3", Name: "Logitech МX Master 3S Wireless Mouse", Description: mouse.Description}))
+		},
+		ReasonFieldsSwappedSuspected: func() []ComparisonResult {
+			mug := Product{ID: "c1", Name: "Stoneware Coffee Mug", Description: "Glazed stoneware coffee mug, 350 ml, dishwasher and microwave safe"}
+			return one(NewLevenshteinEngine().WithSwappedFields(&SwappedFieldsConfig{}).Compare(mug,
+				Product{ID: "c2", Name: mug.Description, Description: mug.Name}))
+		},
+	}
+}
+
+func TestReasonCodesEmitted(t *testing.T) {
+	scenarios := reasonScenarios(t)
+	for _, info := range ReasonRegistry() {
+		scenario, ok := scenarios[info.Code]
+		if !ok {
+			t.Errorf("%s: no fixture scenario", info.Code)
+			continue
+		}
+		found := false
+		for _, result := range scenario() {
+			found = found || result.HasReason(info.Code)
+		}
+		if !found {
+			t.Errorf("%s: not emitted by its fixture scenario", info.Code)
+		}
+	}
+}
+
+func TestReasonCodesStable(t *testing.T) {
+	// Codes are part of the API: never rename or reorder them, only append
+	want := `["exact_match","normalized_exact","identifier_match","copied_description","variant",` +
+		`"combined_similarity","high_name_similarity","high_description_similarity","name_in_description",` +
+		`"bundle_match","sku_name","estimated_similarity","external_score","cluster_inferred","sku_name_mixed",` +
+		`"low_info_names","obfuscation_suspected","cross_language","same_source","ambiguous_product",` +
+		`"low_quality_input","description_timed_out","description_load_failed","description_skipped","variant_vetoed","homoglyphs_normalized",` +
+		`"fields_swapped_suspected"]`
+	var codes []ReasonCode
+	for _, info := range ReasonRegistry() {
+		if info.Description == "" || info.Code.Description() != info.Description {
+			t.Errorf("%s: description %q", info.Code, info.Code.Description())
+		}
+		codes = append(codes, info.Code)
+	}
+	data, err := json.Marshal(codes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("registry codes\n%s\nwant\n%s", data, want)
+	}
+
+	var decoded []ReasonCode
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded, codes) {
+		t.Errorf("codes don't round-trip through JSON: %v, %v", decoded, err)
+	}
+	if ReasonCode("no_such_code").Description() != "" {
+		t.Error("unknown code has a description")
+	}
+}
+
+func TestReasonCodesOnEveryMatch(t *testing.T) {
+	products := GenerateTestCatalog(3, 50)
+	const threshold = 0.8
+	hybrid := NewHybridEngine()
+	if err := hybrid.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	paths := map[string]func() []ComparisonResult{
+		"levenshtein FindDuplicates": func() []ComparisonResult { return NewLevenshteinEngine().FindDuplicates(products, threshold) },
+		"levenshtein FindDuplicatesPtr": func() []ComparisonResult {
+			return NewLevenshteinEngine().FindDuplicatesPtr(productPtrs(products), threshold)
+		},
+		"levenshtein FindDuplicatesChan": func() []ComparisonResult {
+			results, _ := drainChan(NewLevenshteinEngine().FindDuplicatesChan(context.Background(), products, threshold, 0))
+			return results
+		},
+		"levenshtein FindDuplicatesByName": func() []ComparisonResult {
+			return NewLevenshteinEngine().FindDuplicatesByName(products, threshold)
+		},
+		"hybrid FindDuplicates":       func() []ComparisonResult { return hybrid.FindDuplicates(products, threshold) },
+		"hybrid FindDuplicatesForOne": func() []ComparisonResult { return hybrid.FindDuplicatesForOne(products[0], 0.5) },
+		"tfidf FindDuplicates":        func() []ComparisonResult { return NewTFIDFEngine().FindDuplicates(products, threshold) },
+	}
+	for code, scenario := range reasonScenarios(t) {
+		paths["scenario "+string(code)] = scenario
+	}
+
+	for name, path := range paths {
+		matches := 0
+		for _, result := range path() {
+			recomputed := result
+			recomputed.assignReasons()
+			if !reflect.DeepEqual(result.ReasonCodes, recomputed.ReasonCodes) {
+				t.Errorf("%s: %s ↔ %s has codes %v, its fields say %v", name, result.ProductA.ID, result.ProductB.ID, result.ReasonCodes, recomputed.ReasonCodes)
+			}
+			if result.MeetsThreshold {
+				matches++
+				if len(result.ReasonCodes) == 0 {
+					t.Errorf("%s: %s ↔ %s met its threshold without a reason code", name, result.ProductA.ID, result.ProductB.ID)
+				}
+			}
+		}
+		if matches == 0 && name[:8] != "scenario" {
+			t.Errorf("%s: no matches to check", name)
+		}
+	}
+}
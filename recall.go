@@ -0,0 +1,270 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoProductText is returned by ValidateRecallChecked in privacy mode, whose
+// index holds no product text to compare exhaustively
+var ErrNoProductText = errors.New("duplicatecheck: privacy mode index holds no product text")
+
+// RecallMiss is a duplicate exhaustive comparison finds but the hybrid query missed
+type RecallMiss struct {
+	ProductID   string  // The query product
+	CandidateID string  // The indexed product it duplicates
+	Similarity  float64 // CombinedSimilarity by exhaustive comparison
+	// BandCollisions is the number of probed LSH bands the pair shares: 0
+	// means candidate generation missed it, more means it was dropped later
+	// (MaxCandidates, the SimHash screen or a clique)
+	BandCollisions int
+}
+
+// RecallSample is the outcome of validating one product
+type RecallSample struct {
+	ProductID string
+	Expected  int          // Duplicates exhaustive comparison found
+	Found     int          // Of those, the ones FindDuplicatesForOne returned
+	Recall    float64      // Found / Expected (1 when nothing was expected)
+	Truncated bool         // The query was cut to MaxCandidates
+	Missed    []RecallMiss // Strongest first
+	Duration  time.Duration
+}
+
+// RecallReport is the outcome of ValidateRecall
+type RecallReport struct {
+	Threshold float64
+	Samples   []RecallSample
+	Expected  int          // Duplicates exhaustive comparison found across all samples
+	Found     int          // Of those, the ones the hybrid queries returned
+	Recall    float64      // Found / Expected (1 when nothing was expected)
+	Missed    []RecallMiss // Every sample's misses, strongest first
+	// CandidateMisses counts the misses that shared no band with their query
+	CandidateMisses int
+	Duration        time.Duration
+}
+
+// ValidateRecall measures how many duplicates the LSH stage loses
+// It draws sampleSize products from products (all of them when sampleSize <=
+// 0 or exceeds the slice) with a source seeded by seed, and queries each
+// against the index with FindDuplicatesForOne and with an exhaustive
+// Levenshtein comparison against every indexed product. Pairs only the
+// exhaustive comparison finds are reported as misses with their similarity
+// and band collisions. A sampled product is never matched with its own ID.
+// The exhaustive comparisons run in parallel with the banded early exit, but
+// still cost one comparison per indexed product per sample. Without an index,
+// or in privacy mode, the report is empty (see ValidateRecallChecked).
+func (e *HybridEngine) ValidateRecall(products []Product, threshold float64, sampleSize int, seed int64) RecallReport {
+	defer e.levenshteinEngine.guard("ValidateRecall", nil)
+	report, _ := e.ValidateRecallChecked(products, threshold, sampleSize, seed)
+	return report
+}
+
+// ValidateRecallChecked is like ValidateRecall but reports problems
+// Returns ErrIndexNotBuilt without an index and ErrNoProductText in privacy mode.
+func (e *HybridEngine) ValidateRecallChecked(products []Product, threshold float64, sampleSize int, seed int64) (_ RecallReport, err error) {
+	defer e.levenshteinEngine.guard("ValidateRecallChecked", &err)
+	started := time.Now()
+	report := RecallReport{Threshold: threshold}
+	idx := e.currentIndex()
+	if idx == nil {
+		return report, ErrIndexNotBuilt
+	}
+	if e.privacy != nil {
+		return report, ErrNoProductText
+	}
+
+	order := rand.New(rand.NewSource(seed)).Perm(len(products))
+	if sampleSize > 0 && sampleSize < len(order) {
+		order = order[:sampleSize]
+	}
+	for _, i := range order {
+		sample := e.validateSample(idx, &products[i], threshold)
+		report.Samples = append(report.Samples, sample)
+		report.Expected += sample.Expected
+		report.Found += sample.Found
+		report.Missed = append(report.Missed, sample.Missed...)
+	}
+	for _, miss := range report.Missed {
+		if miss.BandCollisions == 0 {
+			report.CandidateMisses++
+		}
+	}
+	sortRecallMisses(report.Missed)
+	report.Recall = recallOf(report.Found, report.Expected)
+	report.Duration = time.Since(started)
+	return report, nil
+}
+
+// validateSample compares the hybrid and exhaustive duplicates of product in idx
+func (e *HybridEngine) validateSample(idx *LSHIndex, product *Product, threshold float64) RecallSample {
+	started := time.Now()
+	sample := RecallSample{ProductID: product.ID}
+
+	expected := e.exhaustiveDuplicates(idx, product, threshold)
+	results, err := e.findDuplicatesForOne(idx, product, threshold)
+	sample.Truncated = errors.Is(err, ErrQueryTruncated)
+	found := make(map[string]bool, len(results))
+	for _, result := range results {
+		found[result.ProductB.ID] = true
+	}
+
+	var collisions map[uint32]int
+	for _, result := range expected {
+		sample.Expected++
+		if found[result.ProductB.ID] {
+			sample.Found++
+			continue
+		}
+		if collisions == nil {
+			// Counted without touching the query stats
+			collisions, _ = e.bandCollisions(idx, e.queryBandHashes(idx, product), e.probeBands(threshold))
+		}
+		sample.Missed = append(sample.Missed, RecallMiss{
+			ProductID:      product.ID,
+			CandidateID:    result.ProductB.ID,
+			Similarity:     result.CombinedSimilarity,
+			BandCollisions: collisions[idx.numbers[result.ProductB.ID]],
+		})
+	}
+	sortRecallMisses(sample.Missed)
+	sample.Recall = recallOf(sample.Found, sample.Expected)
+	sample.Duration = time.Since(started)
+	return sample
+}
+
+// exhaustiveDuplicates compares product with every other indexed product in
+// parallel, returning the pairs meeting threshold
+// Pairs go through the same constraint and pre-filters as verification, but
+// never the LSH stage or the SimHash screen.
+func (e *HybridEngine) exhaustiveDuplicates(idx *LSHIndex, product *Product, threshold float64) []ComparisonResult {
+	ids := idx.ids
+	matches := make([]*ComparisonResult, len(ids))
+	compare := func(i int) {
+		if ids[i] == product.ID || !e.candidateAllowed(idx, product, ids[i]) {
+			return
+		}
+		candidate, exists := idx.products[ids[i]]
+		if !exists {
+			return
+		}
+		l := e.levenshteinEngine
+		weights := l.resolveWeights(product, candidate)
+		if l.idRelation(product.ID, candidate.ID) != IDSameEntity && l.charsetRejects(product, candidate, weights, threshold) {
+			return
+		}
+		result := l.compareWithWeights(product, candidate, weights, memoPair{}, threshold)
+		result.stampThreshold(threshold)
+		if result.MeetsThreshold {
+			matches[i] = &result
+		}
+	}
+
+	workers := e.levenshteinEngine.workerCount(len(ids))
+	if workers <= 1 {
+		for i := range ids {
+			compare(i)
+		}
+	} else {
+		var next int64
+		var group workerGroup
+		for w := 0; w < workers; w++ {
+			group.Go(func() {
+				for !group.stopped() {
+					i := int(atomic.AddInt64(&next, 1)) - 1
+					if i >= len(ids) {
+						return
+					}
+					compare(i)
+				}
+			})
+		}
+		group.Wait()
+	}
+
+	var duplicates []ComparisonResult
+	for _, match := range matches {
+		if match != nil {
+			duplicates = append(duplicates, *match)
+		}
+	}
+	return duplicates
+}
+
+// WithRecallMonitor validates one random indexed product every n
+// FindDuplicatesForOne queries, passing each sample to hook; n <= 0 or a nil
+// hook turns it off (the default)
+// The sample runs in its own goroutine at the triggering query's threshold, so
+// the query doesn't wait for its exhaustive comparison; hook may be called
+// concurrently. With a logger each sample is also logged at Debug. Returns the
+// engine for chaining.
+func (e *HybridEngine) WithRecallMonitor(n int, hook func(RecallSample)) *HybridEngine {
+	if n <= 0 || hook == nil {
+		n, hook = 0, nil
+	}
+	e.recallEvery, e.recallHook = n, hook
+	atomic.StoreUint64(&e.recallQueries, 0)
+	return e
+}
+
+// monitorRecall counts a query against idx, validating a random indexed
+// product on every recallEvery-th one (in deterministic mode, one picked by
+// the query count)
+func (e *HybridEngine) monitorRecall(idx *LSHIndex, threshold float64) {
+	every, hook := e.recallEvery, e.recallHook
+	if every <= 0 || e.privacy != nil {
+		return
+	}
+	queries := atomic.AddUint64(&e.recallQueries, 1)
+	if queries%uint64(every) != 0 || len(idx.ids) == 0 {
+		return
+	}
+	pick := rand.Intn(len(idx.ids))
+	if e.levenshteinEngine.deterministic {
+		pick = int(mix64(queries) % uint64(len(idx.ids)))
+	}
+	product, exists := idx.products[idx.ids[pick]]
+	if !exists {
+		return
+	}
+	go func() {
+		defer e.levenshteinEngine.recoverDetached("recall monitor")
+		sample := e.validateSample(idx, product, threshold)
+		if e.logger != nil {
+			e.logger.LogAttrs(context.Background(), slog.LevelDebug, "recall sample",
+				slog.String("engine", "hybrid"),
+				slog.String("product_id", sample.ProductID),
+				slog.Int("expected", sample.Expected),
+				slog.Int("found", sample.Found),
+				slog.Float64("recall", sample.Recall),
+				slog.Duration("duration", sample.Duration))
+		}
+		hook(sample)
+	}()
+}
+
+// recallOf returns found / expected, or 1 when nothing was expected
+func recallOf(found, expected int) float64 {
+	if expected == 0 {
+		return 1
+	}
+	return float64(found) / float64(expected)
+}
+
+// sortRecallMisses orders misses by similarity, strongest first, then by IDs
+func sortRecallMisses(misses []RecallMiss) {
+	sort.Slice(misses, func(i, j int) bool {
+		if misses[i].Similarity != misses[j].Similarity {
+			return misses[i].Similarity > misses[j].Similarity
+		}
+		if misses[i].ProductID != misses[j].ProductID {
+			return misses[i].ProductID < misses[j].ProductID
+		}
+		return misses[i].CandidateID < misses[j].CandidateID
+	})
+}
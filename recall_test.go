@@ -0,0 +1,169 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// recallCatalog returns products whose typo'd copies score as duplicates but
+// share no word shingles with their originals, so LSH can't find them, and
+// exact copies it always finds
+func recallCatalog() []Product {
+	items := []struct{ name, typo, description, typoDescription string }{
+		{"Sony Wireless Headphones Black", "Sonny Wirelss Headphone Blak",
+			"Noise cancelling over ear headphones with long battery life",
+			"Noise canceling over-ear headphnes with long batery life"},
+		{"Apple Magic Keyboard Silver", "Aple Magik Keybord Silver",
+			"Compact rechargeable keyboard with scissor switches for the desk",
+			"Compact rechargable keybord with sissor switches for the desk"},
+		{"Logitech Ergonomic Mouse Graphite", "Logitec Ergonomc Mous Graphite",
+			"Vertical mouse that reduces wrist strain during long workdays",
+			"Vertical mose that reduce wrist strian during long work days"},
+	}
+	var products []Product
+	for i, item := range items {
+		products = append(products,
+			Product{ID: fmt.Sprintf("p%d", i), Name: item.name, Description: item.description},
+			Product{ID: fmt.Sprintf("p%d-copy", i), Name: item.name, Description: item.description},
+			Product{ID: fmt.Sprintf("p%d-typo", i), Name: item.typo, Description: item.typoDescription})
+	}
+	return products
+}
+
+// recallEngine returns a hybrid engine indexing products through LSH
+func recallEngine(t *testing.T, products []Product) *HybridEngine {
+	t.Helper()
+	config := DefaultHybridConfig()
+	config.ExactModeCutoff = 0 // Candidates must come from LSH
+	engine := NewHybridEngineWithConfig(config)
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	return engine
+}
+
+func TestValidateRecall(t *testing.T) {
+	const threshold = 0.8
+	products := recallCatalog()
+
+	t.Run("misses", func(t *testing.T) {
+		engine := recallEngine(t, products)
+		report := engine.ValidateRecall(products, threshold, 0, 1)
+		if len(report.Samples) != len(products) {
+			t.Fatalf("%d samples, want %d", len(report.Samples), len(products))
+		}
+		// Each original and copy find each other but not the typo'd product,
+		// which finds neither
+		if report.Expected != 18 || report.Found != 6 || len(report.Missed) != 12 {
+			t.Fatalf("expected %d, found %d, missed %d; want 18, 6, 12", report.Expected, report.Found, len(report.Missed))
+		}
+		if report.Recall != 6.0/18 || report.CandidateMisses != 12 {
+			t.Errorf("recall %v with %d candidate misses", report.Recall, report.CandidateMisses)
+		}
+		for i, miss := range report.Missed {
+			if miss.BandCollisions != 0 || miss.Similarity < threshold {
+				t.Errorf("miss %+v", miss)
+			}
+			if i > 0 && miss.Similarity > report.Missed[i-1].Similarity {
+				t.Errorf("misses not strongest first: %v after %v", miss.Similarity, report.Missed[i-1].Similarity)
+			}
+			// The miss is real: the query does not return it
+			query := products[0]
+			for _, p := range products {
+				if p.ID == miss.ProductID {
+					query = p
+				}
+			}
+			for _, result := range engine.FindDuplicatesForOne(query, threshold) {
+				if result.ProductB.ID == miss.CandidateID {
+					t.Errorf("%s found %s", miss.ProductID, miss.CandidateID)
+				}
+			}
+		}
+		for _, sample := range report.Samples {
+			want := 1.0 / 2
+			if sample.Found == 0 {
+				want = 0
+			}
+			if sample.Expected != 2 || sample.Recall != want || sample.Duration <= 0 {
+				t.Errorf("sample %+v", sample)
+			}
+		}
+		if report.Duration <= 0 {
+			t.Error("no duration recorded")
+		}
+	})
+
+	t.Run("no misses", func(t *testing.T) {
+		var exact []Product
+		for _, p := range products {
+			if p.ID[len(p.ID)-1] != 'o' { // Drop the typo'd products
+				exact = append(exact, p)
+			}
+		}
+		report := recallEngine(t, exact).ValidateRecall(exact, threshold, 0, 1)
+		if report.Expected != 6 || report.Found != 6 || report.Recall != 1 || len(report.Missed) != 0 {
+			t.Errorf("report %+v, want 100%% recall of 6 pairs", report)
+		}
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		engine := recallEngine(t, products)
+		a, b := engine.ValidateRecall(products, threshold, 4, 7), engine.ValidateRecall(products, threshold, 4, 7)
+		if len(a.Samples) != 4 {
+			t.Fatalf("%d samples, want 4", len(a.Samples))
+		}
+		for i := range a.Samples {
+			if a.Samples[i].ProductID != b.Samples[i].ProductID {
+				t.Errorf("seed 7 sampled %s then %s", a.Samples[i].ProductID, b.Samples[i].ProductID)
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := NewHybridEngine().ValidateRecallChecked(products, threshold, 0, 1); !errors.Is(err, ErrIndexNotBuilt) {
+			t.Errorf("without an index: %v", err)
+		}
+		private := NewHybridEngine()
+		private.EnablePrivacyMode(PrivacyOptions{})
+		if err := private.BuildIndex(products); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := private.ValidateRecallChecked(products, threshold, 0, 1); !errors.Is(err, ErrNoProductText) {
+			t.Errorf("privacy mode: %v", err)
+		}
+	})
+}
+
+func TestRecallMonitor(t *testing.T) {
+	products := recallCatalog()
+	samples := make(chan RecallSample, 4)
+	engine := recallEngine(t, products).WithRecallMonitor(2, func(sample RecallSample) { samples <- sample })
+
+	for i := 0; i < 4; i++ {
+		engine.FindDuplicatesForOne(products[i], 0.8)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case sample := <-samples:
+			if sample.Expected != 2 {
+				t.Errorf("sample %+v", sample)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("got %d samples, want 2", i)
+		}
+	}
+	select {
+	case sample := <-samples:
+		t.Errorf("extra sample %+v", sample)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Off
+	engine.WithRecallMonitor(0, func(RecallSample) { t.Error("monitor still on") })
+	engine.FindDuplicatesForOne(products[0], 0.8)
+	engine.FindDuplicatesForOne(products[0], 0.8)
+	time.Sleep(50 * time.Millisecond)
+}
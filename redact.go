@@ -0,0 +1,102 @@
+package duplicatecheck
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultRedactionToken replaces masked text when a Redactor sets none
+const DefaultRedactionToken = "[redacted]"
+
+// Patterns of DefaultPIIRedactor
+var (
+	// emailPattern matches addresses such as jane.doe+shop@example.co.uk
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// phonePattern matches numbers in three or more separated groups, such as
+	// +1 (555) 123-4567 or 020 7946 0958; unseparated digit runs, dates and
+	// model numbers are left alone
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)[\s.-]?|\b\d{2,4}[\s.-])\d{3,4}[\s.-]\d{3,4}\b`)
+)
+
+// Redactor masks sensitive text in the products embedded in results, such as
+// seller contact details in descriptions
+// A nil *Redactor redacts nothing.
+type Redactor struct {
+	// Patterns are masked in names and descriptions
+	Patterns []*regexp.Regexp
+	// Replacement replaces each match (empty = DefaultRedactionToken)
+	Replacement string
+	// MaxDescriptionLength cuts descriptions to this many runes after masking,
+	// marking the cut with an ellipsis (0 = no limit)
+	MaxDescriptionLength int
+}
+
+// DefaultPIIRedactor returns a redactor masking email addresses and phone numbers
+// The patterns are conservative: they prefer leaving an unusual phone number
+// visible to masking model numbers, dates or prices.
+func DefaultPIIRedactor() *Redactor {
+	return &Redactor{Patterns: []*regexp.Regexp{emailPattern, phonePattern}}
+}
+
+// RedactText masks every pattern match in s
+func (r *Redactor) RedactText(s string) string {
+	if r == nil {
+		return s
+	}
+	replacement := r.Replacement
+	if replacement == "" {
+		replacement = DefaultRedactionToken
+	}
+	for _, pattern := range r.Patterns {
+		s = pattern.ReplaceAllLiteralString(s, replacement)
+	}
+	return s
+}
+
+// RedactProduct returns a redacted copy of p: name and description masked, and
+// the description cut to MaxDescriptionLength
+// The copy shares nothing with p, whose fields and caches are left untouched.
+func (r *Redactor) RedactProduct(p Product) Product {
+	if r == nil {
+		return p
+	}
+	description := r.RedactText(p.Description)
+	if r.MaxDescriptionLength > 0 && utf8.RuneCountInString(description) > r.MaxDescriptionLength {
+		description = strings.TrimSpace(string([]rune(description)[:r.MaxDescriptionLength])) + "…"
+	}
+	return Product{
+		ID:          p.ID,
+		SourceID:    p.SourceID,
+		Name:        r.RedactText(p.Name),
+		Description: description,
+	}
+}
+
+// RedactResult returns result with both products redacted
+// Scores are kept: they were computed on the original text.
+func (r *Redactor) RedactResult(result ComparisonResult) ComparisonResult {
+	if r == nil {
+		return result
+	}
+	result.ProductA = r.RedactProduct(result.ProductA)
+	result.ProductB = r.RedactProduct(result.ProductB)
+	return result
+}
+
+// WithResultRedaction sets a redactor applied to the products embedded in
+// every returned result (nil, the default, returns them unchanged)
+// Similarities are computed on the original text, and the caller's products
+// are never modified: results hold redacted copies. Quality filters assess the
+// original products. Returns the engine for chaining.
+func (e *LevenshteinEngine) WithResultRedaction(r *Redactor) *LevenshteinEngine {
+	e.redactor = r
+	return e
+}
+
+// WithResultRedaction sets a redactor for returned results (see
+// LevenshteinEngine.WithResultRedaction)
+func (e *HybridEngine) WithResultRedaction(r *Redactor) *HybridEngine {
+	e.levenshteinEngine.WithResultRedaction(r)
+	return e
+}
@@ -0,0 +1,134 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestDefaultPIIRedactor(t *testing.T) {
+	r := DefaultPIIRedactor()
+	tests := []struct {
+		text, want string
+	}{
+		{"Contact jane.doe+shop@example.co.uk today", "Contact [redacted] today"},
+		{"Call +1 (555) 123-4567 or 555.123.4567", "Call [redacted] or [redacted]"},
+		{"UK office: +44 20 7946 0958", "UK office: [redacted]"},
+		{"Sony WH-1000XM5, 1920x1080, released 2024-11-09, $1,299.99", "Sony WH-1000XM5, 1920x1080, released 2024-11-09, $1,299.99"},
+		{"Part 5551234567 in stock", "Part 5551234567 in stock"},
+	}
+	for _, tt := range tests {
+		if got := r.RedactText(tt.text); got != tt.want {
+			t.Errorf("RedactText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	r := &Redactor{
+		Patterns:             []*regexp.Regexp{regexp.MustCompile(`secret-\d+`)},
+		Replacement:          "***",
+		MaxDescriptionLength: 12,
+	}
+	p := Product{ID: "a", SourceID: "src", Name: "Lamp secret-1", Description: "Code secret-22 inside the box"}
+	want := Product{ID: "a", SourceID: "src", Name: "Lamp ***", Description: "Code *** ins…"}
+	if got := r.RedactProduct(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("RedactProduct = %+v, want %+v", got, want)
+	}
+
+	var none *Redactor
+	if got := none.RedactProduct(p); got.Name != p.Name || got.Description != p.Description {
+		t.Errorf("Nil redactor changed %+v to %+v", p, got)
+	}
+}
+
+func TestWithResultRedaction(t *testing.T) {
+	products := []Product{
+		{ID: "1", Name: "Vintage Oak Desk", Description: "Solid oak writing desk. Pickup only, call 555-123-4567 or email oak@example.com"},
+		{ID: "2", Name: "Vintage Oak Desk", Description: "Solid oak writing desk. Pickup only, call 555-987-6543 or email desk@example.org"},
+		{ID: "3", Name: "Steel Office Chair", Description: "Ergonomic chair with lumbar support"},
+	}
+	original := make([]Product, len(products))
+	for i, p := range products {
+		original[i] = Product{ID: p.ID, Name: p.Name, Description: p.Description}
+	}
+
+	redactor := DefaultPIIRedactor()
+	hybrid := NewHybridEngine().WithResultRedaction(redactor)
+	if err := hybrid.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	plain := NewLevenshteinEngine()
+	engines := []struct {
+		name   string
+		engine DuplicateCheckEngine
+	}{
+		{"Levenshtein", NewLevenshteinEngine().WithResultRedaction(redactor)},
+		{"Hybrid", hybrid},
+	}
+	for _, tt := range engines {
+		t.Run(tt.name, func(t *testing.T) {
+			results := tt.engine.FindDuplicates(products, 0.8)
+			results = append(results, tt.engine.Compare(products[0], products[1]))
+			if len(results) < 2 {
+				t.Fatalf("Expected the desk pair, got %d results", len(results))
+			}
+			for _, r := range results {
+				for _, p := range []Product{r.ProductA, r.ProductB} {
+					if emailPattern.MatchString(p.Description) || phonePattern.MatchString(p.Description) {
+						t.Errorf("Result leaks contact details: %q", p.Description)
+					}
+				}
+				// Scores come from the original text, whose phone numbers differ
+				want := plain.Compare(products[0], products[1])
+				if r.CombinedSimilarity != want.CombinedSimilarity || r.DescriptionSimilarity != want.DescriptionSimilarity {
+					t.Errorf("Scores %v/%v, unredacted %v/%v", r.CombinedSimilarity, r.DescriptionSimilarity,
+						want.CombinedSimilarity, want.DescriptionSimilarity)
+				}
+			}
+			for i, p := range products {
+				if p.ID != original[i].ID || p.Name != original[i].Name || p.Description != original[i].Description {
+					t.Errorf("Input product %d changed to %+v", i, p)
+				}
+				fresh := original[i]
+				wantName, wantDesc := fresh.preparedStrings(plain.preparer())
+				if name, desc := p.preparedStrings(plain.preparer()); name != wantName || desc != wantDesc {
+					t.Errorf("Input product %d caches %q / %q", i, name, desc)
+				}
+			}
+		})
+	}
+
+	byName := NewLevenshteinEngine().WithResultRedaction(&Redactor{Patterns: []*regexp.Regexp{regexp.MustCompile(`Oak`)}})
+	for _, r := range byName.FindDuplicatesByName(products, 0.9) {
+		if r.ProductA.Name != "Vintage [redacted] Desk" {
+			t.Errorf("FindDuplicatesByName returned name %q", r.ProductA.Name)
+		}
+	}
+}
+
+func TestRedactionKeepsQualityAssessment(t *testing.T) {
+	// Redaction leaves almost nothing of these descriptions, but the quality
+	// filter must still judge the originals
+	products := []Product{
+		{ID: "1", Name: "Oak Desk", Description: "Call 555-123-4567 for a solid oak writing desk with drawers"},
+		{ID: "2", Name: "Oak Desk", Description: "Call 555-123-4567 for a solid oak writing desk with drawer"},
+	}
+	filter := DefaultQualityFilter()
+	redactor := &Redactor{Patterns: []*regexp.Regexp{regexp.MustCompile(`.+`)}, Replacement: "!!!!!!!!"}
+	for _, mode := range []QualityMode{QualityFlag, QualityExclude} {
+		want := NewLevenshteinEngine().WithQualityFilter(filter, mode).FindDuplicates(products, 0.8)
+		got := NewLevenshteinEngine().WithQualityFilter(filter, mode).WithResultRedaction(redactor).FindDuplicates(products, 0.8)
+		if len(want) != 1 || want[0].LowQualityInput {
+			t.Fatalf("Mode %v: expected one acceptable pair, got %+v", mode, want)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("Mode %v: %d results redacted, %d without", mode, len(got), len(want))
+		}
+		for i := range got {
+			if got[i].LowQualityInput != want[i].LowQualityInput {
+				t.Errorf("Mode %v: LowQualityInput %v redacted, %v without", mode, got[i].LowQualityInput, want[i].LowQualityInput)
+			}
+		}
+	}
+}
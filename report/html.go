@@ -0,0 +1,99 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// htmlTemplate renders a self-contained page: inline CSS, no scripts or
+// external assets, and every product field escaped by html/template
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+	"span":    func(lo, hi float64) string { return fmt.Sprintf("%.1f–%.1f", lo, hi) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { border: 1px solid #ddd; padding: 0.3rem 0.6rem; text-align: left; vertical-align: top; }
+.num { text-align: right; }
+.bar { display: inline-block; width: 12rem; height: 0.8rem; background: #eee; vertical-align: middle; }
+.bar span { display: block; height: 100%; background: #c0392b; }
+.pair { border: 1px solid #ddd; border-radius: 4px; padding: 0.6rem 1rem; margin-bottom: 1rem; }
+.desc { color: #555; font-size: 0.9rem; }
+.near { background: #fdebd0; }
+del { background: #fadbd8; }
+ins { background: #d5f5e3; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .ConfigFingerprint}}
+<p class="config">Engine configuration: <code>{{.ConfigFingerprint}}</code></p>
+{{- end}}
+<h2>Summary</h2>
+<table class="summary">
+<tr><th>Total pairs</th><td class="num">{{.Summary.TotalPairs}}</td></tr>
+<tr><th>Pairs shown</th><td class="num">{{.Summary.ShownPairs}}</td></tr>
+<tr><th>Products involved</th><td class="num">{{.Summary.Products}}</td></tr>
+<tr><th>Groups</th><td class="num">{{len .Groups}}</td></tr>
+</table>
+<h3>Similarity histogram</h3>
+<table class="histogram">
+<tr><th>Similarity</th><th>Pairs</th><th></th></tr>
+{{- range .Summary.Histogram}}
+<tr><td>{{span .Min .Max}}</td><td class="num">{{.Count}}</td><td><span class="bar"><span style="width: {{printf "%.0f" .Bar.Percent}}%"></span></span></td></tr>
+{{- end}}
+</table>
+{{- if .Summary.TopProducts}}
+<h3>Most duplicated products</h3>
+<table class="top-products">
+<tr><th>Product</th><th>Name</th><th>Pairs</th></tr>
+{{- range .Summary.TopProducts}}
+<tr><td>{{.ID}}</td><td>{{.Name}}</td><td class="num">{{.Pairs}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range .Groups}}
+<section class="group">
+<h2>{{.Label}}</h2>
+<p>Pairs: {{len .Pairs}}. Products: {{range $i, $id := .ProductIDs}}{{if $i}}, {{end}}{{$id}}{{end}}</p>
+{{- range .Pairs}}
+<div class="pair">
+<p><span class="bar"><span style="width: {{printf "%.0f" .Bar.Percent}}%"></span></span>
+<strong>{{percent .Similarity}}</strong> (name {{percent .NameSimilarity}}, description {{percent .DescriptionSimilarity}})</p>
+<table>
+<tr><th>{{.A.ID}}</th><td>{{.A.Name}}{{if .A.Description}}<div class="desc">{{.A.Description}}</div>{{end}}</td></tr>
+<tr><th>{{.B.ID}}</th><td>{{.B.Name}}{{if .B.Description}}<div class="desc">{{.B.Description}}</div>{{end}}</td></tr>
+</table>
+<p class="name-diff">Name diff:
+{{- range .NameDiff.MatchedTokens}} {{.}}{{end}}
+{{- range .NameDiff.NearMatches}} <span class="near">{{.A}} → {{.B}}</span>{{end}}
+{{- range .NameDiff.UniqueToA}} <del>{{.}}</del>{{end}}
+{{- range .NameDiff.UniqueToB}} <ins>{{.}}</ins>{{end}}</p>
+{{- if .Reasons}}
+<p class="reasons">Reasons:{{range .Reasons}} <code title="{{.Description}}">{{.}}</code>{{end}}</p>
+{{- end}}
+</div>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the report as a single HTML page with inline CSS
+// The page needs no scripts or external assets, and product fields are
+// HTML-escaped.
+func (r *Report) WriteHTML(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	if err := htmlTemplate.Execute(out, r); err != nil {
+		return err
+	}
+	return out.Flush()
+}
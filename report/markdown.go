@@ -0,0 +1,118 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+// markdownEscaper escapes text so Markdown renders it literally
+// Angle brackets and ampersands are escaped as HTML entities, since most
+// Markdown renderers pass raw HTML through.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "|", `\|`, "#", `\#`,
+	"<", "&lt;", ">", "&gt;", "&", "&amp;", "\n", " ", "\r", " ",
+)
+
+// escapeMarkdown escapes s for inline Markdown
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// WriteMarkdown writes the report as Markdown, bars as unicode blocks
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "# %s\n\n", escapeMarkdown(r.Title))
+	if r.ConfigFingerprint != "" {
+		fmt.Fprintf(out, "Engine configuration: `%s`\n\n", escapeMarkdown(r.ConfigFingerprint))
+	}
+
+	s := r.Summary
+	fmt.Fprintln(out, "## Summary")
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "- Total pairs: %d\n", s.TotalPairs)
+	fmt.Fprintf(out, "- Pairs shown: %d\n", s.ShownPairs)
+	fmt.Fprintf(out, "- Products involved: %d\n", s.Products)
+	fmt.Fprintf(out, "- Groups: %d\n\n", len(r.Groups))
+
+	fmt.Fprintln(out, "### Similarity histogram")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "| Similarity | Pairs | |")
+	fmt.Fprintln(out, "|---|---:|---|")
+	for _, b := range s.Histogram {
+		fmt.Fprintf(out, "| %.1f–%.1f | %d | `%s` |\n", b.Min, b.Max, b.Count, b.Bar)
+	}
+	fmt.Fprintln(out)
+
+	if len(s.TopProducts) > 0 {
+		fmt.Fprintln(out, "### Most duplicated products")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "| Product | Name | Pairs |")
+		fmt.Fprintln(out, "|---|---|---:|")
+		for _, p := range s.TopProducts {
+			fmt.Fprintf(out, "| %s | %s | %d |\n", escapeMarkdown(p.ID), escapeMarkdown(p.Name), p.Pairs)
+		}
+		fmt.Fprintln(out)
+	}
+
+	for _, g := range r.Groups {
+		ids := make([]string, len(g.ProductIDs))
+		for i, id := range g.ProductIDs {
+			ids[i] = escapeMarkdown(id)
+		}
+		fmt.Fprintf(out, "## %s\n\n", escapeMarkdown(g.Label))
+		fmt.Fprintf(out, "Pairs: %d. Products: %s\n\n", len(g.Pairs), strings.Join(ids, ", "))
+		for _, p := range g.Pairs {
+			writeMarkdownPair(out, p)
+		}
+	}
+	return out.Flush()
+}
+
+// writeMarkdownPair writes one pair as a heading, a bar, the two products
+// and its reason codes
+func writeMarkdownPair(out *bufio.Writer, p Pair) {
+	fmt.Fprintf(out, "### %s ↔ %s\n\n", escapeMarkdown(p.A.ID), escapeMarkdown(p.B.ID))
+	fmt.Fprintf(out, "`%s` **%.1f%%** (name %.1f%%, description %.1f%%)\n\n",
+		p.Bar, p.Similarity*100, p.NameSimilarity*100, p.DescriptionSimilarity*100)
+	for _, product := range []Product{p.A, p.B} {
+		fmt.Fprintf(out, "- **%s**: %s", escapeMarkdown(product.ID), escapeMarkdown(product.Name))
+		if product.Description != "" {
+			fmt.Fprintf(out, "  \n  %s", escapeMarkdown(product.Description))
+		}
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "\nName diff: %s\n\n", markdownNameDiff(p.NameDiff))
+	if len(p.Reasons) > 0 {
+		codes := make([]string, len(p.Reasons))
+		for i, code := range p.Reasons {
+			codes[i] = "`" + string(code) + "`"
+		}
+		fmt.Fprintf(out, "Reasons: %s\n\n", strings.Join(codes, ", "))
+	}
+}
+
+// markdownNameDiff renders a name diff: tokens only in A struck through,
+// tokens only in B in bold, near matches as a → b
+func markdownNameDiff(diff duplicatecheck.TokenExplanation) string {
+	var parts []string
+	for _, token := range diff.MatchedTokens {
+		parts = append(parts, escapeMarkdown(token))
+	}
+	for _, near := range diff.NearMatches {
+		parts = append(parts, fmt.Sprintf("*%s → %s*", escapeMarkdown(near.A), escapeMarkdown(near.B)))
+	}
+	for _, token := range diff.UniqueToA {
+		parts = append(parts, "~~"+escapeMarkdown(token)+"~~")
+	}
+	for _, token := range diff.UniqueToB {
+		parts = append(parts, "**"+escapeMarkdown(token)+"**")
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,365 @@
+// Package report turns duplicate scan results into a shareable artifact for
+// human review, as Markdown or a self-contained HTML page
+//
+//	results := engine.FindDuplicates(catalog, 0.85)
+//	r := report.GenerateReport(results, report.DefaultReportOptions())
+//	err := r.WriteHTML(file)
+//
+// A report groups pairs by cluster (products linked by any chain of pairs) or
+// by ProductA, draws a similarity bar per pair, highlights how the two names
+// differ word by word, and summarizes the scan: pair count, a similarity
+// histogram and the products with the most duplicates.
+package report
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+// GroupBy selects how a report groups pairs
+type GroupBy int
+
+const (
+	GroupByCluster  GroupBy = iota // Products linked by any chain of pairs (connected components)
+	GroupByProductA                // Pairs sharing ProductA
+)
+
+// SortOrder selects the order of pairs, and of groups by their first pair
+type SortOrder int
+
+const (
+	SortBySimilarity SortOrder = iota // Most similar first, ties by pair key
+	SortByID                          // By pair key (ProductA ID, then ProductB ID)
+)
+
+// Default option values
+const (
+	DefaultMaxDescriptionLength = 160 // Runes of each description shown
+	DefaultBarWidth             = 20  // Cells in a similarity bar
+	HistogramBuckets            = 10  // Similarity buckets of width 0.1
+	TopProducts                 = 10  // Most duplicated products listed
+)
+
+// ReportOptions controls GenerateReport
+type ReportOptions struct {
+	Title   string    // Report heading (default "Duplicate report")
+	GroupBy GroupBy   // How pairs are grouped (default GroupByCluster)
+	Sort    SortOrder // Order of pairs and groups (default SortBySimilarity)
+	// MaxPairs caps the pairs listed, keeping the first in Sort order
+	// (0 lists all). The summary always covers every pair.
+	MaxPairs int
+	// MaxDescriptionLength is the number of runes of each description shown
+	// (0 uses DefaultMaxDescriptionLength, negative hides descriptions)
+	MaxDescriptionLength int
+	// Redactor masks names and descriptions before they are shown (nil shows
+	// them as given); results from an engine with WithResultRedaction are
+	// already redacted
+	Redactor *duplicatecheck.Redactor
+	// ConfigFingerprint is shown in the report's header, tracing it to the
+	// engine configuration that found the pairs (see
+	// LevenshteinEngine.ConfigFingerprint); empty shows none
+	ConfigFingerprint string
+}
+
+// DefaultReportOptions returns the default report options
+func DefaultReportOptions() ReportOptions {
+	return ReportOptions{
+		Title:                "Duplicate report",
+		GroupBy:              GroupByCluster,
+		Sort:                 SortBySimilarity,
+		MaxDescriptionLength: DefaultMaxDescriptionLength,
+	}
+}
+
+// Bar is a similarity bar as data: Filled of Width cells
+// Markdown renders it as unicode blocks, HTML as a CSS bar.
+type Bar struct {
+	Filled int
+	Width  int
+}
+
+// NewBar returns the bar drawing value, clamped to [0,1], over width cells
+func NewBar(value float64, width int) Bar {
+	if value < 0 {
+		value = 0
+	}
+	if value > 1 {
+		value = 1
+	}
+	return Bar{Filled: int(value*float64(width) + 0.5), Width: width}
+}
+
+// String draws the bar as unicode blocks
+func (b Bar) String() string {
+	return strings.Repeat("█", b.Filled) + strings.Repeat("░", b.Width-b.Filled)
+}
+
+// Percent returns the filled fraction of the bar as a percentage
+func (b Bar) Percent() float64 {
+	if b.Width == 0 {
+		return 0
+	}
+	return 100 * float64(b.Filled) / float64(b.Width)
+}
+
+// Product is a product as shown in a report
+type Product struct {
+	ID          string
+	Name        string
+	Description string // Truncated to MaxDescriptionLength runes; empty when hidden
+}
+
+// Pair is one reviewed pair
+type Pair struct {
+	A, B                  Product
+	Similarity            float64 // CombinedSimilarity
+	NameSimilarity        float64
+	DescriptionSimilarity float64
+	Bar                   Bar
+	// NameDiff explains the names word by word: matched, near and unique tokens
+	NameDiff duplicatecheck.TokenExplanation
+	Reasons  []duplicatecheck.ReasonCode // The result's ReasonCodes
+}
+
+// Group is a set of pairs reviewed together
+type Group struct {
+	Label      string   // Cluster number or ProductA ID
+	ProductIDs []string // Products in the group, sorted
+	Pairs      []Pair
+}
+
+// Bucket is one histogram bucket: pairs with Min <= similarity < Max
+// The last bucket includes 1.0.
+type Bucket struct {
+	Min, Max float64
+	Count    int
+	Bar      Bar // Count relative to the largest bucket
+}
+
+// ProductCount is a product and the number of pairs it appears in
+type ProductCount struct {
+	ID    string
+	Name  string
+	Pairs int
+}
+
+// Summary describes every pair passed to GenerateReport
+type Summary struct {
+	TotalPairs  int
+	ShownPairs  int // Pairs listed in groups (at most MaxPairs)
+	Products    int // Distinct products in any pair
+	Histogram   []Bucket
+	TopProducts []ProductCount // Most pairs first, ties by ID; at most TopProducts
+}
+
+// Report is a duplicate report ready to be written
+type Report struct {
+	Title             string
+	ConfigFingerprint string // From ReportOptions
+	Summary           Summary
+	Groups            []Group
+}
+
+// GenerateReport builds a report from scan results
+func GenerateReport(results []duplicatecheck.ComparisonResult, opts ReportOptions) *Report {
+	if opts.Title == "" {
+		opts.Title = DefaultReportOptions().Title
+	}
+	if opts.MaxDescriptionLength == 0 {
+		opts.MaxDescriptionLength = DefaultMaxDescriptionLength
+	}
+
+	sorted := append([]duplicatecheck.ComparisonResult(nil), results...)
+	sortResults(sorted, opts.Sort)
+
+	r := &Report{Title: opts.Title, ConfigFingerprint: opts.ConfigFingerprint, Summary: summarize(sorted)}
+	if opts.MaxPairs > 0 && len(sorted) > opts.MaxPairs {
+		sorted = sorted[:opts.MaxPairs]
+	}
+	r.Summary.ShownPairs = len(sorted)
+	r.Groups = group(sorted, opts)
+	return r
+}
+
+// pairKey returns the PairKey of a result
+func pairKey(r *duplicatecheck.ComparisonResult) string {
+	return duplicatecheck.PairKey(r.ProductA.ID, r.ProductB.ID)
+}
+
+// sortResults orders results by order
+func sortResults(results []duplicatecheck.ComparisonResult, order SortOrder) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := &results[i], &results[j]
+		if order == SortByID {
+			if a.ProductA.ID != b.ProductA.ID {
+				return a.ProductA.ID < b.ProductA.ID
+			}
+			return a.ProductB.ID < b.ProductB.ID
+		}
+		if a.CombinedSimilarity != b.CombinedSimilarity {
+			return a.CombinedSimilarity > b.CombinedSimilarity
+		}
+		return pairKey(a) < pairKey(b)
+	})
+}
+
+// summarize computes the summary of every result
+func summarize(results []duplicatecheck.ComparisonResult) Summary {
+	summary := Summary{TotalPairs: len(results), Histogram: make([]Bucket, HistogramBuckets)}
+	for i := range summary.Histogram {
+		summary.Histogram[i].Min = float64(i) / HistogramBuckets
+		summary.Histogram[i].Max = float64(i+1) / HistogramBuckets
+	}
+
+	counts := make(map[string]*ProductCount)
+	count := func(p *duplicatecheck.Product) {
+		if c, ok := counts[p.ID]; ok {
+			c.Pairs++
+			return
+		}
+		counts[p.ID] = &ProductCount{ID: p.ID, Name: p.Name, Pairs: 1}
+	}
+	for i := range results {
+		bucket := int(results[i].CombinedSimilarity * HistogramBuckets)
+		if bucket >= HistogramBuckets {
+			bucket = HistogramBuckets - 1
+		}
+		if bucket < 0 {
+			bucket = 0
+		}
+		summary.Histogram[bucket].Count++
+		count(&results[i].ProductA)
+		count(&results[i].ProductB)
+	}
+
+	largest := 0
+	for _, b := range summary.Histogram {
+		if b.Count > largest {
+			largest = b.Count
+		}
+	}
+	for i := range summary.Histogram {
+		value := 0.0
+		if largest > 0 {
+			value = float64(summary.Histogram[i].Count) / float64(largest)
+		}
+		summary.Histogram[i].Bar = NewBar(value, DefaultBarWidth)
+	}
+
+	summary.Products = len(counts)
+	for _, c := range counts {
+		summary.TopProducts = append(summary.TopProducts, *c)
+	}
+	sort.Slice(summary.TopProducts, func(i, j int) bool {
+		a, b := summary.TopProducts[i], summary.TopProducts[j]
+		if a.Pairs != b.Pairs {
+			return a.Pairs > b.Pairs
+		}
+		return a.ID < b.ID
+	})
+	if len(summary.TopProducts) > TopProducts {
+		summary.TopProducts = summary.TopProducts[:TopProducts]
+	}
+	return summary
+}
+
+// group splits sorted results into groups, ordered by their first pair
+func group(results []duplicatecheck.ComparisonResult, opts ReportOptions) []Group {
+	keyOf := func(r *duplicatecheck.ComparisonResult) string { return r.ProductA.ID }
+	if opts.GroupBy == GroupByCluster {
+		keyOf = clusterKeys(results)
+	}
+
+	var groups []Group
+	index := make(map[string]int)
+	members := make(map[string]map[string]bool)
+	for i := range results {
+		r := &results[i]
+		key := keyOf(r)
+		g, ok := index[key]
+		if !ok {
+			g = len(groups)
+			index[key] = g
+			members[key] = make(map[string]bool)
+			label := key
+			if opts.GroupBy == GroupByCluster {
+				label = "Cluster " + strconv.Itoa(g+1)
+			}
+			groups = append(groups, Group{Label: label})
+		}
+		members[key][r.ProductA.ID] = true
+		members[key][r.ProductB.ID] = true
+		groups[g].Pairs = append(groups[g].Pairs, newPair(r, opts))
+	}
+	for key, g := range index {
+		for id := range members[key] {
+			groups[g].ProductIDs = append(groups[g].ProductIDs, id)
+		}
+		sort.Strings(groups[g].ProductIDs)
+	}
+	return groups
+}
+
+// clusterKeys returns a function mapping a result to its connected component,
+// named by the component's root product ID
+func clusterKeys(results []duplicatecheck.ComparisonResult) func(*duplicatecheck.ComparisonResult) string {
+	parent := make(map[string]string)
+	var find func(id string) string
+	find = func(id string) string {
+		p, ok := parent[id]
+		if !ok || p == id {
+			parent[id] = id
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	for i := range results {
+		a, b := find(results[i].ProductA.ID), find(results[i].ProductB.ID)
+		if a != b {
+			if b < a {
+				a, b = b, a
+			}
+			parent[b] = a
+		}
+	}
+	return func(r *duplicatecheck.ComparisonResult) string { return find(r.ProductA.ID) }
+}
+
+// newPair converts a result for display, redacted when opts has a Redactor
+func newPair(r *duplicatecheck.ComparisonResult, opts ReportOptions) Pair {
+	a, b := opts.Redactor.RedactProduct(r.ProductA), opts.Redactor.RedactProduct(r.ProductB)
+	return Pair{
+		A:                     newProduct(&a, opts),
+		B:                     newProduct(&b, opts),
+		Similarity:            r.CombinedSimilarity,
+		NameSimilarity:        r.NameSimilarity,
+		DescriptionSimilarity: r.DescriptionSimilarity,
+		Bar:                   NewBar(r.CombinedSimilarity, DefaultBarWidth),
+		NameDiff:              duplicatecheck.ExplainTokens(a, b),
+		Reasons:               r.ReasonCodes,
+	}
+}
+
+// newProduct converts a product for display, truncating its description
+func newProduct(p *duplicatecheck.Product, opts ReportOptions) Product {
+	product := Product{ID: p.ID, Name: p.Name}
+	if opts.MaxDescriptionLength > 0 {
+		product.Description = truncate(p.Description, opts.MaxDescriptionLength)
+	}
+	return product
+}
+
+// truncate cuts s to limit runes, marking the cut with an ellipsis
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:limit])) + "…"
+}
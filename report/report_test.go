@@ -0,0 +1,187 @@
+package report
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+// fixedResults returns a small result set: one cluster of three products,
+// one separate pair, and a product name carrying markup
+func fixedResults() []duplicatecheck.ComparisonResult {
+	engine := duplicatecheck.NewLevenshteinEngine()
+	products := map[string]duplicatecheck.Product{
+		"p1": {ID: "p1", Name: "Apple iPhone 13 Pro", Description: "128GB graphite smartphone with a very long description that goes on"},
+		"p2": {ID: "p2", Name: "Apple iPhone 13 Pro Max", Description: "128GB graphite smartphone"},
+		"p3": {ID: "p3", Name: "Apple iPhone 13 Pro 128GB", Description: "graphite smartphone"},
+		"p4": {ID: "p4", Name: "<script>alert(1)</script> Kettle", Description: "Steel & glass"},
+		"p5": {ID: "p5", Name: "<script>alert(1)</script> Kettles", Description: "Steel & glass"},
+	}
+	pairs := [][2]string{{"p1", "p2"}, {"p4", "p5"}, {"p2", "p3"}}
+	results := make([]duplicatecheck.ComparisonResult, len(pairs))
+	for i, p := range pairs {
+		results[i] = engine.Compare(products[p[0]], products[p[1]])
+	}
+	return results
+}
+
+func TestGenerateReport(t *testing.T) {
+	results := fixedResults()
+
+	tests := []struct {
+		name       string
+		opts       ReportOptions
+		wantGroups [][]string // Product IDs of each group, in order
+		wantShown  int
+	}{
+		{"Clusters", DefaultReportOptions(), [][]string{{"p4", "p5"}, {"p1", "p2", "p3"}}, 3},
+		{"By ProductA", ReportOptions{GroupBy: GroupByProductA, Sort: SortByID}, [][]string{{"p1", "p2"}, {"p2", "p3"}, {"p4", "p5"}}, 3},
+		{"Max pairs", ReportOptions{Sort: SortByID, MaxPairs: 2}, [][]string{{"p1", "p2", "p3"}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := GenerateReport(results, tt.opts)
+			var groups [][]string
+			for _, g := range r.Groups {
+				groups = append(groups, g.ProductIDs)
+			}
+			if !reflect.DeepEqual(groups, tt.wantGroups) {
+				t.Errorf("Groups = %v, want %v", groups, tt.wantGroups)
+			}
+			if r.Summary.TotalPairs != 3 || r.Summary.ShownPairs != tt.wantShown || r.Summary.Products != 5 {
+				t.Errorf("Summary = %+v", r.Summary)
+			}
+			if top := r.Summary.TopProducts[0]; top.ID != "p2" || top.Pairs != 2 {
+				t.Errorf("Most duplicated product = %+v, want p2 with 2 pairs", top)
+			}
+		})
+	}
+
+	t.Run("Description truncation", func(t *testing.T) {
+		r := GenerateReport(results, ReportOptions{Sort: SortByID, MaxDescriptionLength: 10})
+		if got := r.Groups[0].Pairs[0].A.Description; got != "128GB grap…" {
+			t.Errorf("Truncated description = %q", got)
+		}
+		r = GenerateReport(results, ReportOptions{MaxDescriptionLength: -1})
+		if got := r.Groups[0].Pairs[0].A.Description; got != "" {
+			t.Errorf("Hidden description = %q", got)
+		}
+	})
+
+	t.Run("Redaction", func(t *testing.T) {
+		engine := duplicatecheck.NewLevenshteinEngine()
+		a := duplicatecheck.Product{ID: "s1", Name: "Desk Lamp", Description: "Call 555-123-4567 or mail seller@example.com"}
+		b := duplicatecheck.Product{ID: "s2", Name: "Desk Lamp", Description: "Call 555-123-4568 or mail seller@example.com"}
+		opts := DefaultReportOptions()
+		opts.Redactor = duplicatecheck.DefaultPIIRedactor()
+		r := GenerateReport([]duplicatecheck.ComparisonResult{engine.Compare(a, b)}, opts)
+		pair := r.Groups[0].Pairs[0]
+		if want := "Call [redacted] or mail [redacted]"; pair.A.Description != want || pair.B.Description != want {
+			t.Errorf("Descriptions %q and %q, want %q", pair.A.Description, pair.B.Description, want)
+		}
+	})
+}
+
+func TestNewBar(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{0, "░░░░"},
+		{0.5, "██░░"},
+		{1, "████"},
+		{1.5, "████"},
+		{-1, "░░░░"},
+	}
+	for _, tt := range tests {
+		if got := NewBar(tt.value, 4).String(); got != tt.want {
+			t.Errorf("NewBar(%v, 4) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	r := GenerateReport(fixedResults(), DefaultReportOptions())
+	writers := map[string]func(*bytes.Buffer) error{
+		"Markdown": func(b *bytes.Buffer) error { return r.WriteMarkdown(b) },
+		"HTML":     func(b *bytes.Buffer) error { return r.WriteHTML(b) },
+	}
+	for name, write := range writers {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := write(&buf); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, want := range []string{"Duplicate report", "Total pairs", ">3<", "Cluster 1", "Cluster 2", "p1", "p2", "p3", "p4", "p5", "&lt;script&gt;", "max", "combined_similarity"} {
+				if name == "Markdown" && want == ">3<" {
+					want = "Total pairs: 3"
+				}
+				if !strings.Contains(out, want) {
+					t.Errorf("Output lacks %q", want)
+				}
+			}
+			if strings.Contains(out, "<script>") {
+				t.Error("Product name markup was not escaped")
+			}
+		})
+	}
+
+	t.Run("HTML is self-contained", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := r.WriteHTML(&buf); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, external := range []string{"<link", "<script", "src=", "http://", "https://"} {
+			if strings.Contains(out, external) {
+				t.Errorf("HTML references %q", external)
+			}
+		}
+		if !strings.Contains(out, "<style>") || !strings.Contains(out, `class="bar"`) || !strings.Contains(out, "<ins>max</ins>") {
+			t.Error("HTML lacks inline CSS, bars or the name diff")
+		}
+	})
+
+	t.Run("Markdown bars and name diff", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := r.WriteMarkdown(&buf); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if !strings.Contains(out, "█") || !strings.Contains(out, "**max**") {
+			t.Error("Markdown lacks unicode bars or the name diff")
+		}
+	})
+}
+
+func TestReportConfigFingerprint(t *testing.T) {
+	opts := DefaultReportOptions()
+	opts.ConfigFingerprint = duplicatecheck.NewLevenshteinEngine().ConfigFingerprint()
+	r := GenerateReport(fixedResults(), opts)
+	for name, write := range map[string]func(*bytes.Buffer) error{
+		"Markdown": func(b *bytes.Buffer) error { return r.WriteMarkdown(b) },
+		"HTML":     func(b *bytes.Buffer) error { return r.WriteHTML(b) },
+	} {
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		header, _, _ := strings.Cut(buf.String(), "Summary")
+		if !strings.Contains(header, opts.ConfigFingerprint) {
+			t.Errorf("%s header lacks the config fingerprint %s", name, opts.ConfigFingerprint)
+		}
+	}
+
+	// Without one, nothing is shown
+	var buf bytes.Buffer
+	if err := GenerateReport(fixedResults(), DefaultReportOptions()).WriteMarkdown(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "configuration") {
+		t.Error("report without a fingerprint shows one")
+	}
+}
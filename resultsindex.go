@@ -0,0 +1,147 @@
+package duplicatecheck
+
+import (
+	"sort"
+	"sync"
+)
+
+// ResultsIndex answers per-product questions about a set of duplicate pairs,
+// such as "everything flagged against SKU-12345", without rescanning them
+// The index holds the results and, per product ID, the positions of its pairs;
+// Products are never copied. Results are indexed lazily, on the first query
+// after they were added, so Add can be fed by a streaming scan (see Yield) and
+// queried while it runs. Safe for concurrent use.
+type ResultsIndex struct {
+	mu      sync.Mutex
+	results []ComparisonResult
+	indexed int              // Results already in byID
+	byID    map[string][]int // Product ID -> positions in results
+}
+
+// ProductDegree is the number of pairs a product participates in
+type ProductDegree struct {
+	ID     string
+	Degree int
+}
+
+// NewResultsIndex returns an index over results
+// The slice is referenced, not copied, and must not be modified afterwards;
+// results added later never write into it.
+func NewResultsIndex(results []ComparisonResult) *ResultsIndex {
+	return &ResultsIndex{
+		results: results[:len(results):len(results)],
+		byID:    make(map[string][]int),
+	}
+}
+
+// Add adds one result to the index
+func (x *ResultsIndex) Add(result ComparisonResult) {
+	x.mu.Lock()
+	x.results = append(x.results, result)
+	x.mu.Unlock()
+}
+
+// Yield adds result and returns true, so the index can be passed directly as
+// the callback of a streaming scan such as Pipeline.Stream
+func (x *ResultsIndex) Yield(result ComparisonResult) bool {
+	x.Add(result)
+	return true
+}
+
+// Len returns the number of results in the index
+func (x *ResultsIndex) Len() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return len(x.results)
+}
+
+// catchUp indexes the results added since the last query; x.mu must be held
+func (x *ResultsIndex) catchUp() {
+	for ; x.indexed < len(x.results); x.indexed++ {
+		r := &x.results[x.indexed]
+		x.byID[r.ProductA.ID] = append(x.byID[r.ProductA.ID], x.indexed)
+		if r.ProductB.ID != r.ProductA.ID {
+			x.byID[r.ProductB.ID] = append(x.byID[r.ProductB.ID], x.indexed)
+		}
+	}
+}
+
+// sortedPositions returns the positions of id's pairs, most similar first
+// (ties by partner ID); x.mu must be held
+func (x *ResultsIndex) sortedPositions(id string) []int {
+	x.catchUp()
+	positions := append([]int(nil), x.byID[id]...)
+	sort.SliceStable(positions, func(i, j int) bool {
+		a, b := &x.results[positions[i]], &x.results[positions[j]]
+		if a.CombinedSimilarity != b.CombinedSimilarity {
+			return a.CombinedSimilarity > b.CombinedSimilarity
+		}
+		return partnerID(a, id) < partnerID(b, id)
+	})
+	return positions
+}
+
+// partnerID returns the ID of the product paired with id in r
+func partnerID(r *ComparisonResult, id string) string {
+	if r.ProductA.ID == id {
+		return r.ProductB.ID
+	}
+	return r.ProductA.ID
+}
+
+// PairsFor returns every pair product id participates in, most similar first
+func (x *ResultsIndex) PairsFor(id string) []ComparisonResult {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	positions := x.sortedPositions(id)
+	pairs := make([]ComparisonResult, len(positions))
+	for i, p := range positions {
+		pairs[i] = x.results[p]
+	}
+	return pairs
+}
+
+// PartnerIDs returns the IDs paired with product id, in PairsFor order
+func (x *ResultsIndex) PartnerIDs(id string) []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	positions := x.sortedPositions(id)
+	partners := make([]string, len(positions))
+	for i, p := range positions {
+		partners[i] = partnerID(&x.results[p], id)
+	}
+	return partners
+}
+
+// Degree returns the number of pairs product id participates in
+func (x *ResultsIndex) Degree(id string) int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.catchUp()
+	return len(x.byID[id])
+}
+
+// TopOffenders returns the n products participating in the most pairs, most
+// first (ties by ID); n <= 0 returns every product
+// Products with many duplicates usually point at a data-quality problem worth
+// fixing at the source, such as a placeholder name or a template description.
+func (x *ResultsIndex) TopOffenders(n int) []ProductDegree {
+	x.mu.Lock()
+	x.catchUp()
+	degrees := make([]ProductDegree, 0, len(x.byID))
+	for id, positions := range x.byID {
+		degrees = append(degrees, ProductDegree{ID: id, Degree: len(positions)})
+	}
+	x.mu.Unlock()
+
+	sort.Slice(degrees, func(i, j int) bool {
+		if degrees[i].Degree != degrees[j].Degree {
+			return degrees[i].Degree > degrees[j].Degree
+		}
+		return degrees[i].ID < degrees[j].ID
+	})
+	if n > 0 && n < len(degrees) {
+		degrees = degrees[:n]
+	}
+	return degrees
+}
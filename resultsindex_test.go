@@ -0,0 +1,112 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"testing"
+)
+
+// scoredPair returns a result pairing products a and b at similarity
+func scoredPair(a, b string, similarity float64) ComparisonResult {
+	return ComparisonResult{ProductA: Product{ID: a}, ProductB: Product{ID: b}, CombinedSimilarity: similarity}
+}
+
+func TestResultsIndex(t *testing.T) {
+	// HUB is a placeholder product matching five others; the rest form small groups
+	results := []ComparisonResult{
+		scoredPair("HUB", "P1", 0.82),
+		scoredPair("P2", "HUB", 0.95),
+		scoredPair("HUB", "P3", 0.88),
+		scoredPair("P4", "HUB", 0.88),
+		scoredPair("HUB", "P5", 0.91),
+		scoredPair("P1", "P2", 0.90),
+		scoredPair("P6", "P7", 0.97),
+	}
+	index := NewResultsIndex(results)
+
+	tests := []struct {
+		id       string
+		degree   int
+		partners []string
+	}{
+		{"HUB", 5, []string{"P2", "P5", "P3", "P4", "P1"}},
+		{"P1", 2, []string{"P2", "HUB"}},
+		{"P7", 1, []string{"P6"}},
+		{"missing", 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := index.Degree(tt.id); got != tt.degree {
+				t.Errorf("Degree = %d, want %d", got, tt.degree)
+			}
+			if got := index.PartnerIDs(tt.id); !reflect.DeepEqual(got, tt.partners) {
+				t.Errorf("PartnerIDs = %v, want %v", got, tt.partners)
+			}
+			pairs := index.PairsFor(tt.id)
+			if len(pairs) != tt.degree {
+				t.Fatalf("PairsFor returned %d pairs, want %d", len(pairs), tt.degree)
+			}
+			for i := 1; i < len(pairs); i++ {
+				if pairs[i].CombinedSimilarity > pairs[i-1].CombinedSimilarity {
+					t.Errorf("PairsFor not sorted by similarity: %v before %v", pairs[i-1].CombinedSimilarity, pairs[i].CombinedSimilarity)
+				}
+			}
+		})
+	}
+
+	want := []ProductDegree{{"HUB", 5}, {"P1", 2}, {"P2", 2}}
+	if got := index.TopOffenders(3); !reflect.DeepEqual(got, want) {
+		t.Errorf("TopOffenders(3) = %v, want %v", got, want)
+	}
+	if got := len(index.TopOffenders(0)); got != 8 {
+		t.Errorf("TopOffenders(0) returned %d products, want 8", got)
+	}
+}
+
+func TestResultsIndexIncremental(t *testing.T) {
+	results := make([]ComparisonResult, 1, 2)
+	results[0] = scoredPair("A", "B", 0.9)
+	index := NewResultsIndex(results[:1:2])
+	if got := index.Degree("A"); got != 1 {
+		t.Fatalf("Degree(A) = %d, want 1", got)
+	}
+
+	// Results streamed in after a query are indexed on the next one, and
+	// never written into the caller's slice
+	for _, r := range []ComparisonResult{scoredPair("C", "A", 0.95), scoredPair("C", "B", 0.85)} {
+		if !index.Yield(r) {
+			t.Fatal("Yield returned false")
+		}
+	}
+	if got := index.PartnerIDs("A"); !reflect.DeepEqual(got, []string{"C", "B"}) {
+		t.Errorf("PartnerIDs(A) = %v, want [C B]", got)
+	}
+	if got := index.TopOffenders(1); !reflect.DeepEqual(got, []ProductDegree{{"A", 2}}) {
+		t.Errorf("TopOffenders(1) = %v", got)
+	}
+	if index.Len() != 3 || results[:2][1].ProductA.ID != "" {
+		t.Error("Add wrote into the slice passed to NewResultsIndex")
+	}
+}
+
+func TestResultsIndexFromStream(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, 100)
+	pipeline := &Pipeline{Generator: AllPairsGenerator{}, Verifier: LevenshteinVerifier{Engine: NewLevenshteinEngine()}, Workers: 4}
+
+	index := NewResultsIndex(nil)
+	if err := pipeline.Stream(products, 0.8, index.Yield); err != nil {
+		t.Fatal(err)
+	}
+	results, err := pipeline.Run(products, 0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Len() != len(results) || len(results) == 0 {
+		t.Fatalf("streamed %d results, Run found %d", index.Len(), len(results))
+	}
+	batch := NewResultsIndex(results)
+	for _, p := range products {
+		if got, want := index.Degree(p.ID), batch.Degree(p.ID); got != want {
+			t.Fatalf("Degree(%s) = %d streamed, %d from Run", p.ID, got, want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package duplicatecheck
+
+import "sort"
+
+// DefaultSummaryTiers are the similarity bands SummarizeResults counts pairs
+// in when SummaryOptions.Tiers is empty
+var DefaultSummaryTiers = []float64{0.95, 0.90, 0.80}
+
+// SummaryOptions controls SummarizeResults
+type SummaryOptions struct {
+	// Tiers are the similarity bands to count pairs in, highest first, as for
+	// FindDuplicatesTiered (nil = DefaultSummaryTiers)
+	Tiers []float64
+	// GroupBy returns a product's group, such as its brand, for a per-group
+	// breakdown (nil = no breakdown)
+	GroupBy func(Product) string
+}
+
+// ResultsSummary aggregates a set of results for a dashboard
+// It holds only counts and means, never products, and encodes to JSON as is.
+type ResultsSummary struct {
+	Pairs          int     `json:"pairs"`           // Results summarized
+	Products       int     `json:"products"`        // Distinct product IDs in the results
+	MeanSimilarity float64 `json:"mean_similarity"` // Mean CombinedSimilarity (0 without pairs)
+	MinSimilarity  float64 `json:"min_similarity"`  // Lowest CombinedSimilarity (0 without pairs)
+	MaxSimilarity  float64 `json:"max_similarity"`  // Highest CombinedSimilarity (0 without pairs)
+
+	Bands      []BandCount `json:"bands"`       // Pairs in each tier, counted in the highest tier they clear
+	BelowBands int         `json:"below_bands"` // Pairs below the lowest tier
+
+	MatchTypes  map[string]int     `json:"match_types"`            // Pairs per MatchType name
+	ReasonCodes map[ReasonCode]int `json:"reason_codes,omitempty"` // Pairs carrying each reason code
+
+	// Which field drove each pair: the one with the larger weighted
+	// similarity (weight from WeightsUsed times the field's similarity)
+	NameDriven        int `json:"name_driven"`
+	DescriptionDriven int `json:"description_driven"`
+	Balanced          int `json:"balanced"` // Equal contributions, including unweighted identifier matches
+
+	Groups []GroupSummary `json:"groups,omitempty"` // Per-group breakdown, most pairs first (see SummaryOptions.GroupBy)
+}
+
+// BandCount is the number of pairs in one similarity band
+type BandCount struct {
+	Threshold float64 `json:"threshold"` // Lowest CombinedSimilarity in the band
+	Pairs     int     `json:"pairs"`     // Pairs at or above Threshold and below the next band up
+}
+
+// GroupSummary aggregates the pairs touching one group
+// A pair counts under each of its products' groups, once when both share one.
+type GroupSummary struct {
+	Group          string  `json:"group"`
+	Pairs          int     `json:"pairs"`           // Pairs with a product in the group
+	Products       int     `json:"products"`        // Distinct products of the group in those pairs
+	MeanSimilarity float64 `json:"mean_similarity"` // Mean CombinedSimilarity of those pairs
+}
+
+// groupProduct keys a product within a group
+type groupProduct struct {
+	group, id string
+}
+
+// SummarizeResults aggregates results in a single pass: similarity bands,
+// distinct products, match types, reason codes, which field drove each pair,
+// and, with opts.GroupBy, a per-group breakdown
+// Returns ErrInvalidTiers for tiers FindDuplicatesTiered would reject.
+func SummarizeResults(results []ComparisonResult, opts SummaryOptions) (ResultsSummary, error) {
+	tiers := opts.Tiers
+	if len(tiers) == 0 {
+		tiers = DefaultSummaryTiers
+	}
+	if err := validateTiers(tiers); err != nil {
+		return ResultsSummary{}, err
+	}
+
+	summary := ResultsSummary{
+		Pairs:      len(results),
+		Bands:      make([]BandCount, len(tiers)),
+		MatchTypes: make(map[string]int),
+	}
+	for i, tier := range tiers {
+		summary.Bands[i].Threshold = tier
+	}
+	products := make(map[string]struct{}, 2*len(results))
+	var groups map[string]*GroupSummary
+	var groupSums map[string]float64
+	var groupProducts map[groupProduct]struct{}
+	if opts.GroupBy != nil {
+		groups = make(map[string]*GroupSummary)
+		groupSums = make(map[string]float64)
+		groupProducts = make(map[groupProduct]struct{})
+	}
+
+	var sum float64
+	for i := range results {
+		r := &results[i]
+		similarity := r.CombinedSimilarity
+		sum += similarity
+		if i == 0 || similarity < summary.MinSimilarity {
+			summary.MinSimilarity = similarity
+		}
+		if i == 0 || similarity > summary.MaxSimilarity {
+			summary.MaxSimilarity = similarity
+		}
+
+		banded := false
+		for j, tier := range tiers {
+			if meetsThreshold(similarity, tier) {
+				summary.Bands[j].Pairs++
+				banded = true
+				break
+			}
+		}
+		if !banded {
+			summary.BelowBands++
+		}
+
+		products[r.ProductA.ID] = struct{}{}
+		products[r.ProductB.ID] = struct{}{}
+		summary.MatchTypes[r.MatchType.String()]++
+		for _, code := range r.ReasonCodes {
+			if summary.ReasonCodes == nil {
+				summary.ReasonCodes = make(map[ReasonCode]int)
+			}
+			summary.ReasonCodes[code]++
+		}
+
+		name := r.WeightsUsed.NameWeight * r.NameSimilarity
+		description := r.WeightsUsed.DescriptionWeight * r.DescriptionSimilarity
+		switch {
+		case name > description:
+			summary.NameDriven++
+		case description > name:
+			summary.DescriptionDriven++
+		default:
+			summary.Balanced++
+		}
+
+		if opts.GroupBy != nil {
+			groupA, groupB := opts.GroupBy(r.ProductA), opts.GroupBy(r.ProductB)
+			for k, member := range [2]groupProduct{{groupA, r.ProductA.ID}, {groupB, r.ProductB.ID}} {
+				group := groups[member.group]
+				if group == nil {
+					group = &GroupSummary{Group: member.group}
+					groups[member.group] = group
+				}
+				if _, seen := groupProducts[member]; !seen {
+					groupProducts[member] = struct{}{}
+					group.Products++
+				}
+				if k == 1 && groupA == groupB {
+					continue // The pair counts once under a group both products share
+				}
+				group.Pairs++
+				groupSums[member.group] += similarity
+			}
+		}
+	}
+
+	summary.Products = len(products)
+	if len(results) > 0 {
+		summary.MeanSimilarity = sum / float64(len(results))
+	}
+	for name, group := range groups {
+		group.MeanSimilarity = groupSums[name] / float64(group.Pairs)
+		summary.Groups = append(summary.Groups, *group)
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		a, b := summary.Groups[i], summary.Groups[j]
+		if a.Pairs != b.Pairs {
+			return a.Pairs > b.Pairs
+		}
+		return a.Group < b.Group
+	})
+	return summary, nil
+}
@@ -0,0 +1,127 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// summaryResults is a fixed result set for SummarizeResults, with its
+// aggregates worked out by hand in TestSummarizeResults
+func summaryResults() []ComparisonResult {
+	product := func(id, name string) Product { return Product{ID: id, Name: name} }
+	sony1, sony2 := product("sony-1", "Sony WH-1000XM5"), product("sony-2", "Sony WH1000XM5")
+	bose1, bose2 := product("bose-1", "Bose QC45"), product("bose-2", "Bose QC45 Headphones")
+	apple1, apple2 := product("apple-1", "Apple iPhone 15"), product("apple-2", "Apple iPhone 15 128GB")
+	nameHeavy := ComparisonWeights{NameWeight: 0.7, DescriptionWeight: 0.3}
+	even := ComparisonWeights{NameWeight: 0.5, DescriptionWeight: 0.5}
+	return []ComparisonResult{
+		// Name 0.7 vs description 0.27: name-driven
+		{ProductA: sony1, ProductB: sony2, NameSimilarity: 1.0, DescriptionSimilarity: 0.9, CombinedSimilarity: 0.97,
+			WeightsUsed: nameHeavy, ReasonCodes: []ReasonCode{ReasonCombinedSimilarity, ReasonHighNameSimilarity}},
+		// Name 0.14 vs description 0.288: description-driven
+		{ProductA: sony1, ProductB: bose1, NameSimilarity: 0.2, DescriptionSimilarity: 0.96, CombinedSimilarity: 0.96,
+			WeightsUsed: nameHeavy, MatchType: MatchCopiedDescription, ReasonCodes: []ReasonCode{ReasonCopiedDescription}},
+		// Name 0.4 vs description 0.475: description-driven
+		{ProductA: bose1, ProductB: bose2, NameSimilarity: 0.8, DescriptionSimilarity: 0.95, CombinedSimilarity: 0.85,
+			WeightsUsed: even, ReasonCodes: []ReasonCode{ReasonCombinedSimilarity}},
+		// No weights: balanced
+		{ProductA: apple1, ProductB: apple2, CombinedSimilarity: 1.0,
+			MatchType: MatchIdentifier, ReasonCodes: []ReasonCode{ReasonIdentifierMatch}},
+		// 0.375 each: balanced
+		{ProductA: apple1, ProductB: sony2, NameSimilarity: 0.75, DescriptionSimilarity: 0.75, CombinedSimilarity: 0.75,
+			WeightsUsed: even, ReasonCodes: []ReasonCode{ReasonCombinedSimilarity}},
+	}
+}
+
+// brand groups a product by the first word of its name
+func brand(p Product) string {
+	return strings.ToLower(strings.Fields(p.Name)[0])
+}
+
+func TestSummarizeResults(t *testing.T) {
+	summary, err := SummarizeResults(summaryResults(), SummaryOptions{GroupBy: brand})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Pairs != 5 || summary.Products != 6 {
+		t.Errorf("%d pairs of %d products, want 5 of 6", summary.Pairs, summary.Products)
+	}
+	// (0.97 + 0.96 + 0.85 + 1.0 + 0.75) / 5
+	if math.Abs(summary.MeanSimilarity-0.906) > 1e-9 || summary.MinSimilarity != 0.75 || summary.MaxSimilarity != 1.0 {
+		t.Errorf("similarity mean %v, min %v, max %v; want 0.906, 0.75, 1", summary.MeanSimilarity, summary.MinSimilarity, summary.MaxSimilarity)
+	}
+	wantBands := []BandCount{{0.95, 3}, {0.90, 0}, {0.80, 1}}
+	if !reflect.DeepEqual(summary.Bands, wantBands) || summary.BelowBands != 1 {
+		t.Errorf("bands %v, %d below; want %v, 1 below", summary.Bands, summary.BelowBands, wantBands)
+	}
+	wantTypes := map[string]int{"fuzzy": 3, "copied-description": 1, "identifier": 1}
+	if !reflect.DeepEqual(summary.MatchTypes, wantTypes) {
+		t.Errorf("match types %v, want %v", summary.MatchTypes, wantTypes)
+	}
+	wantCodes := map[ReasonCode]int{
+		ReasonCombinedSimilarity: 3, ReasonHighNameSimilarity: 1, ReasonCopiedDescription: 1, ReasonIdentifierMatch: 1,
+	}
+	if !reflect.DeepEqual(summary.ReasonCodes, wantCodes) {
+		t.Errorf("reason codes %v, want %v", summary.ReasonCodes, wantCodes)
+	}
+	if summary.NameDriven != 1 || summary.DescriptionDriven != 2 || summary.Balanced != 2 {
+		t.Errorf("%d name-driven, %d description-driven, %d balanced; want 1, 2, 2",
+			summary.NameDriven, summary.DescriptionDriven, summary.Balanced)
+	}
+
+	// sony: pairs 1, 2 and 5; apple: 4 and 5; bose: 2 and 3. Pair 1 and 3
+	// count once under the group both products share.
+	wantGroups := []GroupSummary{
+		{Group: "sony", Pairs: 3, Products: 2, MeanSimilarity: (0.97 + 0.96 + 0.75) / 3},
+		{Group: "apple", Pairs: 2, Products: 2, MeanSimilarity: (1.0 + 0.75) / 2},
+		{Group: "bose", Pairs: 2, Products: 2, MeanSimilarity: (0.96 + 0.85) / 2},
+	}
+	if len(summary.Groups) != len(wantGroups) {
+		t.Fatalf("groups %+v, want %+v", summary.Groups, wantGroups)
+	}
+	for i, want := range wantGroups {
+		got := summary.Groups[i]
+		if got.Group != want.Group || got.Pairs != want.Pairs || got.Products != want.Products ||
+			math.Abs(got.MeanSimilarity-want.MeanSimilarity) > 1e-9 {
+			t.Errorf("group %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ResultsSummary
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, summary) {
+		t.Errorf("JSON round trip changed the summary:\n%+v\n%+v", decoded, summary)
+	}
+}
+
+func TestSummarizeResultsOptions(t *testing.T) {
+	summary, err := SummarizeResults(summaryResults(), SummaryOptions{Tiers: []float64{0.9, 0.7}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []BandCount{{0.9, 3}, {0.7, 2}}; !reflect.DeepEqual(summary.Bands, want) || summary.BelowBands != 0 {
+		t.Errorf("bands %v, %d below; want %v, none below", summary.Bands, summary.BelowBands, want)
+	}
+	if summary.Groups != nil {
+		t.Errorf("groups %v without GroupBy", summary.Groups)
+	}
+
+	if _, err := SummarizeResults(nil, SummaryOptions{Tiers: []float64{0.8, 0.9}}); !errors.Is(err, ErrInvalidTiers) {
+		t.Errorf("increasing tiers: err %v, want ErrInvalidTiers", err)
+	}
+	empty, err := SummarizeResults(nil, SummaryOptions{})
+	if err != nil || empty.Pairs != 0 || empty.MeanSimilarity != 0 || len(empty.Bands) != len(DefaultSummaryTiers) {
+		t.Errorf("no results: %+v, %v", empty, err)
+	}
+}
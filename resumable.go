@@ -0,0 +1,263 @@
+package duplicatecheck
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCheckpointInterval is the number of pairs between resumable scan checkpoints
+const DefaultCheckpointInterval = 100000
+
+// resumableBlockPairs is the number of consecutive pairs a parallel worker claims at once
+const resumableBlockPairs = 1024
+
+// ErrCursorMismatch is returned when a ScanCursor is resumed against a different
+// catalog or threshold than the scan it came from
+var ErrCursorMismatch = errors.New("duplicatecheck: scan cursor does not match the catalog")
+
+// ScanCursor records the progress of a resumable scan
+// Pairs are numbered in row order, (0,1), (0,2), ..., (1,2), ... (see PairIndex),
+// and every pair before Pair has been compared and its match, if any, written.
+// The zero value starts a new scan. Cursors are plain values and can be stored
+// as JSON.
+type ScanCursor struct {
+	Catalog   uint64  `json:"catalog"`   // Fingerprint of the product IDs and contents, in order
+	Threshold float64 `json:"threshold"` // Threshold the scan runs at
+	Pair      uint64  `json:"pair"`      // Index of the next pair to compare
+	Total     uint64  `json:"total"`     // Number of pairs in the scan
+}
+
+// Done reports whether the cursor's scan has compared every pair
+func (c ScanCursor) Done() bool {
+	return c.Catalog != 0 && c.Pair >= c.Total
+}
+
+// ResultWriter receives the matches of a resumable scan, in pair order
+type ResultWriter interface {
+	WriteResult(result ComparisonResult) error
+}
+
+// ScanCheckpointer is implemented by ResultWriters that persist scan progress
+// Checkpoint is called every checkpoint interval (see SetCheckpointInterval)
+// after every match before cursor has been written, so a writer that stores
+// the cursor together with its output can resume from it after a crash.
+type ScanCheckpointer interface {
+	Checkpoint(cursor ScanCursor) error
+}
+
+// PairIndex returns the stable index of pair (i, j) among n products, i < j
+// Pairs are numbered in row order: (0,1) is 0, (0,n-1) is n-2, (1,2) is n-1.
+func PairIndex(i, j, n int) uint64 {
+	return rowStart(i, n) + uint64(j-i-1)
+}
+
+// PairAt returns the pair (i < j) with the given PairIndex among n products
+func PairAt(index uint64, n int) (i, j int) {
+	// Last row whose first index is at or below index
+	i = sort.Search(n-1, func(row int) bool { return rowStart(row+1, n) > index })
+	return i, i + 1 + int(index-rowStart(i, n))
+}
+
+// rowStart is the index of pair (i, i+1)
+func rowStart(i, n int) uint64 {
+	return uint64(i) * uint64(2*n-i-1) / 2
+}
+
+// SetCheckpointInterval sets the number of pairs between resumable scan
+// checkpoints (default DefaultCheckpointInterval)
+// Parallel scans compare one interval at a time, so it also bounds the
+// matches held in memory.
+func (e *LevenshteinEngine) SetCheckpointInterval(pairs int) {
+	e.checkpointInterval = pairs
+}
+
+// GetCheckpointInterval returns the number of pairs between resumable scan checkpoints
+func (e *LevenshteinEngine) GetCheckpointInterval() int {
+	if e.checkpointInterval < 1 {
+		return DefaultCheckpointInterval
+	}
+	return e.checkpointInterval
+}
+
+// FindDuplicatesResumable is FindDuplicates in resumable steps: it compares the
+// pairs from cursor on, writes each match to sink in pair order, and returns
+// the cursor reached.
+//
+// A zero cursor starts a new scan. If sink fails, the scan stops and the
+// returned cursor points at the pair whose match was not written; calling
+// again with it continues with no pair skipped or repeated. The cursor embeds
+// a fingerprint of the catalog (IDs and contents, in order) and the threshold;
+// resuming with anything else returns ErrCursorMismatch. Sinks implementing
+// ScanCheckpointer also receive the cursor every checkpoint interval.
+//
+// Catalogs over 50 products are compared in parallel one checkpoint interval
+// at a time, with matches sorted back into pair order before they are written.
+func (e *LevenshteinEngine) FindDuplicatesResumable(products []Product, threshold float64, cursor ScanCursor, sink ResultWriter) (_ ScanCursor, err error) {
+	defer e.guard("FindDuplicatesResumable", &err)
+	if err := validateThreshold(threshold); err != nil {
+		return cursor, err
+	}
+	resolved, collapsed, err := e.resolveProducts(products)
+	if err != nil {
+		return cursor, err
+	}
+	ptrs := productPtrs(resolved)
+	quality := e.newQualityCheck()
+	if quality != nil {
+		ptrs = quality.products(ptrs)
+	}
+
+	n := len(ptrs)
+	start := ScanCursor{Catalog: catalogFingerprint(ptrs), Threshold: threshold}
+	if n > 1 {
+		start.Total = PairIndex(n-2, n-1, n) + 1
+	}
+	if cursor == (ScanCursor{}) {
+		cursor = start
+	} else if cursor.Catalog != start.Catalog || cursor.Threshold != threshold || cursor.Total != start.Total || cursor.Pair > cursor.Total {
+		return cursor, fmt.Errorf("%w (cursor catalog %016x, products %016x)", ErrCursorMismatch, cursor.Catalog, start.Catalog)
+	}
+	if cursor.Done() {
+		return cursor, nil
+	}
+
+	if e.logger != nil {
+		logScanStarted(e.logger, "levenshtein", "resumable", n, threshold)
+	}
+	started, found := time.Now(), 0
+	meter := e.startSummary(n)
+	meter.summary.InputDuplicatesCollapsed = collapsed
+	defer func() { e.reportSummary(meter, found) }()
+
+	warmCaches(ptrs, e.preparer())
+	memo := e.newScanMemo(ptrs)
+	evaluate := func(i, j int, scratch *comparisonScratch) (ComparisonResult, bool) {
+		if !e.pairAllowed(ptrs[i], ptrs[j]) {
+			return ComparisonResult{}, false
+		}
+		result, ok := e.comparePair(ptrs, i, j, memo, scratch, threshold)
+		if !ok {
+			return ComparisonResult{}, false
+		}
+		result.stampThreshold(threshold)
+		return result, result.MeetsThreshold
+	}
+	checkpointer, _ := sink.(ScanCheckpointer)
+	interval := uint64(e.GetCheckpointInterval())
+
+	for cursor.Pair < cursor.Total {
+		// Intervals are aligned to multiples of the interval, wherever the scan resumed
+		end := (cursor.Pair/interval + 1) * interval
+		if end > cursor.Total {
+			end = cursor.Total
+		}
+		matches := compareRange(n, cursor.Pair, end, e.scanWorkers(n, n > 50), evaluate)
+		atomic.AddUint64(&e.pairsCompared, end-cursor.Pair)
+		for _, match := range matches {
+			if quality != nil {
+				quality.flag(&match.result) // Low-quality products were excluded above, or are flagged here
+			}
+			if err := sink.WriteResult(match.result); err != nil {
+				cursor.Pair = match.index
+				if e.logger != nil {
+					logScanInterrupted(e.logger, "levenshtein", err, found)
+				}
+				return cursor, err
+			}
+			found++
+		}
+		cursor.Pair = end
+		if checkpointer != nil {
+			if err := checkpointer.Checkpoint(cursor); err != nil {
+				return cursor, err
+			}
+		}
+	}
+
+	if e.logger != nil {
+		logScanFinished(e.logger, "levenshtein", found, started)
+	}
+	return cursor, nil
+}
+
+// indexedMatch is a match with the index of its pair
+type indexedMatch struct {
+	index  uint64
+	result ComparisonResult
+}
+
+// compareRange evaluates the pairs with index in [from, to) on workers
+// goroutines, each with its own scratch buffers, and returns the kept results
+// in pair order
+func compareRange(n int, from, to uint64, workers int, evaluate func(i, j int, scratch *comparisonScratch) (ComparisonResult, bool)) []indexedMatch {
+	// evaluateBlock walks consecutive pairs from one unranked start
+	evaluateBlock := func(from, to uint64, matches []indexedMatch, scratch *comparisonScratch) []indexedMatch {
+		i, j := PairAt(from, n)
+		for k := from; k < to; k++ {
+			if result, keep := evaluate(i, j, scratch); keep {
+				matches = append(matches, indexedMatch{index: k, result: result})
+			}
+			if j++; j == n {
+				i++
+				j = i + 1
+			}
+		}
+		return matches
+	}
+	if workers <= 1 {
+		return evaluateBlock(from, to, nil, &comparisonScratch{})
+	}
+
+	var (
+		next    = int64(from) - resumableBlockPairs
+		mu      sync.Mutex
+		matches []indexedMatch
+		group   workerGroup
+	)
+	for w := workers; w > 0; w-- {
+		group.Go(func() {
+			var found []indexedMatch
+			scratch := &comparisonScratch{}
+			for !group.stopped() {
+				blockStart := uint64(atomic.AddInt64(&next, resumableBlockPairs))
+				if blockStart >= to {
+					break
+				}
+				blockEnd := blockStart + resumableBlockPairs
+				if blockEnd > to {
+					blockEnd = to
+				}
+				found = evaluateBlock(blockStart, blockEnd, found, scratch)
+			}
+			mu.Lock()
+			matches = append(matches, found...)
+			mu.Unlock()
+		})
+	}
+	group.Wait()
+
+	sort.Slice(matches, func(a, b int) bool { return matches[a].index < matches[b].index })
+	return matches
+}
+
+// catalogFingerprint hashes product IDs and content fingerprints in order
+func catalogFingerprint(products []*Product) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, p := range products {
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(p.ID)))
+		h.Write(buf[:])
+		h.Write([]byte(p.ID))
+		binary.LittleEndian.PutUint64(buf[:], p.Fingerprint())
+		h.Write(buf[:])
+	}
+	binary.LittleEndian.PutUint64(buf[:], uint64(len(products)))
+	h.Write(buf[:])
+	return h.Sum64()
+}
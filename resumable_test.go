@@ -0,0 +1,181 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// errSinkFull stops a resumable scan in tests
+var errSinkFull = errors.New("sink full")
+
+// limitedSink collects results and fails once limit writes or checkpoints have succeeded
+type limitedSink struct {
+	results        []ComparisonResult
+	cursors        []ScanCursor
+	writeLimit     int // Writes accepted before failing (-1 = unlimited)
+	checkpointStop int // Checkpoints accepted before failing (-1 = unlimited)
+}
+
+func (s *limitedSink) WriteResult(result ComparisonResult) error {
+	if s.writeLimit == 0 {
+		return errSinkFull
+	}
+	s.writeLimit--
+	s.results = append(s.results, result)
+	return nil
+}
+
+func (s *limitedSink) Checkpoint(cursor ScanCursor) error {
+	if s.checkpointStop == 0 {
+		return errSinkFull
+	}
+	s.checkpointStop--
+	s.cursors = append(s.cursors, cursor)
+	return nil
+}
+
+func TestPairIndex(t *testing.T) {
+	for _, n := range []int{2, 3, 7, 100} {
+		var k uint64
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				if got := PairIndex(i, j, n); got != k {
+					t.Fatalf("n=%d: PairIndex(%d, %d) = %d, want %d", n, i, j, got, k)
+				}
+				if gi, gj := PairAt(k, n); gi != i || gj != j {
+					t.Fatalf("n=%d: PairAt(%d) = (%d, %d), want (%d, %d)", n, k, gi, gj, i, j)
+				}
+				k++
+			}
+		}
+	}
+}
+
+func TestFindDuplicatesResumable(t *testing.T) {
+	const threshold = 0.7
+	tests := []struct {
+		name           string
+		size           int
+		writeLimit     int
+		checkpointStop int
+	}{
+		{"sequential, sink fails mid-interval", 40, 3, -1},
+		{"sequential, checkpoint fails", 40, -1, 2},
+		{"parallel, sink fails mid-interval", 120, 5, -1},
+		{"parallel, checkpoint fails", 120, -1, 3},
+		{"parallel, both", 120, 7, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := lengthDiverseCatalog(tt.size)
+			engine := NewLevenshteinEngine()
+			engine.SetCheckpointInterval(97)
+			want := engine.FindDuplicates(products, threshold)
+			if len(want) == 0 {
+				t.Fatal("expected results to compare")
+			}
+
+			var (
+				cursor  ScanCursor
+				results []ComparisonResult
+				err     error
+				runs    int
+			)
+			for !cursor.Done() {
+				if runs++; runs > 1000 {
+					t.Fatal("scan made no progress")
+				}
+				sink := &limitedSink{writeLimit: tt.writeLimit, checkpointStop: tt.checkpointStop}
+				before := cursor.Pair
+				cursor, err = engine.FindDuplicatesResumable(products, threshold, cursor, sink)
+				if err != nil && !errors.Is(err, errSinkFull) {
+					t.Fatalf("run %d: %v", runs, err)
+				}
+				if cursor.Pair < before {
+					t.Fatalf("run %d: cursor moved back from %d to %d", runs, before, cursor.Pair)
+				}
+				for _, c := range sink.cursors {
+					if c.Pair%97 != 0 && c.Pair != c.Total {
+						t.Errorf("checkpoint at %d is not interval-aligned", c.Pair)
+					}
+				}
+
+				// Round-trip the cursor as a caller persisting it would
+				data, err := json.Marshal(cursor)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cursor = ScanCursor{}
+				if err := json.Unmarshal(data, &cursor); err != nil {
+					t.Fatal(err)
+				}
+				results = append(results, sink.results...)
+			}
+			if runs < 2 {
+				t.Fatal("scan was never interrupted")
+			}
+
+			// Every run writes in pair order, so the union is in pair order too
+			seen := make(map[string]bool)
+			for _, r := range results {
+				key := PairKey(r.ProductA.ID, r.ProductB.ID)
+				if seen[key] {
+					t.Errorf("pair %s written twice", key)
+				}
+				seen[key] = true
+			}
+			if !reflect.DeepEqual(comparableResults(results), comparableResults(want)) {
+				t.Errorf("resumed scans found %d results, uninterrupted scan %d", len(results), len(want))
+			}
+			if tt.size <= 50 && !reflect.DeepEqual(resultPairs(results), resultPairs(want)) {
+				t.Error("resumed results are not in pair order")
+			}
+		})
+	}
+
+	t.Run("cursor mismatch", func(t *testing.T) {
+		products := lengthDiverseCatalog(30)
+		engine := NewLevenshteinEngine()
+		engine.SetCheckpointInterval(50)
+		cursor, err := engine.FindDuplicatesResumable(products, threshold, ScanCursor{}, &limitedSink{writeLimit: -1, checkpointStop: 1})
+		if !errors.Is(err, errSinkFull) || cursor.Pair != 100 {
+			t.Fatalf("cursor = %+v, err = %v; want a stop at pair 100", cursor, err)
+		}
+
+		edited := append([]Product(nil), products...)
+		edited[5].Description += " (refurbished)"
+		reordered := append([]Product(nil), products...)
+		reordered[0], reordered[1] = reordered[1], reordered[0]
+		cases := []struct {
+			name      string
+			products  []Product
+			threshold float64
+		}{
+			{"edited product", edited, threshold},
+			{"reordered products", reordered, threshold},
+			{"removed product", products[1:], threshold},
+			{"other threshold", products, 0.8},
+		}
+		for _, c := range cases {
+			if _, err := engine.FindDuplicatesResumable(c.products, c.threshold, cursor, &limitedSink{writeLimit: -1, checkpointStop: -1}); !errors.Is(err, ErrCursorMismatch) {
+				t.Errorf("%s: err = %v, want ErrCursorMismatch", c.name, err)
+			}
+		}
+
+		done, err := engine.FindDuplicatesResumable(products, threshold, cursor, &limitedSink{writeLimit: -1, checkpointStop: -1})
+		if err != nil || !done.Done() || done.Pair != done.Total || done.Total != 30*29/2 {
+			t.Errorf("resume = %+v, %v; want a finished scan of %d pairs", done, err, 30*29/2)
+		}
+	})
+
+	t.Run("tiny catalogs", func(t *testing.T) {
+		for n := 0; n < 2; n++ {
+			cursor, err := NewLevenshteinEngine().FindDuplicatesResumable(lengthDiverseCatalog(n), threshold, ScanCursor{}, &limitedSink{writeLimit: -1, checkpointStop: -1})
+			if err != nil || !cursor.Done() {
+				t.Errorf("%d products: cursor = %+v, err = %v", n, cursor, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// DefaultReviewSampleSize is the number of groups SampleClustersForReview returns by default
+const DefaultReviewSampleSize = 50
+
+// DefaultReviewSizeBands are the lower bounds of the group size bands:
+// 2, 3-4, 5-9, 10-49 and 50+ products
+var DefaultReviewSizeBands = []int{2, 3, 5, 10, 50}
+
+// DefaultReviewSimilarityBands are the lower bounds of the minimum similarity
+// bands above the lowest: below 0.85, 0.85-0.9, 0.9-0.95 and 0.95+
+var DefaultReviewSimilarityBands = []float64{0.85, 0.9, 0.95}
+
+// ReviewSampleOptions controls SampleClustersForReview
+type ReviewSampleOptions struct {
+	// Size is the number of groups returned (0 uses DefaultReviewSampleSize);
+	// with no more groups than Size, all of them are returned
+	Size int
+	// SizeBands are the ascending lower bounds of the product count bands
+	// (nil uses DefaultReviewSizeBands)
+	SizeBands []int
+	// SimilarityBands are the ascending lower bounds of the MinSimilarity
+	// bands; groups below the first bound form the lowest band (nil uses
+	// DefaultReviewSimilarityBands)
+	SimilarityBands []float64
+	// Seed picks the sample: the same groups, options and seed sample the
+	// same groups
+	Seed int64
+}
+
+// ReviewStratum is one size band crossed with one minimum similarity band
+type ReviewStratum struct {
+	MinSize       int     // Smallest group size in the band
+	MaxSize       int     // Largest group size in the band (0 = no limit)
+	MinSimilarity float64 // Lowest MinSimilarity in the band
+	MaxSimilarity float64 // MinSimilarity bound above the band (exclusive, 1 inclusive for the top band)
+	Groups        int     // Groups in the stratum
+	Sampled       int     // Groups sampled from it
+}
+
+// String describes the stratum, as "size 3-4, min similarity 0.85-0.90 (4 of 120 groups)"
+func (s *ReviewStratum) String() string {
+	size := fmt.Sprintf("%d-%d", s.MinSize, s.MaxSize)
+	switch {
+	case s.MaxSize == 0:
+		size = fmt.Sprintf("%d+", s.MinSize)
+	case s.MaxSize == s.MinSize:
+		size = fmt.Sprintf("%d", s.MinSize)
+	}
+	return fmt.Sprintf("size %s, min similarity %.2f-%.2f (%d of %d groups)",
+		size, s.MinSimilarity, s.MaxSimilarity, s.Sampled, s.Groups)
+}
+
+// SampleClustersForReview picks opts.Size groups for human review, stratified
+// by group size and by MinSimilarity, so large and loose clusters are seen
+// even when small tight ones dominate
+// Every non-empty stratum gets at least one group (only the largest strata
+// when Size is smaller than their number), and the rest is shared in
+// proportion to stratum sizes (Webster's method). Groups are drawn uniformly
+// within a stratum and returned stratum by stratum, in their input order
+// within one; each is a copy with Stratum set. The input is not modified.
+func SampleClustersForReview(clusters []DuplicateGroup, opts ReviewSampleOptions) []DuplicateGroup {
+	size := opts.Size
+	if size <= 0 {
+		size = DefaultReviewSampleSize
+	}
+	sizeBands := append([]int(nil), opts.SizeBands...)
+	if opts.SizeBands == nil {
+		sizeBands = append(sizeBands, DefaultReviewSizeBands...)
+	}
+	similarityBands := append([]float64(nil), opts.SimilarityBands...)
+	if opts.SimilarityBands == nil {
+		similarityBands = append(similarityBands, DefaultReviewSimilarityBands...)
+	}
+	sort.Ints(sizeBands)
+	sort.Float64s(similarityBands)
+
+	// Strata in band order, each listing its groups in input order
+	members := make(map[[2]int][]int)
+	var keys [][2]int
+	for i := range clusters {
+		key := [2]int{
+			sort.SearchInts(sizeBands, len(clusters[i].Products)+1),
+			sort.SearchFloat64s(similarityBands, clusters[i].MinSimilarity),
+		}
+		// SearchFloat64s finds the first bound >= value; a value on a bound belongs above it
+		if key[1] < len(similarityBands) && similarityBands[key[1]] == clusters[i].MinSimilarity {
+			key[1]++
+		}
+		if _, ok := members[key]; !ok {
+			keys = append(keys, key)
+		}
+		members[key] = append(members[key], i)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	counts := make([]int, len(keys))
+	for s, key := range keys {
+		counts[s] = len(members[key])
+	}
+	takes := allocateReviewSample(counts, size)
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	var sample []DuplicateGroup
+	for s, key := range keys {
+		if takes[s] == 0 {
+			continue
+		}
+		stratum := &ReviewStratum{Groups: counts[s], Sampled: takes[s]}
+		stratum.MinSize, stratum.MaxSize = reviewSizeBand(sizeBands, key[0])
+		stratum.MinSimilarity, stratum.MaxSimilarity = reviewSimilarityBand(similarityBands, key[1])
+
+		drawn := sampleIndexes(rng, counts[s], takes[s])
+		sort.Ints(drawn)
+		for _, i := range drawn {
+			group := clusters[members[key][i]]
+			group.Stratum = stratum
+			sample = append(sample, group)
+		}
+	}
+	return sample
+}
+
+// allocateReviewSample shares size draws between strata of the given counts:
+// one each (the largest strata when size is short), the rest by Webster's
+// method, capped by count
+func allocateReviewSample(counts []int, size int) []int {
+	takes := make([]int, len(counts))
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	if size >= total {
+		copy(takes, counts)
+		return takes
+	}
+	if size < len(counts) {
+		// Too few draws to cover every stratum: one for each of the largest
+		order := make([]int, len(counts))
+		for s := range order {
+			order[s] = s
+		}
+		sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+		for _, s := range order[:size] {
+			takes[s] = 1
+		}
+		return takes
+	}
+	for s := range takes {
+		takes[s] = 1
+	}
+	for given := len(counts); given < size; given++ {
+		best, bestPriority := -1, 0.0
+		for s, c := range counts {
+			if takes[s] == c {
+				continue
+			}
+			// Strictly greater keeps ties with the earlier stratum
+			if priority := float64(c) / (float64(takes[s]) + 0.5); best < 0 || priority > bestPriority {
+				best, bestPriority = s, priority
+			}
+		}
+		takes[best]++
+	}
+	return takes
+}
+
+// reviewSizeBand returns the size bounds of band i (max 0 = no limit)
+func reviewSizeBand(bands []int, i int) (min, max int) {
+	if i > 0 {
+		min = bands[i-1]
+	}
+	if i < len(bands) {
+		max = bands[i] - 1
+	}
+	return min, max
+}
+
+// reviewSimilarityBand returns the similarity bounds of band i
+func reviewSimilarityBand(bands []float64, i int) (min, max float64) {
+	max = 1
+	if i > 0 {
+		min = bands[i-1]
+	}
+	if i < len(bands) {
+		max = bands[i]
+	}
+	return min, max
+}
@@ -0,0 +1,200 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pairResult returns a scan result for two product IDs
+func pairResult(a, b string, similarity float64) ComparisonResult {
+	return ComparisonResult{ProductA: Product{ID: a}, ProductB: Product{ID: b}, CombinedSimilarity: similarity}
+}
+
+func TestClusterDuplicates(t *testing.T) {
+	results := []ComparisonResult{
+		pairResult("c", "d", 0.95),
+		pairResult("a", "b", 0.9),
+		pairResult("x", "y", 0.99),
+		pairResult("b", "e", 0.8),
+		pairResult("c", "a", 0.85),
+	}
+	groups := ClusterDuplicates(results, ClusterOptions{})
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	var ids []string
+	for _, p := range groups[0].Products {
+		ids = append(ids, p.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"a", "b", "c", "d", "e"}) || groups[0].Pairs != 4 {
+		t.Errorf("first group = %v with %d pairs", ids, groups[0].Pairs)
+	}
+	if groups[0].MinSimilarity != 0.8 || groups[0].MaxSimilarity != 0.95 {
+		t.Errorf("first group bounds = %v-%v, want 0.8-0.95", groups[0].MinSimilarity, groups[0].MaxSimilarity)
+	}
+	if groups[0].Similarities != nil {
+		t.Error("similarities retained without RetainSimilarities")
+	}
+	if groups[1].Products[0].ID != "x" || groups[1].MinSimilarity != 0.99 {
+		t.Errorf("second group = %+v", groups[1])
+	}
+
+	retained := ClusterDuplicates(results, ClusterOptions{RetainSimilarities: true})
+	if got := retained[0].Similarities[PairKey("e", "b")]; got != 0.8 || len(retained[0].Similarities) != 4 {
+		t.Errorf("retained similarities = %v", retained[0].Similarities)
+	}
+	if groups := ClusterDuplicates(nil, ClusterOptions{}); len(groups) != 0 {
+		t.Errorf("no results gave %d groups", len(groups))
+	}
+}
+
+func TestReviewRepresentatives(t *testing.T) {
+	// "hub" matches every other member; "edge" only matches "hub", loosely
+	results := []ComparisonResult{
+		pairResult("hub", "a", 0.95),
+		pairResult("hub", "b", 0.93),
+		pairResult("hub", "edge", 0.81),
+		pairResult("a", "b", 0.9),
+	}
+	group := ClusterDuplicates(results, ClusterOptions{RetainSimilarities: true})[0]
+	central, marginal, ok := ReviewRepresentatives(group)
+	if !ok || central.ID != "hub" || marginal.ID != "edge" {
+		t.Errorf("ReviewRepresentatives = %q, %q, %v; want hub, edge", central.ID, marginal.ID, ok)
+	}
+
+	tests := []struct {
+		name  string
+		group DuplicateGroup
+	}{
+		{"no similarities", ClusterDuplicates(results, ClusterOptions{})[0]},
+		{"one member", DuplicateGroup{Products: []Product{{ID: "a"}}, Similarities: map[string]float64{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, ok := ReviewRepresentatives(tt.group); ok {
+				t.Error("ok for a group without representatives")
+			}
+		})
+	}
+}
+
+// stratumBands returns the bands of a stratum's description, without its counts
+func stratumBands(s *ReviewStratum) string {
+	bands, _, _ := strings.Cut(s.String(), " (")
+	return bands
+}
+
+// reviewClusters builds count groups of size products whose pairs all have similarity
+func reviewClusters(prefix string, count, size int, similarity float64) []DuplicateGroup {
+	var results []ComparisonResult
+	for g := 0; g < count; g++ {
+		for m := 1; m < size; m++ {
+			results = append(results, pairResult(fmt.Sprintf("%s%d-0", prefix, g), fmt.Sprintf("%s%d-%d", prefix, g, m), similarity))
+		}
+	}
+	return ClusterDuplicates(results, ClusterOptions{})
+}
+
+func TestSampleClustersForReview(t *testing.T) {
+	// Known composition: one stratum per row under the default bands
+	composition := []struct {
+		prefix     string
+		count      int
+		size       int
+		similarity float64
+		stratum    string
+	}{
+		{"pair", 600, 2, 0.97, "size 2, min similarity 0.95-1.00"},
+		{"trio", 200, 3, 0.9, "size 3-4, min similarity 0.90-0.95"},
+		{"mid", 100, 6, 0.86, "size 5-9, min similarity 0.85-0.90"},
+		{"loose", 80, 12, 0.8, "size 10-49, min similarity 0.00-0.85"},
+		{"huge", 20, 60, 0.8, "size 50+, min similarity 0.00-0.85"},
+	}
+	var clusters []DuplicateGroup
+	for _, c := range composition {
+		clusters = append(clusters, reviewClusters(c.prefix, c.count, c.size, c.similarity)...)
+	}
+	total := len(clusters)
+
+	t.Run("coverage proportions", func(t *testing.T) {
+		const size = 100
+		sample := SampleClustersForReview(clusters, ReviewSampleOptions{Size: size, Seed: 1})
+		if len(sample) != size {
+			t.Fatalf("sampled %d groups, want %d", len(sample), size)
+		}
+		for _, g := range sample {
+			if g.Stratum == nil {
+				t.Fatal("sampled group without a stratum")
+			}
+		}
+		for _, c := range composition {
+			var got int
+			var stratum *ReviewStratum
+			for _, g := range sample {
+				if stratumBands(g.Stratum) == c.stratum {
+					got++
+					stratum = g.Stratum
+				}
+			}
+			share := float64(size) * float64(c.count) / float64(total)
+			if got < 1 || math.Abs(float64(got)-share) > 1.5 {
+				t.Errorf("%s: sampled %d, want about %.1f", c.stratum, got, share)
+			}
+			if stratum != nil && (stratum.Groups != c.count || stratum.Sampled != got) {
+				t.Errorf("%s: stratum = %+v", c.stratum, stratum)
+			}
+		}
+		if clusters[0].Stratum != nil {
+			t.Error("input groups were annotated")
+		}
+	})
+
+	t.Run("small strata are covered", func(t *testing.T) {
+		sample := SampleClustersForReview(clusters, ReviewSampleOptions{Size: 10, Seed: 1})
+		seen := make(map[string]bool)
+		for _, g := range sample {
+			seen[stratumBands(g.Stratum)] = true
+		}
+		if len(sample) != 10 || len(seen) != len(composition) {
+			t.Errorf("sampled %d groups from %d strata, want 10 from %d", len(sample), len(seen), len(composition))
+		}
+	})
+
+	t.Run("fewer draws than strata go to the largest", func(t *testing.T) {
+		sample := SampleClustersForReview(clusters, ReviewSampleOptions{Size: 2, Seed: 1})
+		if len(sample) != 2 || sample[0].Stratum.Groups != 600 || sample[1].Stratum.Groups != 200 {
+			t.Errorf("sample = %+v", sample)
+		}
+	})
+
+	t.Run("determinism per seed", func(t *testing.T) {
+		ids := func(seed int64) []string {
+			var ids []string
+			for _, g := range SampleClustersForReview(clusters, ReviewSampleOptions{Size: 40, Seed: seed}) {
+				ids = append(ids, g.Products[0].ID)
+			}
+			return ids
+		}
+		if !reflect.DeepEqual(ids(7), ids(7)) {
+			t.Error("same seed gave different samples")
+		}
+		if reflect.DeepEqual(ids(7), ids(8)) {
+			t.Error("different seeds gave the same sample")
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		all := SampleClustersForReview(clusters[:30], ReviewSampleOptions{})
+		if len(all) != 30 {
+			t.Errorf("sampled %d of 30 groups with the default size", len(all))
+		}
+		// One size band and one similarity band: a single stratum
+		sample := SampleClustersForReview(clusters, ReviewSampleOptions{Size: 5, SizeBands: []int{}, SimilarityBands: []float64{}})
+		if len(sample) != 5 || sample[0].Stratum.Groups != total || sample[0].Stratum.String() != "size 0+, min similarity 0.00-1.00 (5 of 1000 groups)" {
+			t.Errorf("single stratum sample = %d groups, %v", len(sample), sample[0].Stratum)
+		}
+	})
+}
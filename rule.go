@@ -0,0 +1,413 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MatchRule decides whether a scored pair is a match, for business rules a
+// single combined threshold can't express, e.g. "names at least 0.9 OR
+// combined at least 0.95"
+// Build rules from NameAtLeast, DescriptionAtLeast, CombinedAtLeast and
+// HasMatchType, combined with And, Or and Not. The AtLeast rules compare as
+// thresholds do, within ThresholdEpsilon. Evaluate may be called from
+// parallel scan workers, so custom rules must be safe for concurrent use.
+type MatchRule interface {
+	Evaluate(r ComparisonResult) bool
+}
+
+// NameAtLeast matches pairs whose NameSimilarity is at least threshold
+func NameAtLeast(threshold float64) MatchRule {
+	return fieldRule{field: ruleFieldName, min: threshold}
+}
+
+// DescriptionAtLeast matches pairs whose DescriptionSimilarity is at least threshold
+func DescriptionAtLeast(threshold float64) MatchRule {
+	return fieldRule{field: ruleFieldDescription, min: threshold}
+}
+
+// CombinedAtLeast matches pairs whose CombinedSimilarity is at least threshold
+func CombinedAtLeast(threshold float64) MatchRule {
+	return fieldRule{field: ruleFieldCombined, min: threshold}
+}
+
+// NameInDescriptionAtLeast matches pairs where either name is found in the
+// other product's description with at least threshold similarity; the scores
+// are only computed with WithCrossFieldMatching
+func NameInDescriptionAtLeast(threshold float64) MatchRule {
+	return fieldRule{field: ruleFieldNameInDescription, min: threshold}
+}
+
+// HasMatchType matches pairs of the given MatchType
+func HasMatchType(t MatchType) MatchRule {
+	return matchTypeRule{matchType: t}
+}
+
+// And matches pairs every rule matches (an empty And matches every pair)
+func And(rules ...MatchRule) MatchRule {
+	return andRule(rules)
+}
+
+// Or matches pairs any rule matches (an empty Or matches no pair)
+func Or(rules ...MatchRule) MatchRule {
+	return orRule(rules)
+}
+
+// Not matches pairs rule doesn't match
+func Not(rule MatchRule) MatchRule {
+	return notRule{rule: rule}
+}
+
+// Fields compared by fieldRule
+const (
+	ruleFieldName              = "name_at_least"
+	ruleFieldDescription       = "description_at_least"
+	ruleFieldCombined          = "combined_at_least"
+	ruleFieldNameInDescription = "name_in_description_at_least"
+)
+
+// fieldRule compares one similarity with a minimum
+type fieldRule struct {
+	field string // One of the ruleField constants
+	min   float64
+}
+
+func (r fieldRule) Evaluate(result ComparisonResult) bool {
+	switch r.field {
+	case ruleFieldName:
+		return meetsThreshold(result.NameSimilarity, r.min)
+	case ruleFieldDescription:
+		return meetsThreshold(result.DescriptionSimilarity, r.min)
+	case ruleFieldNameInDescription:
+		return meetsThreshold(result.NameInDescriptionAB, r.min) || meetsThreshold(result.NameInDescriptionBA, r.min)
+	default:
+		return meetsThreshold(result.CombinedSimilarity, r.min)
+	}
+}
+
+// matchTypeRule matches one MatchType
+type matchTypeRule struct {
+	matchType MatchType
+}
+
+func (r matchTypeRule) Evaluate(result ComparisonResult) bool {
+	return result.MatchType == r.matchType
+}
+
+type andRule []MatchRule
+
+func (r andRule) Evaluate(result ComparisonResult) bool {
+	for _, rule := range r {
+		if !rule.Evaluate(result) {
+			return false
+		}
+	}
+	return true
+}
+
+type orRule []MatchRule
+
+func (r orRule) Evaluate(result ComparisonResult) bool {
+	for _, rule := range r {
+		if rule.Evaluate(result) {
+			return true
+		}
+	}
+	return false
+}
+
+type notRule struct {
+	rule MatchRule
+}
+
+func (r notRule) Evaluate(result ComparisonResult) bool {
+	return !r.rule.Evaluate(result)
+}
+
+// ruleFloor returns a CombinedSimilarity every pair matching rule reaches,
+// so scans can prune with it as a threshold without losing matches
+// Only CombinedAtLeast and the exact match types bound the combined score:
+// a name or description can be similar while the other field (or an empty
+// one) drags the combined score anywhere. Rules made only of those leaves,
+// negations, and custom rules get 0, so every pair is scored.
+func ruleFloor(rule MatchRule) float64 {
+	switch r := rule.(type) {
+	case fieldRule:
+		if r.field == ruleFieldCombined {
+			return clampUnit(r.min)
+		}
+		return 0
+	case matchTypeRule:
+		if r.matchType == MatchExact || r.matchType == MatchNormalizedExact || r.matchType == MatchIdentifier {
+			return 1
+		}
+		return 0
+	case andRule:
+		// Every branch holds, so the strongest bound does
+		floor := 0.0
+		for _, branch := range r {
+			if f := ruleFloor(branch); f > floor {
+				floor = f
+			}
+		}
+		return floor
+	case orRule:
+		// Any branch may be the one that holds
+		if len(r) == 0 {
+			return 1
+		}
+		floor := 1.0
+		for _, branch := range r {
+			if f := ruleFloor(branch); f < floor {
+				floor = f
+			}
+		}
+		return floor
+	case suggestedThresholdRule:
+		return clampUnit(r.floor)
+	default:
+		return 0
+	}
+}
+
+// FindDuplicatesByRule returns every pair rule matches
+// Pairs are scanned as in FindDuplicates with the threshold ruleFloor derives
+// from the rule (0 unless it requires a combined score or exact match), then
+// kept if rule matches them. Results have ThresholdUsed set to that floor.
+// Returns nil if the input is rejected by the DuplicateIDPolicy.
+func (e *LevenshteinEngine) FindDuplicatesByRule(products []Product, rule MatchRule) []ComparisonResult {
+	defer e.guard("FindDuplicatesByRule", nil)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil
+	}
+	ptrs := productPtrs(resolved)
+	quality := e.newQualityCheck()
+	if quality != nil {
+		ptrs = quality.products(ptrs)
+	}
+
+	floor := ruleFloor(rule)
+	parallel := len(ptrs) > 50
+	if parallel {
+		warmCaches(ptrs, e.preparer())
+	}
+	memo := e.newScanMemo(ptrs)
+	window := e.newLengthWindow(ptrs, floor)
+	matches := scanPairsWithin(len(ptrs), e.scanWorkers(len(ptrs), parallel), window, func(i, j int, scratch *comparisonScratch) (ComparisonResult, bool) {
+		if !e.pairAllowed(ptrs[i], ptrs[j]) {
+			return ComparisonResult{}, false
+		}
+		result, ok := e.comparePair(ptrs, i, j, memo, scratch, floor)
+		if !ok {
+			return ComparisonResult{}, false
+		}
+		result.stampThreshold(floor)
+		return result, result.MeetsThreshold && rule.Evaluate(result)
+	})
+	e.recordScan(window, len(ptrs))
+
+	if quality != nil {
+		return quality.results(matches)
+	}
+	return matches
+}
+
+// FindDuplicatesByRule returns every pair rule matches among products and the
+// indexed corpus (see LevenshteinEngine.FindDuplicatesByRule)
+// Candidates are looked up and verified with the threshold ruleFloor derives,
+// which is 0 for rules that only constrain names or descriptions: then every
+// LSH candidate is verified, as LSH itself doesn't depend on the threshold
+// (except with AdaptiveBands, which probes every band at 0). Without a built
+// index this falls back to the Levenshtein scan.
+func (e *HybridEngine) FindDuplicatesByRule(products []Product, rule MatchRule) []ComparisonResult {
+	defer e.levenshteinEngine.guard("FindDuplicatesByRule", nil)
+	idx := e.currentIndex()
+	if idx == nil {
+		return e.levenshteinEngine.FindDuplicatesByRule(products, rule)
+	}
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil
+	}
+	ptrs := productPtrs(resolved)
+	quality := e.levenshteinEngine.newQualityCheck()
+	if quality != nil {
+		ptrs = quality.products(ptrs)
+	}
+
+	floor := ruleFloor(rule)
+	var matches []ComparisonResult
+	checked := make(map[string]bool)
+	for _, product := range ptrs {
+		candidates := e.findCandidates(idx, product, floor)
+		query := e.newQuery(product)
+		for _, candidate := range candidates {
+			if candidate.id == product.ID {
+				continue
+			}
+			pairKey := makePairKey(product.ID, candidate.id)
+			if checked[pairKey] {
+				continue
+			}
+			checked[pairKey] = true
+
+			result, ok := e.verifyCandidate(idx, product, query, candidate.id, floor)
+			if !ok {
+				continue
+			}
+			result.stampThreshold(floor)
+			if result.MeetsThreshold && rule.Evaluate(result) {
+				matches = append(matches, result)
+			}
+		}
+	}
+
+	if quality != nil {
+		return quality.results(matches)
+	}
+	return matches
+}
+
+// ruleJSON is the JSON form of a rule: an object with exactly one key
+//
+//	{"or": [{"name_at_least": 0.9}, {"combined_at_least": 0.95},
+//	        {"and": [{"description_at_least": 0.98}, {"name_at_least": 0.7}]}]}
+//
+// "not" holds one rule and "match_type" a MatchType name ("exact").
+type ruleJSON struct {
+	And                      *[]json.RawMessage `json:"and,omitempty"` // Pointers so an empty list still names the combinator
+	Or                       *[]json.RawMessage `json:"or,omitempty"`
+	Not                      json.RawMessage    `json:"not,omitempty"`
+	NameAtLeast              *float64           `json:"name_at_least,omitempty"`
+	DescriptionAtLeast       *float64           `json:"description_at_least,omitempty"`
+	CombinedAtLeast          *float64           `json:"combined_at_least,omitempty"`
+	NameInDescriptionAtLeast *float64           `json:"name_in_description_at_least,omitempty"`
+	MatchType                string             `json:"match_type,omitempty"`
+}
+
+// ErrRuleNotSerializable is returned by MarshalRule for custom MatchRule types
+var ErrRuleNotSerializable = errors.New("duplicatecheck: rule is not built from the rule constructors")
+
+// MarshalRule encodes a rule built from the rule constructors as JSON
+func MarshalRule(rule MatchRule) ([]byte, error) {
+	node, err := ruleToJSON(rule)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(node)
+}
+
+// ParseRule decodes a rule written by MarshalRule (or by hand, see ruleJSON)
+func ParseRule(data []byte) (MatchRule, error) {
+	var node ruleJSON
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("duplicatecheck: rule: %w", err)
+	}
+
+	set := 0
+	for _, present := range []bool{node.And != nil, node.Or != nil, node.Not != nil,
+		node.NameAtLeast != nil, node.DescriptionAtLeast != nil, node.CombinedAtLeast != nil, node.MatchType != "",
+		node.NameInDescriptionAtLeast != nil} {
+		if present {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("duplicatecheck: rule %s must have exactly one key", data)
+	}
+
+	switch {
+	case node.And != nil:
+		rules, err := parseRules(*node.And)
+		if err != nil {
+			return nil, err
+		}
+		return andRule(rules), nil
+	case node.Or != nil:
+		rules, err := parseRules(*node.Or)
+		if err != nil {
+			return nil, err
+		}
+		return orRule(rules), nil
+	case node.Not != nil:
+		rule, err := ParseRule(node.Not)
+		if err != nil {
+			return nil, err
+		}
+		return Not(rule), nil
+	case node.NameAtLeast != nil:
+		return NameAtLeast(*node.NameAtLeast), nil
+	case node.DescriptionAtLeast != nil:
+		return DescriptionAtLeast(*node.DescriptionAtLeast), nil
+	case node.CombinedAtLeast != nil:
+		return CombinedAtLeast(*node.CombinedAtLeast), nil
+	case node.NameInDescriptionAtLeast != nil:
+		return NameInDescriptionAtLeast(*node.NameInDescriptionAtLeast), nil
+	default:
+		for _, t := range []MatchType{MatchFuzzy, MatchNormalizedExact, MatchExact, MatchIdentifier, MatchVariant, MatchCopiedDescription} {
+			if t.String() == node.MatchType {
+				return HasMatchType(t), nil
+			}
+		}
+		return nil, fmt.Errorf("duplicatecheck: rule: unknown match type %q", node.MatchType)
+	}
+}
+
+// parseRules decodes the branches of an "and" or "or" rule
+func parseRules(raw []json.RawMessage) ([]MatchRule, error) {
+	rules := make([]MatchRule, len(raw))
+	for i, data := range raw {
+		rule, err := ParseRule(data)
+		if err != nil {
+			return nil, err
+		}
+		rules[i] = rule
+	}
+	return rules, nil
+}
+
+// ruleToJSON converts a constructor-built rule to its JSON form
+func ruleToJSON(rule MatchRule) (ruleJSON, error) {
+	switch r := rule.(type) {
+	case fieldRule:
+		threshold := r.min
+		switch r.field {
+		case ruleFieldName:
+			return ruleJSON{NameAtLeast: &threshold}, nil
+		case ruleFieldDescription:
+			return ruleJSON{DescriptionAtLeast: &threshold}, nil
+		case ruleFieldNameInDescription:
+			return ruleJSON{NameInDescriptionAtLeast: &threshold}, nil
+		default:
+			return ruleJSON{CombinedAtLeast: &threshold}, nil
+		}
+	case matchTypeRule:
+		return ruleJSON{MatchType: r.matchType.String()}, nil
+	case andRule:
+		branches, err := rulesToJSON(r)
+		return ruleJSON{And: &branches}, err
+	case orRule:
+		branches, err := rulesToJSON(r)
+		return ruleJSON{Or: &branches}, err
+	case notRule:
+		inner, err := MarshalRule(r.rule)
+		return ruleJSON{Not: inner}, err
+	default:
+		return ruleJSON{}, fmt.Errorf("%w: %T", ErrRuleNotSerializable, rule)
+	}
+}
+
+// rulesToJSON encodes the branches of an "and" or "or" rule
+func rulesToJSON(rules []MatchRule) ([]json.RawMessage, error) {
+	branches := make([]json.RawMessage, len(rules))
+	for i, rule := range rules {
+		data, err := MarshalRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		branches[i] = data
+	}
+	return branches, nil
+}
@@ -0,0 +1,256 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// businessRule is "names at least 0.9 OR combined at least 0.95 OR
+// descriptions at least 0.98 with names at least 0.7"
+func businessRule() MatchRule {
+	return Or(
+		NameAtLeast(0.9),
+		CombinedAtLeast(0.95),
+		And(DescriptionAtLeast(0.98), NameAtLeast(0.7)),
+	)
+}
+
+// ruleCatalog holds one pair per branch of businessRule, scored with 20/80
+// name/description weights, and a pair matching none
+// N: names 0.96, descriptions unrelated; C: combined 0.956 with names 0.885
+// and descriptions 0.974; D: descriptions identical, names 0.727 (combined
+// 0.945); L: none.
+func ruleCatalog() []Product {
+	kettle := "Stainless steel kettle with a 1.7 litre capacity, rapid boil element, and automatic shut-off for safety"
+	return []Product{
+		{ID: "N1", Name: "Acme Electric Kettle 1.7L", Description: "A kettle."},
+		{ID: "N2", Name: "Acme Electric Kettle 1.7 L", Description: "Boils water quickly for tea and coffee every morning."},
+		{ID: "C1", Name: "Bolt Travel Mug 350ml Rose", Description: kettle + " and a red lid"},
+		{ID: "C2", Name: "Bolt Travel Mug 350ml Red", Description: kettle + " and a tan lid"},
+		{ID: "D1", Name: "Zenith Toaster Classic", Description: kettle},
+		{ID: "D2", Name: "Zenith Toaster Cube", Description: kettle},
+		{ID: "L1", Name: "Orbit Desk Lamp", Description: "LED desk lamp with a flexible arm"},
+		{ID: "L2", Name: "Orbit Floor Lamp", Description: "Tall floor lamp with a linen shade"},
+	}
+}
+
+// ruleWeights is the 20/80 weight resolver ruleCatalog is scored with
+func ruleWeights(a, b Product) ComparisonWeights {
+	return ComparisonWeights{NameWeight: 0.2, DescriptionWeight: 0.8}
+}
+
+func TestMatchRuleEvaluate(t *testing.T) {
+	result := ComparisonResult{NameSimilarity: 0.8, DescriptionSimilarity: 0.99, CombinedSimilarity: 0.85, MatchType: MatchFuzzy}
+	tests := []struct {
+		name string
+		rule MatchRule
+		want bool
+	}{
+		{"Name at least", NameAtLeast(0.8), true},
+		{"Name below", NameAtLeast(0.81), false},
+		{"Description at least", DescriptionAtLeast(0.98), true},
+		{"Combined below", CombinedAtLeast(0.9), false},
+		{"Match type", HasMatchType(MatchFuzzy), true},
+		{"Other match type", HasMatchType(MatchExact), false},
+		{"And", And(NameAtLeast(0.7), DescriptionAtLeast(0.98)), true},
+		{"And with a failing branch", And(NameAtLeast(0.7), CombinedAtLeast(0.9)), false},
+		{"Or", Or(CombinedAtLeast(0.9), NameAtLeast(0.8)), true},
+		{"Not", Not(CombinedAtLeast(0.9)), true},
+		{"Empty And", And(), true},
+		{"Empty Or", Or(), false},
+		{"Business rule", businessRule(), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Evaluate(result); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleFloor(t *testing.T) {
+	tests := []struct {
+		name string
+		rule MatchRule
+		want float64
+	}{
+		{"Combined", CombinedAtLeast(0.9), 0.9},
+		{"Name only", NameAtLeast(0.9), 0},
+		{"Exact", HasMatchType(MatchExact), 1},
+		{"Fuzzy", HasMatchType(MatchFuzzy), 0},
+		{"And takes the strongest", And(CombinedAtLeast(0.8), NameAtLeast(0.95), CombinedAtLeast(0.9)), 0.9},
+		{"Or takes the weakest", Or(CombinedAtLeast(0.95), HasMatchType(MatchExact)), 0.95},
+		{"Or with an unbounded branch", businessRule(), 0},
+		{"Not", Not(CombinedAtLeast(0.5)), 0},
+		{"Empty Or", Or(), 1},
+		{"Out of range", CombinedAtLeast(1.5), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleFloor(tt.rule); got != tt.want {
+				t.Errorf("ruleFloor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// rulePairs returns the sorted "A|B" IDs of results
+func rulePairs(results []ComparisonResult) []string {
+	pairs := make([]string, len(results))
+	for i, r := range results {
+		a, b := r.ProductA.ID, r.ProductB.ID
+		if b < a {
+			a, b = b, a
+		}
+		pairs[i] = a + "|" + b
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+func TestFindDuplicatesByRule(t *testing.T) {
+	catalog := ruleCatalog()
+	engine := NewLevenshteinEngine().WithWeightResolver(ruleWeights)
+
+	results := engine.FindDuplicatesByRule(catalog, businessRule())
+	if got, want := rulePairs(results), []string{"C1|C2", "D1|D2", "N1|N2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("FindDuplicatesByRule() = %v, want %v", got, want)
+	}
+
+	// Each pair is matched by its own branch only
+	branches := []MatchRule{NameAtLeast(0.9), CombinedAtLeast(0.95), And(DescriptionAtLeast(0.98), NameAtLeast(0.7))}
+	want := map[string]int{"N1": 0, "C1": 1, "D1": 2}
+	for _, r := range results {
+		for i, branch := range branches {
+			if matched := branch.Evaluate(r); matched != (want[r.ProductA.ID] == i) {
+				t.Errorf("%s|%s: branch %d matched = %v (name %.3f, description %.3f, combined %.3f)", r.ProductA.ID,
+					r.ProductB.ID, i, matched, r.NameSimilarity, r.DescriptionSimilarity, r.CombinedSimilarity)
+			}
+		}
+		if r.ThresholdUsed != 0 || !r.MeetsThreshold {
+			t.Errorf("%s|%s: ThresholdUsed %v, MeetsThreshold %v, want 0 and true", r.ProductA.ID, r.ProductB.ID, r.ThresholdUsed, r.MeetsThreshold)
+		}
+	}
+
+	// A combined-only rule agrees with FindDuplicates at that threshold
+	if got, want := rulePairs(engine.FindDuplicatesByRule(catalog, CombinedAtLeast(0.95))), rulePairs(engine.FindDuplicates(catalog, 0.95)); !reflect.DeepEqual(got, want) {
+		t.Errorf("CombinedAtLeast(0.95) found %v, FindDuplicates %v", got, want)
+	}
+}
+
+func TestFindDuplicatesByRuleHybridConsistency(t *testing.T) {
+	catalog := ruleCatalog()
+	levenshtein := NewLevenshteinEngine().WithWeightResolver(ruleWeights)
+	hybrid := NewHybridEngine().WithWeightResolver(ruleWeights)
+	if err := hybrid.BuildIndex(catalog); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	for _, rule := range []MatchRule{businessRule(), CombinedAtLeast(0.95), HasMatchType(MatchFuzzy)} {
+		want := make(map[string]ComparisonResult)
+		for _, r := range levenshtein.FindDuplicatesByRule(catalog, rule) {
+			want[rulePairs([]ComparisonResult{r})[0]] = r
+		}
+		got := hybrid.FindDuplicatesByRule(catalog, rule)
+		for _, r := range got {
+			key := rulePairs([]ComparisonResult{r})[0]
+			w, ok := want[key]
+			if !ok {
+				t.Errorf("hybrid matched %s, Levenshtein didn't", key)
+				continue
+			}
+			if r.CombinedSimilarity != w.CombinedSimilarity {
+				t.Errorf("%s: hybrid combined %v, Levenshtein %v", key, r.CombinedSimilarity, w.CombinedSimilarity)
+			}
+		}
+	}
+
+	// The description-driven branches share most shingles, so LSH finds them
+	pairs := rulePairs(hybrid.FindDuplicatesByRule(catalog, businessRule()))
+	for _, want := range []string{"C1|C2", "D1|D2"} {
+		if !containsString(pairs, want) {
+			t.Errorf("hybrid FindDuplicatesByRule() = %v, missing %s", pairs, want)
+		}
+	}
+
+	// Without an index the hybrid engine scans like the Levenshtein engine
+	unindexed := NewHybridEngine().WithWeightResolver(ruleWeights)
+	if got, want := rulePairs(unindexed.FindDuplicatesByRule(catalog, businessRule())), rulePairs(levenshtein.FindDuplicatesByRule(catalog, businessRule())); !reflect.DeepEqual(got, want) {
+		t.Errorf("unindexed hybrid found %v, Levenshtein %v", got, want)
+	}
+}
+
+// containsString reports whether list holds s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRuleJSON(t *testing.T) {
+	rules := []MatchRule{
+		businessRule(),
+		Not(HasMatchType(MatchExact)),
+		And([]MatchRule{}...),
+		Or([]MatchRule{}...),
+		HasMatchType(MatchNormalizedExact),
+		Or(CombinedAtLeast(0.85), NameInDescriptionAtLeast(0.9)),
+	}
+	for _, rule := range rules {
+		data, err := MarshalRule(rule)
+		if err != nil {
+			t.Fatalf("MarshalRule(%#v): %v", rule, err)
+		}
+		parsed, err := ParseRule(data)
+		if err != nil {
+			t.Fatalf("ParseRule(%s): %v", data, err)
+		}
+		if !reflect.DeepEqual(parsed, rule) {
+			t.Errorf("ParseRule(%s) = %#v, want %#v", data, parsed, rule)
+		}
+	}
+
+	handWritten := `{"or": [{"name_at_least": 0.9}, {"combined_at_least": 0.95},
+		{"and": [{"description_at_least": 0.98}, {"name_at_least": 0.7}]}]}`
+	parsed, err := ParseRule([]byte(handWritten))
+	if err != nil {
+		t.Fatalf("ParseRule: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, businessRule()) {
+		t.Errorf("ParseRule(%s) = %#v, want the business rule", handWritten, parsed)
+	}
+}
+
+func TestRuleJSONErrors(t *testing.T) {
+	for _, data := range []string{
+		`{}`,
+		`{"name_at_least": 0.9, "combined_at_least": 0.9}`,
+		`{"match_type": "close"}`,
+		`{"and": [{"bogus": 1}]}`,
+		`{"not": {}}`,
+		`[1]`,
+	} {
+		if _, err := ParseRule([]byte(data)); err == nil {
+			t.Errorf("ParseRule(%s) succeeded, want an error", data)
+		}
+	}
+
+	custom := Or(NameAtLeast(0.9), customRule{})
+	if _, err := MarshalRule(custom); !errors.Is(err, ErrRuleNotSerializable) {
+		t.Errorf("MarshalRule(custom) error = %v, want ErrRuleNotSerializable", err)
+	}
+	if floor := ruleFloor(And(customRule{}, CombinedAtLeast(0.8))); floor != 0.8 {
+		t.Errorf("ruleFloor(And(custom, 0.8)) = %v, want 0.8", floor)
+	}
+}
+
+// customRule is a MatchRule not built from the constructors
+type customRule struct{}
+
+func (customRule) Evaluate(r ComparisonResult) bool { return r.ProductA.ID != "" }
@@ -0,0 +1,226 @@
+package duplicatecheck
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+// PanicError is a panic the library recovered: at a public entry point in
+// safe mode (see EnableSafeMode), or on a worker goroutine of parallel work,
+// which always hands its panic to the goroutine waiting for it
+type PanicError struct {
+	Op    string // Entry point that recovered the panic, such as "FindDuplicatesChecked"; empty until one does
+	Value any    // Value the code panicked with
+	Stack []byte // Stack of the goroutine that panicked, as runtime/debug.Stack formats it
+}
+
+// Error describes the panic without its stack
+func (e *PanicError) Error() string {
+	if e.Op == "" {
+		return fmt.Sprintf("duplicatecheck: panic: %v", e.Value)
+	}
+	return fmt.Sprintf("duplicatecheck: panic in %s: %v", e.Op, e.Value)
+}
+
+// Unwrap returns the panic value when it is an error, so errors.Is and
+// errors.As see through the panic (say, to ErrProductMutated)
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// panicsRecovered counts the panics safe mode and channel scans recovered
+var panicsRecovered atomic.Uint64
+
+// PanicsRecovered returns how many panics engines have recovered since the
+// process started, across every engine: in safe mode, at a public entry
+// point, and whatever the mode, in FindDuplicatesChan scans, audit sinks and
+// the recall monitor
+// A panic is counted once, by the entry point that recovers it. Export it as
+// a metric: any increase is a bug worth reporting.
+func PanicsRecovered() uint64 {
+	return panicsRecovered.Load()
+}
+
+// newPanicError wraps a recovered value with the current goroutine's stack,
+// keeping the error of a panic already wrapped by a worker
+func newPanicError(recovered any) *PanicError {
+	if p, ok := recovered.(*PanicError); ok {
+		return p
+	}
+	return &PanicError{Value: recovered, Stack: debug.Stack()}
+}
+
+// EnableSafeMode makes the engine's public entry points recover panics, for
+// services that must outlive a bug in the library or in a callback they set
+// Error-returning methods (FindDuplicatesChecked, FindDuplicatesCtx,
+// BuildIndex, FindDuplicatesForOneChecked and the like) return a *PanicError
+// carrying the panic's stack; the others, such as Compare and FindDuplicates,
+// return empty results. Either way the panic is logged at Error, to the
+// engine's logger or slog.Default, and counted in PanicsRecovered. Off by
+// default, so panics propagate to the caller as they always have.
+// Whatever the mode, a panic on a worker goroutine of a parallel scan, index
+// build or verification stops the other workers and is raised again on the
+// calling goroutine, where a deferred recover (or safe mode) can catch it,
+// instead of crashing the process.
+func (e *LevenshteinEngine) EnableSafeMode() {
+	e.safeMode = true
+}
+
+// DisableSafeMode lets panics propagate to the caller (the default)
+func (e *LevenshteinEngine) DisableSafeMode() {
+	e.safeMode = false
+}
+
+// IsSafeModeEnabled returns whether public entry points recover panics
+func (e *LevenshteinEngine) IsSafeModeEnabled() bool {
+	return e.safeMode
+}
+
+// EnableSafeMode makes the engine's public entry points recover panics (see
+// LevenshteinEngine.EnableSafeMode)
+// A build that panics leaves the previous index serving, as a failed build does.
+func (e *HybridEngine) EnableSafeMode() {
+	e.levenshteinEngine.EnableSafeMode()
+}
+
+// DisableSafeMode lets panics propagate to the caller (the default)
+func (e *HybridEngine) DisableSafeMode() {
+	e.levenshteinEngine.DisableSafeMode()
+}
+
+// IsSafeModeEnabled returns whether public entry points recover panics
+func (e *HybridEngine) IsSafeModeEnabled() bool {
+	return e.levenshteinEngine.IsSafeModeEnabled()
+}
+
+// EnableSafeMode makes the engine's public entry points recover panics (see
+// LevenshteinEngine.EnableSafeMode)
+func (e *TFIDFEngine) EnableSafeMode() {
+	e.levenshteinEngine.EnableSafeMode()
+}
+
+// DisableSafeMode lets panics propagate to the caller (the default)
+func (e *TFIDFEngine) DisableSafeMode() {
+	e.levenshteinEngine.DisableSafeMode()
+}
+
+// IsSafeModeEnabled returns whether public entry points recover panics
+func (e *TFIDFEngine) IsSafeModeEnabled() bool {
+	return e.levenshteinEngine.IsSafeModeEnabled()
+}
+
+// guard is deferred by public entry points: in safe mode it recovers a panic,
+// logs and counts it, and stores it in *err (nil for methods without an
+// error, which then return their zero results)
+// It must be the deferred call itself for recover to see the panic.
+func (e *LevenshteinEngine) guard(op string, err *error) {
+	if !e.safeMode {
+		return
+	}
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	failure := newPanicError(recovered)
+	if failure.Op == "" {
+		failure.Op = op
+	}
+	e.panicRecovered(failure)
+	if err != nil {
+		*err = failure
+	}
+}
+
+// recoverDetached is deferred by goroutines no caller waits for, such as the
+// recall monitor's: whatever the mode, their panic is logged and counted,
+// since nothing else could recover it
+func (e *LevenshteinEngine) recoverDetached(op string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	failure := newPanicError(recovered)
+	failure.Op = op
+	e.panicRecovered(failure)
+}
+
+// panicRecovered counts and logs a recovered panic
+func (e *LevenshteinEngine) panicRecovered(failure *PanicError) {
+	panicsRecovered.Add(1)
+	logger := e.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.LogAttrs(context.Background(), slog.LevelError, "panic recovered",
+		slog.String("op", failure.Op),
+		slog.String("panic", fmt.Sprint(failure.Value)),
+		slog.String("stack", string(failure.Stack)))
+}
+
+// workerGroup runs the goroutines of parallel work so that a panic on one
+// of them is raised again on the goroutine waiting for them, as a
+// *PanicError carrying the worker's stack, rather than crashing the process
+// The first panic stops the group: stopped reports it to workers claiming
+// work, and halt (nil = none) is called once, to unblock the others.
+type workerGroup struct {
+	wg     sync.WaitGroup
+	halt   func()
+	failed atomic.Bool
+
+	mu      sync.Mutex
+	failure *PanicError // First panic of a worker
+}
+
+// Go runs fn on a new goroutine of the group
+func (g *workerGroup) Go(fn func()) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.catch()
+		fn()
+	}()
+}
+
+// catch records a panicking worker's panic; deferred by Go
+func (g *workerGroup) catch() {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	failure := newPanicError(recovered)
+	g.mu.Lock()
+	first := g.failure == nil
+	if first {
+		g.failure = failure
+	}
+	g.mu.Unlock()
+	if first {
+		g.failed.Store(true)
+		if g.halt != nil {
+			g.halt()
+		}
+	}
+}
+
+// stopped reports whether a worker has panicked, so the others can stop
+// claiming work
+func (g *workerGroup) stopped() bool {
+	return g.failed.Load()
+}
+
+// Wait waits for every goroutine of the group, then raises the first
+// worker's panic, if any, on the calling goroutine
+func (g *workerGroup) Wait() {
+	g.wg.Wait()
+	g.mu.Lock()
+	failure := g.failure
+	g.mu.Unlock()
+	if failure != nil {
+		panic(failure)
+	}
+}
@@ -0,0 +1,258 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recovered runs fn, returning what it panicked with (nil if it didn't)
+func recovered(fn func()) (value any) {
+	defer func() { value = recover() }()
+	fn()
+	return nil
+}
+
+// checkNoLeak fails t if goroutines started since before are still running
+func checkNoLeak(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines before, %d after", before, after)
+	}
+}
+
+// panickingConstraint panics on one pair of a catalog and allows the others
+func panickingConstraint(a, b Product) bool {
+	if a.ID == "ARTICLE_0007" || b.ID == "ARTICLE_0007" {
+		panic("constraint failed")
+	}
+	return true
+}
+
+func TestWorkerPanicRaisedOnCaller(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, 200)
+	engine := NewLevenshteinEngine().WithPairConstraint(panickingConstraint)
+	engine.SetMaxWorkers(4)
+
+	before := runtime.NumGoroutine()
+	value := recovered(func() { engine.FindDuplicates(products, 0.8) })
+	failure, ok := value.(*PanicError)
+	if !ok {
+		t.Fatalf("parallel scan panicked with %#v, want a *PanicError", value)
+	}
+	if failure.Value != "constraint failed" || !strings.Contains(string(failure.Stack), "panickingConstraint") {
+		t.Errorf("panic %v with stack\n%s", failure.Value, failure.Stack)
+	}
+	checkNoLeak(t, before)
+}
+
+func TestSafeModeLevenshtein(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, 200)
+	var logged bytes.Buffer
+	engine := NewLevenshteinEngine().WithPairConstraint(panickingConstraint).
+		WithLogger(slog.New(slog.NewTextHandler(&logged, nil)))
+	engine.SetMaxWorkers(4)
+	engine.EnableSafeMode()
+	if !engine.IsSafeModeEnabled() {
+		t.Fatal("safe mode not enabled")
+	}
+
+	before, counted := runtime.NumGoroutine(), PanicsRecovered()
+
+	// Legacy APIs return empty results
+	if got := engine.FindDuplicates(products, 0.8); len(got) != 0 {
+		t.Errorf("FindDuplicates returned %d results", len(got))
+	}
+	if got := engine.FindDuplicates(products[:20], 0.8); len(got) != 0 {
+		t.Errorf("sequential FindDuplicates returned %d results", len(got))
+	}
+
+	// Error-returning APIs return the panic
+	for name, scan := range map[string]func() ([]ComparisonResult, error){
+		"FindDuplicatesChecked": func() ([]ComparisonResult, error) { return engine.FindDuplicatesChecked(products, 0.8) },
+		"FindDuplicatesCtx": func() ([]ComparisonResult, error) {
+			return engine.FindDuplicatesCtx(context.Background(), products, 0.8)
+		},
+	} {
+		got, err := scan()
+		var failure *PanicError
+		if !errors.As(err, &failure) || len(got) != 0 {
+			t.Errorf("%s: %d results, error %v", name, len(got), err)
+			continue
+		}
+		if failure.Op != name || failure.Value != "constraint failed" || !strings.Contains(string(failure.Stack), "panickingConstraint") {
+			t.Errorf("%s: op %q, panic %v, stack\n%s", name, failure.Op, failure.Value, failure.Stack)
+		}
+	}
+	if got := PanicsRecovered() - counted; got != 4 {
+		t.Errorf("PanicsRecovered grew by %d, want 4", got)
+	}
+	if got := strings.Count(logged.String(), "level=ERROR msg=\"panic recovered\""); got != 4 {
+		t.Errorf("%d panics logged, want 4:\n%s", got, logged.String())
+	}
+	checkNoLeak(t, before)
+
+	// Compare recovers a panicking weight resolver
+	engine.WithWeightResolver(func(a, b Product) ComparisonWeights { panic("resolver failed") })
+	if got := engine.Compare(products[0], products[1]); got.ProductA.ID != "" || got.CombinedSimilarity != 0 {
+		t.Errorf("Compare returned %+v", got)
+	}
+
+	// Off again, panics propagate as they did
+	engine.DisableSafeMode()
+	if value := recovered(func() { engine.Compare(products[0], products[1]) }); value != "resolver failed" {
+		t.Errorf("Compare without safe mode panicked with %v", value)
+	}
+}
+
+func TestSafeModeHybrid(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, 400)
+	engine := NewHybridEngineWithConfig(HybridConfig{BuildWorkers: 4}).
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	engine.SetMaxWorkers(4)
+	engine.EnableSafeMode()
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	before := runtime.NumGoroutine()
+
+	// A half-built engine panics on the hashing workers; the index built
+	// before keeps serving
+	minHash := engine.minHash
+	engine.minHash = nil
+	err := engine.BuildIndex(products[:200])
+	var failure *PanicError
+	if !errors.As(err, &failure) || failure.Op != "BuildIndexCtx" || len(failure.Stack) == 0 {
+		t.Errorf("BuildIndex of a broken engine: %v", err)
+	}
+	err = engine.BuildIndexPipelined(context.Background(), NewSliceProductSource(products[:200]), PipelineOptions{Workers: 4})
+	if !errors.As(err, &failure) || failure.Op != "BuildIndexPipelined" {
+		t.Errorf("BuildIndexPipelined of a broken engine: %v", err)
+	}
+	engine.minHash = minHash
+	if got := engine.IndexedCount(); got != len(products) {
+		t.Errorf("%d products indexed after failed builds, want %d", got, len(products))
+	}
+
+	// Scans and queries
+	engine.WithPairConstraint(panickingConstraint)
+	if _, err := engine.FindDuplicatesChecked(products, 0.8); !errors.As(err, &failure) || failure.Value != "constraint failed" {
+		t.Errorf("FindDuplicatesChecked: %v", err)
+	}
+	if got := engine.FindDuplicates(products, 0.8); len(got) != 0 {
+		t.Errorf("FindDuplicates returned %d results", len(got))
+	}
+	engine.WithPairConstraint(nil)
+	engine.WithWeightResolver(func(a, b Product) ComparisonWeights { panic("resolver failed") })
+	if _, err := engine.FindDuplicatesForOneChecked(products[0], 0.5); !errors.As(err, &failure) || failure.Value != "resolver failed" {
+		t.Errorf("FindDuplicatesForOneChecked: %v", err)
+	}
+	if got := engine.FindDuplicatesForOne(products[0], 0.5); len(got) != 0 {
+		t.Errorf("FindDuplicatesForOne returned %d results", len(got))
+	}
+	checkNoLeak(t, before)
+
+	// Without safe mode the worker's panic reaches the caller
+	engine.DisableSafeMode()
+	engine.minHash = nil
+	if value := recovered(func() { _ = engine.BuildIndex(products[:200]) }); value == nil {
+		t.Error("BuildIndex of a broken engine didn't panic")
+	} else if _, ok := value.(*PanicError); !ok {
+		t.Errorf("BuildIndex panicked with %#v, want a *PanicError", value)
+	}
+	engine.minHash = minHash
+	checkNoLeak(t, before)
+}
+
+func TestSafeModeEntryPoints(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, 200)
+	engine := NewHybridEngine().WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	engine.EnableSafeMode()
+	engine.WithWeightResolver(func(a, b Product) ComparisonWeights { panic("resolver failed") })
+
+	checks := map[string]func() error{
+		"EstimateDuplicateStatsWithOptions": func() error {
+			_, err := engine.EstimateDuplicateStatsWithOptions(products, 0.8, DuplicateStatsOptions{Exact: true})
+			return err
+		},
+		"SampleDuplicateRate": func() error {
+			_, err := engine.SampleDuplicateRate(products, 0.8, SampleOptions{Size: 20, Seed: 1})
+			return err
+		},
+		"CheckEditImpactChecked": func() error {
+			edited := products[0]
+			edited.Name += " v2"
+			_, err := engine.CheckEditImpactChecked(products[0], edited, 0.8)
+			return err
+		},
+		"ValidateRecallChecked": func() error {
+			_, err := engine.ValidateRecallChecked(products, 0.8, 20, 1)
+			return err
+		},
+		"NearestNeighborReportCtx": func() error {
+			_, _, err := engine.NearestNeighborReportCtx(context.Background(), products[:20], NearestNeighborOptions{})
+			return err
+		},
+	}
+	for name, check := range checks {
+		var failure *PanicError
+		if err := check(); !errors.As(err, &failure) || failure.Op != name || failure.Value != "resolver failed" {
+			t.Errorf("%s: %v, want its *PanicError", name, err)
+		}
+	}
+	engine.WithWeightResolver(nil)
+
+	// A panicking hook outside the scan itself fails the channel scan
+	engine.WithScanSummary(func(ScanSummary) { panic("hook failed") })
+	results, errs := engine.FindDuplicatesChan(context.Background(), products, 0.8, 0)
+	for range results {
+	}
+	var failure *PanicError
+	if err := <-errs; !errors.As(err, &failure) || failure.Op != "FindDuplicatesChan" || failure.Value != "hook failed" {
+		t.Errorf("FindDuplicatesChan with a panicking summary hook: %v", err)
+	}
+
+	// TFIDFEngine
+	tfidf := NewTFIDFEngine()
+	tfidf.EnableSafeMode()
+	if !tfidf.IsSafeModeEnabled() {
+		t.Fatal("TF-IDF safe mode not enabled")
+	}
+	tfidf.levenshteinEngine.WithWeightResolver(func(a, b Product) ComparisonWeights { panic("resolver failed") })
+	if _, err := tfidf.FindDuplicatesChecked(products, 0.8); !errors.As(err, &failure) || failure.Op != "FindDuplicatesChecked" {
+		t.Errorf("TF-IDF FindDuplicatesChecked: %v", err)
+	}
+	if got := tfidf.Compare(products[0], products[1]); got.ProductA.ID != "" {
+		t.Errorf("TF-IDF Compare returned %+v", got)
+	}
+	tfidf.DisableSafeMode()
+	if value := recovered(func() { tfidf.Compare(products[0], products[1]) }); value != "resolver failed" {
+		t.Errorf("TF-IDF Compare without safe mode panicked with %v", value)
+	}
+}
+
+func TestPanicErrorUnwrap(t *testing.T) {
+	failure := newPanicError(ErrProductMutated)
+	if !errors.Is(failure, ErrProductMutated) {
+		t.Error("PanicError doesn't unwrap its error")
+	}
+	if got := (&PanicError{Op: "Compare", Value: "boom"}).Error(); got != "duplicatecheck: panic in Compare: boom" {
+		t.Errorf("Error() = %q", got)
+	}
+	if newPanicError(failure) != failure {
+		t.Error("a worker's PanicError was wrapped again")
+	}
+}
@@ -0,0 +1,238 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// DefaultSampleSize is the number of products SampleDuplicateRate queries by default
+const DefaultSampleSize = 500
+
+// DefaultSampleConfidence is the confidence level of SampleReport intervals by default
+const DefaultSampleConfidence = 0.95
+
+// SampleOptions controls SampleDuplicateRate
+type SampleOptions struct {
+	// Size is the number of products sampled (0 uses DefaultSampleSize); a
+	// catalog no larger than Size is queried in full
+	Size int
+	// Seed picks the sample: the same catalog, options and seed sample the
+	// same products
+	Seed int64
+	// Partition, when set, stratifies the sample by its key (a category, a
+	// seller): each partition is sampled in proportion to its size, at least
+	// two products each, which narrows the interval when duplicate rates
+	// differ between partitions
+	Partition func(Product) string
+	// Confidence is the confidence level of the intervals, in (0, 1)
+	// (0 uses DefaultSampleConfidence)
+	Confidence float64
+}
+
+// SampleCount is the outcome of one sampled product
+type SampleCount struct {
+	ID        string `json:"id"`
+	Partition string `json:"partition,omitempty"`
+	Matches   int    `json:"matches"` // Indexed products at or above the threshold, itself excluded
+}
+
+// SampleReport estimates the duplicates in a catalog from a sample of products
+// Rates are the fraction of products with at least one duplicate; pairs
+// count each duplicate pair once. Low and High bound the Confidence interval.
+type SampleReport struct {
+	Products          int           `json:"products"`            // Catalog size
+	Sampled           int           `json:"sampled"`             // Products queried
+	Confidence        float64       `json:"confidence"`          // Confidence level of the intervals
+	DuplicateRate     float64       `json:"duplicate_rate"`      // Estimated fraction of products with a duplicate
+	DuplicateRateLow  float64       `json:"duplicate_rate_low"`  // Lower bound of DuplicateRate
+	DuplicateRateHigh float64       `json:"duplicate_rate_high"` // Upper bound of DuplicateRate
+	Pairs             float64       `json:"pairs"`               // Estimated duplicate pairs in the catalog
+	PairsLow          float64       `json:"pairs_low"`           // Lower bound of Pairs
+	PairsHigh         float64       `json:"pairs_high"`          // Upper bound of Pairs
+	Samples           []SampleCount `json:"samples"`             // Per-product counts, for your own statistics
+	Note              string        `json:"note"`                // How the estimate was obtained
+}
+
+// SampleDuplicateRate estimates a catalog's duplicate rate and duplicate
+// pair count from opts.Size products instead of scanning it
+// The index is built from products unless one is already built. The sampled
+// products, drawn uniformly without replacement (or per partition), are each
+// queried as in FindDuplicatesForOne, so the work is opts.Size queries however
+// large the catalog. A sampled product's matches estimate the catalog's mean
+// matches per product, and each pair has two products, so Pairs is
+// Products × mean / 2.
+//
+// Intervals use the normal approximation: estimate ± z·SE, where SE is the
+// standard error of the (stratified) sample mean with the finite population
+// correction, clamped to possible values. The approximation is poor when few
+// sampled products have duplicates (under about ten); sample more then. Returns
+// an error for an invalid threshold or confidence, input rejected by the
+// DuplicateIDPolicy, or an index that can't be built.
+func (e *HybridEngine) SampleDuplicateRate(products []Product, threshold float64, opts SampleOptions) (_ SampleReport, err error) {
+	defer e.levenshteinEngine.guard("SampleDuplicateRate", &err)
+	if err := validateThreshold(threshold); err != nil {
+		return SampleReport{}, err
+	}
+	confidence := opts.Confidence
+	if confidence == 0 {
+		confidence = DefaultSampleConfidence
+	}
+	if math.IsNaN(confidence) || confidence <= 0 || confidence >= 1 {
+		return SampleReport{}, fmt.Errorf("duplicatecheck: sample confidence must be in (0, 1), got %v", confidence)
+	}
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return SampleReport{}, err
+	}
+	idx := e.currentIndex()
+	if idx == nil {
+		if err := e.BuildIndex(resolved); err != nil {
+			return SampleReport{}, err
+		}
+		idx = e.currentIndex()
+	}
+	size := opts.Size
+	if size <= 0 {
+		size = DefaultSampleSize
+	}
+
+	report := SampleReport{Products: len(resolved), Confidence: confidence}
+	strata := sampleStrata(resolved, size, opts)
+	for _, stratum := range strata {
+		for _, i := range stratum.sampled {
+			matches, err := e.findDuplicatesForOne(idx, &resolved[i], threshold)
+			if err != nil && !errors.Is(err, ErrQueryTruncated) {
+				return SampleReport{}, err
+			}
+			count := 0
+			for _, m := range matches {
+				if m.ProductB.ID != resolved[i].ID {
+					count++
+				}
+			}
+			stratum.matches = append(stratum.matches, count)
+			report.Samples = append(report.Samples, SampleCount{ID: resolved[i].ID, Partition: stratum.key, Matches: count})
+		}
+	}
+	report.Sampled = len(report.Samples)
+	if report.Sampled == 0 {
+		report.Note = "empty catalog"
+		return report, nil
+	}
+
+	z := math.Sqrt2 * math.Erfinv(confidence)
+	rate, rateSE := stratifiedMean(strata, len(resolved), func(matches int) float64 {
+		if matches > 0 {
+			return 1
+		}
+		return 0
+	})
+	mean, meanSE := stratifiedMean(strata, len(resolved), func(matches int) float64 { return float64(matches) })
+	half := float64(len(resolved)) / 2
+	report.DuplicateRate = rate
+	report.DuplicateRateLow, report.DuplicateRateHigh = math.Max(0, rate-z*rateSE), math.Min(1, rate+z*rateSE)
+	report.Pairs = mean * half
+	report.PairsLow, report.PairsHigh = math.Max(0, (mean-z*meanSE)*half), (mean+z*meanSE)*half
+	report.Note = fmt.Sprintf("estimated from %d of %d products in %d stratum(s); %.0f%% intervals by normal approximation",
+		report.Sampled, report.Products, len(strata), 100*confidence)
+	return report, nil
+}
+
+// sampleStratum is one partition of the catalog and its sampled products
+type sampleStratum struct {
+	key     string
+	size    int   // Products in the partition
+	sampled []int // Positions of the sampled products in the catalog
+	matches []int // Matches of each sampled product, in sampled order
+}
+
+// sampleStrata partitions products by opts.Partition (one stratum without it)
+// and samples each, in proportion to its size
+func sampleStrata(products []Product, size int, opts SampleOptions) []*sampleStratum {
+	members := make(map[string][]int)
+	var keys []string
+	for i := range products {
+		key := ""
+		if opts.Partition != nil {
+			key = opts.Partition(products[i])
+		}
+		if _, ok := members[key]; !ok {
+			keys = append(keys, key)
+		}
+		members[key] = append(members[key], i)
+	}
+	sort.Strings(keys)
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	strata := make([]*sampleStratum, len(keys))
+	for s, key := range keys {
+		positions := members[key]
+		take := len(positions)
+		if len(keys) == 1 {
+			if size < take {
+				take = size
+			}
+		} else if share := int(math.Round(float64(size) * float64(len(positions)) / float64(len(products)))); share < take {
+			take = share
+			if take < 2 {
+				take = 2 // Two products give the partition a variance
+			}
+			if take > len(positions) {
+				take = len(positions)
+			}
+		}
+		stratum := &sampleStratum{key: key, size: len(positions)}
+		for _, i := range sampleIndexes(rng, len(positions), take) {
+			stratum.sampled = append(stratum.sampled, positions[i])
+		}
+		strata[s] = stratum
+	}
+	return strata
+}
+
+// sampleIndexes draws k distinct indexes from [0, n) uniformly, in draw order
+// Floyd's algorithm: memory and time grow with k, not n.
+func sampleIndexes(rng *rand.Rand, n, k int) []int {
+	chosen := make(map[int]bool, k)
+	drawn := make([]int, 0, k)
+	for j := n - k; j < n; j++ {
+		i := rng.Intn(j + 1)
+		if chosen[i] {
+			i = j
+		}
+		chosen[i] = true
+		drawn = append(drawn, i)
+	}
+	return drawn
+}
+
+// stratifiedMean estimates the catalog mean of value(matches) and its standard
+// error: stratum means weighted by stratum size, each with the finite
+// population correction
+func stratifiedMean(strata []*sampleStratum, products int, value func(matches int) float64) (mean, se float64) {
+	var variance float64
+	for _, s := range strata {
+		k := len(s.matches)
+		if k == 0 {
+			continue
+		}
+		var sum, squares float64
+		for _, m := range s.matches {
+			v := value(m)
+			sum += v
+			squares += v * v
+		}
+		stratumMean := sum / float64(k)
+		weight := float64(s.size) / float64(products)
+		mean += weight * stratumMean
+		if k > 1 {
+			sampleVariance := (squares - float64(k)*stratumMean*stratumMean) / float64(k-1)
+			correction := 1 - float64(k)/float64(s.size)
+			variance += weight * weight * math.Max(0, sampleVariance) / float64(k) * correction
+		}
+	}
+	return mean, math.Sqrt(variance)
+}
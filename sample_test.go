@@ -0,0 +1,115 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+func TestSampleDuplicateRate(t *testing.T) {
+	cfg := synth.Default()
+	cfg.Size, cfg.DuplicateRate = 1000, 0.2
+	products, _ := generatedCatalog(cfg)
+	const threshold = 0.8
+
+	engine := NewHybridEngine()
+	if err := engine.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	before := engine.levenshteinEngine.GetScanStats()["name_comparisons"].(uint64)
+	full := engine.FindDuplicates(products, threshold)
+	fullWork := engine.levenshteinEngine.GetScanStats()["name_comparisons"].(uint64) - before
+	withDuplicates := make(map[string]bool)
+	for _, r := range full {
+		withDuplicates[r.ProductA.ID], withDuplicates[r.ProductB.ID] = true, true
+	}
+	trueRate := float64(len(withDuplicates)) / float64(len(products))
+	truePairs := float64(len(full))
+
+	partitions := map[string]func(Product) string{
+		"Uniform":    nil,
+		"Stratified": func(p Product) string { return strings.ToLower(p.Name[:1]) },
+	}
+	for name, partition := range partitions {
+		t.Run(name, func(t *testing.T) {
+			const seeds, size = 20, 200
+			rateCovered, pairsCovered := 0, 0
+			for seed := int64(1); seed <= seeds; seed++ {
+				before := engine.levenshteinEngine.GetScanStats()["name_comparisons"].(uint64)
+				report, err := engine.SampleDuplicateRate(products, threshold, SampleOptions{Size: size, Seed: seed, Partition: partition})
+				if err != nil {
+					t.Fatal(err)
+				}
+				work := engine.levenshteinEngine.GetScanStats()["name_comparisons"].(uint64) - before
+				// A query verifies the candidates of one product, a scan those of
+				// every product (each pair once), so sampling costs about
+				// 2·Sampled/Products of the scan
+				if limit := 4 * fullWork * uint64(report.Sampled) / uint64(len(products)); work > limit {
+					t.Errorf("Seed %d: sampling compared %d names, full scan %d", seed, work, fullWork)
+				}
+				if report.Products != len(products) || report.Sampled < size || len(report.Samples) != report.Sampled {
+					t.Fatalf("Seed %d: report %d/%d products, %d samples", seed, report.Sampled, report.Products, len(report.Samples))
+				}
+				if partition == nil && report.Sampled != size {
+					t.Errorf("Seed %d: sampled %d, want %d", seed, report.Sampled, size)
+				}
+				if report.DuplicateRateLow <= trueRate && trueRate <= report.DuplicateRateHigh {
+					rateCovered++
+				}
+				if report.PairsLow <= truePairs && truePairs <= report.PairsHigh {
+					pairsCovered++
+				}
+			}
+			// 95% intervals: allow a few misses out of 20
+			if rateCovered < seeds-3 || pairsCovered < seeds-3 {
+				t.Errorf("Intervals covered the true rate %.3f in %d of %d seeds, the true %v pairs in %d",
+					trueRate, rateCovered, seeds, truePairs, pairsCovered)
+			}
+		})
+	}
+}
+
+func TestSampleDuplicateRateOptions(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, 200)
+	engine := NewHybridEngine()
+
+	first, err := engine.SampleDuplicateRate(products, 0.8, SampleOptions{Size: 50, Seed: 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if engine.IndexedCount() != len(products) {
+		t.Errorf("Sampling should build the index, %d indexed", engine.IndexedCount())
+	}
+	again, _ := engine.SampleDuplicateRate(products, 0.8, SampleOptions{Size: 50, Seed: 9})
+	if !reflect.DeepEqual(first, again) {
+		t.Error("The same seed sampled differently")
+	}
+	other, _ := engine.SampleDuplicateRate(products, 0.8, SampleOptions{Size: 50, Seed: 10})
+	if reflect.DeepEqual(first.Samples, other.Samples) {
+		t.Error("Another seed sampled the same products")
+	}
+
+	seen := make(map[string]bool)
+	for _, s := range first.Samples {
+		if seen[s.ID] {
+			t.Errorf("Product %s sampled twice", s.ID)
+		}
+		seen[s.ID] = true
+	}
+
+	all, _ := engine.SampleDuplicateRate(products, 0.8, SampleOptions{Size: 1000})
+	if all.Sampled != len(products) || all.DuplicateRateLow != all.DuplicateRate || all.DuplicateRateHigh != all.DuplicateRate {
+		t.Errorf("Sampling the whole catalog: %d sampled, rate %v in [%v, %v]", all.Sampled, all.DuplicateRate, all.DuplicateRateLow, all.DuplicateRateHigh)
+	}
+
+	for _, opts := range []SampleOptions{{Confidence: 1}, {Confidence: -0.5}} {
+		if _, err := engine.SampleDuplicateRate(products, 0.8, opts); err == nil {
+			t.Errorf("Confidence %v accepted", opts.Confidence)
+		}
+	}
+	if _, err := engine.SampleDuplicateRate(products, 1.5, SampleOptions{}); err == nil {
+		t.Error("Threshold 1.5 accepted")
+	}
+}
@@ -0,0 +1,185 @@
+package duplicatecheck
+
+import (
+	"context"
+	"log/slog"
+	"math"
+)
+
+// verificationSampleChunk is how many sampled pairs are compared at a time
+// before they are written, in pair order
+const verificationSampleChunk = 1024
+
+// pairSampler selects pairs by a stable hash of a seed and their IDs
+type pairSampler struct {
+	seed  uint64
+	limit uint64 // Pairs whose hash is below limit are selected
+	all   bool   // rate >= 1
+}
+
+// newPairSampler returns a sampler selecting about rate of all pairs
+func newPairSampler(rate float64, seed int64) pairSampler {
+	s := pairSampler{seed: mix64(uint64(seed))}
+	switch {
+	case rate >= 1:
+		s.all = true
+	case rate > 0:
+		s.limit = uint64(rate * math.MaxUint64)
+	}
+	return s
+}
+
+// idHash is the hash of one product ID pairs are sampled by
+func idHash(id string) uint64 {
+	return contentFingerprint(id)
+}
+
+// selects reports whether the pair of two products with these ID hashes is
+// sampled, whichever order they come in
+func (s pairSampler) selects(hashA, hashB uint64) bool {
+	if s.all {
+		return true
+	}
+	if hashB < hashA {
+		hashA, hashB = hashB, hashA
+	}
+	return mix64(mix64(s.seed^hashA)^hashB) < s.limit
+}
+
+// SamplePairs returns the index pairs (i < j, in pair order) of the products
+// a QA sample at rate selects, about rate of all pairs
+// Each pair is selected by a hash of seed and its two IDs, so the same seed
+// selects the same pairs of products whatever order the catalog lists them
+// in, and a pair's selection doesn't depend on the rest of the catalog: the
+// sample can be rebuilt rather than stored. IDs of any shape are selected at
+// the same rate. rate <= 0 selects nothing, rate >= 1 every pair.
+func SamplePairs(products []Product, rate float64, seed int64) [][2]int {
+	sampler := newPairSampler(rate, seed)
+	if rate <= 0 || len(products) < 2 {
+		return [][2]int{}
+	}
+	hashes := make([]uint64, len(products))
+	for i := range products {
+		hashes[i] = idHash(products[i].ID)
+	}
+	pairs := [][2]int{}
+	for i := range products {
+		for j := i + 1; j < len(products); j++ {
+			if sampler.selects(hashes[i], hashes[j]) {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+	}
+	return pairs
+}
+
+// verificationSampling routes a sample of every scan's pairs to a sink
+type verificationSampling struct {
+	sampler pairSampler
+	sink    ResultWriter
+}
+
+// WithVerificationSampling writes the comparison of a deterministic sample of
+// pairs to sink on every FindDuplicates scan, matches or not, for QA to
+// re-check verdicts by hand; rate <= 0 or a nil sink turns it off (the
+// default). Returns the engine for chaining.
+// The sample is the pairs SamplePairs selects for rate and seed among the
+// scanned products, so reruns over the same catalog sample the same pairs,
+// sequential or parallel. Each sampled pair is compared in full, even when the
+// scan pruned it, and written in pair order after the scan, with
+// MeetsThreshold against the scan's threshold. Pairs the pair constraint or
+// the quality filter exclude are not sampled. The scan's results are the same
+// with sampling on or off. A sink error stops that scan's sample, and is
+// logged when a logger is set.
+func (e *LevenshteinEngine) WithVerificationSampling(rate float64, seed int64, sink ResultWriter) *LevenshteinEngine {
+	if rate <= 0 || sink == nil {
+		e.verificationSample = nil
+		return e
+	}
+	e.verificationSample = &verificationSampling{sampler: newPairSampler(rate, seed), sink: sink}
+	return e
+}
+
+// WithVerificationSampling writes a deterministic sample of every
+// FindDuplicates scan's pairs to sink, compared in full whether or not they
+// were LSH candidates (see LevenshteinEngine.WithVerificationSampling).
+// Returns the engine for chaining.
+func (e *HybridEngine) WithVerificationSampling(rate float64, seed int64, sink ResultWriter) *HybridEngine {
+	e.levenshteinEngine.WithVerificationSampling(rate, seed, sink)
+	return e
+}
+
+// writeVerificationSample compares the sampled pairs of a scan's products and
+// writes them to the sampling sink, in pair order
+func (e *LevenshteinEngine) writeVerificationSample(products []*Product, threshold float64) {
+	sample := e.verificationSample
+	if sample == nil || len(products) < 2 {
+		return
+	}
+	quality := e.newQualityCheck()
+	if quality != nil {
+		products = quality.products(products)
+	}
+	hashes := make([]uint64, len(products))
+	for i, p := range products {
+		hashes[i] = idHash(p.ID)
+	}
+
+	workers := e.scanWorkers(len(products), len(products) > 50)
+	chunk := make([]pairIndexes, 0, verificationSampleChunk)
+	results := make([]ComparisonResult, verificationSampleChunk)
+	flush := func() error {
+		generate := func(visit func(int) bool) {
+			for k := range chunk {
+				if !visit(k) {
+					return
+				}
+			}
+		}
+		runWorkerStages(workers, generate, func() func(int) (int, bool) {
+			return func(k int) (int, bool) {
+				a, b := products[chunk[k].i], products[chunk[k].j]
+				result := e.compareWithWeights(a, b, e.resolveWeights(a, b), memoPair{}, 0)
+				result.stampThreshold(threshold)
+				results[k] = result
+				return k, true
+			}
+		}, func(int) bool { return true })
+		for k := range chunk {
+			if quality != nil {
+				quality.flag(&results[k])
+			}
+			if err := sample.sink.WriteResult(results[k]); err != nil {
+				return err
+			}
+		}
+		chunk = chunk[:0]
+		return nil
+	}
+
+	for i := range products {
+		for j := i + 1; j < len(products); j++ {
+			if !sample.sampler.selects(hashes[i], hashes[j]) || e.pairExcluded(products[i], products[j]) {
+				continue
+			}
+			chunk = append(chunk, pairIndexes{i, j})
+			if len(chunk) == verificationSampleChunk {
+				if err := flush(); err != nil {
+					e.logSampleFailed(err)
+					return
+				}
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		e.logSampleFailed(err)
+	}
+}
+
+// logSampleFailed records a verification sample stopped by its sink
+func (e *LevenshteinEngine) logSampleFailed(err error) {
+	if e.logger != nil {
+		e.logger.LogAttrs(context.Background(), slog.LevelWarn, "verification sample stopped",
+			slog.String("reason", err.Error()))
+	}
+}
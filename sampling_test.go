@@ -0,0 +1,164 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// sampledPairKeys returns the sorted PairKeys of the pairs SamplePairs selects
+func sampledPairKeys(products []Product, rate float64, seed int64) []string {
+	keys := []string{}
+	for _, pair := range SamplePairs(products, rate, seed) {
+		keys = append(keys, PairKey(products[pair[0]].ID, products[pair[1]].ID))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// collectingSink is a ResultWriter keeping what it is given
+type collectingSink struct {
+	results []ComparisonResult
+}
+
+func (s *collectingSink) WriteResult(result ComparisonResult) error {
+	s.results = append(s.results, result)
+	return nil
+}
+
+// keys returns the PairKeys of the collected results, in order
+func (s *collectingSink) keys() []string {
+	keys := make([]string, len(s.results))
+	for i, r := range s.results {
+		keys[i] = PairKey(r.ProductA.ID, r.ProductB.ID)
+	}
+	return keys
+}
+
+func TestSamplePairsReproducible(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 300)
+	want := sampledPairKeys(catalog, 0.01, 42)
+	if len(want) == 0 {
+		t.Fatal("empty sample")
+	}
+	if got := sampledPairKeys(catalog, 0.01, 42); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Error("a rerun sampled other pairs")
+	}
+
+	shuffled := append([]Product(nil), catalog...)
+	rand.New(rand.NewSource(3)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	if got := sampledPairKeys(shuffled, 0.01, 42); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Error("reordering the catalog changed the sample")
+	}
+	if got := sampledPairKeys(catalog, 0.01, 43); fmt.Sprint(got) == fmt.Sprint(want) {
+		t.Error("another seed sampled the same pairs")
+	}
+
+	if got := SamplePairs(catalog, 0, 42); len(got) != 0 {
+		t.Errorf("rate 0 sampled %d pairs", len(got))
+	}
+	if got := SamplePairs(catalog[:10], 1, 42); len(got) != 45 {
+		t.Errorf("rate 1 sampled %d of 45 pairs", len(got))
+	}
+}
+
+func TestSamplePairsRate(t *testing.T) {
+	// Strata of differently shaped IDs, each sampled within itself
+	strata := map[string]func(i int) string{
+		"sequential": func(i int) string { return fmt.Sprint(i) },
+		"padded":     func(i int) string { return fmt.Sprintf("SKU-%06d", i) },
+		"prefixed":   func(i int) string { return fmt.Sprintf("store-a/cat-%d/item-%d", i%3, i) },
+		"one letter": func(i int) string { return string(rune('a'+i%26)) + fmt.Sprint(i/26) },
+		"random":     func(i int) string { return fmt.Sprintf("%016x", rand.New(rand.NewSource(int64(i))).Uint64()) },
+	}
+	const n, rate = 400, 0.01
+	pairs := float64(n * (n - 1) / 2)
+	tolerance := 4 * math.Sqrt(pairs*rate*(1-rate)) // Four standard deviations
+	for name, id := range strata {
+		t.Run(name, func(t *testing.T) {
+			products := make([]Product, n)
+			for i := range products {
+				products[i] = Product{ID: id(i)}
+			}
+			for seed := int64(1); seed <= 3; seed++ {
+				if got := float64(len(SamplePairs(products, rate, seed))); math.Abs(got-pairs*rate) > tolerance {
+					t.Errorf("seed %d: %v pairs sampled, want %v ± %.0f", seed, got, pairs*rate, tolerance)
+				}
+			}
+		})
+	}
+}
+
+func TestVerificationSampling(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 100)
+	want := comparableResults(NewLevenshteinEngine().FindDuplicates(catalog, 0.8))
+	sampled := sampledPairKeys(catalog, 0.02, 7)
+
+	var samples [][]string
+	for _, workers := range []int{1, 4} {
+		sink := &collectingSink{}
+		engine := NewLevenshteinEngine().WithVerificationSampling(0.02, 7, sink)
+		engine.SetMaxWorkers(workers)
+		if got := comparableResults(engine.FindDuplicates(catalog, 0.8)); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%d workers: sampling changed the results", workers)
+		}
+		keys := sink.keys()
+		samples = append(samples, keys)
+		sorted := append([]string(nil), keys...)
+		sort.Strings(sorted)
+		if fmt.Sprint(sorted) != fmt.Sprint(sampled) {
+			t.Errorf("%d workers: sampled %d pairs, SamplePairs %d", workers, len(keys), len(sampled))
+		}
+
+		negatives := 0
+		for _, r := range sink.results {
+			full := NewLevenshteinEngine().Compare(r.ProductA, r.ProductB)
+			if math.Abs(r.CombinedSimilarity-full.CombinedSimilarity) > 1e-12 || r.MeetsThreshold != meetsThreshold(full.CombinedSimilarity, 0.8) || r.ThresholdUsed != 0.8 {
+				t.Errorf("%s: sampled %v (meets %v), Compare %v", PairKey(r.ProductA.ID, r.ProductB.ID), r.CombinedSimilarity, r.MeetsThreshold, full.CombinedSimilarity)
+			}
+			if !r.MeetsThreshold {
+				negatives++
+			}
+		}
+		if negatives == 0 {
+			t.Errorf("%d workers: no below-threshold pairs sampled", workers)
+		}
+	}
+	if fmt.Sprint(samples[0]) != fmt.Sprint(samples[1]) {
+		t.Error("parallel scan wrote the sample in another order")
+	}
+
+	// Hybrid scans sample every pair, candidates or not
+	sink := &collectingSink{}
+	hybrid := NewHybridEngine().WithVerificationSampling(0.02, 7, sink)
+	if err := hybrid.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	hybrid.FindDuplicates(catalog, 0.8)
+	if fmt.Sprint(sink.keys()) != fmt.Sprint(samples[0]) {
+		t.Errorf("hybrid sampled %d pairs, Levenshtein %d", len(sink.results), len(samples[0]))
+	}
+
+	// Excluded pairs aren't sampled, and a failing sink stops the sample
+	sink = &collectingSink{}
+	NewLevenshteinEngine().WithVerificationSampling(0.02, 7, sink).
+		WithPairConstraint(func(a, b Product) bool { return false }).FindDuplicates(catalog, 0.8)
+	if len(sink.results) != 0 {
+		t.Errorf("%d excluded pairs sampled", len(sink.results))
+	}
+	failing := &limitedSink{writeLimit: 3, checkpointStop: -1}
+	NewLevenshteinEngine().WithVerificationSampling(0.02, 7, failing).FindDuplicates(catalog, 0.8)
+	if len(failing.results) != 3 {
+		t.Errorf("failing sink: %d samples written", len(failing.results))
+	}
+
+	// Off again
+	sink = &collectingSink{}
+	engine := NewLevenshteinEngine().WithVerificationSampling(0.02, 7, sink).WithVerificationSampling(0, 7, sink)
+	engine.FindDuplicates(catalog, 0.8)
+	if len(sink.results) != 0 {
+		t.Errorf("rate 0 wrote %d samples", len(sink.results))
+	}
+}
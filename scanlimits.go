@@ -0,0 +1,121 @@
+package duplicatecheck
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Scan limit names, as ScanLimitError.Limit reports them
+const (
+	LimitMaxPairs                 = "MaxPairs"
+	LimitMaxEstimatedDuration     = "MaxEstimatedDuration"
+	LimitMaxEstimatedResultMemory = "MaxEstimatedResultMemory"
+)
+
+// ScanLimits bounds the all-pairs scans a LevenshteinEngine starts (see
+// WithScanLimits); a zero field is no limit
+type ScanLimits struct {
+	// MaxPairs bounds the pairs of the input, n(n-1)/2 for n products,
+	// counted without sampling
+	MaxPairs uint64
+	// MaxEstimatedDuration bounds the scan's wall time as EstimateScanCost
+	// predicts it
+	MaxEstimatedDuration time.Duration
+	// MaxEstimatedResultMemory bounds the bytes of the result slice as
+	// EstimateScanCost predicts them from its sampled match rate
+	MaxEstimatedResultMemory int64
+}
+
+// ScanLimitError is returned when a scan would exceed the engine's ScanLimits
+type ScanLimitError struct {
+	Limit    string       // The limit exceeded (see the Limit constants)
+	Limits   ScanLimits   // The engine's limits
+	Estimate ScanEstimate // The scan's estimate; only Products and Pairs for MaxPairs
+}
+
+// Error implements the error interface
+func (e *ScanLimitError) Error() string {
+	var estimated, limit string
+	switch e.Limit {
+	case LimitMaxPairs:
+		estimated, limit = fmt.Sprintf("%.0f pairs", e.Estimate.Pairs), fmt.Sprintf("%d", e.Limits.MaxPairs)
+	case LimitMaxEstimatedDuration:
+		estimated, limit = e.Estimate.Duration.Round(time.Millisecond).String(), e.Limits.MaxEstimatedDuration.String()
+	case LimitMaxEstimatedResultMemory:
+		estimated, limit = fmt.Sprintf("%d bytes of results", e.Estimate.ResultBytes), fmt.Sprintf("%d bytes", e.Limits.MaxEstimatedResultMemory)
+	}
+	return fmt.Sprintf("duplicatecheck: Levenshtein scan of %d products exceeds %s (estimated %s, limit %s); %s",
+		e.Estimate.Products, e.Limit, estimated, limit, scanLimitRecommendation)
+}
+
+// scanLimitRecommendation is the advice of a ScanLimitError
+const scanLimitRecommendation = "use the hybrid engine (NewHybridEngine), which compares only LSH candidates, for catalogs this size"
+
+// WithScanLimits sets limits checked before an all-pairs scan starts; the
+// zero ScanLimits (the default) turns them off. Returns the engine for chaining.
+// A catalog of 50,000 products has 1.25 billion pairs, which the Levenshtein
+// engine takes hours to compare and may not hold the results of. Before
+// scanning, FindDuplicatesChecked, FindDuplicatesCtx,
+// FindDuplicatesWithSummary, FindDuplicatesChan and FindDuplicatesToFileSorted
+// refuse a scan exceeding a limit with a *ScanLimitError recommending the
+// hybrid engine. FindDuplicates and FindDuplicatesPtr, which return no error,
+// log the same message as a warning (see WithLogger) and scan anyway. Only
+// the estimate limits cost an EstimateScanCost sample, a fraction of a second.
+func (e *LevenshteinEngine) WithScanLimits(limits ScanLimits) *LevenshteinEngine {
+	e.scanLimits = limits
+	return e
+}
+
+// GetScanLimits returns the engine's scan limits
+func (e *LevenshteinEngine) GetScanLimits() ScanLimits {
+	return e.scanLimits
+}
+
+// checkScanLimits returns a *ScanLimitError when scanning products at
+// threshold would exceed the engine's limits
+func (e *LevenshteinEngine) checkScanLimits(products []Product, threshold float64) error {
+	limits := e.scanLimits
+	if limits == (ScanLimits{}) {
+		return nil
+	}
+	n := len(products)
+	pairs := float64(n) * float64(n-1) / 2
+	if n < 2 {
+		pairs = 0
+	}
+	if limits.MaxPairs > 0 && pairs > float64(limits.MaxPairs) {
+		return &ScanLimitError{Limit: LimitMaxPairs, Limits: limits, Estimate: ScanEstimate{Engine: EngineLevenshtein, Products: n, Pairs: pairs}}
+	}
+	if limits.MaxEstimatedDuration <= 0 && limits.MaxEstimatedResultMemory <= 0 {
+		return nil
+	}
+	estimate := EstimateScanCost(products, threshold, EngineLevenshtein)
+	switch {
+	case limits.MaxEstimatedDuration > 0 && estimate.Duration > limits.MaxEstimatedDuration:
+		return &ScanLimitError{Limit: LimitMaxEstimatedDuration, Limits: limits, Estimate: estimate}
+	case limits.MaxEstimatedResultMemory > 0 && estimate.ResultBytes > limits.MaxEstimatedResultMemory:
+		return &ScanLimitError{Limit: LimitMaxEstimatedResultMemory, Limits: limits, Estimate: estimate}
+	}
+	return nil
+}
+
+// warnScanLimits logs a warning when scanning products at threshold would
+// exceed the engine's limits, for the scans that can't refuse
+func (e *LevenshteinEngine) warnScanLimits(products []Product, threshold float64) {
+	if e.logger == nil {
+		return
+	}
+	err := e.checkScanLimits(products, threshold)
+	if err == nil {
+		return
+	}
+	limitErr := err.(*ScanLimitError)
+	e.logger.LogAttrs(context.Background(), slog.LevelWarn, "scan limit exceeded",
+		slog.String("engine", "levenshtein"),
+		slog.String("limit", limitErr.Limit),
+		slog.Int("products", len(products)),
+		slog.String("recommendation", scanLimitRecommendation),
+		slog.String("error", err.Error()))
+}
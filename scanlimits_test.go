@@ -0,0 +1,110 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanLimitsRefuse(t *testing.T) {
+	products := GenerateTestCatalog(1, 60)
+	tests := []struct {
+		name   string
+		limits ScanLimits
+		want   string
+	}{
+		{"pairs", ScanLimits{MaxPairs: 100}, LimitMaxPairs},
+		{"duration", ScanLimits{MaxEstimatedDuration: time.Nanosecond}, LimitMaxEstimatedDuration},
+		{"result memory", ScanLimits{MaxEstimatedResultMemory: 1}, LimitMaxEstimatedResultMemory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewLevenshteinEngine().WithScanLimits(tt.limits)
+			scans := map[string]func() error{
+				"FindDuplicatesChecked": func() error {
+					_, err := engine.FindDuplicatesChecked(products, 0.3)
+					return err
+				},
+				"FindDuplicatesCtx": func() error {
+					_, err := engine.FindDuplicatesCtx(context.Background(), products, 0.3)
+					return err
+				},
+				"FindDuplicatesWithSummary": func() error {
+					_, _, err := engine.FindDuplicatesWithSummary(products, 0.3)
+					return err
+				},
+				"FindDuplicatesChan": func() error {
+					_, err := drainChan(engine.FindDuplicatesChan(context.Background(), products, 0.3, 0))
+					return err
+				},
+				"FindDuplicatesToFileSorted": func() error {
+					path := filepath.Join(t.TempDir(), "results.jsonl")
+					err := engine.FindDuplicatesToFileSorted(products, 0.3, path, SpillOptions{})
+					if _, statErr := os.Stat(path); statErr == nil {
+						t.Error("refused scan wrote a results file")
+					}
+					return err
+				},
+			}
+			for name, scan := range scans {
+				err := scan()
+				var limitErr *ScanLimitError
+				if !errors.As(err, &limitErr) {
+					t.Fatalf("%s: got %v, want a *ScanLimitError", name, err)
+				}
+				if limitErr.Limit != tt.want || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "hybrid engine") {
+					t.Errorf("%s: %v, want limit %s and the hybrid engine recommended", name, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestScanLimitsWithinLimits(t *testing.T) {
+	products := GenerateTestCatalog(1, 60)
+	engine := NewLevenshteinEngine().WithScanLimits(ScanLimits{
+		MaxPairs:                 uint64(len(products) * (len(products) - 1) / 2),
+		MaxEstimatedDuration:     time.Hour,
+		MaxEstimatedResultMemory: 1 << 30,
+	})
+	got, err := engine.FindDuplicatesChecked(products, 0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := NewLevenshteinEngine().FindDuplicates(products, 0.8); len(got) != len(want) {
+		t.Errorf("%d results within limits, want %d", len(got), len(want))
+	}
+}
+
+func TestScanLimitsLegacyWarns(t *testing.T) {
+	products := GenerateTestCatalog(1, 60)
+	want := NewLevenshteinEngine().FindDuplicates(products, 0.8)
+
+	handler := &captureHandler{}
+	engine := NewLevenshteinEngine().WithScanLimits(ScanLimits{MaxPairs: 10}).WithLogger(slog.New(handler))
+	scans := map[string]func() []ComparisonResult{
+		"FindDuplicates":    func() []ComparisonResult { return engine.FindDuplicates(products, 0.8) },
+		"FindDuplicatesPtr": func() []ComparisonResult { return engine.FindDuplicatesPtr(productPtrs(products), 0.8) },
+	}
+	for name, scan := range scans {
+		handler.records = nil
+		if got := scan(); len(got) != len(want) {
+			t.Errorf("%s: %d results, want the scan's %d", name, len(got), len(want))
+		}
+		record, ok := handler.find("scan limit exceeded")
+		if !ok || record.level != slog.LevelWarn {
+			t.Fatalf("%s: no scan limit warning logged", name)
+		}
+		if got := record.attrs["limit"].String(); got != LimitMaxPairs {
+			t.Errorf("%s: warned about limit %q, want %q", name, got, LimitMaxPairs)
+		}
+		if !strings.Contains(record.attrs["recommendation"].String(), "hybrid engine") {
+			t.Errorf("%s: recommendation %q", name, record.attrs["recommendation"])
+		}
+	}
+}
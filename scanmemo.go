@@ -0,0 +1,284 @@
+package duplicatecheck
+
+import "sync"
+
+// scanMemoShards is the number of independently locked shards per field
+const scanMemoShards = 64
+
+// descriptionScore is the description part of one comparison
+type descriptionScore struct {
+	distance   int
+	similarity float64
+	segments   []SegmentScore
+	timedOut   bool // Exceeded MaxComparisonDuration; similarity is 0
+}
+
+// nameScore is the name part of one comparison
+type nameScore struct {
+	distance   int
+	similarity float64
+	rejected   bool // Ruled out by the Rabin-Karp filter; distance and similarity are unset
+
+	deobfuscated float64 // Similarity of the de-obfuscated names, when obfuscated
+	obfuscated   bool    // De-obfuscated names scored higher (see WithDeobfuscation)
+
+	sku      bool // Both names SKU-like, scored by SKU key (see WithSKUNames)
+	skuMixed bool // One name SKU-like, scored as text
+
+	bundle     float64          // Bundle similarity, when both names split into components (see WithBundleSplitter)
+	components []ComponentMatch // The alignment bundle was scored by
+}
+
+// scanMemo reuses name and description scores within one FindDuplicates scan
+// Products are grouped by prepared name and by prepared description before
+// the scan; the score of a pair of groups is computed once and shared by
+// every product pair drawn from them, so a catalog with g distinct names
+// computes at most g² name distances however many rows repeat them. Only
+// pairs involving a group with two or more members can repeat, so only those
+// are stored. The memo lives for one scan, so it never sees a product change.
+type scanMemo struct {
+	names        *fieldGroups // nil when the name memo is off or no name repeats
+	descriptions *fieldGroups // nil when the description memo is off or no description repeats
+	nameShards   [scanMemoShards]nameMemoShard
+	descShards   [scanMemoShards]descriptionMemoShard
+
+	lowInfo []bool // Low-information name of each product, with WithLowInfoNames
+}
+
+// fieldGroups numbers the distinct values of one prepared field in a scan
+type fieldGroups struct {
+	groups []uint32 // Group of each product
+	shared []bool   // Whether a group has two or more members
+}
+
+// nameMemoShard is one mutex-protected part of the name memo
+type nameMemoShard struct {
+	mu     sync.Mutex
+	scores map[uint64]nameScore
+}
+
+// descriptionMemoShard is one mutex-protected part of the description memo
+type descriptionMemoShard struct {
+	mu     sync.Mutex
+	scores map[uint64]descriptionScore
+}
+
+// memoPair identifies a scan pair for the memo and carries the scan worker's
+// scratch buffers; the zero value disables the memo and uses the pools
+type memoPair struct {
+	memo    *scanMemo
+	i, j    int
+	scratch *comparisonScratch
+	scores  *pairScores // Scores shared by the pair's weight profiles (see FindDuplicatesMultiWeights)
+}
+
+// name returns the pair's name score, computing it unless the pair's weight
+// profiles or the scan memo already did
+func (p memoPair) name(compute func() nameScore) nameScore {
+	if p.scores != nil && p.scores.hasName {
+		return p.scores.name
+	}
+	var score nameScore
+	if p.memo != nil {
+		score = p.memo.name(p.i, p.j, compute)
+	} else {
+		score = compute()
+	}
+	if p.scores != nil {
+		p.scores.name, p.scores.hasName = score, true
+	}
+	return score
+}
+
+// description returns the pair's description score, computing it unless the
+// pair's weight profiles or the scan memo already did
+func (p memoPair) description(compute func() descriptionScore) descriptionScore {
+	if p.scores != nil && p.scores.hasDescription {
+		return p.scores.description
+	}
+	var score descriptionScore
+	if p.memo != nil {
+		score = p.memo.description(p.i, p.j, compute)
+	} else {
+		score = compute()
+	}
+	if p.scores != nil {
+		p.scores.description, p.scores.hasDescription = score, true
+	}
+	return score
+}
+
+// EnableDescriptionMemo turns on reuse of description scores within a
+// FindDuplicates scan for products whose prepared descriptions are identical
+// (e.g. templated copy). On by default; results are identical either way.
+func (e *LevenshteinEngine) EnableDescriptionMemo() {
+	e.noDescriptionMemo = false
+}
+
+// DisableDescriptionMemo turns off the per-scan description memo
+func (e *LevenshteinEngine) DisableDescriptionMemo() {
+	e.noDescriptionMemo = true
+}
+
+// IsDescriptionMemoEnabled returns whether the per-scan description memo is active
+func (e *LevenshteinEngine) IsDescriptionMemoEnabled() bool {
+	return !e.noDescriptionMemo
+}
+
+// EnableNameMemo turns on reuse of name scores within a FindDuplicates scan
+// for products whose prepared names are identical ("Gift Card" listed 500
+// times). On by default; results are identical either way.
+func (e *LevenshteinEngine) EnableNameMemo() {
+	e.noNameMemo = false
+}
+
+// DisableNameMemo turns off the per-scan name memo
+func (e *LevenshteinEngine) DisableNameMemo() {
+	e.noNameMemo = true
+}
+
+// IsNameMemoEnabled returns whether the per-scan name memo is active
+func (e *LevenshteinEngine) IsNameMemoEnabled() bool {
+	return !e.noNameMemo
+}
+
+// newScanMemo groups products by prepared name and description, and flags
+// their low-information names (see WithLowInfoNames)
+// Returns nil when both memos are disabled or no field value is shared, and
+// no name is flagged.
+func (e *LevenshteinEngine) newScanMemo(products []*Product) *scanMemo {
+	if len(products) < 3 {
+		return nil
+	}
+	prep := e.preparer()
+	memo := &scanMemo{}
+	if e.lowInfo != nil {
+		memo.lowInfo = e.lowInfo.scan(products, prep)
+	}
+	if !e.noNameMemo {
+		memo.names = newFieldGroups(products, func(p *Product) string {
+			name, _ := p.preparedStrings(prep)
+			return name
+		})
+	}
+	if !e.noDescriptionMemo {
+		memo.descriptions = newFieldGroups(products, func(p *Product) string {
+			_, desc := p.preparedStrings(prep)
+			return desc
+		})
+	}
+	if memo.names == nil && memo.descriptions == nil {
+		if memo.lowInfo != nil {
+			return memo // The memos stay empty: every pair is computed
+		}
+		return nil
+	}
+	for i := range memo.nameShards {
+		memo.nameShards[i].scores = make(map[uint64]nameScore)
+		memo.descShards[i].scores = make(map[uint64]descriptionScore)
+	}
+	return memo
+}
+
+// newFieldGroups groups products by the value of field
+// Returns nil when every value is unique: no pair repeats.
+func newFieldGroups(products []*Product, field func(*Product) string) *fieldGroups {
+	ids := make(map[string]uint32, len(products))
+	groups := make([]uint32, len(products))
+	var counts []int
+	for i, p := range products {
+		value := field(p)
+		id, exists := ids[value]
+		if !exists {
+			id = uint32(len(counts))
+			ids[value] = id
+			counts = append(counts, 0)
+		}
+		groups[i] = id
+		counts[id]++
+	}
+	if len(counts) == len(products) {
+		return nil
+	}
+
+	g := &fieldGroups{groups: groups, shared: make([]bool, len(counts))}
+	for id, count := range counts {
+		g.shared[id] = count > 1
+	}
+	return g
+}
+
+// key returns the memo key and shard of products i and j, and whether their
+// score can repeat in the scan
+// The key keeps the pair's orientation, since scores may record which side is A.
+func (g *fieldGroups) key(i, j int) (uint64, uint32, bool) {
+	if g == nil {
+		return 0, 0, false
+	}
+	groupA, groupB := g.groups[i], g.groups[j]
+	if !g.shared[groupA] && !g.shared[groupB] {
+		return 0, 0, false
+	}
+	return uint64(groupA)<<32 | uint64(groupB), (groupA*31 + groupB) % scanMemoShards, true
+}
+
+// name returns the name score of products i and j, computing it on first use
+func (m *scanMemo) name(i, j int, compute func() nameScore) nameScore {
+	key, shardIdx, ok := m.names.key(i, j)
+	if !ok {
+		return compute()
+	}
+
+	shard := &m.nameShards[shardIdx]
+	shard.mu.Lock()
+	score, exists := shard.scores[key]
+	shard.mu.Unlock()
+	if exists {
+		return score
+	}
+
+	// Computed outside the lock; concurrent workers may both compute the same
+	// deterministic score
+	score = compute()
+	shard.mu.Lock()
+	shard.scores[key] = score
+	shard.mu.Unlock()
+	return score
+}
+
+// description returns the description score of products i and j, computing
+// it on first use
+func (m *scanMemo) description(i, j int, compute func() descriptionScore) descriptionScore {
+	key, shardIdx, ok := m.descriptions.key(i, j)
+	if !ok {
+		return compute()
+	}
+
+	shard := &m.descShards[shardIdx]
+	shard.mu.Lock()
+	score, exists := shard.scores[key]
+	shard.mu.Unlock()
+	if exists {
+		return score
+	}
+
+	// As in name: concurrent workers may both compute the same score
+	score = compute()
+	shard.mu.Lock()
+	shard.scores[key] = score
+	shard.mu.Unlock()
+	return score
+}
+
+// comparePair compares products i and j of a scan at threshold, sharing name
+// and description scores through memo and computing distances in the
+// worker's scratch
+// Returns false, without comparing, when charset pruning rules the pair out.
+func (e *LevenshteinEngine) comparePair(products []*Product, i, j int, memo *scanMemo, scratch *comparisonScratch, threshold float64) (ComparisonResult, bool) {
+	a, b := products[i], products[j]
+	weights := e.resolveWeights(a, b)
+	if e.charsetRejects(a, b, weights, threshold) && e.idRelation(a.ID, b.ID) != IDSameEntity {
+		return ComparisonResult{}, false
+	}
+	return e.compareWithWeights(a, b, weights, memoPair{memo: memo, i: i, j: j, scratch: scratch}, threshold), true
+}
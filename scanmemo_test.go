@@ -0,0 +1,246 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// boilerplateCatalog builds n products with similar names where 30% share one
+// of five boilerplate descriptions and the rest have their own
+func boilerplateCatalog(n int, descWords int) []Product {
+	rng := rand.New(rand.NewSource(9))
+	boilerplates := make([]string, 5)
+	for i := range boilerplates {
+		boilerplates[i] = randomWordText(rng, descWords)
+	}
+
+	products := make([]Product, n)
+	for i := range products {
+		desc := randomWordText(rng, descWords)
+		if rng.Float64() < 0.3 {
+			desc = boilerplates[rng.Intn(len(boilerplates))]
+		}
+		products[i] = Product{
+			ID:          fmt.Sprintf("B%04d", i),
+			Name:        fmt.Sprintf("Acme Phone Case Model %04d", rng.Intn(10000)),
+			Description: desc,
+		}
+	}
+	return products
+}
+
+// comparableResults strips products (which carry cache pointers) and sorts by pair
+func comparableResults(results []ComparisonResult) []ComparisonResult {
+	out := make([]ComparisonResult, len(results))
+	for i, r := range results {
+		idA, idB := r.ProductA.ID, r.ProductB.ID
+		r.ProductA, r.ProductB = Product{ID: idA}, Product{ID: idB}
+		out[i] = r
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return PairKey(out[i].ProductA.ID, out[i].ProductB.ID) < PairKey(out[j].ProductA.ID, out[j].ProductB.ID)
+	})
+	return out
+}
+
+func TestDescriptionMemo(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		segmenter DescriptionSegmenter
+	}{
+		{"sequential", 40, nil},
+		{"parallel", 80, nil},
+		{"segmented", 60, DefaultSegmenter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := boilerplateCatalog(tt.size, 40)
+			memoized := NewLevenshteinEngine().WithDescriptionSegmenter(tt.segmenter, AlignBestMatch)
+			plain := NewLevenshteinEngine().WithDescriptionSegmenter(tt.segmenter, AlignBestMatch)
+			plain.DisableDescriptionMemo()
+
+			if memo := memoized.newScanMemo(productPtrs(products)); memo == nil {
+				t.Fatal("expected a memo for a catalog with shared descriptions")
+			}
+
+			// A low threshold keeps most pairs, so every score is compared
+			got := comparableResults(memoized.FindDuplicates(products, 0.3))
+			want := comparableResults(plain.FindDuplicates(products, 0.3))
+			if len(want) == 0 {
+				t.Fatal("expected results to compare")
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("memoized scan differs from unmemoized scan (%d vs %d results)", len(got), len(want))
+			}
+		})
+	}
+
+	t.Run("toggle", func(t *testing.T) {
+		engine := NewLevenshteinEngine()
+		if !engine.IsDescriptionMemoEnabled() {
+			t.Error("memo should be enabled by default")
+		}
+		engine.DisableDescriptionMemo()
+		if engine.IsDescriptionMemoEnabled() || engine.newScanMemo(productPtrs(boilerplateCatalog(10, 5))) != nil {
+			t.Error("disabled memo still built")
+		}
+		engine.EnableDescriptionMemo()
+		if !engine.IsDescriptionMemoEnabled() {
+			t.Error("memo should be enabled again")
+		}
+	})
+
+	t.Run("unique descriptions", func(t *testing.T) {
+		products := []Product{
+			{ID: "1", Name: "a", Description: "one"},
+			{ID: "2", Name: "b", Description: "two"},
+			{ID: "3", Name: "c", Description: "three"},
+		}
+		if NewLevenshteinEngine().newScanMemo(productPtrs(products)) != nil {
+			t.Error("no memo expected when every description is unique")
+		}
+	})
+}
+
+// repeatedNameCatalog builds n products whose names are drawn from distinct
+// names (short and long, some near-identical), with case and spacing variants,
+// and descriptions that are empty, shared boilerplate or unique
+func repeatedNameCatalog(seed int64, n, distinct int) []Product {
+	rng := rand.New(rand.NewSource(seed))
+	names := make([]string, distinct)
+	for i := range names {
+		switch {
+		case i > 0 && i%4 == 0:
+			names[i] = names[i-1] + " v2" // Near-identical to the previous name
+		case i%3 == 0:
+			names[i] = "Premium " + randomWordText(rng, 4) // Long enough for the Rabin-Karp filter
+		default:
+			names[i] = randomWordText(rng, 2)
+		}
+	}
+	boilerplate := randomWordText(rng, 30)
+
+	products := make([]Product, n)
+	for i := range products {
+		name := names[rng.Intn(len(names))]
+		if rng.Intn(5) == 0 {
+			name = "  " + strings.ToUpper(name) + " " // Same prepared name
+		}
+		var desc string
+		switch rng.Intn(3) {
+		case 0:
+			desc = boilerplate
+		case 1:
+			desc = randomWordText(rng, 10+rng.Intn(20))
+		}
+		products[i] = Product{ID: fmt.Sprintf("N%04d", i), Name: name, Description: desc}
+	}
+	return products
+}
+
+// TestNameMemo checks that reusing name scores never changes a scan
+func TestNameMemo(t *testing.T) {
+	for seed := int64(1); seed <= 3; seed++ {
+		products := repeatedNameCatalog(seed, 80, 12)
+		memoized := NewLevenshteinEngine()
+		plain := NewLevenshteinEngine()
+		plain.DisableNameMemo()
+		plain.DisableDescriptionMemo()
+
+		for _, threshold := range []float64{0.3, 0.85} {
+			want := comparableResults(plain.FindDuplicates(products, threshold))
+			if len(want) == 0 {
+				t.Fatalf("seed %d: expected results to compare", seed)
+			}
+			for _, scan := range []struct {
+				name string
+				find func([]Product, float64) []ComparisonResult
+			}{
+				{"sequential", memoized.FindDuplicates},
+				{"parallel", memoized.FindDuplicatesParallel},
+			} {
+				if got := comparableResults(scan.find(products, threshold)); !reflect.DeepEqual(got, want) {
+					t.Errorf("seed %d, threshold %v, %s: memoized scan differs from unmemoized scan (%d vs %d results)",
+						seed, threshold, scan.name, len(got), len(want))
+				}
+			}
+		}
+	}
+
+	t.Run("comparison count", func(t *testing.T) {
+		const distinct = 12
+		products := repeatedNameCatalog(7, 300, distinct)
+		count := func(engine *LevenshteinEngine) uint64 {
+			engine.FindDuplicates(products, 0.85)
+			return engine.GetScanStats()["name_comparisons"].(uint64)
+		}
+		plain := NewLevenshteinEngine()
+		plain.DisableNameMemo()
+		memoized, unmemoized := count(NewLevenshteinEngine()), count(plain)
+		// At most one distance per ordered pair of distinct names
+		if memoized > distinct*distinct || memoized*20 > unmemoized {
+			t.Errorf("name_comparisons = %d with the memo, %d without", memoized, unmemoized)
+		}
+	})
+
+	t.Run("toggle", func(t *testing.T) {
+		engine := NewLevenshteinEngine()
+		if !engine.IsNameMemoEnabled() {
+			t.Error("memo should be enabled by default")
+		}
+		engine.DisableNameMemo()
+		if engine.IsNameMemoEnabled() {
+			t.Error("memo should be disabled")
+		}
+		if memo := engine.newScanMemo(productPtrs(repeatedNameCatalog(1, 20, 3))); memo != nil && memo.names != nil {
+			t.Error("disabled name memo still built")
+		}
+		engine.EnableNameMemo()
+		if !engine.IsNameMemoEnabled() {
+			t.Error("memo should be enabled again")
+		}
+	})
+}
+
+// BenchmarkNameMemo scans 1000 products sharing 20 names; name-comparisons/op
+// falls from ~n²/2 to at most 20²
+func BenchmarkNameMemo(b *testing.B) {
+	products := repeatedNameCatalog(1, 1000, 20)
+	for _, memo := range []bool{false, true} {
+		b.Run(fmt.Sprintf("memo=%v", memo), func(b *testing.B) {
+			engine := NewLevenshteinEngine()
+			if !memo {
+				engine.DisableNameMemo()
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				engine.FindDuplicates(products, 0.85)
+			}
+			comparisons := engine.GetScanStats()["name_comparisons"].(uint64)
+			b.ReportMetric(float64(comparisons)/float64(b.N), "name-comparisons/op")
+		})
+	}
+}
+
+// BenchmarkDescriptionMemo scans 300 products where 30% share one of five
+// ~500-char boilerplate descriptions
+func BenchmarkDescriptionMemo(b *testing.B) {
+	products := boilerplateCatalog(300, 80)
+	for _, memo := range []bool{false, true} {
+		b.Run(fmt.Sprintf("memo=%v", memo), func(b *testing.B) {
+			engine := NewLevenshteinEngine()
+			if !memo {
+				engine.DisableDescriptionMemo()
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				engine.FindDuplicates(products, 0.85)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package duplicatecheck
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// ScanSummary reports what one scan did, alongside its results
+// Counts come from the engine's cumulative counters (see GetScanStats and
+// HybridEngine.GetStats), taken before and after the scan, so scans running
+// concurrently on the same engine count into each other's summaries.
+type ScanSummary struct {
+	Engine   string // "levenshtein", or "hybrid" for a scan of the hybrid index
+	Products int    // Products scanned
+
+	InputDuplicatesCollapsed int // Input entries dropped as copies of an earlier entry (see EnableInputDeduplication)
+
+	CandidatePairs uint64 // Pairs considered: every pair of the products, or the distinct LSH candidate pairs
+	Comparisons    uint64 // Pairs compared, after the skips below that come before a comparison
+	NameDistances  uint64 // Name edit distances computed; repeated names share one (see EnableNameMemo)
+
+	LengthPruned        uint64 // Pairs skipped by length pruning (see EnableLengthPruning)
+	CharsetPruned       uint64 // Pairs skipped by charset pruning (see EnableCharsetPruning)
+	ConstraintSkipped   uint64 // Pairs the pair constraint excluded (see WithPairConstraint)
+	SimHashSkipped      uint64 // Candidates the SimHash screen rejected (hybrid, see HybridConfig.SimHashScreen)
+	RabinKarpRejected   uint64 // Compared pairs the Rabin-Karp filter settled without a distance
+	DescriptionsSkipped uint64 // Compared pairs whose description comparison was skipped (see EnableDescriptionSkip)
+	CliqueInferred      uint64 // Clique member pairs inferred instead of verified (hybrid, see HybridConfig.CliqueBands)
+
+	// Suppression store outcomes (see WithSuppressions)
+	SuppressionsApplied uint64 // Pairs skipped as suppressed
+	SuppressionsExpired uint64 // Pairs compared because their suppression expired
+	SuppressionsVoided  uint64 // Pairs compared because a product changed since suppressed
+
+	// Hybrid candidate generation (zero for Levenshtein scans)
+	CandidateLookups uint64 // LSH lookups run, one per scanned product
+	BandsProbed      uint64 // Bands probed across the lookups
+	CandidatesFound  uint64 // Candidates the lookups returned, before each pair is deduplicated
+
+	// Distance buffer pool traffic, package-wide (see GetSlicePoolStats)
+	SlicePools SlicePoolStats
+
+	Duplicates    int           // Results returned
+	Workers       int           // Peak goroutines comparing pairs
+	WallTime      time.Duration // Time from start to the last result
+	Deterministic bool          // The scan ran in deterministic mode (see EnableDeterministicMode)
+}
+
+// scanCounters is a snapshot of an engine's cumulative scan counters
+type scanCounters struct {
+	pairsCompared, lengthPruned, nameDistances, charsetPruned, constraintSkipped uint64
+	rabinKarpRejected, descriptionSkipped                                        uint64
+	simHashSkipped, cliqueInferred, bandQueries, probedBands                     uint64
+	candidates, candidatePairs, verified                                         uint64
+	suppressionsApplied, suppressionsExpired, suppressionsVoided                 uint64
+}
+
+// minus returns the counts between snapshot before and c
+func (c scanCounters) minus(before scanCounters) scanCounters {
+	return scanCounters{
+		pairsCompared:      c.pairsCompared - before.pairsCompared,
+		lengthPruned:       c.lengthPruned - before.lengthPruned,
+		nameDistances:      c.nameDistances - before.nameDistances,
+		charsetPruned:      c.charsetPruned - before.charsetPruned,
+		constraintSkipped:  c.constraintSkipped - before.constraintSkipped,
+		rabinKarpRejected:  c.rabinKarpRejected - before.rabinKarpRejected,
+		descriptionSkipped: c.descriptionSkipped - before.descriptionSkipped,
+		simHashSkipped:     c.simHashSkipped - before.simHashSkipped,
+		cliqueInferred:     c.cliqueInferred - before.cliqueInferred,
+		bandQueries:        c.bandQueries - before.bandQueries,
+		probedBands:        c.probedBands - before.probedBands,
+		candidates:         c.candidates - before.candidates,
+		candidatePairs:     c.candidatePairs - before.candidatePairs,
+		verified:           c.verified - before.verified,
+
+		suppressionsApplied: c.suppressionsApplied - before.suppressionsApplied,
+		suppressionsExpired: c.suppressionsExpired - before.suppressionsExpired,
+		suppressionsVoided:  c.suppressionsVoided - before.suppressionsVoided,
+	}
+}
+
+// scanCounters returns the engine's scan counters
+func (e *LevenshteinEngine) scanCounters() scanCounters {
+	return scanCounters{
+		pairsCompared:      atomic.LoadUint64(&e.pairsCompared),
+		lengthPruned:       atomic.LoadUint64(&e.lengthPruned),
+		nameDistances:      atomic.LoadUint64(&e.nameComparisons),
+		charsetPruned:      atomic.LoadUint64(&e.charsetPruned),
+		constraintSkipped:  e.constraintSkips(),
+		rabinKarpRejected:  e.rabinKarpRejections(),
+		descriptionSkipped: atomic.LoadUint64(&e.descriptionSkipped),
+
+		suppressionsApplied: atomic.LoadUint64(&e.suppressionsApplied),
+		suppressionsExpired: atomic.LoadUint64(&e.suppressionsExpired),
+		suppressionsVoided:  atomic.LoadUint64(&e.suppressionsVoided),
+	}
+}
+
+// scanCounters returns the engine's scan counters, its Levenshtein engine's included
+func (e *HybridEngine) scanCounters() scanCounters {
+	c := e.levenshteinEngine.scanCounters()
+	c.simHashSkipped = atomic.LoadUint64(&e.simHashSkipped)
+	c.cliqueInferred = atomic.LoadUint64(&e.cliqueSkipped)
+	c.bandQueries = atomic.LoadUint64(&e.bandQueries)
+	c.probedBands = atomic.LoadUint64(&e.probedBands)
+	c.candidates = atomic.LoadUint64(&e.scanCandidates)
+	c.candidatePairs = atomic.LoadUint64(&e.scanPairs)
+	c.verified = atomic.LoadUint64(&e.verifiedPairs)
+	return c
+}
+
+// scanMeter measures one scan for its ScanSummary
+type scanMeter struct {
+	counters func() scanCounters
+	indexed  bool // A hybrid index scan: candidates come from LSH
+	before   scanCounters
+	pools    SlicePoolStats // Pool counters at the start
+	started  time.Time
+	summary  ScanSummary
+}
+
+// startSummary starts measuring a scan of n products
+func (e *LevenshteinEngine) startSummary(n int) *scanMeter {
+	return &scanMeter{
+		counters: e.scanCounters,
+		before:   e.scanCounters(),
+		pools:    GetSlicePoolStats(),
+		started:  time.Now(),
+		summary:  ScanSummary{Engine: "levenshtein", Products: n, Workers: e.summaryWorkers(n), Deterministic: e.deterministic},
+	}
+}
+
+// startSummary starts measuring a scan of n products, of the index when one
+// is built and of every pair otherwise
+func (e *HybridEngine) startSummary(n int) *scanMeter {
+	if e.currentIndex() == nil {
+		meter := e.levenshteinEngine.startSummary(n)
+		meter.counters = e.scanCounters
+		meter.before = e.scanCounters()
+		return meter
+	}
+	workers := 1 // Candidates are verified on the scan goroutine
+	if n < 1 {
+		workers = 0
+	}
+	return &scanMeter{
+		counters: e.scanCounters,
+		indexed:  true,
+		before:   e.scanCounters(),
+		pools:    GetSlicePoolStats(),
+		started:  time.Now(),
+		summary: ScanSummary{Engine: "hybrid", Products: n, Workers: workers,
+			Deterministic: e.levenshteinEngine.deterministic},
+	}
+}
+
+// summaryWorkers returns the goroutines a pair scan of n products runs on
+func (e *LevenshteinEngine) summaryWorkers(n int) int {
+	if n < 2 {
+		return 0
+	}
+	workers := e.scanWorkers(n, n > 50)
+	if workers > n {
+		workers = n
+	}
+	return workers
+}
+
+// finish returns the summary of the scan, which found duplicates results
+func (m *scanMeter) finish(duplicates int) ScanSummary {
+	s := m.summary
+	s.WallTime = time.Since(m.started)
+	s.Duplicates = duplicates
+	d := m.counters().minus(m.before)
+	if m.indexed {
+		s.CandidatePairs = d.candidatePairs
+		s.Comparisons = d.verified
+	} else {
+		s.CandidatePairs = d.pairsCompared + d.lengthPruned
+		s.Comparisons = d.pairsCompared - d.charsetPruned - d.constraintSkipped - d.suppressionsApplied
+	}
+	s.NameDistances = d.nameDistances
+	s.LengthPruned = d.lengthPruned
+	s.CharsetPruned = d.charsetPruned
+	s.ConstraintSkipped = d.constraintSkipped
+	s.SimHashSkipped = d.simHashSkipped
+	s.RabinKarpRejected = d.rabinKarpRejected
+	s.DescriptionsSkipped = d.descriptionSkipped
+	s.CliqueInferred = d.cliqueInferred
+	s.SuppressionsApplied = d.suppressionsApplied
+	s.SuppressionsExpired = d.suppressionsExpired
+	s.SuppressionsVoided = d.suppressionsVoided
+	s.CandidateLookups = d.bandQueries
+	s.BandsProbed = d.probedBands
+	s.CandidatesFound = d.candidates
+	s.SlicePools = GetSlicePoolStats().minus(m.pools)
+	return s
+}
+
+// WithScanSummary sets a hook receiving the ScanSummary of every
+// FindDuplicatesChan, FindDuplicatesToFileSorted and FindDuplicatesResumable
+// call once its scan has ended, on the scan's goroutine; nil removes it.
+// Returns the engine for chaining.
+// A FindDuplicatesResumable call reports the pairs it compared, even when its
+// sink failed; a failed or cancelled FindDuplicatesToFileSorted reports
+// nothing. Scans returning a slice have FindDuplicatesWithSummary instead.
+func (e *LevenshteinEngine) WithScanSummary(hook func(ScanSummary)) *LevenshteinEngine {
+	e.scanSummaryHook = hook
+	return e
+}
+
+// WithScanSummary sets a hook receiving the ScanSummary of every
+// FindDuplicatesChan call once its scan has ended (see
+// LevenshteinEngine.WithScanSummary). Returns the engine for chaining.
+func (e *HybridEngine) WithScanSummary(hook func(ScanSummary)) *HybridEngine {
+	e.levenshteinEngine.WithScanSummary(hook)
+	return e
+}
+
+// reportSummary passes the summary of a finished scan to the hook, if any
+func (e *LevenshteinEngine) reportSummary(meter *scanMeter, duplicates int) {
+	if e.scanSummaryHook != nil {
+		e.scanSummaryHook(meter.finish(duplicates))
+	}
+}
+
+// FindDuplicatesWithSummary is FindDuplicatesChecked also returning the
+// ScanSummary of the scan
+func (e *LevenshteinEngine) FindDuplicatesWithSummary(products []Product, threshold float64) (_ []ComparisonResult, _ ScanSummary, err error) {
+	defer e.guard("FindDuplicatesWithSummary", &err)
+	resolved, collapsed, err := e.resolveProducts(products)
+	if err != nil {
+		return nil, ScanSummary{}, err
+	}
+	if err := e.checkScanLimits(resolved, threshold); err != nil {
+		return nil, ScanSummary{}, err
+	}
+	meter := e.startSummary(len(resolved))
+	meter.summary.InputDuplicatesCollapsed = collapsed
+	duplicates := e.findDuplicatesUnchecked(context.Background(), productPtrs(resolved), threshold)
+	return duplicates, meter.finish(len(duplicates)), nil
+}
+
+// FindDuplicatesWithSummary is FindDuplicatesChecked also returning the
+// ScanSummary of the scan, with the candidate generation stats of the index
+func (e *HybridEngine) FindDuplicatesWithSummary(products []Product, threshold float64) (_ []ComparisonResult, _ ScanSummary, err error) {
+	defer e.levenshteinEngine.guard("FindDuplicatesWithSummary", &err)
+	resolved, collapsed, err := e.resolveProducts(products)
+	if err != nil {
+		return nil, ScanSummary{}, err
+	}
+	meter := e.startSummary(len(resolved))
+	meter.summary.InputDuplicatesCollapsed = collapsed
+	duplicates := e.findDuplicatesUnchecked(productPtrs(resolved), threshold)
+	return duplicates, meter.finish(len(duplicates)), nil
+}
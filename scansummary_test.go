@@ -0,0 +1,194 @@
+package duplicatecheck
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// summaryCatalog is a small catalog whose pairs each stop at a known scan
+// stage: two duplicates, pairs pruned by name length or charset, pairs the X
+// product's constraint excludes, and near misses settled by Rabin-Karp or
+// without their descriptions
+func summaryCatalog() []Product {
+	return []Product{
+		{ID: "P1", Name: "Apple iPhone 14 Pro 128GB", Description: "Smartphone with A16 chip and triple camera"},
+		{ID: "P2", Name: "Apple iPhone 14 Pro 128 GB", Description: "Smartphone with A16 chip and triple camera"},
+		{ID: "P3", Name: "Samsung Galaxy S23 Ultra", Description: "Android phone with S Pen"},
+		{ID: "P4", Name: "Samsung Galaxy S23 Ultra 5G", Description: "Android phone with S Pen, 5G"},
+		{ID: "P5", Name: "USB-C Cable", Description: "Braided charging cable, 2 m"},
+		{ID: "P6", Name: "Wool Socks", Description: "Warm merino socks, pack of three"},
+		{ID: "P7", Name: "Ceramic Coffee Mug Large", Description: "White stoneware mug, 450 ml"},
+		{ID: "X1", Name: "Apple iPhone 14 Pro 128GB", Description: "Smartphone with A16 chip and triple camera"},
+	}
+}
+
+// withoutX excludes every pair with an X product
+func withoutX(a, b Product) bool {
+	return !strings.HasPrefix(a.ID, "X") && !strings.HasPrefix(b.ID, "X")
+}
+
+// zeroTime clears the one summary field that varies between runs
+func zeroTime(s ScanSummary) ScanSummary {
+	s.WallTime = 0
+	return s
+}
+
+func TestScanSummarySequential(t *testing.T) {
+	tests := []struct {
+		name   string
+		engine func() *LevenshteinEngine
+		want   ScanSummary
+	}{
+		{
+			name:   "defaults",
+			engine: NewLevenshteinEngine,
+			want: ScanSummary{Engine: "levenshtein", Products: 8, CandidatePairs: 28, Comparisons: 6, NameDistances: 3,
+				LengthPruned: 12, CharsetPruned: 10, RabinKarpRejected: 2, Duplicates: 4, Workers: 1},
+		},
+		{
+			name:   "constraint",
+			engine: func() *LevenshteinEngine { return NewLevenshteinEngine().WithPairConstraint(withoutX) },
+			want: ScanSummary{Engine: "levenshtein", Products: 8, CandidatePairs: 28, Comparisons: 4, NameDistances: 2,
+				LengthPruned: 12, CharsetPruned: 7, ConstraintSkipped: 5, RabinKarpRejected: 2, Duplicates: 2, Workers: 1},
+		},
+		{
+			name: "description skip",
+			engine: func() *LevenshteinEngine {
+				engine := NewLevenshteinEngine().WithPairConstraint(withoutX)
+				engine.DisableRabinKarpFilter()
+				return engine
+			},
+			want: ScanSummary{Engine: "levenshtein", Products: 8, CandidatePairs: 28, Comparisons: 4, NameDistances: 4,
+				LengthPruned: 12, CharsetPruned: 7, ConstraintSkipped: 5, DescriptionsSkipped: 2, Duplicates: 2, Workers: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := tt.engine()
+			results, summary, err := engine.FindDuplicatesWithSummary(summaryCatalog(), 0.8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := zeroTime(summary); got != tt.want {
+				t.Errorf("summary = %+v\nwant %+v", got, tt.want)
+			}
+			if summary.Duplicates != len(results) || summary.WallTime <= 0 {
+				t.Errorf("%d duplicates in %v, %d results", summary.Duplicates, summary.WallTime, len(results))
+			}
+
+			// A second scan reports only its own work
+			_, again, _ := engine.FindDuplicatesWithSummary(summaryCatalog(), 0.8)
+			if zeroTime(again) != tt.want {
+				t.Errorf("second scan summary = %+v", zeroTime(again))
+			}
+		})
+	}
+}
+
+func TestScanSummaryParallel(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 200)
+	sequential := NewLevenshteinEngine()
+	sequential.SetMaxWorkers(1)
+	_, want, err := sequential.FindDuplicatesWithSummary(catalog, 0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewLevenshteinEngine()
+	engine.SetMaxWorkers(4)
+	results, got, err := engine.FindDuplicatesWithSummary(catalog, 0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := uint64(len(catalog))
+	if got.CandidatePairs != n*(n-1)/2 || got.CandidatePairs != got.Comparisons+got.LengthPruned+got.CharsetPruned+got.ConstraintSkipped {
+		t.Errorf("pairs don't add up: %+v", got)
+	}
+	if got.Workers != 4 || got.Duplicates != len(results) || got.RabinKarpRejected > got.Comparisons {
+		t.Errorf("summary = %+v", got)
+	}
+	got.Workers, want.Workers = 0, 0
+	if zeroTime(got) != zeroTime(want) {
+		t.Errorf("parallel summary = %+v\nsequential %+v", zeroTime(got), zeroTime(want))
+	}
+}
+
+func TestScanSummaryHybrid(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 300)
+	engine := NewHybridEngineWithConfig(HybridConfig{NumHashFunctions: 120, NumBands: 30, ShingleSize: 2, SimHashScreen: true, CliqueBands: 10})
+
+	// Without an index, the hybrid engine compares every pair
+	_, unindexed, err := engine.FindDuplicatesWithSummary(catalog[:40], 0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unindexed.Engine != "levenshtein" || unindexed.CandidatePairs != 40*39/2 || unindexed.CandidateLookups != 0 {
+		t.Errorf("unindexed summary = %+v", unindexed)
+	}
+
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	results, s, err := engine.FindDuplicatesWithSummary(catalog, 0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Engine != "hybrid" || s.Products != len(catalog) || s.Duplicates != len(results) || s.Workers != 1 {
+		t.Errorf("summary = %+v", s)
+	}
+	if s.CandidatePairs != s.Comparisons+s.SimHashSkipped+s.CharsetPruned+s.ConstraintSkipped {
+		t.Errorf("candidate pairs don't add up: %+v", s)
+	}
+	if s.CandidateLookups != uint64(len(catalog)) || s.BandsProbed != 30*s.CandidateLookups || s.CandidatesFound < s.CandidatePairs {
+		t.Errorf("candidate generation = %+v", s)
+	}
+	if s.SimHashSkipped == 0 || s.CliqueInferred == 0 {
+		t.Errorf("SimHash screen or cliques unused: %+v", s)
+	}
+}
+
+func TestScanSummaryHook(t *testing.T) {
+	var summaries []ScanSummary
+	engine := NewLevenshteinEngine().WithPairConstraint(withoutX).WithScanSummary(func(s ScanSummary) {
+		summaries = append(summaries, s)
+	})
+	want := ScanSummary{Engine: "levenshtein", Products: 8, CandidatePairs: 28, Comparisons: 4, NameDistances: 2,
+		LengthPruned: 12, CharsetPruned: 7, ConstraintSkipped: 5, RabinKarpRejected: 2, Duplicates: 2, Workers: 1}
+
+	results, errs := engine.FindDuplicatesChan(context.Background(), summaryCatalog(), 0.8, 0)
+	for range results {
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 1 || zeroTime(summaries[0]) != want {
+		t.Fatalf("FindDuplicatesChan summaries = %+v", summaries)
+	}
+
+	path := filepath.Join(t.TempDir(), "sorted.jsonl")
+	if err := engine.FindDuplicatesToFileSorted(summaryCatalog(), 0.8, path, SpillOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// The sorted file scan compares every pair the constraint allows
+	if len(summaries) != 2 || summaries[1].Duplicates != 2 || summaries[1].Comparisons != 21 || summaries[1].ConstraintSkipped != 7 {
+		t.Errorf("FindDuplicatesToFileSorted summary = %+v", summaries[len(summaries)-1])
+	}
+
+	if _, err := engine.FindDuplicatesResumable(summaryCatalog(), 0.8, ScanCursor{}, &limitedSink{writeLimit: -1, checkpointStop: -1}); err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 3 || summaries[2].Duplicates != 2 || summaries[2].CandidatePairs != 28 {
+		t.Errorf("FindDuplicatesResumable summary = %+v", summaries[len(summaries)-1])
+	}
+
+	engine.WithScanSummary(nil)
+	results, errs = engine.FindDuplicatesChan(context.Background(), summaryCatalog(), 0.8, 0)
+	for range results {
+	}
+	<-errs
+	if len(summaries) != 3 {
+		t.Error("removed hook still called")
+	}
+}
@@ -0,0 +1,71 @@
+package duplicatecheck
+
+// comparisonScratch holds the buffers distance computations work in, owned
+// by one scan worker for its whole run
+// Compare takes its buffers from sync.Pools, a Get and a Put per buffer and
+// distance; over a long scan that churn, and the pools being emptied by GC,
+// cost more than the work it saves. A scan worker reuses its scratch instead,
+// growing the buffers as needed and never returning them mid-scan. A nil
+// *comparisonScratch means the pools. Not safe for concurrent use.
+type comparisonScratch struct {
+	runesS, runesT []rune       // Decoded strings
+	prev, curr     []int        // DP rows
+	pattern        myersPattern // Myers match vectors of the shorter string
+	pv, mv         []uint64     // Myers vertical deltas of the blocked algorithm
+}
+
+// decode decodes s and t into the scratch rune buffers
+func (c *comparisonScratch) decode(s, t string) ([]rune, []rune) {
+	c.runesS = appendRunes(c.runesS[:0], s)
+	c.runesT = appendRunes(c.runesT[:0], t)
+	return c.runesS, c.runesT
+}
+
+// appendRunes appends the runes of s to buf
+func appendRunes(buf []rune, s string) []rune {
+	for _, r := range s {
+		buf = append(buf, r)
+	}
+	return buf
+}
+
+// rows returns the two DP rows, of length size
+func (c *comparisonScratch) rows(size int) ([]int, []int) {
+	if cap(c.prev) < size {
+		c.prev, c.curr = make([]int, size), make([]int, size)
+	}
+	c.prev, c.curr = c.prev[:size], c.curr[:size]
+	return c.prev, c.curr
+}
+
+// myersPattern builds the match vectors of pattern in the scratch pattern
+// The result is valid until the next call.
+func (c *comparisonScratch) myersPattern(pattern []rune) *myersPattern {
+	p := &c.pattern
+	p.ascii = [128]int32{}
+	for r := range p.other {
+		delete(p.other, r)
+	}
+	p.eq = zeroedUint64s(p.eq, p.index(pattern))
+	p.fill(pattern)
+	return p
+}
+
+// vectors returns the blocked algorithm's vertical delta vectors, zeroed, of
+// length blocks
+func (c *comparisonScratch) vectors(blocks int) ([]uint64, []uint64) {
+	c.pv, c.mv = zeroedUint64s(c.pv, blocks), zeroedUint64s(c.mv, blocks)
+	return c.pv, c.mv
+}
+
+// zeroedUint64s returns buf resized to size and zeroed, reallocated if too small
+func zeroedUint64s(buf []uint64, size int) []uint64 {
+	if cap(buf) < size {
+		return make([]uint64, size)
+	}
+	buf = buf[:size]
+	for i := range buf {
+		buf[i] = 0
+	}
+	return buf
+}
@@ -0,0 +1,127 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestComparisonScratch(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	alphabet := []rune("abcdefgh éßü日本")
+	randomText := func(n int) string {
+		runes := make([]rune, n)
+		for i := range runes {
+			runes[i] = alphabet[rng.Intn(len(alphabet))]
+		}
+		return string(runes)
+	}
+
+	// One scratch for every pair: buffers grow, shrink and are reused, and
+	// every path (DP, single-word and blocked Myers) must match the pools
+	engine := NewLevenshteinEngine()
+	scratch := &comparisonScratch{}
+	for _, n := range []int{0, 5, 31, 40, 64, 65, 200, 1000, 12, 130} {
+		for k := 0; k < 5; k++ {
+			a, b := randomText(n), randomText(n+rng.Intn(11))
+			for _, maxDistance := range []int{-1, n / 4} {
+				want, _ := engine.distanceWithin(a, b, maxDistance, nil)
+				if got, ok := engine.scratchDistanceWithin(a, b, maxDistance, nil, scratch); !ok || got != want {
+					t.Fatalf("n=%d max=%d: scratch distance %d, pooled %d", n, maxDistance, got, want)
+				}
+			}
+		}
+	}
+
+	// Warm, the scratch allocates nothing
+	long, other, short := randomText(1000), randomText(990), randomText(20)
+	if allocs := testing.AllocsPerRun(20, func() {
+		engine.scratchDistance(long, other, scratch)
+		engine.scratchDistance(short, other[:25], scratch)
+	}); allocs != 0 {
+		t.Errorf("scratch distance allocates %v times per run, want 0", allocs)
+	}
+}
+
+// TestScanScratchResults checks that scans computing distances in worker
+// scratch buffers find exactly what Compare scores
+func TestScanScratchResults(t *testing.T) {
+	const threshold = 0.8
+	products := longDescriptionCatalog(80, 400)
+	engine := NewLevenshteinEngine()
+	want := make(map[string]float64)
+	for i := range products {
+		for j := i + 1; j < len(products); j++ {
+			if r := engine.Compare(products[i], products[j]); r.CombinedSimilarity >= threshold {
+				want[makePairKey(products[i].ID, products[j].ID)] = r.CombinedSimilarity
+			}
+		}
+	}
+	for _, parallel := range []bool{false, true} {
+		var results []ComparisonResult
+		if parallel {
+			results = engine.FindDuplicatesParallel(products, threshold)
+		} else {
+			results = engine.FindDuplicates(products, threshold)
+		}
+		if len(results) != len(want) {
+			t.Errorf("parallel=%v: %d results, want %d", parallel, len(results), len(want))
+		}
+		for _, r := range results {
+			if similarity, ok := want[makePairKey(r.ProductA.ID, r.ProductB.ID)]; !ok || similarity != r.CombinedSimilarity {
+				t.Errorf("parallel=%v: %s/%s scored %v, Compare %v (found %v)", parallel, r.ProductA.ID, r.ProductB.ID, r.CombinedSimilarity, similarity, ok)
+			}
+		}
+	}
+}
+
+// longDescriptionCatalog returns the test catalog with descriptions repeated
+// to length bytes
+func longDescriptionCatalog(n, length int) []Product {
+	products := GenerateTestCatalog(goldenCatalogSeed, n)
+	for i := range products {
+		description := products[i].Description + " "
+		products[i].Description = strings.Repeat(description, length/len(description)+1)[:length]
+	}
+	return products
+}
+
+// BenchmarkScanScratch scans 2000 products with 1000-char descriptions,
+// computing distances in pooled buffers fetched per call, as Compare does
+// ("pool"), or in the one scratch each worker owns for the scan ("scratch")
+// Memo, length window and Rabin-Karp filter are off so every pair's names are
+// compared by distance and nothing else allocates per pair.
+func BenchmarkScanScratch(b *testing.B) {
+	const threshold = 0.85
+	products := longDescriptionCatalog(2000, 1000)
+	ptrs := productPtrs(products)
+	engine := NewLevenshteinEngine()
+	engine.DisableRabinKarpFilter()
+	warmCaches(ptrs, engine.preparer())
+
+	for _, parallel := range []bool{false, true} {
+		for _, pooled := range []bool{true, false} {
+			name := fmt.Sprintf("parallel=%v/scratch", parallel)
+			if pooled {
+				name = fmt.Sprintf("parallel=%v/pool", parallel)
+			}
+			b.Run(name, func(b *testing.B) {
+				b.ReportAllocs()
+				for n := 0; n < b.N; n++ {
+					scanPairsWithin(len(ptrs), engine.scanWorkers(len(ptrs), parallel), nil, func(i, j int, scratch *comparisonScratch) (ComparisonResult, bool) {
+						if pooled {
+							scratch = nil
+						}
+						result, ok := engine.comparePair(ptrs, i, j, nil, scratch, threshold)
+						if !ok {
+							return ComparisonResult{}, false
+						}
+						result.stampThreshold(threshold)
+						return result, result.MeetsThreshold
+					})
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package duplicatecheck
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Segment is one section of a description, compared on its own
+type Segment struct {
+	Label  string  // Section kind, used by AlignByLabel (e.g. "marketing", "specs")
+	Text   string  // Section text
+	Weight float64 // Relative weight in the description score (<= 0 counts as 1)
+}
+
+// DescriptionSegmenter splits a normalized (lowercase, trimmed) description into segments
+// Engines call it from parallel workers, so it must be safe for concurrent use.
+type DescriptionSegmenter func(desc string) []Segment
+
+// SegmentAlignment selects how the segments of two descriptions are paired
+type SegmentAlignment int
+
+const (
+	// AlignByOrder pairs the i-th segment of each description (default)
+	AlignByOrder SegmentAlignment = iota
+	// AlignByLabel pairs the k-th segment of each label
+	AlignByLabel
+	// AlignBestMatch pairs segments greedily by highest similarity, so
+	// reordered sections still line up
+	AlignBestMatch
+)
+
+// Labels assigned by DefaultSegmenter
+const (
+	SegmentParagraph = "paragraph" // A block of text between blank lines
+	SegmentBullet    = "bullet"    // One bullet item ("- ", "* ", "• ")
+)
+
+// SegmentScore is the comparison of one pair of aligned segments
+// An unmatched segment has index -1 on the other side and similarity 0.
+type SegmentScore struct {
+	Label      string  // Label of the segment in A (in B when A has none)
+	IndexA     int     // Segment index in A's description, or -1
+	IndexB     int     // Segment index in B's description, or -1
+	Weight     float64 // Weight of the pair (mean of both segment weights)
+	Distance   int     // Levenshtein distance between the segments
+	Similarity float64 // Similarity between the segments [0.0-1.0]
+}
+
+// DefaultSegmenter splits a description on blank lines and bullet markers
+// Every paragraph and every bullet item is a segment of weight 1.
+func DefaultSegmenter(desc string) []Segment {
+	var segments []Segment
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			segments = append(segments, Segment{Label: SegmentParagraph, Text: strings.Join(paragraph, " "), Weight: 1})
+			paragraph = nil
+		}
+	}
+
+	for _, line := range strings.Split(desc, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			flush()
+		case hasBulletMarker(line):
+			flush()
+			segments = append(segments, Segment{Label: SegmentBullet, Text: strings.TrimSpace(line[bulletMarkerLen(line):]), Weight: 1})
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return segments
+}
+
+// bulletMarkers are the line prefixes DefaultSegmenter treats as bullet items
+var bulletMarkers = []string{"- ", "* ", "• "}
+
+// hasBulletMarker reports whether line starts with a bullet marker
+func hasBulletMarker(line string) bool {
+	return bulletMarkerLen(line) > 0
+}
+
+// bulletMarkerLen returns the byte length of line's bullet marker, or 0
+func bulletMarkerLen(line string) int {
+	for _, marker := range bulletMarkers {
+		if strings.HasPrefix(line, marker) {
+			return len(marker)
+		}
+	}
+	return 0
+}
+
+// WithDescriptionSegmenter compares descriptions section by section: the
+// description similarity becomes the weighted mean of per-segment similarities,
+// with segments paired by align. Per-segment scores are reported in
+// ComparisonResult.SegmentSimilarities. Pass nil to restore the flat comparison
+// (the default). Returns the engine for chaining.
+func (e *LevenshteinEngine) WithDescriptionSegmenter(segmenter DescriptionSegmenter, align SegmentAlignment) *LevenshteinEngine {
+	e.segmenter = segmenter
+	e.segmentAlign = align
+	return e
+}
+
+// WithDescriptionSegmenter sets the description segmenter used during
+// verification and Compare (see LevenshteinEngine.WithDescriptionSegmenter)
+func (e *HybridEngine) WithDescriptionSegmenter(segmenter DescriptionSegmenter, align SegmentAlignment) *HybridEngine {
+	e.levenshteinEngine.WithDescriptionSegmenter(segmenter, align)
+	return e
+}
+
+// compareSegmented scores two normalized descriptions segment by segment
+// Falls back to the flat comparison when neither description has a segment.
+func (e *LevenshteinEngine) compareSegmented(descA, descB string, budget *cellBudget) (int, float64, []SegmentScore) {
+	segmentsA, segmentsB := e.segmenter(descA), e.segmenter(descB)
+	if len(segmentsA) == 0 && len(segmentsB) == 0 {
+		distance, _ := e.distanceWithin(descA, descB, -1, budget)
+		return distance, e.computeSimilarity(descA, descB, distance), nil
+	}
+
+	var scores []SegmentScore
+	switch e.segmentAlign {
+	case AlignByLabel:
+		scores = e.alignByLabel(segmentsA, segmentsB, budget)
+	case AlignBestMatch:
+		scores = e.alignBestMatch(segmentsA, segmentsB, budget)
+	default:
+		scores = e.alignByOrder(segmentsA, segmentsB, budget)
+	}
+
+	var distance int
+	var combined WeightedScore
+	for _, score := range scores {
+		distance += score.Distance
+		combined.Add(score.Label, score.Similarity, score.Weight, true)
+	}
+	similarity, _, _ := combined.combine()
+	return distance, similarity, scores
+}
+
+// segmentWeight returns a segment's weight, treating invalid weights as 1
+func segmentWeight(segment Segment) float64 {
+	if segment.Weight <= 0 || math.IsNaN(segment.Weight) || math.IsInf(segment.Weight, 0) {
+		return 1
+	}
+	return segment.Weight
+}
+
+// scoreSegments compares two aligned segments
+// All segments of a description share budget.
+func (e *LevenshteinEngine) scoreSegments(segmentsA, segmentsB []Segment, i, j int, budget *cellBudget) SegmentScore {
+	a, b := segmentsA[i], segmentsB[j]
+	distance, _ := e.distanceWithin(a.Text, b.Text, -1, budget)
+	return SegmentScore{
+		Label:      a.Label,
+		IndexA:     i,
+		IndexB:     j,
+		Weight:     (segmentWeight(a) + segmentWeight(b)) / 2,
+		Distance:   distance,
+		Similarity: e.computeSimilarity(a.Text, b.Text, distance),
+	}
+}
+
+// unmatchedSegment scores a segment with no counterpart: its full length is edits
+func (e *LevenshteinEngine) unmatchedSegment(segment Segment, indexA, indexB int) SegmentScore {
+	return SegmentScore{
+		Label:    segment.Label,
+		IndexA:   indexA,
+		IndexB:   indexB,
+		Weight:   segmentWeight(segment),
+		Distance: e.textLength(segment.Text),
+	}
+}
+
+// alignByOrder pairs segments by position
+func (e *LevenshteinEngine) alignByOrder(segmentsA, segmentsB []Segment, budget *cellBudget) []SegmentScore {
+	var scores []SegmentScore
+	for i := 0; i < len(segmentsA) || i < len(segmentsB); i++ {
+		switch {
+		case i >= len(segmentsB):
+			scores = append(scores, e.unmatchedSegment(segmentsA[i], i, -1))
+		case i >= len(segmentsA):
+			scores = append(scores, e.unmatchedSegment(segmentsB[i], -1, i))
+		default:
+			scores = append(scores, e.scoreSegments(segmentsA, segmentsB, i, i, budget))
+		}
+	}
+	return scores
+}
+
+// alignByLabel pairs the k-th segment of each label in A with the k-th in B
+func (e *LevenshteinEngine) alignByLabel(segmentsA, segmentsB []Segment, budget *cellBudget) []SegmentScore {
+	byLabel := make(map[string][]int)
+	for j, segment := range segmentsB {
+		byLabel[segment.Label] = append(byLabel[segment.Label], j)
+	}
+
+	matched := make([]bool, len(segmentsB))
+	var scores []SegmentScore
+	for i, segment := range segmentsA {
+		if candidates := byLabel[segment.Label]; len(candidates) > 0 {
+			j := candidates[0]
+			byLabel[segment.Label] = candidates[1:]
+			matched[j] = true
+			scores = append(scores, e.scoreSegments(segmentsA, segmentsB, i, j, budget))
+			continue
+		}
+		scores = append(scores, e.unmatchedSegment(segment, i, -1))
+	}
+	return e.appendUnmatchedB(scores, segmentsB, matched)
+}
+
+// alignBestMatch pairs segments greedily, most similar pair first
+// Ties go to the lower index in A, then in B.
+func (e *LevenshteinEngine) alignBestMatch(segmentsA, segmentsB []Segment, budget *cellBudget) []SegmentScore {
+	pairs := make([]SegmentScore, 0, len(segmentsA)*len(segmentsB))
+	for i := range segmentsA {
+		for j := range segmentsB {
+			pairs = append(pairs, e.scoreSegments(segmentsA, segmentsB, i, j, budget))
+		}
+	}
+	sort.SliceStable(pairs, func(x, y int) bool {
+		return pairs[x].Similarity > pairs[y].Similarity
+	})
+
+	matchedA := make([]bool, len(segmentsA))
+	matchedB := make([]bool, len(segmentsB))
+	byA := make([]*SegmentScore, len(segmentsA))
+	for k := range pairs {
+		pair := &pairs[k]
+		if matchedA[pair.IndexA] || matchedB[pair.IndexB] {
+			continue
+		}
+		matchedA[pair.IndexA], matchedB[pair.IndexB] = true, true
+		byA[pair.IndexA] = pair
+	}
+
+	scores := make([]SegmentScore, 0, len(segmentsA))
+	for i, pair := range byA {
+		if pair != nil {
+			scores = append(scores, *pair)
+			continue
+		}
+		scores = append(scores, e.unmatchedSegment(segmentsA[i], i, -1))
+	}
+	return e.appendUnmatchedB(scores, segmentsB, matchedB)
+}
+
+// appendUnmatchedB appends a score for every segment of B left unpaired
+func (e *LevenshteinEngine) appendUnmatchedB(scores []SegmentScore, segmentsB []Segment, matched []bool) []SegmentScore {
+	for j, segment := range segmentsB {
+		if !matched[j] {
+			scores = append(scores, e.unmatchedSegment(segment, -1, j))
+		}
+	}
+	return scores
+}
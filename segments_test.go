@@ -0,0 +1,142 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// marketingCopy is boilerplate shared by every product of a line
+const marketingCopy = "experience the next generation of sound with our flagship wireless headphones, " +
+	"designed for all-day comfort and engineered for audiophiles who demand clarity, depth, " +
+	"and a premium listening experience wherever life takes them."
+
+// specSegmenter labels the first paragraph as marketing and bullets as specs (2x weight)
+func specSegmenter(desc string) []Segment {
+	segments := DefaultSegmenter(desc)
+	for i := range segments {
+		if segments[i].Label == SegmentBullet {
+			segments[i].Label, segments[i].Weight = "spec", 2
+		} else {
+			segments[i].Label = "marketing"
+		}
+	}
+	return segments
+}
+
+func TestDefaultSegmenter(t *testing.T) {
+	tests := []struct {
+		name string
+		desc string
+		want []Segment
+	}{
+		{"empty", "", nil},
+		{"single paragraph", "one line\ncontinued", []Segment{{SegmentParagraph, "one line continued", 1}}},
+		{
+			"paragraphs and bullets",
+			"intro text\n\n- 40h battery\n* usb-c\n• 250g\nclosing words",
+			[]Segment{
+				{SegmentParagraph, "intro text", 1},
+				{SegmentBullet, "40h battery", 1},
+				{SegmentBullet, "usb-c", 1},
+				{SegmentBullet, "250g", 1},
+				{SegmentParagraph, "closing words", 1},
+			},
+		},
+		{"hyphenated word is not a bullet", "-10db noise floor", []Segment{{SegmentParagraph, "-10db noise floor", 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultSegmenter(tt.desc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DefaultSegmenter(%q) = %+v, want %+v", tt.desc, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegmentedDescriptions(t *testing.T) {
+	a := Product{ID: "A", Name: "Aurora Wireless Headphones", Description: marketingCopy +
+		"\n\n- battery: 40 hours\n- driver: 40mm dynamic\n- weight: 250g"}
+	b := Product{ID: "B", Name: "Aurora Wireless Headphones", Description: marketingCopy +
+		"\n\n- battery: 22 hours\n- driver: 30mm planar\n- weight: 310g"}
+
+	t.Run("spec differences weigh more than shared boilerplate", func(t *testing.T) {
+		flat := NewLevenshteinEngine().Compare(a, b)
+		segmented := NewLevenshteinEngine().WithDescriptionSegmenter(specSegmenter, AlignByLabel).Compare(a, b)
+
+		if segmented.DescriptionSimilarity >= flat.DescriptionSimilarity {
+			t.Errorf("segmented description similarity %.3f, want below flat %.3f",
+				segmented.DescriptionSimilarity, flat.DescriptionSimilarity)
+		}
+		if flat.SegmentSimilarities != nil {
+			t.Errorf("flat comparison reported segments: %+v", flat.SegmentSimilarities)
+		}
+		if len(segmented.SegmentSimilarities) != 4 {
+			t.Fatalf("got %d segment scores, want 4", len(segmented.SegmentSimilarities))
+		}
+		if marketing := segmented.SegmentSimilarities[0]; marketing.Label != "marketing" || marketing.Similarity != 1 {
+			t.Errorf("marketing segment = %+v, want identical", marketing)
+		}
+		for _, score := range segmented.SegmentSimilarities[1:] {
+			if score.Label != "spec" || score.Weight != 2 || score.Similarity >= 1 {
+				t.Errorf("spec segment = %+v, want differing spec of weight 2", score)
+			}
+		}
+	})
+
+	t.Run("alignment", func(t *testing.T) {
+		sections := []string{
+			"active noise cancelling with three modes",
+			"bluetooth 5.3 with multipoint pairing",
+			"foldable design with hard travel case",
+		}
+		reordered := Product{ID: "C", Name: a.Name, Description: strings.Join([]string{sections[2], sections[0], sections[1]}, "\n\n")}
+		original := Product{ID: "D", Name: a.Name, Description: strings.Join(sections, "\n\n")}
+
+		tests := []struct {
+			align     SegmentAlignment
+			wantExact bool
+		}{
+			{AlignByOrder, false},
+			{AlignByLabel, false},
+			{AlignBestMatch, true},
+		}
+		for _, tt := range tests {
+			result := NewLevenshteinEngine().WithDescriptionSegmenter(DefaultSegmenter, tt.align).Compare(original, reordered)
+			if gotExact := result.DescriptionSimilarity == 1; gotExact != tt.wantExact {
+				t.Errorf("align %d: description similarity %.3f, want exact=%v", tt.align, result.DescriptionSimilarity, tt.wantExact)
+			}
+			if len(result.SegmentSimilarities) != len(sections) {
+				t.Errorf("align %d: got %d segment scores, want %d", tt.align, len(result.SegmentSimilarities), len(sections))
+			}
+		}
+
+		best := NewLevenshteinEngine().WithDescriptionSegmenter(DefaultSegmenter, AlignBestMatch).Compare(original, reordered)
+		for i, score := range best.SegmentSimilarities {
+			if want := (i + 1) % len(sections); score.IndexA != i || score.IndexB != want {
+				t.Errorf("segment %d paired with %d, want %d", score.IndexA, score.IndexB, want)
+			}
+		}
+	})
+
+	t.Run("unmatched segments score zero", func(t *testing.T) {
+		short := Product{ID: "E", Name: a.Name, Description: "first section"}
+		long := Product{ID: "F", Name: a.Name, Description: "first section\n\nsecond section"}
+
+		result := NewLevenshteinEngine().WithDescriptionSegmenter(DefaultSegmenter, AlignBestMatch).Compare(short, long)
+		if result.DescriptionSimilarity != 0.5 {
+			t.Errorf("description similarity = %.3f, want 0.5", result.DescriptionSimilarity)
+		}
+		unmatched := result.SegmentSimilarities[1]
+		if unmatched.IndexA != -1 || unmatched.IndexB != 1 || unmatched.Similarity != 0 || unmatched.Distance != len("second section") {
+			t.Errorf("unmatched segment = %+v", unmatched)
+		}
+	})
+
+	t.Run("hybrid engine", func(t *testing.T) {
+		engine := NewHybridEngine().WithDescriptionSegmenter(specSegmenter, AlignByLabel)
+		if result := engine.Compare(a, b); len(result.SegmentSimilarities) != 4 {
+			t.Errorf("hybrid Compare reported %d segment scores, want 4", len(result.SegmentSimilarities))
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package duplicatecheck
+
+import (
+	"strings"
+	"unicode"
+)
+
+// shingleTokenizerVersion names the word splitting shingles are built from
+// Bump it whenever tokens change, so index configs built with the old
+// splitting stop matching (see IndexConfigFingerprint).
+const shingleTokenizerVersion = "words/v2"
+
+// shingles returns the shingles of prepared text under the engine's tokenization
+// BuildIndex, queries, and CollisionRate all shingle through here, from the
+// same prepared strings verification compares, and sample alike under
+// MaxShinglesPerProduct.
+func (e *HybridEngine) shingles(text string) []string {
+	shingles, _, _ := e.limitedShingles(text)
+	return shingles
+}
+
+// shingleTokenization describes the engine's shingle word splitting
+func (e *HybridEngine) shingleTokenization() string {
+	if e.splitCompounds {
+		return shingleTokenizerVersion + "+split-compounds"
+	}
+	return shingleTokenizerVersion
+}
+
+// shingleTokens splits text on whitespace and strips leading and trailing
+// punctuation from each word, so "wh-1000xm5," and "(wh-1000xm5)" are both
+// "wh-1000xm5"; words that are only punctuation are dropped
+// With splitCompounds, words are also split on internal hyphens and slashes.
+func shingleTokens(text string, splitCompounds bool) []string {
+	fields := strings.Fields(text)
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if !splitCompounds {
+			if word := strings.TrimFunc(field, unicode.IsPunct); word != "" {
+				tokens = append(tokens, word)
+			}
+			continue
+		}
+		for _, part := range strings.FieldsFunc(field, isCompoundSeparator) {
+			if word := strings.TrimFunc(part, unicode.IsPunct); word != "" {
+				tokens = append(tokens, word)
+			}
+		}
+	}
+	return tokens
+}
+
+// isCompoundSeparator reports whether r joins the parts of a compound word
+func isCompoundSeparator(r rune) bool {
+	return r == '/' || unicode.Is(unicode.Pd, r) // Pd: hyphens and dashes
+}
+
+// nameShingleSize is the character n-gram size of name-only indexes (see
+// IndexedFields)
+// Names are a few words long, so one edited word changes most of their word
+// shingles; character bigrams change about as much as the edit distance does.
+const nameShingleSize = 2
+
+// charShingles returns every run of n consecutive runes of text
+// Text of fewer than n runes makes a single shingle.
+func charShingles(text string, n int) []string {
+	runes := []rune(text)
+	if len(runes) < n {
+		return []string{text}
+	}
+	shingles := make([]string, 0, len(runes)-n+1)
+	for i := 0; i+n <= len(runes); i++ {
+		shingles = append(shingles, string(runes[i:i+n]))
+	}
+	return shingles
+}
@@ -0,0 +1,193 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShingleTokens(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		splitCompounds bool
+		want           []string
+	}{
+		{"trailing punctuation", "sony wh-1000xm5, black.", false, []string{"sony", "wh-1000xm5", "black"}},
+		{"brackets and quotes", `("wh-1000xm5") [grey]`, false, []string{"wh-1000xm5", "grey"}},
+		{"punctuation-only words dropped", "usb - c ... cable", false, []string{"usb", "c", "cable"}},
+		{"internal punctuation kept", "6.7 inch 1,000 mah", false, []string{"6.7", "inch", "1,000", "mah"}},
+		{"symbols kept", "$199 c++ 50%", false, []string{"$199", "c++", "50"}},
+		{"split compounds", "wh-1000xm5, usb-c/lightning", true, []string{"wh", "1000xm5", "usb", "c", "lightning"}},
+		{"split en dash", "2019–2023 models", true, []string{"2019", "2023", "models"}},
+		{"nothing left", "-- ...", false, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shingleTokens(tt.text, tt.splitCompounds); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shingleTokens(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	if got := generateShingles("wh-1000xm5,", 3); !reflect.DeepEqual(got, []string{"wh-1000xm5"}) {
+		t.Errorf("short text shingle = %q", got)
+	}
+}
+
+// legacyShingles is the whitespace-only tokenization shingles used before
+// punctuation was stripped, kept to measure the recall change
+func legacyShingles(text string, n int) []string {
+	tokens := strings.Fields(text)
+	if len(tokens) < n {
+		return []string{text}
+	}
+	shingles := make([]string, 0, len(tokens)-n+1)
+	for i := 0; i <= len(tokens)-n; i++ {
+		shingles = append(shingles, strings.Join(tokens[i:i+n], " "))
+	}
+	return shingles
+}
+
+// repunctuate moves sentence punctuation around in text: commas and periods
+// are dropped, added, or detached from their word
+func repunctuate(rng *rand.Rand, text string) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		bare := strings.TrimRight(word, ",.")
+		switch rng.Intn(4) {
+		case 0:
+			words[i] = bare
+		case 1:
+			words[i] = bare + ","
+		case 2:
+			words[i] = bare + "."
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// collides reports whether two shingle sets share an LSH bucket under engine
+func collides(engine *HybridEngine, a, b []string) bool {
+	sigA, sigB := computeMinHashSignature(a, engine.minHash), computeMinHashSignature(b, engine.minHash)
+	rows := engine.numHashFunctions / engine.numBands
+	for band := 0; band < engine.numBands; band++ {
+		if hashBand(sigA, band*rows, (band+1)*rows) == hashBand(sigB, band*rows, (band+1)*rows) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestShinglePunctuationRecall(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	engine := NewHybridEngine()
+	const pairs = 200
+
+	var legacyHits, hits, legacyFalse, falseHits int
+	for i := 0; i < pairs; i++ {
+		// A description and the same words with punctuation placed differently
+		text := randomWordText(rng, 25)
+		a, b := repunctuate(rng, text), repunctuate(rng, text)
+		if collides(engine, legacyShingles(a, 3), legacyShingles(b, 3)) {
+			legacyHits++
+		}
+		if collides(engine, engine.shingles(a), engine.shingles(b)) {
+			hits++
+		}
+
+		// Control: an unrelated description punctuated the same way
+		other := repunctuate(rng, randomWordText(rng, 25))
+		if collides(engine, legacyShingles(a, 3), legacyShingles(other, 3)) {
+			legacyFalse++
+		}
+		if collides(engine, engine.shingles(a), engine.shingles(other)) {
+			falseHits++
+		}
+	}
+	t.Logf("candidate recall: %d/%d before, %d/%d now; control collisions %d before, %d now",
+		legacyHits, pairs, hits, pairs, legacyFalse, falseHits)
+
+	if hits != pairs {
+		t.Errorf("%d of %d punctuation variants are not candidates", pairs-hits, pairs)
+	}
+	if hits < legacyHits+pairs/4 {
+		t.Errorf("recall %d is not measurably above the legacy %d", hits, legacyHits)
+	}
+	if falseHits > legacyFalse {
+		t.Errorf("control collisions grew from %d to %d", legacyFalse, falseHits)
+	}
+}
+
+func TestSplitCompoundTokens(t *testing.T) {
+	catalog := []Product{
+		{ID: "A", Name: "Sony WH-1000XM5", Description: "Wireless noise cancelling headphones with USB-C charging"},
+		{ID: "B", Name: "Sony WH 1000XM5", Description: "Wireless noise cancelling headphones with USB C charging"},
+	}
+	config := DefaultHybridConfig()
+	config.ShingleSize = 2
+	config.SplitCompoundTokens = true
+	split := NewHybridEngineWithConfig(config)
+	config.SplitCompoundTokens = false
+	whole := NewHybridEngineWithConfig(config)
+
+	textA, textB := split.indexText(&catalog[0]), split.indexText(&catalog[1])
+	if !reflect.DeepEqual(split.shingles(textA), split.shingles(textB)) {
+		t.Errorf("split shingles differ: %q vs %q", split.shingles(textA), split.shingles(textB))
+	}
+	if reflect.DeepEqual(whole.shingles(textA), whole.shingles(textB)) {
+		t.Error("compounds were split with SplitCompoundTokens off")
+	}
+}
+
+func TestIndexConfigFingerprint(t *testing.T) {
+	base := NewHybridEngine()
+	if base.IndexConfigFingerprint() != NewHybridEngine().IndexConfigFingerprint() {
+		t.Fatal("equal configs gave different fingerprints")
+	}
+
+	split := DefaultHybridConfig()
+	split.SplitCompoundTokens = true
+	shingle := DefaultHybridConfig()
+	shingle.ShingleSize = 2
+	limited := DefaultHybridConfig()
+	limited.MaxShinglesPerProduct = 256
+	others := map[string]*HybridEngine{
+		"indexing limits": NewHybridEngineWithConfig(limited),
+		"split compounds": NewHybridEngineWithConfig(split),
+		"shingle size":    NewHybridEngineWithConfig(shingle),
+		"seed":            NewHybridEngine().WithLSHSeed(7),
+		"preparation":     NewHybridEngine().WithTextPreparation(TextPreparation{StripHTML: true}),
+	}
+	for name, other := range others {
+		if other.IndexConfigFingerprint() == base.IndexConfigFingerprint() {
+			t.Errorf("%s did not change the fingerprint", name)
+		}
+	}
+
+	t.Run("snapshot check", func(t *testing.T) {
+		engine := NewHybridEngine()
+		if err := engine.BuildIndex(loadSampleCatalog(t)); err != nil {
+			t.Fatal(err)
+		}
+		config := engine.snapshotConfig(engine.currentIndex())
+		if config.ShingleTokenization != shingleTokenizerVersion || config.Fingerprint == "" {
+			t.Errorf("snapshot config = %+v", config)
+		}
+		if err := base.CheckSnapshotConfig(config); err != nil {
+			t.Errorf("same settings rejected: %v", err)
+		}
+		for name, other := range others {
+			if err := other.CheckSnapshotConfig(config); !errors.Is(err, ErrIncompatibleIndex) {
+				t.Errorf("%s: err = %v, want ErrIncompatibleIndex", name, err)
+			}
+		}
+		legacy := config
+		legacy.Fingerprint = "" // Exported before fingerprints were recorded
+		if err := base.CheckSnapshotConfig(legacy); !errors.Is(err, ErrIncompatibleIndex) {
+			t.Errorf("legacy snapshot: err = %v", err)
+		}
+	})
+}
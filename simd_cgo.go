@@ -1,100 +1,63 @@
-// +build simd
+//go:build simd && cgo
 
 package duplicatecheck
 
 /*
 #include <stdint.h>
-#include <string.h>
 #include <stdlib.h>
 
-#ifdef __SSE4_1__
+// Each kernel fills a DP row in two passes. Deletion (the cell above) and
+// substitution (the cell above-left) only read the previous row, so they are
+// computed four cells at a time; insertion (the cell to the left) depends on
+// the cell just written, so a scalar pass applies it afterwards.
+
+// finish_row fills the columns after the vector part and applies insertion
+static void finish_row(const int32_t* prev, int32_t* curr, char si, const char* t, int32_t j, int32_t tlen) {
+	for (; j <= tlen; j++) {
+		int32_t sub = prev[j - 1] + (si != t[j - 1]);
+		int32_t del = prev[j] + 1;
+		curr[j] = sub < del ? sub : del;
+	}
+	for (j = 1; j <= tlen; j++) {
+		if (curr[j - 1] + 1 < curr[j]) {
+			curr[j] = curr[j - 1] + 1;
+		}
+	}
+}
+
+#if defined(__x86_64__)
 #include <smmintrin.h>
 
-// SIMD-optimized Levenshtein distance using SSE4.1
-// Processes up to 4 cells per iteration for 30-40% speedup on long strings
+// levenshtein_sse41 computes the byte Levenshtein distance with SSE4.1
+// Compiled for SSE4.1 whatever the build flags; Go calls it only after
+// checking the CPU. Returns -1 if allocation fails.
+__attribute__((target("sse4.1")))
 int32_t levenshtein_sse41(const char* s, int32_t slen, const char* t, int32_t tlen) {
-	if (slen == 0) return tlen;
-	if (tlen == 0) return slen;
-
-	// Allocate DP rows (we only need 2 rows for space optimization)
 	int32_t* prev = (int32_t*)malloc((tlen + 1) * sizeof(int32_t));
 	int32_t* curr = (int32_t*)malloc((tlen + 1) * sizeof(int32_t));
-
 	if (!prev || !curr) {
 		free(prev);
 		free(curr);
 		return -1;
 	}
-
-	// Initialize first row: [0, 1, 2, 3, ..., tlen]
 	for (int32_t j = 0; j <= tlen; j++) {
 		prev[j] = j;
 	}
 
-	// Process each row
+	const __m128i one = _mm_set1_epi32(1);
 	for (int32_t i = 1; i <= slen; i++) {
-		curr[0] = i;
 		char si = s[i - 1];
-
-		// Process columns with SIMD where possible
+		curr[0] = i;
 		int32_t j = 1;
-
-		// SIMD vectorized part (process 4 columns at a time)
 		for (; j + 3 <= tlen; j += 4) {
-			// Load 4 values from previous row (diagonals)
-			__m128i diag = _mm_loadu_si128((__m128i*)(prev + j - 1));
-
-			// Load 4 values from current column of previous row
-			__m128i above = _mm_loadu_si128((__m128i*)(prev + j));
-
-			// Compute costs for 4 characters
-			int32_t costs[4];
-			for (int k = 0; k < 4; k++) {
-				costs[k] = (si == t[j + k - 1]) ? 0 : 1;
-			}
-			__m128i cost = _mm_loadu_si128((__m128i*)costs);
-
-			// diagonal + cost (substitution cost)
-			__m128i sub = _mm_add_epi32(diag, cost);
-
-			// above + 1 (deletion cost)
-			__m128i del = _mm_add_epi32(above, _mm_set1_epi32(1));
-
-			// left + 1 (insertion cost) - computed progressively
-			// Start with curr[j-1] + 1
-			int32_t left_val = curr[j - 1] + 1;
-			__m128i left = _mm_set1_epi32(left_val);
-
-			// Minimum of three operations
-			__m128i min1 = _mm_min_epi32(sub, del);
-			__m128i result = _mm_min_epi32(min1, left);
-
-			// Store result and update left for next iteration
-			_mm_storeu_si128((__m128i*)(curr + j), result);
-
-			// Update left_val for next SIMD iteration by reading last computed value
-			// This is needed because each cell depends on previous left value
-			int32_t* result_ptr = (int32_t*)&result;
-			for (int k = 0; k < 3; k++) {
-				curr[j + k + 1] = result_ptr[k] + 1; // Will be overwritten in next iteration
-			}
+			__m128i diag = _mm_loadu_si128((const __m128i*)(prev + j - 1));
+			__m128i above = _mm_loadu_si128((const __m128i*)(prev + j));
+			__m128i cost = _mm_set_epi32(si != t[j + 2], si != t[j + 1], si != t[j], si != t[j - 1]);
+			__m128i best = _mm_min_epi32(_mm_add_epi32(diag, cost), _mm_add_epi32(above, one));
+			_mm_storeu_si128((__m128i*)(curr + j), best);
 		}
+		finish_row(prev, curr, si, t, j, tlen);
 
-		// Scalar part for remaining columns (< 4 columns left)
-		for (; j <= tlen; j++) {
-			int32_t cost = (si == t[j - 1]) ? 0 : 1;
-			int32_t del = prev[j] + 1;
-			int32_t ins = curr[j - 1] + 1;
-			int32_t sub = prev[j - 1] + cost;
-
-			int32_t min_val = del;
-			if (ins < min_val) min_val = ins;
-			if (sub < min_val) min_val = sub;
-
-			curr[j] = min_val;
-		}
-
-		// Swap rows
 		int32_t* temp = prev;
 		prev = curr;
 		curr = temp;
@@ -103,52 +66,45 @@ int32_t levenshtein_sse41(const char* s, int32_t slen, const char* t, int32_t tl
 	int32_t result = prev[tlen];
 	free(prev);
 	free(curr);
-
 	return result;
 }
-
 #else
-// Fallback when SSE4.1 not available
 int32_t levenshtein_sse41(const char* s, int32_t slen, const char* t, int32_t tlen) {
 	(void)s; (void)slen; (void)t; (void)tlen;
-	return -1; // Signal not available
+	return -1;
 }
 #endif
 
-// Pure C scalar implementation (fallback for all platforms)
-int32_t levenshtein_scalar_c(const char* s, int32_t slen, const char* t, int32_t tlen) {
-	if (slen == 0) return tlen;
-	if (tlen == 0) return slen;
+#if defined(__aarch64__)
+#include <arm_neon.h>
 
+// levenshtein_neon computes the byte Levenshtein distance with NEON
+// Returns -1 if allocation fails.
+int32_t levenshtein_neon(const char* s, int32_t slen, const char* t, int32_t tlen) {
 	int32_t* prev = (int32_t*)malloc((tlen + 1) * sizeof(int32_t));
 	int32_t* curr = (int32_t*)malloc((tlen + 1) * sizeof(int32_t));
-
 	if (!prev || !curr) {
 		free(prev);
 		free(curr);
 		return -1;
 	}
-
 	for (int32_t j = 0; j <= tlen; j++) {
 		prev[j] = j;
 	}
 
+	const int32x4_t one = vdupq_n_s32(1);
 	for (int32_t i = 1; i <= slen; i++) {
-		curr[0] = i;
 		char si = s[i - 1];
-
-		for (int32_t j = 1; j <= tlen; j++) {
-			int32_t cost = (si == t[j - 1]) ? 0 : 1;
-			int32_t del = prev[j] + 1;
-			int32_t ins = curr[j - 1] + 1;
-			int32_t sub = prev[j - 1] + cost;
-
-			int32_t min_val = del;
-			if (ins < min_val) min_val = ins;
-			if (sub < min_val) min_val = sub;
-
-			curr[j] = min_val;
+		curr[0] = i;
+		int32_t j = 1;
+		for (; j + 3 <= tlen; j += 4) {
+			int32_t costs[4] = {si != t[j - 1], si != t[j], si != t[j + 1], si != t[j + 2]};
+			int32x4_t diag = vld1q_s32(prev + j - 1);
+			int32x4_t above = vld1q_s32(prev + j);
+			int32x4_t best = vminq_s32(vaddq_s32(diag, vld1q_s32(costs)), vaddq_s32(above, one));
+			vst1q_s32(curr + j, best);
 		}
+		finish_row(prev, curr, si, t, j, tlen);
 
 		int32_t* temp = prev;
 		prev = curr;
@@ -158,9 +114,14 @@ int32_t levenshtein_scalar_c(const char* s, int32_t slen, const char* t, int32_t
 	int32_t result = prev[tlen];
 	free(prev);
 	free(curr);
-
 	return result;
 }
+#else
+int32_t levenshtein_neon(const char* s, int32_t slen, const char* t, int32_t tlen) {
+	(void)s; (void)slen; (void)t; (void)tlen;
+	return -1;
+}
+#endif
 */
 import "C"
 
@@ -168,10 +129,29 @@ import (
 	"unsafe"
 )
 
-// levenshteinDistanceSIMD computes Levenshtein distance using SIMD when available
-// Falls back to scalar C implementation if SIMD is not available on the platform
+// simdKernel is the kernel for this CPU, chosen at startup
+var simdKernel = func() SIMDArchitecture {
+	switch features := DetectCPUFeatures(); {
+	case features.SSE41:
+		return SIMDSSE41
+	case features.NEON:
+		return SIMDNEON
+	}
+	return SIMDScalar
+}()
+
+// simdArchitecture reports the kernel picked for this CPU
+func simdArchitecture() SIMDArchitecture {
+	return simdKernel
+}
+
+// levenshteinDistanceSIMD computes Levenshtein distance with the CPU's kernel
+// Returns -1 when the CPU has none or the kernel can't allocate its rows.
 // This version is compiled when using: go build -tags simd
 func levenshteinDistanceSIMD(s, t string) int {
+	if simdKernel == SIMDScalar {
+		return -1
+	}
 	if len(s) == 0 {
 		return len(t)
 	}
@@ -179,46 +159,14 @@ func levenshteinDistanceSIMD(s, t string) int {
 		return len(s)
 	}
 
-	// Try SSE4.1 SIMD version first
-	result := C.levenshtein_sse41(
-		C.CString(s),
-		C.int32_t(len(s)),
-		C.CString(t),
-		C.int32_t(len(t)),
-	)
-
-	if result >= 0 {
-		return int(result)
-	}
-
-	// Fall back to C scalar implementation
-	result = C.levenshtein_scalar_c(
-		C.CString(s),
-		C.int32_t(len(s)),
-		C.CString(t),
-		C.int32_t(len(t)),
-	)
-
-	if result >= 0 {
-		return int(result)
-	}
-
-	// If C implementation fails, fall back to Go
-	return levenshteinDistanceScalar(s, t)
-}
-
-// init checks if SIMD is available at runtime
-func init() {
-	// Test SIMD availability with a simple case
-	testResult := C.levenshtein_sse41(
-		C.CString("a"),
-		C.int32_t(1),
-		C.CString("a"),
-		C.int32_t(1),
-	)
-
-	// Update detectArchitecture to reflect actual capabilities
-	if testResult == 0 {
-		// SIMD is available and working
+	// The kernels read the strings in place; they hold no Go pointers
+	cs := (*C.char)(unsafe.Pointer(unsafe.StringData(s)))
+	ct := (*C.char)(unsafe.Pointer(unsafe.StringData(t)))
+	var result C.int32_t
+	if simdKernel == SIMDSSE41 {
+		result = C.levenshtein_sse41(cs, C.int32_t(len(s)), ct, C.int32_t(len(t)))
+	} else {
+		result = C.levenshtein_neon(cs, C.int32_t(len(s)), ct, C.int32_t(len(t)))
 	}
+	return int(result)
 }
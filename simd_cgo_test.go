@@ -0,0 +1,56 @@
+//go:build simd && cgo
+
+package duplicatecheck
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+func TestSIMDKernelForCPU(t *testing.T) {
+	features := DetectCPUFeatures()
+	want := SIMDScalar
+	switch {
+	case runtime.GOARCH == "amd64" && features.SSE41:
+		want = SIMDSSE41
+	case runtime.GOARCH == "arm64":
+		want = SIMDNEON
+	}
+	if config := DefaultSIMDConfig(); config.Architecture != want || IsSIMDAvailable() != (want != SIMDScalar) {
+		t.Errorf("Architecture %q, available %v on %+v; want %q", config.Architecture, IsSIMDAvailable(), features, want)
+	}
+}
+
+func TestSIMDKernelMatchesScalar(t *testing.T) {
+	if !IsSIMDAvailable() {
+		t.Skip("No SIMD kernel for this CPU")
+	}
+	rng := rand.New(rand.NewSource(7))
+	randomString := func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = "abcde "[rng.Intn(6)]
+		}
+		return string(b)
+	}
+	config := DefaultSIMDConfig()
+	config.Enabled, config.MinStringLength = true, 0
+	// Lengths around multiples of the 4-cell vectors exercise the scalar tail
+	for m := 0; m <= 13; m++ {
+		for n := 0; n <= 13; n++ {
+			for trial := 0; trial < 5; trial++ {
+				s, u := randomString(m), randomString(n)
+				if got, want := levenshteinDistanceSIMD(s, u), levenshteinDistanceScalar(s, u); got != want {
+					t.Fatalf("Kernel(%q, %q) = %d, want %d", s, u, got, want)
+				}
+			}
+		}
+	}
+	for trial := 0; trial < 50; trial++ {
+		s, u := randomString(100+rng.Intn(500)), randomString(100+rng.Intn(500))
+		if got, want := ComputeDistanceOptimized(s, u, config), levenshteinDistanceScalar(s, u); got != want {
+			t.Fatalf("ComputeDistanceOptimized on %d and %d bytes = %d, want %d", len(s), len(u), got, want)
+		}
+	}
+}
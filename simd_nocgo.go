@@ -0,0 +1,13 @@
+//go:build !simd || !cgo
+
+package duplicatecheck
+
+// simdArchitecture reports the pure Go path: this build has no SIMD kernels
+func simdArchitecture() SIMDArchitecture {
+	return SIMDScalar
+}
+
+// levenshteinDistanceSIMD returns -1: SIMD needs the simd build tag and CGO
+func levenshteinDistanceSIMD(s, t string) int {
+	return -1
+}
@@ -0,0 +1,21 @@
+//go:build !simd || !cgo
+
+package duplicatecheck
+
+import "testing"
+
+func TestSIMDUnavailableWithoutTag(t *testing.T) {
+	config := DefaultSIMDConfig()
+	if config.Architecture != SIMDScalar || IsSIMDAvailable() {
+		t.Errorf("Architecture %q, available %v without the simd tag and CGO", config.Architecture, IsSIMDAvailable())
+	}
+	if config.CPU != DetectCPUFeatures() {
+		t.Errorf("CPU %+v, detected %+v", config.CPU, DetectCPUFeatures())
+	}
+
+	config.Enabled, config.MinStringLength = true, 1
+	s, t2 := "wireless noise cancelling headphones", "wireless noise-canceling headphone"
+	if got, want := ComputeDistanceOptimized(s, t2, config), levenshteinDistanceScalar(s, t2); got != want {
+		t.Errorf("ComputeDistanceOptimized = %d, want %d", got, want)
+	}
+}
@@ -1,10 +1,9 @@
 package duplicatecheck
 
 import (
-	"fmt"
-	"hash/fnv"
 	"math/bits"
 	"strings"
+	"unicode/utf8"
 )
 
 // SimHashFilter implements probabilistic similarity estimation using SimHash algorithm
@@ -30,6 +29,7 @@ type SimHashFilter struct {
 	featureSize int   // Size of n-grams (typically 3-5)
 	enabled     bool  // Whether filter is enabled
 	bitSize     int   // Usually 64 bits
+	mixFeatures bool  // Pass feature hashes through mix64 (see newMixedSimHashFilter)
 }
 
 // SimHashFingerprint represents a 64-bit SimHash for a string
@@ -52,6 +52,18 @@ func NewSimHashFilter(featureSize int) *SimHashFilter {
 	}
 }
 
+// newMixedSimHashFilter creates a SimHash filter whose feature hashes are mixed
+// FNV alone diffuses short features poorly into the high bits: with 3-byte
+// features many bits stay nearly constant, so unrelated long texts estimate
+// around 0.9. Mixing spreads every input bit across all 64 bits, bringing
+// unrelated texts down to ~0.5. Used for index fingerprints; the exported
+// filter keeps its original hashing so existing fingerprints stay valid.
+func newMixedSimHashFilter(featureSize int) *SimHashFilter {
+	s := NewSimHashFilter(featureSize)
+	s.mixFeatures = true
+	return s
+}
+
 // Enable turns on SimHash filtering
 func (s *SimHashFilter) Enable() {
 	s.enabled = true
@@ -76,30 +88,50 @@ func (s *SimHashFilter) Compute64(text string) SimHashFingerprint {
 		return 0
 	}
 
-	// Extract features (n-grams)
-	features := s.extractFeatures(text)
-	if len(features) == 0 {
-		return 0
+	return s.fingerprint(func(fn func(feature string)) {
+		s.eachFeature(text, fn)
+	})
+}
+
+// fieldFingerprint returns Compute64 of a product field as prepared by prep,
+// hashing the product's cached n-grams (see Product.GetDescriptionNgrams)
+// rather than extracting them again
+// Prepared text Compute64 would normalize differently, or that is shorter than
+// a feature, is fingerprinted from the text.
+func (s *SimHashFilter) fieldFingerprint(p *Product, prep *textPreparer, field ngramField) SimHashFingerprint {
+	name, desc := p.preparedStrings(prep)
+	text := name
+	if field == ngramDescription {
+		text = desc
 	}
+	if text != strings.ToLower(strings.TrimSpace(text)) || utf8.RuneCountInString(text) < s.featureSize {
+		return s.Compute64(text)
+	}
+	ngrams := p.fieldNgrams(prep, field, s.featureSize)
+	return s.fingerprint(func(fn func(feature string)) {
+		for _, ngram := range ngrams {
+			fn(ngram[0])
+		}
+	})
+}
 
-	// Build bit vector
-	vector := make([]int, s.bitSize)
-	for _, feature := range features {
+// fingerprint hashes the features each yields into a SimHash
+func (s *SimHashFilter) fingerprint(each func(fn func(feature string))) SimHashFingerprint {
+	// Count, per bit position, the features whose hash sets it
+	ones := make([]int, s.bitSize)
+	features := 0
+	each(func(feature string) {
 		hash := s.hashFeature(feature)
-		// For each bit position, increment if bit is set
-		for i := 0; i < s.bitSize; i++ {
-			if (hash & (uint64(1) << uint(i))) != 0 {
-				vector[i]++
-			} else {
-				vector[i]--
-			}
+		for i := range ones {
+			ones[i] += int(hash >> uint(i) & 1)
 		}
-	}
+		features++
+	})
 
-	// Convert vector to 64-bit hash
+	// A bit is set when more features set it than clear it
 	var result uint64
-	for i := 0; i < s.bitSize; i++ {
-		if vector[i] > 0 {
+	for i, n := range ones {
+		if 2*n > features {
 			result |= (uint64(1) << uint(i))
 		}
 	}
@@ -122,10 +154,14 @@ func (s *SimHashFilter) EstimateSimilarity(text1, text2 string) float64 {
 	return 1.0 - float64(hammingDistance)/float64(s.bitSize)
 }
 
-// QuickReject determines if two strings should be rejected as dissimilar
-// Returns true if strings should continue to Levenshtein (likely similar)
-// Returns false if strings are definitely dissimilar (can skip Levenshtein)
-func (s *SimHashFilter) QuickReject(text1, text2 string, threshold float64) bool {
+// Name returns the filter name ("simhash")
+func (s *SimHashFilter) Name() string {
+	return "simhash"
+}
+
+// MightMatch reports whether two strings may reach threshold (see PreFilter)
+// Returns false if the SimHash estimate is more than 0.15 below threshold
+func (s *SimHashFilter) MightMatch(text1, text2 string, threshold float64) bool {
 	if !s.enabled {
 		return true // If disabled, always continue to Levenshtein
 	}
@@ -135,35 +171,93 @@ func (s *SimHashFilter) QuickReject(text1, text2 string, threshold float64) bool
 
 	// Conservative approach: only reject if very confident
 	// Use threshold - 0.15 safety margin to avoid false negatives
-	return similarity >= (threshold - 0.15)
+	return similarity >= (threshold - simHashSafetyMargin)
+}
+
+// QuickReject determines if two strings should be rejected as dissimilar
+// Returns true if strings should continue to Levenshtein (likely similar)
+// Returns false if strings are definitely dissimilar (can skip Levenshtein)
+// Alias of MightMatch
+func (s *SimHashFilter) QuickReject(text1, text2 string, threshold float64) bool {
+	return s.MightMatch(text1, text2, threshold)
+}
+
+// simHashSafetyMargin is how far below the threshold a SimHash estimate may fall
+// before QuickReject gives up on a pair
+const simHashSafetyMargin = 0.15
+
+// QuickRejectFingerprints applies QuickReject semantics to precomputed fingerprints
+// Returns true if the pair should continue to Levenshtein, false if it can be skipped
+// margin is the safety margin below threshold (QuickReject uses 0.15)
+func QuickRejectFingerprints(a, b SimHashFingerprint, threshold, margin float64) bool {
+	return Similarity(a, b) >= threshold-margin
 }
 
 // extractFeatures extracts n-grams from text
 // Returns list of n-gram features
 func (s *SimHashFilter) extractFeatures(text string) []string {
-	runes := []rune(text)
-	if len(runes) < s.featureSize {
-		// For very short text, return the text itself as feature
+	features := []string{}
+	s.eachFeature(text, func(feature string) {
+		features = append(features, feature)
+	})
+	return features
+}
+
+// eachFeature calls fn with every n-gram of text, in order
+// Features are substrings of text, so nothing is allocated for valid UTF-8.
+// Text shorter than an n-gram is a single feature.
+func (s *SimHashFilter) eachFeature(text string, fn func(feature string)) {
+	if !utf8.ValidString(text) {
+		// Invalid bytes become U+FFFD in features, as rune conversion does
+		text = string([]rune(text))
+	}
+	if utf8.RuneCountInString(text) < s.featureSize {
 		if len(text) > 0 {
-			return []string{text}
+			fn(text)
 		}
-		return []string{}
+		return
 	}
 
-	features := make([]string, 0, len(runes)-s.featureSize+1)
-	for i := 0; i <= len(runes)-s.featureSize; i++ {
-		feature := string(runes[i : i+s.featureSize])
-		features = append(features, feature)
+	// starts holds the byte offsets of the last featureSize+1 rune starts
+	starts := make([]int, 0, s.featureSize+1)
+	for offset := range text {
+		starts = append(starts, offset)
+		if len(starts) == s.featureSize+1 {
+			fn(text[starts[0]:offset])
+			starts = append(starts[:0], starts[1:]...)
+		}
 	}
-
-	return features
+	fn(text[starts[0]:])
 }
 
+// FNV-1 64-bit parameters (see hash/fnv)
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
 // hashFeature computes FNV-64 hash of a feature
+// The hash is inlined so hashing a feature doesn't allocate.
 func (s *SimHashFilter) hashFeature(feature string) uint64 {
-	h := fnv.New64()
-	_, _ = fmt.Fprint(h, feature) // nolint:errcheck // Hash.Write never returns error
-	return h.Sum64()
+	h := uint64(fnvOffset64)
+	for i := 0; i < len(feature); i++ {
+		h *= fnvPrime64
+		h ^= uint64(feature[i])
+	}
+	if s.mixFeatures {
+		return mix64(h)
+	}
+	return h
+}
+
+// mix64 is the SplitMix64 finalizer: a cheap bijective bit mixer
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
 }
 
 // HammingDistance calculates the Hamming distance between two SimHash fingerprints
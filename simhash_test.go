@@ -465,3 +465,57 @@ func BenchmarkHammingDistance(b *testing.B) {
 		_ = HammingDistance(hash1, hash2)
 	}
 }
+
+func TestMixedSimHashFilterSeparatesUnrelatedText(t *testing.T) {
+	mixed := newMixedSimHashFilter(3)
+	a := strings.Repeat("wireless noise cancelling headphones with long battery life ", 20)
+	b := strings.Repeat("organic cotton crew neck t-shirt in assorted colors ", 20)
+
+	if sim := mixed.EstimateSimilarity(a, a); sim != 1.0 {
+		t.Errorf("Identical text should estimate 1.0, got %.3f", sim)
+	}
+	if sim := mixed.EstimateSimilarity(a, b); sim > 0.75 {
+		t.Errorf("Unrelated text should estimate well below 0.75, got %.3f", sim)
+	}
+	if sim := mixed.EstimateSimilarity(a, a+" Ships fast."); sim < 0.85 {
+		t.Errorf("Near-identical text should estimate at least 0.85, got %.3f", sim)
+	}
+}
+
+func TestQuickRejectFingerprints(t *testing.T) {
+	filter := newMixedSimHashFilter(3)
+	a := filter.Compute64("apple iphone 14 pro 128gb space black")
+	b := filter.Compute64("apple iphone 14 pro 128gb space black")
+	if !QuickRejectFingerprints(a, b, 0.95, 0.15) {
+		t.Error("Identical fingerprints should continue to verification")
+	}
+	if QuickRejectFingerprints(0, ^SimHashFingerprint(0), 0.5, 0.15) {
+		t.Error("Opposite fingerprints should be rejected")
+	}
+}
+
+func TestSimHashFieldFingerprint(t *testing.T) {
+	filter := newMixedSimHashFilter(3)
+	products := []Product{
+		{ID: "1", Name: "Apple iPhone 13", Description: "Smartphone with A15 chip and 128GB storage"},
+		{ID: "2", Name: "TV", Description: "ok"},                                // Shorter than a feature
+		{ID: "3", Name: "Café Crème", Description: "Entière, ÉPAISSE ET DOUCE"}, // Multi-byte runes
+		{ID: "4", Name: "bad \xff utf8", Description: ""},
+	}
+	preparers := map[string]*textPreparer{
+		"default": defaultPreparer,
+		"folded":  newTextPreparer(TextPreparation{FoldAccents: true, CollapseWhitespace: true}),
+	}
+	for prepName, prep := range preparers {
+		for i := range products {
+			p := &products[i]
+			name, desc := p.preparedStrings(prep)
+			if got, want := filter.fieldFingerprint(p, prep, ngramName), filter.Compute64(name); got != want {
+				t.Errorf("%s %s name: %x, Compute64 %x", prepName, p.ID, got, want)
+			}
+			if got, want := filter.fieldFingerprint(p, prep, ngramDescription), filter.Compute64(desc); got != want {
+				t.Errorf("%s %s description: %x, Compute64 %x", prepName, p.ID, got, want)
+			}
+		}
+	}
+}
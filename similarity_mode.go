@@ -0,0 +1,111 @@
+package duplicatecheck
+
+import "math"
+
+// SimilarityMode selects how a Levenshtein distance is normalized into a similarity
+type SimilarityMode int
+
+const (
+	// SimilarityLinear is the classic normalization (default):
+	//
+	//	similarity = 1 - d/maxLen
+	//
+	// A distance of 1 costs 33% on "S21" vs "S22" but under 2% on a 60-char name.
+	SimilarityLinear SimilarityMode = iota
+
+	// SimilarityLengthAdjusted makes edits on short strings cost more. Strings
+	// shorter than LevenshteinOptions.LengthFloor (default 12) have each edit
+	// weighted by floor/maxLen:
+	//
+	//	similarity = 1 - (d/maxLen) * max(1, floor/maxLen)
+	//
+	//	"S21" vs "S22"   d=1, maxLen=3:  1 - (1/3)*(12/3) → 0.00  (linear 0.67)
+	//	6-char SKU, d=1:                 1 - (1/6)*(12/6) = 0.67  (linear 0.83)
+	//	60-char name, d=1:               1 - 1/60         = 0.98  (unchanged)
+	//
+	// Identical strings still score 1.0 and strings at or above the floor are
+	// scored exactly as in linear mode. (Simply dividing by max(maxLen, floor)
+	// would do the opposite and inflate short-string scores.)
+	SimilarityLengthAdjusted
+
+	// SimilarityLogistic passes the linear similarity L through a sigmoid with
+	// midpoint m (LogisticMidpoint, default 0.75) and steepness k
+	// (LogisticSteepness, default 12), rescaled so 0 maps to 0 and 1 maps to 1:
+	//
+	//	σ(x) = 1 / (1 + e^-x)
+	//	similarity = (σ(k(L-m)) - σ(-km)) / (σ(k(1-m)) - σ(-km))
+	//
+	// Scores below the midpoint are pushed down and scores above it pushed up,
+	// so the moderate linear scores typical of short strings with one or two
+	// edits ("S21" vs "S22": 0.67 → 0.28) no longer sit near the threshold,
+	// while near-identical long strings stay near 1.0.
+	SimilarityLogistic
+)
+
+// Defaults for the SimilarityMode tuning parameters in LevenshteinOptions
+const (
+	DefaultLengthFloor       = 12
+	DefaultLogisticMidpoint  = 0.75
+	DefaultLogisticSteepness = 12.0
+)
+
+// String returns the mode name
+func (m SimilarityMode) String() string {
+	switch m {
+	case SimilarityLinear:
+		return "linear"
+	case SimilarityLengthAdjusted:
+		return "length-adjusted"
+	case SimilarityLogistic:
+		return "logistic"
+	default:
+		return "unknown"
+	}
+}
+
+// normalizeSimilarity converts a distance into a similarity using the engine's SimilarityMode
+// maxLen must be > 0
+func (e *LevenshteinEngine) normalizeSimilarity(distance, maxLen int) float64 {
+	return e.shapeSimilarity(float64(distance)/float64(maxLen), maxLen)
+}
+
+// shapeSimilarity is normalizeSimilarity for a distance already divided by
+// the maximum distance (see prefixWeightedSimilarity)
+func (e *LevenshteinEngine) shapeSimilarity(ratio float64, maxLen int) float64 {
+	linear := 1.0 - ratio
+
+	switch e.options.SimilarityMode {
+	case SimilarityLengthAdjusted:
+		floor := e.options.LengthFloor
+		if floor <= 0 {
+			floor = DefaultLengthFloor
+		}
+		if maxLen >= floor {
+			return clampUnit(linear)
+		}
+		return clampUnit(1.0 - ratio*(float64(floor)/float64(maxLen)))
+
+	case SimilarityLogistic:
+		m := e.options.LogisticMidpoint
+		if m <= 0 || m >= 1 {
+			m = DefaultLogisticMidpoint
+		}
+		k := e.options.LogisticSteepness
+		if k <= 0 {
+			k = DefaultLogisticSteepness
+		}
+		sigmoid := func(x float64) float64 { return 1.0 / (1.0 + math.Exp(-x)) }
+		low, high := sigmoid(-k*m), sigmoid(k*(1-m))
+		return clampUnit((sigmoid(k*(clampUnit(linear)-m)) - low) / (high - low))
+
+	default:
+		return clampUnit(linear)
+	}
+}
+
+// similarityBoundedByLinear reports whether the active mode never scores a pair
+// above its linear similarity, so linear distance bounds remain safe for pruning
+// A PrefixBias can lift names edited only past their prefix above it.
+func (e *LevenshteinEngine) similarityBoundedByLinear() bool {
+	return e.options.SimilarityMode != SimilarityLogistic && e.options.PrefixBias <= 0
+}
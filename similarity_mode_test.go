@@ -0,0 +1,104 @@
+package duplicatecheck
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSimilarityModes(t *testing.T) {
+	linear := NewLevenshteinEngine()
+	adjusted := NewLevenshteinEngineWithOptions(LevenshteinOptions{SimilarityMode: SimilarityLengthAdjusted})
+	logistic := NewLevenshteinEngineWithOptions(LevenshteinOptions{SimilarityMode: SimilarityLogistic})
+
+	longA := strings.Repeat("abcdefghij", 6)
+	longB := longA[:59] + "X"
+
+	tests := []struct {
+		name   string
+		a, b   string
+		engine *LevenshteinEngine
+		want   float64
+	}{
+		{"Linear short", "S21", "S22", linear, 2.0 / 3.0},
+		{"Length-adjusted short", "S21", "S22", adjusted, 0},
+		{"Length-adjusted SKU", "ab-123", "ab-124", adjusted, 1 - (1.0/6)*(12.0/6)},
+		{"Length-adjusted long unaffected", longA, longB, adjusted, 1 - 1.0/60},
+		{"Length-adjusted identical", "S21", "S21", adjusted, 1},
+		{"Logistic short", "S21", "S22", logistic, 0.282},
+		{"Logistic identical", "S21", "S21", logistic, 1},
+		{"Logistic unrelated", "abc", "xyz", logistic, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.engine.computeSimilarity(tt.a, tt.b, tt.engine.computeDistance(tt.a, tt.b))
+			if math.Abs(got-tt.want) > 0.005 {
+				t.Errorf("similarity(%q, %q) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Logistic keeps near-identical long names high", func(t *testing.T) {
+		got := logistic.computeSimilarity(longA, longB, 1)
+		if got < 0.98 {
+			t.Errorf("Expected >= 0.98, got %.4f", got)
+		}
+	})
+}
+
+func TestSimilarityModeAppliesToBothFields(t *testing.T) {
+	engine := NewLevenshteinEngineWithOptions(LevenshteinOptions{SimilarityMode: SimilarityLengthAdjusted, LengthFloor: 20})
+	result := engine.Compare(
+		Product{ID: "1", Name: "Galaxy S21", Description: "Black 8GB"},
+		Product{ID: "2", Name: "Galaxy S22", Description: "Black 6GB"},
+	)
+
+	// Both fields are 10 and 9 chars with one edit: 1 - (1/len)*(20/len)
+	if want := 1 - (1.0/10)*(20.0/10); math.Abs(result.NameSimilarity-want) > 1e-9 {
+		t.Errorf("NameSimilarity = %.4f, want %.4f", result.NameSimilarity, want)
+	}
+	if want := 1 - (1.0/9)*(20.0/9); math.Abs(result.DescriptionSimilarity-want) > 1e-9 {
+		t.Errorf("DescriptionSimilarity = %.4f, want %.4f", result.DescriptionSimilarity, want)
+	}
+	if result.SimilarityMode != SimilarityLengthAdjusted {
+		t.Errorf("Result should record the mode, got %s", result.SimilarityMode)
+	}
+	if NewLevenshteinEngine().Compare(Product{Name: "a"}, Product{Name: "a"}).SimilarityMode != SimilarityLinear {
+		t.Error("Default mode should be linear")
+	}
+}
+
+func TestSimilarityModeFindDuplicatesByName(t *testing.T) {
+	products := []Product{
+		{ID: "1", Name: "S21"},
+		{ID: "2", Name: "S22"},
+		{ID: "3", Name: "Samsung Galaxy S21 Ultra 5G Phantom Black"},
+		{ID: "4", Name: "Samsung Galaxy S21 Ultra 5G Phantom Blak"},
+	}
+
+	for _, mode := range []SimilarityMode{SimilarityLinear, SimilarityLengthAdjusted, SimilarityLogistic} {
+		t.Run(mode.String(), func(t *testing.T) {
+			engine := NewLevenshteinEngineWithOptions(LevenshteinOptions{SimilarityMode: mode})
+			got := engine.FindDuplicatesByName(products, 0.6)
+
+			// Results must match a brute-force check with the same mode
+			want := 0
+			for i := range products {
+				for j := i + 1; j < len(products); j++ {
+					if engine.CompareNames(products[i], products[j]).Similarity >= 0.6 {
+						want++
+					}
+				}
+			}
+			if len(got) != want {
+				t.Errorf("FindDuplicatesByName found %d pairs, brute force %d", len(got), want)
+			}
+			for _, r := range got {
+				if r.SimilarityMode != mode {
+					t.Errorf("Result recorded mode %s", r.SimilarityMode)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package duplicatecheck
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Patterns of DefaultSKUNamePatterns, matched against prepared (lowercased) names
+var (
+	// gtinNamePattern matches all-digit names of 8 digits or more, such as a
+	// GTIN pasted as the name
+	gtinNamePattern = regexp.MustCompile(`^[0-9]{8,}$`)
+	// prefixedSKUPattern matches a SKU keyword followed by a code holding at
+	// least three digits, such as "SKU-00421" or "Art. 4711-B"
+	prefixedSKUPattern = regexp.MustCompile(`^(?:sku|art|item|ref|part|pn|mpn|upc|ean|gtin)\.?[\s#:_-]*(?:[a-z_.-]*[0-9]){3}[a-z0-9_.-]*$`)
+	// hyphenatedCodePattern matches a short letter prefix, a hyphen and four or
+	// more digits, optionally with hyphenated suffixes, such as "AB-12345-XL"
+	hyphenatedCodePattern = regexp.MustCompile(`^[a-z]{1,4}-[0-9]{4,}(?:-[a-z0-9]+)*$`)
+)
+
+// SKUNameConfig configures comparing names that are bare codes, such as a
+// GTIN or a SKU pasted as the product name, by exact match
+type SKUNameConfig struct {
+	// Patterns detect SKU-like names: a name is SKU-like when any pattern
+	// matches the whole prepared name, lowercased and trimmed (nil =
+	// DefaultSKUNamePatterns())
+	Patterns []*regexp.Regexp
+}
+
+// DefaultSKUNamePatterns returns the default SKU-like name patterns: all-digit
+// names of 8 digits or more, a SKU keyword (sku, art, item, ref, part, pn,
+// mpn, upc, ean, gtin) followed by a code with at least three digits, and
+// hyphenated codes such as "AB-12345"
+// The result is a fresh slice, so callers can extend it freely.
+func DefaultSKUNamePatterns() []*regexp.Regexp {
+	return []*regexp.Regexp{gtinNamePattern, prefixedSKUPattern, hyphenatedCodePattern}
+}
+
+// skuNameMatcher is a SKUNameConfig prepared for matching
+type skuNameMatcher struct {
+	config SKUNameConfig // The config it was prepared from, defaults filled in
+}
+
+// WithSKUNames makes comparisons treat SKU-like names as codes; nil turns it
+// off (the default)
+// Edit distance says little about codes: two GTINs sharing their first 11
+// digits are different products, yet score about 0.85. When both names are
+// SKU-like they are compared by their SKU key, the prepared name without
+// spaces and the separators - _ . / # :, scoring NameSimilarity 1.0 when the
+// keys are equal and 0.0 otherwise, and the result is flagged
+// SKUNameComparison. A pair where only one name is SKU-like is compared as
+// usual and flagged SKUNameMixed. Name-only comparisons (FindDuplicatesByName)
+// don't detect SKU names. Returns the engine for chaining.
+func (e *LevenshteinEngine) WithSKUNames(config *SKUNameConfig) *LevenshteinEngine {
+	if config == nil {
+		e.skuNames = nil
+		return e
+	}
+	patterns := config.Patterns
+	if patterns == nil {
+		patterns = DefaultSKUNamePatterns()
+	}
+	e.skuNames = &skuNameMatcher{config: SKUNameConfig{Patterns: append([]*regexp.Regexp(nil), patterns...)}}
+	return e
+}
+
+// WithSKUNames makes comparisons treat SKU-like names as codes (see
+// LevenshteinEngine.WithSKUNames), and indexes products with SKU-like names
+// by their SKU key instead of MinHash
+// Such a product's signature is derived from its key alone, so the LSH
+// buckets act as an exact-match lookup for it: it collides, in every band,
+// with exactly the products sharing its key, and never with products found
+// through their text. Any built index is discarded. Returns the engine for
+// chaining.
+func (e *HybridEngine) WithSKUNames(config *SKUNameConfig) *HybridEngine {
+	e.levenshteinEngine.WithSKUNames(config)
+	e.resetIndex()
+	return e
+}
+
+// key returns the SKU key of a prepared name, and whether the name is SKU-like
+func (m *skuNameMatcher) key(name string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	for _, pattern := range m.config.Patterns {
+		if pattern.MatchString(name) {
+			return strings.Map(func(r rune) rune {
+				switch r {
+				case ' ', '\t', '-', '_', '.', '/', '#', ':':
+					return -1
+				}
+				return r
+			}, name), true
+		}
+	}
+	return "", false
+}
+
+// fingerprint identifies the patterns, for IndexConfigFingerprint
+func (m *skuNameMatcher) fingerprint() uint64 {
+	fields := []string{"sku-names/v1"}
+	for _, pattern := range m.config.Patterns {
+		fields = append(fields, pattern.String())
+	}
+	return contentFingerprint(fields...)
+}
+
+// scoreNamePair scores two prepared names: by SKU key when both are SKU-like
+// (see WithSKUNames), otherwise as scoreTextNames does
+func (e *LevenshteinEngine) scoreNamePair(nameA, nameB string, scratch *comparisonScratch) nameScore {
+	keyA, skuA := e.skuNames.key(nameA)
+	keyB, skuB := e.skuNames.key(nameB)
+	if skuA && skuB {
+		if keyA == keyB {
+			return nameScore{similarity: 1, sku: true}
+		}
+		return nameScore{distance: utf8.RuneCountInString(nameA) + utf8.RuneCountInString(nameB), sku: true}
+	}
+	score := e.scoreTextNames(nameA, nameB, scratch)
+	score.skuMixed = skuA || skuB
+	return e.scoreBundle(score, nameA, nameB, scratch)
+}
+
+// skuKey returns the SKU key of a product's name, and whether the engine
+// indexes it by that key (see WithSKUNames)
+func (e *HybridEngine) skuKey(product *Product) (string, bool) {
+	if e.levenshteinEngine.skuNames == nil {
+		return "", false
+	}
+	name, _ := product.preparedStrings(e.preparer())
+	return e.levenshteinEngine.skuNames.key(name)
+}
+
+// skuPair reports whether a and b both have SKU-like names (b may be nil)
+func (e *HybridEngine) skuPair(a, b *Product) bool {
+	if b == nil {
+		return false
+	}
+	_, skuA := e.skuKey(a)
+	_, skuB := e.skuKey(b)
+	return skuA && skuB
+}
+
+// productSignatures returns the MinHash signatures indexed for a product
+// whose index text is text, or the signature of its SKU key
+func (e *HybridEngine) productSignatures(product *Product, text string) [][]uint32 {
+	if key, ok := e.skuKey(product); ok {
+		return [][]uint32{e.skuSignature(key)}
+	}
+	return e.computeSignatures(text)
+}
+
+// skuSignature returns the signature indexed for a SKU key: every row a hash
+// of the key, so two keys share a band only if they are equal
+func (e *HybridEngine) skuSignature(key string) []uint32 {
+	seed := contentFingerprint("sku", key)
+	signature := make([]uint32, e.numHashFunctions)
+	for i := range signature {
+		signature[i] = uint32(mix64(seed + uint64(i)*0x9e3779b97f4a7c15))
+	}
+	return signature
+}
+
+// skuFingerprintParts returns the IndexConfigFingerprint parts of the SKU
+// name settings (none when off, so other indexes keep their fingerprint)
+func (e *HybridEngine) skuFingerprintParts() []string {
+	if e.levenshteinEngine.skuNames == nil {
+		return nil
+	}
+	return []string{"sku-names", strconv.FormatUint(e.levenshteinEngine.skuNames.fingerprint(), 16)}
+}
@@ -0,0 +1,123 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestSKUNameKey(t *testing.T) {
+	matcher := NewLevenshteinEngine().WithSKUNames(&SKUNameConfig{}).skuNames
+	tests := []struct {
+		name string
+		key  string
+		sku  bool
+	}{
+		{"4251234567890", "4251234567890", true},
+		{"12345678", "12345678", true},
+		{"1234567", "", false},
+		{"sku-00421", "sku00421", true},
+		{"sku 00421", "sku00421", true},
+		{"art. 4711-b", "art4711b", true},
+		{"pn: 123", "pn123", true},
+		{"ab-12345-xl", "ab12345xl", true},
+		{"sku", "", false},
+		{"sku-12", "", false},
+		{"wireless mouse 2000", "", false},
+		{"iphone 15", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, sku := matcher.key(tt.name)
+			if key != tt.key || sku != tt.sku {
+				t.Errorf("key(%q) = %q, %v; want %q, %v", tt.name, key, sku, tt.key, tt.sku)
+			}
+		})
+	}
+
+	custom := NewLevenshteinEngine().WithSKUNames(&SKUNameConfig{Patterns: []*regexp.Regexp{regexp.MustCompile(`^x[0-9]+$`)}}).skuNames
+	if _, sku := custom.key("4251234567890"); sku {
+		t.Error("custom patterns kept the defaults")
+	}
+	if key, sku := custom.key("x42"); !sku || key != "x42" {
+		t.Errorf("custom pattern: %q, %v", key, sku)
+	}
+}
+
+func TestSKUNameComparison(t *testing.T) {
+	tests := []struct {
+		name           string
+		nameA, nameB   string
+		nameSimilarity float64
+		sku, mixed     bool
+	}{
+		{"GTINs sharing 11 leading digits", "4251234567890", "4251234567812", 0, true, false},
+		{"same SKU, other separators", "SKU-00421", "sku 00421", 1, true, false},
+		{"identical SKUs", "SKU-00421", "SKU-00421", 1, true, false},
+		{"SKU and a title", "SKU-00421", "Wireless Mouse", -1, false, true},
+		{"titles", "Wireless Mouse", "Wireless Mouse Pro", -1, false, false},
+	}
+	engine := NewLevenshteinEngine().WithSKUNames(&SKUNameConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Product{ID: "a", Name: tt.nameA, Description: "Spare part"}
+			b := Product{ID: "b", Name: tt.nameB, Description: "Spare part"}
+			result := engine.Compare(a, b)
+			if result.SKUNameComparison != tt.sku || result.SKUNameMixed != tt.mixed {
+				t.Errorf("SKUNameComparison %v, SKUNameMixed %v; want %v, %v",
+					result.SKUNameComparison, result.SKUNameMixed, tt.sku, tt.mixed)
+			}
+			if tt.nameSimilarity >= 0 && result.NameSimilarity != tt.nameSimilarity {
+				t.Errorf("NameSimilarity %v, want %v", result.NameSimilarity, tt.nameSimilarity)
+			}
+		})
+	}
+
+	// Without WithSKUNames the GTINs look like near-duplicates
+	plain := NewLevenshteinEngine().Compare(Product{Name: "4251234567890"}, Product{Name: "4251234567812"})
+	if plain.NameSimilarity < 0.8 || plain.SKUNameComparison {
+		t.Errorf("plain GTIN comparison: %v, flagged %v", plain.NameSimilarity, plain.SKUNameComparison)
+	}
+}
+
+func TestHybridSKUNameLookup(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 300)
+	for i := 0; i < 100; i++ {
+		// SKUs a digit apart, each listed twice with different separators
+		catalog = append(catalog,
+			Product{ID: fmt.Sprintf("sku-%d", i), Name: fmt.Sprintf("SKU-%05d", 42100+i), Description: "Replacement filter cartridge"},
+			Product{ID: fmt.Sprintf("sku-%d-copy", i), Name: fmt.Sprintf("sku %05d", 42100+i), Description: "Replacement filter cartridge"})
+	}
+	engine := NewHybridEngine().WithSKUNames(&SKUNameConfig{})
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i += 7 {
+		query := catalog[300+2*i]
+		var candidates []string
+		for _, c := range engine.findCandidates(engine.currentIndex(), &query, 0.75) {
+			candidates = append(candidates, c.id)
+		}
+		sort.Strings(candidates)
+		want := []string{query.ID, query.ID + "-copy"}
+		if fmt.Sprint(candidates) != fmt.Sprint(want) {
+			t.Errorf("candidates of %s: %v, want %v", query.Name, candidates, want)
+		}
+	}
+
+	results := engine.FindDuplicates(catalog[300:], 0.75)
+	if len(results) != 100 {
+		t.Errorf("%d duplicates among the SKUs, want 100", len(results))
+	}
+	for _, r := range results {
+		if !r.SKUNameComparison || r.ProductA.ID+"-copy" != r.ProductB.ID && r.ProductB.ID+"-copy" != r.ProductA.ID {
+			t.Errorf("unexpected pair %s/%s (SKUNameComparison %v)", r.ProductA.ID, r.ProductB.ID, r.SKUNameComparison)
+		}
+	}
+
+	if NewHybridEngine().IndexConfigFingerprint() == engine.IndexConfigFingerprint() {
+		t.Error("SKU names left the index fingerprint unchanged")
+	}
+}
@@ -0,0 +1,207 @@
+package duplicatecheck
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultSlicePoolMaxCapacity is the largest DP row or rune buffer pooled
+	// by default; longer strings allocate their buffers per comparison
+	DefaultSlicePoolMaxCapacity = 4096
+	// defaultVectorPoolMaxCapacity is the largest Myers bit vector pooled
+	defaultVectorPoolMaxCapacity = 1 << 16
+	// defaultRowCapacity is the capacity of a new DP row; most product names
+	// and descriptions are shorter
+	defaultRowCapacity = 1024
+)
+
+// SlicePoolConfig configures the pools that distance computations outside a
+// scan take their DP rows, rune buffers and bit vectors from (see
+// ConfigureSlicePools)
+// Scans give each worker its own buffers, so they use the pools for hybrid
+// candidate verification and one-off comparisons only.
+type SlicePoolConfig struct {
+	// Disabled allocates every buffer and pools none, trading allocations for
+	// no memory retained between comparisons
+	Disabled bool
+	// SizeClasses are the DP row and rune buffer capacities pooled, each class
+	// holding the buffers up to its size (nil = one class of
+	// DefaultSlicePoolMaxCapacity, sized to each request). A buffer a class
+	// other than the first allocates has the class's capacity, so requests
+	// of varying length reuse it; longer requests than the last class
+	// allocate per comparison. E.g. {1024, 4096, 16384} pools descriptions
+	// up to 16k characters.
+	SizeClasses []int
+}
+
+// SlicePoolCounters counts one pool's traffic
+type SlicePoolCounters struct {
+	Gets       uint64 `json:"gets"`        // Buffers requested
+	Puts       uint64 `json:"puts"`        // Buffers returned to the pool
+	SizeMisses uint64 `json:"size_misses"` // Requests and returns over the largest size class, bypassing the pool
+	News       uint64 `json:"news"`        // Buffers allocated because the pool had none large enough
+}
+
+// SlicePoolStats counts the traffic of the distance buffer pools (see
+// GetSlicePoolStats)
+type SlicePoolStats struct {
+	Rows    SlicePoolCounters `json:"rows"`    // DP rows of short-pattern distances
+	Runes   SlicePoolCounters `json:"runes"`   // Strings decoded to runes
+	Vectors SlicePoolCounters `json:"vectors"` // Bit vectors of Myers' algorithm
+}
+
+// slicePool pools slices by capacity class
+type slicePool[T any] struct {
+	classes  []int       // Largest capacity each class pools, ascending
+	pools    []sync.Pool // One per class
+	initial  int         // Least capacity a first-class allocation gets
+	counters *slicePoolCounters
+}
+
+// slicePoolCounters is SlicePoolCounters updated atomically
+type slicePoolCounters struct {
+	gets, puts, sizeMisses, news uint64
+}
+
+// slicePoolSet is the pools of one SlicePoolConfig; nil pools are disabled
+type slicePoolSet struct {
+	rows    *slicePool[int]
+	runes   *slicePool[rune]
+	vectors *slicePool[uint64]
+}
+
+var (
+	// slicePools holds the pools of the current SlicePoolConfig
+	slicePools atomic.Pointer[slicePoolSet]
+	// Counters outlive reconfiguration, so stats stay cumulative
+	rowPoolCounters, runePoolCounters, vectorPoolCounters slicePoolCounters
+)
+
+func init() {
+	ConfigureSlicePools(SlicePoolConfig{})
+}
+
+// ConfigureSlicePools replaces the distance buffer pools (see SlicePoolConfig)
+// The buffers pooled so far are dropped. Safe to call while comparisons run;
+// they finish with the pools they started with.
+func ConfigureSlicePools(config SlicePoolConfig) {
+	if config.Disabled {
+		slicePools.Store(&slicePoolSet{})
+		return
+	}
+	classes := config.SizeClasses
+	if len(classes) == 0 {
+		classes = []int{DefaultSlicePoolMaxCapacity}
+	}
+	classes = append([]int(nil), classes...)
+	sort.Ints(classes)
+	slicePools.Store(&slicePoolSet{
+		rows:    newSlicePool[int](classes, defaultRowCapacity, &rowPoolCounters),
+		runes:   newSlicePool[rune](classes, 0, &runePoolCounters),
+		vectors: newSlicePool[uint64]([]int{defaultVectorPoolMaxCapacity}, 0, &vectorPoolCounters),
+	})
+}
+
+// GetSlicePoolStats returns the cumulative traffic of the distance buffer
+// pools, package-wide; disabled pools count nothing
+func GetSlicePoolStats() SlicePoolStats {
+	return SlicePoolStats{
+		Rows:    rowPoolCounters.load(),
+		Runes:   runePoolCounters.load(),
+		Vectors: vectorPoolCounters.load(),
+	}
+}
+
+// runePoolCapacity returns the largest rune buffer pooled (0 when disabled)
+func runePoolCapacity() int {
+	if pools := slicePools.Load(); pools.runes != nil {
+		return pools.runes.largest()
+	}
+	return 0
+}
+
+// newSlicePool returns a pool for classes, ascending
+func newSlicePool[T any](classes []int, initial int, counters *slicePoolCounters) *slicePool[T] {
+	return &slicePool[T]{classes: classes, pools: make([]sync.Pool, len(classes)), initial: initial, counters: counters}
+}
+
+// class returns the index of the smallest class holding capacity, or -1
+func (p *slicePool[T]) class(capacity int) int {
+	k := sort.SearchInts(p.classes, capacity)
+	if k == len(p.classes) {
+		return -1
+	}
+	return k
+}
+
+// get returns a slice of length size, pooled when one is large enough
+// Its contents are unspecified.
+func (p *slicePool[T]) get(size int) []T {
+	atomic.AddUint64(&p.counters.gets, 1)
+	k := p.class(size)
+	if k < 0 {
+		atomic.AddUint64(&p.counters.sizeMisses, 1)
+		atomic.AddUint64(&p.counters.news, 1)
+		return make([]T, size)
+	}
+	if pooled, ok := p.pools[k].Get().(*[]T); ok && cap(*pooled) >= size {
+		return (*pooled)[:size]
+	}
+	atomic.AddUint64(&p.counters.news, 1)
+	capacity := p.classes[k]
+	if k == 0 {
+		capacity = size
+		if capacity < p.initial {
+			capacity = p.initial
+		}
+	}
+	return make([]T, size, capacity)
+}
+
+// put returns a slice to its class, reporting whether it was pooled
+func (p *slicePool[T]) put(slice []T) bool {
+	k := p.class(cap(slice))
+	if k < 0 {
+		atomic.AddUint64(&p.counters.sizeMisses, 1)
+		return false
+	}
+	atomic.AddUint64(&p.counters.puts, 1)
+	p.pools[k].Put(&slice)
+	return true
+}
+
+// largest returns the largest capacity the pool holds
+func (p *slicePool[T]) largest() int {
+	return p.classes[len(p.classes)-1]
+}
+
+// load returns the counters
+func (c *slicePoolCounters) load() SlicePoolCounters {
+	return SlicePoolCounters{
+		Gets:       atomic.LoadUint64(&c.gets),
+		Puts:       atomic.LoadUint64(&c.puts),
+		SizeMisses: atomic.LoadUint64(&c.sizeMisses),
+		News:       atomic.LoadUint64(&c.news),
+	}
+}
+
+// minus returns the counts between before and c
+func (c SlicePoolCounters) minus(before SlicePoolCounters) SlicePoolCounters {
+	return SlicePoolCounters{
+		Gets:       c.Gets - before.Gets,
+		Puts:       c.Puts - before.Puts,
+		SizeMisses: c.SizeMisses - before.SizeMisses,
+		News:       c.News - before.News,
+	}
+}
+
+// minus returns the counts between before and s
+func (s SlicePoolStats) minus(before SlicePoolStats) SlicePoolStats {
+	return SlicePoolStats{
+		Rows:    s.Rows.minus(before.Rows),
+		Runes:   s.Runes.minus(before.Runes),
+		Vectors: s.Vectors.minus(before.Vectors),
+	}
+}
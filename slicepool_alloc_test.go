@@ -0,0 +1,32 @@
+//go:build !race
+
+package duplicatecheck
+
+import "testing"
+
+// TestSlicePoolLongDescriptions counts allocations with and without size
+// classes; the race detector drops pooled values at random, so the file isn't
+// built with -race
+func TestSlicePoolLongDescriptions(t *testing.T) {
+	a, b := longDescriptionPair()
+	engine := NewLevenshteinEngine()
+	engine.Compare(a, b)
+
+	before := GetSlicePoolStats().Runes
+	engine.Compare(a, b)
+	if got := GetSlicePoolStats().Runes.minus(before); got.SizeMisses == 0 {
+		t.Errorf("default pools took 5000-character descriptions: %+v", got)
+	}
+	plain := testing.AllocsPerRun(20, func() { engine.Compare(a, b) })
+
+	withSlicePools(t, SlicePoolConfig{SizeClasses: []int{1024, 4096, 16384}})
+	engine.Compare(a, b)
+	before = GetSlicePoolStats().Runes
+	classed := testing.AllocsPerRun(20, func() { engine.Compare(a, b) })
+	if got := GetSlicePoolStats().Runes.minus(before); got.SizeMisses != 0 || got.Puts != got.Gets {
+		t.Errorf("size classes: %+v", got)
+	}
+	if classed > plain-2 {
+		t.Errorf("%v allocations per comparison with size classes, %v without", classed, plain)
+	}
+}
@@ -0,0 +1,114 @@
+package duplicatecheck
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// longDescriptionPair returns two products whose 5000-character descriptions
+// differ by a few words
+func longDescriptionPair() (Product, Product) {
+	words := strings.Fields("stainless steel frame with walnut finish adjustable shelves soft close hinges")
+	var a, b strings.Builder
+	for i := 0; a.Len() < 5000 || b.Len() < 5000; i++ {
+		word := words[i%len(words)]
+		a.WriteString(word + " ")
+		if i%97 == 0 {
+			word = "oak"
+		}
+		b.WriteString(word + " ")
+	}
+	return Product{ID: "A", Name: "Walnut Bookcase", Description: a.String()[:5000]},
+		Product{ID: "B", Name: "Walnut Bookcase Tall", Description: b.String()[:5000]}
+}
+
+// withSlicePools configures the pools for one test, restoring the defaults after
+func withSlicePools(t testing.TB, config SlicePoolConfig) {
+	ConfigureSlicePools(config)
+	t.Cleanup(func() { ConfigureSlicePools(SlicePoolConfig{}) })
+}
+
+func TestSlicePoolClasses(t *testing.T) {
+	withSlicePools(t, SlicePoolConfig{SizeClasses: []int{4096, 1024, 16384}})
+	pool := slicePools.Load().runes
+	tests := []struct {
+		size, class, capacity int
+	}{
+		{10, 0, 10}, // The first class sizes buffers to the request
+		{1024, 0, 1024},
+		{1025, 1, 4096},
+		{5000, 2, 16384},
+		{16385, -1, 16385},
+	}
+	for _, tt := range tests {
+		if k := pool.class(tt.size); k != tt.class {
+			t.Errorf("class(%d) = %d, want %d", tt.size, k, tt.class)
+		}
+		before := GetSlicePoolStats().Runes
+		buf := pool.get(tt.size)
+		if len(buf) != tt.size || cap(buf) != tt.capacity {
+			t.Errorf("get(%d): len %d cap %d, want cap %d", tt.size, len(buf), cap(buf), tt.capacity)
+		}
+		if pooled := pool.put(buf); pooled != (tt.class >= 0) {
+			t.Errorf("put(cap %d) pooled = %v", cap(buf), pooled)
+		}
+		got := GetSlicePoolStats().Runes.minus(before)
+		misses := uint64(0)
+		if tt.class < 0 {
+			misses = 2
+		}
+		if got.Gets != 1 || got.News > 1 || got.SizeMisses != misses || got.Puts != 1-misses/2 {
+			t.Errorf("size %d: counters %+v", tt.size, got)
+		}
+	}
+
+	// The default keeps one class, sizing DP rows to at least 1024
+	ConfigureSlicePools(SlicePoolConfig{})
+	if row := getIntSlice(10); len(row) != 10 || cap(row) < defaultRowCapacity {
+		t.Errorf("default row: len %d cap %d", len(row), cap(row))
+	}
+	if putIntSlice(make([]int, DefaultSlicePoolMaxCapacity+1)) {
+		t.Error("default pool took a row over DefaultSlicePoolMaxCapacity")
+	}
+}
+
+func TestSlicePoolDisabled(t *testing.T) {
+	withSlicePools(t, SlicePoolConfig{Disabled: true})
+	a, b := longDescriptionPair()
+	engine := NewLevenshteinEngine()
+	before := GetSlicePoolStats()
+	engine.Compare(a, b)
+	engine.Compare(Product{ID: "1", Name: "Oak Desk"}, Product{ID: "2", Name: "Oak Desks"})
+	if got := GetSlicePoolStats(); got != before {
+		t.Errorf("disabled pools counted traffic: %+v, before %+v", got, before)
+	}
+	if report, _ := NewLevenshteinEngine().Warmup(context.Background(), []Product{a, b}, time.Second); report.PooledSlices != 0 {
+		t.Errorf("warmup pooled %d slices into disabled pools", report.PooledSlices)
+	}
+}
+
+// BenchmarkCompareLongDescriptions compares 5000-character descriptions with
+// the default pools, which don't hold their rune buffers, and with a 16k size
+// class, which does
+func BenchmarkCompareLongDescriptions(b *testing.B) {
+	pa, pb := longDescriptionPair()
+	for _, bench := range []struct {
+		name   string
+		config SlicePoolConfig
+	}{
+		{"default", SlicePoolConfig{}},
+		{"size classes", SlicePoolConfig{SizeClasses: []int{1024, 4096, 16384}}},
+		{"disabled", SlicePoolConfig{Disabled: true}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			withSlicePools(b, bench.config)
+			engine := NewLevenshteinEngine()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				engine.Compare(pa, pb)
+			}
+		})
+	}
+}
@@ -0,0 +1,488 @@
+package duplicatecheck
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+)
+
+// The comparison spec: the semantics every engine and every FindDuplicates
+// variant share, as stated in the DuplicateCheckEngine and ComparisonResult
+// docs. Optimizations (pre-filters, lazy skips, SIMD) must keep it passing.
+
+// specEngine is an engine the spec runs against
+type specEngine struct {
+	name string
+	new  func() DuplicateCheckEngine
+	// complete is set when the engine's scans return every pair meeting the
+	// threshold; LSH candidate generation may miss some
+	complete bool
+}
+
+// specEngines returns the engines the spec runs against
+func specEngines() []specEngine {
+	return []specEngine{
+		{"Levenshtein", func() DuplicateCheckEngine { return NewLevenshteinEngine() }, true},
+		{"Hybrid", func() DuplicateCheckEngine { return NewHybridEngine() }, true},
+		{"Hybrid LSH", func() DuplicateCheckEngine { return NewHybridEngine().WithExactModeCutoff(0) }, false},
+	}
+}
+
+// specFinder is one way an engine finds the duplicates in a catalog
+type specFinder struct {
+	name string
+	find func(t *testing.T, products []Product, threshold float64) []ComparisonResult
+}
+
+// specFinders returns every FindDuplicates variant engines built by newEngine
+// implement, each on a fresh engine
+func specFinders(newEngine func() DuplicateCheckEngine) []specFinder {
+	type checked interface {
+		FindDuplicatesChecked([]Product, float64) ([]ComparisonResult, error)
+	}
+	type withCtx interface {
+		FindDuplicatesCtx(context.Context, []Product, float64) ([]ComparisonResult, error)
+	}
+	type parallel interface {
+		FindDuplicatesParallel([]Product, float64) []ComparisonResult
+	}
+	type channel interface {
+		FindDuplicatesChan(context.Context, []Product, float64, int) (<-chan ComparisonResult, <-chan error)
+	}
+	type summarized interface {
+		FindDuplicatesWithSummary([]Product, float64) ([]ComparisonResult, ScanSummary, error)
+	}
+	type bestEffort interface {
+		FindDuplicatesBestEffort(context.Context, []Product, float64) ([]ComparisonResult, bool)
+	}
+	type resumable interface {
+		FindDuplicatesResumable([]Product, float64, ScanCursor, ResultWriter) (ScanCursor, error)
+	}
+	type tiered interface {
+		FindDuplicatesTiered([]Product, []float64) (TieredResults, error)
+	}
+	type multiWeights interface {
+		FindDuplicatesMultiWeights([]Product, map[string]ComparisonWeights, map[string]float64) map[string][]ComparisonResult
+	}
+	type byRule interface {
+		FindDuplicatesByRule([]Product, MatchRule) []ComparisonResult
+	}
+
+	probe := newEngine()
+	finders := []specFinder{{"FindDuplicates", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+		return newEngine().FindDuplicates(products, threshold)
+	}}}
+	add := func(name string, find func(t *testing.T, products []Product, threshold float64) []ComparisonResult) {
+		finders = append(finders, specFinder{name, find})
+	}
+	if _, ok := probe.(PointerEngine); ok {
+		add("FindDuplicatesPtr", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			return newEngine().(PointerEngine).FindDuplicatesPtr(productPtrs(products), threshold)
+		})
+	}
+	if _, ok := probe.(DefaultThresholdEngine); ok {
+		add("FindDuplicatesDefault", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			engine := newEngine().(DefaultThresholdEngine)
+			if err := engine.SetDefaultThreshold(threshold); err != nil {
+				t.Fatal(err)
+			}
+			return engine.FindDuplicatesDefault(products)
+		})
+	}
+	if _, ok := probe.(checked); ok {
+		add("FindDuplicatesChecked", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			results, err := newEngine().(checked).FindDuplicatesChecked(products, threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return results
+		})
+	}
+	if _, ok := probe.(withCtx); ok {
+		add("FindDuplicatesCtx", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			results, err := newEngine().(withCtx).FindDuplicatesCtx(context.Background(), products, threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return results
+		})
+	}
+	if _, ok := probe.(parallel); ok {
+		add("FindDuplicatesParallel", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			return newEngine().(parallel).FindDuplicatesParallel(products, threshold)
+		})
+	}
+	if _, ok := probe.(channel); ok {
+		add("FindDuplicatesChan", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			results, err := drainChan(newEngine().(channel).FindDuplicatesChan(context.Background(), products, threshold, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return results
+		})
+	}
+	if _, ok := probe.(summarized); ok {
+		add("FindDuplicatesWithSummary", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			results, _, err := newEngine().(summarized).FindDuplicatesWithSummary(products, threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return results
+		})
+	}
+	if _, ok := probe.(bestEffort); ok {
+		add("FindDuplicatesBestEffort", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			results, complete := newEngine().(bestEffort).FindDuplicatesBestEffort(context.Background(), products, threshold)
+			if !complete {
+				t.Fatal("best-effort scan without a deadline didn't complete")
+			}
+			return results
+		})
+	}
+	if _, ok := probe.(resumable); ok {
+		add("FindDuplicatesResumable", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			sink := &specSink{}
+			if _, err := newEngine().(resumable).FindDuplicatesResumable(products, threshold, ScanCursor{}, sink); err != nil {
+				t.Fatal(err)
+			}
+			return sink.results
+		})
+	}
+	if _, ok := probe.(tiered); ok {
+		add("FindDuplicatesTiered", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			if threshold <= 0 {
+				t.Skip("tiers must be above 0")
+			}
+			tiers, err := newEngine().(tiered).FindDuplicatesTiered(products, []float64{threshold})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return tiers.Tiers[0].Results
+		})
+	}
+	if _, ok := probe.(multiWeights); ok {
+		add("FindDuplicatesMultiWeights", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			return newEngine().(multiWeights).FindDuplicatesMultiWeights(products,
+				map[string]ComparisonWeights{"default": DefaultWeights()}, map[string]float64{"default": threshold})["default"]
+		})
+	}
+	if _, ok := probe.(byRule); ok {
+		add("FindDuplicatesByRule", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			return newEngine().(byRule).FindDuplicatesByRule(products, CombinedAtLeast(threshold))
+		})
+	}
+	if _, ok := probe.(*HybridEngine); ok {
+		add("FindDuplicatesForOne", func(t *testing.T, products []Product, threshold float64) []ComparisonResult {
+			engine := newEngine().(*HybridEngine)
+			if err := engine.BuildIndex(products); err != nil {
+				t.Fatal(err)
+			}
+			// Each pair is found from both of its products; keep the first
+			var results []ComparisonResult
+			seen := make(map[string]bool)
+			for _, p := range products {
+				for _, r := range engine.FindDuplicatesForOne(p, threshold) {
+					if key := makePairKey(r.ProductA.ID, r.ProductB.ID); !seen[key] {
+						seen[key] = true
+						results = append(results, r)
+					}
+				}
+			}
+			return results
+		})
+	}
+	return finders
+}
+
+// specSink collects the results of a resumable scan
+type specSink struct {
+	results []ComparisonResult
+}
+
+func (s *specSink) WriteResult(result ComparisonResult) error {
+	s.results = append(s.results, result)
+	return nil
+}
+
+// specCatalog returns the catalog the spec scans
+func specCatalog() []Product {
+	return GenerateTestCatalog(goldenCatalogSeed, 40)
+}
+
+// specScores returns the CombinedSimilarity Compare gives each pair of
+// products, by pair key
+func specScores(engine DuplicateCheckEngine, products []Product) map[string]float64 {
+	scores := make(map[string]float64)
+	for i := range products {
+		for j := i + 1; j < len(products); j++ {
+			r := engine.Compare(products[i], products[j])
+			scores[makePairKey(products[i].ID, products[j].ID)] = r.CombinedSimilarity
+		}
+	}
+	return scores
+}
+
+// boundaryScores returns up to n distinct pair scores in [0.5, 1), spread
+// over the range, to run scans at exactly a pair's score
+func boundaryScores(scores map[string]float64, n int) []float64 {
+	distinct := make(map[float64]bool)
+	for _, s := range scores {
+		if s >= 0.5 && s < 1 {
+			distinct[s] = true
+		}
+	}
+	sorted := make([]float64, 0, len(distinct))
+	for s := range distinct {
+		sorted = append(sorted, s)
+	}
+	sort.Float64s(sorted)
+	if len(sorted) <= n {
+		return sorted
+	}
+	picked := make([]float64, n)
+	for i := range picked {
+		picked[i] = sorted[i*(len(sorted)-1)/(n-1)]
+	}
+	return picked
+}
+
+func TestSpecCompareSymmetry(t *testing.T) {
+	catalog := specCatalog()
+	for _, se := range specEngines() {
+		t.Run(se.name, func(t *testing.T) {
+			engine := se.new()
+			for i := range catalog {
+				for j := i + 1; j < len(catalog); j++ {
+					ab, ba := engine.Compare(catalog[i], catalog[j]), engine.Compare(catalog[j], catalog[i])
+					if ab.NameDistance != ba.NameDistance || ab.DescriptionDistance != ba.DescriptionDistance ||
+						ab.NameSimilarity != ba.NameSimilarity || ab.DescriptionSimilarity != ba.DescriptionSimilarity ||
+						ab.CombinedSimilarity != ba.CombinedSimilarity || ab.MeetsThreshold != ba.MeetsThreshold {
+						t.Errorf("%s/%s: %+v, reversed %+v", catalog[i].ID, catalog[j].ID, ab, ba)
+					}
+					if ab.ProductA.ID != catalog[i].ID || ab.ProductB.ID != catalog[j].ID {
+						t.Errorf("%s/%s: result holds %s and %s", catalog[i].ID, catalog[j].ID, ab.ProductA.ID, ab.ProductB.ID)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSpecEmptyFields(t *testing.T) {
+	a := Product{ID: "A", Name: "Apple iPhone 13 Pro", Description: "Smartphone with a 6.1 inch display"}
+	b := Product{ID: "B", Name: "Apple iPhone 13", Description: "Smartphone with a 6.1 inch OLED display"}
+	full := NewLevenshteinEngine().Compare(a, b)
+
+	tests := []struct {
+		name string
+		a, b Product
+		want func(r ComparisonResult) float64
+	}{
+		{"Both fields present", a, b, func(r ComparisonResult) float64 {
+			return 0.7*r.NameSimilarity + 0.3*r.DescriptionSimilarity
+		}},
+		{"Names empty on both sides", Product{ID: "A", Description: a.Description}, Product{ID: "B", Description: b.Description},
+			func(r ComparisonResult) float64 { return full.DescriptionSimilarity }},
+		{"Descriptions empty on both sides", Product{ID: "A", Name: a.Name}, Product{ID: "B", Name: b.Name},
+			func(r ComparisonResult) float64 { return full.NameSimilarity }},
+		{"Each lacks a different field", Product{ID: "A", Name: a.Name}, Product{ID: "B", Description: a.Description},
+			func(ComparisonResult) float64 { return 0 }},
+		{"Description empty on one side", a, Product{ID: "B", Name: b.Name}, func(r ComparisonResult) float64 {
+			return 0.7 * full.NameSimilarity // The empty side scores 0 and keeps its weight
+		}},
+		{"Both products empty", Product{ID: "A"}, Product{ID: "B"}, func(ComparisonResult) float64 { return 1 }},
+	}
+	for _, se := range specEngines() {
+		for _, tt := range tests {
+			t.Run(se.name+"/"+tt.name, func(t *testing.T) {
+				engine := se.new()
+				for _, r := range []ComparisonResult{engine.Compare(tt.a, tt.b), engine.Compare(tt.b, tt.a)} {
+					if want := tt.want(r); math.Abs(r.CombinedSimilarity-want) > ThresholdEpsilon {
+						t.Errorf("CombinedSimilarity = %v, want %v", r.CombinedSimilarity, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSpecWeightNormalization(t *testing.T) {
+	a := Product{ID: "A", Name: "Samsung Galaxy S22 Ultra", Description: "Android phone with S Pen and 108MP camera"}
+	b := Product{ID: "B", Name: "Samsung Galaxy S22", Description: "Android phone with a 50MP camera"}
+	identities := []struct {
+		name string
+		w, v ComparisonWeights
+	}{
+		{"(2, 1) is (2/3, 1/3)", ComparisonWeights{2, 1}, ComparisonWeights{2.0 / 3, 1.0 / 3}},
+		{"(7, 3) is (0.7, 0.3)", ComparisonWeights{7, 3}, ComparisonWeights{0.7, 0.3}},
+		{"(0, 0) is the defaults", ComparisonWeights{}, DefaultWeights()},
+		{"Negative weights are 0", ComparisonWeights{-1, 1}, ComparisonWeights{0, 1}},
+		{"NaN weights are 0", ComparisonWeights{1, math.NaN()}, ComparisonWeights{1, 0}},
+	}
+	for _, se := range specEngines() {
+		t.Run(se.name, func(t *testing.T) {
+			engine := se.new()
+			for _, tt := range identities {
+				rw, rv := engine.CompareWithWeights(a, b, tt.w), engine.CompareWithWeights(a, b, tt.v)
+				if math.Abs(rw.CombinedSimilarity-rv.CombinedSimilarity) > ThresholdEpsilon {
+					t.Errorf("%s: CombinedSimilarity %v and %v", tt.name, rw.CombinedSimilarity, rv.CombinedSimilarity)
+				}
+				if rw.WeightsUsed != tt.w.Normalized() || math.Abs(rw.WeightsUsed.NameWeight+rw.WeightsUsed.DescriptionWeight-1) > ThresholdEpsilon {
+					t.Errorf("%s: WeightsUsed %+v, want %+v", tt.name, rw.WeightsUsed, tt.w.Normalized())
+				}
+				// Normalization comes before combining: the combined score is
+				// the field scores weighted by WeightsUsed
+				want := rw.WeightsUsed.NameWeight*rw.NameSimilarity + rw.WeightsUsed.DescriptionWeight*rw.DescriptionSimilarity
+				if math.Abs(rw.CombinedSimilarity-want) > ThresholdEpsilon {
+					t.Errorf("%s: CombinedSimilarity %v, want %v", tt.name, rw.CombinedSimilarity, want)
+				}
+				// Weights don't change the field scores
+				if rw.NameSimilarity != rv.NameSimilarity || rw.DescriptionSimilarity != rv.DescriptionSimilarity {
+					t.Errorf("%s: field scores changed with the weights", tt.name)
+				}
+			}
+			// Compare uses the engine's weights, the defaults
+			if r, d := engine.Compare(a, b), engine.CompareWithWeights(a, b, DefaultWeights()); r.CombinedSimilarity != d.CombinedSimilarity {
+				t.Errorf("Compare %v, CompareWithWeights(DefaultWeights()) %v", r.CombinedSimilarity, d.CombinedSimilarity)
+			}
+		})
+	}
+}
+
+func TestSpecLegacyFields(t *testing.T) {
+	catalog := specCatalog()
+	for _, se := range specEngines() {
+		t.Run(se.name, func(t *testing.T) {
+			// byName returns the name-only scan of engines that have one
+			byName := func(engine DuplicateCheckEngine) []ComparisonResult {
+				if scanner, ok := engine.(interface {
+					FindDuplicatesByName([]Product, float64) []ComparisonResult
+				}); ok {
+					return scanner.FindDuplicatesByName(catalog, 0.5)
+				}
+				return nil
+			}
+
+			engine := se.new()
+			results := append([]ComparisonResult{engine.Compare(catalog[0], catalog[1])}, engine.FindDuplicates(catalog, 0.5)...)
+			named := byName(engine)
+			if len(named) == 0 {
+				t.Fatal("FindDuplicatesByName found no pairs")
+			}
+			results = append(results, named...)
+			for _, r := range results {
+				legacy := r.LegacyView()
+				if r.Similarity != r.CombinedSimilarity || r.Distance != r.NameDistance ||
+					legacy.Similarity != r.CombinedSimilarity || legacy.Distance != r.NameDistance {
+					t.Errorf("%s/%s: Similarity %v, Distance %d, LegacyView %+v; CombinedSimilarity %v, NameDistance %d",
+						r.ProductA.ID, r.ProductB.ID, r.Similarity, r.Distance, legacy, r.CombinedSimilarity, r.NameDistance)
+				}
+			}
+
+			// Without compatibility mode the legacy fields stay zero and
+			// thresholds still apply to CombinedSimilarity
+			quiet := se.new()
+			quiet.(interface{ DisableCompatibilityMode() }).DisableCompatibilityMode()
+			quietResults := append(quiet.FindDuplicates(catalog, 0.5), byName(quiet)...)
+			if len(quietResults) != len(results)-1 {
+				t.Errorf("%d results without compatibility mode, %d with it", len(quietResults), len(results)-1)
+			}
+			for _, r := range quietResults {
+				if r.Similarity != 0 || r.Distance != 0 || r.LegacyView().Similarity != r.CombinedSimilarity {
+					t.Errorf("%s/%s: legacy fields %v, %d without compatibility mode", r.ProductA.ID, r.ProductB.ID, r.Similarity, r.Distance)
+				}
+			}
+		})
+	}
+}
+
+func TestSpecThresholdBoundary(t *testing.T) {
+	// Each threshold runs three scans with every finder: keep the catalog
+	// small, spanning products 4 and 18 so a pair scores exactly 1
+	catalog := specCatalog()[4:19]
+	for _, se := range specEngines() {
+		scores := specScores(se.new(), catalog)
+		thresholds := append([]float64{0, 1}, boundaryScores(scores, 2)...)
+		for _, finder := range specFinders(se.new) {
+			t.Run(se.name+"/"+finder.name, func(t *testing.T) {
+				for _, threshold := range thresholds {
+					// Inclusive within ThresholdEpsilon: a pair scoring exactly
+					// the threshold, or a hair below it, is a match
+					for _, probe := range []float64{threshold, threshold + ThresholdEpsilon/2} {
+						if probe > 1 {
+							continue
+						}
+						checkSpecScan(t, se, finder.find(t, catalog, probe), scores, probe)
+					}
+					// A pair further below than ThresholdEpsilon is not
+					if above := threshold + 1e-6; above <= 1 {
+						checkSpecScan(t, se, finder.find(t, catalog, above), scores, above)
+					}
+				}
+			})
+		}
+	}
+}
+
+// checkSpecScan checks the results of a scan at threshold against the pair
+// scores Compare gives: each pair once, scored as Compare scores it, meeting
+// the threshold within ThresholdEpsilon, and, for complete engines, every
+// pair that does
+func checkSpecScan(t *testing.T, se specEngine, results []ComparisonResult, scores map[string]float64, threshold float64) {
+	t.Helper()
+	if results == nil {
+		t.Errorf("threshold %v: nil results", threshold)
+		return
+	}
+	found := make(map[string]bool, len(results))
+	for _, r := range results {
+		key := makePairKey(r.ProductA.ID, r.ProductB.ID)
+		score, ok := scores[key]
+		switch {
+		case !ok:
+			t.Errorf("threshold %v: %s isn't a pair of the catalog", threshold, key)
+		case found[key]:
+			t.Errorf("threshold %v: %s returned twice", threshold, key)
+		case math.Abs(r.CombinedSimilarity-score) > ThresholdEpsilon:
+			t.Errorf("threshold %v: %s scored %v, Compare gives %v", threshold, key, r.CombinedSimilarity, score)
+		case !meetsThreshold(r.CombinedSimilarity, threshold) || !r.MeetsThreshold || r.ThresholdUsed != threshold:
+			t.Errorf("threshold %v: %s scored %v, MeetsThreshold %v, ThresholdUsed %v",
+				threshold, key, r.CombinedSimilarity, r.MeetsThreshold, r.ThresholdUsed)
+		}
+		found[key] = true
+	}
+	if !se.complete {
+		return
+	}
+	var missing []string
+	for key, score := range scores {
+		if meetsThreshold(score, threshold) && !found[key] {
+			missing = append(missing, fmt.Sprintf("%s (%v)", key, score))
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		t.Errorf("threshold %v: %d pairs meeting it missing: %v", threshold, len(missing), missing)
+	}
+}
+
+func TestSpecMatchRuleBoundary(t *testing.T) {
+	// Rules compare within ThresholdEpsilon, as thresholds do
+	r := ComparisonResult{NameSimilarity: 0.85 - ThresholdEpsilon/2, DescriptionSimilarity: 0.85 - ThresholdEpsilon/2,
+		CombinedSimilarity: 0.85 - ThresholdEpsilon/2, NameInDescriptionAB: 0.85 - ThresholdEpsilon/2}
+	for name, rule := range map[string]MatchRule{
+		"NameAtLeast":              NameAtLeast(0.85),
+		"DescriptionAtLeast":       DescriptionAtLeast(0.85),
+		"CombinedAtLeast":          CombinedAtLeast(0.85),
+		"NameInDescriptionAtLeast": NameInDescriptionAtLeast(0.85),
+	} {
+		if !rule.Evaluate(r) {
+			t.Errorf("%s(0.85) rejects a score ThresholdEpsilon/2 below", name)
+		}
+		if rule.Evaluate(ComparisonResult{}) {
+			t.Errorf("%s(0.85) matches a zero result", name)
+		}
+	}
+}
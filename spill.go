@@ -0,0 +1,435 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// DefaultSpillBatchSize is the number of results held in memory per sorted run
+const DefaultSpillBatchSize = 100000
+
+// ResultRef is a compact ComparisonResult that references products by ID
+// It is what FindDuplicatesToFileSorted spills and writes, so memory and disk
+// use don't grow with description length.
+type ResultRef struct {
+	ProductAID            string  `json:"product_a"`
+	ProductBID            string  `json:"product_b"`
+	NameSimilarity        float64 `json:"name_similarity"`
+	DescriptionSimilarity float64 `json:"description_similarity"`
+	CombinedSimilarity    float64 `json:"combined_similarity"`
+}
+
+// rawResultRef is a ResultRef serialized unrounded, for spilled runs
+type rawResultRef ResultRef
+
+// MarshalJSON encodes the ref with similarities rounded to CanonicalDecimals,
+// so JSON output is the same on every platform; ResultRef fields stay raw
+func (r ResultRef) MarshalJSON() ([]byte, error) {
+	r.NameSimilarity = roundSimilarity(r.NameSimilarity)
+	r.DescriptionSimilarity = roundSimilarity(r.DescriptionSimilarity)
+	r.CombinedSimilarity = roundSimilarity(r.CombinedSimilarity)
+	return json.Marshal(rawResultRef(r))
+}
+
+// Ref returns the compact form of a result
+func (r *ComparisonResult) Ref() ResultRef {
+	return ResultRef{
+		ProductAID:            r.ProductA.ID,
+		ProductBID:            r.ProductB.ID,
+		NameSimilarity:        r.NameSimilarity,
+		DescriptionSimilarity: r.DescriptionSimilarity,
+		CombinedSimilarity:    r.CombinedSimilarity,
+	}
+}
+
+// ResultFileFormat selects the output layout of FindDuplicatesToFileSorted
+type ResultFileFormat int
+
+const (
+	// ResultFileJSONL writes one ResultRef JSON object per line
+	ResultFileJSONL ResultFileFormat = iota
+	// ResultFileCSV writes a header row, then one row per ResultRef
+	ResultFileCSV
+)
+
+// SpillOptions controls FindDuplicatesToFileSorted
+type SpillOptions struct {
+	// BatchSize is the number of results kept in memory before a sorted run
+	// is spilled to disk (default DefaultSpillBatchSize)
+	BatchSize int
+	// TempDir is where sorted runs are written (default os.TempDir())
+	TempDir string
+	// Format of the final output file (default ResultFileJSONL)
+	Format ResultFileFormat
+	// Context cancels the scan and merge; nil means context.Background()
+	Context context.Context
+}
+
+// FindDuplicatesToFileSorted writes every pair at or above threshold to path,
+// sorted by CombinedSimilarity descending (ties by product IDs), without holding
+// the full result set in memory.
+//
+// Matches are buffered in batches of opts.BatchSize, each batch is sorted and
+// spilled to a temporary run file, and the runs are merge-sorted into path.
+// Temporary files are removed on success, error, and cancellation; on failure
+// the partial output file is removed too.
+func (e *LevenshteinEngine) FindDuplicatesToFileSorted(products []Product, threshold float64, path string, opts SpillOptions) (err error) {
+	defer e.guard("FindDuplicatesToFileSorted", &err)
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = DefaultSpillBatchSize
+	}
+
+	products, collapsed, err := e.resolveProducts(products)
+	if err != nil {
+		return err
+	}
+	if err := e.checkScanLimits(products, threshold); err != nil {
+		return err
+	}
+
+	spiller, err := newResultSpiller(opts.TempDir, opts.BatchSize)
+	if err != nil {
+		return err
+	}
+	defer spiller.cleanup()
+
+	ptrs := productPtrs(products)
+	meter := e.startSummary(len(ptrs))
+	meter.summary.InputDuplicatesCollapsed = collapsed
+	warmCaches(ptrs, e.preparer())
+
+	var spillErr error
+	found := 0
+	streamPairs(len(ptrs), e.scanWorkers(len(ptrs), len(ptrs) > 50), func(i, j int) (ComparisonResult, bool) {
+		if !e.pairAllowed(ptrs[i], ptrs[j]) {
+			return ComparisonResult{}, false
+		}
+		result := e.compareAt(ptrs[i], ptrs[j], threshold)
+		result.stampThreshold(threshold)
+		return result, result.MeetsThreshold
+	}, func(result ComparisonResult) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		if spillErr = spiller.add(result.Ref()); spillErr != nil {
+			return false
+		}
+		found++
+		return true
+	})
+	if err := ctx.Err(); err != nil {
+		if e.logger != nil {
+			logScanInterrupted(e.logger, "levenshtein", err, found)
+		}
+		return err
+	}
+	if spillErr != nil {
+		return spillErr
+	}
+	e.recordScan(nil, len(ptrs))
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	header := resultFileHeader{ConfigFingerprint: e.ConfigFingerprint(), DeterministicMode: e.deterministic}
+	if err := spiller.writeSorted(ctx, out, opts.Format, header); err != nil {
+		return err
+	}
+	e.reportSummary(meter, found)
+	return nil
+}
+
+// refLess orders results by CombinedSimilarity descending, then by product IDs
+func refLess(a, b *ResultRef) bool {
+	if a.CombinedSimilarity != b.CombinedSimilarity {
+		return a.CombinedSimilarity > b.CombinedSimilarity
+	}
+	if a.ProductAID != b.ProductAID {
+		return a.ProductAID < b.ProductAID
+	}
+	return a.ProductBID < b.ProductBID
+}
+
+// resultSpiller buffers results and spills them to sorted run files
+type resultSpiller struct {
+	dir       string
+	batchSize int
+	batch     []ResultRef
+	runs      []string // Paths of sorted run files, in creation order
+}
+
+// newResultSpiller creates a private temporary directory for run files
+func newResultSpiller(tempDir string, batchSize int) (*resultSpiller, error) {
+	dir, err := os.MkdirTemp(tempDir, "duplicatecheck-spill-*")
+	if err != nil {
+		return nil, err
+	}
+	return &resultSpiller{dir: dir, batchSize: batchSize}, nil
+}
+
+// add buffers one result, spilling a sorted run when the batch is full
+func (s *resultSpiller) add(ref ResultRef) error {
+	s.batch = append(s.batch, ref)
+	if len(s.batch) >= s.batchSize {
+		return s.flush()
+	}
+	return nil
+}
+
+// sortBatch sorts the in-memory batch
+func (s *resultSpiller) sortBatch() {
+	sort.Slice(s.batch, func(i, j int) bool { return refLess(&s.batch[i], &s.batch[j]) })
+}
+
+// flush sorts the in-memory batch and writes it as a new run file
+func (s *resultSpiller) flush() error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+	s.sortBatch()
+
+	path := filepath.Join(s.dir, fmt.Sprintf("run-%06d.jsonl", len(s.runs)))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, path)
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for i := range s.batch {
+		// Runs keep raw scores: the merge compares them as each run was sorted
+		if err := enc.Encode((*rawResultRef)(&s.batch[i])); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	s.batch = s.batch[:0]
+	return f.Close()
+}
+
+// cleanup removes every run file
+func (s *resultSpiller) cleanup() {
+	os.RemoveAll(s.dir)
+}
+
+// writeSorted writes all buffered and spilled results to w in sorted order,
+// under header
+func (s *resultSpiller) writeSorted(ctx context.Context, w io.Writer, format ResultFileFormat, header resultFileHeader) error {
+	out, err := newResultWriter(w, format, header)
+	if err != nil {
+		return err
+	}
+
+	// Everything fit in one batch: no merge needed
+	if len(s.runs) == 0 {
+		s.sortBatch()
+		for i := range s.batch {
+			if err := out.write(&s.batch[i]); err != nil {
+				return err
+			}
+		}
+		return out.flush()
+	}
+
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	// k-way merge of the sorted runs
+	merge := make(runHeap, 0, len(s.runs))
+	for _, path := range s.runs {
+		f, err := os.Open(path)
+		if err != nil {
+			merge.close()
+			return err
+		}
+		run := &runReader{file: f, dec: json.NewDecoder(bufio.NewReader(f))}
+		ok, err := run.next()
+		if err != nil {
+			f.Close()
+			merge.close()
+			return err
+		}
+		if ok {
+			merge = append(merge, run)
+		} else {
+			f.Close()
+		}
+	}
+	defer merge.close()
+	heap.Init(&merge)
+
+	for written := 0; merge.Len() > 0; written++ {
+		if written%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		run := merge[0]
+		if err := out.write(&run.current); err != nil {
+			return err
+		}
+		ok, err := run.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&merge, 0)
+		} else {
+			run.file.Close()
+			heap.Pop(&merge)
+		}
+	}
+	return out.flush()
+}
+
+// runReader reads one sorted run file
+type runReader struct {
+	file    *os.File
+	dec     *json.Decoder
+	current ResultRef
+}
+
+// next advances to the following record; returns false at the end of the run
+func (r *runReader) next() (bool, error) {
+	r.current = ResultRef{}
+	if err := r.dec.Decode(&r.current); err != nil {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// runHeap orders run readers by their current record (heap.Interface)
+type runHeap []*runReader
+
+func (h runHeap) Len() int            { return len(h) }
+func (h runHeap) Less(i, j int) bool  { return refLess(&h[i].current, &h[j].current) }
+func (h runHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(*runReader)) }
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	run := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return run
+}
+
+// close closes every open run file
+func (h runHeap) close() {
+	for _, run := range h {
+		run.file.Close()
+	}
+}
+
+// resultWriter encodes ResultRefs in the requested output format
+type resultWriter struct {
+	buf  *bufio.Writer
+	json *json.Encoder
+	csv  *csv.Writer
+}
+
+// resultFileHeader is the first JSONL record of a results file written with
+// a config fingerprint
+type resultFileHeader struct {
+	ConfigFingerprint string `json:"config_fingerprint"`
+	// DeterministicMode is set when the scan ran in deterministic mode
+	DeterministicMode bool `json:"deterministic_mode,omitempty"`
+}
+
+// Comment lines of a CSV results file, above its header row, holding the
+// config fingerprint and, for deterministic scans, the mode
+const (
+	csvFingerprintPrefix    = "# config_fingerprint: "
+	csvDeterministicComment = "# deterministic_mode: true"
+)
+
+// newResultWriter prepares a writer; CSV output starts with a header row
+// A header with a fingerprint is written first: as a resultFileHeader record
+// in JSONL, as comment lines in CSV.
+func newResultWriter(w io.Writer, format ResultFileFormat, header resultFileHeader) (*resultWriter, error) {
+	buf := bufio.NewWriter(w)
+	switch format {
+	case ResultFileJSONL:
+		enc := json.NewEncoder(buf)
+		if header.ConfigFingerprint != "" {
+			if err := enc.Encode(header); err != nil {
+				return nil, err
+			}
+		}
+		return &resultWriter{buf: buf, json: enc}, nil
+	case ResultFileCSV:
+		if header.ConfigFingerprint != "" {
+			comments := csvFingerprintPrefix + header.ConfigFingerprint + "\n"
+			if header.DeterministicMode {
+				comments += csvDeterministicComment + "\n"
+			}
+			if _, err := buf.WriteString(comments); err != nil {
+				return nil, err
+			}
+		}
+		cw := csv.NewWriter(buf)
+		header := []string{"product_a", "product_b", "combined_similarity", "name_similarity", "description_similarity"}
+		if err := cw.Write(header); err != nil {
+			return nil, err
+		}
+		return &resultWriter{buf: buf, csv: cw}, nil
+	default:
+		return nil, fmt.Errorf("duplicatecheck: unknown result file format %d", format)
+	}
+}
+
+// write encodes one record
+func (w *resultWriter) write(ref *ResultRef) error {
+	if w.json != nil {
+		return w.json.Encode(ref)
+	}
+	return w.csv.Write([]string{
+		ref.ProductAID,
+		ref.ProductBID,
+		strconv.FormatFloat(ref.CombinedSimilarity, 'f', -1, 64),
+		strconv.FormatFloat(ref.NameSimilarity, 'f', -1, 64),
+		strconv.FormatFloat(ref.DescriptionSimilarity, 'f', -1, 64),
+	})
+}
+
+// flush writes any buffered output
+func (w *resultWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return w.buf.Flush()
+}
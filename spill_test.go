@@ -0,0 +1,296 @@
+package duplicatecheck
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"testing"
+	"time"
+)
+
+// generateSpillCatalog builds products with short random names so a low
+// threshold matches most pairs
+func generateSpillCatalog(n int) []Product {
+	rng := rand.New(rand.NewSource(3))
+	letters := "abcd"
+	products := make([]Product, n)
+	for i := range products {
+		name := make([]byte, 8)
+		for j := range name {
+			name[j] = letters[rng.Intn(len(letters))]
+		}
+		products[i] = Product{ID: fmt.Sprintf("p%04d", i), Name: string(name)}
+	}
+	return products
+}
+
+// readResultRefs parses a JSONL results file: the config fingerprint header,
+// then one ResultRef per line
+func readResultRefs(t *testing.T, path string) []ResultRef {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open output: %v", err)
+	}
+	defer f.Close()
+
+	var refs []ResultRef
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return nil
+	}
+	var header resultFileHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.ConfigFingerprint == "" {
+		t.Fatalf("Invalid header %q: %v", scanner.Text(), err)
+	}
+	for scanner.Scan() {
+		var ref ResultRef
+		if err := json.Unmarshal(scanner.Bytes(), &ref); err != nil {
+			t.Fatalf("Invalid line %q: %v", scanner.Text(), err)
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// assertDirEmpty fails if dir holds any entries
+func assertDirEmpty(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected temp dir to be cleaned up, found %d entries", len(entries))
+	}
+}
+
+func TestFindDuplicatesToFileSorted(t *testing.T) {
+	products := generateSpillCatalog(120)
+	engine := NewLevenshteinEngine()
+	const threshold = 0.3
+
+	want := engine.FindDuplicates(products, threshold)
+	if len(want) < 2000 {
+		t.Fatalf("Test catalog should produce many pairs, got %d", len(want))
+	}
+
+	t.Run("Multiple runs merge in global order", func(t *testing.T) {
+		tempDir := t.TempDir()
+		out := filepath.Join(t.TempDir(), "results.jsonl")
+
+		// 500 per batch forces several spilled runs
+		err := engine.FindDuplicatesToFileSorted(products, threshold, out, SpillOptions{BatchSize: 500, TempDir: tempDir})
+		if err != nil {
+			t.Fatalf("FindDuplicatesToFileSorted failed: %v", err)
+		}
+		assertDirEmpty(t, tempDir)
+
+		refs := readResultRefs(t, out)
+		if len(refs) != len(want) {
+			t.Fatalf("Expected %d results, got %d", len(want), len(refs))
+		}
+		for i := 1; i < len(refs); i++ {
+			if refLess(&refs[i], &refs[i-1]) {
+				t.Fatalf("Output not sorted at line %d: %.4f after %.4f", i, refs[i].CombinedSimilarity, refs[i-1].CombinedSimilarity)
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, ref := range refs {
+			seen[makePairKey(ref.ProductAID, ref.ProductBID)] = true
+		}
+		for _, r := range want {
+			if !seen[makePairKey(r.ProductA.ID, r.ProductB.ID)] {
+				t.Fatalf("Missing pair %s/%s", r.ProductA.ID, r.ProductB.ID)
+			}
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "results.csv")
+		err := engine.FindDuplicatesToFileSorted(products, threshold, out, SpillOptions{BatchSize: 700, Format: ResultFileCSV})
+		if err != nil {
+			t.Fatalf("FindDuplicatesToFileSorted failed: %v", err)
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		reader := csv.NewReader(f)
+		reader.Comment = '#' // The config fingerprint line
+		rows, err := reader.ReadAll()
+		if err != nil {
+			t.Fatalf("Invalid CSV: %v", err)
+		}
+		if rows[0][0] != "product_a" || len(rows)-1 != len(want) {
+			t.Errorf("Expected header and %d rows, got %d rows starting with %v", len(want), len(rows)-1, rows[0])
+		}
+	})
+
+	t.Run("Single batch", func(t *testing.T) {
+		tempDir := t.TempDir()
+		out := filepath.Join(t.TempDir(), "results.jsonl")
+		if err := engine.FindDuplicatesToFileSorted(products, threshold, out, SpillOptions{TempDir: tempDir}); err != nil {
+			t.Fatalf("FindDuplicatesToFileSorted failed: %v", err)
+		}
+		assertDirEmpty(t, tempDir)
+		if refs := readResultRefs(t, out); len(refs) != len(want) {
+			t.Errorf("Expected %d results, got %d", len(want), len(refs))
+		}
+	})
+
+	t.Run("Cancellation cleans up", func(t *testing.T) {
+		tempDir := t.TempDir()
+		out := filepath.Join(t.TempDir(), "results.jsonl")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := engine.FindDuplicatesToFileSorted(products, threshold, out, SpillOptions{BatchSize: 100, TempDir: tempDir, Context: ctx})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+		assertDirEmpty(t, tempDir)
+		if _, err := os.Stat(out); !os.IsNotExist(err) {
+			t.Error("No output should be written after cancellation")
+		}
+	})
+
+	t.Run("Error cleans up", func(t *testing.T) {
+		tempDir := t.TempDir()
+		out := filepath.Join(t.TempDir(), "missing", "results.jsonl") // parent doesn't exist
+		err := engine.FindDuplicatesToFileSorted(products, threshold, out, SpillOptions{BatchSize: 500, TempDir: tempDir})
+		if err == nil {
+			t.Fatal("Expected an error creating the output file")
+		}
+		assertDirEmpty(t, tempDir)
+	})
+}
+
+// peakHeapDuring samples HeapAlloc while fn runs and returns the peak growth
+func peakHeapDuring(fn func()) uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	base := stats.HeapAlloc
+
+	var peak uint64
+	var mu sync.Mutex
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			var s runtime.MemStats
+			runtime.ReadMemStats(&s)
+			mu.Lock()
+			if s.HeapAlloc > base && s.HeapAlloc-base > peak {
+				peak = s.HeapAlloc - base
+			}
+			mu.Unlock()
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	fn()
+	close(done)
+	wg.Wait()
+	return peak
+}
+
+func TestFindDuplicatesToFileSortedMemoryBound(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping memory measurement in short mode")
+	}
+
+	// Collect aggressively so HeapAlloc tracks live data, not garbage
+	defer debug.SetGCPercent(debug.SetGCPercent(5))
+
+	products := generateSpillCatalog(180)
+	engine := NewLevenshteinEngine()
+	const threshold = 0.3
+
+	var matches int
+	inMemoryPeak := peakHeapDuring(func() {
+		matches = len(engine.FindDuplicates(products, threshold))
+	})
+
+	out := filepath.Join(t.TempDir(), "results.jsonl")
+	spillPeak := peakHeapDuring(func() {
+		if err := engine.FindDuplicatesToFileSorted(products, threshold, out, SpillOptions{BatchSize: 1000}); err != nil {
+			t.Fatalf("FindDuplicatesToFileSorted failed: %v", err)
+		}
+	})
+
+	t.Logf("%d matches: in-memory peak %d KB, spilled peak %d KB (batch of 1000)", matches, inMemoryPeak/1024, spillPeak/1024)
+	if spillPeak*4 > inMemoryPeak {
+		t.Errorf("Spilled heap growth %d KB should stay far below in-memory %d KB", spillPeak/1024, inMemoryPeak/1024)
+	}
+}
+
+func TestResultRoundingOutput(t *testing.T) {
+	result := ComparisonResult{
+		ProductA:              Product{ID: "a"},
+		ProductB:              Product{ID: "b"},
+		NameSimilarity:        0.8499999999999999,
+		DescriptionSimilarity: 0.123456789,
+		CombinedSimilarity:    0.8499999999999999,
+		ThresholdUsed:         0.85,
+		MeetsThreshold:        true,
+	}
+
+	// JSON rounds to CanonicalDecimals; the ref itself stays raw
+	ref := result.Ref()
+	data, err := json.Marshal(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"product_a":"a","product_b":"b","name_similarity":0.85,"description_similarity":0.123457,"combined_similarity":0.85}`
+	if string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+	if ref.CombinedSimilarity != result.CombinedSimilarity {
+		t.Errorf("ref rounded to %v", ref.CombinedSimilarity)
+	}
+
+	tests := []struct {
+		decimals           int
+		combined, describe float64
+	}{
+		{-1, 0.8499999999999999, 0.123456789},
+		{0, 1, 0},
+		{2, 0.85, 0.12},
+		{6, 0.85, 0.123457},
+	}
+	for _, tt := range tests {
+		rounded := result.Rounded(tt.decimals)
+		if rounded.CombinedSimilarity != tt.combined || rounded.DescriptionSimilarity != tt.describe {
+			t.Errorf("Rounded(%d) = %v, %v; want %v, %v", tt.decimals, rounded.CombinedSimilarity, rounded.DescriptionSimilarity, tt.combined, tt.describe)
+		}
+		if !rounded.MeetsThreshold {
+			t.Errorf("Rounded(%d) changed the threshold decision", tt.decimals)
+		}
+	}
+	results := []ComparisonResult{result}
+	RoundResults(results, 3)
+	if results[0].NameSimilarity != 0.85 {
+		t.Errorf("RoundResults left %v", results[0].NameSimilarity)
+	}
+}
@@ -0,0 +1,180 @@
+package duplicatecheck
+
+import (
+	"context"
+	"sync"
+)
+
+// FindDuplicatesChan is FindDuplicates delivering its matches on a channel
+// holding up to buffer results, for consumers ranging over it with their own
+// concurrency
+// A full channel blocks the scan: a slow consumer slows the scan down rather
+// than results piling up, so at most buffer results plus a few per worker
+// are in flight. The error channel delivers at most one error: the input's
+// *DuplicateIDError, a *ScanLimitError (see WithScanLimits), ctx.Err() when
+// ctx is done before the scan ends, or a *PanicError when the scan panicked. Both channels are closed once the scan has stopped
+// and its goroutines have exited; cancelling ctx is how a consumer that stops
+// reading early ends the scan. VariantsSeparate sends the variants last.
+func (e *LevenshteinEngine) FindDuplicatesChan(ctx context.Context, products []Product, threshold float64, buffer int) (<-chan ComparisonResult, <-chan error) {
+	return streamChan(ctx, buffer, e, func(ctx context.Context, yield func(ComparisonResult) bool) (*scanMeter, error) {
+		resolved, collapsed, err := e.resolveProducts(products)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.checkScanLimits(resolved, threshold); err != nil {
+			return nil, err
+		}
+		meter := e.startSummary(len(resolved))
+		meter.summary.InputDuplicatesCollapsed = collapsed
+		e.streamScan(ctx, ctx.Done(), productPtrs(resolved), threshold, nil, yield)
+		return meter, nil
+	})
+}
+
+// FindDuplicatesChan is FindDuplicates delivering its matches on a channel
+// (see LevenshteinEngine.FindDuplicatesChan)
+// ctx also stops candidate generation, checked before each product's lookup.
+func (e *HybridEngine) FindDuplicatesChan(ctx context.Context, products []Product, threshold float64, buffer int) (<-chan ComparisonResult, <-chan error) {
+	return streamChan(ctx, buffer, e.levenshteinEngine, func(ctx context.Context, yield func(ComparisonResult) bool) (*scanMeter, error) {
+		resolved, collapsed, err := e.resolveProducts(products)
+		if err != nil {
+			return nil, err
+		}
+		meter := e.startSummary(len(resolved))
+		meter.summary.InputDuplicatesCollapsed = collapsed
+		e.streamScan(ctx, productPtrs(resolved), threshold, nil, yield)
+		return meter, nil
+	})
+}
+
+// streamChan runs scan on its own goroutine, sending what it yields on the
+// returned result channel, and its error (or ctx's) on the error channel
+// A panic in the scan, on a worker or the scan goroutine, cancels the scan
+// and is reported as its error, a *PanicError counted in PanicsRecovered. Once the scan has ended, the meter it returns
+// reports the results sent to the WithScanSummary hook.
+func streamChan(ctx context.Context, buffer int, e *LevenshteinEngine, scan func(ctx context.Context, yield func(ComparisonResult) bool) (*scanMeter, error)) (<-chan ComparisonResult, <-chan error) {
+	if buffer < 0 {
+		buffer = 0
+	}
+	results := make(chan ComparisonResult, buffer)
+	errs := make(chan error, 1) // Never blocks the one send
+
+	go func() {
+		defer close(errs)
+		defer close(results)
+		if err := runStream(ctx, results, e, scan); err != nil {
+			errs <- err
+		}
+	}()
+	return results, errs
+}
+
+// runStream is the goroutine of streamChan, returning the error to report
+// Safe mode recovers a panic outside the scan as well, such as in the
+// WithScanSummary hook or the notifier.
+func runStream(ctx context.Context, results chan<- ComparisonResult, e *LevenshteinEngine, scan func(ctx context.Context, yield func(ComparisonResult) bool) (*scanMeter, error)) (err error) {
+	defer e.guard("FindDuplicatesChan", &err)
+	scanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var failure error
+	var failOnce sync.Once
+	fail := func(recovered any) {
+		failOnce.Do(func() {
+			panicked := newPanicError(recovered)
+			if panicked.Op == "" {
+				panicked.Op = "FindDuplicatesChan"
+			}
+			panicsRecovered.Add(1)
+			failure = panicked
+			cancel()
+		})
+	}
+
+	sent := 0
+	notify := e.startNotify()
+	defer notify.flush()
+	send := func(result ComparisonResult) bool {
+		select {
+		case results <- result:
+			sent++
+			notify.add(&result)
+			return true
+		case <-scanCtx.Done():
+			return false
+		}
+	}
+	yield, flush := e.streamVariants(send)
+
+	var meter *scanMeter
+	err = func() (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				fail(recovered)
+			}
+		}()
+		meter, err = scan(context.WithValue(scanCtx, scanFailureKey{}, fail), yield)
+		return err
+	}()
+	if err == nil && failure == nil {
+		flush()
+	}
+	if meter != nil {
+		e.reportSummary(meter, sent)
+	}
+	switch {
+	case err != nil:
+		return err
+	case failure != nil:
+		return failure
+	}
+	return ctx.Err()
+}
+
+// scanFailureKey is the context key of a channel scan's failure handler
+type scanFailureKey struct{}
+
+// scanFailure returns the failure handler streamChan put in ctx, or nil
+func scanFailure(ctx context.Context) func(any) {
+	fail, _ := ctx.Value(scanFailureKey{}).(func(any))
+	return fail
+}
+
+// recoverPairs wraps a worker's evaluate so a panic fails the scan through
+// fail, cancelling it at once rather than once the workers finish; the pair is dropped
+func recoverPairs(evaluate func(i, j int, scratch *comparisonScratch) (ComparisonResult, bool), fail func(any)) func(i, j int, scratch *comparisonScratch) (ComparisonResult, bool) {
+	return func(i, j int, scratch *comparisonScratch) (result ComparisonResult, keep bool) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				fail(recovered)
+				result, keep = ComparisonResult{}, false
+			}
+		}()
+		return evaluate(i, j, scratch)
+	}
+}
+
+// streamVariants applies VariantOptions.Output to streamed results: yield
+// drops or holds back variants, and flush sends the held ones
+func (e *LevenshteinEngine) streamVariants(send func(ComparisonResult) bool) (yield func(ComparisonResult) bool, flush func()) {
+	if e.variants == nil || e.variants.Output == VariantsInclude {
+		return send, func() {}
+	}
+	var variants []ComparisonResult
+	yield = func(result ComparisonResult) bool {
+		if result.MatchType != MatchVariant {
+			return send(result)
+		}
+		if e.variants.Output == VariantsSeparate {
+			variants = append(variants, result)
+		}
+		return true
+	}
+	flush = func() {
+		for _, result := range variants {
+			if !send(result) {
+				return
+			}
+		}
+	}
+	return yield, flush
+}
@@ -0,0 +1,170 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// drainChan collects every result of a channel scan and its error
+func drainChan(results <-chan ComparisonResult, errs <-chan error) ([]ComparisonResult, error) {
+	var collected []ComparisonResult
+	for result := range results {
+		collected = append(collected, result)
+	}
+	return collected, <-errs
+}
+
+// sortedPairKeys returns the pair keys of results, sorted
+func sortedPairKeys(results []ComparisonResult) []string {
+	keys := make([]string, len(results))
+	for i, r := range results {
+		keys[i] = makePairKey(r.ProductA.ID, r.ProductB.ID)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestFindDuplicatesChanMatchesSlice(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, 100)
+	hybrid := NewHybridEngine()
+	if err := hybrid.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	engines := []struct {
+		name  string
+		slice func([]Product, float64) []ComparisonResult
+		chans func([]Product, int) (<-chan ComparisonResult, <-chan error)
+	}{
+		{"levenshtein", NewLevenshteinEngine().FindDuplicates,
+			func(p []Product, buffer int) (<-chan ComparisonResult, <-chan error) {
+				return NewLevenshteinEngine().FindDuplicatesChan(context.Background(), p, 0.8, buffer)
+			}},
+		{"hybrid", hybrid.FindDuplicates,
+			func(p []Product, buffer int) (<-chan ComparisonResult, <-chan error) {
+				return hybrid.FindDuplicatesChan(context.Background(), p, 0.8, buffer)
+			}},
+	}
+	for _, engine := range engines {
+		for _, input := range [][]Product{products, products[:20]} { // Parallel and sequential
+			want := sortedPairKeys(engine.slice(input, 0.8))
+			for _, buffer := range []int{0, 1, 64} {
+				got, err := drainChan(engine.chans(input, buffer))
+				if err != nil {
+					t.Fatalf("%s: %v", engine.name, err)
+				}
+				if keys := sortedPairKeys(got); strings.Join(keys, ",") != strings.Join(want, ",") {
+					t.Errorf("%s, %d products, buffer %d: %d results, want the slice API's %d", engine.name, len(input), buffer, len(keys), len(want))
+				}
+			}
+		}
+	}
+}
+
+func TestFindDuplicatesChanBackPressure(t *testing.T) {
+	// Every pair matches: without back-pressure the scan would race ahead
+	products := make([]Product, 120)
+	for i := range products {
+		products[i] = Product{ID: string(rune('a'+i%26)) + strings.Repeat("x", i/26), Name: "Oak Desk Lamp", Description: "Brass"}
+	}
+	var evaluated int64
+	engine := NewLevenshteinEngine().WithPairConstraint(func(a, b Product) bool {
+		atomic.AddInt64(&evaluated, 1)
+		return true
+	})
+	const workers, buffer = 2, 4
+	engine.SetMaxWorkers(workers)
+
+	results, errs := engine.FindDuplicatesChan(context.Background(), products, 0.9, buffer)
+	received := int64(0)
+	for range results {
+		received++
+		if received%1000 == 1 {
+			// A slow consumer: the scan waits for it
+			time.Sleep(50 * time.Millisecond)
+			// Results queued: the channel, the stage queue (2 per worker),
+			// one per worker and one being sent
+			if inFlight := atomic.LoadInt64(&evaluated) - received; inFlight > buffer+3*workers+1 {
+				t.Errorf("after %d results, %d pairs evaluated: %d in flight", received, atomic.LoadInt64(&evaluated), inFlight)
+			}
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(len(products) * (len(products) - 1) / 2); received != want {
+		t.Errorf("received %d results, want %d", received, want)
+	}
+}
+
+func TestFindDuplicatesChanCancel(t *testing.T) {
+	products := GenerateTestCatalog(goldenCatalogSeed, 400)
+	hybrid := NewHybridEngine()
+	if err := hybrid.BuildIndex(products); err != nil {
+		t.Fatal(err)
+	}
+	scans := map[string]func(context.Context) (<-chan ComparisonResult, <-chan error){
+		"levenshtein": func(ctx context.Context) (<-chan ComparisonResult, <-chan error) {
+			return NewLevenshteinEngine().FindDuplicatesChan(ctx, products, 0.5, 0)
+		},
+		"hybrid": func(ctx context.Context) (<-chan ComparisonResult, <-chan error) {
+			return hybrid.FindDuplicatesChan(ctx, products, 0.5, 0)
+		},
+	}
+	for name, scan := range scans {
+		t.Run(name, func(t *testing.T) {
+			before := runtime.NumGoroutine()
+			ctx, cancel := context.WithCancel(context.Background())
+			results, errs := scan(ctx)
+			<-results // The consumer reads one result, then walks away
+			cancel()
+
+			// Blocked sends give up, and both channels close
+			if err := <-errs; !errors.Is(err, context.Canceled) {
+				t.Errorf("error %v, want context.Canceled", err)
+			}
+			for range results {
+			}
+			deadline := time.Now().Add(2 * time.Second)
+			for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if after := runtime.NumGoroutine(); after > before {
+				t.Errorf("%d goroutines before the scan, %d after cancelling it", before, after)
+			}
+		})
+	}
+}
+
+func TestFindDuplicatesChanErrors(t *testing.T) {
+	// Repeated IDs under the default DuplicateIDReject
+	repeated := []Product{{ID: "1", Name: "Lamp"}, {ID: "1", Name: "Desk Lamp"}}
+	results, errs := NewLevenshteinEngine().FindDuplicatesChan(context.Background(), repeated, 0.8, 0)
+	if got, err := drainChan(results, errs); len(got) != 0 || !errors.As(err, new(*DuplicateIDError)) {
+		t.Errorf("repeated IDs: %d results, error %v", len(got), err)
+	}
+
+	// A failing worker fails the scan instead of the process
+	for _, n := range []int{20, 200} {
+		engine := NewLevenshteinEngine().WithPairConstraint(func(a, b Product) bool {
+			panic("constraint failed")
+		})
+		got, err := drainChan(engine.FindDuplicatesChan(context.Background(), GenerateTestCatalog(goldenCatalogSeed, n), 0.8, 0))
+		if err == nil || !strings.Contains(err.Error(), "constraint failed") || len(got) != 0 {
+			t.Errorf("%d products with a panicking constraint: %d results, error %v", n, len(got), err)
+		}
+	}
+
+	// Already cancelled: nothing is sent
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, err := drainChan(NewLevenshteinEngine().FindDuplicatesChan(ctx, GenerateTestCatalog(goldenCatalogSeed, 200), 0.8, 0))
+	if !errors.Is(err, context.Canceled) || len(got) != 0 {
+		t.Errorf("cancelled context: %d results, error %v", len(got), err)
+	}
+}
@@ -0,0 +1,95 @@
+package duplicatecheck
+
+// StringComparer scores plain strings, such as addresses or ticket subjects,
+// with the engines' option stack and no Product or engine around them
+// Strings are scored exactly as LevenshteinEngine scores product names under
+// the same options: it runs on the engine's own name code, so the two APIs
+// can't drift apart. Safe for concurrent use.
+type StringComparer struct {
+	engine *LevenshteinEngine
+}
+
+// StringCompareOption configures a StringComparer (see NewStringComparer)
+type StringCompareOption func(*StringComparer)
+
+// NewStringComparer returns a comparer with the given options
+// Without options strings are lowercased and trimmed, and scored by linear
+// rune-based Levenshtein similarity.
+func NewStringComparer(opts ...StringCompareOption) *StringComparer {
+	c := &StringComparer{engine: NewLevenshteinEngine()}
+	// Rabin-Karp rejects dissimilar long names without a score; a comparer
+	// always reports one
+	c.engine.DisableRabinKarpFilter()
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// StringPreparation prepares both strings like product names under prep
+// (markup, accents, whitespace, synonyms); MaxDescriptionLength doesn't apply
+func StringPreparation(prep TextPreparation) StringCompareOption {
+	return func(c *StringComparer) {
+		c.engine.WithTextPreparation(prep)
+	}
+}
+
+// StringDistanceOptions sets how distances are measured and turned into
+// similarities (grapheme mode, SimilarityMode and its tuning)
+// MaxComparisonDuration doesn't apply: names are never budgeted.
+func StringDistanceOptions(opts LevenshteinOptions) StringCompareOption {
+	return func(c *StringComparer) {
+		c.engine.SetOptions(opts)
+	}
+}
+
+// StringDeobfuscation scores strings written with look-alike characters as
+// their de-obfuscated forms when that scores higher (see WithDeobfuscation)
+func StringDeobfuscation(config *DeobfuscationConfig) StringCompareOption {
+	return func(c *StringComparer) {
+		c.engine.WithDeobfuscation(config)
+	}
+}
+
+// prepare returns a and b prepared as product names
+func (c *StringComparer) prepare(a, b string) (string, string) {
+	prep := c.engine.preparer().options
+	return prep.prepareText(a), prep.prepareText(b)
+}
+
+// Similarity returns the similarity of a and b [0.0-1.0]
+// With StringDeobfuscation it is the de-obfuscated similarity when higher.
+func (c *StringComparer) Similarity(a, b string) float64 {
+	preparedA, preparedB := c.prepare(a, b)
+	score := c.engine.scoreNamePair(preparedA, preparedB, nil)
+	if score.obfuscated {
+		return score.deobfuscated
+	}
+	return score.similarity
+}
+
+// Distance returns the edit distance between the prepared a and b
+func (c *StringComparer) Distance(a, b string) int {
+	preparedA, preparedB := c.prepare(a, b)
+	return c.engine.computeDistance(preparedA, preparedB)
+}
+
+// SimilarityWithin returns the similarity of a and b and whether it reaches
+// threshold
+// Like FindDuplicatesByName it rejects pairs by length and stops the distance
+// early once the threshold is out of reach, so rejecting a dissimilar pair
+// costs far less than Similarity; a rejected pair's similarity is reported as
+// 0. With StringDeobfuscation the full similarity is computed.
+func (c *StringComparer) SimilarityWithin(a, b string, threshold float64) (float64, bool) {
+	if c.engine.deobfuscation != nil {
+		similarity := c.Similarity(a, b)
+		if !meetsThreshold(similarity, threshold) {
+			return 0, false
+		}
+		return similarity, true
+	}
+	preparedA, preparedB := c.prepare(a, b)
+	_, similarity, ok := c.engine.nameWithin(preparedA, preparedB,
+		c.engine.textLength(preparedA), c.engine.textLength(preparedB), threshold)
+	return similarity, ok
+}
@@ -0,0 +1,136 @@
+package duplicatecheck
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestStringComparer(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []StringCompareOption
+		a, b       string
+		similarity float64
+		distance   int
+	}{
+		{"defaults", nil, "  12 Main Street ", "12 main st", 1 - 4.0/14, 4},
+		{"identical after preparation", []StringCompareOption{StringPreparation(TextPreparation{FoldAccents: true, CollapseWhitespace: true})},
+			"Rue  de la Paix, Málaga", "rue de la paix, malaga", 1.0, 0},
+		{"synonyms", []StringCompareOption{StringPreparation(TextPreparation{Synonyms: streetSynonyms()})},
+			"12 Main Street", "12 main st", 1.0, 0},
+		{"graphemes", []StringCompareOption{StringDistanceOptions(LevenshteinOptions{GraphemeMode: true})},
+			"café", "cafe", 0.75, 1},
+		{"deobfuscation", []StringCompareOption{StringDeobfuscation(&DeobfuscationConfig{Watchlist: []string{"Nike"}})},
+			"N1ke shoes", "nike shoes", 1.0, 1},
+		{"empty", nil, "", "  ", 1.0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewStringComparer(tt.opts...)
+			if got := c.Similarity(tt.a, tt.b); !approxEqual(got, tt.similarity) {
+				t.Errorf("Similarity = %v, want %v", got, tt.similarity)
+			}
+			if got := c.Distance(tt.a, tt.b); got != tt.distance {
+				t.Errorf("Distance = %d, want %d", got, tt.distance)
+			}
+			if got, ok := c.SimilarityWithin(tt.a, tt.b, tt.similarity); !ok || !approxEqual(got, tt.similarity) {
+				t.Errorf("SimilarityWithin(%v) = %v, %v", tt.similarity, got, ok)
+			}
+			if tt.similarity < 1 {
+				if got, ok := c.SimilarityWithin(tt.a, tt.b, tt.similarity+0.01); ok || got != 0 {
+					t.Errorf("SimilarityWithin above the similarity = %v, %v; want 0, false", got, ok)
+				}
+			}
+		})
+	}
+}
+
+// streetSynonyms maps street-type abbreviations to their full forms
+func streetSynonyms() *SynonymDictionary {
+	dict := NewSynonymDictionary()
+	dict.Add("street", "st")
+	dict.Add("avenue", "ave", "av")
+	return dict
+}
+
+// approxEqual reports whether two similarities agree to 1e-9
+func approxEqual(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
+
+// TestStringComparerMatchesEngine is a differential test: under matching
+// options, StringComparer must score every pair of a corpus exactly as
+// LevenshteinEngine scores the same strings as product names
+func TestStringComparerMatchesEngine(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 60)
+	rng := rand.New(rand.NewSource(7))
+	var pairs [][2]string
+	for i := 0; i < len(catalog); i++ {
+		pairs = append(pairs, [2]string{catalog[i].Name, catalog[rng.Intn(len(catalog))].Name})
+		if i > 0 {
+			pairs = append(pairs, [2]string{catalog[i].Name, catalog[i-1].Name})
+		}
+	}
+	pairs = append(pairs, [2]string{"N1ke A!r Max", "Nike Air Max"}, [2]string{"Café <b>Crème</b>", "cafe creme"})
+
+	configs := []struct {
+		name    string
+		prep    TextPreparation
+		options LevenshteinOptions
+		deob    *DeobfuscationConfig
+	}{
+		{"defaults", DefaultTextPreparation(), DefaultLevenshteinOptions(), nil},
+		{"preparation", TextPreparation{StripHTML: true, FoldAccents: true, CollapseWhitespace: true, Synonyms: DefaultSynonyms()}, DefaultLevenshteinOptions(), nil},
+		{"graphemes", DefaultTextPreparation(), LevenshteinOptions{GraphemeMode: true}, nil},
+		{"length adjusted", DefaultTextPreparation(), LevenshteinOptions{SimilarityMode: SimilarityLengthAdjusted}, nil},
+		{"logistic", DefaultTextPreparation(), LevenshteinOptions{SimilarityMode: SimilarityLogistic}, nil},
+		{"deobfuscation", DefaultTextPreparation(), DefaultLevenshteinOptions(), &DeobfuscationConfig{AggressiveDeobfuscation: true}},
+	}
+	for _, cfg := range configs {
+		t.Run(cfg.name, func(t *testing.T) {
+			comparer := NewStringComparer(StringPreparation(cfg.prep), StringDistanceOptions(cfg.options), StringDeobfuscation(cfg.deob))
+			engine := NewLevenshteinEngineWithOptions(cfg.options).WithTextPreparation(cfg.prep).WithDeobfuscation(cfg.deob)
+			engine.DisableRabinKarpFilter()
+
+			for _, pair := range pairs {
+				a, b := Product{ID: "a", Name: pair[0]}, Product{ID: "b", Name: pair[1]}
+				result := engine.Compare(a, b)
+				want := result.NameSimilarity
+				if result.ObfuscationSuspected {
+					want = result.DeobfuscatedNameSimilarity
+				}
+				if got := comparer.Similarity(pair[0], pair[1]); got != want {
+					t.Fatalf("Similarity(%q, %q) = %v, engine name similarity %v", pair[0], pair[1], got, want)
+				}
+				if got := comparer.Distance(pair[0], pair[1]); got != result.NameDistance {
+					t.Fatalf("Distance(%q, %q) = %d, engine name distance %d", pair[0], pair[1], got, result.NameDistance)
+				}
+				for _, threshold := range []float64{0.5, 0.8, 0.95} {
+					got, ok := comparer.SimilarityWithin(pair[0], pair[1], threshold)
+					if ok != (want >= threshold) || (ok && got != want) {
+						t.Fatalf("SimilarityWithin(%q, %q, %v) = %v, %v; name similarity %v", pair[0], pair[1], threshold, got, ok, want)
+					}
+				}
+			}
+
+			// Without deobfuscation, SimilarityWithin keeps what FindDuplicatesByName keeps
+			if cfg.deob == nil {
+				const threshold = 0.6
+				byName := make(map[string]float64)
+				for _, r := range engine.FindDuplicatesByName(catalog, threshold) {
+					byName[makePairKey(r.ProductA.ID, r.ProductB.ID)] = r.NameSimilarity
+				}
+				for i := range catalog {
+					for j := i + 1; j < len(catalog); j++ {
+						got, ok := comparer.SimilarityWithin(catalog[i].Name, catalog[j].Name, threshold)
+						want, found := byName[makePairKey(catalog[i].ID, catalog[j].ID)]
+						if ok != found || got != want {
+							t.Fatalf("%s/%s: SimilarityWithin = %v, %v; FindDuplicatesByName = %v, %v",
+								catalog[i].ID, catalog[j].ID, got, ok, want, found)
+						}
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Suppression records a reviewed pair scans should stop flagging, for as
+// long as neither listing changes
+type Suppression struct {
+	IDA          string    `json:"id_a"`                 // The lower of the two IDs
+	IDB          string    `json:"id_b"`                 // The higher of the two IDs
+	FingerprintA uint64    `json:"fingerprint_a"`        // Product.Fingerprint of IDA when suppressed
+	FingerprintB uint64    `json:"fingerprint_b"`        // Product.Fingerprint of IDB when suppressed
+	ExpiresAt    time.Time `json:"expires_at,omitempty"` // When the suppression lapses (zero: never)
+}
+
+// SuppressionStatus is what a SuppressionStore says about a pair
+type SuppressionStatus int
+
+const (
+	// SuppressionNone means the pair was never suppressed
+	SuppressionNone SuppressionStatus = iota
+	// SuppressionApplied means the pair is suppressed
+	SuppressionApplied
+	// SuppressionExpired means the pair's suppression has expired
+	SuppressionExpired
+	// SuppressionVoided means a product of the pair was edited since it was
+	// suppressed, so the suppression no longer applies
+	SuppressionVoided
+)
+
+// SuppressionStore holds reviewed pairs that expire, or lapse as soon as
+// either listing is edited (see WithSuppressions)
+// A suppression records both products' content fingerprints; it applies
+// only while it hasn't expired and both products still have those
+// fingerprints. Safe for concurrent use, so a review tool can add pairs while
+// scans consult the store.
+type SuppressionStore struct {
+	mu      sync.RWMutex
+	entries map[string]Suppression // By PairKey
+}
+
+// NewSuppressionStore returns an empty suppression store
+func NewSuppressionStore() *SuppressionStore {
+	return &SuppressionStore{entries: make(map[string]Suppression)}
+}
+
+// Add suppresses the pair idA-idB until expiresAt (zero: never), for as long
+// as the products keep the content fingerprints fingerprintA and fingerprintB
+// (see Product.Fingerprint); it replaces any earlier suppression of the pair
+func (s *SuppressionStore) Add(idA, idB string, fingerprintA, fingerprintB uint64, expiresAt time.Time) {
+	if idA > idB {
+		idA, idB = idB, idA
+		fingerprintA, fingerprintB = fingerprintB, fingerprintA
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[makePairKey(idA, idB)] = Suppression{IDA: idA, IDB: idB, FingerprintA: fingerprintA, FingerprintB: fingerprintB, ExpiresAt: expiresAt}
+}
+
+// Remove deletes the suppression of a pair, reporting whether it had one
+func (s *SuppressionStore) Remove(idA, idB string) bool {
+	key := makePairKey(idA, idB)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.entries[key]
+	delete(s.entries, key)
+	return exists
+}
+
+// Status reports whether the suppression of a's and b's pair applies at now
+func (s *SuppressionStore) Status(a, b Product, now time.Time) SuppressionStatus {
+	return s.status(&a, &b, now)
+}
+
+// status is Status for product pointers
+func (s *SuppressionStore) status(a, b *Product, now time.Time) SuppressionStatus {
+	s.mu.RLock()
+	entry, exists := s.entries[makePairKey(a.ID, b.ID)]
+	s.mu.RUnlock()
+	if !exists {
+		return SuppressionNone
+	}
+	if !entry.ExpiresAt.IsZero() && !now.Before(entry.ExpiresAt) {
+		return SuppressionExpired
+	}
+	if a.ID != entry.IDA {
+		a, b = b, a
+	}
+	if a.Fingerprint() != entry.FingerprintA || b.Fingerprint() != entry.FingerprintB {
+		return SuppressionVoided
+	}
+	return SuppressionApplied
+}
+
+// Purge deletes the suppressions expired at now, returning how many
+// Voided suppressions are kept: only the products tell they were edited.
+func (s *SuppressionStore) Purge(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purged := 0
+	for key, entry := range s.entries {
+		if !entry.ExpiresAt.IsZero() && !now.Before(entry.ExpiresAt) {
+			delete(s.entries, key)
+			purged++
+		}
+	}
+	return purged
+}
+
+// Len returns the number of suppressions in the store, expired ones included
+func (s *SuppressionStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
+
+// Suppressions returns the store's suppressions, ordered by IDA then IDB
+func (s *SuppressionStore) Suppressions() []Suppression {
+	s.mu.RLock()
+	entries := make([]Suppression, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	s.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IDA != entries[j].IDA {
+			return entries[i].IDA < entries[j].IDA
+		}
+		return entries[i].IDB < entries[j].IDB
+	})
+	return entries
+}
+
+// Save writes the store's suppressions to w as a JSON array
+func (s *SuppressionStore) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(s.Suppressions())
+}
+
+// LoadSuppressionStore reads a store written by SuppressionStore.Save
+// Returns an error for a suppression pairing an ID with itself or missing
+// one, which Add never records.
+func LoadSuppressionStore(r io.Reader) (*SuppressionStore, error) {
+	var entries []Suppression
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("duplicatecheck: reading suppressions: %w", err)
+	}
+	s := NewSuppressionStore()
+	for i, entry := range entries {
+		if entry.IDA == "" || entry.IDB == "" || entry.IDA == entry.IDB {
+			return nil, fmt.Errorf("duplicatecheck: suppression %d pairs %q with %q", i, entry.IDA, entry.IDB)
+		}
+		s.Add(entry.IDA, entry.IDB, entry.FingerprintA, entry.FingerprintB, entry.ExpiresAt)
+	}
+	return s, nil
+}
+
+// WithSuppressions makes scans skip the pairs store suppresses; nil turns it
+// off (the default). Returns the engine for chaining.
+// Scans consult the store as they reach each pair, alongside the pair
+// constraint (see WithPairConstraint), so suppressions added during a scan
+// may already apply to it. Compare and CompareWithWeights compare whatever
+// they are given. Applied, expired and voided suppressions are counted in
+// GetScanStats and ScanSummary.
+func (e *LevenshteinEngine) WithSuppressions(store *SuppressionStore) *LevenshteinEngine {
+	e.suppressions = store
+	return e
+}
+
+// WithSuppressions makes candidate verification skip the pairs store
+// suppresses (see LevenshteinEngine.WithSuppressions). In privacy mode the
+// index holds no text to fingerprint, so suppressions of indexed candidates
+// count as voided. Returns the engine for chaining.
+func (e *HybridEngine) WithSuppressions(store *SuppressionStore) *HybridEngine {
+	e.levenshteinEngine.WithSuppressions(store)
+	return e
+}
+
+// suppressed reports whether the suppression store leaves a pair out of
+// scans, counting what it says about the pair
+func (e *LevenshteinEngine) suppressed(a, b *Product) bool {
+	if e.suppressions == nil {
+		return false
+	}
+	switch e.suppressions.status(a, b, time.Now()) {
+	case SuppressionApplied:
+		atomic.AddUint64(&e.suppressionsApplied, 1)
+		return true
+	case SuppressionExpired:
+		atomic.AddUint64(&e.suppressionsExpired, 1)
+	case SuppressionVoided:
+		atomic.AddUint64(&e.suppressionsVoided, 1)
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// suppressionCatalog holds one duplicate pair and an unrelated product
+func suppressionCatalog() []Product {
+	return []Product{
+		{ID: "1", Name: "Sony WH-1000XM5 Wireless Headphones", Description: "Noise cancelling, black"},
+		{ID: "2", Name: "Sony WH-1000XM5 Wireless Headphone", Description: "Noise cancelling, black"},
+		{ID: "3", Name: "Le Creuset Dutch Oven", Description: "Enameled cast iron"},
+	}
+}
+
+func TestSuppressionStatus(t *testing.T) {
+	products := suppressionCatalog()
+	a, b := products[0], products[1]
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	edited := b
+	edited.Description = "Noise cancelling, silver"
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		a, b      Product
+		at        time.Time
+		want      SuppressionStatus
+	}{
+		{"applies", now.Add(time.Hour), a, b, now, SuppressionApplied},
+		{"applies either way round", now.Add(time.Hour), b, a, now, SuppressionApplied},
+		{"never expires", time.Time{}, a, b, now.AddDate(10, 0, 0), SuppressionApplied},
+		{"expired", now.Add(time.Hour), a, b, now.Add(time.Hour), SuppressionExpired},
+		{"voided by an edit", now.Add(time.Hour), a, edited, now, SuppressionVoided},
+		{"case-only edit keeps it", now.Add(time.Hour), a, Product{ID: "2", Name: strings.ToUpper(b.Name), Description: b.Description}, now, SuppressionApplied},
+		{"other pair", now.Add(time.Hour), a, products[2], now, SuppressionNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewSuppressionStore()
+			store.Add(b.ID, a.ID, b.Fingerprint(), a.Fingerprint(), tt.expiresAt)
+			if got := store.Status(tt.a, tt.b, tt.at); got != tt.want {
+				t.Errorf("Status = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuppressionStorePurge(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewSuppressionStore()
+	store.Add("1", "2", 1, 2, now.Add(-time.Minute))
+	store.Add("1", "3", 1, 3, now)
+	store.Add("2", "3", 2, 3, now.Add(time.Minute))
+	store.Add("3", "4", 3, 4, time.Time{})
+
+	if got := store.Purge(now); got != 2 {
+		t.Errorf("Purge = %d, want 2", got)
+	}
+	var pairs []string
+	for _, s := range store.Suppressions() {
+		pairs = append(pairs, s.IDA+"-"+s.IDB)
+	}
+	if want := []string{"2-3", "3-4"}; !reflect.DeepEqual(pairs, want) {
+		t.Errorf("kept %v, want %v", pairs, want)
+	}
+	if !store.Remove("4", "3") || store.Remove("3", "4") || store.Len() != 1 {
+		t.Errorf("Remove left %d suppressions, want 1", store.Len())
+	}
+}
+
+func TestSuppressionStorePersistence(t *testing.T) {
+	expires := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := NewSuppressionStore()
+	store.Add("b", "a", 22, 11, expires)
+	store.Add("c", "d", 33, 44, time.Time{})
+
+	var buf bytes.Buffer
+	if err := store.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSuppressionStore(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Suppression{
+		{IDA: "a", IDB: "b", FingerprintA: 11, FingerprintB: 22, ExpiresAt: expires},
+		{IDA: "c", IDB: "d", FingerprintA: 33, FingerprintB: 44},
+	}
+	if got := loaded.Suppressions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []string{`{`, `[{"id_a":"a","id_b":"a"}]`, `[{"id_a":"a"}]`} {
+		if _, err := LoadSuppressionStore(strings.NewReader(bad)); err == nil {
+			t.Errorf("LoadSuppressionStore(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestSuppressionsFindDuplicates(t *testing.T) {
+	products := suppressionCatalog()
+	edited := append([]Product(nil), products...)
+	edited[1].Description = "Noise cancelling, silver edition"
+
+	tests := []struct {
+		name        string
+		products    []Product
+		expiresAt   time.Time
+		wantPairs   []string
+		wantSummary ScanSummary
+	}{
+		{"suppressed", products, time.Now().Add(time.Hour), []string{}, ScanSummary{SuppressionsApplied: 1}},
+		{"expired", products, time.Now().Add(-time.Hour), []string{PairKey("1", "2")}, ScanSummary{SuppressionsExpired: 1}},
+		{"voided by an edit", edited, time.Now().Add(time.Hour), []string{PairKey("1", "2")}, ScanSummary{SuppressionsVoided: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewSuppressionStore()
+			store.Add("1", "2", products[0].Fingerprint(), products[1].Fingerprint(), tt.expiresAt)
+
+			hybrid := NewHybridEngine().WithSuppressions(store)
+			if err := hybrid.BuildIndex(tt.products); err != nil {
+				t.Fatal(err)
+			}
+			engines := map[string]DuplicateCheckEngine{
+				"levenshtein": NewLevenshteinEngine().WithSuppressions(store),
+				"hybrid":      hybrid,
+			}
+			for name, engine := range engines {
+				if got := resultKeys(engine.FindDuplicates(tt.products, 0.8)); !reflect.DeepEqual(got, tt.wantPairs) {
+					t.Errorf("%s: FindDuplicates = %v, want %v", name, got, tt.wantPairs)
+				}
+			}
+
+			_, summary, err := NewLevenshteinEngine().WithSuppressions(store).FindDuplicatesWithSummary(tt.products, 0.8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := [3]uint64{summary.SuppressionsApplied, summary.SuppressionsExpired, summary.SuppressionsVoided}
+			want := [3]uint64{tt.wantSummary.SuppressionsApplied, tt.wantSummary.SuppressionsExpired, tt.wantSummary.SuppressionsVoided}
+			if got != want {
+				t.Errorf("summary applied/expired/voided = %v, want %v", got, want)
+			}
+			if summary.Comparisons != 3-summary.SuppressionsApplied-summary.LengthPruned-summary.CharsetPruned {
+				t.Errorf("summary compared %d of 3 pairs, %d suppressed", summary.Comparisons, summary.SuppressionsApplied)
+			}
+		})
+	}
+}
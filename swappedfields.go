@@ -0,0 +1,160 @@
+package duplicatecheck
+
+import (
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Defaults of SwappedFieldsConfig
+const (
+	// DefaultSwappedNameRatio is the default SwappedFieldsConfig.NameRatio
+	DefaultSwappedNameRatio = 1.5
+	// DefaultSwappedLengthAgreement is the default SwappedFieldsConfig.LengthAgreement
+	DefaultSwappedLengthAgreement = 0.8
+)
+
+// SwappedFieldsConfig configures the crossed comparison of pairs whose name
+// and description look swapped, for feeds that put a subset of rows'
+// descriptions in the Name column and their titles in Description
+// A pair is only compared crossed when its normal score misses the threshold
+// and one product looks swapped: its name is at least NameRatio times as long
+// as the other product's name, and its length agrees with the other
+// product's description to within LengthAgreement (shorter/longer). Near
+// duplicates have comparable names, so they never pay for it.
+type SwappedFieldsConfig struct {
+	// NameRatio is how many times longer than the other product's name a
+	// name must be to look like a description (default DefaultSwappedNameRatio)
+	NameRatio float64
+	// LengthAgreement is the smallest ratio, shorter over longer, of that
+	// name's length to the other product's description length (default
+	// DefaultSwappedLengthAgreement)
+	LengthAgreement float64
+}
+
+// DefaultSwappedFieldsConfig returns the default trigger: a name half again
+// as long as the other's, and within 20% of the other's description length
+func DefaultSwappedFieldsConfig() SwappedFieldsConfig {
+	return SwappedFieldsConfig{NameRatio: DefaultSwappedNameRatio, LengthAgreement: DefaultSwappedLengthAgreement}
+}
+
+// WithSwappedFields makes comparisons score pairs whose fields look swapped
+// (see SwappedFieldsConfig) crossed as well: the swapped product's
+// description against the other's name, and its name against the other's
+// description, under the pair's weights; nil turns it off (the default)
+// When the crossed score meets the threshold (the scan's, or the engine
+// default in Compare), it becomes CombinedSimilarity and the result is
+// flagged FieldsSwappedSuspected, with ReasonFieldsSwappedSuspected; the
+// uncrossed NameSimilarity and DescriptionSimilarity are kept. While enabled,
+// scans don't prune pairs by name length or character set, since a swapped
+// name is far longer than its twin's. GetScanStats counts the crossed
+// comparisons in "swapped_fields_compared". Returns the engine for chaining.
+func (e *LevenshteinEngine) WithSwappedFields(config *SwappedFieldsConfig) *LevenshteinEngine {
+	if config != nil {
+		c := *config
+		if c.NameRatio <= 0 {
+			c.NameRatio = DefaultSwappedNameRatio
+		}
+		if c.LengthAgreement <= 0 {
+			c.LengthAgreement = DefaultSwappedLengthAgreement
+		}
+		c.LengthAgreement = clampUnit(c.LengthAgreement)
+		config = &c
+	}
+	e.swappedFields = config
+	return e
+}
+
+// WithSwappedFields sets the crossed comparison of swapped-looking pairs for
+// verification and Compare (see LevenshteinEngine.WithSwappedFields)
+// A swapped product shares its text with its twin, so LSH still proposes
+// the pair; only the verification is crossed.
+func (e *HybridEngine) WithSwappedFields(config *SwappedFieldsConfig) *HybridEngine {
+	e.levenshteinEngine.WithSwappedFields(config)
+	return e
+}
+
+// swappedScore is the crossed comparison of a pair whose fields look swapped
+type swappedScore struct {
+	suspected      bool    // The crossed score met the threshold and beat the normal one
+	nameSimilarity float64 // The swapped product's description against the other's name
+	descSimilarity float64 // The swapped product's name against the other's description
+	combined       float64 // Both, under the pair's weights
+}
+
+// swappedSide returns the product (0 for A, 1 for B) whose name and
+// description look swapped, or -1
+func (c *SwappedFieldsConfig) swappedSide(nameA, nameB, descA, descB string) int {
+	lenNameA, lenNameB := utf8.RuneCountInString(nameA), utf8.RuneCountInString(nameB)
+	looksSwapped := func(name, otherName, otherDesc int) bool {
+		return otherName > 0 && float64(name) >= c.NameRatio*float64(otherName) &&
+			lengthAgreement(name, otherDesc) >= c.LengthAgreement
+	}
+	switch {
+	case looksSwapped(lenNameA, lenNameB, utf8.RuneCountInString(descB)):
+		return 0
+	case looksSwapped(lenNameB, lenNameA, utf8.RuneCountInString(descA)):
+		return 1
+	default:
+		return -1
+	}
+}
+
+// lengthAgreement returns the ratio of the shorter length to the longer (0
+// when either is 0)
+func lengthAgreement(a, b int) float64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if a > b {
+		a, b = b, a
+	}
+	return float64(a) / float64(b)
+}
+
+// swappedFieldsScore compares a pair crossed when its combined score misses
+// threshold (0: the engine default) and one product looks swapped; the zero
+// score unless the crossed combined score beats it and meets threshold
+func (e *LevenshteinEngine) swappedFieldsScore(nameA, nameB, descA, descB string, combined float64, normalized ComparisonWeights, threshold float64, scratch *comparisonScratch) swappedScore {
+	if e.swappedFields == nil {
+		return swappedScore{}
+	}
+	if threshold <= 0 {
+		threshold = e.threshold
+	}
+	if meetsThreshold(combined, threshold) {
+		return swappedScore{}
+	}
+	side := e.swappedFields.swappedSide(nameA, nameB, descA, descB)
+	if side < 0 {
+		return swappedScore{}
+	}
+	atomic.AddUint64(&e.swappedFieldsCompared, 1)
+
+	// The swapped product's description is its title, and its name its description
+	titleA, titleB, textA, textB := descA, nameB, nameA, descB
+	if side == 1 {
+		titleA, titleB, textA, textB = nameA, descB, descA, nameB
+	}
+	nameDistance := e.scratchDistance(titleA, titleB, scratch)
+	score := swappedScore{nameSimilarity: e.nameSimilarity(titleA, titleB, nameDistance)}
+	score.descSimilarity = e.compareDescriptions(textA, textB, scratch).similarity
+	score.combined = combinePreparedFields(titleA, titleB, textA, textB, score.nameSimilarity, score.descSimilarity, normalized)
+	if score.combined <= combined || !meetsThreshold(score.combined, threshold) {
+		return swappedScore{}
+	}
+	score.suspected = true
+	return score
+}
+
+// apply records a suspected crossed comparison in result, its score
+// replacing CombinedSimilarity
+func (s swappedScore) apply(result *ComparisonResult) {
+	if !s.suspected {
+		return
+	}
+	result.FieldsSwappedSuspected = true
+	result.SwappedNameSimilarity = s.nameSimilarity
+	result.SwappedDescriptionSimilarity = s.descSimilarity
+	result.CombinedSimilarity = s.combined
+	result.MeetsThreshold = meetsThreshold(s.combined, result.ThresholdUsed)
+}
@@ -0,0 +1,86 @@
+package duplicatecheck
+
+import "testing"
+
+func TestSwappedFields(t *testing.T) {
+	catalog := loadSampleCatalog(t)
+	original := sampleProduct(t, catalog, "P001")
+	// The feed put this row's description in Name and its title in Description
+	twin := Product{ID: "P001-feed", Name: original.Description, Description: original.Name}
+	config := DefaultSwappedFieldsConfig()
+
+	t.Run("Swapped twin matches", func(t *testing.T) {
+		plain := NewLevenshteinEngine().Compare(original, twin)
+		if plain.MeetsThreshold || plain.FieldsSwappedSuspected {
+			t.Fatalf("without swapped fields: combined %v, flagged %v", plain.CombinedSimilarity, plain.FieldsSwappedSuspected)
+		}
+
+		engine := NewLevenshteinEngine().WithSwappedFields(&config)
+		result := engine.Compare(original, twin)
+		if !result.MeetsThreshold || !result.FieldsSwappedSuspected || !result.HasReason(ReasonFieldsSwappedSuspected) {
+			t.Fatalf("combined %v, flagged %v, reasons %v", result.CombinedSimilarity, result.FieldsSwappedSuspected, result.ReasonCodes)
+		}
+		if result.SwappedNameSimilarity != 1 || result.SwappedDescriptionSimilarity != 1 || result.CombinedSimilarity != 1 {
+			t.Errorf("crossed scores %v, %v, combined %v, want 1", result.SwappedNameSimilarity, result.SwappedDescriptionSimilarity, result.CombinedSimilarity)
+		}
+		// The uncrossed scores are kept
+		if result.NameSimilarity != plain.NameSimilarity || result.DescriptionSimilarity != plain.DescriptionSimilarity {
+			t.Errorf("uncrossed scores %v, %v, want %v, %v", result.NameSimilarity, result.DescriptionSimilarity, plain.NameSimilarity, plain.DescriptionSimilarity)
+		}
+		// Either side may be the swapped one
+		if reversed := engine.Compare(twin, original); !reversed.FieldsSwappedSuspected || reversed.CombinedSimilarity != result.CombinedSimilarity {
+			t.Errorf("reversed: combined %v, flagged %v", reversed.CombinedSimilarity, reversed.FieldsSwappedSuspected)
+		}
+	})
+
+	t.Run("FindDuplicates", func(t *testing.T) {
+		products := append(append([]Product(nil), catalog...), twin)
+		engine := NewLevenshteinEngine().WithSwappedFields(&config)
+		found := false
+		for _, r := range engine.FindDuplicates(products, 0.85) {
+			if r.ProductA.ID == twin.ID || r.ProductB.ID == twin.ID {
+				found = found || (r.FieldsSwappedSuspected && r.HasReason(ReasonFieldsSwappedSuspected))
+			} else if r.FieldsSwappedSuspected {
+				t.Errorf("%s/%s flagged swapped", r.ProductA.ID, r.ProductB.ID)
+			}
+		}
+		if !found {
+			t.Error("swapped twin not found")
+		}
+		if got := engine.GetScanStats()["length_pruned_pairs"]; got != uint64(0) {
+			t.Errorf("%d pairs pruned by length", got)
+		}
+	})
+
+	t.Run("Normal pairs never compared crossed", func(t *testing.T) {
+		engine := NewLevenshteinEngine().WithSwappedFields(&config)
+		for _, pair := range [][2]string{{"P001", "P002"}, {"P003", "P004"}, {"P001", "P003"}, {"P005", "P006"}, {"P010", "P011"}} {
+			a, b := sampleProduct(t, catalog, pair[0]), sampleProduct(t, catalog, pair[1])
+			if result := engine.Compare(a, b); result.FieldsSwappedSuspected {
+				t.Errorf("%s/%s flagged swapped", a.ID, b.ID)
+			}
+		}
+		if got := engine.GetScanStats()["swapped_fields_compared"]; got != uint64(0) {
+			t.Errorf("%d crossed comparisons of normal pairs", got)
+		}
+		engine.Compare(original, twin)
+		if got := engine.GetScanStats()["swapped_fields_compared"]; got != uint64(1) {
+			t.Errorf("%d crossed comparisons of the swapped pair, want 1", got)
+		}
+	})
+
+	t.Run("Config round trip", func(t *testing.T) {
+		engine := NewLevenshteinEngine().WithSwappedFields(&SwappedFieldsConfig{NameRatio: 1.6})
+		cfg := engine.Config()
+		if cfg.SwappedFields == nil || cfg.SwappedFields.NameRatio != 1.6 || cfg.SwappedFields.LengthAgreement != DefaultSwappedLengthAgreement {
+			t.Fatalf("Config().SwappedFields = %+v", cfg.SwappedFields)
+		}
+		restored, err := NewEngineFromConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := restored.Compare(original, twin); !result.FieldsSwappedSuspected {
+			t.Error("restored engine doesn't compare swapped fields")
+		}
+	})
+}
@@ -0,0 +1,242 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SynonymDictionary rewrites abbreviations and spelling variants to one
+// canonical form ("2nd gen" → "2nd generation", "grey" → "gray")
+// Variants are matched as whole tokens, case-insensitively, and may span
+// several words; the longest variant starting at a token wins. Tokens are runs
+// of letters and digits, so "wh-1000xm5" is the two tokens "wh" and "1000xm5",
+// and a variant matches its tokens whatever punctuation or space separates them.
+//
+// Engines use a dictionary through TextPreparation.Synonyms (see WithSynonyms).
+type SynonymDictionary struct {
+	variants map[string]string // Variant tokens joined by spaces -> canonical form
+	maxWords int               // Longest variant, in tokens
+}
+
+// NewSynonymDictionary returns an empty dictionary
+func NewSynonymDictionary() *SynonymDictionary {
+	return &SynonymDictionary{variants: make(map[string]string)}
+}
+
+// defaultSynonyms lists the built-in canonical forms and their variants
+var defaultSynonyms = map[string][]string{
+	"generation": {"gen"},
+	"1st":        {"first"},
+	"2nd":        {"second"},
+	"3rd":        {"third"},
+	"4th":        {"fourth"},
+	"5th":        {"fifth"},
+	"gray":       {"grey"},
+	"color":      {"colour"},
+	"aluminum":   {"aluminium"},
+	"inch":       {"inches", "inchs"},
+	"gb":         {"gigabyte", "gigabytes"},
+	"tb":         {"terabyte", "terabytes"},
+	"mb":         {"megabyte", "megabytes"},
+	"cm":         {"centimeter", "centimeters", "centimetre", "centimetres"},
+	"mm":         {"millimeter", "millimeters", "millimetre", "millimetres"},
+	"kg":         {"kilogram", "kilograms", "kgs"},
+	"lb":         {"lbs", "pound", "pounds"},
+	"oz":         {"ounce", "ounces"},
+	"ml":         {"milliliter", "milliliters", "millilitre", "millilitres"},
+	"pack":       {"pk", "pck"},
+	"pcs":        {"pieces", "piece"},
+	"usb-c":      {"usb type-c", "usb type c", "type-c", "type c", "usbc"},
+	"xl":         {"extra large", "x-large"},
+	"xs":         {"extra small", "x-small"},
+}
+
+// DefaultSynonyms returns a new dictionary with common ecommerce abbreviations
+// and spelling variants (units, ordinals, "gen", US spellings)
+// The result is a fresh copy, so callers can Add to it freely.
+func DefaultSynonyms() *SynonymDictionary {
+	d := NewSynonymDictionary()
+	for canonical, variants := range defaultSynonyms {
+		d.Add(canonical, variants...)
+	}
+	return d
+}
+
+// Add registers variants of canonical
+// A later Add of the same variant replaces its canonical form.
+func (d *SynonymDictionary) Add(canonical string, variants ...string) {
+	if d.variants == nil {
+		d.variants = make(map[string]string)
+	}
+	for _, variant := range variants {
+		tokens := synonymTokens(strings.ToLower(variant))
+		if len(tokens) == 0 {
+			continue
+		}
+		words := make([]string, len(tokens))
+		for i, token := range tokens {
+			words[i] = token.text
+		}
+		d.variants[strings.Join(words, " ")] = canonical
+		if len(words) > d.maxWords {
+			d.maxWords = len(words)
+		}
+	}
+}
+
+// LoadJSON adds the entries of a JSON object mapping each canonical form to
+// its variants: {"generation": ["gen"], "gray": ["grey"]}
+func (d *SynonymDictionary) LoadJSON(r io.Reader) error {
+	var entries map[string][]string
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return err
+	}
+	for canonical, variants := range entries {
+		d.Add(canonical, variants...)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the dictionary in the LoadJSON format, each canonical
+// form's variants sorted
+func (d *SynonymDictionary) MarshalJSON() ([]byte, error) {
+	entries := make(map[string][]string)
+	for variant, canonical := range d.variants {
+		entries[canonical] = append(entries[canonical], variant)
+	}
+	for _, variants := range entries {
+		sort.Strings(variants)
+	}
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON replaces the dictionary's entries with those of a LoadJSON document
+func (d *SynonymDictionary) UnmarshalJSON(data []byte) error {
+	var entries map[string][]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	*d = SynonymDictionary{variants: make(map[string]string)}
+	for canonical, variants := range entries {
+		d.Add(canonical, variants...)
+	}
+	return nil
+}
+
+// Len returns the number of variants in the dictionary
+func (d *SynonymDictionary) Len() int {
+	return len(d.variants)
+}
+
+// Clone returns an independent copy of the dictionary
+func (d *SynonymDictionary) Clone() *SynonymDictionary {
+	if d == nil {
+		return nil
+	}
+	clone := &SynonymDictionary{variants: make(map[string]string, len(d.variants)), maxWords: d.maxWords}
+	for variant, canonical := range d.variants {
+		clone.variants[variant] = canonical
+	}
+	return clone
+}
+
+// Fingerprint identifies the dictionary's entries; equal entries give equal fingerprints
+func (d *SynonymDictionary) Fingerprint() uint64 {
+	fields := make([]string, 0, 2*len(d.variants)+1)
+	fields = append(fields, "synonyms/v1")
+	variants := make([]string, 0, len(d.variants))
+	for variant := range d.variants {
+		variants = append(variants, variant)
+	}
+	sort.Strings(variants)
+	for _, variant := range variants {
+		fields = append(fields, variant, d.variants[variant])
+	}
+	return contentFingerprint(fields...)
+}
+
+// Apply rewrites every variant in text to its canonical form
+// Text between rewritten tokens, and every token that is not part of a
+// variant, is left as it was.
+func (d *SynonymDictionary) Apply(text string) string {
+	if d == nil || len(d.variants) == 0 {
+		return text
+	}
+	tokens := synonymTokens(text)
+	if len(tokens) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0 // End of the text copied so far
+	words := make([]string, 0, d.maxWords)
+	for i := 0; i < len(tokens); {
+		matched, canonical := 0, ""
+		words = words[:0]
+		for n := 1; n <= d.maxWords && i+n <= len(tokens); n++ {
+			words = append(words, strings.ToLower(tokens[i+n-1].text))
+			if c, ok := d.variants[strings.Join(words, " ")]; ok {
+				matched, canonical = n, c
+			}
+		}
+		if matched == 0 {
+			i++
+			continue
+		}
+		b.WriteString(text[last:tokens[i].start])
+		b.WriteString(canonical)
+		last = tokens[i+matched-1].end
+		i += matched
+	}
+	if last == 0 {
+		return text // Nothing rewritten
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// synonymToken is one run of letters and digits in a text
+type synonymToken struct {
+	text       string
+	start, end int // Byte offsets in the text
+}
+
+// synonymTokens splits text into runs of letters and digits
+func synonymTokens(text string) []synonymToken {
+	var tokens []synonymToken
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		} else if !word && start >= 0 {
+			tokens = append(tokens, synonymToken{text: text[start:i], start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, synonymToken{text: text[start:], start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// WithSynonyms makes the engine rewrite synonyms before comparing text, by
+// setting TextPreparation.Synonyms to a copy of dict (nil turns it off)
+// Returns the engine for chaining.
+func (e *LevenshteinEngine) WithSynonyms(dict *SynonymDictionary) *LevenshteinEngine {
+	options := e.GetTextPreparation()
+	options.Synonyms = dict
+	return e.WithTextPreparation(options)
+}
+
+// WithSynonyms makes the engine rewrite synonyms before indexing and
+// verification (see LevenshteinEngine.WithSynonyms). Any built index is
+// discarded. Returns the engine for chaining.
+func (e *HybridEngine) WithSynonyms(dict *SynonymDictionary) *HybridEngine {
+	options := e.GetTextPreparation()
+	options.Synonyms = dict
+	return e.WithTextPreparation(options)
+}
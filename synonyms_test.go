@@ -0,0 +1,125 @@
+package duplicatecheck
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSynonymDictionaryApply(t *testing.T) {
+	dict := DefaultSynonyms()
+	dict.Add("silver", "sterling silver")
+	dict.Add("wh1000xm5", "wh-1000xm5")
+	dict.Add("inch", "in")
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"single token", "airpods pro 2nd gen", "airpods pro 2nd generation"},
+		{"case-insensitive", "Grey Hoodie", "gray Hoodie"},
+		{"multi-word variant", "sterling silver ring", "silver ring"},
+		{"longest match wins", "usb type-c cable", "usb-c cable"},
+		{"any separator", "sony wh 1000xm5", "sony wh1000xm5"},
+		{"hyphenated variant", "sony wh-1000xm5 headphones", "sony wh1000xm5 headphones"},
+		{"punctuation kept", "6.7 inches, grey.", "6.7 inch, gray."},
+		{"inside a word", "greyhound ingen genuine", "greyhound ingen genuine"},
+		{"suffix of a word", "mini-ipad in box", "mini-ipad inch box"},
+		{"partial multi-word", "sterling", "sterling"},
+		{"no tokens", "--", "--"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dict.Apply(tt.text); got != tt.want {
+				t.Errorf("Apply(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("nil and empty", func(t *testing.T) {
+		var none *SynonymDictionary
+		if got := none.Apply("grey"); got != "grey" {
+			t.Errorf("nil dictionary rewrote to %q", got)
+		}
+		var zero SynonymDictionary
+		zero.Add("gray", "grey")
+		if got := zero.Apply("grey"); got != "gray" {
+			t.Errorf("zero-value dictionary: got %q", got)
+		}
+	})
+}
+
+func TestSynonymDictionaryLoadJSON(t *testing.T) {
+	dict := NewSynonymDictionary()
+	if err := dict.LoadJSON(strings.NewReader(`{"silver": ["sterling silver", "925 silver"], "gray": ["grey"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if dict.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", dict.Len())
+	}
+	if got := dict.Apply("925 Silver grey ring"); got != "silver gray ring" {
+		t.Errorf("Apply = %q", got)
+	}
+	if err := dict.LoadJSON(strings.NewReader(`["not", "an", "object"]`)); err == nil {
+		t.Error("expected an error for a JSON array")
+	}
+}
+
+func TestWithSynonyms(t *testing.T) {
+	catalog := loadSampleCatalog(t)
+	p012, p013 := sampleProduct(t, catalog, "P012"), sampleProduct(t, catalog, "P013")
+
+	plain := NewLevenshteinEngine()
+	if got := plain.Compare(p012, p013).CombinedSimilarity; got > 0.95 {
+		t.Fatalf("P012/P013 already score %.3f without synonyms", got)
+	}
+	engine := NewLevenshteinEngine().WithSynonyms(DefaultSynonyms())
+	if got := engine.Compare(p012, p013).CombinedSimilarity; got <= 0.95 {
+		t.Errorf("P012/P013 with default synonyms = %.3f, want > 0.95", got)
+	}
+
+	t.Run("hybrid index sees canonical text", func(t *testing.T) {
+		hybrid := NewHybridEngine().WithSynonyms(DefaultSynonyms())
+		if err := hybrid.BuildIndex(catalog); err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, r := range hybrid.FindDuplicatesForOne(p013, 0.95) {
+			found = found || r.ProductB.ID == "P012" || r.ProductA.ID == "P012"
+		}
+		if !found {
+			t.Error("hybrid engine did not match P013 to P012 with default synonyms")
+		}
+	})
+
+	t.Run("engines keep a copy", func(t *testing.T) {
+		dict := NewSynonymDictionary()
+		dict.Add("gray", "grey")
+		engine := NewLevenshteinEngine().WithSynonyms(dict)
+		before := engine.GetTextPreparation().Fingerprint()
+		dict.Add("generation", "gen")
+		if engine.GetTextPreparation().Fingerprint() != before {
+			t.Error("changing the dictionary changed the engine's preparation")
+		}
+		if got := engine.Compare(p012, p013).CombinedSimilarity; got > 0.95 {
+			t.Errorf("engine picked up a variant added after WithSynonyms (%.3f)", got)
+		}
+	})
+
+	t.Run("fingerprint", func(t *testing.T) {
+		withDefault := TextPreparation{Synonyms: DefaultSynonyms()}
+		if withDefault.Fingerprint() != (TextPreparation{Synonyms: DefaultSynonyms()}).Fingerprint() {
+			t.Error("equal dictionaries gave different fingerprints")
+		}
+		if withDefault.Fingerprint() == DefaultTextPreparation().Fingerprint() {
+			t.Error("synonyms did not change the preparation fingerprint")
+		}
+
+		// A product cached by a plain engine is re-prepared by one with synonyms
+		shared := p013
+		plain.Compare(shared, p012)
+		if name, _ := shared.preparedStrings(engine.preparer()); name != "apple airpods pro 2nd generation" {
+			t.Errorf("prepared name = %q", name)
+		}
+	})
+}
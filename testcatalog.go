@@ -0,0 +1,144 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Building blocks of the synthetic article catalogs used by GenerateTestCatalog and the tests
+var (
+	articleTopics = []string{
+		"Understanding",
+		"Complete Guide to",
+		"Introduction to",
+		"Advanced Techniques in",
+		"Best Practices for",
+		"How to Master",
+		"Deep Dive into",
+		"Exploring",
+	}
+
+	articleSubjects = []string{
+		"Machine Learning",
+		"Web Development",
+		"Cloud Computing",
+		"Data Science",
+		"Artificial Intelligence",
+		"Blockchain Technology",
+		"Cybersecurity",
+		"DevOps",
+		"Mobile Development",
+		"Database Design",
+		"API Development",
+		"Microservices Architecture",
+		"Container Orchestration",
+		"Serverless Computing",
+		"GraphQL",
+	}
+
+	articleYears = []string{"2023", "2024", "2025"}
+)
+
+// articleTemplates are description templates taking the article subject
+var articleTemplates = []string{
+	"%s has become increasingly important in modern software development. " +
+		"This article explores the key concepts, best practices, and real-world applications. " +
+		"We cover everything from basic principles to advanced techniques that professionals use daily. " +
+		"Whether you're just starting out or looking to deepen your expertise, this guide provides " +
+		"valuable insights and practical examples. Learn how to apply these concepts in your projects " +
+		"and stay ahead of the curve in this rapidly evolving field.",
+
+	"In the ever-changing landscape of technology, %s stands out as a critical skill. " +
+		"This comprehensive guide breaks down complex topics into digestible sections. " +
+		"We examine industry trends, common challenges, and proven solutions that work. " +
+		"Through detailed examples and step-by-step tutorials, you'll gain hands-on experience. " +
+		"Discover tools, frameworks, and methodologies that leading companies use to build scalable solutions.",
+
+	"Master %s with this in-depth tutorial covering fundamentals to advanced concepts. " +
+		"We've compiled insights from industry experts and real-world case studies. " +
+		"Learn optimization techniques, performance best practices, and security considerations. " +
+		"This guide includes code samples, architectural patterns, and troubleshooting tips. " +
+		"Perfect for developers looking to enhance their skills and build production-ready applications.",
+
+	"Explore the world of %s through practical examples and clear explanations. " +
+		"This article demystifies complex concepts and provides actionable knowledge. " +
+		"From setup and configuration to deployment and monitoring, we cover the complete lifecycle. " +
+		"Understand trade-offs, make informed decisions, and avoid common pitfalls. " +
+		"Includes comparison with alternatives and recommendations for different use cases.",
+}
+
+// articleDescription fills a description template for subject
+// variant picks the template and an optional closing paragraph
+func articleDescription(subject string, variant int) string {
+	description := fmt.Sprintf(articleTemplates[variant%len(articleTemplates)], subject)
+
+	// Add some variation based on variant
+	if variant%3 == 0 {
+		description += " Updated with the latest features and industry standards. " +
+			"Includes bonus section on emerging trends and future predictions."
+	} else if variant%5 == 0 {
+		description += " Features interviews with senior engineers and technical leaders. " +
+			"Real production examples from Fortune 500 companies."
+	}
+
+	return description
+}
+
+// GenerateTestCatalog returns a deterministic synthetic catalog of n articles
+// The same seed and n always yield the same products, so the catalog can back
+// golden-result regression tests (see CanonicalizeResults). About one product in
+// six is a near-duplicate of an earlier one: a changed year, a title typo, or an
+// extra sentence. IDs are "ARTICLE_0001", "ARTICLE_0002", ...
+func GenerateTestCatalog(seed int64, n int) []Product {
+	if n <= 0 {
+		return nil
+	}
+	rng := rand.New(rand.NewSource(seed))
+	catalog := make([]Product, n)
+
+	for i := range catalog {
+		id := fmt.Sprintf("ARTICLE_%04d", i+1)
+
+		if i > 0 && rng.Intn(6) == 0 {
+			original := catalog[rng.Intn(i)]
+			catalog[i] = Product{ID: id, Name: original.Name, Description: original.Description}
+			mutateTestArticle(rng, &catalog[i])
+			continue
+		}
+
+		subject := articleSubjects[rng.Intn(len(articleSubjects))]
+		catalog[i] = Product{
+			ID: id,
+			Name: fmt.Sprintf("%s %s in %s",
+				articleTopics[rng.Intn(len(articleTopics))],
+				subject,
+				articleYears[rng.Intn(len(articleYears))]),
+			Description: articleDescription(subject, rng.Intn(60)),
+		}
+	}
+
+	return catalog
+}
+
+// mutateTestArticle applies one small edit to a copied article
+func mutateTestArticle(rng *rand.Rand, p *Product) {
+	switch rng.Intn(3) {
+	case 0:
+		// Change the year
+		for _, year := range articleYears {
+			if strings.HasSuffix(p.Name, year) {
+				p.Name = strings.TrimSuffix(p.Name, year) + articleYears[rng.Intn(len(articleYears))]
+				break
+			}
+		}
+	case 1:
+		// Swap two adjacent letters of the title
+		name := []byte(p.Name)
+		pos := rng.Intn(len(name) - 1)
+		name[pos], name[pos+1] = name[pos+1], name[pos]
+		p.Name = string(name)
+	default:
+		p.Description += " Revised edition with corrected examples."
+	}
+}
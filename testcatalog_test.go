@@ -0,0 +1,217 @@
+package duplicatecheck
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden result files in testdata/golden")
+
+// goldenCatalogSeed and goldenCatalogSize fix the catalog the golden files were generated from
+const (
+	goldenCatalogSeed = 42
+	goldenCatalogSize = 150
+)
+
+var goldenThresholds = []float64{0.75, 0.85, 0.95}
+
+func TestGenerateTestCatalogDeterministic(t *testing.T) {
+	a := GenerateTestCatalog(7, 80)
+	b := GenerateTestCatalog(7, 80)
+	if len(a) != 80 {
+		t.Fatalf("Expected 80 products, got %d", len(a))
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || a[i].Name != b[i].Name || a[i].Description != b[i].Description {
+			t.Fatalf("Product %d differs between runs with the same seed", i)
+		}
+	}
+
+	c := GenerateTestCatalog(8, 80)
+	same := 0
+	for i := range a {
+		if a[i].Name == c[i].Name {
+			same++
+		}
+	}
+	if same == len(a) {
+		t.Error("Different seeds should produce different catalogs")
+	}
+
+	if GenerateTestCatalog(7, 0) != nil {
+		t.Error("An empty catalog should be nil")
+	}
+}
+
+func TestCanonicalizeResults(t *testing.T) {
+	results := []ComparisonResult{
+		{ProductA: Product{ID: "b"}, ProductB: Product{ID: "a"}, CombinedSimilarity: 0.123456789},
+		{ProductA: Product{ID: "a"}, ProductB: Product{ID: "c"}, CombinedSimilarity: 0.9999999},
+	}
+	got := CanonicalizeResults(results)
+	want := []ResultRef{
+		{ProductAID: "a", ProductBID: "b", CombinedSimilarity: 0.123457},
+		{ProductAID: "a", ProductBID: "c", CombinedSimilarity: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CanonicalizeResults() = %+v, want %+v", got, want)
+	}
+	if PairKey("b", "a") != PairKey("a", "b") {
+		t.Error("PairKey should not depend on argument order")
+	}
+}
+
+// goldenPath returns the golden file for an engine at a threshold
+func goldenPath(engine string, threshold float64) string {
+	return filepath.Join("testdata", "golden", fmt.Sprintf("%s_%.2f.json", engine, threshold))
+}
+
+// checkGolden compares results with a golden file, or rewrites it with -update
+func checkGolden(t *testing.T, path string, got []ResultRef) {
+	t.Helper()
+	if *updateGolden {
+		data, err := json.MarshalIndent(got, "", "  ")
+		if err != nil {
+			t.Fatalf("Failed to encode results: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			t.Fatalf("Failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	var want []ResultRef
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatalf("Failed to decode golden file: %v", err)
+	}
+
+	wantByKey := make(map[string]ResultRef, len(want))
+	for _, ref := range want {
+		wantByKey[PairKey(ref.ProductAID, ref.ProductBID)] = ref
+	}
+	for _, ref := range got {
+		key := PairKey(ref.ProductAID, ref.ProductBID)
+		expected, exists := wantByKey[key]
+		switch {
+		case !exists:
+			t.Errorf("New pair %s (%.6f)", key, ref.CombinedSimilarity)
+		case expected != ref:
+			t.Errorf("Pair %s changed: got %+v, want %+v", key, ref, expected)
+		}
+		delete(wantByKey, key)
+	}
+	for key, ref := range wantByKey {
+		t.Errorf("Missing pair %s (%.6f)", key, ref.CombinedSimilarity)
+	}
+}
+
+// TestGoldenResults guards engine scores against unintended changes
+// Run `go test -run TestGoldenResults -update` after an intentional scoring change.
+func TestGoldenResults(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, goldenCatalogSize)
+
+	levenshtein := NewLevenshteinEngine()
+	hybrid := NewHybridEngine()
+	if err := hybrid.BuildIndex(catalog); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	for _, threshold := range goldenThresholds {
+		exact := CanonicalizeResults(levenshtein.FindDuplicates(catalog, threshold))
+		approximate := CanonicalizeResults(hybrid.FindDuplicates(catalog, threshold))
+
+		t.Run(fmt.Sprintf("Levenshtein %.2f", threshold), func(t *testing.T) {
+			if len(exact) == 0 {
+				t.Fatal("The golden catalog should produce duplicates")
+			}
+			checkGolden(t, goldenPath("levenshtein", threshold), exact)
+		})
+
+		t.Run(fmt.Sprintf("Hybrid %.2f", threshold), func(t *testing.T) {
+			checkGolden(t, goldenPath("hybrid", threshold), approximate)
+		})
+
+		// Hybrid verifies candidates with the same scorer, so it can miss pairs
+		// but never invent them or score them differently
+		t.Run(fmt.Sprintf("Hybrid subset of Levenshtein %.2f", threshold), func(t *testing.T) {
+			exactByKey := make(map[string]ResultRef, len(exact))
+			for _, ref := range exact {
+				exactByKey[PairKey(ref.ProductAID, ref.ProductBID)] = ref
+			}
+			for _, ref := range approximate {
+				key := PairKey(ref.ProductAID, ref.ProductBID)
+				expected, exists := exactByKey[key]
+				if !exists {
+					t.Errorf("Hybrid found %s, which Levenshtein did not", key)
+				} else if expected != ref {
+					t.Errorf("Pair %s scored differently: hybrid %+v, Levenshtein %+v", key, ref, expected)
+				}
+			}
+		})
+	}
+}
+
+// generatedCatalog returns the synthetic catalog the gen package publishes,
+// with its duplicate pairs keyed by PairKey
+func generatedCatalog(cfg synth.Config) ([]Product, map[string]bool) {
+	catalog := synth.Generate(cfg)
+	products := make([]Product, len(catalog.Records))
+	for i, r := range catalog.Records {
+		products[i] = Product{ID: r.ID, Name: r.Name, Description: r.Description}
+	}
+	truth := make(map[string]bool)
+	for _, pair := range catalog.DuplicatePairs() {
+		truth[PairKey(products[pair[0]].ID, products[pair[1]].ID)] = true
+	}
+	return products, truth
+}
+
+// TestGeneratedCatalogRecall pins Levenshtein recall per mutation type on the
+// published synthetic catalog, at the default threshold
+func TestGeneratedCatalogRecall(t *testing.T) {
+	tests := []struct {
+		mutation  synth.Mutation
+		minRecall float64
+	}{
+		{synth.MutateCase, 1},
+		{synth.MutateTypo, 1},
+		{synth.MutateBoilerplate, 1},
+		{synth.MutateSpecSwap, 0.9},
+		// Moving a token costs two edits per rune moved, a known weakness of
+		// edit distance on names; only checked for false positives
+		{synth.MutateReorder, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mutation.String(), func(t *testing.T) {
+			cfg := synth.Default()
+			cfg.Size, cfg.Mutations = 200, []synth.Mutation{tt.mutation}
+			products, truth := generatedCatalog(cfg)
+
+			found := 0
+			for _, r := range NewLevenshteinEngine().FindDuplicates(products, DefaultThreshold) {
+				if !truth[PairKey(r.ProductA.ID, r.ProductB.ID)] {
+					t.Errorf("False positive %s ~ %s (%.3f)", r.ProductA.Name, r.ProductB.Name, r.CombinedSimilarity)
+					continue
+				}
+				found++
+			}
+			if recall := float64(found) / float64(len(truth)); recall < tt.minRecall {
+				t.Errorf("Recall %.2f (%d of %d), want at least %.2f", recall, found, len(truth), tt.minRecall)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+// Package testsuite checks that a DuplicateCheckEngine honors the contract
+// the library's own engines follow
+//
+// Authors of new engines run it from their tests:
+//
+//	func TestMyEngineConformance(t *testing.T) {
+//		testsuite.RunEngineConformanceTests(t, func() duplicatecheck.DuplicateCheckEngine {
+//			return NewMyEngine()
+//		})
+//	}
+//
+// The suite scores pairs from the deterministic GenerateTestCatalog, so a
+// failure names products that reproduce it.
+package testsuite
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+// Catalog the suite scores
+const (
+	CatalogSeed = 42  // GenerateTestCatalog seed
+	CatalogSize = 120 // Products in the catalog
+)
+
+// tolerance absorbs floating-point rounding between equal scores
+const tolerance = 1e-9
+
+// RunEngineConformanceTests runs the DuplicateCheckEngine contract against
+// engines built by newEngine, one fresh engine per subtest:
+//   - Compare(a, b) and Compare(b, a) give the same similarities
+//   - every similarity is in [0.0-1.0]
+//   - products with identical text score 1.0
+//   - with both names empty CombinedSimilarity is DescriptionSimilarity, with
+//     both descriptions empty it is NameSimilarity, and it is 0 when one
+//     product has only a name and the other only a description
+//   - CompareWithWeights normalizes weights, reports them in WeightsUsed and
+//     combines the field scores with them
+//   - FindDuplicates returns each pair at most once, never pairs a product with
+//     itself and never returns a pair below the threshold less
+//     duplicatecheck.ThresholdEpsilon, and marks each result with the
+//     threshold it met
+//   - FindDuplicates of no products or one product returns an empty, non-nil slice
+func RunEngineConformanceTests(t *testing.T, newEngine func() duplicatecheck.DuplicateCheckEngine) {
+	t.Helper()
+	catalog := duplicatecheck.GenerateTestCatalog(CatalogSeed, CatalogSize)
+	pairs := samplePairs(catalog)
+
+	t.Run("Symmetry", func(t *testing.T) {
+		engine := newEngine()
+		for _, pair := range pairs {
+			ab, ba := engine.Compare(pair[0], pair[1]), engine.Compare(pair[1], pair[0])
+			for _, score := range []struct {
+				field  string
+				ab, ba float64
+			}{
+				{"NameSimilarity", ab.NameSimilarity, ba.NameSimilarity},
+				{"DescriptionSimilarity", ab.DescriptionSimilarity, ba.DescriptionSimilarity},
+				{"CombinedSimilarity", ab.CombinedSimilarity, ba.CombinedSimilarity},
+			} {
+				if !near(score.ab, score.ba) {
+					t.Errorf("%s: %s %v, reversed %v", describe(pair[0], pair[1]), score.field, score.ab, score.ba)
+				}
+			}
+			if ab.ProductA.ID != pair[0].ID || ab.ProductB.ID != pair[1].ID {
+				t.Errorf("%s: result holds %s and %s", describe(pair[0], pair[1]), ab.ProductA.ID, ab.ProductB.ID)
+			}
+		}
+	})
+
+	t.Run("Bounds", func(t *testing.T) {
+		engine := newEngine()
+		for _, pair := range pairs {
+			checkBounds(t, describe(pair[0], pair[1]), engine.Compare(pair[0], pair[1]))
+		}
+	})
+
+	t.Run("Identical products", func(t *testing.T) {
+		engine := newEngine()
+		for _, p := range catalog {
+			copied := p
+			copied.ID += "-copy"
+			if r := engine.Compare(p, copied); !near(r.CombinedSimilarity, 1) {
+				t.Errorf("%s: identical text scored %v", describe(p, copied), r.CombinedSimilarity)
+			}
+		}
+	})
+
+	t.Run("Empty fields", func(t *testing.T) {
+		engine := newEngine()
+		for i := 0; i+1 < len(catalog); i += 2 {
+			a, b := catalog[i], catalog[i+1]
+			noNames := engine.Compare(
+				duplicatecheck.Product{ID: a.ID, Description: a.Description},
+				duplicatecheck.Product{ID: b.ID, Description: b.Description},
+			)
+			if !near(noNames.CombinedSimilarity, noNames.DescriptionSimilarity) {
+				t.Errorf("%s without names: combined %v, description %v", describe(a, b), noNames.CombinedSimilarity, noNames.DescriptionSimilarity)
+			}
+			noDescriptions := engine.Compare(
+				duplicatecheck.Product{ID: a.ID, Name: a.Name},
+				duplicatecheck.Product{ID: b.ID, Name: b.Name},
+			)
+			if !near(noDescriptions.CombinedSimilarity, noDescriptions.NameSimilarity) {
+				t.Errorf("%s without descriptions: combined %v, name %v", describe(a, b), noDescriptions.CombinedSimilarity, noDescriptions.NameSimilarity)
+			}
+			disjoint := engine.Compare(
+				duplicatecheck.Product{ID: a.ID, Name: a.Name},
+				duplicatecheck.Product{ID: b.ID, Description: a.Description},
+			)
+			if disjoint.CombinedSimilarity != 0 {
+				t.Errorf("%s: a name-only and a description-only product scored %v", describe(a, b), disjoint.CombinedSimilarity)
+			}
+		}
+	})
+
+	t.Run("Weights", func(t *testing.T) {
+		engine := newEngine()
+		for _, pair := range pairs {
+			a, b := pair[0], pair[1]
+			if a.Name == "" || b.Name == "" || a.Description == "" || b.Description == "" {
+				continue
+			}
+			name := fmt.Sprintf("%s with weights", describe(a, b))
+			weighted := engine.CompareWithWeights(a, b, duplicatecheck.ComparisonWeights{NameWeight: 0.7, DescriptionWeight: 0.3})
+			checkBounds(t, name, weighted)
+			if !near(weighted.WeightsUsed.NameWeight, 0.7) || !near(weighted.WeightsUsed.DescriptionWeight, 0.3) {
+				t.Errorf("%s 0.7/0.3: WeightsUsed %+v", name, weighted.WeightsUsed)
+			}
+			if want := 0.7*weighted.NameSimilarity + 0.3*weighted.DescriptionSimilarity; !near(weighted.CombinedSimilarity, want) {
+				t.Errorf("%s 0.7/0.3: combined %v, want %v", name, weighted.CombinedSimilarity, want)
+			}
+			if scaled := engine.CompareWithWeights(a, b, duplicatecheck.ComparisonWeights{NameWeight: 7, DescriptionWeight: 3}); !near(scaled.CombinedSimilarity, weighted.CombinedSimilarity) {
+				t.Errorf("%s 7/3: combined %v, 0.7/0.3 gave %v", name, scaled.CombinedSimilarity, weighted.CombinedSimilarity)
+			}
+			if nameOnly := engine.CompareWithWeights(a, b, duplicatecheck.ComparisonWeights{NameWeight: 1}); !near(nameOnly.CombinedSimilarity, nameOnly.NameSimilarity) {
+				t.Errorf("%s 1/0: combined %v, name %v", name, nameOnly.CombinedSimilarity, nameOnly.NameSimilarity)
+			}
+			if descOnly := engine.CompareWithWeights(a, b, duplicatecheck.ComparisonWeights{DescriptionWeight: 1}); !near(descOnly.CombinedSimilarity, descOnly.DescriptionSimilarity) {
+				t.Errorf("%s 0/1: combined %v, description %v", name, descOnly.CombinedSimilarity, descOnly.DescriptionSimilarity)
+			}
+			zero := engine.CompareWithWeights(a, b, duplicatecheck.ComparisonWeights{})
+			defaults := engine.CompareWithWeights(a, b, duplicatecheck.DefaultWeights())
+			if !near(zero.CombinedSimilarity, defaults.CombinedSimilarity) {
+				t.Errorf("%s 0/0: combined %v, want the default weights' %v", name, zero.CombinedSimilarity, defaults.CombinedSimilarity)
+			}
+		}
+	})
+
+	t.Run("FindDuplicates", func(t *testing.T) {
+		inCatalog := make(map[string]bool, len(catalog))
+		for _, p := range catalog {
+			inCatalog[p.ID] = true
+		}
+		for _, threshold := range []float64{0.5, 0.75, 0.9, 1} {
+			engine := newEngine()
+			seen := make(map[[2]string]bool)
+			for _, r := range engine.FindDuplicates(catalog, threshold) {
+				a, b := r.ProductA.ID, r.ProductB.ID
+				name := fmt.Sprintf("Threshold %v: %s", threshold, describe(r.ProductA, r.ProductB))
+				checkBounds(t, name, r)
+				if belowThreshold(r.CombinedSimilarity, threshold) {
+					t.Errorf("%s scored %v, below the threshold", name, r.CombinedSimilarity)
+				}
+				if !r.MeetsThreshold || r.ThresholdUsed != threshold {
+					t.Errorf("%s: MeetsThreshold %v, ThresholdUsed %v", name, r.MeetsThreshold, r.ThresholdUsed)
+				}
+				if a == b {
+					t.Errorf("%s pairs a product with itself", name)
+				}
+				if !inCatalog[a] || !inCatalog[b] {
+					t.Errorf("%s holds a product not in the input", name)
+				}
+				if b < a {
+					a, b = b, a
+				}
+				if seen[[2]string{a, b}] {
+					t.Errorf("%s returned twice", name)
+				}
+				seen[[2]string{a, b}] = true
+			}
+		}
+		engine := newEngine()
+		for _, input := range []struct {
+			name     string
+			products []duplicatecheck.Product
+		}{
+			{"No products", nil},
+			{"Empty slice", []duplicatecheck.Product{}},
+			{"One product", catalog[:1]},
+		} {
+			if results := engine.FindDuplicates(input.products, 0); results == nil || len(results) != 0 {
+				t.Errorf("%s: got %v, want an empty non-nil slice", input.name, results)
+			}
+		}
+	})
+}
+
+// samplePairs returns every pair of the first products, where the catalog's
+// near-duplicates cluster, and a spread of pairs across the rest
+func samplePairs(catalog []duplicatecheck.Product) [][2]duplicatecheck.Product {
+	var pairs [][2]duplicatecheck.Product
+	head := len(catalog) / 3
+	for i := 0; i < head; i++ {
+		for j := i + 1; j < head; j++ {
+			pairs = append(pairs, [2]duplicatecheck.Product{catalog[i], catalog[j]})
+		}
+	}
+	for i := head; i < len(catalog); i++ {
+		if j := (i*37 + 11) % len(catalog); j != i {
+			pairs = append(pairs, [2]duplicatecheck.Product{catalog[i], catalog[j]})
+		}
+	}
+	return pairs
+}
+
+// checkBounds fails unless every similarity of r is in [0.0-1.0]
+func checkBounds(t *testing.T, name string, r duplicatecheck.ComparisonResult) {
+	t.Helper()
+	for _, score := range []struct {
+		field string
+		value float64
+	}{
+		{"NameSimilarity", r.NameSimilarity},
+		{"DescriptionSimilarity", r.DescriptionSimilarity},
+		{"CombinedSimilarity", r.CombinedSimilarity},
+	} {
+		if !(score.value >= 0 && score.value <= 1) {
+			t.Errorf("%s: %s %v outside [0, 1]", name, score.field, score.value)
+		}
+	}
+}
+
+// belowThreshold reports whether a similarity misses threshold by more than
+// duplicatecheck.ThresholdEpsilon, the tolerance engines apply
+func belowThreshold(similarity, threshold float64) bool {
+	return similarity < threshold-duplicatecheck.ThresholdEpsilon
+}
+
+// near reports whether two scores are equal up to rounding
+func near(x, y float64) bool {
+	return math.Abs(x-y) <= tolerance
+}
+
+// describe names a pair in failure messages
+func describe(a, b duplicatecheck.Product) string {
+	return fmt.Sprintf("%s/%s", a.ID, b.ID)
+}
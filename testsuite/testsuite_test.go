@@ -0,0 +1,40 @@
+package testsuite
+
+import (
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck"
+)
+
+func TestBuiltinEngines(t *testing.T) {
+	engines := []struct {
+		name      string
+		newEngine func() duplicatecheck.DuplicateCheckEngine
+	}{
+		{"Levenshtein", func() duplicatecheck.DuplicateCheckEngine { return duplicatecheck.NewLevenshteinEngine() }},
+		{"Hybrid", func() duplicatecheck.DuplicateCheckEngine { return duplicatecheck.NewHybridEngine() }},
+		{"TF-IDF", func() duplicatecheck.DuplicateCheckEngine { return duplicatecheck.NewTFIDFEngine() }},
+	}
+	for _, tt := range engines {
+		t.Run(tt.name, func(t *testing.T) {
+			RunEngineConformanceTests(t, tt.newEngine)
+		})
+	}
+}
+
+func TestBelowThreshold(t *testing.T) {
+	tests := []struct {
+		similarity, threshold float64
+		want                  bool
+	}{
+		{0.85, 0.85, false},
+		{0.85 - 1e-12, 0.85, false}, // Floating-point noise meets the threshold
+		{0.85 - 1e-6, 0.85, true},
+		{0.9, 0.85, false},
+	}
+	for _, tt := range tests {
+		if got := belowThreshold(tt.similarity, tt.threshold); got != tt.want {
+			t.Errorf("belowThreshold(%v, %v) = %v, want %v", tt.similarity, tt.threshold, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package duplicatecheck
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// DefaultTFIDFAlpha is the share of character Levenshtein similarity blended
+// into TFIDFEngine name similarity
+const DefaultTFIDFAlpha = 0.2
+
+// TFIDFEngine scores names by IDF-weighted token overlap instead of by
+// characters, so rare tokens ("A2894", "256GB", "14") dominate and tokens
+// most names share ("Apple", "iPhone", "Pro") barely count
+//
+// Name similarity is the cosine of the two names' tf-idf vectors, blended with
+// character Levenshtein similarity by alpha:
+//
+//	name = alpha*levenshtein + (1-alpha)*cosine
+//
+// Tokens are runs of letters and digits in the prepared name. Document
+// frequencies come from the corpus fitted with FitCorpus or, for a
+// FindDuplicates call on an engine without one, from the products scanned.
+// Descriptions are compared with Levenshtein as in LevenshteinEngine.
+type TFIDFEngine struct {
+	levenshteinEngine *LevenshteinEngine // Description scoring, preparation, and the character blend
+	alpha             float64            // Share of Levenshtein in name similarity
+	threshold         float64            // Default threshold for IsDuplicate and FindDuplicatesDefault
+	corpusMu          sync.RWMutex       // Guards corpus
+	corpus            *idfCorpus         // Fitted corpus; nil until FitCorpus
+}
+
+// idfCorpus holds document frequencies over a set of product names
+type idfCorpus struct {
+	documents int            // Number of names counted
+	frequency map[string]int // Token -> number of names containing it
+	products  []*Product     // Fitted products, for FindDuplicatesForOne
+}
+
+var (
+	_ DuplicateCheckEngine   = (*TFIDFEngine)(nil)
+	_ DefaultThresholdEngine = (*TFIDFEngine)(nil)
+)
+
+// NewTFIDFEngine creates a TF-IDF engine with DefaultTFIDFAlpha and default weights
+func NewTFIDFEngine() *TFIDFEngine {
+	return &TFIDFEngine{
+		levenshteinEngine: NewLevenshteinEngine(),
+		alpha:             DefaultTFIDFAlpha,
+		threshold:         DefaultThreshold,
+	}
+}
+
+// NewTFIDFEngineWithWeights creates a TF-IDF engine with custom name and description weights
+func NewTFIDFEngineWithWeights(weights ComparisonWeights) *TFIDFEngine {
+	engine := NewTFIDFEngine()
+	engine.levenshteinEngine = NewLevenshteinEngineWithWeights(weights)
+	return engine
+}
+
+// GetName returns the algorithm name
+func (e *TFIDFEngine) GetName() string {
+	return "TF-IDF Token Cosine"
+}
+
+// SetAlpha sets the share of character Levenshtein similarity in name
+// similarity: 0 is pure tf-idf cosine, 1 is plain Levenshtein
+// Returns an error outside [0.0-1.0].
+func (e *TFIDFEngine) SetAlpha(alpha float64) error {
+	if err := validateThreshold(alpha); err != nil {
+		return err
+	}
+	e.alpha = alpha
+	return nil
+}
+
+// GetAlpha returns the share of Levenshtein in name similarity
+func (e *TFIDFEngine) GetAlpha() float64 {
+	return e.alpha
+}
+
+// WithTextPreparation sets how the engine prepares text before tokenizing and
+// comparing it. A fitted corpus is discarded, since its tokens came from the
+// old preparation. Returns the engine for chaining.
+func (e *TFIDFEngine) WithTextPreparation(options TextPreparation) *TFIDFEngine {
+	e.levenshteinEngine.WithTextPreparation(options)
+	e.corpusMu.Lock()
+	e.corpus = nil
+	e.corpusMu.Unlock()
+	return e
+}
+
+// GetTextPreparation returns the engine's text preparation options
+func (e *TFIDFEngine) GetTextPreparation() TextPreparation {
+	return e.levenshteinEngine.GetTextPreparation()
+}
+
+// SetDefaultThreshold changes the threshold used by IsDuplicate and FindDuplicatesDefault
+func (e *TFIDFEngine) SetDefaultThreshold(threshold float64) error {
+	if err := validateThreshold(threshold); err != nil {
+		return err
+	}
+	e.threshold = threshold
+	return nil
+}
+
+// GetDefaultThreshold returns the engine's default threshold
+func (e *TFIDFEngine) GetDefaultThreshold() float64 {
+	return e.threshold
+}
+
+// IsDuplicate compares two products against the default threshold
+func (e *TFIDFEngine) IsDuplicate(a, b Product) (bool, ComparisonResult) {
+	defer e.levenshteinEngine.guard("IsDuplicate", nil)
+	result := e.Compare(a, b)
+	return result.MeetsThreshold, result
+}
+
+// FindDuplicatesDefault is FindDuplicates with the default threshold
+func (e *TFIDFEngine) FindDuplicatesDefault(products []Product) []ComparisonResult {
+	defer e.levenshteinEngine.guard("FindDuplicatesDefault", nil)
+	return e.FindDuplicates(products, e.threshold)
+}
+
+// FitCorpus counts document frequencies over products' names and keeps the
+// products for FindDuplicatesForOne, replacing any earlier corpus
+func (e *TFIDFEngine) FitCorpus(products []Product) (err error) {
+	defer e.levenshteinEngine.guard("FitCorpus", &err)
+	resolved, err := ResolveDuplicateIDs(products, e.levenshteinEngine.idPolicy)
+	if err != nil {
+		return err
+	}
+	corpus := newIDFCorpus(productPtrs(resolved), e.levenshteinEngine.preparer())
+	e.corpusMu.Lock()
+	e.corpus = corpus
+	e.corpusMu.Unlock()
+	return nil
+}
+
+// IsFitted reports whether a corpus has been fitted
+func (e *TFIDFEngine) IsFitted() bool {
+	return e.currentCorpus() != nil
+}
+
+// currentCorpus returns the fitted corpus, or nil
+func (e *TFIDFEngine) currentCorpus() *idfCorpus {
+	e.corpusMu.RLock()
+	defer e.corpusMu.RUnlock()
+	return e.corpus
+}
+
+// Compare scores two products against the fitted corpus
+// Without one every token has the same IDF, so names are compared by plain
+// token cosine.
+func (e *TFIDFEngine) Compare(a, b Product) ComparisonResult {
+	defer e.levenshteinEngine.guard("Compare", nil)
+	return e.CompareWithWeights(a, b, e.levenshteinEngine.resolveWeights(&a, &b))
+}
+
+// CompareWithWeights is Compare with custom name and description weights
+func (e *TFIDFEngine) CompareWithWeights(a, b Product, weights ComparisonWeights) ComparisonResult {
+	defer e.levenshteinEngine.guard("CompareWithWeights", nil)
+	corpus := e.currentCorpus()
+	preparer := e.levenshteinEngine.preparer()
+	return e.compare(&a, &b, corpus.vector(&a, preparer), corpus.vector(&b, preparer), weights)
+}
+
+// FindDuplicates returns the pairs at or above threshold
+// Without a fitted corpus, document frequencies are counted over products for
+// this call only.
+func (e *TFIDFEngine) FindDuplicates(products []Product, threshold float64) []ComparisonResult {
+	defer e.levenshteinEngine.guard("FindDuplicates", nil)
+	duplicates, _ := e.FindDuplicatesChecked(products, threshold)
+	return duplicates
+}
+
+// FindDuplicatesChecked is like FindDuplicates but reports input problems
+// Returns a *DuplicateIDError when the input repeats IDs under DuplicateIDReject.
+func (e *TFIDFEngine) FindDuplicatesChecked(products []Product, threshold float64) (_ []ComparisonResult, err error) {
+	defer e.levenshteinEngine.guard("FindDuplicatesChecked", &err)
+	resolved, err := ResolveDuplicateIDs(products, e.levenshteinEngine.idPolicy)
+	if err != nil {
+		return nil, err
+	}
+	ptrs := productPtrs(resolved)
+	preparer := e.levenshteinEngine.preparer()
+	corpus := e.currentCorpus()
+	if corpus == nil {
+		corpus = newIDFCorpus(ptrs, preparer)
+	}
+
+	vectors := make([]tfidfVector, len(ptrs))
+	for i, p := range ptrs {
+		vectors[i] = corpus.vector(p, preparer)
+	}
+	parallel := len(ptrs) > 50
+	if parallel {
+		warmCaches(ptrs, preparer)
+	}
+	return scanPairs(len(ptrs), e.levenshteinEngine.scanWorkers(len(ptrs), parallel), func(i, j int) (ComparisonResult, bool) {
+		weights := e.levenshteinEngine.resolveWeights(ptrs[i], ptrs[j])
+		result := e.compare(ptrs[i], ptrs[j], vectors[i], vectors[j], weights)
+		result.stampThreshold(threshold)
+		return result, result.MeetsThreshold
+	}), nil
+}
+
+// FindDuplicatesForOne compares product with every product in the fitted
+// corpus except itself (same ID) and returns the matches at or above threshold
+// Returns nil when no corpus has been fitted.
+func (e *TFIDFEngine) FindDuplicatesForOne(product Product, threshold float64) []ComparisonResult {
+	defer e.levenshteinEngine.guard("FindDuplicatesForOne", nil)
+	corpus := e.currentCorpus()
+	if corpus == nil {
+		return nil
+	}
+	preparer := e.levenshteinEngine.preparer()
+	query := corpus.vector(&product, preparer)
+
+	duplicates := []ComparisonResult{}
+	for _, candidate := range corpus.products {
+		if candidate.ID == product.ID {
+			continue
+		}
+		weights := e.levenshteinEngine.resolveWeights(&product, candidate)
+		result := e.compare(&product, candidate, query, corpus.vector(candidate, preparer), weights)
+		result.stampThreshold(threshold)
+		if result.MeetsThreshold {
+			duplicates = append(duplicates, result)
+		}
+	}
+	return duplicates
+}
+
+// compare scores a pair given the tf-idf vectors of both names
+func (e *TFIDFEngine) compare(a, b *Product, vectorA, vectorB tfidfVector, weights ComparisonWeights) ComparisonResult {
+	lev := e.levenshteinEngine
+	normalized := weights.Normalized()
+	preparer := lev.preparer()
+	nameA, descA := a.preparedStrings(preparer)
+	nameB, descB := b.preparedStrings(preparer)
+
+	nameDistance := lev.computeDistance(nameA, nameB)
+	nameSimilarity := lev.nameSimilarity(nameA, nameB, nameDistance)
+	if nameA != nameB {
+		nameSimilarity = clampUnit(e.alpha*nameSimilarity + (1-e.alpha)*vectorA.cosine(vectorB))
+	}
+	desc := lev.compareDescriptions(descA, descB, nil)
+	combined := combinePreparedFields(nameA, nameB, descA, descB, nameSimilarity, desc.similarity, normalized)
+
+	return lev.finishResult(ComparisonResult{
+		ProductA:              *a,
+		ProductB:              *b,
+		NameDistance:          nameDistance,
+		NameSimilarity:        nameSimilarity,
+		DescriptionDistance:   desc.distance,
+		DescriptionSimilarity: desc.similarity,
+		CombinedSimilarity:    combined,
+		WeightsUsed:           normalized,
+		SimilarityMode:        lev.options.SimilarityMode,
+		ThresholdUsed:         e.threshold,
+		MeetsThreshold:        meetsThreshold(combined, e.threshold),
+		SegmentSimilarities:   desc.segments,
+		DescriptionTimedOut:   desc.timedOut,
+	})
+}
+
+// newIDFCorpus counts the names of products that contain each token
+func newIDFCorpus(products []*Product, preparer *textPreparer) *idfCorpus {
+	corpus := &idfCorpus{
+		documents: len(products),
+		frequency: make(map[string]int),
+		products:  products,
+	}
+	for _, p := range products {
+		for token := range nameTermCounts(p, preparer) {
+			corpus.frequency[token]++
+		}
+	}
+	return corpus
+}
+
+// idf returns the smoothed inverse document frequency of token
+// Tokens absent from the corpus get the highest IDF; a nil corpus weighs every
+// token 1.
+func (c *idfCorpus) idf(token string) float64 {
+	if c == nil {
+		return 1
+	}
+	return math.Log(float64(1+c.documents)/float64(1+c.frequency[token])) + 1
+}
+
+// vector returns the unit-length tf-idf vector of p's prepared name
+func (c *idfCorpus) vector(p *Product, preparer *textPreparer) tfidfVector {
+	counts := nameTermCounts(p, preparer)
+	vector := make(tfidfVector, 0, len(counts))
+	for token, count := range counts {
+		vector = append(vector, tfidfTerm{token: token, weight: float64(count) * c.idf(token)})
+	}
+	// Sorted terms make cosine a merge, summed in the same order every time
+	sort.Slice(vector, func(i, j int) bool { return vector[i].token < vector[j].token })
+	var norm float64
+	for _, term := range vector {
+		norm += term.weight * term.weight
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i].weight /= norm
+		}
+	}
+	return vector
+}
+
+// tfidfTerm is one token's weight in a tf-idf vector
+type tfidfTerm struct {
+	token  string
+	weight float64
+}
+
+// tfidfVector is a unit-length tf-idf vector, sorted by token
+type tfidfVector []tfidfTerm
+
+// cosine returns the cosine similarity of two unit vectors
+func (v tfidfVector) cosine(other tfidfVector) float64 {
+	var dot float64
+	for i, j := 0, 0; i < len(v) && j < len(other); {
+		switch {
+		case v[i].token < other[j].token:
+			i++
+		case v[i].token > other[j].token:
+			j++
+		default:
+			dot += v[i].weight * other[j].weight
+			i++
+			j++
+		}
+	}
+	return clampUnit(dot)
+}
+
+// nameTermCounts counts the tokens of p's prepared name
+func nameTermCounts(p *Product, preparer *textPreparer) map[string]int {
+	name, _ := p.preparedStrings(preparer)
+	counts := make(map[string]int)
+	for _, token := range synonymTokens(name) {
+		counts[token.text]++
+	}
+	return counts
+}
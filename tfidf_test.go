@@ -0,0 +1,202 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// phoneCatalog is a catalog where "Apple", "iPhone", "Pro", and "Max" are in
+// most names and model numbers, capacities, and part numbers are rare
+func phoneCatalog() []Product {
+	var products []Product
+	for i, model := range []string{"12", "13", "14", "15"} {
+		for j, variant := range []string{"", " Pro", " Pro Max"} {
+			for k, capacity := range []string{"128GB", "256GB"} {
+				products = append(products, Product{
+					ID:   fmt.Sprintf("IP%d%d%d", i, j, k),
+					Name: fmt.Sprintf("Apple iPhone %s%s %s", model, variant, capacity),
+				})
+			}
+		}
+	}
+	return append(products,
+		Product{ID: "S1", Name: "Samsung Galaxy S23 Ultra 256GB SM-S918B"},
+		Product{ID: "S2", Name: "Samsung Galaxy S23 Ultra 256GB SM-S918B Phantom Black"},
+	)
+}
+
+func TestTFIDFEngineRareTokensDominate(t *testing.T) {
+	catalog := phoneCatalog()
+	engine := NewTFIDFEngine()
+	if err := engine.FitCorpus(catalog); err != nil {
+		t.Fatal(err)
+	}
+	plain := NewLevenshteinEngine()
+	plain.DisableRabinKarpFilter()
+
+	tests := []struct {
+		name   string
+		a, b   Product
+		higher bool // Whether TF-IDF should score the pair above plain Levenshtein
+	}{
+		{
+			name:   "different models share a long prefix",
+			a:      Product{ID: "A", Name: "Apple iPhone 14 Pro Max 256GB"},
+			b:      Product{ID: "B", Name: "Apple iPhone 13 Pro Max 256GB"},
+			higher: false,
+		},
+		{
+			name:   "different capacities",
+			a:      Product{ID: "A", Name: "Apple iPhone 15 Pro 128GB"},
+			b:      Product{ID: "B", Name: "Apple iPhone 15 Pro 256GB"},
+			higher: false,
+		},
+		{
+			name:   "duplicate missing a common token",
+			a:      Product{ID: "A", Name: "Apple iPhone 14 Pro Max 256GB A2894"},
+			b:      Product{ID: "B", Name: "iPhone 14 Pro Max 256GB A2894"},
+			higher: true,
+		},
+		{
+			name:   "duplicate with common tokens reordered",
+			a:      Product{ID: "A", Name: "Apple iPhone 13 Pro 128GB"},
+			b:      Product{ID: "B", Name: "iPhone 13 Pro Apple 128GB"},
+			higher: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idf := engine.Compare(tt.a, tt.b).CombinedSimilarity
+			lev := plain.Compare(tt.a, tt.b).CombinedSimilarity
+			if tt.higher && idf <= lev {
+				t.Errorf("TF-IDF %.3f <= Levenshtein %.3f", idf, lev)
+			}
+			if !tt.higher && idf >= lev {
+				t.Errorf("TF-IDF %.3f >= Levenshtein %.3f", idf, lev)
+			}
+		})
+	}
+}
+
+func TestTFIDFEngineAlpha(t *testing.T) {
+	a := Product{ID: "A", Name: "Apple iPhone 14 Pro Max 256GB"}
+	b := Product{ID: "B", Name: "Apple iPhone 13 Pro Max 256GB"}
+	engine := NewTFIDFEngine()
+	if err := engine.FitCorpus(phoneCatalog()); err != nil {
+		t.Fatal(err)
+	}
+	plain := NewLevenshteinEngine()
+
+	var previous float64 = -1
+	for _, alpha := range []float64{0, 0.2, 0.5, 1} {
+		if err := engine.SetAlpha(alpha); err != nil {
+			t.Fatal(err)
+		}
+		got := engine.Compare(a, b).NameSimilarity
+		if got <= previous {
+			t.Errorf("alpha %.1f: name similarity %.3f did not rise from %.3f", alpha, got, previous)
+		}
+		previous = got
+	}
+	if want := plain.Compare(a, b).NameSimilarity; previous != want {
+		t.Errorf("alpha 1 = %.3f, want the Levenshtein score %.3f", previous, want)
+	}
+	for _, alpha := range []float64{-0.1, 1.1} {
+		if err := engine.SetAlpha(alpha); err == nil {
+			t.Errorf("SetAlpha(%v) accepted", alpha)
+		}
+	}
+}
+
+func TestTFIDFEngineFindDuplicates(t *testing.T) {
+	catalog := phoneCatalog()
+	samsung := PairKey("S1", "S2")
+
+	t.Run("fits the scanned products", func(t *testing.T) {
+		// Names of different iPhone models differ only in the model number
+		crossModel := func(results []ComparisonResult) int {
+			count := 0
+			for _, r := range results {
+				a, b := r.ProductA.ID, r.ProductB.ID
+				if a[:2] == "IP" && b[:2] == "IP" && a[2] != b[2] {
+					count++
+				}
+			}
+			return count
+		}
+		if crossModel(NewLevenshteinEngine().FindDuplicates(catalog, 0.8)) == 0 {
+			t.Fatal("expected Levenshtein to match different models")
+		}
+
+		engine := NewTFIDFEngine()
+		results := engine.FindDuplicates(catalog, 0.8)
+		if n := crossModel(results); n != 0 {
+			t.Errorf("TF-IDF matched %d pairs of different models", n)
+		}
+		found := false
+		for _, r := range results {
+			found = found || PairKey(r.ProductA.ID, r.ProductB.ID) == samsung
+		}
+		if !found {
+			t.Error("S1/S2 not found")
+		}
+		if engine.IsFitted() {
+			t.Error("FindDuplicates persisted a corpus")
+		}
+	})
+
+	t.Run("parallel scan matches sequential", func(t *testing.T) {
+		engine := NewTFIDFEngine()
+		if err := engine.FitCorpus(catalog); err != nil {
+			t.Fatal(err)
+		}
+		large := append(lengthDiverseCatalog(60), catalog...)
+		var sequential []ComparisonResult
+		for i := range large {
+			for j := i + 1; j < len(large); j++ {
+				r := engine.Compare(large[i], large[j])
+				if r.stampThreshold(0.8); r.MeetsThreshold {
+					sequential = append(sequential, r)
+				}
+			}
+		}
+		if len(sequential) == 0 {
+			t.Fatal("expected matches to compare")
+		}
+		got := comparableResults(engine.FindDuplicates(large, 0.8))
+		if want := comparableResults(sequential); !reflect.DeepEqual(got, want) {
+			t.Errorf("parallel scan found %d pairs, pairwise Compare %d", len(got), len(want))
+		}
+	})
+
+	t.Run("find for one uses the fitted corpus", func(t *testing.T) {
+		engine := NewTFIDFEngine()
+		if results := engine.FindDuplicatesForOne(catalog[0], 0.5); results != nil {
+			t.Errorf("unfitted engine returned %d results", len(results))
+		}
+		if err := engine.FitCorpus(catalog); err != nil {
+			t.Fatal(err)
+		}
+		query := Product{ID: "NEW", Name: "Samsung Galaxy S23 Ultra SM-S918B 256GB"}
+		results := engine.FindDuplicatesForOne(query, 0.8)
+		if len(results) != 2 {
+			t.Fatalf("got %d matches, want S1 and S2", len(results))
+		}
+		for _, r := range engine.FindDuplicatesForOne(catalog[len(catalog)-1], 0) {
+			if r.ProductB.ID == r.ProductA.ID {
+				t.Error("product matched itself")
+			}
+		}
+	})
+
+	t.Run("repeated IDs", func(t *testing.T) {
+		repeated := append(append([]Product(nil), catalog...), catalog[0])
+		if _, err := NewTFIDFEngine().FindDuplicatesChecked(repeated, 0.8); err == nil {
+			t.Error("expected a DuplicateIDError")
+		}
+		if err := NewTFIDFEngine().FitCorpus(repeated); err == nil {
+			t.Error("FitCorpus accepted repeated IDs")
+		}
+	})
+}
@@ -0,0 +1,318 @@
+package duplicatecheck
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Defaults of SuggestOptions
+const (
+	DefaultSuggestCandidatePairs = 5000 // LSH candidate pairs sampled per group
+	DefaultSuggestRandomPairs    = 2000 // Random pairs sampled per group
+	DefaultSuggestBins           = 40   // Histogram bins over [0.0-1.0]
+)
+
+// minSuggestClassPairs is the fewest sampled pairs each side of a suggested
+// threshold must hold for SuggestThresholdsByGroup to suggest it
+const minSuggestClassPairs = 3
+
+// SuggestOptions controls SuggestThresholdsByGroup
+type SuggestOptions struct {
+	// NewEngine returns the engine scoring one group's pairs, indexing the
+	// group for its candidates (nil = NewHybridEngine)
+	NewEngine func() *HybridEngine
+	// CandidatePairs caps the LSH candidate pairs sampled per group, which
+	// carry the high tail (0 = DefaultSuggestCandidatePairs)
+	CandidatePairs int
+	// RandomPairs is the number of random pairs sampled per group, which
+	// carry the bulk of non-duplicates (0 = DefaultSuggestRandomPairs)
+	RandomPairs int
+	// Bins is the number of histogram bins over [0.0-1.0] (0 = DefaultSuggestBins)
+	Bins int
+	// Seed picks the sampled pairs: the same catalog, options and seed
+	// suggest the same thresholds
+	Seed int64
+}
+
+// HistogramBin counts the sampled pairs of one similarity range
+type HistogramBin struct {
+	Min   float64 `json:"min"`   // Lowest CombinedSimilarity in the bin
+	Max   float64 `json:"max"`   // Upper bound, exclusive except for the last bin
+	Pairs int     `json:"pairs"` // Sampled pairs in the bin
+}
+
+// ThresholdSuggestion is the threshold SuggestThresholdsByGroup suggests for
+// one group, with the sample it was read from
+// Confidence is 0, and Threshold 0, when the sample shows no separate high
+// tail: too few pairs, or no split leaving minSuggestClassPairs on each side.
+type ThresholdSuggestion struct {
+	Group          string  `json:"group"`
+	Products       int     `json:"products"`        // Products in the group
+	Pairs          int     `json:"pairs"`           // Pairs sampled
+	CandidatePairs int     `json:"candidate_pairs"` // Of which LSH candidates
+	Threshold      float64 `json:"threshold"`       // Suggested CombinedSimilarity cutoff
+	TailPairs      int     `json:"tail_pairs"`      // Sampled pairs at or above Threshold
+	// Separation is the share of the sample's variance the split between
+	// the bulk and the tail explains (Otsu's criterion, 0.0-1.0)
+	Separation float64 `json:"separation"`
+	// ValleyDepth is how far the histogram dips at Threshold below the
+	// lower of the bulk's and the tail's peaks (0.0-1.0; 0 = no valley)
+	ValleyDepth float64 `json:"valley_depth"`
+	// Confidence is Separation times ValleyDepth: high for a clear gap
+	// between two peaks, low for a tail fading out of the bulk
+	Confidence float64        `json:"confidence"`
+	Histogram  []HistogramBin `json:"histogram"`
+}
+
+// SuggestThresholdsByGroup suggests a CombinedSimilarity threshold for each
+// group groupFn puts products in, without labels
+// Each group's pairs are sampled two ways: its LSH candidates, which hold the
+// likely duplicates, and random pairs, which hold the bulk of non-duplicates.
+// Their similarities are binned, split in two classes where the split
+// explains most of the variance (Otsu's method), and the threshold is put at
+// the middle of the lowest run of bins below the tail's peak, down to the
+// bulk's (the valley), or at the split itself when the histogram doesn't dip
+// between them (the knee).
+// This is an unsupervised heuristic: treat the thresholds as starting points
+// to check against the attached histograms or, better, against labeled
+// pairs with FitCalibration. SuggestedThresholdRule turns them into a rule.
+// A group whose index can't be built (see DuplicateIDPolicy) is sampled by
+// random pairs only.
+func SuggestThresholdsByGroup(products []Product, groupFn func(Product) string, opts SuggestOptions) map[string]ThresholdSuggestion {
+	groups := make(map[string][]Product)
+	for _, p := range products {
+		group := groupFn(p)
+		groups[group] = append(groups[group], p)
+	}
+	suggestions := make(map[string]ThresholdSuggestion, len(groups))
+	for group, members := range groups {
+		suggestion := suggestThreshold(sampleGroupSimilarities(members, opts), opts)
+		suggestion.Group = group
+		suggestion.Products = len(members)
+		suggestions[group] = suggestion
+	}
+	return suggestions
+}
+
+// groupSample is the similarities sampled from one group
+type groupSample struct {
+	similarities []float64
+	candidates   int // Leading similarities from LSH candidates
+}
+
+// sampleGroupSimilarities scores a group's LSH candidate pairs and random
+// pairs, each pair once
+func sampleGroupSimilarities(products []Product, opts SuggestOptions) groupSample {
+	var sample groupSample
+	if len(products) < 2 {
+		return sample
+	}
+	newEngine := opts.NewEngine
+	if newEngine == nil {
+		newEngine = NewHybridEngine
+	}
+	maxCandidates := opts.CandidatePairs
+	if maxCandidates <= 0 {
+		maxCandidates = DefaultSuggestCandidatePairs
+	}
+	randomPairs := opts.RandomPairs
+	if randomPairs <= 0 {
+		randomPairs = DefaultSuggestRandomPairs
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	engine := newEngine()
+	seen := make(map[string]bool)
+
+	if err := engine.BuildIndex(products); err == nil {
+		candidates := engine.FindDuplicates(products, 0)
+		// Scan order varies with parallelism; the sample mustn't
+		sort.Slice(candidates, func(i, j int) bool {
+			return PairKey(candidates[i].ProductA.ID, candidates[i].ProductB.ID) < PairKey(candidates[j].ProductA.ID, candidates[j].ProductB.ID)
+		})
+		if len(candidates) > maxCandidates {
+			rng.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+			candidates = candidates[:maxCandidates]
+		}
+		for _, r := range candidates {
+			seen[PairKey(r.ProductA.ID, r.ProductB.ID)] = true
+			sample.similarities = append(sample.similarities, r.CombinedSimilarity)
+		}
+		sample.candidates = len(sample.similarities)
+	}
+
+	// Scored in full: Compare stops early below the engine's threshold
+	scorer := engine.levenshteinEngine
+	score := func(i, j int) {
+		a, b := &products[i], &products[j]
+		if key := PairKey(a.ID, b.ID); !seen[key] {
+			seen[key] = true
+			result := scorer.compareWithWeights(a, b, scorer.resolveWeights(a, b), memoPair{}, 0)
+			sample.similarities = append(sample.similarities, result.CombinedSimilarity)
+		}
+	}
+	n := len(products)
+	if n*(n-1)/2 <= randomPairs {
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				score(i, j)
+			}
+		}
+		return sample
+	}
+	for k := 0; k < randomPairs; k++ {
+		i, j := rng.Intn(n), rng.Intn(n-1)
+		if j >= i {
+			j++
+		}
+		score(i, j)
+	}
+	return sample
+}
+
+// suggestThreshold bins a group's sample and reads a threshold from it
+func suggestThreshold(sample groupSample, opts SuggestOptions) ThresholdSuggestion {
+	bins := opts.Bins
+	if bins <= 0 {
+		bins = DefaultSuggestBins
+	}
+	suggestion := ThresholdSuggestion{
+		Pairs:          len(sample.similarities),
+		CandidatePairs: sample.candidates,
+		Histogram:      make([]HistogramBin, bins),
+	}
+	counts := make([]float64, bins)
+	for i := range suggestion.Histogram {
+		suggestion.Histogram[i].Min = float64(i) / float64(bins)
+		suggestion.Histogram[i].Max = float64(i+1) / float64(bins)
+	}
+	for _, s := range sample.similarities {
+		bin := int(clampUnit(s) * float64(bins))
+		if bin >= bins {
+			bin = bins - 1
+		}
+		suggestion.Histogram[bin].Pairs++
+		counts[bin]++
+	}
+
+	split, separation := otsuSplit(counts)
+	if split == 0 {
+		return suggestion
+	}
+	low, high := 0, split // Peaks of the bulk and the tail
+	for i := range counts {
+		if i < split && counts[i] > counts[low] {
+			low = i
+		}
+		if i >= split && counts[i] > counts[high] {
+			high = i
+		}
+	}
+	// The valley is the run of the emptiest bins between the peaks nearest
+	// the tail: duplicates sit just above it. Without bins between the peaks
+	// the split is the knee.
+	start, end := split, split // Bins [start, end) of the valley
+	if high-low > 1 {
+		valley := counts[high-1]
+		for i := low + 1; i < high; i++ {
+			valley = math.Min(valley, counts[i])
+		}
+		end = high
+		for counts[end-1] != valley {
+			end--
+		}
+		start = end - 1
+		for start > low+1 && counts[start-1] == valley {
+			start--
+		}
+		suggestion.ValleyDepth = 1 - valley/math.Min(counts[low], counts[high])
+	}
+	threshold := float64(start+end) / 2 / float64(bins)
+	tail := 0
+	for _, s := range sample.similarities {
+		if meetsThreshold(s, threshold) {
+			tail++
+		}
+	}
+	if tail < minSuggestClassPairs || len(sample.similarities)-tail < minSuggestClassPairs {
+		return suggestion
+	}
+	suggestion.Threshold = threshold
+	suggestion.TailPairs = tail
+	suggestion.Separation = separation
+	suggestion.Confidence = separation * suggestion.ValleyDepth
+	return suggestion
+}
+
+// otsuSplit returns the bin splitting counts in the two classes with the
+// largest between-class variance, and the share of the total variance that
+// is; 0 when no split leaves minSuggestClassPairs in each class
+func otsuSplit(counts []float64) (int, float64) {
+	var total, sum, sumSquares float64
+	for i, c := range counts {
+		center := float64(i) + 0.5
+		total += c
+		sum += c * center
+		sumSquares += c * center * center
+	}
+	if total < 2*minSuggestClassPairs {
+		return 0, 0
+	}
+	variance := sumSquares/total - (sum/total)*(sum/total)
+	best, bestBetween := 0, 0.0
+	var lowCount, lowSum float64
+	for k := 1; k < len(counts); k++ {
+		lowCount += counts[k-1]
+		lowSum += counts[k-1] * (float64(k-1) + 0.5)
+		highCount := total - lowCount
+		if lowCount < minSuggestClassPairs || highCount < minSuggestClassPairs {
+			continue
+		}
+		meanLow, meanHigh := lowSum/lowCount, (sum-lowSum)/highCount
+		if between := lowCount * highCount / (total * total) * (meanHigh - meanLow) * (meanHigh - meanLow); between > bestBetween {
+			best, bestBetween = k, between
+		}
+	}
+	if best == 0 || variance == 0 {
+		return 0, 0
+	}
+	return best, bestBetween / variance
+}
+
+// suggestedThresholdRule matches pairs reaching the threshold of both their
+// products' groups
+type suggestedThresholdRule struct {
+	groupFn    func(Product) string
+	thresholds map[string]float64
+	fallback   float64
+	floor      float64 // Lowest threshold the rule applies (see ruleFloor)
+}
+
+// SuggestedThresholdRule returns a rule matching pairs whose CombinedSimilarity
+// reaches the suggested threshold of each product's group, for
+// FindDuplicatesByRule
+// Groups without a suggestion, or with one below minConfidence, use
+// fallback. The rule is custom: MarshalRule can't encode it.
+func SuggestedThresholdRule(suggestions map[string]ThresholdSuggestion, groupFn func(Product) string, minConfidence, fallback float64) MatchRule {
+	rule := suggestedThresholdRule{groupFn: groupFn, thresholds: make(map[string]float64), fallback: fallback, floor: fallback}
+	for group, s := range suggestions {
+		if s.Confidence > 0 && s.Confidence >= minConfidence {
+			rule.thresholds[group] = s.Threshold
+			rule.floor = math.Min(rule.floor, s.Threshold)
+		}
+	}
+	return rule
+}
+
+func (r suggestedThresholdRule) Evaluate(result ComparisonResult) bool {
+	return meetsThreshold(result.CombinedSimilarity, r.threshold(result.ProductA)) &&
+		meetsThreshold(result.CombinedSimilarity, r.threshold(result.ProductB))
+}
+
+// threshold returns the threshold of p's group
+func (r suggestedThresholdRule) threshold(p Product) float64 {
+	if threshold, ok := r.thresholds[r.groupFn(p)]; ok {
+		return threshold
+	}
+	return r.fallback
+}
@@ -0,0 +1,156 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// suggestCatalog returns two groups with different duplicate tails:
+// "electronics" has long, distinct names whose duplicates are near copies,
+// "fashion" short, generic names whose duplicates are rewordings
+func suggestCatalog() []Product {
+	rng := rand.New(rand.NewSource(7))
+	pick := func(words []string) string { return words[rng.Intn(len(words))] }
+	var catalog []Product
+
+	brands := []string{"Samsung", "Sony", "Lenovo", "Apple", "Asus", "Philips", "Garmin", "Canon"}
+	lines := []string{"Galaxy", "Bravia", "ThinkPad", "MacBook", "ZenBook", "Hue", "Forerunner", "EOS"}
+	kinds := []string{"smartphone", "television", "laptop", "smartwatch", "camera", "speaker"}
+	for i := 0; i < 120; i++ {
+		kind := pick(kinds)
+		p := Product{
+			ID: fmt.Sprintf("E%03d", i),
+			Name: fmt.Sprintf("%s %s %c%d %dGB %s %s", pick(brands), pick(lines), 'A'+rune(rng.Intn(26)), 100+rng.Intn(900),
+				[]int{64, 128, 256, 512}[rng.Intn(4)], pick([]string{"Black", "Silver", "Blue", "Graphite"}), kind),
+			Description: fmt.Sprintf("%s with %d-inch display, %d hours of battery and model code %06d",
+				kind, 5+rng.Intn(60), 8+rng.Intn(40), rng.Intn(1000000)),
+		}
+		catalog = append(catalog, p)
+		if i%4 == 0 {
+			// A reseller's copy with trivial edits
+			catalog = append(catalog, Product{ID: p.ID + "-copy", Name: p.Name + ".", Description: strings.Replace(p.Description, " and ", " & ", 1)})
+		}
+	}
+
+	colors := []string{"Blue", "Red", "Black", "White", "Green"}
+	materials := []string{"cotton", "linen", "wool", "denim"}
+	garments := []string{"shirt", "dress", "jacket", "skirt", "scarf", "sweater"}
+	for i := 0; i < len(colors)*len(materials)*len(garments); i++ {
+		color, material, garment := colors[i%5], materials[i/5%4], garments[i/20]
+		p := Product{
+			ID:          fmt.Sprintf("F%03d", i),
+			Name:        fmt.Sprintf("%s %s %s", color, material, garment),
+			Description: fmt.Sprintf("Casual %s %s for everyday wear", material, garment),
+		}
+		catalog = append(catalog, p)
+		if i%4 == 0 {
+			// Another seller's wording of the same item
+			catalog = append(catalog, Product{ID: p.ID + "-copy",
+				Name:        fmt.Sprintf("%s %s in %s", strings.ToUpper(material[:1])+material[1:], garment, strings.ToLower(color)),
+				Description: fmt.Sprintf("Everyday casual %s made of %s", garment, material)})
+		}
+	}
+	return catalog
+}
+
+// category groups suggestCatalog's products
+func category(p Product) string {
+	if strings.HasPrefix(p.ID, "E") {
+		return "electronics"
+	}
+	return "fashion"
+}
+
+func TestSuggestThresholdsByGroup(t *testing.T) {
+	catalog := suggestCatalog()
+	suggestions := SuggestThresholdsByGroup(catalog, category, SuggestOptions{Seed: 1})
+	if len(suggestions) != 2 {
+		t.Fatalf("suggestions for %d groups, want 2", len(suggestions))
+	}
+	electronics, fashion := suggestions["electronics"], suggestions["fashion"]
+	t.Logf("electronics %.3f (confidence %.2f), fashion %.3f (confidence %.2f)",
+		electronics.Threshold, electronics.Confidence, fashion.Threshold, fashion.Confidence)
+
+	for _, s := range []ThresholdSuggestion{electronics, fashion} {
+		if s.Products != 150 || s.Pairs == 0 || s.CandidatePairs == 0 || s.CandidatePairs > s.Pairs {
+			t.Errorf("%s: %d products, %d pairs of which %d candidates", s.Group, s.Products, s.Pairs, s.CandidatePairs)
+		}
+		if len(s.Histogram) != DefaultSuggestBins {
+			t.Fatalf("%s: %d bins, want %d", s.Group, len(s.Histogram), DefaultSuggestBins)
+		}
+		// The threshold may fall inside a bin
+		binned, above, reaching := 0, 0, 0
+		for _, bin := range s.Histogram {
+			binned += bin.Pairs
+			if bin.Min >= s.Threshold {
+				above += bin.Pairs
+			}
+			if bin.Max > s.Threshold {
+				reaching += bin.Pairs
+			}
+		}
+		if binned != s.Pairs || s.TailPairs < above || s.TailPairs > reaching {
+			t.Errorf("%s: histogram holds %d pairs, %d-%d from the threshold; want %d and %d",
+				s.Group, binned, above, reaching, s.Pairs, s.TailPairs)
+		}
+		if s.Confidence <= 0 || s.Confidence > 1 || s.Confidence != s.Separation*s.ValleyDepth {
+			t.Errorf("%s: confidence %v from separation %v and valley %v", s.Group, s.Confidence, s.Separation, s.ValleyDepth)
+		}
+	}
+	// The near copies of long names stand apart: a high threshold catches
+	// them all. Short generic names need a lower one, and separate less clearly.
+	if electronics.Threshold < 0.85 || electronics.TailPairs != 30 {
+		t.Errorf("electronics: threshold %v with %d tail pairs, want >= 0.85 and the 30 copies", electronics.Threshold, electronics.TailPairs)
+	}
+	if fashion.Threshold > electronics.Threshold-0.1 || fashion.Confidence >= electronics.Confidence {
+		t.Errorf("fashion threshold %v (confidence %v), want well below electronics' %v (%v)",
+			fashion.Threshold, fashion.Confidence, electronics.Threshold, electronics.Confidence)
+	}
+
+	if again := SuggestThresholdsByGroup(catalog, category, SuggestOptions{Seed: 1}); !reflect.DeepEqual(again, suggestions) {
+		t.Error("the same seed suggested differently")
+	}
+	lone := SuggestThresholdsByGroup(catalog[:1], category, SuggestOptions{Bins: 10})["electronics"]
+	if lone.Pairs != 0 || lone.Threshold != 0 || lone.Confidence != 0 || len(lone.Histogram) != 10 {
+		t.Errorf("single product: %+v", lone)
+	}
+}
+
+func TestSuggestedThresholdRule(t *testing.T) {
+	catalog := suggestCatalog()
+	suggestions := SuggestThresholdsByGroup(catalog, category, SuggestOptions{Seed: 1})
+	const fallback = 0.85
+	rule := SuggestedThresholdRule(suggestions, category, 0.5, fallback)
+	if floor, want := ruleFloor(rule), suggestions["fashion"].Threshold; floor != want {
+		t.Errorf("rule floor %v, want the fashion threshold %v", floor, want)
+	}
+
+	results := NewLevenshteinEngine().FindDuplicatesByRule(catalog, rule)
+	lowFashion := false
+	for _, r := range results {
+		for _, p := range []Product{r.ProductA, r.ProductB} {
+			if want := suggestions[category(p)].Threshold; r.CombinedSimilarity < want {
+				t.Fatalf("%s-%s at %v is below %s's threshold %v", r.ProductA.ID, r.ProductB.ID, r.CombinedSimilarity, category(p), want)
+			}
+		}
+		lowFashion = lowFashion || r.CombinedSimilarity < fallback
+	}
+	if !lowFashion {
+		t.Error("no fashion pair below the fallback matched")
+	}
+
+	// Below minConfidence, groups fall back
+	strict := SuggestedThresholdRule(suggestions, category, 1.1, fallback)
+	for _, r := range NewLevenshteinEngine().FindDuplicatesByRule(catalog, strict) {
+		if r.CombinedSimilarity < fallback {
+			t.Fatalf("%s-%s at %v matched below the fallback", r.ProductA.ID, r.ProductB.ID, r.CombinedSimilarity)
+		}
+	}
+	if _, err := MarshalRule(rule); !errors.Is(err, ErrRuleNotSerializable) {
+		t.Errorf("MarshalRule = %v, want ErrRuleNotSerializable", err)
+	}
+}
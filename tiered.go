@@ -0,0 +1,112 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidTiers is returned by FindDuplicatesTiered for tiers that are
+// empty, outside (0.0-1.0], or not in decreasing order
+var ErrInvalidTiers = errors.New("duplicatecheck: tiers must be in (0, 1] and in decreasing order")
+
+// ResultTier is one severity tier of a FindDuplicatesTiered scan
+type ResultTier struct {
+	Threshold float64            // Lowest CombinedSimilarity in the tier
+	Results   []ComparisonResult // Pairs at or above Threshold and below the next tier up
+}
+
+// TieredResults holds the matches of a FindDuplicatesTiered scan, each in the
+// highest tier it clears
+type TieredResults struct {
+	Tiers []ResultTier // One per requested tier, highest threshold first
+}
+
+// Count returns the number of matches across all tiers
+func (r TieredResults) Count() int {
+	count := 0
+	for _, tier := range r.Tiers {
+		count += len(tier.Results)
+	}
+	return count
+}
+
+// All returns the matches of every tier, highest tier first
+func (r TieredResults) All() []ComparisonResult {
+	all := make([]ComparisonResult, 0, r.Count())
+	for _, tier := range r.Tiers {
+		all = append(all, tier.Results...)
+	}
+	return all
+}
+
+// validateTiers checks tiers for FindDuplicatesTiered
+func validateTiers(tiers []float64) error {
+	if len(tiers) == 0 {
+		return fmt.Errorf("%w: no tiers", ErrInvalidTiers)
+	}
+	for i, tier := range tiers {
+		if math.IsNaN(tier) || tier <= 0 || tier > 1 {
+			return fmt.Errorf("%w: tier %v", ErrInvalidTiers, tier)
+		}
+		if i > 0 && tier >= tiers[i-1] {
+			return fmt.Errorf("%w: %v follows %v", ErrInvalidTiers, tier, tiers[i-1])
+		}
+	}
+	return nil
+}
+
+// bucketByTier places each result in the highest tier it clears and stamps it
+// with that tier's threshold
+// Every result must clear the lowest tier.
+func bucketByTier(results []ComparisonResult, tiers []float64) TieredResults {
+	tiered := TieredResults{Tiers: make([]ResultTier, len(tiers))}
+	for i, tier := range tiers {
+		tiered.Tiers[i].Threshold = tier
+	}
+	for _, result := range results {
+		for i, tier := range tiers {
+			if meetsThreshold(result.CombinedSimilarity, tier) {
+				result.stampThreshold(tier)
+				tiered.Tiers[i].Results = append(tiered.Tiers[i].Results, result)
+				break
+			}
+		}
+	}
+	return tiered
+}
+
+// FindDuplicatesTiered finds duplicates at several thresholds in one scan and
+// returns each match in the highest tier it clears
+// tiers run from the highest threshold down, such as {0.97, 0.88, 0.80} for
+// auto-merge, review and watch. The scan is FindDuplicates at the lowest tier,
+// so length pruning and the other early exits work as for that threshold, and
+// each result's ThresholdUsed is its tier's threshold. Returns ErrInvalidTiers
+// for tiers that are empty, outside (0.0-1.0] or not decreasing, and a
+// *DuplicateIDError under DuplicateIDReject.
+func (e *LevenshteinEngine) FindDuplicatesTiered(products []Product, tiers []float64) (_ TieredResults, err error) {
+	defer e.guard("FindDuplicatesTiered", &err)
+	if err := validateTiers(tiers); err != nil {
+		return TieredResults{}, err
+	}
+	results, err := e.FindDuplicatesChecked(products, tiers[len(tiers)-1])
+	if err != nil {
+		return TieredResults{}, err
+	}
+	return bucketByTier(results, tiers), nil
+}
+
+// FindDuplicatesTiered finds duplicates at several thresholds in one scan (see
+// LevenshteinEngine.FindDuplicatesTiered)
+// Candidates are generated and verified once, at the lowest tier.
+func (e *HybridEngine) FindDuplicatesTiered(products []Product, tiers []float64) (_ TieredResults, err error) {
+	defer e.levenshteinEngine.guard("FindDuplicatesTiered", &err)
+	if err := validateTiers(tiers); err != nil {
+		return TieredResults{}, err
+	}
+	results, err := e.FindDuplicatesChecked(products, tiers[len(tiers)-1])
+	if err != nil {
+		return TieredResults{}, err
+	}
+	return bucketByTier(results, tiers), nil
+}
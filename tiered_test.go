@@ -0,0 +1,98 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicatesTiered(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 80)
+	tiers := []float64{0.97, 0.88, 0.80}
+	lowest := tiers[len(tiers)-1]
+
+	levenshtein, hybrid := NewLevenshteinEngine(), NewHybridEngine()
+	engines := []struct {
+		name   string
+		engine interface {
+			DuplicateCheckEngine
+			FindDuplicatesTiered([]Product, []float64) (TieredResults, error)
+		}
+		stats func() map[string]interface{}
+	}{
+		{"Levenshtein", levenshtein, levenshtein.GetScanStats},
+		{"Hybrid", hybrid, hybrid.levenshteinEngine.GetScanStats},
+	}
+	for _, tt := range engines {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.stats()["name_comparisons"].(uint64)
+			tiered, err := tt.engine.FindDuplicatesTiered(catalog, tiers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tieredWork := tt.stats()["name_comparisons"].(uint64) - before
+			single := tt.engine.FindDuplicates(catalog, lowest)
+			singleWork := tt.stats()["name_comparisons"].(uint64) - before - tieredWork
+			if tieredWork != singleWork {
+				t.Errorf("Tiered scan compared %d names, one scan at %v compared %d", tieredWork, lowest, singleWork)
+			}
+
+			if len(tiered.Tiers) != len(tiers) {
+				t.Fatalf("%d tiers, want %d", len(tiered.Tiers), len(tiers))
+			}
+			seen := make(map[string]int)
+			for i, tier := range tiered.Tiers {
+				if tier.Threshold != tiers[i] {
+					t.Errorf("Tier %d threshold %v, want %v", i, tier.Threshold, tiers[i])
+				}
+				for _, r := range tier.Results {
+					if r.CombinedSimilarity < tiers[i] || (i > 0 && r.CombinedSimilarity >= tiers[i-1]) {
+						t.Errorf("%s/%s scored %v in tier %v", r.ProductA.ID, r.ProductB.ID, r.CombinedSimilarity, tiers[i])
+					}
+					if r.ThresholdUsed != tiers[i] || !r.MeetsThreshold {
+						t.Errorf("%s/%s: ThresholdUsed %v in tier %v", r.ProductA.ID, r.ProductB.ID, r.ThresholdUsed, tiers[i])
+					}
+					seen[PairKey(r.ProductA.ID, r.ProductB.ID)]++
+				}
+			}
+			for key, n := range seen {
+				if n != 1 {
+					t.Errorf("Pair %s in %d tiers", key, n)
+				}
+			}
+			if tiered.Count() == 0 || len(tiered.Tiers[0].Results) == 0 {
+				t.Fatal("Expected matches, including exact copies in the top tier")
+			}
+
+			all := tiered.All()
+			for i := range all {
+				all[i].stampThreshold(lowest)
+			}
+			if got, want := CanonicalizeResults(all), CanonicalizeResults(single); !reflect.DeepEqual(got, want) {
+				t.Errorf("Tiers hold %d matches, one scan at %v found %d", len(got), lowest, len(want))
+			}
+		})
+	}
+}
+
+func TestFindDuplicatesTieredInvalid(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, 20)
+	for _, tiers := range [][]float64{
+		nil,
+		{0.8, 0.9},
+		{0.9, 0.9},
+		{1.1, 0.8},
+		{0.9, 0},
+		{0.9, -0.1},
+	} {
+		if _, err := NewLevenshteinEngine().FindDuplicatesTiered(catalog, tiers); !errors.Is(err, ErrInvalidTiers) {
+			t.Errorf("Levenshtein %v: %v, want ErrInvalidTiers", tiers, err)
+		}
+		if _, err := NewHybridEngine().FindDuplicatesTiered(catalog, tiers); !errors.Is(err, ErrInvalidTiers) {
+			t.Errorf("Hybrid %v: %v, want ErrInvalidTiers", tiers, err)
+		}
+	}
+	if _, err := NewLevenshteinEngine().FindDuplicatesTiered(catalog, []float64{1}); err != nil {
+		t.Errorf("Single tier at 1: %v", err)
+	}
+}
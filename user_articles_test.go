@@ -189,51 +189,19 @@ func BenchmarkUserArticleScanning(b *testing.B) {
 func generateUserArticles(count int) []Product {
 	articles := make([]Product, count)
 
-	// Article topics and templates
-	topics := []string{
-		"Understanding",
-		"Complete Guide to",
-		"Introduction to",
-		"Advanced Techniques in",
-		"Best Practices for",
-		"How to Master",
-		"Deep Dive into",
-		"Exploring",
-	}
-
-	subjects := []string{
-		"Machine Learning",
-		"Web Development",
-		"Cloud Computing",
-		"Data Science",
-		"Artificial Intelligence",
-		"Blockchain Technology",
-		"Cybersecurity",
-		"DevOps",
-		"Mobile Development",
-		"Database Design",
-		"API Development",
-		"Microservices Architecture",
-		"Container Orchestration",
-		"Serverless Computing",
-		"GraphQL",
-	}
-
-	years := []string{"2023", "2024", "2025"}
-
 	// Generate diverse articles
 	for i := 0; i < count; i++ {
-		topicIdx := i % len(topics)
-		subjectIdx := (i / len(topics)) % len(subjects)
-		yearIdx := i % len(years)
+		topicIdx := i % len(articleTopics)
+		subjectIdx := (i / len(articleTopics)) % len(articleSubjects)
+		yearIdx := i % len(articleYears)
 
 		title := fmt.Sprintf("%s %s in %s",
-			topics[topicIdx],
-			subjects[subjectIdx],
-			years[yearIdx])
+			articleTopics[topicIdx],
+			articleSubjects[subjectIdx],
+			articleYears[yearIdx])
 
 		// Generate description with variation
-		description := generateArticleDescription(subjects[subjectIdx], i)
+		description := articleDescription(articleSubjects[subjectIdx], i)
 
 		// Add a near-duplicate for testing (article #250)
 		if i == 250 {
@@ -254,50 +222,6 @@ func generateUserArticles(count int) []Product {
 	return articles
 }
 
-// generateArticleDescription creates varied article descriptions
-func generateArticleDescription(subject string, seed int) string {
-	templates := []string{
-		"%s has become increasingly important in modern software development. " +
-			"This article explores the key concepts, best practices, and real-world applications. " +
-			"We cover everything from basic principles to advanced techniques that professionals use daily. " +
-			"Whether you're just starting out or looking to deepen your expertise, this guide provides " +
-			"valuable insights and practical examples. Learn how to apply these concepts in your projects " +
-			"and stay ahead of the curve in this rapidly evolving field.",
-
-		"In the ever-changing landscape of technology, %s stands out as a critical skill. " +
-			"This comprehensive guide breaks down complex topics into digestible sections. " +
-			"We examine industry trends, common challenges, and proven solutions that work. " +
-			"Through detailed examples and step-by-step tutorials, you'll gain hands-on experience. " +
-			"Discover tools, frameworks, and methodologies that leading companies use to build scalable solutions.",
-
-		"Master %s with this in-depth tutorial covering fundamentals to advanced concepts. " +
-			"We've compiled insights from industry experts and real-world case studies. " +
-			"Learn optimization techniques, performance best practices, and security considerations. " +
-			"This guide includes code samples, architectural patterns, and troubleshooting tips. " +
-			"Perfect for developers looking to enhance their skills and build production-ready applications.",
-
-		"Explore the world of %s through practical examples and clear explanations. " +
-			"This article demystifies complex concepts and provides actionable knowledge. " +
-			"From setup and configuration to deployment and monitoring, we cover the complete lifecycle. " +
-			"Understand trade-offs, make informed decisions, and avoid common pitfalls. " +
-			"Includes comparison with alternatives and recommendations for different use cases.",
-	}
-
-	templateIdx := seed % len(templates)
-	description := fmt.Sprintf(templates[templateIdx], subject)
-
-	// Add some variation based on seed
-	if seed%3 == 0 {
-		description += " Updated with the latest features and industry standards. " +
-			"Includes bonus section on emerging trends and future predictions."
-	} else if seed%5 == 0 {
-		description += " Features interviews with senior engineers and technical leaders. " +
-			"Real production examples from Fortune 500 companies."
-	}
-
-	return description
-}
-
 // TestUserArticleWithCustomWeights tests article comparison with different weighting strategies
 func TestUserArticleWithCustomWeights(t *testing.T) {
 	article1 := Product{
@@ -0,0 +1,336 @@
+package duplicatecheck
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SpecDimension is a kind of attribute spec tokens are extracted for
+type SpecDimension int
+
+const (
+	// SpecStorage is a capacity: "256GB", "1 TB"
+	SpecStorage SpecDimension = iota
+	// SpecColor is a color, with its modifiers: "Black", "Phantom Black", "Space Gray"
+	SpecColor
+	// SpecSize is a clothing size or a length: "XL", "13.4-inch", "55\""
+	SpecSize
+	// SpecModel is any other word with a digit: "14", "S23", "WH-1000XM5"
+	SpecModel
+)
+
+// String returns the dimension name
+func (d SpecDimension) String() string {
+	switch d {
+	case SpecStorage:
+		return "storage"
+	case SpecColor:
+		return "color"
+	case SpecSize:
+		return "size"
+	case SpecModel:
+		return "model"
+	default:
+		return "unknown"
+	}
+}
+
+// SpecTokens are the attribute tokens of a text by dimension, lowercase,
+// deduplicated and sorted
+type SpecTokens map[SpecDimension][]string
+
+// Conflicts returns the dimensions, in order, in which both t and other have
+// tokens and the tokens differ
+// A dimension only one side mentions isn't a conflict: it is missing, not different.
+func (t SpecTokens) Conflicts(other SpecTokens) []SpecDimension {
+	var conflicts []SpecDimension
+	for d := SpecStorage; d <= SpecModel; d++ {
+		a, b := t[d], other[d]
+		if len(a) == 0 || len(b) == 0 || strings.Join(a, " ") == strings.Join(b, " ") {
+			continue
+		}
+		conflicts = append(conflicts, d)
+	}
+	return conflicts
+}
+
+// baseColors are the color words SpecColor recognizes, with their spellings
+var baseColors = map[string]string{
+	"black": "black", "white": "white", "silver": "silver", "gold": "gold",
+	"grey": "grey", "gray": "grey", "blue": "blue", "red": "red", "green": "green",
+	"pink": "pink", "purple": "purple", "yellow": "yellow", "orange": "orange",
+	"brown": "brown", "beige": "beige", "graphite": "graphite", "titanium": "titanium",
+	"navy": "navy", "teal": "teal", "cream": "cream", "ivory": "ivory", "lavender": "lavender",
+	"midnight": "midnight", "starlight": "starlight", "bronze": "bronze", "copper": "copper",
+}
+
+// colorModifiers qualify the color word after them ("phantom black", "rose gold")
+var colorModifiers = map[string]bool{
+	"phantom": true, "space": true, "rose": true, "sky": true, "light": true, "dark": true,
+	"deep": true, "jet": true, "matte": true, "natural": true, "sierra": true, "alpine": true,
+	"pacific": true, "midnight": true, "mint": true, "pale": true, "bright": true,
+}
+
+// clothingSizes are the sizes SpecSize recognizes as whole words
+var clothingSizes = map[string]bool{
+	"xxs": true, "xs": true, "s": true, "m": true, "l": true, "xl": true,
+	"xxl": true, "xxxl": true, "2xl": true, "3xl": true,
+}
+
+// storageUnits and lengthUnits are the units of SpecStorage and SpecSize
+// tokens, with the spelling tokens use
+var (
+	storageUnits = map[string]string{"mb": "mb", "gb": "gb", "tb": "tb"}
+	lengthUnits  = map[string]string{"inch": "in", "inches": "in", "in": "in", `"`: "in", "cm": "cm", "mm": "mm"}
+)
+
+// ExtractSpecTokens returns the storage, color, size and model tokens of text,
+// usually a product name
+// Units are joined to their numbers ("1 TB" is "1tb", "13.4-inch" is
+// "13.4in"), gray is spelled grey, and hyphens are dropped from model words,
+// so "WH-1000XM5" and "WH1000XM5" are the same model.
+func ExtractSpecTokens(text string) SpecTokens {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		// Apostrophes stay inside words, so the s of "men's" isn't a size
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(`.-"'’`, r)
+	})
+	for i, word := range words {
+		words[i] = strings.Trim(word, ".-")
+	}
+
+	tokens := make(SpecTokens)
+	add := func(d SpecDimension, token string) {
+		tokens[d] = append(tokens[d], token)
+	}
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if word == "" {
+			continue
+		}
+		next := ""
+		if i+1 < len(words) {
+			next = words[i+1]
+		}
+
+		// A number with its unit, attached ("256gb", "13.4-inch") or the next word
+		if number, unit := splitQuantity(word); number != "" {
+			if unit == "" && next != "" {
+				if _, ok := storageUnits[next]; ok {
+					unit = next
+				} else if _, ok := lengthUnits[next]; ok {
+					unit = next
+				}
+				if unit != "" {
+					i++
+				}
+			}
+			if spelled, ok := storageUnits[unit]; ok {
+				add(SpecStorage, number+spelled)
+				continue
+			}
+			if spelled, ok := lengthUnits[unit]; ok {
+				add(SpecSize, number+spelled)
+				continue
+			}
+		}
+
+		switch {
+		case clothingSizes[word]:
+			add(SpecSize, word)
+		case colorModifiers[word] && baseColors[next] != "":
+			// The modifier and its color are one token
+			add(SpecColor, word+" "+baseColors[next])
+			i++
+		case baseColors[word] != "":
+			add(SpecColor, baseColors[word])
+		case strings.ContainsFunc(word, unicode.IsDigit):
+			add(SpecModel, strings.ReplaceAll(word, "-", ""))
+		}
+	}
+
+	for d, values := range tokens {
+		sort.Strings(values)
+		unique := values[:0]
+		for i, v := range values {
+			if i == 0 || v != values[i-1] {
+				unique = append(unique, v)
+			}
+		}
+		tokens[d] = unique
+	}
+	return tokens
+}
+
+// splitQuantity splits a word into a leading number and the rest ("256gb" is
+// "256", "gb"; "13.4-inch" is "13.4", "inch"), or returns "" without one
+func splitQuantity(word string) (number, unit string) {
+	end := 0
+	for end < len(word) && (word[end] >= '0' && word[end] <= '9' || word[end] == '.' && end > 0) {
+		end++
+	}
+	number = strings.TrimRight(word[:end], ".")
+	if number == "" {
+		return "", ""
+	}
+	return number, strings.TrimPrefix(word[len(number):], "-")
+}
+
+// specTokens returns the spec tokens of the product's name, extracted once and
+// cached alongside the normalized strings
+func (c *productCache) specTokens() SpecTokens {
+	if tokens := c.specs.Load(); tokens != nil {
+		return *tokens
+	}
+	// Deterministic, so concurrent callers may race but store the same value
+	tokens := ExtractSpecTokens(c.name)
+	c.specs.Store(&tokens)
+	return tokens
+}
+
+// VariantOutput selects what FindDuplicates does with variant pairs
+type VariantOutput int
+
+const (
+	// VariantsExclude leaves variants out of FindDuplicates (default)
+	VariantsExclude VariantOutput = iota
+	// VariantsInclude returns variants among the duplicates, in scan order
+	VariantsInclude
+	// VariantsSeparate returns the duplicates first, then the variants
+	VariantsSeparate
+)
+
+// DefaultVariantNameSimilarity is the name similarity a pair needs to be a variant
+const DefaultVariantNameSimilarity = 0.75
+
+// VariantOptions configures variant detection (see WithVariantDetection)
+type VariantOptions struct {
+	// Axes are the dimensions variants of one product differ in (nil =
+	// storage, color and size)
+	Axes []SpecDimension
+	// MinNameSimilarity is the name similarity a pair needs to be a variant
+	// (0 = DefaultVariantNameSimilarity)
+	MinNameSimilarity float64
+	// Output selects what FindDuplicates does with variants
+	Output VariantOutput
+}
+
+// DefaultVariantOptions returns variant detection over storage, color and size
+func DefaultVariantOptions() VariantOptions {
+	return VariantOptions{
+		Axes:              []SpecDimension{SpecStorage, SpecColor, SpecSize},
+		MinNameSimilarity: DefaultVariantNameSimilarity,
+	}
+}
+
+// WithVariantDetection classifies pairs of one product family as variants
+// The spec tokens of both names (see ExtractSpecTokens) are compared: a pair
+// whose names reach MinNameSimilarity and whose tokens conflict only in
+// variant axes gets MatchVariant. A pair whose tokens conflict in any other
+// dimension, such as two model numbers, names different products and scores
+// a combined similarity of 0; field similarities are kept.
+// Returns the engine for chaining.
+func (e *LevenshteinEngine) WithVariantDetection(opts VariantOptions) *LevenshteinEngine {
+	defaults := DefaultVariantOptions()
+	if opts.Axes == nil {
+		opts.Axes = defaults.Axes
+	}
+	if opts.MinNameSimilarity <= 0 {
+		opts.MinNameSimilarity = defaults.MinNameSimilarity
+	}
+	e.variants = &opts
+	return e
+}
+
+// WithVariantDetection classifies pairs of one product family as variants
+// (see LevenshteinEngine.WithVariantDetection)
+func (e *HybridEngine) WithVariantDetection(opts VariantOptions) *HybridEngine {
+	e.levenshteinEngine.WithVariantDetection(opts)
+	return e
+}
+
+// variantMatch classifies a fuzzy pair with this name similarity: MatchVariant
+// when its products differ only in variant axes, and vetoed when they
+// conflict elsewhere; MatchFuzzy without variant detection
+func (e *LevenshteinEngine) variantMatch(a, b *Product, nameSimilarity float64) (matchType MatchType, vetoed bool) {
+	if e.variants == nil {
+		return MatchFuzzy, false
+	}
+	prep := e.preparer()
+	conflicts := a.loadCacheFor(prep).specTokens().Conflicts(b.loadCacheFor(prep).specTokens())
+	if len(conflicts) == 0 {
+		return MatchFuzzy, false
+	}
+	for _, conflict := range conflicts {
+		if !e.isVariantAxis(conflict) {
+			return MatchFuzzy, true
+		}
+	}
+	if nameSimilarity >= e.variants.MinNameSimilarity {
+		return MatchVariant, false
+	}
+	return MatchFuzzy, false
+}
+
+// isVariantAxis reports whether d is one of the engine's variant axes
+func (e *LevenshteinEngine) isVariantAxis(d SpecDimension) bool {
+	for _, axis := range e.variants.Axes {
+		if axis == d {
+			return true
+		}
+	}
+	return false
+}
+
+// variantOutput applies VariantOptions.Output to scan results
+func (e *LevenshteinEngine) variantOutput(results []ComparisonResult) []ComparisonResult {
+	if e.variants == nil || e.variants.Output == VariantsInclude {
+		return results
+	}
+	kept := results[:0]
+	var variants []ComparisonResult
+	for _, result := range results {
+		if result.MatchType != MatchVariant {
+			kept = append(kept, result)
+		} else if e.variants.Output == VariantsSeparate {
+			variants = append(variants, result)
+		}
+	}
+	return append(kept, variants...)
+}
+
+// onlyVariants returns the variant pairs of results
+func onlyVariants(results []ComparisonResult) []ComparisonResult {
+	variants := []ComparisonResult{}
+	for _, result := range results {
+		if result.MatchType == MatchVariant {
+			variants = append(variants, result)
+		}
+	}
+	return variants
+}
+
+// FindVariants returns the pairs at threshold classified MatchVariant, for
+// building variant groups; VariantOptions.Output doesn't apply
+// Variant detection must be on (see WithVariantDetection): without it no pair
+// is a variant. Returns nil when the input repeats IDs under DuplicateIDReject.
+func (e *LevenshteinEngine) FindVariants(products []Product, threshold float64) []ComparisonResult {
+	defer e.guard("FindVariants", nil)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil
+	}
+	return onlyVariants(e.findPairs(context.Background(), productPtrs(resolved), threshold))
+}
+
+// FindVariants returns the pairs at threshold classified MatchVariant
+// (see LevenshteinEngine.FindVariants)
+func (e *HybridEngine) FindVariants(products []Product, threshold float64) []ComparisonResult {
+	defer e.levenshteinEngine.guard("FindVariants", nil)
+	resolved, _, err := e.resolveProducts(products)
+	if err != nil {
+		return nil
+	}
+	return onlyVariants(e.findPairs(productPtrs(resolved), threshold))
+}
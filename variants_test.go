@@ -0,0 +1,113 @@
+package duplicatecheck
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractSpecTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want SpecTokens
+	}{
+		{"Samsung Galaxy S23 Ultra 512GB Phantom Black", SpecTokens{
+			SpecStorage: {"512gb"}, SpecColor: {"phantom black"}, SpecModel: {"s23"}}},
+		{"Apple iPhone 14 Pro Max 1 TB Space Gray", SpecTokens{
+			SpecStorage: {"1tb"}, SpecColor: {"space grey"}, SpecModel: {"14"}}},
+		{"Dell XPS 13.4-inch Laptop, 16GB RAM", SpecTokens{
+			SpecStorage: {"16gb"}, SpecSize: {"13.4in"}}},
+		{"Sony WH-1000XM5 Headphones Black/Silver", SpecTokens{
+			SpecColor: {"black", "silver"}, SpecModel: {"wh1000xm5"}}},
+		{"Men's Cotton T-Shirt XL white", SpecTokens{
+			SpecSize: {"xl"}, SpecColor: {"white"}}},
+		{"Samsung 55\" QLED TV", SpecTokens{SpecSize: {"55in"}}},
+		{"Handmade ceramic mug", SpecTokens{}},
+	}
+	for _, tt := range tests {
+		if got := ExtractSpecTokens(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractSpecTokens(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+
+	// Spellings of one model agree; a dimension one side lacks doesn't conflict
+	a := ExtractSpecTokens("Sony WH-1000XM5 Wireless Headphones")
+	b := ExtractSpecTokens("Sony WH1000XM5 Wireless Headphones Grey")
+	if conflicts := a.Conflicts(b); len(conflicts) != 0 {
+		t.Errorf("conflicts %v, want none", conflicts)
+	}
+}
+
+func TestVariantDetection(t *testing.T) {
+	catalog := loadSampleCatalog(t)
+	pairs := []struct {
+		name      string
+		a, b      string
+		matchType MatchType
+		duplicate bool // Returned by FindDuplicates under VariantsExclude
+	}{
+		{"color names differ", "P003", "P004", MatchVariant, false},
+		{"case differs", "P001", "P002", MatchNormalizedExact, true},
+		{"model numbers differ", "P005", "P006", MatchFuzzy, false},
+		{"hyphenated model", "P007", "P011", MatchFuzzy, true},
+	}
+	engine := NewLevenshteinEngine().WithVariantDetection(VariantOptions{Axes: []SpecDimension{SpecStorage, SpecColor}})
+	plain := NewLevenshteinEngine()
+	for _, tt := range pairs {
+		a, b := sampleProduct(t, catalog, tt.a), sampleProduct(t, catalog, tt.b)
+		result := engine.Compare(a, b)
+		if result.MatchType != tt.matchType {
+			t.Errorf("%s: MatchType %v, want %v", tt.name, result.MatchType, tt.matchType)
+		}
+		if result.MeetsThreshold != tt.duplicate && tt.matchType != MatchVariant {
+			t.Errorf("%s: MeetsThreshold %v (%.3f)", tt.name, result.MeetsThreshold, result.CombinedSimilarity)
+		}
+		// Only the combined score of a vetoed pair changes
+		if base := plain.Compare(a, b); base.NameSimilarity != result.NameSimilarity ||
+			(result.CombinedSimilarity != base.CombinedSimilarity && result.CombinedSimilarity != 0) {
+			t.Errorf("%s: scored %+v, without variant detection %+v", tt.name, result, base)
+		}
+	}
+
+	// Without a color axis the color conflict vetoes the pair instead
+	storageOnly := NewLevenshteinEngine().WithVariantDetection(VariantOptions{Axes: []SpecDimension{SpecStorage}})
+	if result := storageOnly.Compare(sampleProduct(t, catalog, "P003"), sampleProduct(t, catalog, "P004")); result.MatchType == MatchVariant || result.MeetsThreshold {
+		t.Errorf("storage axis only: MatchType %v, MeetsThreshold %v", result.MatchType, result.MeetsThreshold)
+	}
+
+	// FindVariants and each FindDuplicates output
+	pairKeys := func(results []ComparisonResult) []string {
+		keys := []string{}
+		for _, r := range results {
+			keys = append(keys, makePairKey(r.ProductA.ID, r.ProductB.ID))
+		}
+		return keys
+	}
+	variant := makePairKey("P003", "P004")
+	if got := pairKeys(engine.FindVariants(catalog, DefaultThreshold)); !reflect.DeepEqual(got, []string{variant}) {
+		t.Errorf("FindVariants = %v, want [%s]", got, variant)
+	}
+	excluded := pairKeys(engine.FindDuplicates(catalog, DefaultThreshold))
+	for _, key := range excluded {
+		if key == variant || key == makePairKey("P005", "P006") {
+			t.Errorf("FindDuplicates excluding variants returned %s", key)
+		}
+	}
+	for _, output := range []VariantOutput{VariantsInclude, VariantsSeparate} {
+		engine.WithVariantDetection(VariantOptions{Axes: []SpecDimension{SpecStorage, SpecColor}, Output: output})
+		got := pairKeys(engine.FindDuplicates(catalog, DefaultThreshold))
+		if len(got) != len(excluded)+1 {
+			t.Errorf("output %v: %v, want %v and the variant", output, got, excluded)
+		} else if output == VariantsSeparate && got[len(got)-1] != variant {
+			t.Errorf("separate output: %v, want the variant last", got)
+		}
+	}
+
+	// The hybrid engine classifies the pairs it verifies
+	hybrid := NewHybridEngine().WithVariantDetection(VariantOptions{Axes: []SpecDimension{SpecStorage, SpecColor}})
+	if err := hybrid.BuildIndex(catalog); err != nil {
+		t.Fatal(err)
+	}
+	if got := pairKeys(hybrid.FindVariants(catalog, DefaultThreshold)); !reflect.DeepEqual(got, []string{variant}) {
+		t.Errorf("hybrid FindVariants = %v, want [%s]", got, variant)
+	}
+}
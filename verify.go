@@ -0,0 +1,183 @@
+package duplicatecheck
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// verifyClaimSize is the number of pairs a VerifyPairs worker claims at a time
+const verifyClaimSize = 64
+
+// ProductPair is a candidate pair produced outside the engine, for VerifyPairs
+type ProductPair struct {
+	A, B Product
+}
+
+// VerifyOptions controls VerifyPairs
+type VerifyOptions struct {
+	// ReturnAll returns a result for every verified pair, with MeetsThreshold
+	// telling whether it reached the threshold. By default only pairs at or
+	// above the threshold are returned.
+	ReturnAll bool
+}
+
+// VerifyPairs scores candidate pairs generated elsewhere (a blocking key, a
+// search index, another service) without scanning for candidates itself
+// Pairs are deduplicated by PairKey (the first occurrence is kept, in either
+// order) and a pair whose two products share an ID is skipped. Results follow
+// the input order of the pairs they score, whatever the worker count. Unless
+// opts.ReturnAll is set, pairs whose name distance alone rules out the
+// threshold are rejected without finishing the DP or scoring descriptions.
+// If ctx is cancelled, VerifyPairs returns the pairs verified so far (still in
+// input order) with ctx.Err().
+func (e *LevenshteinEngine) VerifyPairs(ctx context.Context, pairs []ProductPair, threshold float64, opts VerifyOptions) (_ []ComparisonResult, err error) {
+	defer e.guard("VerifyPairs", &err)
+	if err := validateThreshold(threshold); err != nil {
+		return nil, err
+	}
+	pending := e.verificationPairs(pairs)
+
+	results := make([]ComparisonResult, len(pending))
+	verified := make([]bool, len(pending))
+	verify := func(i int) {
+		a, b := &pending[i].A, &pending[i].B
+		if !e.pairAllowed(a, b) {
+			return
+		}
+		weights := e.resolveWeights(a, b)
+		if !opts.ReturnAll && e.verifyRejects(a, b, weights.Normalized(), threshold) {
+			return
+		}
+		skipBelow := threshold
+		if opts.ReturnAll {
+			skipBelow = 0 // Every returned pair carries its full scores
+		}
+		result := e.compareWithWeights(a, b, weights, memoPair{}, skipBelow)
+		result.stampThreshold(threshold)
+		if opts.ReturnAll || result.MeetsThreshold {
+			results[i], verified[i] = result, true
+		}
+	}
+
+	workers := e.workerCount(len(pending))
+	if workers > (len(pending)+verifyClaimSize-1)/verifyClaimSize {
+		workers = (len(pending) + verifyClaimSize - 1) / verifyClaimSize
+	}
+	if workers <= 1 {
+		for i := range pending {
+			if i%verifyClaimSize == 0 && ctx.Err() != nil {
+				break
+			}
+			verify(i)
+		}
+	} else {
+		var next int64
+		var group workerGroup
+		for w := 0; w < workers; w++ {
+			group.Go(func() {
+				for ctx.Err() == nil && !group.stopped() {
+					start := int(atomic.AddInt64(&next, verifyClaimSize)) - verifyClaimSize
+					if start >= len(pending) {
+						return
+					}
+					end := start + verifyClaimSize
+					if end > len(pending) {
+						end = len(pending)
+					}
+					for i := start; i < end; i++ {
+						verify(i)
+					}
+				}
+			})
+		}
+		group.Wait()
+	}
+
+	var kept []ComparisonResult
+	for i := range results {
+		if verified[i] {
+			kept = append(kept, results[i])
+		}
+	}
+	return kept, ctx.Err()
+}
+
+// verificationPairs returns the distinct, non-self pairs of pairs in input order
+// Copies of a product (by ID, with the same fields) share one prepared cache,
+// so a product appearing in many pairs is normalized once.
+func (e *LevenshteinEngine) verificationPairs(pairs []ProductPair) []ProductPair {
+	prep := e.preparer()
+	seen := make(map[string]bool, len(pairs))
+	prepared := make(map[string]*Product)
+	share := func(p *Product) {
+		if c, ok := prepared[p.ID]; ok && c.Name == p.Name && c.Description == p.Description {
+			*p = *c
+			return
+		}
+		p.loadCacheFor(prep)
+		prepared[p.ID] = p
+	}
+
+	pending := make([]ProductPair, 0, len(pairs))
+	for i := range pairs {
+		if pairs[i].A.ID == pairs[i].B.ID {
+			continue
+		}
+		key := PairKey(pairs[i].A.ID, pairs[i].B.ID)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		pending = append(pending, pairs[i])
+	}
+	for i := range pending {
+		share(&pending[i].A)
+		share(&pending[i].B)
+	}
+	return pending
+}
+
+// verifyRejects reports whether the names of a and b alone rule out threshold
+// Even a perfect description score adds at most the description weight, which
+// bounds the name distance worth computing; the DP stops once it is exceeded.
+// Returns false whenever no such bound is safe (cross-field matching and
+// swapped fields can raise a score, non-linear similarity modes can exceed the linear bound, SKU-like
+// names equal up to separators score 1.0, low-information names don't count,
+// and bundles score their best item alignment).
+func (e *LevenshteinEngine) verifyRejects(a, b *Product, normalized ComparisonWeights, threshold float64) bool {
+	if threshold <= 0 || e.crossField != nil || e.swappedFields != nil || e.skuNames != nil || e.lowInfo != nil || e.bundleSplitter != nil || !e.similarityBoundedByLinear() {
+		return false
+	}
+	nameA, descA := a.preparedStrings(e.preparer())
+	nameB, descB := b.preparedStrings(e.preparer())
+	if nameA == "" && nameB == "" {
+		return false
+	}
+
+	// Both descriptions empty: the name score is the combined score
+	minNameSimilarity := threshold - ThresholdEpsilon
+	if descA != "" || descB != "" {
+		if normalized.NameWeight <= 0 {
+			return false
+		}
+		minNameSimilarity = (threshold - ThresholdEpsilon - (1 - normalized.NameWeight)) / normalized.NameWeight
+	}
+	if minNameSimilarity <= 0 {
+		return false
+	}
+
+	maxLen := e.textLength(nameA)
+	if lenB := e.textLength(nameB); lenB > maxLen {
+		maxLen = lenB
+	}
+	// (epsilon keeps 0.2*40 = 7.9999... from flooring to 7)
+	maxDistance := int((1-minNameSimilarity)*float64(maxLen) + 1e-9)
+	return e.computeDistanceWithThreshold(nameA, nameB, maxDistance) > maxDistance
+}
+
+// VerifyPairs scores externally generated candidate pairs (see
+// LevenshteinEngine.VerifyPairs); the LSH index is not consulted
+func (e *HybridEngine) VerifyPairs(ctx context.Context, pairs []ProductPair, threshold float64, opts VerifyOptions) (_ []ComparisonResult, err error) {
+	defer e.levenshteinEngine.guard("VerifyPairs", &err)
+	return e.levenshteinEngine.VerifyPairs(ctx, pairs, threshold, opts)
+}
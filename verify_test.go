@@ -0,0 +1,173 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/solrac97gr/duplicatecheck/internal/synth"
+)
+
+// allPairs returns every pair of products, in scan order
+func allPairs(products []Product) []ProductPair {
+	var pairs []ProductPair
+	for i := range products {
+		for j := i + 1; j < len(products); j++ {
+			pairs = append(pairs, ProductPair{A: products[i], B: products[j]})
+		}
+	}
+	return pairs
+}
+
+// resultKeys returns the pair keys of results, in order
+func resultKeys(results []ComparisonResult) []string {
+	keys := make([]string, len(results))
+	for i, r := range results {
+		keys[i] = PairKey(r.ProductA.ID, r.ProductB.ID)
+	}
+	return keys
+}
+
+func TestVerifyPairs(t *testing.T) {
+	iphone := Product{ID: "1", Name: "Apple iPhone 13 Pro", Description: "128GB graphite"}
+	iphoneMax := Product{ID: "2", Name: "Apple iPhone 13 Pro Max", Description: "128GB graphite"}
+	kettle := Product{ID: "3", Name: "Steel Kettle", Description: "1.7L"}
+	hose := Product{ID: "4", Name: "Garden Hose 50ft", Description: ""}
+	pairs := []ProductPair{
+		{A: iphone, B: iphoneMax},
+		{A: kettle, B: hose},
+		{A: iphoneMax, B: iphone}, // Same pair reversed
+		{A: kettle, B: kettle},    // Self pair
+		{A: hose, B: iphone},
+		{A: iphone, B: iphoneMax}, // Exact repeat
+	}
+
+	tests := []struct {
+		name      string
+		threshold float64
+		opts      VerifyOptions
+		want      []string
+	}{
+		{"Threshold keeps matches", 0.8, VerifyOptions{}, []string{"1|2"}},
+		{"ReturnAll keeps every distinct pair", 0.8, VerifyOptions{ReturnAll: true}, []string{"1|2", "3|4", "1|4"}},
+		{"Zero threshold", 0, VerifyOptions{}, []string{"1|2", "3|4", "1|4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewLevenshteinEngine()
+			results, err := engine.VerifyPairs(context.Background(), pairs, tt.threshold, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resultKeys(results); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("VerifyPairs() pairs = %v, want %v", got, tt.want)
+			}
+			for _, r := range results {
+				want := engine.Compare(r.ProductA, r.ProductB)
+				if r.CombinedSimilarity != want.CombinedSimilarity || r.ThresholdUsed != tt.threshold {
+					t.Errorf("%s|%s: CombinedSimilarity %v, ThresholdUsed %v, want %v and %v", r.ProductA.ID, r.ProductB.ID, r.CombinedSimilarity, r.ThresholdUsed, want.CombinedSimilarity, tt.threshold)
+				}
+				if r.MeetsThreshold != (r.CombinedSimilarity >= tt.threshold) {
+					t.Errorf("%s|%s: MeetsThreshold = %v", r.ProductA.ID, r.ProductB.ID, r.MeetsThreshold)
+				}
+			}
+		})
+	}
+
+	t.Run("Invalid threshold", func(t *testing.T) {
+		if _, err := NewLevenshteinEngine().VerifyPairs(context.Background(), pairs, 1.5, VerifyOptions{}); err == nil {
+			t.Error("Expected an error for a threshold above 1")
+		}
+	})
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		results, err := NewHybridEngine().VerifyPairs(ctx, pairs, 0.8, VerifyOptions{})
+		if !errors.Is(err, context.Canceled) || len(results) != 0 {
+			t.Errorf("VerifyPairs() = %d results, %v; want none and context.Canceled", len(results), err)
+		}
+	})
+}
+
+// TestVerifyPairsMatchesScan checks that the name-distance early exit never
+// drops a pair the full scan finds, and that the order is the input order
+func TestVerifyPairsMatchesScan(t *testing.T) {
+	cfg := synth.Default()
+	cfg.Size, cfg.DuplicateRate = 80, 0.3
+	products, _ := generatedCatalog(cfg)
+	pairs := allPairs(products)
+
+	for _, threshold := range []float64{0.6, 0.8, 0.95} {
+		engine := NewLevenshteinEngine()
+		results, err := engine.VerifyPairs(context.Background(), pairs, threshold, VerifyOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		all, err := engine.VerifyPairs(context.Background(), pairs, threshold, VerifyOptions{ReturnAll: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != len(pairs) {
+			t.Fatalf("ReturnAll returned %d results for %d pairs", len(all), len(pairs))
+		}
+		var want []string
+		for _, r := range all {
+			if r.MeetsThreshold {
+				want = append(want, PairKey(r.ProductA.ID, r.ProductB.ID))
+			}
+		}
+		if got := resultKeys(results); !reflect.DeepEqual(got, want) {
+			t.Errorf("threshold %v: VerifyPairs() found %d pairs, ReturnAll has %d at or above the threshold", threshold, len(got), len(want))
+		}
+
+		scan := make(map[string]bool)
+		for _, r := range engine.FindDuplicates(products, threshold) {
+			scan[PairKey(r.ProductA.ID, r.ProductB.ID)] = true
+		}
+		if len(scan) != len(want) {
+			t.Errorf("threshold %v: FindDuplicates() found %d pairs, VerifyPairs() %d", threshold, len(scan), len(want))
+		}
+		for _, key := range want {
+			if !scan[key] {
+				t.Errorf("threshold %v: %s not found by FindDuplicates()", threshold, key)
+			}
+		}
+	}
+}
+
+func BenchmarkVerifyPairs(b *testing.B) {
+	cfg := synth.Default()
+	cfg.Size = 2000
+	products, _ := generatedCatalog(cfg)
+	rng := rand.New(rand.NewSource(1))
+	pairs := make([]ProductPair, 100000)
+	for i := range pairs {
+		pairs[i] = ProductPair{A: products[rng.Intn(len(products))], B: products[rng.Intn(len(products))]}
+	}
+	const threshold = DefaultThreshold
+
+	b.Run("VerifyPairs", func(b *testing.B) {
+		engine := NewLevenshteinEngine()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := engine.VerifyPairs(context.Background(), pairs, threshold, VerifyOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Naive loop", func(b *testing.B) {
+		engine := NewLevenshteinEngine()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var results []ComparisonResult
+			for _, p := range pairs {
+				if r := engine.Compare(p.A, p.B); r.CombinedSimilarity >= threshold {
+					results = append(results, r)
+				}
+			}
+		}
+	})
+}
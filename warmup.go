@@ -0,0 +1,224 @@
+package duplicatecheck
+
+import (
+	"context"
+	"runtime"
+	"time"
+	"unicode/utf8"
+)
+
+// warmupComparisons is how many throwaway comparisons Warmup runs
+const warmupComparisons = 8
+
+// WarmupReport describes what a Warmup call prepared, for logging
+type WarmupReport struct {
+	Products     int           // Sample products whose prepared text is now cached
+	PooledSlices int           // Distance buffers added to the pools
+	Comparisons  int           // Throwaway comparisons run
+	Queries      int           // Candidate lookups run (HybridEngine only)
+	Signatures   int           // Query signatures cached (HybridEngine only)
+	Elapsed      time.Duration // Time spent
+	Complete     bool          // False if the budget or context stopped it early
+}
+
+// Warmup prepares the engine for a latency-sensitive first request
+// It caches the prepared text of every sample product, fills the buffer pools
+// with slices sized for the longest sample strings, and runs a few throwaway
+// comparisons. Copies of the sample products made afterwards share their
+// caches. Warmup stops early once budget has elapsed (budget <= 0 means no
+// limit), reporting Complete false; if ctx is done first it also returns
+// ctx.Err().
+func (e *LevenshteinEngine) Warmup(ctx context.Context, sample []Product, budget time.Duration) (_ WarmupReport, err error) {
+	defer e.guard("Warmup", &err)
+	w := newWarmup(ctx, budget)
+	ptrs := productPtrs(sample)
+	if e.warmProducts(w, ptrs) {
+		for i := 0; i+1 < len(ptrs) && i < warmupComparisons; i++ {
+			if w.stopped() {
+				break
+			}
+			e.ComparePtr(ptrs[i], ptrs[i+1])
+			w.report.Comparisons++
+		}
+	}
+	return w.finish()
+}
+
+// Warmup prepares the engine for a latency-sensitive first query
+// Beyond LevenshteinEngine.Warmup's text caching and buffer pools, it looks
+// up candidates for each sample query, touching the index buckets they land
+// in, caches the queries' MinHash signatures so querying them again skips
+// shingling, and verifies a few of the candidates found. The budget and ctx
+// behave as in LevenshteinEngine.Warmup. Returns ErrIndexNotBuilt without an
+// index.
+func (e *HybridEngine) Warmup(ctx context.Context, sampleQueries []Product, budget time.Duration) (_ WarmupReport, err error) {
+	defer e.levenshteinEngine.guard("Warmup", &err)
+	idx := e.currentIndex()
+	if idx == nil {
+		return WarmupReport{}, ErrIndexNotBuilt
+	}
+	w := newWarmup(ctx, budget)
+	ptrs := productPtrs(sampleQueries)
+	if !e.levenshteinEngine.warmProducts(w, ptrs) {
+		return w.finish()
+	}
+
+	key := e.IndexConfigFingerprint()
+	for _, product := range ptrs {
+		if w.stopped() {
+			break
+		}
+		c := product.loadCacheFor(e.preparer())
+		c.storeSignatures(key, e.productSignatures(product, e.indexText(product)))
+		w.report.Signatures++
+
+		candidates := e.findCandidates(idx, product, 0)
+		w.report.Queries++
+		if w.report.Comparisons >= warmupComparisons {
+			continue
+		}
+		query := e.newQuery(product)
+		for _, candidate := range candidates {
+			if candidate.id == product.ID {
+				continue
+			}
+			e.verifyCandidate(idx, product, query, candidate.id, 0)
+			w.report.Comparisons++
+			break
+		}
+	}
+	return w.finish()
+}
+
+// querySignatures returns the MinHash signatures of text, the indexText of
+// product, reusing those Warmup cached under the current index configuration
+func (e *HybridEngine) querySignatures(product *Product, text string) [][]uint32 {
+	if c := product.cachedValueFor(e.preparer()); c != nil {
+		if signatures := c.cachedSignatures(e.IndexConfigFingerprint); signatures != nil {
+			return signatures
+		}
+	}
+	return e.productSignatures(product, text)
+}
+
+// storeSignatures caches signatures computed under the index config key
+func (c *productCache) storeSignatures(key uint64, signatures [][]uint32) {
+	c.signatureMu.Lock()
+	defer c.signatureMu.Unlock()
+	c.signatureKey = key
+	c.signatures = signatures
+}
+
+// cachedSignatures returns the cached signatures if they were computed under
+// the index configuration whose fingerprint key returns, or nil
+// key is only called when signatures are cached, so queries of products that
+// were never warmed don't pay for the fingerprint.
+func (c *productCache) cachedSignatures(key func() uint64) [][]uint32 {
+	c.signatureMu.RLock()
+	defer c.signatureMu.RUnlock()
+	if c.signatures == nil || c.signatureKey != key() {
+		return nil
+	}
+	return c.signatures
+}
+
+// warmProducts caches the prepared text of products and primes the buffer
+// pools for their lengths, reporting false if w stopped before it finished
+func (e *LevenshteinEngine) warmProducts(w *warmup, products []*Product) bool {
+	prep := e.preparer()
+	longest := 0 // Bytes of the longest prepared string
+	vectors := 0 // Words of Myers match vectors for the longest pattern
+	for _, p := range products {
+		if w.stopped() {
+			return false
+		}
+		name, desc := p.preparedStrings(prep)
+		for _, s := range []string{name, desc} {
+			if len(s) > longest {
+				longest = len(s)
+			}
+			if words := myersVectorWords(s); words > vectors {
+				vectors = words
+			}
+		}
+		w.report.Products++
+	}
+	if w.stopped() {
+		return false
+	}
+
+	// Each concurrent comparison holds two DP rows, two rune buffers and three
+	// Myers vectors; prime a set per worker, at the capacity of the largest
+	for worker := runtime.GOMAXPROCS(0); worker > 0; worker-- {
+		for i := 0; i < 2; i++ {
+			if putIntSlice(make([]int, defaultRowCapacity)) {
+				w.report.PooledSlices++
+			}
+			if longest > 0 && longest <= runePoolCapacity() && putRunes(make([]rune, 0, longest)) {
+				w.report.PooledSlices++
+			}
+		}
+		if vectors > 0 && vectors <= defaultVectorPoolMaxCapacity {
+			for i := 0; i < 3; i++ {
+				if putUint64Slice(make([]uint64, vectors)) {
+					w.report.PooledSlices++
+				}
+			}
+		}
+	}
+	return true
+}
+
+// myersVectorWords returns the words of match vectors newMyersPattern needs
+// for s, or 0 if s is too short to be compared with Myers' algorithm
+func myersVectorWords(s string) int {
+	length := utf8.RuneCountInString(s)
+	if length <= myersMinPatternLength {
+		return 0
+	}
+	distinct := make(map[rune]struct{})
+	for _, r := range s {
+		distinct[r] = struct{}{}
+	}
+	return (len(distinct) + 1) * ((length + 63) / 64)
+}
+
+// warmup tracks the budget and progress of a Warmup call
+type warmup struct {
+	ctx         context.Context
+	start       time.Time
+	deadline    time.Time // Zero without a budget
+	interrupted bool
+	err         error
+	report      WarmupReport
+}
+
+// newWarmup starts a warmup limited by ctx and budget
+func newWarmup(ctx context.Context, budget time.Duration) *warmup {
+	w := &warmup{ctx: ctx, start: time.Now()}
+	if budget > 0 {
+		w.deadline = w.start.Add(budget)
+	}
+	return w
+}
+
+// stopped reports whether the context is done or the budget has run out
+func (w *warmup) stopped() bool {
+	if w.interrupted {
+		return true
+	}
+	if err := w.ctx.Err(); err != nil {
+		w.err = err
+		w.interrupted = true
+	} else if !w.deadline.IsZero() && time.Now().After(w.deadline) {
+		w.interrupted = true
+	}
+	return w.interrupted
+}
+
+// finish returns the report and the context's error, if it stopped the warmup
+func (w *warmup) finish() (WarmupReport, error) {
+	w.report.Elapsed = time.Since(w.start)
+	w.report.Complete = !w.interrupted
+	return w.report, w.err
+}
@@ -0,0 +1,179 @@
+package duplicatecheck
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+// longDescriptionSample returns products with names and ~2000-character
+// descriptions, long enough to be compared with Myers' algorithm
+func longDescriptionSample(n int) []Product {
+	rng := rand.New(rand.NewSource(7))
+	products := make([]Product, n)
+	for i := range products {
+		products[i] = Product{
+			ID:          string(rune('A' + i)),
+			Name:        randomWordText(rng, 4),
+			Description: randomWordText(rng, 330),
+		}
+	}
+	return products
+}
+
+// mallocsDuring returns the number of heap allocations f made
+func mallocsDuring(f func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.ReadMemStats(&after)
+	return after.Mallocs - before.Mallocs
+}
+
+// drainPools empties the buffer pools: sync.Pool drops its items after two
+// collections
+func drainPools() {
+	runtime.GC()
+	runtime.GC()
+}
+
+func TestLevenshteinWarmupFirstCompare(t *testing.T) {
+	// A collection between warming and measuring would empty the pools again
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+	engine := NewLevenshteinEngine()
+
+	drainPools()
+	cold := longDescriptionSample(2)
+	coldMallocs := mallocsDuring(func() { engine.ComparePtr(&cold[0], &cold[1]) })
+
+	drainPools()
+	sample := longDescriptionSample(4)
+	report, err := engine.Warmup(context.Background(), sample, 0)
+	if err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	first := mallocsDuring(func() { engine.Compare(sample[2], sample[3]) })
+	steady := testing.AllocsPerRun(20, func() { engine.Compare(sample[2], sample[3]) })
+
+	t.Logf("cold %d, first after warmup %d, steady state %.0f allocations (%+v)", coldMallocs, first, steady, report)
+	if float64(first) > steady {
+		t.Errorf("first Compare after Warmup allocated %d times, steady state %.0f", first, steady)
+	}
+	if float64(coldMallocs) <= steady {
+		t.Errorf("cold Compare allocated %d times, want more than the steady state %.0f", coldMallocs, steady)
+	}
+}
+
+func TestLevenshteinWarmupReport(t *testing.T) {
+	sample := longDescriptionSample(12)
+
+	t.Run("No budget", func(t *testing.T) {
+		report, err := NewLevenshteinEngine().Warmup(context.Background(), sample, 0)
+		if err != nil {
+			t.Fatalf("Warmup: %v", err)
+		}
+		if !report.Complete || report.Products != len(sample) || report.Comparisons != warmupComparisons {
+			t.Errorf("report = %+v, want complete with %d products and %d comparisons", report, len(sample), warmupComparisons)
+		}
+		if report.PooledSlices == 0 {
+			t.Error("report.PooledSlices = 0, want buffers added to the pools")
+		}
+		if sample[0].cachedValue() == nil {
+			t.Error("sample product has no cached prepared text after Warmup")
+		}
+	})
+
+	t.Run("Expired budget", func(t *testing.T) {
+		report, err := NewLevenshteinEngine().Warmup(context.Background(), longDescriptionSample(12), time.Nanosecond)
+		if err != nil {
+			t.Fatalf("Warmup: %v", err)
+		}
+		if report.Complete {
+			t.Errorf("report = %+v, want incomplete", report)
+		}
+	})
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		report, err := NewLevenshteinEngine().Warmup(ctx, longDescriptionSample(12), 0)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Warmup error = %v, want context.Canceled", err)
+		}
+		if report.Complete || report.Products != 0 {
+			t.Errorf("report = %+v, want incomplete with no products", report)
+		}
+	})
+}
+
+func TestHybridWarmup(t *testing.T) {
+	catalog := longDescriptionSample(10)
+	catalog = append(catalog, Product{ID: "Z", Name: catalog[0].Name, Description: catalog[0].Description + " extra"})
+
+	engine := NewHybridEngine()
+	if _, err := engine.Warmup(context.Background(), catalog, 0); !errors.Is(err, ErrIndexNotBuilt) {
+		t.Fatalf("Warmup before BuildIndex error = %v, want ErrIndexNotBuilt", err)
+	}
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+
+	queries := append([]Product(nil), catalog...)
+	report, err := engine.Warmup(context.Background(), queries, 0)
+	if err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	if !report.Complete || report.Queries != len(queries) || report.Signatures != len(queries) {
+		t.Errorf("report = %+v, want complete with %d queries and signatures", report, len(queries))
+	}
+	if report.Comparisons == 0 {
+		t.Errorf("report = %+v, want the Z/A candidate verified", report)
+	}
+
+	// Copies of a warmed query reuse its signatures and find the same candidates
+	warmed := queries[0]
+	text := engine.indexText(&warmed)
+	cached := engine.querySignatures(&warmed, text)
+	if want := engine.computeSignatures(text); &cached[0][0] == &want[0][0] || !equalSignatures(cached, want) {
+		t.Error("querySignatures did not return the cached signatures")
+	}
+	if again := engine.querySignatures(&warmed, text); &again[0][0] != &cached[0][0] {
+		t.Error("querySignatures recomputed the signatures of a warmed query")
+	}
+	cold := Product{ID: warmed.ID, Name: warmed.Name, Description: warmed.Description}
+	if got, want := resultPairs(engine.FindDuplicatesForOne(warmed, 0.5)), resultPairs(engine.FindDuplicatesForOne(cold, 0.5)); len(got) == 0 || !reflect.DeepEqual(got, want) {
+		t.Errorf("warmed query found %v, cold query %v", got, want)
+	}
+
+	// A different index configuration ignores the cached signatures
+	engine.WithLSHSeed(99)
+	if err := engine.BuildIndex(catalog); err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+	if again := engine.querySignatures(&warmed, text); &again[0][0] == &cached[0][0] {
+		t.Error("querySignatures reused signatures cached under another seed")
+	}
+}
+
+// equalSignatures reports whether a and b hold the same signatures
+func equalSignatures(a, b [][]uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
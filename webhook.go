@@ -0,0 +1,193 @@
+package duplicatecheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Defaults of HTTPWebhookNotifier
+const (
+	// DefaultWebhookTimeout is the default HTTPWebhookNotifier.Timeout
+	DefaultWebhookTimeout = 10 * time.Second
+	// DefaultWebhookRetries is the default HTTPWebhookNotifier.MaxRetries
+	DefaultWebhookRetries = 3
+	// DefaultWebhookBackoff is the default HTTPWebhookNotifier.Backoff
+	DefaultWebhookBackoff = 500 * time.Millisecond
+	// DefaultWebhookMaxBackoff is the default HTTPWebhookNotifier.MaxBackoff
+	DefaultWebhookMaxBackoff = 30 * time.Second
+)
+
+// Headers of webhook requests
+const (
+	// WebhookSignatureHeader carries "sha256=" and the hexadecimal HMAC-SHA256
+	// of the request body under HTTPWebhookNotifier.Secret
+	WebhookSignatureHeader = "X-Duplicatecheck-Signature"
+	// WebhookRunHeader carries Notification.RunID
+	WebhookRunHeader = "X-Duplicatecheck-Run"
+)
+
+// HTTPWebhookNotifier posts notifications as JSON (see Notification) to an
+// HTTP endpoint, retrying failed deliveries
+// A delivery fails on a transport error or a non-2xx status. Failures on a
+// 5xx, 408 or 429 status or a transport error are retried up to MaxRetries
+// times, waiting Backoff, then twice as long each time up to MaxBackoff;
+// other statuses fail at once. A delivery that fails for good is passed to
+// DeadLetter. Configure the fields before the first Notify; safe for
+// concurrent use after that.
+type HTTPWebhookNotifier struct {
+	URL string
+	// Headers are added to every request (Content-Type is application/json)
+	Headers http.Header
+	// Secret signs each body with HMAC-SHA256 in WebhookSignatureHeader
+	// (empty = unsigned); receivers check it with VerifyWebhookSignature
+	Secret []byte
+	// Timeout bounds each attempt (default DefaultWebhookTimeout)
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first attempt (default
+	// DefaultWebhookRetries, negative = none)
+	MaxRetries int
+	// Backoff is the wait before the first retry (default DefaultWebhookBackoff)
+	Backoff time.Duration
+	// MaxBackoff caps the wait between retries (default DefaultWebhookMaxBackoff)
+	MaxBackoff time.Duration
+	// Client sends the requests (nil = http.DefaultClient)
+	Client *http.Client
+	// DeadLetter receives notifications whose delivery failed for good, with
+	// the last error, on the delivering goroutine (nil = none)
+	DeadLetter func(n Notification, err error)
+}
+
+// NewHTTPWebhookNotifier returns a notifier posting to url, signing with
+// secret (nil = unsigned), with the default timeout and retries
+func NewHTTPWebhookNotifier(url string, secret []byte) *HTTPWebhookNotifier {
+	return &HTTPWebhookNotifier{URL: url, Secret: secret}
+}
+
+// WebhookError is a delivery the endpoint answered with a non-2xx status
+type WebhookError struct {
+	StatusCode int
+	Body       string // Start of the response body
+}
+
+// Error describes the status
+func (e *WebhookError) Error() string {
+	return fmt.Sprintf("duplicatecheck: webhook returned %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// retryable reports whether a later attempt may succeed
+func (e *WebhookError) retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests
+}
+
+// Notify implements ResultNotifier
+func (w *HTTPWebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return w.deadLetter(n, err)
+	}
+	retries := w.MaxRetries
+	if retries == 0 {
+		retries = DefaultWebhookRetries
+	}
+	backoff := w.Backoff
+	if backoff <= 0 {
+		backoff = DefaultWebhookBackoff
+	}
+	maxBackoff := w.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultWebhookMaxBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = w.post(ctx, n.RunID, body)
+		if err == nil {
+			return nil
+		}
+		var status *WebhookError
+		if attempt >= retries || (errors.As(err, &status) && !status.retryable()) {
+			return w.deadLetter(n, err)
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return w.deadLetter(n, ctx.Err())
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// deadLetter hands a failed notification to DeadLetter, returning err
+func (w *HTTPWebhookNotifier) deadLetter(n Notification, err error) error {
+	if w.DeadLetter != nil {
+		w.DeadLetter(n, err)
+	}
+	return err
+}
+
+// post makes one delivery attempt
+func (w *HTTPWebhookNotifier) post(ctx context.Context, runID string, body []byte) error {
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range w.Headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookRunHeader, runID)
+	if len(w.Secret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(w.Secret, body))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &WebhookError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(excerpt))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body) // Drain so the connection is reused
+	return nil
+}
+
+// SignWebhookPayload returns the WebhookSignatureHeader value of body under secret
+func SignWebhookPayload(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature, a WebhookSignatureHeader
+// value, signs body under secret, comparing in constant time
+func VerifyWebhookSignature(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(SignWebhookPayload(secret, body)))
+}
@@ -0,0 +1,180 @@
+package duplicatecheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver records the requests of a test webhook endpoint, answering
+// them with the statuses of respond in turn (200 once it runs out)
+type webhookReceiver struct {
+	mu       sync.Mutex
+	bodies   [][]byte
+	headers  []http.Header
+	respond  []int
+	attempts int
+	release  chan struct{} // Requests wait for it to close when set
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.release != nil {
+		<-r.release
+	}
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := http.StatusOK
+	if r.attempts < len(r.respond) {
+		status = r.respond[r.attempts]
+	}
+	r.attempts++
+	if status == http.StatusOK {
+		r.bodies = append(r.bodies, body)
+		r.headers = append(r.headers, req.Header.Clone())
+	}
+	w.WriteHeader(status)
+}
+
+// notifications decodes the delivered notifications
+func (r *webhookReceiver) notifications(t *testing.T) []Notification {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notifications := make([]Notification, len(r.bodies))
+	for i, body := range r.bodies {
+		if err := json.Unmarshal(body, &notifications[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return notifications
+}
+
+// testNotification returns a one-match notification of two sample products
+func testNotification(t *testing.T) Notification {
+	t.Helper()
+	catalog := loadSampleCatalog(t)
+	result := NewLevenshteinEngine().Compare(sampleProduct(t, catalog, "P001"), sampleProduct(t, catalog, "P002"))
+	return Notification{Source: NotifySourceScan, RunID: "run-1", ConfigFingerprint: "cfg",
+		Matches: []NotifiedMatch{(&NotificationQueue{}).match(&result)}}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	secret := []byte("shared secret")
+	webhook := NewHTTPWebhookNotifier(server.URL, secret)
+	webhook.Headers = http.Header{"Authorization": {"Bearer token"}}
+	n := testNotification(t)
+	if err := webhook.Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+
+	body, header := receiver.bodies[0], receiver.headers[0]
+	if !VerifyWebhookSignature(secret, body, header.Get(WebhookSignatureHeader)) {
+		t.Errorf("signature %q doesn't verify", header.Get(WebhookSignatureHeader))
+	}
+	if VerifyWebhookSignature([]byte("other secret"), body, header.Get(WebhookSignatureHeader)) {
+		t.Error("signature verifies under another secret")
+	}
+	tampered := append([]byte(nil), body...)
+	tampered[len(tampered)-2] = ' '
+	if VerifyWebhookSignature(secret, tampered, header.Get(WebhookSignatureHeader)) {
+		t.Error("signature verifies a tampered body")
+	}
+	if header.Get("Authorization") != "Bearer token" || header.Get(WebhookRunHeader) != "run-1" || header.Get("Content-Type") != "application/json" {
+		t.Errorf("headers %v", header)
+	}
+
+	got := receiver.notifications(t)[0]
+	match := got.Matches[0]
+	if got.RunID != "run-1" || got.ConfigFingerprint != "cfg" || match.ProductA.ID != "P001" || match.ProductB.ID != "P002" ||
+		match.CombinedSimilarity != n.Matches[0].CombinedSimilarity || match.MatchType != n.Matches[0].MatchType {
+		t.Errorf("payload %+v", got)
+	}
+
+	// Unsigned without a secret
+	if err := NewHTTPWebhookNotifier(server.URL, nil).Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	if signature := receiver.headers[1].Get(WebhookSignatureHeader); signature != "" {
+		t.Errorf("unsigned request has signature %q", signature)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	receiver := &webhookReceiver{respond: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	deadLetters := 0
+	webhook := &HTTPWebhookNotifier{URL: server.URL, Backoff: time.Millisecond,
+		DeadLetter: func(Notification, error) { deadLetters++ }}
+	if err := webhook.Notify(context.Background(), testNotification(t)); err != nil {
+		t.Fatalf("delivery failed after retries: %v", err)
+	}
+	if receiver.attempts != 3 || len(receiver.bodies) != 1 || deadLetters != 0 {
+		t.Errorf("%d attempts, %d delivered, %d dead letters", receiver.attempts, len(receiver.bodies), deadLetters)
+	}
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		retries  int
+		attempts int
+	}{
+		{"Retries exhausted", http.StatusInternalServerError, 2, 3},
+		{"No retries", http.StatusBadGateway, -1, 1},
+		{"Not retryable", http.StatusBadRequest, 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &webhookReceiver{respond: []int{tt.status, tt.status, tt.status, tt.status}}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			var dead []Notification
+			var deadErr error
+			webhook := &HTTPWebhookNotifier{URL: server.URL, MaxRetries: tt.retries, Backoff: time.Millisecond,
+				DeadLetter: func(n Notification, err error) { dead, deadErr = append(dead, n), err }}
+			n := testNotification(t)
+			err := webhook.Notify(context.Background(), n)
+
+			var status *WebhookError
+			if !errors.As(err, &status) || status.StatusCode != tt.status {
+				t.Fatalf("Notify() = %v", err)
+			}
+			if receiver.attempts != tt.attempts {
+				t.Errorf("%d attempts, want %d", receiver.attempts, tt.attempts)
+			}
+			if len(dead) != 1 || dead[0].RunID != n.RunID || deadErr != err {
+				t.Errorf("dead letters %v, error %v", dead, deadErr)
+			}
+		})
+	}
+
+	t.Run("Cancelled", func(t *testing.T) {
+		receiver := &webhookReceiver{respond: []int{http.StatusServiceUnavailable}}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		dead := 0
+		webhook := &HTTPWebhookNotifier{URL: server.URL, Backoff: time.Hour,
+			DeadLetter: func(Notification, error) { dead++ }}
+		time.AfterFunc(10*time.Millisecond, cancel)
+		if err := webhook.Notify(ctx, testNotification(t)); !errors.Is(err, context.Canceled) || dead != 1 {
+			t.Errorf("Notify() = %v, %d dead letters", err, dead)
+		}
+	})
+}
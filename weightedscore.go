@@ -0,0 +1,203 @@
+package duplicatecheck
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MissingSignalPolicy decides how WeightedScore.Combine treats signals added
+// as not present
+type MissingSignalPolicy int
+
+const (
+	// MissingRenormalize leaves missing signals out and shares their weight
+	// among the present ones (default, the policy of every engine comparison)
+	MissingRenormalize MissingSignalPolicy = iota
+	// MissingAsZero scores missing signals as similarity 0 at their full weight
+	MissingAsZero
+	// MissingVetoes makes the combined score 0 when any signal is missing
+	MissingVetoes
+)
+
+// String returns a human-readable name for the policy
+func (p MissingSignalPolicy) String() string {
+	switch p {
+	case MissingRenormalize:
+		return "renormalize"
+	case MissingAsZero:
+		return "as-zero"
+	case MissingVetoes:
+		return "veto"
+	default:
+		return fmt.Sprintf("MissingSignalPolicy(%d)", int(p))
+	}
+}
+
+// weightedSignal is one similarity added to a WeightedScore
+type weightedSignal struct {
+	name       string
+	similarity float64
+	weight     float64
+	present    bool
+}
+
+// inlineSignals is the number of signals a WeightedScore holds without allocating
+const inlineSignals = 4
+
+// WeightedScore combines partial similarity signals with weights, the way
+// engines combine name and description similarity
+// The zero value is ready to use with MissingRenormalize. Signals are
+// combined as follows:
+//
+//   - Negative, NaN and infinite weights count as 0 (see ComparisonWeights.Normalized)
+//   - Missing signals are handled by Policy; MissingAsZero counts them as
+//     present with similarity 0
+//   - No present signal combines to 0
+//   - When the present signals' weights total 0 (or overflow), each counts
+//     with weight 1
+//   - Otherwise the score is the weighted mean Σ wᵢ·sᵢ / Σ wᵢ of the present
+//     signals, clamped to [0.0-1.0] with NaN counting as 0
+//
+// The mean is folded in the order signals were added, as an interpolation
+// towards each one by its share of the weight so far: score starts at the
+// first signal's similarity, then score += (sᵢ - score) · (wᵢ / Σ_{j≤i} wⱼ),
+// the product rounded before the sum so no platform fuses it into a
+// multiply-add. Identical signals therefore combine to exactly their
+// similarity, and two signals s₁, s₂ with normalized weights 1-w, w combine
+// to s₁ + (s₂ - s₁)·w. Engines add the description, then the name.
+type WeightedScore struct {
+	// Policy decides how signals added as not present count
+	Policy MissingSignalPolicy
+
+	inline [inlineSignals]weightedSignal
+	extra  []weightedSignal
+	n      int
+}
+
+// Add records one signal: its similarity in [0.0-1.0], its relative weight,
+// and whether it is present (false for a field one side lacks)
+// Signals may share a name; Combine reports their weights summed.
+func (s *WeightedScore) Add(name string, similarity float64, weight float64, present bool) {
+	signal := weightedSignal{name: name, similarity: similarity, weight: weight, present: present}
+	if s.n < inlineSignals {
+		s.inline[s.n] = signal
+	} else {
+		s.extra = append(s.extra, signal)
+	}
+	s.n++
+}
+
+// Len returns the number of signals added
+func (s *WeightedScore) Len() int {
+	return s.n
+}
+
+// Reset forgets every signal, keeping the policy
+func (s *WeightedScore) Reset() {
+	s.n = 0
+	s.extra = s.extra[:0]
+}
+
+// signal returns the i-th signal added
+func (s *WeightedScore) signal(i int) *weightedSignal {
+	if i < inlineSignals {
+		return &s.inline[i]
+	}
+	return &s.extra[i-inlineSignals]
+}
+
+// Combine returns the combined similarity and the share of the weight each
+// signal carried, by name (0 for signals left out)
+func (s *WeightedScore) Combine() (float64, map[string]float64) {
+	score, total, equal := s.combine()
+	shares := make(map[string]float64, s.n)
+	for i := 0; i < s.n; i++ {
+		signal := s.signal(i)
+		shares[signal.name] += 0 // Signals left out are reported too
+		if total == 0 || !s.counts(signal) {
+			continue
+		}
+		weight := cleanWeight(signal.weight)
+		if equal {
+			weight = 1
+		}
+		shares[signal.name] += weight / total
+	}
+	return score, shares
+}
+
+// counts reports whether a signal takes part in the combined score
+func (s *WeightedScore) counts(signal *weightedSignal) bool {
+	return signal.present || s.Policy == MissingAsZero
+}
+
+// combine returns the combined similarity, the total weight of the signals
+// taking part (0 for none) and whether they count equally
+func (s *WeightedScore) combine() (score, total float64, equal bool) {
+	var count int
+	for i := 0; i < s.n; i++ {
+		signal := s.signal(i)
+		if !signal.present && s.Policy == MissingVetoes {
+			return 0, 0, false
+		}
+		if s.counts(signal) {
+			total += cleanWeight(signal.weight)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0, false
+	}
+	if equal = total == 0 || math.IsInf(total, 0); equal {
+		total = float64(count)
+	}
+
+	var folded float64
+	started := false
+	for i := 0; i < s.n; i++ {
+		signal := s.signal(i)
+		if !s.counts(signal) {
+			continue
+		}
+		similarity := signal.similarity
+		if !signal.present {
+			similarity = 0
+		}
+		weight := 1.0
+		if !equal {
+			weight = cleanWeight(signal.weight)
+		}
+		if !started {
+			score, folded, started = similarity, weight, true
+			continue
+		}
+		folded += weight
+		if folded > 0 {
+			score += float64((similarity - score) * (weight / folded))
+		}
+	}
+	return clampUnit(score), total, equal
+}
+
+// Validate reports signals Combine would have to repair, mirroring
+// ComparisonWeights.Validate
+// Returns an error for negative, NaN or infinite weights, similarities outside
+// [0.0-1.0], or when every weight is 0.
+func (s *WeightedScore) Validate() error {
+	var total float64
+	for i := 0; i < s.n; i++ {
+		signal := s.signal(i)
+		if math.IsNaN(signal.weight) || math.IsInf(signal.weight, 0) || signal.weight < 0 {
+			return fmt.Errorf("duplicatecheck: weight of %q must be a finite non-negative number, got %v", signal.name, signal.weight)
+		}
+		if math.IsNaN(signal.similarity) || signal.similarity < 0 || signal.similarity > 1 {
+			return fmt.Errorf("duplicatecheck: similarity of %q must be in [0, 1], got %v", signal.name, signal.similarity)
+		}
+		total += signal.weight
+	}
+	if s.n > 0 && total == 0 {
+		return errors.New("duplicatecheck: signal weights can't all be 0")
+	}
+	return nil
+}
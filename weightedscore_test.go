@@ -0,0 +1,163 @@
+package duplicatecheck
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestWeightedScoreCombine(t *testing.T) {
+	type signal struct {
+		name       string
+		similarity float64
+		weight     float64
+		present    bool
+	}
+	description := signal{"description", 0.25, 1, true}
+	name := signal{"name", 0.75, 3, true}
+	missing := func(s signal) signal {
+		s.present = false
+		return s
+	}
+	weightless := func(s signal) signal {
+		s.weight = 0
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		policy     MissingSignalPolicy
+		signals    []signal
+		want       float64
+		wantShares map[string]float64
+	}{
+		{"all present", MissingRenormalize, []signal{description, name}, 0.625,
+			map[string]float64{"description": 0.25, "name": 0.75}},
+		{"one missing", MissingRenormalize, []signal{description, missing(name)}, 0.25,
+			map[string]float64{"description": 1, "name": 0}},
+		{"all missing", MissingRenormalize, []signal{missing(description), missing(name)}, 0,
+			map[string]float64{"description": 0, "name": 0}},
+		{"zero total weight", MissingRenormalize, []signal{weightless(description), weightless(name)}, 0.5,
+			map[string]float64{"description": 0.5, "name": 0.5}},
+		{"present weight zero", MissingRenormalize, []signal{weightless(description), missing(name)}, 0.25,
+			map[string]float64{"description": 1, "name": 0}},
+		{"as zero: all present", MissingAsZero, []signal{description, name}, 0.625,
+			map[string]float64{"description": 0.25, "name": 0.75}},
+		{"as zero: one missing", MissingAsZero, []signal{description, missing(name)}, 0.0625,
+			map[string]float64{"description": 0.25, "name": 0.75}},
+		{"as zero: all missing", MissingAsZero, []signal{missing(description), missing(name)}, 0,
+			map[string]float64{"description": 0.25, "name": 0.75}},
+		{"veto: all present", MissingVetoes, []signal{description, name}, 0.625,
+			map[string]float64{"description": 0.25, "name": 0.75}},
+		{"veto: one missing", MissingVetoes, []signal{description, missing(name)}, 0,
+			map[string]float64{"description": 0, "name": 0}},
+		{"no signals", MissingRenormalize, nil, 0, map[string]float64{}},
+		{"three signals", MissingRenormalize, []signal{{"a", 0.5, 1, true}, {"b", 1, 1, true}, {"c", 0, 2, true}}, 0.375,
+			map[string]float64{"a": 0.25, "b": 0.25, "c": 0.5}},
+		{"shared name", MissingRenormalize, []signal{{"seg", 0.5, 1, true}, {"seg", 1, 1, true}, {"other", 0, 2, true}}, 0.375,
+			map[string]float64{"seg": 0.5, "other": 0.5}},
+		{"invalid weights count as 0", MissingRenormalize, []signal{{"a", 0.5, math.NaN(), true}, {"b", 1, -2, true}, {"c", 0, 1, true}}, 0,
+			map[string]float64{"a": 0, "b": 0, "c": 1}},
+		{"clamped", MissingRenormalize, []signal{{"a", 1.5, 1, true}, {"b", math.NaN(), 1, true}}, 0,
+			map[string]float64{"a": 0.5, "b": 0.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := WeightedScore{Policy: tt.policy}
+			for _, s := range tt.signals {
+				score.Add(s.name, s.similarity, s.weight, s.present)
+			}
+			got, shares := score.Combine()
+			if got != tt.want {
+				t.Errorf("Combine() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(shares, tt.wantShares) {
+				t.Errorf("shares = %v, want %v", shares, tt.wantShares)
+			}
+		})
+	}
+}
+
+func TestWeightedScoreManySignals(t *testing.T) {
+	var score WeightedScore
+	for i := 0; i < 3*inlineSignals; i++ {
+		score.Add(fmt.Sprintf("s%d", i), 0.3, float64(i+1), true)
+	}
+	if got, _ := score.Combine(); got != 0.3 {
+		t.Errorf("identical signals combined to %v, want exactly 0.3", got)
+	}
+	score.Reset()
+	if score.Len() != 0 {
+		t.Fatalf("Len() = %d after Reset", score.Len())
+	}
+	score.Add("only", 0.4, 1, true)
+	if got, _ := score.Combine(); got != 0.4 {
+		t.Errorf("after Reset combined to %v, want 0.4", got)
+	}
+}
+
+func TestWeightedScoreValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		similarity float64
+		weight     float64
+		wantErr    bool
+	}{
+		{"valid", 0.5, 1, false},
+		{"zero weight", 0.5, 0, true},
+		{"negative weight", 0.5, -1, true},
+		{"NaN weight", 0.5, math.NaN(), true},
+		{"infinite weight", 0.5, math.Inf(1), true},
+		{"similarity above 1", 1.5, 1, true},
+		{"NaN similarity", math.NaN(), 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var score WeightedScore
+			score.Add("signal", tt.similarity, tt.weight, true)
+			if err := score.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+	var empty WeightedScore
+	if err := empty.Validate(); err != nil {
+		t.Errorf("empty score: %v", err)
+	}
+}
+
+// TestWeightedScoreKeepsEngineScores pins every pair's CombinedSimilarity on
+// the first 60 products of the golden catalog, bit for bit, to the digests
+// recorded before the engines combined fields through WeightedScore
+func TestWeightedScoreKeepsEngineScores(t *testing.T) {
+	catalog := GenerateTestCatalog(goldenCatalogSeed, goldenCatalogSize)[:60]
+	tests := []struct {
+		weights ComparisonWeights
+		engine  func() DuplicateCheckEngine
+		want    uint64
+	}{
+		{DefaultWeights(), func() DuplicateCheckEngine { return NewLevenshteinEngine() }, 0xe7850e478e67176b},
+		{PresetBalanced(), func() DuplicateCheckEngine { return NewLevenshteinEngine() }, 0x8898c2bb05434c48},
+		{PresetContentProducts(), func() DuplicateCheckEngine { return NewLevenshteinEngine() }, 0x5a38c77f7234160b},
+		{ComparisonWeights{NameWeight: 1}, func() DuplicateCheckEngine { return NewLevenshteinEngine() }, 0xad89c789f0bf70b1},
+		{ComparisonWeights{DescriptionWeight: 1}, func() DuplicateCheckEngine { return NewLevenshteinEngine() }, 0x682248c54a202f81},
+		{DefaultWeights(), func() DuplicateCheckEngine { return NewTFIDFEngine() }, 0x8cf0c138729b0bd9},
+	}
+	for _, tt := range tests {
+		engine := tt.engine()
+		t.Run(fmt.Sprintf("%s %v", engine.GetName(), tt.weights), func(t *testing.T) {
+			digest := fnv.New64a()
+			for i := range catalog {
+				for j := i + 1; j < len(catalog); j++ {
+					result := engine.CompareWithWeights(catalog[i], catalog[j], tt.weights)
+					fmt.Fprintf(digest, "%x,", math.Float64bits(result.CombinedSimilarity))
+				}
+			}
+			if got := digest.Sum64(); got != tt.want {
+				t.Errorf("score digest %#x, want %#x", got, tt.want)
+			}
+		})
+	}
+}
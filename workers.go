@@ -0,0 +1,150 @@
+package duplicatecheck
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// cpuEnvironment is what worker sizing reads about the machine; tests
+// replace its functions to simulate hosts, containers and GOMAXPROCS
+type cpuEnvironment struct {
+	numCPU     func() int
+	gomaxprocs func() int
+	readFile   func(path string) ([]byte, error)
+}
+
+// hostCPUs reads the real machine
+var hostCPUs = cpuEnvironment{
+	numCPU:     runtime.NumCPU,
+	gomaxprocs: func() int { return runtime.GOMAXPROCS(0) },
+	readFile:   os.ReadFile,
+}
+
+// cgroupV2CPUMax holds the cgroup v2 CPU quota, as mounted inside a container
+const cgroupV2CPUMax = "/sys/fs/cgroup/cpu.max"
+
+// cgroupV1Dirs are the usual mounts of the cgroup v1 cpu controller
+var cgroupV1Dirs = []string{"/sys/fs/cgroup/cpu", "/sys/fs/cgroup/cpu,cpuacct"}
+
+// detectedQuota returns the host's cgroup CPU quota, read once
+var detectedQuota = sync.OnceValues(hostCPUs.cgroupQuota)
+
+// cgroupQuota returns the CPUs the cgroup CPU quota allows, rounded up, and
+// whether a quota is set
+// Missing or unreadable files mean no quota: detection never fails a scan.
+func (env cpuEnvironment) cgroupQuota() (int, bool) {
+	// cgroup v2: "max 100000" (unlimited) or "<quota> <period>"
+	if data, err := env.readFile(cgroupV2CPUMax); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 && fields[0] != "max" {
+			return quotaCPUs(fields[0], fields[1])
+		}
+		return 0, false
+	}
+
+	// cgroup v1: cpu.cfs_quota_us is -1 when unlimited
+	for _, dir := range cgroupV1Dirs {
+		quota, err := env.readFile(dir + "/cpu.cfs_quota_us")
+		if err != nil {
+			continue
+		}
+		period, err := env.readFile(dir + "/cpu.cfs_period_us")
+		if err != nil {
+			continue
+		}
+		return quotaCPUs(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+	}
+	return 0, false
+}
+
+// quotaCPUs converts a CFS quota and period, in microseconds, to CPUs
+// rounded up; a non-positive or malformed quota means none
+func quotaCPUs(quota, period string) (int, bool) {
+	q, err := strconv.ParseInt(quota, 10, 64)
+	if err != nil || q <= 0 {
+		return 0, false
+	}
+	p, err := strconv.ParseInt(period, 10, 64)
+	if err != nil || p <= 0 {
+		return 0, false
+	}
+	return int((q + p - 1) / p), true
+}
+
+// availableCPUs returns the CPUs scans can use: the smallest of NumCPU,
+// GOMAXPROCS and the cgroup quota
+func (env cpuEnvironment) availableCPUs(quota int, limited bool) int {
+	cpus := env.numCPU()
+	if procs := env.gomaxprocs(); procs < cpus {
+		cpus = procs
+	}
+	if limited && quota < cpus {
+		cpus = quota
+	}
+	if cpus < 1 {
+		cpus = 1
+	}
+	return cpus
+}
+
+// EffectiveWorkerCount returns the number of goroutines parallel scans of
+// numProducts products run on, so operators can log it before a big scan
+// It is sized from the CPUs the process may use: the smallest of NumCPU,
+// GOMAXPROCS and the cgroup (v1 or v2) CPU quota of a container, detected once.
+// Engines given a limit with SetMaxWorkers size from that limit instead.
+func EffectiveWorkerCount(numProducts int) int {
+	return getOptimalWorkerCount(numProducts)
+}
+
+// SetMaxWorkers bounds the goroutines of the engine's parallel scans,
+// replacing CPU detection (see EffectiveWorkerCount); 0 restores detection
+func (e *LevenshteinEngine) SetMaxWorkers(workers int) {
+	if workers < 0 {
+		workers = 0
+	}
+	e.maxWorkers = workers
+}
+
+// GetMaxWorkers returns the worker limit set by SetMaxWorkers (0 = detected)
+func (e *LevenshteinEngine) GetMaxWorkers() int {
+	return e.maxWorkers
+}
+
+// SetMaxWorkers bounds the goroutines of the engine's parallel work,
+// verification and index building alike (see LevenshteinEngine.SetMaxWorkers)
+// An explicit HybridConfig.BuildWorkers is capped by it too.
+func (e *HybridEngine) SetMaxWorkers(workers int) {
+	e.levenshteinEngine.SetMaxWorkers(workers)
+}
+
+// GetMaxWorkers returns the worker limit set by SetMaxWorkers (0 = detected)
+func (e *HybridEngine) GetMaxWorkers() int {
+	return e.levenshteinEngine.GetMaxWorkers()
+}
+
+// workerCount returns the goroutines parallel work over n items runs on
+// (1 in deterministic mode)
+func (e *LevenshteinEngine) workerCount(n int) int {
+	if e.deterministic {
+		return 1
+	}
+	if e.maxWorkers > 0 {
+		if workers := workersFor(n, e.maxWorkers); workers < e.maxWorkers {
+			return workers
+		}
+		return e.maxWorkers
+	}
+	return getOptimalWorkerCount(n)
+}
+
+// scanWorkers returns the workers of a pair scan over n items: 1 unless
+// parallel is set
+func (e *LevenshteinEngine) scanWorkers(n int, parallel bool) int {
+	if !parallel {
+		return 1
+	}
+	return e.workerCount(n)
+}
@@ -0,0 +1,125 @@
+package duplicatecheck
+
+import (
+	"os"
+	"testing"
+)
+
+// fakeCPUs returns an environment with numCPU CPUs, GOMAXPROCS procs and the
+// given cgroup files
+func fakeCPUs(numCPU, procs int, files map[string]string) cpuEnvironment {
+	return cpuEnvironment{
+		numCPU:     func() int { return numCPU },
+		gomaxprocs: func() int { return procs },
+		readFile: func(path string) ([]byte, error) {
+			if data, ok := files[path]; ok {
+				return []byte(data), nil
+			}
+			return nil, os.ErrNotExist
+		},
+	}
+}
+
+func TestCgroupQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		cpus    int
+		limited bool
+	}{
+		{"no cgroup files", nil, 0, false},
+		{"v2 quota", map[string]string{cgroupV2CPUMax: "200000 100000\n"}, 2, true},
+		{"v2 fractional quota rounds up", map[string]string{cgroupV2CPUMax: "150000 100000\n"}, 2, true},
+		{"v2 unlimited", map[string]string{cgroupV2CPUMax: "max 100000\n"}, 0, false},
+		{"v2 malformed", map[string]string{cgroupV2CPUMax: "lots\n"}, 0, false},
+		{"v1 quota", map[string]string{
+			"/sys/fs/cgroup/cpu/cpu.cfs_quota_us":  "50000\n",
+			"/sys/fs/cgroup/cpu/cpu.cfs_period_us": "100000\n",
+		}, 1, true},
+		{"v1 combined mount", map[string]string{
+			"/sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  "400000\n",
+			"/sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+		}, 4, true},
+		{"v1 unlimited", map[string]string{
+			"/sys/fs/cgroup/cpu/cpu.cfs_quota_us":  "-1\n",
+			"/sys/fs/cgroup/cpu/cpu.cfs_period_us": "100000\n",
+		}, 0, false},
+		{"v1 without period", map[string]string{"/sys/fs/cgroup/cpu/cpu.cfs_quota_us": "50000\n"}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpus, limited := fakeCPUs(8, 8, tt.files).cgroupQuota()
+			if cpus != tt.cpus || limited != tt.limited {
+				t.Errorf("cgroupQuota() = %d, %v; want %d, %v", cpus, limited, tt.cpus, tt.limited)
+			}
+		})
+	}
+}
+
+func TestAvailableCPUs(t *testing.T) {
+	quota2 := map[string]string{cgroupV2CPUMax: "200000 100000"}
+	tests := []struct {
+		name    string
+		env     cpuEnvironment
+		cpus    int
+		workers int // For a large scan
+	}{
+		{"unlimited host", fakeCPUs(64, 64, nil), 64, 16},
+		{"quota-limited container", fakeCPUs(64, 64, quota2), 2, 4},
+		{"GOMAXPROCS restricted", fakeCPUs(64, 4, nil), 4, 8},
+		{"GOMAXPROCS below quota", fakeCPUs(64, 1, quota2), 1, 2},
+		{"small CI box", fakeCPUs(2, 2, nil), 2, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota, limited := tt.env.cgroupQuota()
+			cpus := tt.env.availableCPUs(quota, limited)
+			if cpus != tt.cpus {
+				t.Errorf("availableCPUs = %d, want %d", cpus, tt.cpus)
+			}
+			if got := workersFor(10000, cpus); got != tt.workers {
+				t.Errorf("workers for a large scan = %d, want %d", got, tt.workers)
+			}
+			if got := workersFor(100, cpus); got > 2 || got > cpus {
+				t.Errorf("workers for a small scan = %d, want at most 2 and %d", got, cpus)
+			}
+		})
+	}
+}
+
+func TestSetMaxWorkers(t *testing.T) {
+	engine := NewLevenshteinEngine()
+	if got, want := engine.workerCount(10000), EffectiveWorkerCount(10000); got != want {
+		t.Errorf("default workerCount = %d, EffectiveWorkerCount %d", got, want)
+	}
+
+	engine.SetMaxWorkers(3)
+	tests := []struct {
+		n, workers int
+	}{
+		{10000, 3}, // Not oversubscribed past the limit
+		{500, 3},
+		{100, 2},
+	}
+	for _, tt := range tests {
+		if got := engine.workerCount(tt.n); got != tt.workers {
+			t.Errorf("workerCount(%d) with 3 max workers = %d, want %d", tt.n, got, tt.workers)
+		}
+	}
+	if got := engine.scanWorkers(10000, false); got != 1 {
+		t.Errorf("sequential scanWorkers = %d, want 1", got)
+	}
+
+	engine.SetMaxWorkers(-1)
+	if engine.GetMaxWorkers() != 0 {
+		t.Errorf("negative limit kept: %d", engine.GetMaxWorkers())
+	}
+
+	config := DefaultHybridConfig()
+	config.BuildWorkers = 8
+	hybrid := NewHybridEngineWithConfig(config)
+	hybrid.SetMaxWorkers(2)
+	if got := hybrid.buildWorkerCount(10000); got != 2 {
+		t.Errorf("hybrid build workers = %d, want the limit 2", got)
+	}
+}